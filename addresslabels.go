@@ -0,0 +1,174 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/chaindb"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	"github.com/wealdtech/chaind/util"
+)
+
+// runAddressLabels runs an address labels command.
+func runAddressLabels(ctx context.Context, command string) error {
+	chainDB, err := startDatabase(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if db, isPostgreSQL := chainDB.(*postgresqlchaindb.Service); isPostgreSQL {
+		if err := checkSchemaVersion(ctx, db); err != nil {
+			return err
+		}
+	}
+
+	switch command {
+	case "", "list":
+		return listAddressLabels(ctx, chainDB)
+	case "import":
+		return importAddressLabels(ctx, chainDB)
+	case "remove":
+		return removeAddressLabels(ctx, chainDB)
+	default:
+		return fmt.Errorf("unknown address labels command %q; supported commands are list, import and remove", command)
+	}
+}
+
+// listAddressLabels prints address labels, optionally filtered by address and label.
+func listAddressLabels(ctx context.Context, chainDB chaindb.Service) error {
+	filter := &chaindb.AddressLabelFilter{
+		Labels: viper.GetStringSlice("address-labels.labels"),
+	}
+	if len(viper.GetStringSlice("address-labels.addresses")) > 0 {
+		addresses, err := addressLabelAddresses()
+		if err != nil {
+			return err
+		}
+		filter.Addresses = addresses
+	}
+
+	labels, err := chainDB.(chaindb.AddressLabelsProvider).AddressLabels(ctx, filter)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain address labels")
+	}
+	if len(labels) == 0 {
+		fmt.Println("No address labels")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ADDRESS\tLABEL\tCATEGORY")
+	for _, label := range labels {
+		fmt.Fprintf(writer, "%#x\t%s\t%s\n", label.Address, label.Label, label.Category)
+	}
+	writer.Flush()
+
+	return nil
+}
+
+// importAddressLabels imports address labels from the configured file.
+func importAddressLabels(ctx context.Context, chainDB chaindb.Service) error {
+	path := viper.GetString("address-labels.file")
+	if path == "" {
+		return errors.New("no address labels file specified")
+	}
+	format := viper.GetString("address-labels.format")
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	file, err := os.Open(resolvePath(path))
+	if err != nil {
+		return errors.Wrap(err, "failed to open address labels file")
+	}
+	defer file.Close()
+
+	labels, err := util.ParseAddressLabels(file, format)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse address labels file")
+	}
+
+	ctx, cancel, err := chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	for _, label := range labels {
+		if err := chainDB.(chaindb.AddressLabelsSetter).SetAddressLabel(ctx, label); err != nil {
+			cancel()
+			return errors.Wrapf(err, "failed to set label for address %#x", label.Address)
+		}
+	}
+	if err := chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	fmt.Printf("Imported %d address label(s)\n", len(labels))
+
+	return nil
+}
+
+// removeAddressLabels removes the labels for the configured addresses.
+func removeAddressLabels(ctx context.Context, chainDB chaindb.Service) error {
+	addresses, err := addressLabelAddresses()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel, err := chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	for _, address := range addresses {
+		if err := chainDB.(chaindb.AddressLabelsSetter).RemoveAddressLabel(ctx, address); err != nil {
+			cancel()
+			return errors.Wrapf(err, "failed to remove label for address %#x", address)
+		}
+	}
+	if err := chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	fmt.Printf("Removed %d address label(s)\n", len(addresses))
+
+	return nil
+}
+
+// addressLabelAddresses parses the configured addresses.
+func addressLabelAddresses() ([][20]byte, error) {
+	configured := viper.GetStringSlice("address-labels.addresses")
+	if len(configured) == 0 {
+		return nil, errors.New("no addresses specified")
+	}
+
+	addresses := make([][20]byte, 0, len(configured))
+	for _, address := range configured {
+		data, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+		if err != nil || len(data) != 20 {
+			return nil, fmt.Errorf("invalid address %q", address)
+		}
+		addresses = append(addresses, [20]byte(data))
+	}
+
+	return addresses, nil
+}
@@ -0,0 +1,90 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/util"
+)
+
+// logLevelRequest is the body of a request to change log levels.
+type logLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// initAdmin initialises the admin server.
+func initAdmin(ctx context.Context) {
+	adminAddress := viper.GetString("admin.listen-address")
+	if adminAddress == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /loglevels", getLogLevels)
+	mux.HandleFunc("PUT /loglevels", setLogLevel)
+	mux.HandleFunc("PUT /loglevels/{service}", setLogLevel)
+
+	server := &http.Server{
+		Addr:              adminAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Info().Str("admin_address", adminAddress).Msg("Starting admin server")
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Warn().Str("admin_address", adminAddress).Err(err).Msg("Failed to run admin server")
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Warn().Err(err).Msg("Failed to shut down admin server")
+		}
+	}()
+}
+
+// getLogLevels returns the log levels of all services.
+func getLogLevels(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(util.ServiceLogLevels()); err != nil {
+		log.Warn().Err(err).Msg("Failed to write log levels")
+	}
+}
+
+// setLogLevel sets the log level of a service, or of all services if none is supplied.
+func setLogLevel(w http.ResponseWriter, r *http.Request) {
+	req := &logLevelRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	service := r.PathValue("service")
+	if err := util.SetServiceLogLevel(service, req.Level); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Info().Str("target", service).Str("level", req.Level).Msg("Log level changed")
+
+	getLogLevels(w, r)
+}
@@ -0,0 +1,79 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	backfillvalidators "github.com/wealdtech/chaind/services/validators/backfill"
+	"github.com/wealdtech/chaind/util"
+)
+
+// runBackfillValidators backfills historical validator balances.
+func runBackfillValidators(ctx context.Context) error {
+	chainDB, err := startDatabase(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if db, isPostgreSQL := chainDB.(*postgresqlchaindb.Service); isPostgreSQL {
+		if err := checkSchemaVersion(ctx, db); err != nil {
+			return err
+		}
+	}
+
+	address := viper.GetString("backfill-validators.address")
+	if address == "" {
+		address = viper.GetString("eth2client.address")
+	}
+	eth2Client, err := fetchClient(ctx, address)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", address))
+	}
+
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(util.LogLevel("chaintime")),
+		standardchaintime.WithGenesisProvider(eth2Client.(eth2client.GenesisProvider)),
+		standardchaintime.WithSpecProvider(eth2Client.(eth2client.SpecProvider)),
+		standardchaintime.WithForkScheduleProvider(eth2Client.(eth2client.ForkScheduleProvider)),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to start chain time service")
+	}
+
+	loadShedder, err := startLoadShedder(ctx, chainTime, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to start load shedder")
+	}
+
+	backfiller, err := backfillvalidators.New(ctx,
+		backfillvalidators.WithLogLevel(util.LogLevel("backfill-validators")),
+		backfillvalidators.WithETH2Client(eth2Client),
+		backfillvalidators.WithChainDB(chainDB),
+		backfillvalidators.WithChainTime(chainTime),
+		backfillvalidators.WithLoadShedder(loadShedder),
+		backfillvalidators.WithStartEpoch(viper.GetInt64("backfill-validators.start-epoch")),
+		backfillvalidators.WithEndEpoch(viper.GetInt64("backfill-validators.end-epoch")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create validators backfiller")
+	}
+
+	return backfiller.Backfill(ctx)
+}
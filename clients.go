@@ -21,12 +21,16 @@ import (
 	"github.com/attestantio/go-eth2-client/http"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/beaconproxy"
+	standardbeaconproxy "github.com/wealdtech/chaind/services/beaconproxy/standard"
+	"github.com/wealdtech/chaind/services/metrics"
 	"github.com/wealdtech/chaind/util"
 )
 
 var (
-	clients   map[string]eth2client.Service
-	clientsMu sync.Mutex
+	clients     map[string]eth2client.Service
+	beaconProxy beaconproxy.Service
+	clientsMu   sync.Mutex
 )
 
 // fetchClient fetches a client service, instantiating it if required.
@@ -40,11 +44,32 @@ func fetchClient(ctx context.Context, address string) (eth2client.Service, error
 	var client eth2client.Service
 	var exists bool
 	if client, exists = clients[address]; !exists {
-		var err error
+		auths, err := beaconNodeAuths()
+		if err != nil {
+			return nil, err
+		}
+		// The headers are obtained once, as the client sends fixed headers.
+		clientAddress, headers, err := util.BeaconNodeHeaders(ctx, address, auths, fetchSecret)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain beacon node authentication")
+		}
+		if viper.GetBool("eth2client.proxy.enable") {
+			if err := startBeaconProxy(ctx, nil); err != nil {
+				return nil, err
+			}
+			// The proxy adds the headers, so that they are also sent with event streams.
+			clientAddress, err = beaconProxy.Proxy(ctx, clientAddress, headers)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to proxy beacon node")
+			}
+			headers = make(map[string]string)
+		}
 		client, err = http.New(ctx,
 			http.WithLogLevel(util.LogLevel("eth2client")),
 			http.WithTimeout(viper.GetDuration("eth2client.timeout")),
-			http.WithAddress(address))
+			http.WithAddress(clientAddress),
+			http.WithExtraHeaders(headers),
+		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to initiate client")
 		}
@@ -58,6 +83,63 @@ func fetchClient(ctx context.Context, address string) (eth2client.Service, error
 	return client, nil
 }
 
+// initBeaconProxy starts the proxy for beacon node requests if it is enabled.
+func initBeaconProxy(ctx context.Context, monitor metrics.Service) error {
+	if !viper.GetBool("eth2client.proxy.enable") {
+		return nil
+	}
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	return startBeaconProxy(ctx, monitor)
+}
+
+// startBeaconProxy starts the proxy for beacon node requests, if not already
+// started.  It must be called with the clients lock held.
+func startBeaconProxy(ctx context.Context, monitor metrics.Service) error {
+	if beaconProxy != nil {
+		return nil
+	}
+
+	var cacheDir string
+	if viper.GetString("eth2client.proxy.cache.dir") != "" {
+		cacheDir = resolvePath(viper.GetString("eth2client.proxy.cache.dir"))
+	}
+	var err error
+	beaconProxy, err = standardbeaconproxy.New(ctx,
+		standardbeaconproxy.WithLogLevel(util.LogLevel("eth2client.proxy")),
+		standardbeaconproxy.WithMonitor(monitor),
+		standardbeaconproxy.WithListenAddress(viper.GetString("eth2client.proxy.listen-address")),
+		standardbeaconproxy.WithTimeout(viper.GetDuration("eth2client.timeout")),
+		standardbeaconproxy.WithCacheDir(cacheDir),
+		standardbeaconproxy.WithCacheMaxSize(viper.GetUint64("eth2client.proxy.cache.max-size-mb")*1024*1024),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to start beacon node proxy")
+	}
+
+	return nil
+}
+
+// beaconNodeAuths provides the configured authentication for beacon node endpoints.
+// eth2client.auth-token applies to eth2client.address, unless it is configured
+// in eth2client.endpoints.
+func beaconNodeAuths() ([]*util.BeaconNodeAuth, error) {
+	auths := make([]*util.BeaconNodeAuth, 0)
+	if err := viper.UnmarshalKey("eth2client.endpoints", &auths); err != nil {
+		return nil, errors.Wrap(err, "failed to parse beacon node endpoints")
+	}
+	if viper.GetString("eth2client.auth-token") != "" {
+		auths = append(auths, &util.BeaconNodeAuth{
+			Address:   viper.GetString("eth2client.address"),
+			AuthToken: viper.GetString("eth2client.auth-token"),
+		})
+	}
+
+	return auths, nil
+}
+
 func confirmClientInterfaces(client eth2client.Service) error {
 	if _, isProvider := client.(eth2client.GenesisProvider); !isProvider {
 		return errors.New("client is not a GenesisProvider")
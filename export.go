@@ -0,0 +1,307 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/chaindb"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	"github.com/wealdtech/chaind/services/chaintime"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/util"
+)
+
+// interchangeFormatVersion is the version of the EIP-3076 interchange format generated.
+const interchangeFormatVersion = "5"
+
+// slashingProtectionInterchange is an EIP-3076 slashing protection interchange document.
+type slashingProtectionInterchange struct {
+	Metadata *slashingProtectionMetadata    `json:"metadata"`
+	Data     []*slashingProtectionValidator `json:"data"`
+}
+
+type slashingProtectionMetadata struct {
+	InterchangeFormatVersion string `json:"interchange_format_version"`
+	GenesisValidatorsRoot    string `json:"genesis_validators_root"`
+}
+
+type slashingProtectionValidator struct {
+	PubKey             string                           `json:"pubkey"`
+	SignedBlocks       []*slashingProtectionBlock       `json:"signed_blocks"`
+	SignedAttestations []*slashingProtectionAttestation `json:"signed_attestations"`
+}
+
+type slashingProtectionBlock struct {
+	Slot string `json:"slot"`
+}
+
+type slashingProtectionAttestation struct {
+	SourceEpoch string `json:"source_epoch"`
+	TargetEpoch string `json:"target_epoch"`
+}
+
+// signedHistory is the signing history of a single validator.
+type signedHistory struct {
+	slots       map[phase0.Slot]struct{}
+	checkpoints map[[2]phase0.Epoch]struct{}
+}
+
+// runExport runs an export command.
+func runExport(ctx context.Context, command string) error {
+	chainDB, err := startDatabase(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if db, isPostgreSQL := chainDB.(*postgresqlchaindb.Service); isPostgreSQL {
+		if err := checkSchemaVersion(ctx, db); err != nil {
+			return err
+		}
+	}
+
+	switch command {
+	case "slashing-protection":
+		chainTime, err := standardchaintime.New(ctx,
+			standardchaintime.WithLogLevel(util.LogLevel("chaintime")),
+			standardchaintime.WithGenesisProvider(chainDB.(eth2client.GenesisProvider)),
+			standardchaintime.WithSpecProvider(chainDB.(eth2client.SpecProvider)),
+			standardchaintime.WithForkScheduleProvider(chainDB.(eth2client.ForkScheduleProvider)),
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to start chain time service")
+		}
+
+		return exportSlashingProtection(ctx, chainDB, chainTime)
+	default:
+		return fmt.Errorf("unknown export command %q; supported commands are slashing-protection", command)
+	}
+}
+
+// exportSlashingProtection writes an EIP-3076 slashing protection interchange document
+// for the configured validators, derived from their indexed proposals and attestations.
+// Signatures that were not included in the chain are unknown, so a watermark a margin
+// beyond the current epoch is added to cover them.
+func exportSlashingProtection(ctx context.Context, chainDB chaindb.Service, chainTime chaintime.Service) error {
+	indices, err := resolveValidatorIndices(ctx, chainDB, viper.GetStringSlice("export.validators"))
+	if err != nil {
+		return err
+	}
+	validators, err := chainDB.(chaindb.ValidatorsProvider).ValidatorsByIndex(ctx, indices)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain validators")
+	}
+	for _, index := range indices {
+		if _, exists := validators[index]; !exists {
+			return fmt.Errorf("unknown validator %d", index)
+		}
+	}
+
+	genesisResponse, err := chainDB.(eth2client.GenesisProvider).Genesis(ctx, &api.GenesisOpts{})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain genesis")
+	}
+
+	histories, err := signedHistories(ctx, chainDB, indices)
+	if err != nil {
+		return err
+	}
+	watermarkEpoch := chainTime.CurrentEpoch() + phase0.Epoch(viper.GetUint64("export.margin"))
+	watermarkSlot := chainTime.FirstSlotOfEpoch(watermarkEpoch)
+	for _, history := range histories {
+		addWatermark(history, watermarkSlot, watermarkEpoch)
+	}
+
+	interchange := &slashingProtectionInterchange{
+		Metadata: &slashingProtectionMetadata{
+			InterchangeFormatVersion: interchangeFormatVersion,
+			GenesisValidatorsRoot:    fmt.Sprintf("%#x", genesisResponse.Data.GenesisValidatorsRoot),
+		},
+		Data: make([]*slashingProtectionValidator, 0, len(indices)),
+	}
+	for _, index := range indices {
+		interchange.Data = append(interchange.Data, interchangeValidator(validators[index].PublicKey,
+			histories[index],
+			viper.GetBool("export.minimal"),
+		))
+	}
+
+	data, err := json.MarshalIndent(interchange, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal interchange")
+	}
+	data = append(data, '\n')
+
+	if output := viper.GetString("export.output"); output != "" {
+		if err := os.WriteFile(output, data, 0o600); err != nil {
+			return errors.Wrap(err, "failed to write interchange")
+		}
+
+		return nil
+	}
+	_, err = os.Stdout.Write(data)
+
+	return err
+}
+
+// signedHistories obtains the slots of blocks and the checkpoints of attestations
+// signed by the given validators, canonical or otherwise.
+func signedHistories(ctx context.Context,
+	chainDB chaindb.Service,
+	indices []phase0.ValidatorIndex,
+) (
+	map[phase0.ValidatorIndex]*signedHistory,
+	error,
+) {
+	histories := make(map[phase0.ValidatorIndex]*signedHistory, len(indices))
+	for _, index := range indices {
+		histories[index] = &signedHistory{
+			slots:       make(map[phase0.Slot]struct{}),
+			checkpoints: make(map[[2]phase0.Epoch]struct{}),
+		}
+	}
+
+	blocksStreamProvider, isProvider := chainDB.(chaindb.BlocksStreamProvider)
+	if !isProvider {
+		return nil, errors.New("chain database does not support streaming blocks")
+	}
+	blocks, err := blocksStreamProvider.BlocksStream(ctx, &chaindb.BlockFilter{
+		ProposerIndices: indices,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain blocks")
+	}
+	defer blocks.Close()
+	for blocks.Next(ctx) {
+		block := blocks.Value()
+		histories[block.ProposerIndex].slots[block.Slot] = struct{}{}
+	}
+	if err := blocks.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain blocks")
+	}
+
+	attestationsStreamProvider, isProvider := chainDB.(chaindb.AttestationsStreamProvider)
+	if !isProvider {
+		return nil, errors.New("chain database does not support streaming attestations")
+	}
+	attestations, err := attestationsStreamProvider.AttestationsStream(ctx, &chaindb.AttestationFilter{
+		ValidatorIndices: indices,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attestations")
+	}
+	defer attestations.Close()
+	for attestations.Next(ctx) {
+		attestation := attestations.Value()
+		checkpoints := [2]phase0.Epoch{attestation.SourceEpoch, attestation.TargetEpoch}
+		for _, index := range attestation.AggregationIndices {
+			if history, exists := histories[index]; exists {
+				history.checkpoints[checkpoints] = struct{}{}
+			}
+		}
+	}
+	if err := attestations.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attestations")
+	}
+
+	return histories, nil
+}
+
+// addWatermark adds a block at the given slot and an attestation targeting the given epoch to
+// a signing history, so that validator clients refuse to sign anything up to that point.
+// The attestation's source is the highest source in the history, to avoid refusing
+// attestations with the sources that will follow.
+func addWatermark(history *signedHistory, slot phase0.Slot, epoch phase0.Epoch) {
+	history.slots[slot] = struct{}{}
+
+	source := phase0.Epoch(0)
+	for checkpoint := range history.checkpoints {
+		if checkpoint[0] > source {
+			source = checkpoint[0]
+		}
+	}
+	if source > epoch {
+		source = epoch
+	}
+	history.checkpoints[[2]phase0.Epoch{source, epoch}] = struct{}{}
+}
+
+// interchangeValidator creates the interchange data for a validator from its signing history.
+// If minimal is true then only the highest block slot and a single attestation with the highest
+// source and target epochs are included.
+func interchangeValidator(pubKey phase0.BLSPubKey,
+	history *signedHistory,
+	minimal bool,
+) *slashingProtectionValidator {
+	slots := make([]phase0.Slot, 0, len(history.slots))
+	for slot := range history.slots {
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i int, j int) bool { return slots[i] < slots[j] })
+
+	checkpoints := make([][2]phase0.Epoch, 0, len(history.checkpoints))
+	for checkpoint := range history.checkpoints {
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	sort.Slice(checkpoints, func(i int, j int) bool {
+		if checkpoints[i][1] != checkpoints[j][1] {
+			return checkpoints[i][1] < checkpoints[j][1]
+		}
+
+		return checkpoints[i][0] < checkpoints[j][0]
+	})
+
+	if minimal {
+		if len(slots) > 0 {
+			slots = slots[len(slots)-1:]
+		}
+		if len(checkpoints) > 0 {
+			highest := checkpoints[len(checkpoints)-1]
+			for _, checkpoint := range checkpoints {
+				if checkpoint[0] > highest[0] {
+					highest[0] = checkpoint[0]
+				}
+			}
+			checkpoints = [][2]phase0.Epoch{highest}
+		}
+	}
+
+	res := &slashingProtectionValidator{
+		PubKey:             fmt.Sprintf("%#x", pubKey),
+		SignedBlocks:       make([]*slashingProtectionBlock, 0, len(slots)),
+		SignedAttestations: make([]*slashingProtectionAttestation, 0, len(checkpoints)),
+	}
+	for _, slot := range slots {
+		res.SignedBlocks = append(res.SignedBlocks, &slashingProtectionBlock{
+			Slot: strconv.FormatUint(uint64(slot), 10),
+		})
+	}
+	for _, checkpoint := range checkpoints {
+		res.SignedAttestations = append(res.SignedAttestations, &slashingProtectionAttestation{
+			SourceEpoch: strconv.FormatUint(uint64(checkpoint[0]), 10),
+			TargetEpoch: strconv.FormatUint(uint64(checkpoint[1]), 10),
+		})
+	}
+
+	return res
+}
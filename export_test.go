@@ -0,0 +1,144 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+// testSignedHistory creates a signing history from the given slots and source and target epochs.
+func testSignedHistory(slots []phase0.Slot, checkpoints [][2]phase0.Epoch) *signedHistory {
+	history := &signedHistory{
+		slots:       make(map[phase0.Slot]struct{}),
+		checkpoints: make(map[[2]phase0.Epoch]struct{}),
+	}
+	for _, slot := range slots {
+		history.slots[slot] = struct{}{}
+	}
+	for _, checkpoint := range checkpoints {
+		history.checkpoints[checkpoint] = struct{}{}
+	}
+
+	return history
+}
+
+func TestInterchangeValidator(t *testing.T) {
+	pubKey := phase0.BLSPubKey{0x01, 0xab}
+	pubKeyStr := "0x01ab" + strings.Repeat("00", 46)
+
+	tests := []struct {
+		name     string
+		history  *signedHistory
+		minimal  bool
+		expected string
+	}{
+		{
+			name:     "Empty",
+			history:  testSignedHistory(nil, nil),
+			expected: `{"pubkey":"` + pubKeyStr + `","signed_blocks":[],"signed_attestations":[]}`,
+		},
+		{
+			name:     "EmptyMinimal",
+			history:  testSignedHistory(nil, nil),
+			minimal:  true,
+			expected: `{"pubkey":"` + pubKeyStr + `","signed_blocks":[],"signed_attestations":[]}`,
+		},
+		{
+			name:    "Full",
+			history: testSignedHistory([]phase0.Slot{300, 10, 200}, [][2]phase0.Epoch{{5, 6}, {1, 2}, {4, 6}}),
+			expected: `{"pubkey":"` + pubKeyStr + `",` +
+				`"signed_blocks":[{"slot":"10"},{"slot":"200"},{"slot":"300"}],` +
+				`"signed_attestations":[{"source_epoch":"1","target_epoch":"2"},{"source_epoch":"4","target_epoch":"6"},{"source_epoch":"5","target_epoch":"6"}]}`,
+		},
+		{
+			name:    "Minimal",
+			history: testSignedHistory([]phase0.Slot{300, 10, 200}, [][2]phase0.Epoch{{1, 2}, {4, 6}}),
+			minimal: true,
+			expected: `{"pubkey":"` + pubKeyStr + `",` +
+				`"signed_blocks":[{"slot":"300"}],` +
+				`"signed_attestations":[{"source_epoch":"4","target_epoch":"6"}]}`,
+		},
+		{
+			name: "MinimalCombined",
+			// The highest source is not in the attestation with the highest target.
+			history: testSignedHistory([]phase0.Slot{5}, [][2]phase0.Epoch{{8, 9}, {3, 12}, {1, 10}}),
+			minimal: true,
+			expected: `{"pubkey":"` + pubKeyStr + `",` +
+				`"signed_blocks":[{"slot":"5"}],` +
+				`"signed_attestations":[{"source_epoch":"8","target_epoch":"12"}]}`,
+		},
+		{
+			name:    "LargeValues",
+			history: testSignedHistory([]phase0.Slot{18446744073709551615}, [][2]phase0.Epoch{{18446744073709551614, 18446744073709551615}}),
+			expected: `{"pubkey":"` + pubKeyStr + `",` +
+				`"signed_blocks":[{"slot":"18446744073709551615"}],` +
+				`"signed_attestations":[{"source_epoch":"18446744073709551614","target_epoch":"18446744073709551615"}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.Marshal(interchangeValidator(pubKey, test.history, test.minimal))
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, string(data))
+		})
+	}
+}
+
+func TestAddWatermark(t *testing.T) {
+	tests := []struct {
+		name        string
+		history     *signedHistory
+		slot        phase0.Slot
+		epoch       phase0.Epoch
+		slots       []phase0.Slot
+		checkpoints [][2]phase0.Epoch
+	}{
+		{
+			name:        "Empty",
+			history:     testSignedHistory(nil, nil),
+			slot:        320,
+			epoch:       10,
+			slots:       []phase0.Slot{320},
+			checkpoints: [][2]phase0.Epoch{{0, 10}},
+		},
+		{
+			name:        "HighestSource",
+			history:     testSignedHistory([]phase0.Slot{100}, [][2]phase0.Epoch{{6, 7}, {2, 8}}),
+			slot:        320,
+			epoch:       10,
+			slots:       []phase0.Slot{100, 320},
+			checkpoints: [][2]phase0.Epoch{{2, 8}, {6, 7}, {6, 10}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addWatermark(test.history, test.slot, test.epoch)
+			require.Equal(t, testSignedHistory(test.slots, test.checkpoints), test.history)
+
+			// The watermark is the highest block and attestation in a minimal export.
+			validator := interchangeValidator(phase0.BLSPubKey{}, test.history, true)
+			require.Len(t, validator.SignedBlocks, 1)
+			require.Equal(t, "320", validator.SignedBlocks[0].Slot)
+			require.Len(t, validator.SignedAttestations, 1)
+			require.Equal(t, "10", validator.SignedAttestations[0].TargetEpoch)
+		})
+	}
+}
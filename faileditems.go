@@ -0,0 +1,161 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/chaindb"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	"github.com/wealdtech/chaind/util"
+)
+
+// runFailedItems runs a failed items command.
+func runFailedItems(ctx context.Context, command string) error {
+	chainDB, err := startDatabase(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if db, isPostgreSQL := chainDB.(*postgresqlchaindb.Service); isPostgreSQL {
+		if err := checkSchemaVersion(ctx, db); err != nil {
+			return err
+		}
+	}
+	if _, isProvider := chainDB.(chaindb.FailedItemsProvider); !isProvider {
+		return errors.New("chain database does not support failed items")
+	}
+
+	switch command {
+	case "", "list":
+		return listFailedItems(ctx, chainDB)
+	case "retry":
+		return setFailedItemsState(ctx, chainDB,
+			[]string{chaindb.FailedItemStateDead, chaindb.FailedItemStateAcknowledged},
+			chaindb.FailedItemStateRetry,
+		)
+	case "acknowledge":
+		return setFailedItemsState(ctx, chainDB,
+			[]string{chaindb.FailedItemStateFailing, chaindb.FailedItemStateDead},
+			chaindb.FailedItemStateAcknowledged,
+		)
+	default:
+		return fmt.Errorf("unknown failed items command %q; supported commands are list, retry and acknowledge", command)
+	}
+}
+
+// listFailedItems prints failed items, optionally filtered by module, kind, range and state.
+func listFailedItems(ctx context.Context, chainDB chaindb.Service) error {
+	filter, err := failedItemFilter(viper.GetStringSlice("failed-items.states"))
+	if err != nil {
+		return err
+	}
+
+	items, err := chainDB.(chaindb.FailedItemsProvider).FailedItems(ctx, filter)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain failed items")
+	}
+	if len(items) == 0 {
+		fmt.Println("No failed items")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "MODULE\tKIND\tITEM\tSTATE\tATTEMPTS\tLAST FAILURE\tERROR")
+	for _, item := range items {
+		fmt.Fprintf(writer, "%s\t%s\t%d\t%s\t%d\t%s\t%s\n",
+			item.Module,
+			item.Kind,
+			item.Item,
+			item.State,
+			item.Attempts,
+			item.LastFailure.Format("2006-01-02T15:04:05Z07:00"),
+			item.Error,
+		)
+	}
+	writer.Flush()
+
+	return nil
+}
+
+// setFailedItemsState sets the state of the selected failed items that are
+// currently in one of the given states.
+func setFailedItemsState(ctx context.Context, chainDB chaindb.Service, fromStates []string, state string) error {
+	if len(viper.GetStringSlice("failed-items.modules")) == 0 {
+		return errors.New("no modules specified")
+	}
+	filter, err := failedItemFilter(fromStates)
+	if err != nil {
+		return err
+	}
+
+	items, err := chainDB.(chaindb.FailedItemsProvider).FailedItems(ctx, filter)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain failed items")
+	}
+
+	ctx, cancel, err := chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	for _, item := range items {
+		if err := chainDB.(chaindb.FailedItemsSetter).SetFailedItemState(ctx, item.Module, item.Kind, item.Item, state); err != nil {
+			cancel()
+			return errors.Wrapf(err, "failed to set state of %s %s %d", item.Module, item.Kind, item.Item)
+		}
+	}
+	if err := chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	fmt.Printf("Set %d failed item(s) to %s\n", len(items), state)
+
+	return nil
+}
+
+// failedItemFilter creates a filter for failed items from the configuration.
+func failedItemFilter(states []string) (*chaindb.FailedItemFilter, error) {
+	filter := &chaindb.FailedItemFilter{
+		Modules: viper.GetStringSlice("failed-items.modules"),
+		Kind:    viper.GetString("failed-items.kind"),
+		States:  states,
+	}
+	switch filter.Kind {
+	case "", chaindb.FailedItemKindSlot, chaindb.FailedItemKindEpoch:
+	default:
+		return nil, fmt.Errorf("invalid kind %q; supported kinds are slot and epoch", filter.Kind)
+	}
+
+	if viper.GetString("failed-items.range") != "" {
+		if filter.Kind == "" {
+			return nil, errors.New("a kind is required to select a range of items")
+		}
+		// Items are slots or epochs, both of which are parsed as a slot range.
+		from, to, err := util.ParseSlotRange(viper.GetString("failed-items.range"))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid range")
+		}
+		fromItem := uint64(from)
+		toItem := uint64(to)
+		filter.From = &fromItem
+		filter.To = &toItem
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,235 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// genesisProgressService is the name of the wait for genesis for progress.
+const genesisProgressService = "genesis"
+
+// genesisState is the state of the wait for genesis, stored as a progress value.
+type genesisState int64
+
+const (
+	// genesisStateUnknown is the state when the wait for genesis has not been recorded.
+	genesisStateUnknown genesisState = iota
+	// genesisStateAwaitingGenesis is the state when the beacon node does not yet have genesis.
+	genesisStateAwaitingGenesis
+	// genesisStateAwaitingChainStart is the state when genesis is known but the chain has not started.
+	genesisStateAwaitingChainStart
+	// genesisStateStarted is the state when the chain has started.
+	genesisStateStarted
+)
+
+// genesisBootstrap is the state of the wait for genesis.
+type genesisBootstrap struct {
+	State       genesisState
+	GenesisTime *time.Time
+	Polls       int64
+	LastPoll    time.Time
+}
+
+// fetchGenesisClient fetches the client for the beacon node.  If genesis.await
+// is set it waits for the beacon node to have the chain's genesis, rather than
+// returning an error, recording its progress in the database.
+func fetchGenesisClient(ctx context.Context,
+	chainDB chaindb.Service,
+	address string,
+) (
+	eth2client.Service,
+	error,
+) {
+	if !viper.GetBool("genesis.await") {
+		return fetchClient(ctx, address)
+	}
+
+	pollInterval := viper.GetDuration("genesis.poll-interval")
+	if pollInterval <= 0 {
+		return nil, errors.New("genesis poll interval must be greater than 0")
+	}
+
+	return awaitGenesis(ctx, chainDB, pollInterval, func(ctx context.Context) (eth2client.Service, error) {
+		return fetchClient(ctx, address)
+	})
+}
+
+// awaitGenesis polls for a client until the beacon node has genesis, recording its
+// progress in the database.  Errors other than genesis being unavailable are returned.
+func awaitGenesis(ctx context.Context,
+	chainDB chaindb.Service,
+	pollInterval time.Duration,
+	fetch func(ctx context.Context) (eth2client.Service, error),
+) (
+	eth2client.Service,
+	error,
+) {
+	state, err := obtainGenesisBootstrap(ctx, chainDB)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		state = &genesisBootstrap{}
+	}
+	state.State = genesisStateAwaitingGenesis
+
+	for {
+		eth2Client, err := fetch(ctx)
+		state.Polls++
+		state.LastPoll = time.Now()
+		if err == nil {
+			genesisResponse, err := eth2Client.(eth2client.GenesisProvider).Genesis(ctx, &api.GenesisOpts{})
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to obtain genesis")
+			}
+			genesisTime := genesisResponse.Data.GenesisTime
+			state.GenesisTime = &genesisTime
+			state.State = genesisStateAwaitingChainStart
+			if !genesisTime.After(time.Now()) {
+				state.State = genesisStateStarted
+			}
+			if err := setGenesisBootstrap(ctx, chainDB, state); err != nil {
+				return nil, err
+			}
+
+			return eth2Client, nil
+		}
+		if !genesisUnavailable(err) {
+			return nil, err
+		}
+
+		if err := setGenesisBootstrap(ctx, chainDB, state); err != nil {
+			return nil, err
+		}
+		log.Info().Err(err).Dur("poll_interval", pollInterval).Msg("Genesis not available from beacon node; waiting")
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// genesisUnavailable returns true if the error is due to the beacon node not yet having genesis.
+func genesisUnavailable(err error) bool {
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusNotFound
+	}
+
+	return false
+}
+
+// markGenesisStarted records that the chain has started, if genesis.await is set.
+func markGenesisStarted(ctx context.Context, chainDB chaindb.Service) error {
+	if !viper.GetBool("genesis.await") {
+		return nil
+	}
+
+	state, err := obtainGenesisBootstrap(ctx, chainDB)
+	if err != nil {
+		return err
+	}
+	if state == nil || state.State == genesisStateStarted {
+		return nil
+	}
+	state.State = genesisStateStarted
+
+	return setGenesisBootstrap(ctx, chainDB, state)
+}
+
+// setGenesisBootstrap stores the state of the wait for genesis.
+func setGenesisBootstrap(ctx context.Context, chainDB chaindb.Service, state *genesisBootstrap) error {
+	ctx, cancel, err := chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := chainDB.SetProgress(ctx, genesisProgressService, "state", int64(state.State)); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set genesis state")
+	}
+	if err := chainDB.SetProgress(ctx, genesisProgressService, "polls", state.Polls); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set genesis polls")
+	}
+	if err := chainDB.SetProgress(ctx, genesisProgressService, "last_poll", state.LastPoll.Unix()); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set genesis last poll")
+	}
+	if state.GenesisTime != nil {
+		if err := chainDB.SetProgress(ctx, genesisProgressService, "genesis_time", state.GenesisTime.Unix()); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set genesis time")
+		}
+	}
+	if err := chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// obtainGenesisBootstrap obtains the state of the wait for genesis, or nil if
+// it has not been recorded.
+func obtainGenesisBootstrap(ctx context.Context, chainDB chaindb.Service) (*genesisBootstrap, error) {
+	progress, err := chainDB.Progress(ctx, genesisProgressService)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain genesis state")
+	}
+	if progress == nil {
+		return nil, nil
+	}
+	val, exists := progress.Values["state"]
+	if !exists {
+		return nil, nil
+	}
+
+	state := &genesisBootstrap{
+		State:    genesisState(val),
+		Polls:    progress.Values["polls"],
+		LastPoll: time.Unix(progress.Values["last_poll"], 0),
+	}
+	if val, exists := progress.Values["genesis_time"]; exists {
+		genesisTime := time.Unix(val, 0)
+		state.GenesisTime = &genesisTime
+	}
+
+	return state, nil
+}
+
+// printGenesisBootstrap prints the state of the wait for genesis in human-readable form.
+func printGenesisBootstrap(state *genesisBootstrap) {
+	switch state.State {
+	case genesisStateAwaitingGenesis:
+		fmt.Printf("Waiting for genesis (%d polls, last at %s)\n", state.Polls, state.LastPoll.Format(time.RFC3339))
+	case genesisStateAwaitingChainStart:
+		fmt.Printf("Waiting for chain start at %s", state.GenesisTime.Format(time.RFC3339))
+		if untilGenesis := time.Until(*state.GenesisTime); untilGenesis > 0 {
+			fmt.Printf(" (in %s)", untilGenesis.Truncate(time.Second))
+		}
+		fmt.Println()
+	}
+}
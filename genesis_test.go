@@ -0,0 +1,227 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/api"
+	mockclient "github.com/attestantio/go-eth2-client/mock"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+)
+
+// genesisDB is a chain database that holds progress.
+type genesisDB struct {
+	chaindb.Service
+
+	progress map[string]int64
+}
+
+func newGenesisDB() *genesisDB {
+	return &genesisDB{
+		Service:  mockchaindb.New(),
+		progress: make(map[string]int64),
+	}
+}
+
+func (d *genesisDB) SetProgress(_ context.Context, _ string, key string, value int64) error {
+	d.progress[key] = value
+
+	return nil
+}
+
+func (d *genesisDB) Progress(_ context.Context, service string) (*chaindb.Progress, error) {
+	if len(d.progress) == 0 {
+		return nil, nil
+	}
+	values := make(map[string]int64, len(d.progress))
+	for key, value := range d.progress {
+		values[key] = value
+	}
+
+	return &chaindb.Progress{Service: service, Values: values}, nil
+}
+
+// genesisFetcher returns a fetch function that fails with the given errors before returning the client.
+func genesisFetcher(client eth2client.Service, errs ...error) func(context.Context) (eth2client.Service, error) {
+	return func(_ context.Context) (eth2client.Service, error) {
+		if len(errs) > 0 {
+			err := errs[0]
+			errs = errs[1:]
+
+			return nil, err
+		}
+
+		return client, nil
+	}
+}
+
+func TestAwaitGenesis(t *testing.T) {
+	ctx := context.Background()
+
+	notFound := errors.Wrap(&api.Error{StatusCode: http.StatusNotFound}, "failed to confirm node connection")
+	genesisTime := time.Unix(1700000000, 0)
+	futureGenesisTime := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name        string
+		genesisTime time.Time
+		errs        []error
+		err         string
+		state       genesisState
+		polls       int64
+	}{
+		{
+			name:        "Started",
+			genesisTime: genesisTime,
+			state:       genesisStateStarted,
+			polls:       1,
+		},
+		{
+			name:        "AwaitingGenesis",
+			genesisTime: genesisTime,
+			errs:        []error{notFound, notFound},
+			state:       genesisStateStarted,
+			polls:       3,
+		},
+		{
+			name:        "AwaitingChainStart",
+			genesisTime: futureGenesisTime,
+			errs:        []error{notFound},
+			state:       genesisStateAwaitingChainStart,
+			polls:       2,
+		},
+		{
+			name:        "OtherError",
+			genesisTime: genesisTime,
+			errs:        []error{notFound, errors.New("connection refused")},
+			err:         "connection refused",
+			state:       genesisStateAwaitingGenesis,
+			polls:       1,
+		},
+		{
+			name:        "ServerError",
+			genesisTime: genesisTime,
+			errs:        []error{&api.Error{StatusCode: http.StatusInternalServerError}},
+			err:         "failed with status 500",
+			state:       genesisStateUnknown,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, err := mockclient.New(ctx,
+				mockclient.WithLogLevel(zerolog.Disabled),
+				mockclient.WithGenesisTime(test.genesisTime),
+			)
+			require.NoError(t, err)
+			chainDB := newGenesisDB()
+
+			res, err := awaitGenesis(ctx, chainDB, time.Millisecond, genesisFetcher(client, test.errs...))
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, client, res)
+			}
+
+			state, err := obtainGenesisBootstrap(ctx, chainDB)
+			require.NoError(t, err)
+			if test.state == genesisStateUnknown {
+				require.Nil(t, state)
+				return
+			}
+			require.Equal(t, test.state, state.State)
+			require.Equal(t, test.polls, state.Polls)
+			if test.state == genesisStateAwaitingGenesis {
+				require.Nil(t, state.GenesisTime)
+			} else {
+				require.Equal(t, test.genesisTime.Unix(), state.GenesisTime.Unix())
+			}
+		})
+	}
+}
+
+func TestAwaitGenesisResumes(t *testing.T) {
+	ctx := context.Background()
+
+	notFound := &api.Error{StatusCode: http.StatusNotFound}
+	chainDB := newGenesisDB()
+	require.NoError(t, setGenesisBootstrap(ctx, chainDB, &genesisBootstrap{
+		State:    genesisStateAwaitingGenesis,
+		Polls:    5,
+		LastPoll: time.Unix(1600000000, 0),
+	}))
+
+	client, err := mockclient.New(ctx,
+		mockclient.WithLogLevel(zerolog.Disabled),
+		mockclient.WithGenesisTime(time.Unix(1700000000, 0)),
+	)
+	require.NoError(t, err)
+	_, err = awaitGenesis(ctx, chainDB, time.Millisecond, genesisFetcher(client, notFound))
+	require.NoError(t, err)
+
+	// Polls continue from the recorded count.
+	state, err := obtainGenesisBootstrap(ctx, chainDB)
+	require.NoError(t, err)
+	require.Equal(t, genesisStateStarted, state.State)
+	require.Equal(t, int64(7), state.Polls)
+	require.True(t, state.LastPoll.After(time.Unix(1600000000, 0)))
+}
+
+func TestMarkGenesisStarted(t *testing.T) {
+	ctx := context.Background()
+	viper.Set("genesis.await", true)
+	t.Cleanup(func() { viper.Set("genesis.await", false) })
+
+	// Nothing recorded, so nothing to mark.
+	chainDB := newGenesisDB()
+	require.NoError(t, markGenesisStarted(ctx, chainDB))
+	state, err := obtainGenesisBootstrap(ctx, chainDB)
+	require.NoError(t, err)
+	require.Nil(t, state)
+
+	genesisTime := time.Unix(1700000000, 0)
+	require.NoError(t, setGenesisBootstrap(ctx, chainDB, &genesisBootstrap{
+		State:       genesisStateAwaitingChainStart,
+		GenesisTime: &genesisTime,
+		Polls:       2,
+		LastPoll:    time.Unix(1600000000, 0),
+	}))
+	require.NoError(t, markGenesisStarted(ctx, chainDB))
+	state, err = obtainGenesisBootstrap(ctx, chainDB)
+	require.NoError(t, err)
+	require.Equal(t, &genesisBootstrap{
+		State:       genesisStateStarted,
+		GenesisTime: &genesisTime,
+		Polls:       2,
+		LastPoll:    time.Unix(1600000000, 0),
+	}, state)
+}
+
+func TestGenesisUnavailable(t *testing.T) {
+	require.True(t, genesisUnavailable(&api.Error{StatusCode: http.StatusNotFound}))
+	require.True(t, genesisUnavailable(errors.Wrap(&api.Error{StatusCode: http.StatusNotFound}, "failed to fetch genesis")))
+	require.False(t, genesisUnavailable(&api.Error{StatusCode: http.StatusServiceUnavailable}))
+	require.False(t, genesisUnavailable(errors.New("connection refused")))
+}
@@ -14,7 +14,10 @@
 package main
 
 import (
+	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -33,12 +36,44 @@ func initLogging() error {
 	zerolog.SetGlobalLevel(zerolog.TraceLevel)
 
 	// Change the output file.
+	var output io.Writer = os.Stderr
 	if viper.GetString("log-file") != "" {
 		f, err := os.OpenFile(resolvePath(viper.GetString("log-file")), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
 		if err != nil {
 			return errors.Wrap(err, "failed to open log file")
 		}
-		zerologger.Logger = zerologger.Logger.Output(f)
+		output = f
+	}
+
+	// Change the output format.
+	switch strings.ToLower(viper.GetString("log-format")) {
+	case "", "json":
+		if output != os.Stderr {
+			zerologger.Logger = zerologger.Logger.Output(output)
+		}
+	case "console":
+		zerologger.Logger = zerologger.Logger.Output(zerolog.ConsoleWriter{
+			Out:        output,
+			NoColor:    output != os.Stderr,
+			TimeFormat: time.RFC3339,
+		})
+	default:
+		return errors.New("log format must be one of json or console")
+	}
+
+	// Sample repetitive messages.
+	if viper.GetUint32("log-sampling.burst") > 0 {
+		minLevel, err := util.ParseLogLevel(viper.GetString("log-sampling.level"))
+		if err != nil {
+			return errors.Wrap(err, "invalid log sampling level")
+		}
+		if viper.GetDuration("log-sampling.period") <= 0 {
+			return errors.New("log sampling period must be greater than 0")
+		}
+		zerologger.Logger = zerologger.Logger.Hook(util.NewRepeatSampler(viper.GetUint32("log-sampling.burst"),
+			viper.GetDuration("log-sampling.period"),
+			minLevel,
+		))
 	}
 
 	// Set the local logger from the global logger.
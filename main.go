@@ -37,25 +37,58 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"github.com/wealdtech/chaind/handlers"
+	"github.com/wealdtech/chaind/plugins"
+	standardarchiver "github.com/wealdtech/chaind/services/archiver/standard"
 	standardbeaconcommittees "github.com/wealdtech/chaind/services/beaconcommittees/standard"
 	"github.com/wealdtech/chaind/services/blocks"
 	standardblocks "github.com/wealdtech/chaind/services/blocks/standard"
+	"github.com/wealdtech/chaind/services/cache"
+	memorycache "github.com/wealdtech/chaind/services/cache/memory"
+	rediscache "github.com/wealdtech/chaind/services/cache/redis"
 	"github.com/wealdtech/chaind/services/chaindb"
 	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
 	"github.com/wealdtech/chaind/services/chaintime"
 	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	standardclientfingerprints "github.com/wealdtech/chaind/services/clientfingerprints/standard"
+	"github.com/wealdtech/chaind/services/coldstore"
+	filecoldstore "github.com/wealdtech/chaind/services/coldstore/file"
+	s3coldstore "github.com/wealdtech/chaind/services/coldstore/s3"
+	standardequivocations "github.com/wealdtech/chaind/services/equivocations/standard"
 	getlogseth1deposits "github.com/wealdtech/chaind/services/eth1deposits/getlogs"
+	standardexporter "github.com/wealdtech/chaind/services/exporter/standard"
 	standardfinalizer "github.com/wealdtech/chaind/services/finalizer/standard"
+	standardgossip "github.com/wealdtech/chaind/services/gossip/standard"
+	standardheads "github.com/wealdtech/chaind/services/heads/standard"
+	standardindexmanager "github.com/wealdtech/chaind/services/indexmanager/standard"
+	"github.com/wealdtech/chaind/services/leader"
+	standardleader "github.com/wealdtech/chaind/services/leader/standard"
+	"github.com/wealdtech/chaind/services/loadshedder"
+	standardloadshedder "github.com/wealdtech/chaind/services/loadshedder/standard"
+	standardmaintenance "github.com/wealdtech/chaind/services/maintenance/standard"
 	"github.com/wealdtech/chaind/services/metrics"
 	nullmetrics "github.com/wealdtech/chaind/services/metrics/null"
 	prometheusmetrics "github.com/wealdtech/chaind/services/metrics/prometheus"
+	standardoutbox "github.com/wealdtech/chaind/services/outbox/standard"
+	standardproofs "github.com/wealdtech/chaind/services/proofs/standard"
 	standardproposerduties "github.com/wealdtech/chaind/services/proposerduties/standard"
+	"github.com/wealdtech/chaind/services/publisher"
+	kafkapublisher "github.com/wealdtech/chaind/services/publisher/kafka"
+	natspublisher "github.com/wealdtech/chaind/services/publisher/nats"
+	webhookpublisher "github.com/wealdtech/chaind/services/publisher/webhook"
+	standardqueues "github.com/wealdtech/chaind/services/queues/standard"
+	standardreceipts "github.com/wealdtech/chaind/services/receipts/standard"
 	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
 	standardspec "github.com/wealdtech/chaind/services/spec/standard"
+	standardstatus "github.com/wealdtech/chaind/services/status/standard"
 	"github.com/wealdtech/chaind/services/summarizer"
 	standardsummarizer "github.com/wealdtech/chaind/services/summarizer/standard"
 	standardsynccommittees "github.com/wealdtech/chaind/services/synccommittees/standard"
 	standardvalidators "github.com/wealdtech/chaind/services/validators/standard"
+	standardverifier "github.com/wealdtech/chaind/services/verifier/standard"
+	"github.com/wealdtech/chaind/services/warehouse"
+	bigquerywarehouse "github.com/wealdtech/chaind/services/warehouse/bigquery"
+	postgresqlwarehouse "github.com/wealdtech/chaind/services/warehouse/postgresql"
+	standardwatchlist "github.com/wealdtech/chaind/services/watchlist/standard"
 	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
@@ -93,11 +126,100 @@ func main2() int {
 		fmt.Fprintf(os.Stderr, "failed to initialise majordomo: %v\n", err)
 		return 1
 	}
+	secrets = majordomo
 	if err := initLogging(); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialise logging: %v\n", err)
 		return 1
 	}
 
+	if pflag.Arg(0) == "status" {
+		if err := runStatus(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to obtain status: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if pflag.Arg(0) == "schema" {
+		if err := runSchema(ctx, pflag.Arg(1)); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to run schema command: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if pflag.Arg(0) == "watchlist" {
+		if err := runWatchlist(ctx, pflag.Arg(1)); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to run watchlist command: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if pflag.Arg(0) == "address-labels" {
+		if err := runAddressLabels(ctx, pflag.Arg(1)); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to run address labels command: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if pflag.Arg(0) == "export" {
+		if err := runExport(ctx, pflag.Arg(1)); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to run export command: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if pflag.Arg(0) == "snapshot" {
+		if err := runSnapshot(ctx, pflag.Arg(1)); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to run snapshot command: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if pflag.Arg(0) == "failed-items" {
+		if err := runFailedItems(ctx, pflag.Arg(1)); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to run failed items command: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if pflag.Arg(0) == "spec" {
+		if err := runSpec(ctx, pflag.Arg(1)); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to run spec command: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if pflag.Arg(0) == "backfill-validators" {
+		if err := runBackfillValidators(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to backfill validators: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if pflag.Arg(0) == "reprocess-blocks" {
+		if err := runReprocessBlocks(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to reprocess blocks: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if pflag.Arg(0) == "reindex" {
+		if err := runReindex(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to reindex: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
 	logModules()
 	log.Info().Str("version", ReleaseVersion).Msg("Starting chaind")
 
@@ -107,6 +229,7 @@ func main2() int {
 	}
 
 	initProfiling()
+	initAdmin(ctx)
 
 	runtime.GOMAXPROCS(runtime.NumCPU() * 8)
 
@@ -123,7 +246,13 @@ func main2() int {
 	setRelease(ctx, ReleaseVersion)
 	setReady(ctx, false)
 
-	if err := startServices(ctx, monitor); err != nil {
+	if err := initBeaconProxy(ctx, monitor); err != nil {
+		log.Error().Err(err).Msg("Failed to initialise beacon node proxy")
+		return 1
+	}
+
+	statusSvc, leaderSvc, err := startServices(ctx, monitor)
+	if err != nil {
 		log.Error().Err(err).Msg("Failed to initialise services")
 		return 1
 	}
@@ -131,18 +260,31 @@ func main2() int {
 
 	log.Info().Msg("All services operational")
 
+	// If leadership is lost then exit, as another instance will take over writing to the database.
+	var leadershipLost <-chan struct{}
+	if leaderSvc != nil {
+		leadershipLost = leaderSvc.Lost()
+	}
+
+	if viper.GetBool("run-once") {
+		return runOnce(ctx, statusSvc, leadershipLost)
+	}
+
 	// Wait for signal.
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	for {
-		sig := <-sigCh
-		if sig == syscall.SIGINT || sig == syscall.SIGTERM || sig == os.Interrupt || sig == os.Kill {
-			break
+		select {
+		case sig := <-sigCh:
+			if sig == syscall.SIGINT || sig == syscall.SIGTERM || sig == os.Interrupt || sig == os.Kill {
+				log.Info().Msg("Stopping chaind")
+				return 0
+			}
+		case <-leadershipLost:
+			log.Error().Msg("Leadership lost; stopping chaind")
+			return 1
 		}
 	}
-
-	log.Info().Msg("Stopping chaind")
-	return 0
 }
 
 // fetchConfig fetches configuration from various sources.
@@ -151,30 +293,212 @@ func fetchConfig() error {
 	pflag.Bool("version", false, "show version and exit")
 	pflag.String("log-level", "info", "minimum level of messsages to log")
 	pflag.String("log-file", "", "redirect log output to a file")
+	pflag.String("log-format", "json", "format of log messages (json or console)")
+	pflag.Uint32("log-sampling.burst", 0, "maximum number of identical messages to log in each sampling period (0 to disable sampling)")
+	pflag.Duration("log-sampling.period", time.Minute, "period over which identical messages are sampled")
+	pflag.String("log-sampling.level", "warn", "minimum level of messages to sample")
+	pflag.String("admin.listen-address", "", "Address on which to serve the admin API")
+	pflag.Bool("run-once", false, "Catch up with the chain and exit, rather than running continuously")
+	pflag.Duration("run-once-timeout", 0, "Maximum time to wait for modules to catch up when running once (0 for no limit)")
+	pflag.Int64("run-once-max-gap", 2, "Maximum number of items modules can be behind and be considered caught up when running once")
 	pflag.String("profile-address", "", "Address on which to run Go profile server")
 	pflag.String("tracing-address", "", "Address to which to send tracing data")
+	pflag.String("tracing.protocol", "grpc", "Protocol with which to send tracing data (grpc or http)")
+	pflag.Float64("tracing.sampling-ratio", 1, "Proportion of traces to send (0 to 1)")
+	pflag.String("metrics.otlp.address", "", "Address to which to send OpenTelemetry metrics")
+	pflag.Duration("metrics.otlp.interval", time.Minute, "Interval at which to send OpenTelemetry metrics")
+	pflag.Duration("majordomo.refresh-interval", 5*time.Minute, "Interval after which secrets are fetched again to pick up rotated values")
 	pflag.String("eth2client.address", "", "Address for beacon node")
 	pflag.Duration("eth2client.timeout", 2*time.Minute, "Timeout for beacon node requests")
+	pflag.String("eth2client.auth-token", "", "Bearer token for beacon node requests, or a reference to a secret holding it")
+	pflag.Bool("eth2client.proxy.enable", false, "Send beacon node requests through a proxy that deduplicates concurrent identical requests")
+	pflag.String("eth2client.proxy.listen-address", "127.0.0.1:0", "Address on which the beacon node proxy listens")
+	pflag.String("eth2client.proxy.cache.dir", "", "Directory in which the beacon node proxy caches responses that cannot change (empty to disable caching)")
+	pflag.Uint64("eth2client.proxy.cache.max-size-mb", 1024, "Maximum size of the beacon node proxy cache, in megabytes (0 for no limit)")
+	pflag.Bool("genesis.await", false, "Wait for the beacon node to obtain genesis, rather than exiting, for networks that have not yet started")
+	pflag.Duration("genesis.poll-interval", 30*time.Second, "Interval at which to poll the beacon node for genesis when awaiting genesis")
 	pflag.Bool("blocks.enable", true, "Enable fetching of block-related information")
 	pflag.Int32("blocks.start-slot", -1, "Slot from which to start fetching blocks")
+	pflag.Int64("blocks.end-slot", -1, "Last slot for which to fetch blocks, after which the module idles")
 	pflag.Bool("blocks.refetch", false, "Refetch all blocks even if they are already in the database")
+	pflag.Uint64("blocks.batch-slots", 1, "Number of slots whose blocks are written in a single database transaction")
+	pflag.Uint64("blocks.pipeline.queue-size", 32, "Number of blocks held between each stage of the block processing pipeline")
+	pflag.Bool("blocks.orphaned-bodies", false, "Store the contents of blocks that are not on the canonical chain")
+	pflag.Bool("blocks.headers-first.enable", false, "Fetch the headers of all missing blocks before fetching their bodies")
+	pflag.Uint64("blocks.headers-first.fetchers", 16, "Number of block headers fetched concurrently when fetching headers ahead of bodies")
+	pflag.Bool("blocks.arrivals", false, "Store the time at which blocks indexed at the head of the chain arrived")
+	pflag.Bool("blocks.raw.enable", false, "Store the SSZ encoding of blocks")
+	pflag.Bool("blocks.raw.cold-storage", false, "Store the SSZ encoding of blocks in cold storage rather than the database")
+	pflag.Duration("blocks.poll-interval", 0, "Time without beacon node events after which to poll for new blocks (defaults to two slots)")
 	pflag.Bool("finalizer.enable", true, "Enable additional information on receipt of finality checkpoint")
+	pflag.Bool("finalizer.checkpoints.enable", false, "Store the boundary roots and finality checkpoints of each finalized epoch")
+	pflag.Bool("finalizer.randao.enable", false, "Store the RANDAO mix of each finalized epoch")
 	pflag.Bool("summarizer.enable", true, "Enable summary information")
 	pflag.Bool("summarizer.epochs.enable", true, "Enable summary information for epochs")
 	pflag.Bool("summarizer.blocks.enable", true, "Enable summary information for blocks")
 	pflag.Bool("summarizer.validators.enable", false, "Enable summary information for validators (warning: creates a lot of data)")
+	pflag.Bool("summarizer.validators.rankings", false, "Enable rankings of validator effectiveness alongside validator day summaries")
+	pflag.Bool("summarizer.committees.enable", false, "Enable summary information for beacon committees alongside epoch summaries")
+	pflag.Bool("summarizer.sync-periods.enable", false, "Enable summary information for validators in each sync committee period")
+	pflag.Bool("summarizer.proposers.enable", false, "Enable profitability summary information for block proposers")
+	pflag.StringSlice("summarizer.proposers.windows", nil, "Windows, in addition to days, for which to summarize proposer profitability (week, month)")
+	pflag.Bool("summarizer.clusters.enable", false, "Enable clustering of validators by shared withdrawal credentials")
+	pflag.Bool("summarizer.clusters.fee-recipients", false, "Also cluster validators by the fee recipients of their blocks")
+	pflag.Bool("summarizer.blob-fees.enable", false, "Enable calculation of the blob fees of canonical blocks")
+	pflag.Uint64("summarizer.blob-fees.window", 32, "Number of blocks over which rolling blob fee values are calculated")
+	pflag.Bool("summarizer.balance-anomalies.enable", false, "Enable detection of validator balance changes outside of the range expected from rewards and penalties")
+	pflag.Uint64("summarizer.balance-anomalies.threshold", 1000000, "Amount, in Gwei, by which a balance change must be outside of its expected range to be an anomaly")
+	pflag.Bool("summarizer.balance-anomalies.alert", false, "Log a warning for each balance anomaly")
+	pflag.Int64("summarizer.start-epoch", -1, "First epoch to summarize")
+	pflag.Int64("summarizer.end-epoch", -1, "Last epoch to summarize")
 	pflag.Uint64("summarizer.max-days-per-run", 28, "Maximum number of days' of data to summarize in a single run (when pruning)")
+	pflag.Uint64("summarizer.concurrency", 1, "Number of epochs, and of days, to summarize concurrently")
 	pflag.Bool("validators.enable", true, "Enable fetching of validator-related information")
 	pflag.Bool("validators.balances.enable", false, "Enable fetching of validator balances (warning: creates a lot of data)")
+	pflag.Int64("validators.start-epoch", -1, "First epoch for which to fetch validator balances")
+	pflag.Int64("validators.end-epoch", -1, "Last epoch for which to update validators and fetch their balances, after which the module idles")
+	pflag.Int64("validators.shard.from-index", -1, "First validator index handled by this instance, when sharding validators across instances")
+	pflag.Int64("validators.shard.to-index", -1, "Last validator index handled by this instance, when sharding validators across instances")
 	pflag.Bool("beacon-committees.enable", true, "Enable fetching of beacon committee-related information")
+	pflag.Bool("beacon-committees.shuffling-verification.enable", false, "Verify beacon committees against those calculated locally from the shuffling of active validators")
 	pflag.Bool("proposer-duties.enable", true, "Enable fetching of proposer duty-related information")
 	pflag.Bool("sync-committees.enable", true, "Enable fetching of sync committee-related information")
 	pflag.Int32("sync-committees.start-period", -1, "Period from which to start fetching sync committees")
 	pflag.Bool("eth1deposits.enable", false, "Enable fetching of Ethereum 1 deposit information")
 	pflag.String("eth1deposits.start-block", "", "Ethereum 1 block from which to start fetching deposits")
+	pflag.Duration("eth1deposits.reconciliation-interval", time.Hour, "Interval between reconciliations of deposits against the deposit contract (0 to disable)")
 	pflag.String("eth1client.address", "", "Address for Ethereum 1 node")
-	pflag.String("chaindb.url", "", "URL for database")
+	pflag.String("chaindb.url", "", "URL or connection string for database")
+	pflag.String("chaindb.server", "", "Server for database, either a host name, an IP address or the directory of a unix socket (used if chaindb.url is not supplied)")
+	pflag.Int32("chaindb.port", 5432, "Port for database")
+	pflag.String("chaindb.user", "", "User for database")
+	pflag.String("chaindb.password", "", "Password for database, or a reference to a secret holding it")
+	pflag.String("chaindb.database", "", "Name of database (defaults to the user)")
+	pflag.String("chaindb.ssl-mode", "", "SSL mode for database (disable, allow, prefer, require, verify-ca or verify-full)")
+	pflag.String("chaindb.channel-binding", "", "Channel binding mode for database (disable or prefer)")
+	pflag.String("chaindb.client-cert", "", "Path to the client TLS certificate for database")
+	pflag.String("chaindb.client-key", "", "Path to the client TLS key for database")
+	pflag.String("chaindb.ca-cert", "", "Path to the certificate authority TLS certificate for database")
 	pflag.Uint("chaindb.max-connections", 16, "maximum number of concurrent database connections")
+	pflag.Duration("chaindb.max-connection-lifetime", time.Hour, "Time after which database connections are closed")
+	pflag.Duration("chaindb.max-connection-idle-time", 30*time.Minute, "Time after which idle database connections are closed")
+	pflag.String("chaindb.statement-cache-mode", "cache_statement", "Mode for preparing and caching statements (cache_statement, cache_describe, describe_exec, exec or simple_protocol)")
+	pflag.Duration("chaindb.statement-timeout", 0, "Time after which database statements are cancelled (0 for no timeout)")
+	pflag.Duration("chaindb.query-timeout", 0, "Time after which chaind cancels database queries (0 for no timeout)")
+	pflag.Bool("chaindb.compact-attestations", false, "Store attestations without aggregation indices (requires beacon committees)")
+	pflag.Bool("chaindb.auto-upgrade", true, "Upgrade the database schema on startup if required")
+	pflag.Bool("chaindb.concurrent-indexes", false, "Create secondary indexes without locking their tables against writes")
+	pflag.Bool("chaindb.external-schema", false, "Never change the database schema, instead validating the externally managed schema on startup")
+	pflag.String("chaindb.schema", "", "Database schema in which to hold tables (defaults to the first schema in the server's search path)")
+	pflag.StringSlice("chaindb.read-only-roles", nil, "Database roles to be granted read access to the tables, created if not present")
+	pflag.Bool("chaindb.row-level-security", false, "Enable row-level security on the tables, allowing only the read-only roles to read rows")
+	pflag.Int("chaindb.write-batch-size", 0, "Number of small upserts, such as validator balances and epoch summaries, to send to the database together (0 to send each immediately)")
+	pflag.Duration("chaindb.write-flush-interval", 0, "Longest time to hold small upserts before sending them to the database (0 to hold until the batch is full)")
+	pflag.String("chaindb.timescale.mode", "auto", "Use of TimescaleDB (auto to use it if installed, enable or disable)")
+	pflag.Uint64("chaindb.timescale.compress-after", 2250, "Age in epochs after which data in TimescaleDB hypertables is compressed (0 for no compression)")
+	pflag.Uint64("chaindb.timescale.aggregate-epochs", 225, "Width in epochs of the buckets of TimescaleDB continuous aggregates")
+	pflag.Bool("chaindb.audit.enable", false, "Record the writes made by each database transaction in t_audit_log")
+	pflag.Duration("chaindb.audit.retention", 168*time.Hour, "Time for which audit log entries are kept (0 to keep indefinitely)")
+	pflag.Bool("leader-election.enable", false, "Only start modules once this instance is elected leader amongst instances using the same database")
+	pflag.String("leader-election.name", "chaind", "Name of the leader election, shared by instances that compete for leadership")
+	pflag.Duration("leader-election.interval", 5*time.Second, "Interval between attempts to become leader, and between checks that leadership is still held")
+	pflag.Bool("load-shedding.enable", false, "Delay heavy background work until the busy periods of each slot have passed")
+	pflag.StringSlice("load-shedding.busy-windows", []string{"0s-4s"}, "Busy periods of each slot, relative to the start of the slot, in the form start-end")
+	pflag.String("storage.profile", "full", "Storage profile, either full or light (light keeps only summaries of attestations, committees and sync aggregates)")
+	pflag.Bool("indexmanager.enable", false, "Drop secondary indexes while backfilling blocks, and create them once caught up")
+	pflag.Uint64("indexmanager.max-slot-lag", 64, "Maximum number of slots blocks can lag the chain head and be considered caught up")
+	pflag.Bool("maintenance.enable", false, "Monitor tables for dead tuples and stale statistics, and recommend VACUUM and ANALYZE")
+	pflag.Bool("maintenance.automatic", false, "Run recommended VACUUM and ANALYZE operations rather than only reporting them")
+	pflag.Duration("maintenance.interval", time.Hour, "Interval between maintenance checks")
+	pflag.Uint64("maintenance.max-slot-lag", 64, "Maximum number of slots blocks can lag the chain head for maintenance to run")
+	pflag.Int64("maintenance.min-dead-tuples", 10000, "Minimum number of dead tuples in a table before it is vacuumed")
+	pflag.Float64("maintenance.dead-tuple-ratio", 0.2, "Ratio of dead to live tuples in a table above which it is vacuumed")
+	pflag.Float64("maintenance.analyze-ratio", 0.1, "Ratio of modified to live tuples in a table above which it is analyzed")
+	pflag.Int64("backfill-validators.start-epoch", -1, "First epoch for which to backfill validator balances")
+	pflag.Int64("backfill-validators.end-epoch", -1, "Last epoch for which to backfill validator balances (defaults to the last completed epoch)")
+	pflag.String("backfill-validators.address", "", "Address for archive beacon node from which to backfill validator balances (defaults to eth2client.address)")
+	pflag.Int64("reprocess-blocks.start-slot", -1, "First slot for which to reprocess stored raw blocks")
+	pflag.Int64("reprocess-blocks.end-slot", -1, "Last slot for which to reprocess stored raw blocks (defaults to the latest stored raw block)")
+	pflag.Bool("from-archive", false, "Reindex from stored raw blocks")
+	pflag.String("slots", "", "Range of slots to reindex, for example 1000-2000")
+	pflag.String("network-a", "", "First network for \"chaind spec diff\", either a database connection URL or a beacon node address (defaults to chaindb.url)")
+	pflag.String("network-b", "", "Second network for \"chaind spec diff\", either a database connection URL or a beacon node address (defaults to eth2client.address)")
+	pflag.Uint64("schema.target-version", 0, "Version of the schema to which to migrate (defaults to the latest version)")
+	pflag.Bool("schema.dry-run", false, "Print the statements for a schema migration without applying them")
+	pflag.Uint64("schema.version", 0, "Version of the schema for which to print statements (defaults to creating the latest version)")
+	pflag.String("status.listen-address", "", "Address on which to serve status and health information")
+	pflag.Uint64("status.max-slot-lag", 64, "Maximum number of slots blocks can lag the chain head and be considered healthy")
+	pflag.Bool("archiver.enable", false, "Enable offloading of old data to cold storage")
+	pflag.Uint64("archiver.max-epochs-per-run", 225, "Maximum number of epochs' of data to archive in a single run")
+	pflag.Bool("clientfingerprints.enable", false, "Enable fingerprinting of the clients that produced blocks")
+	pflag.String("clientfingerprints.rules", "", "Path to a JSON file of client fingerprint rules (defaults to built-in rules)")
+	pflag.String("clientfingerprints.releases", "", "Path to a JSON file of client releases with which to annotate blocks")
+	pflag.Uint64("clientfingerprints.max-slots-per-run", 7200, "Maximum number of slots to fingerprint in a single run")
+	pflag.Bool("equivocations.enable", false, "Enable detection of equivocations")
+	pflag.Uint64("equivocations.surround-lookback", 16, "Number of previous epochs against which attestations are checked for surround votes")
+	pflag.Uint64("equivocations.max-epochs-per-run", 225, "Maximum number of epochs to check for equivocations in a single run")
+	pflag.Bool("exporter.enable", false, "Enable export of tables to a data warehouse")
+	pflag.StringSlice("exporter.tables", nil, "Tables to export to the data warehouse (defaults to all exportable tables)")
+	pflag.Duration("exporter.interval", 5*time.Minute, "Interval between exports to the data warehouse")
+	pflag.Uint32("exporter.marks-per-batch", 32, "Maximum number of slots or epochs of a table to export in a single batch")
+	pflag.Bool("outbox.enable", false, "Enable capture of changes to tables, and their publication to downstream consumers")
+	pflag.StringSlice("outbox.tables", nil, "Tables whose changes are captured (defaults to all capturable tables)")
+	pflag.Duration("outbox.interval", time.Second, "Interval between dispatches of captured changes to publishers")
+	pflag.Uint32("outbox.batch-size", 1000, "Maximum number of captured changes dispatched to publishers in a single batch")
+	pflag.Int("cache.memory.max-entries", 0, "Maximum number of entries held in the in-memory cache (0 for no maximum)")
+	pflag.String("cache.redis.url", "", "URL of a Redis server to use as a shared cache in place of the in-memory cache")
+	pflag.String("cache.redis.prefix", "chaind:", "Prefix for keys held in the Redis cache")
+	pflag.Bool("cache.reads.enable", false, "Cache the results of frequent reads from the database")
+	pflag.Duration("cache.reads.ttl", time.Minute, "Time for which the results of reads are cached")
+	pflag.StringSlice("export.validators", nil, "Indices or public keys of validators for export commands")
+	pflag.String("export.output", "", "File to which exported data is written (defaults to standard output)")
+	pflag.Bool("export.minimal", true, "Export only the latest signed block and attestation checkpoints for slashing protection")
+	pflag.Uint64("export.margin", 2, "Number of epochs beyond the current epoch at which a watermark is added to exported slashing protection")
+	pflag.String("snapshot.dir", "", "Directory of the snapshot for snapshot commands")
+	pflag.Bool("snapshot.verify", true, "Verify the chain against the beacon node after restoring a snapshot")
+	pflag.Int("snapshot.spot-checks", 64, "Number of block roots, in addition to the first and latest, checked against the beacon node when verifying a snapshot")
+	pflag.Uint32("failed-items.max-attempts", 5, "Number of times processing of a slot or epoch can fail before it is recorded as dead and skipped (0 to retry indefinitely)")
+	pflag.StringSlice("failed-items.modules", nil, "Modules for failed items commands")
+	pflag.String("failed-items.kind", "", "Kind of items, slot or epoch, for failed items commands")
+	pflag.String("failed-items.range", "", "Range of items, of the form A-B, for failed items commands")
+	pflag.StringSlice("failed-items.states", nil, "States by which to filter the failed items list command")
+	pflag.Bool("watchlist.enable", false, "Enable events for validators on the watchlist")
+	pflag.Uint64("watchlist.max-epochs-per-run", 225, "Maximum number of epochs of watchlist events to update in a single run")
+	pflag.StringSlice("watchlist.validators", nil, "Indices or public keys of validators for watchlist commands")
+	pflag.String("watchlist.label", "", "Label for validators added to the watchlist")
+	pflag.StringSlice("watchlist.proofs", nil, "Proofs of ownership of validators added to the watchlist, in the same order as the validators")
+	pflag.Uint32("watchlist.limit", 100, "Maximum number of events or alerts shown by the watchlist events and alerts commands")
+	pflag.Float64("watchlist.alerts.attestation-effectiveness.threshold", 0, "Attestation effectiveness percentage below which an alert is raised for a watched validator (0 to disable)")
+	pflag.Uint64("watchlist.alerts.attestation-effectiveness.epochs", 10, "Number of epochs over which attestation effectiveness is calculated for alerts")
+	pflag.Uint64("watchlist.alerts.missed-attestations", 0, "Number of consecutive missed attestations at which an alert is raised for a watched validator (0 to disable)")
+	pflag.Uint64("watchlist.alerts.missed-proposals", 0, "Number of consecutive missed proposals at which an alert is raised for a watched validator (0 to disable)")
+	pflag.String("address-labels.file", "", "File from which to import address labels")
+	pflag.String("address-labels.format", "", "Format of the address labels file, csv or json (defaults to the file extension)")
+	pflag.StringSlice("address-labels.addresses", nil, "Addresses for address labels commands")
+	pflag.StringSlice("address-labels.labels", nil, "Labels or categories by which to filter the address labels list command")
+	pflag.Bool("verifier.enable", false, "Enable verification of indexed blocks against a second beacon node")
+	pflag.String("verifier.address", "", "Address for the reference beacon node against which to verify indexed blocks")
+	pflag.Int64("verifier.start-slot", -1, "Slot from which to (re-)verify indexed blocks")
+	pflag.Bool("receipts.enable", false, "Enable fetching of transaction receipts and events for execution payloads")
+	pflag.String("receipts.address", "", "Address for the execution node from which to fetch receipts (defaults to eth1client.address)")
+	pflag.Duration("receipts.timeout", 30*time.Second, "Timeout for execution node requests")
+	pflag.Int64("receipts.start-slot", -1, "Slot from which to (re-)fetch receipts")
+	pflag.StringSlice("receipts.events.addresses", nil, "Contract addresses for which to store events (default all)")
+	pflag.StringSlice("receipts.events.topics", nil, "First topics for which to store events (default all)")
+	pflag.StringSlice("receipts.relays", nil, "Addresses of relays from which to obtain the declared values of the execution payloads they delivered")
+	pflag.Bool("gossip.enable", false, "Enable capture of the times at which blocks and attestations are first seen")
+	pflag.Bool("gossip.attestations", true, "Capture attestation arrival times as well as block arrival times")
+	pflag.Duration("gossip.flush-interval", 12*time.Second, "Interval at which captured arrival times are written to the database")
+	pflag.Bool("proofs.enable", false, "Enable generation of Merkle proofs for indexed data")
+	pflag.String("proofs.listen-address", "", "Address on which to serve Merkle proofs")
+	pflag.String("proofs.address", "", "Address for the beacon node from which to fetch blocks and states for proofs (defaults to eth2client.address)")
+	pflag.Bool("heads.enable", false, "Enable observation of the chain head in each slot")
+	pflag.String("heads.address", "", "Address of the beacon node from which to observe the chain head, if different from the main beacon node")
+	pflag.Duration("heads.offset", 4*time.Second, "Offset from the start of each slot at which to observe the chain head")
+	pflag.Bool("queues.enable", false, "Enable projection of validator activation and exit queues")
+	pflag.String("queues.listen-address", "", "Address on which to serve queue projections")
+	pflag.Uint64("queues.snapshot-interval", 225, "Number of epochs between stored snapshots of queue projections (0 to disable)")
+	pflag.StringSlice("plugins.paths", nil, "Paths of Go plugins that provide custom indexers")
 	pflag.Parse()
 	if err := viper.BindPFlags(pflag.CommandLine); err != nil {
 		return errors.Wrap(err, "failed to bind pflags to viper")
@@ -257,30 +581,290 @@ func startMonitor(ctx context.Context) (metrics.Service, error) {
 	return monitor, nil
 }
 
-func startDatabase(ctx context.Context) (chaindb.Service, error) {
+func startDatabase(ctx context.Context, coldStore coldstore.Service) (chaindb.Service, error) {
+	cache, err := startCache(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hooks, err := sqlHooks()
+	if err != nil {
+		return nil, err
+	}
+
+	connectionParams, err := databaseConnectionParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Trace().Msg("Starting chain database service")
-	chainDB, err := postgresqlchaindb.New(ctx,
+	params := []postgresqlchaindb.Parameter{
 		postgresqlchaindb.WithLogLevel(util.LogLevel("chaindb")),
-		postgresqlchaindb.WithConnectionURL(viper.GetString("chaindb.url")),
 		postgresqlchaindb.WithMaxConnections(viper.GetUint("chaindb.max-connections")),
-	)
+		postgresqlchaindb.WithMaxConnectionLifetime(viper.GetDuration("chaindb.max-connection-lifetime")),
+		postgresqlchaindb.WithMaxConnectionIdleTime(viper.GetDuration("chaindb.max-connection-idle-time")),
+		postgresqlchaindb.WithStatementCacheMode(viper.GetString("chaindb.statement-cache-mode")),
+		postgresqlchaindb.WithStatementTimeout(viper.GetDuration("chaindb.statement-timeout")),
+		postgresqlchaindb.WithQueryTimeout(viper.GetDuration("chaindb.query-timeout")),
+		postgresqlchaindb.WithQueryTimeouts(queryTimeouts()),
+		postgresqlchaindb.WithPoolPartitions(poolPartitions()),
+		postgresqlchaindb.WithCompactAttestations(viper.GetBool("chaindb.compact-attestations")),
+		postgresqlchaindb.WithColdStore(coldStore),
+		postgresqlchaindb.WithConcurrentIndexes(viper.GetBool("chaindb.concurrent-indexes")),
+		postgresqlchaindb.WithExternalSchema(viper.GetBool("chaindb.external-schema")),
+		postgresqlchaindb.WithSchema(viper.GetString("chaindb.schema")),
+		postgresqlchaindb.WithReadOnlyRoles(viper.GetStringSlice("chaindb.read-only-roles")),
+		postgresqlchaindb.WithRowLevelSecurity(viper.GetBool("chaindb.row-level-security")),
+		postgresqlchaindb.WithWriteBatchSize(viper.GetInt("chaindb.write-batch-size")),
+		postgresqlchaindb.WithWriteFlushInterval(viper.GetDuration("chaindb.write-flush-interval")),
+		postgresqlchaindb.WithTimescale(viper.GetString("chaindb.timescale.mode")),
+		postgresqlchaindb.WithTimescaleCompressAfter(viper.GetUint64("chaindb.timescale.compress-after")),
+		postgresqlchaindb.WithTimescaleAggregateEpochs(viper.GetUint64("chaindb.timescale.aggregate-epochs")),
+		postgresqlchaindb.WithSpecTypes(specTypes()),
+		postgresqlchaindb.WithAudit(viper.GetBool("chaindb.audit.enable")),
+		postgresqlchaindb.WithAuditRetention(viper.GetDuration("chaindb.audit.retention")),
+		postgresqlchaindb.WithSQLHooks(hooks),
+		postgresqlchaindb.WithValidatorIndexCache(cache),
+	}
+	params = append(params, connectionParams...)
+	if viper.GetBool("cache.reads.enable") {
+		params = append(params,
+			postgresqlchaindb.WithReadCache(cache),
+			postgresqlchaindb.WithReadCacheTTL(viper.GetDuration("cache.reads.ttl")),
+		)
+	}
+	chainDB, err := postgresqlchaindb.New(ctx, params...)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to start chain database service")
 	}
 	return chainDB, err
 }
 
-func startServices(ctx context.Context, monitor metrics.Service) error {
+// databaseConnectionParams provides the parameters for connecting to the database,
+// either from its connection URL or from its individual components.
+func databaseConnectionParams(ctx context.Context) ([]postgresqlchaindb.Parameter, error) {
+	password := viper.GetString("chaindb.password")
+	var passwordParams []postgresqlchaindb.Parameter
+	if isSecretReference(password) {
+		secret, err := newRotatingSecret(ctx, password, viper.GetDuration("majordomo.refresh-interval"))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain database password")
+		}
+		passwordParams = append(passwordParams, postgresqlchaindb.WithPasswordProvider(secret.Value))
+	} else {
+		passwordParams = append(passwordParams, postgresqlchaindb.WithPassword(password))
+	}
+
+	if viper.GetString("chaindb.url") != "" {
+		params := []postgresqlchaindb.Parameter{
+			postgresqlchaindb.WithConnectionURL(viper.GetString("chaindb.url")),
+		}
+		if isSecretReference(password) {
+			// A password held in a secret store overrides any in the URL.
+			params = append(params, passwordParams...)
+		}
+		return params, nil
+	}
+
+	params := []postgresqlchaindb.Parameter{
+		postgresqlchaindb.WithServer(viper.GetString("chaindb.server")),
+		postgresqlchaindb.WithPort(viper.GetInt32("chaindb.port")),
+		postgresqlchaindb.WithUser(viper.GetString("chaindb.user")),
+		postgresqlchaindb.WithDatabase(viper.GetString("chaindb.database")),
+		postgresqlchaindb.WithSSLMode(viper.GetString("chaindb.ssl-mode")),
+		postgresqlchaindb.WithChannelBinding(viper.GetString("chaindb.channel-binding")),
+	}
+	params = append(params, passwordParams...)
+	if viper.GetString("chaindb.client-cert") != "" {
+		cert, err := os.ReadFile(resolvePath(viper.GetString("chaindb.client-cert")))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read database client certificate")
+		}
+		params = append(params, postgresqlchaindb.WithClientCert(cert))
+	}
+	if viper.GetString("chaindb.client-key") != "" {
+		key, err := os.ReadFile(resolvePath(viper.GetString("chaindb.client-key")))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read database client key")
+		}
+		params = append(params, postgresqlchaindb.WithClientKey(key))
+	}
+	if viper.GetString("chaindb.ca-cert") != "" {
+		cert, err := os.ReadFile(resolvePath(viper.GetString("chaindb.ca-cert")))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read database CA certificate")
+		}
+		params = append(params, postgresqlchaindb.WithCACert(cert))
+	}
+
+	return params, nil
+}
+
+// poolPartitions provides the configured database pool partitions, keyed by module.
+func poolPartitions() map[string]*postgresqlchaindb.PoolPartition {
+	partitions := make(map[string]*postgresqlchaindb.PoolPartition)
+	for name := range viper.GetStringMap("chaindb.pools") {
+		partition := &postgresqlchaindb.PoolPartition{
+			MaxConnections: viper.GetUint(fmt.Sprintf("chaindb.pools.%s.max-connections", name)),
+		}
+		if viper.IsSet(fmt.Sprintf("chaindb.pools.%s.statement-timeout", name)) {
+			statementTimeout := viper.GetDuration(fmt.Sprintf("chaindb.pools.%s.statement-timeout", name))
+			partition.StatementTimeout = &statementTimeout
+		}
+		if viper.IsSet(fmt.Sprintf("chaindb.pools.%s.query-timeout", name)) {
+			queryTimeout := viper.GetDuration(fmt.Sprintf("chaindb.pools.%s.query-timeout", name))
+			partition.QueryTimeout = &queryTimeout
+		}
+		partitions[name] = partition
+	}
+
+	return partitions
+}
+
+// queryTimeouts provides the configured database query timeouts, keyed by operation.
+func queryTimeouts() map[string]time.Duration {
+	timeouts := make(map[string]time.Duration)
+	for operation := range viper.GetStringMap("chaindb.query-timeouts") {
+		timeouts[operation] = viper.GetDuration(fmt.Sprintf("chaindb.query-timeouts.%s", operation))
+	}
+
+	return timeouts
+}
+
+// specTypes provides the configured chain specification types, keyed by chain
+// specification key.
+func specTypes() map[string]string {
+	types := make(map[string]string)
+	for key, typ := range viper.GetStringMapString("chaindb.spec-types") {
+		// Configuration keys are case-insensitive, chain specification keys are upper case.
+		types[strings.ToUpper(key)] = typ
+	}
+
+	return types
+}
+
+// sqlHooks provides the configured SQL hooks.
+func sqlHooks() ([]*postgresqlchaindb.SQLHook, error) {
+	hooks := make([]*postgresqlchaindb.SQLHook, 0)
+	if err := viper.UnmarshalKey("chaindb.sql-hooks", &hooks); err != nil {
+		return nil, errors.Wrap(err, "failed to parse SQL hooks")
+	}
+
+	return hooks, nil
+}
+
+// moduleChainDB provides the chain database for a module, using the module's
+// pool partition if one is configured, and attributing its writes to the
+// module in the audit log.
+func moduleChainDB(chainDB chaindb.Service, module string) chaindb.Service {
+	if db, isPostgreSQL := chainDB.(*postgresqlchaindb.Service); isPostgreSQL {
+		return db.Partition(module).ForModule(module)
+	}
+
+	return chainDB
+}
+
+// startCache starts the cache, held in Redis if configured or else in memory.
+func startCache(ctx context.Context) (cache.Service, error) {
+	if viper.GetString("cache.redis.url") != "" {
+		log.Trace().Msg("Starting Redis cache")
+		redisCache, err := rediscache.New(ctx,
+			rediscache.WithLogLevel(util.LogLevel("cache")),
+			rediscache.WithURL(viper.GetString("cache.redis.url")),
+			rediscache.WithPrefix(viper.GetString("cache.redis.prefix")),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to start Redis cache")
+		}
+		return redisCache, nil
+	}
+
+	log.Trace().Msg("Starting memory cache")
+	memoryCache, err := memorycache.New(ctx,
+		memorycache.WithLogLevel(util.LogLevel("cache")),
+		memorycache.WithMaxEntries(viper.GetInt("cache.memory.max-entries")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start memory cache")
+	}
+
+	return memoryCache, nil
+}
+
+// startColdStore starts the cold store, if configured.
+func startColdStore(ctx context.Context) (coldstore.Service, error) {
+	switch {
+	case viper.GetString("coldstore.s3.bucket") != "":
+		log.Trace().Msg("Starting S3 cold store")
+		coldStore, err := s3coldstore.New(ctx,
+			s3coldstore.WithLogLevel(util.LogLevel("coldstore")),
+			s3coldstore.WithRegion(viper.GetString("coldstore.s3.region")),
+			s3coldstore.WithEndpoint(viper.GetString("coldstore.s3.endpoint")),
+			s3coldstore.WithBucket(viper.GetString("coldstore.s3.bucket")),
+			s3coldstore.WithPrefix(viper.GetString("coldstore.s3.prefix")),
+			s3coldstore.WithCredentials(viper.GetString("coldstore.s3.id"), viper.GetString("coldstore.s3.secret")),
+			s3coldstore.WithPathStyle(viper.GetBool("coldstore.s3.path-style")),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to start S3 cold store")
+		}
+		return coldStore, nil
+	case viper.GetString("coldstore.file.base-dir") != "":
+		log.Trace().Msg("Starting file cold store")
+		coldStore, err := filecoldstore.New(ctx,
+			filecoldstore.WithLogLevel(util.LogLevel("coldstore")),
+			filecoldstore.WithBaseDir(util.ResolvePath(viper.GetString("coldstore.file.base-dir"))),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to start file cold store")
+		}
+		return coldStore, nil
+	default:
+		log.Debug().Msg("No cold store configured")
+		return nil, nil
+	}
+}
+
+// startServices starts the services, returning the status service and the leader election service if they are running.
+func startServices(ctx context.Context, monitor metrics.Service) (*standardstatus.Service, leader.Service, error) {
+	storageModes, err := util.StorageModes(viper.GetString("storage.profile"), viper.GetStringMapString("storage.tables"))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid storage configuration")
+	}
+	log.Debug().Interface("modes", storageModes).Msg("Table storage")
+
+	coldStore, err := startColdStore(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	log.Trace().Msg("Checking for schema upgrades")
-	chainDB, err := startDatabase(ctx)
+	chainDB, err := startDatabase(ctx, coldStore)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+
+	// Leadership must be held before the schema is upgraded or any module writes to the database.
+	leaderSvc, err := startLeader(ctx, chainDB, monitor)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start leader election service")
 	}
 
 	if _, isUpgrader := chainDB.(*postgresqlchaindb.Service); isUpgrader {
+		if !viper.GetBool("chaindb.auto-upgrade") && !viper.GetBool("chaindb.external-schema") {
+			if err := checkSchemaVersion(ctx, chainDB.(*postgresqlchaindb.Service)); err != nil {
+				return nil, nil, err
+			}
+		}
 		requiresRefetch, err := chainDB.(*postgresqlchaindb.Service).Upgrade(ctx)
 		if err != nil {
-			return errors.Wrap(err, "failed to upgrade chain database")
+			return nil, nil, errors.Wrap(err, "failed to upgrade chain database")
+		}
+		if err := chainDB.(*postgresqlchaindb.Service).ApplyTimescale(ctx); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to apply TimescaleDB to chain database")
+		}
+		if err := chainDB.(*postgresqlchaindb.Service).ApplyAccessControls(ctx); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to apply access controls to chain database")
 		}
 		if requiresRefetch {
 			// The upgrade requires us to refetch blocks, so set up the options accordingly.
@@ -291,12 +875,9 @@ func startServices(ctx context.Context, monitor metrics.Service) error {
 	}
 
 	log.Trace().Msg("Starting Ethereum 2 client service")
-	eth2Client, err := fetchClient(ctx, viper.GetString("eth2client.address"))
+	eth2Client, err := fetchGenesisClient(ctx, chainDB, viper.GetString("eth2client.address"))
 	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("eth2client.address")))
-	}
-	if err != nil {
-		return errors.Wrap(err, "failed to start Ethereum 2 client service")
+		return nil, nil, errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("eth2client.address")))
 	}
 
 	log.Trace().Msg("Starting chain time service")
@@ -307,7 +888,7 @@ func startServices(ctx context.Context, monitor metrics.Service) error {
 		standardchaintime.WithForkScheduleProvider(eth2Client.(eth2client.ForkScheduleProvider)),
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to start chain time service")
+		return nil, nil, errors.Wrap(err, "failed to start chain time service")
 	}
 
 	// Wait for chainstart.
@@ -324,39 +905,72 @@ func startServices(ctx context.Context, monitor metrics.Service) error {
 		log.Info().Time("chain_start", chainTime.GenesisTime()).Msg("Waiting for chain start.")
 		time.Sleep(timeToGenesis)
 	}
+	if err := markGenesisStarted(ctx, chainDB); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to record chain start")
+	}
 
 	waitForNodeSync(ctx, eth2Client)
 
+	log.Trace().Msg("Starting status service")
+	statusSvc, err := startStatus(ctx, eth2Client, chainDB, chainTime)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start status service")
+	}
+
 	// Spec should be the first service that starts.  This adds configuration data to
 	// chaindb so it is accessible to other services.
 	if !specServiceStarted {
 		log.Trace().Msg("Starting spec service")
 		if err := startSpec(ctx, eth2Client, chainDB, monitor); err != nil {
-			return errors.Wrap(err, "failed to start spec service")
+			return nil, nil, errors.Wrap(err, "failed to start spec service")
 		}
 	}
 
+	log.Trace().Msg("Starting load shedder")
+	loadShedder, err := startLoadShedder(ctx, chainTime, monitor)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start load shedder")
+	}
+
 	// Sync committees service is needed by blocks service.
+	// Start the index manager before any services that index data, so that
+	// secondary indexes are dropped before backfilling starts.
+	log.Trace().Msg("Starting index manager service")
+	if err := startIndexManager(ctx, chainDB, chainTime); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start index manager service")
+	}
+
+	log.Trace().Msg("Starting maintenance service")
+	if err := startMaintenance(ctx, moduleChainDB(chainDB, "maintenance"), chainTime, monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start maintenance service")
+	}
+
 	log.Trace().Msg("Starting sync committees service")
-	if err := startSyncCommittees(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
-		return errors.Wrap(err, "failed to start sync committees service")
+	if err := startSyncCommittees(ctx, eth2Client, moduleChainDB(chainDB, "sync-committees"), chainTime, monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start sync committees service")
 	}
 
 	// Shared activity semaphore for blocks and finalizer, to avoid potential deadlock.
 	activitySem := semaphore.NewWeighted(1)
 
+	log.Trace().Msg("Starting plugins")
+	pluginsRunner, err := startPlugins(ctx, moduleChainDB(chainDB, "plugins"))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start plugins")
+	}
+
 	log.Trace().Msg("Starting blocks service")
-	blocks, err := startBlocks(ctx, eth2Client, chainDB, chainTime, monitor, activitySem)
+	blocks, err := startBlocks(ctx, eth2Client, moduleChainDB(chainDB, "blocks"), chainTime, monitor, activitySem, storageModes, coldStore, pluginsRunner)
 	if err != nil {
-		return errors.Wrap(err, "failed to start blocks service")
+		return nil, nil, errors.Wrap(err, "failed to start blocks service")
 	}
 
 	var summarizerSvc summarizer.Service
 	if blocks != nil {
 		log.Trace().Msg("Starting summarizer service")
-		summarizerSvc, err = startSummarizer(ctx, eth2Client, chainDB, chainTime, monitor)
+		summarizerSvc, err = startSummarizer(ctx, eth2Client, moduleChainDB(chainDB, "summarizer"), chainTime, loadShedder, monitor, storageModes)
 		if err != nil {
-			return errors.Wrap(err, "failed to start summarizer service")
+			return nil, nil, errors.Wrap(err, "failed to start summarizer service")
 		}
 	}
 
@@ -365,31 +979,126 @@ func startServices(ctx context.Context, monitor metrics.Service) error {
 	if summarizerSvc != nil {
 		finalityHandlers = append(finalityHandlers, summarizerSvc.(handlers.FinalityHandler))
 	}
-	if err := startFinalizer(ctx, eth2Client, chainDB, chainTime, blocks, monitor, finalityHandlers, activitySem); err != nil {
-		return errors.Wrap(err, "failed to start finalizer service")
+	if pluginsRunner != nil && pluginsRunner.HasFinalityIndexers() {
+		finalityHandlers = append(finalityHandlers, pluginsRunner)
+	}
+	if err := startFinalizer(ctx, eth2Client, moduleChainDB(chainDB, "finalizer"), chainTime, blocks, monitor, finalityHandlers, activitySem); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start finalizer service")
 	}
 
 	log.Trace().Msg("Starting validators service")
-	if err := startValidators(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
-		return errors.Wrap(err, "failed to start validators service")
+	if err := startValidators(ctx, eth2Client, moduleChainDB(chainDB, "validators"), chainTime, loadShedder, monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start validators service")
 	}
 
 	log.Trace().Msg("Starting beacon committees service")
-	if err := startBeaconCommittees(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
-		return errors.Wrap(err, "failed to start beacon committees service")
+	if err := startBeaconCommittees(ctx, eth2Client, moduleChainDB(chainDB, "beacon-committees"), chainTime, monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start beacon committees service")
 	}
 
 	log.Trace().Msg("Starting proposer duties service")
-	if err := startProposerDuties(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
-		return errors.Wrap(err, "failed to start proposer duties service")
+	if err := startProposerDuties(ctx, eth2Client, moduleChainDB(chainDB, "proposer-duties"), chainTime, monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start proposer duties service")
 	}
 
 	log.Trace().Msg("Starting Ethereum 1 deposits service")
-	if err := startETH1Deposits(ctx, chainDB, monitor); err != nil {
-		return errors.Wrap(err, "failed to start Ethereum 1 deposits service")
+	if err := startETH1Deposits(ctx, moduleChainDB(chainDB, "eth1deposits"), monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start Ethereum 1 deposits service")
 	}
 
-	return nil
+	log.Trace().Msg("Starting archiver service")
+	if err := startArchiver(ctx, moduleChainDB(chainDB, "archiver"), chainTime, coldStore, monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start archiver service")
+	}
+
+	log.Trace().Msg("Starting client fingerprints service")
+	if err := startClientFingerprints(ctx, moduleChainDB(chainDB, "clientfingerprints"), chainTime, monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start client fingerprints service")
+	}
+
+	log.Trace().Msg("Starting equivocations service")
+	if err := startEquivocations(ctx, moduleChainDB(chainDB, "equivocations"), chainTime, monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start equivocations service")
+	}
+
+	log.Trace().Msg("Starting exporter service")
+	if err := startExporter(ctx, moduleChainDB(chainDB, "exporter"), chainTime, monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start exporter service")
+	}
+
+	log.Trace().Msg("Starting outbox service")
+	if err := startOutbox(ctx, moduleChainDB(chainDB, "outbox"), monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start outbox service")
+	}
+
+	log.Trace().Msg("Starting watchlist service")
+	if err := startWatchlist(ctx, moduleChainDB(chainDB, "watchlist"), chainTime, monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start watchlist service")
+	}
+
+	log.Trace().Msg("Starting verifier service")
+	if err := startVerifier(ctx, moduleChainDB(chainDB, "verifier"), chainTime, monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start verifier service")
+	}
+
+	log.Trace().Msg("Starting receipts service")
+	if err := startReceipts(ctx, moduleChainDB(chainDB, "receipts"), chainTime, monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start receipts service")
+	}
+
+	log.Trace().Msg("Starting gossip service")
+	if err := startGossip(ctx, eth2Client, moduleChainDB(chainDB, "gossip"), chainTime, monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start gossip service")
+	}
+
+	log.Trace().Msg("Starting proofs service")
+	if err := startProofs(ctx, eth2Client, moduleChainDB(chainDB, "proofs"), chainTime); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start proofs service")
+	}
+
+	log.Trace().Msg("Starting queues service")
+	if err := startQueues(ctx, moduleChainDB(chainDB, "queues"), chainTime); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start queues service")
+	}
+
+	log.Trace().Msg("Starting heads service")
+	if err := startHeads(ctx, eth2Client, moduleChainDB(chainDB, "heads"), chainTime, monitor); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start heads service")
+	}
+
+	return statusSvc, leaderSvc, nil
+}
+
+// startLeader starts the leader election service and waits for this instance to become leader.
+// Returns nil if leader election is not enabled.
+func startLeader(ctx context.Context,
+	chainDB chaindb.Service,
+	monitor metrics.Service,
+) (
+	leader.Service,
+	error,
+) {
+	if !viper.GetBool("leader-election.enable") {
+		return nil, nil
+	}
+
+	leaderSvc, err := standardleader.New(ctx,
+		standardleader.WithLogLevel(util.LogLevel("leader")),
+		standardleader.WithMonitor(monitor),
+		standardleader.WithChainDB(chainDB),
+		standardleader.WithName(viper.GetString("leader-election.name")),
+		standardleader.WithInterval(viper.GetDuration("leader-election.interval")),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Msg("Waiting to become leader")
+	if err := leaderSvc.AwaitLeadership(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to become leader")
+	}
+
+	return leaderSvc, nil
 }
 
 func waitForNodeSync(ctx context.Context, eth2Client eth2client.Service) {
@@ -480,6 +1189,9 @@ func startBlocks(
 	chainTime chaintime.Service,
 	monitor metrics.Service,
 	activitySem *semaphore.Weighted,
+	storageModes map[string]util.StorageMode,
+	coldStore coldstore.Service,
+	pluginsRunner *plugins.Runner,
 ) (
 	blocks.Service,
 	error,
@@ -496,6 +1208,14 @@ func startBlocks(
 		}
 	}
 
+	var rawBlocksColdStore coldstore.Service
+	if viper.GetBool("blocks.raw.enable") && viper.GetBool("blocks.raw.cold-storage") {
+		if coldStore == nil {
+			return nil, errors.New("raw blocks cold storage requires a cold store")
+		}
+		rawBlocksColdStore = coldStore
+	}
+
 	s, err := standardblocks.New(ctx,
 		standardblocks.WithLogLevel(util.LogLevel("blocks")),
 		standardblocks.WithMonitor(monitor),
@@ -503,8 +1223,21 @@ func startBlocks(
 		standardblocks.WithChainTime(chainTime),
 		standardblocks.WithChainDB(chainDB),
 		standardblocks.WithStartSlot(viper.GetInt64("blocks.start-slot")),
+		standardblocks.WithEndSlot(viper.GetInt64("blocks.end-slot")),
 		standardblocks.WithRefetch(viper.GetBool("blocks.refetch")),
+		standardblocks.WithPollInterval(viper.GetDuration("blocks.poll-interval")),
+		standardblocks.WithBatchSlots(viper.GetUint64("blocks.batch-slots")),
+		standardblocks.WithPipelineQueueSize(viper.GetUint64("blocks.pipeline.queue-size")),
+		standardblocks.WithMaxAttempts(viper.GetUint32("failed-items.max-attempts")),
+		standardblocks.WithOrphanedBodies(viper.GetBool("blocks.orphaned-bodies")),
+		standardblocks.WithHeadersFirst(viper.GetBool("blocks.headers-first.enable")),
+		standardblocks.WithHeaderFetchers(viper.GetUint64("blocks.headers-first.fetchers")),
+		standardblocks.WithArrivals(viper.GetBool("blocks.arrivals")),
+		standardblocks.WithBlobSidecars(storageModes[util.StorageTableBlobSidecars] != util.StorageModeNone),
+		standardblocks.WithRawBlocks(viper.GetBool("blocks.raw.enable")),
+		standardblocks.WithColdStore(rawBlocksColdStore),
 		standardblocks.WithActivitySem(activitySem),
+		standardblocks.WithPlugins(pluginsRunner),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create blocks service")
@@ -513,6 +1246,21 @@ func startBlocks(
 	return s, nil
 }
 
+// startPlugins starts the custom indexers that are compiled in to chaind or
+// loaded from Go plugins; nil if there are none.
+func startPlugins(ctx context.Context, chainDB chaindb.Service) (*plugins.Runner, error) {
+	loaded, err := plugins.Load(viper.GetStringSlice("plugins.paths"))
+	if err != nil {
+		return nil, err
+	}
+	indexers := append(plugins.Registered(), loaded...)
+	if len(indexers) == 0 {
+		return nil, nil
+	}
+
+	return plugins.NewRunner(ctx, chainDB, indexers, util.LogLevel("plugins"))
+}
+
 func startFinalizer(
 	ctx context.Context,
 	eth2Client eth2client.Service,
@@ -544,6 +1292,8 @@ func startFinalizer(
 		standardfinalizer.WithBlocks(blocks),
 		standardfinalizer.WithFinalityHandlers(finalityHandlers),
 		standardfinalizer.WithActivitySem(activitySem),
+		standardfinalizer.WithCheckpoints(viper.GetBool("finalizer.checkpoints.enable")),
+		standardfinalizer.WithRANDAOMixes(viper.GetBool("finalizer.randao.enable")),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to create finalizer service")
@@ -557,12 +1307,19 @@ func startSummarizer(
 	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
 	chainTime chaintime.Service,
+	loadShedder loadshedder.Service,
 	monitor metrics.Service,
+	storageModes map[string]util.StorageMode,
 ) (
 	summarizer.Service,
 	error,
 ) {
 	if !viper.GetBool("summarizer.enable") {
+		for table, mode := range storageModes {
+			if mode == util.StorageModeTransient {
+				log.Warn().Str("table", table).Msg("Summarizer disabled; transient data will not be pruned")
+			}
+		}
 		return nil, nil
 	}
 
@@ -571,13 +1328,33 @@ func startSummarizer(
 		standardsummarizer.WithMonitor(monitor),
 		standardsummarizer.WithETH2Client(eth2Client),
 		standardsummarizer.WithChainTime(chainTime),
+		standardsummarizer.WithLoadShedder(loadShedder),
 		standardsummarizer.WithChainDB(chainDB),
 		standardsummarizer.WithEpochSummaries(viper.GetBool("summarizer.epochs.enable")),
 		standardsummarizer.WithBlockSummaries(viper.GetBool("summarizer.blocks.enable")),
 		standardsummarizer.WithValidatorSummaries(viper.GetBool("summarizer.validators.enable")),
+		standardsummarizer.WithValidatorRankings(viper.GetBool("summarizer.validators.rankings")),
+		standardsummarizer.WithCommitteeSummaries(viper.GetBool("summarizer.committees.enable")),
+		standardsummarizer.WithSyncPeriodSummaries(viper.GetBool("summarizer.sync-periods.enable")),
+		standardsummarizer.WithProposerSummaries(viper.GetBool("summarizer.proposers.enable")),
+		standardsummarizer.WithProposerSummaryWindows(viper.GetStringSlice("summarizer.proposers.windows")),
+		standardsummarizer.WithValidatorClusters(viper.GetBool("summarizer.clusters.enable")),
+		standardsummarizer.WithClusterFeeRecipients(viper.GetBool("summarizer.clusters.fee-recipients")),
+		standardsummarizer.WithBlobFees(viper.GetBool("summarizer.blob-fees.enable")),
+		standardsummarizer.WithBlobFeeWindow(viper.GetUint64("summarizer.blob-fees.window")),
+		standardsummarizer.WithBalanceAnomalies(viper.GetBool("summarizer.balance-anomalies.enable")),
+		standardsummarizer.WithBalanceAnomalyThreshold(viper.GetUint64("summarizer.balance-anomalies.threshold")),
+		standardsummarizer.WithBalanceAnomalyAlerts(viper.GetBool("summarizer.balance-anomalies.alert")),
+		standardsummarizer.WithMaxAttempts(viper.GetUint32("failed-items.max-attempts")),
 		standardsummarizer.WithMaxDaysPerRun(viper.GetUint64("summarizer.max-days-per-run")),
+		standardsummarizer.WithConcurrency(viper.GetUint64("summarizer.concurrency")),
+		standardsummarizer.WithStartEpoch(viper.GetInt64("summarizer.start-epoch")),
+		standardsummarizer.WithEndEpoch(viper.GetInt64("summarizer.end-epoch")),
 		standardsummarizer.WithValidatorEpochRetention(viper.GetString("summarizer.validators.epoch-retention")),
 		standardsummarizer.WithValidatorBalanceRetention(viper.GetString("summarizer.validators.balance-retention")),
+		standardsummarizer.WithTransientAttestations(storageModes[util.StorageTableAttestations] == util.StorageModeTransient),
+		standardsummarizer.WithTransientBeaconCommittees(storageModes[util.StorageTableBeaconCommittees] == util.StorageModeTransient),
+		standardsummarizer.WithTransientSyncAggregates(storageModes[util.StorageTableSyncAggregates] == util.StorageModeTransient),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create summarizer service")
@@ -591,6 +1368,7 @@ func startValidators(
 	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
 	chainTime chaintime.Service,
+	loadShedder loadshedder.Service,
 	monitor metrics.Service,
 ) error {
 	if !viper.GetBool("validators.enable") {
@@ -610,8 +1388,12 @@ func startValidators(
 		standardvalidators.WithMonitor(monitor),
 		standardvalidators.WithETH2Client(eth2Client),
 		standardvalidators.WithChainTime(chainTime),
+		standardvalidators.WithLoadShedder(loadShedder),
 		standardvalidators.WithChainDB(chainDB),
 		standardvalidators.WithBalances(viper.GetBool("validators.balances.enable")),
+		standardvalidators.WithStartEpoch(viper.GetInt64("validators.start-epoch")),
+		standardvalidators.WithEndEpoch(viper.GetInt64("validators.end-epoch")),
+		standardvalidators.WithShard(viper.GetInt64("validators.shard.from-index"), viper.GetInt64("validators.shard.to-index")),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to create validators service")
@@ -620,6 +1402,41 @@ func startValidators(
 	return nil
 }
 
+// startLoadShedder starts the load shedder.
+// Returns nil if load shedding is not enabled.
+func startLoadShedder(ctx context.Context,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) (
+	loadshedder.Service,
+	error,
+) {
+	if !viper.GetBool("load-shedding.enable") {
+		return nil, nil
+	}
+
+	busyWindows := make([]loadshedder.Window, 0)
+	for _, input := range viper.GetStringSlice("load-shedding.busy-windows") {
+		window, err := loadshedder.ParseWindow(input)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid busy window %q", input)
+		}
+		busyWindows = append(busyWindows, window)
+	}
+
+	loadShedder, err := standardloadshedder.New(ctx,
+		standardloadshedder.WithLogLevel(util.LogLevel("load-shedding")),
+		standardloadshedder.WithMonitor(monitor),
+		standardloadshedder.WithChainTime(chainTime),
+		standardloadshedder.WithBusyWindows(busyWindows),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadShedder, nil
+}
+
 func startBeaconCommittees(
 	ctx context.Context,
 	eth2Client eth2client.Service,
@@ -645,6 +1462,7 @@ func startBeaconCommittees(
 		standardbeaconcommittees.WithETH2Client(eth2Client),
 		standardbeaconcommittees.WithChainTime(chainTime),
 		standardbeaconcommittees.WithChainDB(chainDB),
+		standardbeaconcommittees.WithVerifyShuffling(viper.GetBool("beacon-committees.shuffling-verification.enable")),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to create beacon committees service")
@@ -704,6 +1522,7 @@ func startETH1Deposits(
 		getlogseth1deposits.WithStartBlock(viper.GetString("eth1deposits.start-block")),
 		getlogseth1deposits.WithETH1DepositsSetter(chainDB.(chaindb.ETH1DepositsSetter)),
 		getlogseth1deposits.WithETH1Confirmations(viper.GetUint64("eth1deposits.confirmations")),
+		getlogseth1deposits.WithReconciliationInterval(viper.GetDuration("eth1deposits.reconciliation-interval")),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to start Ethereum 1 deposits service")
@@ -712,6 +1531,656 @@ func startETH1Deposits(
 	return nil
 }
 
+func startArchiver(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	coldStore coldstore.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("archiver.enable") {
+		return nil
+	}
+	if coldStore == nil {
+		return errors.New("archiver requires a cold store")
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardarchiver.New(ctx,
+		standardarchiver.WithLogLevel(util.LogLevel("archiver")),
+		standardarchiver.WithMonitor(monitor),
+		standardarchiver.WithChainDB(chainDB),
+		standardarchiver.WithChainTime(chainTime),
+		standardarchiver.WithScheduler(scheduler),
+		standardarchiver.WithColdStore(coldStore),
+		standardarchiver.WithValidatorBalanceRetention(viper.GetString("archiver.validators.balance-retention")),
+		standardarchiver.WithMaxEpochsPerRun(viper.GetUint64("archiver.max-epochs-per-run")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create archiver service")
+	}
+
+	return nil
+}
+
+func startClientFingerprints(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("clientfingerprints.enable") {
+		return nil
+	}
+
+	var rules *standardclientfingerprints.RuleSet
+	if viper.GetString("clientfingerprints.rules") != "" {
+		data, err := os.ReadFile(resolvePath(viper.GetString("clientfingerprints.rules")))
+		if err != nil {
+			return errors.Wrap(err, "failed to read client fingerprint rules")
+		}
+		rules, err = standardclientfingerprints.ParseRules(data)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse client fingerprint rules")
+		}
+	}
+
+	var releases *standardclientfingerprints.ReleaseSet
+	if viper.GetString("clientfingerprints.releases") != "" {
+		data, err := os.ReadFile(resolvePath(viper.GetString("clientfingerprints.releases")))
+		if err != nil {
+			return errors.Wrap(err, "failed to read client releases")
+		}
+		releases, err = standardclientfingerprints.ParseReleases(data)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse client releases")
+		}
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardclientfingerprints.New(ctx,
+		standardclientfingerprints.WithLogLevel(util.LogLevel("clientfingerprints")),
+		standardclientfingerprints.WithMonitor(monitor),
+		standardclientfingerprints.WithChainDB(chainDB),
+		standardclientfingerprints.WithChainTime(chainTime),
+		standardclientfingerprints.WithScheduler(scheduler),
+		standardclientfingerprints.WithRules(rules),
+		standardclientfingerprints.WithReleases(releases),
+		standardclientfingerprints.WithMaxSlotsPerRun(viper.GetUint64("clientfingerprints.max-slots-per-run")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create client fingerprints service")
+	}
+
+	return nil
+}
+
+func startEquivocations(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("equivocations.enable") {
+		return nil
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardequivocations.New(ctx,
+		standardequivocations.WithLogLevel(util.LogLevel("equivocations")),
+		standardequivocations.WithMonitor(monitor),
+		standardequivocations.WithChainDB(chainDB),
+		standardequivocations.WithChainTime(chainTime),
+		standardequivocations.WithScheduler(scheduler),
+		standardequivocations.WithSurroundLookback(viper.GetUint64("equivocations.surround-lookback")),
+		standardequivocations.WithMaxEpochsPerRun(viper.GetUint64("equivocations.max-epochs-per-run")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create equivocations service")
+	}
+
+	return nil
+}
+
+func startWatchlist(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("watchlist.enable") {
+		return nil
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardwatchlist.New(ctx,
+		standardwatchlist.WithLogLevel(util.LogLevel("watchlist")),
+		standardwatchlist.WithMonitor(monitor),
+		standardwatchlist.WithChainDB(chainDB),
+		standardwatchlist.WithChainTime(chainTime),
+		standardwatchlist.WithScheduler(scheduler),
+		standardwatchlist.WithMaxEpochsPerRun(viper.GetUint64("watchlist.max-epochs-per-run")),
+		standardwatchlist.WithAttestationEffectivenessAlert(viper.GetFloat64("watchlist.alerts.attestation-effectiveness.threshold"),
+			viper.GetUint64("watchlist.alerts.attestation-effectiveness.epochs")),
+		standardwatchlist.WithMissedAttestationsAlert(viper.GetUint64("watchlist.alerts.missed-attestations")),
+		standardwatchlist.WithMissedProposalsAlert(viper.GetUint64("watchlist.alerts.missed-proposals")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create watchlist service")
+	}
+
+	return nil
+}
+
+func startVerifier(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("verifier.enable") {
+		return nil
+	}
+
+	address := viper.GetString("verifier.address")
+	if address == "" {
+		return errors.New("no verifier address specified")
+	}
+	if address == viper.GetString("eth2client.address") {
+		log.Warn().Msg("Verifier address is the same as the indexing beacon node; verification will not detect a faulty node")
+	}
+	eth2Client, err := fetchClient(ctx, address)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", address))
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardverifier.New(ctx,
+		standardverifier.WithLogLevel(util.LogLevel("verifier")),
+		standardverifier.WithMonitor(monitor),
+		standardverifier.WithETH2Client(eth2Client),
+		standardverifier.WithChainDB(chainDB),
+		standardverifier.WithChainTime(chainTime),
+		standardverifier.WithScheduler(scheduler),
+		standardverifier.WithStartSlot(viper.GetInt64("verifier.start-slot")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create verifier service")
+	}
+
+	return nil
+}
+
+func startReceipts(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("receipts.enable") {
+		return nil
+	}
+
+	address := viper.GetString("receipts.address")
+	if address == "" {
+		address = viper.GetString("eth1client.address")
+	}
+	if address == "" {
+		return errors.New("no receipts address specified")
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardreceipts.New(ctx,
+		standardreceipts.WithLogLevel(util.LogLevel("receipts")),
+		standardreceipts.WithMonitor(monitor),
+		standardreceipts.WithConnectionURL(address),
+		standardreceipts.WithTimeout(viper.GetDuration("receipts.timeout")),
+		standardreceipts.WithChainDB(chainDB),
+		standardreceipts.WithChainTime(chainTime),
+		standardreceipts.WithScheduler(scheduler),
+		standardreceipts.WithStartSlot(viper.GetInt64("receipts.start-slot")),
+		standardreceipts.WithEventAddresses(viper.GetStringSlice("receipts.events.addresses")),
+		standardreceipts.WithEventTopics(viper.GetStringSlice("receipts.events.topics")),
+		standardreceipts.WithRelays(viper.GetStringSlice("receipts.relays")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create receipts service")
+	}
+
+	return nil
+}
+
+func startExporter(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("exporter.enable") {
+		return nil
+	}
+
+	dataWarehouse, err := startWarehouse(ctx)
+	if err != nil {
+		return err
+	}
+	if dataWarehouse == nil {
+		return errors.New("exporter requires a data warehouse")
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardexporter.New(ctx,
+		standardexporter.WithLogLevel(util.LogLevel("exporter")),
+		standardexporter.WithMonitor(monitor),
+		standardexporter.WithChainDB(chainDB),
+		standardexporter.WithChainTime(chainTime),
+		standardexporter.WithScheduler(scheduler),
+		standardexporter.WithWarehouse(dataWarehouse),
+		standardexporter.WithTables(viper.GetStringSlice("exporter.tables")),
+		standardexporter.WithInterval(viper.GetDuration("exporter.interval")),
+		standardexporter.WithMarksPerBatch(viper.GetUint32("exporter.marks-per-batch")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create exporter service")
+	}
+
+	return nil
+}
+
+// startWarehouse starts the data warehouse, if configured.
+func startWarehouse(ctx context.Context) (warehouse.Service, error) {
+	switch {
+	case viper.GetString("warehouse.bigquery.project") != "":
+		log.Trace().Msg("Starting BigQuery warehouse")
+		credentialsFile := viper.GetString("warehouse.bigquery.credentials-file")
+		if credentialsFile != "" {
+			credentialsFile = util.ResolvePath(credentialsFile)
+		}
+		dataWarehouse, err := bigquerywarehouse.New(ctx,
+			bigquerywarehouse.WithLogLevel(util.LogLevel("warehouse")),
+			bigquerywarehouse.WithProject(viper.GetString("warehouse.bigquery.project")),
+			bigquerywarehouse.WithDataset(viper.GetString("warehouse.bigquery.dataset")),
+			bigquerywarehouse.WithCredentialsFile(credentialsFile),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to start BigQuery warehouse")
+		}
+		return dataWarehouse, nil
+	case viper.GetString("warehouse.postgresql.url") != "":
+		log.Trace().Msg("Starting PostgreSQL warehouse")
+		dataWarehouse, err := postgresqlwarehouse.New(ctx,
+			postgresqlwarehouse.WithLogLevel(util.LogLevel("warehouse")),
+			postgresqlwarehouse.WithURL(viper.GetString("warehouse.postgresql.url")),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to start PostgreSQL warehouse")
+		}
+		return dataWarehouse, nil
+	default:
+		log.Debug().Msg("No data warehouse configured")
+		return nil, nil
+	}
+}
+
+func startOutbox(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("outbox.enable") {
+		// Stop capturing changes from any previous run, as they would otherwise accumulate.
+		if outboxSetter, isOutboxSetter := chainDB.(chaindb.OutboxSetter); isOutboxSetter {
+			ctx, cancel, err := chainDB.BeginTx(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to begin transaction")
+			}
+			if err := outboxSetter.SetOutboxCapture(ctx, []string{}); err != nil {
+				cancel()
+				return errors.Wrap(err, "failed to stop capture of changes")
+			}
+			if err := chainDB.CommitTx(ctx); err != nil {
+				cancel()
+				return errors.Wrap(err, "failed to commit transaction")
+			}
+		}
+		return nil
+	}
+
+	publishers, err := startPublishers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(publishers) == 0 {
+		return errors.New("outbox requires at least one publisher")
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardoutbox.New(ctx,
+		standardoutbox.WithLogLevel(util.LogLevel("outbox")),
+		standardoutbox.WithMonitor(monitor),
+		standardoutbox.WithChainDB(chainDB),
+		standardoutbox.WithScheduler(scheduler),
+		standardoutbox.WithPublishers(publishers),
+		standardoutbox.WithTables(viper.GetStringSlice("outbox.tables")),
+		standardoutbox.WithInterval(viper.GetDuration("outbox.interval")),
+		standardoutbox.WithBatchSize(viper.GetUint32("outbox.batch-size")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create outbox service")
+	}
+
+	return nil
+}
+
+// startPublishers starts the publishers that are configured.
+func startPublishers(ctx context.Context) ([]publisher.Service, error) {
+	publishers := make([]publisher.Service, 0)
+
+	if viper.GetString("publishers.kafka.url") != "" {
+		log.Trace().Msg("Starting Kafka publisher")
+		kafkaPublisher, err := kafkapublisher.New(ctx,
+			kafkapublisher.WithLogLevel(util.LogLevel("publishers.kafka")),
+			kafkapublisher.WithURL(viper.GetString("publishers.kafka.url")),
+			kafkapublisher.WithTopic(viper.GetString("publishers.kafka.topic")),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to start Kafka publisher")
+		}
+		publishers = append(publishers, kafkaPublisher)
+	}
+
+	if viper.GetString("publishers.nats.url") != "" {
+		log.Trace().Msg("Starting NATS publisher")
+		params := []natspublisher.Parameter{
+			natspublisher.WithLogLevel(util.LogLevel("publishers.nats")),
+			natspublisher.WithURL(viper.GetString("publishers.nats.url")),
+		}
+		if viper.GetString("publishers.nats.subject") != "" {
+			params = append(params, natspublisher.WithSubject(viper.GetString("publishers.nats.subject")))
+		}
+		if viper.GetString("publishers.nats.subject-layout") != "" {
+			params = append(params, natspublisher.WithSubjectLayout(viper.GetString("publishers.nats.subject-layout")))
+		}
+		if viper.GetString("publishers.nats.serialization") != "" {
+			params = append(params, natspublisher.WithSerialization(viper.GetString("publishers.nats.serialization")))
+		}
+		params = append(params, natspublisher.WithJetStream(viper.GetBool("publishers.nats.jetstream")))
+		natsPublisher, err := natspublisher.New(ctx, params...)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to start NATS publisher")
+		}
+		publishers = append(publishers, natsPublisher)
+	}
+
+	if viper.GetString("publishers.webhook.url") != "" {
+		log.Trace().Msg("Starting webhook publisher")
+		webhookPublisher, err := webhookpublisher.New(ctx,
+			webhookpublisher.WithLogLevel(util.LogLevel("publishers.webhook")),
+			webhookpublisher.WithURL(viper.GetString("publishers.webhook.url")),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to start webhook publisher")
+		}
+		publishers = append(publishers, webhookPublisher)
+	}
+
+	return publishers, nil
+}
+
+func startIndexManager(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+) error {
+	if !viper.GetBool("indexmanager.enable") {
+		return nil
+	}
+	if viper.GetBool("chaindb.external-schema") {
+		return errors.New("index manager cannot drop or create indexes in an externally managed schema")
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardindexmanager.New(ctx,
+		standardindexmanager.WithLogLevel(util.LogLevel("indexmanager")),
+		standardindexmanager.WithChainDB(chainDB),
+		standardindexmanager.WithChainTime(chainTime),
+		standardindexmanager.WithScheduler(scheduler),
+		standardindexmanager.WithMaxSlotLag(viper.GetUint64("indexmanager.max-slot-lag")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create index manager service")
+	}
+
+	return nil
+}
+
+func startMaintenance(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("maintenance.enable") {
+		return nil
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardmaintenance.New(ctx,
+		standardmaintenance.WithLogLevel(util.LogLevel("maintenance")),
+		standardmaintenance.WithMonitor(monitor),
+		standardmaintenance.WithChainDB(chainDB),
+		standardmaintenance.WithChainTime(chainTime),
+		standardmaintenance.WithScheduler(scheduler),
+		standardmaintenance.WithMaxSlotLag(viper.GetUint64("maintenance.max-slot-lag")),
+		standardmaintenance.WithInterval(viper.GetDuration("maintenance.interval")),
+		standardmaintenance.WithAutomatic(viper.GetBool("maintenance.automatic")),
+		standardmaintenance.WithMinDeadTuples(viper.GetInt64("maintenance.min-dead-tuples")),
+		standardmaintenance.WithDeadTupleRatio(viper.GetFloat64("maintenance.dead-tuple-ratio")),
+		standardmaintenance.WithAnalyzeRatio(viper.GetFloat64("maintenance.analyze-ratio")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create maintenance service")
+	}
+
+	return nil
+}
+
+func startGossip(
+	ctx context.Context,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("gossip.enable") {
+		return nil
+	}
+
+	_, err := standardgossip.New(ctx,
+		standardgossip.WithLogLevel(util.LogLevel("gossip")),
+		standardgossip.WithMonitor(monitor),
+		standardgossip.WithETH2Client(eth2Client),
+		standardgossip.WithChainDB(chainDB),
+		standardgossip.WithChainTime(chainTime),
+		standardgossip.WithAttestations(viper.GetBool("gossip.attestations")),
+		standardgossip.WithFlushInterval(viper.GetDuration("gossip.flush-interval")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create gossip service")
+	}
+
+	return nil
+}
+
+func startProofs(
+	ctx context.Context,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+) error {
+	if !viper.GetBool("proofs.enable") {
+		return nil
+	}
+
+	if viper.GetString("proofs.listen-address") == "" {
+		return errors.New("no proofs listen address specified")
+	}
+
+	var err error
+	if viper.GetString("proofs.address") != "" {
+		eth2Client, err = fetchClient(ctx, viper.GetString("proofs.address"))
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("proofs.address")))
+		}
+	}
+
+	_, err = standardproofs.New(ctx,
+		standardproofs.WithLogLevel(util.LogLevel("proofs")),
+		standardproofs.WithETH2Client(eth2Client),
+		standardproofs.WithChainDB(chainDB),
+		standardproofs.WithChainTime(chainTime),
+		standardproofs.WithListenAddress(viper.GetString("proofs.listen-address")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create proofs service")
+	}
+
+	return nil
+}
+
+func startQueues(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+) error {
+	if !viper.GetBool("queues.enable") {
+		return nil
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardqueues.New(ctx,
+		standardqueues.WithLogLevel(util.LogLevel("queues")),
+		standardqueues.WithChainDB(chainDB),
+		standardqueues.WithChainTime(chainTime),
+		standardqueues.WithScheduler(scheduler),
+		standardqueues.WithListenAddress(viper.GetString("queues.listen-address")),
+		standardqueues.WithSnapshotInterval(viper.GetUint64("queues.snapshot-interval")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create queues service")
+	}
+
+	return nil
+}
+
+func startHeads(
+	ctx context.Context,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("heads.enable") {
+		return nil
+	}
+
+	var err error
+	if viper.GetString("heads.address") != "" {
+		eth2Client, err = fetchClient(ctx, viper.GetString("heads.address"))
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("heads.address")))
+		}
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardheads.New(ctx,
+		standardheads.WithLogLevel(util.LogLevel("heads")),
+		standardheads.WithMonitor(monitor),
+		standardheads.WithETH2Client(eth2Client),
+		standardheads.WithChainDB(chainDB),
+		standardheads.WithChainTime(chainTime),
+		standardheads.WithScheduler(scheduler),
+		standardheads.WithOffset(viper.GetDuration("heads.offset")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create heads service")
+	}
+
+	return nil
+}
+
 func startSyncCommittees(
 	ctx context.Context,
 	eth2Client eth2client.Service,
@@ -0,0 +1,70 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugins provides an extension point for custom indexers, which
+// receive data from chaind's modules and store their own tables in the same
+// transactions as chaind.
+package plugins
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Indexer is a custom indexer.  An indexer receives data by also
+// implementing one or more of BlockIndexer and FinalityIndexer.
+type Indexer interface {
+	// Name provides the name of the indexer.
+	Name() string
+}
+
+// Initializer is implemented by indexers that prepare the database when chaind
+// starts, for example by creating their tables.
+type Initializer interface {
+	// Init initializes the indexer.
+	Init(ctx context.Context, tx pgx.Tx) error
+}
+
+// BlockIndexer is implemented by indexers that handle blocks.
+type BlockIndexer interface {
+	// OnBlock is called when the blocks module stores a block, after the block
+	// and its contents have been stored and in the same transaction.
+	// Returning an error prevents the block from being stored.
+	OnBlock(ctx context.Context, tx pgx.Tx, block *Block) error
+}
+
+// FinalityIndexer is implemented by indexers that handle finality updates.
+type FinalityIndexer interface {
+	// OnFinalityUpdated is called when finality has been updated in the
+	// database.  Each call has its own transaction, which is rolled back if
+	// an error is returned.
+	OnFinalityUpdated(ctx context.Context, tx pgx.Tx, epoch phase0.Epoch) error
+}
+
+// Block is a block passed to indexers.
+type Block struct {
+	// SignedBlock is the block as obtained from the beacon node.
+	SignedBlock *spec.VersionedSignedBeaconBlock
+	// Block is the block as stored in the database.
+	Block *chaindb.Block
+}
+
+// TxProvider provides the database transaction held in a context.
+type TxProvider interface {
+	// Tx provides the transaction held in the context; nil if no transaction.
+	Tx(ctx context.Context) pgx.Tx
+}
@@ -0,0 +1,80 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugins
+
+import (
+	"fmt"
+	"plugin"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// NewIndexerSymbol is the symbol that Go plugins export to provide their indexer.
+// It must be a function of type func() (plugins.Indexer, error).
+const NewIndexerSymbol = "NewIndexer"
+
+var (
+	registryMu sync.Mutex
+	registry   []Indexer
+)
+
+// Register registers an indexer compiled in to chaind, usually from the init()
+// function of its package.  It panics if an indexer with the same name is
+// already registered.
+func Register(indexer Indexer) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, registered := range registry {
+		if registered.Name() == indexer.Name() {
+			panic(fmt.Sprintf("indexer %s registered twice", indexer.Name()))
+		}
+	}
+	registry = append(registry, indexer)
+}
+
+// Registered provides the registered indexers, in order of registration.
+func Registered() []Indexer {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	return append([]Indexer{}, registry...)
+}
+
+// Load loads indexers from Go plugins at the given paths.
+func Load(paths []string) ([]Indexer, error) {
+	indexers := make([]Indexer, 0, len(paths))
+	for _, path := range paths {
+		p, err := plugin.Open(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open plugin %s", path)
+		}
+		sym, err := p.Lookup(NewIndexerSymbol)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find %s in plugin %s", NewIndexerSymbol, path)
+		}
+		newIndexer, isNewIndexer := sym.(func() (Indexer, error))
+		if !isNewIndexer {
+			return nil, fmt.Errorf("%s in plugin %s is not a func() (plugins.Indexer, error)", NewIndexerSymbol, path)
+		}
+		indexer, err := newIndexer()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create indexer from plugin %s", path)
+		}
+		indexers = append(indexers, indexer)
+	}
+
+	return indexers, nil
+}
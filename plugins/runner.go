@@ -0,0 +1,185 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugins
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Runner passes data to indexers.
+type Runner struct {
+	log              zerolog.Logger
+	chainDB          chaindb.Service
+	txProvider       TxProvider
+	blockIndexers    []namedBlockIndexer
+	finalityIndexers []namedFinalityIndexer
+}
+
+type namedBlockIndexer struct {
+	name    string
+	indexer BlockIndexer
+}
+
+type namedFinalityIndexer struct {
+	name    string
+	indexer FinalityIndexer
+}
+
+// NewRunner creates a runner for the given indexers, initializing them.
+func NewRunner(ctx context.Context,
+	chainDB chaindb.Service,
+	indexers []Indexer,
+	logLevel zerolog.Level,
+) (
+	*Runner,
+	error,
+) {
+	txProvider, isTxProvider := chainDB.(TxProvider)
+	if !isTxProvider {
+		return nil, errors.New("chain DB does not provide transactions to plugins")
+	}
+
+	r := &Runner{
+		log:        util.ServiceLogger("plugins", "standard", logLevel),
+		chainDB:    chainDB,
+		txProvider: txProvider,
+	}
+
+	names := make(map[string]bool)
+	initializers := make([]Initializer, 0)
+	initializerNames := make([]string, 0)
+	for _, indexer := range indexers {
+		name := indexer.Name()
+		if names[name] {
+			return nil, fmt.Errorf("multiple indexers named %s", name)
+		}
+		names[name] = true
+
+		handled := false
+		if initializer, isInitializer := indexer.(Initializer); isInitializer {
+			initializers = append(initializers, initializer)
+			initializerNames = append(initializerNames, name)
+		}
+		if blockIndexer, isBlockIndexer := indexer.(BlockIndexer); isBlockIndexer {
+			r.blockIndexers = append(r.blockIndexers, namedBlockIndexer{name: name, indexer: blockIndexer})
+			handled = true
+		}
+		if finalityIndexer, isFinalityIndexer := indexer.(FinalityIndexer); isFinalityIndexer {
+			r.finalityIndexers = append(r.finalityIndexers, namedFinalityIndexer{name: name, indexer: finalityIndexer})
+			handled = true
+		}
+		if !handled {
+			r.log.Warn().Str("indexer", name).Msg("Indexer does not handle any data")
+		}
+	}
+
+	if len(initializers) > 0 {
+		ctx, cancel, err := chainDB.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx := txProvider.Tx(ctx)
+		for i, initializer := range initializers {
+			if err := initializer.Init(ctx, tx); err != nil {
+				cancel()
+				return nil, errors.Wrapf(err, "failed to initialize indexer %s", initializerNames[i])
+			}
+		}
+		if err := chainDB.CommitTx(ctx); err != nil {
+			cancel()
+			return nil, errors.Wrap(err, "failed to commit transaction")
+		}
+	}
+
+	for _, indexer := range indexers {
+		r.log.Info().Str("indexer", indexer.Name()).Msg("Indexer started")
+	}
+
+	return r, nil
+}
+
+// OnBlock passes a stored block to the block indexers.
+// This requires the context to hold an active transaction.
+func (r *Runner) OnBlock(ctx context.Context, block *Block) error {
+	if len(r.blockIndexers) == 0 {
+		return nil
+	}
+
+	tx := r.txProvider.Tx(ctx)
+	if tx == nil {
+		return errors.New("no transaction for plugins")
+	}
+
+	for _, blockIndexer := range r.blockIndexers {
+		ctx, span := otel.Tracer("wealdtech.chaind.plugins").Start(ctx, "OnBlock",
+			trace.WithAttributes(
+				attribute.String("indexer", blockIndexer.name),
+			))
+		err := blockIndexer.indexer.OnBlock(ctx, tx, block)
+		span.End()
+		if err != nil {
+			return errors.Wrapf(err, "indexer %s failed to handle block", blockIndexer.name)
+		}
+	}
+
+	return nil
+}
+
+// OnFinalityUpdated passes a finality update to the finality indexers.
+func (r *Runner) OnFinalityUpdated(ctx context.Context, epoch phase0.Epoch) {
+	for _, finalityIndexer := range r.finalityIndexers {
+		if err := r.onFinalityUpdated(ctx, finalityIndexer, epoch); err != nil {
+			r.log.Error().Str("indexer", finalityIndexer.name).Uint64("epoch", uint64(epoch)).Err(err).Msg("Indexer failed to handle finality update")
+		}
+	}
+}
+
+func (r *Runner) onFinalityUpdated(ctx context.Context, finalityIndexer namedFinalityIndexer, epoch phase0.Epoch) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.plugins").Start(ctx, "OnFinalityUpdated",
+		trace.WithAttributes(
+			attribute.String("indexer", finalityIndexer.name),
+			attribute.Int64("epoch", int64(epoch)),
+		))
+	defer span.End()
+
+	ctx, cancel, err := r.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := finalityIndexer.indexer.OnFinalityUpdated(ctx, r.txProvider.Tx(ctx), epoch); err != nil {
+		cancel()
+		return err
+	}
+	if err := r.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// HasFinalityIndexers returns true if any indexers handle finality updates.
+func (r *Runner) HasFinalityIndexers() bool {
+	return len(r.finalityIndexers) > 0
+}
@@ -0,0 +1,150 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugins_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/plugins"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+)
+
+// txChainDB is a mock chain database that provides a transaction to plugins.
+type txChainDB struct {
+	chaindb.Service
+	tx pgx.Tx
+}
+
+func (s *txChainDB) Tx(_ context.Context) pgx.Tx {
+	return s.tx
+}
+
+// testTx is a transaction; its methods are not called.
+type testTx struct {
+	pgx.Tx
+}
+
+type testIndexer struct {
+	name      string
+	calls     *[]string
+	err       error
+	initCalls int
+}
+
+func (i *testIndexer) Name() string {
+	return i.name
+}
+
+func (i *testIndexer) Init(_ context.Context, tx pgx.Tx) error {
+	if tx == nil {
+		return errors.New("no transaction")
+	}
+	i.initCalls++
+
+	return nil
+}
+
+func (i *testIndexer) OnBlock(_ context.Context, tx pgx.Tx, _ *plugins.Block) error {
+	if tx == nil {
+		return errors.New("no transaction")
+	}
+	*i.calls = append(*i.calls, i.name)
+
+	return i.err
+}
+
+type testFinalityIndexer struct {
+	epochs []phase0.Epoch
+}
+
+func (*testFinalityIndexer) Name() string {
+	return "finality"
+}
+
+func (i *testFinalityIndexer) OnFinalityUpdated(_ context.Context, _ pgx.Tx, epoch phase0.Epoch) error {
+	i.epochs = append(i.epochs, epoch)
+
+	return nil
+}
+
+func TestNewRunner(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := plugins.NewRunner(ctx, mockchaindb.New(), nil, zerolog.Disabled)
+	require.EqualError(t, err, "chain DB does not provide transactions to plugins")
+
+	chainDB := &txChainDB{Service: mockchaindb.New(), tx: &testTx{}}
+	calls := make([]string, 0)
+	_, err = plugins.NewRunner(ctx, chainDB, []plugins.Indexer{
+		&testIndexer{name: "a", calls: &calls},
+		&testIndexer{name: "a", calls: &calls},
+	}, zerolog.Disabled)
+	require.EqualError(t, err, "multiple indexers named a")
+
+	indexer := &testIndexer{name: "a", calls: &calls}
+	runner, err := plugins.NewRunner(ctx, chainDB, []plugins.Indexer{indexer}, zerolog.Disabled)
+	require.NoError(t, err)
+	require.Equal(t, 1, indexer.initCalls)
+	require.False(t, runner.HasFinalityIndexers())
+}
+
+func TestOnBlock(t *testing.T) {
+	ctx := context.Background()
+	chainDB := &txChainDB{Service: mockchaindb.New(), tx: &testTx{}}
+	block := &plugins.Block{Block: &chaindb.Block{Slot: 1}}
+
+	calls := make([]string, 0)
+	runner, err := plugins.NewRunner(ctx, chainDB, []plugins.Indexer{
+		&testIndexer{name: "a", calls: &calls},
+		&testIndexer{name: "b", calls: &calls, err: errors.New("mock error")},
+		&testIndexer{name: "c", calls: &calls},
+	}, zerolog.Disabled)
+	require.NoError(t, err)
+
+	require.EqualError(t, runner.OnBlock(ctx, block), "indexer b failed to handle block: mock error")
+	require.Equal(t, []string{"a", "b"}, calls)
+
+	chainDB.tx = nil
+	require.EqualError(t, runner.OnBlock(ctx, block), "no transaction for plugins")
+}
+
+func TestOnFinalityUpdated(t *testing.T) {
+	ctx := context.Background()
+	chainDB := &txChainDB{Service: mockchaindb.New(), tx: &testTx{}}
+
+	indexer := &testFinalityIndexer{}
+	runner, err := plugins.NewRunner(ctx, chainDB, []plugins.Indexer{indexer}, zerolog.Disabled)
+	require.NoError(t, err)
+	require.True(t, runner.HasFinalityIndexers())
+
+	runner.OnFinalityUpdated(ctx, 5)
+	runner.OnFinalityUpdated(ctx, 6)
+	require.Equal(t, []phase0.Epoch{5, 6}, indexer.epochs)
+}
+
+func TestRegister(t *testing.T) {
+	calls := make([]string, 0)
+	plugins.Register(&testIndexer{name: "registered", calls: &calls})
+	require.Len(t, plugins.Registered(), 1)
+	require.Panics(t, func() {
+		plugins.Register(&testIndexer{name: "registered", calls: &calls})
+	})
+}
@@ -0,0 +1,85 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	standardblocks "github.com/wealdtech/chaind/services/blocks/standard"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// runReindex repopulates the data derived from blocks for a range of slots,
+// without using a beacon node.
+func runReindex(ctx context.Context) error {
+	if !viper.GetBool("from-archive") {
+		return errors.New("no source specified; --from-archive is required")
+	}
+	startSlot, endSlot, err := util.ParseSlotRange(viper.GetString("slots"))
+	if err != nil {
+		return errors.Wrap(err, "invalid slots")
+	}
+
+	coldStore, err := startColdStore(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to start cold store")
+	}
+	chainDB, err := startDatabase(ctx, coldStore)
+	if err != nil {
+		return err
+	}
+	if db, isPostgreSQL := chainDB.(*postgresqlchaindb.Service); isPostgreSQL {
+		if err := checkSchemaVersion(ctx, db); err != nil {
+			return err
+		}
+	}
+
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(util.LogLevel("chaintime")),
+		standardchaintime.WithGenesisProvider(chainDB.(eth2client.GenesisProvider)),
+		standardchaintime.WithSpecProvider(chainDB.(eth2client.SpecProvider)),
+		standardchaintime.WithForkScheduleProvider(chainDB.(eth2client.ForkScheduleProvider)),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to start chain time service")
+	}
+
+	// Without a client the blocks module uses only data in the database, and
+	// leaves blob sidecars as they are.
+	blocks, err := standardblocks.New(ctx,
+		standardblocks.WithLogLevel(util.LogLevel("blocks")),
+		standardblocks.WithChainTime(chainTime),
+		standardblocks.WithChainDB(chainDB),
+		standardblocks.WithBlobSidecars(false),
+		standardblocks.WithCatchup(false),
+		standardblocks.WithActivitySem(semaphore.NewWeighted(1)),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create blocks service")
+	}
+
+	log.Info().Uint64("start_slot", uint64(startSlot)).Uint64("end_slot", uint64(endSlot)).Msg("Reindexing from raw blocks")
+	if err := blocks.Reprocess(ctx, startSlot, endSlot); err != nil {
+		return err
+	}
+	log.Info().Msg("Reindexed from raw blocks")
+
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	standardblocks "github.com/wealdtech/chaind/services/blocks/standard"
+	"github.com/wealdtech/chaind/services/chaindb"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// runReprocessBlocks replays stored raw blocks, updating the data derived from them.
+func runReprocessBlocks(ctx context.Context) error {
+	coldStore, err := startColdStore(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to start cold store")
+	}
+	chainDB, err := startDatabase(ctx, coldStore)
+	if err != nil {
+		return err
+	}
+	if db, isPostgreSQL := chainDB.(*postgresqlchaindb.Service); isPostgreSQL {
+		if err := checkSchemaVersion(ctx, db); err != nil {
+			return err
+		}
+	}
+
+	if viper.GetInt64("reprocess-blocks.start-slot") < 0 {
+		return errors.New("no start slot specified")
+	}
+	startSlot := phase0.Slot(viper.GetInt64("reprocess-blocks.start-slot"))
+	var endSlot phase0.Slot
+	if viper.GetInt64("reprocess-blocks.end-slot") >= 0 {
+		endSlot = phase0.Slot(viper.GetInt64("reprocess-blocks.end-slot"))
+	} else {
+		rawBlocks, err := chainDB.(chaindb.RawBlocksProvider).RawBlocks(ctx, &chaindb.RawBlockFilter{
+			Limit: 1,
+			Order: chaindb.OrderLatest,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain latest raw block")
+		}
+		if len(rawBlocks) == 0 {
+			return errors.New("no raw blocks stored")
+		}
+		endSlot = rawBlocks[0].Slot
+	}
+
+	address := viper.GetString("blocks.address")
+	if address == "" {
+		address = viper.GetString("eth2client.address")
+	}
+	eth2Client, err := fetchClient(ctx, address)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", address))
+	}
+
+	storageModes, err := util.StorageModes(viper.GetString("storage.profile"), viper.GetStringMapString("storage.tables"))
+	if err != nil {
+		return errors.Wrap(err, "invalid storage configuration")
+	}
+
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(util.LogLevel("chaintime")),
+		standardchaintime.WithGenesisProvider(eth2Client.(eth2client.GenesisProvider)),
+		standardchaintime.WithSpecProvider(eth2Client.(eth2client.SpecProvider)),
+		standardchaintime.WithForkScheduleProvider(eth2Client.(eth2client.ForkScheduleProvider)),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to start chain time service")
+	}
+
+	blocks, err := standardblocks.New(ctx,
+		standardblocks.WithLogLevel(util.LogLevel("blocks")),
+		standardblocks.WithETH2Client(eth2Client),
+		standardblocks.WithChainTime(chainTime),
+		standardblocks.WithChainDB(chainDB),
+		standardblocks.WithBlobSidecars(storageModes[util.StorageTableBlobSidecars] != util.StorageModeNone),
+		standardblocks.WithCatchup(false),
+		standardblocks.WithActivitySem(semaphore.NewWeighted(1)),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create blocks service")
+	}
+
+	log.Info().Uint64("start_slot", uint64(startSlot)).Uint64("end_slot", uint64(endSlot)).Msg("Reprocessing raw blocks")
+	if err := blocks.Reprocess(ctx, startSlot, endSlot); err != nil {
+		return err
+	}
+	log.Info().Msg("Reprocessed raw blocks")
+
+	return nil
+}
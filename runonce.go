@@ -0,0 +1,200 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/status"
+)
+
+// Exit codes for run-once mode.
+const (
+	runOnceExitCaughtUp    = 0
+	runOnceExitFailed      = 1
+	runOnceExitTimedOut    = 2
+	runOnceExitInterrupted = 3
+)
+
+// runOnceCheckInterval is the interval between checks of progress in run-once mode.
+const runOnceCheckInterval = 30 * time.Second
+
+// runOnceCheck is a progress item that must catch up in run-once mode.
+type runOnceCheck struct {
+	service string
+	item    string
+	// enable are the configuration values that must all be true for the item to be checked.
+	enable []string
+	// targetService and targetItem, if present, provide the target for items that
+	// do not have one of their own.
+	targetService string
+	targetItem    string
+}
+
+// runOnceChecks are the progress items that must catch up in run-once mode.  Modules that
+// run continuously, such as gossip capture and the outbox, are not checked.
+var runOnceChecks = []*runOnceCheck{
+	{service: "blocks", item: "latest_slot", enable: []string{"blocks.enable"}},
+	{service: "finalizer", item: "latest_epoch", enable: []string{"finalizer.enable"}},
+	{service: "finalizer", item: "latest_checkpoint_epoch", enable: []string{"finalizer.enable", "finalizer.checkpoints.enable"}},
+	{service: "finalizer", item: "latest_randao_epoch", enable: []string{"finalizer.enable", "finalizer.randao.enable"}},
+	{service: "summarizer", item: "latest_epoch", enable: []string{"summarizer.enable", "summarizer.epochs.enable"}},
+	{service: "summarizer", item: "latest_block_epoch", enable: []string{"summarizer.enable", "summarizer.blocks.enable"}},
+	{service: "summarizer", item: "latest_validator_epoch", enable: []string{"summarizer.enable", "summarizer.validators.enable"}},
+	{service: "validators", item: "latest_epoch", enable: []string{"validators.enable"}},
+	{service: "validators", item: "latest_balances_epoch", enable: []string{"validators.enable", "validators.balances.enable"}},
+	{service: "beacon-committees", item: "latest_epoch", enable: []string{"beacon-committees.enable"}},
+	{service: "proposer-duties", item: "latest_epoch", enable: []string{"proposer-duties.enable"}},
+	{service: "sync-committees", item: "latest_period", enable: []string{"sync-committees.enable"}},
+	{service: "client-fingerprints", item: "latest_slot", enable: []string{"clientfingerprints.enable"}},
+	{service: "equivocations", item: "latest_epoch", enable: []string{"equivocations.enable"}},
+	{service: "watchlist", item: "latest_epoch", enable: []string{"watchlist.enable"}},
+	{
+		service:       "verifier",
+		item:          "latest_slot",
+		enable:        []string{"verifier.enable", "finalizer.enable"},
+		targetService: "finalizer",
+		targetItem:    "latest_canonical_slot",
+	},
+	{
+		service:       "receipts",
+		item:          "latest_slot",
+		enable:        []string{"receipts.enable", "finalizer.enable"},
+		targetService: "finalizer",
+		targetItem:    "latest_canonical_slot",
+	},
+}
+
+// runOnce waits for the enabled modules to catch up with the chain, and returns the exit code.
+func runOnce(ctx context.Context, statusSvc status.Service, leadershipLost <-chan struct{}) int {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(sigCh)
+
+	return awaitCaughtUp(ctx, statusSvc, runOnceCheckInterval, sigCh, leadershipLost)
+}
+
+// awaitCaughtUp checks progress at the given interval until the enabled modules have caught up,
+// or until it is timed out, interrupted or leadership is lost, and returns the exit code.
+func awaitCaughtUp(ctx context.Context,
+	statusSvc status.Service,
+	interval time.Duration,
+	sigCh <-chan os.Signal,
+	leadershipLost <-chan struct{},
+) int {
+	timeout := viper.GetDuration("run-once-timeout")
+	maxGap := viper.GetInt64("run-once-max-gap")
+	log.Info().Dur("timeout", timeout).Int64("max_gap", maxGap).Msg("Running once; waiting for modules to catch up")
+
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timeoutCh = time.After(timeout)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-timeoutCh:
+			log.Error().Msg("Timed out waiting for modules to catch up")
+			return runOnceExitTimedOut
+		case <-sigCh:
+			log.Info().Msg("Interrupted waiting for modules to catch up")
+			return runOnceExitInterrupted
+		case <-ctx.Done():
+			log.Info().Msg("Context done waiting for modules to catch up")
+			return runOnceExitInterrupted
+		case <-leadershipLost:
+			// Another instance will take over writing to the database.
+			log.Error().Msg("Leadership lost waiting for modules to catch up")
+			return runOnceExitFailed
+		}
+
+		st, err := statusSvc.Status(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to obtain status")
+			return runOnceExitFailed
+		}
+		outstanding := runOnceOutstanding(st, maxGap)
+		if len(outstanding) == 0 {
+			log.Info().Msg("All modules caught up")
+			return runOnceExitCaughtUp
+		}
+		log.Debug().Strs("outstanding", outstanding).Msg("Modules not yet caught up")
+	}
+}
+
+// runOnceOutstanding returns the progress items that have yet to catch up.
+func runOnceOutstanding(st *status.Status, maxGap int64) []string {
+	progress := make(map[string]*status.Progress)
+	pendingGaps := make(map[string]int)
+	for _, serviceStatus := range st.Services {
+		for _, item := range serviceStatus.Progress {
+			progress[fmt.Sprintf("%s/%s", serviceStatus.Name, item.Name)] = item
+		}
+		pendingGaps[serviceStatus.Name] = serviceStatus.PendingGaps
+	}
+
+	outstanding := make([]string, 0)
+	for _, check := range runOnceChecks {
+		if !runOnceCheckEnabled(check) {
+			continue
+		}
+		key := fmt.Sprintf("%s/%s", check.service, check.item)
+		item, exists := progress[key]
+		if !exists || item.Latest == nil {
+			// Nothing processed yet.
+			outstanding = append(outstanding, key)
+			continue
+		}
+		if pendingGaps[check.service] > 0 {
+			outstanding = append(outstanding, key)
+			continue
+		}
+
+		gap := item.Gap
+		if check.targetService != "" {
+			target, exists := progress[fmt.Sprintf("%s/%s", check.targetService, check.targetItem)]
+			if !exists || target.Latest == nil {
+				outstanding = append(outstanding, key)
+				continue
+			}
+			targetGap := *target.Latest - *item.Latest
+			gap = &targetGap
+		}
+		if gap != nil && *gap > maxGap {
+			outstanding = append(outstanding, key)
+		}
+	}
+
+	return outstanding
+}
+
+// runOnceCheckEnabled returns true if the module for a check is enabled.
+func runOnceCheckEnabled(check *runOnceCheck) bool {
+	for _, enable := range check.enable {
+		if !viper.GetBool(enable) {
+			return false
+		}
+	}
+
+	return true
+}
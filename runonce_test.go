@@ -0,0 +1,359 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/status"
+)
+
+// runOnceStatus is a status service that returns each of its statuses in turn,
+// repeating the last one when they run out.
+type runOnceStatus struct {
+	mu       sync.Mutex
+	statuses []*status.Status
+	err      error
+	calls    int
+}
+
+func (s *runOnceStatus) Status(_ context.Context) (*status.Status, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	st := s.statuses[0]
+	if len(s.statuses) > 1 {
+		s.statuses = s.statuses[1:]
+	}
+
+	return st, nil
+}
+
+// setRunOnceConfig sets configuration values for the duration of a test.
+func setRunOnceConfig(t *testing.T, config map[string]any) {
+	t.Helper()
+	for key, val := range config {
+		viper.Set(key, val)
+	}
+	t.Cleanup(func() {
+		for key := range config {
+			viper.Set(key, nil)
+		}
+	})
+}
+
+func progressStatus(name string, pendingGaps int, items ...*status.Progress) *status.ServiceStatus {
+	return &status.ServiceStatus{
+		Name:        name,
+		Progress:    items,
+		PendingGaps: pendingGaps,
+	}
+}
+
+func progressItem(name string, latest int64, gap int64) *status.Progress {
+	return &status.Progress{
+		Name:   name,
+		Latest: &latest,
+		Gap:    &gap,
+	}
+}
+
+// latestItem is a progress item without a target of its own.
+func latestItem(name string, latest int64) *status.Progress {
+	return &status.Progress{
+		Name:   name,
+		Latest: &latest,
+	}
+}
+
+func TestRunOnceOutstanding(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]any
+		status   *status.Status
+		expected []string
+	}{
+		{
+			name:   "NoneEnabled",
+			status: &status.Status{},
+		},
+		{
+			name:     "NotStarted",
+			config:   map[string]any{"blocks.enable": true},
+			status:   &status.Status{},
+			expected: []string{"blocks/latest_slot"},
+		},
+		{
+			name:   "NoLatest",
+			config: map[string]any{"blocks.enable": true},
+			status: &status.Status{
+				Services: []*status.ServiceStatus{
+					progressStatus("blocks", 0, &status.Progress{Name: "latest_slot"}),
+				},
+			},
+			expected: []string{"blocks/latest_slot"},
+		},
+		{
+			name:   "WithinMaxGap",
+			config: map[string]any{"blocks.enable": true},
+			status: &status.Status{
+				Services: []*status.ServiceStatus{
+					progressStatus("blocks", 0, progressItem("latest_slot", 100, 2)),
+				},
+			},
+		},
+		{
+			name:   "BeyondMaxGap",
+			config: map[string]any{"blocks.enable": true},
+			status: &status.Status{
+				Services: []*status.ServiceStatus{
+					progressStatus("blocks", 0, progressItem("latest_slot", 100, 3)),
+				},
+			},
+			expected: []string{"blocks/latest_slot"},
+		},
+		{
+			name:   "PendingGaps",
+			config: map[string]any{"blocks.enable": true},
+			status: &status.Status{
+				Services: []*status.ServiceStatus{
+					progressStatus("blocks", 1, progressItem("latest_slot", 100, 0)),
+				},
+			},
+			expected: []string{"blocks/latest_slot"},
+		},
+		{
+			name: "SubModuleDisabled",
+			config: map[string]any{
+				"finalizer.enable":             true,
+				"finalizer.checkpoints.enable": false,
+			},
+			status: &status.Status{
+				Services: []*status.ServiceStatus{
+					progressStatus("finalizer", 0, progressItem("latest_epoch", 10, 0)),
+				},
+			},
+		},
+		{
+			name: "SubModuleEnabled",
+			config: map[string]any{
+				"finalizer.enable":             true,
+				"finalizer.checkpoints.enable": true,
+			},
+			status: &status.Status{
+				Services: []*status.ServiceStatus{
+					progressStatus("finalizer", 0, progressItem("latest_epoch", 10, 0)),
+				},
+			},
+			expected: []string{"finalizer/latest_checkpoint_epoch"},
+		},
+		{
+			name: "TargetBehind",
+			config: map[string]any{
+				"finalizer.enable": true,
+				"verifier.enable":  true,
+			},
+			status: &status.Status{
+				Services: []*status.ServiceStatus{
+					progressStatus("finalizer", 0,
+						progressItem("latest_epoch", 10, 0),
+						latestItem("latest_canonical_slot", 320),
+					),
+					progressStatus("verifier", 0, latestItem("latest_slot", 300)),
+				},
+			},
+			expected: []string{"verifier/latest_slot"},
+		},
+		{
+			name: "TargetReached",
+			config: map[string]any{
+				"finalizer.enable": true,
+				"verifier.enable":  true,
+			},
+			status: &status.Status{
+				Services: []*status.ServiceStatus{
+					progressStatus("finalizer", 0,
+						progressItem("latest_epoch", 10, 0),
+						latestItem("latest_canonical_slot", 320),
+					),
+					progressStatus("verifier", 0, latestItem("latest_slot", 319)),
+				},
+			},
+		},
+		{
+			name: "TargetMissing",
+			config: map[string]any{
+				"finalizer.enable": true,
+				"verifier.enable":  true,
+			},
+			status: &status.Status{
+				Services: []*status.ServiceStatus{
+					progressStatus("finalizer", 0, progressItem("latest_epoch", 10, 0)),
+					progressStatus("verifier", 0, latestItem("latest_slot", 319)),
+				},
+			},
+			expected: []string{"verifier/latest_slot"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setRunOnceConfig(t, test.config)
+			outstanding := runOnceOutstanding(test.status, 2)
+			if test.expected == nil {
+				require.Empty(t, outstanding)
+			} else {
+				require.Equal(t, test.expected, outstanding)
+			}
+		})
+	}
+}
+
+func TestAwaitCaughtUp(t *testing.T) {
+	behind := &status.Status{
+		Services: []*status.ServiceStatus{
+			progressStatus("blocks", 0, progressItem("latest_slot", 90, 10)),
+		},
+	}
+	caughtUp := &status.Status{
+		Services: []*status.ServiceStatus{
+			progressStatus("blocks", 0, progressItem("latest_slot", 100, 0)),
+		},
+	}
+
+	tests := []struct {
+		name           string
+		timeout        time.Duration
+		statusSvc      *runOnceStatus
+		signal         bool
+		cancel         bool
+		leadershipLost bool
+		expected       int
+		minCalls       int
+	}{
+		{
+			name:      "CaughtUp",
+			statusSvc: &runOnceStatus{statuses: []*status.Status{behind, behind, caughtUp}},
+			expected:  runOnceExitCaughtUp,
+			minCalls:  3,
+		},
+		{
+			name:      "StatusFailed",
+			statusSvc: &runOnceStatus{err: errors.New("database unavailable")},
+			expected:  runOnceExitFailed,
+			minCalls:  1,
+		},
+		{
+			name:      "TimedOut",
+			timeout:   50 * time.Millisecond,
+			statusSvc: &runOnceStatus{statuses: []*status.Status{behind}},
+			expected:  runOnceExitTimedOut,
+		},
+		{
+			name:      "Interrupted",
+			statusSvc: &runOnceStatus{statuses: []*status.Status{behind}},
+			signal:    true,
+			expected:  runOnceExitInterrupted,
+		},
+		{
+			name:      "ContextDone",
+			statusSvc: &runOnceStatus{statuses: []*status.Status{behind}},
+			cancel:    true,
+			expected:  runOnceExitInterrupted,
+		},
+		{
+			name:           "LeadershipLost",
+			statusSvc:      &runOnceStatus{statuses: []*status.Status{behind}},
+			leadershipLost: true,
+			expected:       runOnceExitFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setRunOnceConfig(t, map[string]any{
+				"blocks.enable":    true,
+				"run-once-timeout": test.timeout,
+				"run-once-max-gap": int64(2),
+			})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sigCh := make(chan os.Signal, 1)
+			leadershipLost := make(chan struct{})
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				if test.signal {
+					sigCh <- syscall.SIGTERM
+				}
+				if test.cancel {
+					cancel()
+				}
+				if test.leadershipLost {
+					close(leadershipLost)
+				}
+			}()
+
+			exitCode := awaitCaughtUp(ctx, test.statusSvc, 5*time.Millisecond, sigCh, leadershipLost)
+			require.Equal(t, test.expected, exitCode)
+			require.GreaterOrEqual(t, test.statusSvc.calls, test.minCalls)
+		})
+	}
+}
+
+func TestAwaitCaughtUpKeepsRunning(t *testing.T) {
+	setRunOnceConfig(t, map[string]any{
+		"blocks.enable":    true,
+		"run-once-timeout": time.Duration(0),
+		"run-once-max-gap": int64(2),
+	})
+	statusSvc := &runOnceStatus{
+		statuses: []*status.Status{
+			{
+				Services: []*status.ServiceStatus{
+					progressStatus("blocks", 0, progressItem("latest_slot", 90, 10)),
+				},
+			},
+		},
+	}
+
+	// Without a timeout the wait continues for as long as modules are behind.
+	done := make(chan int, 1)
+	sigCh := make(chan os.Signal, 1)
+	go func() {
+		done <- awaitCaughtUp(context.Background(), statusSvc, 5*time.Millisecond, sigCh, nil)
+	}()
+	select {
+	case exitCode := <-done:
+		require.Fail(t, "exited while modules behind", "exit code %d", exitCode)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	sigCh <- syscall.SIGINT
+	require.Equal(t, runOnceExitInterrupted, <-done)
+	statusSvc.mu.Lock()
+	defer statusSvc.mu.Unlock()
+	require.Greater(t, statusSvc.calls, 1)
+}
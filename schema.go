@@ -0,0 +1,179 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+// runSchema runs a schema command.
+func runSchema(ctx context.Context, command string) error {
+	chainDB, err := startDatabase(ctx, nil)
+	if err != nil {
+		return err
+	}
+	db, isPostgreSQL := chainDB.(*postgresqlchaindb.Service)
+	if !isPostgreSQL {
+		return errors.New("chain database does not support schema management")
+	}
+
+	switch command {
+	case "", "info":
+		return printSchemaInfo(ctx, db)
+	case "migrate":
+		return migrateSchema(ctx, db)
+	case "migrations":
+		return printSchemaMigrations(ctx, db)
+	case "drop-indexes":
+		return db.DropSecondaryIndexes(ctx)
+	case "create-indexes":
+		return db.CreateSecondaryIndexes(ctx)
+	case "print":
+		return printSchemaStatements(ctx, db)
+	default:
+		return fmt.Errorf("unknown schema command %q; supported commands are info, migrate, migrations, drop-indexes, create-indexes and print", command)
+	}
+}
+
+// printSchemaInfo prints the version and history of the schema.
+func printSchemaInfo(ctx context.Context, db *postgresqlchaindb.Service) error {
+	version, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain schema version")
+	}
+	fmt.Printf("Schema version: %d\n", version)
+	fmt.Printf("Latest version: %d\n", db.LatestSchemaVersion())
+
+	history, err := db.SchemaHistory(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain schema history")
+	}
+	if len(history) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "TIMESTAMP\tFROM\tTO")
+	for _, change := range history {
+		fmt.Fprintf(writer, "%s\t%d\t%d\n", change.Timestamp.Format(time.RFC3339), change.FromVersion, change.ToVersion)
+	}
+	writer.Flush()
+
+	return nil
+}
+
+// printSchemaMigrations prints the steps run when migrating the schema.
+func printSchemaMigrations(ctx context.Context, db *postgresqlchaindb.Service) error {
+	steps, err := db.MigrationSteps(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain migration steps")
+	}
+	if len(steps) == 0 {
+		fmt.Println("No migration steps recorded")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "STARTED\tVERSION\tSTEP\tFUNCTION\tDIRECTION\tDURATION\tSTATUS\tERROR")
+	for _, step := range steps {
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			step.Started.Format(time.RFC3339),
+			step.Version,
+			step.Step,
+			step.Function,
+			step.Direction,
+			step.Finished.Sub(step.Started).Round(time.Millisecond),
+			step.Status,
+			step.Error,
+		)
+	}
+	writer.Flush()
+
+	return nil
+}
+
+// migrateSchema migrates the schema to the configured version.
+func migrateSchema(ctx context.Context, db *postgresqlchaindb.Service) error {
+	res, err := db.Migrate(ctx, &postgresqlchaindb.MigrateOpts{
+		TargetVersion: viper.GetUint64("schema.target-version"),
+		DryRun:        viper.GetBool("schema.dry-run"),
+	})
+	if err != nil {
+		return err
+	}
+
+	if res.FromVersion == res.ToVersion {
+		fmt.Printf("Schema is already at version %d\n", res.ToVersion)
+		return nil
+	}
+
+	if viper.GetBool("schema.dry-run") {
+		fmt.Printf("-- Migration from version %d to version %d (dry run; not applied)\n", res.FromVersion, res.ToVersion)
+		for _, statement := range res.Statements {
+			fmt.Printf("%s;\n\n", statement)
+		}
+		return nil
+	}
+
+	fmt.Printf("Migrated schema from version %d to version %d\n", res.FromVersion, res.ToVersion)
+	if res.RequiresRefetch {
+		fmt.Println("This migration requires blocks to be refetched; run chaind with --blocks.start-slot=0 --blocks.refetch=true")
+	}
+
+	return nil
+}
+
+// printSchemaStatements prints the statements for the configured version of the
+// schema, for application outside of chaind.
+func printSchemaStatements(ctx context.Context, db *postgresqlchaindb.Service) error {
+	version := viper.GetUint64("schema.version")
+	statements, err := db.SchemaStatements(ctx, version)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain schema statements")
+	}
+
+	if version == 0 {
+		fmt.Printf("-- Creation of version %d\n", db.LatestSchemaVersion())
+	} else {
+		fmt.Printf("-- Upgrade from version %d to version %d\n", version-1, version)
+	}
+	for _, statement := range statements {
+		fmt.Printf("%s;\n\n", strings.TrimSuffix(statement, ";"))
+	}
+
+	return nil
+}
+
+// checkSchemaVersion returns an error if the schema requires upgrading.
+func checkSchemaVersion(ctx context.Context, db *postgresqlchaindb.Service) error {
+	version, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain schema version")
+	}
+	if version < db.LatestSchemaVersion() {
+		return fmt.Errorf("database schema version %d requires upgrading to version %d; run \"chaind schema migrate\" or enable chaindb.auto-upgrade", version, db.LatestSchemaVersion())
+	}
+
+	return nil
+}
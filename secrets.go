@@ -0,0 +1,95 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	majordomo "github.com/wealdtech/go-majordomo"
+)
+
+// secrets fetches the values of configuration items that can be held in a secret store.
+var secrets majordomo.Service
+
+// isSecretReference returns true if a configuration value refers to a secret store,
+// rather than being the value itself.
+func isSecretReference(value string) bool {
+	return strings.Contains(value, "://")
+}
+
+// fetchSecret fetches the value of a configuration item, which is either the value
+// itself or a reference to a secret store.
+func fetchSecret(ctx context.Context, source string) (string, error) {
+	value, err := secrets.Fetch(ctx, source)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to fetch secret")
+	}
+
+	// Secrets held in files commonly have a trailing newline.
+	return strings.TrimSpace(string(value)), nil
+}
+
+// rotatingSecret is a secret that is fetched again once its refresh interval has
+// passed, so that rotated values are picked up without a restart.
+type rotatingSecret struct {
+	source          string
+	refreshInterval time.Duration
+	mu              sync.Mutex
+	value           string
+	fetched         time.Time
+}
+
+// newRotatingSecret creates a rotating secret, fetching its initial value.
+func newRotatingSecret(ctx context.Context, source string, refreshInterval time.Duration) (*rotatingSecret, error) {
+	s := &rotatingSecret{
+		source:          source,
+		refreshInterval: refreshInterval,
+	}
+	if _, err := s.Value(ctx); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Value provides the current value of the secret.  If the secret cannot be fetched
+// again the previous value is returned, as it may still be valid.
+func (s *rotatingSecret) Value(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.fetched.IsZero() && time.Since(s.fetched) < s.refreshInterval {
+		return s.value, nil
+	}
+
+	value, err := fetchSecret(ctx, s.source)
+	if err != nil {
+		if s.fetched.IsZero() {
+			return "", err
+		}
+		log.Warn().Err(err).Msg("Failed to refresh secret; using previous value")
+		return s.value, nil
+	}
+	if !s.fetched.IsZero() && value != s.value {
+		log.Info().Msg("Secret has been rotated")
+	}
+	s.value = value
+	s.fetched = time.Now()
+
+	return s.value, nil
+}
@@ -0,0 +1,135 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/coldstore"
+)
+
+// archive offloads data that is past its retention period to cold storage.
+func (s *Service) archive(ctx context.Context) {
+	// Only allow 1 archive to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another archive running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	if err := s.archiveValidatorBalances(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to archive validator balances")
+	}
+}
+
+// archiveValidatorBalances offloads validator balances that are past their retention period.
+func (s *Service) archiveValidatorBalances(ctx context.Context) error {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	archiveTime := s.validatorBalanceRetention.Decrement(time.Now())
+	if archiveTime.Before(s.chainTime.GenesisTime()) {
+		log.Trace().Time("archive_time", archiveTime).Msg("Retention period starts before genesis; nothing to archive")
+		return nil
+	}
+	// Archive all epochs that finished before the archive time.
+	targetEpoch := s.chainTime.TimestampToEpoch(archiveTime)
+	log.Trace().Stringer("retention", s.validatorBalanceRetention).Time("archive_time", archiveTime).Uint64("target_epoch", uint64(targetEpoch)).Msg("Archive parameters for balances")
+
+	archived := uint64(0)
+	for epoch := phase0.Epoch(md.LatestValidatorBalanceEpoch + 1); epoch < targetEpoch; epoch++ {
+		if archived == s.maxEpochsPerRun {
+			log.Trace().Uint64("epoch", uint64(epoch)).Msg("Reached maximum epochs for this run")
+			break
+		}
+		rows, err := s.archiveValidatorBalancesForEpoch(ctx, md, epoch)
+		if err != nil {
+			return errors.Wrapf(err, "failed to archive validator balances for epoch %d", epoch)
+		}
+		// Count every epoch visited, including those without balances, so that runs are bounded.
+		archived++
+		monitorValidatorBalancesArchived(epoch, rows)
+	}
+
+	return nil
+}
+
+// archiveValidatorBalancesForEpoch offloads the validator balances for a single epoch, returning the
+// number of balances offloaded.
+func (s *Service) archiveValidatorBalancesForEpoch(ctx context.Context, md *metadata, epoch phase0.Epoch) (int, error) {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to begin transaction")
+	}
+
+	// Removal and offload happen in the same transaction, so if the offload fails the balances remain in the database.
+	balances, err := s.balancesArchiver.RemoveValidatorBalancesByEpoch(ctx, epoch)
+	if err != nil {
+		cancel()
+		return 0, errors.Wrap(err, "failed to remove validator balances")
+	}
+
+	if len(balances) > 0 {
+		// Balances are stored in partitions by validator index, so that reads for a few validators
+		// do not need to load the balances of every validator.
+		key := fmt.Sprintf("validator_balances/%d", epoch)
+		partitions := coldstore.PartitionByValidator(balances, func(balance *chaindb.ValidatorBalance) phase0.ValidatorIndex {
+			return balance.Index
+		})
+		for partition, partitionBalances := range partitions {
+			data, err := coldstore.MarshalJSONL(partitionBalances)
+			if err != nil {
+				cancel()
+				return 0, errors.Wrap(err, "failed to encode validator balances")
+			}
+			if err := s.coldStore.Put(ctx, coldstore.PartitionKey(key, uint64(partition)), data); err != nil {
+				cancel()
+				return 0, errors.Wrap(err, "failed to store validator balances")
+			}
+		}
+		if err := s.offloadsSetter.SetArchiveOffload(ctx, &chaindb.ArchiveOffload{
+			Table:     "t_validator_balances",
+			Epoch:     epoch,
+			Key:       key,
+			Location:  s.coldStore.Location(key),
+			Rows:      uint64(len(balances)),
+			Timestamp: time.Now(),
+		}); err != nil {
+			cancel()
+			return 0, errors.Wrap(err, "failed to set archive offload")
+		}
+		log.Trace().Uint64("epoch", uint64(epoch)).Int("rows", len(balances)).Str("location", s.coldStore.Location(key)).Msg("Archived validator balances")
+	}
+
+	md.LatestValidatorBalanceEpoch = int64(epoch)
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return 0, errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return 0, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return len(balances), nil
+}
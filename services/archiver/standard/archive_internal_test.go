@@ -0,0 +1,151 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/chaintime"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	"github.com/wealdtech/chaind/services/coldstore"
+	"github.com/wealdtech/chaind/services/coldstore/file"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// archiveDB holds validator balances and progress for archive tests.
+type archiveDB struct {
+	chaindb.Service
+	balances map[phase0.Epoch][]*chaindb.ValidatorBalance
+	offloads []*chaindb.ArchiveOffload
+	latest   int64
+}
+
+func (d *archiveDB) RemoveValidatorBalancesByEpoch(_ context.Context, epoch phase0.Epoch) ([]*chaindb.ValidatorBalance, error) {
+	balances := d.balances[epoch]
+	delete(d.balances, epoch)
+
+	return balances, nil
+}
+
+func (d *archiveDB) SetArchiveOffload(_ context.Context, offload *chaindb.ArchiveOffload) error {
+	d.offloads = append(d.offloads, offload)
+
+	return nil
+}
+
+func (d *archiveDB) SetProgress(_ context.Context, _ string, _ string, value int64) error {
+	d.latest = value
+
+	return nil
+}
+
+func (d *archiveDB) Progress(_ context.Context, service string) (*chaindb.Progress, error) {
+	return &chaindb.Progress{
+		Service: service,
+		Values: map[string]int64{
+			"latest_validator_balance_epoch": d.latest,
+		},
+	}, nil
+}
+
+// archiveChainTime places every timestamp in a fixed epoch.
+type archiveChainTime struct {
+	chaintime.Service
+	epoch phase0.Epoch
+}
+
+func (c *archiveChainTime) TimestampToEpoch(_ time.Time) phase0.Epoch {
+	return c.epoch
+}
+
+func TestArchiveValidatorBalances(t *testing.T) {
+	ctx := context.Background()
+	log = zerolog.Nop()
+
+	coldStore, err := file.New(ctx,
+		file.WithLogLevel(zerolog.Disabled),
+		file.WithBaseDir(t.TempDir()),
+	)
+	require.NoError(t, err)
+
+	retention, err := util.ParseCalendarDuration("P1D")
+	require.NoError(t, err)
+
+	// Only epochs 1 and 4 have balances; the others are empty or already pruned.
+	chainDB := &archiveDB{
+		Service: mockchaindb.New(),
+		balances: map[phase0.Epoch][]*chaindb.ValidatorBalance{
+			1: {
+				{Index: 1, Epoch: 1, Balance: 1},
+				{Index: coldstore.ValidatorPartitionSize, Epoch: 1, Balance: 2},
+			},
+			4: {
+				{Index: 2, Epoch: 4, Balance: 3},
+			},
+		},
+		latest: -1,
+	}
+	s := &Service{
+		chainDB:                   chainDB,
+		chainTime:                 &archiveChainTime{Service: mockchaintime.New(), epoch: 10},
+		coldStore:                 coldStore,
+		balancesArchiver:          chainDB,
+		offloadsSetter:            chainDB,
+		validatorBalanceRetention: retention,
+		maxEpochsPerRun:           3,
+		activitySem:               semaphore.NewWeighted(1),
+	}
+
+	// The first run visits epochs 0-2, including empty epochs in its limit.
+	require.NoError(t, s.archiveValidatorBalances(ctx))
+	require.Equal(t, int64(2), chainDB.latest)
+	require.Len(t, chainDB.offloads, 1)
+	require.Equal(t, phase0.Epoch(1), chainDB.offloads[0].Epoch)
+	require.Equal(t, "validator_balances/1", chainDB.offloads[0].Key)
+	require.Equal(t, uint64(2), chainDB.offloads[0].Rows)
+
+	// Each validator partition is stored separately.
+	for partition, expected := range [][]*chaindb.ValidatorBalance{
+		{{Index: 1, Epoch: 1, Balance: 1}},
+		{{Index: coldstore.ValidatorPartitionSize, Epoch: 1, Balance: 2}},
+	} {
+		data, err := coldStore.Get(ctx, coldstore.PartitionKey("validator_balances/1", uint64(partition)))
+		require.NoError(t, err)
+		balances, err := coldstore.UnmarshalJSONL[*chaindb.ValidatorBalance](data)
+		require.NoError(t, err)
+		require.Equal(t, expected, balances)
+	}
+	_, err = coldStore.Get(ctx, coldstore.PartitionKey("validator_balances/1", 2))
+	require.ErrorIs(t, err, coldstore.ErrNotFound)
+
+	// The second run picks up from where the first stopped.
+	require.NoError(t, s.archiveValidatorBalances(ctx))
+	require.Equal(t, int64(5), chainDB.latest)
+	require.Len(t, chainDB.offloads, 2)
+	require.Equal(t, phase0.Epoch(4), chainDB.offloads[1].Epoch)
+
+	// Subsequent runs stop before the target epoch.
+	require.NoError(t, s.archiveValidatorBalances(ctx))
+	require.NoError(t, s.archiveValidatorBalances(ctx))
+	require.Equal(t, int64(9), chainDB.latest)
+	require.Len(t, chainDB.offloads, 2)
+}
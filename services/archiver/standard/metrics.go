@@ -0,0 +1,76 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_archiver"
+
+var (
+	latestBalanceEpoch prometheus.Gauge
+	rowsArchived       *prometheus.CounterVec
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if latestBalanceEpoch != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	latestBalanceEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "latest_balance_epoch",
+		Help:      "Latest epoch of validator balances archived",
+	})
+	if err := prometheus.Register(latestBalanceEpoch); err != nil {
+		return errors.Wrap(err, "failed to register latest_balance_epoch")
+	}
+
+	rowsArchived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rows_archived_total",
+		Help:      "Number of rows archived to cold storage",
+	}, []string{"table"})
+	if err := prometheus.Register(rowsArchived); err != nil {
+		return errors.Wrap(err, "failed to register rows_archived_total")
+	}
+
+	return nil
+}
+
+func monitorValidatorBalancesArchived(epoch phase0.Epoch, rows int) {
+	if latestBalanceEpoch != nil {
+		latestBalanceEpoch.Set(float64(epoch))
+	}
+	if rowsArchived != nil {
+		rowsArchived.WithLabelValues("t_validator_balances").Add(float64(rows))
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/coldstore"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+type parameters struct {
+	logLevel                  zerolog.Level
+	monitor                   metrics.Service
+	chainDB                   chaindb.Service
+	chainTime                 chaintime.Service
+	scheduler                 scheduler.Service
+	coldStore                 coldstore.Service
+	validatorBalanceRetention string
+	maxEpochsPerRun           uint64
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(p *parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithScheduler sets the scheduler for this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
+// WithColdStore sets the cold store to which data is offloaded.
+func WithColdStore(coldStore coldstore.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.coldStore = coldStore
+	})
+}
+
+// WithValidatorBalanceRetention provides the amount of validator balance data to retain in the database.
+func WithValidatorBalanceRetention(retention string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorBalanceRetention = retention
+	})
+}
+
+// WithMaxEpochsPerRun sets the maximum number of epochs to archive in a single run.
+func WithMaxEpochsPerRun(maxEpochsPerRun uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxEpochsPerRun = maxEpochsPerRun
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:        zerolog.GlobalLevel(),
+		maxEpochsPerRun: 225,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified")
+	}
+	if parameters.coldStore == nil {
+		return nil, errors.New("no cold store specified")
+	}
+	if parameters.validatorBalanceRetention == "" {
+		return nil, errors.New("no validator balance retention specified")
+	}
+	if parameters.maxEpochsPerRun == 0 {
+		return nil, errors.New("max epochs per run must be greater than 0")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,102 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/coldstore"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// Service is an archiver service.
+type Service struct {
+	chainDB                   chaindb.Service
+	chainTime                 chaintime.Service
+	coldStore                 coldstore.Service
+	balancesArchiver          chaindb.ValidatorBalancesArchiver
+	offloadsSetter            chaindb.ArchiveOffloadsSetter
+	validatorBalanceRetention *util.CalendarDuration
+	maxEpochsPerRun           uint64
+	activitySem               *semaphore.Weighted
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("archiver", "standard", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	balancesArchiver, isBalancesArchiver := parameters.chainDB.(chaindb.ValidatorBalancesArchiver)
+	if !isBalancesArchiver {
+		return nil, errors.New("chain DB does not support validator balance archiving")
+	}
+
+	offloadsSetter, isOffloadsSetter := parameters.chainDB.(chaindb.ArchiveOffloadsSetter)
+	if !isOffloadsSetter {
+		return nil, errors.New("chain DB does not support archive offload setting")
+	}
+
+	validatorBalanceRetention, err := util.ParseCalendarDuration(parameters.validatorBalanceRetention)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse validator balance retention")
+	}
+
+	s := &Service{
+		chainDB:                   parameters.chainDB,
+		chainTime:                 parameters.chainTime,
+		coldStore:                 parameters.coldStore,
+		balancesArchiver:          balancesArchiver,
+		offloadsSetter:            offloadsSetter,
+		validatorBalanceRetention: validatorBalanceRetention,
+		maxEpochsPerRun:           parameters.maxEpochsPerRun,
+		activitySem:               semaphore.NewWeighted(1),
+	}
+
+	// Archive once per epoch.
+	runtimeFunc := func(ctx context.Context, data any) (time.Time, error) {
+		return s.chainTime.StartOfEpoch(s.chainTime.CurrentEpoch() + 1), nil
+	}
+	jobFunc := func(ctx context.Context, data any) {
+		s := data.(*Service)
+		s.archive(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "archiver", "archive",
+		runtimeFunc,
+		nil,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic archive")
+	}
+
+	return s, nil
+}
@@ -0,0 +1,145 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/archiver/standard"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	"github.com/wealdtech/chaind/services/coldstore/file"
+	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	chainDB := mockchaindb.New()
+	chainTime := mockchaintime.New()
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(zerolog.Disabled),
+	)
+	require.NoError(t, err)
+
+	coldStore, err := file.New(ctx,
+		file.WithLogLevel(zerolog.Disabled),
+		file.WithBaseDir(t.TempDir()),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+				standard.WithColdStore(coldStore),
+				standard.WithValidatorBalanceRetention("P1Y"),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "ChainTimeMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(scheduler),
+				standard.WithColdStore(coldStore),
+				standard.WithValidatorBalanceRetention("P1Y"),
+			},
+			err: "problem with parameters: no chain time specified",
+		},
+		{
+			name: "SchedulerMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithColdStore(coldStore),
+				standard.WithValidatorBalanceRetention("P1Y"),
+			},
+			err: "problem with parameters: no scheduler specified",
+		},
+		{
+			name: "ColdStoreMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+				standard.WithValidatorBalanceRetention("P1Y"),
+			},
+			err: "problem with parameters: no cold store specified",
+		},
+		{
+			name: "RetentionMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+				standard.WithColdStore(coldStore),
+			},
+			err: "problem with parameters: no validator balance retention specified",
+		},
+		{
+			name: "MaxEpochsPerRunZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+				standard.WithColdStore(coldStore),
+				standard.WithValidatorBalanceRetention("P1Y"),
+				standard.WithMaxEpochsPerRun(0),
+			},
+			err: "problem with parameters: max epochs per run must be greater than 0",
+		},
+		{
+			name: "ChainDBNotArchiver",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				// Expose only the base service, so the chain database cannot archive.
+				standard.WithChainDB(struct{ chaindb.Service }{chainDB}),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+				standard.WithColdStore(coldStore),
+				standard.WithValidatorBalanceRetention("P1Y"),
+			},
+			err: "chain DB does not support validator balance archiving",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
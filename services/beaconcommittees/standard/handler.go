@@ -69,6 +69,9 @@ func (s *Service) OnBeaconChainHeadUpdated(
 	}
 
 	s.catchup(ctx, md)
+	if s.verifyShuffling {
+		s.verifyShufflings(ctx, md)
+	}
 }
 
 // catchup is the general-purpose catchup system.
@@ -15,45 +15,76 @@ package standard
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/pkg/errors"
 )
 
 // metadata stored about this service.
 type metadata struct {
-	LatestEpoch int64 `json:"latest_epoch"`
+	LatestEpoch         int64
+	LatestVerifiedEpoch int64
 }
 
-// metadataKey is the key for the metadata.
-var metadataKey = "beaconcommittees.standard"
+// progressService is the name of this service for progress.
+var progressService = "beaconcommittees.standard"
+
+// validatorsProgressService is the name of the validators service for progress.
+var validatorsProgressService = "validators.standard"
 
 // getMetadata gets metadata for this service.
 func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 	md := &metadata{
-		LatestEpoch: -1,
+		LatestEpoch:         -1,
+		LatestVerifiedEpoch: -1,
 	}
-	mdJSON, err := s.chainDB.Metadata(ctx, metadataKey)
+	progress, err := s.chainDB.Progress(ctx, progressService)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to fetch metadata")
+		return nil, errors.Wrap(err, "failed to fetch progress")
 	}
-	if mdJSON == nil {
+	if progress == nil {
 		return md, nil
 	}
-	if err := json.Unmarshal(mdJSON, md); err != nil {
-		return nil, errors.Wrap(err, "failed to unmarshal metadata")
+	if val, exists := progress.Values["latest_epoch"]; exists {
+		md.LatestEpoch = val
+	}
+	if val, exists := progress.Values["latest_verified_epoch"]; exists {
+		md.LatestVerifiedEpoch = val
 	}
 	return md, nil
 }
 
 // setMetadata sets metadata for this service.
 func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
-	mdJSON, err := json.Marshal(md)
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal metadata")
+	if err := s.chainDB.SetProgress(ctx, progressService, "latest_epoch", md.LatestEpoch); err != nil {
+		return errors.Wrap(err, "failed to update latest epoch")
 	}
-	if err := s.chainDB.SetMetadata(ctx, metadataKey, mdJSON); err != nil {
-		return errors.Wrap(err, "failed to update metadata")
+	if s.verifyShuffling {
+		if err := s.chainDB.SetProgress(ctx, progressService, "latest_verified_epoch", md.LatestVerifiedEpoch); err != nil {
+			return errors.Wrap(err, "failed to update latest verified epoch")
+		}
 	}
 	return nil
 }
+
+// validatorsEpoch returns the latest epoch for which the validators module has
+// updated validators, or -1 if it has not updated any.
+func (s *Service) validatorsEpoch(ctx context.Context) (int64, error) {
+	return s.progressValue(ctx, validatorsProgressService, "latest_epoch")
+}
+
+// progressValue returns a progress value of another service, or -1 if it is not present.
+func (s *Service) progressValue(ctx context.Context, service string, key string) (int64, error) {
+	progress, err := s.chainDB.Progress(ctx, service)
+	if err != nil {
+		return -1, errors.Wrapf(err, "failed to fetch %s progress", service)
+	}
+	if progress == nil {
+		return -1, nil
+	}
+	val, exists := progress.Values[key]
+	if !exists {
+		return -1, nil
+	}
+
+	return val, nil
+}
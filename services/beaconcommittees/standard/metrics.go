@@ -25,9 +25,11 @@ import (
 var metricsNamespace = "chaind_beaconcommittees"
 
 var (
-	highestEpoch    phase0.Epoch
-	latestEpoch     prometheus.Gauge
-	epochsProcessed prometheus.Gauge
+	highestEpoch        phase0.Epoch
+	latestEpoch         prometheus.Gauge
+	epochsProcessed     prometheus.Gauge
+	latestVerifiedEpoch prometheus.Gauge
+	shufflingMismatches prometheus.Counter
 )
 
 func registerMetrics(_ context.Context, monitor metrics.Service) error {
@@ -65,6 +67,24 @@ func registerPrometheusMetrics() error {
 		return errors.Wrap(err, "failed to register epochs_processed")
 	}
 
+	latestVerifiedEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "latest_verified_epoch",
+		Help:      "Latest epoch for which the shuffling was verified",
+	})
+	if err := prometheus.Register(latestVerifiedEpoch); err != nil {
+		return errors.Wrap(err, "failed to register latest_verified_epoch")
+	}
+
+	shufflingMismatches = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "shuffling_mismatches_total",
+		Help:      "Number of beacon committees that differ from the locally calculated shuffling",
+	})
+	if err := prometheus.Register(shufflingMismatches); err != nil {
+		return errors.Wrap(err, "failed to register shuffling_mismatches_total")
+	}
+
 	return nil
 }
 
@@ -86,3 +106,15 @@ func monitorEpochProcessed(epoch phase0.Epoch) {
 		}
 	}
 }
+
+func monitorEpochVerified(epoch phase0.Epoch) {
+	if latestVerifiedEpoch != nil {
+		latestVerifiedEpoch.Set(float64(epoch))
+	}
+}
+
+func monitorShufflingMismatches(mismatches int) {
+	if shufflingMismatches != nil {
+		shufflingMismatches.Add(float64(mismatches))
+	}
+}
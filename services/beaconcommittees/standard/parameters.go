@@ -24,12 +24,13 @@ import (
 )
 
 type parameters struct {
-	logLevel   zerolog.Level
-	monitor    metrics.Service
-	eth2Client eth2client.Service
-	chainDB    chaindb.Service
-	chainTime  chaintime.Service
-	startEpoch int64
+	logLevel        zerolog.Level
+	monitor         metrics.Service
+	eth2Client      eth2client.Service
+	chainDB         chaindb.Service
+	chainTime       chaintime.Service
+	startEpoch      int64
+	verifyShuffling bool
 }
 
 // Parameter is the interface for service parameters.
@@ -85,6 +86,14 @@ func WithStartEpoch(startEpoch int64) Parameter {
 	})
 }
 
+// WithVerifyShuffling sets the module to verify beacon committees against
+// those calculated locally from the shuffling of active validators.
+func WithVerifyShuffling(verifyShuffling bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.verifyShuffling = verifyShuffling
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
@@ -17,12 +17,14 @@ import (
 	"context"
 
 	eth2client "github.com/attestantio/go-eth2-client"
-	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -33,6 +35,18 @@ type Service struct {
 	beaconCommitteesSetter chaindb.BeaconCommitteesSetter
 	chainTime              chaintime.Service
 	activitySem            *semaphore.Weighted
+
+	// Shuffling verification.
+	verifyShuffling           bool
+	beaconCommitteesProvider  chaindb.BeaconCommitteesProvider
+	blocksProvider            chaindb.BlocksProvider
+	validatorsProvider        chaindb.ValidatorsProvider
+	shufflingMismatchesSetter chaindb.ShufflingMismatchesSetter
+	shuffleRoundCount         uint64
+	targetCommitteeSize       uint64
+	maxCommitteesPerSlot      uint64
+	minSeedLookahead          uint64
+	domainBeaconAttester      phase0.DomainType
 }
 
 // module-wide log.
@@ -46,7 +60,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "beaconcommittees").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log = util.ServiceLogger("beaconcommittees", "standard", parameters.logLevel)
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -62,6 +76,13 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		beaconCommitteesSetter: beaconCommitteesSetter,
 		chainTime:              parameters.chainTime,
 		activitySem:            semaphore.NewWeighted(1),
+		verifyShuffling:        parameters.verifyShuffling,
+	}
+
+	if s.verifyShuffling {
+		if err := s.setupShufflingVerification(ctx, parameters); err != nil {
+			return nil, err
+		}
 	}
 
 	// Update to current epoch before starting (in the background).
@@ -89,13 +110,66 @@ func (s *Service) updateAfterRestart(ctx context.Context, startEpoch int64) {
 		return
 	}
 	s.catchup(ctx, md)
+	if s.verifyShuffling {
+		s.verifyShufflings(ctx, md)
+	}
 	s.activitySem.Release(1)
 
 	// Set up the handler for new chain head updates.
-	if err := s.eth2Client.(eth2client.EventsProvider).Events(ctx, []string{"head"}, func(event *api.Event) {
-		eventData := event.Data.(*api.HeadEvent)
+	if err := s.eth2Client.(eth2client.EventsProvider).Events(ctx, []string{"head"}, func(event *apiv1.Event) {
+		eventData := event.Data.(*apiv1.HeadEvent)
 		s.OnBeaconChainHeadUpdated(ctx, eventData.Slot, eventData.Block, eventData.State, eventData.EpochTransition)
 	}); err != nil {
 		log.Fatal().Err(err).Msg("Failed to add beacon chain head updated handler")
 	}
 }
+
+// setupShufflingVerification sets up the providers and chain specification
+// values required to verify shufflings.
+func (s *Service) setupShufflingVerification(ctx context.Context, parameters *parameters) error {
+	var isProvider bool
+	s.beaconCommitteesProvider, isProvider = parameters.chainDB.(chaindb.BeaconCommitteesProvider)
+	if !isProvider {
+		return errors.New("chain DB does not support beacon committee providing")
+	}
+	s.blocksProvider, isProvider = parameters.chainDB.(chaindb.BlocksProvider)
+	if !isProvider {
+		return errors.New("chain DB does not support block providing")
+	}
+	s.validatorsProvider, isProvider = parameters.chainDB.(chaindb.ValidatorsProvider)
+	if !isProvider {
+		return errors.New("chain DB does not support validator providing")
+	}
+	s.shufflingMismatchesSetter, isProvider = parameters.chainDB.(chaindb.ShufflingMismatchesSetter)
+	if !isProvider {
+		return errors.New("chain DB does not support shuffling mismatch setting")
+	}
+
+	specProvider, isProvider := parameters.eth2Client.(eth2client.SpecProvider)
+	if !isProvider {
+		return errors.New("Ethereum 2 client does not provide spec")
+	}
+	specResponse, err := specProvider.Spec(ctx, &api.SpecOpts{})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain spec")
+	}
+	spec := specResponse.Data
+
+	for key, target := range map[string]*uint64{
+		"SHUFFLE_ROUND_COUNT":     &s.shuffleRoundCount,
+		"TARGET_COMMITTEE_SIZE":   &s.targetCommitteeSize,
+		"MAX_COMMITTEES_PER_SLOT": &s.maxCommitteesPerSlot,
+		"MIN_SEED_LOOKAHEAD":      &s.minSeedLookahead,
+	} {
+		*target, err = chaindb.SpecValue[uint64](spec, key)
+		if err != nil {
+			return err
+		}
+	}
+	s.domainBeaconAttester, err = chaindb.SpecValue[phase0.DomainType](spec, "DOMAIN_BEACON_ATTESTER")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,406 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/finalizer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// committeeKey identifies a beacon committee.
+type committeeKey struct {
+	slot  phase0.Slot
+	index phase0.CommitteeIndex
+}
+
+// verifyShufflings verifies the shufflings of epochs from the last epoch verified.
+func (s *Service) verifyShufflings(ctx context.Context, md *metadata) {
+	if err := s.verifyShufflingEpochs(ctx, md); err != nil {
+		log.Warn().Err(err).Msg("Failed to verify shufflings")
+	}
+}
+
+// verifyShufflingEpochs verifies the shufflings of epochs up to the latest epoch that can be verified.
+func (s *Service) verifyShufflingEpochs(ctx context.Context, md *metadata) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.beaconcommittees.standard").Start(ctx, "verifyShufflingEpochs")
+	defer span.End()
+
+	targetEpoch, err := s.shufflingTargetEpoch(ctx, md)
+	if err != nil {
+		return err
+	}
+	if targetEpoch < 0 || md.LatestVerifiedEpoch >= targetEpoch {
+		log.Trace().Int64("target_epoch", targetEpoch).Msg("No shufflings to verify")
+		return nil
+	}
+	log.Trace().Int64("start_epoch", md.LatestVerifiedEpoch+1).Int64("target_epoch", targetEpoch).Msg("Verifying shufflings")
+
+	// Validators are obtained once for all epochs, as the activation and exit epochs
+	// of a validator do not change once they have passed.
+	validators, err := s.validatorsProvider.Validators(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain validators")
+	}
+
+	for epoch := md.LatestVerifiedEpoch + 1; epoch <= targetEpoch; epoch++ {
+		if err := s.verifyShufflingEpoch(ctx, md, phase0.Epoch(epoch), validators); err != nil {
+			return errors.Wrapf(err, "failed to verify shuffling for epoch %d", epoch)
+		}
+	}
+
+	return nil
+}
+
+// shufflingTargetEpoch is the latest epoch for which the shuffling can be verified, being
+// limited by the epochs for which committees have been stored, for which validators have
+// been updated, and for which the block that provides the seed has been canonicalized.
+// It returns -1 if no epochs can be verified.
+func (s *Service) shufflingTargetEpoch(ctx context.Context, md *metadata) (int64, error) {
+	targetEpoch := md.LatestEpoch
+
+	validatorsEpoch, err := s.validatorsEpoch(ctx)
+	if err != nil {
+		return -1, err
+	}
+	if validatorsEpoch < targetEpoch {
+		targetEpoch = validatorsEpoch
+	}
+
+	canonicalSlot, err := finalizer.CanonicalSlot(ctx, s.chainDB)
+	if err != nil {
+		return -1, err
+	}
+	if canonicalSlot < 0 {
+		return -1, nil
+	}
+	// The seed for an epoch requires the canonical chain up to the end of the epoch
+	// MIN_SEED_LOOKAHEAD+1 epochs earlier.
+	seedEpoch := int64(s.chainTime.SlotToEpoch(phase0.Slot(canonicalSlot+1))) + int64(s.minSeedLookahead)
+	if seedEpoch < targetEpoch {
+		targetEpoch = seedEpoch
+	}
+
+	return targetEpoch, nil
+}
+
+// verifyShufflingEpoch verifies the shuffling of the given epoch, recording any mismatches.
+func (s *Service) verifyShufflingEpoch(ctx context.Context,
+	md *metadata,
+	epoch phase0.Epoch,
+	validators []*chaindb.Validator,
+) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.beaconcommittees.standard").Start(ctx, "verifyShufflingEpoch",
+		trace.WithAttributes(
+			attribute.Int64("epoch", int64(epoch)),
+		))
+	defer span.End()
+
+	mismatches, err := s.shufflingMismatches(ctx, epoch, validators)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if len(mismatches) > 0 {
+		if err := s.shufflingMismatchesSetter.SetShufflingMismatches(ctx, mismatches); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set shuffling mismatches")
+		}
+	}
+
+	md.LatestVerifiedEpoch = int64(epoch)
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	for _, mismatch := range mismatches {
+		log.Warn().Uint64("slot", uint64(mismatch.Slot)).Uint64("index", uint64(mismatch.Index)).Msg("Beacon committee differs from locally calculated shuffling")
+	}
+	monitorShufflingMismatches(len(mismatches))
+	monitorEpochVerified(epoch)
+
+	return nil
+}
+
+// shufflingMismatches calculates the beacon committees of the given epoch and compares them
+// with those stored, returning any that differ.
+func (s *Service) shufflingMismatches(ctx context.Context,
+	epoch phase0.Epoch,
+	validators []*chaindb.Validator,
+) (
+	[]*chaindb.ShufflingMismatch,
+	error,
+) {
+	seed, err := s.seed(ctx, epoch)
+	if err != nil {
+		return nil, err
+	}
+	if seed == nil {
+		log.Trace().Uint64("epoch", uint64(epoch)).Msg("No seed available; not verifying shuffling")
+		return nil, nil
+	}
+
+	firstSlot := s.chainTime.FirstSlotOfEpoch(epoch)
+	lastSlot := s.chainTime.FirstSlotOfEpoch(epoch+1) - 1
+	committees, err := s.beaconCommitteesProvider.BeaconCommittees(ctx, &chaindb.BeaconCommitteeFilter{
+		Order: chaindb.OrderEarliest,
+		From:  &firstSlot,
+		To:    &lastSlot,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain beacon committees")
+	}
+	if len(committees) == 0 {
+		log.Trace().Uint64("epoch", uint64(epoch)).Msg("No beacon committees stored; not verifying shuffling")
+		return nil, nil
+	}
+
+	expectedCommittees := s.epochCommittees(epoch, activeValidatorIndices(validators, epoch), *seed)
+
+	return compareCommittees(committees, expectedCommittees, time.Now()), nil
+}
+
+// seed calculates the seed for the beacon committees of the given epoch from the RANDAO mix
+// at the end of the epoch MIN_SEED_LOOKAHEAD+1 epochs earlier.  The mix is that of the last
+// canonical block up to that point, obtained by mixing its RANDAO reveal in to the mix in its
+// execution payload, so is only available for blocks after the merge.
+// It returns nil if the seed cannot be calculated.
+func (s *Service) seed(ctx context.Context, epoch phase0.Epoch) (*phase0.Root, error) {
+	if uint64(epoch) <= s.minSeedLookahead {
+		// The seed is based on the genesis RANDAO mix.
+		return nil, nil
+	}
+	if epoch-phase0.Epoch(s.minSeedLookahead) <= s.chainTime.BellatrixInitialEpoch() {
+		// The mix is prior to the merge.
+		return nil, nil
+	}
+
+	mixSlot := s.chainTime.FirstSlotOfEpoch(epoch-phase0.Epoch(s.minSeedLookahead)) - 1
+	canonical := true
+	blocks, err := s.blocksProvider.Blocks(ctx, &chaindb.BlockFilter{
+		Limit:     1,
+		Order:     chaindb.OrderLatest,
+		To:        &mixSlot,
+		Canonical: &canonical,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain block for RANDAO mix")
+	}
+	if len(blocks) == 0 || blocks[0].ExecutionPayload == nil {
+		return nil, nil
+	}
+
+	seed := attesterSeed(s.domainBeaconAttester, epoch, randaoMix(blocks[0].ExecutionPayload.PrevRandao, blocks[0].RANDAOReveal))
+
+	return &seed, nil
+}
+
+// epochCommittees calculates the beacon committees for the given epoch.
+func (s *Service) epochCommittees(epoch phase0.Epoch,
+	activeIndices []phase0.ValidatorIndex,
+	seed phase0.Root,
+) []*chaindb.BeaconCommittee {
+	shuffled := shuffleList(activeIndices, seed, s.shuffleRoundCount)
+	validators := uint64(len(shuffled))
+
+	slotsPerEpoch := s.chainTime.SlotsPerEpoch()
+	committeesPerSlot := validators / slotsPerEpoch / s.targetCommitteeSize
+	if committeesPerSlot > s.maxCommitteesPerSlot {
+		committeesPerSlot = s.maxCommitteesPerSlot
+	}
+	if committeesPerSlot == 0 {
+		committeesPerSlot = 1
+	}
+	committeeCount := committeesPerSlot * slotsPerEpoch
+
+	firstSlot := s.chainTime.FirstSlotOfEpoch(epoch)
+	committees := make([]*chaindb.BeaconCommittee, 0, committeeCount)
+	for slotOffset := uint64(0); slotOffset < slotsPerEpoch; slotOffset++ {
+		for index := uint64(0); index < committeesPerSlot; index++ {
+			position := slotOffset*committeesPerSlot + index
+			start := validators * position / committeeCount
+			end := validators * (position + 1) / committeeCount
+			committee := make([]phase0.ValidatorIndex, end-start)
+			copy(committee, shuffled[start:end])
+			committees = append(committees, &chaindb.BeaconCommittee{
+				Slot:      firstSlot + phase0.Slot(slotOffset),
+				Index:     phase0.CommitteeIndex(index),
+				Committee: committee,
+			})
+		}
+	}
+
+	return committees
+}
+
+// activeValidatorIndices returns the indices of the validators active at the given epoch,
+// in ascending order.
+func activeValidatorIndices(validators []*chaindb.Validator, epoch phase0.Epoch) []phase0.ValidatorIndex {
+	indices := make([]phase0.ValidatorIndex, 0, len(validators))
+	for _, validator := range validators {
+		if validator.ActivationEpoch <= epoch && epoch < validator.ExitEpoch {
+			indices = append(indices, validator.Index)
+		}
+	}
+	sort.Slice(indices, func(i int, j int) bool {
+		return indices[i] < indices[j]
+	})
+
+	return indices
+}
+
+// randaoMix returns the RANDAO mix that results from mixing a RANDAO reveal in to a mix.
+func randaoMix(mix [32]byte, reveal phase0.BLSSignature) [32]byte {
+	revealHash := sha256.Sum256(reveal[:])
+	var res [32]byte
+	for i := range res {
+		res[i] = mix[i] ^ revealHash[i]
+	}
+
+	return res
+}
+
+// attesterSeed returns the seed for the beacon committees of an epoch given the RANDAO mix
+// from which it is derived.
+func attesterSeed(domain phase0.DomainType, epoch phase0.Epoch, mix [32]byte) phase0.Root {
+	data := make([]byte, 0, len(domain)+8+len(mix))
+	data = append(data, domain[:]...)
+	data = binary.LittleEndian.AppendUint64(data, uint64(epoch))
+	data = append(data, mix[:]...)
+
+	return sha256.Sum256(data)
+}
+
+// shuffleList shuffles a list of validator indices with the swap-or-not shuffle, such that
+// the validator at each position i of the result is that at position compute_shuffled_index(i)
+// of the input.  This is equivalent to calling compute_shuffled_index for each position, but
+// requires far fewer hashes.
+func shuffleList(indices []phase0.ValidatorIndex, seed phase0.Root, rounds uint64) []phase0.ValidatorIndex {
+	res := make([]phase0.ValidatorIndex, len(indices))
+	copy(res, indices)
+	count := uint64(len(res))
+	if count < 2 {
+		return res
+	}
+
+	// buf holds the seed, the round and the position window for hashing.
+	buf := make([]byte, len(seed)+1+4)
+	copy(buf, seed[:])
+	sources := make([][32]byte, (count+255)/256)
+	// Rounds are applied in reverse order, as each round of compute_shuffled_index
+	// acts on the position obtained from the previous round.
+	for round := rounds; round > 0; round-- {
+		buf[len(seed)] = byte(round - 1)
+		pivotHash := sha256.Sum256(buf[:len(seed)+1])
+		pivot := binary.LittleEndian.Uint64(pivotHash[:8]) % count
+		for i := range sources {
+			binary.LittleEndian.PutUint32(buf[len(seed)+1:], uint32(i))
+			sources[i] = sha256.Sum256(buf)
+		}
+		for i := uint64(0); i < count; i++ {
+			flip := (pivot + count - i) % count
+			if i >= flip {
+				// Each pair is swapped once, from its lower position.
+				continue
+			}
+			// The bit for the pair is at the higher position.
+			if (sources[flip/256][(flip%256)/8]>>(flip%8))&1 == 1 {
+				res[i], res[flip] = res[flip], res[i]
+			}
+		}
+	}
+
+	return res
+}
+
+// compareCommittees compares stored beacon committees with those expected, returning
+// mismatches for those that differ.
+func compareCommittees(committees []*chaindb.BeaconCommittee,
+	expectedCommittees []*chaindb.BeaconCommittee,
+	detected time.Time,
+) []*chaindb.ShufflingMismatch {
+	expected := make(map[committeeKey][]phase0.ValidatorIndex, len(expectedCommittees))
+	for _, committee := range expectedCommittees {
+		expected[committeeKey{slot: committee.Slot, index: committee.Index}] = committee.Committee
+	}
+
+	mismatches := make([]*chaindb.ShufflingMismatch, 0)
+	for _, committee := range committees {
+		key := committeeKey{slot: committee.Slot, index: committee.Index}
+		expectedCommittee, exists := expected[key]
+		delete(expected, key)
+		if exists && sameCommittee(committee.Committee, expectedCommittee) {
+			continue
+		}
+		mismatches = append(mismatches, &chaindb.ShufflingMismatch{
+			Slot:              committee.Slot,
+			Index:             committee.Index,
+			Committee:         committee.Committee,
+			ExpectedCommittee: expectedCommittee,
+			Detected:          detected,
+		})
+	}
+	for key, expectedCommittee := range expected {
+		mismatches = append(mismatches, &chaindb.ShufflingMismatch{
+			Slot:              key.slot,
+			Index:             key.index,
+			ExpectedCommittee: expectedCommittee,
+			Detected:          detected,
+		})
+	}
+
+	sort.Slice(mismatches, func(i int, j int) bool {
+		if mismatches[i].Slot != mismatches[j].Slot {
+			return mismatches[i].Slot < mismatches[j].Slot
+		}
+		return mismatches[i].Index < mismatches[j].Index
+	})
+
+	return mismatches
+}
+
+// sameCommittee returns true if the two committees have the same members in the same order.
+func sameCommittee(a []phase0.ValidatorIndex, b []phase0.ValidatorIndex) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
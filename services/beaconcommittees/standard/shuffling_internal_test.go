@@ -0,0 +1,158 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/mock"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+)
+
+// computeShuffledIndex is compute_shuffled_index from the consensus specification.
+func computeShuffledIndex(index uint64, count uint64, seed phase0.Root, rounds uint64) uint64 {
+	for round := uint64(0); round < rounds; round++ {
+		pivotHash := sha256.Sum256(append(seed[:], byte(round)))
+		pivot := binary.LittleEndian.Uint64(pivotHash[:8]) % count
+		flip := (pivot + count - index) % count
+		position := index
+		if flip > position {
+			position = flip
+		}
+		source := sha256.Sum256(binary.LittleEndian.AppendUint32(append(seed[:], byte(round)), uint32(position/256)))
+		if (source[(position%256)/8]>>(position%8))%2 == 1 {
+			index = flip
+		}
+	}
+
+	return index
+}
+
+func TestShuffleList(t *testing.T) {
+	tests := []struct {
+		name   string
+		count  uint64
+		rounds uint64
+	}{
+		{name: "Empty", count: 0, rounds: 90},
+		{name: "Single", count: 1, rounds: 90},
+		{name: "Pair", count: 2, rounds: 90},
+		{name: "Small", count: 33, rounds: 90},
+		{name: "Window", count: 256, rounds: 90},
+		{name: "MultipleWindows", count: 1000, rounds: 90},
+		{name: "FewRounds", count: 1000, rounds: 10},
+	}
+
+	seed := phase0.Root(sha256.Sum256([]byte("seed")))
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indices := make([]phase0.ValidatorIndex, test.count)
+			for i := range indices {
+				// Offset indices so that positions and values differ.
+				indices[i] = phase0.ValidatorIndex(i*3 + 7)
+			}
+			shuffled := shuffleList(indices, seed, test.rounds)
+			require.Len(t, shuffled, len(indices))
+			for i := range shuffled {
+				require.Equal(t, indices[computeShuffledIndex(uint64(i), test.count, seed, test.rounds)], shuffled[i])
+			}
+		})
+	}
+}
+
+func TestEpochCommittees(t *testing.T) {
+	ctx := context.Background()
+
+	consensusClient, err := mock.New(ctx,
+		mock.WithGenesisTime(time.Now()),
+	)
+	require.NoError(t, err)
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithGenesisProvider(consensusClient),
+		standardchaintime.WithSpecProvider(consensusClient),
+		standardchaintime.WithForkScheduleProvider(consensusClient),
+	)
+	require.NoError(t, err)
+
+	s := &Service{
+		chainTime:            chainTime,
+		shuffleRoundCount:    90,
+		targetCommitteeSize:  4,
+		maxCommitteesPerSlot: 2,
+	}
+
+	validators := make([]*chaindb.Validator, 0)
+	for i := 0; i < 300; i++ {
+		validators = append(validators, &chaindb.Validator{
+			Index:           phase0.ValidatorIndex(i),
+			ActivationEpoch: 0,
+			ExitEpoch:       0xffffffffffffffff,
+		})
+	}
+	// Validators that are not active.
+	validators[10].ActivationEpoch = 20
+	validators[11].ExitEpoch = 5
+
+	seed := phase0.Root(sha256.Sum256([]byte("seed")))
+	activeIndices := activeValidatorIndices(validators, 10)
+	require.Len(t, activeIndices, 298)
+	committees := s.epochCommittees(10, activeIndices, seed)
+
+	// 298 validators over 32 slots with a target committee size of 4 gives 2 committees per slot.
+	require.Len(t, committees, 64)
+	shuffled := shuffleList(activeIndices, seed, 90)
+	members := make([]phase0.ValidatorIndex, 0, len(activeIndices))
+	for i, committee := range committees {
+		require.Equal(t, phase0.Slot(320+i/2), committee.Slot)
+		require.Equal(t, phase0.CommitteeIndex(i%2), committee.Index)
+		require.True(t, len(committee.Committee) == 4 || len(committee.Committee) == 5)
+		members = append(members, committee.Committee...)
+	}
+	require.Equal(t, shuffled, members)
+}
+
+func TestCompareCommittees(t *testing.T) {
+	detected := time.Unix(1700000000, 0)
+	expected := []*chaindb.BeaconCommittee{
+		{Slot: 1, Index: 0, Committee: []phase0.ValidatorIndex{1, 2}},
+		{Slot: 1, Index: 1, Committee: []phase0.ValidatorIndex{3, 4}},
+		{Slot: 2, Index: 0, Committee: []phase0.ValidatorIndex{5, 6}},
+		{Slot: 2, Index: 1, Committee: []phase0.ValidatorIndex{7, 8}},
+	}
+
+	require.Empty(t, compareCommittees(expected, expected, detected))
+
+	committees := []*chaindb.BeaconCommittee{
+		// Same members in a different order.
+		{Slot: 1, Index: 0, Committee: []phase0.ValidatorIndex{2, 1}},
+		{Slot: 1, Index: 1, Committee: []phase0.ValidatorIndex{3, 4}},
+		// Missing a member.
+		{Slot: 2, Index: 0, Committee: []phase0.ValidatorIndex{5}},
+		// Slot 2 index 1 is missing, and slot 2 index 2 is unexpected.
+		{Slot: 2, Index: 2, Committee: []phase0.ValidatorIndex{9}},
+	}
+	require.Equal(t, []*chaindb.ShufflingMismatch{
+		{Slot: 1, Index: 0, Committee: []phase0.ValidatorIndex{2, 1}, ExpectedCommittee: []phase0.ValidatorIndex{1, 2}, Detected: detected},
+		{Slot: 2, Index: 0, Committee: []phase0.ValidatorIndex{5}, ExpectedCommittee: []phase0.ValidatorIndex{5, 6}, Detected: detected},
+		{Slot: 2, Index: 1, ExpectedCommittee: []phase0.ValidatorIndex{7, 8}, Detected: detected},
+		{Slot: 2, Index: 2, Committee: []phase0.ValidatorIndex{9}, Detected: detected},
+	}, compareCommittees(committees, expected, detected))
+}
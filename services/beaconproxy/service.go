@@ -0,0 +1,26 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beaconproxy
+
+import (
+	"context"
+)
+
+// Service is a proxy for requests to beacon nodes.
+type Service interface {
+	// Proxy provides the address through which to send requests to the beacon
+	// node at the given address.  The supplied headers are added to each request
+	// sent to the beacon node.
+	Proxy(ctx context.Context, address string, headers map[string]string) (string, error)
+}
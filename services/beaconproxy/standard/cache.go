@@ -0,0 +1,221 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/gob"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// diskCache holds responses on disk, removing the least recently used responses
+// to stay within its maximum size.
+type diskCache struct {
+	dir     string
+	maxSize uint64
+	mu      sync.Mutex
+	size    uint64
+	// entries are the elements of lru by key.
+	entries map[string]*list.Element
+	// lru holds cache entries, most recently used first.
+	lru *list.List
+}
+
+// diskCacheEntry is the record of a response held in the cache.
+type diskCacheEntry struct {
+	key  string
+	size uint64
+}
+
+// newDiskCache creates a cache in the given directory, indexing any responses
+// already present.
+func newDiskCache(dir string, maxSize uint64) (*diskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrap(err, "failed to create cache directory")
+	}
+
+	c := &diskCache{
+		dir:     dir,
+		maxSize: maxSize,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+
+	type existingFile struct {
+		key     string
+		size    uint64
+		modTime time.Time
+	}
+	existing := make([]*existingFile, 0)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(path, ".tmp") {
+			// Left over from an interrupted write.
+			return os.Remove(path)
+		}
+		if len(d.Name()) != 64 {
+			// Not a cached response.
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		existing = append(existing, &existingFile{
+			key:     d.Name(),
+			size:    uint64(info.Size()),
+			modTime: info.ModTime(),
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to index cache directory")
+	}
+
+	// Add oldest first, so that the most recent are at the front.
+	sort.Slice(existing, func(i int, j int) bool {
+		return existing[i].modTime.Before(existing[j].modTime)
+	})
+	for _, file := range existing {
+		c.entries[file.key] = c.lru.PushFront(&diskCacheEntry{key: file.key, size: file.size})
+		c.size += file.size
+	}
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+	monitorCacheSize(c.size)
+
+	return c, nil
+}
+
+// Get fetches a response from the cache, returning nil if it is not present.
+func (c *diskCache) Get(key string) *proxiedResponse {
+	c.mu.Lock()
+	element, exists := c.entries[key]
+	if exists {
+		c.lru.MoveToFront(element)
+	}
+	c.mu.Unlock()
+	if !exists {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		// Could have been evicted since it was found.
+		log.Trace().Err(err).Str("key", key).Msg("Failed to read cached response")
+		return nil
+	}
+	res := &proxiedResponse{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(res); err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Failed to decode cached response; removing")
+		c.remove(key)
+		return nil
+	}
+
+	return res
+}
+
+// Put stores a response in the cache.
+func (c *diskCache) Put(key string, res *proxiedResponse) error {
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(res); err != nil {
+		return errors.Wrap(err, "failed to encode response")
+	}
+	size := uint64(buf.Len())
+	if c.maxSize > 0 && size > c.maxSize {
+		log.Trace().Str("key", key).Uint64("size", size).Msg("Response too large to cache")
+		return nil
+	}
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.Wrap(err, "failed to create directory")
+	}
+	// Write to a temporary file and rename, so that readers never see partial data.
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, buf.Bytes(), 0o600); err != nil {
+		return errors.Wrap(err, "failed to write response")
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.Wrap(err, "failed to move response in to place")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, exists := c.entries[key]; exists {
+		c.size -= element.Value.(*diskCacheEntry).size
+		c.lru.Remove(element)
+	}
+	c.entries[key] = c.lru.PushFront(&diskCacheEntry{key: key, size: size})
+	c.size += size
+	c.evict()
+	monitorCacheSize(c.size)
+
+	return nil
+}
+
+// remove removes a response from the cache.
+func (c *diskCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, exists := c.entries[key]; exists {
+		c.removeElement(element)
+	}
+	monitorCacheSize(c.size)
+}
+
+// evict removes the least recently used responses until the cache is within its
+// maximum size.  It must be called with the lock held.
+func (c *diskCache) evict() {
+	if c.maxSize == 0 {
+		return
+	}
+	for c.size > c.maxSize {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// removeElement removes the response for an element.  It must be called with the lock held.
+func (c *diskCache) removeElement(element *list.Element) {
+	entry := element.Value.(*diskCacheEntry)
+	if err := os.Remove(c.path(entry.key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warn().Err(err).Str("key", entry.key).Msg("Failed to remove cached response")
+	}
+	c.lru.Remove(element)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// path provides the path of the file for a key.  Files are spread over subdirectories
+// to avoid very large directories.
+func (c *diskCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
@@ -0,0 +1,65 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskCacheEviction(t *testing.T) {
+	dir := t.TempDir()
+
+	keys := []string{
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"0000000000000000000000000000000000000000000000000000000000000002",
+		"0000000000000000000000000000000000000000000000000000000000000003",
+	}
+	res := &proxiedResponse{
+		StatusCode: 200,
+		Body:       make([]byte, 100),
+	}
+
+	// Find the size of a single entry.
+	c, err := newDiskCache(dir, 0)
+	require.NoError(t, err)
+	require.NoError(t, c.Put(keys[0], res))
+	entrySize := c.size
+	require.NotNil(t, c.Get(keys[0]))
+
+	// Allow two entries.
+	c, err = newDiskCache(dir, 2*entrySize)
+	require.NoError(t, err)
+	require.Equal(t, entrySize, c.size)
+	require.NoError(t, c.Put(keys[1], res))
+	// Use the first entry, so that the second is the least recently used.
+	require.NotNil(t, c.Get(keys[0]))
+	require.NoError(t, c.Put(keys[2], res))
+	require.Equal(t, 2*entrySize, c.size)
+	require.NotNil(t, c.Get(keys[0]))
+	require.Nil(t, c.Get(keys[1]))
+	require.NotNil(t, c.Get(keys[2]))
+	_, err = os.Stat(filepath.Join(dir, "00", keys[1]))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// Entries too large for the cache are not stored.
+	c, err = newDiskCache(dir, entrySize-1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), c.size)
+	require.NoError(t, c.Put(keys[0], res))
+	require.Nil(t, c.Get(keys[0]))
+}
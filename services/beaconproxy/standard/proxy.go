@@ -0,0 +1,299 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// finalityRefreshInterval is the interval after which the finalized slot of a
+// beacon node is fetched again.
+const finalityRefreshInterval = time.Minute
+
+// forwardedHeaders are the headers of requests that are passed to the beacon node.
+// They form part of the key that identifies a request.
+var forwardedHeaders = []string{"Accept", "Content-Type", "Eth-Consensus-Version"}
+
+// hopHeaders are the headers of responses that are not passed back to the client.
+var hopHeaders = map[string]struct{}{
+	"Connection":        {},
+	"Content-Length":    {},
+	"Keep-Alive":        {},
+	"Transfer-Encoding": {},
+	"Upgrade":           {},
+}
+
+// immutableRequestRegex matches requests for data at a given block or state, capturing its identifier.
+var immutableRequestRegex = regexp.MustCompile(`^/eth/v[0-9]+/(?:beacon/(?:blocks|blinded_blocks|headers|blob_sidecars|states|rewards/blocks)|debug/beacon/states)/([^/]+)(?:/.*)?$`)
+
+// rootRegex matches block and state roots.
+var rootRegex = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+
+// proxiedResponse is a response from a beacon node.
+type proxiedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// ServeHTTP proxies a request to a beacon node.
+func (s *Service) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r, path, err := s.route(req.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if path == "/eth/v1/events" {
+		// Event streams are long-lived, so are passed straight through.
+		monitorRequest("streamed")
+		r.events.ServeHTTP(w, req)
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	headers := make(http.Header)
+	for _, name := range forwardedHeaders {
+		if value := req.Header.Get(name); value != "" {
+			headers.Set(name, value)
+		}
+	}
+	key := requestKey(r.upstream, req.Method, path, req.URL.RawQuery, headers, body)
+
+	immutable := s.cache != nil && s.immutable(r, path)
+	if immutable {
+		if res := s.cache.Get(key); res != nil {
+			log.Trace().Str("path", path).Msg("Served from cache")
+			monitorRequest("cached")
+			writeResponse(w, res)
+			return
+		}
+	}
+
+	val, err, shared := s.requests.Do(key, func() (any, error) {
+		return s.fetch(r, req.Method, path, req.URL.RawQuery, headers, body)
+	})
+	if err != nil {
+		log.Debug().Err(err).Str("path", path).Msg("Request to beacon node failed")
+		monitorRequest("failed")
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	res, isResponse := val.(*proxiedResponse)
+	if !isResponse {
+		monitorRequest("failed")
+		http.Error(w, "invalid response", http.StatusInternalServerError)
+		return
+	}
+	if shared {
+		monitorRequest("deduplicated")
+	} else {
+		monitorRequest("fetched")
+		if immutable && res.StatusCode == http.StatusOK {
+			if err := s.cache.Put(key, res); err != nil {
+				log.Warn().Err(err).Str("path", path).Msg("Failed to cache response")
+			}
+		}
+	}
+
+	writeResponse(w, res)
+}
+
+// route provides the route of a request, and the path of the request without
+// its route prefix.
+func (s *Service) route(path string) (*route, string, error) {
+	prefix, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	index, err := strconv.Atoi(prefix)
+	if err != nil {
+		return nil, "", errors.New("unknown route")
+	}
+
+	s.routesMu.RLock()
+	defer s.routesMu.RUnlock()
+	if index < 0 || index >= len(s.routes) {
+		return nil, "", errors.New("unknown route")
+	}
+
+	return s.routes[index], routePath(path), nil
+}
+
+// fetch sends a request to the beacon node.
+func (s *Service) fetch(r *route,
+	method string,
+	path string,
+	rawQuery string,
+	headers http.Header,
+	body []byte,
+) (
+	*proxiedResponse,
+	error,
+) {
+	// The request is shared between callers, so does not use the context of any one of them.
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var bodyReader io.Reader
+	if len(body) > 0 {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.url(path, rawQuery).String(), bodyReader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	req.Header = headers.Clone()
+	r.setHeaders(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to call beacon node")
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response from beacon node")
+	}
+
+	res := &proxiedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     make(http.Header),
+		Body:       respBody,
+	}
+	for name, values := range resp.Header {
+		if _, exists := hopHeaders[name]; !exists {
+			res.Header[name] = values
+		}
+	}
+
+	return res, nil
+}
+
+// immutable returns true if the response to a request cannot change.  This is
+// the case for requests for data at a block or state given by its root, or at
+// a finalized slot.
+func (s *Service) immutable(r *route, path string) bool {
+	match := immutableRequestRegex.FindStringSubmatch(path)
+	if match == nil {
+		return false
+	}
+	id := match[1]
+
+	switch {
+	case id == "genesis":
+		return true
+	case rootRegex.MatchString(id):
+		return true
+	default:
+		slot, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			// Head, finalized, justified etc.
+			return false
+		}
+		finalizedSlot, known := s.finalizedSlot(r)
+
+		return known && slot <= finalizedSlot
+	}
+}
+
+// finalizedSlot provides the slot of the latest finalized block of the beacon node.
+func (s *Service) finalizedSlot(r *route) (uint64, bool) {
+	r.finalityMu.Lock()
+	defer r.finalityMu.Unlock()
+
+	if !r.finalityFetched.IsZero() && time.Since(r.finalityFetched) < finalityRefreshInterval {
+		return r.finalizedSlot, r.finalityKnown
+	}
+	r.finalityFetched = time.Now()
+
+	headers := make(http.Header)
+	headers.Set("Accept", "application/json")
+	res, err := s.fetch(r, http.MethodGet, "/eth/v1/beacon/headers/finalized", "", headers, nil)
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to obtain finalized block header")
+		return r.finalizedSlot, r.finalityKnown
+	}
+	if res.StatusCode != http.StatusOK {
+		log.Debug().Int("status_code", res.StatusCode).Msg("Failed to obtain finalized block header")
+		return r.finalizedSlot, r.finalityKnown
+	}
+
+	var header struct {
+		Data struct {
+			Header struct {
+				Message struct {
+					Slot string `json:"slot"`
+				} `json:"message"`
+			} `json:"header"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(res.Body, &header); err != nil {
+		log.Debug().Err(err).Msg("Failed to parse finalized block header")
+		return r.finalizedSlot, r.finalityKnown
+	}
+	slot, err := strconv.ParseUint(header.Data.Header.Message.Slot, 10, 64)
+	if err != nil {
+		log.Debug().Err(err).Msg("Invalid slot for finalized block header")
+		return r.finalizedSlot, r.finalityKnown
+	}
+	r.finalizedSlot = slot
+	r.finalityKnown = true
+
+	return r.finalizedSlot, r.finalityKnown
+}
+
+// requestKey provides a key that identifies a request.
+func requestKey(upstream string,
+	method string,
+	path string,
+	rawQuery string,
+	headers http.Header,
+	body []byte,
+) string {
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%s\n%s\n%s\n%s\n", upstream, method, path, rawQuery)
+	for _, name := range forwardedHeaders {
+		_, _ = fmt.Fprintf(hash, "%s\n", headers.Get(name))
+	}
+	_, _ = hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// writeResponse writes a response from the beacon node to the client.
+func writeResponse(w http.ResponseWriter, res *proxiedResponse) {
+	for name, values := range res.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(res.Body)))
+	w.WriteHeader(res.StatusCode)
+	if _, err := w.Write(res.Body); err != nil {
+		log.Trace().Err(err).Msg("Failed to write response")
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/singleflight"
+)
+
+// Service is a proxy for requests to beacon nodes that runs within chaind.
+// Identical requests that are in flight at the same time are sent to the beacon
+// node once, and responses that cannot change, such as those for finalized
+// blocks, are cached on disk.
+type Service struct {
+	listener net.Listener
+	client   *http.Client
+	timeout  time.Duration
+	cache    *diskCache
+	requests singleflight.Group
+	routesMu sync.RWMutex
+	routes   []*route
+}
+
+// route is a beacon node to which requests are proxied.
+type route struct {
+	upstream string
+	headers  map[string]string
+	events   *httputil.ReverseProxy
+	// finalityMu protects the finalized slot.
+	finalityMu      sync.Mutex
+	finalizedSlot   uint64
+	finalityKnown   bool
+	finalityFetched time.Time
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("beaconproxy", "standard", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	var cache *diskCache
+	if parameters.cacheDir != "" {
+		cache, err = newDiskCache(parameters.cacheDir, parameters.cacheMaxSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("tcp", parameters.listenAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to listen")
+	}
+
+	s := &Service{
+		listener: listener,
+		client:   &http.Client{},
+		timeout:  parameters.timeout,
+		cache:    cache,
+		routes:   make([]*route, 0),
+	}
+
+	server := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("Proxy server stopped")
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		//nolint:contextcheck
+		if err := server.Close(); err != nil {
+			log.Warn().Err(err).Msg("Failed to close proxy server")
+		}
+	}()
+	log.Trace().Str("address", listener.Addr().String()).Msg("Proxy listening")
+
+	return s, nil
+}
+
+// Proxy provides the address through which to send requests to the beacon
+// node at the given address.  The supplied headers are added to each request
+// sent to the beacon node.
+func (s *Service) Proxy(_ context.Context, address string, headers map[string]string) (string, error) {
+	upstream := address
+	if !strings.HasPrefix(upstream, "http") {
+		upstream = fmt.Sprintf("http://%s", upstream)
+	}
+	upstream = strings.TrimSuffix(upstream, "/")
+	if _, err := url.Parse(upstream); err != nil {
+		return "", errors.Wrap(err, "invalid address")
+	}
+
+	s.routesMu.Lock()
+	defer s.routesMu.Unlock()
+
+	for i, existing := range s.routes {
+		if existing.upstream == upstream && sameHeaders(existing.headers, headers) {
+			return s.routeAddress(i), nil
+		}
+	}
+
+	r := &route{
+		upstream: upstream,
+		headers:  headers,
+	}
+	r.events = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL = r.url(routePath(pr.In.URL.Path), pr.In.URL.RawQuery)
+			pr.Out.Host = ""
+			r.setHeaders(pr.Out)
+		},
+		// Pass events on as they arrive.
+		FlushInterval: -1,
+	}
+	s.routes = append(s.routes, r)
+
+	return s.routeAddress(len(s.routes) - 1), nil
+}
+
+// routeAddress provides the address of the proxy for a route.
+func (s *Service) routeAddress(index int) string {
+	return fmt.Sprintf("http://%s/%d/", s.listener.Addr().String(), index)
+}
+
+// url provides the URL of a request to the beacon node of the route.
+func (r *route) url(path string, rawQuery string) *url.URL {
+	// Address has already been validated.
+	res, _ := url.Parse(fmt.Sprintf("%s%s", r.upstream, path))
+	res.RawQuery = rawQuery
+
+	return res
+}
+
+// setHeaders sets the headers of the route on a request.
+func (r *route) setHeaders(req *http.Request) {
+	for name, value := range r.headers {
+		req.Header.Set(name, value)
+	}
+}
+
+// routePath provides the path of a proxied request without its route prefix.
+func routePath(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	if index := strings.Index(path, "/"); index != -1 {
+		return path[index:]
+	}
+
+	return "/"
+}
+
+// sameHeaders returns true if the two sets of headers are the same.
+func sameHeaders(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if bValue, exists := b[name]; !exists || bValue != value {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,288 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/beaconproxy/standard"
+)
+
+// testBeaconNode is a beacon node that counts the requests it receives.
+type testBeaconNode struct {
+	server   *httptest.Server
+	requests sync.Map
+	// release, if set, holds requests until it is closed.
+	release chan struct{}
+}
+
+func newTestBeaconNode(t *testing.T) *testBeaconNode {
+	t.Helper()
+
+	node := &testBeaconNode{}
+	node.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count, _ := node.requests.LoadOrStore(r.URL.Path, new(atomic.Int32))
+		count.(*atomic.Int32).Add(1)
+		if node.release != nil {
+			<-node.release
+		}
+		switch {
+		case r.URL.Path == "/eth/v1/beacon/headers/finalized":
+			_, _ = w.Write([]byte(`{"data":{"header":{"message":{"slot":"200"}}}}`))
+		case r.URL.Path == "/eth/v1/node/identity":
+			_, _ = fmt.Fprintf(w, `{"authorization":%q}`, r.Header.Get("Authorization"))
+		case strings.HasPrefix(r.URL.Path, "/eth/v2/beacon/blocks/"):
+			w.Header().Set("Eth-Consensus-Version", "deneb")
+			_, _ = fmt.Fprintf(w, `{"block":%q,"query":%q}`, strings.TrimPrefix(r.URL.Path, "/eth/v2/beacon/blocks/"), r.URL.RawQuery)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(node.server.Close)
+
+	return node
+}
+
+// count provides the number of requests received for a path.
+func (n *testBeaconNode) count(path string) int {
+	count, exists := n.requests.Load(path)
+	if !exists {
+		return 0
+	}
+
+	return int(count.(*atomic.Int32).Load())
+}
+
+func get(t *testing.T, url string) (int, http.Header, string) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp.StatusCode, resp.Header, string(body)
+}
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ListenAddressMissing",
+			params: []standard.Parameter{
+				standard.WithListenAddress(""),
+			},
+			err: "problem with parameters: no listen address specified",
+		},
+		{
+			name: "TimeoutZero",
+			params: []standard.Parameter{
+				standard.WithTimeout(0),
+			},
+			err: "problem with parameters: timeout must be greater than 0",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithCacheDir(t.TempDir()),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestProxy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	node := newTestBeaconNode(t)
+	s, err := standard.New(ctx)
+	require.NoError(t, err)
+
+	address, err := s.Proxy(ctx, node.server.URL+"/", map[string]string{"Authorization": "Bearer secret"})
+	require.NoError(t, err)
+	// The same beacon node and headers share an address.
+	sameAddress, err := s.Proxy(ctx, node.server.URL, map[string]string{"Authorization": "Bearer secret"})
+	require.NoError(t, err)
+	require.Equal(t, address, sameAddress)
+	otherAddress, err := s.Proxy(ctx, node.server.URL, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, address, otherAddress)
+
+	// Headers are added to requests.
+	status, _, body := get(t, address+"eth/v1/node/identity")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, `{"authorization":"Bearer secret"}`, body)
+	status, _, body = get(t, otherAddress+"eth/v1/node/identity")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, `{"authorization":""}`, body)
+
+	// Status codes, headers and query parameters are passed through.
+	status, header, body := get(t, address+"eth/v2/beacon/blocks/head?a=b")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "deneb", header.Get("Eth-Consensus-Version"))
+	require.Equal(t, `{"block":"head","query":"a=b"}`, body)
+	status, _, _ = get(t, address+"eth/v1/unknown")
+	require.Equal(t, http.StatusNotFound, status)
+
+	// Unknown routes are rejected.
+	status, _, _ = get(t, strings.TrimSuffix(address, "0/")+"5/eth/v1/node/identity")
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestProxyDeduplication(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	node := newTestBeaconNode(t)
+	node.release = make(chan struct{})
+	s, err := standard.New(ctx)
+	require.NoError(t, err)
+	address, err := s.Proxy(ctx, node.server.URL, nil)
+	require.NoError(t, err)
+
+	requests := 5
+	bodies := make(chan string, requests)
+	for i := 0; i < requests; i++ {
+		go func() {
+			resp, err := http.Get(address + "eth/v2/beacon/blocks/head")
+			if err != nil {
+				bodies <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				bodies <- err.Error()
+				return
+			}
+			bodies <- string(body)
+		}()
+	}
+	// Allow the requests to reach the proxy before the beacon node responds.
+	require.Eventually(t, func() bool { return node.count("/eth/v2/beacon/blocks/head") == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	close(node.release)
+
+	for i := 0; i < requests; i++ {
+		require.Equal(t, `{"block":"head","query":""}`, <-bodies)
+	}
+	require.Equal(t, 1, node.count("/eth/v2/beacon/blocks/head"))
+
+	// Requests that are not in flight at the same time are sent again.
+	_, _, _ = get(t, address+"eth/v2/beacon/blocks/head")
+	require.Equal(t, 2, node.count("/eth/v2/beacon/blocks/head"))
+}
+
+func TestProxyCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	node := newTestBeaconNode(t)
+	cacheDir := t.TempDir()
+	s, err := standard.New(ctx, standard.WithCacheDir(cacheDir))
+	require.NoError(t, err)
+	address, err := s.Proxy(ctx, node.server.URL, nil)
+	require.NoError(t, err)
+
+	root := "0x0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20"
+	tests := []struct {
+		name     string
+		path     string
+		requests int
+	}{
+		{
+			name:     "Root",
+			path:     "/eth/v2/beacon/blocks/" + root,
+			requests: 1,
+		},
+		{
+			name:     "FinalizedSlot",
+			path:     "/eth/v2/beacon/blocks/200",
+			requests: 1,
+		},
+		{
+			name:     "UnfinalizedSlot",
+			path:     "/eth/v2/beacon/blocks/201",
+			requests: 3,
+		},
+		{
+			name:     "Head",
+			path:     "/eth/v2/beacon/blocks/head",
+			requests: 3,
+		},
+		{
+			name:     "NotFound",
+			path:     "/eth/v1/beacon/headers/" + root,
+			requests: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var expectedBody string
+			for i := 0; i < 3; i++ {
+				_, header, body := get(t, address+strings.TrimPrefix(test.path, "/"))
+				if i == 0 {
+					expectedBody = body
+				}
+				require.Equal(t, expectedBody, body)
+				if strings.HasPrefix(test.path, "/eth/v2/beacon/blocks/") {
+					require.Equal(t, "deneb", header.Get("Eth-Consensus-Version"))
+				}
+			}
+			require.Equal(t, test.requests, node.count(test.path))
+		})
+	}
+	require.Equal(t, 1, node.count("/eth/v1/beacon/headers/finalized"))
+
+	// Cached responses are available after a restart.
+	cancel()
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	s, err = standard.New(ctx, standard.WithCacheDir(cacheDir))
+	require.NoError(t, err)
+	address, err = s.Proxy(ctx, node.server.URL, nil)
+	require.NoError(t, err)
+	_, _, body := get(t, address+"eth/v2/beacon/blocks/"+root)
+	require.Equal(t, fmt.Sprintf(`{"block":%q,"query":""}`, root), body)
+	require.Equal(t, 1, node.count("/eth/v2/beacon/blocks/"+root))
+}
@@ -0,0 +1,91 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// arrivalRetentionSlots is the number of slots for which arrivals are held
+// waiting for their block to be indexed.
+const arrivalRetentionSlots = 64
+
+// noteArrival notes the time at which the beacon node announced a block.
+func (s *Service) noteArrival(slot phase0.Slot, root phase0.Root, seen time.Time) {
+	if !s.arrivals {
+		return
+	}
+
+	s.pendingArrivalsMu.Lock()
+	defer s.pendingArrivalsMu.Unlock()
+
+	if arrival, exists := s.pendingArrivals[root]; exists && !seen.Before(arrival.SeenTimestamp) {
+		// Keep the earliest announcement.
+		return
+	}
+	s.pendingArrivals[root] = &chaindb.BlockArrival{
+		BlockRoot:     root,
+		Slot:          slot,
+		SeenTimestamp: seen,
+		Delay:         seen.Sub(s.chainTime.StartOfSlot(slot)),
+	}
+
+	// Remove arrivals for blocks that were never indexed.
+	if slot > arrivalRetentionSlots {
+		for pendingRoot, arrival := range s.pendingArrivals {
+			if arrival.Slot < slot-arrivalRetentionSlots {
+				delete(s.pendingArrivals, pendingRoot)
+			}
+		}
+	}
+}
+
+// setArrival stores the arrival of a block, if it was announced by the beacon node.
+// The arrival remains pending, so that it is stored again if the transaction
+// fails; it is removed by clearArrivals once the transaction has committed, or
+// otherwise after arrivalRetentionSlots.
+func (s *Service) setArrival(ctx context.Context, root phase0.Root) error {
+	if !s.arrivals {
+		return nil
+	}
+
+	s.pendingArrivalsMu.Lock()
+	arrival, exists := s.pendingArrivals[root]
+	s.pendingArrivalsMu.Unlock()
+	if !exists {
+		// Block was not indexed at the head of the chain.
+		return nil
+	}
+
+	monitorBlockArrival(arrival.Delay)
+
+	return s.arrivalsSetter.SetBlockArrivals(ctx, []*chaindb.BlockArrival{arrival})
+}
+
+// clearArrivals removes the pending arrivals of blocks whose transaction has committed.
+func (s *Service) clearArrivals(roots []phase0.Root) {
+	if !s.arrivals || len(roots) == 0 {
+		return
+	}
+
+	s.pendingArrivalsMu.Lock()
+	defer s.pendingArrivalsMu.Unlock()
+	for _, root := range roots {
+		delete(s.pendingArrivals, root)
+	}
+}
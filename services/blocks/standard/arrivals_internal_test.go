@@ -0,0 +1,151 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+// arrivalsChainTime is a chain time with 12 second slots starting at a fixed genesis.
+type arrivalsChainTime struct {
+	chaintime.Service
+
+	genesis time.Time
+}
+
+func (c *arrivalsChainTime) StartOfSlot(slot phase0.Slot) time.Time {
+	return c.genesis.Add(time.Duration(slot) * 12 * time.Second)
+}
+
+// arrivalsDB is a pipeline database that records stored arrivals, and whose commits can fail.
+type arrivalsDB struct {
+	*pipelineDB
+
+	arrivals  []*chaindb.BlockArrival
+	commitErr error
+}
+
+func (d *arrivalsDB) SetBlockArrivals(_ context.Context, arrivals []*chaindb.BlockArrival) error {
+	d.arrivals = append(d.arrivals, arrivals...)
+
+	return nil
+}
+
+func (d *arrivalsDB) CommitTx(ctx context.Context) error {
+	if d.commitErr != nil {
+		return d.commitErr
+	}
+
+	return d.pipelineDB.CommitTx(ctx)
+}
+
+func newArrivalsService(db *arrivalsDB) *Service {
+	s := newPipelineService(db.pipelineDB)
+	s.chainDB = db
+	s.attestationsSetter = db
+	s.arrivals = true
+	s.arrivalsSetter = db
+	s.pendingArrivals = make(map[phase0.Root]*chaindb.BlockArrival)
+	s.chainTime = &arrivalsChainTime{Service: mockchaintime.New(), genesis: time.Unix(1600000000, 0)}
+
+	return s
+}
+
+func TestNoteArrival(t *testing.T) {
+	s := newArrivalsService(&arrivalsDB{pipelineDB: newPipelineDB(t)})
+	slotStart := s.chainTime.StartOfSlot(100)
+
+	// The delay is measured from the start of the slot.
+	s.noteArrival(100, phase0.Root{0x01}, slotStart.Add(3*time.Second))
+	require.Equal(t, &chaindb.BlockArrival{
+		BlockRoot:     phase0.Root{0x01},
+		Slot:          100,
+		SeenTimestamp: slotStart.Add(3 * time.Second),
+		Delay:         3 * time.Second,
+	}, s.pendingArrivals[phase0.Root{0x01}])
+
+	// A later sighting does not replace an earlier one.
+	s.noteArrival(100, phase0.Root{0x01}, slotStart.Add(5*time.Second))
+	require.Equal(t, 3*time.Second, s.pendingArrivals[phase0.Root{0x01}].Delay)
+
+	// An earlier sighting replaces a later one.
+	s.noteArrival(100, phase0.Root{0x01}, slotStart.Add(time.Second))
+	require.Equal(t, slotStart.Add(time.Second), s.pendingArrivals[phase0.Root{0x01}].SeenTimestamp)
+	require.Equal(t, time.Second, s.pendingArrivals[phase0.Root{0x01}].Delay)
+
+	// Sightings before the start of the slot have a negative delay.
+	s.noteArrival(101, phase0.Root{0x02}, s.chainTime.StartOfSlot(101).Add(-time.Second))
+	require.Equal(t, -time.Second, s.pendingArrivals[phase0.Root{0x02}].Delay)
+
+	// Disabled arrivals are not noted.
+	s.arrivals = false
+	s.noteArrival(102, phase0.Root{0x03}, s.chainTime.StartOfSlot(102))
+	require.Len(t, s.pendingArrivals, 2)
+}
+
+func TestNoteArrivalEviction(t *testing.T) {
+	s := newArrivalsService(&arrivalsDB{pipelineDB: newPipelineDB(t)})
+
+	s.noteArrival(10, phase0.Root{0x01}, s.chainTime.StartOfSlot(10))
+	s.noteArrival(20, phase0.Root{0x02}, s.chainTime.StartOfSlot(20))
+
+	// Arrivals are retained for arrivalRetentionSlots.
+	s.noteArrival(10+arrivalRetentionSlots, phase0.Root{0x03}, s.chainTime.StartOfSlot(10+arrivalRetentionSlots))
+	require.Len(t, s.pendingArrivals, 3)
+
+	// Arrivals older than that are evicted.
+	s.noteArrival(11+arrivalRetentionSlots, phase0.Root{0x04}, s.chainTime.StartOfSlot(11+arrivalRetentionSlots))
+	require.Len(t, s.pendingArrivals, 3)
+	require.NotContains(t, s.pendingArrivals, phase0.Root{0x01})
+	require.Contains(t, s.pendingArrivals, phase0.Root{0x02})
+}
+
+func TestSetArrivalAfterCommit(t *testing.T) {
+	ctx := context.Background()
+
+	db := &arrivalsDB{pipelineDB: newPipelineDB(t), commitErr: errors.New("commit failed")}
+	s := newArrivalsService(db)
+	root := phase0.Root{0x01}
+	s.noteArrival(1, root, s.chainTime.StartOfSlot(1).Add(2*time.Second))
+
+	queue := func() chan *pipelineItem {
+		queue := make(chan *pipelineItem, 1)
+		queue <- &pipelineItem{slot: 1, dbBlock: &chaindb.Block{Slot: 1, Root: root}, contents: &blockContents{}}
+		close(queue)
+
+		return queue
+	}
+
+	// The arrival is stored but remains pending if the commit fails.
+	md := &metadata{LatestSlot: -1, LatestHeaderSlot: -1}
+	require.ErrorContains(t, s.persistItems(ctx, md, queue(), everyN(4, 1)), "commit failed")
+	require.Len(t, db.arrivals, 1)
+	require.Contains(t, s.pendingArrivals, root)
+
+	// It is stored again, and removed, once the commit succeeds.
+	db.commitErr = nil
+	require.NoError(t, s.persistItems(ctx, md, queue(), everyN(4, 1)))
+	require.Len(t, db.arrivals, 2)
+	require.Equal(t, 2*time.Second, db.arrivals[1].Delay)
+	require.NotContains(t, s.pendingArrivals, root)
+}
@@ -0,0 +1,75 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// When fetching headers first the bodies of blocks are filled in by the body
+// backfill worker, which runs alongside the handlers that fetch headers.  Its
+// progress is the latest slot in the metadata, which it alone updates, and it
+// never moves past the latest header slot.
+
+// triggerBodyBackfill wakes the body backfill worker, if it is not already
+// due to run.
+func (s *Service) triggerBodyBackfill() {
+	select {
+	case s.bodyBackfillCh <- struct{}{}:
+	default:
+	}
+}
+
+// backfillBodies is the body backfill worker.  It fills in bodies each time
+// it is triggered, until the context is done.
+func (s *Service) backfillBodies(ctx context.Context, md *metadata) {
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debug().Msg("Context done; body backfill stopped")
+			return
+		case <-s.bodyBackfillCh:
+			s.fillBodies(ctx, md)
+		}
+	}
+}
+
+// fillBodies fetches the bodies of blocks from the slot after the latest
+// slot in the metadata up to the latest slot for which headers have been
+// stored.
+func (s *Service) fillBodies(ctx context.Context, md *metadata) {
+	s.bodiesMu.Lock()
+	defer s.bodiesMu.Unlock()
+
+	s.retryFailedSlots(ctx)
+
+	// Only the latest header slot is taken from the database; the latest
+	// slot is held by the worker.
+	stored, err := s.getMetadata(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain metadata for body backfill")
+		return
+	}
+	if stored.LatestHeaderSlot > md.LatestSlot {
+		log.Trace().Int64("slot", md.LatestSlot+1).Int64("end_slot", stored.LatestHeaderSlot).Msg("Filling bodies")
+		if !s.catchupBlocks(ctx, md, func() phase0.Slot { return phase0.Slot(stored.LatestHeaderSlot) }) {
+			return
+		}
+	}
+
+	s.clearFailedSlots(ctx, md)
+	s.storeOrphanedBlocks(ctx, phase0.Slot(md.LatestSlot))
+}
@@ -0,0 +1,118 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+)
+
+// subscribe sets up handlers for beacon node events, along with a poller that
+// fetches new blocks if events stop arriving.
+func (s *Service) subscribe(ctx context.Context) {
+	s.lastEventTime.Store(time.Now().UnixNano())
+
+	eventsProvider, isEventsProvider := s.eth2Client.(eth2client.EventsProvider)
+	if !isEventsProvider {
+		log.Warn().Msg("Beacon node does not support events; polling for new blocks")
+	} else if err := eventsProvider.Events(ctx, s.topics(), func(event *api.Event) {
+		s.onEvent(ctx, event)
+	}); err != nil {
+		log.Warn().Err(err).Msg("Failed to subscribe to beacon node events; polling for new blocks")
+	}
+
+	go s.poll(ctx)
+}
+
+// topics provides the beacon node event topics to which the module subscribes.
+func (s *Service) topics() []string {
+	topics := []string{"head", "chain_reorg"}
+	if s.orphanedBodies || s.arrivals {
+		// Block events include blocks that never become canonical, and
+		// arrive before the block becomes the head of the chain.
+		topics = append(topics, "block")
+	}
+
+	return topics
+}
+
+// onEvent handles an event from the beacon node.
+func (s *Service) onEvent(ctx context.Context, event *api.Event) {
+	if event.Data == nil {
+		// Happens when the channel shuts down, nothing to worry about.
+		return
+	}
+
+	started := time.Now()
+	s.lastEventTime.Store(started.UnixNano())
+
+	switch data := event.Data.(type) {
+	case *api.HeadEvent:
+		s.noteArrival(data.Slot, data.Block, started)
+		s.OnBeaconChainHeadUpdated(ctx, data.Slot, data.Block, data.State, data.EpochTransition)
+	case *api.ChainReorgEvent:
+		s.OnChainReorg(ctx, data)
+	case *api.BlockEvent:
+		s.noteArrival(data.Slot, data.Block, started)
+		s.OnBlockEvent(ctx, data)
+	default:
+		log.Trace().Str("topic", event.Topic).Msg("Ignoring unhandled event")
+		return
+	}
+
+	monitorEventProcessed(event.Topic, time.Since(started))
+}
+
+// poll fetches new blocks if no events have been received from the beacon
+// node for the poll interval.
+func (s *Service) poll(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debug().Msg("Context done; poller stopped")
+			return
+		case <-ticker.C:
+			if time.Since(time.Unix(0, s.lastEventTime.Load())) < s.pollInterval {
+				continue
+			}
+			log.Debug().Msg("No recent events from beacon node; polling for new blocks")
+			s.pollForBlocks(ctx)
+		}
+	}
+}
+
+// pollForBlocks fetches any blocks that have not yet been processed.
+func (s *Service) pollForBlocks(ctx context.Context) {
+	// Only allow 1 handler to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another handler running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain metadata")
+		return
+	}
+
+	s.catchup(ctx, md)
+	monitorPoll()
+}
@@ -0,0 +1,180 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaintime"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	"golang.org/x/sync/semaphore"
+)
+
+// reorgClient is a beacon node whose blocks from the fork slot onwards change
+// when it moves to another fork, and which records the slots of blocks fetched.
+type reorgClient struct {
+	*headersClient
+
+	mu       sync.Mutex
+	fork     byte
+	forkSlot phase0.Slot
+	fetched  []phase0.Slot
+}
+
+func (c *reorgClient) SignedBeaconBlock(ctx context.Context,
+	opts *api.SignedBeaconBlockOpts,
+) (
+	*api.Response[*spec.VersionedSignedBeaconBlock],
+	error,
+) {
+	response, err := c.headersClient.SignedBeaconBlock(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	block := response.Data.Phase0.Message
+	c.fetched = append(c.fetched, block.Slot)
+	if c.fork != 0 && block.Slot >= c.forkSlot {
+		block.Body.Graffiti = [32]byte{c.fork}
+	}
+
+	return response, nil
+}
+
+// takeFetched returns the slots fetched since it was last called.
+func (c *reorgClient) takeFetched() []phase0.Slot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fetched := c.fetched
+	c.fetched = nil
+
+	return fetched
+}
+
+func newEventsService(db *headersDB, client *reorgClient, chainTime chaintime.Service) *Service {
+	return &Service{
+		eth2Client:               client,
+		chainDB:                  db,
+		blocksSetter:             db,
+		attestationsSetter:       db,
+		attesterSlashingsSetter:  db,
+		proposerSlashingsSetter:  db,
+		depositsSetter:           db,
+		voluntaryExitsSetter:     db,
+		beaconCommitteesProvider: db,
+		chainTime:                chainTime,
+		endSlot:                  -1,
+		batchSlots:               4,
+		queueSize:                2,
+		pollInterval:             20 * time.Millisecond,
+		activitySem:              semaphore.NewWeighted(1),
+		pendingRoots:             make(map[phase0.Root]phase0.Slot),
+	}
+}
+
+func TestOnChainReorg(t *testing.T) {
+	ctx := context.Background()
+	db := newHeadersDB(t)
+	client := &reorgClient{headersClient: &headersClient{missed: map[phase0.Slot]bool{4: true}}}
+	chainTime := &headersChainTime{Service: mockchaintime.New(), currentSlot: 8}
+	s := newEventsService(db, client, chainTime)
+
+	s.OnBeaconChainHeadUpdated(ctx, 8, phase0.Root{0x01, 8}, phase0.Root{}, false)
+	require.Equal(t, []phase0.Slot{0, 1, 2, 3, 5, 6, 7, 8}, client.takeFetched())
+	latestSlot, _ := db.latest("latest_slot")
+	require.Equal(t, int64(8), latestSlot)
+
+	// The chain reorganises from slot 6, and moves on to slot 10.
+	client.fork = 1
+	client.forkSlot = 6
+	chainTime.currentSlot = 10
+	newHead := phase0.Root{0x02, 10}
+	s.OnChainReorg(ctx, &apiv1.ChainReorgEvent{
+		Slot:         10,
+		Depth:        5,
+		NewHeadBlock: newHead,
+	})
+
+	// Slots back to the depth of the reorganisation that had already been
+	// processed are fetched again, and later slots are caught up as usual.
+	require.Equal(t, []phase0.Slot{5, 6, 7, 8, 9, 10}, client.takeFetched())
+	latestSlot, _ = db.latest("latest_slot")
+	require.Equal(t, int64(10), latestSlot)
+	require.Equal(t, newHead, s.lastHandledBlockRoot)
+
+	// Blocks from the new fork are stored alongside those they replaced.
+	blocks, err := db.BlocksBySlot(ctx, 5)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	for _, slot := range []phase0.Slot{6, 7, 8} {
+		blocks, err := db.BlocksBySlot(ctx, slot)
+		require.NoError(t, err)
+		require.Len(t, blocks, 2, "slot %d", slot)
+		graffiti := [][]byte{blocks[0].Graffiti[:1], blocks[1].Graffiti[:1]}
+		require.ElementsMatch(t, [][]byte{{0x00}, {0x01}}, graffiti, "slot %d", slot)
+	}
+
+	// A reorganisation deeper than the chain refetches from genesis.
+	s.OnChainReorg(ctx, &apiv1.ChainReorgEvent{Slot: 2, Depth: 5})
+	require.Equal(t, []phase0.Slot{0, 1, 2}, client.takeFetched())
+}
+
+func TestPollFallback(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	db := newHeadersDB(t)
+	client := &reorgClient{headersClient: &headersClient{}}
+	chainTime := &headersChainTime{Service: mockchaintime.New(), currentSlot: 3}
+	s := newEventsService(db, client, chainTime)
+
+	// Events keep arriving, so the poller does not fetch blocks.
+	s.lastEventTime.Store(time.Now().Add(time.Hour).UnixNano())
+	done := make(chan struct{})
+	go func() {
+		s.poll(ctx)
+		close(done)
+	}()
+	time.Sleep(10 * s.pollInterval)
+	require.Empty(t, client.takeFetched())
+
+	// A closed event channel does not count as an event.
+	s.onEvent(ctx, &apiv1.Event{Topic: "head"})
+	time.Sleep(10 * s.pollInterval)
+	require.Empty(t, client.takeFetched())
+
+	// Events stop, so the poller fetches the blocks.
+	s.lastEventTime.Store(time.Now().Add(-time.Hour).UnixNano())
+	require.Eventually(t, func() bool {
+		latestSlot, exists := db.latest("latest_slot")
+		return exists && latestSlot == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, []phase0.Slot{0, 1, 2, 3}, client.takeFetched())
+
+	// The poller stops with its context.
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "poller did not stop")
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// failedItemsModule is the name of this module in failed items.
+const failedItemsModule = "blocks"
+
+// slotError is an error processing the block for a specific slot.
+type slotError struct {
+	slot phase0.Slot
+	err  error
+}
+
+func (e *slotError) Error() string {
+	return fmt.Sprintf("slot %d: %v", e.slot, e.err)
+}
+
+func (e *slotError) Unwrap() error {
+	return e.err
+}
+
+// infrastructureError is an error from the database or beacon node met while
+// processing a block.  It is not a fault of the block, so is not attributed
+// to the block's slot.
+type infrastructureError struct {
+	err error
+}
+
+func (e *infrastructureError) Error() string {
+	return e.err.Error()
+}
+
+func (e *infrastructureError) Unwrap() error {
+	return e.err
+}
+
+// blockError attributes an error processing the block for a slot to that
+// slot, unless the error came from infrastructure.
+func blockError(slot phase0.Slot, err error) error {
+	var infraErr *infrastructureError
+	if errors.As(err, &infraErr) {
+		return err
+	}
+
+	return &slotError{slot: slot, err: err}
+}
+
+// deadSlots provides the slots in the given range that have failed too many
+// times, and so are skipped.
+func (s *Service) deadSlots(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) (map[phase0.Slot]bool, error) {
+	if s.maxAttempts == 0 {
+		return nil, nil
+	}
+
+	from := uint64(startSlot)
+	to := uint64(endSlot)
+	items, err := s.failedItemsProvider.FailedItems(ctx, &chaindb.FailedItemFilter{
+		Modules: []string{failedItemsModule},
+		Kind:    chaindb.FailedItemKindSlot,
+		States:  []string{chaindb.FailedItemStateDead, chaindb.FailedItemStateAcknowledged},
+		From:    &from,
+		To:      &to,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain dead slots")
+	}
+
+	slots := make(map[phase0.Slot]bool, len(items))
+	for _, item := range items {
+		slots[phase0.Slot(item.Item)] = true
+	}
+
+	return slots, nil
+}
+
+// recordFailedSlot records a failure to process the given slot.
+// Returns true if the slot has now failed too many times, and so will be skipped.
+func (s *Service) recordFailedSlot(ctx context.Context, slot phase0.Slot, failure error) bool {
+	if s.maxAttempts == 0 {
+		return false
+	}
+	log := log.With().Uint64("slot", uint64(slot)).Logger()
+
+	item, err := util.RecordFailedItem(ctx, s.chainDB, failedItemsModule, chaindb.FailedItemKindSlot, uint64(slot), failure, s.maxAttempts)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to record failed slot")
+		return false
+	}
+	if item.State != chaindb.FailedItemStateDead {
+		log.Debug().Uint32("attempts", item.Attempts).Msg("Recorded failed slot")
+		return false
+	}
+
+	log.Warn().Uint32("attempts", item.Attempts).Str("error", item.Error).Msg("Slot failed too many times; skipping")
+	return true
+}
+
+// retryFailedSlots processes slots that have been marked for retry.
+func (s *Service) retryFailedSlots(ctx context.Context) {
+	if s.maxAttempts == 0 {
+		return
+	}
+
+	items, err := s.failedItemsProvider.FailedItems(ctx, &chaindb.FailedItemFilter{
+		Modules: []string{failedItemsModule},
+		Kind:    chaindb.FailedItemKindSlot,
+		States:  []string{chaindb.FailedItemStateRetry},
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain slots to retry")
+		return
+	}
+
+	retried := make([]uint64, 0, len(items))
+	for _, item := range items {
+		slot := phase0.Slot(item.Item)
+		if err := s.refetchSlot(ctx, slot); err != nil {
+			log.Warn().Uint64("slot", uint64(slot)).Err(err).Msg("Failed to retry slot")
+			s.recordFailedSlot(ctx, slot, err)
+			continue
+		}
+		log.Info().Uint64("slot", uint64(slot)).Msg("Retried slot")
+		retried = append(retried, item.Item)
+	}
+
+	if err := util.RemoveFailedItems(ctx, s.chainDB, failedItemsModule, chaindb.FailedItemKindSlot, retried); err != nil {
+		log.Error().Err(err).Msg("Failed to remove retried slots")
+	}
+}
+
+// clearFailedSlots removes failing slots that have since been processed.
+func (s *Service) clearFailedSlots(ctx context.Context, md *metadata) {
+	if s.maxAttempts == 0 || md.LatestSlot < 0 {
+		return
+	}
+
+	to := uint64(md.LatestSlot)
+	items, err := s.failedItemsProvider.FailedItems(ctx, &chaindb.FailedItemFilter{
+		Modules: []string{failedItemsModule},
+		Kind:    chaindb.FailedItemKindSlot,
+		States:  []string{chaindb.FailedItemStateFailing},
+		To:      &to,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain failing slots")
+		return
+	}
+
+	processed := make([]uint64, len(items))
+	for i := range items {
+		processed[i] = items[i].Item
+	}
+	if err := util.RemoveFailedItems(ctx, s.chainDB, failedItemsModule, chaindb.FailedItemKindSlot, processed); err != nil {
+		log.Error().Err(err).Msg("Failed to remove processed slots")
+	}
+}
@@ -22,6 +22,7 @@ import (
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/altair"
 	"github.com/attestantio/go-eth2-client/spec/bellatrix"
@@ -29,6 +30,7 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/deneb"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/plugins"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -77,71 +79,210 @@ func (s *Service) OnBeaconChainHeadUpdated(
 	}
 
 	s.catchup(ctx, md)
+	if !s.headersFirst {
+		s.storeOrphanedBlocks(ctx, phase0.Slot(md.LatestSlot))
+	}
 
 	s.lastHandledBlockRoot = blockRoot
 }
 
-// catchup is the general-purpose catchup system.
-func (s *Service) catchup(ctx context.Context, md *metadata) {
-	for slot := phase0.Slot(md.LatestSlot + 1); slot <= s.chainTime.CurrentSlot(); slot++ {
-		if err := s.UpdateSlot(ctx, md, slot); err != nil {
-			log.Error().Uint64("slot", uint64(slot)).Err(err).Msg("Failed to catchup")
+// OnChainReorg receives chain reorganisation notifications.
+func (s *Service) OnChainReorg(ctx context.Context, event *apiv1.ChainReorgEvent) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "OnChainReorg",
+		trace.WithAttributes(
+			attribute.Int64("slot", int64(event.Slot)),
+			attribute.Int64("depth", int64(event.Depth)),
+		))
+	defer span.End()
+
+	log := log.With().Uint64("slot", uint64(event.Slot)).Uint64("depth", event.Depth).Logger()
+
+	// Wait for any active handler to finish, as the reorganised blocks must
+	// be fetched regardless.
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		log.Debug().Err(err).Msg("Failed to acquire semaphore")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	log.Debug().
+		Str("old_head_block", fmt.Sprintf("%#x", event.OldHeadBlock)).
+		Str("new_head_block", fmt.Sprintf("%#x", event.NewHeadBlock)).
+		Msg("Chain reorganisation; refetching blocks")
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain metadata")
+		return
+	}
+
+	// Blocks after the common ancestor may have been replaced, so fetch them
+	// again even if there are blocks in the database for their slots.
+	startSlot := phase0.Slot(0)
+	if uint64(event.Slot) > event.Depth {
+		startSlot = event.Slot - phase0.Slot(event.Depth)
+	}
+	// The body backfill worker also stores blocks when fetching headers
+	// first, so hold it off while refetching.
+	s.bodiesMu.Lock()
+	for slot := startSlot; slot <= event.Slot && int64(slot) <= md.LatestSlot; slot++ {
+		if err := s.refetchSlot(ctx, slot); err != nil {
+			s.bodiesMu.Unlock()
+			log.Error().Uint64("refetch_slot", uint64(slot)).Err(err).Msg("Failed to refetch block")
+			return
+		}
+	}
+	s.bodiesMu.Unlock()
+	if s.headersFirst && int64(startSlot) <= md.LatestHeaderSlot {
+		endSlot := event.Slot
+		if int64(endSlot) > md.LatestHeaderSlot {
+			endSlot = phase0.Slot(md.LatestHeaderSlot)
+		}
+		if err := s.updateHeaders(ctx, md, startSlot, endSlot); err != nil {
+			log.Error().Err(err).Msg("Failed to refetch headers")
 			return
 		}
 	}
-}
 
-// UpdateSlot updates block for the given slot.
-func (s *Service) UpdateSlot(ctx context.Context, md *metadata, slot phase0.Slot) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "UpdateSlot",
-		trace.WithAttributes(
-			attribute.Int64("slot", int64(slot)),
-		))
-	defer span.End()
+	s.catchup(ctx, md)
+	if !s.headersFirst {
+		s.storeOrphanedBlocks(ctx, phase0.Slot(md.LatestSlot))
+	}
+
+	s.lastHandledBlockRoot = event.NewHeadBlock
+}
 
-	// Each slot runs in its own transaction, to make the data available sooner.
+// refetchSlot fetches the block for the given slot regardless of whether the
+// database already holds a block for the slot.
+func (s *Service) refetchSlot(ctx context.Context, slot phase0.Slot) error {
 	ctx, cancel, err := s.chainDB.BeginTx(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to begin transaction")
 	}
 
-	if err := s.updateBlockForSlot(ctx, slot); err != nil {
+	if err := s.updateBlockForSlot(ctx, slot, true); err != nil {
 		cancel()
 		return errors.Wrap(err, "failed to update block")
 	}
-	span.AddEvent("Updated block")
-
-	md.LatestSlot = int64(slot)
-	if err := s.setMetadata(ctx, md); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set metadata")
-	}
-	span.AddEvent("Set metadata")
 
 	if err := s.chainDB.CommitTx(ctx); err != nil {
 		cancel()
 		return errors.Wrap(err, "failed to commit transaction")
 	}
-	span.AddEvent("Committed transaction")
 
-	monitorSlotProcessed(slot)
 	return nil
 }
 
-func (s *Service) updateBlockForSlot(ctx context.Context, slot phase0.Slot) error {
+// catchup is the general-purpose catchup system.
+func (s *Service) catchup(ctx context.Context, md *metadata) {
+	// Headers are fetched for the entire range first, so that the topology
+	// of the chain is available before the bodies are fetched.  The bodies
+	// are filled in by the body backfill worker.
+	if s.headersFirst {
+		if err := s.catchupHeaders(ctx, md); err != nil {
+			log.Error().Uint64("slot", uint64(md.LatestHeaderSlot+1)).Err(err).Msg("Failed to catchup headers")
+		}
+		return
+	}
+
+	s.retryFailedSlots(ctx)
+	if !s.catchupBlocks(ctx, md, s.catchupLimit) {
+		return
+	}
+	s.clearFailedSlots(ctx, md)
+}
+
+// catchupBlocks fetches blocks from the slot after the latest slot in the
+// metadata up to the limit, which moves on as time passes so is checked
+// again after each run of slots.
+// Returns false if the limit was not reached.
+func (s *Service) catchupBlocks(ctx context.Context, md *metadata, limit func() phase0.Slot) bool {
+	for slot := phase0.Slot(md.LatestSlot + 1); slot <= limit(); slot = phase0.Slot(md.LatestSlot + 1) {
+		endSlot := limit()
+		if err := s.processSlots(ctx, md, slot, endSlot, s.batchEnd(endSlot)); err != nil {
+			var slotErr *slotError
+			if errors.As(err, &slotErr) && s.recordFailedSlot(ctx, slotErr.slot, slotErr.err) {
+				// The slot will be skipped from now on, so carry on.
+				continue
+			}
+			log.Error().Uint64("slot", uint64(md.LatestSlot+1)).Uint64("end_slot", uint64(endSlot)).Err(err).Msg("Failed to catchup")
+			return false
+		}
+	}
+
+	return true
+}
+
+// batchEnd returns a function that states if a slot is the last in its batch
+// when catching up to the given end slot.
+// Batches end on a multiple of the batch size, so that for example a batch
+// size of an epoch's worth of slots commits at epoch boundaries.
+func (s *Service) batchEnd(endSlot phase0.Slot) func(phase0.Slot) bool {
+	return func(slot phase0.Slot) bool {
+		return slot == endSlot || uint64(slot)%s.batchSlots == s.batchSlots-1
+	}
+}
+
+// catchupLimit is the latest slot to which to catch up, being the current
+// slot or the end slot if earlier.
+func (s *Service) catchupLimit() phase0.Slot {
+	limit := s.chainTime.CurrentSlot()
+	if s.endSlot >= 0 && phase0.Slot(s.endSlot) < limit {
+		limit = phase0.Slot(s.endSlot)
+	}
+
+	return limit
+}
+
+// UpdateSlot updates block for the given slot.
+func (s *Service) UpdateSlot(ctx context.Context, md *metadata, slot phase0.Slot) error {
+	return s.UpdateSlots(ctx, md, slot, slot)
+}
+
+// UpdateSlots updates blocks for the given range of slots, inclusive, in a single transaction.
+func (s *Service) UpdateSlots(ctx context.Context, md *metadata, startSlot phase0.Slot, endSlot phase0.Slot) error {
+	return s.processSlots(ctx, md, startSlot, endSlot, func(slot phase0.Slot) bool {
+		return slot == endSlot
+	})
+}
+
+// updateBlockForSlot fetches and stores the block for the given slot.
+// This requires the context to hold an active transaction.
+func (s *Service) updateBlockForSlot(ctx context.Context, slot phase0.Slot, refetch bool) error {
 	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "updateBlockForSlot",
 		trace.WithAttributes(
 			attribute.Int64("slot", int64(slot)),
 		))
 	defer span.End()
+
+	signedBlock, err := s.fetchBlock(ctx, slot, refetch)
+	if err != nil {
+		return err
+	}
+	if signedBlock == nil {
+		return nil
+	}
+
+	return s.OnBlock(ctx, signedBlock)
+}
+
+// fetchBlock fetches the block for the given slot from the beacon node.
+// It returns nil if there is no block for the slot, or if the database
+// already holds a block for the slot and it is not being refetched.
+func (s *Service) fetchBlock(ctx context.Context, slot phase0.Slot, refetch bool) (*spec.VersionedSignedBeaconBlock, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "fetchBlock",
+		trace.WithAttributes(
+			attribute.Int64("slot", int64(slot)),
+		))
+	defer span.End()
 	log := log.With().Uint64("slot", uint64(slot)).Logger()
 
 	// Start off by seeing if we already have the block (unless we are re-fetching regardless).
-	if !s.refetch {
+	if !refetch {
 		blocks, err := s.chainDB.(chaindb.BlocksProvider).BlocksBySlot(ctx, slot)
 		if err == nil && len(blocks) > 0 {
 			log.Debug().Msg("Already have this block; not re-fetching")
-			return nil
+			return nil, nil
 		}
 	}
 	span.AddEvent("Checked for block")
@@ -155,14 +296,14 @@ func (s *Service) updateBlockForSlot(ctx context.Context, slot phase0.Slot) erro
 		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
 			// Possible that this is a missed slot, don't error.
 			log.Debug().Msg("No beacon block obtained for slot")
-			return nil
+			return nil, nil
 		}
 
-		return errors.Wrap(err, "failed to obtain beacon block for slot")
+		return nil, errors.Wrap(err, "failed to obtain beacon block for slot")
 	}
 	span.AddEvent("Obtained block")
 
-	return s.OnBlock(ctx, signedBlockResponse.Data)
+	return signedBlockResponse.Data, nil
 }
 
 // OnBlock handles a block.
@@ -171,252 +312,285 @@ func (s *Service) OnBlock(ctx context.Context, signedBlock *spec.VersionedSigned
 	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "OnBlock")
 	defer span.End()
 
-	// Update the block in the database.
 	dbBlock, err := s.dbBlock(ctx, signedBlock)
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain database block")
 	}
+	contents, err := s.blockContents(ctx, signedBlock, dbBlock)
+	if err != nil {
+		return err
+	}
+
+	return s.persistBlock(ctx, signedBlock, dbBlock, contents)
+}
+
+// persistBlock stores a block and its contents.
+// This requires the context to hold an active transaction.
+func (s *Service) persistBlock(ctx context.Context,
+	signedBlock *spec.VersionedSignedBeaconBlock,
+	dbBlock *chaindb.Block,
+	contents *blockContents,
+) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "persistBlock",
+		trace.WithAttributes(
+			attribute.Int64("slot", int64(dbBlock.Slot)),
+		))
+	defer span.End()
+
 	if err := s.blocksSetter.SetBlock(ctx, dbBlock); err != nil {
 		return errors.Wrap(err, "failed to set block")
 	}
+	if s.rawBlocks {
+		if err := s.storeRawBlock(ctx, signedBlock, dbBlock); err != nil {
+			return errors.Wrap(err, "failed to store raw block")
+		}
+	}
+
+	if err := s.setBlockContents(ctx, dbBlock, contents); err != nil {
+		return err
+	}
+
+	if s.plugins != nil {
+		if err := s.plugins.OnBlock(ctx, &plugins.Block{
+			SignedBlock: signedBlock,
+			Block:       dbBlock,
+		}); err != nil {
+			return errors.Wrap(err, "failed to pass block to plugins")
+		}
+	}
+
+	return nil
+}
+
+// onBlockContents handles the contents of a block that has been stored.
+func (s *Service) onBlockContents(ctx context.Context, signedBlock *spec.VersionedSignedBeaconBlock, dbBlock *chaindb.Block) error {
+	contents, err := s.blockContents(ctx, signedBlock, dbBlock)
+	if err != nil {
+		return err
+	}
+
+	return s.setBlockContents(ctx, dbBlock, contents)
+}
+
+// blockContents holds the database representations of the contents of a block.
+type blockContents struct {
+	attestations      []*chaindb.Attestation
+	proposerSlashings []*chaindb.ProposerSlashing
+	attesterSlashings []*chaindb.AttesterSlashing
+	deposits          []*chaindb.Deposit
+	voluntaryExits    []*chaindb.VoluntaryExit
+	syncAggregate     *chaindb.SyncAggregate
+	blobSidecars      []*chaindb.BlobSidecar
+}
+
+// blockContents obtains the database representations of the contents of a block.
+func (s *Service) blockContents(ctx context.Context, signedBlock *spec.VersionedSignedBeaconBlock, dbBlock *chaindb.Block) (*blockContents, error) {
 	switch signedBlock.Version {
 	case spec.DataVersionPhase0:
-		return s.onBlockPhase0(ctx, signedBlock.Phase0, dbBlock)
+		return s.blockContentsPhase0(ctx, signedBlock.Phase0, dbBlock)
 	case spec.DataVersionAltair:
-		return s.onBlockAltair(ctx, signedBlock.Altair, dbBlock)
+		return s.blockContentsAltair(ctx, signedBlock.Altair, dbBlock)
 	case spec.DataVersionBellatrix:
-		return s.onBlockBellatrix(ctx, signedBlock.Bellatrix, dbBlock)
+		return s.blockContentsBellatrix(ctx, signedBlock.Bellatrix, dbBlock)
 	case spec.DataVersionCapella:
-		return s.onBlockCapella(ctx, signedBlock.Capella, dbBlock)
+		return s.blockContentsCapella(ctx, signedBlock.Capella, dbBlock)
 	case spec.DataVersionDeneb:
-		return s.onBlockDeneb(ctx, signedBlock.Deneb, dbBlock)
+		return s.blockContentsDeneb(ctx, signedBlock.Deneb, dbBlock)
 	case spec.DataVersionUnknown:
-		return errors.New("unknown block version")
+		return nil, errors.New("unknown block version")
 	default:
-		return fmt.Errorf("unhandled block version %v", signedBlock.Version)
+		return nil, fmt.Errorf("unhandled block version %v", signedBlock.Version)
 	}
 }
 
-func (s *Service) onBlockPhase0(ctx context.Context, signedBlock *phase0.SignedBeaconBlock, dbBlock *chaindb.Block) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "OnBlockPhase0")
+func (s *Service) blockContentsPhase0(ctx context.Context, signedBlock *phase0.SignedBeaconBlock, dbBlock *chaindb.Block) (*blockContents, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "blockContentsPhase0")
 	defer span.End()
 
-	if err := s.updateAttestationsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.Attestations); err != nil {
-		return errors.Wrap(err, "failed to update attestations")
-	}
-	if err := s.updateProposerSlashingsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.ProposerSlashings); err != nil {
-		return errors.Wrap(err, "failed to update proposer slashings")
-	}
-	if err := s.updateAttesterSlashingsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.AttesterSlashings); err != nil {
-		return errors.Wrap(err, "failed to update attester slashings")
-	}
-	if err := s.updateDepositsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.Deposits); err != nil {
-		return errors.Wrap(err, "failed to update deposits")
-	}
-	if err := s.updateVoluntaryExitsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.VoluntaryExits); err != nil {
-		return errors.Wrap(err, "failed to update voluntary exits")
+	slot := signedBlock.Message.Slot
+	body := signedBlock.Message.Body
+	contents := &blockContents{
+		attesterSlashings: s.dbAttesterSlashings(ctx, slot, dbBlock.Root, body.AttesterSlashings),
+		deposits:          s.dbDeposits(ctx, slot, dbBlock.Root, body.Deposits),
+		voluntaryExits:    s.dbVoluntaryExits(ctx, slot, dbBlock.Root, body.VoluntaryExits),
 	}
-	return nil
+	var err error
+	if contents.attestations, err = s.dbAttestations(ctx, slot, dbBlock.Root, body.Attestations); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attestations")
+	}
+	if contents.proposerSlashings, err = s.dbProposerSlashings(ctx, slot, dbBlock.Root, body.ProposerSlashings); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain proposer slashings")
+	}
+
+	return contents, nil
 }
 
-func (s *Service) onBlockAltair(ctx context.Context, signedBlock *altair.SignedBeaconBlock, dbBlock *chaindb.Block) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "OnBlockAltair")
+func (s *Service) blockContentsAltair(ctx context.Context, signedBlock *altair.SignedBeaconBlock, dbBlock *chaindb.Block) (*blockContents, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "blockContentsAltair")
 	defer span.End()
 
-	if err := s.updateAttestationsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.Attestations); err != nil {
-		return errors.Wrap(err, "failed to update attestations")
-	}
-	if err := s.updateProposerSlashingsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.ProposerSlashings); err != nil {
-		return errors.Wrap(err, "failed to update proposer slashings")
-	}
-	if err := s.updateAttesterSlashingsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.AttesterSlashings); err != nil {
-		return errors.Wrap(err, "failed to update attester slashings")
-	}
-	if err := s.updateDepositsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.Deposits); err != nil {
-		return errors.Wrap(err, "failed to update deposits")
-	}
-	if err := s.updateVoluntaryExitsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.VoluntaryExits); err != nil {
-		return errors.Wrap(err, "failed to update voluntary exits")
-	}
-	if err := s.updateSyncAggregateForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.SyncAggregate); err != nil {
-		return errors.Wrap(err, "failed to update sync aggregate")
+	slot := signedBlock.Message.Slot
+	body := signedBlock.Message.Body
+	contents := &blockContents{
+		attesterSlashings: s.dbAttesterSlashings(ctx, slot, dbBlock.Root, body.AttesterSlashings),
+		deposits:          s.dbDeposits(ctx, slot, dbBlock.Root, body.Deposits),
+		voluntaryExits:    s.dbVoluntaryExits(ctx, slot, dbBlock.Root, body.VoluntaryExits),
 	}
-	return nil
+	var err error
+	if contents.attestations, err = s.dbAttestations(ctx, slot, dbBlock.Root, body.Attestations); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attestations")
+	}
+	if contents.proposerSlashings, err = s.dbProposerSlashings(ctx, slot, dbBlock.Root, body.ProposerSlashings); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain proposer slashings")
+	}
+	if contents.syncAggregate, err = s.dbSyncAggregate(ctx, slot, dbBlock.Root, body.SyncAggregate); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain sync aggregate")
+	}
+
+	return contents, nil
 }
 
-func (s *Service) onBlockBellatrix(ctx context.Context, signedBlock *bellatrix.SignedBeaconBlock, dbBlock *chaindb.Block) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "OnBlockBellatrix")
+func (s *Service) blockContentsBellatrix(ctx context.Context, signedBlock *bellatrix.SignedBeaconBlock, dbBlock *chaindb.Block) (*blockContents, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "blockContentsBellatrix")
 	defer span.End()
 
-	if err := s.updateAttestationsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.Attestations); err != nil {
-		return errors.Wrap(err, "failed to update attestations")
-	}
-	if err := s.updateProposerSlashingsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.ProposerSlashings); err != nil {
-		return errors.Wrap(err, "failed to update proposer slashings")
-	}
-	if err := s.updateAttesterSlashingsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.AttesterSlashings); err != nil {
-		return errors.Wrap(err, "failed to update attester slashings")
-	}
-	if err := s.updateDepositsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.Deposits); err != nil {
-		return errors.Wrap(err, "failed to update deposits")
-	}
-	if err := s.updateVoluntaryExitsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.VoluntaryExits); err != nil {
-		return errors.Wrap(err, "failed to update voluntary exits")
-	}
-	if err := s.updateSyncAggregateForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.SyncAggregate); err != nil {
-		return errors.Wrap(err, "failed to update sync aggregate")
+	slot := signedBlock.Message.Slot
+	body := signedBlock.Message.Body
+	contents := &blockContents{
+		attesterSlashings: s.dbAttesterSlashings(ctx, slot, dbBlock.Root, body.AttesterSlashings),
+		deposits:          s.dbDeposits(ctx, slot, dbBlock.Root, body.Deposits),
+		voluntaryExits:    s.dbVoluntaryExits(ctx, slot, dbBlock.Root, body.VoluntaryExits),
 	}
-	return nil
+	var err error
+	if contents.attestations, err = s.dbAttestations(ctx, slot, dbBlock.Root, body.Attestations); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attestations")
+	}
+	if contents.proposerSlashings, err = s.dbProposerSlashings(ctx, slot, dbBlock.Root, body.ProposerSlashings); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain proposer slashings")
+	}
+	if contents.syncAggregate, err = s.dbSyncAggregate(ctx, slot, dbBlock.Root, body.SyncAggregate); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain sync aggregate")
+	}
+
+	return contents, nil
 }
 
-func (s *Service) onBlockCapella(ctx context.Context, signedBlock *capella.SignedBeaconBlock, dbBlock *chaindb.Block) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "OnBlockCapella")
+func (s *Service) blockContentsCapella(ctx context.Context, signedBlock *capella.SignedBeaconBlock, dbBlock *chaindb.Block) (*blockContents, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "blockContentsCapella")
 	defer span.End()
 
-	if err := s.updateAttestationsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.Attestations); err != nil {
-		return errors.Wrap(err, "failed to update attestations")
-	}
-	if err := s.updateProposerSlashingsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.ProposerSlashings); err != nil {
-		return errors.Wrap(err, "failed to update proposer slashings")
-	}
-	if err := s.updateAttesterSlashingsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.AttesterSlashings); err != nil {
-		return errors.Wrap(err, "failed to update attester slashings")
-	}
-	if err := s.updateDepositsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.Deposits); err != nil {
-		return errors.Wrap(err, "failed to update deposits")
-	}
-	if err := s.updateVoluntaryExitsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.VoluntaryExits); err != nil {
-		return errors.Wrap(err, "failed to update voluntary exits")
-	}
-	if err := s.updateSyncAggregateForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.SyncAggregate); err != nil {
-		return errors.Wrap(err, "failed to update sync aggregate")
+	slot := signedBlock.Message.Slot
+	body := signedBlock.Message.Body
+	contents := &blockContents{
+		attesterSlashings: s.dbAttesterSlashings(ctx, slot, dbBlock.Root, body.AttesterSlashings),
+		deposits:          s.dbDeposits(ctx, slot, dbBlock.Root, body.Deposits),
+		voluntaryExits:    s.dbVoluntaryExits(ctx, slot, dbBlock.Root, body.VoluntaryExits),
 	}
-	return nil
+	var err error
+	if contents.attestations, err = s.dbAttestations(ctx, slot, dbBlock.Root, body.Attestations); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attestations")
+	}
+	if contents.proposerSlashings, err = s.dbProposerSlashings(ctx, slot, dbBlock.Root, body.ProposerSlashings); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain proposer slashings")
+	}
+	if contents.syncAggregate, err = s.dbSyncAggregate(ctx, slot, dbBlock.Root, body.SyncAggregate); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain sync aggregate")
+	}
+
+	return contents, nil
 }
 
-func (s *Service) onBlockDeneb(ctx context.Context, signedBlock *deneb.SignedBeaconBlock, dbBlock *chaindb.Block) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "OnBlockDeneb")
+func (s *Service) blockContentsDeneb(ctx context.Context, signedBlock *deneb.SignedBeaconBlock, dbBlock *chaindb.Block) (*blockContents, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "blockContentsDeneb")
 	defer span.End()
 
-	if err := s.updateAttestationsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.Attestations); err != nil {
-		return errors.Wrap(err, "failed to update attestations")
-	}
-	if err := s.updateProposerSlashingsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.ProposerSlashings); err != nil {
-		return errors.Wrap(err, "failed to update proposer slashings")
-	}
-	if err := s.updateAttesterSlashingsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.AttesterSlashings); err != nil {
-		return errors.Wrap(err, "failed to update attester slashings")
-	}
-	if err := s.updateDepositsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.Deposits); err != nil {
-		return errors.Wrap(err, "failed to update deposits")
-	}
-	if err := s.updateVoluntaryExitsForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.VoluntaryExits); err != nil {
-		return errors.Wrap(err, "failed to update voluntary exits")
-	}
-	if err := s.updateSyncAggregateForBlock(ctx,
-		signedBlock.Message.Slot,
-		dbBlock.Root,
-		signedBlock.Message.Body.SyncAggregate); err != nil {
-		return errors.Wrap(err, "failed to update sync aggregate")
-	}
-	if len(signedBlock.Message.Body.BlobKZGCommitments) > 0 {
-		if err := s.updateBlobSidecarsForBlock(ctx, dbBlock.Root); err != nil {
-			return errors.Wrap(err, "failed to update blob sidecars")
+	slot := signedBlock.Message.Slot
+	body := signedBlock.Message.Body
+	contents := &blockContents{
+		attesterSlashings: s.dbAttesterSlashings(ctx, slot, dbBlock.Root, body.AttesterSlashings),
+		deposits:          s.dbDeposits(ctx, slot, dbBlock.Root, body.Deposits),
+		voluntaryExits:    s.dbVoluntaryExits(ctx, slot, dbBlock.Root, body.VoluntaryExits),
+	}
+	var err error
+	if contents.attestations, err = s.dbAttestations(ctx, slot, dbBlock.Root, body.Attestations); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attestations")
+	}
+	if contents.proposerSlashings, err = s.dbProposerSlashings(ctx, slot, dbBlock.Root, body.ProposerSlashings); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain proposer slashings")
+	}
+	if contents.syncAggregate, err = s.dbSyncAggregate(ctx, slot, dbBlock.Root, body.SyncAggregate); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain sync aggregate")
+	}
+	if s.blobSidecars && len(body.BlobKZGCommitments) > 0 {
+		if contents.blobSidecars, err = s.dbBlobSidecars(ctx, dbBlock.Root); err != nil {
+			return nil, errors.Wrap(err, "failed to obtain blob sidecars")
+		}
+	}
+
+	return contents, nil
+}
+
+// setBlockContents stores the contents of a block.
+// This requires the context to hold an active transaction.
+func (s *Service) setBlockContents(ctx context.Context, dbBlock *chaindb.Block, contents *blockContents) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "setBlockContents")
+	defer span.End()
+
+	if err := s.setArrival(ctx, dbBlock.Root); err != nil {
+		return errors.Wrap(err, "failed to set block arrival")
+	}
+
+	if err := s.attestationsSetter.SetAttestations(ctx, contents.attestations); err != nil {
+		log.Debug().Err(err).Msg("Failed to set attestations en masse, setting individually")
+		for _, dbAttestation := range contents.attestations {
+			if err := s.attestationsSetter.SetAttestation(ctx, dbAttestation); err != nil {
+				return errors.Wrap(err, "failed to set attestation")
+			}
+		}
+	}
+	for _, dbProposerSlashing := range contents.proposerSlashings {
+		if err := s.proposerSlashingsSetter.SetProposerSlashing(ctx, dbProposerSlashing); err != nil {
+			return errors.Wrap(err, "failed to set proposer slashing")
+		}
+	}
+	for _, dbAttesterSlashing := range contents.attesterSlashings {
+		if err := s.attesterSlashingsSetter.SetAttesterSlashing(ctx, dbAttesterSlashing); err != nil {
+			return errors.Wrap(err, "failed to set attester slashing")
+		}
+	}
+	for _, dbDeposit := range contents.deposits {
+		if err := s.depositsSetter.SetDeposit(ctx, dbDeposit); err != nil {
+			return errors.Wrap(err, "failed to set deposit")
+		}
+	}
+	for _, dbVoluntaryExit := range contents.voluntaryExits {
+		if err := s.voluntaryExitsSetter.SetVoluntaryExit(ctx, dbVoluntaryExit); err != nil {
+			return errors.Wrap(err, "failed to set voluntary exit")
+		}
+	}
+	if contents.syncAggregate != nil {
+		if err := s.syncAggregateSetter.SetSyncAggregate(ctx, contents.syncAggregate); err != nil {
+			return errors.Wrap(err, "failed to set sync aggregate")
 		}
 	}
+	if len(contents.blobSidecars) > 0 {
+		if err := s.blobSidecarsSetter.SetBlobSidecars(ctx, contents.blobSidecars); err != nil {
+			return errors.Wrap(err, "failed to set blob sidecars")
+		}
+	}
+
 	return nil
 }
 
-func (s *Service) updateAttestationsForBlock(ctx context.Context,
+func (s *Service) dbAttestations(ctx context.Context,
 	slot phase0.Slot,
 	blockRoot phase0.Root,
 	attestations []*phase0.Attestation,
-) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "updateAttestationsForBlock")
+) (
+	[]*chaindb.Attestation,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "dbAttestations")
 	defer span.End()
 
 	var err error
@@ -432,7 +606,7 @@ func (s *Service) updateAttestationsForBlock(ctx context.Context,
 		To:   &slot,
 	})
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain beacon committees")
+		return nil, &infrastructureError{err: errors.Wrap(err, "failed to obtain beacon committees")}
 	}
 	for _, bc := range bcs {
 		if _, exists := beaconCommittees[bc.Slot]; !exists {
@@ -445,121 +619,86 @@ func (s *Service) updateAttestationsForBlock(ctx context.Context,
 	for i, attestation := range attestations {
 		dbAttestations[i], err = s.dbAttestation(ctx, slot, blockRoot, uint64(i), attestation, beaconCommittees)
 		if err != nil {
-			return errors.Wrap(err, "failed to obtain database attestation")
+			return nil, errors.Wrap(err, "failed to obtain database attestation")
 		}
 	}
-	if err := s.attestationsSetter.SetAttestations(ctx, dbAttestations); err != nil {
-		log.Debug().Err(err).Msg("Failed to set attestations en masse, setting individually")
-		for _, dbAttestation := range dbAttestations {
-			if err := s.attestationsSetter.SetAttestation(ctx, dbAttestation); err != nil {
-				return errors.Wrap(err, "failed to set attestation")
-			}
-		}
-	}
-	return nil
+
+	return dbAttestations, nil
 }
 
-func (s *Service) updateProposerSlashingsForBlock(ctx context.Context,
+func (s *Service) dbProposerSlashings(ctx context.Context,
 	slot phase0.Slot,
 	blockRoot phase0.Root,
 	proposerSlashings []*phase0.ProposerSlashing,
-) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "updateProposerSlashingsForBlock")
-	defer span.End()
-
+) (
+	[]*chaindb.ProposerSlashing,
+	error,
+) {
+	dbProposerSlashings := make([]*chaindb.ProposerSlashing, len(proposerSlashings))
 	for i, proposerSlashing := range proposerSlashings {
-		dbProposerSlashing, err := s.dbProposerSlashing(ctx, slot, blockRoot, uint64(i), proposerSlashing)
+		var err error
+		dbProposerSlashings[i], err = s.dbProposerSlashing(ctx, slot, blockRoot, uint64(i), proposerSlashing)
 		if err != nil {
-			return errors.Wrap(err, "failed to obtain database proposer slashing")
-		}
-		if err := s.proposerSlashingsSetter.SetProposerSlashing(ctx, dbProposerSlashing); err != nil {
-			return errors.Wrap(err, "failed to set proposer slashing")
+			return nil, errors.Wrap(err, "failed to obtain database proposer slashing")
 		}
 	}
-	return nil
+
+	return dbProposerSlashings, nil
 }
 
-func (s *Service) updateAttesterSlashingsForBlock(ctx context.Context,
+func (s *Service) dbAttesterSlashings(ctx context.Context,
 	slot phase0.Slot,
 	blockRoot phase0.Root,
 	attesterSlashings []*phase0.AttesterSlashing,
-) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "updateAttesterSlashingsForBlock")
-	defer span.End()
-
+) []*chaindb.AttesterSlashing {
+	dbAttesterSlashings := make([]*chaindb.AttesterSlashing, len(attesterSlashings))
 	for i, attesterSlashing := range attesterSlashings {
-		dbAttesterSlashing := s.dbAttesterSlashing(ctx, slot, blockRoot, uint64(i), attesterSlashing)
-		if err := s.attesterSlashingsSetter.SetAttesterSlashing(ctx, dbAttesterSlashing); err != nil {
-			return errors.Wrap(err, "failed to set attester slashing")
-		}
+		dbAttesterSlashings[i] = s.dbAttesterSlashing(ctx, slot, blockRoot, uint64(i), attesterSlashing)
 	}
-	return nil
+
+	return dbAttesterSlashings
 }
 
-func (s *Service) updateDepositsForBlock(ctx context.Context,
+func (s *Service) dbDeposits(ctx context.Context,
 	slot phase0.Slot,
 	blockRoot phase0.Root,
 	deposits []*phase0.Deposit,
-) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "updateDepositssForBlock")
-	defer span.End()
-
+) []*chaindb.Deposit {
+	dbDeposits := make([]*chaindb.Deposit, len(deposits))
 	for i, deposit := range deposits {
-		dbDeposit := s.dbDeposit(ctx, slot, blockRoot, uint64(i), deposit)
-		if err := s.depositsSetter.SetDeposit(ctx, dbDeposit); err != nil {
-			return errors.Wrap(err, "failed to set deposit")
-		}
+		dbDeposits[i] = s.dbDeposit(ctx, slot, blockRoot, uint64(i), deposit)
 	}
-	return nil
+
+	return dbDeposits
 }
 
-func (s *Service) updateVoluntaryExitsForBlock(ctx context.Context,
+func (s *Service) dbVoluntaryExits(ctx context.Context,
 	slot phase0.Slot,
 	blockRoot phase0.Root,
 	voluntaryExits []*phase0.SignedVoluntaryExit,
-) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "updateVoluntaryExitsForBlock")
-	defer span.End()
-
+) []*chaindb.VoluntaryExit {
+	dbVoluntaryExits := make([]*chaindb.VoluntaryExit, len(voluntaryExits))
 	for i, voluntaryExit := range voluntaryExits {
-		dbVoluntaryExit := s.dbVoluntaryExit(ctx, slot, blockRoot, uint64(i), voluntaryExit)
-		if err := s.voluntaryExitsSetter.SetVoluntaryExit(ctx, dbVoluntaryExit); err != nil {
-			return errors.Wrap(err, "failed to set voluntary exit")
-		}
-	}
-	return nil
-}
-
-func (s *Service) updateSyncAggregateForBlock(ctx context.Context,
-	slot phase0.Slot,
-	blockRoot phase0.Root,
-	syncAggregate *altair.SyncAggregate,
-) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "updateSyncAggregateForBlock")
-	defer span.End()
-
-	dbSyncAggregate, err := s.dbSyncAggregate(ctx, slot, blockRoot, syncAggregate)
-	if err != nil {
-		return errors.Wrap(err, "failed to obtain database sync aggregate")
+		dbVoluntaryExits[i] = s.dbVoluntaryExit(ctx, slot, blockRoot, uint64(i), voluntaryExit)
 	}
 
-	if err := s.syncAggregateSetter.SetSyncAggregate(ctx, dbSyncAggregate); err != nil {
-		return errors.Wrap(err, "failed to set sync aggregate")
-	}
-	return nil
+	return dbVoluntaryExits
 }
 
-func (s *Service) updateBlobSidecarsForBlock(ctx context.Context,
+func (s *Service) dbBlobSidecars(ctx context.Context,
 	blockRoot phase0.Root,
-) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "updateBlobSidecarsForBlock")
+) (
+	[]*chaindb.BlobSidecar,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "dbBlobSidecars")
 	defer span.End()
 
 	response, err := s.eth2Client.(eth2client.BlobSidecarsProvider).BlobSidecars(ctx, &api.BlobSidecarsOpts{
 		Block: blockRoot.String(),
 	})
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain beacon block blobs")
+		return nil, &infrastructureError{err: errors.Wrap(err, "failed to obtain beacon block blobs")}
 	}
 
 	dbBlobSidecars := make([]*chaindb.BlobSidecar, len(response.Data))
@@ -567,11 +706,7 @@ func (s *Service) updateBlobSidecarsForBlock(ctx context.Context,
 		dbBlobSidecars[i] = s.dbBlobSidecar(ctx, blockRoot, response.Data[i])
 	}
 
-	if err := s.blobSidecarsSetter.SetBlobSidecars(ctx, dbBlobSidecars); err != nil {
-		return errors.Wrap(err, "failed to set blob sidecars")
-	}
-
-	return nil
+	return dbBlobSidecars, nil
 }
 
 func (s *Service) dbBlock(
@@ -730,6 +865,8 @@ func (*Service) dbBlockBellatrix(
 		},
 	}
 
+	setTransactionStats(dbBlock.ExecutionPayload, block.Body.ExecutionPayload.Transactions)
+
 	return dbBlock, nil
 }
 
@@ -818,6 +955,8 @@ func (*Service) dbBlockCapella(
 		BLSToExecutionChanges: blsToExecutionChanges,
 	}
 
+	setTransactionStats(dbBlock.ExecutionPayload, block.Body.ExecutionPayload.Transactions)
+
 	return dbBlock, nil
 }
 
@@ -901,9 +1040,31 @@ func (*Service) dbBlockDeneb(
 		BlobKZGCommitments:    block.Body.BlobKZGCommitments,
 	}
 
+	setTransactionStats(dbBlock.ExecutionPayload, block.Body.ExecutionPayload.Transactions)
+
 	return dbBlock, nil
 }
 
+// blobTransactionType is the EIP-2718 type of blob-carrying transactions.
+const blobTransactionType = 0x03
+
+// setTransactionStats sets the transaction statistics of an execution payload.
+func setTransactionStats(payload *chaindb.ExecutionPayload, transactions []bellatrix.Transaction) {
+	count := uint64(len(transactions))
+	size := uint64(0)
+	blobCount := uint64(0)
+	for _, transaction := range transactions {
+		size += uint64(len(transaction))
+		if len(transaction) > 0 && transaction[0] == blobTransactionType {
+			blobCount++
+		}
+	}
+
+	payload.TransactionCount = &count
+	payload.TransactionsSize = &size
+	payload.BlobTransactionCount = &blobCount
+}
+
 func (s *Service) dbAttestation(
 	ctx context.Context,
 	inclusionSlot phase0.Slot,
@@ -966,7 +1127,7 @@ func (s *Service) dbSyncAggregate(
 		syncCommittee, err = s.syncCommitteesProvider.SyncCommittee(ctx, period)
 		if err != nil {
 			log.Warn().Err(err).Uint64("slot", uint64(slot)).Uint64("sync_committee_period", period).Msg("Failed to obtain sync committee period")
-			return nil, errors.Wrap(err, "failed to obtain sync committee")
+			return nil, &infrastructureError{err: errors.Wrap(err, "failed to obtain sync committee")}
 		}
 		s.syncCommittees[period] = syncCommittee
 		// Remove older sync committee.
@@ -987,6 +1148,7 @@ func (s *Service) dbSyncAggregate(
 		InclusionBlockRoot: blockRoot,
 		Bits:               syncAggregate.SyncCommitteeBits,
 		Indices:            indices,
+		Signature:          &syncAggregate.SyncCommitteeSignature,
 	}
 
 	return dbSyncAggregate, nil
@@ -1147,12 +1309,15 @@ func (s *Service) beaconCommittee(ctx context.Context,
 		beaconCommittees[slot][index] = beaconCommittee
 		return beaconCommittee, nil
 	}
+	if s.eth2Client == nil {
+		return nil, fmt.Errorf("no beacon committees for slot %d in the database", slot)
+	}
 	// Try to fetch from the chain.
 	chainBeaconCommitteesResponse, err := s.eth2Client.(eth2client.BeaconCommitteesProvider).BeaconCommittees(ctx, &api.BeaconCommitteesOpts{
 		State: fmt.Sprintf("%d", slot),
 	})
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to fetch beacon committees")
+		return nil, &infrastructureError{err: errors.Wrap(err, "failed to fetch beacon committees")}
 	}
 	chainBeaconCommittees := chainBeaconCommitteesResponse.Data
 	log.Debug().Uint64("slot", uint64(slot)).Msg("Obtained beacon committees from API")
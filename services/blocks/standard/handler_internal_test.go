@@ -0,0 +1,104 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestSetTransactionStats(t *testing.T) {
+	// Legacy transactions are RLP lists, so start with a byte of at least 0xc0.
+	legacy := bellatrix.Transaction{0xf8, 0x6b, 0x01, 0x02}
+	dynamicFee := bellatrix.Transaction{0x02, 0xf8, 0x72, 0x01, 0x02}
+	blob := bellatrix.Transaction{0x03, 0xf9, 0x01, 0x02, 0x03, 0x04}
+
+	tests := []struct {
+		name         string
+		transactions []bellatrix.Transaction
+		count        uint64
+		size         uint64
+		blobCount    uint64
+	}{
+		{
+			name: "Nil",
+		},
+		{
+			name:         "NoTransactions",
+			transactions: []bellatrix.Transaction{},
+		},
+		{
+			name:         "Legacy",
+			transactions: []bellatrix.Transaction{legacy},
+			count:        1,
+			size:         4,
+		},
+		{
+			name:         "DynamicFee",
+			transactions: []bellatrix.Transaction{dynamicFee},
+			count:        1,
+			size:         5,
+		},
+		{
+			name:         "Blob",
+			transactions: []bellatrix.Transaction{blob},
+			count:        1,
+			size:         6,
+			blobCount:    1,
+		},
+		{
+			name:         "Mixed",
+			transactions: []bellatrix.Transaction{legacy, blob, dynamicFee, blob},
+			count:        4,
+			size:         21,
+			blobCount:    2,
+		},
+		{
+			name:         "EmptyTransaction",
+			transactions: []bellatrix.Transaction{{}, legacy},
+			count:        2,
+			size:         4,
+		},
+		{
+			// Only the type is inspected, so a truncated blob transaction is still counted as one.
+			name:         "TruncatedBlob",
+			transactions: []bellatrix.Transaction{{0x03}},
+			count:        1,
+			size:         1,
+			blobCount:    1,
+		},
+		{
+			name:         "UnknownType",
+			transactions: []bellatrix.Transaction{{0x7f, 0x01}},
+			count:        1,
+			size:         2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload := &chaindb.ExecutionPayload{}
+			setTransactionStats(payload, test.transactions)
+			require.NotNil(t, payload.TransactionCount)
+			require.Equal(t, test.count, *payload.TransactionCount)
+			require.NotNil(t, payload.TransactionsSize)
+			require.Equal(t, test.size, *payload.TransactionsSize)
+			require.NotNil(t, payload.BlobTransactionCount)
+			require.Equal(t, test.blobCount, *payload.BlobTransactionCount)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
+)
+
+// headerBatchSlots is the number of slots whose headers are written in a
+// single database transaction.  Headers are small, so are written in larger
+// batches than blocks.
+const headerBatchSlots = phase0.Slot(1024)
+
+// catchupHeaders fetches the headers of blocks up to the catchup limit,
+// ahead of their bodies.  The body backfill worker is woken after each
+// batch, so bodies are filled in behind the headers.
+func (s *Service) catchupHeaders(ctx context.Context, md *metadata) error {
+	// The limit moves on as time passes, so continue until it has been reached.
+	for slot := phase0.Slot(md.LatestHeaderSlot + 1); slot <= s.catchupLimit(); slot = phase0.Slot(md.LatestHeaderSlot + 1) {
+		endSlot := slot + headerBatchSlots - 1
+		if limit := s.catchupLimit(); endSlot > limit {
+			endSlot = limit
+		}
+		if err := s.updateHeaders(ctx, md, slot, endSlot); err != nil {
+			return err
+		}
+		s.triggerBodyBackfill()
+	}
+
+	return nil
+}
+
+// updateHeaders fetches and stores the headers of blocks for the given range
+// of slots, inclusive, in a single transaction.
+func (s *Service) updateHeaders(ctx context.Context, md *metadata, startSlot phase0.Slot, endSlot phase0.Slot) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "updateHeaders",
+		trace.WithAttributes(
+			attribute.Int64("start_slot", int64(startSlot)),
+			attribute.Int64("end_slot", int64(endSlot)),
+		))
+	defer span.End()
+
+	headers, err := s.fetchHeaders(ctx, startSlot, endSlot)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if len(headers) > 0 {
+		if err := s.blockHeadersSetter.SetBlockHeaders(ctx, headers); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set block headers")
+		}
+	}
+
+	if int64(endSlot) > md.LatestHeaderSlot {
+		md.LatestHeaderSlot = int64(endSlot)
+	}
+	if err := s.setHeaderMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	log.Trace().Uint64("start_slot", uint64(startSlot)).Uint64("end_slot", uint64(endSlot)).Int("headers", len(headers)).Msg("Stored headers")
+
+	return nil
+}
+
+// fetchHeaders fetches the headers of blocks for the given range of slots,
+// inclusive, from the beacon node.  Headers are returned in slot order, and
+// slots without a block are omitted.
+func (s *Service) fetchHeaders(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.BlockHeader, error) {
+	slotHeaders := make([]*chaindb.BlockHeader, endSlot+1-startSlot)
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(int(s.headerFetchers))
+	for slot := startSlot; slot <= endSlot; slot++ {
+		g.Go(func() error {
+			header, err := s.fetchHeader(ctx, slot)
+			if err != nil {
+				return errors.Wrapf(err, "failed to fetch header for slot %d", slot)
+			}
+			slotHeaders[slot-startSlot] = header
+
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	headers := make([]*chaindb.BlockHeader, 0, len(slotHeaders))
+	for _, header := range slotHeaders {
+		if header != nil {
+			headers = append(headers, header)
+		}
+	}
+
+	return headers, nil
+}
+
+// fetchHeader fetches the header of the block for the given slot from the
+// beacon node.  It returns nil if there is no block for the slot.
+func (s *Service) fetchHeader(ctx context.Context, slot phase0.Slot) (*chaindb.BlockHeader, error) {
+	headerResponse, err := s.headersProvider.BeaconBlockHeader(ctx, &api.BeaconBlockHeaderOpts{
+		Block: fmt.Sprintf("%d", slot),
+	})
+	if err != nil {
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			// Possible that this is a missed slot, don't error.
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	message := headerResponse.Data.Header.Message
+	return &chaindb.BlockHeader{
+		Slot:          message.Slot,
+		ProposerIndex: message.ProposerIndex,
+		Root:          headerResponse.Data.Root,
+		ParentRoot:    message.ParentRoot,
+		StateRoot:     message.StateRoot,
+		BodyRoot:      message.BodyRoot,
+	}, nil
+}
@@ -0,0 +1,329 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/chaintime"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+// headersClient is a beacon node that serves headers and blocks for all
+// slots other than its missed slots, and counts the blocks fetched.
+type headersClient struct {
+	missed       map[phase0.Slot]bool
+	blockFetches atomic.Int64
+}
+
+func (*headersClient) Name() string {
+	return "headers"
+}
+
+func (*headersClient) Address() string {
+	return "headers"
+}
+
+func (c *headersClient) slot(block string) (phase0.Slot, error) {
+	slot, err := strconv.ParseUint(block, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if c.missed[phase0.Slot(slot)] {
+		return 0, &api.Error{StatusCode: http.StatusNotFound}
+	}
+
+	return phase0.Slot(slot), nil
+}
+
+func (c *headersClient) BeaconBlockHeader(_ context.Context,
+	opts *api.BeaconBlockHeaderOpts,
+) (
+	*api.Response[*apiv1.BeaconBlockHeader],
+	error,
+) {
+	slot, err := c.slot(opts.Block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.Response[*apiv1.BeaconBlockHeader]{
+		Data: &apiv1.BeaconBlockHeader{
+			Root: phase0.Root{0x01, byte(slot)},
+			Header: &phase0.SignedBeaconBlockHeader{
+				Message: &phase0.BeaconBlockHeader{
+					Slot:       slot,
+					ParentRoot: phase0.Root{0x01, byte(slot - 1)},
+				},
+			},
+		},
+	}, nil
+}
+
+func (c *headersClient) SignedBeaconBlock(_ context.Context,
+	opts *api.SignedBeaconBlockOpts,
+) (
+	*api.Response[*spec.VersionedSignedBeaconBlock],
+	error,
+) {
+	slot, err := c.slot(opts.Block)
+	if err != nil {
+		return nil, err
+	}
+	c.blockFetches.Add(1)
+
+	return &api.Response[*spec.VersionedSignedBeaconBlock]{
+		Data: &spec.VersionedSignedBeaconBlock{
+			Version: spec.DataVersionPhase0,
+			Phase0: &phase0.SignedBeaconBlock{
+				Message: &phase0.BeaconBlock{
+					Slot: slot,
+					Body: &phase0.BeaconBlockBody{
+						ETH1Data: &phase0.ETH1Data{
+							BlockHash: make([]byte, 32),
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// headersDB is a chain database that records headers and committed progress.
+type headersDB struct {
+	*mockchaindb.InMemoryService
+
+	mu       sync.Mutex
+	headers  []*chaindb.BlockHeader
+	pending  map[string]int64
+	progress map[string]int64
+}
+
+func newHeadersDB(t *testing.T) *headersDB {
+	t.Helper()
+	inMemory, err := mockchaindb.NewInMemory(context.Background(), nil)
+	require.NoError(t, err)
+
+	return &headersDB{
+		InMemoryService: inMemory,
+		pending:         make(map[string]int64),
+		progress:        make(map[string]int64),
+	}
+}
+
+func (d *headersDB) SetBlockHeaders(_ context.Context, headers []*chaindb.BlockHeader) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.headers = append(d.headers, headers...)
+	return nil
+}
+
+func (d *headersDB) SetProgress(_ context.Context, _ string, key string, value int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending[key] = value
+	return nil
+}
+
+func (d *headersDB) CommitTx(_ context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, value := range d.pending {
+		d.progress[key] = value
+	}
+	d.pending = make(map[string]int64)
+	return nil
+}
+
+func (d *headersDB) Progress(_ context.Context, service string) (*chaindb.Progress, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	values := make(map[string]int64, len(d.progress))
+	for key, value := range d.progress {
+		values[key] = value
+	}
+	return &chaindb.Progress{Service: service, Values: values}, nil
+}
+
+func (d *headersDB) latest(key string) (int64, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	value, exists := d.progress[key]
+	return value, exists
+}
+
+func (d *headersDB) headerSlots() []phase0.Slot {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	slots := make([]phase0.Slot, len(d.headers))
+	for i := range d.headers {
+		slots[i] = d.headers[i].Slot
+	}
+	return slots
+}
+
+// blockSlots returns the slots up to the given slot for which blocks are stored.
+func (d *headersDB) blockSlots(ctx context.Context, t *testing.T, maxSlot phase0.Slot) []phase0.Slot {
+	t.Helper()
+	slots := make([]phase0.Slot, 0)
+	for slot := phase0.Slot(0); slot <= maxSlot; slot++ {
+		blocks, err := d.BlocksBySlot(ctx, slot)
+		require.NoError(t, err)
+		if len(blocks) > 0 {
+			slots = append(slots, slot)
+		}
+	}
+	return slots
+}
+
+// headersChainTime is a chain time whose current slot can be set.
+type headersChainTime struct {
+	chaintime.Service
+
+	currentSlot phase0.Slot
+}
+
+func (c *headersChainTime) CurrentSlot() phase0.Slot {
+	return c.currentSlot
+}
+
+func newHeadersFirstService(db *headersDB, client *headersClient, chainTime chaintime.Service) *Service {
+	return &Service{
+		eth2Client:               client,
+		chainDB:                  db,
+		blocksSetter:             db,
+		attestationsSetter:       db,
+		attesterSlashingsSetter:  db,
+		proposerSlashingsSetter:  db,
+		depositsSetter:           db,
+		voluntaryExitsSetter:     db,
+		beaconCommitteesProvider: db,
+		blockHeadersSetter:       db,
+		headersProvider:          client,
+		chainTime:                chainTime,
+		endSlot:                  -1,
+		batchSlots:               4,
+		queueSize:                2,
+		headersFirst:             true,
+		headerFetchers:           2,
+		bodyBackfillCh:           make(chan struct{}, 1),
+		pendingRoots:             make(map[phase0.Root]phase0.Slot),
+	}
+}
+
+func TestCatchupHeadersOnly(t *testing.T) {
+	ctx := context.Background()
+	db := newHeadersDB(t)
+	client := &headersClient{missed: map[phase0.Slot]bool{4: true}}
+	s := newHeadersFirstService(db, client, &headersChainTime{Service: mockchaintime.New(), currentSlot: 9})
+
+	md := &metadata{LatestSlot: -1, LatestHeaderSlot: -1}
+	s.catchup(ctx, md)
+
+	// Headers are stored for all slots with blocks, and their progress recorded.
+	require.Equal(t, []phase0.Slot{0, 1, 2, 3, 5, 6, 7, 8, 9}, db.headerSlots())
+	latestHeaderSlot, exists := db.latest("latest_header_slot")
+	require.True(t, exists)
+	require.Equal(t, int64(9), latestHeaderSlot)
+
+	// No bodies are fetched or stored, and their progress is untouched.
+	require.Zero(t, client.blockFetches.Load())
+	require.Empty(t, db.blockSlots(ctx, t, 9))
+	_, exists = db.latest("latest_slot")
+	require.False(t, exists)
+	require.Equal(t, int64(-1), md.LatestSlot)
+
+	// The body backfill worker has been woken.
+	require.Len(t, s.bodyBackfillCh, 1)
+}
+
+func TestFillBodies(t *testing.T) {
+	ctx := context.Background()
+	db := newHeadersDB(t)
+	client := &headersClient{missed: map[phase0.Slot]bool{4: true}}
+	chainTime := &headersChainTime{Service: mockchaintime.New(), currentSlot: 9}
+	s := newHeadersFirstService(db, client, chainTime)
+
+	// Bodies are not fetched before their headers.
+	bodyMD := &metadata{LatestSlot: -1, LatestHeaderSlot: -1}
+	s.fillBodies(ctx, bodyMD)
+	require.Zero(t, client.blockFetches.Load())
+	require.Equal(t, int64(-1), bodyMD.LatestSlot)
+
+	headerMD := &metadata{LatestSlot: -1, LatestHeaderSlot: -1}
+	s.catchup(ctx, headerMD)
+
+	// Bodies are filled in up to the latest header slot.
+	s.fillBodies(ctx, bodyMD)
+	require.Equal(t, []phase0.Slot{0, 1, 2, 3, 5, 6, 7, 8, 9}, db.blockSlots(ctx, t, 12))
+	require.Equal(t, int64(9), client.blockFetches.Load())
+	require.Equal(t, int64(9), bodyMD.LatestSlot)
+	latestSlot, exists := db.latest("latest_slot")
+	require.True(t, exists)
+	require.Equal(t, int64(9), latestSlot)
+
+	// Filling bodies does not touch the header progress.
+	latestHeaderSlot, _ := db.latest("latest_header_slot")
+	require.Equal(t, int64(9), latestHeaderSlot)
+
+	// Later headers are followed by only their bodies.
+	chainTime.currentSlot = 12
+	s.catchup(ctx, headerMD)
+	s.fillBodies(ctx, bodyMD)
+	require.Equal(t, []phase0.Slot{0, 1, 2, 3, 5, 6, 7, 8, 9, 10, 11, 12}, db.blockSlots(ctx, t, 12))
+	require.Equal(t, int64(12), client.blockFetches.Load())
+	latestSlot, _ = db.latest("latest_slot")
+	require.Equal(t, int64(12), latestSlot)
+}
+
+func TestBackfillBodies(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	db := newHeadersDB(t)
+	client := &headersClient{}
+	s := newHeadersFirstService(db, client, &headersChainTime{Service: mockchaintime.New(), currentSlot: 20})
+
+	done := make(chan struct{})
+	go func() {
+		s.backfillBodies(ctx, &metadata{LatestSlot: -1, LatestHeaderSlot: -1})
+		close(done)
+	}()
+
+	// Indexing headers wakes the worker, which fills in the bodies behind them.
+	s.catchup(ctx, &metadata{LatestSlot: -1, LatestHeaderSlot: -1})
+	require.Eventually(t, func() bool {
+		latestSlot, exists := db.latest("latest_slot")
+		return exists && latestSlot == 20
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Len(t, db.blockSlots(ctx, t, 20), 21)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "body backfill did not stop")
+	}
+}
@@ -15,45 +15,57 @@ package standard
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/pkg/errors"
 )
 
 // metadata stored about this service.
 type metadata struct {
-	LatestSlot int64 `json:"latest_slot"`
+	LatestSlot int64
+	// LatestHeaderSlot is the latest slot for which the header has been
+	// fetched ahead of the body.
+	LatestHeaderSlot int64
 }
 
-// metadataKey is the key for the metadata.
-var metadataKey = "blocks.standard"
+// progressService is the name of this service for progress.
+var progressService = "blocks.standard"
 
 // getMetadata gets metadata for this service.
 func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 	md := &metadata{
-		LatestSlot: -1,
+		LatestSlot:       -1,
+		LatestHeaderSlot: -1,
 	}
-	mdJSON, err := s.chainDB.Metadata(ctx, metadataKey)
+	progress, err := s.chainDB.Progress(ctx, progressService)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to fetch metadata")
+		return nil, errors.Wrap(err, "failed to fetch progress")
 	}
-	if mdJSON == nil {
+	if progress == nil {
 		return md, nil
 	}
-	if err := json.Unmarshal(mdJSON, md); err != nil {
-		return nil, errors.Wrap(err, "failed to unmarshal metadata")
+	if val, exists := progress.Values["latest_slot"]; exists {
+		md.LatestSlot = val
+	}
+	if val, exists := progress.Values["latest_header_slot"]; exists {
+		md.LatestHeaderSlot = val
 	}
 	return md, nil
 }
 
-// setMetadata sets metadata for this service.
+// setMetadata sets the latest slot for which blocks have been stored.
+// When fetching headers first this is the progress of the body backfill
+// worker, so the latest header slot is left untouched.
 func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
-	mdJSON, err := json.Marshal(md)
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal metadata")
+	if err := s.chainDB.SetProgress(ctx, progressService, "latest_slot", md.LatestSlot); err != nil {
+		return errors.Wrap(err, "failed to update latest slot")
 	}
-	if err := s.chainDB.SetMetadata(ctx, metadataKey, mdJSON); err != nil {
-		return errors.Wrap(err, "failed to update metadata")
+	return nil
+}
+
+// setHeaderMetadata sets the latest slot for which headers have been stored.
+func (s *Service) setHeaderMetadata(ctx context.Context, md *metadata) error {
+	if err := s.chainDB.SetProgress(ctx, progressService, "latest_header_slot", md.LatestHeaderSlot); err != nil {
+		return errors.Wrap(err, "failed to update latest header slot")
 	}
 	return nil
 }
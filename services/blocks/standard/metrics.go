@@ -15,6 +15,7 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
@@ -28,6 +29,14 @@ var (
 	highestSlot    phase0.Slot
 	latestSlot     prometheus.Gauge
 	slotsProcessed prometheus.Gauge
+	eventDuration  *prometheus.HistogramVec
+	polls          prometheus.Counter
+	orphanedBlocks prometheus.Counter
+	arrivalDelay   prometheus.Histogram
+
+	pipelineStageDuration *prometheus.HistogramVec
+	pipelineStageBlocked  *prometheus.HistogramVec
+	pipelineQueueLength   *prometheus.GaugeVec
 )
 
 func registerMetrics(_ context.Context, monitor metrics.Service) error {
@@ -65,6 +74,76 @@ func registerPrometheusMetrics() error {
 		return errors.Wrap(err, "failed to register slots_processed")
 	}
 
+	eventDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "event_duration_seconds",
+		Help:      "Time taken to process beacon node events",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8, 16, 32},
+	}, []string{"topic"})
+	if err := prometheus.Register(eventDuration); err != nil {
+		return errors.Wrap(err, "failed to register event_duration_seconds")
+	}
+
+	polls = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "polls_total",
+		Help:      "Number of times the module polled for blocks due to a lack of events",
+	})
+	if err := prometheus.Register(polls); err != nil {
+		return errors.Wrap(err, "failed to register polls_total")
+	}
+
+	orphanedBlocks = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "orphaned_blocks_total",
+		Help:      "Number of non-canonical blocks stored",
+	})
+	if err := prometheus.Register(orphanedBlocks); err != nil {
+		return errors.Wrap(err, "failed to register orphaned_blocks_total")
+	}
+
+	arrivalDelay = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "arrival_delay_seconds",
+		Help:      "Time between the start of the slot and the beacon node announcing blocks indexed at head",
+		Buckets:   []float64{0.5, 1, 1.5, 2, 2.5, 3, 3.5, 4, 6, 8, 12},
+	})
+	if err := prometheus.Register(arrivalDelay); err != nil {
+		return errors.Wrap(err, "failed to register arrival_delay_seconds")
+	}
+
+	pipelineStageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "pipeline",
+		Name:      "stage_duration_seconds",
+		Help:      "Time taken by each stage of the pipeline to process a slot",
+		Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8},
+	}, []string{"stage"})
+	if err := prometheus.Register(pipelineStageDuration); err != nil {
+		return errors.Wrap(err, "failed to register pipeline_stage_duration_seconds")
+	}
+
+	pipelineStageBlocked = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "pipeline",
+		Name:      "stage_blocked_seconds",
+		Help:      "Time each stage of the pipeline waited for the following stage to accept a slot",
+		Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8},
+	}, []string{"stage"})
+	if err := prometheus.Register(pipelineStageBlocked); err != nil {
+		return errors.Wrap(err, "failed to register pipeline_stage_blocked_seconds")
+	}
+
+	pipelineQueueLength = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "pipeline",
+		Name:      "queue_length",
+		Help:      "Number of slots waiting to be processed by each stage of the pipeline",
+	}, []string{"stage"})
+	if err := prometheus.Register(pipelineQueueLength); err != nil {
+		return errors.Wrap(err, "failed to register pipeline_queue_length")
+	}
+
 	return nil
 }
 
@@ -86,3 +165,45 @@ func monitorSlotProcessed(slot phase0.Slot) {
 		}
 	}
 }
+
+func monitorEventProcessed(topic string, duration time.Duration) {
+	if eventDuration != nil {
+		eventDuration.WithLabelValues(topic).Observe(duration.Seconds())
+	}
+}
+
+func monitorPoll() {
+	if polls != nil {
+		polls.Inc()
+	}
+}
+
+func monitorOrphanedBlockStored() {
+	if orphanedBlocks != nil {
+		orphanedBlocks.Inc()
+	}
+}
+
+func monitorBlockArrival(delay time.Duration) {
+	if arrivalDelay != nil {
+		arrivalDelay.Observe(delay.Seconds())
+	}
+}
+
+func monitorPipelineStage(stage string, duration time.Duration) {
+	if pipelineStageDuration != nil {
+		pipelineStageDuration.WithLabelValues(stage).Observe(duration.Seconds())
+	}
+}
+
+func monitorPipelineStageBlocked(stage string, duration time.Duration) {
+	if pipelineStageBlocked != nil {
+		pipelineStageBlocked.WithLabelValues(stage).Observe(duration.Seconds())
+	}
+}
+
+func monitorPipelineQueueLength(stage string, length int) {
+	if pipelineQueueLength != nil {
+		pipelineQueueLength.WithLabelValues(stage).Set(float64(length))
+	}
+}
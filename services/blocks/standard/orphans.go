@@ -0,0 +1,112 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OnBlockEvent receives block notifications.
+// The block is noted, and stored once its slot has been processed if it was
+// not obtained as the canonical block for its slot.
+func (s *Service) OnBlockEvent(_ context.Context, event *apiv1.BlockEvent) {
+	if !s.orphanedBodies {
+		return
+	}
+
+	s.pendingRootsMu.Lock()
+	s.pendingRoots[event.Block] = event.Slot
+	s.pendingRootsMu.Unlock()
+}
+
+// storeOrphanedBlocks stores blocks that have been seen by the beacon node
+// up to the given slot, but were not obtained as canonical blocks.
+// This requires the activity semaphore to be held or, when fetching headers
+// first, the bodies lock.
+func (s *Service) storeOrphanedBlocks(ctx context.Context, latestSlot phase0.Slot) {
+	if !s.orphanedBodies {
+		return
+	}
+
+	roots := make([]phase0.Root, 0)
+	s.pendingRootsMu.Lock()
+	for root, slot := range s.pendingRoots {
+		if slot <= latestSlot {
+			roots = append(roots, root)
+			delete(s.pendingRoots, root)
+		}
+	}
+	s.pendingRootsMu.Unlock()
+
+	for _, root := range roots {
+		if err := s.storeOrphanedBlock(ctx, root); err != nil {
+			log.Warn().Str("block_root", fmt.Sprintf("%#x", root)).Err(err).Msg("Failed to store orphaned block")
+		}
+	}
+}
+
+// storeOrphanedBlock stores the block with the given root if it is not already present.
+func (s *Service) storeOrphanedBlock(ctx context.Context, root phase0.Root) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "storeOrphanedBlock",
+		trace.WithAttributes(
+			attribute.String("root", fmt.Sprintf("%#x", root)),
+		))
+	defer span.End()
+
+	_, err := s.chainDB.(chaindb.BlocksProvider).BlockByRoot(ctx, root)
+	if err == nil {
+		// Already have the block, most likely because it is canonical.
+		return nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return errors.Wrap(err, "failed to check for block")
+	}
+
+	signedBlockResponse, err := s.eth2Client.(eth2client.SignedBeaconBlockProvider).SignedBeaconBlock(ctx, &api.SignedBeaconBlockOpts{
+		Block: root.String(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain block")
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := s.OnBlock(ctx, signedBlockResponse.Data); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to store block")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	s.clearArrivals([]phase0.Root{root})
+	log.Debug().Str("block_root", fmt.Sprintf("%#x", root)).Msg("Stored orphaned block")
+	monitorOrphanedBlockStored()
+
+	return nil
+}
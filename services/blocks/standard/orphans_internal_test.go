@@ -0,0 +1,172 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+// orphanClient is a beacon node that also serves blocks by root for blocks
+// that did not become canonical, and counts those fetched.
+type orphanClient struct {
+	*reorgClient
+
+	orphans       map[phase0.Root]*spec.VersionedSignedBeaconBlock
+	orphanFetches int
+}
+
+func (c *orphanClient) SignedBeaconBlock(ctx context.Context,
+	opts *api.SignedBeaconBlockOpts,
+) (
+	*api.Response[*spec.VersionedSignedBeaconBlock],
+	error,
+) {
+	if !strings.HasPrefix(opts.Block, "0x") {
+		return c.reorgClient.SignedBeaconBlock(ctx, opts)
+	}
+
+	for root, block := range c.orphans {
+		if root.String() == opts.Block {
+			c.orphanFetches++
+			return &api.Response[*spec.VersionedSignedBeaconBlock]{Data: block}, nil
+		}
+	}
+
+	return nil, &api.Error{StatusCode: http.StatusNotFound}
+}
+
+// orphanBlock returns a block for the slot that competes with the canonical
+// block, holding a single deposit.
+func orphanBlock(t *testing.T, slot phase0.Slot) (*spec.VersionedSignedBeaconBlock, phase0.Root) {
+	t.Helper()
+
+	proof := make([][]byte, 33)
+	for i := range proof {
+		proof[i] = make([]byte, 32)
+	}
+	block := &phase0.BeaconBlock{
+		Slot: slot,
+		Body: &phase0.BeaconBlockBody{
+			ETH1Data: &phase0.ETH1Data{
+				BlockHash: make([]byte, 32),
+			},
+			Graffiti: [32]byte{0xff},
+			Deposits: []*phase0.Deposit{
+				{
+					Proof: proof,
+					Data: &phase0.DepositData{
+						PublicKey:             phase0.BLSPubKey{0xff, byte(slot)},
+						WithdrawalCredentials: make([]byte, 32),
+						Amount:                32000000000,
+					},
+				},
+			},
+		},
+	}
+	root, err := block.HashTreeRoot()
+	require.NoError(t, err)
+
+	return &spec.VersionedSignedBeaconBlock{
+		Version: spec.DataVersionPhase0,
+		Phase0:  &phase0.SignedBeaconBlock{Message: block},
+	}, root
+}
+
+func TestStoreOrphanedBlocks(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+	}{
+		{
+			name:    "Enabled",
+			enabled: true,
+		},
+		{
+			name:    "Disabled",
+			enabled: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newHeadersDB(t)
+			orphan, orphanRoot := orphanBlock(t, 2)
+			laterOrphan, laterOrphanRoot := orphanBlock(t, 5)
+			client := &orphanClient{
+				reorgClient: &reorgClient{headersClient: &headersClient{}},
+				orphans: map[phase0.Root]*spec.VersionedSignedBeaconBlock{
+					orphanRoot:      orphan,
+					laterOrphanRoot: laterOrphan,
+				},
+			}
+			s := newEventsService(db, client.reorgClient, &headersChainTime{Service: mockchaintime.New(), currentSlot: 3})
+			s.eth2Client = client
+			s.orphanedBodies = test.enabled
+			require.Equal(t, test.enabled, strings.Contains(strings.Join(s.topics(), ","), "block"))
+
+			// The beacon node announces the canonical block for slot 1, and blocks
+			// for slots 2 and 5 that do not become canonical.
+			canonicalResponse, err := client.SignedBeaconBlock(ctx, &api.SignedBeaconBlockOpts{Block: "1"})
+			require.NoError(t, err)
+			canonicalRoot, err := canonicalResponse.Data.Root()
+			require.NoError(t, err)
+			client.takeFetched()
+			s.onEvent(ctx, &apiv1.Event{Topic: "block", Data: &apiv1.BlockEvent{Slot: 1, Block: canonicalRoot}})
+			s.onEvent(ctx, &apiv1.Event{Topic: "block", Data: &apiv1.BlockEvent{Slot: 2, Block: orphanRoot}})
+			s.onEvent(ctx, &apiv1.Event{Topic: "block", Data: &apiv1.BlockEvent{Slot: 5, Block: laterOrphanRoot}})
+
+			s.OnBeaconChainHeadUpdated(ctx, 3, phase0.Root{0x01, 3}, phase0.Root{}, false)
+			require.Equal(t, []phase0.Slot{0, 1, 2, 3}, client.takeFetched())
+
+			deposits, err := db.Deposits(ctx, &chaindb.DepositFilter{
+				PublicKeys: []phase0.BLSPubKey{{0xff, 2}, {0xff, 5}},
+			})
+			require.NoError(t, err)
+			_, err = db.BlockByRoot(ctx, orphanRoot)
+			if !test.enabled {
+				// Block events are ignored, so no orphaned blocks are fetched.
+				require.Error(t, err)
+				require.Empty(t, deposits)
+				require.Zero(t, client.orphanFetches)
+				require.Empty(t, s.pendingRoots)
+				return
+			}
+
+			// Only the orphaned block whose slot has been processed is fetched, and it
+			// is stored along with its contents.
+			require.NoError(t, err)
+			require.Equal(t, 1, client.orphanFetches)
+			require.Len(t, deposits, 1)
+			require.Equal(t, orphanRoot, deposits[0].InclusionBlockRoot)
+			require.Equal(t, phase0.Slot(2), deposits[0].InclusionSlot)
+			blocks, err := db.BlocksBySlot(ctx, 2)
+			require.NoError(t, err)
+			require.Len(t, blocks, 2)
+			// The later block waits for its slot to be processed.
+			require.Equal(t, map[phase0.Root]phase0.Slot{laterOrphanRoot: 5}, s.pendingRoots)
+		})
+	}
+}
@@ -15,24 +15,41 @@ package standard
 
 import (
 	"errors"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/plugins"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/coldstore"
 	"github.com/wealdtech/chaind/services/metrics"
 	"golang.org/x/sync/semaphore"
 )
 
 type parameters struct {
-	logLevel    zerolog.Level
-	monitor     metrics.Service
-	eth2Client  eth2client.Service
-	chainDB     chaindb.Service
-	chainTime   chaintime.Service
-	startSlot   int64
-	refetch     bool
-	activitySem *semaphore.Weighted
+	logLevel       zerolog.Level
+	monitor        metrics.Service
+	eth2Client     eth2client.Service
+	chainDB        chaindb.Service
+	chainTime      chaintime.Service
+	startSlot      int64
+	endSlot        int64
+	refetch        bool
+	pollInterval   time.Duration
+	batchSlots     uint64
+	queueSize      uint64
+	maxAttempts    uint32
+	orphanedBodies bool
+	arrivals       bool
+	blobSidecars   bool
+	rawBlocks      bool
+	headersFirst   bool
+	headerFetchers uint64
+	coldStore      coldstore.Service
+	catchup        bool
+	activitySem    *semaphore.Weighted
+	plugins        *plugins.Runner
 }
 
 // Parameter is the interface for service parameters.
@@ -88,6 +105,14 @@ func WithStartSlot(startSlot int64) Parameter {
 	})
 }
 
+// WithEndSlot sets the end slot for this module.  Once blocks up to and
+// including the end slot have been fetched the module idles.
+func WithEndSlot(endSlot int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.endSlot = endSlot
+	})
+}
+
 // WithRefetch sets the refetch flag for this module.
 func WithRefetch(refetch bool) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -95,6 +120,104 @@ func WithRefetch(refetch bool) Parameter {
 	})
 }
 
+// WithPollInterval sets the interval after which the module polls for new
+// blocks if it has not received any events from the beacon node.
+// If not supplied this defaults to two slots.
+func WithPollInterval(pollInterval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.pollInterval = pollInterval
+	})
+}
+
+// WithBatchSlots sets the number of slots whose blocks are written in a single
+// database transaction when catching up.
+func WithBatchSlots(batchSlots uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.batchSlots = batchSlots
+	})
+}
+
+// WithPipelineQueueSize sets the number of blocks that can be held between
+// each stage of the processing pipeline.
+func WithPipelineQueueSize(queueSize uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.queueSize = queueSize
+	})
+}
+
+// WithMaxAttempts sets the number of times that processing of a slot can
+// fail before the slot is recorded as dead and skipped.
+// If 0 then failing slots are retried indefinitely.
+func WithMaxAttempts(maxAttempts uint32) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxAttempts = maxAttempts
+	})
+}
+
+// WithOrphanedBodies states if the module should store the contents of
+// blocks that are not on the canonical chain.
+func WithOrphanedBodies(orphanedBodies bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.orphanedBodies = orphanedBodies
+	})
+}
+
+// WithArrivals states if the module should record the time at which
+// blocks indexed at the head of the chain arrived.
+func WithArrivals(arrivals bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.arrivals = arrivals
+	})
+}
+
+// WithBlobSidecars states if the module should store blob sidecars.
+func WithBlobSidecars(blobSidecars bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.blobSidecars = blobSidecars
+	})
+}
+
+// WithRawBlocks states if the module should store the SSZ encoding of
+// blocks.
+func WithRawBlocks(rawBlocks bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.rawBlocks = rawBlocks
+	})
+}
+
+// WithHeadersFirst states if the module should fetch the headers of all
+// missing blocks before fetching their bodies.
+func WithHeadersFirst(headersFirst bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.headersFirst = headersFirst
+	})
+}
+
+// WithHeaderFetchers sets the number of headers fetched concurrently when
+// fetching headers ahead of bodies.
+func WithHeaderFetchers(headerFetchers uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.headerFetchers = headerFetchers
+	})
+}
+
+// WithColdStore sets the cold store in which to store the SSZ encoding of
+// blocks.  If not supplied then they are stored in the database.
+func WithColdStore(coldStore coldstore.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.coldStore = coldStore
+	})
+}
+
+// WithCatchup states if the module should catch up with and follow the
+// chain when it starts.  If not then an Ethereum 2 client is optional, and
+// data not present in the database is not fetched from the chain.
+func WithCatchup(catchup bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.catchup = catchup
+	})
+}
+
 // WithActivitySem sets the activity semaphore for this module.
 func WithActivitySem(sem *semaphore.Weighted) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -102,11 +225,24 @@ func WithActivitySem(sem *semaphore.Weighted) Parameter {
 	})
 }
 
+// WithPlugins sets the runner that passes stored blocks to plugin indexers.
+func WithPlugins(runner *plugins.Runner) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.plugins = runner
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel:  zerolog.GlobalLevel(),
-		startSlot: -1,
+		logLevel:       zerolog.GlobalLevel(),
+		startSlot:      -1,
+		endSlot:        -1,
+		batchSlots:     1,
+		queueSize:      32,
+		headerFetchers: 16,
+		blobSidecars:   true,
+		catchup:        true,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -114,7 +250,9 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 		}
 	}
 
-	if parameters.eth2Client == nil {
+	// Without catching up the module only processes blocks it is given, so
+	// can operate without a client.
+	if parameters.eth2Client == nil && parameters.catchup {
 		return nil, errors.New("no Ethereum 2 client specified")
 	}
 	if parameters.chainDB == nil {
@@ -126,6 +264,24 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.activitySem == nil {
 		return nil, errors.New("no activity semaphore specified")
 	}
+	if parameters.endSlot >= 0 && parameters.startSlot > parameters.endSlot {
+		return nil, errors.New("end slot before start slot")
+	}
+	if parameters.batchSlots == 0 {
+		return nil, errors.New("batch slots must be greater than 0")
+	}
+	if parameters.queueSize == 0 {
+		return nil, errors.New("pipeline queue size must be greater than 0")
+	}
+	if parameters.headersFirst && parameters.headerFetchers == 0 {
+		return nil, errors.New("header fetchers must be greater than 0")
+	}
+	if parameters.pollInterval < 0 {
+		return nil, errors.New("poll interval cannot be negative")
+	}
+	if parameters.pollInterval == 0 {
+		parameters.pollInterval = 2 * parameters.chainTime.SlotDuration()
+	}
 
 	return &parameters, nil
 }
@@ -0,0 +1,282 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
+)
+
+// Blocks pass through the pipeline in the following stages:
+//   - fetch obtains the block for the slot from the beacon node
+//   - decode converts the block to its database representation
+//   - transform converts the contents of the block to their database representations
+//   - persist writes the block and its contents to the database
+//
+// Each stage runs concurrently with the others, and hands its slots to the
+// next stage through a bounded queue.  If a stage falls behind, for example
+// persist when the database is slow, the queue in front of it fills and the
+// stages before it block, limiting the number of blocks held in memory.
+const (
+	stageFetch     = "fetch"
+	stageDecode    = "decode"
+	stageTransform = "transform"
+	stagePersist   = "persist"
+)
+
+// pipelineItem is the information about a slot that passes through the pipeline.
+type pipelineItem struct {
+	slot        phase0.Slot
+	signedBlock *spec.VersionedSignedBeaconBlock
+	dbBlock     *chaindb.Block
+	contents    *blockContents
+}
+
+// processSlots processes blocks for the given range of slots, inclusive.
+// Blocks are committed to the database in batches, with a batch ending at
+// each slot for which batchEnd returns true; batchEnd must return true for
+// the end slot.
+func (s *Service) processSlots(ctx context.Context,
+	md *metadata,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+	batchEnd func(phase0.Slot) bool,
+) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "processSlots",
+		trace.WithAttributes(
+			attribute.Int64("start_slot", int64(startSlot)),
+			attribute.Int64("end_slot", int64(endSlot)),
+		))
+	defer span.End()
+
+	deadSlots, err := s.deadSlots(ctx, startSlot, endSlot)
+	if err != nil {
+		return err
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	decodeQueue := make(chan *pipelineItem, s.queueSize)
+	transformQueue := make(chan *pipelineItem, s.queueSize)
+	persistQueue := make(chan *pipelineItem, s.queueSize)
+
+	g.Go(func() error {
+		return s.fetchSlots(ctx, startSlot, endSlot, deadSlots, decodeQueue)
+	})
+	g.Go(func() error {
+		return runPipelineStage(ctx, stageDecode, decodeQueue, transformQueue, s.decodeItem)
+	})
+	g.Go(func() error {
+		return runPipelineStage(ctx, stageTransform, transformQueue, persistQueue, s.transformItem)
+	})
+	g.Go(func() error {
+		return s.persistItems(ctx, md, persistQueue, batchEnd)
+	})
+
+	return g.Wait()
+}
+
+// fetchSlots is the fetch stage of the pipeline.
+// Failures to fetch blocks are not attributed to their slot, as they are
+// usually due to the beacon node rather than the block.
+func (s *Service) fetchSlots(ctx context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+	deadSlots map[phase0.Slot]bool,
+	out chan<- *pipelineItem,
+) error {
+	defer close(out)
+
+	for slot := startSlot; slot <= endSlot; slot++ {
+		if deadSlots[slot] {
+			// Pass the slot on without a block, so that it is skipped.
+			log.Trace().Uint64("slot", uint64(slot)).Msg("Skipping dead slot")
+			if err := sendPipelineItem(ctx, stageFetch, out, &pipelineItem{slot: slot}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		started := time.Now()
+		signedBlock, err := s.fetchBlock(ctx, slot, s.refetch)
+		if err != nil {
+			return errors.Wrapf(err, "failed to fetch block for slot %d", slot)
+		}
+		monitorPipelineStage(stageFetch, time.Since(started))
+
+		if err := sendPipelineItem(ctx, stageFetch, out, &pipelineItem{
+			slot:        slot,
+			signedBlock: signedBlock,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeItem is the decode stage of the pipeline.
+// Failures to decode are attributed to their slot.
+func (s *Service) decodeItem(ctx context.Context, item *pipelineItem) error {
+	if item.signedBlock == nil {
+		// Nothing to decode.
+		return nil
+	}
+
+	var err error
+	item.dbBlock, err = s.dbBlock(ctx, item.signedBlock)
+	if err != nil {
+		return blockError(item.slot, errors.Wrap(err, "failed to obtain database block"))
+	}
+
+	return nil
+}
+
+// transformItem is the transform stage of the pipeline.
+// Failures to transform are attributed to their slot, unless they come from
+// the database or beacon node.
+func (s *Service) transformItem(ctx context.Context, item *pipelineItem) error {
+	if item.dbBlock == nil {
+		// Nothing to transform.
+		return nil
+	}
+
+	var err error
+	item.contents, err = s.blockContents(ctx, item.signedBlock, item.dbBlock)
+	if err != nil {
+		return blockError(item.slot, errors.Wrap(err, "failed to obtain block contents"))
+	}
+
+	return nil
+}
+
+// persistItems is the persist stage of the pipeline.
+// Failures to persist are not attributed to their slot, as they are due to
+// the database rather than the block.
+func (s *Service) persistItems(ctx context.Context,
+	md *metadata,
+	in <-chan *pipelineItem,
+	batchEnd func(phase0.Slot) bool,
+) error {
+	var (
+		txCtx      context.Context
+		cancel     context.CancelFunc
+		batchStart phase0.Slot
+		batchRoots []phase0.Root
+	)
+	for item := range in {
+		monitorPipelineQueueLength(stagePersist, len(in))
+		started := time.Now()
+
+		if txCtx == nil {
+			var err error
+			txCtx, cancel, err = s.chainDB.BeginTx(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to begin transaction")
+			}
+			batchStart = item.slot
+			batchRoots = batchRoots[:0]
+		}
+
+		if item.dbBlock != nil {
+			if err := s.persistBlock(txCtx, item.signedBlock, item.dbBlock, item.contents); err != nil {
+				cancel()
+				return errors.Wrapf(err, "failed to persist block for slot %d", item.slot)
+			}
+			batchRoots = append(batchRoots, item.dbBlock.Root)
+		}
+
+		if batchEnd(item.slot) {
+			md.LatestSlot = int64(item.slot)
+			if err := s.setMetadata(txCtx, md); err != nil {
+				cancel()
+				return errors.Wrap(err, "failed to set metadata")
+			}
+			if err := s.chainDB.CommitTx(txCtx); err != nil {
+				cancel()
+				return errors.Wrap(err, "failed to commit transaction")
+			}
+			txCtx = nil
+			s.clearArrivals(batchRoots)
+			for slot := batchStart; slot <= item.slot; slot++ {
+				monitorSlotProcessed(slot)
+			}
+		}
+		monitorPipelineStage(stagePersist, time.Since(started))
+	}
+
+	if txCtx != nil {
+		// The pipeline stopped part-way through a batch, so discard it.
+		cancel()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		return errors.New("pipeline ended part-way through a batch")
+	}
+
+	return nil
+}
+
+// runPipelineStage runs an intermediate stage of the pipeline, processing
+// items from its input queue and passing them to its output queue.
+func runPipelineStage(ctx context.Context,
+	stage string,
+	in <-chan *pipelineItem,
+	out chan<- *pipelineItem,
+	process func(context.Context, *pipelineItem) error,
+) error {
+	defer close(out)
+
+	for item := range in {
+		monitorPipelineQueueLength(stage, len(in))
+		started := time.Now()
+		if err := process(ctx, item); err != nil {
+			return err
+		}
+		monitorPipelineStage(stage, time.Since(started))
+
+		if err := sendPipelineItem(ctx, stage, out, item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sendPipelineItem sends an item to the next stage of the pipeline, waiting
+// for space in its queue if required.
+func sendPipelineItem(ctx context.Context,
+	stage string,
+	out chan<- *pipelineItem,
+	item *pipelineItem,
+) error {
+	started := time.Now()
+	select {
+	case out <- item:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	monitorPipelineStageBlocked(stage, time.Since(started))
+
+	return nil
+}
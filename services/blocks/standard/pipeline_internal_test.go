@@ -0,0 +1,525 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+// pipelineDB is a chain database that records the progress committed by the
+// pipeline, and can be set to fail.
+type pipelineDB struct {
+	*mockchaindb.InMemoryService
+
+	commits       []int64
+	pending       int64
+	deadSlots     []phase0.Slot
+	setBlockErr   error
+	committeesErr error
+}
+
+func newPipelineDB(t *testing.T) *pipelineDB {
+	t.Helper()
+	inMemory, err := mockchaindb.NewInMemory(context.Background(), nil)
+	require.NoError(t, err)
+
+	return &pipelineDB{
+		InMemoryService: inMemory,
+		pending:         -1,
+	}
+}
+
+func (d *pipelineDB) SetProgress(_ context.Context, _ string, key string, value int64) error {
+	if key == "latest_slot" {
+		d.pending = value
+	}
+	return nil
+}
+
+func (d *pipelineDB) CommitTx(_ context.Context) error {
+	d.commits = append(d.commits, d.pending)
+	return nil
+}
+
+func (d *pipelineDB) SetBlock(_ context.Context, _ *chaindb.Block) error {
+	return d.setBlockErr
+}
+
+func (d *pipelineDB) BeaconCommittees(_ context.Context, _ *chaindb.BeaconCommitteeFilter) ([]*chaindb.BeaconCommittee, error) {
+	if d.committeesErr != nil {
+		return nil, d.committeesErr
+	}
+	return []*chaindb.BeaconCommittee{}, nil
+}
+
+func (d *pipelineDB) BeaconCommitteeBySlotAndIndex(_ context.Context, _ phase0.Slot, _ phase0.CommitteeIndex) (*chaindb.BeaconCommittee, error) {
+	return nil, nil
+}
+
+func (d *pipelineDB) FailedItems(_ context.Context, _ *chaindb.FailedItemFilter) ([]*chaindb.FailedItem, error) {
+	items := make([]*chaindb.FailedItem, len(d.deadSlots))
+	for i := range d.deadSlots {
+		items[i] = &chaindb.FailedItem{
+			Module: failedItemsModule,
+			Kind:   chaindb.FailedItemKindSlot,
+			Item:   uint64(d.deadSlots[i]),
+			State:  chaindb.FailedItemStateDead,
+		}
+	}
+	return items, nil
+}
+
+func newPipelineService(db *pipelineDB) *Service {
+	return &Service{
+		chainDB:                  db,
+		blocksSetter:             db,
+		beaconCommitteesProvider: db,
+		failedItemsProvider:      db,
+		queueSize:                2,
+	}
+}
+
+// everyN returns a batch end function that ends batches every n slots and at the end slot.
+func everyN(n uint64, endSlot phase0.Slot) func(phase0.Slot) bool {
+	return func(slot phase0.Slot) bool {
+		return slot == endSlot || uint64(slot)%n == n-1
+	}
+}
+
+// queueItems returns a closed queue containing empty items for the given slots.
+func queueItems(startSlot phase0.Slot, endSlot phase0.Slot) chan *pipelineItem {
+	queue := make(chan *pipelineItem, int(endSlot-startSlot)+1)
+	for slot := startSlot; slot <= endSlot; slot++ {
+		queue <- &pipelineItem{slot: slot}
+	}
+	close(queue)
+
+	return queue
+}
+
+// phase0Item returns an item for a slot with a phase 0 block holding a single attestation.
+func phase0Item(slot phase0.Slot) *pipelineItem {
+	return &pipelineItem{
+		slot: slot,
+		signedBlock: &spec.VersionedSignedBeaconBlock{
+			Version: spec.DataVersionPhase0,
+			Phase0: &phase0.SignedBeaconBlock{
+				Message: &phase0.BeaconBlock{
+					Slot: slot,
+					Body: &phase0.BeaconBlockBody{
+						Attestations: []*phase0.Attestation{
+							{
+								Data: &phase0.AttestationData{
+									Slot:   slot - 1,
+									Source: &phase0.Checkpoint{},
+									Target: &phase0.Checkpoint{},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		dbBlock: &chaindb.Block{Slot: slot},
+	}
+}
+
+func TestProcessSlotsBatches(t *testing.T) {
+	ctx := context.Background()
+
+	db := newPipelineDB(t)
+	// All slots are dead, so pass through the pipeline without blocks.
+	for slot := phase0.Slot(0); slot < 10; slot++ {
+		db.deadSlots = append(db.deadSlots, slot)
+	}
+	s := newPipelineService(db)
+	s.maxAttempts = 3
+
+	md := &metadata{LatestSlot: -1, LatestHeaderSlot: -1}
+	require.NoError(t, s.processSlots(ctx, md, 0, 9, everyN(4, 9)))
+	require.Equal(t, []int64{3, 7, 9}, db.commits)
+	require.Equal(t, int64(9), md.LatestSlot)
+}
+
+func TestPersistItemsEmptySlots(t *testing.T) {
+	ctx := context.Background()
+
+	db := newPipelineDB(t)
+	s := newPipelineService(db)
+
+	md := &metadata{LatestSlot: -1, LatestHeaderSlot: -1}
+	require.NoError(t, s.persistItems(ctx, md, queueItems(5, 6), everyN(32, 6)))
+	require.Equal(t, []int64{6}, db.commits)
+	require.Equal(t, int64(6), md.LatestSlot)
+}
+
+func TestPersistItemsPartialBatch(t *testing.T) {
+	ctx := context.Background()
+
+	db := newPipelineDB(t)
+	s := newPipelineService(db)
+
+	// The queue closes part-way through the second batch, which must be discarded.
+	md := &metadata{LatestSlot: -1, LatestHeaderSlot: -1}
+	err := s.persistItems(ctx, md, queueItems(0, 5), everyN(4, 7))
+	require.EqualError(t, err, "pipeline ended part-way through a batch")
+	require.Equal(t, []int64{3}, db.commits)
+	require.Equal(t, int64(3), md.LatestSlot)
+
+	// The same with a cancelled context returns the cancellation.
+	db.commits = nil
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	md = &metadata{LatestSlot: -1, LatestHeaderSlot: -1}
+	err = s.persistItems(cancelledCtx, md, queueItems(0, 1), everyN(4, 7))
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, db.commits)
+	require.Equal(t, int64(-1), md.LatestSlot)
+}
+
+func TestPersistItemsFailure(t *testing.T) {
+	ctx := context.Background()
+
+	db := newPipelineDB(t)
+	db.setBlockErr = errors.New("database unavailable")
+	s := newPipelineService(db)
+
+	queue := make(chan *pipelineItem, 2)
+	queue <- &pipelineItem{slot: 0}
+	queue <- &pipelineItem{slot: 1, dbBlock: &chaindb.Block{Slot: 1}}
+	close(queue)
+
+	md := &metadata{LatestSlot: -1, LatestHeaderSlot: -1}
+	err := s.persistItems(ctx, md, queue, everyN(4, 3))
+	require.ErrorContains(t, err, "database unavailable")
+	// Database failures are not the fault of the slot, so are not attributed to it.
+	var slotErr *slotError
+	require.False(t, errors.As(err, &slotErr))
+	require.Empty(t, db.commits)
+	require.Equal(t, int64(-1), md.LatestSlot)
+}
+
+func TestDecodeItemFailure(t *testing.T) {
+	ctx := context.Background()
+
+	s := newPipelineService(newPipelineDB(t))
+
+	// Empty slots have nothing to decode.
+	require.NoError(t, s.decodeItem(ctx, &pipelineItem{slot: 1}))
+
+	err := s.decodeItem(ctx, &pipelineItem{
+		slot:        2,
+		signedBlock: &spec.VersionedSignedBeaconBlock{Version: spec.DataVersionUnknown},
+	})
+	var slotErr *slotError
+	require.True(t, errors.As(err, &slotErr))
+	require.Equal(t, phase0.Slot(2), slotErr.slot)
+}
+
+func TestTransformItemFailure(t *testing.T) {
+	ctx := context.Background()
+
+	// Empty slots have nothing to transform.
+	s := newPipelineService(newPipelineDB(t))
+	require.NoError(t, s.transformItem(ctx, &pipelineItem{slot: 1}))
+
+	// No committee for the attestation is a fault with the block, so is attributed to its slot.
+	err := s.transformItem(ctx, phase0Item(5))
+	var slotErr *slotError
+	require.True(t, errors.As(err, &slotErr))
+	require.Equal(t, phase0.Slot(5), slotErr.slot)
+
+	// A database failure is not attributed to the slot.
+	db := newPipelineDB(t)
+	db.committeesErr = errors.New("database unavailable")
+	s = newPipelineService(db)
+	err = s.transformItem(ctx, phase0Item(5))
+	require.ErrorContains(t, err, "database unavailable")
+	require.False(t, errors.As(err, &slotErr))
+}
+
+func TestRunPipelineStageStopsOnError(t *testing.T) {
+	ctx := context.Background()
+
+	out := make(chan *pipelineItem, 10)
+	err := runPipelineStage(ctx, stageDecode, queueItems(0, 5), out, func(_ context.Context, item *pipelineItem) error {
+		if item.slot == 3 {
+			return &slotError{slot: item.slot, err: errors.New("bad block")}
+		}
+		return nil
+	})
+	var slotErr *slotError
+	require.True(t, errors.As(err, &slotErr))
+	require.Equal(t, phase0.Slot(3), slotErr.slot)
+
+	// Items before the failure are passed on, and the output queue is closed.
+	slots := make([]phase0.Slot, 0)
+	for item := range out {
+		slots = append(slots, item.slot)
+	}
+	require.Equal(t, []phase0.Slot{0, 1, 2}, slots)
+}
+
+func TestRunPipelineStageBackpressure(t *testing.T) {
+	ctx := context.Background()
+
+	var processed atomic.Int32
+	out := make(chan *pipelineItem, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- runPipelineStage(ctx, stageDecode, queueItems(0, 4), out, func(_ context.Context, _ *pipelineItem) error {
+			processed.Add(1)
+			return nil
+		})
+	}()
+
+	// With nothing reading the output the stage stops with one item queued
+	// and one waiting to be sent.
+	require.Eventually(t, func() bool { return processed.Load() == 2 }, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, int32(2), processed.Load())
+
+	// Reading the output allows the stage to complete.
+	slots := make([]phase0.Slot, 0)
+	for item := range out {
+		slots = append(slots, item.slot)
+	}
+	require.NoError(t, <-done)
+	require.Equal(t, []phase0.Slot{0, 1, 2, 3, 4}, slots)
+}
+
+func TestRunPipelineStageCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	out := make(chan *pipelineItem)
+	done := make(chan error, 1)
+	go func() {
+		done <- runPipelineStage(ctx, stageDecode, queueItems(0, 4), out, func(_ context.Context, _ *pipelineItem) error {
+			return nil
+		})
+	}()
+
+	// The stage is blocked sending its first item; cancelling releases it.
+	cancel()
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		require.Fail(t, "stage did not stop on cancellation")
+	}
+	_, open := <-out
+	require.False(t, open)
+}
+
+func TestBatchEnd(t *testing.T) {
+	tests := []struct {
+		name       string
+		batchSlots uint64
+		startSlot  phase0.Slot
+		endSlot    phase0.Slot
+		expected   []phase0.Slot
+	}{
+		{
+			name:       "Single",
+			batchSlots: 1,
+			startSlot:  5,
+			endSlot:    8,
+			expected:   []phase0.Slot{5, 6, 7, 8},
+		},
+		{
+			name:       "Aligned",
+			batchSlots: 4,
+			startSlot:  0,
+			endSlot:    11,
+			expected:   []phase0.Slot{3, 7, 11},
+		},
+		{
+			name:       "Unaligned",
+			batchSlots: 4,
+			startSlot:  2,
+			endSlot:    9,
+			expected:   []phase0.Slot{3, 7, 9},
+		},
+		{
+			name:       "WithinBatch",
+			batchSlots: 32,
+			startSlot:  33,
+			endSlot:    40,
+			expected:   []phase0.Slot{40},
+		},
+		{
+			name:       "Epochs",
+			batchSlots: 32,
+			startSlot:  30,
+			endSlot:    70,
+			expected:   []phase0.Slot{31, 63, 70},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Service{batchSlots: test.batchSlots}
+			batchEnd := s.batchEnd(test.endSlot)
+			ends := make([]phase0.Slot, 0)
+			for slot := test.startSlot; slot <= test.endSlot; slot++ {
+				if batchEnd(slot) {
+					ends = append(ends, slot)
+				}
+			}
+			require.Equal(t, test.expected, ends)
+		})
+	}
+}
+
+// batchDB is a chain database whose blocks and progress are only stored when
+// their transaction commits, and can be set to fail to store the block for a slot.
+type batchDB struct {
+	*mockchaindb.InMemoryService
+
+	mu            sync.Mutex
+	pendingBlocks []*chaindb.Block
+	pendingSlot   int64
+	progress      map[string]int64
+	commits       []int64
+	rollbacks     [][]phase0.Slot
+	failSlot      *phase0.Slot
+}
+
+func newBatchDB(t *testing.T) *batchDB {
+	t.Helper()
+	inMemory, err := mockchaindb.NewInMemory(context.Background(), nil)
+	require.NoError(t, err)
+
+	return &batchDB{
+		InMemoryService: inMemory,
+		pendingSlot:     -1,
+		progress:        make(map[string]int64),
+	}
+}
+
+func (d *batchDB) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	return ctx, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		slots := make([]phase0.Slot, len(d.pendingBlocks))
+		for i := range d.pendingBlocks {
+			slots[i] = d.pendingBlocks[i].Slot
+		}
+		d.rollbacks = append(d.rollbacks, slots)
+		d.pendingBlocks = nil
+		d.pendingSlot = -1
+	}, nil
+}
+
+func (d *batchDB) SetBlock(_ context.Context, block *chaindb.Block) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.failSlot != nil && *d.failSlot == block.Slot {
+		return errors.New("database unavailable")
+	}
+	d.pendingBlocks = append(d.pendingBlocks, block)
+	return nil
+}
+
+func (d *batchDB) SetProgress(_ context.Context, _ string, key string, value int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if key == "latest_slot" {
+		d.pendingSlot = value
+	}
+	return nil
+}
+
+func (d *batchDB) CommitTx(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, block := range d.pendingBlocks {
+		if err := d.InMemoryService.SetBlock(ctx, block); err != nil {
+			return err
+		}
+	}
+	d.pendingBlocks = nil
+	d.progress["latest_slot"] = d.pendingSlot
+	d.commits = append(d.commits, d.pendingSlot)
+	return nil
+}
+
+// storedSlots returns the slots up to the given slot for which blocks have been committed.
+func (d *batchDB) storedSlots(ctx context.Context, t *testing.T, maxSlot phase0.Slot) []phase0.Slot {
+	t.Helper()
+	slots := make([]phase0.Slot, 0)
+	for slot := phase0.Slot(0); slot <= maxSlot; slot++ {
+		blocks, err := d.BlocksBySlot(ctx, slot)
+		require.NoError(t, err)
+		if len(blocks) > 0 {
+			slots = append(slots, slot)
+		}
+	}
+	return slots
+}
+
+func TestCatchupBatchRollback(t *testing.T) {
+	ctx := context.Background()
+
+	db := newBatchDB(t)
+	failSlot := phase0.Slot(6)
+	db.failSlot = &failSlot
+	client := &headersClient{missed: map[phase0.Slot]bool{5: true}}
+	s := &Service{
+		eth2Client:               client,
+		chainDB:                  db,
+		blocksSetter:             db,
+		attestationsSetter:       db,
+		attesterSlashingsSetter:  db,
+		proposerSlashingsSetter:  db,
+		depositsSetter:           db,
+		voluntaryExitsSetter:     db,
+		beaconCommitteesProvider: db,
+		chainTime:                &headersChainTime{Service: mockchaintime.New(), currentSlot: 9},
+		endSlot:                  -1,
+		batchSlots:               4,
+		queueSize:                2,
+	}
+
+	// Failing to store the block for slot 6 discards the rest of its batch,
+	// including the block for slot 4 that had already been written.
+	md := &metadata{LatestSlot: -1, LatestHeaderSlot: -1}
+	require.False(t, s.catchupBlocks(ctx, md, s.catchupLimit))
+	require.Equal(t, []int64{3}, db.commits)
+	require.Equal(t, [][]phase0.Slot{{4}}, db.rollbacks)
+	require.Equal(t, []phase0.Slot{0, 1, 2, 3}, db.storedSlots(ctx, t, 9))
+	require.Equal(t, int64(3), db.progress["latest_slot"])
+	require.Equal(t, int64(3), md.LatestSlot)
+
+	// Catching up again restarts from the failed batch.
+	db.failSlot = nil
+	db.commits = nil
+	require.True(t, s.catchupBlocks(ctx, md, s.catchupLimit))
+	require.Equal(t, []int64{7, 9}, db.commits)
+	require.Equal(t, []phase0.Slot{0, 1, 2, 3, 4, 6, 7, 8, 9}, db.storedSlots(ctx, t, 9))
+	require.Equal(t, int64(9), db.progress["latest_slot"])
+	require.Equal(t, int64(9), md.LatestSlot)
+}
@@ -0,0 +1,271 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/finalizer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// reprocessBatchSlots is the number of slots' worth of raw blocks that are
+// reprocessed in a single transaction.
+const reprocessBatchSlots = 32
+
+// storeRawBlock stores the SSZ encoding of a block, either in the database
+// or in cold storage.
+func (s *Service) storeRawBlock(ctx context.Context, signedBlock *spec.VersionedSignedBeaconBlock, dbBlock *chaindb.Block) error {
+	data, err := encodeRawBlock(signedBlock)
+	if err != nil {
+		return err
+	}
+
+	rawBlock := &chaindb.RawBlock{
+		Root:    dbBlock.Root,
+		Slot:    dbBlock.Slot,
+		Version: signedBlock.Version,
+	}
+	if s.coldStore == nil {
+		rawBlock.Data = data
+	} else {
+		rawBlock.Key = fmt.Sprintf("blocks/%d/%#x.ssz", dbBlock.Slot, dbBlock.Root)
+		if err := s.coldStore.Put(ctx, rawBlock.Key, data); err != nil {
+			return errors.Wrap(err, "failed to store raw block in cold storage")
+		}
+	}
+
+	return s.rawBlocksSetter.SetRawBlock(ctx, rawBlock)
+}
+
+// Reprocess replays the raw blocks stored for the given range of slots,
+// inclusive, updating the data derived from them.
+// The canonical state of the blocks is retained, but that of data derived
+// from them is not, so the progress of the finalizer is rewound to the start
+// of the range for it to be re-established.
+func (s *Service) Reprocess(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "Reprocess",
+		trace.WithAttributes(
+			attribute.Int64("start_slot", int64(startSlot)),
+			attribute.Int64("end_slot", int64(endSlot)),
+		))
+	defer span.End()
+
+	rawBlocksProvider, isProvider := s.chainDB.(chaindb.RawBlocksProvider)
+	if !isProvider {
+		return errors.New("chain DB does not support raw block providing")
+	}
+
+	if endSlot < startSlot {
+		return errors.New("end slot before start slot")
+	}
+
+	for slot := startSlot; slot <= endSlot; slot += reprocessBatchSlots {
+		batchEndSlot := slot + reprocessBatchSlots - 1
+		if batchEndSlot > endSlot {
+			batchEndSlot = endSlot
+		}
+		rawBlocks, err := rawBlocksProvider.RawBlocks(ctx, &chaindb.RawBlockFilter{
+			Order: chaindb.OrderEarliest,
+			From:  &slot,
+			To:    &batchEndSlot,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain raw blocks")
+		}
+		if err := s.reprocessRawBlocks(ctx, rawBlocks); err != nil {
+			return errors.Wrapf(err, "failed to reprocess raw blocks from slot %d", slot)
+		}
+		log.Debug().Uint64("start_slot", uint64(slot)).Uint64("end_slot", uint64(batchEndSlot)).Int("blocks", len(rawBlocks)).Msg("Reprocessed raw blocks")
+	}
+
+	return s.rewindFinalizer(ctx, startSlot)
+}
+
+// reprocessRawBlocks reprocesses the given raw blocks in a single transaction.
+func (s *Service) reprocessRawBlocks(ctx context.Context, rawBlocks []*chaindb.RawBlock) error {
+	if len(rawBlocks) == 0 {
+		return nil
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	for _, rawBlock := range rawBlocks {
+		if err := s.reprocessRawBlock(ctx, rawBlock); err != nil {
+			cancel()
+			return errors.Wrapf(err, "failed to reprocess raw block %#x", rawBlock.Root)
+		}
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// reprocessRawBlock reprocesses a single raw block.
+// This requires the context to hold an active transaction.
+func (s *Service) reprocessRawBlock(ctx context.Context, rawBlock *chaindb.RawBlock) error {
+	signedBlock, err := decodeRawBlock(rawBlock)
+	if err != nil {
+		return err
+	}
+
+	dbBlock, err := s.dbBlock(ctx, signedBlock)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain database block")
+	}
+	if dbBlock.Root != rawBlock.Root {
+		return fmt.Errorf("raw block has root %#x", dbBlock.Root)
+	}
+
+	// Retain the canonical state of the block, if known.
+	existing, err := s.chainDB.(chaindb.BlocksProvider).BlockByRoot(ctx, dbBlock.Root)
+	switch {
+	case err == nil:
+		dbBlock.Canonical = existing.Canonical
+	case !errors.Is(err, pgx.ErrNoRows):
+		return errors.Wrap(err, "failed to obtain existing block")
+	}
+
+	if err := s.blocksSetter.SetBlock(ctx, dbBlock); err != nil {
+		return errors.Wrap(err, "failed to set block")
+	}
+
+	if err := s.onBlockContents(ctx, signedBlock, dbBlock); err != nil {
+		return err
+	}
+
+	// Contents are set after the block they reference, so set the block again
+	// to propagate its canonical state to the contents that were just stored.
+	if dbBlock.Canonical != nil {
+		if err := s.blocksSetter.SetBlock(ctx, dbBlock); err != nil {
+			return errors.Wrap(err, "failed to set canonical state of block contents")
+		}
+	}
+
+	return nil
+}
+
+// rewindFinalizer rewinds the progress of the finalizer to before the given
+// slot, if it has passed it.
+func (s *Service) rewindFinalizer(ctx context.Context, slot phase0.Slot) error {
+	progress, err := s.chainDB.Progress(ctx, finalizer.ProgressService)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch finalizer progress")
+	}
+	if progress == nil {
+		return nil
+	}
+
+	latestCanonicalSlot := int64(slot) - 1
+	latestEpoch := int64(s.chainTime.SlotToEpoch(slot)) - 1
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if val, exists := progress.Values["latest_canonical_slot"]; exists && val > latestCanonicalSlot {
+		if err := s.chainDB.SetProgress(ctx, finalizer.ProgressService, "latest_canonical_slot", latestCanonicalSlot); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to rewind latest canonical slot")
+		}
+	}
+	if val, exists := progress.Values["latest_epoch"]; exists && val > latestEpoch {
+		if err := s.chainDB.SetProgress(ctx, finalizer.ProgressService, "latest_epoch", latestEpoch); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to rewind latest epoch")
+		}
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// encodeRawBlock provides the SSZ encoding of a signed beacon block.
+func encodeRawBlock(signedBlock *spec.VersionedSignedBeaconBlock) ([]byte, error) {
+	var data []byte
+	var err error
+	switch signedBlock.Version {
+	case spec.DataVersionPhase0:
+		data, err = signedBlock.Phase0.MarshalSSZ()
+	case spec.DataVersionAltair:
+		data, err = signedBlock.Altair.MarshalSSZ()
+	case spec.DataVersionBellatrix:
+		data, err = signedBlock.Bellatrix.MarshalSSZ()
+	case spec.DataVersionCapella:
+		data, err = signedBlock.Capella.MarshalSSZ()
+	case spec.DataVersionDeneb:
+		data, err = signedBlock.Deneb.MarshalSSZ()
+	default:
+		return nil, fmt.Errorf("unhandled block version %v", signedBlock.Version)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode block")
+	}
+
+	return data, nil
+}
+
+// decodeRawBlock decodes the SSZ encoding of a signed beacon block.
+func decodeRawBlock(rawBlock *chaindb.RawBlock) (*spec.VersionedSignedBeaconBlock, error) {
+	signedBlock := &spec.VersionedSignedBeaconBlock{
+		Version: rawBlock.Version,
+	}
+	var err error
+	switch rawBlock.Version {
+	case spec.DataVersionPhase0:
+		signedBlock.Phase0 = &phase0.SignedBeaconBlock{}
+		err = signedBlock.Phase0.UnmarshalSSZ(rawBlock.Data)
+	case spec.DataVersionAltair:
+		signedBlock.Altair = &altair.SignedBeaconBlock{}
+		err = signedBlock.Altair.UnmarshalSSZ(rawBlock.Data)
+	case spec.DataVersionBellatrix:
+		signedBlock.Bellatrix = &bellatrix.SignedBeaconBlock{}
+		err = signedBlock.Bellatrix.UnmarshalSSZ(rawBlock.Data)
+	case spec.DataVersionCapella:
+		signedBlock.Capella = &capella.SignedBeaconBlock{}
+		err = signedBlock.Capella.UnmarshalSSZ(rawBlock.Data)
+	case spec.DataVersionDeneb:
+		signedBlock.Deneb = &deneb.SignedBeaconBlock{}
+		err = signedBlock.Deneb.UnmarshalSSZ(rawBlock.Data)
+	default:
+		return nil, fmt.Errorf("unhandled block version %v", rawBlock.Version)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode block")
+	}
+
+	return signedBlock, nil
+}
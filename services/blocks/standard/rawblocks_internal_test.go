@@ -0,0 +1,353 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/chaintime"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	"github.com/wealdtech/chaind/services/finalizer"
+)
+
+func TestRawBlockRoundTrip(t *testing.T) {
+	eth1Data := &phase0.ETH1Data{BlockHash: make([]byte, 32)}
+	syncAggregate := &altair.SyncAggregate{SyncCommitteeBits: make([]byte, 64)}
+
+	tests := []struct {
+		name  string
+		block *spec.VersionedSignedBeaconBlock
+	}{
+		{
+			name: "Phase0",
+			block: &spec.VersionedSignedBeaconBlock{
+				Version: spec.DataVersionPhase0,
+				Phase0: &phase0.SignedBeaconBlock{
+					Message: &phase0.BeaconBlock{
+						Slot: 1,
+						Body: &phase0.BeaconBlockBody{ETH1Data: eth1Data},
+					},
+				},
+			},
+		},
+		{
+			name: "Altair",
+			block: &spec.VersionedSignedBeaconBlock{
+				Version: spec.DataVersionAltair,
+				Altair: &altair.SignedBeaconBlock{
+					Message: &altair.BeaconBlock{
+						Slot: 2,
+						Body: &altair.BeaconBlockBody{ETH1Data: eth1Data, SyncAggregate: syncAggregate},
+					},
+				},
+			},
+		},
+		{
+			name: "Bellatrix",
+			block: &spec.VersionedSignedBeaconBlock{
+				Version: spec.DataVersionBellatrix,
+				Bellatrix: &bellatrix.SignedBeaconBlock{
+					Message: &bellatrix.BeaconBlock{
+						Slot: 3,
+						Body: &bellatrix.BeaconBlockBody{
+							ETH1Data:         eth1Data,
+							SyncAggregate:    syncAggregate,
+							ExecutionPayload: &bellatrix.ExecutionPayload{BlockNumber: 3},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Capella",
+			block: &spec.VersionedSignedBeaconBlock{
+				Version: spec.DataVersionCapella,
+				Capella: &capella.SignedBeaconBlock{
+					Message: &capella.BeaconBlock{
+						Slot: 4,
+						Body: &capella.BeaconBlockBody{
+							ETH1Data:         eth1Data,
+							SyncAggregate:    syncAggregate,
+							ExecutionPayload: &capella.ExecutionPayload{BlockNumber: 4, Withdrawals: []*capella.Withdrawal{}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Deneb",
+			block: &spec.VersionedSignedBeaconBlock{
+				Version: spec.DataVersionDeneb,
+				Deneb: &deneb.SignedBeaconBlock{
+					Message: &deneb.BeaconBlock{
+						Slot: 5,
+						Body: &deneb.BeaconBlockBody{
+							ETH1Data:         eth1Data,
+							SyncAggregate:    syncAggregate,
+							ExecutionPayload: &deneb.ExecutionPayload{BlockNumber: 5, BaseFeePerGas: uint256.NewInt(7), Withdrawals: []*capella.Withdrawal{}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := encodeRawBlock(test.block)
+			require.NoError(t, err)
+			decoded, err := decodeRawBlock(&chaindb.RawBlock{Version: test.block.Version, Data: data})
+			require.NoError(t, err)
+			require.Equal(t, test.block.Version, decoded.Version)
+			expectedRoot, err := test.block.Root()
+			require.NoError(t, err)
+			root, err := decoded.Root()
+			require.NoError(t, err)
+			require.Equal(t, expectedRoot, root)
+			reencoded, err := encodeRawBlock(decoded)
+			require.NoError(t, err)
+			require.Equal(t, data, reencoded)
+		})
+	}
+
+	_, err := encodeRawBlock(&spec.VersionedSignedBeaconBlock{Version: spec.DataVersionUnknown})
+	require.EqualError(t, err, "unhandled block version unknown")
+	_, err = decodeRawBlock(&chaindb.RawBlock{Version: spec.DataVersionUnknown})
+	require.EqualError(t, err, "unhandled block version unknown")
+}
+
+// reprocessDB is a chain database that records the order in which blocks and
+// their contents are set, along with finalizer progress.
+type reprocessDB struct {
+	*mockchaindb.InMemoryService
+
+	events   []string
+	progress map[string]int64
+}
+
+func (d *reprocessDB) SetBlock(ctx context.Context, block *chaindb.Block) error {
+	switch {
+	case block.Canonical == nil:
+		d.events = append(d.events, "block")
+	case *block.Canonical:
+		d.events = append(d.events, "canonical block")
+	default:
+		d.events = append(d.events, "non-canonical block")
+	}
+
+	return d.InMemoryService.SetBlock(ctx, block)
+}
+
+func (d *reprocessDB) SetVoluntaryExit(_ context.Context, _ *chaindb.VoluntaryExit) error {
+	d.events = append(d.events, "voluntary exit")
+
+	return nil
+}
+
+func (d *reprocessDB) SetProgress(_ context.Context, _ string, key string, value int64) error {
+	d.progress[key] = value
+
+	return nil
+}
+
+func (d *reprocessDB) Progress(_ context.Context, service string) (*chaindb.Progress, error) {
+	if service != finalizer.ProgressService || len(d.progress) == 0 {
+		return nil, nil
+	}
+	values := make(map[string]int64, len(d.progress))
+	for key, value := range d.progress {
+		values[key] = value
+	}
+
+	return &chaindb.Progress{Service: service, Values: values}, nil
+}
+
+// reprocessBlock returns a phase 0 block for the given slot holding a single voluntary exit.
+func reprocessBlock(slot phase0.Slot) *spec.VersionedSignedBeaconBlock {
+	return &spec.VersionedSignedBeaconBlock{
+		Version: spec.DataVersionPhase0,
+		Phase0: &phase0.SignedBeaconBlock{
+			Message: &phase0.BeaconBlock{
+				Slot: slot,
+				Body: &phase0.BeaconBlockBody{
+					ETH1Data: &phase0.ETH1Data{BlockHash: make([]byte, 32)},
+					VoluntaryExits: []*phase0.SignedVoluntaryExit{
+						{Message: &phase0.VoluntaryExit{Epoch: 1, ValidatorIndex: 2}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// reprocessChainTime is a chain time with 32 slots per epoch.
+type reprocessChainTime struct {
+	chaintime.Service
+}
+
+func (*reprocessChainTime) SlotToEpoch(slot phase0.Slot) phase0.Epoch {
+	return phase0.Epoch(slot / 32)
+}
+
+func newReprocessService(t *testing.T, db *reprocessDB) *Service {
+	t.Helper()
+
+	return &Service{
+		chainDB:                  db,
+		chainTime:                &reprocessChainTime{Service: mockchaintime.New()},
+		blocksSetter:             db,
+		attestationsSetter:       db,
+		attesterSlashingsSetter:  db,
+		proposerSlashingsSetter:  db,
+		depositsSetter:           db,
+		voluntaryExitsSetter:     db,
+		beaconCommitteesProvider: db,
+		pendingRoots:             make(map[phase0.Root]phase0.Slot),
+	}
+}
+
+func TestReprocessRawBlockKeepsCanonical(t *testing.T) {
+	ctx := context.Background()
+
+	canonical := true
+	notCanonical := false
+	tests := []struct {
+		name      string
+		canonical *bool
+		events    []string
+	}{
+		{
+			name:   "Unknown",
+			events: []string{"block", "voluntary exit"},
+		},
+		{
+			name:      "Canonical",
+			canonical: &canonical,
+			events:    []string{"canonical block", "voluntary exit", "canonical block"},
+		},
+		{
+			name:      "NonCanonical",
+			canonical: &notCanonical,
+			events:    []string{"non-canonical block", "voluntary exit", "non-canonical block"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inMemory, err := mockchaindb.NewInMemory(ctx, nil)
+			require.NoError(t, err)
+			db := &reprocessDB{InMemoryService: inMemory, progress: make(map[string]int64)}
+			s := newReprocessService(t, db)
+
+			signedBlock := reprocessBlock(10)
+			dbBlock, err := s.dbBlock(ctx, signedBlock)
+			require.NoError(t, err)
+			dbBlock.Canonical = test.canonical
+			require.NoError(t, inMemory.SetBlock(ctx, dbBlock))
+
+			data, err := encodeRawBlock(signedBlock)
+			require.NoError(t, err)
+			require.NoError(t, s.reprocessRawBlock(ctx, &chaindb.RawBlock{
+				Root:    dbBlock.Root,
+				Slot:    dbBlock.Slot,
+				Version: signedBlock.Version,
+				Data:    data,
+			}))
+
+			// The block is set again after its contents, so that its canonical state reaches them.
+			require.Equal(t, test.events, db.events)
+			stored, err := db.BlockByRoot(ctx, dbBlock.Root)
+			require.NoError(t, err)
+			require.Equal(t, test.canonical, stored.Canonical)
+		})
+	}
+}
+
+func TestReprocessRawBlockRootMismatch(t *testing.T) {
+	ctx := context.Background()
+
+	inMemory, err := mockchaindb.NewInMemory(ctx, nil)
+	require.NoError(t, err)
+	db := &reprocessDB{InMemoryService: inMemory, progress: make(map[string]int64)}
+	s := newReprocessService(t, db)
+
+	data, err := encodeRawBlock(reprocessBlock(10))
+	require.NoError(t, err)
+	require.ErrorContains(t, s.reprocessRawBlock(ctx, &chaindb.RawBlock{
+		Root:    phase0.Root{0x01},
+		Slot:    10,
+		Version: spec.DataVersionPhase0,
+		Data:    data,
+	}), "raw block has root")
+	require.Empty(t, db.events)
+}
+
+func TestRewindFinalizer(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		progress map[string]int64
+		slot     phase0.Slot
+		expected map[string]int64
+	}{
+		{
+			name:     "NoProgress",
+			progress: map[string]int64{},
+			slot:     64,
+			expected: map[string]int64{},
+		},
+		{
+			name:     "Ahead",
+			progress: map[string]int64{"latest_canonical_slot": 200, "latest_epoch": 6},
+			slot:     64,
+			expected: map[string]int64{"latest_canonical_slot": 63, "latest_epoch": 1},
+		},
+		{
+			name:     "Behind",
+			progress: map[string]int64{"latest_canonical_slot": 20, "latest_epoch": 0},
+			slot:     64,
+			expected: map[string]int64{"latest_canonical_slot": 20, "latest_epoch": 0},
+		},
+		{
+			name:     "SlotAheadEpochBehind",
+			progress: map[string]int64{"latest_canonical_slot": 70, "latest_epoch": 1},
+			slot:     64,
+			expected: map[string]int64{"latest_canonical_slot": 63, "latest_epoch": 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inMemory, err := mockchaindb.NewInMemory(ctx, nil)
+			require.NoError(t, err)
+			db := &reprocessDB{InMemoryService: inMemory, progress: test.progress}
+			s := newReprocessService(t, db)
+
+			require.NoError(t, s.rewindFinalizer(ctx, test.slot))
+			require.Equal(t, test.expected, db.progress)
+		})
+	}
+}
@@ -15,15 +15,19 @@ package standard
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
-	api "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/plugins"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/coldstore"
+	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -41,11 +45,36 @@ type Service struct {
 	beaconCommitteesProvider chaindb.BeaconCommitteesProvider
 	syncCommitteesProvider   chaindb.SyncCommitteesProvider
 	blobSidecarsSetter       chaindb.BlobSidecarsSetter
+	arrivalsSetter           chaindb.ArrivalsSetter
+	rawBlocksSetter          chaindb.RawBlocksSetter
+	blockHeadersSetter       chaindb.BlockHeadersSetter
+	headersProvider          eth2client.BeaconBlockHeadersProvider
+	coldStore                coldstore.Service
 	chainTime                chaintime.Service
+	endSlot                  int64
 	refetch                  bool
+	pollInterval             time.Duration
+	batchSlots               uint64
+	queueSize                uint64
+	maxAttempts              uint32
+	failedItemsProvider      chaindb.FailedItemsProvider
+	orphanedBodies           bool
+	arrivals                 bool
+	blobSidecars             bool
+	rawBlocks                bool
+	headersFirst             bool
+	headerFetchers           uint64
+	bodiesMu                 sync.Mutex
+	bodyBackfillCh           chan struct{}
+	pendingRootsMu           sync.Mutex
+	pendingRoots             map[phase0.Root]phase0.Slot
+	pendingArrivalsMu        sync.Mutex
+	pendingArrivals          map[phase0.Root]*chaindb.BlockArrival
+	lastEventTime            atomic.Int64
 	lastHandledBlockRoot     phase0.Root
 	activitySem              *semaphore.Weighted
 	syncCommittees           map[uint64]*chaindb.SyncCommittee
+	plugins                  *plugins.Runner
 }
 
 // module-wide log.
@@ -59,7 +88,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "blocks").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log = util.ServiceLogger("blocks", "standard", parameters.logLevel)
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -115,6 +144,51 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not support blob sidecar setting")
 	}
 
+	var arrivalsSetter chaindb.ArrivalsSetter
+	if parameters.arrivals {
+		var isArrivalsSetter bool
+		arrivalsSetter, isArrivalsSetter = parameters.chainDB.(chaindb.ArrivalsSetter)
+		if !isArrivalsSetter {
+			return nil, errors.New("chain DB does not support arrival setting")
+		}
+	}
+
+	var rawBlocksSetter chaindb.RawBlocksSetter
+	if parameters.rawBlocks {
+		var isRawBlocksSetter bool
+		rawBlocksSetter, isRawBlocksSetter = parameters.chainDB.(chaindb.RawBlocksSetter)
+		if !isRawBlocksSetter {
+			return nil, errors.New("chain DB does not support raw block setting")
+		}
+	}
+
+	var blockHeadersSetter chaindb.BlockHeadersSetter
+	var headersProvider eth2client.BeaconBlockHeadersProvider
+	if parameters.headersFirst {
+		var isBlockHeadersSetter bool
+		blockHeadersSetter, isBlockHeadersSetter = parameters.chainDB.(chaindb.BlockHeadersSetter)
+		if !isBlockHeadersSetter {
+			return nil, errors.New("chain DB does not support block header setting")
+		}
+		var isHeadersProvider bool
+		headersProvider, isHeadersProvider = parameters.eth2Client.(eth2client.BeaconBlockHeadersProvider)
+		if !isHeadersProvider {
+			return nil, errors.New("client does not provide beacon block headers")
+		}
+	}
+
+	var failedItemsProvider chaindb.FailedItemsProvider
+	if parameters.maxAttempts > 0 {
+		var isFailedItemsProvider bool
+		failedItemsProvider, isFailedItemsProvider = parameters.chainDB.(chaindb.FailedItemsProvider)
+		if !isFailedItemsProvider {
+			return nil, errors.New("chain DB does not support failed item providing")
+		}
+		if _, isFailedItemsSetter := parameters.chainDB.(chaindb.FailedItemsSetter); !isFailedItemsSetter {
+			return nil, errors.New("chain DB does not support failed item setting")
+		}
+	}
+
 	s := &Service{
 		eth2Client:               parameters.eth2Client,
 		chainDB:                  parameters.chainDB,
@@ -128,10 +202,31 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		beaconCommitteesProvider: beaconCommitteesProvider,
 		syncCommitteesProvider:   syncCommitteesProvider,
 		blobSidecarsSetter:       blobSidecarsSetter,
+		arrivalsSetter:           arrivalsSetter,
+		rawBlocksSetter:          rawBlocksSetter,
+		blockHeadersSetter:       blockHeadersSetter,
+		headersProvider:          headersProvider,
+		coldStore:                parameters.coldStore,
 		chainTime:                parameters.chainTime,
+		endSlot:                  parameters.endSlot,
 		refetch:                  parameters.refetch,
+		pollInterval:             parameters.pollInterval,
+		batchSlots:               parameters.batchSlots,
+		queueSize:                parameters.queueSize,
+		maxAttempts:              parameters.maxAttempts,
+		failedItemsProvider:      failedItemsProvider,
+		orphanedBodies:           parameters.orphanedBodies,
+		arrivals:                 parameters.arrivals,
+		blobSidecars:             parameters.blobSidecars,
+		rawBlocks:                parameters.rawBlocks,
+		headersFirst:             parameters.headersFirst,
+		headerFetchers:           parameters.headerFetchers,
+		bodyBackfillCh:           make(chan struct{}, 1),
+		pendingRoots:             make(map[phase0.Root]phase0.Slot),
+		pendingArrivals:          make(map[phase0.Root]*chaindb.BlockArrival),
 		activitySem:              parameters.activitySem,
 		syncCommittees:           make(map[uint64]*chaindb.SyncCommittee),
+		plugins:                  parameters.plugins,
 	}
 
 	// Note the current highest processed block for the monitor.
@@ -141,8 +236,10 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 	monitorLatestSlot(phase0.Slot(md.LatestSlot))
 
-	// Update to current epoch before starting (in the background).
-	go s.updateAfterRestart(ctx, parameters.startSlot)
+	if parameters.catchup {
+		// Update to current epoch before starting (in the background).
+		go s.updateAfterRestart(ctx, parameters.startSlot)
+	}
 
 	return s, nil
 }
@@ -166,21 +263,36 @@ func (s *Service) updateAfterRestart(ctx context.Context, startSlot int64) {
 	if startSlot >= 0 {
 		// Explicit requirement to start at a given slot.
 		md.LatestSlot = startSlot - 1
+		if md.LatestHeaderSlot < md.LatestSlot {
+			md.LatestHeaderSlot = md.LatestSlot
+		}
+	}
+
+	if s.headersFirst {
+		// Bodies are filled in behind the headers by the body backfill worker,
+		// which holds its own copy of the metadata.
+		go s.backfillBodies(ctx, &metadata{
+			LatestSlot:       md.LatestSlot,
+			LatestHeaderSlot: md.LatestHeaderSlot,
+		})
+		s.triggerBodyBackfill()
 	}
 
 	log.Info().Uint64("slot", uint64(md.LatestSlot)).Msg("Catching up from slot")
 	s.catchup(ctx, md)
 	log.Info().Msg("Caught up")
 
-	// Set up the handler for new chain head updates.
-	if err := s.eth2Client.(eth2client.EventsProvider).Events(ctx, []string{"head"}, func(event *api.Event) {
-		if event.Data == nil {
-			// Happens when the channel shuts down, nothing to worry about.
-			return
-		}
-		eventData := event.Data.(*api.HeadEvent)
-		s.OnBeaconChainHeadUpdated(ctx, eventData.Slot, eventData.Block, eventData.State, eventData.EpochTransition)
-	}); err != nil {
-		log.Fatal().Err(err).Msg("Failed to add beacon chain head updated handler")
+	// When fetching headers first the handlers only fetch headers, so the
+	// end slot is reached once its header has been stored.
+	latestSlot := md.LatestSlot
+	if s.headersFirst {
+		latestSlot = md.LatestHeaderSlot
 	}
+	if s.endSlot >= 0 && latestSlot >= s.endSlot {
+		log.Info().Int64("end_slot", s.endSlot).Msg("Reached end slot; idling")
+		return
+	}
+
+	// Set up the handlers for new chain head updates.
+	s.subscribe(ctx)
 }
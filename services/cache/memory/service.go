@@ -0,0 +1,126 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a cache held in memory.
+type Service struct {
+	maxEntries int
+	entries    map[string]*entry
+	mutex      sync.RWMutex
+}
+
+// entry is a value in the cache.
+type entry struct {
+	value []byte
+	// expiry is the time at which the entry expires; zero if it does not expire.
+	expiry time.Time
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("cache", "memory", parameters.logLevel)
+
+	return &Service{
+		maxEntries: parameters.maxEntries,
+		entries:    make(map[string]*entry),
+	}, nil
+}
+
+// Get fetches the values for the given keys.
+func (s *Service) Get(_ context.Context, keys []string) (map[string][]byte, error) {
+	now := time.Now()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	res := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		entry, exists := s.entries[key]
+		if !exists || entry.expired(now) {
+			continue
+		}
+		res[key] = entry.value
+	}
+
+	return res, nil
+}
+
+// Set stores the given values, expiring after the given time to live.
+func (s *Service) Set(_ context.Context, values map[string][]byte, ttl time.Duration) error {
+	var expiry time.Time
+	if ttl > 0 {
+		expiry = time.Now().Add(ttl)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key, value := range values {
+		if _, exists := s.entries[key]; !exists && s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+			s.evict()
+		}
+		s.entries[key] = &entry{
+			value:  value,
+			expiry: expiry,
+		}
+	}
+
+	return nil
+}
+
+// Delete removes the given keys from the cache.
+func (s *Service) Delete(_ context.Context, keys []string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+
+	return nil
+}
+
+// evict makes room for a new entry by removing an arbitrary entry.
+// This assumes that the mutex is held.
+func (s *Service) evict() {
+	for key := range s.entries {
+		log.Trace().Str("key", key).Msg("Evicting entry")
+		delete(s.entries, key)
+		return
+	}
+}
+
+// expired returns true if the entry has expired at the given time.
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiry.IsZero() && now.After(e.expiry)
+}
@@ -0,0 +1,111 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/cache/memory"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []memory.Parameter
+		err    string
+	}{
+		{
+			name: "MaxEntriesNegative",
+			params: []memory.Parameter{
+				memory.WithLogLevel(zerolog.Disabled),
+				memory.WithMaxEntries(-1),
+			},
+			err: "problem with parameters: max entries cannot be negative",
+		},
+		{
+			name: "Good",
+			params: []memory.Parameter{
+				memory.WithLogLevel(zerolog.Disabled),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := memory.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSetGetDelete(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := memory.New(ctx, memory.WithLogLevel(zerolog.Disabled))
+	require.NoError(t, err)
+
+	res, err := s.Get(ctx, []string{"a", "b"})
+	require.NoError(t, err)
+	require.Empty(t, res)
+
+	require.NoError(t, s.Set(ctx, map[string][]byte{"a": []byte("1"), "b": []byte("2")}, 0))
+	res, err = s.Get(ctx, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"a": []byte("1"), "b": []byte("2")}, res)
+
+	require.NoError(t, s.Delete(ctx, []string{"a"}))
+	res, err = s.Get(ctx, []string{"a", "b"})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"b": []byte("2")}, res)
+}
+
+func TestExpiry(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := memory.New(ctx, memory.WithLogLevel(zerolog.Disabled))
+	require.NoError(t, err)
+
+	require.NoError(t, s.Set(ctx, map[string][]byte{"a": []byte("1")}, time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	res, err := s.Get(ctx, []string{"a"})
+	require.NoError(t, err)
+	require.Empty(t, res)
+}
+
+func TestMaxEntries(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := memory.New(ctx,
+		memory.WithLogLevel(zerolog.Disabled),
+		memory.WithMaxEntries(2),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Set(ctx, map[string][]byte{"a": []byte("1"), "b": []byte("2")}, 0))
+	require.NoError(t, s.Set(ctx, map[string][]byte{"c": []byte("3")}, 0))
+	res, err := s.Get(ctx, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, []byte("3"), res["c"])
+}
@@ -0,0 +1,124 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a cache held in Redis, allowing it to be shared between instances.
+type Service struct {
+	client *goredis.Client
+	prefix string
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("cache", "redis", parameters.logLevel)
+
+	options, err := goredis.ParseURL(parameters.url)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid URL")
+	}
+	client := goredis.NewClient(options)
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to connect to Redis")
+	}
+
+	go func() {
+		<-ctx.Done()
+		log.Trace().Msg("Context done; closing client")
+		if err := client.Close(); err != nil {
+			log.Debug().Err(err).Msg("Failed to close client")
+		}
+	}()
+
+	return &Service{
+		client: client,
+		prefix: parameters.prefix,
+	}, nil
+}
+
+// Get fetches the values for the given keys.
+func (s *Service) Get(ctx context.Context, keys []string) (map[string][]byte, error) {
+	res := make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return res, nil
+	}
+
+	prefixedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		prefixedKeys[i] = s.prefix + key
+	}
+	values, err := s.client.MGet(ctx, prefixedKeys...).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get values")
+	}
+	for i, value := range values {
+		if str, isString := value.(string); isString {
+			res[keys[i]] = []byte(str)
+		}
+	}
+
+	return res, nil
+}
+
+// Set stores the given values, expiring after the given time to live.
+func (s *Service) Set(ctx context.Context, values map[string][]byte, ttl time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	pipeline := s.client.Pipeline()
+	for key, value := range values {
+		pipeline.Set(ctx, s.prefix+key, value, ttl)
+	}
+	if _, err := pipeline.Exec(ctx); err != nil {
+		return errors.Wrap(err, "failed to set values")
+	}
+
+	return nil
+}
+
+// Delete removes the given keys from the cache.
+func (s *Service) Delete(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		prefixedKeys[i] = s.prefix + key
+	}
+	if err := s.client.Del(ctx, prefixedKeys...).Err(); err != nil {
+		return errors.Wrap(err, "failed to delete values")
+	}
+
+	return nil
+}
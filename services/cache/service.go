@@ -0,0 +1,33 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Service is a cache of values by key.
+type Service interface {
+	// Get fetches the values for the given keys.
+	// Keys that are not present in the cache are omitted from the result.
+	Get(ctx context.Context, keys []string) (map[string][]byte, error)
+
+	// Set stores the given values, expiring after the given time to live.
+	// A time to live of 0 means that the values do not expire.
+	Set(ctx context.Context, values map[string][]byte, ttl time.Duration) error
+
+	// Delete removes the given keys from the cache.
+	Delete(ctx context.Context, keys []string) error
+}
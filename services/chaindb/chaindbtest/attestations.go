@@ -0,0 +1,307 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindbtest
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// testAttestationData returns attestations for the suite.  Every block after the
+// first includes two attestations for the slot before it, one from committee 0
+// with validators baseIndex and baseIndex+1 and one from committee 1 with validators
+// baseIndex+2 and baseIndex+3.  Attestations share the canonical status of their
+// including block.
+func testAttestationData(blocks []*chaindb.Block) []*chaindb.Attestation {
+	attestations := make([]*chaindb.Attestation, 0)
+	for _, block := range blocks {
+		if block.Slot == baseSlot {
+			continue
+		}
+		for i := uint64(0); i < 2; i++ {
+			attestations = append(attestations, &chaindb.Attestation{
+				InclusionSlot:      block.Slot,
+				InclusionBlockRoot: block.Root,
+				InclusionIndex:     i,
+				Slot:               block.Slot - 1,
+				CommitteeIndex:     phase0.CommitteeIndex(i),
+				AggregationBits:    []byte{0x07},
+				AggregationIndices: []phase0.ValidatorIndex{
+					baseIndex + phase0.ValidatorIndex(i*2),
+					baseIndex + phase0.ValidatorIndex(i*2+1),
+				},
+				BeaconBlockRoot: block.ParentRoot,
+				SourceEpoch:     baseEpoch,
+				SourceRoot:      testRoot("source", 0),
+				TargetEpoch:     baseEpoch + 1,
+				TargetRoot:      testRoot("target", 0),
+				Canonical:       block.Canonical,
+			})
+		}
+	}
+
+	return attestations
+}
+
+// setAttestations sets the suite's blocks and attestations.
+func setAttestations(ctx context.Context, t *testing.T, s chaindb.Service) ([]*chaindb.Block, []*chaindb.Attestation) {
+	t.Helper()
+
+	setter := implementation[chaindb.AttestationsSetter](t, s)
+	blocks := setBlocks(ctx, t, s)
+	attestations := testAttestationData(blocks)
+	require.NoError(t, setter.SetAttestations(ctx, attestations))
+
+	return blocks, attestations
+}
+
+// sortedAttestations returns attestations in order of inclusion slot, inclusion block root
+// then inclusion index.
+func sortedAttestations(attestations ...*chaindb.Attestation) []*chaindb.Attestation {
+	res := append([]*chaindb.Attestation{}, attestations...)
+	sort.Slice(res, func(i int, j int) bool {
+		if res[i].InclusionSlot != res[j].InclusionSlot {
+			return res[i].InclusionSlot < res[j].InclusionSlot
+		}
+		if cmp := bytes.Compare(res[i].InclusionBlockRoot[:], res[j].InclusionBlockRoot[:]); cmp != 0 {
+			return cmp < 0
+		}
+
+		return res[i].InclusionIndex < res[j].InclusionIndex
+	})
+
+	return res
+}
+
+// selectAttestations returns the attestations that match the supplied function.
+func selectAttestations(attestations []*chaindb.Attestation, match func(attestation *chaindb.Attestation) bool) []*chaindb.Attestation {
+	res := make([]*chaindb.Attestation, 0)
+	for _, attestation := range attestations {
+		if match(attestation) {
+			res = append(res, attestation)
+		}
+	}
+
+	return res
+}
+
+// requireAttestations requires that the attestations match, in order.
+func requireAttestations(t *testing.T, expected []*chaindb.Attestation, actual []*chaindb.Attestation) {
+	t.Helper()
+
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		require.Equal(t, expected[i].InclusionSlot, actual[i].InclusionSlot)
+		require.Equal(t, expected[i].InclusionBlockRoot, actual[i].InclusionBlockRoot)
+		require.Equal(t, expected[i].InclusionIndex, actual[i].InclusionIndex)
+		require.Equal(t, expected[i].Slot, actual[i].Slot)
+		require.Equal(t, expected[i].CommitteeIndex, actual[i].CommitteeIndex)
+		require.Equal(t, expected[i].AggregationBits, actual[i].AggregationBits)
+		require.Equal(t, expected[i].AggregationIndices, actual[i].AggregationIndices)
+		require.Equal(t, expected[i].BeaconBlockRoot, actual[i].BeaconBlockRoot)
+		require.Equal(t, expected[i].SourceEpoch, actual[i].SourceEpoch)
+		require.Equal(t, expected[i].SourceRoot, actual[i].SourceRoot)
+		require.Equal(t, expected[i].TargetEpoch, actual[i].TargetEpoch)
+		require.Equal(t, expected[i].TargetRoot, actual[i].TargetRoot)
+		require.Equal(t, expected[i].Canonical, actual[i].Canonical)
+	}
+}
+
+// testAttestations checks the functions to obtain attestations.
+func testAttestations(t *testing.T, s chaindb.Service) {
+	provider := implementation[chaindb.AttestationsProvider](t, s)
+	ctx := beginTx(t, s)
+	blocks, attestations := setAttestations(ctx, t, s)
+
+	inBlock, err := provider.AttestationsInBlock(ctx, blocks[3].Root)
+	require.NoError(t, err)
+	requireAttestations(t,
+		selectAttestations(attestations, func(attestation *chaindb.Attestation) bool {
+			return attestation.InclusionBlockRoot == blocks[3].Root
+		}),
+		sortedAttestations(inBlock...),
+	)
+
+	forBlock, err := provider.AttestationsForBlock(ctx, blocks[2].Root)
+	require.NoError(t, err)
+	requireAttestations(t,
+		selectAttestations(attestations, func(attestation *chaindb.Attestation) bool {
+			return attestation.BeaconBlockRoot == blocks[2].Root
+		}),
+		sortedAttestations(forBlock...),
+	)
+
+	forSlotRange, err := provider.AttestationsForSlotRange(ctx, baseSlot+1, baseSlot+3)
+	require.NoError(t, err)
+	requireAttestations(t,
+		sortedAttestations(selectAttestations(attestations, func(attestation *chaindb.Attestation) bool {
+			return attestation.Slot >= baseSlot+1 && attestation.Slot < baseSlot+3
+		})...),
+		sortedAttestations(forSlotRange...),
+	)
+
+	inSlotRange, err := provider.AttestationsInSlotRange(ctx, baseSlot+5, baseSlot+6)
+	require.NoError(t, err)
+	requireAttestations(t,
+		sortedAttestations(selectAttestations(attestations, func(attestation *chaindb.Attestation) bool {
+			return attestation.InclusionSlot == baseSlot+5
+		})...),
+		sortedAttestations(inSlotRange...),
+	)
+
+	indeterminate, err := provider.IndeterminateAttestationSlots(ctx, baseSlot, baseSlot+8)
+	require.NoError(t, err)
+	require.Equal(t, []phase0.Slot{baseSlot + 6}, indeterminate)
+
+	filtered, err := provider.Attestations(ctx, &chaindb.AttestationFilter{
+		From:             slotPtr(baseSlot),
+		ValidatorIndices: []phase0.ValidatorIndex{baseIndex + 2},
+	})
+	require.NoError(t, err)
+	requireAttestations(t,
+		sortedAttestations(selectAttestations(attestations, func(attestation *chaindb.Attestation) bool {
+			return attestation.CommitteeIndex == 1
+		})...),
+		filtered,
+	)
+
+	filtered, err = provider.Attestations(ctx, &chaindb.AttestationFilter{
+		From:      slotPtr(baseSlot),
+		Canonical: boolPtr(false),
+	})
+	require.NoError(t, err)
+	requireAttestations(t,
+		sortedAttestations(selectAttestations(attestations, func(attestation *chaindb.Attestation) bool {
+			return attestation.Canonical != nil && !*attestation.Canonical
+		})...),
+		filtered,
+	)
+
+	filtered, err = provider.Attestations(ctx, &chaindb.AttestationFilter{
+		ScheduledFrom: slotPtr(baseSlot + 2),
+		ScheduledTo:   slotPtr(baseSlot + 2),
+	})
+	require.NoError(t, err)
+	requireAttestations(t,
+		sortedAttestations(selectAttestations(attestations, func(attestation *chaindb.Attestation) bool {
+			return attestation.Slot == baseSlot+2
+		})...),
+		filtered,
+	)
+
+	filtered, err = provider.Attestations(ctx, &chaindb.AttestationFilter{
+		From:  slotPtr(baseSlot),
+		Order: chaindb.OrderLatest,
+		Limit: 3,
+	})
+	require.NoError(t, err)
+	sorted := sortedAttestations(attestations...)
+	requireAttestations(t, sorted[len(sorted)-3:], filtered)
+}
+
+// testAttestationsForValidator checks the function to obtain the attestations of a single validator.
+func testAttestationsForValidator(t *testing.T, s chaindb.Service) {
+	provider := implementation[chaindb.AttestationsProvider](t, s)
+	specSetter := implementation[chaindb.ChainSpecSetter](t, s)
+	committeesSetter := implementation[chaindb.BeaconCommitteesSetter](t, s)
+	ctx := beginTx(t, s)
+
+	require.NoError(t, specSetter.SetChainSpecValue(ctx, "SLOTS_PER_EPOCH", uint64(32)))
+	_, attestations := setAttestations(ctx, t, s)
+	for _, attestation := range attestations {
+		require.NoError(t, committeesSetter.SetBeaconCommittee(ctx, &chaindb.BeaconCommittee{
+			Slot:      attestation.Slot,
+			Index:     attestation.CommitteeIndex,
+			Committee: attestation.AggregationIndices,
+		}))
+	}
+
+	forValidator, err := provider.AttestationsForValidator(ctx, baseIndex+2, baseEpoch, baseEpoch+1)
+	require.NoError(t, err)
+	requireAttestations(t,
+		sortedAttestations(selectAttestations(attestations, func(attestation *chaindb.Attestation) bool {
+			return attestation.CommitteeIndex == 1
+		})...),
+		forValidator,
+	)
+
+	forValidator, err = provider.AttestationsForValidator(ctx, baseIndex+2, baseEpoch+1, baseEpoch+2)
+	require.NoError(t, err)
+	require.Empty(t, forValidator)
+
+	forValidator, err = provider.AttestationsForValidator(ctx, baseIndex+4, baseEpoch, baseEpoch+1)
+	require.NoError(t, err)
+	require.Empty(t, forValidator)
+}
+
+// testAttestationsPagination checks that attestations can be paged through with cursors and streamed.
+func testAttestationsPagination(t *testing.T, s chaindb.Service) {
+	provider := implementation[chaindb.AttestationsProvider](t, s)
+	ctx := beginTx(t, s)
+	_, attestations := setAttestations(ctx, t, s)
+	expected := sortedAttestations(attestations...)
+
+	for _, order := range []chaindb.Order{chaindb.OrderEarliest, chaindb.OrderLatest} {
+		fetched := make([]*chaindb.Attestation, 0)
+		filter := &chaindb.AttestationFilter{
+			From:  slotPtr(baseSlot),
+			Order: order,
+			Limit: 5,
+		}
+		for {
+			page, err := provider.Attestations(ctx, filter)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page), 5)
+			if len(page) == 0 {
+				break
+			}
+			boundary := page[len(page)-1]
+			if order == chaindb.OrderLatest {
+				boundary = page[0]
+			}
+			fetched = append(fetched, page...)
+			filter.Cursor = &chaindb.AttestationCursor{
+				InclusionSlot:      boundary.InclusionSlot,
+				InclusionBlockRoot: boundary.InclusionBlockRoot,
+				InclusionIndex:     boundary.InclusionIndex,
+			}
+		}
+		requireAttestations(t, expected, sortedAttestations(fetched...))
+	}
+
+	streamer := implementation[chaindb.AttestationsStreamProvider](t, s)
+	stream, err := streamer.AttestationsStream(ctx, &chaindb.AttestationFilter{
+		From:             slotPtr(baseSlot),
+		ValidatorIndices: []phase0.ValidatorIndex{baseIndex},
+	})
+	require.NoError(t, err)
+	defer stream.Close()
+	streamed := make([]*chaindb.Attestation, 0)
+	for stream.Next(ctx) {
+		streamed = append(streamed, stream.Value())
+	}
+	require.NoError(t, stream.Err())
+	requireAttestations(t,
+		selectAttestations(expected, func(attestation *chaindb.Attestation) bool {
+			return attestation.CommitteeIndex == 0
+		}),
+		streamed,
+	)
+}
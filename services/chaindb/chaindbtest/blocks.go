@@ -0,0 +1,252 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindbtest
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// testBlockData returns blocks for the suite:
+//   - canonical blocks in slots baseSlot to baseSlot+5, each the child of the previous block;
+//   - a non-canonical block in slot baseSlot+5 that is also the child of the block in slot baseSlot+4;
+//   - no block in slot baseSlot+6;
+//   - a block without a canonical status in slot baseSlot+7.
+func testBlockData() []*chaindb.Block {
+	blocks := make([]*chaindb.Block, 0)
+	for i := uint64(0); i < 6; i++ {
+		blocks = append(blocks, testBlock(i, 0, boolPtr(true)))
+	}
+	blocks = append(blocks, testBlock(5, 1, boolPtr(false)))
+	blocks = append(blocks, testBlock(7, 0, nil))
+
+	return blocks
+}
+
+// testBlock returns a block at the given offset from the base slot.
+func testBlock(offset uint64, variant uint64, canonical *bool) *chaindb.Block {
+	block := &chaindb.Block{
+		Slot:          baseSlot + phase0.Slot(offset),
+		ProposerIndex: baseIndex + phase0.ValidatorIndex(offset%4),
+		Root:          testRoot("block", offset*16+variant),
+		Graffiti:      make([]byte, 32),
+		BodyRoot:      testRoot("body", offset*16+variant),
+		StateRoot:     testRoot("state", offset*16+variant),
+		Canonical:     canonical,
+		ETH1BlockHash: make([]byte, 32),
+	}
+	copy(block.Graffiti, "chaindbtest")
+	if offset > 0 {
+		parentOffset := offset - 1
+		if offset == 7 {
+			parentOffset = 5
+		}
+		block.ParentRoot = testRoot("block", parentOffset*16)
+	}
+
+	return block
+}
+
+// setBlocks sets the suite's blocks.
+func setBlocks(ctx context.Context, t *testing.T, s chaindb.Service) []*chaindb.Block {
+	t.Helper()
+
+	setter := implementation[chaindb.BlocksSetter](t, s)
+	blocks := testBlockData()
+	for _, block := range blocks {
+		require.NoError(t, setter.SetBlock(ctx, block))
+	}
+
+	return blocks
+}
+
+// requireBlocks requires that the blocks match, in order.
+func requireBlocks(t *testing.T, expected []*chaindb.Block, actual []*chaindb.Block) {
+	t.Helper()
+
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		requireBlock(t, expected[i], actual[i])
+	}
+}
+
+// requireBlock requires that the block matches.
+func requireBlock(t *testing.T, expected *chaindb.Block, actual *chaindb.Block) {
+	t.Helper()
+
+	require.NotNil(t, actual)
+	require.Equal(t, expected.Slot, actual.Slot)
+	require.Equal(t, expected.Root, actual.Root)
+	require.Equal(t, expected.ProposerIndex, actual.ProposerIndex)
+	require.Equal(t, expected.ParentRoot, actual.ParentRoot)
+	require.Equal(t, expected.BodyRoot, actual.BodyRoot)
+	require.Equal(t, expected.StateRoot, actual.StateRoot)
+	require.Equal(t, expected.Graffiti, actual.Graffiti)
+	require.Equal(t, expected.Canonical, actual.Canonical)
+}
+
+// sortedBlocks returns blocks in order of slot then root.
+func sortedBlocks(blocks ...*chaindb.Block) []*chaindb.Block {
+	res := append([]*chaindb.Block{}, blocks...)
+	sort.Slice(res, func(i int, j int) bool {
+		if res[i].Slot != res[j].Slot {
+			return res[i].Slot < res[j].Slot
+		}
+
+		return bytes.Compare(res[i].Root[:], res[j].Root[:]) < 0
+	})
+
+	return res
+}
+
+// testBlocks checks the functions to obtain blocks.
+func testBlocks(t *testing.T, s chaindb.Service) {
+	provider := implementation[chaindb.BlocksProvider](t, s)
+	ctx := beginTx(t, s)
+	blocks := setBlocks(ctx, t, s)
+	fork := blocks[6]
+
+	block, err := provider.BlockByRoot(ctx, blocks[2].Root)
+	require.NoError(t, err)
+	requireBlock(t, blocks[2], block)
+
+	_, err = provider.BlockByRoot(ctx, testRoot("unknown", 0))
+	require.Error(t, err)
+
+	slotBlocks, err := provider.BlocksBySlot(ctx, baseSlot+5)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []phase0.Root{blocks[5].Root, fork.Root}, blockRoots(slotBlocks))
+
+	rangeBlocks, err := provider.BlocksForSlotRange(ctx, baseSlot+1, baseSlot+3)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []phase0.Root{blocks[1].Root, blocks[2].Root}, blockRoots(rangeBlocks))
+
+	childBlocks, err := provider.BlocksByParentRoot(ctx, blocks[4].Root)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []phase0.Root{blocks[5].Root, fork.Root}, blockRoots(childBlocks))
+
+	emptySlots, err := provider.EmptySlots(ctx, baseSlot, baseSlot+7)
+	require.NoError(t, err)
+	require.Equal(t, []phase0.Slot{baseSlot + 6}, emptySlots)
+
+	latestBlocks, err := provider.LatestBlocks(ctx)
+	require.NoError(t, err)
+	requireBlocks(t, []*chaindb.Block{blocks[7]}, latestBlocks)
+
+	indeterminate, err := provider.IndeterminateBlocks(ctx, baseSlot, baseSlot+8)
+	require.NoError(t, err)
+	require.Equal(t, []phase0.Root{blocks[7].Root}, indeterminate)
+
+	presence, err := provider.CanonicalBlockPresenceForSlotRange(ctx, baseSlot, baseSlot+8)
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, true, true, true, true, true, false, false}, presence)
+
+	latestCanonical, err := provider.LatestCanonicalBlock(ctx)
+	require.NoError(t, err)
+	require.Equal(t, baseSlot+5, latestCanonical)
+
+	filtered, err := provider.Blocks(ctx, &chaindb.BlockFilter{
+		From:      slotPtr(baseSlot + 4),
+		To:        slotPtr(baseSlot + 7),
+		Canonical: boolPtr(true),
+	})
+	require.NoError(t, err)
+	requireBlocks(t, []*chaindb.Block{blocks[4], blocks[5]}, filtered)
+
+	filtered, err = provider.Blocks(ctx, &chaindb.BlockFilter{
+		From:            slotPtr(baseSlot),
+		ProposerIndices: []phase0.ValidatorIndex{baseIndex + 1},
+	})
+	require.NoError(t, err)
+	requireBlocks(t, sortedBlocks(blocks[1], blocks[5], fork), filtered)
+
+	filtered, err = provider.Blocks(ctx, &chaindb.BlockFilter{
+		From:  slotPtr(baseSlot),
+		Order: chaindb.OrderLatest,
+		Limit: 2,
+	})
+	require.NoError(t, err)
+	requireBlocks(t, sortedBlocks(blocks[7], fork, blocks[5])[1:], filtered)
+}
+
+// testBlocksPagination checks that blocks can be paged through with cursors and streamed.
+func testBlocksPagination(t *testing.T, s chaindb.Service) {
+	provider := implementation[chaindb.BlocksProvider](t, s)
+	ctx := beginTx(t, s)
+	expected := sortedBlocks(setBlocks(ctx, t, s)...)
+
+	for _, order := range []chaindb.Order{chaindb.OrderEarliest, chaindb.OrderLatest} {
+		fetched := make([]*chaindb.Block, 0)
+		filter := &chaindb.BlockFilter{
+			From:  slotPtr(baseSlot),
+			Order: order,
+			Limit: 3,
+		}
+		for {
+			page, err := provider.Blocks(ctx, filter)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page), 3)
+			if len(page) == 0 {
+				break
+			}
+			boundary := page[len(page)-1]
+			if order == chaindb.OrderLatest {
+				boundary = page[0]
+			}
+			fetched = append(fetched, page...)
+			filter.Cursor = &chaindb.BlockCursor{
+				Slot: boundary.Slot,
+				Root: boundary.Root,
+			}
+		}
+		requireBlocks(t, expected, sortedBlocks(fetched...))
+	}
+
+	streamer := implementation[chaindb.BlocksStreamProvider](t, s)
+	for _, order := range []chaindb.Order{chaindb.OrderEarliest, chaindb.OrderLatest} {
+		stream, err := streamer.BlocksStream(ctx, &chaindb.BlockFilter{
+			From:  slotPtr(baseSlot),
+			Order: order,
+		})
+		require.NoError(t, err)
+		streamed := make([]*chaindb.Block, 0)
+		for stream.Next(ctx) {
+			streamed = append(streamed, stream.Value())
+		}
+		require.NoError(t, stream.Err())
+		stream.Close()
+		if order == chaindb.OrderLatest {
+			for i, j := 0, len(streamed)-1; i < j; i, j = i+1, j-1 {
+				streamed[i], streamed[j] = streamed[j], streamed[i]
+			}
+		}
+		requireBlocks(t, expected, streamed)
+	}
+}
+
+// blockRoots returns the roots of the blocks.
+func blockRoots(blocks []*chaindb.Block) []phase0.Root {
+	roots := make([]phase0.Root, len(blocks))
+	for i := range blocks {
+		roots[i] = blocks[i].Root
+	}
+
+	return roots
+}
@@ -0,0 +1,293 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindbtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// canonicalBlock returns a block without a canonical state for the canonical blocks test,
+// at the given offset from the test's first slot.
+func canonicalBlock(offset uint64, variant uint64, parent *chaindb.Block) *chaindb.Block {
+	block := &chaindb.Block{
+		Slot:          baseSlot + 64 + phase0.Slot(offset),
+		ProposerIndex: baseIndex,
+		Root:          testRoot("canonical block", offset*16+variant),
+		Graffiti:      make([]byte, 32),
+		BodyRoot:      testRoot("canonical body", offset*16+variant),
+		StateRoot:     testRoot("canonical state", offset*16+variant),
+		ETH1BlockHash: make([]byte, 32),
+	}
+	if parent != nil {
+		block.ParentRoot = parent.Root
+	}
+
+	return block
+}
+
+// testCanonicalBlocks checks that chains of blocks can be obtained and marked as canonical,
+// that orphaned forks and other indeterminate blocks are marked as non-canonical, and that
+// canonicalization can be resumed part way through a chain.
+func testCanonicalBlocks(t *testing.T, s chaindb.Service) {
+	blocksSetter := implementation[chaindb.BlocksSetter](t, s)
+	blocksProvider := implementation[chaindb.BlocksProvider](t, s)
+	setter := implementation[chaindb.CanonicalBlocksSetter](t, s)
+	ctx := beginTx(t, s)
+
+	// Chain of blocks with a missed slot at offset 3, an orphaned fork of two blocks
+	// from offset 2, and a block after the end of the chain.
+	b0 := canonicalBlock(0, 0, nil)
+	b1 := canonicalBlock(1, 0, b0)
+	b2 := canonicalBlock(2, 0, b1)
+	b4 := canonicalBlock(4, 0, b2)
+	b5 := canonicalBlock(5, 0, b4)
+	fork3 := canonicalBlock(3, 1, b2)
+	fork4 := canonicalBlock(4, 1, fork3)
+	b6 := canonicalBlock(6, 0, b5)
+	for _, block := range []*chaindb.Block{b0, b1, b2, b4, b5, fork3, fork4, b6} {
+		require.NoError(t, blocksSetter.SetBlock(ctx, block))
+	}
+
+	headerRoots := func(headers []*chaindb.BlockHeader) []phase0.Root {
+		roots := make([]phase0.Root, len(headers))
+		for i := range headers {
+			require.Equal(t, headers[i].Slot, fetchBlock(ctx, t, blocksProvider, headers[i].Root).Slot)
+			roots[i] = headers[i].Root
+		}
+		return roots
+	}
+	requireCanonical := func(expected *bool, blocks ...*chaindb.Block) {
+		t.Helper()
+		for _, block := range blocks {
+			require.Equal(t, expected, fetchBlock(ctx, t, blocksProvider, block.Root).Canonical, "slot %d", block.Slot)
+		}
+	}
+
+	// Chains are provided in slot order, back to the minimum slot.
+	headers, err := setter.ChainHeaders(ctx, b5.Root, b0.Slot)
+	require.NoError(t, err)
+	require.Equal(t, []phase0.Root{b0.Root, b1.Root, b2.Root, b4.Root, b5.Root}, headerRoots(headers))
+	require.Equal(t, b4.Root, headers[4].ParentRoot)
+
+	headers, err = setter.ChainHeaders(ctx, fork4.Root, b2.Slot)
+	require.NoError(t, err)
+	require.Equal(t, []phase0.Root{b2.Root, fork3.Root, fork4.Root}, headerRoots(headers))
+
+	headers, err = setter.ChainHeaders(ctx, testRoot("canonical block", 0xffff), b0.Slot)
+	require.NoError(t, err)
+	require.Empty(t, headers)
+
+	// Canonicalize the first range of the chain, as if the run were then interrupted.
+	headers, err = setter.ChainHeaders(ctx, b2.Root, b0.Slot)
+	require.NoError(t, err)
+	require.NoError(t, setter.SetBlocksCanonical(ctx, headerRoots(headers)))
+	require.NoError(t, setter.SetIndeterminateBlocksCanonical(ctx, b0.Slot, b2.Slot))
+	requireCanonical(boolPtr(true), b0, b1, b2)
+	requireCanonical(nil, b4, b5, fork3, fork4, b6)
+
+	// Resume from after the first range.
+	headers, err = setter.ChainHeaders(ctx, b5.Root, b2.Slot+1)
+	require.NoError(t, err)
+	require.Equal(t, []phase0.Root{b4.Root, b5.Root}, headerRoots(headers))
+	require.NoError(t, setter.SetBlocksCanonical(ctx, headerRoots(headers)))
+	require.NoError(t, setter.SetIndeterminateBlocksCanonical(ctx, b2.Slot, b5.Slot))
+	requireCanonical(boolPtr(true), b0, b1, b2, b4, b5)
+	// The orphaned fork has no canonical children, so is non-canonical.
+	requireCanonical(boolPtr(false), fork3, fork4)
+	// Blocks after the range remain indeterminate.
+	requireCanonical(nil, b6)
+
+	// Setting blocks canonical again has no effect.
+	require.NoError(t, setter.SetBlocksCanonical(ctx, []phase0.Root{b4.Root, b5.Root}))
+	requireCanonical(boolPtr(true), b4, b5)
+}
+
+// childCanonicalBlocks stores the blocks for the child canonical tests, with a deposit and a
+// withdrawal in each: a chain of three blocks, the last of which is stored as canonical, and an
+// orphaned fork from the first.  The first two blocks of the chain and the fork are then
+// canonicalized, so the blocks are returned in the order chain, chain, chain, fork.
+func childCanonicalBlocks(ctx context.Context, t *testing.T, s chaindb.Service) []*chaindb.Block {
+	t.Helper()
+
+	blocksSetter := implementation[chaindb.BlocksSetter](t, s)
+	depositsSetter := implementation[chaindb.DepositsSetter](t, s)
+
+	b0 := canonicalBlock(16, 0, nil)
+	b1 := canonicalBlock(17, 0, b0)
+	b2 := canonicalBlock(18, 0, b1)
+	b2.Canonical = boolPtr(true)
+	fork1 := canonicalBlock(17, 1, b0)
+	blocks := []*chaindb.Block{b0, b1, b2, fork1}
+	for i, block := range blocks {
+		block.ExecutionPayload = &chaindb.ExecutionPayload{
+			BlockNumber:   uint64(block.Slot),
+			BlockHash:     testRoot("canonical payload", uint64(i)),
+			BaseFeePerGas: big.NewInt(7),
+			Withdrawals: []*chaindb.Withdrawal{
+				{
+					InclusionBlockRoot: block.Root,
+					InclusionSlot:      block.Slot,
+					Index:              capella.WithdrawalIndex(i),
+					ValidatorIndex:     childCanonicalIndex(i),
+					Amount:             phase0.Gwei(i + 1),
+				},
+			},
+		}
+		require.NoError(t, blocksSetter.SetBlock(ctx, block))
+		require.NoError(t, depositsSetter.SetDeposit(ctx, &chaindb.Deposit{
+			InclusionSlot:         block.Slot,
+			InclusionBlockRoot:    block.Root,
+			ValidatorPubKey:       childCanonicalPubKey(i),
+			WithdrawalCredentials: make([]byte, 32),
+			Amount:                32000000000,
+		}))
+	}
+
+	return blocks
+}
+
+// childCanonicalIndex returns the validator index of the withdrawal in the given child canonical block.
+func childCanonicalIndex(i int) phase0.ValidatorIndex {
+	return baseIndex + 0x100 + phase0.ValidatorIndex(i)
+}
+
+// childCanonicalPubKey returns the public key of the deposit in the given child canonical block.
+func childCanonicalPubKey(i int) phase0.BLSPubKey {
+	var pubKey phase0.BLSPubKey
+	root := testRoot("canonical deposit", uint64(i))
+	copy(pubKey[:], root[:])
+
+	return pubKey
+}
+
+// requireChildCanonicalContents requires that the deposits and withdrawals of the child canonical
+// blocks that match the canonical state are those in the blocks with the expected indices.
+func requireChildCanonicalContents(ctx context.Context,
+	t *testing.T,
+	s chaindb.Service,
+	blocks []*chaindb.Block,
+	canonical *bool,
+	expected ...int,
+) {
+	t.Helper()
+
+	depositsProvider := implementation[chaindb.DepositsProvider](t, s)
+	withdrawalsProvider := implementation[chaindb.WithdrawalsProvider](t, s)
+
+	pubKeys := make([]phase0.BLSPubKey, len(blocks))
+	indices := make([]phase0.ValidatorIndex, len(blocks))
+	for i := range blocks {
+		pubKeys[i] = childCanonicalPubKey(i)
+		indices[i] = childCanonicalIndex(i)
+	}
+	blockIndex := func(root phase0.Root) int {
+		for i := range blocks {
+			if blocks[i].Root == root {
+				return i
+			}
+		}
+		require.Fail(t, "unknown block")
+		return -1
+	}
+
+	deposits, err := depositsProvider.Deposits(ctx, &chaindb.DepositFilter{
+		PublicKeys: pubKeys,
+		Canonical:  canonical,
+	})
+	require.NoError(t, err)
+	depositBlocks := make([]int, 0, len(deposits))
+	for _, deposit := range deposits {
+		depositBlocks = append(depositBlocks, blockIndex(deposit.InclusionBlockRoot))
+	}
+
+	withdrawals, err := withdrawalsProvider.Withdrawals(ctx, &chaindb.WithdrawalFilter{
+		ValidatorIndices: indices,
+		Canonical:        canonical,
+	})
+	require.NoError(t, err)
+	withdrawalBlocks := make([]int, 0, len(withdrawals))
+	for _, withdrawal := range withdrawals {
+		withdrawalBlocks = append(withdrawalBlocks, blockIndex(withdrawal.InclusionBlockRoot))
+	}
+
+	require.ElementsMatch(t, expected, depositBlocks, "deposits")
+	require.ElementsMatch(t, expected, withdrawalBlocks, "withdrawals")
+}
+
+// testChildCanonical checks that the deposits and withdrawals of a block follow its canonical
+// state, both when the block is stored and when it is later canonicalized.
+func testChildCanonical(t *testing.T, s chaindb.Service) {
+	setter := implementation[chaindb.CanonicalBlocksSetter](t, s)
+	ctx := beginTx(t, s)
+
+	blocks := childCanonicalBlocks(ctx, t, s)
+	requireContents := func(canonical *bool, expected ...int) {
+		t.Helper()
+		requireChildCanonicalContents(ctx, t, s, blocks, canonical, expected...)
+	}
+
+	// Only the contents of the block stored as canonical are canonical to start with.
+	requireContents(nil, 0, 1, 2, 3)
+	requireContents(boolPtr(true), 2)
+	requireContents(boolPtr(false))
+
+	// Canonicalizing the chain marks the contents of its blocks canonical, and those
+	// of the orphaned fork non-canonical.
+	require.NoError(t, setter.SetBlocksCanonical(ctx, []phase0.Root{blocks[0].Root, blocks[1].Root}))
+	require.NoError(t, setter.SetIndeterminateBlocksCanonical(ctx, blocks[0].Slot, blocks[2].Slot))
+	requireContents(nil, 0, 1, 2, 3)
+	requireContents(boolPtr(true), 0, 1, 2)
+	requireContents(boolPtr(false), 3)
+}
+
+// RunCanonicalOnly checks a chain database that has been configured to provide only data from
+// canonical blocks, checking that deposits and withdrawals from blocks that are non-canonical or
+// not yet canonicalized are not returned unless explicitly requested.
+func RunCanonicalOnly(t *testing.T, s chaindb.Service) {
+	t.Helper()
+
+	setter := implementation[chaindb.CanonicalBlocksSetter](t, s)
+	ctx := beginTx(t, s)
+
+	blocks := childCanonicalBlocks(ctx, t, s)
+	requireContents := func(canonical *bool, expected ...int) {
+		t.Helper()
+		requireChildCanonicalContents(ctx, t, s, blocks, canonical, expected...)
+	}
+
+	requireContents(nil, 2)
+
+	require.NoError(t, setter.SetBlocksCanonical(ctx, []phase0.Root{blocks[0].Root, blocks[1].Root}))
+	require.NoError(t, setter.SetIndeterminateBlocksCanonical(ctx, blocks[0].Slot, blocks[2].Slot))
+	requireContents(nil, 0, 1, 2)
+	// Non-canonical data is still available if asked for.
+	requireContents(boolPtr(false), 3)
+}
+
+// fetchBlock requires that the block with the given root is present, and returns it.
+func fetchBlock(ctx context.Context, t *testing.T, provider chaindb.BlocksProvider, root phase0.Root) *chaindb.Block {
+	t.Helper()
+
+	block, err := provider.BlockByRoot(ctx, root)
+	require.NoError(t, err)
+
+	return block
+}
@@ -0,0 +1,149 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindbtest
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// testGenesis checks that genesis can be set and obtained.
+func testGenesis(t *testing.T, s chaindb.Service) {
+	setter := implementation[chaindb.GenesisSetter](t, s)
+	provider := implementation[chaindb.GenesisProvider](t, s)
+	ctx := beginTx(t, s)
+
+	if _, err := provider.Genesis(ctx, &api.GenesisOpts{}); err == nil {
+		t.Skip("chain database already has genesis")
+	}
+
+	genesis := &apiv1.Genesis{
+		GenesisTime:           time.Unix(1606824023, 0),
+		GenesisValidatorsRoot: testRoot("genesis validators root", 0),
+		GenesisForkVersion:    phase0.Version{0x01, 0x02, 0x03, 0x04},
+	}
+	require.NoError(t, setter.SetGenesis(ctx, genesis))
+
+	response, err := provider.Genesis(ctx, &api.GenesisOpts{})
+	require.NoError(t, err)
+	require.True(t, genesis.GenesisTime.Equal(response.Data.GenesisTime))
+	require.Equal(t, genesis.GenesisValidatorsRoot, response.Data.GenesisValidatorsRoot)
+	require.Equal(t, genesis.GenesisForkVersion, response.Data.GenesisForkVersion)
+}
+
+// testChainSpec checks that chain specification values can be set and obtained,
+// and that they retain their types.
+func testChainSpec(t *testing.T, s chaindb.Service) {
+	setter := implementation[chaindb.ChainSpecSetter](t, s)
+	provider := implementation[chaindb.ChainSpecProvider](t, s)
+	ctx := beginTx(t, s)
+
+	bigInteger, success := new(big.Int).SetString("58750000000000000000000", 10)
+	require.True(t, success)
+	values := map[string]any{
+		"CHAINDBTEST_INTEGER":         uint64(12345),
+		"SECONDS_PER_CHAINDBTEST":     12 * time.Second,
+		"CHAINDBTEST_FORK_VERSION":    phase0.Version{0x01, 0x02, 0x03, 0x04},
+		"CHAINDBTEST_STRING":          "value",
+		"DOMAIN_CHAINDBTEST":          phase0.DomainType{0x05, 0x06, 0x07, 0x08},
+		"CHAINDBTEST_GENESIS_TIME":    time.Unix(1606824023, 0),
+		"CHAINDBTEST_ZERO_INTEGER":    uint64(0),
+		"CHAINDBTEST_ANOTHER_INTEGER": uint64(1),
+		// Values that do not match the types suggested by their keys or
+		// contents are held as provided.
+		"CHAINDBTEST_NOT_A_TIME":   "12345",
+		"CHAINDBTEST_HEX_STRING":   "0x1234",
+		"CHAINDBTEST_BIG_INTEGER":  bigInteger,
+		"CHAINDBTEST_SMALL_BIGINT": big.NewInt(32),
+	}
+	for key, value := range values {
+		require.NoError(t, setter.SetChainSpecValue(ctx, key, value))
+	}
+
+	for key, expected := range values {
+		value, err := provider.ChainSpecValue(ctx, key)
+		require.NoError(t, err, key)
+		if expectedTime, isTime := expected.(time.Time); isTime {
+			valueTime, isTime := value.(time.Time)
+			require.True(t, isTime, key)
+			require.True(t, expectedTime.Equal(valueTime), key)
+
+			continue
+		}
+		require.Equal(t, expected, value, key)
+	}
+
+	spec, err := provider.ChainSpec(ctx)
+	require.NoError(t, err)
+	for key := range values {
+		require.Contains(t, spec.Values, key)
+	}
+
+	// Values are available as other types regardless of how they are held.
+	seconds, err := chaindb.SpecValue[uint64](spec.Values, "SECONDS_PER_CHAINDBTEST")
+	require.NoError(t, err)
+	require.Equal(t, uint64(12), seconds)
+	duration, err := chaindb.SpecValue[time.Duration](spec.Values, "SECONDS_PER_CHAINDBTEST")
+	require.NoError(t, err)
+	require.Equal(t, 12*time.Second, duration)
+	epoch, err := chaindb.SpecValue[phase0.Epoch](spec.Values, "CHAINDBTEST_INTEGER")
+	require.NoError(t, err)
+	require.Equal(t, phase0.Epoch(12345), epoch)
+	version, err := chaindb.SpecValue[[]byte](spec.Values, "CHAINDBTEST_FORK_VERSION")
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, version)
+	genesisTime, err := chaindb.SpecValue[time.Time](spec.Values, "CHAINDBTEST_GENESIS_TIME")
+	require.NoError(t, err)
+	require.True(t, genesisTime.Equal(time.Unix(1606824023, 0)))
+	_, err = chaindb.SpecValue[phase0.Version](spec.Values, "CHAINDBTEST_STRING")
+	require.EqualError(t, err, "CHAINDBTEST_STRING of unexpected type: cannot convert string to bytes")
+	smallBigInt, err := chaindb.SpecValue[uint64](spec.Values, "CHAINDBTEST_SMALL_BIGINT")
+	require.NoError(t, err)
+	require.Equal(t, uint64(32), smallBigInt)
+	_, err = chaindb.SpecValue[uint64](spec.Values, "CHAINDBTEST_BIG_INTEGER")
+	require.EqualError(t, err, "CHAINDBTEST_BIG_INTEGER of unexpected type: value 58750000000000000000000 out of range")
+	_, err = chaindb.SpecValue[uint64](spec.Values, "CHAINDBTEST_UNKNOWN")
+	require.EqualError(t, err, "CHAINDBTEST_UNKNOWN not found in chain specification")
+
+	_, err = provider.ChainSpecValue(ctx, "CHAINDBTEST_UNKNOWN")
+	require.Error(t, err)
+}
+
+// testMetadata checks that metadata can be set, updated and obtained.
+func testMetadata(t *testing.T, s chaindb.Service) {
+	setter := implementation[chaindb.MetadataSetter](t, s)
+	provider := implementation[chaindb.MetadataProvider](t, s)
+	ctx := beginTx(t, s)
+
+	value, err := provider.Metadata(ctx, "chaindbtest.unknown")
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	require.NoError(t, setter.SetMetadata(ctx, "chaindbtest", []byte(`{"value":1}`)))
+	value, err = provider.Metadata(ctx, "chaindbtest")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"value":1}`, string(value))
+
+	require.NoError(t, setter.SetMetadata(ctx, "chaindbtest", []byte(`{"value":2}`)))
+	value, err = provider.Metadata(ctx, "chaindbtest")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"value":2}`, string(value))
+}
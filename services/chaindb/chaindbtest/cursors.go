@@ -0,0 +1,187 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindbtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// testCursorPagination checks that paging through blocks, attestations and validators
+// with cursors returns every item exactly once and in order, for every page size and
+// in both orders, including where several blocks share a slot.
+func testCursorPagination(t *testing.T, s chaindb.Service) {
+	ctx := beginTx(t, s)
+
+	// Add further forks, so that three blocks share slot baseSlot+5 and two share baseSlot+2.
+	blocksSetter := implementation[chaindb.BlocksSetter](t, s)
+	blocks := setBlocks(ctx, t, s)
+	forks := []*chaindb.Block{
+		testBlock(5, 2, boolPtr(false)),
+		testBlock(2, 1, boolPtr(false)),
+	}
+	for _, fork := range forks {
+		require.NoError(t, blocksSetter.SetBlock(ctx, fork))
+	}
+	blocks = append(blocks, forks...)
+	attestations := testAttestationData(blocks)
+	require.NoError(t, implementation[chaindb.AttestationsSetter](t, s).SetAttestations(ctx, attestations))
+	validators := setValidators(ctx, t, s)
+
+	blocksProvider := implementation[chaindb.BlocksProvider](t, s)
+	expectedBlocks := sortedBlocks(blocks...)
+	for _, order := range []chaindb.Order{chaindb.OrderEarliest, chaindb.OrderLatest} {
+		for limit := 1; limit <= len(expectedBlocks)+1; limit++ {
+			t.Run(fmt.Sprintf("Blocks/%s/%d", orderName(order), limit), func(t *testing.T) {
+				filter := &chaindb.BlockFilter{
+					From:  slotPtr(baseSlot),
+					Order: order,
+					Limit: uint32(limit),
+				}
+				pages := make([][]*chaindb.Block, 0)
+				for {
+					page, err := blocksProvider.Blocks(ctx, filter)
+					require.NoError(t, err)
+					require.LessOrEqual(t, len(page), limit)
+					if len(page) == 0 {
+						break
+					}
+					// Pages are returned in slot then root order, whatever the order of paging.
+					requireBlocks(t, sortedBlocks(page...), page)
+					pages = append(pages, page)
+					boundary := page[len(page)-1]
+					if order == chaindb.OrderLatest {
+						boundary = page[0]
+					}
+					filter.Cursor = &chaindb.BlockCursor{
+						Slot: boundary.Slot,
+						Root: boundary.Root,
+					}
+				}
+				requireBlocks(t, expectedBlocks, joinPages(pages, order))
+			})
+		}
+	}
+
+	attestationsProvider := implementation[chaindb.AttestationsProvider](t, s)
+	expectedAttestations := sortedAttestations(attestations...)
+	for _, order := range []chaindb.Order{chaindb.OrderEarliest, chaindb.OrderLatest} {
+		for _, limit := range []int{1, 2, 3, 5, len(expectedAttestations), len(expectedAttestations) + 1} {
+			t.Run(fmt.Sprintf("Attestations/%s/%d", orderName(order), limit), func(t *testing.T) {
+				filter := &chaindb.AttestationFilter{
+					From:  slotPtr(baseSlot),
+					Order: order,
+					Limit: uint32(limit),
+				}
+				pages := make([][]*chaindb.Attestation, 0)
+				for {
+					page, err := attestationsProvider.Attestations(ctx, filter)
+					require.NoError(t, err)
+					require.LessOrEqual(t, len(page), limit)
+					if len(page) == 0 {
+						break
+					}
+					requireAttestations(t, sortedAttestations(page...), page)
+					pages = append(pages, page)
+					boundary := page[len(page)-1]
+					if order == chaindb.OrderLatest {
+						boundary = page[0]
+					}
+					filter.Cursor = &chaindb.AttestationCursor{
+						InclusionSlot:      boundary.InclusionSlot,
+						InclusionBlockRoot: boundary.InclusionBlockRoot,
+						InclusionIndex:     boundary.InclusionIndex,
+					}
+				}
+				requireAttestations(t, expectedAttestations, joinPages(pages, order))
+			})
+		}
+	}
+
+	validatorsProvider := implementation[chaindb.ValidatorsProvider](t, s)
+	for _, order := range []chaindb.Order{chaindb.OrderEarliest, chaindb.OrderLatest} {
+		for limit := 1; limit <= len(validators)+1; limit++ {
+			t.Run(fmt.Sprintf("Validators/%s/%d", orderName(order), limit), func(t *testing.T) {
+				// Start just beyond the suite's validators, to exclude any others in the database.
+				cursor := baseIndex - 1
+				if order == chaindb.OrderLatest {
+					cursor = baseIndex + testValidatorCount
+				}
+				pages := make([][]*chaindb.Validator, 0)
+				for {
+					page, err := validatorsProvider.ValidatorsByFilter(ctx, &chaindb.ValidatorFilter{
+						Order:  order,
+						Limit:  uint32(limit),
+						Cursor: &cursor,
+					})
+					require.NoError(t, err)
+					require.LessOrEqual(t, len(page), limit)
+					page = suiteValidators(page)
+					if len(page) == 0 {
+						break
+					}
+					for i := 1; i < len(page); i++ {
+						require.Less(t, page[i-1].Index, page[i].Index)
+					}
+					pages = append(pages, page)
+					cursor = page[len(page)-1].Index
+					if order == chaindb.OrderLatest {
+						cursor = page[0].Index
+					}
+				}
+				require.Equal(t, validators, joinPages(pages, order))
+			})
+		}
+	}
+}
+
+// orderName returns the name of the order, for naming tests.
+func orderName(order chaindb.Order) string {
+	if order == chaindb.OrderLatest {
+		return "Latest"
+	}
+
+	return "Earliest"
+}
+
+// joinPages joins pages of results, each in ascending order, in to a single ascending list.
+// Pages fetched latest first are reversed.
+func joinPages[T any](pages [][]T, order chaindb.Order) []T {
+	res := make([]T, 0)
+	for i := range pages {
+		page := pages[i]
+		if order == chaindb.OrderLatest {
+			page = pages[len(pages)-1-i]
+		}
+		res = append(res, page...)
+	}
+
+	return res
+}
+
+// suiteValidators returns the validators that were written by the suite.
+func suiteValidators(validators []*chaindb.Validator) []*chaindb.Validator {
+	res := make([]*chaindb.Validator, 0, len(validators))
+	for _, validator := range validators {
+		if validator.Index >= baseIndex && validator.Index < baseIndex+phase0.ValidatorIndex(testValidatorCount) {
+			res = append(res, validator)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,122 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindbtest
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// testBeaconCommittees checks the functions to set and obtain beacon committees.
+func testBeaconCommittees(t *testing.T, s chaindb.Service) {
+	setter := implementation[chaindb.BeaconCommitteesSetter](t, s)
+	provider := implementation[chaindb.BeaconCommitteesProvider](t, s)
+	ctx := beginTx(t, s)
+
+	committees := make([]*chaindb.BeaconCommittee, 0)
+	for slot := baseSlot; slot < baseSlot+4; slot++ {
+		for index := phase0.CommitteeIndex(0); index < 2; index++ {
+			committee := &chaindb.BeaconCommittee{
+				Slot:  slot,
+				Index: index,
+				Committee: []phase0.ValidatorIndex{
+					baseIndex + phase0.ValidatorIndex(uint64(slot-baseSlot)*4+uint64(index)*2),
+					baseIndex + phase0.ValidatorIndex(uint64(slot-baseSlot)*4+uint64(index)*2+1),
+				},
+			}
+			require.NoError(t, setter.SetBeaconCommittee(ctx, committee))
+			committees = append(committees, committee)
+		}
+	}
+
+	committee, err := provider.BeaconCommitteeBySlotAndIndex(ctx, baseSlot+1, 1)
+	require.NoError(t, err)
+	require.Equal(t, committees[3], committee)
+
+	filtered, err := provider.BeaconCommittees(ctx, &chaindb.BeaconCommitteeFilter{
+		From:             slotPtr(baseSlot + 1),
+		To:               slotPtr(baseSlot + 2),
+		CommitteeIndices: []phase0.CommitteeIndex{0},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.BeaconCommittee{committees[2], committees[4]}, filtered)
+
+	filtered, err = provider.BeaconCommittees(ctx, &chaindb.BeaconCommitteeFilter{
+		From:             slotPtr(baseSlot),
+		ValidatorIndices: []phase0.ValidatorIndex{baseIndex + 7},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.BeaconCommittee{committees[3]}, filtered)
+
+	duties, err := provider.AttesterDuties(ctx, baseSlot, baseSlot+2, []phase0.ValidatorIndex{baseIndex + 1, baseIndex + 6, baseIndex + 9})
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.AttesterDuty{
+		{
+			Slot:           baseSlot,
+			Committee:      0,
+			ValidatorIndex: baseIndex + 1,
+			CommitteeIndex: 1,
+		},
+		{
+			Slot:           baseSlot + 1,
+			Committee:      1,
+			ValidatorIndex: baseIndex + 6,
+			CommitteeIndex: 0,
+		},
+	}, duties)
+}
+
+// testProposerDuties checks the functions to set and obtain proposer duties.
+func testProposerDuties(t *testing.T, s chaindb.Service) {
+	setter := implementation[chaindb.ProposerDutiesSetter](t, s)
+	provider := implementation[chaindb.ProposerDutiesProvider](t, s)
+	ctx := beginTx(t, s)
+
+	duties := make([]*chaindb.ProposerDuty, 0)
+	for slot := baseSlot; slot < baseSlot+6; slot++ {
+		duty := &chaindb.ProposerDuty{
+			Slot:           slot,
+			ValidatorIndex: baseIndex + phase0.ValidatorIndex(uint64(slot-baseSlot)%3),
+		}
+		require.NoError(t, setter.SetProposerDuty(ctx, duty))
+		duties = append(duties, duty)
+	}
+
+	forRange, err := provider.ProposerDutiesForSlotRange(ctx, baseSlot+1, baseSlot+4)
+	require.NoError(t, err)
+	require.Equal(t, duties[1:4], forRange)
+
+	forValidator, err := provider.ProposerDutiesForValidator(ctx, baseIndex+2)
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.ProposerDuty{duties[2], duties[5]}, forValidator)
+
+	filtered, err := provider.ProposerDuties(ctx, &chaindb.ProposerDutyFilter{
+		From:             slotPtr(baseSlot + 1),
+		To:               slotPtr(baseSlot + 5),
+		ValidatorIndices: []phase0.ValidatorIndex{baseIndex + 1, baseIndex + 2},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.ProposerDuty{duties[1], duties[2], duties[4], duties[5]}, filtered)
+
+	filtered, err = provider.ProposerDuties(ctx, &chaindb.ProposerDutyFilter{
+		From:  slotPtr(baseSlot),
+		Order: chaindb.OrderLatest,
+		Limit: 2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, duties[4:6], filtered)
+}
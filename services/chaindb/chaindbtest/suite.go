@@ -0,0 +1,118 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chaindbtest provides a conformance suite for implementations of the
+// chain database providers and setters, so that all backends can be checked
+// against the same contracts.
+package chaindbtest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// baseSlot is the first slot of the data written by the suite.  It is far beyond
+// any real chain, so that the suite can run against a database that already
+// contains data.
+const baseSlot = phase0.Slot(1 << 40)
+
+// baseEpoch is the first epoch of the data written by the suite.
+const baseEpoch = phase0.Epoch(1 << 35)
+
+// baseIndex is the first validator index of the data written by the suite.
+const baseIndex = phase0.ValidatorIndex(1 << 40)
+
+// Run runs the conformance suite against chain databases created by newService.
+// A chain database is created for each test, and tests for providers and setters
+// that it does not implement are skipped.
+// All writes take place in a transaction that is rolled back at the end of each
+// test, so the suite does not leave data behind in persistent backends.
+func Run(t *testing.T, newService func(t *testing.T) chaindb.Service) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, s chaindb.Service)
+	}{
+		{name: "Genesis", test: testGenesis},
+		{name: "ChainSpec", test: testChainSpec},
+		{name: "Metadata", test: testMetadata},
+		{name: "Blocks", test: testBlocks},
+		{name: "BlocksPagination", test: testBlocksPagination},
+		{name: "CanonicalBlocks", test: testCanonicalBlocks},
+		{name: "ChildCanonical", test: testChildCanonical},
+		{name: "Attestations", test: testAttestations},
+		{name: "AttestationsPagination", test: testAttestationsPagination},
+		{name: "AttestationsForValidator", test: testAttestationsForValidator},
+		{name: "CursorPagination", test: testCursorPagination},
+		{name: "Validators", test: testValidators},
+		{name: "ValidatorBalances", test: testValidatorBalances},
+		{name: "BeaconCommittees", test: testBeaconCommittees},
+		{name: "ProposerDuties", test: testProposerDuties},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.test(t, newService(t))
+		})
+	}
+}
+
+// beginTx begins a transaction that is rolled back when the test finishes.
+func beginTx(t *testing.T, s chaindb.Service) context.Context {
+	t.Helper()
+
+	ctx, cancel, err := s.BeginTx(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(cancel)
+
+	return ctx
+}
+
+// implementation returns the chain database as the given interface, skipping
+// the test if it is not implemented.
+func implementation[T any](t *testing.T, s chaindb.Service) T {
+	t.Helper()
+
+	res, isImplemented := s.(T)
+	if !isImplemented {
+		t.Skipf("chain database does not implement %T", (*T)(nil))
+	}
+
+	return res
+}
+
+// testRoot returns a deterministic root for the given purpose and value.
+func testRoot(purpose string, value uint64) phase0.Root {
+	data := make([]byte, len(purpose)+8)
+	copy(data, purpose)
+	binary.BigEndian.PutUint64(data[len(purpose):], value)
+
+	return sha256.Sum256(data)
+}
+
+// slotPtr returns a pointer to the slot.
+func slotPtr(slot phase0.Slot) *phase0.Slot {
+	return &slot
+}
+
+// boolPtr returns a pointer to the boolean.
+func boolPtr(val bool) *bool {
+	return &val
+}
@@ -0,0 +1,176 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindbtest
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// testValidatorCount is the number of validators in the suite.
+const testValidatorCount = 8
+
+// testValidatorData returns validators for the suite.
+func testValidatorData() []*chaindb.Validator {
+	validators := make([]*chaindb.Validator, 0, testValidatorCount)
+	for i := uint64(0); i < testValidatorCount; i++ {
+		var pubKey phase0.BLSPubKey
+		copy(pubKey[:], "chaindbtest")
+		binary.BigEndian.PutUint64(pubKey[len(pubKey)-8:], i)
+		var withdrawalCredentials [32]byte
+		withdrawalCredentials[0] = 0x01
+		binary.BigEndian.PutUint64(withdrawalCredentials[24:], i)
+		validators = append(validators, &chaindb.Validator{
+			PublicKey:                  pubKey,
+			Index:                      baseIndex + phase0.ValidatorIndex(i),
+			EffectiveBalance:           32000000000,
+			Slashed:                    i == 3,
+			ActivationEligibilityEpoch: baseEpoch,
+			ActivationEpoch:            baseEpoch + 1,
+			ExitEpoch:                  0xffffffffffffffff,
+			WithdrawableEpoch:          0xffffffffffffffff,
+			WithdrawalCredentials:      withdrawalCredentials,
+		})
+	}
+
+	return validators
+}
+
+// setValidators sets the suite's validators.
+func setValidators(ctx context.Context, t *testing.T, s chaindb.Service) []*chaindb.Validator {
+	t.Helper()
+
+	setter := implementation[chaindb.ValidatorsSetter](t, s)
+	validators := testValidatorData()
+	for _, validator := range validators {
+		require.NoError(t, setter.SetValidator(ctx, validator))
+	}
+
+	return validators
+}
+
+// testValidators checks the functions to obtain validators.
+func testValidators(t *testing.T, s chaindb.Service) {
+	provider := implementation[chaindb.ValidatorsProvider](t, s)
+	ctx := beginTx(t, s)
+	validators := setValidators(ctx, t, s)
+
+	all, err := provider.Validators(ctx)
+	require.NoError(t, err)
+	for _, validator := range validators {
+		require.Contains(t, all, validator)
+	}
+
+	byIndex, err := provider.ValidatorsByIndex(ctx, []phase0.ValidatorIndex{baseIndex + 1, baseIndex + 3, baseIndex + testValidatorCount})
+	require.NoError(t, err)
+	require.Equal(t, map[phase0.ValidatorIndex]*chaindb.Validator{
+		baseIndex + 1: validators[1],
+		baseIndex + 3: validators[3],
+	}, byIndex)
+
+	byPublicKey, err := provider.ValidatorsByPublicKey(ctx, []phase0.BLSPubKey{validators[2].PublicKey, {0x01}})
+	require.NoError(t, err)
+	require.Equal(t, map[phase0.BLSPubKey]*chaindb.Validator{
+		validators[2].PublicKey: validators[2],
+	}, byPublicKey)
+
+	// Page through the validators, earliest first.
+	cursor := baseIndex - 1
+	fetched := make([]*chaindb.Validator, 0)
+	for {
+		page, err := provider.ValidatorsByFilter(ctx, &chaindb.ValidatorFilter{
+			Limit:  3,
+			Cursor: &cursor,
+		})
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		fetched = append(fetched, page...)
+		cursor = page[len(page)-1].Index
+	}
+	require.Equal(t, validators, fetched)
+
+	latest, err := provider.ValidatorsByFilter(ctx, &chaindb.ValidatorFilter{
+		Limit: 2,
+		Order: chaindb.OrderLatest,
+	})
+	require.NoError(t, err)
+	require.Equal(t, validators[testValidatorCount-2:], latest)
+
+	streamer := implementation[chaindb.ValidatorsStreamProvider](t, s)
+	start := baseIndex - 1
+	stream, err := streamer.ValidatorsStream(ctx, &chaindb.ValidatorFilter{
+		Cursor: &start,
+	})
+	require.NoError(t, err)
+	defer stream.Close()
+	streamed := make([]*chaindb.Validator, 0)
+	for stream.Next(ctx) {
+		streamed = append(streamed, stream.Value())
+	}
+	require.NoError(t, stream.Err())
+	require.Equal(t, validators, streamed)
+}
+
+// testValidatorBalances checks the functions to set and obtain validator balances.
+func testValidatorBalances(t *testing.T, s chaindb.Service) {
+	setter := implementation[chaindb.ValidatorsSetter](t, s)
+	provider := implementation[chaindb.ValidatorsProvider](t, s)
+	ctx := beginTx(t, s)
+	validators := setValidators(ctx, t, s)
+
+	balances := make([]*chaindb.ValidatorBalance, 0)
+	for epoch := baseEpoch; epoch < baseEpoch+4; epoch++ {
+		for _, validator := range validators {
+			balances = append(balances, &chaindb.ValidatorBalance{
+				Index:            validator.Index,
+				Epoch:            epoch,
+				Balance:          32000000000 + phase0.Gwei(epoch-baseEpoch)*1000 + phase0.Gwei(validator.Index-baseIndex),
+				EffectiveBalance: 32000000000,
+			})
+		}
+	}
+	require.NoError(t, setter.SetValidatorBalances(ctx, balances[:testValidatorCount]))
+	for _, balance := range balances[testValidatorCount:] {
+		require.NoError(t, setter.SetValidatorBalance(ctx, balance))
+	}
+
+	byEpoch, err := provider.ValidatorBalancesByEpoch(ctx, baseEpoch+1)
+	require.NoError(t, err)
+	require.ElementsMatch(t, balances[testValidatorCount:2*testValidatorCount], byEpoch)
+
+	byIndexAndEpoch, err := provider.ValidatorBalancesByIndexAndEpoch(ctx, []phase0.ValidatorIndex{baseIndex + 2}, baseEpoch+2)
+	require.NoError(t, err)
+	require.Equal(t, map[phase0.ValidatorIndex]*chaindb.ValidatorBalance{
+		baseIndex + 2: balances[2*testValidatorCount+2],
+	}, byIndexAndEpoch)
+
+	byRange, err := provider.ValidatorBalancesByIndexAndEpochRange(ctx, []phase0.ValidatorIndex{baseIndex + 1}, baseEpoch+1, baseEpoch+3)
+	require.NoError(t, err)
+	require.Equal(t, map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance{
+		baseIndex + 1: {balances[testValidatorCount+1], balances[2*testValidatorCount+1]},
+	}, byRange)
+
+	byEpochs, err := provider.ValidatorBalancesByIndexAndEpochs(ctx, []phase0.ValidatorIndex{baseIndex + 4}, []phase0.Epoch{baseEpoch, baseEpoch + 3})
+	require.NoError(t, err)
+	require.Equal(t, map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance{
+		baseIndex + 4: {balances[4], balances[3*testValidatorCount+4]},
+	}, byEpochs)
+}
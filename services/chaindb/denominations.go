@@ -0,0 +1,100 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindb
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// Denomination is the unit in which values of ether are provided.
+type Denomination string
+
+const (
+	// DenominationWei provides values in wei.
+	DenominationWei Denomination = "wei"
+	// DenominationGwei provides values in gwei, rounded to the nearest gwei
+	// with halves rounded away from zero.
+	DenominationGwei Denomination = "gwei"
+)
+
+// weiPerGwei is the number of wei in a gwei.
+var weiPerGwei = big.NewInt(1_000_000_000)
+
+// halfGwei is half a gwei in wei.
+var halfGwei = big.NewInt(500_000_000)
+
+// valueDenominationKey is the context key for the denomination of values.
+type valueDenominationKey struct{}
+
+// WithValueDenomination returns a context with which providers return values
+// of ether, such as base fees and payload values, in the given denomination.
+// Values are always stored in wei, and are provided in wei unless requested
+// otherwise, so the denomination only applies to calls made with the context.
+func WithValueDenomination(ctx context.Context, denomination Denomination) context.Context {
+	return context.WithValue(ctx, valueDenominationKey{}, denomination)
+}
+
+// ValueDenomination returns the denomination in which values of ether are to
+// be provided for calls made with the context; wei if none has been set.
+func ValueDenomination(ctx context.Context) Denomination {
+	if denomination, ok := ctx.Value(valueDenominationKey{}).(Denomination); ok && denomination.Valid() {
+		return denomination
+	}
+
+	return DenominationWei
+}
+
+// Valid returns true if the denomination is known.
+func (d Denomination) Valid() bool {
+	return d == DenominationWei || d == DenominationGwei
+}
+
+// FromWei converts a value in wei to the denomination.
+// It returns nil if the value is nil.
+func (d Denomination) FromWei(wei *big.Int) *big.Int {
+	if wei == nil {
+		return nil
+	}
+
+	if d == DenominationGwei {
+		gwei, remainder := new(big.Int).QuoRem(wei, weiPerGwei, new(big.Int))
+		if remainder.CmpAbs(halfGwei) >= 0 {
+			gwei.Add(gwei, big.NewInt(int64(remainder.Sign())))
+		}
+
+		return gwei
+	}
+
+	return new(big.Int).Set(wei)
+}
+
+// GweiToWei converts a value in gwei to wei.
+func GweiToWei(gwei phase0.Gwei) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(uint64(gwei)), weiPerGwei)
+}
+
+// SumWei sums values in wei without risk of overflow, ignoring nil values.
+func SumWei(values ...*big.Int) *big.Int {
+	res := new(big.Int)
+	for _, value := range values {
+		if value != nil {
+			res.Add(res, value)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,107 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindb_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestFromWei(t *testing.T) {
+	tests := []struct {
+		name         string
+		denomination chaindb.Denomination
+		wei          string
+		expected     string
+	}{
+		{
+			name:         "Wei",
+			denomination: chaindb.DenominationWei,
+			wei:          "1234567890123456789",
+			expected:     "1234567890123456789",
+		},
+		{
+			name:         "GweiExact",
+			denomination: chaindb.DenominationGwei,
+			wei:          "32000000000000000000",
+			expected:     "32000000000",
+		},
+		{
+			name:         "GweiRoundDown",
+			denomination: chaindb.DenominationGwei,
+			wei:          "1234567890499999999",
+			expected:     "1234567890",
+		},
+		{
+			name:         "GweiRoundHalf",
+			denomination: chaindb.DenominationGwei,
+			wei:          "1234567890500000000",
+			expected:     "1234567891",
+		},
+		{
+			name:         "GweiRoundUp",
+			denomination: chaindb.DenominationGwei,
+			wei:          "1234567890999999999",
+			expected:     "1234567891",
+		},
+		{
+			name:         "GweiSubGwei",
+			denomination: chaindb.DenominationGwei,
+			wei:          "7",
+			expected:     "0",
+		},
+		{
+			name:         "GweiNegative",
+			denomination: chaindb.DenominationGwei,
+			wei:          "-1500000000",
+			expected:     "-2",
+		},
+		{
+			name:         "GweiBeyondUint64",
+			denomination: chaindb.DenominationGwei,
+			wei:          "123456789012345678901234567890123",
+			expected:     "123456789012345678901235",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wei, ok := new(big.Int).SetString(test.wei, 10)
+			require.True(t, ok)
+			res := test.denomination.FromWei(wei)
+			require.Equal(t, test.expected, res.String())
+			// The input is not altered.
+			require.Equal(t, test.wei, wei.String())
+		})
+	}
+
+	require.Nil(t, chaindb.DenominationGwei.FromWei(nil))
+}
+
+func TestValueDenomination(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, chaindb.DenominationWei, chaindb.ValueDenomination(ctx))
+
+	gweiCtx := chaindb.WithValueDenomination(ctx, chaindb.DenominationGwei)
+	require.Equal(t, chaindb.DenominationGwei, chaindb.ValueDenomination(gweiCtx))
+	// The denomination only applies to calls with the context.
+	require.Equal(t, chaindb.DenominationWei, chaindb.ValueDenomination(ctx))
+
+	unknownCtx := chaindb.WithValueDenomination(ctx, chaindb.Denomination("ether"))
+	require.Equal(t, chaindb.DenominationWei, chaindb.ValueDenomination(unknownCtx))
+}
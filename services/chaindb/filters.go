@@ -29,10 +29,1006 @@ const (
 	OrderLatest
 )
 
+// BlockCursor is the position of a block in the ordering of blocks, for keyset pagination.
+type BlockCursor struct {
+	Slot phase0.Slot
+	Root phase0.Root
+}
+
+// AttestationCursor is the position of an attestation in the ordering of attestations,
+// for keyset pagination.
+type AttestationCursor struct {
+	InclusionSlot      phase0.Slot
+	InclusionBlockRoot phase0.Root
+	InclusionIndex     uint64
+}
+
 // BlockSummaryFilter defines a filter for fetching block summaries.
 // Filter elements are ANDed together.
 // Results are always returned in ascending slot order.
-type BlockSummaryFilter struct {
+type BlockSummaryFilter struct {
+	// Limit is the maximum number of items to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch items.
+	// If nil then no condition is applied.
+	From *phase0.Slot
+
+	// To is the latest slot from which to fetch items.
+	// If nil then no condition is applied.
+	To *phase0.Slot
+}
+
+// ValidatorSummaryFilter defines a filter for fetching validator summaries.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (epoch, validator index) order.
+type ValidatorSummaryFilter struct {
+	// Limit is the maximum number of summaries to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest epoch from which to fetch summaries.
+	// If nil then there is no earliest epoch.
+	From *phase0.Epoch
+
+	// To is the latest epoch from which to fetch summaries.
+	// If nil then there is no latest epoch.
+	To *phase0.Epoch
+
+	// ValidatorIndices is the list of validator indices for which to obtain summaries.
+	// If nil then no filter is applied
+	ValidatorIndices *[]phase0.ValidatorIndex
+}
+
+// EpochSummaryFilter defines a filter for fetching epoch summaries.
+// Filter elements are ANDed together.
+// Results are always returned in ascending epoch order.
+type EpochSummaryFilter struct {
+	// Limit is the maximum number of summaries to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest epoch from which to fetch summaries.
+	// If nil then there is no earliest epoch.
+	From *phase0.Epoch
+
+	// To is the latest epoch from which to fetch summaries.
+	// If nil then there is no latest epoch.
+	To *phase0.Epoch
+}
+
+// CommitteeEpochSummaryFilter defines a filter for fetching committee epoch summaries.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (slot, committee index) order.
+type CommitteeEpochSummaryFilter struct {
+	// Limit is the maximum number of summaries to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest epoch from which to fetch summaries.
+	// If nil then there is no earliest epoch.
+	From *phase0.Epoch
+
+	// To is the latest epoch from which to fetch summaries.
+	// If nil then there is no latest epoch.
+	To *phase0.Epoch
+
+	// CommitteeIndices are the committee indices for which to fetch summaries.
+	// If nil then no filter is applied.
+	CommitteeIndices []phase0.CommitteeIndex
+}
+
+// CheckpointFilter defines a filter for fetching epoch checkpoints.
+// Filter elements are ANDed together.
+// Results are always returned in ascending epoch order.
+type CheckpointFilter struct {
+	// Limit is the maximum number of checkpoints to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest epoch from which to fetch checkpoints.
+	// If nil then there is no earliest epoch.
+	From *phase0.Epoch
+
+	// To is the latest epoch from which to fetch checkpoints.
+	// If nil then there is no latest epoch.
+	To *phase0.Epoch
+
+	// StateRoots are the boundary state roots for which to fetch checkpoints.
+	// If nil then no filter is applied.
+	StateRoots []phase0.Root
+}
+
+// RANDAOMixFilter defines a filter for fetching RANDAO mixes.
+// Filter elements are ANDed together.
+// Results are always returned in ascending epoch order.
+type RANDAOMixFilter struct {
+	// Limit is the maximum number of mixes to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest epoch from which to fetch mixes.
+	// If nil then there is no earliest epoch.
+	From *phase0.Epoch
+
+	// To is the latest epoch from which to fetch mixes.
+	// If nil then there is no latest epoch.
+	To *phase0.Epoch
+}
+
+// RawBlockFilter defines a filter for fetching raw blocks.
+// Filter elements are ANDed together.
+// Results are always returned in ascending slot order.
+type RawBlockFilter struct {
+	// Limit is the maximum number of raw blocks to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch raw blocks.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest slot from which to fetch raw blocks.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+}
+
+// NetworkAggregateFilter defines a filter for fetching network aggregates.
+// Filter elements are ANDed together.
+// Results are always returned in ascending epoch order.
+type NetworkAggregateFilter struct {
+	// Limit is the maximum number of aggregates to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest epoch from which to fetch aggregates.
+	// If nil then there is no earliest epoch.
+	From *phase0.Epoch
+
+	// To is the latest epoch from which to fetch aggregates.
+	// If nil then there is no latest epoch.
+	To *phase0.Epoch
+}
+
+// EntryQueueFilter defines a filter for fetching entry queues.
+// Filter elements are ANDed together.
+// Results are always returned in ascending epoch order.
+type EntryQueueFilter struct {
+	// Limit is the maximum number of entry queues to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest epoch from which to fetch entry queues.
+	// If nil then there is no earliest epoch.
+	From *phase0.Epoch
+
+	// To is the latest epoch from which to fetch entry queues.
+	// If nil then there is no latest epoch.
+	To *phase0.Epoch
+}
+
+// QueueProjectionFilter defines a filter for fetching queue projections.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (epoch, validator index) order.
+type QueueProjectionFilter struct {
+	// Limit is the maximum number of projections to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest epoch from which to fetch projections.
+	// If nil then there is no earliest epoch.
+	From *phase0.Epoch
+
+	// To is the latest epoch from which to fetch projections.
+	// If nil then there is no latest epoch.
+	To *phase0.Epoch
+
+	// ValidatorIndices are the validator indices for which to fetch projections.
+	// If nil then no filter is applied.
+	ValidatorIndices []phase0.ValidatorIndex
+}
+
+// AuditEntryFilter defines a filter for fetching audit log entries.
+// Filter elements are ANDed together.
+// Results are always returned in ascending timestamp order.
+type AuditEntryFilter struct {
+	// Limit is the maximum number of entries to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest time from which to fetch entries.
+	// If nil then there is no earliest time.
+	From *time.Time
+
+	// To is the latest time to which to fetch entries.
+	// If nil then there is no latest time.
+	To *time.Time
+
+	// Modules are the modules for which to fetch entries.
+	// If nil then no filter is applied.
+	Modules []string
+
+	// Operations are the operations for which to fetch entries; entries
+	// are returned if they include any of the operations.
+	// If nil then no filter is applied.
+	Operations []string
+}
+
+// ValidatorAnomalyFilter defines a filter for fetching validator anomalies.
+// Filter elements are ANDed together.
+// Results are always returned in ascending order of the time at which they were last seen.
+type ValidatorAnomalyFilter struct {
+	// Limit is the maximum number of anomalies to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// Types are the types of anomaly to fetch.
+	// If nil then no filter is applied.
+	Types []string
+
+	// PublicKeys are the public keys for which to fetch anomalies.
+	// If nil then no filter is applied.
+	PublicKeys []phase0.BLSPubKey
+
+	// ValidatorIndices are the validator indices for which to fetch anomalies.
+	// If nil then no filter is applied.
+	ValidatorIndices []phase0.ValidatorIndex
+}
+
+// BlobFeeFilter defines a filter for fetching blob fees.
+// Filter elements are ANDed together.
+// Results are always returned in ascending slot order.
+type BlobFeeFilter struct {
+	// Limit is the maximum number of blob fees to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch blob fees.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest slot from which to fetch blob fees.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+}
+
+// BlockHeaderFilter defines a filter for fetching block headers.
+// Filter elements are ANDed together.
+// Results are always returned in ascending slot order.
+type BlockHeaderFilter struct {
+	// Limit is the maximum number of headers to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch headers.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest slot from which to fetch headers.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+
+	// Roots are the roots of the blocks for which to fetch headers.
+	// If nil then no filter is applied.
+	Roots []phase0.Root
+
+	// ParentRoots are the parent roots of the blocks for which to fetch headers.
+	// If nil then no filter is applied.
+	ParentRoots []phase0.Root
+
+	// ProposerIndices are the proposer indices of the blocks for which to fetch headers.
+	// If nil then no filter is applied.
+	ProposerIndices []phase0.ValidatorIndex
+}
+
+// BalanceAnomalyFilter defines a filter for fetching balance anomalies.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (epoch, validator index) order.
+type BalanceAnomalyFilter struct {
+	// Limit is the maximum number of anomalies to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest epoch from which to fetch anomalies.
+	// If nil then there is no earliest epoch.
+	From *phase0.Epoch
+
+	// To is the latest epoch from which to fetch anomalies.
+	// If nil then there is no latest epoch.
+	To *phase0.Epoch
+
+	// ValidatorIndices are the validator indices for which to fetch anomalies.
+	// If nil then no filter is applied.
+	ValidatorIndices []phase0.ValidatorIndex
+
+	// Reasons are the reasons of the anomalies to fetch.
+	// If nil then no filter is applied.
+	Reasons []string
+}
+
+// WithdrawalForecastFilter defines a filter for fetching withdrawal forecasts.
+// Filter elements are ANDed together.
+// Results are always returned in ascending validator index order.
+type WithdrawalForecastFilter struct {
+	// Limit is the maximum number of forecasts to return.
+	Limit uint32
+
+	// From is the earliest forecast slot from which to fetch forecasts.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest forecast slot from which to fetch forecasts.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+
+	// ValidatorIndices are the validator indices for which to fetch forecasts.
+	// If nil then no filter is applied.
+	ValidatorIndices []phase0.ValidatorIndex
+}
+
+// HeadObservationFilter defines a filter for fetching head observations.
+// Filter elements are ANDed together.
+// Results are always returned in ascending slot order.
+type HeadObservationFilter struct {
+	// Limit is the maximum number of observations to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch observations.
+	// This relates to the slot in which the observation was made.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest slot to which to fetch observations.
+	// This relates to the slot in which the observation was made.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+
+	// Reorg must match the reorg flag.
+	// If nil then no filter is applied.
+	Reorg *bool
+}
+
+// EquivocationFilter defines a filter for fetching equivocations.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (second slot, validator index) order.
+type EquivocationFilter struct {
+	// Limit is the maximum number of equivocations to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest second slot from which to fetch equivocations.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest second slot to which to fetch equivocations.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+
+	// Types are the types of equivocation to fetch.
+	// If nil then no filter is applied.
+	Types []string
+
+	// ValidatorIndices is the list of validator indices for which to obtain equivocations.
+	// If nil then no filter is applied.
+	ValidatorIndices *[]phase0.ValidatorIndex
+
+	// Slashed, if set, restricts results to equivocations that have, or have not, been slashed.
+	// If nil then no filter is applied.
+	Slashed *bool
+}
+
+// ValidatorDaySummaryFilter defines a filter for fetching validator day summaries.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (start timestamp, validator index) order.
+type ValidatorDaySummaryFilter struct {
+	// Limit is the maximum number of summaries to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest timestamp from which to fetch summaries.
+	// If nil then there is no earliest epoch.
+	From *time.Time
+
+	// To is the latest timestamp from which to fetch summaries.
+	// If nil then there is no latest epoch.
+	To *time.Time
+
+	// ValidatorIndices is the list of validator indices for which to obtain summaries.
+	// If nil then no filter is applied
+	ValidatorIndices *[]phase0.ValidatorIndex
+}
+
+// ValidatorAPRFilter defines a filter for calculating validator APRs.
+// Filter elements are ANDed together.
+// Results are always returned in ascending validator index order.
+type ValidatorAPRFilter struct {
+	// From is the start of the window over which to calculate APRs.
+	From time.Time
+
+	// To is the end of the window over which to calculate APRs.
+	To time.Time
+
+	// ValidatorIndices is the list of validator indices for which to calculate APRs.
+	// If nil then no filter is applied
+	ValidatorIndices *[]phase0.ValidatorIndex
+}
+
+// ValidatorDayRankingFilter defines a filter for fetching validator day rankings.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (start timestamp, validator index) order.
+type ValidatorDayRankingFilter struct {
+	// Limit is the maximum number of rankings to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest timestamp from which to fetch rankings.
+	// If nil then there is no earliest timestamp.
+	From *time.Time
+
+	// To is the latest timestamp from which to fetch rankings.
+	// If nil then there is no latest timestamp.
+	To *time.Time
+
+	// ValidatorIndices is the list of validator indices for which to obtain rankings.
+	// If nil then no filter is applied
+	ValidatorIndices *[]phase0.ValidatorIndex
+}
+
+// BeaconCommitteeFilter defines a filter for fetching beacon committees.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (slot, committee index) order.
+type BeaconCommitteeFilter struct {
+	// Limit is the maximum number of items to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch items.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest slot to which to fetch items.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+
+	// CommitteeIndices is the list of committee indices for which to obtain items.
+	// If nil then no filter is applied
+	CommitteeIndices []phase0.CommitteeIndex
+
+	// ValidatorIndices is the list of validator indices for which to obtain items.
+	// Committees are returned if they contain any of the validators, and
+	// committee members are returned if they are one of the validators.
+	// If nil then no filter is applied.
+	ValidatorIndices []phase0.ValidatorIndex
+}
+
+// AttestationFilter defines a filter for fetching attestations.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (inclusion slot, inclusion block root, inclusion index) order.
+type AttestationFilter struct {
+	// Limit is the maximum number of items to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch items.
+	// This relates to the attestation slot.
+	// If nil then there is no earliest slot.
+	ScheduledFrom *phase0.Slot
+
+	// To is the latest slot to which to fetch items.
+	// This relates to the attestation slot.
+	// If nil then there is no latest slot.
+	ScheduledTo *phase0.Slot
+
+	// From is the earliest slot from which to fetch items.
+	// This relates to the inclusion slot.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest slot to which to fetch items.
+	// This relates to the inclusion slot.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+
+	// ValidatorIndices are the indices of the validators for which to fetch items.
+	// If nil then there is no index filter.
+	ValidatorIndices []phase0.ValidatorIndex
+
+	// HeadCorrect must match the head correct flag.
+	// If nil then no filter is applied
+	HeadCorrect *bool
+
+	// Canonical must match the canonical flag.
+	// If nil then no filter is applied
+	Canonical *bool
+
+	// Cursor restricts results to those strictly beyond the cursor in the direction
+	// of the order: after it for OrderEarliest, or before it for OrderLatest.
+	// To fetch the next page of results set it to the position of the last
+	// attestation returned for OrderEarliest, or the first for OrderLatest.
+	// If nil then no cursor is applied.
+	Cursor *AttestationCursor
+}
+
+// SyncAggregateFilter defines a filter for fetching sync aggregates.
+// Filter elements are ANDed together.
+// Results are always returned in ascending slot order.
+type SyncAggregateFilter struct {
+	// Limit is the maximum number of items to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch items.
+	// This relates to the inclusion slot.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest slot to which to fetch items.
+	// This relates to the inclusion slot.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+}
+
+// BLSToExecutionChangeFilter defines a filter for fetching BLS to execution changes.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (slot,index) order.
+type BLSToExecutionChangeFilter struct {
+	// Limit is the maximum number of items to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch items.
+	// This relates to the inclusion slot.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest slot to which to fetch items.
+	// This relates to the inclusion slot.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+
+	// ValidatorIndices is the list of validator indices for which to obtain items.
+	// If nil then no filter is applied
+	ValidatorIndices []phase0.ValidatorIndex
+}
+
+// BlobSidecarFilter defines a filter for fetching blob sidecars.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (slot,index) order.
+type BlobSidecarFilter struct {
+	// Limit is the maximum number of items to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch items.
+	// This relates to the inclusion slot.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest slot to which to fetch items.
+	// This relates to the inclusion slot.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+
+	// BlockRoots are the block roots for which to fetch items.
+	// If nil then there no filter is applied.
+	BlockRoots []phase0.Root
+
+	// Indices are the indices of the items.
+	// If nil then there no filter is applied.
+	Indices []uint64
+}
+
+// BlockFilter defines a filter for fetching blocks.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (slot,root) order.
+type BlockFilter struct {
+	// Limit is the maximum number of items to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch items.
+	// This relates to the inclusion slot.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest slot to which to fetch items.
+	// This relates to the inclusion slot.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+
+	// Canonical must match the canonical flag.
+	// If nil then no filter is applied
+	Canonical *bool
+
+	// ProposerIndices are the indices of the proposers of the blocks.
+	// If nil then no filter is applied.
+	ProposerIndices []phase0.ValidatorIndex
+
+	// FeeRecipientLabels are the labels or categories of the fee recipients of the
+	// execution payloads of the blocks.
+	// If nil then no filter is applied.
+	FeeRecipientLabels []string
+
+	// GraffitiContains is a case-insensitive substring of the graffiti of the blocks.
+	// If nil then no filter is applied.
+	GraffitiContains *string
+
+	// GraffitiRegex is a POSIX regular expression matching the graffiti of the blocks.
+	// If nil then no filter is applied.
+	GraffitiRegex *string
+
+	// Cursor restricts results to those strictly beyond the cursor in the direction
+	// of the order: after it for OrderEarliest, or before it for OrderLatest.
+	// To fetch the next page of results set it to the position of the last
+	// block returned for OrderEarliest, or the first for OrderLatest.
+	// If nil then no cursor is applied.
+	Cursor *BlockCursor
+}
+
+// ValidatorFilter defines a filter for fetching validators.
+// Filter elements are ANDed together.
+// Results are always returned in ascending index order.
+type ValidatorFilter struct {
+	// Limit is the maximum number of validators to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the validators with the
+	// lowest indices that match the filter are returned, or OrderLatest, in
+	// which case the validators with the highest indices that match the
+	// filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// Cursor restricts results to validators strictly beyond the given index in the
+	// direction of the order: higher for OrderEarliest, or lower for OrderLatest.
+	// If nil then no cursor is applied.
+	Cursor *phase0.ValidatorIndex
+}
+
+// GraffitiFrequencyFilter defines a filter for obtaining the frequency of graffiti.
+// Filter elements are ANDed together.
+// Results are always returned in descending order of frequency.
+type GraffitiFrequencyFilter struct {
+	// Limit is the maximum number of items to return.
+	Limit uint32
+
+	// From is the earliest epoch from which to count blocks.
+	// If nil then there is no earliest epoch.
+	From *phase0.Epoch
+
+	// To is the latest epoch to which to count blocks.
+	// If nil then there is no latest epoch.
+	To *phase0.Epoch
+
+	// GraffitiContains is a case-insensitive substring of the graffiti.
+	// If nil then no filter is applied.
+	GraffitiContains *string
+
+	// GraffitiRegex is a POSIX regular expression matching the graffiti.
+	// If nil then no filter is applied.
+	GraffitiRegex *string
+}
+
+// WithdrawalFilter defines a filter for fetching withdrawals.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (slot,index) order.
+type WithdrawalFilter struct {
+	// Limit is the maximum number of items to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch items.
+	// This relates to the inclusion slot.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest slot to which to fetch items.
+	// This relates to the inclusion slot.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+
+	// ValidatorIndices is the list of validator indices for which to obtain items.
+	// If nil then no filter is applied.
+	ValidatorIndices []phase0.ValidatorIndex
+
+	// Canonical will return only withdrawals from canonical or non-canonical blocks.
+	// Note that neither true nor false will return withdrawals from indeterminate blocks.
+	// If nil then no filter is applied.
+	Canonical *bool
+
+	// AddressLabels are the labels or categories of the withdrawal addresses.
+	// If nil then no filter is applied.
+	AddressLabels []string
+}
+
+// ValidatorCredentialsChangeFilter defines a filter for fetching validator credentials changes.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (epoch, validator index) order.
+type ValidatorCredentialsChangeFilter struct {
+	// Limit is the maximum number of items to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest epoch from which to fetch items.
+	// If nil then there is no earliest epoch.
+	From *phase0.Epoch
+
+	// To is the latest epoch to which to fetch items.
+	// If nil then there is no latest epoch.
+	To *phase0.Epoch
+
+	// ValidatorIndices is the list of validator indices for which to obtain items.
+	// If nil then no filter is applied.
+	ValidatorIndices []phase0.ValidatorIndex
+}
+
+// ValidatorConsolidationFilter defines a filter for fetching validator consolidations.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (epoch, source index) order.
+type ValidatorConsolidationFilter struct {
+	// Limit is the maximum number of items to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest epoch from which to fetch items.
+	// If nil then there is no earliest epoch.
+	From *phase0.Epoch
+
+	// To is the latest epoch to which to fetch items.
+	// If nil then there is no latest epoch.
+	To *phase0.Epoch
+
+	// ValidatorIndices is the list of validator indices for which to obtain items.
+	// Items match if either their source or target is in the list.
+	// If nil then no filter is applied.
+	ValidatorIndices []phase0.ValidatorIndex
+}
+
+// ArchiveOffloadFilter defines a filter for fetching archive offloads.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (table, epoch) order.
+type ArchiveOffloadFilter struct {
+	// Limit is the maximum number of items to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// Tables is the list of tables for which to obtain items.
+	// If nil then no filter is applied.
+	Tables []string
+
+	// From is the earliest epoch from which to fetch items.
+	// If nil then there is no earliest epoch.
+	From *phase0.Epoch
+
+	// To is the latest epoch to which to fetch items.
+	// If nil then there is no latest epoch.
+	To *phase0.Epoch
+}
+
+// BlockClientFingerprintFilter defines a filter for fetching block client fingerprints.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (slot, block root) order.
+type BlockClientFingerprintFilter struct {
+	// Limit is the maximum number of items to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch items.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest slot to which to fetch items.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+
+	// ConsensusClients are the consensus clients for which to fetch items.
+	// If nil then no filter is applied.
+	ConsensusClients []string
+
+	// ExecutionClients are the execution clients for which to fetch items.
+	// If nil then no filter is applied.
+	ExecutionClients []string
+}
+
+// ClientReleaseFilter defines a filter for fetching client releases.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (release time, layer, client, version) order.
+type ClientReleaseFilter struct {
+	// Layers are the layers for which to fetch items.
+	// If nil then no filter is applied.
+	Layers []string
+
+	// Clients are the clients for which to fetch items.
+	// If nil then no filter is applied.
+	Clients []string
+}
+
+// BlockClientReleaseFilter defines a filter for fetching block client releases.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (slot, block root) order.
+type BlockClientReleaseFilter struct {
+	// Limit is the maximum number of items to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch items.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest slot to which to fetch items.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+
+	// ConsensusVersions are the consensus versions for which to fetch items.
+	// If nil then no filter is applied.
+	ConsensusVersions []string
+
+	// ExecutionVersions are the execution versions for which to fetch items.
+	// If nil then no filter is applied.
+	ExecutionVersions []string
+}
+
+// ArrivalFilter defines a filter for fetching block and attestation arrivals.
+// Filter elements are ANDed together.
+// Results are always returned in ascending slot order.
+type ArrivalFilter struct {
 	// Limit is the maximum number of items to return.
 	Limit uint32
 
@@ -43,19 +1039,19 @@ type BlockSummaryFilter struct {
 	Order Order
 
 	// From is the earliest slot from which to fetch items.
-	// If nil then no condition is applied.
+	// If nil then there is no earliest slot.
 	From *phase0.Slot
 
-	// To is the latest slot from which to fetch items.
-	// If nil then no condition is applied.
+	// To is the latest slot to which to fetch items.
+	// If nil then there is no latest slot.
 	To *phase0.Slot
 }
 
-// ValidatorSummaryFilter defines a filter for fetching validator summaries.
+// WatchlistEventFilter defines a filter for fetching watchlist events.
 // Filter elements are ANDed together.
-// Results are always returned in ascending (epoch, validator index) order.
-type ValidatorSummaryFilter struct {
-	// Limit is the maximum number of summaries to return.
+// Results are always returned in ascending (slot, validator index, type) order.
+type WatchlistEventFilter struct {
+	// Limit is the maximum number of events to return.
 	Limit uint32
 
 	// Order is either OrderEarliest, in which case the earliest results
@@ -64,24 +1060,28 @@ type ValidatorSummaryFilter struct {
 	// The default is OrderEarliest.
 	Order Order
 
-	// From is the earliest epoch from which to fetch summaries.
+	// From is the earliest epoch from which to fetch events.
 	// If nil then there is no earliest epoch.
 	From *phase0.Epoch
 
-	// To is the latest epoch from which to fetch summaries.
+	// To is the latest epoch to which to fetch events.
 	// If nil then there is no latest epoch.
 	To *phase0.Epoch
 
-	// ValidatorIndices is the list of validator indices for which to obtain summaries.
-	// If nil then no filter is applied
-	ValidatorIndices *[]phase0.ValidatorIndex
+	// Types are the types of event to fetch.
+	// If nil then no filter is applied.
+	Types []string
+
+	// ValidatorIndices is the list of validator indices for which to obtain events.
+	// If nil then no filter is applied.
+	ValidatorIndices []phase0.ValidatorIndex
 }
 
-// EpochSummaryFilter defines a filter for fetching epoch summaries.
+// WatchlistAlertFilter defines a filter for fetching watchlist alerts.
 // Filter elements are ANDed together.
-// Results are always returned in ascending epoch order.
-type EpochSummaryFilter struct {
-	// Limit is the maximum number of summaries to return.
+// Results are always returned in ascending (epoch, validator index, rule) order.
+type WatchlistAlertFilter struct {
+	// Limit is the maximum number of alerts to return.
 	Limit uint32
 
 	// Order is either OrderEarliest, in which case the earliest results
@@ -90,20 +1090,24 @@ type EpochSummaryFilter struct {
 	// The default is OrderEarliest.
 	Order Order
 
-	// From is the earliest epoch from which to fetch summaries.
+	// From is the earliest epoch from which to fetch alerts.
 	// If nil then there is no earliest epoch.
 	From *phase0.Epoch
 
-	// To is the latest epoch from which to fetch summaries.
+	// To is the latest epoch to which to fetch alerts.
 	// If nil then there is no latest epoch.
 	To *phase0.Epoch
+
+	// ValidatorIndices is the list of validator indices for which to obtain alerts.
+	// If nil then no filter is applied.
+	ValidatorIndices []phase0.ValidatorIndex
 }
 
-// ValidatorDaySummaryFilter defines a filter for fetching validator day summaries.
+// VerificationDisagreementFilter defines a filter for fetching verification disagreements.
 // Filter elements are ANDed together.
-// Results are always returned in ascending (start timestamp, validator index) order.
-type ValidatorDaySummaryFilter struct {
-	// Limit is the maximum number of summaries to return.
+// Results are always returned in ascending slot order.
+type VerificationDisagreementFilter struct {
+	// Limit is the maximum number of disagreements to return.
 	Limit uint32
 
 	// Order is either OrderEarliest, in which case the earliest results
@@ -112,24 +1116,24 @@ type ValidatorDaySummaryFilter struct {
 	// The default is OrderEarliest.
 	Order Order
 
-	// From is the earliest timestamp from which to fetch summaries.
-	// If nil then there is no earliest epoch.
-	From *time.Time
+	// From is the earliest slot from which to fetch disagreements.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
 
-	// To is the latest timestamp from which to fetch summaries.
-	// If nil then there is no latest epoch.
-	To *time.Time
+	// To is the latest slot to which to fetch disagreements.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
 
-	// ValidatorIndices is the list of validator indices for which to obtain summaries.
-	// If nil then no filter is applied
-	ValidatorIndices *[]phase0.ValidatorIndex
+	// Types are the types of disagreement to fetch.
+	// If nil then no filter is applied.
+	Types []string
 }
 
-// BeaconCommitteeFilter defines a filter for fetching beacon committees.
+// ShufflingMismatchFilter defines a filter for fetching shuffling mismatches.
 // Filter elements are ANDed together.
-// Results are always returned in ascending (slot, committee index) order.
-type BeaconCommitteeFilter struct {
-	// Limit is the maximum number of items to return.
+// Results are always returned in ascending (slot,index) order.
+type ShufflingMismatchFilter struct {
+	// Limit is the maximum number of mismatches to return.
 	Limit uint32
 
 	// Order is either OrderEarliest, in which case the earliest results
@@ -138,24 +1142,20 @@ type BeaconCommitteeFilter struct {
 	// The default is OrderEarliest.
 	Order Order
 
-	// From is the earliest slot from which to fetch items.
+	// From is the earliest slot from which to fetch mismatches.
 	// If nil then there is no earliest slot.
 	From *phase0.Slot
 
-	// To is the latest slot to which to fetch items.
+	// To is the latest slot to which to fetch mismatches.
 	// If nil then there is no latest slot.
 	To *phase0.Slot
-
-	// CommitteeIndices is the list of committee indices for which to obtain items.
-	// If nil then no filter is applied
-	CommitteeIndices []phase0.CommitteeIndex
 }
 
-// AttestationFilter defines a filter for fetching attestations.
+// TransactionReceiptFilter defines a filter for fetching transaction receipts.
 // Filter elements are ANDed together.
-// Results are always returned in ascending (slot, committee index) order.
-type AttestationFilter struct {
-	// Limit is the maximum number of items to return.
+// Results are always returned in ascending (slot,index) order.
+type TransactionReceiptFilter struct {
+	// Limit is the maximum number of receipts to return.
 	Limit uint32
 
 	// Order is either OrderEarliest, in which case the earliest results
@@ -164,43 +1164,227 @@ type AttestationFilter struct {
 	// The default is OrderEarliest.
 	Order Order
 
-	// From is the earliest slot from which to fetch items.
-	// This relates to the attestation slot.
+	// From is the earliest slot from which to fetch receipts.
 	// If nil then there is no earliest slot.
-	ScheduledFrom *phase0.Slot
+	From *phase0.Slot
 
-	// To is the latest slot to which to fetch items.
-	// This relates to the attestation slot.
+	// To is the latest slot to which to fetch receipts.
 	// If nil then there is no latest slot.
-	ScheduledTo *phase0.Slot
+	To *phase0.Slot
 
-	// From is the earliest slot from which to fetch items.
-	// This relates to the inclusion slot.
+	// Hashes are the transaction hashes for which to fetch receipts.
+	// If nil then no filter is applied.
+	Hashes [][32]byte
+
+	// Status is the status of receipts to fetch.
+	// If nil then no filter is applied.
+	Status *uint8
+}
+
+// TransactionEventFilter defines a filter for fetching transaction events.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (slot,index) order.
+type TransactionEventFilter struct {
+	// Limit is the maximum number of events to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch events.
 	// If nil then there is no earliest slot.
 	From *phase0.Slot
 
-	// To is the latest slot to which to fetch items.
-	// This relates to the inclusion slot.
+	// To is the latest slot to which to fetch events.
 	// If nil then there is no latest slot.
 	To *phase0.Slot
 
-	// ValidatorIndices are the indices of the validators for which to fetch items.
-	// If nil then there is no index filter.
-	ValidatorIndices []phase0.ValidatorIndex
+	// Addresses are the contract addresses that emitted the events.
+	// If nil then no filter is applied.
+	Addresses [][20]byte
 
-	// HeadCorrect must match the head correct flag.
-	// If nil then no filter is applied
-	HeadCorrect *bool
+	// Topics are the first topics of the events, commonly the event signature.
+	// If nil then no filter is applied.
+	Topics [][32]byte
+}
 
-	// Canonical must match the canonical flag.
+// ETH1DepositFilter defines a filter for fetching Ethereum 1 deposits.
+// Filter elements are ANDed together.
+// Results are always returned in ascending deposit index order.
+type ETH1DepositFilter struct {
+	// Limit is the maximum number of deposits to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest Ethereum 1 block from which to fetch deposits.
+	// If nil then there is no earliest block.
+	From *uint64
+
+	// To is the latest Ethereum 1 block to which to fetch deposits.
+	// If nil then there is no latest block.
+	To *uint64
+
+	// SenderLabels are the labels or categories of the senders of the deposits.
+	// If nil then no filter is applied.
+	SenderLabels []string
+}
+
+// ETH1DepositDiscrepancyFilter defines a filter for fetching Ethereum 1 deposit discrepancies.
+// Filter elements are ANDed together.
+// Results are always returned in ascending block number order.
+type ETH1DepositDiscrepancyFilter struct {
+	// Limit is the maximum number of discrepancies to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest Ethereum 1 block from which to fetch discrepancies.
+	// If nil then there is no earliest block.
+	From *uint64
+
+	// To is the latest Ethereum 1 block to which to fetch discrepancies.
+	// If nil then there is no latest block.
+	To *uint64
+}
+
+// AddressLabelFilter defines a filter for fetching address labels.
+// Filter elements are ANDed together.
+// Results are always returned in ascending address order.
+type AddressLabelFilter struct {
+	// Limit is the maximum number of labels to return.
+	Limit uint32
+
+	// Addresses are the addresses for which to fetch labels.
+	// If nil then no filter is applied.
+	Addresses [][20]byte
+
+	// Labels are the labels or categories for which to fetch labels.
+	// If nil then no filter is applied.
+	Labels []string
+}
+
+// ValidatorSyncPeriodSummaryFilter defines a filter for fetching validator sync period summaries.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (period, validator index) order.
+type ValidatorSyncPeriodSummaryFilter struct {
+	// Limit is the maximum number of summaries to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest sync committee period from which to fetch summaries.
+	// If nil then there is no earliest period.
+	From *uint64
+
+	// To is the latest sync committee period from which to fetch summaries.
+	// If nil then there is no latest period.
+	To *uint64
+
+	// ValidatorIndices is the list of validator indices for which to obtain summaries.
 	// If nil then no filter is applied
-	Canonical *bool
+	ValidatorIndices *[]phase0.ValidatorIndex
 }
 
-// SyncAggregateFilter defines a filter for fetching sync aggregates.
+// ProposerPeriodSummaryFilter defines a filter for fetching proposer period summaries.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (start timestamp, validator index, tag) order.
+type ProposerPeriodSummaryFilter struct {
+	// Limit is the maximum number of summaries to return.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// Window is the window of the summaries, one of the ProposerSummaryWindow constants.
+	// If empty then no filter is applied.
+	Window string
+
+	// From is the timestamp from which to fetch summaries.
+	// This relates to the start of the window.
+	// If nil then there is no earliest timestamp.
+	From *time.Time
+
+	// To is the timestamp to which to fetch summaries.
+	// This relates to the start of the window.
+	// If nil then there is no latest timestamp.
+	To *time.Time
+
+	// ValidatorIndices is the list of validator indices for which to obtain summaries.
+	// If nil then no filter is applied.
+	ValidatorIndices *[]phase0.ValidatorIndex
+
+	// Tags is the list of tags for which to obtain summaries.
+	// If nil then no filter is applied.
+	Tags *[]string
+}
+
+// ValidatorClusterFilter defines a filter for fetching validator clusters.
+// Filter elements are ANDed together.
+// Results are always returned in descending size order, and then by ID.
+type ValidatorClusterFilter struct {
+	// Limit is the maximum number of clusters to return.
+	Limit uint32
+
+	// ValidatorIndices is the list of validator indices whose clusters are obtained.
+	// If nil then no filter is applied.
+	ValidatorIndices []phase0.ValidatorIndex
+
+	// MinSize is the minimum number of validators in the clusters.
+	// If 0 then no filter is applied.
+	MinSize int
+}
+
+// FailedItemFilter defines a filter for fetching failed items.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (module, kind, item) order.
+type FailedItemFilter struct {
+	// Limit is the maximum number of items to return.
+	Limit uint32
+
+	// Modules are the modules whose items are returned.
+	// If nil then no filter is applied.
+	Modules []string
+
+	// Kind is the kind of the items, one of the FailedItemKind constants.
+	// If empty then no filter is applied.
+	Kind string
+
+	// States are the states of the items, from the FailedItemState constants.
+	// If nil then no filter is applied.
+	States []string
+
+	// From is the earliest item to return.
+	// If nil then there is no earliest item.
+	From *uint64
+
+	// To is the latest item to return.
+	// If nil then there is no latest item.
+	To *uint64
+}
+
+// ProposerDutyFilter defines a filter for fetching proposer duties.
 // Filter elements are ANDed together.
 // Results are always returned in ascending slot order.
-type SyncAggregateFilter struct {
+type ProposerDutyFilter struct {
 	// Limit is the maximum number of items to return.
 	Limit uint32
 
@@ -211,20 +1395,22 @@ type SyncAggregateFilter struct {
 	Order Order
 
 	// From is the earliest slot from which to fetch items.
-	// This relates to the inclusion slot.
 	// If nil then there is no earliest slot.
 	From *phase0.Slot
 
 	// To is the latest slot to which to fetch items.
-	// This relates to the inclusion slot.
 	// If nil then there is no latest slot.
 	To *phase0.Slot
+
+	// ValidatorIndices are the indices of the proposers for which to fetch items.
+	// If nil then no filter is applied.
+	ValidatorIndices []phase0.ValidatorIndex
 }
 
-// BLSToExecutionChangeFilter defines a filter for fetching BLS to execution changes.
+// AttesterSlashingFilter defines a filter for fetching attester slashings.
 // Filter elements are ANDed together.
-// Results are always returned in ascending (slot,index) order.
-type BLSToExecutionChangeFilter struct {
+// Results are always returned in ascending (inclusion slot, inclusion index) order.
+type AttesterSlashingFilter struct {
 	// Limit is the maximum number of items to return.
 	Limit uint32
 
@@ -244,15 +1430,25 @@ type BLSToExecutionChangeFilter struct {
 	// If nil then there is no latest slot.
 	To *phase0.Slot
 
-	// ValidatorIndices is the list of validator indices for which to obtain items.
-	// If nil then no filter is applied
+	// ValidatorIndices are the indices of the validators slashed by the items,
+	// being those present in both attestations of the slashing.
+	// If nil then no filter is applied.
 	ValidatorIndices []phase0.ValidatorIndex
+
+	// Canonical will return only items from canonical or non-canonical blocks.
+	// Note that neither true nor false will return items from indeterminate blocks.
+	// If nil then no filter is applied.
+	Canonical *bool
+
+	// ExcludeNonCanonical will not return items from non-canonical blocks, but
+	// will return items from canonical and indeterminate blocks.
+	ExcludeNonCanonical bool
 }
 
-// BlobSidecarFilter defines a filter for fetching blob sidecars.
+// ProposerSlashingFilter defines a filter for fetching proposer slashings.
 // Filter elements are ANDed together.
-// Results are always returned in ascending (slot,index) order.
-type BlobSidecarFilter struct {
+// Results are always returned in ascending (inclusion slot, inclusion index) order.
+type ProposerSlashingFilter struct {
 	// Limit is the maximum number of items to return.
 	Limit uint32
 
@@ -272,19 +1468,24 @@ type BlobSidecarFilter struct {
 	// If nil then there is no latest slot.
 	To *phase0.Slot
 
-	// BlockRoots are the block roots for which to fetch items.
-	// If nil then there no filter is applied.
-	BlockRoots []phase0.Root
+	// ValidatorIndices are the indices of the proposers slashed by the items.
+	// If nil then no filter is applied.
+	ValidatorIndices []phase0.ValidatorIndex
 
-	// Indices are the indices of the items.
-	// If nil then there no filter is applied.
-	Indices []uint64
+	// Canonical will return only items from canonical or non-canonical blocks.
+	// Note that neither true nor false will return items from indeterminate blocks.
+	// If nil then no filter is applied.
+	Canonical *bool
+
+	// ExcludeNonCanonical will not return items from non-canonical blocks, but
+	// will return items from canonical and indeterminate blocks.
+	ExcludeNonCanonical bool
 }
 
-// BlockFilter defines a filter for fetching blocks.
+// DepositFilter defines a filter for fetching deposits.
 // Filter elements are ANDed together.
-// Results are always returned in ascending (slot,root) order.
-type BlockFilter struct {
+// Results are always returned in ascending (inclusion slot, inclusion index) order.
+type DepositFilter struct {
 	// Limit is the maximum number of items to return.
 	Limit uint32
 
@@ -304,15 +1505,24 @@ type BlockFilter struct {
 	// If nil then there is no latest slot.
 	To *phase0.Slot
 
-	// Canonical must match the canonical flag.
-	// If nil then no filter is applied
+	// PublicKeys are the public keys of the validators for which to fetch items.
+	// If nil then no filter is applied.
+	PublicKeys []phase0.BLSPubKey
+
+	// Canonical will return only items from canonical or non-canonical blocks.
+	// Note that neither true nor false will return items from indeterminate blocks.
+	// If nil then no filter is applied.
 	Canonical *bool
+
+	// ExcludeNonCanonical will not return items from non-canonical blocks, but
+	// will return items from canonical and indeterminate blocks.
+	ExcludeNonCanonical bool
 }
 
-// WithdrawalFilter defines a filter for fetching withdrawals.
+// VoluntaryExitFilter defines a filter for fetching voluntary exits.
 // Filter elements are ANDed together.
-// Results are always returned in ascending (slot,index) order.
-type WithdrawalFilter struct {
+// Results are always returned in ascending (inclusion slot, inclusion index) order.
+type VoluntaryExitFilter struct {
 	// Limit is the maximum number of items to return.
 	Limit uint32
 
@@ -332,12 +1542,16 @@ type WithdrawalFilter struct {
 	// If nil then there is no latest slot.
 	To *phase0.Slot
 
-	// ValidatorIndices is the list of validator indices for which to obtain items.
+	// ValidatorIndices are the indices of the exiting validators for which to fetch items.
 	// If nil then no filter is applied.
 	ValidatorIndices []phase0.ValidatorIndex
 
-	// Canonical will return only withdrawals from canonical or non-canonical blocks.
-	// Note that neither true nor false will return withdrawals from indeterminate blocks.
+	// Canonical will return only items from canonical or non-canonical blocks.
+	// Note that neither true nor false will return items from indeterminate blocks.
 	// If nil then no filter is applied.
 	Canonical *bool
+
+	// ExcludeNonCanonical will not return items from non-canonical blocks, but
+	// will return items from canonical and indeterminate blocks.
+	ExcludeNonCanonical bool
 }
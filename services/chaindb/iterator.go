@@ -0,0 +1,33 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindb
+
+import "context"
+
+// Iterator provides items one at a time, allowing large result sets to be
+// processed without holding them all in memory.
+type Iterator[T any] interface {
+	// Next advances the iterator to the next item.  It returns false when
+	// there are no more items, or if an error occurred.
+	Next(ctx context.Context) bool
+
+	// Value returns the item at the current position of the iterator.
+	Value() T
+
+	// Err returns the error, if any, that caused Next to return false.
+	Err() error
+
+	// Close releases any resources held by the iterator.
+	Close()
+}
@@ -0,0 +1,190 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindb
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// fixtureSlotsPerEpoch is the number of slots per epoch used by deterministic fixtures.
+const fixtureSlotsPerEpoch = 32
+
+// fixtureBalance is the balance of validators in deterministic fixtures, in Gwei.
+const fixtureBalance = phase0.Gwei(32000000000)
+
+// Fixtures are the data with which to populate an in-memory chain database.
+type Fixtures struct {
+	Genesis           *apiv1.Genesis
+	Spec              map[string]any
+	Validators        []*chaindb.Validator
+	ValidatorBalances []*chaindb.ValidatorBalance
+	Blocks            []*chaindb.Block
+	Attestations      []*chaindb.Attestation
+	BeaconCommittees  []*chaindb.BeaconCommittee
+	ProposerDuties    []*chaindb.ProposerDuty
+}
+
+// DeterministicFixtures creates fixtures for a chain with the given number of
+// validators and slots.  The same parameters always generate the same data:
+//   - a canonical block in every slot, with proposer slot modulo validators;
+//   - a single committee per slot, of the validators whose index modulo 32 matches the slot modulo 32;
+//   - an attestation from all members of the committee of the previous slot in every block after genesis,
+//     with correct head, target and source votes;
+//   - a balance of 32 Ether for every validator at every epoch.
+func DeterministicFixtures(validators uint64, slots uint64) *Fixtures {
+	fixtures := &Fixtures{
+		Genesis: &apiv1.Genesis{
+			GenesisTime:           time.Unix(1606824023, 0),
+			GenesisValidatorsRoot: fixtureRoot("genesis validators root", 0),
+			GenesisForkVersion:    phase0.Version{0x00, 0x00, 0x00, 0x00},
+		},
+		Spec: map[string]any{
+			"SECONDS_PER_SLOT":             12 * time.Second,
+			"SLOTS_PER_EPOCH":              uint64(fixtureSlotsPerEpoch),
+			"EPOCHS_PER_HISTORICAL_VECTOR": uint64(65536),
+			"MAX_EFFECTIVE_BALANCE":        uint64(fixtureBalance),
+		},
+		Validators:        make([]*chaindb.Validator, 0, validators),
+		ValidatorBalances: make([]*chaindb.ValidatorBalance, 0),
+		Blocks:            make([]*chaindb.Block, 0, slots),
+		Attestations:      make([]*chaindb.Attestation, 0, slots),
+		BeaconCommittees:  make([]*chaindb.BeaconCommittee, 0, slots),
+		ProposerDuties:    make([]*chaindb.ProposerDuty, 0, slots),
+	}
+
+	for i := uint64(0); i < validators; i++ {
+		var pubKey phase0.BLSPubKey
+		binary.BigEndian.PutUint64(pubKey[len(pubKey)-8:], i+1)
+		var withdrawalCredentials [32]byte
+		withdrawalCredentials[0] = 0x01
+		binary.BigEndian.PutUint64(withdrawalCredentials[24:], i+1)
+		fixtures.Validators = append(fixtures.Validators, &chaindb.Validator{
+			PublicKey:                  pubKey,
+			Index:                      phase0.ValidatorIndex(i),
+			EffectiveBalance:           fixtureBalance,
+			ActivationEligibilityEpoch: 0,
+			ActivationEpoch:            0,
+			ExitEpoch:                  0xffffffffffffffff,
+			WithdrawableEpoch:          0xffffffffffffffff,
+			WithdrawalCredentials:      withdrawalCredentials,
+		})
+	}
+
+	epochs := (slots + fixtureSlotsPerEpoch - 1) / fixtureSlotsPerEpoch
+	for epoch := uint64(0); epoch < epochs; epoch++ {
+		for i := uint64(0); i < validators; i++ {
+			fixtures.ValidatorBalances = append(fixtures.ValidatorBalances, &chaindb.ValidatorBalance{
+				Index:            phase0.ValidatorIndex(i),
+				Epoch:            phase0.Epoch(epoch),
+				Balance:          fixtureBalance,
+				EffectiveBalance: fixtureBalance,
+			})
+		}
+	}
+
+	canonical := true
+	for slot := uint64(0); slot < slots; slot++ {
+		block := &chaindb.Block{
+			Slot:      phase0.Slot(slot),
+			Root:      fixtureBlockRoot(slot),
+			BodyRoot:  fixtureRoot("body", slot),
+			StateRoot: fixtureRoot("state", slot),
+			Graffiti:  make([]byte, 32),
+			Canonical: &canonical,
+		}
+		if validators > 0 {
+			block.ProposerIndex = phase0.ValidatorIndex(slot % validators)
+		}
+		if slot > 0 {
+			block.ParentRoot = fixtureBlockRoot(slot - 1)
+		}
+		copy(block.Graffiti, "chaind fixture")
+		fixtures.Blocks = append(fixtures.Blocks, block)
+
+		fixtures.ProposerDuties = append(fixtures.ProposerDuties, &chaindb.ProposerDuty{
+			Slot:           block.Slot,
+			ValidatorIndex: block.ProposerIndex,
+		})
+
+		committee := make([]phase0.ValidatorIndex, 0)
+		for i := slot % fixtureSlotsPerEpoch; i < validators; i += fixtureSlotsPerEpoch {
+			committee = append(committee, phase0.ValidatorIndex(i))
+		}
+		fixtures.BeaconCommittees = append(fixtures.BeaconCommittees, &chaindb.BeaconCommittee{
+			Slot:      block.Slot,
+			Index:     0,
+			Committee: committee,
+		})
+	}
+
+	for slot := uint64(1); slot < slots; slot++ {
+		attested := slot - 1
+		committee := fixtures.BeaconCommittees[attested].Committee
+		if len(committee) == 0 {
+			continue
+		}
+		aggregationBits := bitfield.NewBitlist(uint64(len(committee)))
+		for i := range committee {
+			aggregationBits.SetBitAt(uint64(i), true)
+		}
+		targetEpoch := attested / fixtureSlotsPerEpoch
+		sourceEpoch := uint64(0)
+		if targetEpoch > 0 {
+			sourceEpoch = targetEpoch - 1
+		}
+		fixtures.Attestations = append(fixtures.Attestations, &chaindb.Attestation{
+			InclusionSlot:          phase0.Slot(slot),
+			InclusionBlockRoot:     fixtureBlockRoot(slot),
+			InclusionIndex:         0,
+			Slot:                   phase0.Slot(attested),
+			CommitteeIndex:         0,
+			AggregationBits:        aggregationBits,
+			AggregationIndices:     committee,
+			BeaconBlockRoot:        fixtureBlockRoot(attested),
+			SourceEpoch:            phase0.Epoch(sourceEpoch),
+			SourceRoot:             fixtureBlockRoot(sourceEpoch * fixtureSlotsPerEpoch),
+			TargetEpoch:            phase0.Epoch(targetEpoch),
+			TargetRoot:             fixtureBlockRoot(targetEpoch * fixtureSlotsPerEpoch),
+			Canonical:              &canonical,
+			TargetCorrect:          &canonical,
+			HeadCorrect:            &canonical,
+			SourceCorrect:          &canonical,
+			InclusionTargetCorrect: &canonical,
+			InclusionHeadCorrect:   &canonical,
+		})
+	}
+
+	return fixtures
+}
+
+// fixtureBlockRoot returns the deterministic root of the block at the given slot.
+func fixtureBlockRoot(slot uint64) phase0.Root {
+	return fixtureRoot("block", slot)
+}
+
+// fixtureRoot returns a deterministic root for the given purpose and value.
+func fixtureRoot(purpose string, value uint64) phase0.Root {
+	data := make([]byte, len(purpose)+8)
+	copy(data, purpose)
+	binary.BigEndian.PutUint64(data[len(purpose):], value)
+
+	return sha256.Sum256(data)
+}
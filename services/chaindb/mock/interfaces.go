@@ -0,0 +1,158 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindb
+
+import "github.com/wealdtech/chaind/services/chaindb"
+
+// Ensure that the mock implements all chain database interfaces.
+var (
+	_ chaindb.AttestationsStreamProvider           = (*service)(nil)
+	_ chaindb.AttestationsProvider                 = (*service)(nil)
+	_ chaindb.AttestingIndicesProvider             = (*service)(nil)
+	_ chaindb.AttestationsSetter                   = (*service)(nil)
+	_ chaindb.AttestationsPruner                   = (*service)(nil)
+	_ chaindb.AttesterSlashingsProvider            = (*service)(nil)
+	_ chaindb.AttesterSlashingsSetter              = (*service)(nil)
+	_ chaindb.BeaconCommitteesProvider             = (*service)(nil)
+	_ chaindb.BeaconCommitteeMembersProvider       = (*service)(nil)
+	_ chaindb.BeaconCommitteesSetter               = (*service)(nil)
+	_ chaindb.BeaconCommitteesPruner               = (*service)(nil)
+	_ chaindb.BlocksStreamProvider                 = (*service)(nil)
+	_ chaindb.BlocksProvider                       = (*service)(nil)
+	_ chaindb.GraffitiProvider                     = (*service)(nil)
+	_ chaindb.BlocksSetter                         = (*service)(nil)
+	_ chaindb.CanonicalBlocksSetter                = (*service)(nil)
+	_ chaindb.BlobSidecarsProvider                 = (*service)(nil)
+	_ chaindb.BlobSidecarsSetter                   = (*service)(nil)
+	_ chaindb.ChainSpecProvider                    = (*service)(nil)
+	_ chaindb.ChainSpecSetter                      = (*service)(nil)
+	_ chaindb.ForkScheduleProvider                 = (*service)(nil)
+	_ chaindb.ForkScheduleSetter                   = (*service)(nil)
+	_ chaindb.GenesisProvider                      = (*service)(nil)
+	_ chaindb.GenesisSetter                        = (*service)(nil)
+	_ chaindb.ETH1DepositsProvider                 = (*service)(nil)
+	_ chaindb.ETH1DepositsSetter                   = (*service)(nil)
+	_ chaindb.ETH1DepositDiscrepanciesProvider     = (*service)(nil)
+	_ chaindb.ETH1DepositDiscrepanciesSetter       = (*service)(nil)
+	_ chaindb.ProposerDutiesProvider               = (*service)(nil)
+	_ chaindb.ProposerDutiesSetter                 = (*service)(nil)
+	_ chaindb.ProposerSlashingsProvider            = (*service)(nil)
+	_ chaindb.ProposerSlashingsSetter              = (*service)(nil)
+	_ chaindb.SyncAggregateProvider                = (*service)(nil)
+	_ chaindb.SyncAggregateSetter                  = (*service)(nil)
+	_ chaindb.SyncAggregatePruner                  = (*service)(nil)
+	_ chaindb.SyncParticipationProvider            = (*service)(nil)
+	_ chaindb.ValidatorIndicesProvider             = (*service)(nil)
+	_ chaindb.ValidatorsStreamProvider             = (*service)(nil)
+	_ chaindb.ValidatorsProvider                   = (*service)(nil)
+	_ chaindb.AggregateValidatorBalancesProvider   = (*service)(nil)
+	_ chaindb.ValidatorBalancesPruner              = (*service)(nil)
+	_ chaindb.ValidatorBalancesArchiver            = (*service)(nil)
+	_ chaindb.ValidatorsSetter                     = (*service)(nil)
+	_ chaindb.ValidatorCredentialsProvider         = (*service)(nil)
+	_ chaindb.ValidatorCredentialsSetter           = (*service)(nil)
+	_ chaindb.ValidatorShardsProvider              = (*service)(nil)
+	_ chaindb.ValidatorShardsSetter                = (*service)(nil)
+	_ chaindb.ValidatorSetDiffProvider             = (*service)(nil)
+	_ chaindb.ValidatorConsolidationsProvider      = (*service)(nil)
+	_ chaindb.ValidatorConsolidationsSetter        = (*service)(nil)
+	_ chaindb.ArchiveOffloadsProvider              = (*service)(nil)
+	_ chaindb.ArchiveOffloadsSetter                = (*service)(nil)
+	_ chaindb.BlockClientFingerprintsProvider      = (*service)(nil)
+	_ chaindb.BlockClientFingerprintsSetter        = (*service)(nil)
+	_ chaindb.ClientReleasesProvider               = (*service)(nil)
+	_ chaindb.ClientReleasesSetter                 = (*service)(nil)
+	_ chaindb.BlockClientReleasesProvider          = (*service)(nil)
+	_ chaindb.BlockClientReleasesSetter            = (*service)(nil)
+	_ chaindb.ArrivalsProvider                     = (*service)(nil)
+	_ chaindb.ArrivalsSetter                       = (*service)(nil)
+	_ chaindb.SecondaryIndexManager                = (*service)(nil)
+	_ chaindb.TableMaintainer                      = (*service)(nil)
+	_ chaindb.DepositsProvider                     = (*service)(nil)
+	_ chaindb.DepositsSetter                       = (*service)(nil)
+	_ chaindb.VoluntaryExitsProvider               = (*service)(nil)
+	_ chaindb.VoluntaryExitsSetter                 = (*service)(nil)
+	_ chaindb.ValidatorDaySummariesProvider        = (*service)(nil)
+	_ chaindb.ValidatorAPRsProvider                = (*service)(nil)
+	_ chaindb.ValidatorDaySummariesSetter          = (*service)(nil)
+	_ chaindb.ValidatorDayRankingsProvider         = (*service)(nil)
+	_ chaindb.ValidatorDayRankingsSetter           = (*service)(nil)
+	_ chaindb.ValidatorEpochSummariesProvider      = (*service)(nil)
+	_ chaindb.ValidatorEpochSummariesPruner        = (*service)(nil)
+	_ chaindb.ValidatorEpochSummariesSetter        = (*service)(nil)
+	_ chaindb.BlockSummariesProvider               = (*service)(nil)
+	_ chaindb.BlockSummariesSetter                 = (*service)(nil)
+	_ chaindb.NetworkAggregatesProvider            = (*service)(nil)
+	_ chaindb.NetworkAggregatesSetter              = (*service)(nil)
+	_ chaindb.EntryQueuesProvider                  = (*service)(nil)
+	_ chaindb.EntryQueuesSetter                    = (*service)(nil)
+	_ chaindb.QueueProjectionsProvider             = (*service)(nil)
+	_ chaindb.QueueProjectionsSetter               = (*service)(nil)
+	_ chaindb.WithdrawalForecastsProvider          = (*service)(nil)
+	_ chaindb.WithdrawalForecastsSetter            = (*service)(nil)
+	_ chaindb.AuditLogProvider                     = (*service)(nil)
+	_ chaindb.AuditLogPruner                       = (*service)(nil)
+	_ chaindb.BlobFeesProvider                     = (*service)(nil)
+	_ chaindb.ValidatorAnomaliesProvider           = (*service)(nil)
+	_ chaindb.BlobFeesSetter                       = (*service)(nil)
+	_ chaindb.BalanceAnomaliesProvider             = (*service)(nil)
+	_ chaindb.BalanceAnomaliesSetter               = (*service)(nil)
+	_ chaindb.BlockHeadersProvider                 = (*service)(nil)
+	_ chaindb.BlockHeadersSetter                   = (*service)(nil)
+	_ chaindb.ValidatorClustersProvider            = (*service)(nil)
+	_ chaindb.ValidatorClustersSetter              = (*service)(nil)
+	_ chaindb.FailedItemsProvider                  = (*service)(nil)
+	_ chaindb.FailedItemsSetter                    = (*service)(nil)
+	_ chaindb.HeadObservationsProvider             = (*service)(nil)
+	_ chaindb.HeadObservationsSetter               = (*service)(nil)
+	_ chaindb.EquivocationsProvider                = (*service)(nil)
+	_ chaindb.EquivocationsSetter                  = (*service)(nil)
+	_ chaindb.EpochSummariesProvider               = (*service)(nil)
+	_ chaindb.EpochSummariesSetter                 = (*service)(nil)
+	_ chaindb.CommitteeEpochSummariesProvider      = (*service)(nil)
+	_ chaindb.CommitteeEpochSummariesSetter        = (*service)(nil)
+	_ chaindb.ValidatorSyncPeriodSummariesProvider = (*service)(nil)
+	_ chaindb.ValidatorSyncPeriodSummariesSetter   = (*service)(nil)
+	_ chaindb.ProposerPeriodSummariesProvider      = (*service)(nil)
+	_ chaindb.ProposerPeriodSummariesSetter        = (*service)(nil)
+	_ chaindb.ProposerPeriodTotalsProvider         = (*service)(nil)
+	_ chaindb.CheckpointsProvider                  = (*service)(nil)
+	_ chaindb.CheckpointsSetter                    = (*service)(nil)
+	_ chaindb.RANDAOMixesProvider                  = (*service)(nil)
+	_ chaindb.RANDAOMixesSetter                    = (*service)(nil)
+	_ chaindb.RawBlocksProvider                    = (*service)(nil)
+	_ chaindb.RawBlocksSetter                      = (*service)(nil)
+	_ chaindb.SyncCommitteesProvider               = (*service)(nil)
+	_ chaindb.SyncCommitteesSetter                 = (*service)(nil)
+	_ chaindb.WithdrawalsProvider                  = (*service)(nil)
+	_ chaindb.BLSToExecutionChangesProvider        = (*service)(nil)
+	_ chaindb.ExportRowsProvider                   = (*service)(nil)
+	_ chaindb.OutboxProvider                       = (*service)(nil)
+	_ chaindb.OutboxSetter                         = (*service)(nil)
+	_ chaindb.WatchlistProvider                    = (*service)(nil)
+	_ chaindb.WatchlistSetter                      = (*service)(nil)
+	_ chaindb.VerificationDisagreementsProvider    = (*service)(nil)
+	_ chaindb.VerificationDisagreementsSetter      = (*service)(nil)
+	_ chaindb.ShufflingMismatchesProvider          = (*service)(nil)
+	_ chaindb.ShufflingMismatchesSetter            = (*service)(nil)
+	_ chaindb.TransactionReceiptsProvider          = (*service)(nil)
+	_ chaindb.TransactionReceiptsSetter            = (*service)(nil)
+	_ chaindb.ExecutionPayloadValuesSetter         = (*service)(nil)
+	_ chaindb.AddressLabelsProvider                = (*service)(nil)
+	_ chaindb.AddressLabelsSetter                  = (*service)(nil)
+	_ chaindb.Service                              = (*service)(nil)
+	_ chaindb.SessionLocker                        = (*service)(nil)
+	_ chaindb.MetadataProvider                     = (*service)(nil)
+	_ chaindb.MetadataSetter                       = (*service)(nil)
+)
@@ -0,0 +1,70 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindb_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestInterfacesAsserted ensures that every chain database interface is asserted
+// to be implemented by the mock, so that the mock cannot drift from the interfaces.
+func TestInterfacesAsserted(t *testing.T) {
+	// Interfaces that are not implemented by a chain database.
+	excluded := map[string]bool{
+		"Iterator":    true,
+		"SessionLock": true,
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, "..", nil, 0)
+	require.NoError(t, err)
+	interfaces := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				genDecl, isGenDecl := decl.(*ast.GenDecl)
+				if !isGenDecl || genDecl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range genDecl.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					if _, isInterface := typeSpec.Type.(*ast.InterfaceType); isInterface && !excluded[typeSpec.Name.Name] {
+						interfaces[typeSpec.Name.Name] = true
+					}
+				}
+			}
+		}
+	}
+	require.NotEmpty(t, interfaces)
+
+	file, err := parser.ParseFile(fset, "interfaces.go", nil, 0)
+	require.NoError(t, err)
+	asserted := make(map[string]bool)
+	ast.Inspect(file, func(node ast.Node) bool {
+		if selector, isSelector := node.(*ast.SelectorExpr); isSelector {
+			asserted[selector.Sel.Name] = true
+		}
+
+		return true
+	})
+
+	for name := range interfaces {
+		require.True(t, asserted[name], "interface %s not asserted for the mock", name)
+	}
+}
@@ -0,0 +1,1454 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindb
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// InMemoryService is a chain database that holds genesis, chain specification,
+// metadata, blocks, attestations, deposits, withdrawals, validators, validator balances,
+// beacon committees and proposer duties in memory, allowing code that uses chain database providers
+// to be tested without a database.
+// Other providers behave as per the mock returned by New.
+// Transactions are accepted but have no effect: writes are visible immediately.
+// Lookups for single items that are not present return pgx.ErrNoRows, as per the
+// PostgreSQL implementation.
+type InMemoryService struct {
+	service
+
+	mu               sync.RWMutex
+	genesis          *apiv1.Genesis
+	spec             map[string]any
+	metadata         map[string][]byte
+	blocks           map[phase0.Root]*chaindb.Block
+	attestations     []*chaindb.Attestation
+	deposits         []*chaindb.Deposit
+	validators       map[phase0.ValidatorIndex]*chaindb.Validator
+	balances         map[phase0.Epoch]map[phase0.ValidatorIndex]*chaindb.ValidatorBalance
+	beaconCommittees map[phase0.Slot]map[phase0.CommitteeIndex]*chaindb.BeaconCommittee
+	proposerDuties   map[phase0.Slot]*chaindb.ProposerDuty
+}
+
+// NewInMemory creates a new in-memory chain database, populated with the
+// supplied fixtures if present.
+func NewInMemory(ctx context.Context, fixtures *Fixtures) (*InMemoryService, error) {
+	s := &InMemoryService{
+		spec:             make(map[string]any),
+		metadata:         make(map[string][]byte),
+		blocks:           make(map[phase0.Root]*chaindb.Block),
+		attestations:     make([]*chaindb.Attestation, 0),
+		deposits:         make([]*chaindb.Deposit, 0),
+		validators:       make(map[phase0.ValidatorIndex]*chaindb.Validator),
+		balances:         make(map[phase0.Epoch]map[phase0.ValidatorIndex]*chaindb.ValidatorBalance),
+		beaconCommittees: make(map[phase0.Slot]map[phase0.CommitteeIndex]*chaindb.BeaconCommittee),
+		proposerDuties:   make(map[phase0.Slot]*chaindb.ProposerDuty),
+	}
+
+	if fixtures != nil {
+		if err := s.load(ctx, fixtures); err != nil {
+			return nil, errors.Wrap(err, "failed to load fixtures")
+		}
+	}
+
+	return s, nil
+}
+
+// load loads fixtures in to the database.
+func (s *InMemoryService) load(ctx context.Context, fixtures *Fixtures) error {
+	if fixtures.Genesis != nil {
+		if err := s.SetGenesis(ctx, fixtures.Genesis); err != nil {
+			return err
+		}
+	}
+	for key, value := range fixtures.Spec {
+		if err := s.SetChainSpecValue(ctx, key, value); err != nil {
+			return err
+		}
+	}
+	for _, validator := range fixtures.Validators {
+		if err := s.SetValidator(ctx, validator); err != nil {
+			return err
+		}
+	}
+	if err := s.SetValidatorBalances(ctx, fixtures.ValidatorBalances); err != nil {
+		return err
+	}
+	for _, block := range fixtures.Blocks {
+		if err := s.SetBlock(ctx, block); err != nil {
+			return err
+		}
+	}
+	if err := s.SetAttestations(ctx, fixtures.Attestations); err != nil {
+		return err
+	}
+	for _, beaconCommittee := range fixtures.BeaconCommittees {
+		if err := s.SetBeaconCommittee(ctx, beaconCommittee); err != nil {
+			return err
+		}
+	}
+	for _, proposerDuty := range fixtures.ProposerDuties {
+		if err := s.SetProposerDuty(ctx, proposerDuty); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Genesis fetches genesis values.
+func (s *InMemoryService) Genesis(_ context.Context,
+	_ *api.GenesisOpts,
+) (
+	*api.Response[*apiv1.Genesis],
+	error,
+) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.genesis == nil {
+		return nil, pgx.ErrNoRows
+	}
+
+	return &api.Response[*apiv1.Genesis]{
+		Data:     s.genesis,
+		Metadata: make(map[string]any),
+	}, nil
+}
+
+// SetGenesis sets the genesis information.
+func (s *InMemoryService) SetGenesis(_ context.Context, genesis *apiv1.Genesis) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	genesisCopy := *genesis
+	s.genesis = &genesisCopy
+
+	return nil
+}
+
+// ChainSpec fetches the chain specification.
+func (s *InMemoryService) ChainSpec(_ context.Context) (*chaindb.Spec, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values := make(map[string]any, len(s.spec))
+	for key, value := range s.spec {
+		values[key] = value
+	}
+
+	return chaindb.NewSpec(values), nil
+}
+
+// ChainSpecValue fetches a chain specification value given its key.
+func (s *InMemoryService) ChainSpecValue(_ context.Context, key string) (any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, exists := s.spec[key]
+	if !exists {
+		return nil, pgx.ErrNoRows
+	}
+
+	return value, nil
+}
+
+// SetChainSpecValue sets the value of the provided key.
+func (s *InMemoryService) SetChainSpecValue(_ context.Context, key string, value any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.spec[key] = value
+
+	return nil
+}
+
+// Metadata fetches a metadata value.
+// Returns nil if the key is not present.
+func (s *InMemoryService) Metadata(_ context.Context, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.metadata[key], nil
+}
+
+// SetMetadata sets a metadata key to a JSON value.
+func (s *InMemoryService) SetMetadata(_ context.Context, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.metadata[key] = bytes.Clone(value)
+
+	return nil
+}
+
+// SetBlock sets a block.
+func (s *InMemoryService) SetBlock(_ context.Context, block *chaindb.Block) error {
+	if block == nil {
+		return errors.New("block nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	blockCopy := *block
+	s.blocks[block.Root] = &blockCopy
+
+	return nil
+}
+
+// Blocks provides blocks according to the filter.
+// Filtering by fee recipient labels is not supported.
+func (s *InMemoryService) Blocks(_ context.Context, filter *chaindb.BlockFilter) ([]*chaindb.Block, error) {
+	if len(filter.FeeRecipientLabels) > 0 {
+		return nil, errors.New("fee recipient labels are not supported")
+	}
+	var graffitiRegex *regexp.Regexp
+	if filter.GraffitiRegex != nil {
+		var err error
+		graffitiRegex, err = regexp.CompilePOSIX(*filter.GraffitiRegex)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid graffiti regular expression")
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	blocks := make([]*chaindb.Block, 0)
+	for _, block := range s.blocks {
+		if filter.From != nil && block.Slot < *filter.From {
+			continue
+		}
+		if filter.To != nil && block.Slot > *filter.To {
+			continue
+		}
+		if filter.Canonical != nil && (block.Canonical == nil || *block.Canonical != *filter.Canonical) {
+			continue
+		}
+		if len(filter.ProposerIndices) > 0 && !containsIndex(filter.ProposerIndices, block.ProposerIndex) {
+			continue
+		}
+		graffiti := string(bytes.Trim(block.Graffiti, "\x00"))
+		if filter.GraffitiContains != nil && !strings.Contains(strings.ToLower(graffiti), strings.ToLower(*filter.GraffitiContains)) {
+			continue
+		}
+		if graffitiRegex != nil && !graffitiRegex.MatchString(graffiti) {
+			continue
+		}
+		if filter.Cursor != nil {
+			cmp := compareBlockPosition(block.Slot, block.Root, filter.Cursor.Slot, filter.Cursor.Root)
+			if (filter.Order == chaindb.OrderLatest && cmp >= 0) || (filter.Order != chaindb.OrderLatest && cmp <= 0) {
+				continue
+			}
+		}
+		blocks = append(blocks, block)
+	}
+
+	sortBlocks(blocks)
+
+	return limit(blocks, filter.Limit, filter.Order)
+}
+
+// BlocksStream provides blocks according to the filter one at a time.
+func (s *InMemoryService) BlocksStream(ctx context.Context,
+	filter *chaindb.BlockFilter,
+) (
+	chaindb.Iterator[*chaindb.Block],
+	error,
+) {
+	blocks, err := s.Blocks(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Order == chaindb.OrderLatest {
+		reverse(blocks)
+	}
+
+	return &sliceIterator[*chaindb.Block]{items: blocks}, nil
+}
+
+// BlocksBySlot fetches all blocks with the given slot.
+func (s *InMemoryService) BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*chaindb.Block, error) {
+	return s.BlocksForSlotRange(ctx, slot, slot+1)
+}
+
+// BlocksForSlotRange fetches all blocks with the given slot range.
+// Ranges are inclusive of start and exclusive of end.
+func (s *InMemoryService) BlocksForSlotRange(_ context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	[]*chaindb.Block,
+	error,
+) {
+	return s.blocksMatching(func(block *chaindb.Block) bool {
+		return block.Slot >= startSlot && block.Slot < endSlot
+	}), nil
+}
+
+// BlockByRoot fetches the block with the given root.
+func (s *InMemoryService) BlockByRoot(_ context.Context, root phase0.Root) (*chaindb.Block, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	block, exists := s.blocks[root]
+	if !exists {
+		return nil, pgx.ErrNoRows
+	}
+
+	return block, nil
+}
+
+// BlocksByParentRoot fetches the blocks with the given parent root.
+func (s *InMemoryService) BlocksByParentRoot(_ context.Context, root phase0.Root) ([]*chaindb.Block, error) {
+	return s.blocksMatching(func(block *chaindb.Block) bool {
+		return block.ParentRoot == root
+	}), nil
+}
+
+// EmptySlots fetches the slots in the given range without a block in the database.
+func (s *InMemoryService) EmptySlots(_ context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	present := make(map[phase0.Slot]bool)
+	for _, block := range s.blocks {
+		present[block.Slot] = true
+	}
+
+	slots := make([]phase0.Slot, 0)
+	for slot := minSlot; slot <= maxSlot; slot++ {
+		if !present[slot] {
+			slots = append(slots, slot)
+		}
+	}
+
+	return slots, nil
+}
+
+// LatestBlocks fetches the blocks with the highest slot number in the database.
+func (s *InMemoryService) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
+	s.mu.RLock()
+	found := false
+	latest := phase0.Slot(0)
+	for _, block := range s.blocks {
+		if !found || block.Slot > latest {
+			found = true
+			latest = block.Slot
+		}
+	}
+	s.mu.RUnlock()
+
+	if !found {
+		return []*chaindb.Block{}, nil
+	}
+
+	return s.BlocksBySlot(ctx, latest)
+}
+
+// IndeterminateBlocks fetches the roots of blocks in the given slot range that do not have a canonical status.
+func (s *InMemoryService) IndeterminateBlocks(_ context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Root, error) {
+	blocks := s.blocksMatching(func(block *chaindb.Block) bool {
+		return block.Slot >= minSlot && block.Slot < maxSlot && block.Canonical == nil
+	})
+
+	roots := make([]phase0.Root, len(blocks))
+	for i := range blocks {
+		roots[i] = blocks[i].Root
+	}
+
+	return roots, nil
+}
+
+// ChainHeaders provides the headers of the chain of blocks held in the database that ends
+// with the block with the given root, back to and including minSlot, in slot order.
+func (s *InMemoryService) ChainHeaders(_ context.Context, root phase0.Root, minSlot phase0.Slot) ([]*chaindb.BlockHeader, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	headers := make([]*chaindb.BlockHeader, 0)
+	for {
+		block, exists := s.blocks[root]
+		if !exists || block.Slot < minSlot {
+			break
+		}
+		headers = append(headers, &chaindb.BlockHeader{
+			Slot:          block.Slot,
+			ProposerIndex: block.ProposerIndex,
+			Root:          block.Root,
+			ParentRoot:    block.ParentRoot,
+			StateRoot:     block.StateRoot,
+			BodyRoot:      block.BodyRoot,
+		})
+		if block.Slot == 0 {
+			break
+		}
+		root = block.ParentRoot
+	}
+
+	// Headers were obtained from the end of the chain, so reverse them.
+	for i, j := 0, len(headers)-1; i < j; i, j = i+1, j-1 {
+		headers[i], headers[j] = headers[j], headers[i]
+	}
+
+	return headers, nil
+}
+
+// SetBlocksCanonical marks the blocks with the given roots as canonical.
+func (s *InMemoryService) SetBlocksCanonical(_ context.Context, roots []phase0.Root) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	canonical := true
+	for _, root := range roots {
+		if block, exists := s.blocks[root]; exists {
+			block.Canonical = &canonical
+		}
+	}
+
+	return nil
+}
+
+// SetIndeterminateBlocksCanonical sets the canonical state of blocks from minSlot up to but
+// not including maxSlot that do not have one.
+func (s *InMemoryService) SetIndeterminateBlocksCanonical(_ context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Decide all states before applying them, as per a single update in the database.
+	states := make(map[phase0.Root]bool)
+	for root, block := range s.blocks {
+		if block.Slot < minSlot || block.Slot >= maxSlot || block.Canonical != nil {
+			continue
+		}
+		states[root] = false
+		for _, child := range s.blocks {
+			if child.ParentRoot == root && child.Canonical != nil && *child.Canonical {
+				states[root] = true
+				break
+			}
+		}
+	}
+	for root, canonical := range states {
+		state := canonical
+		s.blocks[root].Canonical = &state
+	}
+
+	return nil
+}
+
+// CanonicalBlockPresenceForSlotRange returns a boolean for each slot in the range for the presence
+// of a canonical block.
+// Ranges are inclusive of start and exclusive of end.
+func (s *InMemoryService) CanonicalBlockPresenceForSlotRange(_ context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	[]bool,
+	error,
+) {
+	if endSlot < startSlot {
+		return []bool{}, nil
+	}
+
+	presence := make([]bool, endSlot-startSlot)
+	for _, block := range s.blocksMatching(func(block *chaindb.Block) bool {
+		return block.Slot >= startSlot && block.Slot < endSlot && block.Canonical != nil && *block.Canonical
+	}) {
+		presence[block.Slot-startSlot] = true
+	}
+
+	return presence, nil
+}
+
+// LatestCanonicalBlock returns the slot of the latest canonical block known in the database.
+func (s *InMemoryService) LatestCanonicalBlock(_ context.Context) (phase0.Slot, error) {
+	latest := phase0.Slot(0)
+	for _, block := range s.blocksMatching(func(block *chaindb.Block) bool {
+		return block.Canonical != nil && *block.Canonical
+	}) {
+		if block.Slot > latest {
+			latest = block.Slot
+		}
+	}
+
+	return latest, nil
+}
+
+// blocksMatching returns the blocks that match the supplied function, in order of slot then root.
+func (s *InMemoryService) blocksMatching(match func(block *chaindb.Block) bool) []*chaindb.Block {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	blocks := make([]*chaindb.Block, 0)
+	for _, block := range s.blocks {
+		if match(block) {
+			blocks = append(blocks, block)
+		}
+	}
+	sortBlocks(blocks)
+
+	return blocks
+}
+
+// SetAttestation sets an attestation.
+func (s *InMemoryService) SetAttestation(ctx context.Context, attestation *chaindb.Attestation) error {
+	return s.SetAttestations(ctx, []*chaindb.Attestation{attestation})
+}
+
+// SetAttestations sets multiple attestations.
+func (s *InMemoryService) SetAttestations(_ context.Context, attestations []*chaindb.Attestation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, attestation := range attestations {
+		if attestation == nil {
+			return errors.New("attestation nil")
+		}
+		attestationCopy := *attestation
+		replaced := false
+		for i := range s.attestations {
+			if s.attestations[i].InclusionSlot == attestation.InclusionSlot &&
+				s.attestations[i].InclusionBlockRoot == attestation.InclusionBlockRoot &&
+				s.attestations[i].InclusionIndex == attestation.InclusionIndex {
+				s.attestations[i] = &attestationCopy
+				replaced = true
+
+				break
+			}
+		}
+		if !replaced {
+			s.attestations = append(s.attestations, &attestationCopy)
+		}
+	}
+
+	return nil
+}
+
+// Attestations provides attestations according to the filter.
+func (s *InMemoryService) Attestations(_ context.Context, filter *chaindb.AttestationFilter) ([]*chaindb.Attestation, error) {
+	attestations := s.attestationsMatching(func(attestation *chaindb.Attestation) bool {
+		if filter.ScheduledFrom != nil && attestation.Slot < *filter.ScheduledFrom {
+			return false
+		}
+		if filter.ScheduledTo != nil && attestation.Slot > *filter.ScheduledTo {
+			return false
+		}
+		if filter.From != nil && attestation.InclusionSlot < *filter.From {
+			return false
+		}
+		if filter.To != nil && attestation.InclusionSlot > *filter.To {
+			return false
+		}
+		if len(filter.ValidatorIndices) > 0 && !containsAnyIndex(filter.ValidatorIndices, attestation.AggregationIndices) {
+			return false
+		}
+		if filter.Canonical != nil && (attestation.Canonical == nil || *attestation.Canonical != *filter.Canonical) {
+			return false
+		}
+		if filter.HeadCorrect != nil && (attestation.HeadCorrect == nil || *attestation.HeadCorrect != *filter.HeadCorrect) {
+			return false
+		}
+		if filter.Cursor != nil {
+			cmp := compareAttestationPosition(attestation, filter.Cursor)
+			if (filter.Order == chaindb.OrderLatest && cmp >= 0) || (filter.Order != chaindb.OrderLatest && cmp <= 0) {
+				return false
+			}
+		}
+
+		return true
+	})
+
+	return limit(attestations, filter.Limit, filter.Order)
+}
+
+// AttestationsStream provides attestations according to the filter one at a time.
+func (s *InMemoryService) AttestationsStream(ctx context.Context,
+	filter *chaindb.AttestationFilter,
+) (
+	chaindb.Iterator[*chaindb.Attestation],
+	error,
+) {
+	attestations, err := s.Attestations(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Order == chaindb.OrderLatest {
+		reverse(attestations)
+	}
+
+	return &sliceIterator[*chaindb.Attestation]{items: attestations}, nil
+}
+
+// AttestationsForBlock fetches all attestations made for the given block.
+func (s *InMemoryService) AttestationsForBlock(_ context.Context, blockRoot phase0.Root) ([]*chaindb.Attestation, error) {
+	return s.attestationsMatching(func(attestation *chaindb.Attestation) bool {
+		return attestation.BeaconBlockRoot == blockRoot
+	}), nil
+}
+
+// AttestationsInBlock fetches all attestations contained in the given block.
+func (s *InMemoryService) AttestationsInBlock(_ context.Context, blockRoot phase0.Root) ([]*chaindb.Attestation, error) {
+	return s.attestationsMatching(func(attestation *chaindb.Attestation) bool {
+		return attestation.InclusionBlockRoot == blockRoot
+	}), nil
+}
+
+// AttestationsForSlotRange fetches all attestations made for the given slot range.
+// Ranges are inclusive of start and exclusive of end.
+func (s *InMemoryService) AttestationsForSlotRange(_ context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	[]*chaindb.Attestation,
+	error,
+) {
+	return s.attestationsMatching(func(attestation *chaindb.Attestation) bool {
+		return attestation.Slot >= startSlot && attestation.Slot < endSlot
+	}), nil
+}
+
+// AttestationsInSlotRange fetches all attestations made in the given slot range.
+// Ranges are inclusive of start and exclusive of end.
+func (s *InMemoryService) AttestationsInSlotRange(_ context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	[]*chaindb.Attestation,
+	error,
+) {
+	return s.attestationsMatching(func(attestation *chaindb.Attestation) bool {
+		return attestation.InclusionSlot >= startSlot && attestation.InclusionSlot < endSlot
+	}), nil
+}
+
+// AttestationsForValidator fetches all attestations that include the given validator for the given epoch range.
+// Ranges are inclusive of start and exclusive of end.
+func (s *InMemoryService) AttestationsForValidator(ctx context.Context,
+	index phase0.ValidatorIndex,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	[]*chaindb.Attestation,
+	error,
+) {
+	val, err := s.ChainSpecValue(ctx, "SLOTS_PER_EPOCH")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain SLOTS_PER_EPOCH")
+	}
+	slotsPerEpoch, isUint64 := val.(uint64)
+	if !isUint64 {
+		return nil, errors.New("SLOTS_PER_EPOCH of unexpected type")
+	}
+	startSlot := phase0.Slot(uint64(startEpoch) * slotsPerEpoch)
+	endSlot := phase0.Slot(uint64(endEpoch) * slotsPerEpoch)
+
+	return s.attestationsMatching(func(attestation *chaindb.Attestation) bool {
+		if attestation.Slot < startSlot || attestation.Slot >= endSlot {
+			return false
+		}
+		for _, attester := range attestation.AggregationIndices {
+			if attester == index {
+				return true
+			}
+		}
+
+		return false
+	}), nil
+}
+
+// IndeterminateAttestationSlots fetches the slots in the given range with attestations that do not have a canonical status.
+func (s *InMemoryService) IndeterminateAttestationSlots(_ context.Context,
+	minSlot phase0.Slot,
+	maxSlot phase0.Slot,
+) (
+	[]phase0.Slot,
+	error,
+) {
+	slots := make([]phase0.Slot, 0)
+	present := make(map[phase0.Slot]bool)
+	for _, attestation := range s.attestationsMatching(func(attestation *chaindb.Attestation) bool {
+		return attestation.Slot >= minSlot && attestation.Slot < maxSlot && attestation.Canonical == nil
+	}) {
+		if !present[attestation.Slot] {
+			present[attestation.Slot] = true
+			slots = append(slots, attestation.Slot)
+		}
+	}
+	sort.Slice(slots, func(i int, j int) bool {
+		return slots[i] < slots[j]
+	})
+
+	return slots, nil
+}
+
+// attestationsMatching returns the attestations that match the supplied function,
+// in order of inclusion slot, inclusion block root then inclusion index.
+func (s *InMemoryService) attestationsMatching(match func(attestation *chaindb.Attestation) bool) []*chaindb.Attestation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	attestations := make([]*chaindb.Attestation, 0)
+	for _, attestation := range s.attestations {
+		if match(attestation) {
+			attestations = append(attestations, attestation)
+		}
+	}
+	sort.Slice(attestations, func(i int, j int) bool {
+		return compareAttestationPosition(attestations[i], &chaindb.AttestationCursor{
+			InclusionSlot:      attestations[j].InclusionSlot,
+			InclusionBlockRoot: attestations[j].InclusionBlockRoot,
+			InclusionIndex:     attestations[j].InclusionIndex,
+		}) < 0
+	})
+
+	return attestations
+}
+
+// SetValidator sets a validator.
+func (s *InMemoryService) SetValidator(_ context.Context, validator *chaindb.Validator) error {
+	if validator == nil {
+		return errors.New("validator nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	validatorCopy := *validator
+	s.validators[validator.Index] = &validatorCopy
+
+	return nil
+}
+
+// SetValidatorBalance sets a validator balance.
+func (s *InMemoryService) SetValidatorBalance(ctx context.Context, balance *chaindb.ValidatorBalance) error {
+	return s.SetValidatorBalances(ctx, []*chaindb.ValidatorBalance{balance})
+}
+
+// SetValidatorBalances sets multiple validator balances.
+func (s *InMemoryService) SetValidatorBalances(_ context.Context, balances []*chaindb.ValidatorBalance) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, balance := range balances {
+		if balance == nil {
+			return errors.New("validator balance nil")
+		}
+		if _, exists := s.balances[balance.Epoch]; !exists {
+			s.balances[balance.Epoch] = make(map[phase0.ValidatorIndex]*chaindb.ValidatorBalance)
+		}
+		balanceCopy := *balance
+		s.balances[balance.Epoch][balance.Index] = &balanceCopy
+	}
+
+	return nil
+}
+
+// Validators fetches all validators.
+func (s *InMemoryService) Validators(_ context.Context) ([]*chaindb.Validator, error) {
+	return s.validatorsMatching(func(_ *chaindb.Validator) bool { return true }), nil
+}
+
+// ValidatorsByFilter fetches validators according to the filter.
+func (s *InMemoryService) ValidatorsByFilter(_ context.Context, filter *chaindb.ValidatorFilter) ([]*chaindb.Validator, error) {
+	validators := s.validatorsMatching(func(validator *chaindb.Validator) bool {
+		if filter.Cursor == nil {
+			return true
+		}
+		if filter.Order == chaindb.OrderLatest {
+			return validator.Index < *filter.Cursor
+		}
+
+		return validator.Index > *filter.Cursor
+	})
+
+	return limit(validators, filter.Limit, filter.Order)
+}
+
+// ValidatorsStream provides validators according to the filter one at a time.
+func (s *InMemoryService) ValidatorsStream(ctx context.Context,
+	filter *chaindb.ValidatorFilter,
+) (
+	chaindb.Iterator[*chaindb.Validator],
+	error,
+) {
+	validators, err := s.ValidatorsByFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Order == chaindb.OrderLatest {
+		reverse(validators)
+	}
+
+	return &sliceIterator[*chaindb.Validator]{items: validators}, nil
+}
+
+// ValidatorsByPublicKey fetches all validators matching the given public keys.
+func (s *InMemoryService) ValidatorsByPublicKey(_ context.Context,
+	pubKeys []phase0.BLSPubKey,
+) (
+	map[phase0.BLSPubKey]*chaindb.Validator,
+	error,
+) {
+	wanted := make(map[phase0.BLSPubKey]bool, len(pubKeys))
+	for _, pubKey := range pubKeys {
+		wanted[pubKey] = true
+	}
+
+	res := make(map[phase0.BLSPubKey]*chaindb.Validator)
+	for _, validator := range s.validatorsMatching(func(validator *chaindb.Validator) bool {
+		return wanted[validator.PublicKey]
+	}) {
+		res[validator.PublicKey] = validator
+	}
+
+	return res, nil
+}
+
+// ValidatorsByIndex fetches all validators matching the given indices.
+func (s *InMemoryService) ValidatorsByIndex(_ context.Context,
+	indices []phase0.ValidatorIndex,
+) (
+	map[phase0.ValidatorIndex]*chaindb.Validator,
+	error,
+) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make(map[phase0.ValidatorIndex]*chaindb.Validator)
+	for _, index := range indices {
+		if validator, exists := s.validators[index]; exists {
+			res[index] = validator
+		}
+	}
+
+	return res, nil
+}
+
+// ValidatorBalancesByEpoch fetches all validator balances for the given epoch.
+func (s *InMemoryService) ValidatorBalancesByEpoch(_ context.Context,
+	epoch phase0.Epoch,
+) (
+	[]*chaindb.ValidatorBalance,
+	error,
+) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	balances := make([]*chaindb.ValidatorBalance, 0, len(s.balances[epoch]))
+	for _, balance := range s.balances[epoch] {
+		balances = append(balances, balance)
+	}
+	sort.Slice(balances, func(i int, j int) bool {
+		return balances[i].Index < balances[j].Index
+	})
+
+	return balances, nil
+}
+
+// ValidatorBalancesByIndexAndEpoch fetches the validator balances for the given validators and epoch.
+func (s *InMemoryService) ValidatorBalancesByIndexAndEpoch(_ context.Context,
+	indices []phase0.ValidatorIndex,
+	epoch phase0.Epoch,
+) (
+	map[phase0.ValidatorIndex]*chaindb.ValidatorBalance,
+	error,
+) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make(map[phase0.ValidatorIndex]*chaindb.ValidatorBalance)
+	for _, index := range indices {
+		if balance, exists := s.balances[epoch][index]; exists {
+			res[index] = balance
+		}
+	}
+
+	return res, nil
+}
+
+// ValidatorBalancesByIndexAndEpochRange fetches the validator balances for the given validators and epoch range.
+// Ranges are inclusive of start and exclusive of end.
+func (s *InMemoryService) ValidatorBalancesByIndexAndEpochRange(ctx context.Context,
+	indices []phase0.ValidatorIndex,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance,
+	error,
+) {
+	epochs := make([]phase0.Epoch, 0)
+	for epoch := startEpoch; epoch < endEpoch; epoch++ {
+		epochs = append(epochs, epoch)
+	}
+
+	return s.ValidatorBalancesByIndexAndEpochs(ctx, indices, epochs)
+}
+
+// ValidatorBalancesByIndexAndEpochs fetches the validator balances for the given validators at the specified epochs.
+func (s *InMemoryService) ValidatorBalancesByIndexAndEpochs(_ context.Context,
+	indices []phase0.ValidatorIndex,
+	epochs []phase0.Epoch,
+) (
+	map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance,
+	error,
+) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make(map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance)
+	for _, index := range indices {
+		for _, epoch := range epochs {
+			if balance, exists := s.balances[epoch][index]; exists {
+				res[index] = append(res[index], balance)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// validatorsMatching returns the validators that match the supplied function, in order of index.
+func (s *InMemoryService) validatorsMatching(match func(validator *chaindb.Validator) bool) []*chaindb.Validator {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	validators := make([]*chaindb.Validator, 0)
+	for _, validator := range s.validators {
+		if match(validator) {
+			validators = append(validators, validator)
+		}
+	}
+	sort.Slice(validators, func(i int, j int) bool {
+		return validators[i].Index < validators[j].Index
+	})
+
+	return validators
+}
+
+// SetBeaconCommittee sets a beacon committee.
+func (s *InMemoryService) SetBeaconCommittee(_ context.Context, beaconCommittee *chaindb.BeaconCommittee) error {
+	if beaconCommittee == nil {
+		return errors.New("beacon committee nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.beaconCommittees[beaconCommittee.Slot]; !exists {
+		s.beaconCommittees[beaconCommittee.Slot] = make(map[phase0.CommitteeIndex]*chaindb.BeaconCommittee)
+	}
+	beaconCommitteeCopy := *beaconCommittee
+	s.beaconCommittees[beaconCommittee.Slot][beaconCommittee.Index] = &beaconCommitteeCopy
+
+	return nil
+}
+
+// BeaconCommitteeBySlotAndIndex fetches the beacon committee with the given slot and index.
+func (s *InMemoryService) BeaconCommitteeBySlotAndIndex(_ context.Context,
+	slot phase0.Slot,
+	index phase0.CommitteeIndex,
+) (
+	*chaindb.BeaconCommittee,
+	error,
+) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	beaconCommittee, exists := s.beaconCommittees[slot][index]
+	if !exists {
+		return nil, pgx.ErrNoRows
+	}
+
+	return beaconCommittee, nil
+}
+
+// BeaconCommittees fetches the beacon committees matching the filter.
+func (s *InMemoryService) BeaconCommittees(_ context.Context,
+	filter *chaindb.BeaconCommitteeFilter,
+) (
+	[]*chaindb.BeaconCommittee,
+	error,
+) {
+	s.mu.RLock()
+	beaconCommittees := make([]*chaindb.BeaconCommittee, 0)
+	for slot, committees := range s.beaconCommittees {
+		if filter.From != nil && slot < *filter.From {
+			continue
+		}
+		if filter.To != nil && slot > *filter.To {
+			continue
+		}
+		for index, committee := range committees {
+			if len(filter.CommitteeIndices) > 0 && !containsCommitteeIndex(filter.CommitteeIndices, index) {
+				continue
+			}
+			if len(filter.ValidatorIndices) > 0 && !containsAnyIndex(filter.ValidatorIndices, committee.Committee) {
+				continue
+			}
+			beaconCommittees = append(beaconCommittees, committee)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(beaconCommittees, func(i int, j int) bool {
+		if beaconCommittees[i].Slot != beaconCommittees[j].Slot {
+			return beaconCommittees[i].Slot < beaconCommittees[j].Slot
+		}
+
+		return beaconCommittees[i].Index < beaconCommittees[j].Index
+	})
+
+	return limit(beaconCommittees, filter.Limit, filter.Order)
+}
+
+// AttesterDuties fetches the attester duties at the given slot range for the given validator indices.
+// Ranges are inclusive of start and exclusive of end.
+func (s *InMemoryService) AttesterDuties(_ context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+	validatorIndices []phase0.ValidatorIndex,
+) (
+	[]*chaindb.AttesterDuty,
+	error,
+) {
+	wanted := make(map[phase0.ValidatorIndex]bool, len(validatorIndices))
+	for _, index := range validatorIndices {
+		wanted[index] = true
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	duties := make([]*chaindb.AttesterDuty, 0)
+	for slot := startSlot; slot < endSlot; slot++ {
+		for _, committee := range s.beaconCommittees[slot] {
+			for position, index := range committee.Committee {
+				if wanted[index] {
+					duties = append(duties, &chaindb.AttesterDuty{
+						Slot:           slot,
+						Committee:      committee.Index,
+						ValidatorIndex: index,
+						CommitteeIndex: uint64(position),
+					})
+				}
+			}
+		}
+	}
+	sort.Slice(duties, func(i int, j int) bool {
+		if duties[i].Slot != duties[j].Slot {
+			return duties[i].Slot < duties[j].Slot
+		}
+		if duties[i].Committee != duties[j].Committee {
+			return duties[i].Committee < duties[j].Committee
+		}
+
+		return duties[i].CommitteeIndex < duties[j].CommitteeIndex
+	})
+
+	return duties, nil
+}
+
+// SetProposerDuty sets a proposer duty.
+func (s *InMemoryService) SetProposerDuty(_ context.Context, proposerDuty *chaindb.ProposerDuty) error {
+	if proposerDuty == nil {
+		return errors.New("proposer duty nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	proposerDutyCopy := *proposerDuty
+	s.proposerDuties[proposerDuty.Slot] = &proposerDutyCopy
+
+	return nil
+}
+
+// ProposerDuties provides proposer duties according to the filter.
+func (s *InMemoryService) ProposerDuties(_ context.Context, filter *chaindb.ProposerDutyFilter) ([]*chaindb.ProposerDuty, error) {
+	validators := make(map[phase0.ValidatorIndex]bool, len(filter.ValidatorIndices))
+	for _, index := range filter.ValidatorIndices {
+		validators[index] = true
+	}
+
+	duties := s.proposerDutiesMatching(func(duty *chaindb.ProposerDuty) bool {
+		if filter.From != nil && duty.Slot < *filter.From {
+			return false
+		}
+		if filter.To != nil && duty.Slot > *filter.To {
+			return false
+		}
+		if len(validators) > 0 && !validators[duty.ValidatorIndex] {
+			return false
+		}
+
+		return true
+	})
+
+	return limit(duties, filter.Limit, filter.Order)
+}
+
+// ProposerDutiesForSlotRange fetches all proposer duties for the given slot range.
+// Ranges are inclusive of start and exclusive of end.
+func (s *InMemoryService) ProposerDutiesForSlotRange(_ context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	[]*chaindb.ProposerDuty,
+	error,
+) {
+	return s.proposerDutiesMatching(func(duty *chaindb.ProposerDuty) bool {
+		return duty.Slot >= startSlot && duty.Slot < endSlot
+	}), nil
+}
+
+// ProposerDutiesForValidator provides all proposer duties for the given validator index.
+func (s *InMemoryService) ProposerDutiesForValidator(_ context.Context,
+	proposer phase0.ValidatorIndex,
+) (
+	[]*chaindb.ProposerDuty,
+	error,
+) {
+	return s.proposerDutiesMatching(func(duty *chaindb.ProposerDuty) bool {
+		return duty.ValidatorIndex == proposer
+	}), nil
+}
+
+// proposerDutiesMatching returns the proposer duties that match the supplied function, in order of slot.
+func (s *InMemoryService) proposerDutiesMatching(match func(duty *chaindb.ProposerDuty) bool) []*chaindb.ProposerDuty {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	duties := make([]*chaindb.ProposerDuty, 0)
+	for _, duty := range s.proposerDuties {
+		if match(duty) {
+			duties = append(duties, duty)
+		}
+	}
+	sort.Slice(duties, func(i int, j int) bool {
+		return duties[i].Slot < duties[j].Slot
+	})
+
+	return duties
+}
+
+// SetDeposit sets a deposit.
+func (s *InMemoryService) SetDeposit(_ context.Context, deposit *chaindb.Deposit) error {
+	if deposit == nil {
+		return errors.New("deposit nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	depositCopy := *deposit
+	for i := range s.deposits {
+		if s.deposits[i].InclusionSlot == deposit.InclusionSlot &&
+			s.deposits[i].InclusionBlockRoot == deposit.InclusionBlockRoot &&
+			s.deposits[i].InclusionIndex == deposit.InclusionIndex {
+			s.deposits[i] = &depositCopy
+			return nil
+		}
+	}
+	s.deposits = append(s.deposits, &depositCopy)
+
+	return nil
+}
+
+// Deposits provides deposits according to the filter.
+// The canonical state of a deposit is that of the block that includes it.
+func (s *InMemoryService) Deposits(_ context.Context, filter *chaindb.DepositFilter) ([]*chaindb.Deposit, error) {
+	deposits := s.depositsMatching(func(deposit *chaindb.Deposit, canonical *bool) bool {
+		if filter.From != nil && deposit.InclusionSlot < *filter.From {
+			return false
+		}
+		if filter.To != nil && deposit.InclusionSlot > *filter.To {
+			return false
+		}
+		if len(filter.PublicKeys) > 0 && !containsPubKey(filter.PublicKeys, deposit.ValidatorPubKey) {
+			return false
+		}
+		if filter.Canonical != nil && (canonical == nil || *canonical != *filter.Canonical) {
+			return false
+		}
+		if filter.ExcludeNonCanonical && canonical != nil && !*canonical {
+			return false
+		}
+
+		return true
+	})
+
+	return limit(deposits, filter.Limit, filter.Order)
+}
+
+// DepositsByPublicKey fetches deposits for the given validator public keys.
+func (s *InMemoryService) DepositsByPublicKey(_ context.Context,
+	pubKeys []phase0.BLSPubKey,
+) (
+	map[phase0.BLSPubKey][]*chaindb.Deposit,
+	error,
+) {
+	res := make(map[phase0.BLSPubKey][]*chaindb.Deposit)
+	for _, deposit := range s.depositsMatching(func(deposit *chaindb.Deposit, _ *bool) bool {
+		return containsPubKey(pubKeys, deposit.ValidatorPubKey)
+	}) {
+		res[deposit.ValidatorPubKey] = append(res[deposit.ValidatorPubKey], deposit)
+	}
+
+	return res, nil
+}
+
+// DepositsForSlotRange fetches all deposits made in the given slot range.
+// Ranges are inclusive of start and exclusive of end.
+func (s *InMemoryService) DepositsForSlotRange(_ context.Context,
+	minSlot phase0.Slot,
+	maxSlot phase0.Slot,
+) (
+	[]*chaindb.Deposit,
+	error,
+) {
+	return s.depositsMatching(func(deposit *chaindb.Deposit, _ *bool) bool {
+		return deposit.InclusionSlot >= minSlot && deposit.InclusionSlot < maxSlot
+	}), nil
+}
+
+// depositsMatching returns the deposits that match the supplied function, given the canonical
+// state of their inclusion block, in order of inclusion slot and index.
+func (s *InMemoryService) depositsMatching(match func(deposit *chaindb.Deposit, canonical *bool) bool) []*chaindb.Deposit {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deposits := make([]*chaindb.Deposit, 0)
+	for _, deposit := range s.deposits {
+		if match(deposit, s.blockCanonical(deposit.InclusionBlockRoot)) {
+			deposits = append(deposits, deposit)
+		}
+	}
+	sort.Slice(deposits, func(i int, j int) bool {
+		if deposits[i].InclusionSlot != deposits[j].InclusionSlot {
+			return deposits[i].InclusionSlot < deposits[j].InclusionSlot
+		}
+		return deposits[i].InclusionIndex < deposits[j].InclusionIndex
+	})
+
+	return deposits
+}
+
+// Withdrawals provides withdrawals according to the filter.
+// Withdrawals are those in the execution payloads of stored blocks, and their canonical
+// state is that of the block.  Filtering by address labels is not supported.
+func (s *InMemoryService) Withdrawals(_ context.Context, filter *chaindb.WithdrawalFilter) ([]*chaindb.Withdrawal, error) {
+	if len(filter.AddressLabels) > 0 {
+		return nil, errors.New("address labels are not supported")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	withdrawals := make([]*chaindb.Withdrawal, 0)
+	for _, block := range s.blocks {
+		if block.ExecutionPayload == nil {
+			continue
+		}
+		if filter.Canonical != nil && (block.Canonical == nil || *block.Canonical != *filter.Canonical) {
+			continue
+		}
+		for _, withdrawal := range block.ExecutionPayload.Withdrawals {
+			if filter.From != nil && withdrawal.InclusionSlot < *filter.From {
+				continue
+			}
+			if filter.To != nil && withdrawal.InclusionSlot > *filter.To {
+				continue
+			}
+			if len(filter.ValidatorIndices) > 0 && !containsIndex(filter.ValidatorIndices, withdrawal.ValidatorIndex) {
+				continue
+			}
+			withdrawals = append(withdrawals, withdrawal)
+		}
+	}
+	sort.Slice(withdrawals, func(i int, j int) bool {
+		if withdrawals[i].InclusionSlot != withdrawals[j].InclusionSlot {
+			return withdrawals[i].InclusionSlot < withdrawals[j].InclusionSlot
+		}
+		return withdrawals[i].InclusionIndex < withdrawals[j].InclusionIndex
+	})
+
+	return limit(withdrawals, filter.Limit, filter.Order)
+}
+
+// blockCanonical returns the canonical state of the block with the given root, or nil
+// if the block is not held or its state is not yet known.
+// The caller must hold the lock.
+func (s *InMemoryService) blockCanonical(root phase0.Root) *bool {
+	block, exists := s.blocks[root]
+	if !exists {
+		return nil
+	}
+
+	return block.Canonical
+}
+
+// sliceIterator is an iterator over a slice of items.
+type sliceIterator[T any] struct {
+	items   []T
+	current T
+}
+
+// Next advances the iterator to the next item.
+func (i *sliceIterator[T]) Next(_ context.Context) bool {
+	if len(i.items) == 0 {
+		return false
+	}
+	i.current = i.items[0]
+	i.items = i.items[1:]
+
+	return true
+}
+
+// Value returns the item at the current position of the iterator.
+func (i *sliceIterator[T]) Value() T {
+	return i.current
+}
+
+// Err returns the error, if any, that caused Next to return false.
+func (*sliceIterator[T]) Err() error {
+	return nil
+}
+
+// Close releases any resources held by the iterator.
+func (i *sliceIterator[T]) Close() {
+	i.items = nil
+}
+
+// limit applies the limit of a filter to items sorted in ascending order, keeping
+// the earliest items for OrderEarliest and the latest items for OrderLatest.
+func limit[T any](items []T, limit uint32, order chaindb.Order) ([]T, error) {
+	if order != chaindb.OrderEarliest && order != chaindb.OrderLatest {
+		return nil, errors.New("no order specified")
+	}
+	if limit == 0 || uint32(len(items)) <= limit {
+		return items, nil
+	}
+	if order == chaindb.OrderLatest {
+		return items[uint32(len(items))-limit:], nil
+	}
+
+	return items[:limit], nil
+}
+
+// reverse reverses items in place.
+func reverse[T any](items []T) {
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+}
+
+// sortBlocks sorts blocks in order of slot then root.
+func sortBlocks(blocks []*chaindb.Block) {
+	sort.Slice(blocks, func(i int, j int) bool {
+		return compareBlockPosition(blocks[i].Slot, blocks[i].Root, blocks[j].Slot, blocks[j].Root) < 0
+	})
+}
+
+// compareBlockPosition compares the position of two blocks in order of slot then root.
+func compareBlockPosition(slot1 phase0.Slot, root1 phase0.Root, slot2 phase0.Slot, root2 phase0.Root) int {
+	switch {
+	case slot1 < slot2:
+		return -1
+	case slot1 > slot2:
+		return 1
+	default:
+		return bytes.Compare(root1[:], root2[:])
+	}
+}
+
+// compareAttestationPosition compares the position of an attestation with a cursor,
+// in order of inclusion slot, inclusion block root then inclusion index.
+func compareAttestationPosition(attestation *chaindb.Attestation, cursor *chaindb.AttestationCursor) int {
+	if attestation.InclusionSlot != cursor.InclusionSlot {
+		if attestation.InclusionSlot < cursor.InclusionSlot {
+			return -1
+		}
+
+		return 1
+	}
+	if cmp := bytes.Compare(attestation.InclusionBlockRoot[:], cursor.InclusionBlockRoot[:]); cmp != 0 {
+		return cmp
+	}
+	switch {
+	case attestation.InclusionIndex < cursor.InclusionIndex:
+		return -1
+	case attestation.InclusionIndex > cursor.InclusionIndex:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// containsIndex returns true if the index is present in the indices.
+func containsIndex(indices []phase0.ValidatorIndex, index phase0.ValidatorIndex) bool {
+	for i := range indices {
+		if indices[i] == index {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsPubKey returns true if the public key is present in the public keys.
+func containsPubKey(pubKeys []phase0.BLSPubKey, pubKey phase0.BLSPubKey) bool {
+	for i := range pubKeys {
+		if pubKeys[i] == pubKey {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsAnyIndex returns true if any of the candidates are present in the indices.
+func containsAnyIndex(indices []phase0.ValidatorIndex, candidates []phase0.ValidatorIndex) bool {
+	for i := range candidates {
+		if containsIndex(indices, candidates[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsCommitteeIndex returns true if the index is present in the indices.
+func containsCommitteeIndex(indices []phase0.CommitteeIndex, index phase0.CommitteeIndex) bool {
+	for i := range indices {
+		if indices[i] == index {
+			return true
+		}
+	}
+
+	return false
+}
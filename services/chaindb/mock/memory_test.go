@@ -0,0 +1,172 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/chaindbtest"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+)
+
+func TestInMemoryConformance(t *testing.T) {
+	chaindbtest.Run(t, func(t *testing.T) chaindb.Service {
+		s, err := mockchaindb.NewInMemory(context.Background(), nil)
+		require.NoError(t, err)
+
+		return s
+	})
+}
+
+func TestInMemoryEmpty(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := mockchaindb.NewInMemory(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = s.Genesis(ctx, &api.GenesisOpts{})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	_, err = s.BlockByRoot(ctx, phase0.Root{})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	blocks, err := s.LatestBlocks(ctx)
+	require.NoError(t, err)
+	require.Empty(t, blocks)
+}
+
+func TestInMemoryFixtures(t *testing.T) {
+	ctx := context.Background()
+
+	fixtures := mockchaindb.DeterministicFixtures(64, 96)
+	require.Equal(t, fixtures, mockchaindb.DeterministicFixtures(64, 96))
+
+	s, err := mockchaindb.NewInMemory(ctx, fixtures)
+	require.NoError(t, err)
+
+	genesis, err := s.Genesis(ctx, &api.GenesisOpts{})
+	require.NoError(t, err)
+	require.Equal(t, fixtures.Genesis, genesis.Data)
+
+	slotsPerEpoch, err := s.ChainSpecValue(ctx, "SLOTS_PER_EPOCH")
+	require.NoError(t, err)
+	require.Equal(t, uint64(32), slotsPerEpoch)
+
+	latestBlocks, err := s.LatestBlocks(ctx)
+	require.NoError(t, err)
+	require.Len(t, latestBlocks, 1)
+	require.Equal(t, phase0.Slot(95), latestBlocks[0].Slot)
+
+	parent, err := s.BlockByRoot(ctx, latestBlocks[0].ParentRoot)
+	require.NoError(t, err)
+	require.Equal(t, phase0.Slot(94), parent.Slot)
+
+	validators, err := s.Validators(ctx)
+	require.NoError(t, err)
+	require.Len(t, validators, 64)
+
+	balances, err := s.ValidatorBalancesByIndexAndEpochRange(ctx, []phase0.ValidatorIndex{1, 2}, 0, 3)
+	require.NoError(t, err)
+	require.Len(t, balances[1], 3)
+
+	attestations, err := s.Attestations(ctx, &chaindb.AttestationFilter{
+		ValidatorIndices: []phase0.ValidatorIndex{33},
+	})
+	require.NoError(t, err)
+	require.Len(t, attestations, 3)
+	for _, attestation := range attestations {
+		require.Contains(t, attestation.AggregationIndices, phase0.ValidatorIndex(33))
+	}
+
+	duties, err := s.AttesterDuties(ctx, 0, 96, []phase0.ValidatorIndex{33})
+	require.NoError(t, err)
+	require.Len(t, duties, 3)
+	require.Equal(t, phase0.Slot(1), duties[0].Slot)
+	require.Equal(t, uint64(1), duties[0].CommitteeIndex)
+}
+
+func TestInMemoryPagination(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := mockchaindb.NewInMemory(ctx, mockchaindb.DeterministicFixtures(8, 10))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		order chaindb.Order
+		slots []phase0.Slot
+	}{
+		{
+			name:  "Earliest",
+			order: chaindb.OrderEarliest,
+			slots: []phase0.Slot{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		},
+		{
+			name:  "Latest",
+			order: chaindb.OrderLatest,
+			slots: []phase0.Slot{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Page through the blocks with a cursor.
+			slots := make([]phase0.Slot, 0)
+			filter := &chaindb.BlockFilter{
+				Limit: 3,
+				Order: test.order,
+			}
+			for {
+				blocks, err := s.Blocks(ctx, filter)
+				require.NoError(t, err)
+				if len(blocks) == 0 {
+					break
+				}
+				boundary := blocks[len(blocks)-1]
+				if test.order == chaindb.OrderLatest {
+					boundary = blocks[0]
+					for i := len(blocks) - 1; i >= 0; i-- {
+						slots = append(slots, blocks[i].Slot)
+					}
+				} else {
+					for _, block := range blocks {
+						slots = append(slots, block.Slot)
+					}
+				}
+				filter.Cursor = &chaindb.BlockCursor{
+					Slot: boundary.Slot,
+					Root: boundary.Root,
+				}
+			}
+			require.Equal(t, test.slots, slots)
+
+			// Stream the blocks.
+			stream, err := s.BlocksStream(ctx, &chaindb.BlockFilter{Order: test.order})
+			require.NoError(t, err)
+			defer stream.Close()
+			slots = make([]phase0.Slot, 0)
+			for stream.Next(ctx) {
+				slots = append(slots, stream.Value().Slot)
+			}
+			require.NoError(t, stream.Err())
+			require.Equal(t, test.slots, slots)
+		})
+	}
+}
@@ -15,20 +15,61 @@ package chaindb
 
 import (
 	"context"
+	"math/big"
 	"time"
 
-	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
 )
 
 type service struct{}
 
+// emptyIterator is an iterator with no items.
+type emptyIterator[T any] struct{}
+
+// Next advances the iterator to the next item.
+func (*emptyIterator[T]) Next(_ context.Context) bool {
+	return false
+}
+
+// Value returns the item at the current position of the iterator.
+func (*emptyIterator[T]) Value() T {
+	var value T
+
+	return value
+}
+
+// Err returns the error, if any, that caused Next to return false.
+func (*emptyIterator[T]) Err() error {
+	return nil
+}
+
+// Close releases any resources held by the iterator.
+func (*emptyIterator[T]) Close() {}
+
 // New creates a new mock chain database.
 func New() chaindb.Service {
 	return &service{}
 }
 
+// Attestations obtains attestations matching the supplied filter.
+func (s *service) Attestations(_ context.Context, _ *chaindb.AttestationFilter) ([]*chaindb.Attestation, error) {
+	return nil, nil
+}
+
+// AttestationsStream provides attestations according to the filter one at a time.
+func (s *service) AttestationsStream(_ context.Context,
+	_ *chaindb.AttestationFilter,
+) (
+	chaindb.Iterator[*chaindb.Attestation],
+	error,
+) {
+	return &emptyIterator[*chaindb.Attestation]{}, nil
+}
+
 // AttestationsForBlock fetches all attestations made for the given block.
 func (s *service) AttestationsForBlock(_ context.Context, _ phase0.Root) ([]*chaindb.Attestation, error) {
 	return nil, nil
@@ -65,6 +106,18 @@ func (s *service) AttestationsInSlotRange(_ context.Context,
 	return nil, nil
 }
 
+// AttestationsForValidator fetches all attestations that include the given validator for the given epoch range.
+func (s *service) AttestationsForValidator(_ context.Context,
+	_ phase0.ValidatorIndex,
+	_ phase0.Epoch,
+	_ phase0.Epoch,
+) (
+	[]*chaindb.Attestation,
+	error,
+) {
+	return nil, nil
+}
+
 // IndeterminateAttestationSlots fetches the slots in the given range with attestations that do not have a canonical status.
 func (s *service) IndeterminateAttestationSlots(_ context.Context,
 	_ phase0.Slot,
@@ -81,6 +134,11 @@ func (s *service) SetAttestation(_ context.Context, _ *chaindb.Attestation) erro
 	return nil
 }
 
+// AttesterSlashings provides attester slashings according to the filter.
+func (s *service) AttesterSlashings(_ context.Context, _ *chaindb.AttesterSlashingFilter) ([]*chaindb.AttesterSlashing, error) {
+	return []*chaindb.AttesterSlashing{}, nil
+}
+
 // AttesterSlashingsForSlotRange fetches all attester slashings made for the given slot range.
 // It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
 func (s *service) AttesterSlashingsForSlotRange(_ context.Context,
@@ -132,6 +190,11 @@ func (s *service) AttesterDuties(_ context.Context,
 	return nil, nil
 }
 
+// BeaconCommitteeMembers fetches the members of the beacon committees matching the filter.
+func (s *service) BeaconCommitteeMembers(_ context.Context, _ *chaindb.BeaconCommitteeFilter) ([]*chaindb.AttesterDuty, error) {
+	return []*chaindb.AttesterDuty{}, nil
+}
+
 // SetBeaconCommittee sets a beacon committee.
 func (s *service) SetBeaconCommittee(_ context.Context, _ *chaindb.BeaconCommittee) error {
 	return nil
@@ -142,6 +205,16 @@ func (s *service) Blocks(_ context.Context, _ *chaindb.BlockFilter) ([]*chaindb.
 	return []*chaindb.Block{}, nil
 }
 
+// BlocksStream provides blocks according to the filter one at a time.
+func (s *service) BlocksStream(_ context.Context,
+	_ *chaindb.BlockFilter,
+) (
+	chaindb.Iterator[*chaindb.Block],
+	error,
+) {
+	return &emptyIterator[*chaindb.Block]{}, nil
+}
+
 // BlocksBySlot fetches all blocks with the given slot.
 func (s *service) BlocksBySlot(_ context.Context, _ phase0.Slot) ([]*chaindb.Block, error) {
 	return nil, nil
@@ -197,14 +270,471 @@ func (s *service) SetBlock(_ context.Context, _ *chaindb.Block) error {
 	return nil
 }
 
+// ChainHeaders provides the headers of a chain of blocks.
+func (s *service) ChainHeaders(_ context.Context, _ phase0.Root, _ phase0.Slot) ([]*chaindb.BlockHeader, error) {
+	return []*chaindb.BlockHeader{}, nil
+}
+
+// SetBlocksCanonical marks blocks as canonical.
+func (s *service) SetBlocksCanonical(_ context.Context, _ []phase0.Root) error {
+	return nil
+}
+
+// SetIndeterminateBlocksCanonical sets the canonical state of indeterminate blocks.
+func (s *service) SetIndeterminateBlocksCanonical(_ context.Context, _ phase0.Slot, _ phase0.Slot) error {
+	return nil
+}
+
+// BlockClientFingerprints provides block client fingerprints according to the filter.
+func (s *service) BlockClientFingerprints(_ context.Context, _ *chaindb.BlockClientFingerprintFilter) ([]*chaindb.BlockClientFingerprint, error) {
+	return []*chaindb.BlockClientFingerprint{}, nil
+}
+
+// SetBlockClientFingerprints sets block client fingerprints.
+func (s *service) SetBlockClientFingerprints(_ context.Context, _ []*chaindb.BlockClientFingerprint) error {
+	return nil
+}
+
+// ClientReleases provides client releases according to the filter.
+func (s *service) ClientReleases(_ context.Context, _ *chaindb.ClientReleaseFilter) ([]*chaindb.ClientRelease, error) {
+	return []*chaindb.ClientRelease{}, nil
+}
+
+// SetClientReleases sets client releases, replacing any existing releases.
+func (s *service) SetClientReleases(_ context.Context, _ []*chaindb.ClientRelease) error {
+	return nil
+}
+
+// BlockClientReleases provides block client releases according to the filter.
+func (s *service) BlockClientReleases(_ context.Context, _ *chaindb.BlockClientReleaseFilter) ([]*chaindb.BlockClientRelease, error) {
+	return []*chaindb.BlockClientRelease{}, nil
+}
+
+// SetBlockClientReleases sets block client releases.
+func (s *service) SetBlockClientReleases(_ context.Context, _ []*chaindb.BlockClientRelease) error {
+	return nil
+}
+
+// Equivocations provides equivocations according to the filter.
+func (s *service) Equivocations(_ context.Context, _ *chaindb.EquivocationFilter) ([]*chaindb.Equivocation, error) {
+	return []*chaindb.Equivocation{}, nil
+}
+
+// SetEquivocations sets equivocations.
+func (s *service) SetEquivocations(_ context.Context, _ []*chaindb.Equivocation) error {
+	return nil
+}
+
+// BlockArrivals provides block arrivals according to the filter.
+func (s *service) BlockArrivals(_ context.Context, _ *chaindb.ArrivalFilter) ([]*chaindb.BlockArrival, error) {
+	return []*chaindb.BlockArrival{}, nil
+}
+
+// AttestationArrivals provides attestation arrivals according to the filter.
+func (s *service) AttestationArrivals(_ context.Context, _ *chaindb.ArrivalFilter) ([]*chaindb.AttestationArrival, error) {
+	return []*chaindb.AttestationArrival{}, nil
+}
+
+// SetBlockArrivals sets block arrivals.
+func (s *service) SetBlockArrivals(_ context.Context, _ []*chaindb.BlockArrival) error {
+	return nil
+}
+
+// SetAttestationArrivals sets attestation arrivals.
+func (s *service) SetAttestationArrivals(_ context.Context, _ []*chaindb.AttestationArrival) error {
+	return nil
+}
+
+// ExportRows obtains the columns of a table, and its rows for the given range of marks.
+func (s *service) ExportRows(_ context.Context,
+	_ string,
+	_ string,
+	_ int64,
+	_ int64,
+	_ uint32,
+) (
+	[]*chaindb.ExportColumn,
+	[][]any,
+	error,
+) {
+	return []*chaindb.ExportColumn{}, [][]any{}, nil
+}
+
+// OutboxCapturableTables provides the tables whose changes can be captured in the outbox.
+func (s *service) OutboxCapturableTables(_ context.Context) ([]string, error) {
+	return []string{}, nil
+}
+
+// OutboxEvents provides up to limit events from the outbox, in the order in which they were written.
+func (s *service) OutboxEvents(_ context.Context, _ uint32) ([]*chaindb.OutboxEvent, error) {
+	return []*chaindb.OutboxEvent{}, nil
+}
+
+// SetOutboxCapture captures changes to the given tables in the outbox.
+func (s *service) SetOutboxCapture(_ context.Context, _ []string) error {
+	return nil
+}
+
+// DeleteOutboxEvents deletes the events with the given IDs from the outbox.
+func (s *service) DeleteOutboxEvents(_ context.Context, _ []uint64) error {
+	return nil
+}
+
+// WatchedValidators provides all validators on the watchlist, in index order.
+func (s *service) WatchedValidators(_ context.Context) ([]*chaindb.WatchedValidator, error) {
+	return []*chaindb.WatchedValidator{}, nil
+}
+
+// WatchlistEvents provides watchlist events according to the filter.
+func (s *service) WatchlistEvents(_ context.Context, _ *chaindb.WatchlistEventFilter) ([]*chaindb.WatchlistEvent, error) {
+	return []*chaindb.WatchlistEvent{}, nil
+}
+
+// WatchlistAlerts provides watchlist alerts according to the filter.
+func (s *service) WatchlistAlerts(_ context.Context, _ *chaindb.WatchlistAlertFilter) ([]*chaindb.WatchlistAlert, error) {
+	return []*chaindb.WatchlistAlert{}, nil
+}
+
+// SetWatchedValidator adds a validator to the watchlist, or updates it if already present.
+func (s *service) SetWatchedValidator(_ context.Context, _ *chaindb.WatchedValidator) error {
+	return nil
+}
+
+// RemoveWatchedValidator removes a validator from the watchlist, along with its events and alerts.
+func (s *service) RemoveWatchedValidator(_ context.Context, _ phase0.ValidatorIndex) error {
+	return nil
+}
+
+// SetWatchlistEvents sets watchlist events.
+func (s *service) SetWatchlistEvents(_ context.Context, _ []*chaindb.WatchlistEvent) error {
+	return nil
+}
+
+// SetWatchlistAlerts sets watchlist alerts.
+func (s *service) SetWatchlistAlerts(_ context.Context, _ []*chaindb.WatchlistAlert) error {
+	return nil
+}
+
+// VerificationDisagreements provides verification disagreements according to the filter.
+func (s *service) VerificationDisagreements(_ context.Context, _ *chaindb.VerificationDisagreementFilter) ([]*chaindb.VerificationDisagreement, error) {
+	return []*chaindb.VerificationDisagreement{}, nil
+}
+
+// SetVerificationDisagreement sets a verification disagreement.
+func (s *service) SetVerificationDisagreement(_ context.Context, _ *chaindb.VerificationDisagreement) error {
+	return nil
+}
+
+// ShufflingMismatches provides shuffling mismatches according to the filter.
+func (s *service) ShufflingMismatches(_ context.Context, _ *chaindb.ShufflingMismatchFilter) ([]*chaindb.ShufflingMismatch, error) {
+	return []*chaindb.ShufflingMismatch{}, nil
+}
+
+// SetShufflingMismatches sets shuffling mismatches.
+func (s *service) SetShufflingMismatches(_ context.Context, _ []*chaindb.ShufflingMismatch) error {
+	return nil
+}
+
+// TransactionReceipts provides transaction receipts according to the filter.
+func (s *service) TransactionReceipts(_ context.Context, _ *chaindb.TransactionReceiptFilter) ([]*chaindb.TransactionReceipt, error) {
+	return []*chaindb.TransactionReceipt{}, nil
+}
+
+// TransactionEvents provides transaction events according to the filter.
+func (s *service) TransactionEvents(_ context.Context, _ *chaindb.TransactionEventFilter) ([]*chaindb.TransactionEvent, error) {
+	return []*chaindb.TransactionEvent{}, nil
+}
+
+// SetTransactionReceipts sets transaction receipts.
+func (s *service) SetTransactionReceipts(_ context.Context, _ []*chaindb.TransactionReceipt) error {
+	return nil
+}
+
+// SetTransactionEvents sets transaction events.
+func (s *service) SetTransactionEvents(_ context.Context, _ []*chaindb.TransactionEvent) error {
+	return nil
+}
+
+// SetExecutionPayloadValues sets the values of execution payloads.
+func (s *service) SetExecutionPayloadValues(_ context.Context, _ []*chaindb.ExecutionPayloadValue) error {
+	return nil
+}
+
+// ETH1Deposits provides Ethereum 1 deposits according to the filter.
+func (s *service) ETH1Deposits(_ context.Context, _ *chaindb.ETH1DepositFilter) ([]*chaindb.ETH1Deposit, error) {
+	return []*chaindb.ETH1Deposit{}, nil
+}
+
+// AddressLabels provides address labels according to the filter.
+func (s *service) AddressLabels(_ context.Context, _ *chaindb.AddressLabelFilter) ([]*chaindb.AddressLabel, error) {
+	return []*chaindb.AddressLabel{}, nil
+}
+
+// SetAddressLabel sets the label for an address.
+func (s *service) SetAddressLabel(_ context.Context, _ *chaindb.AddressLabel) error {
+	return nil
+}
+
+// RemoveAddressLabel removes the label for an address.
+func (s *service) RemoveAddressLabel(_ context.Context, _ [20]byte) error {
+	return nil
+}
+
+// ValidatorSetDiff provides the changes to the validator set after fromEpoch, up to and including toEpoch.
+func (s *service) ValidatorSetDiff(_ context.Context, fromEpoch phase0.Epoch, toEpoch phase0.Epoch) (*chaindb.ValidatorSetDiff, error) {
+	return &chaindb.ValidatorSetDiff{
+		FromEpoch: fromEpoch,
+		ToEpoch:   toEpoch,
+	}, nil
+}
+
+// ValidatorShards provides the progress of all validator shards, ordered by first validator index.
+func (*service) ValidatorShards(_ context.Context) ([]*chaindb.ValidatorShard, error) {
+	return []*chaindb.ValidatorShard{}, nil
+}
+
+// SetValidatorShard sets the progress of a validator shard.
+func (*service) SetValidatorShard(_ context.Context, _ *chaindb.ValidatorShard) error {
+	return nil
+}
+
+// GraffitiFrequencies provides the number of canonical blocks with each graffiti according to the filter.
+func (*service) GraffitiFrequencies(_ context.Context, _ *chaindb.GraffitiFrequencyFilter) ([]*chaindb.GraffitiFrequency, error) {
+	return []*chaindb.GraffitiFrequency{}, nil
+}
+
+// CommitteeEpochSummaries provides committee epoch summaries according to the filter.
+func (*service) CommitteeEpochSummaries(_ context.Context, _ *chaindb.CommitteeEpochSummaryFilter) ([]*chaindb.CommitteeEpochSummary, error) {
+	return []*chaindb.CommitteeEpochSummary{}, nil
+}
+
+// SetCommitteeEpochSummaries sets multiple committee epoch summaries.
+func (*service) SetCommitteeEpochSummaries(_ context.Context, _ []*chaindb.CommitteeEpochSummary) error {
+	return nil
+}
+
+// ValidatorSyncPeriodSummaries provides validator sync period summaries according to the filter.
+func (*service) ValidatorSyncPeriodSummaries(_ context.Context, _ *chaindb.ValidatorSyncPeriodSummaryFilter) ([]*chaindb.ValidatorSyncPeriodSummary, error) {
+	return []*chaindb.ValidatorSyncPeriodSummary{}, nil
+}
+
+// SetValidatorSyncPeriodSummaries sets multiple validator sync period summaries.
+func (*service) SetValidatorSyncPeriodSummaries(_ context.Context, _ []*chaindb.ValidatorSyncPeriodSummary) error {
+	return nil
+}
+
+// QueueProjections provides queue projections according to the filter.
+func (*service) QueueProjections(_ context.Context, _ *chaindb.QueueProjectionFilter) ([]*chaindb.QueueProjection, error) {
+	return []*chaindb.QueueProjection{}, nil
+}
+
+// QueueProjectionAccuracy provides the accuracy of activation projections.
+func (*service) QueueProjectionAccuracy(_ context.Context, _ phase0.Epoch, _ phase0.Epoch, _ phase0.Epoch) ([]*chaindb.QueueProjectionAccuracy, error) {
+	return []*chaindb.QueueProjectionAccuracy{}, nil
+}
+
+// SetQueueProjections sets multiple queue projections.
+func (*service) SetQueueProjections(_ context.Context, _ []*chaindb.QueueProjection) error {
+	return nil
+}
+
+// AuditEntries provides audit log entries according to the filter.
+func (*service) AuditEntries(_ context.Context, _ *chaindb.AuditEntryFilter) ([]*chaindb.AuditEntry, error) {
+	return []*chaindb.AuditEntry{}, nil
+}
+
+// PruneAuditLog prunes audit log entries written before the given time.
+func (*service) PruneAuditLog(_ context.Context, _ time.Time) error {
+	return nil
+}
+
+// WithdrawalForecasts provides withdrawal forecasts according to the filter.
+func (*service) WithdrawalForecasts(_ context.Context, _ *chaindb.WithdrawalForecastFilter) ([]*chaindb.WithdrawalForecast, error) {
+	return []*chaindb.WithdrawalForecast{}, nil
+}
+
+// SetWithdrawalForecasts sets multiple withdrawal forecasts.
+func (*service) SetWithdrawalForecasts(_ context.Context, _ []*chaindb.WithdrawalForecast) error {
+	return nil
+}
+
+// ValidatorAnomalies provides validator anomalies according to the filter.
+func (*service) ValidatorAnomalies(_ context.Context, _ *chaindb.ValidatorAnomalyFilter) ([]*chaindb.ValidatorAnomaly, error) {
+	return []*chaindb.ValidatorAnomaly{}, nil
+}
+
+// BlobFees provides blob fees according to the filter.
+func (*service) BlobFees(_ context.Context, _ *chaindb.BlobFeeFilter) ([]*chaindb.BlobFee, error) {
+	return []*chaindb.BlobFee{}, nil
+}
+
+// SetBlobFees sets multiple blob fees.
+func (*service) SetBlobFees(_ context.Context, _ []*chaindb.BlobFee) error {
+	return nil
+}
+
+// BalanceAnomalies provides balance anomalies according to the filter.
+func (*service) BalanceAnomalies(_ context.Context, _ *chaindb.BalanceAnomalyFilter) ([]*chaindb.BalanceAnomaly, error) {
+	return []*chaindb.BalanceAnomaly{}, nil
+}
+
+// SetBalanceAnomalies sets multiple balance anomalies.
+func (*service) SetBalanceAnomalies(_ context.Context, _ []*chaindb.BalanceAnomaly) error {
+	return nil
+}
+
+// BlockHeaders provides block headers according to the filter.
+func (*service) BlockHeaders(_ context.Context, _ *chaindb.BlockHeaderFilter) ([]*chaindb.BlockHeader, error) {
+	return []*chaindb.BlockHeader{}, nil
+}
+
+// SetBlockHeaders sets multiple block headers.
+func (*service) SetBlockHeaders(_ context.Context, _ []*chaindb.BlockHeader) error {
+	return nil
+}
+
+// ValidatorClusters provides validator clusters according to the filter.
+func (*service) ValidatorClusters(_ context.Context, _ *chaindb.ValidatorClusterFilter) ([]*chaindb.ValidatorCluster, error) {
+	return []*chaindb.ValidatorCluster{}, nil
+}
+
+// ValidatorClusterLinks provides the items that link the given validators to their clusters.
+func (*service) ValidatorClusterLinks(_ context.Context, _ []phase0.ValidatorIndex) ([]*chaindb.ValidatorClusterLink, error) {
+	return []*chaindb.ValidatorClusterLink{}, nil
+}
+
+// ValidatorClusterSizes provides the distribution of cluster sizes.
+func (*service) ValidatorClusterSizes(_ context.Context) ([]*chaindb.ValidatorClusterSize, error) {
+	return []*chaindb.ValidatorClusterSize{}, nil
+}
+
+// SetValidatorClusterLinks sets validator cluster links.
+func (*service) SetValidatorClusterLinks(_ context.Context, _ []*chaindb.ValidatorClusterLink) error {
+	return nil
+}
+
+// FailedItems provides failed items according to the filter.
+func (*service) FailedItems(_ context.Context, _ *chaindb.FailedItemFilter) ([]*chaindb.FailedItem, error) {
+	return []*chaindb.FailedItem{}, nil
+}
+
+// RecordFailedItem records a failure to process an item.
+func (*service) RecordFailedItem(_ context.Context, item *chaindb.FailedItem, _ uint32) (*chaindb.FailedItem, error) {
+	return item, nil
+}
+
+// SetFailedItemState sets the state of a failed item.
+func (*service) SetFailedItemState(_ context.Context, _ string, _ string, _ uint64, _ string) error {
+	return nil
+}
+
+// RemoveFailedItem removes a failed item.
+func (*service) RemoveFailedItem(_ context.Context, _ string, _ string, _ uint64) error {
+	return nil
+}
+
+// HeadObservations provides head observations according to the filter.
+func (*service) HeadObservations(_ context.Context, _ *chaindb.HeadObservationFilter) ([]*chaindb.HeadObservation, error) {
+	return []*chaindb.HeadObservation{}, nil
+}
+
+// SetHeadObservation sets a head observation.
+func (*service) SetHeadObservation(_ context.Context, _ *chaindb.HeadObservation) error {
+	return nil
+}
+
+// ProposerPeriodSummaries provides proposer period summaries according to the filter.
+func (*service) ProposerPeriodSummaries(_ context.Context, _ *chaindb.ProposerPeriodSummaryFilter) ([]*chaindb.ProposerPeriodSummary, error) {
+	return []*chaindb.ProposerPeriodSummary{}, nil
+}
+
+// ProposerPeriodTotals provides the totals of the proposer period summaries that match the filter.
+func (*service) ProposerPeriodTotals(_ context.Context, _ *chaindb.ProposerPeriodSummaryFilter) (*chaindb.ProposerPeriodTotals, error) {
+	return &chaindb.ProposerPeriodTotals{
+		ConsensusRewards: new(big.Int),
+		ExecutionFees:    new(big.Int),
+		MEVPayments:      new(big.Int),
+	}, nil
+}
+
+// SetProposerPeriodSummaries sets multiple proposer period summaries.
+func (*service) SetProposerPeriodSummaries(_ context.Context, _ []*chaindb.ProposerPeriodSummary) error {
+	return nil
+}
+
+// Checkpoints provides epoch checkpoints according to the filter.
+func (*service) Checkpoints(_ context.Context, _ *chaindb.CheckpointFilter) ([]*chaindb.EpochCheckpoint, error) {
+	return []*chaindb.EpochCheckpoint{}, nil
+}
+
+// SetCheckpoint sets an epoch checkpoint.
+func (*service) SetCheckpoint(_ context.Context, _ *chaindb.EpochCheckpoint) error {
+	return nil
+}
+
+// RANDAOMixes provides RANDAO mixes according to the filter.
+func (*service) RANDAOMixes(_ context.Context, _ *chaindb.RANDAOMixFilter) ([]*chaindb.RANDAOMix, error) {
+	return []*chaindb.RANDAOMix{}, nil
+}
+
+// SetRANDAOMix sets the RANDAO mix of an epoch.
+func (*service) SetRANDAOMix(_ context.Context, _ *chaindb.RANDAOMix) error {
+	return nil
+}
+
+// SetRawBlock sets a raw block.
+func (*service) SetRawBlock(_ context.Context, _ *chaindb.RawBlock) error {
+	return nil
+}
+
+// RawBlockByRoot fetches the raw block with the given root.
+func (*service) RawBlockByRoot(_ context.Context, _ phase0.Root) (*chaindb.RawBlock, error) {
+	return nil, nil
+}
+
+// RawBlocks provides raw blocks according to the filter.
+func (*service) RawBlocks(_ context.Context, _ *chaindb.RawBlockFilter) ([]*chaindb.RawBlock, error) {
+	return []*chaindb.RawBlock{}, nil
+}
+
+// DropSecondaryIndexes drops secondary indexes.
+func (s *service) DropSecondaryIndexes(_ context.Context) error {
+	return nil
+}
+
+// CreateSecondaryIndexes creates secondary indexes that are not present.
+func (s *service) CreateSecondaryIndexes(_ context.Context) error {
+	return nil
+}
+
+// TableMaintenanceStats provides statistics for chaind's tables.
+func (*service) TableMaintenanceStats(_ context.Context) ([]*chaindb.TableMaintenanceStats, error) {
+	return []*chaindb.TableMaintenanceStats{}, nil
+}
+
+// VacuumTable vacuums the table.
+func (*service) VacuumTable(_ context.Context, _ string, _ bool) error {
+	return nil
+}
+
+// AnalyzeTable updates the planner statistics for the table.
+func (*service) AnalyzeTable(_ context.Context, _ string) error {
+	return nil
+}
+
 // Spec provides the spec information of the chain.
 func (s *service) Spec(ctx context.Context) (map[string]any, error) {
-	return s.ChainSpec(ctx)
+	spec, err := s.ChainSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return spec.Values, nil
 }
 
-// ChainSpec fetches all chain specification values.
-func (s *service) ChainSpec(_ context.Context) (map[string]any, error) {
-	return map[string]any{
+// ChainSpec fetches the chain specification.
+func (s *service) ChainSpec(_ context.Context) (*chaindb.Spec, error) {
+	return chaindb.NewSpec(map[string]any{
 		"ALTAIR_FORK_EPOCH":                        uint64(74240),
 		"ALTAIR_FORK_VERSION":                      phase0.Version{0x01, 0x00, 0x00, 0x00},
 		"BASE_REWARD_FACTOR":                       uint64(64),
@@ -302,7 +832,7 @@ func (s *service) ChainSpec(_ context.Context) (map[string]any, error) {
 		"VALIDATOR_REGISTRY_LIMIT":                 uint64(1099511627776),
 		"WEIGHT_DENOMINATOR":                       uint64(64),
 		"WHISTLEBLOWER_REWARD_QUOTIENT":            uint64(512),
-	}, nil
+	}), nil
 }
 
 // ChainSpecValue fetches a chain specification value given its key.
@@ -316,8 +846,16 @@ func (s *service) SetChainSpecValue(_ context.Context, _ string, _ any) error {
 }
 
 // ForkSchedule provides details of past and future changes in the chain's fork version.
-func (s *service) ForkSchedule(_ context.Context) ([]*phase0.Fork, error) {
-	return nil, nil
+func (s *service) ForkSchedule(_ context.Context,
+	_ *api.ForkScheduleOpts,
+) (
+	*api.Response[[]*phase0.Fork],
+	error,
+) {
+	return &api.Response[[]*phase0.Fork]{
+		Data:     []*phase0.Fork{},
+		Metadata: make(map[string]any),
+	}, nil
 }
 
 // SetForkSchedule sets the fork schedule.
@@ -325,13 +863,28 @@ func (s *service) SetForkSchedule(_ context.Context, _ []*phase0.Fork) error {
 	return nil
 }
 
+// Forks provides the scheduled forks of the chain.
+func (*service) Forks(_ context.Context) ([]*chaindb.Fork, error) {
+	return []*chaindb.Fork{}, nil
+}
+
+// SetForks sets the scheduled forks of the chain.
+func (*service) SetForks(_ context.Context, _ []*chaindb.Fork) error {
+	return nil
+}
+
 // Genesis fetches genesis values.
-func (s *service) Genesis(_ context.Context) (*api.Genesis, error) {
-	return nil, nil
+func (s *service) Genesis(_ context.Context,
+	_ *api.GenesisOpts,
+) (
+	*api.Response[*apiv1.Genesis],
+	error,
+) {
+	return nil, errors.New("genesis not available")
 }
 
 // SetGenesis sets the genesis information.
-func (s *service) SetGenesis(_ context.Context, _ *api.Genesis) error {
+func (s *service) SetGenesis(_ context.Context, _ *apiv1.Genesis) error {
 	return nil
 }
 
@@ -345,6 +898,21 @@ func (s *service) SetETH1Deposit(_ context.Context, _ *chaindb.ETH1Deposit) erro
 	return nil
 }
 
+// ETH1DepositDiscrepancies provides Ethereum 1 deposit discrepancies according to the filter.
+func (s *service) ETH1DepositDiscrepancies(_ context.Context, _ *chaindb.ETH1DepositDiscrepancyFilter) ([]*chaindb.ETH1DepositDiscrepancy, error) {
+	return []*chaindb.ETH1DepositDiscrepancy{}, nil
+}
+
+// SetETH1DepositDiscrepancy sets an Ethereum 1 deposit discrepancy.
+func (s *service) SetETH1DepositDiscrepancy(_ context.Context, _ *chaindb.ETH1DepositDiscrepancy) error {
+	return nil
+}
+
+// ProposerDuties provides proposer duties according to the filter.
+func (s *service) ProposerDuties(_ context.Context, _ *chaindb.ProposerDutyFilter) ([]*chaindb.ProposerDuty, error) {
+	return []*chaindb.ProposerDuty{}, nil
+}
+
 // ProposerDutiesForSlotRange fetches all proposer duties for the given slot range.
 // Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 // proposer duties for slots 2 and 3.
@@ -362,6 +930,11 @@ func (s *service) SetProposerDuty(_ context.Context, _ *chaindb.ProposerDuty) er
 	return nil
 }
 
+// ProposerSlashings provides proposer slashings according to the filter.
+func (s *service) ProposerSlashings(_ context.Context, _ *chaindb.ProposerSlashingFilter) ([]*chaindb.ProposerSlashing, error) {
+	return []*chaindb.ProposerSlashing{}, nil
+}
+
 // ProposerSlashingsForSlotRange fetches all proposer slashings made for the given slot range.
 // It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
 func (s *service) ProposerSlashingsForSlotRange(_ context.Context, _ phase0.Slot, _ phase0.Slot) ([]*chaindb.ProposerSlashing, error) {
@@ -394,6 +967,11 @@ func (s *service) Validators(_ context.Context) ([]*chaindb.Validator, error) {
 	return nil, nil
 }
 
+// ValidatorIndices fetches the indices of the validators with the given public keys.
+func (s *service) ValidatorIndices(_ context.Context, _ []phase0.BLSPubKey) (map[phase0.BLSPubKey]phase0.ValidatorIndex, error) {
+	return map[phase0.BLSPubKey]phase0.ValidatorIndex{}, nil
+}
+
 // ValidatorsByPublicKey fetches all validators matching the given public keys.
 // This is a common starting point for external entities to query specific validators, as they should
 // always have the public key at a minimum, hence the return map keyed by public key.
@@ -406,6 +984,26 @@ func (s *service) ValidatorsByPublicKey(_ context.Context,
 	return nil, nil
 }
 
+// ValidatorsByFilter fetches validators according to the filter.
+func (s *service) ValidatorsByFilter(_ context.Context,
+	_ *chaindb.ValidatorFilter,
+) (
+	[]*chaindb.Validator,
+	error,
+) {
+	return []*chaindb.Validator{}, nil
+}
+
+// ValidatorsStream provides validators according to the filter one at a time.
+func (s *service) ValidatorsStream(_ context.Context,
+	_ *chaindb.ValidatorFilter,
+) (
+	chaindb.Iterator[*chaindb.Validator],
+	error,
+) {
+	return &emptyIterator[*chaindb.Validator]{}, nil
+}
+
 // ValidatorsByIndex fetches all validators matching the given indices.
 func (s *service) ValidatorsByIndex(_ context.Context,
 	_ []phase0.ValidatorIndex,
@@ -515,6 +1113,11 @@ func (s *service) SetValidatorBalances(_ context.Context, _ []*chaindb.Validator
 	return nil
 }
 
+// Deposits provides deposits according to the filter.
+func (s *service) Deposits(_ context.Context, _ *chaindb.DepositFilter) ([]*chaindb.Deposit, error) {
+	return []*chaindb.Deposit{}, nil
+}
+
 // DepositsByPublicKey fetches deposits for a given set of validator public keys.
 func (s *service) DepositsByPublicKey(_ context.Context, _ []phase0.BLSPubKey) (map[phase0.BLSPubKey][]*chaindb.Deposit, error) {
 	return nil, nil
@@ -531,6 +1134,17 @@ func (s *service) SetDeposit(_ context.Context, _ *chaindb.Deposit) error {
 	return nil
 }
 
+// VoluntaryExits provides voluntary exits according to the filter.
+func (s *service) VoluntaryExits(_ context.Context, _ *chaindb.VoluntaryExitFilter) ([]*chaindb.VoluntaryExit, error) {
+	return []*chaindb.VoluntaryExit{}, nil
+}
+
+// VoluntaryExitsForSlotRange fetches all voluntary exits included in the given slot range.
+// It will return voluntary exits from blocks that are canonical or undefined, but not from non-canonical blocks.
+func (s *service) VoluntaryExitsForSlotRange(_ context.Context, _ phase0.Slot, _ phase0.Slot) ([]*chaindb.VoluntaryExit, error) {
+	return nil, nil
+}
+
 // SetVoluntaryExit sets a voluntary exit.
 func (s *service) SetVoluntaryExit(_ context.Context, _ *chaindb.VoluntaryExit) error {
 	return nil
@@ -592,8 +1206,8 @@ func (s *service) Withdrawals(_ context.Context, _ *chaindb.WithdrawalFilter) ([
 }
 
 // BeginTx begins a transaction.
-func (s *service) BeginTx(_ context.Context) (context.Context, context.CancelFunc, error) {
-	return nil, nil, nil
+func (s *service) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	return ctx, func() {}, nil
 }
 
 // CommitTx commits a transaction.
@@ -602,8 +1216,8 @@ func (s *service) CommitTx(_ context.Context) error {
 }
 
 // BeginROTx begins a read-only transaction.
-func (s *service) BeginROTx(_ context.Context) (context.Context, error) {
-	return nil, nil
+func (s *service) BeginROTx(ctx context.Context) (context.Context, error) {
+	return ctx, nil
 }
 
 // CommitROTx commits a read-only transaction.
@@ -618,3 +1232,243 @@ func (s *service) SetMetadata(_ context.Context, _ string, _ []byte) error {
 func (s *service) Metadata(_ context.Context, _ string) ([]byte, error) {
 	return nil, nil
 }
+
+// SetProgress sets a progress value for a service.
+func (s *service) SetProgress(_ context.Context, _ string, _ string, _ int64) error {
+	return nil
+}
+
+// SetProgressGaps sets the gaps in progress for a service, replacing any existing gaps for the key.
+func (s *service) SetProgressGaps(_ context.Context, _ string, _ string, _ []int64) error {
+	return nil
+}
+
+// Progress obtains the progress for a service.
+func (s *service) Progress(_ context.Context, _ string) (*chaindb.Progress, error) {
+	return nil, nil
+}
+
+// AttestingIndices expands the aggregation bits of the attestation to validator indices.
+func (s *service) AttestingIndices(_ context.Context, _ *chaindb.Attestation) ([]phase0.ValidatorIndex, error) {
+	return []phase0.ValidatorIndex{}, nil
+}
+
+// SetAttestations sets multiple attestations.
+func (s *service) SetAttestations(_ context.Context, _ []*chaindb.Attestation) error {
+	return nil
+}
+
+// PruneAttestations prunes attestations included before the given slot.
+func (s *service) PruneAttestations(_ context.Context, _ phase0.Slot) error {
+	return nil
+}
+
+// BeaconCommittees fetches the beacon committees matching the filter.
+func (s *service) BeaconCommittees(_ context.Context, _ *chaindb.BeaconCommitteeFilter) ([]*chaindb.BeaconCommittee, error) {
+	return []*chaindb.BeaconCommittee{}, nil
+}
+
+// PruneBeaconCommittees prunes beacon committees for slots before the given slot.
+func (s *service) PruneBeaconCommittees(_ context.Context, _ phase0.Slot) error {
+	return nil
+}
+
+// BlobSidecars fetches the blob sidecars matching the filter.
+func (s *service) BlobSidecars(_ context.Context, _ *chaindb.BlobSidecarFilter) ([]*chaindb.BlobSidecar, error) {
+	return []*chaindb.BlobSidecar{}, nil
+}
+
+// SetBlobSidecar sets a blob sidecar.
+func (s *service) SetBlobSidecar(_ context.Context, _ *chaindb.BlobSidecar) error {
+	return nil
+}
+
+// SetBlobSidecars sets multiple blob sidecars.
+func (s *service) SetBlobSidecars(_ context.Context, _ []*chaindb.BlobSidecar) error {
+	return nil
+}
+
+// SyncAggregates provides sync aggregates according to the filter.
+func (s *service) SyncAggregates(_ context.Context, _ *chaindb.SyncAggregateFilter) ([]*chaindb.SyncAggregate, error) {
+	return []*chaindb.SyncAggregate{}, nil
+}
+
+// SyncParticipation provides the participation of the sync committee in
+// the sync aggregates that match the filter.
+func (s *service) SyncParticipation(_ context.Context, _ *chaindb.SyncAggregateFilter) ([]*chaindb.SyncParticipation, error) {
+	return []*chaindb.SyncParticipation{}, nil
+}
+
+// SyncCommitteeParticipants provides the participation of each member of
+// the sync committee in the sync aggregate of the given block.
+func (s *service) SyncCommitteeParticipants(_ context.Context, _ phase0.Root) ([]*chaindb.SyncCommitteeParticipant, error) {
+	return []*chaindb.SyncCommitteeParticipant{}, nil
+}
+
+// PruneSyncAggregates prunes sync aggregates for slots before the given slot.
+func (s *service) PruneSyncAggregates(_ context.Context, _ phase0.Slot) error {
+	return nil
+}
+
+// PruneValidatorBalances prunes validator balances up to (but not including) the given epoch.
+func (s *service) PruneValidatorBalances(_ context.Context, _ phase0.Epoch, _ []phase0.ValidatorIndex) error {
+	return nil
+}
+
+// RemoveValidatorBalancesByEpoch removes the validator balances for the given epoch, returning them.
+func (s *service) RemoveValidatorBalancesByEpoch(_ context.Context, _ phase0.Epoch) ([]*chaindb.ValidatorBalance, error) {
+	return []*chaindb.ValidatorBalance{}, nil
+}
+
+// ValidatorCredentialsChanges fetches withdrawal credentials changes according to the filter.
+func (s *service) ValidatorCredentialsChanges(_ context.Context,
+	_ *chaindb.ValidatorCredentialsChangeFilter,
+) (
+	[]*chaindb.ValidatorCredentialsChange,
+	error,
+) {
+	return []*chaindb.ValidatorCredentialsChange{}, nil
+}
+
+// ValidatorEffectiveBalanceCeilingChanges fetches effective balance ceiling changes according to the filter.
+func (s *service) ValidatorEffectiveBalanceCeilingChanges(_ context.Context,
+	_ *chaindb.ValidatorCredentialsChangeFilter,
+) (
+	[]*chaindb.ValidatorCredentialsChange,
+	error,
+) {
+	return []*chaindb.ValidatorCredentialsChange{}, nil
+}
+
+// SetValidatorCredentialsChange sets a withdrawal credentials change.
+func (s *service) SetValidatorCredentialsChange(_ context.Context, _ *chaindb.ValidatorCredentialsChange) error {
+	return nil
+}
+
+// ValidatorConsolidations fetches validator consolidations according to the filter.
+func (s *service) ValidatorConsolidations(_ context.Context,
+	_ *chaindb.ValidatorConsolidationFilter,
+) (
+	[]*chaindb.ValidatorConsolidation,
+	error,
+) {
+	return []*chaindb.ValidatorConsolidation{}, nil
+}
+
+// SetValidatorConsolidation sets a validator consolidation.
+func (s *service) SetValidatorConsolidation(_ context.Context, _ *chaindb.ValidatorConsolidation) error {
+	return nil
+}
+
+// ArchiveOffloads fetches archive offloads according to the filter.
+func (s *service) ArchiveOffloads(_ context.Context, _ *chaindb.ArchiveOffloadFilter) ([]*chaindb.ArchiveOffload, error) {
+	return []*chaindb.ArchiveOffload{}, nil
+}
+
+// SetArchiveOffload sets an archive offload.
+func (s *service) SetArchiveOffload(_ context.Context, _ *chaindb.ArchiveOffload) error {
+	return nil
+}
+
+// ValidatorDaySummaries fetches validator day summaries according to the filter.
+func (s *service) ValidatorDaySummaries(_ context.Context,
+	_ *chaindb.ValidatorDaySummaryFilter,
+) (
+	[]*chaindb.ValidatorDaySummary,
+	error,
+) {
+	return []*chaindb.ValidatorDaySummary{}, nil
+}
+
+// SetValidatorDaySummary sets a validator day summary.
+func (s *service) SetValidatorDaySummary(_ context.Context, _ *chaindb.ValidatorDaySummary) error {
+	return nil
+}
+
+// SetValidatorDaySummaries sets multiple validator day summaries.
+func (s *service) SetValidatorDaySummaries(_ context.Context, _ []*chaindb.ValidatorDaySummary) error {
+	return nil
+}
+
+// ValidatorAPRs fetches validator APRs according to the filter.
+func (s *service) ValidatorAPRs(_ context.Context, _ *chaindb.ValidatorAPRFilter) ([]*chaindb.ValidatorAPR, error) {
+	return []*chaindb.ValidatorAPR{}, nil
+}
+
+// AggregateValidatorAPR fetches the aggregate APR of the validators according to the filter.
+func (s *service) AggregateValidatorAPR(_ context.Context, _ *chaindb.ValidatorAPRFilter) (*chaindb.ValidatorAPR, error) {
+	return &chaindb.ValidatorAPR{}, nil
+}
+
+// ValidatorDayRankings fetches validator day rankings according to the filter.
+func (s *service) ValidatorDayRankings(_ context.Context,
+	_ *chaindb.ValidatorDayRankingFilter,
+) (
+	[]*chaindb.ValidatorDayRanking,
+	error,
+) {
+	return []*chaindb.ValidatorDayRanking{}, nil
+}
+
+// SetValidatorDayRankings sets validator day rankings.
+func (s *service) SetValidatorDayRankings(_ context.Context, _ []*chaindb.ValidatorDayRanking) error {
+	return nil
+}
+
+// PruneValidatorEpochSummaries prunes validator epoch summaries up to (but not including) the given epoch.
+func (s *service) PruneValidatorEpochSummaries(_ context.Context, _ phase0.Epoch, _ []phase0.ValidatorIndex) error {
+	return nil
+}
+
+// NetworkAggregates fetches network aggregates according to the filter.
+func (s *service) NetworkAggregates(_ context.Context, _ *chaindb.NetworkAggregateFilter) ([]*chaindb.NetworkAggregate, error) {
+	return []*chaindb.NetworkAggregate{}, nil
+}
+
+// SetNetworkAggregate sets a network aggregate.
+func (s *service) SetNetworkAggregate(_ context.Context, _ *chaindb.NetworkAggregate) error {
+	return nil
+}
+
+// EntryQueues fetches entry queues according to the filter.
+func (s *service) EntryQueues(_ context.Context, _ *chaindb.EntryQueueFilter) ([]*chaindb.EntryQueue, error) {
+	return []*chaindb.EntryQueue{}, nil
+}
+
+// SetEntryQueue sets an entry queue.
+func (s *service) SetEntryQueue(_ context.Context, _ *chaindb.EntryQueue) error {
+	return nil
+}
+
+// EpochSummaries fetches epoch summaries according to the filter.
+func (s *service) EpochSummaries(_ context.Context, _ *chaindb.EpochSummaryFilter) ([]*chaindb.EpochSummary, error) {
+	return []*chaindb.EpochSummary{}, nil
+}
+
+// BLSToExecutionChanges fetches BLS to execution changes according to the filter.
+func (s *service) BLSToExecutionChanges(_ context.Context,
+	_ *chaindb.BLSToExecutionChangeFilter,
+) (
+	[]*chaindb.BLSToExecutionChange,
+	error,
+) {
+	return []*chaindb.BLSToExecutionChange{}, nil
+}
+
+// TrySessionLock attempts to obtain the named lock without waiting.
+// The mock always obtains the lock.
+func (s *service) TrySessionLock(_ context.Context, _ string) (chaindb.SessionLock, error) {
+	return &sessionLock{}, nil
+}
+
+type sessionLock struct{}
+
+// Check returns an error if the session holding the lock has been lost.
+func (*sessionLock) Check(_ context.Context) error {
+	return nil
+}
+
+// Release releases the lock.
+func (*sessionLock) Release(_ context.Context) error {
+	return nil
+}
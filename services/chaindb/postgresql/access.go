@@ -0,0 +1,272 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// setSearchPath sets the search path of connections so that unqualified names
+// refer to the given schema.  Shared extensions are expected in the public
+// schema, so that remains in the path.
+func setSearchPath(config *pgxpool.Config, schema string) {
+	if schema == "" {
+		return
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = fmt.Sprintf("%s, public", pgx.Identifier{schema}.Sanitize())
+}
+
+// lockName provides the name of an advisory lock, scoped to the schema.
+// Advisory locks are held across the database, so locks for different schemas
+// must have different names.
+func (s *Service) lockName(name string) string {
+	if s.schema == "" {
+		return name
+	}
+
+	return fmt.Sprintf("%s.%s", s.schema, name)
+}
+
+// ensureSchema ensures that the configured schema exists, creating it if the
+// schema is not externally managed.
+func (s *Service) ensureSchema(ctx context.Context) error {
+	if s.schema == "" {
+		return nil
+	}
+
+	if s.externalSchema {
+		exists, err := s.schemaExists(ctx)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errors.Wrapf(ErrExternalSchema, "schema %s does not exist", s.schema)
+		}
+
+		return nil
+	}
+
+	if _, err := s.pool.Exec(ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", pgx.Identifier{s.schema}.Sanitize())); err != nil {
+		return errors.Wrapf(err, "failed to create schema %s", s.schema)
+	}
+	// Extensions can only be installed once per database, so place them where
+	// all schemas can use them.
+	if _, err := s.pool.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS pg_trgm SCHEMA public"); err != nil {
+		return errors.Wrap(err, "failed to create pg_trgm extension")
+	}
+
+	return nil
+}
+
+// schemaExists returns true if the configured schema exists.
+func (s *Service) schemaExists(ctx context.Context) (bool, error) {
+	if s.schema == "" {
+		return true, nil
+	}
+
+	var exists bool
+	if err := s.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM pg_namespace WHERE nspname = $1)`, s.schema).Scan(&exists); err != nil {
+		return false, errors.Wrap(err, "failed to check presence of schema")
+	}
+
+	return exists, nil
+}
+
+// ApplyAccessControls grants read access to the tables in the schema to the
+// read-only roles, creating the roles if required, and enables row-level
+// security on the tables if configured.  It should be called after the schema
+// is upgraded, so that tables added by the upgrade are covered.
+func (s *Service) ApplyAccessControls(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "ApplyAccessControls")
+	defer span.End()
+
+	if len(s.readOnlyRoles) == 0 {
+		return nil
+	}
+	if s.externalSchema {
+		return errors.Wrap(ErrExternalSchema, "cannot apply access controls")
+	}
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.applyAccessControls(ctx); err != nil {
+		cancel()
+		return err
+	}
+
+	if err := s.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+func (s *Service) applyAccessControls(ctx context.Context) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	var schema string
+	if err := tx.QueryRow(ctx, `SELECT current_schema()`).Scan(&schema); err != nil {
+		return errors.Wrap(err, "failed to obtain schema")
+	}
+	schemaIdentifier := pgx.Identifier{schema}.Sanitize()
+
+	for _, role := range s.readOnlyRoles {
+		roleIdentifier := pgx.Identifier{role}.Sanitize()
+
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM pg_roles WHERE rolname = $1)`, role).Scan(&exists); err != nil {
+			return errors.Wrapf(err, "failed to check presence of role %s", role)
+		}
+		if !exists {
+			log.Info().Str("role", role).Msg("Creating read-only role")
+			if _, err := tx.Exec(ctx, fmt.Sprintf("CREATE ROLE %s NOLOGIN", roleIdentifier)); err != nil {
+				return errors.Wrapf(err, "failed to create role %s", role)
+			}
+		}
+
+		for _, statement := range []string{
+			fmt.Sprintf("GRANT USAGE ON SCHEMA %s TO %s", schemaIdentifier, roleIdentifier),
+			fmt.Sprintf("GRANT SELECT ON ALL TABLES IN SCHEMA %s TO %s", schemaIdentifier, roleIdentifier),
+			fmt.Sprintf("ALTER DEFAULT PRIVILEGES IN SCHEMA %s GRANT SELECT ON TABLES TO %s", schemaIdentifier, roleIdentifier),
+		} {
+			if _, err := tx.Exec(ctx, statement); err != nil {
+				return errors.Wrapf(err, "failed to grant read access to role %s", role)
+			}
+		}
+	}
+
+	if !s.rowLevelSecurity {
+		return nil
+	}
+
+	tables, err := s.schemaTables(ctx)
+	if err != nil {
+		return err
+	}
+	policies, err := s.readOnlyPolicies(ctx)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		tableIdentifier := pgx.Identifier{table.name}.Sanitize()
+		if !table.rowSecurity {
+			// Row-level security does not apply to the owner of the table, so chaind is unaffected.
+			log.Info().Str("table", table.name).Msg("Enabling row-level security")
+			if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", tableIdentifier)); err != nil {
+				return errors.Wrapf(err, "failed to enable row-level security on %s", table.name)
+			}
+		}
+		for _, role := range s.readOnlyRoles {
+			policy := readOnlyPolicyName(role)
+			if policies[table.name][policy] {
+				continue
+			}
+			if _, err := tx.Exec(ctx, fmt.Sprintf("CREATE POLICY %s ON %s FOR SELECT TO %s USING (true)",
+				pgx.Identifier{policy}.Sanitize(),
+				tableIdentifier,
+				pgx.Identifier{role}.Sanitize(),
+			)); err != nil {
+				return errors.Wrapf(err, "failed to create policy for role %s on %s", role, table.name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// readOnlyPolicyName provides the name of the row-level security policy for a read-only role.
+func readOnlyPolicyName(role string) string {
+	return fmt.Sprintf("p_read_%s", role)
+}
+
+// accessTable is a table and whether it has row-level security enabled.
+type accessTable struct {
+	name        string
+	rowSecurity bool
+}
+
+// schemaTables provides the tables in the schema.
+func (s *Service) schemaTables(ctx context.Context) ([]*accessTable, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return nil, ErrNoTransaction
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT tablename
+      ,rowsecurity
+FROM pg_tables
+WHERE schemaname = current_schema()
+ORDER BY tablename`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain tables")
+	}
+	defer rows.Close()
+
+	tables := make([]*accessTable, 0)
+	for rows.Next() {
+		table := &accessTable{}
+		if err := rows.Scan(&table.name, &table.rowSecurity); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, rows.Err()
+}
+
+// readOnlyPolicies provides the row-level security policies in the schema, keyed by table then policy name.
+func (s *Service) readOnlyPolicies(ctx context.Context) (map[string]map[string]bool, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return nil, ErrNoTransaction
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT tablename
+      ,policyname
+FROM pg_policies
+WHERE schemaname = current_schema()`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain policies")
+	}
+	defer rows.Close()
+
+	policies := make(map[string]map[string]bool)
+	for rows.Next() {
+		var table string
+		var policy string
+		if err := rows.Scan(&table, &policy); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if _, exists := policies[table]; !exists {
+			policies[table] = make(map[string]bool)
+		}
+		policies[table][policy] = true
+	}
+
+	return policies, rows.Err()
+}
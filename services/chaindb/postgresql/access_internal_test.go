@@ -0,0 +1,51 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetSearchPath(t *testing.T) {
+	config, err := pgxpool.ParseConfig("host=localhost user=chain")
+	require.NoError(t, err)
+	setSearchPath(config, "")
+	require.NotContains(t, config.ConnConfig.RuntimeParams, "search_path")
+
+	setSearchPath(config, "holesky")
+	require.Equal(t, `"holesky", public`, config.ConnConfig.RuntimeParams["search_path"])
+}
+
+func TestLockName(t *testing.T) {
+	require.Equal(t, "chaind.schema.migrate", (&Service{}).lockName(migrationLockName))
+	require.Equal(t, "holesky.chaind.schema.migrate", (&Service{schema: "holesky"}).lockName(migrationLockName))
+}
+
+func TestRowLevelSecurityParameters(t *testing.T) {
+	_, err := parseAndCheckParameters(
+		WithConnectionURL("postgres://localhost"),
+		WithRowLevelSecurity(true),
+	)
+	require.EqualError(t, err, "row-level security requires at least one read-only role")
+
+	_, err = parseAndCheckParameters(
+		WithConnectionURL("postgres://localhost"),
+		WithRowLevelSecurity(true),
+		WithReadOnlyRoles([]string{"chain_reader"}),
+	)
+	require.NoError(t, err)
+}
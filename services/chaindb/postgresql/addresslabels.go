@@ -0,0 +1,173 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetAddressLabel sets the label for an address.
+// An existing label for the address is replaced.
+func (s *Service) SetAddressLabel(ctx context.Context, label *chaindb.AddressLabel) error {
+	ctx, span := startSpan(ctx, "SetAddressLabel")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_address_labels(f_address
+                            ,f_label
+                            ,f_category
+                            )
+VALUES($1,$2,$3)
+ON CONFLICT (f_address) DO
+UPDATE
+SET f_label = excluded.f_label
+   ,f_category = excluded.f_category
+`,
+		label.Address[:],
+		label.Label,
+		label.Category,
+	)
+
+	return err
+}
+
+// RemoveAddressLabel removes the label for an address.
+func (s *Service) RemoveAddressLabel(ctx context.Context, address [20]byte) error {
+	ctx, span := startSpan(ctx, "RemoveAddressLabel")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+DELETE FROM t_address_labels
+WHERE f_address = $1
+`,
+		address[:],
+	)
+
+	return err
+}
+
+// AddressLabels provides address labels according to the filter.
+func (s *Service) AddressLabels(ctx context.Context,
+	filter *chaindb.AddressLabelFilter,
+) (
+	[]*chaindb.AddressLabel,
+	error,
+) {
+	ctx, span := startSpan(ctx, "AddressLabels")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_address
+      ,f_label
+      ,f_category
+FROM t_address_labels`)
+
+	wherestr := "WHERE"
+
+	if len(filter.Addresses) > 0 {
+		addresses := make([][]byte, len(filter.Addresses))
+		for i := range filter.Addresses {
+			addresses[i] = filter.Addresses[i][:]
+		}
+		queryVals = append(queryVals, addresses)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_address = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.Labels) > 0 {
+		queryVals = append(queryVals, filter.Labels)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s (f_label = ANY($%d) OR f_category = ANY($%d))`, wherestr, len(queryVals), len(queryVals)))
+	}
+
+	queryBuilder.WriteString(`
+ORDER BY f_address`)
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := make([]*chaindb.AddressLabel, 0)
+	for rows.Next() {
+		label := &chaindb.AddressLabel{}
+		var address []byte
+		err := rows.Scan(
+			&address,
+			&label.Label,
+			&label.Category,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(label.Address[:], address)
+		labels = append(labels, label)
+	}
+
+	return labels, nil
+}
+
+// addressLabelsCondition provides an SQL condition that matches the given address
+// column against addresses with the labels or categories in the given parameter.
+func addressLabelsCondition(column string, param int) string {
+	return fmt.Sprintf("%s IN (SELECT f_address FROM t_address_labels WHERE f_label = ANY($%d) OR f_category = ANY($%d))", column, param, param)
+}
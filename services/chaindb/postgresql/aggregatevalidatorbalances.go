@@ -22,7 +22,6 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // AggregateValidatorBalancesByIndexAndEpoch fetches the aggregate validator balances for the given validators and epoch.
@@ -34,7 +33,7 @@ func (s *Service) AggregateValidatorBalancesByIndexAndEpoch(
 	*chaindb.AggregateValidatorBalance,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "AggregateValidatorBalancesByIndexAndEpoch")
+	ctx, span := startSpan(ctx, "AggregateValidatorBalancesByIndexAndEpoch")
 	defer span.End()
 
 	if len(validatorIndices) == 0 {
@@ -93,7 +92,7 @@ func (s *Service) AggregateValidatorBalancesByIndexAndEpochRange(
 	[]*chaindb.AggregateValidatorBalance,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "AggregateValidatorBalancesByIndexAndEpochRange")
+	ctx, span := startSpan(ctx, "AggregateValidatorBalancesByIndexAndEpochRange")
 	defer span.End()
 
 	if len(validatorIndices) == 0 {
@@ -156,7 +155,7 @@ func (s *Service) AggregateValidatorBalancesByIndexAndEpochs(
 	[]*chaindb.AggregateValidatorBalance,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "AggregateValidatorBalancesByIndexAndEpochs")
+	ctx, span := startSpan(ctx, "AggregateValidatorBalancesByIndexAndEpochs")
 	defer span.End()
 
 	if len(validatorIndices) == 0 {
@@ -0,0 +1,267 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/coldstore"
+)
+
+// validatorBalancesTable is the name of the validator balances table, as recorded in archive offloads.
+const validatorBalancesTable = "t_validator_balances"
+
+// RemoveValidatorBalancesByEpoch removes the validator balances for the given epoch,
+// returning the balances that were removed.
+func (s *Service) RemoveValidatorBalancesByEpoch(ctx context.Context, epoch phase0.Epoch) ([]*chaindb.ValidatorBalance, error) {
+	ctx, span := startSpan(ctx, "RemoveValidatorBalancesByEpoch")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return nil, ErrNoTransaction
+	}
+
+	rows, err := tx.Query(ctx, `
+DELETE FROM t_validator_balances
+WHERE f_epoch = $1
+RETURNING f_validator_index
+         ,f_epoch
+         ,f_balance
+         ,f_effective_balance`,
+		epoch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	validatorBalances := make([]*chaindb.ValidatorBalance, 0)
+	for rows.Next() {
+		validatorBalance, err := validatorBalanceFromRow(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		validatorBalances = append(validatorBalances, validatorBalance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to remove validator balances")
+	}
+
+	sort.Slice(validatorBalances, func(i int, j int) bool {
+		return validatorBalances[i].Index < validatorBalances[j].Index
+	})
+
+	return validatorBalances, nil
+}
+
+// archivedValidatorBalances fetches validator balances that have been offloaded to cold storage
+// for the given inclusive epoch range.  If epochs is supplied then only those epochs within the range
+// are fetched, and if validatorIndices is supplied then only balances for those validators are returned.
+func (s *Service) archivedValidatorBalances(ctx context.Context,
+	from phase0.Epoch,
+	to phase0.Epoch,
+	epochs []phase0.Epoch,
+	validatorIndices []phase0.ValidatorIndex,
+) (
+	[]*chaindb.ValidatorBalance,
+	error,
+) {
+	if s.coldStore == nil {
+		// No cold storage, so nothing archived.
+		return nil, nil
+	}
+
+	offloads, err := s.ArchiveOffloads(ctx, &chaindb.ArchiveOffloadFilter{
+		Order:  chaindb.OrderEarliest,
+		Tables: []string{validatorBalancesTable},
+		From:   &from,
+		To:     &to,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain archive offloads")
+	}
+	if len(offloads) == 0 {
+		return nil, nil
+	}
+
+	// Only fetch offloaded epochs that are no longer present in the database.
+	candidateEpochs := make([]phase0.Epoch, 0, len(offloads))
+	var requiredEpochs map[phase0.Epoch]bool
+	if len(epochs) > 0 {
+		requiredEpochs = make(map[phase0.Epoch]bool, len(epochs))
+		for _, epoch := range epochs {
+			requiredEpochs[epoch] = true
+		}
+	}
+	for _, offload := range offloads {
+		if requiredEpochs == nil || requiredEpochs[offload.Epoch] {
+			candidateEpochs = append(candidateEpochs, offload.Epoch)
+		}
+	}
+	if len(candidateEpochs) == 0 {
+		return nil, nil
+	}
+	presentEpochs, err := s.validatorBalanceEpochsPresent(ctx, candidateEpochs)
+	if err != nil {
+		return nil, err
+	}
+
+	var requiredIndices map[phase0.ValidatorIndex]bool
+	if len(validatorIndices) > 0 {
+		requiredIndices = make(map[phase0.ValidatorIndex]bool, len(validatorIndices))
+		for _, index := range validatorIndices {
+			requiredIndices[index] = true
+		}
+	}
+	partitions := coldstore.ValidatorPartitions(validatorIndices)
+
+	validatorBalances := make([]*chaindb.ValidatorBalance, 0)
+	for _, offload := range offloads {
+		if requiredEpochs != nil && !requiredEpochs[offload.Epoch] {
+			continue
+		}
+		if presentEpochs[offload.Epoch] {
+			continue
+		}
+		log.Trace().Uint64("epoch", uint64(offload.Epoch)).Str("location", offload.Location).Msg("Fetching archived validator balances")
+		archived, err := s.archivedValidatorBalancesForOffload(ctx, offload, partitions)
+		if err != nil {
+			return nil, err
+		}
+		for _, validatorBalance := range archived {
+			if requiredIndices != nil && !requiredIndices[validatorBalance.Index] {
+				continue
+			}
+			validatorBalances = append(validatorBalances, validatorBalance)
+		}
+	}
+
+	return validatorBalances, nil
+}
+
+// archivedValidatorBalancesForOffload fetches the archived validator balances for a single offload.
+// If partitions is supplied then only those partitions are fetched, otherwise all partitions are fetched.
+func (s *Service) archivedValidatorBalancesForOffload(ctx context.Context,
+	offload *chaindb.ArchiveOffload,
+	partitions []uint64,
+) (
+	[]*chaindb.ValidatorBalance,
+	error,
+) {
+	validatorBalances := make([]*chaindb.ValidatorBalance, 0)
+	all := len(partitions) == 0
+	for i := 0; all || i < len(partitions); i++ {
+		partition := uint64(i)
+		if !all {
+			partition = partitions[i]
+		}
+		data, err := s.coldStore.Get(ctx, coldstore.PartitionKey(offload.Key, partition))
+		if errors.Is(err, coldstore.ErrNotFound) {
+			// Partitions are contiguous, so a missing partition means there are no more validators.
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to obtain archived validator balances for epoch %d", offload.Epoch)
+		}
+		archived, err := coldstore.UnmarshalJSONL[*chaindb.ValidatorBalance](data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode archived validator balances for epoch %d", offload.Epoch)
+		}
+		validatorBalances = append(validatorBalances, archived...)
+	}
+
+	return validatorBalances, nil
+}
+
+// validatorBalanceEpochsPresent returns the subset of the given epochs that have validator balances in the database.
+func (s *Service) validatorBalanceEpochsPresent(ctx context.Context, epochs []phase0.Epoch) (map[phase0.Epoch]bool, error) {
+	ctx, span := startSpan(ctx, "validatorBalanceEpochsPresent")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	dbEpochs := make([]uint64, len(epochs))
+	for i, epoch := range epochs {
+		dbEpochs[i] = uint64(epoch)
+	}
+	rows, err := tx.Query(ctx, `
+SELECT x.f_epoch
+FROM unnest($1::BIGINT[]) AS x(f_epoch)
+WHERE EXISTS(SELECT 1 FROM t_validator_balances WHERE t_validator_balances.f_epoch = x.f_epoch)`,
+		dbEpochs,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator balance epochs")
+	}
+	defer rows.Close()
+
+	present := make(map[phase0.Epoch]bool)
+	for rows.Next() {
+		var epoch uint64
+		if err := rows.Scan(&epoch); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		present[phase0.Epoch(epoch)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator balance epochs")
+	}
+
+	return present, nil
+}
+
+// mergeArchivedValidatorBalances merges archived validator balances in to those obtained from the database,
+// ignoring any archived balances for epochs that are already present and keeping each validator's balances
+// in epoch order.
+func mergeArchivedValidatorBalances(validatorBalances map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance,
+	archived []*chaindb.ValidatorBalance,
+) {
+	if len(archived) == 0 {
+		return
+	}
+
+	present := make(map[phase0.ValidatorIndex]map[phase0.Epoch]bool, len(validatorBalances))
+	for index, balances := range validatorBalances {
+		present[index] = make(map[phase0.Epoch]bool, len(balances))
+		for _, balance := range balances {
+			present[index][balance.Epoch] = true
+		}
+	}
+
+	for _, balance := range archived {
+		if present[balance.Index][balance.Epoch] {
+			continue
+		}
+		validatorBalances[balance.Index] = append(validatorBalances[balance.Index], balance)
+	}
+
+	for _, balances := range validatorBalances {
+		sort.Slice(balances, func(i int, j int) bool {
+			return balances[i].Epoch < balances[j].Epoch
+		})
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/coldstore"
+	"github.com/wealdtech/chaind/services/coldstore/file"
+)
+
+func TestMergeArchivedValidatorBalances(t *testing.T) {
+	tests := []struct {
+		name     string
+		balances map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance
+		archived []*chaindb.ValidatorBalance
+		expected map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance
+	}{
+		{
+			name: "NoArchived",
+			balances: map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance{
+				1: {{Index: 1, Epoch: 5, Balance: 5}},
+			},
+			expected: map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance{
+				1: {{Index: 1, Epoch: 5, Balance: 5}},
+			},
+		},
+		{
+			name:     "ArchivedOnly",
+			balances: map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance{},
+			archived: []*chaindb.ValidatorBalance{
+				{Index: 1, Epoch: 4, Balance: 4},
+				{Index: 1, Epoch: 3, Balance: 3},
+			},
+			expected: map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance{
+				1: {{Index: 1, Epoch: 3, Balance: 3}, {Index: 1, Epoch: 4, Balance: 4}},
+			},
+		},
+		{
+			name: "Mixed",
+			balances: map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance{
+				1: {{Index: 1, Epoch: 5, Balance: 5}},
+			},
+			archived: []*chaindb.ValidatorBalance{
+				{Index: 1, Epoch: 4, Balance: 4},
+				{Index: 1, Epoch: 5, Balance: 50},
+				{Index: 2, Epoch: 4, Balance: 4},
+			},
+			expected: map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance{
+				1: {{Index: 1, Epoch: 4, Balance: 4}, {Index: 1, Epoch: 5, Balance: 5}},
+				2: {{Index: 2, Epoch: 4, Balance: 4}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mergeArchivedValidatorBalances(test.balances, test.archived)
+			require.Equal(t, test.expected, test.balances)
+		})
+	}
+}
+
+func TestArchivedValidatorBalancesForOffload(t *testing.T) {
+	ctx := context.Background()
+
+	coldStore, err := file.New(ctx,
+		file.WithLogLevel(zerolog.Disabled),
+		file.WithBaseDir(t.TempDir()),
+	)
+	require.NoError(t, err)
+
+	// Partitions 0 and 2 hold balances; partition 1 is empty.
+	balances := []*chaindb.ValidatorBalance{
+		{Index: 1, Epoch: 5, Balance: 1},
+		{Index: 2, Epoch: 5, Balance: 2},
+		{Index: 2*coldstore.ValidatorPartitionSize + 1, Epoch: 5, Balance: 3},
+	}
+	partitions := coldstore.PartitionByValidator(balances, func(balance *chaindb.ValidatorBalance) phase0.ValidatorIndex {
+		return balance.Index
+	})
+	for partition, partitionBalances := range partitions {
+		data, err := coldstore.MarshalJSONL(partitionBalances)
+		require.NoError(t, err)
+		require.NoError(t, coldStore.Put(ctx, coldstore.PartitionKey("validator_balances/5", uint64(partition)), data))
+	}
+
+	s := &Service{coldStore: coldStore}
+	offload := &chaindb.ArchiveOffload{
+		Table: validatorBalancesTable,
+		Epoch: 5,
+		Key:   "validator_balances/5",
+	}
+
+	tests := []struct {
+		name       string
+		partitions []uint64
+		expected   []*chaindb.ValidatorBalance
+	}{
+		{
+			name:     "All",
+			expected: balances,
+		},
+		{
+			name:       "First",
+			partitions: []uint64{0},
+			expected:   balances[:2],
+		},
+		{
+			name:       "Last",
+			partitions: []uint64{2},
+			expected:   balances[2:],
+		},
+		{
+			name:       "Empty",
+			partitions: []uint64{1},
+			expected:   []*chaindb.ValidatorBalance{},
+		},
+		{
+			name:       "Missing",
+			partitions: []uint64{5},
+			expected:   []*chaindb.ValidatorBalance{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := s.archivedValidatorBalancesForOffload(ctx, offload, test.partitions)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, res)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetArchiveOffload sets an archive offload.
+func (s *Service) SetArchiveOffload(ctx context.Context, offload *chaindb.ArchiveOffload) error {
+	ctx, span := startSpan(ctx, "SetArchiveOffload")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_archive_offloads(f_table
+                              ,f_epoch
+                              ,f_key
+                              ,f_location
+                              ,f_rows
+                              ,f_timestamp
+                              )
+VALUES($1,$2,$3,$4,$5,$6)
+ON CONFLICT (f_table,f_epoch) DO
+UPDATE
+SET f_key = excluded.f_key
+   ,f_location = excluded.f_location
+   ,f_rows = excluded.f_rows
+   ,f_timestamp = excluded.f_timestamp
+`,
+		offload.Table,
+		offload.Epoch,
+		offload.Key,
+		offload.Location,
+		offload.Rows,
+		offload.Timestamp,
+	)
+
+	return err
+}
+
+// ArchiveOffloads provides archive offloads according to the filter.
+func (s *Service) ArchiveOffloads(ctx context.Context,
+	filter *chaindb.ArchiveOffloadFilter,
+) (
+	[]*chaindb.ArchiveOffload,
+	error,
+) {
+	ctx, span := startSpan(ctx, "ArchiveOffloads")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_table
+      ,f_epoch
+      ,f_key
+      ,f_location
+      ,f_rows
+      ,f_timestamp
+FROM t_archive_offloads`)
+
+	wherestr := "WHERE"
+
+	if len(filter.Tables) > 0 {
+		queryVals = append(queryVals, filter.Tables)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_table = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch <= $%d`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch, f_table`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch DESC, f_table DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	offloads := make([]*chaindb.ArchiveOffload, 0)
+	for rows.Next() {
+		offload := &chaindb.ArchiveOffload{}
+		err := rows.Scan(
+			&offload.Table,
+			&offload.Epoch,
+			&offload.Key,
+			&offload.Location,
+			&offload.Rows,
+			&offload.Timestamp,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		offloads = append(offloads, offload)
+	}
+
+	// Always return order of table then epoch.
+	sort.Slice(offloads, func(i int, j int) bool {
+		if offloads[i].Table != offloads[j].Table {
+			return offloads[i].Table < offloads[j].Table
+		}
+		return offloads[i].Epoch < offloads[j].Epoch
+	})
+
+	return offloads, nil
+}
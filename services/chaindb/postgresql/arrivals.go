@@ -0,0 +1,308 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetBlockArrivals sets block arrivals.
+// The earliest arrival for each block is retained, as it holds the first-seen time.
+func (s *Service) SetBlockArrivals(ctx context.Context, arrivals []*chaindb.BlockArrival) error {
+	ctx, span := startSpan(ctx, "SetBlockArrivals")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, arrival := range arrivals {
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_block_arrivals(f_block_root
+                            ,f_slot
+                            ,f_seen_timestamp
+                            ,f_delay_ms
+                            )
+VALUES($1,$2,$3,$4)
+ON CONFLICT (f_block_root) DO
+UPDATE
+SET f_seen_timestamp = excluded.f_seen_timestamp
+   ,f_delay_ms = excluded.f_delay_ms
+WHERE excluded.f_seen_timestamp < t_block_arrivals.f_seen_timestamp
+`,
+			arrival.BlockRoot[:],
+			arrival.Slot,
+			arrival.SeenTimestamp,
+			arrival.Delay.Milliseconds(),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetAttestationArrivals sets attestation arrivals.
+// Existing arrivals are retained, as they hold the first-seen time.
+func (s *Service) SetAttestationArrivals(ctx context.Context, arrivals []*chaindb.AttestationArrival) error {
+	ctx, span := startSpan(ctx, "SetAttestationArrivals")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, arrival := range arrivals {
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_attestation_arrivals(f_slot
+                                  ,f_committee_index
+                                  ,f_beacon_block_root
+                                  ,f_data_root
+                                  ,f_aggregation_bits
+                                  ,f_seen_timestamp
+                                  ,f_delay_ms
+                                  )
+VALUES($1,$2,$3,$4,$5,$6,$7)
+ON CONFLICT (f_data_root,f_aggregation_bits) DO NOTHING
+`,
+			arrival.Slot,
+			arrival.CommitteeIndex,
+			arrival.BeaconBlockRoot[:],
+			arrival.DataRoot[:],
+			arrival.AggregationBits,
+			arrival.SeenTimestamp,
+			arrival.Delay.Milliseconds(),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BlockArrivals provides block arrivals according to the filter.
+func (s *Service) BlockArrivals(ctx context.Context, filter *chaindb.ArrivalFilter) ([]*chaindb.BlockArrival, error) {
+	ctx, span := startSpan(ctx, "BlockArrivals")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_block_root
+      ,f_slot
+      ,f_seen_timestamp
+      ,f_delay_ms
+FROM t_block_arrivals`)
+
+	if err := addArrivalFilter(&queryBuilder, &queryVals, filter); err != nil {
+		return nil, err
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	arrivals := make([]*chaindb.BlockArrival, 0)
+	blockRoot := make([]byte, phase0.RootLength)
+	for rows.Next() {
+		arrival := &chaindb.BlockArrival{}
+		var delay int64
+		err := rows.Scan(
+			&blockRoot,
+			&arrival.Slot,
+			&arrival.SeenTimestamp,
+			&delay,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(arrival.BlockRoot[:], blockRoot)
+		arrival.Delay = time.Duration(delay) * time.Millisecond
+		arrivals = append(arrivals, arrival)
+	}
+
+	// Always return order of slot then block root.
+	sort.Slice(arrivals, func(i int, j int) bool {
+		if arrivals[i].Slot != arrivals[j].Slot {
+			return arrivals[i].Slot < arrivals[j].Slot
+		}
+		return bytes.Compare(arrivals[i].BlockRoot[:], arrivals[j].BlockRoot[:]) < 0
+	})
+
+	return arrivals, nil
+}
+
+// AttestationArrivals provides attestation arrivals according to the filter.
+func (s *Service) AttestationArrivals(ctx context.Context, filter *chaindb.ArrivalFilter) ([]*chaindb.AttestationArrival, error) {
+	ctx, span := startSpan(ctx, "AttestationArrivals")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_slot
+      ,f_committee_index
+      ,f_beacon_block_root
+      ,f_data_root
+      ,f_aggregation_bits
+      ,f_seen_timestamp
+      ,f_delay_ms
+FROM t_attestation_arrivals`)
+
+	if err := addArrivalFilter(&queryBuilder, &queryVals, filter); err != nil {
+		return nil, err
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	arrivals := make([]*chaindb.AttestationArrival, 0)
+	beaconBlockRoot := make([]byte, phase0.RootLength)
+	dataRoot := make([]byte, phase0.RootLength)
+	for rows.Next() {
+		arrival := &chaindb.AttestationArrival{}
+		var delay int64
+		err := rows.Scan(
+			&arrival.Slot,
+			&arrival.CommitteeIndex,
+			&beaconBlockRoot,
+			&dataRoot,
+			&arrival.AggregationBits,
+			&arrival.SeenTimestamp,
+			&delay,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(arrival.BeaconBlockRoot[:], beaconBlockRoot)
+		copy(arrival.DataRoot[:], dataRoot)
+		arrival.Delay = time.Duration(delay) * time.Millisecond
+		arrivals = append(arrivals, arrival)
+	}
+
+	// Always return order of slot then committee index then seen time.
+	sort.Slice(arrivals, func(i int, j int) bool {
+		if arrivals[i].Slot != arrivals[j].Slot {
+			return arrivals[i].Slot < arrivals[j].Slot
+		}
+		if arrivals[i].CommitteeIndex != arrivals[j].CommitteeIndex {
+			return arrivals[i].CommitteeIndex < arrivals[j].CommitteeIndex
+		}
+		return arrivals[i].SeenTimestamp.Before(arrivals[j].SeenTimestamp)
+	})
+
+	return arrivals, nil
+}
+
+// addArrivalFilter adds the common arrival filter to a query.
+func addArrivalFilter(queryBuilder *strings.Builder,
+	queryVals *[]any,
+	filter *chaindb.ArrivalFilter,
+) error {
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		*queryVals = append(*queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot >= $%d`, wherestr, len(*queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		*queryVals = append(*queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot <= $%d`, wherestr, len(*queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC`)
+	default:
+		return errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		*queryVals = append(*queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(*queryVals)))
+	}
+
+	return nil
+}
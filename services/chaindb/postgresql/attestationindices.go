@@ -0,0 +1,117 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// AttestingIndices expands the aggregation bits of the attestation to validator indices
+// using the stored beacon committee.
+func (s *Service) AttestingIndices(ctx context.Context, attestation *chaindb.Attestation) ([]phase0.ValidatorIndex, error) {
+	ctx, span := startSpan(ctx, "AttestingIndices")
+	defer span.End()
+
+	if attestation == nil {
+		return nil, errors.New("attestation missing")
+	}
+
+	committee, err := s.BeaconCommitteeBySlotAndIndex(ctx, attestation.Slot, attestation.CommitteeIndex)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain beacon committee")
+	}
+	if committee == nil {
+		return nil, errors.New("beacon committee not found")
+	}
+
+	return expandAggregationBits(attestation.AggregationBits, committee.Committee)
+}
+
+// expandAttestationIndices populates the aggregation indices of attestations that
+// were stored in compact form.
+func (s *Service) expandAttestationIndices(ctx context.Context, attestations []*chaindb.Attestation) error {
+	var minSlot phase0.Slot
+	var maxSlot phase0.Slot
+	found := false
+	for _, attestation := range attestations {
+		if attestation.AggregationIndices != nil {
+			continue
+		}
+		if !found || attestation.Slot < minSlot {
+			minSlot = attestation.Slot
+		}
+		if !found || attestation.Slot > maxSlot {
+			maxSlot = attestation.Slot
+		}
+		found = true
+	}
+	if !found {
+		// Nothing to expand.
+		return nil
+	}
+
+	committees, err := s.BeaconCommittees(ctx, &chaindb.BeaconCommitteeFilter{
+		From: &minSlot,
+		To:   &maxSlot,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain beacon committees")
+	}
+	committeeMap := make(map[phase0.Slot]map[phase0.CommitteeIndex][]phase0.ValidatorIndex)
+	for _, committee := range committees {
+		if _, exists := committeeMap[committee.Slot]; !exists {
+			committeeMap[committee.Slot] = make(map[phase0.CommitteeIndex][]phase0.ValidatorIndex)
+		}
+		committeeMap[committee.Slot][committee.Index] = committee.Committee
+	}
+
+	for _, attestation := range attestations {
+		if attestation.AggregationIndices != nil {
+			continue
+		}
+		committee, exists := committeeMap[attestation.Slot][attestation.CommitteeIndex]
+		if !exists {
+			return fmt.Errorf("no beacon committee for slot %d index %d to expand attestation indices", attestation.Slot, attestation.CommitteeIndex)
+		}
+		attestation.AggregationIndices, err = expandAggregationBits(attestation.AggregationBits, committee)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expandAggregationBits expands aggregation bits to validator indices given the committee.
+func expandAggregationBits(aggregationBits []byte, committee []phase0.ValidatorIndex) ([]phase0.ValidatorIndex, error) {
+	bits := bitfield.Bitlist(aggregationBits)
+	if bits.Len() != uint64(len(committee)) {
+		return nil, errors.New("aggregation bits and committee size mismatch")
+	}
+
+	indices := make([]phase0.ValidatorIndex, 0, bits.Count())
+	for i := uint64(0); i < bits.Len(); i++ {
+		if bits.BitAt(i) {
+			indices = append(indices, committee[i])
+		}
+	}
+
+	return indices, nil
+}
@@ -0,0 +1,68 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandAggregationBits(t *testing.T) {
+	tests := []struct {
+		name      string
+		bits      []byte
+		committee []phase0.ValidatorIndex
+		expected  []phase0.ValidatorIndex
+		err       string
+	}{
+		{
+			name:      "Empty",
+			bits:      []byte{0x10},
+			committee: []phase0.ValidatorIndex{1, 2, 3, 4},
+			expected:  []phase0.ValidatorIndex{},
+		},
+		{
+			name:      "Some",
+			bits:      []byte{0x15},
+			committee: []phase0.ValidatorIndex{10, 20, 30, 40},
+			expected:  []phase0.ValidatorIndex{10, 30},
+		},
+		{
+			name:      "All",
+			bits:      []byte{0x1f},
+			committee: []phase0.ValidatorIndex{10, 20, 30, 40},
+			expected:  []phase0.ValidatorIndex{10, 20, 30, 40},
+		},
+		{
+			name:      "SizeMismatch",
+			bits:      []byte{0x1f},
+			committee: []phase0.ValidatorIndex{10, 20, 30},
+			err:       "aggregation bits and committee size mismatch",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := expandAggregationBits(test.bits, test.committee)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expected, res)
+			}
+		})
+	}
+}
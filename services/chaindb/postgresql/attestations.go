@@ -14,6 +14,7 @@
 package postgresql
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"fmt"
@@ -24,12 +25,11 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // SetAttestation sets an attestation.
 func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attestation) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetAttestation")
+	ctx, span := startSpan(ctx, "SetAttestation")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -52,6 +52,26 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
 		headCorrect.Valid = true
 		headCorrect.Bool = *attestation.HeadCorrect
 	}
+	var sourceCorrect sql.NullBool
+	if attestation.SourceCorrect != nil {
+		sourceCorrect.Valid = true
+		sourceCorrect.Bool = *attestation.SourceCorrect
+	}
+	var inclusionTargetCorrect sql.NullBool
+	if attestation.InclusionTargetCorrect != nil {
+		inclusionTargetCorrect.Valid = true
+		inclusionTargetCorrect.Bool = *attestation.InclusionTargetCorrect
+	}
+	var inclusionHeadCorrect sql.NullBool
+	if attestation.InclusionHeadCorrect != nil {
+		inclusionHeadCorrect.Valid = true
+		inclusionHeadCorrect.Bool = *attestation.InclusionHeadCorrect
+	}
+	aggregationIndices := attestation.AggregationIndices
+	if s.compactAttestations {
+		// Indices are expanded from the aggregation bits and beacon committee when read.
+		aggregationIndices = nil
+	}
 	_, err := tx.Exec(ctx, `
       INSERT INTO t_attestations(f_inclusion_slot
                                 ,f_inclusion_block_root
@@ -68,8 +88,11 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
                                 ,f_canonical
                                 ,f_target_correct
                                 ,f_head_correct
+                                ,f_source_correct
+                                ,f_inclusion_target_correct
+                                ,f_inclusion_head_correct
 						  )
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18)
       ON CONFLICT (f_inclusion_slot,f_inclusion_block_root,f_inclusion_index) DO
       UPDATE
       SET f_slot = excluded.f_slot
@@ -84,6 +107,9 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
          ,f_canonical = excluded.f_canonical
          ,f_target_correct = excluded.f_target_correct
          ,f_head_correct = excluded.f_head_correct
+         ,f_source_correct = excluded.f_source_correct
+         ,f_inclusion_target_correct = excluded.f_inclusion_target_correct
+         ,f_inclusion_head_correct = excluded.f_inclusion_head_correct
 	  `,
 		attestation.InclusionSlot,
 		attestation.InclusionBlockRoot[:],
@@ -91,7 +117,7 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
 		attestation.Slot,
 		attestation.CommitteeIndex,
 		attestation.AggregationBits,
-		attestation.AggregationIndices,
+		aggregationIndices,
 		attestation.BeaconBlockRoot[:],
 		attestation.SourceEpoch,
 		attestation.SourceRoot[:],
@@ -100,14 +126,20 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
 		canonical,
 		targetCorrect,
 		headCorrect,
+		sourceCorrect,
+		inclusionTargetCorrect,
+		inclusionHeadCorrect,
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return s.runSQLHooks(ctx, tx, "attestation", attestationHookValues(attestation))
 }
 
 // SetAttestations sets multiple attestations.
 func (s *Service) SetAttestations(ctx context.Context, attestations []*chaindb.Attestation) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetAttestations")
+	ctx, span := startSpan(ctx, "SetAttestations")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -133,6 +165,9 @@ func (s *Service) SetAttestations(ctx context.Context, attestations []*chaindb.A
 			"f_canonical",
 			"f_target_correct",
 			"f_head_correct",
+			"f_source_correct",
+			"f_inclusion_target_correct",
+			"f_inclusion_head_correct",
 		},
 		pgx.CopyFromSlice(len(attestations), func(i int) ([]any, error) {
 			var canonical sql.NullBool
@@ -150,6 +185,26 @@ func (s *Service) SetAttestations(ctx context.Context, attestations []*chaindb.A
 				headCorrect.Valid = true
 				headCorrect.Bool = *attestations[i].HeadCorrect
 			}
+			var sourceCorrect sql.NullBool
+			if attestations[i].SourceCorrect != nil {
+				sourceCorrect.Valid = true
+				sourceCorrect.Bool = *attestations[i].SourceCorrect
+			}
+			var inclusionTargetCorrect sql.NullBool
+			if attestations[i].InclusionTargetCorrect != nil {
+				inclusionTargetCorrect.Valid = true
+				inclusionTargetCorrect.Bool = *attestations[i].InclusionTargetCorrect
+			}
+			var inclusionHeadCorrect sql.NullBool
+			if attestations[i].InclusionHeadCorrect != nil {
+				inclusionHeadCorrect.Valid = true
+				inclusionHeadCorrect.Bool = *attestations[i].InclusionHeadCorrect
+			}
+			aggregationIndices := attestations[i].AggregationIndices
+			if s.compactAttestations {
+				// Indices are expanded from the aggregation bits and beacon committee when read.
+				aggregationIndices = nil
+			}
 			return []any{
 				attestations[i].InclusionSlot,
 				attestations[i].InclusionBlockRoot[:],
@@ -157,7 +212,7 @@ func (s *Service) SetAttestations(ctx context.Context, attestations []*chaindb.A
 				attestations[i].Slot,
 				attestations[i].CommitteeIndex,
 				attestations[i].AggregationBits,
-				attestations[i].AggregationIndices,
+				aggregationIndices,
 				attestations[i].BeaconBlockRoot[:],
 				attestations[i].SourceEpoch,
 				attestations[i].SourceRoot[:],
@@ -166,14 +221,31 @@ func (s *Service) SetAttestations(ctx context.Context, attestations []*chaindb.A
 				canonical,
 				targetCorrect,
 				headCorrect,
+				sourceCorrect,
+				inclusionTargetCorrect,
+				inclusionHeadCorrect,
 			}, nil
 		}))
-	return err
+	if err != nil {
+		return err
+	}
+
+	if len(s.sqlHooks["attestation"]) > 0 {
+		values := make([]pgx.NamedArgs, len(attestations))
+		for i := range attestations {
+			values[i] = attestationHookValues(attestations[i])
+		}
+		if err := s.runSQLHooks(ctx, tx, "attestation", values...); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // AttestationsForBlock fetches all attestations made for the given block.
 func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Root) ([]*chaindb.Attestation, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "AttestationsForBlock")
+	ctx, span := startSpan(ctx, "AttestationsForBlock")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -186,7 +258,7 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
 		tx = s.tx(ctx)
 	}
 
-	rows, err := tx.Query(ctx, `
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
       SELECT f_inclusion_slot
             ,f_inclusion_block_root
             ,f_inclusion_index
@@ -202,10 +274,13 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
             ,f_canonical
             ,f_target_correct
             ,f_head_correct
+            ,f_source_correct
+            ,f_inclusion_target_correct
+            ,f_inclusion_head_correct
       FROM t_attestations
-      WHERE f_beacon_block_root = $1
+      WHERE f_beacon_block_root = $1%s
       ORDER BY f_inclusion_slot
-	          ,f_inclusion_index`,
+	          ,f_inclusion_index`, s.canonicalOnlyCondition()),
 		blockRoot[:],
 	)
 	if err != nil {
@@ -216,64 +291,23 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
 	attestations := make([]*chaindb.Attestation, 0)
 
 	for rows.Next() {
-		attestation := &chaindb.Attestation{}
-		var inclusionBlockRoot []byte
-		var aggregationIndices []uint64
-		var beaconBlockRoot []byte
-		var sourceRoot []byte
-		var targetRoot []byte
-		var canonical sql.NullBool
-		var targetCorrect sql.NullBool
-		var headCorrect sql.NullBool
-		err := rows.Scan(
-			&attestation.InclusionSlot,
-			&inclusionBlockRoot,
-			&attestation.InclusionIndex,
-			&attestation.Slot,
-			&attestation.CommitteeIndex,
-			&attestation.AggregationBits,
-			&aggregationIndices,
-			&beaconBlockRoot,
-			&attestation.SourceEpoch,
-			&sourceRoot,
-			&attestation.TargetEpoch,
-			&targetRoot,
-			&canonical,
-			&targetCorrect,
-			&headCorrect,
-		)
+		attestation, err := scanAttestation(rows)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan row")
-		}
-		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
-		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
-		for i := range aggregationIndices {
-			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
-		}
-		copy(attestation.BeaconBlockRoot[:], beaconBlockRoot)
-		copy(attestation.SourceRoot[:], sourceRoot)
-		copy(attestation.TargetRoot[:], targetRoot)
-		if canonical.Valid {
-			val := canonical.Bool
-			attestation.Canonical = &val
-		}
-		if targetCorrect.Valid {
-			val := targetCorrect.Bool
-			attestation.TargetCorrect = &val
-		}
-		if headCorrect.Valid {
-			val := headCorrect.Bool
-			attestation.HeadCorrect = &val
+			return nil, err
 		}
 		attestations = append(attestations, attestation)
 	}
 
+	if err := s.expandAttestationIndices(ctx, attestations); err != nil {
+		return nil, errors.Wrap(err, "failed to expand attestation indices")
+	}
+
 	return attestations, nil
 }
 
 // AttestationsInBlock fetches all attestations contained in the given block.
 func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root) ([]*chaindb.Attestation, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "AttestationsInBlock")
+	ctx, span := startSpan(ctx, "AttestationsInBlock")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -286,7 +320,7 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
 		tx = s.tx(ctx)
 	}
 
-	rows, err := tx.Query(ctx, `
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
       SELECT f_inclusion_slot
             ,f_inclusion_block_root
             ,f_inclusion_index
@@ -302,10 +336,13 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
             ,f_canonical
             ,f_target_correct
             ,f_head_correct
+            ,f_source_correct
+            ,f_inclusion_target_correct
+            ,f_inclusion_head_correct
       FROM t_attestations
-      WHERE f_inclusion_block_root = $1
+      WHERE f_inclusion_block_root = $1%s
       ORDER BY f_inclusion_slot
-	          ,f_inclusion_index`,
+	          ,f_inclusion_index`, s.canonicalOnlyCondition()),
 		blockRoot[:],
 	)
 	if err != nil {
@@ -316,58 +353,17 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
 	attestations := make([]*chaindb.Attestation, 0)
 
 	for rows.Next() {
-		attestation := &chaindb.Attestation{}
-		var inclusionBlockRoot []byte
-		var aggregationIndices []uint64
-		var beaconBlockRoot []byte
-		var sourceRoot []byte
-		var targetRoot []byte
-		var canonical sql.NullBool
-		var targetCorrect sql.NullBool
-		var headCorrect sql.NullBool
-		err := rows.Scan(
-			&attestation.InclusionSlot,
-			&inclusionBlockRoot,
-			&attestation.InclusionIndex,
-			&attestation.Slot,
-			&attestation.CommitteeIndex,
-			&attestation.AggregationBits,
-			&aggregationIndices,
-			&beaconBlockRoot,
-			&attestation.SourceEpoch,
-			&sourceRoot,
-			&attestation.TargetEpoch,
-			&targetRoot,
-			&canonical,
-			&targetCorrect,
-			&headCorrect,
-		)
+		attestation, err := scanAttestation(rows)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan row")
-		}
-		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
-		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
-		for i := range aggregationIndices {
-			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
-		}
-		copy(attestation.BeaconBlockRoot[:], beaconBlockRoot)
-		copy(attestation.SourceRoot[:], sourceRoot)
-		copy(attestation.TargetRoot[:], targetRoot)
-		if canonical.Valid {
-			val := canonical.Bool
-			attestation.Canonical = &val
-		}
-		if targetCorrect.Valid {
-			val := targetCorrect.Bool
-			attestation.TargetCorrect = &val
-		}
-		if headCorrect.Valid {
-			val := headCorrect.Bool
-			attestation.HeadCorrect = &val
+			return nil, err
 		}
 		attestations = append(attestations, attestation)
 	}
 
+	if err := s.expandAttestationIndices(ctx, attestations); err != nil {
+		return nil, errors.Wrap(err, "failed to expand attestation indices")
+	}
+
 	return attestations, nil
 }
 
@@ -375,7 +371,7 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
 // Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 // attestations for slots 2 and 3.
 func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.Attestation, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "AttestationsForSlotRange")
+	ctx, span := startSpan(ctx, "AttestationsForSlotRange")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -388,7 +384,7 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
 		tx = s.tx(ctx)
 	}
 
-	rows, err := tx.Query(ctx, `
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
       SELECT f_inclusion_slot
             ,f_inclusion_block_root
             ,f_inclusion_index
@@ -404,11 +400,14 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
             ,f_canonical
             ,f_target_correct
             ,f_head_correct
+            ,f_source_correct
+            ,f_inclusion_target_correct
+            ,f_inclusion_head_correct
       FROM t_attestations
       WHERE f_slot >= $1
-        AND f_slot < $2
+        AND f_slot < $2%s
       ORDER BY f_inclusion_slot
-	          ,f_inclusion_index`,
+	          ,f_inclusion_index`, s.canonicalOnlyCondition()),
 		startSlot,
 		endSlot,
 	)
@@ -420,58 +419,17 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
 	attestations := make([]*chaindb.Attestation, 0)
 
 	for rows.Next() {
-		attestation := &chaindb.Attestation{}
-		var inclusionBlockRoot []byte
-		var aggregationIndices []uint64
-		var beaconBlockRoot []byte
-		var sourceRoot []byte
-		var targetRoot []byte
-		var canonical sql.NullBool
-		var targetCorrect sql.NullBool
-		var headCorrect sql.NullBool
-		err := rows.Scan(
-			&attestation.InclusionSlot,
-			&inclusionBlockRoot,
-			&attestation.InclusionIndex,
-			&attestation.Slot,
-			&attestation.CommitteeIndex,
-			&attestation.AggregationBits,
-			&aggregationIndices,
-			&beaconBlockRoot,
-			&attestation.SourceEpoch,
-			&sourceRoot,
-			&attestation.TargetEpoch,
-			&targetRoot,
-			&canonical,
-			&targetCorrect,
-			&headCorrect,
-		)
+		attestation, err := scanAttestation(rows)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan row")
-		}
-		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
-		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
-		for i := range aggregationIndices {
-			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
-		}
-		copy(attestation.BeaconBlockRoot[:], beaconBlockRoot)
-		copy(attestation.SourceRoot[:], sourceRoot)
-		copy(attestation.TargetRoot[:], targetRoot)
-		if canonical.Valid {
-			val := canonical.Bool
-			attestation.Canonical = &val
-		}
-		if targetCorrect.Valid {
-			val := targetCorrect.Bool
-			attestation.TargetCorrect = &val
-		}
-		if headCorrect.Valid {
-			val := headCorrect.Bool
-			attestation.HeadCorrect = &val
+			return nil, err
 		}
 		attestations = append(attestations, attestation)
 	}
 
+	if err := s.expandAttestationIndices(ctx, attestations); err != nil {
+		return nil, errors.Wrap(err, "failed to expand attestation indices")
+	}
+
 	return attestations, nil
 }
 
@@ -479,7 +437,7 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
 // Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 // attestations in slots 2 and 3.
 func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.Attestation, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "AttestationsInSlotRange")
+	ctx, span := startSpan(ctx, "AttestationsInSlotRange")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -492,7 +450,7 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
 		tx = s.tx(ctx)
 	}
 
-	rows, err := tx.Query(ctx, `
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
       SELECT f_inclusion_slot
             ,f_inclusion_block_root
             ,f_inclusion_index
@@ -508,11 +466,14 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
             ,f_canonical
             ,f_target_correct
             ,f_head_correct
+            ,f_source_correct
+            ,f_inclusion_target_correct
+            ,f_inclusion_head_correct
       FROM t_attestations
       WHERE f_inclusion_slot >= $1
-        AND f_inclusion_slot < $2
+        AND f_inclusion_slot < $2%s
       ORDER BY f_inclusion_slot
-	          ,f_inclusion_index`,
+	          ,f_inclusion_index`, s.canonicalOnlyCondition()),
 		startSlot,
 		endSlot,
 	)
@@ -524,64 +485,122 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
 	attestations := make([]*chaindb.Attestation, 0)
 
 	for rows.Next() {
-		attestation := &chaindb.Attestation{}
-		var inclusionBlockRoot []byte
-		var aggregationIndices []uint64
-		var beaconBlockRoot []byte
-		var sourceRoot []byte
-		var targetRoot []byte
-		var canonical sql.NullBool
-		var targetCorrect sql.NullBool
-		var headCorrect sql.NullBool
-		err := rows.Scan(
-			&attestation.InclusionSlot,
-			&inclusionBlockRoot,
-			&attestation.InclusionIndex,
-			&attestation.Slot,
-			&attestation.CommitteeIndex,
-			&attestation.AggregationBits,
-			&aggregationIndices,
-			&beaconBlockRoot,
-			&attestation.SourceEpoch,
-			&sourceRoot,
-			&attestation.TargetEpoch,
-			&targetRoot,
-			&canonical,
-			&targetCorrect,
-			&headCorrect,
-		)
+		attestation, err := scanAttestation(rows)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan row")
+			return nil, err
 		}
-		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
-		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
-		for i := range aggregationIndices {
-			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
-		}
-		copy(attestation.BeaconBlockRoot[:], beaconBlockRoot)
-		copy(attestation.SourceRoot[:], sourceRoot)
-		copy(attestation.TargetRoot[:], targetRoot)
-		if canonical.Valid {
-			val := canonical.Bool
-			attestation.Canonical = &val
+		attestations = append(attestations, attestation)
+	}
+
+	if err := s.expandAttestationIndices(ctx, attestations); err != nil {
+		return nil, errors.Wrap(err, "failed to expand attestation indices")
+	}
+
+	return attestations, nil
+}
+
+// AttestationsForValidator fetches all attestations that include the given validator for the given epoch range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+// attestations for epochs 2 and 3.
+func (s *Service) AttestationsForValidator(ctx context.Context,
+	index phase0.ValidatorIndex,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	[]*chaindb.Attestation,
+	error,
+) {
+	ctx, span := startSpan(ctx, "AttestationsForValidator")
+	defer span.End()
+
+	slotsPerEpoch, err := s.slotsPerEpoch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
 		}
-		if targetCorrect.Valid {
-			val := targetCorrect.Bool
-			attestation.TargetCorrect = &val
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// A validator is a member of a single beacon committee each epoch, so find the
+	// validator's committees through the index on committee members and fetch only
+	// the attestations for those committees.
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
+      SELECT t_attestations.f_inclusion_slot
+            ,t_attestations.f_inclusion_block_root
+            ,t_attestations.f_inclusion_index
+            ,t_attestations.f_slot
+            ,t_attestations.f_committee_index
+            ,t_attestations.f_aggregation_bits
+            ,t_attestations.f_aggregation_indices
+            ,t_attestations.f_beacon_block_root
+            ,t_attestations.f_source_epoch
+            ,t_attestations.f_source_root
+            ,t_attestations.f_target_epoch
+            ,t_attestations.f_target_root
+            ,t_attestations.f_canonical
+            ,t_attestations.f_target_correct
+            ,t_attestations.f_head_correct
+            ,t_attestations.f_source_correct
+            ,t_attestations.f_inclusion_target_correct
+            ,t_attestations.f_inclusion_head_correct
+            ,t_beacon_committees.f_committee
+      FROM t_beacon_committees
+      JOIN t_attestations ON t_attestations.f_slot = t_beacon_committees.f_slot
+                         AND t_attestations.f_committee_index = t_beacon_committees.f_index
+      WHERE t_beacon_committees.f_committee @> ARRAY[$1]::BIGINT[]
+        AND t_beacon_committees.f_slot >= $2
+        AND t_beacon_committees.f_slot < $3%s
+      ORDER BY t_attestations.f_inclusion_slot
+              ,t_attestations.f_inclusion_block_root
+              ,t_attestations.f_inclusion_index`, s.canonicalOnlyCondition()),
+		index,
+		uint64(startEpoch)*slotsPerEpoch,
+		uint64(endEpoch)*slotsPerEpoch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attestations := make([]*chaindb.Attestation, 0)
+
+	for rows.Next() {
+		var committee []uint64
+		attestation, err := scanAttestation(rows, &committee)
+		if err != nil {
+			return nil, err
 		}
-		if headCorrect.Valid {
-			val := headCorrect.Bool
-			attestation.HeadCorrect = &val
+		if attestation.AggregationIndices == nil {
+			// Attestation is stored in compact form, so expand its indices from the committee.
+			members := make([]phase0.ValidatorIndex, len(committee))
+			for i := range committee {
+				members[i] = phase0.ValidatorIndex(committee[i])
+			}
+			attestation.AggregationIndices, err = expandAggregationBits(attestation.AggregationBits, members)
+			if err != nil {
+				return nil, err
+			}
 		}
 		attestations = append(attestations, attestation)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attestations")
+	}
 
-	return attestations, nil
+	// Remove attestations for the validator's committees that the validator did not attest to.
+	return filterAttestationsByIndices(attestations, []phase0.ValidatorIndex{index}), nil
 }
 
 // IndeterminateAttestationSlots fetches the slots in the given range with attestations that do not have a canonical status.
 func (s *Service) IndeterminateAttestationSlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "IndeterminateAttestationSlots")
+	ctx, span := startSpan(ctx, "IndeterminateAttestationSlots")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -627,7 +646,7 @@ func (s *Service) IndeterminateAttestationSlots(ctx context.Context, minSlot pha
 //
 //nolint:gocyclo,maintidx
 func (s *Service) Attestations(ctx context.Context, filter *chaindb.AttestationFilter) ([]*chaindb.Attestation, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "Attestations")
+	ctx, span := startSpan(ctx, "Attestations")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -660,6 +679,9 @@ SELECT f_inclusion_slot
       ,f_canonical
       ,f_target_correct
       ,f_head_correct
+      ,f_source_correct
+      ,f_inclusion_target_correct
+      ,f_inclusion_head_correct
 FROM t_attestations`)
 
 	conditions := make([]string, 0)
@@ -686,12 +708,25 @@ FROM t_attestations`)
 
 	if len(filter.ValidatorIndices) > 0 {
 		queryVals = append(queryVals, filter.ValidatorIndices)
-		conditions = append(conditions, fmt.Sprintf("f_aggregation_indices && $%d", len(queryVals)))
+		// Attestations stored in compact form are matched against the members of their
+		// committee whose aggregation bit is set, so that the limit applies to matching rows.
+		// Bitlists are little-endian within each byte, as is get_bit().
+		conditions = append(conditions, fmt.Sprintf(`(f_aggregation_indices && $%d OR (f_aggregation_indices IS NULL AND EXISTS (
+    SELECT 1
+    FROM t_beacon_committees
+        ,UNNEST(t_beacon_committees.f_committee) WITH ORDINALITY AS m(f_validator_index,f_position)
+    WHERE t_beacon_committees.f_slot = t_attestations.f_slot
+      AND t_beacon_committees.f_index = t_attestations.f_committee_index
+      AND t_beacon_committees.f_committee && $%d
+      AND m.f_validator_index = ANY($%d)
+      AND get_bit(t_attestations.f_aggregation_bits,(m.f_position-1)::INT) = 1)))`, len(queryVals), len(queryVals), len(queryVals)))
 	}
 
 	if filter.Canonical != nil {
 		queryVals = append(queryVals, *filter.Canonical)
 		conditions = append(conditions, fmt.Sprintf("f_canonical = $%d", len(queryVals)))
+	} else if s.canonicalOnly {
+		conditions = append(conditions, "f_canonical = true")
 	}
 
 	if filter.HeadCorrect != nil {
@@ -699,6 +734,12 @@ FROM t_attestations`)
 		conditions = append(conditions, fmt.Sprintf("f_head_correct = $%d", len(queryVals)))
 	}
 
+	if filter.Cursor != nil {
+		queryVals = append(queryVals, filter.Cursor.InclusionSlot, filter.Cursor.InclusionBlockRoot[:], filter.Cursor.InclusionIndex)
+		conditions = append(conditions, fmt.Sprintf("(f_inclusion_slot,f_inclusion_block_root,f_inclusion_index) %s ($%d,$%d,$%d)",
+			cursorComparison(filter.Order), len(queryVals)-2, len(queryVals)-1, len(queryVals)))
+	}
+
 	if len(conditions) > 0 {
 		queryBuilder.WriteString("\nWHERE ")
 		queryBuilder.WriteString(strings.Join(conditions, "\n  AND "))
@@ -707,10 +748,10 @@ FROM t_attestations`)
 	switch filter.Order {
 	case chaindb.OrderEarliest:
 		queryBuilder.WriteString(`
-ORDER BY f_inclusion_slot, f_inclusion_index`)
+ORDER BY f_inclusion_slot, f_inclusion_block_root, f_inclusion_index`)
 	case chaindb.OrderLatest:
 		queryBuilder.WriteString(`
-ORDER BY f_inclusion_slot DESC,f_inclusion_index DESC`)
+ORDER BY f_inclusion_slot DESC,f_inclusion_block_root DESC,f_inclusion_index DESC`)
 	default:
 		return nil, errors.New("no order specified")
 	}
@@ -740,75 +781,147 @@ LIMIT $%d`, len(queryVals)))
 
 	attestations := make([]*chaindb.Attestation, 0)
 
-	boolTrue := true
-	boolFalse := false
-
-	inclusionBlockRoot := make([]byte, phase0.RootLength)
-	aggregationIndices := make([]uint64, 0)
-	beaconBlockRoot := make([]byte, phase0.RootLength)
-	sourceRoot := make([]byte, phase0.RootLength)
-	targetRoot := make([]byte, phase0.RootLength)
-	canonical := sql.NullBool{}
-	targetCorrect := sql.NullBool{}
-	headCorrect := sql.NullBool{}
 	for rows.Next() {
-		attestation := &chaindb.Attestation{}
-		err := rows.Scan(
-			&attestation.InclusionSlot,
-			&inclusionBlockRoot,
-			&attestation.InclusionIndex,
-			&attestation.Slot,
-			&attestation.CommitteeIndex,
-			&attestation.AggregationBits,
-			&aggregationIndices,
-			&beaconBlockRoot,
-			&attestation.SourceEpoch,
-			&sourceRoot,
-			&attestation.TargetEpoch,
-			&targetRoot,
-			&canonical,
-			&targetCorrect,
-			&headCorrect,
-		)
+		attestation, err := scanAttestation(rows)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan row")
-		}
-		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
-		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
-		for i := range aggregationIndices {
-			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
-		}
-		copy(attestation.BeaconBlockRoot[:], beaconBlockRoot)
-		copy(attestation.SourceRoot[:], sourceRoot)
-		copy(attestation.TargetRoot[:], targetRoot)
-		if canonical.Valid && canonical.Bool {
-			attestation.Canonical = &boolTrue
-		}
-		if canonical.Valid && !canonical.Bool {
-			attestation.Canonical = &boolFalse
-		}
-		if targetCorrect.Valid && targetCorrect.Bool {
-			attestation.TargetCorrect = &boolTrue
-		}
-		if targetCorrect.Valid && !targetCorrect.Bool {
-			attestation.TargetCorrect = &boolFalse
-		}
-		if headCorrect.Valid && headCorrect.Bool {
-			attestation.HeadCorrect = &boolTrue
-		}
-		if headCorrect.Valid && !headCorrect.Bool {
-			attestation.HeadCorrect = &boolFalse
+			return nil, err
 		}
 		attestations = append(attestations, attestation)
 	}
 
-	// Always return order of inclusion slot then inclusion index.
+	// Always return order of inclusion slot, inclusion block root, then inclusion index.
 	sort.Slice(attestations, func(i int, j int) bool {
 		if attestations[i].InclusionSlot != attestations[j].InclusionSlot {
 			return attestations[i].InclusionSlot < attestations[j].InclusionSlot
 		}
+		if cmp := bytes.Compare(attestations[i].InclusionBlockRoot[:], attestations[j].InclusionBlockRoot[:]); cmp != 0 {
+			return cmp < 0
+		}
 		return attestations[i].InclusionIndex < attestations[j].InclusionIndex
 	})
 
+	if err := s.expandAttestationIndices(ctx, attestations); err != nil {
+		return nil, errors.Wrap(err, "failed to expand attestation indices")
+	}
+
 	return attestations, nil
 }
+
+// scanAttestation scans an attestation from a row that holds the columns of
+// t_attestations in the order selected by the attestation providers, followed
+// by any additional columns, which are scanned in to extra.
+// Indices are not present for attestations stored in compact form, so are left
+// for the caller to expand.
+func scanAttestation(rows pgx.Rows, extra ...any) (*chaindb.Attestation, error) {
+	attestation := &chaindb.Attestation{}
+	var inclusionBlockRoot []byte
+	var aggregationIndices []uint64
+	var beaconBlockRoot []byte
+	var sourceRoot []byte
+	var targetRoot []byte
+	var canonical sql.NullBool
+	var targetCorrect sql.NullBool
+	var headCorrect sql.NullBool
+	var sourceCorrect sql.NullBool
+	var inclusionTargetCorrect sql.NullBool
+	var inclusionHeadCorrect sql.NullBool
+	dest := []any{
+		&attestation.InclusionSlot,
+		&inclusionBlockRoot,
+		&attestation.InclusionIndex,
+		&attestation.Slot,
+		&attestation.CommitteeIndex,
+		&attestation.AggregationBits,
+		&aggregationIndices,
+		&beaconBlockRoot,
+		&attestation.SourceEpoch,
+		&sourceRoot,
+		&attestation.TargetEpoch,
+		&targetRoot,
+		&canonical,
+		&targetCorrect,
+		&headCorrect,
+		&sourceCorrect,
+		&inclusionTargetCorrect,
+		&inclusionHeadCorrect,
+	}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return nil, errors.Wrap(err, "failed to scan row")
+	}
+	copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
+	if aggregationIndices != nil {
+		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
+		for i := range aggregationIndices {
+			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
+		}
+	}
+	copy(attestation.BeaconBlockRoot[:], beaconBlockRoot)
+	copy(attestation.SourceRoot[:], sourceRoot)
+	copy(attestation.TargetRoot[:], targetRoot)
+	if canonical.Valid {
+		val := canonical.Bool
+		attestation.Canonical = &val
+	}
+	if targetCorrect.Valid {
+		val := targetCorrect.Bool
+		attestation.TargetCorrect = &val
+	}
+	if headCorrect.Valid {
+		val := headCorrect.Bool
+		attestation.HeadCorrect = &val
+	}
+	if sourceCorrect.Valid {
+		val := sourceCorrect.Bool
+		attestation.SourceCorrect = &val
+	}
+	if inclusionTargetCorrect.Valid {
+		val := inclusionTargetCorrect.Bool
+		attestation.InclusionTargetCorrect = &val
+	}
+	if inclusionHeadCorrect.Valid {
+		val := inclusionHeadCorrect.Bool
+		attestation.InclusionHeadCorrect = &val
+	}
+
+	return attestation, nil
+}
+
+// filterAttestationsByIndices returns the attestations that contain at least one of the given indices.
+func filterAttestationsByIndices(attestations []*chaindb.Attestation, indices []phase0.ValidatorIndex) []*chaindb.Attestation {
+	indexMap := make(map[phase0.ValidatorIndex]struct{}, len(indices))
+	for _, index := range indices {
+		indexMap[index] = struct{}{}
+	}
+
+	res := make([]*chaindb.Attestation, 0, len(attestations))
+	for _, attestation := range attestations {
+		for _, index := range attestation.AggregationIndices {
+			if _, exists := indexMap[index]; exists {
+				res = append(res, attestation)
+				break
+			}
+		}
+	}
+
+	return res
+}
+
+// PruneAttestations prunes attestations included before the given slot.
+func (s *Service) PruneAttestations(ctx context.Context, to phase0.Slot) error {
+	ctx, span := startSpan(ctx, "PruneAttestations")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+DELETE FROM t_attestations
+WHERE f_inclusion_slot < $1
+`,
+		to,
+	)
+
+	return err
+}
@@ -0,0 +1,170 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestAttestationsForValidator(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(ctx,
+		WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	slotsPerEpoch, err := s.slotsPerEpoch(ctx)
+	require.NoError(t, err)
+
+	epoch := phase0.Epoch(1 << 30)
+	slot := phase0.Slot(uint64(epoch) * slotsPerEpoch)
+	nextEpochSlot := slot + phase0.Slot(slotsPerEpoch)
+	validatorA := phase0.ValidatorIndex(1 << 40)
+	validatorB := validatorA + 1
+	validatorC := validatorA + 2
+
+	block := &chaindb.Block{
+		Slot:          nextEpochSlot + 1,
+		Root:          phase0.Root{0xcb, 0x01},
+		Graffiti:      make([]byte, 32),
+		BodyRoot:      phase0.Root{0xcb, 0x02},
+		StateRoot:     phase0.Root{0xcb, 0x03},
+		ETH1BlockHash: make([]byte, 32),
+	}
+	require.NoError(t, s.SetBlock(ctx, block))
+	for _, committee := range []*chaindb.BeaconCommittee{
+		{Slot: slot, Index: 0, Committee: []phase0.ValidatorIndex{validatorA, validatorB}},
+		{Slot: slot, Index: 1, Committee: []phase0.ValidatorIndex{validatorC}},
+		{Slot: nextEpochSlot, Index: 0, Committee: []phase0.ValidatorIndex{validatorB, validatorA}},
+	} {
+		require.NoError(t, s.SetBeaconCommittee(ctx, committee))
+	}
+
+	attestation := func(index uint64,
+		attestationSlot phase0.Slot,
+		committeeIndex phase0.CommitteeIndex,
+		aggregationBits []byte,
+		aggregationIndices []phase0.ValidatorIndex,
+	) *chaindb.Attestation {
+		return &chaindb.Attestation{
+			InclusionSlot:      block.Slot,
+			InclusionBlockRoot: block.Root,
+			InclusionIndex:     index,
+			Slot:               attestationSlot,
+			CommitteeIndex:     committeeIndex,
+			AggregationBits:    aggregationBits,
+			AggregationIndices: aggregationIndices,
+			BeaconBlockRoot:    phase0.Root{0xcb, 0x04},
+			TargetRoot:         phase0.Root{0xcb, 0x05},
+		}
+	}
+	attestations := []*chaindb.Attestation{
+		// Validator A in the first epoch.
+		attestation(0, slot, 0, []byte{0x05}, []phase0.ValidatorIndex{validatorA}),
+		// Validator A's committee, but only validator B.
+		attestation(1, slot, 0, []byte{0x06}, []phase0.ValidatorIndex{validatorB}),
+		// Another committee.
+		attestation(2, slot, 1, []byte{0x03}, []phase0.ValidatorIndex{validatorC}),
+		// Validator A in the next epoch.
+		attestation(3, nextEpochSlot, 0, []byte{0x06}, []phase0.ValidatorIndex{validatorA}),
+	}
+	for _, attestation := range attestations {
+		require.NoError(t, s.SetAttestation(ctx, attestation))
+	}
+
+	check := func(t *testing.T) {
+		t.Helper()
+
+		res, err := s.AttestationsForValidator(ctx, validatorA, epoch, epoch+1)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Equal(t, uint64(0), res[0].InclusionIndex)
+		require.Equal(t, []phase0.ValidatorIndex{validatorA}, res[0].AggregationIndices)
+
+		res, err = s.AttestationsForValidator(ctx, validatorA, epoch, epoch+2)
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+		require.Equal(t, uint64(3), res[1].InclusionIndex)
+		require.Equal(t, []phase0.ValidatorIndex{validatorA}, res[1].AggregationIndices)
+
+		res, err = s.AttestationsForValidator(ctx, validatorC, epoch, epoch+2)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Equal(t, uint64(2), res[0].InclusionIndex)
+	}
+
+	// Attestations with their aggregation indices.
+	check(t)
+
+	// Attestations stored in compact form are expanded from the committee.
+	_, err = s.tx(ctx).Exec(ctx, `
+UPDATE t_attestations
+SET f_aggregation_indices = NULL
+WHERE f_inclusion_block_root = $1`,
+		block.Root[:],
+	)
+	require.NoError(t, err)
+	check(t)
+
+	// Committees are found through the index on their members, which is created by upgrade 57.
+	membersIndexUsed := func() bool {
+		_, err := s.tx(ctx).Exec(ctx, "SET LOCAL enable_seqscan = off")
+		require.NoError(t, err)
+		defer func() {
+			_, err := s.tx(ctx).Exec(ctx, "SET LOCAL enable_seqscan = on")
+			require.NoError(t, err)
+		}()
+
+		rows, err := s.tx(ctx).Query(ctx, `
+EXPLAIN SELECT f_slot
+FROM t_beacon_committees
+WHERE f_committee @> ARRAY[$1]::BIGINT[]`,
+			validatorA,
+		)
+		require.NoError(t, err)
+		defer rows.Close()
+		used := false
+		for rows.Next() {
+			var line string
+			require.NoError(t, rows.Scan(&line))
+			if strings.Contains(line, "i_beacon_committees_2") {
+				used = true
+			}
+		}
+		require.NoError(t, rows.Err())
+
+		return used
+	}
+	require.True(t, membersIndexUsed())
+
+	// Without the index the results are unchanged.
+	require.NoError(t, dropBeaconCommitteeMembersIndex(ctx, s))
+	require.False(t, membersIndexUsed())
+	check(t)
+
+	require.NoError(t, createBeaconCommitteeMembersIndex(ctx, s))
+	require.True(t, membersIndexUsed())
+	check(t)
+}
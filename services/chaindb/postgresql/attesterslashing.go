@@ -15,16 +15,18 @@ package postgresql
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // SetAttesterSlashing sets an attester slashing.
 func (s *Service) SetAttesterSlashing(ctx context.Context, attesterSlashing *chaindb.AttesterSlashing) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetAttesterSlashing")
+	ctx, span := startSpan(ctx, "SetAttesterSlashing")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -103,10 +105,9 @@ func (s *Service) SetAttesterSlashing(ctx context.Context, attesterSlashing *cha
 	return err
 }
 
-// AttesterSlashingsForSlotRange fetches all attester slashings made for the given slot range.
-// It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
-func (s *Service) AttesterSlashingsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.AttesterSlashing, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "AttesterSlashingsForSlotRange")
+// AttesterSlashings provides attester slashings according to the filter.
+func (s *Service) AttesterSlashings(ctx context.Context, filter *chaindb.AttesterSlashingFilter) ([]*chaindb.AttesterSlashing, error) {
+	ctx, span := startSpan(ctx, "AttesterSlashings")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -119,150 +120,93 @@ func (s *Service) AttesterSlashingsForSlotRange(ctx context.Context, minSlot pha
 		tx = s.tx(ctx)
 	}
 
-	rows, err := tx.Query(ctx, `
-      SELECT f_inclusion_slot
-            ,f_inclusion_block_root
-            ,f_inclusion_index
-            ,f_attestation_1_indices
-            ,f_attestation_1_slot
-            ,f_attestation_1_committee_index
-            ,f_attestation_1_beacon_block_root
-            ,f_attestation_1_source_epoch
-            ,f_attestation_1_source_root
-            ,f_attestation_1_target_epoch
-            ,f_attestation_1_target_root
-            ,f_attestation_1_signature
-            ,f_attestation_2_indices
-            ,f_attestation_2_slot
-            ,f_attestation_2_committee_index
-            ,f_attestation_2_beacon_block_root
-            ,f_attestation_2_source_epoch
-            ,f_attestation_2_source_root
-            ,f_attestation_2_target_epoch
-            ,f_attestation_2_target_root
-            ,f_attestation_2_signature
-      FROM t_attester_slashings
-      WHERE f_inclusion_slot >= $1
-        AND f_inclusion_slot < $2
-		AND f_inclusion_slot IN (SELECT f_slot FROM t_blocks WHERE f_slot >= $1 AND f_slot < $2 AND (f_canonical IS NULL OR f_canonical = true))
-      ORDER BY f_inclusion_slot
-	          ,f_inclusion_index`,
-		minSlot,
-		maxSlot,
-	)
-	if err != nil {
-		return nil, err
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_inclusion_slot
+      ,f_inclusion_block_root
+      ,f_inclusion_index
+      ,f_attestation_1_indices
+      ,f_attestation_1_slot
+      ,f_attestation_1_committee_index
+      ,f_attestation_1_beacon_block_root
+      ,f_attestation_1_source_epoch
+      ,f_attestation_1_source_root
+      ,f_attestation_1_target_epoch
+      ,f_attestation_1_target_root
+      ,f_attestation_1_signature
+      ,f_attestation_2_indices
+      ,f_attestation_2_slot
+      ,f_attestation_2_committee_index
+      ,f_attestation_2_beacon_block_root
+      ,f_attestation_2_source_epoch
+      ,f_attestation_2_source_root
+      ,f_attestation_2_target_epoch
+      ,f_attestation_2_target_root
+      ,f_attestation_2_signature
+FROM t_attester_slashings`)
+
+	conditions := make([]string, 0)
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("f_inclusion_slot >= $%d", len(queryVals)))
 	}
-	defer rows.Close()
 
-	attesterSlashings := make([]*chaindb.AttesterSlashing, 0)
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("f_inclusion_slot <= $%d", len(queryVals)))
+	}
 
-	var inclusionBlockRoot []byte
-	var attestation1Indices []uint64
-	var attestation1BeaconBlockRoot []byte
-	var attestation1SourceRoot []byte
-	var attestation1TargetRoot []byte
-	var attestation1Signature []byte
-	var attestation2Indices []uint64
-	var attestation2BeaconBlockRoot []byte
-	var attestation2SourceRoot []byte
-	var attestation2TargetRoot []byte
-	var attestation2Signature []byte
-	for rows.Next() {
-		attesterSlashing := &chaindb.AttesterSlashing{}
-		err := rows.Scan(
-			&attesterSlashing.InclusionSlot,
-			&inclusionBlockRoot,
-			&attesterSlashing.InclusionIndex,
-			&attestation1Indices,
-			&attesterSlashing.Attestation1Slot,
-			&attesterSlashing.Attestation1CommitteeIndex,
-			&attestation1BeaconBlockRoot,
-			&attesterSlashing.Attestation1SourceEpoch,
-			&attestation1SourceRoot,
-			&attesterSlashing.Attestation1TargetEpoch,
-			&attestation1TargetRoot,
-			&attestation1Signature,
-			&attestation2Indices,
-			&attesterSlashing.Attestation2Slot,
-			&attesterSlashing.Attestation2CommitteeIndex,
-			&attestation2BeaconBlockRoot,
-			&attesterSlashing.Attestation2SourceEpoch,
-			&attestation2SourceRoot,
-			&attesterSlashing.Attestation2TargetEpoch,
-			&attestation2TargetRoot,
-			&attestation2Signature,
-		)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan row")
-		}
-		copy(attesterSlashing.InclusionBlockRoot[:], inclusionBlockRoot)
-		attesterSlashing.Attestation1Indices = make([]phase0.ValidatorIndex, len(attestation1Indices))
-		for i := range attestation1Indices {
-			attesterSlashing.Attestation1Indices[i] = phase0.ValidatorIndex(attestation1Indices[i])
-		}
-		copy(attesterSlashing.Attestation1BeaconBlockRoot[:], attestation1BeaconBlockRoot)
-		copy(attesterSlashing.Attestation1SourceRoot[:], attestation1SourceRoot)
-		copy(attesterSlashing.Attestation1TargetRoot[:], attestation1TargetRoot)
-		copy(attesterSlashing.Attestation1Signature[:], attestation1Signature)
-		attesterSlashing.Attestation2Indices = make([]phase0.ValidatorIndex, len(attestation2Indices))
-		for i := range attestation2Indices {
-			attesterSlashing.Attestation2Indices[i] = phase0.ValidatorIndex(attestation2Indices[i])
-		}
-		copy(attesterSlashing.Attestation2BeaconBlockRoot[:], attestation2BeaconBlockRoot)
-		copy(attesterSlashing.Attestation2SourceRoot[:], attestation2SourceRoot)
-		copy(attesterSlashing.Attestation2TargetRoot[:], attestation2TargetRoot)
-		copy(attesterSlashing.Attestation2Signature[:], attestation2Signature)
-		attesterSlashings = append(attesterSlashings, attesterSlashing)
+	if len(filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, filter.ValidatorIndices)
+		conditions = append(conditions, fmt.Sprintf("EXISTS(SELECT 1 FROM unnest(f_attestation_1_indices) AS i WHERE i = ANY($%d) AND i = ANY(f_attestation_2_indices))", len(queryVals)))
 	}
 
-	return attesterSlashings, nil
-}
+	if filter.Canonical != nil {
+		queryVals = append(queryVals, *filter.Canonical)
+		conditions = append(conditions, inclusionCanonicalCondition("t_attester_slashings", len(queryVals)))
+	}
 
-// AttesterSlashingsForValidator fetches all attester slashings made for the given validator.
-// It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
-func (s *Service) AttesterSlashingsForValidator(ctx context.Context, index phase0.ValidatorIndex) ([]*chaindb.AttesterSlashing, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "AttesterSlashingsForValidator")
-	defer span.End()
+	if filter.ExcludeNonCanonical {
+		conditions = append(conditions, inclusionNotNonCanonicalCondition("t_attester_slashings"))
+	}
 
-	tx := s.tx(ctx)
-	if tx == nil {
-		ctx, err := s.BeginROTx(ctx)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to begin transaction")
+	if len(conditions) > 0 {
+		queryBuilder.WriteString("\nWHERE ")
+		queryBuilder.WriteString(strings.Join(conditions, "\n  AND "))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_inclusion_slot, f_inclusion_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_inclusion_slot DESC,f_inclusion_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
 		}
-		defer s.CommitROTx(ctx)
-		tx = s.tx(ctx)
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
 	}
 
-	rows, err := tx.Query(ctx, `
-      SELECT f_inclusion_slot
-            ,f_inclusion_block_root
-            ,f_inclusion_index
-            ,f_attestation_1_indices
-            ,f_attestation_1_slot
-            ,f_attestation_1_committee_index
-            ,f_attestation_1_beacon_block_root
-            ,f_attestation_1_source_epoch
-            ,f_attestation_1_source_root
-            ,f_attestation_1_target_epoch
-            ,f_attestation_1_target_root
-            ,f_attestation_1_signature
-            ,f_attestation_2_indices
-            ,f_attestation_2_slot
-            ,f_attestation_2_committee_index
-            ,f_attestation_2_beacon_block_root
-            ,f_attestation_2_source_epoch
-            ,f_attestation_2_source_root
-            ,f_attestation_2_target_epoch
-            ,f_attestation_2_target_root
-            ,f_attestation_2_signature
-      FROM t_attester_slashings
-      WHERE $1 = ANY(f_attestation_1_indices)
-        AND $1 = ANY(f_attestation_2_indices)
-      ORDER BY f_inclusion_slot
-	          ,f_inclusion_index`,
-		index,
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
 	)
 	if err != nil {
 		return nil, err
@@ -330,5 +274,36 @@ func (s *Service) AttesterSlashingsForValidator(ctx context.Context, index phase
 		attesterSlashings = append(attesterSlashings, attesterSlashing)
 	}
 
+	// Always return order of inclusion slot then inclusion index.
+	sort.Slice(attesterSlashings, func(i int, j int) bool {
+		if attesterSlashings[i].InclusionSlot != attesterSlashings[j].InclusionSlot {
+			return attesterSlashings[i].InclusionSlot < attesterSlashings[j].InclusionSlot
+		}
+		return attesterSlashings[i].InclusionIndex < attesterSlashings[j].InclusionIndex
+	})
 	return attesterSlashings, nil
 }
+
+// AttesterSlashingsForSlotRange fetches all attester slashings made for the given slot range.
+// It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
+func (s *Service) AttesterSlashingsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.AttesterSlashing, error) {
+	if maxSlot <= minSlot {
+		return []*chaindb.AttesterSlashing{}, nil
+	}
+	to := maxSlot - 1
+
+	return s.AttesterSlashings(ctx, &chaindb.AttesterSlashingFilter{
+		From:                &minSlot,
+		To:                  &to,
+		ExcludeNonCanonical: true,
+	})
+}
+
+// AttesterSlashingsForValidator fetches all attester slashings made for the given validator.
+// It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
+func (s *Service) AttesterSlashingsForValidator(ctx context.Context, index phase0.ValidatorIndex) ([]*chaindb.AttesterSlashing, error) {
+	return s.AttesterSlashings(ctx, &chaindb.AttesterSlashingFilter{
+		ValidatorIndices:    []phase0.ValidatorIndex{index},
+		ExcludeNonCanonical: true,
+	})
+}
@@ -0,0 +1,364 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// auditPruneInterval is the interval between prunes of the audit log.
+const auditPruneInterval = time.Hour
+
+// defaultAuditModule is the module recorded for writes made other than by a module.
+const defaultAuditModule = "chaind"
+
+// auditedPrefixes are the prefixes of the names of operations that write to the database.
+var auditedPrefixes = []string{"Set", "Delete", "Prune", "Record", "Remove"}
+
+// AuditRecord is a context tag for the writes made by a transaction, for the audit log.
+type AuditRecord struct{}
+
+// auditRecord holds the writes made by a transaction, for the audit log.
+type auditRecord struct {
+	mutex      sync.Mutex
+	started    time.Time
+	operations map[string]int64
+	rows       int64
+	// closed is set once the record has been written, after which further
+	// writes, such as that of the record itself, are not recorded.
+	closed bool
+}
+
+// newAuditRecord creates a new audit record.
+func newAuditRecord() *auditRecord {
+	return &auditRecord{
+		started:    time.Now(),
+		operations: make(map[string]int64),
+	}
+}
+
+// isAuditedOperation returns true if the operation writes to the database.
+// Only exported operations are audited, as internal operations are called by them.
+func isAuditedOperation(operation string) bool {
+	for _, prefix := range auditedPrefixes {
+		if strings.HasPrefix(operation, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recordAuditOperation records a call to the operation in the audit record of the transaction, if any.
+func recordAuditOperation(ctx context.Context, operation string) {
+	if !isAuditedOperation(operation) {
+		return
+	}
+	record, ok := ctx.Value(&AuditRecord{}).(*auditRecord)
+	if !ok {
+		return
+	}
+
+	record.mutex.Lock()
+	if !record.closed {
+		record.operations[operation]++
+	}
+	record.mutex.Unlock()
+}
+
+// recordAuditRows records rows written by a statement in the audit record of the transaction, if any.
+func recordAuditRows(ctx context.Context, rows int64) {
+	if rows <= 0 {
+		return
+	}
+	record, ok := ctx.Value(&AuditRecord{}).(*auditRecord)
+	if !ok {
+		return
+	}
+
+	record.mutex.Lock()
+	if !record.closed {
+		record.rows += rows
+	}
+	record.mutex.Unlock()
+}
+
+// writtenRows returns the number of rows written by a statement with the given command tag.
+func writtenRows(tag pgconn.CommandTag) int64 {
+	if tag.Insert() || tag.Update() || tag.Delete() {
+		return tag.RowsAffected()
+	}
+
+	return 0
+}
+
+// ForModule provides the service with writes attributed to the given module
+// in the audit log.  If the audit log is not enabled then the service itself
+// is returned.
+func (s *Service) ForModule(module string) *Service {
+	if !s.audit {
+		return s
+	}
+
+	labelled := *s
+	labelled.module = module
+
+	return &labelled
+}
+
+// writeAuditEntry writes the audit entry for the writes made by the transaction, if any.
+// The entry is written in a nested transaction so that a failure to write it, for
+// example because the audit log has yet to be created, does not prevent the
+// transaction from committing.
+func (s *Service) writeAuditEntry(ctx context.Context, tx pgx.Tx) {
+	record, ok := ctx.Value(&AuditRecord{}).(*auditRecord)
+	if !ok {
+		return
+	}
+
+	record.mutex.Lock()
+	record.closed = true
+	operations := record.operations
+	rows := record.rows
+	record.mutex.Unlock()
+	if len(operations) == 0 {
+		return
+	}
+
+	module := s.module
+	if module == "" {
+		module = defaultAuditModule
+	}
+	data, err := json.Marshal(operations)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode audit log operations")
+		return
+	}
+
+	nestedTx, err := tx.Begin(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to begin audit log transaction")
+		return
+	}
+	if _, err := nestedTx.Exec(ctx, `
+INSERT INTO t_audit_log(f_timestamp
+                       ,f_started
+                       ,f_tx_id
+                       ,f_module
+                       ,f_operations
+                       ,f_rows)
+VALUES($1,$2,$3,$4,$5,$6)
+`,
+		time.Now(),
+		record.started,
+		s.txID(ctx),
+		module,
+		string(data),
+		rows,
+	); err != nil {
+		log.Warn().Err(err).Msg("Failed to write audit log entry")
+		if err := nestedTx.Rollback(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to roll back audit log transaction")
+		}
+		return
+	}
+	if err := nestedTx.Commit(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to commit audit log transaction")
+	}
+}
+
+// AuditEntries provides audit log entries according to the filter.
+func (s *Service) AuditEntries(ctx context.Context, filter *chaindb.AuditEntryFilter) ([]*chaindb.AuditEntry, error) {
+	ctx, span := startSpan(ctx, "AuditEntries")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_timestamp
+      ,f_started
+      ,f_tx_id
+      ,f_module
+      ,f_operations
+      ,f_rows
+FROM t_audit_log`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_timestamp >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_timestamp <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.Modules) > 0 {
+		queryVals = append(queryVals, filter.Modules)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_module = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.Operations) > 0 {
+		queryVals = append(queryVals, filter.Operations)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_operations ?| $%d`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_timestamp`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_timestamp DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*chaindb.AuditEntry, 0)
+	for rows.Next() {
+		entry := &chaindb.AuditEntry{}
+		err := rows.Scan(
+			&entry.Timestamp,
+			&entry.Started,
+			&entry.TxID,
+			&entry.Module,
+			&entry.Operations,
+			&entry.Rows,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		entries = append(entries, entry)
+	}
+
+	// Always return order of timestamp.
+	sort.Slice(entries, func(i int, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+
+	return entries, rows.Err()
+}
+
+// PruneAuditLog prunes audit log entries written before the given time.
+func (s *Service) PruneAuditLog(ctx context.Context, before time.Time) error {
+	ctx, span := startSpan(ctx, "PruneAuditLog")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+DELETE FROM t_audit_log
+WHERE f_timestamp < $1
+`,
+		before,
+	); err != nil {
+		return errors.Wrap(err, "failed to prune audit log")
+	}
+
+	return nil
+}
+
+// pruneAuditLogPeriodically prunes audit log entries older than the retention period
+// until the context is done.
+func (s *Service) pruneAuditLogPeriodically(ctx context.Context) {
+	ticker := time.NewTicker(auditPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.pruneAuditLog(ctx); err != nil {
+				log.Warn().Err(err).Msg("Failed to prune audit log")
+			}
+		}
+	}
+}
+
+// pruneAuditLog prunes audit log entries older than the retention period.
+func (s *Service) pruneAuditLog(ctx context.Context) error {
+	ctx, cancel, err := s.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.PruneAuditLog(ctx, time.Now().Add(-s.auditRetention)); err != nil {
+		cancel()
+		return err
+	}
+	if err := s.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
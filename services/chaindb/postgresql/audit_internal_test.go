@@ -0,0 +1,68 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsAuditedOperation(t *testing.T) {
+	require.True(t, isAuditedOperation("SetBlock"))
+	require.True(t, isAuditedOperation("PruneAttestations"))
+	require.True(t, isAuditedOperation("RemoveWatchedValidator"))
+	require.False(t, isAuditedOperation("Blocks"))
+	require.False(t, isAuditedOperation("setWithdrawals"))
+}
+
+func TestAuditRecord(t *testing.T) {
+	record := newAuditRecord()
+	ctx := context.WithValue(context.Background(), &AuditRecord{}, record)
+
+	recordAuditOperation(ctx, "SetBlock")
+	recordAuditOperation(ctx, "SetBlock")
+	recordAuditOperation(ctx, "SetAttestations")
+	recordAuditOperation(ctx, "Blocks")
+	recordAuditRows(ctx, writtenRows(pgconn.NewCommandTag("INSERT 0 1")))
+	recordAuditRows(ctx, writtenRows(pgconn.NewCommandTag("UPDATE 3")))
+	recordAuditRows(ctx, writtenRows(pgconn.NewCommandTag("SELECT 10")))
+	recordAuditRows(ctx, 5)
+	require.Equal(t, map[string]int64{"SetBlock": 2, "SetAttestations": 1}, record.operations)
+	require.Equal(t, int64(9), record.rows)
+
+	// Writes after the record is closed are not recorded.
+	record.closed = true
+	recordAuditOperation(ctx, "SetBlock")
+	recordAuditRows(ctx, 1)
+	require.Equal(t, map[string]int64{"SetBlock": 2, "SetAttestations": 1}, record.operations)
+	require.Equal(t, int64(9), record.rows)
+
+	// Writes outside of an audited transaction are ignored.
+	recordAuditOperation(context.Background(), "SetBlock")
+	recordAuditRows(context.Background(), 1)
+}
+
+func TestForModule(t *testing.T) {
+	s := &Service{}
+	require.Same(t, s, s.ForModule("blocks"))
+
+	s.audit = true
+	labelled := s.ForModule("blocks")
+	require.NotSame(t, s, labelled)
+	require.Equal(t, "blocks", labelled.module)
+	require.Equal(t, "", s.module)
+}
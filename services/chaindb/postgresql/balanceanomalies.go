@@ -0,0 +1,255 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetBalanceAnomalies sets multiple balance anomalies.
+func (s *Service) SetBalanceAnomalies(ctx context.Context, anomalies []*chaindb.BalanceAnomaly) error {
+	ctx, span := startSpan(ctx, "SetBalanceAnomalies")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	// Create a savepoint in case the copy fails.
+	nestedTx, err := tx.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create nested transaction")
+	}
+
+	_, err = nestedTx.CopyFrom(ctx,
+		pgx.Identifier{"t_balance_anomalies"},
+		balanceAnomalyColumns,
+		pgx.CopyFromSlice(len(anomalies), func(i int) ([]any, error) {
+			return balanceAnomalyValues(anomalies[i]), nil
+		}))
+
+	if err == nil {
+		if err := nestedTx.Commit(ctx); err != nil {
+			return errors.Wrap(err, "failed to commit nested transaction")
+		}
+	} else {
+		if err := nestedTx.Rollback(ctx); err != nil {
+			return errors.Wrap(err, "failed to roll back nested transaction")
+		}
+
+		log.Debug().Err(err).Msg("Failed to copy insert balance anomalies; applying one at a time")
+		for _, anomaly := range anomalies {
+			if _, err := tx.Exec(ctx, `
+INSERT INTO t_balance_anomalies(f_validator_index
+                               ,f_epoch
+                               ,f_reason
+                               ,f_previous_balance
+                               ,f_balance
+                               ,f_withdrawn
+                               ,f_deposited
+                               ,f_delta
+                               ,f_expected_min_delta
+                               ,f_expected_max_delta
+                               ,f_deviation)
+VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+ON CONFLICT (f_validator_index,f_epoch) DO
+UPDATE
+SET f_reason = excluded.f_reason
+   ,f_previous_balance = excluded.f_previous_balance
+   ,f_balance = excluded.f_balance
+   ,f_withdrawn = excluded.f_withdrawn
+   ,f_deposited = excluded.f_deposited
+   ,f_delta = excluded.f_delta
+   ,f_expected_min_delta = excluded.f_expected_min_delta
+   ,f_expected_max_delta = excluded.f_expected_max_delta
+   ,f_deviation = excluded.f_deviation
+`,
+				balanceAnomalyValues(anomaly)...,
+			); err != nil {
+				return errors.Wrap(err, "failed to set balance anomaly")
+			}
+		}
+	}
+
+	return nil
+}
+
+// balanceAnomalyColumns are the columns of t_balance_anomalies, in the order of balanceAnomalyValues.
+var balanceAnomalyColumns = []string{
+	"f_validator_index",
+	"f_epoch",
+	"f_reason",
+	"f_previous_balance",
+	"f_balance",
+	"f_withdrawn",
+	"f_deposited",
+	"f_delta",
+	"f_expected_min_delta",
+	"f_expected_max_delta",
+	"f_deviation",
+}
+
+// balanceAnomalyValues provides the values of a balance anomaly for t_balance_anomalies.
+func balanceAnomalyValues(anomaly *chaindb.BalanceAnomaly) []any {
+	return []any{
+		anomaly.ValidatorIndex,
+		anomaly.Epoch,
+		anomaly.Reason,
+		anomaly.PreviousBalance,
+		anomaly.Balance,
+		anomaly.Withdrawn,
+		anomaly.Deposited,
+		anomaly.Delta,
+		anomaly.ExpectedMinDelta,
+		anomaly.ExpectedMaxDelta,
+		anomaly.Deviation,
+	}
+}
+
+// BalanceAnomalies provides balance anomalies according to the filter.
+func (s *Service) BalanceAnomalies(ctx context.Context, filter *chaindb.BalanceAnomalyFilter) ([]*chaindb.BalanceAnomaly, error) {
+	ctx, span := startSpan(ctx, "BalanceAnomalies")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_validator_index
+      ,f_epoch
+      ,f_reason
+      ,f_previous_balance
+      ,f_balance
+      ,f_withdrawn
+      ,f_deposited
+      ,f_delta
+      ,f_expected_min_delta
+      ,f_expected_max_delta
+      ,f_deviation
+FROM t_balance_anomalies`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_validator_index = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.Reasons) > 0 {
+		queryVals = append(queryVals, filter.Reasons)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_reason = ANY($%d)`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch,f_validator_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch DESC,f_validator_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	anomalies := make([]*chaindb.BalanceAnomaly, 0)
+	for rows.Next() {
+		anomaly := &chaindb.BalanceAnomaly{}
+		err := rows.Scan(
+			&anomaly.ValidatorIndex,
+			&anomaly.Epoch,
+			&anomaly.Reason,
+			&anomaly.PreviousBalance,
+			&anomaly.Balance,
+			&anomaly.Withdrawn,
+			&anomaly.Deposited,
+			&anomaly.Delta,
+			&anomaly.ExpectedMinDelta,
+			&anomaly.ExpectedMaxDelta,
+			&anomaly.Deviation,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+
+	// Always return order of epoch then validator index.
+	sort.Slice(anomalies, func(i int, j int) bool {
+		if anomalies[i].Epoch != anomalies[j].Epoch {
+			return anomalies[i].Epoch < anomalies[j].Epoch
+		}
+		return anomalies[i].ValidatorIndex < anomalies[j].ValidatorIndex
+	})
+
+	return anomalies, rows.Err()
+}
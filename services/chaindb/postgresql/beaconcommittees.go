@@ -22,14 +22,13 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
 // SetBeaconCommittee sets a beacon committee.
 func (s *Service) SetBeaconCommittee(ctx context.Context, beaconCommittee *chaindb.BeaconCommittee) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetBeaconCommittee")
+	ctx, span := startSpan(ctx, "SetBeaconCommittee")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -37,7 +36,7 @@ func (s *Service) SetBeaconCommittee(ctx context.Context, beaconCommittee *chain
 		return ErrNoTransaction
 	}
 
-	_, err := tx.Exec(ctx, `
+	return s.queueExec(ctx, tx, `
       INSERT INTO t_beacon_committees(f_slot
                                      ,f_index
                                      ,f_committee)
@@ -50,8 +49,6 @@ func (s *Service) SetBeaconCommittee(ctx context.Context, beaconCommittee *chain
 		beaconCommittee.Index,
 		beaconCommittee.Committee,
 	)
-
-	return err
 }
 
 // BeaconCommittees fetches the beacon committees matching the filter.
@@ -61,7 +58,7 @@ func (s *Service) BeaconCommittees(ctx context.Context,
 	[]*chaindb.BeaconCommittee,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "BeaconCommittees")
+	ctx, span := startSpan(ctx, "BeaconCommittees")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -104,6 +101,13 @@ FROM t_beacon_committees`)
 		queryVals = append(queryVals, filter.CommitteeIndices)
 		queryBuilder.WriteString(fmt.Sprintf(`
 %s f_index = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_committee && $%d::BIGINT[]`, wherestr, len(queryVals)))
 	}
 
 	switch filter.Order {
@@ -171,9 +175,139 @@ LIMIT $%d`, len(queryVals)))
 	return committees, nil
 }
 
+// BeaconCommitteeMembers fetches the members of the beacon committees matching the filter,
+// with one entry per validator in each committee.  The limit of the filter applies to members.
+func (s *Service) BeaconCommitteeMembers(ctx context.Context,
+	filter *chaindb.BeaconCommitteeFilter,
+) (
+	[]*chaindb.AttesterDuty,
+	error,
+) {
+	ctx, span := startSpan(ctx, "BeaconCommitteeMembers")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	// Positions from the ordinality are 1-based.
+	queryBuilder.WriteString(`
+SELECT f_slot
+      ,f_index
+      ,m.f_validator_index
+      ,m.f_position - 1
+FROM t_beacon_committees
+CROSS JOIN LATERAL UNNEST(f_committee) WITH ORDINALITY AS m(f_validator_index, f_position)`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.CommitteeIndices) > 0 {
+		queryVals = append(queryVals, filter.CommitteeIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_index = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.ValidatorIndices) > 0 {
+		// Check the array as well as the member, to skip committees without any of the validators before expanding them.
+		queryVals = append(queryVals, filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_committee && $%d::BIGINT[]
+  AND m.f_validator_index = ANY($%d)`, wherestr, len(queryVals), len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot, f_index, m.f_position`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC, f_index DESC, m.f_position DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query failed")
+	}
+	defer rows.Close()
+	span.AddEvent("Ran query")
+
+	members := make([]*chaindb.AttesterDuty, 0)
+	for rows.Next() {
+		member := &chaindb.AttesterDuty{}
+		err := rows.Scan(
+			&member.Slot,
+			&member.Committee,
+			&member.ValidatorIndex,
+			&member.CommitteeIndex,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		members = append(members, member)
+	}
+	span.AddEvent("Compiled results", trace.WithAttributes(attribute.Int("entries", len(members))))
+
+	// Always return order of slot then committee index then position in committee.
+	sort.Slice(members, func(i int, j int) bool {
+		if members[i].Slot != members[j].Slot {
+			return members[i].Slot < members[j].Slot
+		}
+		if members[i].Committee != members[j].Committee {
+			return members[i].Committee < members[j].Committee
+		}
+		return members[i].CommitteeIndex < members[j].CommitteeIndex
+	})
+
+	return members, nil
+}
+
 // BeaconCommitteeBySlotAndIndex fetches the beacon committee with the given slot and index.
 func (s *Service) BeaconCommitteeBySlotAndIndex(ctx context.Context, slot phase0.Slot, index phase0.CommitteeIndex) (*chaindb.BeaconCommittee, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "BeaconCommitteeBySlotAndIndex")
+	ctx, span := startSpan(ctx, "BeaconCommitteeBySlotAndIndex")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -215,7 +349,7 @@ func (s *Service) BeaconCommitteeBySlotAndIndex(ctx context.Context, slot phase0
 
 // AttesterDuties fetches the attester duties at the given slot range for the given validator indices.
 func (s *Service) AttesterDuties(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot, validatorIndices []phase0.ValidatorIndex) ([]*chaindb.AttesterDuty, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "AttesterDuties")
+	ctx, span := startSpan(ctx, "AttesterDuties")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -281,3 +415,23 @@ func (s *Service) AttesterDuties(ctx context.Context, startSlot phase0.Slot, end
 
 	return res, nil
 }
+
+// PruneBeaconCommittees prunes beacon committees for slots before the given slot.
+func (s *Service) PruneBeaconCommittees(ctx context.Context, to phase0.Slot) error {
+	ctx, span := startSpan(ctx, "PruneBeaconCommittees")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+DELETE FROM t_beacon_committees
+WHERE f_slot < $1
+`,
+		to,
+	)
+
+	return err
+}
@@ -109,6 +109,15 @@ func TestBeaconCommittees(t *testing.T) {
 			},
 			committees: `[{"Slot":2000000001,"Index":1,"Committee":[1,2,3]},{"Slot":2000000001,"Index":2,"Committee":[4,5,6]}]`,
 		},
+		{
+			name: "Validators",
+			filter: &chaindb.BeaconCommitteeFilter{
+				From:             slotPtr(2000000001),
+				To:               slotPtr(2000000003),
+				ValidatorIndices: []phase0.ValidatorIndex{2, 8},
+			},
+			committees: `[{"Slot":2000000001,"Index":1,"Committee":[1,2,3]},{"Slot":2000000002,"Index":1,"Committee":[7,8,9]}]`,
+		},
 		{
 			name: "ReverseLimit2",
 			filter: &chaindb.BeaconCommitteeFilter{
@@ -136,3 +145,73 @@ func TestBeaconCommittees(t *testing.T) {
 		})
 	}
 }
+
+func TestBeaconCommitteeMembers(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithServer(os.Getenv("CHAINDB_SERVER")),
+		postgresql.WithPort(atoi(os.Getenv("CHAINDB_PORT"))),
+		postgresql.WithUser(os.Getenv("CHAINDB_USER")),
+		postgresql.WithPassword(os.Getenv("CHAINDB_PASSWORD")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	require.NoError(t, s.SetBeaconCommittee(ctx, &chaindb.BeaconCommittee{
+		Slot:      2000000001,
+		Index:     1,
+		Committee: []phase0.ValidatorIndex{1, 2, 3},
+	}))
+	require.NoError(t, s.SetBeaconCommittee(ctx, &chaindb.BeaconCommittee{
+		Slot:      2000000002,
+		Index:     1,
+		Committee: []phase0.ValidatorIndex{3, 4},
+	}))
+
+	tests := []struct {
+		name    string
+		filter  *chaindb.BeaconCommitteeFilter
+		members string
+	}{
+		{
+			name: "All",
+			filter: &chaindb.BeaconCommitteeFilter{
+				From: slotPtr(2000000001),
+				To:   slotPtr(2000000002),
+			},
+			members: `[{"Slot":2000000001,"Committee":1,"ValidatorIndex":1,"CommitteeIndex":0},{"Slot":2000000001,"Committee":1,"ValidatorIndex":2,"CommitteeIndex":1},{"Slot":2000000001,"Committee":1,"ValidatorIndex":3,"CommitteeIndex":2},{"Slot":2000000002,"Committee":1,"ValidatorIndex":3,"CommitteeIndex":0},{"Slot":2000000002,"Committee":1,"ValidatorIndex":4,"CommitteeIndex":1}]`,
+		},
+		{
+			name: "Validator",
+			filter: &chaindb.BeaconCommitteeFilter{
+				From:             slotPtr(2000000001),
+				To:               slotPtr(2000000002),
+				ValidatorIndices: []phase0.ValidatorIndex{3},
+			},
+			members: `[{"Slot":2000000001,"Committee":1,"ValidatorIndex":3,"CommitteeIndex":2},{"Slot":2000000002,"Committee":1,"ValidatorIndex":3,"CommitteeIndex":0}]`,
+		},
+		{
+			name: "ReverseLimit2",
+			filter: &chaindb.BeaconCommitteeFilter{
+				From:  slotPtr(2000000001),
+				To:    slotPtr(2000000002),
+				Limit: 2,
+				Order: chaindb.OrderLatest,
+			},
+			members: `[{"Slot":2000000002,"Committee":1,"ValidatorIndex":3,"CommitteeIndex":0},{"Slot":2000000002,"Committee":1,"ValidatorIndex":4,"CommitteeIndex":1}]`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := s.BeaconCommitteeMembers(ctx, test.filter)
+			require.NoError(t, err)
+			output, err := json.Marshal(res)
+			require.NoError(t, err)
+			require.Equal(t, test.members, string(output))
+		})
+	}
+}
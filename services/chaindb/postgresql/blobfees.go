@@ -0,0 +1,248 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetBlobFees sets multiple blob fees.
+func (s *Service) SetBlobFees(ctx context.Context, fees []*chaindb.BlobFee) error {
+	ctx, span := startSpan(ctx, "SetBlobFees")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	// Create a savepoint in case the copy fails.
+	nestedTx, err := tx.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create nested transaction")
+	}
+
+	_, err = nestedTx.CopyFrom(ctx,
+		pgx.Identifier{"t_blob_fees"},
+		blobFeeColumns,
+		pgx.CopyFromSlice(len(fees), func(i int) ([]any, error) {
+			return blobFeeValues(fees[i]), nil
+		}))
+
+	if err == nil {
+		if err := nestedTx.Commit(ctx); err != nil {
+			return errors.Wrap(err, "failed to commit nested transaction")
+		}
+	} else {
+		if err := nestedTx.Rollback(ctx); err != nil {
+			return errors.Wrap(err, "failed to roll back nested transaction")
+		}
+
+		log.Debug().Err(err).Msg("Failed to copy insert blob fees; applying one at a time")
+		for _, fee := range fees {
+			if _, err := tx.Exec(ctx, `
+INSERT INTO t_blob_fees(f_slot
+                       ,f_execution_block_number
+                       ,f_excess_blob_gas
+                       ,f_blob_gas_used
+                       ,f_target_blob_gas
+                       ,f_blob_base_fee
+                       ,f_window_blocks
+                       ,f_window_blob_gas_used
+                       ,f_window_mean_blob_base_fee
+                       ,f_window_min_blob_base_fee
+                       ,f_window_max_blob_base_fee)
+VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+ON CONFLICT (f_slot) DO
+UPDATE
+SET f_execution_block_number = excluded.f_execution_block_number
+   ,f_excess_blob_gas = excluded.f_excess_blob_gas
+   ,f_blob_gas_used = excluded.f_blob_gas_used
+   ,f_target_blob_gas = excluded.f_target_blob_gas
+   ,f_blob_base_fee = excluded.f_blob_base_fee
+   ,f_window_blocks = excluded.f_window_blocks
+   ,f_window_blob_gas_used = excluded.f_window_blob_gas_used
+   ,f_window_mean_blob_base_fee = excluded.f_window_mean_blob_base_fee
+   ,f_window_min_blob_base_fee = excluded.f_window_min_blob_base_fee
+   ,f_window_max_blob_base_fee = excluded.f_window_max_blob_base_fee
+`,
+				blobFeeValues(fee)...,
+			); err != nil {
+				return errors.Wrap(err, "failed to set blob fee")
+			}
+		}
+	}
+
+	return nil
+}
+
+// blobFeeColumns are the columns of t_blob_fees, in the order of blobFeeValues.
+var blobFeeColumns = []string{
+	"f_slot",
+	"f_execution_block_number",
+	"f_excess_blob_gas",
+	"f_blob_gas_used",
+	"f_target_blob_gas",
+	"f_blob_base_fee",
+	"f_window_blocks",
+	"f_window_blob_gas_used",
+	"f_window_mean_blob_base_fee",
+	"f_window_min_blob_base_fee",
+	"f_window_max_blob_base_fee",
+}
+
+// blobFeeValues provides the values of a blob fee for t_blob_fees.
+func blobFeeValues(fee *chaindb.BlobFee) []any {
+	return []any{
+		fee.Slot,
+		fee.ExecutionBlockNumber,
+		fee.ExcessBlobGas,
+		fee.BlobGasUsed,
+		fee.TargetBlobGas,
+		numericFromBigInt(fee.BlobBaseFee),
+		fee.WindowBlocks,
+		fee.WindowBlobGasUsed,
+		numericFromBigInt(fee.WindowMeanBlobBaseFee),
+		numericFromBigInt(fee.WindowMinBlobBaseFee),
+		numericFromBigInt(fee.WindowMaxBlobBaseFee),
+	}
+}
+
+// BlobFees provides blob fees according to the filter.
+func (s *Service) BlobFees(ctx context.Context, filter *chaindb.BlobFeeFilter) ([]*chaindb.BlobFee, error) {
+	ctx, span := startSpan(ctx, "BlobFees")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_slot
+      ,f_execution_block_number
+      ,f_excess_blob_gas
+      ,f_blob_gas_used
+      ,f_target_blob_gas
+      ,f_blob_base_fee
+      ,f_window_blocks
+      ,f_window_blob_gas_used
+      ,f_window_mean_blob_base_fee
+      ,f_window_min_blob_base_fee
+      ,f_window_max_blob_base_fee
+FROM t_blob_fees`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot <= $%d`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fees := make([]*chaindb.BlobFee, 0)
+	var blobBaseFee decimal.NullDecimal
+	var windowMeanBlobBaseFee decimal.NullDecimal
+	var windowMinBlobBaseFee decimal.NullDecimal
+	var windowMaxBlobBaseFee decimal.NullDecimal
+	for rows.Next() {
+		fee := &chaindb.BlobFee{}
+		err := rows.Scan(
+			&fee.Slot,
+			&fee.ExecutionBlockNumber,
+			&fee.ExcessBlobGas,
+			&fee.BlobGasUsed,
+			&fee.TargetBlobGas,
+			&blobBaseFee,
+			&fee.WindowBlocks,
+			&fee.WindowBlobGasUsed,
+			&windowMeanBlobBaseFee,
+			&windowMinBlobBaseFee,
+			&windowMaxBlobBaseFee,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		fee.BlobBaseFee = bigIntFromNumeric(blobBaseFee)
+		fee.WindowMeanBlobBaseFee = bigIntFromNumeric(windowMeanBlobBaseFee)
+		fee.WindowMinBlobBaseFee = bigIntFromNumeric(windowMinBlobBaseFee)
+		fee.WindowMaxBlobBaseFee = bigIntFromNumeric(windowMaxBlobBaseFee)
+		fees = append(fees, fee)
+	}
+
+	// Always return order of slot.
+	sort.Slice(fees, func(i int, j int) bool {
+		return fees[i].Slot < fees[j].Slot
+	})
+
+	return fees, rows.Err()
+}
@@ -21,7 +21,6 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // BlobSidecars provides blob sidecars according to the filter.
@@ -31,7 +30,7 @@ func (s *Service) BlobSidecars(ctx context.Context,
 	[]*chaindb.BlobSidecar,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "BlobSidecars")
+	ctx, span := startSpan(ctx, "BlobSidecars")
 	defer span.End()
 
 	tx := s.tx(ctx)
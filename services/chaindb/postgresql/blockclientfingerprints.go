@@ -0,0 +1,200 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetBlockClientFingerprints sets block client fingerprints.
+func (s *Service) SetBlockClientFingerprints(ctx context.Context, fingerprints []*chaindb.BlockClientFingerprint) error {
+	ctx, span := startSpan(ctx, "SetBlockClientFingerprints")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, fingerprint := range fingerprints {
+		var consensusClient sql.NullString
+		if fingerprint.ConsensusClient != "" {
+			consensusClient.Valid = true
+			consensusClient.String = fingerprint.ConsensusClient
+		}
+		var executionClient sql.NullString
+		if fingerprint.ExecutionClient != "" {
+			executionClient.Valid = true
+			executionClient.String = fingerprint.ExecutionClient
+		}
+
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_block_client_fingerprints(f_block_root
+                                       ,f_slot
+                                       ,f_consensus_client
+                                       ,f_execution_client
+                                       )
+VALUES($1,$2,$3,$4)
+ON CONFLICT (f_block_root) DO
+UPDATE
+SET f_slot = excluded.f_slot
+   ,f_consensus_client = excluded.f_consensus_client
+   ,f_execution_client = excluded.f_execution_client
+`,
+			fingerprint.BlockRoot[:],
+			fingerprint.Slot,
+			consensusClient,
+			executionClient,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BlockClientFingerprints provides block client fingerprints according to the filter.
+func (s *Service) BlockClientFingerprints(ctx context.Context,
+	filter *chaindb.BlockClientFingerprintFilter,
+) (
+	[]*chaindb.BlockClientFingerprint,
+	error,
+) {
+	ctx, span := startSpan(ctx, "BlockClientFingerprints")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_block_root
+      ,f_slot
+      ,f_consensus_client
+      ,f_execution_client
+FROM t_block_client_fingerprints`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.ConsensusClients) > 0 {
+		queryVals = append(queryVals, filter.ConsensusClients)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_consensus_client = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.ExecutionClients) > 0 {
+		queryVals = append(queryVals, filter.ExecutionClients)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_execution_client = ANY($%d)`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot, f_block_root`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC, f_block_root DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fingerprints := make([]*chaindb.BlockClientFingerprint, 0)
+	blockRoot := make([]byte, phase0.RootLength)
+	for rows.Next() {
+		fingerprint := &chaindb.BlockClientFingerprint{}
+		var consensusClient sql.NullString
+		var executionClient sql.NullString
+		err := rows.Scan(
+			&blockRoot,
+			&fingerprint.Slot,
+			&consensusClient,
+			&executionClient,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(fingerprint.BlockRoot[:], blockRoot)
+		fingerprint.ConsensusClient = consensusClient.String
+		fingerprint.ExecutionClient = executionClient.String
+		fingerprints = append(fingerprints, fingerprint)
+	}
+
+	// Always return order of slot then block root.
+	sort.Slice(fingerprints, func(i int, j int) bool {
+		if fingerprints[i].Slot != fingerprints[j].Slot {
+			return fingerprints[i].Slot < fingerprints[j].Slot
+		}
+		return bytes.Compare(fingerprints[i].BlockRoot[:], fingerprints[j].BlockRoot[:]) < 0
+	})
+
+	return fingerprints, nil
+}
@@ -0,0 +1,240 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetBlockClientReleases sets block client releases.
+func (s *Service) SetBlockClientReleases(ctx context.Context, releases []*chaindb.BlockClientRelease) error {
+	ctx, span := startSpan(ctx, "SetBlockClientReleases")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, release := range releases {
+		var consensusClient sql.NullString
+		var consensusVersion sql.NullString
+		var consensusVersionMatched sql.NullBool
+		if release.ConsensusVersion != "" {
+			consensusClient.Valid = true
+			consensusClient.String = release.ConsensusClient
+			consensusVersion.Valid = true
+			consensusVersion.String = release.ConsensusVersion
+			consensusVersionMatched.Valid = true
+			consensusVersionMatched.Bool = release.ConsensusVersionMatched
+		}
+		var executionClient sql.NullString
+		var executionVersion sql.NullString
+		var executionVersionMatched sql.NullBool
+		if release.ExecutionVersion != "" {
+			executionClient.Valid = true
+			executionClient.String = release.ExecutionClient
+			executionVersion.Valid = true
+			executionVersion.String = release.ExecutionVersion
+			executionVersionMatched.Valid = true
+			executionVersionMatched.Bool = release.ExecutionVersionMatched
+		}
+
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_block_client_releases(f_block_root
+                                   ,f_slot
+                                   ,f_consensus_client
+                                   ,f_consensus_version
+                                   ,f_consensus_version_matched
+                                   ,f_execution_client
+                                   ,f_execution_version
+                                   ,f_execution_version_matched
+                                   )
+VALUES($1,$2,$3,$4,$5,$6,$7,$8)
+ON CONFLICT (f_block_root) DO
+UPDATE
+SET f_slot = excluded.f_slot
+   ,f_consensus_client = excluded.f_consensus_client
+   ,f_consensus_version = excluded.f_consensus_version
+   ,f_consensus_version_matched = excluded.f_consensus_version_matched
+   ,f_execution_client = excluded.f_execution_client
+   ,f_execution_version = excluded.f_execution_version
+   ,f_execution_version_matched = excluded.f_execution_version_matched
+`,
+			release.BlockRoot[:],
+			release.Slot,
+			consensusClient,
+			consensusVersion,
+			consensusVersionMatched,
+			executionClient,
+			executionVersion,
+			executionVersionMatched,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BlockClientReleases provides block client releases according to the filter.
+func (s *Service) BlockClientReleases(ctx context.Context,
+	filter *chaindb.BlockClientReleaseFilter,
+) (
+	[]*chaindb.BlockClientRelease,
+	error,
+) {
+	ctx, span := startSpan(ctx, "BlockClientReleases")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_block_root
+      ,f_slot
+      ,f_consensus_client
+      ,f_consensus_version
+      ,f_consensus_version_matched
+      ,f_execution_client
+      ,f_execution_version
+      ,f_execution_version_matched
+FROM t_block_client_releases`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.ConsensusVersions) > 0 {
+		queryVals = append(queryVals, filter.ConsensusVersions)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_consensus_version = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.ExecutionVersions) > 0 {
+		queryVals = append(queryVals, filter.ExecutionVersions)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_execution_version = ANY($%d)`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot, f_block_root`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC, f_block_root DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	releases := make([]*chaindb.BlockClientRelease, 0)
+	blockRoot := make([]byte, phase0.RootLength)
+	for rows.Next() {
+		release := &chaindb.BlockClientRelease{}
+		var consensusClient sql.NullString
+		var consensusVersion sql.NullString
+		var consensusVersionMatched sql.NullBool
+		var executionClient sql.NullString
+		var executionVersion sql.NullString
+		var executionVersionMatched sql.NullBool
+		err := rows.Scan(
+			&blockRoot,
+			&release.Slot,
+			&consensusClient,
+			&consensusVersion,
+			&consensusVersionMatched,
+			&executionClient,
+			&executionVersion,
+			&executionVersionMatched,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(release.BlockRoot[:], blockRoot)
+		release.ConsensusClient = consensusClient.String
+		release.ConsensusVersion = consensusVersion.String
+		release.ConsensusVersionMatched = consensusVersionMatched.Bool
+		release.ExecutionClient = executionClient.String
+		release.ExecutionVersion = executionVersion.String
+		release.ExecutionVersionMatched = executionVersionMatched.Bool
+		releases = append(releases, release)
+	}
+
+	// Always return order of slot then block root.
+	sort.Slice(releases, func(i int, j int) bool {
+		if releases[i].Slot != releases[j].Slot {
+			return releases[i].Slot < releases[j].Slot
+		}
+		return bytes.Compare(releases[i].BlockRoot[:], releases[j].BlockRoot[:]) < 0
+	})
+
+	return releases, nil
+}
@@ -0,0 +1,246 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetBlockHeaders sets multiple block headers.
+func (s *Service) SetBlockHeaders(ctx context.Context, headers []*chaindb.BlockHeader) error {
+	ctx, span := startSpan(ctx, "SetBlockHeaders")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	// Create a savepoint in case the copy fails.
+	nestedTx, err := tx.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create nested transaction")
+	}
+
+	_, err = nestedTx.CopyFrom(ctx,
+		pgx.Identifier{"t_block_headers"},
+		blockHeaderColumns,
+		pgx.CopyFromSlice(len(headers), func(i int) ([]any, error) {
+			return blockHeaderValues(headers[i]), nil
+		}))
+
+	if err == nil {
+		if err := nestedTx.Commit(ctx); err != nil {
+			return errors.Wrap(err, "failed to commit nested transaction")
+		}
+	} else {
+		if err := nestedTx.Rollback(ctx); err != nil {
+			return errors.Wrap(err, "failed to roll back nested transaction")
+		}
+
+		log.Debug().Err(err).Msg("Failed to copy insert block headers; applying one at a time")
+		for _, header := range headers {
+			if _, err := tx.Exec(ctx, `
+INSERT INTO t_block_headers(f_slot
+                           ,f_proposer_index
+                           ,f_root
+                           ,f_parent_root
+                           ,f_state_root
+                           ,f_body_root)
+VALUES($1,$2,$3,$4,$5,$6)
+ON CONFLICT (f_root) DO
+UPDATE
+SET f_slot = excluded.f_slot
+   ,f_proposer_index = excluded.f_proposer_index
+   ,f_parent_root = excluded.f_parent_root
+   ,f_state_root = excluded.f_state_root
+   ,f_body_root = excluded.f_body_root
+`,
+				blockHeaderValues(header)...,
+			); err != nil {
+				return errors.Wrap(err, "failed to set block header")
+			}
+		}
+	}
+
+	return nil
+}
+
+// blockHeaderColumns are the columns of t_block_headers, in the order of blockHeaderValues.
+var blockHeaderColumns = []string{
+	"f_slot",
+	"f_proposer_index",
+	"f_root",
+	"f_parent_root",
+	"f_state_root",
+	"f_body_root",
+}
+
+// blockHeaderValues provides the values of a block header for t_block_headers.
+func blockHeaderValues(header *chaindb.BlockHeader) []any {
+	return []any{
+		header.Slot,
+		header.ProposerIndex,
+		header.Root[:],
+		header.ParentRoot[:],
+		header.StateRoot[:],
+		header.BodyRoot[:],
+	}
+}
+
+// BlockHeaders provides block headers according to the filter.
+func (s *Service) BlockHeaders(ctx context.Context, filter *chaindb.BlockHeaderFilter) ([]*chaindb.BlockHeader, error) {
+	ctx, span := startSpan(ctx, "BlockHeaders")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_slot
+      ,f_proposer_index
+      ,f_root
+      ,f_parent_root
+      ,f_state_root
+      ,f_body_root
+FROM t_block_headers`)
+
+	conditions := make([]string, 0)
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("f_slot >= $%d", len(queryVals)))
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("f_slot <= $%d", len(queryVals)))
+	}
+
+	if len(filter.Roots) > 0 {
+		roots := make([][]byte, len(filter.Roots))
+		for i := range filter.Roots {
+			roots[i] = filter.Roots[i][:]
+		}
+		queryVals = append(queryVals, roots)
+		conditions = append(conditions, fmt.Sprintf("f_root = ANY($%d)", len(queryVals)))
+	}
+
+	if len(filter.ParentRoots) > 0 {
+		parentRoots := make([][]byte, len(filter.ParentRoots))
+		for i := range filter.ParentRoots {
+			parentRoots[i] = filter.ParentRoots[i][:]
+		}
+		queryVals = append(queryVals, parentRoots)
+		conditions = append(conditions, fmt.Sprintf("f_parent_root = ANY($%d)", len(queryVals)))
+	}
+
+	if len(filter.ProposerIndices) > 0 {
+		queryVals = append(queryVals, filter.ProposerIndices)
+		conditions = append(conditions, fmt.Sprintf("f_proposer_index = ANY($%d)", len(queryVals)))
+	}
+
+	if len(conditions) > 0 {
+		queryBuilder.WriteString("\nWHERE ")
+		queryBuilder.WriteString(strings.Join(conditions, "\n  AND "))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot,f_root`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC,f_root DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	headers := make([]*chaindb.BlockHeader, 0)
+	var root []byte
+	var parentRoot []byte
+	var stateRoot []byte
+	var bodyRoot []byte
+	for rows.Next() {
+		header := &chaindb.BlockHeader{}
+		err := rows.Scan(
+			&header.Slot,
+			&header.ProposerIndex,
+			&root,
+			&parentRoot,
+			&stateRoot,
+			&bodyRoot,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(header.Root[:], root)
+		copy(header.ParentRoot[:], parentRoot)
+		copy(header.StateRoot[:], stateRoot)
+		copy(header.BodyRoot[:], bodyRoot)
+		headers = append(headers, header)
+	}
+
+	// Always return order of slot.
+	sort.Slice(headers, func(i int, j int) bool {
+		if headers[i].Slot != headers[j].Slot {
+			return headers[i].Slot < headers[j].Slot
+		}
+		return bytes.Compare(headers[i].Root[:], headers[j].Root[:]) < 0
+	})
+
+	return headers, rows.Err()
+}
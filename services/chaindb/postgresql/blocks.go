@@ -25,12 +25,11 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // SetBlock sets a block.
 func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetBlock")
+	ctx, span := startSpan(ctx, "SetBlock")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -109,12 +108,17 @@ func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
 		return errors.Wrap(err, "failed to set BLS to execution changes")
 	}
 
-	return nil
+	// Propagate the canonical state of the block to its contents.
+	if err := s.setChildCanonical(ctx, block); err != nil {
+		return errors.Wrap(err, "failed to set canonical state of block contents")
+	}
+
+	return s.runSQLHooks(ctx, tx, "block", blockHookValues(block))
 }
 
 // Blocks provides blocks according to the filter.
 func (s *Service) Blocks(ctx context.Context, filter *chaindb.BlockFilter) ([]*chaindb.Block, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "Blocks")
+	ctx, span := startSpan(ctx, "Blocks")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -160,12 +164,36 @@ FROM t_blocks`)
 		queryVals = append(queryVals, *filter.To)
 		queryBuilder.WriteString(fmt.Sprintf(`
 %s f_slot <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
 	}
 
 	if filter.Canonical != nil {
 		queryVals = append(queryVals, *filter.Canonical)
 		queryBuilder.WriteString(fmt.Sprintf(`
 %s f_canonical = $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.ProposerIndices) > 0 {
+		queryVals = append(queryVals, filter.ProposerIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_proposer_index = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.FeeRecipientLabels) > 0 {
+		queryVals = append(queryVals, filter.FeeRecipientLabels)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_root IN (SELECT f_block_root FROM t_block_execution_payloads WHERE %s)`, wherestr, addressLabelsCondition("f_fee_recipient", len(queryVals))))
+		wherestr = "  AND"
+	}
+
+	queryVals, wherestr = addGraffitiConditions(&queryBuilder, queryVals, wherestr, filter.GraffitiContains, filter.GraffitiRegex)
+
+	if filter.Cursor != nil {
+		queryVals = append(queryVals, filter.Cursor.Slot, filter.Cursor.Root[:])
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s (f_slot,f_root) %s ($%d,$%d)`, wherestr, cursorComparison(filter.Order), len(queryVals)-1, len(queryVals)))
 	}
 
 	switch filter.Order {
@@ -278,7 +306,7 @@ LIMIT $%d`, len(queryVals)))
 
 // BlocksBySlot fetches all blocks with the given slot.
 func (s *Service) BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*chaindb.Block, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "BlocksBySlot")
+	ctx, span := startSpan(ctx, "BlocksBySlot")
 	defer span.End()
 
 	var err error
@@ -380,7 +408,7 @@ func (s *Service) BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*chaind
 // Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 // blocks duties for slots 2 and 3.
 func (s *Service) BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.Block, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "BlocksForSlotRange")
+	ctx, span := startSpan(ctx, "BlocksForSlotRange")
 	defer span.End()
 
 	var err error
@@ -482,7 +510,7 @@ func (s *Service) BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot,
 
 // BlockByRoot fetches the block with the given root.
 func (s *Service) BlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.Block, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "BlockByroot")
+	ctx, span := startSpan(ctx, "BlockByroot")
 	defer span.End()
 
 	var err error
@@ -573,7 +601,7 @@ func (s *Service) BlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.B
 // Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 // presence duties for slots 2 and 3.
 func (s *Service) CanonicalBlockPresenceForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]bool, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "CanonicalBlockPresenceForSlotRange")
+	ctx, span := startSpan(ctx, "CanonicalBlockPresenceForSlotRange")
 	defer span.End()
 
 	var err error
@@ -620,7 +648,7 @@ func (s *Service) CanonicalBlockPresenceForSlotRange(ctx context.Context, startS
 
 // BlocksByParentRoot fetches the blocks with the given root.
 func (s *Service) BlocksByParentRoot(ctx context.Context, parentRoot phase0.Root) ([]*chaindb.Block, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "BlocksByParentRoot")
+	ctx, span := startSpan(ctx, "BlocksByParentRoot")
 	defer span.End()
 
 	var err error
@@ -719,7 +747,7 @@ func (s *Service) BlocksByParentRoot(ctx context.Context, parentRoot phase0.Root
 
 // EmptySlots fetches the slots in the given range without a block in the database.
 func (s *Service) EmptySlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "EmptySlots")
+	ctx, span := startSpan(ctx, "EmptySlots")
 	defer span.End()
 
 	var err error
@@ -763,7 +791,7 @@ func (s *Service) EmptySlots(ctx context.Context, minSlot phase0.Slot, maxSlot p
 
 // IndeterminateBlocks fetches the blocks in the given range that do not have a canonical status.
 func (s *Service) IndeterminateBlocks(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Root, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "IndeterminateBlocks")
+	ctx, span := startSpan(ctx, "IndeterminateBlocks")
 	defer span.End()
 
 	var err error
@@ -810,7 +838,7 @@ func (s *Service) IndeterminateBlocks(ctx context.Context, minSlot phase0.Slot,
 
 // LatestBlocks fetches the blocks with the highest slot number for in the database.
 func (s *Service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "LatestBlocks")
+	ctx, span := startSpan(ctx, "LatestBlocks")
 	defer span.End()
 
 	var err error
@@ -911,7 +939,7 @@ func (s *Service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
 
 // LatestCanonicalBlock returns the slot of the latest canonical block known in the database.
 func (s *Service) LatestCanonicalBlock(ctx context.Context) (phase0.Slot, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "LatestCanonicalBlock")
+	ctx, span := startSpan(ctx, "LatestCanonicalBlock")
 	defer span.End()
 
 	var err error
@@ -945,7 +973,7 @@ func (s *Service) LatestCanonicalBlock(ctx context.Context) (phase0.Slot, error)
 // Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 // blocks duties for slots 2 and 3.
 func (s *Service) ProposalCount(ctx context.Context, validatorIndices []phase0.ValidatorIndex, startSlot phase0.Slot, endSlot phase0.Slot) (uint64, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ProposalCount")
+	ctx, span := startSpan(ctx, "ProposalCount")
 	defer span.End()
 
 	var err error
@@ -21,12 +21,11 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // SetBlockSummary sets a block summary.
 func (s *Service) SetBlockSummary(ctx context.Context, summary *chaindb.BlockSummary) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetBlockSummary")
+	ctx, span := startSpan(ctx, "SetBlockSummary")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -59,7 +58,7 @@ func (s *Service) SetBlockSummary(ctx context.Context, summary *chaindb.BlockSum
 }
 
 func (s *Service) BlockSummaries(ctx context.Context, filter *chaindb.BlockSummaryFilter) ([]*chaindb.BlockSummary, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "BlockSummaries")
+	ctx, span := startSpan(ctx, "BlockSummaries")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -156,7 +155,7 @@ LIMIT $%d`, len(queryVals)))
 
 // BlockSummaryForSlot obtains the summary of a block for a given slot.
 func (s *Service) BlockSummaryForSlot(ctx context.Context, slot phase0.Slot) (*chaindb.BlockSummary, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "BlockSummaryForSlot")
+	ctx, span := startSpan(ctx, "BlockSummaryForSlot")
 	defer span.End()
 
 	tx := s.tx(ctx)
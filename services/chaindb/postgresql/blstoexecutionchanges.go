@@ -23,12 +23,11 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // setBLSToExecutionChanges sets the BLS to execution changes of a block.
 func (s *Service) setBLSToExecutionChanges(ctx context.Context, block *chaindb.Block) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "setBLSToExecutionChanges")
+	ctx, span := startSpan(ctx, "setBLSToExecutionChanges")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -75,7 +74,7 @@ SET f_validator_index = excluded.f_validator_index
 
 // BLSToExecutionChanges provides withdrawals according to the filter.
 func (s *Service) BLSToExecutionChanges(ctx context.Context, filter *chaindb.BLSToExecutionChangeFilter) ([]*chaindb.BLSToExecutionChange, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "BLSToExecutionChanges")
+	ctx, span := startSpan(ctx, "BLSToExecutionChanges")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -0,0 +1,350 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// canonicalChainCTE is a common table expression that provides the chain of
+// blocks held in the database that ends with the block with root $1, back to
+// and including the slot $2.
+const canonicalChainCTE = `
+WITH RECURSIVE chain(f_root, f_parent_root, f_slot) AS (
+  SELECT f_root
+        ,f_parent_root
+        ,f_slot
+  FROM t_blocks
+  WHERE f_root = $1
+    AND f_slot >= $2
+  UNION ALL
+  SELECT t_blocks.f_root
+        ,t_blocks.f_parent_root
+        ,t_blocks.f_slot
+  FROM t_blocks
+  JOIN chain ON t_blocks.f_root = chain.f_parent_root
+  WHERE chain.f_slot > 0
+    AND t_blocks.f_slot >= $2
+)`
+
+// setChildCanonical propagates the canonical state of a block to the data it contains.
+// Attestations are not updated here, as their canonical state is set by the finalizer
+// alongside their correctness.
+func (s *Service) setChildCanonical(ctx context.Context, block *chaindb.Block) error {
+	ctx, span := startSpan(ctx, "setChildCanonical")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	var canonical sql.NullBool
+	if block.Canonical != nil {
+		canonical.Valid = true
+		canonical.Bool = *block.Canonical
+	}
+
+	return setContentsCanonical(ctx, tx, [][]byte{block.Root[:]}, canonical)
+}
+
+// canonicalOnlyCondition returns an additional condition for a query that restricts
+// results to canonical data if the service has been configured to do so.
+func (s *Service) canonicalOnlyCondition() string {
+	if !s.canonicalOnly {
+		return ""
+	}
+
+	return "\n        AND f_canonical = true"
+}
+
+// inclusionCanonicalCondition provides a condition that the block including items in the
+// given table has the canonical state in the given parameter, for tables that do not hold
+// the canonical state of their items.
+func inclusionCanonicalCondition(table string, param int) string {
+	return fmt.Sprintf("EXISTS(SELECT 1 FROM t_blocks WHERE t_blocks.f_root = %s.f_inclusion_block_root AND t_blocks.f_canonical = $%d)", table, param)
+}
+
+// inclusionNotNonCanonicalCondition provides a condition that the block including items in
+// the given table is not non-canonical, for tables that do not hold the canonical state of
+// their items.
+func inclusionNotNonCanonicalCondition(table string) string {
+	return fmt.Sprintf("NOT EXISTS(SELECT 1 FROM t_blocks WHERE t_blocks.f_root = %s.f_inclusion_block_root AND t_blocks.f_canonical = false)", table)
+}
+
+// ChainHeaders provides the headers of the chain of blocks held in the database that ends
+// with the block with the given root, back to and including the given slot, in slot order.
+// It returns an empty list if the block with the given root is not held in the database.
+func (s *Service) ChainHeaders(ctx context.Context,
+	root phase0.Root,
+	minSlot phase0.Slot,
+) (
+	[]*chaindb.BlockHeader,
+	error,
+) {
+	ctx, span := startSpan(ctx, "ChainHeaders")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, canonicalChainCTE+`
+SELECT t_blocks.f_slot
+      ,t_blocks.f_proposer_index
+      ,t_blocks.f_root
+      ,t_blocks.f_parent_root
+      ,t_blocks.f_state_root
+      ,t_blocks.f_body_root
+FROM chain
+JOIN t_blocks ON t_blocks.f_root = chain.f_root
+ORDER BY t_blocks.f_slot
+`,
+		root[:],
+		minSlot,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain chain")
+	}
+	defer rows.Close()
+
+	headers := make([]*chaindb.BlockHeader, 0)
+	var blockRoot []byte
+	var parentRoot []byte
+	var stateRoot []byte
+	var bodyRoot []byte
+	for rows.Next() {
+		header := &chaindb.BlockHeader{}
+		if err := rows.Scan(
+			&header.Slot,
+			&header.ProposerIndex,
+			&blockRoot,
+			&parentRoot,
+			&stateRoot,
+			&bodyRoot,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(header.Root[:], blockRoot)
+		copy(header.ParentRoot[:], parentRoot)
+		copy(header.StateRoot[:], stateRoot)
+		copy(header.BodyRoot[:], bodyRoot)
+		headers = append(headers, header)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain chain")
+	}
+
+	return headers, nil
+}
+
+// SetBlocksCanonical marks the blocks with the given roots as canonical, along with
+// their contents.
+func (s *Service) SetBlocksCanonical(ctx context.Context, roots []phase0.Root) error {
+	ctx, span := startSpan(ctx, "SetBlocksCanonical")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if len(roots) == 0 {
+		return nil
+	}
+
+	dbRoots := make([][]byte, len(roots))
+	for i := range roots {
+		dbRoots[i] = roots[i][:]
+	}
+
+	rows, err := tx.Query(ctx, `
+UPDATE t_blocks
+SET f_canonical = true
+WHERE f_root = ANY($1)
+  AND f_canonical IS DISTINCT FROM true
+RETURNING f_root
+`,
+		dbRoots,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to set blocks canonical")
+	}
+	updatedRoots, err := scanRoots(rows)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain canonical blocks")
+	}
+
+	if err := setContentsCanonical(ctx, tx, updatedRoots, sql.NullBool{Valid: true, Bool: true}); err != nil {
+		return err
+	}
+
+	return s.runBlockHooks(ctx, tx, updatedRoots)
+}
+
+// SetIndeterminateBlocksCanonical sets the canonical state of the blocks from minSlot
+// up to but not including maxSlot that do not have one, along with their contents.  A
+// block is canonical if it has a canonical child, otherwise it is non-canonical.
+func (s *Service) SetIndeterminateBlocksCanonical(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error {
+	ctx, span := startSpan(ctx, "SetIndeterminateBlocksCanonical")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	rows, err := tx.Query(ctx, `
+UPDATE t_blocks
+SET f_canonical = EXISTS(SELECT 1
+                         FROM t_blocks AS t_children
+                         WHERE t_children.f_parent_root = t_blocks.f_root
+                           AND t_children.f_canonical = true)
+WHERE f_slot >= $1
+  AND f_slot < $2
+  AND f_canonical IS NULL
+RETURNING f_root
+         ,f_canonical
+`,
+		minSlot,
+		maxSlot,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to set indeterminate blocks canonical")
+	}
+
+	canonicalRoots := make([][]byte, 0)
+	nonCanonicalRoots := make([][]byte, 0)
+	for rows.Next() {
+		var blockRoot []byte
+		var canonical bool
+		if err := rows.Scan(&blockRoot, &canonical); err != nil {
+			rows.Close()
+			return errors.Wrap(err, "failed to scan row")
+		}
+		if canonical {
+			canonicalRoots = append(canonicalRoots, blockRoot)
+		} else {
+			nonCanonicalRoots = append(nonCanonicalRoots, blockRoot)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "failed to obtain indeterminate blocks")
+	}
+
+	if err := setContentsCanonical(ctx, tx, canonicalRoots, sql.NullBool{Valid: true, Bool: true}); err != nil {
+		return err
+	}
+	if err := setContentsCanonical(ctx, tx, nonCanonicalRoots, sql.NullBool{Valid: true, Bool: false}); err != nil {
+		return err
+	}
+	return s.runBlockHooks(ctx, tx, append(canonicalRoots, nonCanonicalRoots...))
+}
+
+// setContentsCanonical sets the canonical state of the contents of the blocks
+// with the given roots.  A null state marks the contents as indeterminate.
+func setContentsCanonical(ctx context.Context, tx pgx.Tx, roots [][]byte, canonical sql.NullBool) error {
+	if len(roots) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `
+UPDATE t_deposits
+SET f_canonical = $2
+WHERE f_inclusion_block_root = ANY($1)
+  AND f_canonical IS DISTINCT FROM $2
+`,
+		roots,
+		canonical,
+	); err != nil {
+		return errors.Wrap(err, "failed to set canonical for deposits")
+	}
+
+	if _, err := tx.Exec(ctx, `
+UPDATE t_block_withdrawals
+SET f_canonical = $2
+WHERE f_block_root = ANY($1)
+  AND f_canonical IS DISTINCT FROM $2
+`,
+		roots,
+		canonical,
+	); err != nil {
+		return errors.Wrap(err, "failed to set canonical for withdrawals")
+	}
+
+	if _, err := tx.Exec(ctx, `
+UPDATE t_block_execution_payloads
+SET f_canonical = $2
+WHERE f_block_root = ANY($1)
+  AND f_canonical IS DISTINCT FROM $2
+`,
+		roots,
+		canonical,
+	); err != nil {
+		return errors.Wrap(err, "failed to set canonical for execution payload")
+	}
+
+	return nil
+}
+
+// runBlockHooks runs the SQL hooks for blocks whose canonical state has been
+// set in bulk, as they would be run had the blocks been stored individually.
+func (s *Service) runBlockHooks(ctx context.Context, tx pgx.Tx, roots [][]byte) error {
+	if len(s.sqlHooks["block"]) == 0 || len(roots) == 0 {
+		return nil
+	}
+
+	values := make([]pgx.NamedArgs, 0, len(roots))
+	for _, blockRoot := range roots {
+		var root phase0.Root
+		copy(root[:], blockRoot)
+		block, err := s.BlockByRoot(ctx, root)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain block for SQL hooks")
+		}
+		values = append(values, blockHookValues(block))
+	}
+
+	return s.runSQLHooks(ctx, tx, "block", values...)
+}
+
+// scanRoots scans a single column of roots from the rows, closing them.
+func scanRoots(rows pgx.Rows) ([][]byte, error) {
+	defer rows.Close()
+
+	roots := make([][]byte, 0)
+	for rows.Next() {
+		var root []byte
+		if err := rows.Scan(&root); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		roots = append(roots, root)
+	}
+
+	return roots, rows.Err()
+}
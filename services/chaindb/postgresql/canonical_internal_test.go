@@ -0,0 +1,92 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestExecutionPayloadCanonical(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(ctx,
+		WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	// A chain of two blocks and an orphaned fork, beyond any real chain.
+	block := func(slot phase0.Slot, variant byte, parent phase0.Root) *chaindb.Block {
+		return &chaindb.Block{
+			Slot:          slot,
+			Root:          phase0.Root{0xfe, variant, byte(slot)},
+			ParentRoot:    parent,
+			Graffiti:      make([]byte, 32),
+			ETH1BlockHash: make([]byte, 32),
+			ExecutionPayload: &chaindb.ExecutionPayload{
+				BlockNumber:   uint64(slot),
+				BlockHash:     [32]byte{0xfe, variant, byte(slot)},
+				BaseFeePerGas: big.NewInt(7),
+			},
+		}
+	}
+	b0 := block(1<<40, 0, phase0.Root{})
+	b1 := block(1<<40+1, 0, b0.Root)
+	fork1 := block(1<<40+1, 1, b0.Root)
+	for _, block := range []*chaindb.Block{b0, b1, fork1} {
+		require.NoError(t, s.SetBlock(ctx, block))
+	}
+
+	payloadCanonical := func(block *chaindb.Block) *bool {
+		t.Helper()
+		var canonical sql.NullBool
+		require.NoError(t, s.tx(ctx).QueryRow(ctx, `
+SELECT f_canonical
+FROM t_block_execution_payloads
+WHERE f_block_root = $1`,
+			block.Root[:],
+		).Scan(&canonical))
+		if !canonical.Valid {
+			return nil
+		}
+		return &canonical.Bool
+	}
+	canonical := true
+	notCanonical := false
+
+	// Payloads follow their blocks' canonical state.
+	require.Nil(t, payloadCanonical(b0))
+	require.Nil(t, payloadCanonical(fork1))
+
+	require.NoError(t, s.SetBlocksCanonical(ctx, []phase0.Root{b0.Root, b1.Root}))
+	require.NoError(t, s.SetIndeterminateBlocksCanonical(ctx, b0.Slot, b1.Slot+1))
+	require.Equal(t, &canonical, payloadCanonical(b0))
+	require.Equal(t, &canonical, payloadCanonical(b1))
+	require.Equal(t, &notCanonical, payloadCanonical(fork1))
+
+	// Storing a block again with its canonical state keeps the payload in step.
+	fork1.Canonical = &notCanonical
+	require.NoError(t, s.SetBlock(ctx, fork1))
+	require.Equal(t, &notCanonical, payloadCanonical(fork1))
+}
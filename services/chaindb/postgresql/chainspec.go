@@ -17,18 +17,61 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
-	"go.opentelemetry.io/otel"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 )
 
+// chainSpecCacheKey is the read cache key for the chain specification.
+const chainSpecCacheKey = "chain_spec"
+
+const (
+	// SpecTypeString is a chain specification value held as a string.
+	SpecTypeString = "string"
+	// SpecTypeUint64 is a chain specification value held as a uint64.
+	SpecTypeUint64 = "uint64"
+	// SpecTypeBigInt is a chain specification value held as a *big.Int.
+	SpecTypeBigInt = "bigint"
+	// SpecTypeBytes is a chain specification value held as a []byte.
+	SpecTypeBytes = "bytes"
+	// SpecTypeVersion is a chain specification value held as a phase0.Version.
+	SpecTypeVersion = "version"
+	// SpecTypeDomainType is a chain specification value held as a phase0.DomainType.
+	SpecTypeDomainType = "domain_type"
+	// SpecTypeDuration is a chain specification value held as a time.Duration.
+	SpecTypeDuration = "duration"
+	// SpecTypeTime is a chain specification value held as a time.Time.
+	SpecTypeTime = "time"
+)
+
+var specTypes = map[string]bool{
+	SpecTypeString:     true,
+	SpecTypeUint64:     true,
+	SpecTypeBigInt:     true,
+	SpecTypeBytes:      true,
+	SpecTypeVersion:    true,
+	SpecTypeDomainType: true,
+	SpecTypeDuration:   true,
+	SpecTypeTime:       true,
+}
+
+// chainSpecDBVal is a chain specification value as held in the database.
+type chainSpecDBVal struct {
+	Value string `json:"value"`
+	// Type is the type of the value when it was stored; empty for values
+	// stored before types were recorded.
+	Type string `json:"type,omitempty"`
+}
+
 // SetChainSpecValue sets the value of the provided key.
 func (s *Service) SetChainSpecValue(ctx context.Context, key string, value any) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetChainSpecValue")
+	ctx, span := startSpan(ctx, "SetChainSpecValue")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -36,39 +79,49 @@ func (s *Service) SetChainSpecValue(ctx context.Context, key string, value any)
 		return ErrNoTransaction
 	}
 
-	var dbVal string
-	switch v := value.(type) {
-	case phase0.Slot, phase0.Epoch, phase0.CommitteeIndex, phase0.ValidatorIndex, phase0.Gwei:
-		dbVal = fmt.Sprintf("%d", v)
-	case phase0.Root, phase0.Version, phase0.DomainType, phase0.ForkDigest, phase0.Domain, phase0.BLSPubKey, phase0.BLSSignature, []byte:
-		dbVal = fmt.Sprintf("%#x", v)
-	case time.Duration:
-		dbVal = strconv.Itoa(int(v.Seconds()))
-	case time.Time:
-		dbVal = strconv.FormatInt(v.Unix(), 10)
-	default:
-		dbVal = fmt.Sprintf("%v", v)
-	}
+	dbVal := util.FormatSpecValue(value)
 	_, err := tx.Exec(ctx, `
       INSERT INTO t_chain_spec(f_key
-                              ,f_value)
-      VALUES($1,$2)
+                              ,f_value
+                              ,f_type)
+      VALUES($1,$2,$3)
       ON CONFLICT (f_key) DO
       UPDATE
       SET f_value = excluded.f_value
+         ,f_type = excluded.f_type
       `,
 		key,
 		dbVal,
+		specValueType(value),
 	)
+	if err != nil {
+		return err
+	}
+	s.invalidateReadCache(ctx, chainSpecCacheKey, chainSpecCacheKey+":"+key)
 
-	return err
+	return nil
 }
 
-// ChainSpec fetches all chain specification values.
-func (s *Service) ChainSpec(ctx context.Context) (map[string]any, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ChainSpec")
+// ChainSpec fetches the chain specification.
+func (s *Service) ChainSpec(ctx context.Context) (*chaindb.Spec, error) {
+	ctx, span := startSpan(ctx, "ChainSpec")
 	defer span.End()
 
+	dbVals, err := cachedRead(ctx, s, chainSpecCacheKey, s.chainSpecDBVals)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any, len(dbVals))
+	for key, dbVal := range dbVals {
+		values[key] = s.dbValToSpec(ctx, key, dbVal)
+	}
+
+	return chaindb.NewSpec(values), nil
+}
+
+// chainSpecDBVals fetches all chain specification values as held in the database.
+func (s *Service) chainSpecDBVals(ctx context.Context) (map[string]*chainSpecDBVal, error) {
 	var err error
 
 	tx := s.tx(ctx)
@@ -81,10 +134,11 @@ func (s *Service) ChainSpec(ctx context.Context) (map[string]any, error) {
 		defer s.CommitROTx(ctx)
 	}
 
-	spec := make(map[string]any)
+	dbVals := make(map[string]*chainSpecDBVal)
 	rows, err := tx.Query(ctx, `
       SELECT f_key
             ,f_value
+            ,f_type
       FROM t_chain_spec
 	  `)
 	if err != nil {
@@ -94,26 +148,43 @@ func (s *Service) ChainSpec(ctx context.Context) (map[string]any, error) {
 
 	for rows.Next() {
 		var key string
-		var dbVal string
+		dbVal := &chainSpecDBVal{}
+		var dbType *string
 		err := rows.Scan(
 			&key,
-			&dbVal,
+			&dbVal.Value,
+			&dbType,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
+		if dbType != nil {
+			dbVal.Type = *dbType
+		}
 
-		spec[key] = dbValToSpec(ctx, key, dbVal)
+		dbVals[key] = dbVal
 	}
 
-	return spec, nil
+	return dbVals, nil
 }
 
 // ChainSpecValue fetches a chain specification value given its key.
 func (s *Service) ChainSpecValue(ctx context.Context, key string) (any, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ChainSpecValue")
+	ctx, span := startSpan(ctx, "ChainSpecValue")
 	defer span.End()
 
+	dbVal, err := cachedRead(ctx, s, chainSpecCacheKey+":"+key, func(ctx context.Context) (*chainSpecDBVal, error) {
+		return s.chainSpecDBVal(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.dbValToSpec(ctx, key, dbVal), nil
+}
+
+// chainSpecDBVal fetches a chain specification value as held in the database.
+func (s *Service) chainSpecDBVal(ctx context.Context, key string) (*chainSpecDBVal, error) {
 	tx := s.tx(ctx)
 	if tx == nil {
 		ctx, err := s.BeginROTx(ctx)
@@ -124,21 +195,127 @@ func (s *Service) ChainSpecValue(ctx context.Context, key string) (any, error) {
 		tx = s.tx(ctx)
 	}
 
-	var dbVal string
+	dbVal := &chainSpecDBVal{}
+	var dbType *string
 	err := tx.QueryRow(ctx, `
       SELECT f_value
+            ,f_type
       FROM t_chain_spec
 	  WHERE f_key = $1
-	  `, key).Scan(&dbVal)
+	  `, key).Scan(&dbVal.Value, &dbType)
 	if err != nil {
 		return nil, err
 	}
+	if dbType != nil {
+		dbVal.Type = *dbType
+	}
 
-	return dbValToSpec(ctx, key, dbVal), nil
+	return dbVal, nil
 }
 
 // dbValToSpec turns a database value in to a spec value.
-func dbValToSpec(_ context.Context, key string, val string) any {
+// A configured type for the key takes precedence, followed by the type
+// recorded when the value was stored.  Values without either are
+// classified by their key and contents.
+func (s *Service) dbValToSpec(_ context.Context, key string, dbVal *chainSpecDBVal) any {
+	if typ, exists := s.specTypes[key]; exists {
+		value, err := parseSpecValue(typ, dbVal.Value)
+		if err == nil {
+			return value
+		}
+		log.Warn().Str("key", key).Str("type", typ).Err(err).Msg("Failed to parse chain specification value with configured type")
+	}
+
+	if dbVal.Type != "" {
+		value, err := parseSpecValue(dbVal.Type, dbVal.Value)
+		if err == nil {
+			return value
+		}
+		log.Warn().Str("key", key).Str("type", dbVal.Type).Err(err).Msg("Failed to parse chain specification value with stored type")
+	}
+
+	return guessSpecValue(key, dbVal.Value)
+}
+
+// specValueType provides the type with which a spec value is stored.
+func specValueType(value any) string {
+	switch value.(type) {
+	case uint64, int, phase0.Slot, phase0.Epoch, phase0.CommitteeIndex, phase0.ValidatorIndex, phase0.Gwei:
+		return SpecTypeUint64
+	case *big.Int:
+		return SpecTypeBigInt
+	case phase0.Version:
+		return SpecTypeVersion
+	case phase0.DomainType:
+		return SpecTypeDomainType
+	case phase0.Root, phase0.ForkDigest, phase0.Domain, phase0.BLSPubKey, phase0.BLSSignature, []byte:
+		return SpecTypeBytes
+	case time.Duration:
+		return SpecTypeDuration
+	case time.Time:
+		return SpecTypeTime
+	default:
+		// Values of unknown types are preserved as their string representation.
+		return SpecTypeString
+	}
+}
+
+// parseSpecValue parses a database value as the given type.
+func parseSpecValue(typ string, val string) (any, error) {
+	switch typ {
+	case SpecTypeString:
+		return val, nil
+	case SpecTypeUint64:
+		return strconv.ParseUint(val, 10, 64)
+	case SpecTypeBigInt:
+		bigVal, success := new(big.Int).SetString(val, 10)
+		if !success {
+			return nil, fmt.Errorf("invalid big integer %q", val)
+		}
+		return bigVal, nil
+	case SpecTypeBytes:
+		return hex.DecodeString(strings.TrimPrefix(val, "0x"))
+	case SpecTypeVersion:
+		byteVal, err := hex.DecodeString(strings.TrimPrefix(val, "0x"))
+		if err != nil {
+			return nil, err
+		}
+		var version phase0.Version
+		if len(byteVal) != len(version) {
+			return nil, fmt.Errorf("invalid version length %d", len(byteVal))
+		}
+		copy(version[:], byteVal)
+		return version, nil
+	case SpecTypeDomainType:
+		byteVal, err := hex.DecodeString(strings.TrimPrefix(val, "0x"))
+		if err != nil {
+			return nil, err
+		}
+		var domainType phase0.DomainType
+		if len(byteVal) != len(domainType) {
+			return nil, fmt.Errorf("invalid domain type length %d", len(byteVal))
+		}
+		copy(domainType[:], byteVal)
+		return domainType, nil
+	case SpecTypeDuration:
+		intVal, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return time.Duration(intVal) * time.Second, nil
+	case SpecTypeTime:
+		intVal, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return time.Unix(intVal, 0), nil
+	default:
+		return nil, fmt.Errorf("unknown type %s", typ)
+	}
+}
+
+// guessSpecValue turns a database value without a known type in to a spec value.
+func guessSpecValue(key string, val string) any {
 	// Handle domains.
 	if strings.HasPrefix(key, "DOMAIN_") {
 		byteVal, err := hex.DecodeString(strings.TrimPrefix(val, "0x"))
@@ -195,3 +372,38 @@ func dbValToSpec(_ context.Context, key string, val string) any {
 	// Assume string.
 	return val
 }
+
+// slotsPerEpoch provides the number of slots in an epoch from the chain specification.
+func (s *Service) slotsPerEpoch(ctx context.Context) (uint64, error) {
+	val, err := s.ChainSpecValue(ctx, "SLOTS_PER_EPOCH")
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain slots per epoch")
+	}
+	slotsPerEpoch, isUint64 := val.(uint64)
+	if !isUint64 {
+		return 0, errors.New("slots per epoch of unexpected type")
+	}
+
+	return slotsPerEpoch, nil
+}
+
+// slotsPerSyncCommitteePeriod provides the number of slots in a sync committee period from the chain specification.
+func (s *Service) slotsPerSyncCommitteePeriod(ctx context.Context) (uint64, error) {
+	slotsPerEpoch, err := s.slotsPerEpoch(ctx)
+	if err != nil {
+		return 0, err
+	}
+	val, err := s.ChainSpecValue(ctx, "EPOCHS_PER_SYNC_COMMITTEE_PERIOD")
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain epochs per sync committee period")
+	}
+	epochsPerSyncCommitteePeriod, isUint64 := val.(uint64)
+	if !isUint64 {
+		return 0, errors.New("epochs per sync committee period of unexpected type")
+	}
+	if epochsPerSyncCommitteePeriod == 0 {
+		return 0, errors.New("epochs per sync committee period cannot be 0")
+	}
+
+	return slotsPerEpoch * epochsPerSyncCommitteePeriod, nil
+}
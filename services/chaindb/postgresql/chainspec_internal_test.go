@@ -0,0 +1,146 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestDBValToSpec(t *testing.T) {
+	ctx := context.Background()
+
+	s := &Service{
+		specTypes: map[string]string{
+			"OVERRIDDEN_TIME":  SpecTypeString,
+			"OVERRIDDEN_BIG":   SpecTypeBigInt,
+			"BAD_OVERRIDE_INT": SpecTypeUint64,
+		},
+	}
+
+	bigVal, success := new(big.Int).SetString("58750000000000000000000", 10)
+	require.True(t, success)
+
+	tests := []struct {
+		name     string
+		key      string
+		dbVal    *chainSpecDBVal
+		expected any
+	}{
+		{
+			name:     "LegacyTime",
+			key:      "MIN_GENESIS_TIME",
+			dbVal:    &chainSpecDBVal{Value: "1606824000"},
+			expected: time.Unix(1606824000, 0),
+		},
+		{
+			name:     "StoredString",
+			key:      "SOMETHING_TIME",
+			dbVal:    &chainSpecDBVal{Value: "1606824000", Type: SpecTypeString},
+			expected: "1606824000",
+		},
+		{
+			name:     "StoredHexString",
+			key:      "CONFIG_NAME",
+			dbVal:    &chainSpecDBVal{Value: "0x1234", Type: SpecTypeString},
+			expected: "0x1234",
+		},
+		{
+			name:     "StoredBigInt",
+			key:      "TERMINAL_TOTAL_DIFFICULTY",
+			dbVal:    &chainSpecDBVal{Value: "58750000000000000000000", Type: SpecTypeBigInt},
+			expected: bigVal,
+		},
+		{
+			name:     "StoredVersion",
+			key:      "ALTAIR_FORK_VERSION",
+			dbVal:    &chainSpecDBVal{Value: "0x01000000", Type: SpecTypeVersion},
+			expected: phase0.Version{0x01, 0x00, 0x00, 0x00},
+		},
+		{
+			name:     "StoredDuration",
+			key:      "SECONDS_PER_SLOT",
+			dbVal:    &chainSpecDBVal{Value: "12", Type: SpecTypeDuration},
+			expected: 12 * time.Second,
+		},
+		{
+			name:     "StoredTypeInvalid",
+			key:      "SLOTS_PER_EPOCH",
+			dbVal:    &chainSpecDBVal{Value: "32", Type: "unknown"},
+			expected: uint64(32),
+		},
+		{
+			name:     "OverrideTime",
+			key:      "OVERRIDDEN_TIME",
+			dbVal:    &chainSpecDBVal{Value: "1606824000", Type: SpecTypeTime},
+			expected: "1606824000",
+		},
+		{
+			name:     "OverrideBigInt",
+			key:      "OVERRIDDEN_BIG",
+			dbVal:    &chainSpecDBVal{Value: "58750000000000000000000"},
+			expected: bigVal,
+		},
+		{
+			name:     "OverrideUnparsable",
+			key:      "BAD_OVERRIDE_INT",
+			dbVal:    &chainSpecDBVal{Value: "abc", Type: SpecTypeString},
+			expected: "abc",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := s.dbValToSpec(ctx, test.key, test.dbVal)
+			if expectedTime, isTime := test.expected.(time.Time); isTime {
+				resTime, isTime := res.(time.Time)
+				require.True(t, isTime)
+				require.True(t, expectedTime.Equal(resTime))
+
+				return
+			}
+			require.Equal(t, test.expected, res)
+		})
+	}
+}
+
+func TestSpecValueTypeRoundTrip(t *testing.T) {
+	values := []any{
+		uint64(12345),
+		phase0.Epoch(10),
+		big.NewInt(1000),
+		phase0.Version{0x01, 0x02, 0x03, 0x04},
+		phase0.DomainType{0x05, 0x06, 0x07, 0x08},
+		[]byte{0x01, 0x02},
+		12 * time.Second,
+		"value",
+	}
+
+	for _, value := range values {
+		res, err := parseSpecValue(specValueType(value), util.FormatSpecValue(value))
+		require.NoError(t, err)
+		if epoch, isEpoch := value.(phase0.Epoch); isEpoch {
+			require.Equal(t, uint64(epoch), res)
+
+			continue
+		}
+		require.Equal(t, value, res)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetCheckpoint sets an epoch checkpoint.
+func (s *Service) SetCheckpoint(ctx context.Context, checkpoint *chaindb.EpochCheckpoint) error {
+	ctx, span := startSpan(ctx, "SetCheckpoint")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_epoch_checkpoints(f_epoch
+                               ,f_block_root
+                               ,f_state_root
+                               ,f_previous_justified_epoch
+                               ,f_previous_justified_root
+                               ,f_justified_epoch
+                               ,f_justified_root
+                               ,f_finalized_epoch
+                               ,f_finalized_root)
+VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9)
+ON CONFLICT (f_epoch) DO
+UPDATE
+SET f_block_root = excluded.f_block_root
+   ,f_state_root = excluded.f_state_root
+   ,f_previous_justified_epoch = excluded.f_previous_justified_epoch
+   ,f_previous_justified_root = excluded.f_previous_justified_root
+   ,f_justified_epoch = excluded.f_justified_epoch
+   ,f_justified_root = excluded.f_justified_root
+   ,f_finalized_epoch = excluded.f_finalized_epoch
+   ,f_finalized_root = excluded.f_finalized_root
+`,
+		checkpoint.Epoch,
+		checkpoint.BlockRoot[:],
+		checkpoint.StateRoot[:],
+		checkpoint.PreviousJustifiedEpoch,
+		checkpoint.PreviousJustifiedRoot[:],
+		checkpoint.JustifiedEpoch,
+		checkpoint.JustifiedRoot[:],
+		checkpoint.FinalizedEpoch,
+		checkpoint.FinalizedRoot[:],
+	)
+
+	return err
+}
+
+// Checkpoints provides epoch checkpoints according to the filter.
+func (s *Service) Checkpoints(ctx context.Context, filter *chaindb.CheckpointFilter) ([]*chaindb.EpochCheckpoint, error) {
+	ctx, span := startSpan(ctx, "Checkpoints")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_epoch
+      ,f_block_root
+      ,f_state_root
+      ,f_previous_justified_epoch
+      ,f_previous_justified_root
+      ,f_justified_epoch
+      ,f_justified_root
+      ,f_finalized_epoch
+      ,f_finalized_root
+FROM t_epoch_checkpoints`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.StateRoots) > 0 {
+		stateRoots := make([][]byte, len(filter.StateRoots))
+		for i := range filter.StateRoots {
+			stateRoots[i] = filter.StateRoots[i][:]
+		}
+		queryVals = append(queryVals, stateRoots)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_state_root = ANY($%d)`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checkpoints := make([]*chaindb.EpochCheckpoint, 0)
+	blockRoot := make([]byte, phase0.RootLength)
+	stateRoot := make([]byte, phase0.RootLength)
+	previousJustifiedRoot := make([]byte, phase0.RootLength)
+	justifiedRoot := make([]byte, phase0.RootLength)
+	finalizedRoot := make([]byte, phase0.RootLength)
+	for rows.Next() {
+		checkpoint := &chaindb.EpochCheckpoint{}
+		err := rows.Scan(
+			&checkpoint.Epoch,
+			&blockRoot,
+			&stateRoot,
+			&checkpoint.PreviousJustifiedEpoch,
+			&previousJustifiedRoot,
+			&checkpoint.JustifiedEpoch,
+			&justifiedRoot,
+			&checkpoint.FinalizedEpoch,
+			&finalizedRoot,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(checkpoint.BlockRoot[:], blockRoot)
+		copy(checkpoint.StateRoot[:], stateRoot)
+		copy(checkpoint.PreviousJustifiedRoot[:], previousJustifiedRoot)
+		copy(checkpoint.JustifiedRoot[:], justifiedRoot)
+		copy(checkpoint.FinalizedRoot[:], finalizedRoot)
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	// Always return order of epoch.
+	sort.Slice(checkpoints, func(i int, j int) bool {
+		return checkpoints[i].Epoch < checkpoints[j].Epoch
+	})
+	return checkpoints, nil
+}
@@ -0,0 +1,114 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestCheckpoints(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	// Use epochs far beyond any test data to avoid clashes.
+	checkpoint := func(epoch phase0.Epoch) *chaindb.EpochCheckpoint {
+		return &chaindb.EpochCheckpoint{
+			Epoch:                  epoch,
+			BlockRoot:              phase0.Root{0x01, byte(epoch)},
+			StateRoot:              phase0.Root{0x02, byte(epoch)},
+			PreviousJustifiedEpoch: epoch - 2,
+			PreviousJustifiedRoot:  phase0.Root{0x03, byte(epoch)},
+			JustifiedEpoch:         epoch - 1,
+			JustifiedRoot:          phase0.Root{0x04, byte(epoch)},
+			FinalizedEpoch:         epoch - 2,
+			FinalizedRoot:          phase0.Root{0x05, byte(epoch)},
+		}
+	}
+	epochs := []phase0.Epoch{0xfff0000, 0xfff0001, 0xfff0002, 0xfff0003}
+
+	// Try to set outside of a transaction; should fail.
+	require.EqualError(t, s.SetCheckpoint(ctx, checkpoint(epochs[0])), postgresql.ErrNoTransaction.Error())
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	for _, epoch := range epochs {
+		require.NoError(t, s.SetCheckpoint(ctx, checkpoint(epoch)))
+	}
+	// Setting an epoch again replaces its checkpoint.
+	updated := checkpoint(epochs[1])
+	updated.BlockRoot = phase0.Root{0x06}
+	require.NoError(t, s.SetCheckpoint(ctx, updated))
+
+	from := epochs[0]
+	to := epochs[2]
+	tests := []struct {
+		name     string
+		filter   *chaindb.CheckpointFilter
+		expected []*chaindb.EpochCheckpoint
+	}{
+		{
+			name:     "Range",
+			filter:   &chaindb.CheckpointFilter{From: &from, To: &to},
+			expected: []*chaindb.EpochCheckpoint{checkpoint(epochs[0]), updated, checkpoint(epochs[2])},
+		},
+		{
+			name:     "Earliest",
+			filter:   &chaindb.CheckpointFilter{From: &from, Limit: 2},
+			expected: []*chaindb.EpochCheckpoint{checkpoint(epochs[0]), updated},
+		},
+		{
+			name:     "Latest",
+			filter:   &chaindb.CheckpointFilter{From: &from, Order: chaindb.OrderLatest, Limit: 2},
+			expected: []*chaindb.EpochCheckpoint{checkpoint(epochs[2]), checkpoint(epochs[3])},
+		},
+		{
+			name: "StateRoots",
+			filter: &chaindb.CheckpointFilter{
+				From:       &from,
+				StateRoots: []phase0.Root{checkpoint(epochs[3]).StateRoot, checkpoint(epochs[1]).StateRoot},
+			},
+			expected: []*chaindb.EpochCheckpoint{updated, checkpoint(epochs[3])},
+		},
+		{
+			name: "StateRootMissing",
+			filter: &chaindb.CheckpointFilter{
+				From:       &from,
+				StateRoots: []phase0.Root{{0xff}},
+			},
+			expected: []*chaindb.EpochCheckpoint{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkpoints, err := s.Checkpoints(ctx, test.filter)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, checkpoints)
+		})
+	}
+}
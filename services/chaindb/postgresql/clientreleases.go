@@ -0,0 +1,171 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetClientReleases sets client releases, replacing any existing releases.
+func (s *Service) SetClientReleases(ctx context.Context, releases []*chaindb.ClientRelease) error {
+	ctx, span := startSpan(ctx, "SetClientReleases")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+DELETE FROM t_client_releases
+`); err != nil {
+		return errors.Wrap(err, "failed to remove existing client releases")
+	}
+
+	for _, release := range releases {
+		behaviors := release.Behaviors
+		if behaviors == nil {
+			behaviors = make([]string, 0)
+		}
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_client_releases(f_layer
+                             ,f_client
+                             ,f_version
+                             ,f_released
+                             ,f_behaviors
+                             )
+VALUES($1,$2,$3,$4,$5)
+ON CONFLICT (f_layer,f_client,f_version) DO
+UPDATE
+SET f_released = excluded.f_released
+   ,f_behaviors = excluded.f_behaviors
+`,
+			release.Layer,
+			release.Client,
+			release.Version,
+			release.Released,
+			behaviors,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClientReleases provides client releases according to the filter.
+func (s *Service) ClientReleases(ctx context.Context,
+	filter *chaindb.ClientReleaseFilter,
+) (
+	[]*chaindb.ClientRelease,
+	error,
+) {
+	ctx, span := startSpan(ctx, "ClientReleases")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_layer
+      ,f_client
+      ,f_version
+      ,f_released
+      ,f_behaviors
+FROM t_client_releases`)
+
+	wherestr := "WHERE"
+
+	if len(filter.Layers) > 0 {
+		queryVals = append(queryVals, filter.Layers)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_layer = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.Clients) > 0 {
+		queryVals = append(queryVals, filter.Clients)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_client = ANY($%d)`, wherestr, len(queryVals)))
+	}
+
+	queryBuilder.WriteString(`
+ORDER BY f_released, f_layer, f_client, f_version`)
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	releases := make([]*chaindb.ClientRelease, 0)
+	for rows.Next() {
+		release := &chaindb.ClientRelease{}
+		err := rows.Scan(
+			&release.Layer,
+			&release.Client,
+			&release.Version,
+			&release.Released,
+			&release.Behaviors,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		releases = append(releases, release)
+	}
+
+	// Always return order of release time then layer, client and version.
+	sort.Slice(releases, func(i int, j int) bool {
+		if !releases[i].Released.Equal(releases[j].Released) {
+			return releases[i].Released.Before(releases[j].Released)
+		}
+		if releases[i].Layer != releases[j].Layer {
+			return releases[i].Layer < releases[j].Layer
+		}
+		if releases[i].Client != releases[j].Client {
+			return releases[i].Client < releases[j].Client
+		}
+		return releases[i].Version < releases[j].Version
+	})
+
+	return releases, nil
+}
@@ -0,0 +1,204 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetCommitteeEpochSummaries sets multiple committee epoch summaries.
+// Any existing summaries for the epochs covered are replaced.
+func (s *Service) SetCommitteeEpochSummaries(ctx context.Context, summaries []*chaindb.CommitteeEpochSummary) error {
+	ctx, span := startSpan(ctx, "SetCommitteeEpochSummaries")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	epochs := make([]phase0.Epoch, 0)
+	seen := make(map[phase0.Epoch]bool)
+	for _, summary := range summaries {
+		if !seen[summary.Epoch] {
+			seen[summary.Epoch] = true
+			epochs = append(epochs, summary.Epoch)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+DELETE FROM t_committee_epoch_summaries
+WHERE f_epoch = ANY($1)
+`,
+		epochs,
+	); err != nil {
+		return errors.Wrap(err, "failed to remove existing committee epoch summaries")
+	}
+
+	if _, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"t_committee_epoch_summaries"},
+		[]string{
+			"f_slot",
+			"f_committee_index",
+			"f_epoch",
+			"f_validators",
+			"f_attesting_validators",
+			"f_source_timely_validators",
+			"f_target_correct_validators",
+			"f_head_correct_validators",
+			"f_average_inclusion_delay",
+		},
+		pgx.CopyFromSlice(len(summaries), func(i int) ([]any, error) {
+			return []any{
+				summaries[i].Slot,
+				summaries[i].CommitteeIndex,
+				summaries[i].Epoch,
+				summaries[i].Validators,
+				summaries[i].AttestingValidators,
+				summaries[i].SourceTimelyValidators,
+				summaries[i].TargetCorrectValidators,
+				summaries[i].HeadCorrectValidators,
+				summaries[i].AverageInclusionDelay,
+			}, nil
+		})); err != nil {
+		return errors.Wrap(err, "failed to copy committee epoch summaries")
+	}
+
+	return nil
+}
+
+// CommitteeEpochSummaries provides committee epoch summaries according to the filter.
+func (s *Service) CommitteeEpochSummaries(ctx context.Context, filter *chaindb.CommitteeEpochSummaryFilter) ([]*chaindb.CommitteeEpochSummary, error) {
+	ctx, span := startSpan(ctx, "CommitteeEpochSummaries")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_slot
+      ,f_committee_index
+      ,f_epoch
+      ,f_validators
+      ,f_attesting_validators
+      ,f_source_timely_validators
+      ,f_target_correct_validators
+      ,f_head_correct_validators
+      ,f_average_inclusion_delay
+FROM t_committee_epoch_summaries`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.CommitteeIndices) > 0 {
+		queryVals = append(queryVals, filter.CommitteeIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_committee_index = ANY($%d)`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot, f_committee_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC,f_committee_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]*chaindb.CommitteeEpochSummary, 0)
+	for rows.Next() {
+		summary := &chaindb.CommitteeEpochSummary{}
+		err := rows.Scan(
+			&summary.Slot,
+			&summary.CommitteeIndex,
+			&summary.Epoch,
+			&summary.Validators,
+			&summary.AttestingValidators,
+			&summary.SourceTimelyValidators,
+			&summary.TargetCorrectValidators,
+			&summary.HeadCorrectValidators,
+			&summary.AverageInclusionDelay,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		summaries = append(summaries, summary)
+	}
+
+	// Always return order of slot then committee index.
+	sort.Slice(summaries, func(i int, j int) bool {
+		if summaries[i].Slot != summaries[j].Slot {
+			return summaries[i].Slot < summaries[j].Slot
+		}
+		return summaries[i].CommitteeIndex < summaries[j].CommitteeIndex
+	})
+	return summaries, nil
+}
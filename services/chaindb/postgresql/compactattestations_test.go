@@ -0,0 +1,118 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestCompactAttestationsByValidator(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+		postgresql.WithCompactAttestations(true),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	slot := phase0.Slot(1 << 41)
+	validatorA := phase0.ValidatorIndex(1 << 41)
+	validatorB := validatorA + 1
+	block := &chaindb.Block{
+		Slot:          slot + 1,
+		Root:          phase0.Root{0xca, 0x01},
+		Graffiti:      make([]byte, 32),
+		BodyRoot:      phase0.Root{0xca, 0x02},
+		StateRoot:     phase0.Root{0xca, 0x03},
+		ETH1BlockHash: make([]byte, 32),
+	}
+	require.NoError(t, s.SetBlock(ctx, block))
+	require.NoError(t, s.SetBeaconCommittee(ctx, &chaindb.BeaconCommittee{
+		Slot:      slot,
+		Index:     0,
+		Committee: []phase0.ValidatorIndex{validatorA, validatorB, validatorB + 1, validatorB + 2},
+	}))
+
+	// Alternate attestations between validator A (bit 0) and validator B (bit 1).
+	// Both are members of the committee, but only half of the attestations contain A.
+	attestations := make([]*chaindb.Attestation, 0)
+	for i := uint64(0); i < 10; i++ {
+		aggregationBits := []byte{0x11}
+		if i%2 == 1 {
+			aggregationBits = []byte{0x12}
+		}
+		attestations = append(attestations, &chaindb.Attestation{
+			InclusionSlot:      block.Slot,
+			InclusionBlockRoot: block.Root,
+			InclusionIndex:     i,
+			Slot:               slot,
+			CommitteeIndex:     0,
+			AggregationBits:    aggregationBits,
+			BeaconBlockRoot:    phase0.Root{0xca, 0x04},
+			TargetRoot:         phase0.Root{0xca, 0x05},
+		})
+	}
+	require.NoError(t, s.SetAttestations(ctx, attestations))
+
+	// The limit should apply to matching attestations, so a full page is returned.
+	res, err := s.Attestations(ctx, &chaindb.AttestationFilter{
+		ScheduledFrom:    &slot,
+		ScheduledTo:      &slot,
+		ValidatorIndices: []phase0.ValidatorIndex{validatorA},
+		Limit:            3,
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	for i, attestation := range res {
+		require.Equal(t, uint64(i*2), attestation.InclusionIndex)
+		require.Equal(t, []phase0.ValidatorIndex{validatorA}, attestation.AggregationIndices)
+	}
+
+	// A committee member that attested to nothing should match nothing.
+	res, err = s.Attestations(ctx, &chaindb.AttestationFilter{
+		ScheduledFrom:    &slot,
+		ScheduledTo:      &slot,
+		ValidatorIndices: []phase0.ValidatorIndex{validatorB + 1},
+	})
+	require.NoError(t, err)
+	require.Empty(t, res)
+
+	// An attestation without a stored committee cannot be expanded.
+	missingSlot := slot - 1
+	require.NoError(t, s.SetAttestation(ctx, &chaindb.Attestation{
+		InclusionSlot:      block.Slot,
+		InclusionBlockRoot: block.Root,
+		InclusionIndex:     10,
+		Slot:               missingSlot,
+		CommitteeIndex:     0,
+		AggregationBits:    []byte{0x11},
+		BeaconBlockRoot:    phase0.Root{0xca, 0x04},
+		TargetRoot:         phase0.Root{0xca, 0x05},
+	}))
+	_, err = s.Attestations(ctx, &chaindb.AttestationFilter{
+		ScheduledFrom: &missingSlot,
+		ScheduledTo:   &missingSlot,
+	})
+	require.ErrorContains(t, err, "no beacon committee for slot")
+}
@@ -0,0 +1,49 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/chaindbtest"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestConformance(t *testing.T) {
+	chaindbtest.Run(t, func(t *testing.T) chaindb.Service {
+		s, err := postgresql.New(context.Background(),
+			postgresql.WithLogLevel(zerolog.Disabled),
+			postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+		)
+		require.NoError(t, err)
+
+		return s
+	})
+}
+
+func TestConformanceCanonicalOnly(t *testing.T) {
+	s, err := postgresql.New(context.Background(),
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+		postgresql.WithCanonicalOnly(true),
+	)
+	require.NoError(t, err)
+
+	chaindbtest.RunCanonicalOnly(t, s)
+}
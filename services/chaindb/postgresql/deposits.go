@@ -15,16 +15,19 @@ package postgresql
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // SetDeposit sets a deposit.
 func (s *Service) SetDeposit(ctx context.Context, deposit *chaindb.Deposit) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetDeposit")
+	ctx, span := startSpan(ctx, "SetDeposit")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -32,19 +35,42 @@ func (s *Service) SetDeposit(ctx context.Context, deposit *chaindb.Deposit) erro
 		return ErrNoTransaction
 	}
 
-	_, err := tx.Exec(ctx, `
-      INSERT INTO t_deposits(f_inclusion_slot
-                            ,f_inclusion_block_root
-                            ,f_inclusion_index
-                            ,f_validator_pubkey
-                            ,f_withdrawal_credentials
-                            ,f_amount)
-      VALUES($1,$2,$3,$4,$5,$6)
-      ON CONFLICT (f_inclusion_slot,f_inclusion_block_root,f_inclusion_index) DO
-      UPDATE
-      SET f_validator_pubkey = excluded.f_validator_pubkey
-         ,f_withdrawal_credentials = excluded.f_withdrawal_credentials
-         ,f_amount = excluded.f_amount
+	// The deposit is written and checked against the first earlier deposit for
+	// its public key in a single statement; a row is returned only if their
+	// withdrawal credentials differ.
+	var firstCredentials []byte
+	var firstInclusionSlot phase0.Slot
+	err := tx.QueryRow(ctx, `
+      WITH first AS (
+        SELECT f_withdrawal_credentials
+              ,f_inclusion_slot
+        FROM t_deposits
+        WHERE f_validator_pubkey = $4
+          AND f_canonical IS DISTINCT FROM false
+          AND (f_inclusion_slot,f_inclusion_index) < ($1,$3)
+        ORDER BY f_inclusion_slot
+                ,f_inclusion_index
+        LIMIT 1
+      ), upsert AS (
+        INSERT INTO t_deposits(f_inclusion_slot
+                              ,f_inclusion_block_root
+                              ,f_inclusion_index
+                              ,f_validator_pubkey
+                              ,f_withdrawal_credentials
+                              ,f_amount
+                              ,f_canonical)
+        VALUES($1,$2,$3,$4,$5,$6,(SELECT f_canonical FROM t_blocks WHERE f_root = $2))
+        ON CONFLICT (f_inclusion_slot,f_inclusion_block_root,f_inclusion_index) DO
+        UPDATE
+        SET f_validator_pubkey = excluded.f_validator_pubkey
+           ,f_withdrawal_credentials = excluded.f_withdrawal_credentials
+           ,f_amount = excluded.f_amount
+           ,f_canonical = excluded.f_canonical
+      )
+      SELECT f_withdrawal_credentials
+            ,f_inclusion_slot
+      FROM first
+      WHERE f_withdrawal_credentials <> $5
       `,
 		deposit.InclusionSlot,
 		deposit.InclusionBlockRoot[:],
@@ -52,14 +78,24 @@ func (s *Service) SetDeposit(ctx context.Context, deposit *chaindb.Deposit) erro
 		deposit.ValidatorPubKey[:],
 		deposit.WithdrawalCredentials,
 		deposit.Amount,
-	)
+	).Scan(&firstCredentials, &firstInclusionSlot)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// Credentials match, or this is the first deposit.
+	case err != nil:
+		return err
+	default:
+		if err := s.recordDepositCredentialsMismatch(ctx, tx, deposit, firstCredentials, firstInclusionSlot); err != nil {
+			return err
+		}
+	}
 
-	return err
+	return s.runSQLHooks(ctx, tx, "deposit", depositHookValues(deposit))
 }
 
-// DepositsByPublicKey fetches deposits for a given set of validator public keys.
-func (s *Service) DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey][]*chaindb.Deposit, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "DepositsByPublicKey")
+// Deposits provides deposits according to the filter.
+func (s *Service) Deposits(ctx context.Context, filter *chaindb.DepositFilter) ([]*chaindb.Deposit, error) {
+	ctx, span := startSpan(ctx, "Deposits")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -72,89 +108,84 @@ func (s *Service) DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSP
 		tx = s.tx(ctx)
 	}
 
-	validatorPubKeys := make([][]byte, len(pubKeys))
-	for i := range pubKeys {
-		validatorPubKeys[i] = pubKeys[i][:]
-	}
-	rows, err := tx.Query(ctx, `
-      SELECT f_inclusion_slot
-            ,f_inclusion_block_root
-            ,f_inclusion_index
-            ,f_validator_pubkey
-            ,f_withdrawal_credentials
-            ,f_amount
-      FROM t_deposits
-      WHERE f_validator_pubkey = ANY($1)
-      ORDER BY f_inclusion_slot
-              ,f_inclusion_index
-	  `,
-		validatorPubKeys,
-	)
-	if err != nil {
-		return nil, err
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_inclusion_slot
+      ,f_inclusion_block_root
+      ,f_inclusion_index
+      ,f_validator_pubkey
+      ,f_withdrawal_credentials
+      ,f_amount
+FROM t_deposits`)
+
+	conditions := make([]string, 0)
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("f_inclusion_slot >= $%d", len(queryVals)))
 	}
-	defer rows.Close()
 
-	deposits := make(map[phase0.BLSPubKey][]*chaindb.Deposit, len(pubKeys))
-	for rows.Next() {
-		deposit := &chaindb.Deposit{}
-		var inclusionBlockRoot []byte
-		var validatorPubKey []byte
-		err := rows.Scan(
-			&deposit.InclusionSlot,
-			&inclusionBlockRoot,
-			&deposit.InclusionIndex,
-			&validatorPubKey,
-			&deposit.WithdrawalCredentials,
-			&deposit.Amount,
-		)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan row")
-		}
-		copy(deposit.InclusionBlockRoot[:], inclusionBlockRoot)
-		copy(deposit.ValidatorPubKey[:], validatorPubKey)
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("f_inclusion_slot <= $%d", len(queryVals)))
+	}
 
-		_, exists := deposits[deposit.ValidatorPubKey]
-		if !exists {
-			deposits[deposit.ValidatorPubKey] = make([]*chaindb.Deposit, 0)
+	if len(filter.PublicKeys) > 0 {
+		validatorPubKeys := make([][]byte, len(filter.PublicKeys))
+		for i := range filter.PublicKeys {
+			validatorPubKeys[i] = filter.PublicKeys[i][:]
 		}
-		deposits[deposit.ValidatorPubKey] = append(deposits[deposit.ValidatorPubKey], deposit)
+		queryVals = append(queryVals, validatorPubKeys)
+		conditions = append(conditions, fmt.Sprintf("f_validator_pubkey = ANY($%d)", len(queryVals)))
 	}
 
-	return deposits, nil
-}
+	if filter.Canonical != nil {
+		queryVals = append(queryVals, *filter.Canonical)
+		conditions = append(conditions, fmt.Sprintf("f_canonical = $%d", len(queryVals)))
+	} else if s.canonicalOnly {
+		conditions = append(conditions, "f_canonical = true")
+	}
 
-// DepositsForSlotRange fetches all deposits made in the given slot range.
-// It will return deposits from blocks that are canonical or undefined, but not from non-canonical blocks.
-func (s *Service) DepositsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.Deposit, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "DepositsForSlotRange")
-	defer span.End()
+	if filter.ExcludeNonCanonical {
+		conditions = append(conditions, "(f_canonical IS NULL OR f_canonical = true)")
+	}
 
-	tx := s.tx(ctx)
-	if tx == nil {
-		ctx, err := s.BeginROTx(ctx)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to begin transaction")
+	if len(conditions) > 0 {
+		queryBuilder.WriteString("\nWHERE ")
+		queryBuilder.WriteString(strings.Join(conditions, "\n  AND "))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_inclusion_slot, f_inclusion_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_inclusion_slot DESC,f_inclusion_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
 		}
-		defer s.CommitROTx(ctx)
-		tx = s.tx(ctx)
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
 	}
 
-	rows, err := tx.Query(ctx, `
-      SELECT f_inclusion_slot
-            ,f_inclusion_block_root
-            ,f_inclusion_index
-            ,f_validator_pubkey
-            ,f_withdrawal_credentials
-            ,f_amount
-      FROM t_deposits
-      WHERE f_inclusion_slot >= $1
-        AND f_inclusion_slot < $2
-		AND f_inclusion_slot IN (SELECT f_slot FROM t_blocks WHERE f_slot >= $1 AND f_slot < $2 AND (f_canonical IS NULL OR f_canonical = true))
-      ORDER BY f_inclusion_slot
-              ,f_inclusion_index`,
-		minSlot,
-		maxSlot,
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
 	)
 	if err != nil {
 		return nil, err
@@ -183,5 +214,48 @@ func (s *Service) DepositsForSlotRange(ctx context.Context, minSlot phase0.Slot,
 		deposits = append(deposits, deposit)
 	}
 
+	// Always return order of inclusion slot then inclusion index.
+	sort.Slice(deposits, func(i int, j int) bool {
+		if deposits[i].InclusionSlot != deposits[j].InclusionSlot {
+			return deposits[i].InclusionSlot < deposits[j].InclusionSlot
+		}
+		return deposits[i].InclusionIndex < deposits[j].InclusionIndex
+	})
+	return deposits, nil
+}
+
+// DepositsByPublicKey fetches deposits for a given set of validator public keys.
+func (s *Service) DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey][]*chaindb.Deposit, error) {
+	deposits := make(map[phase0.BLSPubKey][]*chaindb.Deposit, len(pubKeys))
+	if len(pubKeys) == 0 {
+		return deposits, nil
+	}
+
+	items, err := s.Deposits(ctx, &chaindb.DepositFilter{
+		PublicKeys: pubKeys,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, deposit := range items {
+		deposits[deposit.ValidatorPubKey] = append(deposits[deposit.ValidatorPubKey], deposit)
+	}
+
 	return deposits, nil
 }
+
+// DepositsForSlotRange fetches all deposits made in the given slot range.
+// It will return deposits from blocks that are canonical or undefined, but not from non-canonical blocks.
+func (s *Service) DepositsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.Deposit, error) {
+	if maxSlot <= minSlot {
+		return []*chaindb.Deposit{}, nil
+	}
+	to := maxSlot - 1
+
+	return s.Deposits(ctx, &chaindb.DepositFilter{
+		From:                &minSlot,
+		To:                  &to,
+		ExcludeNonCanonical: true,
+	})
+}
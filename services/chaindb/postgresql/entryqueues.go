@@ -0,0 +1,172 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetEntryQueue sets an entry queue.
+func (s *Service) SetEntryQueue(ctx context.Context, entryQueue *chaindb.EntryQueue) error {
+	ctx, span := startSpan(ctx, "SetEntryQueue")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_entry_queues(f_epoch
+                          ,f_pending_validators
+                          ,f_queue_length
+                          ,f_queue_balance
+                          ,f_activation_churn_limit
+                          ,f_projected_wait
+                          ,f_activated_validators
+                          ,f_average_wait)
+VALUES($1,$2,$3,$4,$5,$6,$7,$8)
+ON CONFLICT (f_epoch) DO
+UPDATE
+SET f_pending_validators = excluded.f_pending_validators
+   ,f_queue_length = excluded.f_queue_length
+   ,f_queue_balance = excluded.f_queue_balance
+   ,f_activation_churn_limit = excluded.f_activation_churn_limit
+   ,f_projected_wait = excluded.f_projected_wait
+   ,f_activated_validators = excluded.f_activated_validators
+   ,f_average_wait = excluded.f_average_wait
+`,
+		entryQueue.Epoch,
+		entryQueue.PendingValidators,
+		entryQueue.QueueLength,
+		entryQueue.QueueBalance,
+		entryQueue.ActivationChurnLimit,
+		entryQueue.ProjectedWait,
+		entryQueue.ActivatedValidators,
+		entryQueue.AverageWait,
+	)
+
+	return err
+}
+
+// EntryQueues provides entry queues according to the filter.
+func (s *Service) EntryQueues(ctx context.Context, filter *chaindb.EntryQueueFilter) ([]*chaindb.EntryQueue, error) {
+	ctx, span := startSpan(ctx, "EntryQueues")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_epoch
+      ,f_pending_validators
+      ,f_queue_length
+      ,f_queue_balance
+      ,f_activation_churn_limit
+      ,f_projected_wait
+      ,f_activated_validators
+      ,f_average_wait
+FROM t_entry_queues`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch <= $%d`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entryQueues := make([]*chaindb.EntryQueue, 0)
+	for rows.Next() {
+		entryQueue := &chaindb.EntryQueue{}
+		err := rows.Scan(
+			&entryQueue.Epoch,
+			&entryQueue.PendingValidators,
+			&entryQueue.QueueLength,
+			&entryQueue.QueueBalance,
+			&entryQueue.ActivationChurnLimit,
+			&entryQueue.ProjectedWait,
+			&entryQueue.ActivatedValidators,
+			&entryQueue.AverageWait,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		entryQueues = append(entryQueues, entryQueue)
+	}
+
+	// Always return order of epoch.
+	sort.Slice(entryQueues, func(i int, j int) bool {
+		return entryQueues[i].Epoch < entryQueues[j].Epoch
+	})
+	return entryQueues, nil
+}
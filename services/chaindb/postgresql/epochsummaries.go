@@ -15,18 +15,22 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"sort"
 	"strings"
 
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
+// latestEpochSummaryCacheKey is the read cache key for the latest epoch summary.
+const latestEpochSummaryCacheKey = "epoch_summaries:latest"
+
 // SetEpochSummary sets an epoch summary.
 func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSummary) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetEpochSummary")
+	ctx, span := startSpan(ctx, "SetEpochSummary")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -55,8 +59,24 @@ func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSum
                                    ,f_deposits
                                    ,f_exiting_validators
                                    ,f_canonical_blocks
-                                   ,f_withdrawals)
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21)
+                                   ,f_withdrawals
+                                   ,f_source_timely_validators
+                                   ,f_source_timely_balance
+                                   ,f_participation_rate
+                                   ,f_source_timely_rate
+                                   ,f_target_correct_rate
+                                   ,f_head_correct_rate
+                                   ,f_missed_proposals
+                                   ,f_orphaned_blocks
+                                   ,f_voluntary_exits
+                                   ,f_average_inclusion_delay
+                                   ,f_source_correct_validators
+                                   ,f_source_correct_balance
+                                   ,f_inclusion_target_correct_validators
+                                   ,f_inclusion_target_correct_balance
+                                   ,f_inclusion_head_correct_validators
+                                   ,f_inclusion_head_correct_balance)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28,$29,$30,$31,$32,$33,$34,$35,$36,$37)
       ON CONFLICT (f_epoch) DO
       UPDATE
       SET f_activation_queue_length = excluded.f_activation_queue_length
@@ -79,6 +99,22 @@ func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSum
          ,f_exiting_validators = excluded.f_exiting_validators
          ,f_canonical_blocks = excluded.f_canonical_blocks
          ,f_withdrawals = excluded.f_withdrawals
+         ,f_source_timely_validators = excluded.f_source_timely_validators
+         ,f_source_timely_balance = excluded.f_source_timely_balance
+         ,f_participation_rate = excluded.f_participation_rate
+         ,f_source_timely_rate = excluded.f_source_timely_rate
+         ,f_target_correct_rate = excluded.f_target_correct_rate
+         ,f_head_correct_rate = excluded.f_head_correct_rate
+         ,f_missed_proposals = excluded.f_missed_proposals
+         ,f_orphaned_blocks = excluded.f_orphaned_blocks
+         ,f_voluntary_exits = excluded.f_voluntary_exits
+         ,f_average_inclusion_delay = excluded.f_average_inclusion_delay
+         ,f_source_correct_validators = excluded.f_source_correct_validators
+         ,f_source_correct_balance = excluded.f_source_correct_balance
+         ,f_inclusion_target_correct_validators = excluded.f_inclusion_target_correct_validators
+         ,f_inclusion_target_correct_balance = excluded.f_inclusion_target_correct_balance
+         ,f_inclusion_head_correct_validators = excluded.f_inclusion_head_correct_validators
+         ,f_inclusion_head_correct_balance = excluded.f_inclusion_head_correct_balance
 		 `,
 		summary.Epoch,
 		summary.ActivationQueueLength,
@@ -101,16 +137,48 @@ func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSum
 		summary.ExitingValidators,
 		summary.CanonicalBlocks,
 		summary.Withdrawals,
+		summary.SourceTimelyValidators,
+		summary.SourceTimelyBalance,
+		summary.ParticipationRate,
+		summary.SourceTimelyRate,
+		summary.TargetCorrectRate,
+		summary.HeadCorrectRate,
+		summary.MissedProposals,
+		summary.OrphanedBlocks,
+		summary.VoluntaryExits,
+		summary.AverageInclusionDelay,
+		summary.SourceCorrectValidators,
+		summary.SourceCorrectBalance,
+		summary.InclusionTargetCorrectValidators,
+		summary.InclusionTargetCorrectBalance,
+		summary.InclusionHeadCorrectValidators,
+		summary.InclusionHeadCorrectBalance,
 	)
+	if err != nil {
+		return err
+	}
+	s.invalidateReadCache(ctx, latestEpochSummaryCacheKey)
 
-	return err
+	return nil
 }
 
 // EpochSummaries provides summaries according to the filter.
 func (s *Service) EpochSummaries(ctx context.Context, filter *chaindb.EpochSummaryFilter) ([]*chaindb.EpochSummary, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "EpochSummaries")
+	ctx, span := startSpan(ctx, "EpochSummaries")
 	defer span.End()
 
+	if filter.Order == chaindb.OrderLatest && filter.Limit == 1 && filter.From == nil && filter.To == nil {
+		// Request for the latest summary, which is cached.
+		return cachedRead(ctx, s, latestEpochSummaryCacheKey, func(ctx context.Context) ([]*chaindb.EpochSummary, error) {
+			return s.epochSummaries(ctx, filter)
+		})
+	}
+
+	return s.epochSummaries(ctx, filter)
+}
+
+// epochSummaries provides summaries according to the filter from the database.
+func (s *Service) epochSummaries(ctx context.Context, filter *chaindb.EpochSummaryFilter) ([]*chaindb.EpochSummary, error) {
 	tx := s.tx(ctx)
 	if tx == nil {
 		ctx, err := s.BeginROTx(ctx)
@@ -147,6 +215,22 @@ SELECT f_epoch
       ,f_exiting_validators
       ,f_canonical_blocks
       ,f_withdrawals
+      ,f_source_timely_validators
+      ,f_source_timely_balance
+      ,f_participation_rate
+      ,f_source_timely_rate
+      ,f_target_correct_rate
+      ,f_head_correct_rate
+      ,f_missed_proposals
+      ,f_orphaned_blocks
+      ,f_voluntary_exits
+      ,f_average_inclusion_delay
+      ,f_source_correct_validators
+      ,f_source_correct_balance
+      ,f_inclusion_target_correct_validators
+      ,f_inclusion_target_correct_balance
+      ,f_inclusion_head_correct_validators
+      ,f_inclusion_head_correct_balance
 FROM t_epoch_summaries`)
 
 	wherestr := "WHERE"
@@ -201,6 +285,22 @@ LIMIT $%d`, len(queryVals)))
 	summaries := make([]*chaindb.EpochSummary, 0)
 	for rows.Next() {
 		summary := &chaindb.EpochSummary{}
+		var sourceTimelyValidators sql.NullInt64
+		var sourceTimelyBalance sql.NullInt64
+		var participationRate sql.NullFloat64
+		var sourceTimelyRate sql.NullFloat64
+		var targetCorrectRate sql.NullFloat64
+		var headCorrectRate sql.NullFloat64
+		var missedProposals sql.NullInt64
+		var orphanedBlocks sql.NullInt64
+		var voluntaryExits sql.NullInt64
+		var averageInclusionDelay sql.NullFloat64
+		var sourceCorrectValidators sql.NullInt64
+		var sourceCorrectBalance sql.NullInt64
+		var inclusionTargetCorrectValidators sql.NullInt64
+		var inclusionTargetCorrectBalance sql.NullInt64
+		var inclusionHeadCorrectValidators sql.NullInt64
+		var inclusionHeadCorrectBalance sql.NullInt64
 		err := rows.Scan(
 			&summary.Epoch,
 			&summary.ActivationQueueLength,
@@ -223,10 +323,45 @@ LIMIT $%d`, len(queryVals)))
 			&summary.ExitingValidators,
 			&summary.CanonicalBlocks,
 			&summary.Withdrawals,
+			&sourceTimelyValidators,
+			&sourceTimelyBalance,
+			&participationRate,
+			&sourceTimelyRate,
+			&targetCorrectRate,
+			&headCorrectRate,
+			&missedProposals,
+			&orphanedBlocks,
+			&voluntaryExits,
+			&averageInclusionDelay,
+			&sourceCorrectValidators,
+			&sourceCorrectBalance,
+			&inclusionTargetCorrectValidators,
+			&inclusionTargetCorrectBalance,
+			&inclusionHeadCorrectValidators,
+			&inclusionHeadCorrectBalance,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
+		// Summaries created before these values were introduced will not have them.
+		summary.SourceTimelyValidators = int(sourceTimelyValidators.Int64)
+		summary.SourceTimelyBalance = phase0.Gwei(sourceTimelyBalance.Int64)
+		summary.ParticipationRate = participationRate.Float64
+		summary.SourceTimelyRate = sourceTimelyRate.Float64
+		summary.TargetCorrectRate = targetCorrectRate.Float64
+		summary.HeadCorrectRate = headCorrectRate.Float64
+		summary.MissedProposals = int(missedProposals.Int64)
+		summary.OrphanedBlocks = int(orphanedBlocks.Int64)
+		summary.VoluntaryExits = int(voluntaryExits.Int64)
+		if averageInclusionDelay.Valid {
+			summary.AverageInclusionDelay = &averageInclusionDelay.Float64
+		}
+		summary.SourceCorrectValidators = int(sourceCorrectValidators.Int64)
+		summary.SourceCorrectBalance = phase0.Gwei(sourceCorrectBalance.Int64)
+		summary.InclusionTargetCorrectValidators = int(inclusionTargetCorrectValidators.Int64)
+		summary.InclusionTargetCorrectBalance = phase0.Gwei(inclusionTargetCorrectBalance.Int64)
+		summary.InclusionHeadCorrectValidators = int(inclusionHeadCorrectValidators.Int64)
+		summary.InclusionHeadCorrectBalance = phase0.Gwei(inclusionHeadCorrectBalance.Int64)
 		summaries = append(summaries, summary)
 	}
 
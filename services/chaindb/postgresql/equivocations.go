@@ -0,0 +1,247 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetEquivocations sets equivocations.
+func (s *Service) SetEquivocations(ctx context.Context, equivocations []*chaindb.Equivocation) error {
+	ctx, span := startSpan(ctx, "SetEquivocations")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, equivocation := range equivocations {
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_equivocations(f_type
+                           ,f_validator_index
+                           ,f_slot_1
+                           ,f_root_1
+                           ,f_slot_2
+                           ,f_root_2
+                           ,f_source_epoch_1
+                           ,f_target_epoch_1
+                           ,f_source_epoch_2
+                           ,f_target_epoch_2
+                           ,f_slashed
+                           )
+VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+ON CONFLICT (f_validator_index,f_type,f_root_1,f_root_2) DO
+UPDATE
+SET f_slot_1 = excluded.f_slot_1
+   ,f_slot_2 = excluded.f_slot_2
+   ,f_source_epoch_1 = excluded.f_source_epoch_1
+   ,f_target_epoch_1 = excluded.f_target_epoch_1
+   ,f_source_epoch_2 = excluded.f_source_epoch_2
+   ,f_target_epoch_2 = excluded.f_target_epoch_2
+   ,f_slashed = excluded.f_slashed
+`,
+			equivocation.Type,
+			equivocation.ValidatorIndex,
+			equivocation.Slot1,
+			equivocation.Root1[:],
+			equivocation.Slot2,
+			equivocation.Root2[:],
+			nullEpoch(equivocation.SourceEpoch1),
+			nullEpoch(equivocation.TargetEpoch1),
+			nullEpoch(equivocation.SourceEpoch2),
+			nullEpoch(equivocation.TargetEpoch2),
+			equivocation.Slashed,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Equivocations provides equivocations according to the filter.
+func (s *Service) Equivocations(ctx context.Context, filter *chaindb.EquivocationFilter) ([]*chaindb.Equivocation, error) {
+	ctx, span := startSpan(ctx, "Equivocations")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_type
+      ,f_validator_index
+      ,f_slot_1
+      ,f_root_1
+      ,f_slot_2
+      ,f_root_2
+      ,f_source_epoch_1
+      ,f_target_epoch_1
+      ,f_source_epoch_2
+      ,f_target_epoch_2
+      ,f_slashed
+FROM t_equivocations`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot_2 >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot_2 <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.Types) > 0 {
+		queryVals = append(queryVals, filter.Types)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_type = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.ValidatorIndices != nil && len(*filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, *filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_validator_index = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.Slashed != nil {
+		queryVals = append(queryVals, *filter.Slashed)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slashed = $%d`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot_2, f_validator_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot_2 DESC, f_validator_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	equivocations := make([]*chaindb.Equivocation, 0)
+	root1 := make([]byte, phase0.RootLength)
+	root2 := make([]byte, phase0.RootLength)
+	for rows.Next() {
+		equivocation := &chaindb.Equivocation{}
+		var sourceEpoch1 sql.NullInt64
+		var targetEpoch1 sql.NullInt64
+		var sourceEpoch2 sql.NullInt64
+		var targetEpoch2 sql.NullInt64
+		err := rows.Scan(
+			&equivocation.Type,
+			&equivocation.ValidatorIndex,
+			&equivocation.Slot1,
+			&root1,
+			&equivocation.Slot2,
+			&root2,
+			&sourceEpoch1,
+			&targetEpoch1,
+			&sourceEpoch2,
+			&targetEpoch2,
+			&equivocation.Slashed,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(equivocation.Root1[:], root1)
+		copy(equivocation.Root2[:], root2)
+		equivocation.SourceEpoch1 = epochFromNull(sourceEpoch1)
+		equivocation.TargetEpoch1 = epochFromNull(targetEpoch1)
+		equivocation.SourceEpoch2 = epochFromNull(sourceEpoch2)
+		equivocation.TargetEpoch2 = epochFromNull(targetEpoch2)
+		equivocations = append(equivocations, equivocation)
+	}
+
+	// Always return order of second slot then validator index.
+	sort.Slice(equivocations, func(i int, j int) bool {
+		if equivocations[i].Slot2 != equivocations[j].Slot2 {
+			return equivocations[i].Slot2 < equivocations[j].Slot2
+		}
+		return equivocations[i].ValidatorIndex < equivocations[j].ValidatorIndex
+	})
+
+	return equivocations, nil
+}
+
+// nullEpoch converts an optional epoch to a nullable database value.
+func nullEpoch(epoch *phase0.Epoch) sql.NullInt64 {
+	if epoch == nil {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Valid: true, Int64: int64(*epoch)}
+}
+
+// epochFromNull converts a nullable database value to an optional epoch.
+func epochFromNull(val sql.NullInt64) *phase0.Epoch {
+	if !val.Valid {
+		return nil
+	}
+	epoch := phase0.Epoch(val.Int64)
+
+	return &epoch
+}
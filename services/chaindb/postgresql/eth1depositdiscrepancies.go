@@ -0,0 +1,176 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetETH1DepositDiscrepancy sets an Ethereum 1 deposit discrepancy.
+func (s *Service) SetETH1DepositDiscrepancy(ctx context.Context, discrepancy *chaindb.ETH1DepositDiscrepancy) error {
+	ctx, span := startSpan(ctx, "SetETH1DepositDiscrepancy")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	missingDepositIndices := discrepancy.MissingDepositIndices
+	if missingDepositIndices == nil {
+		missingDepositIndices = make([]uint64, 0)
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_eth1_deposit_discrepancies(f_eth1_block_number
+                                        ,f_timestamp
+                                        ,f_contract_deposit_count
+                                        ,f_indexed_deposit_count
+                                        ,f_contract_deposit_root
+                                        ,f_indexed_deposit_root
+                                        ,f_missing_deposit_indices)
+VALUES($1,$2,$3,$4,$5,$6,$7)
+ON CONFLICT (f_eth1_block_number) DO
+UPDATE
+SET f_timestamp = excluded.f_timestamp
+   ,f_contract_deposit_count = excluded.f_contract_deposit_count
+   ,f_indexed_deposit_count = excluded.f_indexed_deposit_count
+   ,f_contract_deposit_root = excluded.f_contract_deposit_root
+   ,f_indexed_deposit_root = excluded.f_indexed_deposit_root
+   ,f_missing_deposit_indices = excluded.f_missing_deposit_indices
+`,
+		discrepancy.ETH1BlockNumber,
+		discrepancy.Timestamp,
+		discrepancy.ContractDepositCount,
+		discrepancy.IndexedDepositCount,
+		discrepancy.ContractDepositRoot[:],
+		discrepancy.IndexedDepositRoot[:],
+		missingDepositIndices,
+	)
+
+	return err
+}
+
+// ETH1DepositDiscrepancies provides Ethereum 1 deposit discrepancies according to the filter.
+func (s *Service) ETH1DepositDiscrepancies(ctx context.Context, filter *chaindb.ETH1DepositDiscrepancyFilter) ([]*chaindb.ETH1DepositDiscrepancy, error) {
+	ctx, span := startSpan(ctx, "ETH1DepositDiscrepancies")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_eth1_block_number
+      ,f_timestamp
+      ,f_contract_deposit_count
+      ,f_indexed_deposit_count
+      ,f_contract_deposit_root
+      ,f_indexed_deposit_root
+      ,f_missing_deposit_indices
+FROM t_eth1_deposit_discrepancies`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_eth1_block_number >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_eth1_block_number <= $%d`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_eth1_block_number`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_eth1_block_number DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	discrepancies := make([]*chaindb.ETH1DepositDiscrepancy, 0)
+	for rows.Next() {
+		discrepancy := &chaindb.ETH1DepositDiscrepancy{}
+		var contractDepositRoot []byte
+		var indexedDepositRoot []byte
+		err := rows.Scan(
+			&discrepancy.ETH1BlockNumber,
+			&discrepancy.Timestamp,
+			&discrepancy.ContractDepositCount,
+			&discrepancy.IndexedDepositCount,
+			&contractDepositRoot,
+			&indexedDepositRoot,
+			&discrepancy.MissingDepositIndices,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(discrepancy.ContractDepositRoot[:], contractDepositRoot)
+		copy(discrepancy.IndexedDepositRoot[:], indexedDepositRoot)
+		discrepancies = append(discrepancies, discrepancy)
+	}
+
+	// Always return order of block number.
+	sort.Slice(discrepancies, func(i int, j int) bool {
+		return discrepancies[i].ETH1BlockNumber < discrepancies[j].ETH1BlockNumber
+	})
+	return discrepancies, nil
+}
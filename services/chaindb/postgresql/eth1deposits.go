@@ -15,16 +15,18 @@ package postgresql
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // SetETH1Deposit sets an Ethereum 1 deposit.
 func (s *Service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Deposit) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetETH1Deposit")
+	ctx, span := startSpan(ctx, "SetETH1Deposit")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -84,7 +86,7 @@ func (s *Service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Depos
 
 // ETH1DepositsByPublicKey fetches Ethereum 1 deposits for a given set of validator public keys.
 func (s *Service) ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) ([]*chaindb.ETH1Deposit, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ETH1DepositsByPublicKey")
+	ctx, span := startSpan(ctx, "ETH1DepositsByPublicKey")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -159,3 +161,132 @@ func (s *Service) ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.
 
 	return deposits, nil
 }
+
+// ETH1Deposits provides Ethereum 1 deposits according to the filter.
+func (s *Service) ETH1Deposits(ctx context.Context, filter *chaindb.ETH1DepositFilter) ([]*chaindb.ETH1Deposit, error) {
+	ctx, span := startSpan(ctx, "ETH1Deposits")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_eth1_block_number
+      ,f_eth1_block_hash
+      ,f_eth1_block_timestamp
+      ,f_eth1_tx_hash
+      ,f_eth1_log_index
+      ,f_eth1_sender
+      ,f_eth1_recipient
+      ,f_eth1_gas_used
+      ,f_eth1_gas_price
+      ,f_deposit_index
+      ,f_validator_pubkey
+      ,f_withdrawal_credentials
+      ,f_signature
+      ,f_amount
+FROM t_eth1_deposits`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_eth1_block_number >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_eth1_block_number <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.SenderLabels) > 0 {
+		queryVals = append(queryVals, filter.SenderLabels)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s %s`, wherestr, addressLabelsCondition("f_eth1_sender", len(queryVals))))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_deposit_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_deposit_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deposits := make([]*chaindb.ETH1Deposit, 0)
+	for rows.Next() {
+		deposit := &chaindb.ETH1Deposit{}
+		var validatorPubKey []byte
+		var signature []byte
+		err := rows.Scan(
+			&deposit.ETH1BlockNumber,
+			&deposit.ETH1BlockHash,
+			&deposit.ETH1BlockTimestamp,
+			&deposit.ETH1TxHash,
+			&deposit.ETH1LogIndex,
+			&deposit.ETH1Sender,
+			&deposit.ETH1Recipient,
+			&deposit.ETH1GasUsed,
+			&deposit.ETH1GasPrice,
+			&deposit.DepositIndex,
+			&validatorPubKey,
+			&deposit.WithdrawalCredentials,
+			&signature,
+			&deposit.Amount,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(deposit.ValidatorPubKey[:], validatorPubKey)
+		copy(deposit.Signature[:], signature)
+		deposits = append(deposits, deposit)
+	}
+
+	// Always return order of deposit index.
+	sort.Slice(deposits, func(i int, j int) bool {
+		return deposits[i].DepositIndex < deposits[j].DepositIndex
+	})
+
+	return deposits, nil
+}
@@ -21,12 +21,11 @@ import (
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // setExecutionPayload sets the execution payload of a block.
 func (s *Service) setExecutionPayload(ctx context.Context, block *chaindb.Block) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "setExecutionPayload")
+	ctx, span := startSpan(ctx, "setExecutionPayload")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -71,8 +70,11 @@ INSERT INTO t_block_execution_payloads(f_block_root
                                       ,f_extra_data
                                       ,f_blob_gas_used
                                       ,f_excess_blob_gas
+                                      ,f_transaction_count
+                                      ,f_transactions_size
+                                      ,f_blob_transaction_count
                                       )
-VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16)
+VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19)
 ON CONFLICT (f_block_root) DO
 UPDATE
 SET f_block_number = excluded.f_block_number
@@ -90,6 +92,9 @@ SET f_block_number = excluded.f_block_number
    ,f_extra_data = excluded.f_extra_data
    ,f_blob_gas_used = excluded.f_blob_gas_used
    ,f_excess_blob_gas = excluded.f_excess_blob_gas
+   ,f_transaction_count = excluded.f_transaction_count
+   ,f_transactions_size = excluded.f_transactions_size
+   ,f_blob_transaction_count = excluded.f_blob_transaction_count
 `,
 		block.Root[:],
 		block.ExecutionPayload.BlockNumber,
@@ -102,11 +107,14 @@ SET f_block_number = excluded.f_block_number
 		block.ExecutionPayload.PrevRandao[:],
 		block.ExecutionPayload.GasLimit,
 		block.ExecutionPayload.GasUsed,
-		decimal.NewFromBigInt(block.ExecutionPayload.BaseFeePerGas, 0),
+		numericFromBigInt(block.ExecutionPayload.BaseFeePerGas),
 		block.ExecutionPayload.Timestamp,
 		extraData,
 		block.ExecutionPayload.BlobGasUsed,
 		block.ExecutionPayload.ExcessBlobGas,
+		block.ExecutionPayload.TransactionCount,
+		block.ExecutionPayload.TransactionsSize,
+		block.ExecutionPayload.BlobTransactionCount,
 	)
 	if err != nil {
 		return err
@@ -127,7 +135,7 @@ func (s *Service) executionPayload(ctx context.Context,
 	*chaindb.ExecutionPayload,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "executionPayload")
+	ctx, span := startSpan(ctx, "executionPayload")
 	defer span.End()
 
 	payload := &chaindb.ExecutionPayload{}
@@ -138,7 +146,9 @@ func (s *Service) executionPayload(ctx context.Context,
 	var receiptsRoot []byte
 	var logsBloom []byte
 	var prevRandao []byte
-	var baseFeePerGas decimal.Decimal
+	var baseFeePerGas decimal.NullDecimal
+	var payloadValue decimal.NullDecimal
+	var payloadValueSource *string
 
 	err := tx.QueryRow(ctx, `
 SELECT f_block_number
@@ -156,6 +166,11 @@ SELECT f_block_number
       ,f_extra_data
       ,f_blob_gas_used
       ,f_excess_blob_gas
+      ,f_payload_value
+      ,f_payload_value_source
+      ,f_transaction_count
+      ,f_transactions_size
+      ,f_blob_transaction_count
 FROM t_block_execution_payloads
 WHERE f_block_root = $1`,
 		root[:],
@@ -175,6 +190,11 @@ WHERE f_block_root = $1`,
 		&payload.ExtraData,
 		&payload.BlobGasUsed,
 		&payload.ExcessBlobGas,
+		&payloadValue,
+		&payloadValueSource,
+		&payload.TransactionCount,
+		&payload.TransactionsSize,
+		&payload.BlobTransactionCount,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -190,7 +210,11 @@ WHERE f_block_root = $1`,
 	copy(payload.ReceiptsRoot[:], receiptsRoot)
 	copy(payload.LogsBloom[:], logsBloom)
 	copy(payload.PrevRandao[:], prevRandao)
-	payload.BaseFeePerGas = baseFeePerGas.BigInt()
+	payload.BaseFeePerGas = denominated(ctx, bigIntFromNumeric(baseFeePerGas))
+	payload.PayloadValue = denominated(ctx, bigIntFromNumeric(payloadValue))
+	if payloadValueSource != nil {
+		payload.PayloadValueSource = *payloadValueSource
+	}
 
 	return payload, nil
 }
@@ -203,7 +227,7 @@ func (s *Service) executionPayloads(ctx context.Context,
 	map[phase0.Root]*chaindb.ExecutionPayload,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "executionPayloads")
+	ctx, span := startSpan(ctx, "executionPayloads")
 	defer span.End()
 
 	broots := make([][]byte, len(roots))
@@ -228,6 +252,11 @@ SELECT f_block_root
       ,f_extra_data
       ,f_blob_gas_used
       ,f_excess_blob_gas
+      ,f_payload_value
+      ,f_payload_value_source
+      ,f_transaction_count
+      ,f_transactions_size
+      ,f_blob_transaction_count
 FROM t_block_execution_payloads
 WHERE f_block_root = ANY($1)`,
 		broots,
@@ -248,7 +277,9 @@ WHERE f_block_root = ANY($1)`,
 		var receiptsRoot []byte
 		var logsBloom []byte
 		var prevRandao []byte
-		var baseFeePerGas decimal.Decimal
+		var baseFeePerGas decimal.NullDecimal
+		var payloadValue decimal.NullDecimal
+		var payloadValueSource *string
 		err := rows.Scan(&blockRoot,
 			&payload.BlockNumber,
 			&blockHash,
@@ -265,6 +296,11 @@ WHERE f_block_root = ANY($1)`,
 			&payload.ExtraData,
 			&payload.BlobGasUsed,
 			&payload.ExcessBlobGas,
+			&payloadValue,
+			&payloadValueSource,
+			&payload.TransactionCount,
+			&payload.TransactionsSize,
+			&payload.BlobTransactionCount,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -276,7 +312,11 @@ WHERE f_block_root = ANY($1)`,
 		copy(payload.ReceiptsRoot[:], receiptsRoot)
 		copy(payload.LogsBloom[:], logsBloom)
 		copy(payload.PrevRandao[:], prevRandao)
-		payload.BaseFeePerGas = baseFeePerGas.BigInt()
+		payload.BaseFeePerGas = denominated(ctx, bigIntFromNumeric(baseFeePerGas))
+		payload.PayloadValue = denominated(ctx, bigIntFromNumeric(payloadValue))
+		if payloadValueSource != nil {
+			payload.PayloadValueSource = *payloadValueSource
+		}
 
 		var key phase0.Root
 		copy(key[:], blockRoot)
@@ -285,3 +325,34 @@ WHERE f_block_root = ANY($1)`,
 
 	return res, nil
 }
+
+// SetExecutionPayloadValues sets the values of execution payloads.
+func (s *Service) SetExecutionPayloadValues(ctx context.Context, values []*chaindb.ExecutionPayloadValue) error {
+	ctx, span := startSpan(ctx, "SetExecutionPayloadValues")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, value := range values {
+		if value.Value == nil {
+			return errors.New("payload value missing")
+		}
+		if _, err := tx.Exec(ctx, `
+UPDATE t_block_execution_payloads
+SET f_payload_value = $2
+   ,f_payload_value_source = $3
+WHERE f_block_root = $1
+`,
+			value.BlockRoot[:],
+			numericFromBigInt(value.Value),
+			value.Source,
+		); err != nil {
+			return errors.Wrap(err, "failed to set execution payload value")
+		}
+	}
+
+	return nil
+}
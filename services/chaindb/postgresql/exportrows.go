@@ -0,0 +1,166 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// exportColumnTypes maps PostgreSQL type OIDs to export column types.
+var exportColumnTypes = map[uint32]*chaindb.ExportColumn{
+	pgtype.Int2OID:             {Type: chaindb.ExportColumnTypeInteger},
+	pgtype.Int4OID:             {Type: chaindb.ExportColumnTypeInteger},
+	pgtype.Int8OID:             {Type: chaindb.ExportColumnTypeInteger},
+	pgtype.Float4OID:           {Type: chaindb.ExportColumnTypeFloat},
+	pgtype.Float8OID:           {Type: chaindb.ExportColumnTypeFloat},
+	pgtype.BoolOID:             {Type: chaindb.ExportColumnTypeBoolean},
+	pgtype.ByteaOID:            {Type: chaindb.ExportColumnTypeBytes},
+	pgtype.TimestampOID:        {Type: chaindb.ExportColumnTypeTimestamp},
+	pgtype.TimestamptzOID:      {Type: chaindb.ExportColumnTypeTimestamp},
+	pgtype.NumericOID:          {Type: chaindb.ExportColumnTypeNumeric},
+	pgtype.TextOID:             {Type: chaindb.ExportColumnTypeString},
+	pgtype.VarcharOID:          {Type: chaindb.ExportColumnTypeString},
+	pgtype.Int2ArrayOID:        {Type: chaindb.ExportColumnTypeInteger, Repeated: true},
+	pgtype.Int4ArrayOID:        {Type: chaindb.ExportColumnTypeInteger, Repeated: true},
+	pgtype.Int8ArrayOID:        {Type: chaindb.ExportColumnTypeInteger, Repeated: true},
+	pgtype.Float4ArrayOID:      {Type: chaindb.ExportColumnTypeFloat, Repeated: true},
+	pgtype.Float8ArrayOID:      {Type: chaindb.ExportColumnTypeFloat, Repeated: true},
+	pgtype.BoolArrayOID:        {Type: chaindb.ExportColumnTypeBoolean, Repeated: true},
+	pgtype.ByteaArrayOID:       {Type: chaindb.ExportColumnTypeBytes, Repeated: true},
+	pgtype.TimestamptzArrayOID: {Type: chaindb.ExportColumnTypeTimestamp, Repeated: true},
+	pgtype.NumericArrayOID:     {Type: chaindb.ExportColumnTypeNumeric, Repeated: true},
+	pgtype.TextArrayOID:        {Type: chaindb.ExportColumnTypeString, Repeated: true},
+}
+
+// ExportRows obtains the columns of a table, and its rows for the first maxMarks distinct
+// values of the mark column in the inclusive range [from, to].
+// Numeric values are returned as strings.
+func (s *Service) ExportRows(ctx context.Context,
+	table string,
+	markColumn string,
+	from int64,
+	to int64,
+	maxMarks uint32,
+) (
+	[]*chaindb.ExportColumn,
+	[][]any,
+	error,
+) {
+	ctx, span := startSpan(ctx, "ExportRows")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	tableName := pgx.Identifier{table}.Sanitize()
+	markColumnName := pgx.Identifier{markColumn}.Sanitize()
+	query := fmt.Sprintf(`
+SELECT *
+FROM %s
+WHERE %s IN (
+  SELECT DISTINCT %s
+  FROM %s
+  WHERE %s >= $1
+    AND %s <= $2
+  ORDER BY %s
+  LIMIT $3
+)
+ORDER BY %s`, tableName, markColumnName, markColumnName, tableName, markColumnName, markColumnName, markColumnName, markColumnName)
+
+	if e := log.Trace(); e.Enabled() {
+		e.Str("query", strings.ReplaceAll(query, "\n", " ")).Int64("from", from).Int64("to", to).Uint32("max_marks", maxMarks).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx, query, from, to, maxMarks)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	fieldDescriptions := rows.FieldDescriptions()
+	columns := make([]*chaindb.ExportColumn, len(fieldDescriptions))
+	for i, fieldDescription := range fieldDescriptions {
+		columnType, exists := exportColumnTypes[fieldDescription.DataTypeOID]
+		if !exists {
+			// Fall back to the textual representation.
+			columnType = &chaindb.ExportColumn{Type: chaindb.ExportColumnTypeString}
+		}
+		columns[i] = &chaindb.ExportColumn{
+			Name:     fieldDescription.Name,
+			Type:     columnType.Type,
+			Repeated: columnType.Repeated,
+		}
+	}
+
+	res := make([][]any, 0)
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to obtain row values")
+		}
+		for i := range values {
+			values[i] = exportValue(values[i], columns[i])
+		}
+		res = append(res, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to read rows")
+	}
+
+	return columns, res, nil
+}
+
+// exportValue converts a value obtained from the database to one suitable for export.
+func exportValue(value any, column *chaindb.ExportColumn) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		res := make([]any, len(v))
+		for i := range v {
+			res[i] = exportValue(v[i], column)
+		}
+		return res
+	case decimal.Decimal:
+		return v.String()
+	case pgtype.Numeric:
+		val, err := v.Value()
+		if err != nil {
+			return nil
+		}
+		return val
+	}
+	if column.Type == chaindb.ExportColumnTypeString {
+		if _, isString := value.(string); !isString {
+			return fmt.Sprintf("%v", value)
+		}
+	}
+
+	return value
+}
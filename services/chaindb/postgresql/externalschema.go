@@ -0,0 +1,592 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrExternalSchema is returned when an action would change the schema of a
+// database whose schema is managed externally.
+var ErrExternalSchema = errors.New("schema is externally managed")
+
+// Kinds of schema mismatch.
+const (
+	SchemaMismatchVersion     = "version"
+	SchemaMismatchExtension   = "missing extension"
+	SchemaMismatchTable       = "missing table"
+	SchemaMismatchColumn      = "missing column"
+	SchemaMismatchColumnType  = "column type"
+	SchemaMismatchNullability = "column nullability"
+	SchemaMismatchIndex       = "missing index"
+	SchemaMismatchIndexTable  = "index table"
+	SchemaMismatchFunction    = "missing function"
+)
+
+// SchemaMismatch is a difference between the schema in the database and that
+// expected by this release.
+type SchemaMismatch struct {
+	Kind     string
+	Table    string
+	Object   string
+	Expected string
+	Actual   string
+}
+
+// String provides a human-readable description of the mismatch.
+func (m *SchemaMismatch) String() string {
+	var object string
+	switch {
+	case m.Table != "" && m.Object != "":
+		object = fmt.Sprintf("%s.%s", m.Table, m.Object)
+	case m.Table != "":
+		object = m.Table
+	default:
+		object = m.Object
+	}
+
+	switch {
+	case m.Actual != "":
+		return fmt.Sprintf("%s %s: expected %s, found %s", m.Kind, object, m.Expected, m.Actual)
+	case m.Expected != "":
+		return fmt.Sprintf("%s %s: expected %s", m.Kind, object, m.Expected)
+	default:
+		return fmt.Sprintf("%s %s", m.Kind, object)
+	}
+}
+
+// schemaColumn is a column of a table.
+type schemaColumn struct {
+	name     string
+	dataType string
+	notNull  bool
+}
+
+// schemaTable is a table and its columns.
+type schemaTable struct {
+	name    string
+	columns []*schemaColumn
+}
+
+// expectedSchema is the schema expected by this release.
+type expectedSchema struct {
+	extensions []string
+	tables     []*schemaTable
+	// indexes are the tables of indexes, keyed by index name.
+	indexes   map[string]string
+	functions []string
+}
+
+// actualSchema is the schema present in the database.
+type actualSchema struct {
+	extensions map[string]bool
+	// columns are the columns of tables, keyed by table then column name.
+	columns   map[string]map[string]*schemaColumn
+	indexes   map[string]string
+	functions map[string]bool
+}
+
+var (
+	schemaExtensionRe = regexp.MustCompile(`^CREATE EXTENSION (?:IF NOT EXISTS )?(\w+)`)
+	schemaTableRe     = regexp.MustCompile(`^CREATE TABLE (\w+) \($`)
+	schemaIndexRe     = regexp.MustCompile(`^CREATE (?:UNIQUE )?INDEX (?:IF NOT EXISTS )?(\w+) ON (\w+)`)
+	schemaFunctionRe  = regexp.MustCompile(`^CREATE (?:OR REPLACE )?FUNCTION (\w+)\(`)
+	schemaColumnRe    = regexp.MustCompile(`^,?\s*(f_\w+)\s+(.+)$`)
+	// schemaConstraintRe matches the start of the constraints of a column definition.
+	schemaConstraintRe = regexp.MustCompile(`\s+(?:NOT NULL|NULL|PRIMARY KEY|UNIQUE|REFERENCES|DEFAULT|CHECK)\b`)
+	schemaFloatRe      = regexp.MustCompile(`^FLOAT\((\d+)\)$`)
+)
+
+// ValidateSchema compares the schema in the database with that expected by
+// this release, returning the differences.  Objects in the database that are
+// not expected, such as additional indexes, are not considered mismatches.
+func (s *Service) ValidateSchema(ctx context.Context) ([]*SchemaMismatch, error) {
+	ctx, span := startSpan(ctx, "ValidateSchema")
+	defer span.End()
+
+	version, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain schema version")
+	}
+	if version > currentVersion {
+		// Later versions are expected to be a superset of this version, so
+		// compare the structure regardless.
+		log.Warn().Uint64("version", version).Msg("This release is running an older version than that in the database, please upgrade to the latest release")
+	}
+	if version < currentVersion {
+		// The structure of the schema cannot be usefully compared with that of an earlier version.
+		return []*SchemaMismatch{{
+			Kind:     SchemaMismatchVersion,
+			Object:   "schema",
+			Expected: strconv.FormatUint(currentVersion, 10),
+			Actual:   strconv.FormatUint(version, 10),
+		}}, nil
+	}
+
+	expected, err := latestSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	actual, err := s.actualSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return compareSchemas(expected, actual), nil
+}
+
+// checkExternalSchema returns an error if the externally managed schema does not
+// match that expected by this release.
+func (s *Service) checkExternalSchema(ctx context.Context) error {
+	mismatches, err := s.ValidateSchema(ctx)
+	if err != nil {
+		return err
+	}
+	if len(mismatches) == 0 {
+		log.Trace().Uint64("version", currentVersion).Msg("Externally managed schema matches expectations")
+		return nil
+	}
+
+	for _, mismatch := range mismatches {
+		log.Error().Str("kind", mismatch.Kind).Str("table", mismatch.Table).Str("object", mismatch.Object).Str("expected", mismatch.Expected).Str("actual", mismatch.Actual).Msg("Schema mismatch")
+	}
+
+	return fmt.Errorf("externally managed schema has %d mismatch(es) with version %d, the first being %s; apply the statements from \"chaind schema print\"", len(mismatches), currentVersion, mismatches[0].String())
+}
+
+// SchemaStatements provides the statements that upgrade the schema to the
+// given version from the version before, followed by the statement that
+// records the version.  If the version is 0 then the statements create the
+// latest version of the schema in an empty database.
+//
+// Upgrades that inspect the database before deciding on their statements see
+// the database as it is, so their statements should be generated against a
+// database at the prior version.
+func (s *Service) SchemaStatements(ctx context.Context, version uint64) ([]string, error) {
+	if version > currentVersion {
+		return nil, errors.Errorf("version %d is later than the latest version %d", version, currentVersion)
+	}
+
+	var statements []string
+	var err error
+	if version == 0 {
+		statements, err = s.recordStatements(ctx, func(ctx context.Context) error {
+			return createInitialTables(ctx, s)
+		})
+		version = currentVersion
+	} else {
+		statements, err = s.recordStatements(ctx, func(ctx context.Context) error {
+			if upgrade, exists := upgrades[version]; exists {
+				for _, upgradeFunc := range upgrade.funcs {
+					if err := upgradeFunc(ctx, s); err != nil {
+						return err
+					}
+				}
+			}
+
+			return nil
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return append(statements, fmt.Sprintf(`INSERT INTO t_metadata(f_key,f_value) VALUES('schema','{"version":%d}')
+ON CONFLICT (f_key) DO UPDATE SET f_value = excluded.f_value`, version)), nil
+}
+
+// recordStatements records, rather than executes, the statements issued by the function.
+func (s *Service) recordStatements(ctx context.Context, fn func(context.Context) error) ([]string, error) {
+	ctx, cancel, err := s.BeginTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer cancel()
+
+	statements := make([]string, 0)
+	ctx = context.WithValue(ctx, &Tx{}, &recordingTx{
+		Tx:         s.tx(ctx),
+		statements: &statements,
+	})
+
+	if err := fn(ctx); err != nil {
+		return nil, err
+	}
+
+	return statements, nil
+}
+
+// latestSchema provides the expected schema, as created for the latest version.
+func latestSchema() (*expectedSchema, error) {
+	statements := make([]string, 0)
+	ctx := context.WithValue(context.Background(), &Tx{}, &recordingTx{statements: &statements})
+	if err := createInitialTables(ctx, &Service{}); err != nil {
+		return nil, err
+	}
+
+	return parseSchema(strings.Join(statements, "\n"))
+}
+
+// parseSchema parses the objects expected by chaind from schema statements.
+func parseSchema(ddl string) (*expectedSchema, error) {
+	schema := &expectedSchema{
+		indexes: make(map[string]string),
+	}
+
+	var table *schemaTable
+	for _, line := range strings.Split(ddl, "\n") {
+		if idx := strings.Index(line, "--"); idx != -1 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if table != nil {
+			if strings.HasPrefix(line, ")") {
+				schema.tables = append(schema.tables, table)
+				table = nil
+				continue
+			}
+			match := schemaColumnRe.FindStringSubmatch(line)
+			if match == nil {
+				// Table constraint.
+				continue
+			}
+			column, err := parseColumn(match[1], match[2])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid definition of %s.%s", table.name, match[1])
+			}
+			table.columns = append(table.columns, column)
+			continue
+		}
+
+		if match := schemaTableRe.FindStringSubmatch(line); match != nil {
+			table = &schemaTable{name: match[1]}
+			continue
+		}
+		if match := schemaIndexRe.FindStringSubmatch(line); match != nil {
+			schema.indexes[match[1]] = match[2]
+			continue
+		}
+		if match := schemaExtensionRe.FindStringSubmatch(line); match != nil {
+			schema.extensions = append(schema.extensions, match[1])
+			continue
+		}
+		if match := schemaFunctionRe.FindStringSubmatch(line); match != nil {
+			schema.functions = append(schema.functions, match[1])
+			continue
+		}
+	}
+	if table != nil {
+		return nil, fmt.Errorf("definition of %s is not terminated", table.name)
+	}
+
+	return schema, nil
+}
+
+// parseColumn parses the definition of a column.
+func parseColumn(name string, definition string) (*schemaColumn, error) {
+	dataType := definition
+	constraints := ""
+	if loc := schemaConstraintRe.FindStringIndex(definition); loc != nil {
+		dataType = definition[:loc[0]]
+		constraints = definition[loc[0]:]
+	}
+	dataType, err := catalogType(strings.TrimSpace(dataType))
+	if err != nil {
+		return nil, err
+	}
+
+	return &schemaColumn{
+		name:     name,
+		dataType: dataType,
+		notNull:  strings.Contains(constraints, "NOT NULL") || strings.Contains(constraints, "PRIMARY KEY"),
+	}, nil
+}
+
+// catalogType provides the name of a type as reported by the database catalog.
+func catalogType(dataType string) (string, error) {
+	suffix := ""
+	if strings.HasSuffix(dataType, "[]") {
+		suffix = "[]"
+		dataType = strings.TrimSuffix(dataType, "[]")
+	}
+
+	var name string
+	switch dataType {
+	case "BIGINT", "BIGSERIAL":
+		name = "bigint"
+	case "INTEGER", "INT", "SERIAL":
+		name = "integer"
+	case "SMALLINT":
+		name = "smallint"
+	case "BOOL", "BOOLEAN":
+		name = "boolean"
+	case "BYTEA":
+		name = "bytea"
+	case "TEXT":
+		name = "text"
+	case "JSONB":
+		name = "jsonb"
+	case "NUMERIC":
+		name = "numeric"
+	case "DOUBLE PRECISION":
+		name = "double precision"
+	case "TIMESTAMPTZ":
+		name = "timestamp with time zone"
+	default:
+		match := schemaFloatRe.FindStringSubmatch(dataType)
+		if match == nil {
+			return "", fmt.Errorf("unknown type %s", dataType)
+		}
+		precision, err := strconv.Atoi(match[1])
+		if err != nil {
+			return "", errors.Wrap(err, "invalid precision")
+		}
+		// Floats with up to 24 bits of precision are stored as reals.
+		name = "double precision"
+		if precision <= 24 {
+			name = "real"
+		}
+	}
+
+	return name + suffix, nil
+}
+
+// actualSchema obtains the schema present in the database.
+func (s *Service) actualSchema(ctx context.Context) (*actualSchema, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.CommitROTx(ctx)
+	}
+
+	schema := &actualSchema{
+		extensions: make(map[string]bool),
+		columns:    make(map[string]map[string]*schemaColumn),
+		indexes:    make(map[string]string),
+		functions:  make(map[string]bool),
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT c.relname
+      ,a.attname
+      ,format_type(a.atttypid, a.atttypmod)
+      ,a.attnotnull
+FROM pg_attribute a
+JOIN pg_class c ON c.oid = a.attrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = current_schema()
+  AND c.relkind IN ('r','p')
+  AND a.attnum > 0
+  AND NOT a.attisdropped`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain columns")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table string
+		column := &schemaColumn{}
+		if err := rows.Scan(&table, &column.name, &column.dataType, &column.notNull); err != nil {
+			return nil, errors.Wrap(err, "failed to scan column")
+		}
+		if _, exists := schema.columns[table]; !exists {
+			schema.columns[table] = make(map[string]*schemaColumn)
+		}
+		schema.columns[table][column.name] = column
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain columns")
+	}
+
+	rows, err = tx.Query(ctx, `
+SELECT indexname
+      ,tablename
+FROM pg_indexes
+WHERE schemaname = current_schema()`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain indexes")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var index string
+		var table string
+		if err := rows.Scan(&index, &table); err != nil {
+			return nil, errors.Wrap(err, "failed to scan index")
+		}
+		schema.indexes[index] = table
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain indexes")
+	}
+
+	rows, err = tx.Query(ctx, `
+SELECT p.proname
+FROM pg_proc p
+JOIN pg_namespace n ON n.oid = p.pronamespace
+WHERE n.nspname = current_schema()`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain functions")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var function string
+		if err := rows.Scan(&function); err != nil {
+			return nil, errors.Wrap(err, "failed to scan function")
+		}
+		schema.functions[function] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain functions")
+	}
+
+	rows, err = tx.Query(ctx, `SELECT extname FROM pg_extension`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain extensions")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var extension string
+		if err := rows.Scan(&extension); err != nil {
+			return nil, errors.Wrap(err, "failed to scan extension")
+		}
+		schema.extensions[extension] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain extensions")
+	}
+
+	return schema, nil
+}
+
+// compareSchemas returns the objects in the expected schema that are missing
+// from, or differ in, the actual schema.
+func compareSchemas(expected *expectedSchema, actual *actualSchema) []*SchemaMismatch {
+	mismatches := make([]*SchemaMismatch, 0)
+
+	for _, extension := range expected.extensions {
+		if !actual.extensions[extension] {
+			mismatches = append(mismatches, &SchemaMismatch{
+				Kind:   SchemaMismatchExtension,
+				Object: extension,
+			})
+		}
+	}
+
+	for _, table := range expected.tables {
+		columns, exists := actual.columns[table.name]
+		if !exists {
+			mismatches = append(mismatches, &SchemaMismatch{
+				Kind:  SchemaMismatchTable,
+				Table: table.name,
+			})
+			continue
+		}
+		for _, column := range table.columns {
+			actualColumn, exists := columns[column.name]
+			if !exists {
+				mismatches = append(mismatches, &SchemaMismatch{
+					Kind:     SchemaMismatchColumn,
+					Table:    table.name,
+					Object:   column.name,
+					Expected: column.dataType,
+				})
+				continue
+			}
+			if actualColumn.dataType != column.dataType {
+				mismatches = append(mismatches, &SchemaMismatch{
+					Kind:     SchemaMismatchColumnType,
+					Table:    table.name,
+					Object:   column.name,
+					Expected: column.dataType,
+					Actual:   actualColumn.dataType,
+				})
+			}
+			if actualColumn.notNull != column.notNull {
+				mismatches = append(mismatches, &SchemaMismatch{
+					Kind:     SchemaMismatchNullability,
+					Table:    table.name,
+					Object:   column.name,
+					Expected: nullability(column.notNull),
+					Actual:   nullability(actualColumn.notNull),
+				})
+			}
+		}
+	}
+
+	indexes := make([]string, 0, len(expected.indexes))
+	for index := range expected.indexes {
+		indexes = append(indexes, index)
+	}
+	sort.Strings(indexes)
+	for _, index := range indexes {
+		table := expected.indexes[index]
+		if _, exists := actual.columns[table]; !exists {
+			// Already reported as a missing table.
+			continue
+		}
+		actualTable, exists := actual.indexes[index]
+		switch {
+		case !exists:
+			mismatches = append(mismatches, &SchemaMismatch{
+				Kind:   SchemaMismatchIndex,
+				Table:  table,
+				Object: index,
+			})
+		case actualTable != table:
+			mismatches = append(mismatches, &SchemaMismatch{
+				Kind:     SchemaMismatchIndexTable,
+				Object:   index,
+				Expected: table,
+				Actual:   actualTable,
+			})
+		}
+	}
+
+	for _, function := range expected.functions {
+		if !actual.functions[function] {
+			mismatches = append(mismatches, &SchemaMismatch{
+				Kind:   SchemaMismatchFunction,
+				Object: function,
+			})
+		}
+	}
+
+	return mismatches
+}
+
+// nullability describes the nullability of a column.
+func nullability(notNull bool) string {
+	if notNull {
+		return "NOT NULL"
+	}
+
+	return "NULL"
+}
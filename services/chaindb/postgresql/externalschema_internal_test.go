@@ -0,0 +1,163 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestSchema(t *testing.T) {
+	schema, err := latestSchema()
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"pg_trgm"}, schema.extensions)
+	require.Equal(t, []string{"f_outbox_capture"}, schema.functions)
+	require.Equal(t, "t_metadata", schema.indexes["i_metadata_1"])
+	for _, index := range secondaryIndexes {
+		require.Contains(t, schema.indexes, index.name)
+	}
+
+	tables := make(map[string]*schemaTable, len(schema.tables))
+	for _, table := range schema.tables {
+		tables[table.name] = table
+	}
+	require.Equal(t, []*schemaColumn{
+		{name: "f_key", dataType: "text", notNull: true},
+		{name: "f_value", dataType: "jsonb", notNull: true},
+	}, tables["t_metadata"].columns)
+	require.Equal(t, []*schemaColumn{
+		{name: "f_id", dataType: "bigint", notNull: true},
+		{name: "f_table", dataType: "text", notNull: true},
+		{name: "f_operation", dataType: "text", notNull: true},
+		{name: "f_data", dataType: "jsonb", notNull: true},
+		{name: "f_timestamp", dataType: "timestamp with time zone", notNull: true},
+	}, tables["t_outbox"].columns)
+	// Table constraints are not columns.
+	for _, column := range tables["t_proposer_period_summaries"].columns {
+		require.Regexp(t, `^f_`, column.name)
+	}
+}
+
+func TestCatalogType(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataType string
+		expected string
+		err      string
+	}{
+		{name: "BigInt", dataType: "BIGINT", expected: "bigint"},
+		{name: "BigSerial", dataType: "BIGSERIAL", expected: "bigint"},
+		{name: "BigIntArray", dataType: "BIGINT[]", expected: "bigint[]"},
+		{name: "Bool", dataType: "BOOL", expected: "boolean"},
+		{name: "DoublePrecision", dataType: "DOUBLE PRECISION", expected: "double precision"},
+		{name: "Float4", dataType: "FLOAT(4)", expected: "real"},
+		{name: "Float53", dataType: "FLOAT(53)", expected: "double precision"},
+		{name: "TimestampTZ", dataType: "TIMESTAMPTZ", expected: "timestamp with time zone"},
+		{name: "Unknown", dataType: "UUID", err: "unknown type UUID"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dataType, err := catalogType(test.dataType)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expected, dataType)
+			}
+		})
+	}
+}
+
+func TestCompareSchemas(t *testing.T) {
+	expected := &expectedSchema{
+		extensions: []string{"pg_trgm"},
+		tables: []*schemaTable{
+			{
+				name: "t_a",
+				columns: []*schemaColumn{
+					{name: "f_x", dataType: "bigint", notNull: true},
+					{name: "f_y", dataType: "bytea"},
+					{name: "f_z", dataType: "text"},
+				},
+			},
+			{
+				name: "t_b",
+				columns: []*schemaColumn{
+					{name: "f_x", dataType: "bigint", notNull: true},
+				},
+			},
+		},
+		indexes: map[string]string{
+			"i_a_1": "t_a",
+			"i_a_2": "t_a",
+			"i_b_1": "t_b",
+		},
+		functions: []string{"f_capture"},
+	}
+
+	matching := &actualSchema{
+		extensions: map[string]bool{"pg_trgm": true, "plpgsql": true},
+		columns: map[string]map[string]*schemaColumn{
+			"t_a": {
+				"f_x":     {name: "f_x", dataType: "bigint", notNull: true},
+				"f_y":     {name: "f_y", dataType: "bytea"},
+				"f_z":     {name: "f_z", dataType: "text"},
+				"f_extra": {name: "f_extra", dataType: "text"},
+			},
+			"t_b": {
+				"f_x": {name: "f_x", dataType: "bigint", notNull: true},
+			},
+		},
+		indexes: map[string]string{
+			"i_a_1":     "t_a",
+			"i_a_2":     "t_a",
+			"i_b_1":     "t_b",
+			"i_a_local": "t_a",
+		},
+		functions: map[string]bool{"f_capture": true},
+	}
+	require.Empty(t, compareSchemas(expected, matching))
+
+	mismatched := &actualSchema{
+		extensions: map[string]bool{},
+		columns: map[string]map[string]*schemaColumn{
+			"t_a": {
+				"f_x": {name: "f_x", dataType: "integer", notNull: true},
+				"f_y": {name: "f_y", dataType: "bytea", notNull: true},
+			},
+		},
+		indexes: map[string]string{
+			"i_a_2": "t_b",
+		},
+		functions: map[string]bool{},
+	}
+	mismatches := compareSchemas(expected, mismatched)
+	descriptions := make([]string, 0, len(mismatches))
+	for _, mismatch := range mismatches {
+		descriptions = append(descriptions, mismatch.String())
+	}
+	require.Equal(t, []string{
+		"missing extension pg_trgm",
+		"column type t_a.f_x: expected bigint, found integer",
+		"column nullability t_a.f_y: expected NULL, found NOT NULL",
+		"missing column t_a.f_z: expected text",
+		"missing table t_b",
+		"missing index t_a.i_a_1",
+		"index table i_a_2: expected t_a, found t_b",
+		"missing function f_capture",
+	}, descriptions)
+}
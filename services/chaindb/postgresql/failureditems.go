@@ -0,0 +1,278 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// RecordFailedItem records a failure to process an item, incrementing
+// its attempts.  The item is dead once it has failed maxAttempts times,
+// or if it fails when being retried.
+// Returns the item as recorded.
+func (s *Service) RecordFailedItem(ctx context.Context,
+	item *chaindb.FailedItem,
+	maxAttempts uint32,
+) (
+	*chaindb.FailedItem,
+	error,
+) {
+	ctx, span := startSpan(ctx, "RecordFailedItem")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return nil, ErrNoTransaction
+	}
+
+	recorded := &chaindb.FailedItem{
+		Module: item.Module,
+		Kind:   item.Kind,
+		Item:   item.Item,
+		Error:  item.Error,
+	}
+	err := tx.QueryRow(ctx, `
+INSERT INTO t_failed_items(f_module
+                          ,f_kind
+                          ,f_item
+                          ,f_state
+                          ,f_attempts
+                          ,f_error
+                          ,f_first_failure
+                          ,f_last_failure
+                          )
+VALUES($1,$2,$3,CASE WHEN $4 <= 1 THEN $5 ELSE $6 END,1,$7,$8,$8)
+ON CONFLICT (f_module,f_kind,f_item) DO
+UPDATE
+SET f_state = CASE WHEN t_failed_items.f_state <> $6 OR t_failed_items.f_attempts + 1 >= $4 THEN $5 ELSE $6 END
+   ,f_attempts = t_failed_items.f_attempts + 1
+   ,f_error = excluded.f_error
+   ,f_last_failure = excluded.f_last_failure
+RETURNING f_state
+         ,f_attempts
+         ,f_first_failure
+         ,f_last_failure
+`,
+		item.Module,
+		item.Kind,
+		int64(item.Item),
+		int64(maxAttempts),
+		chaindb.FailedItemStateDead,
+		chaindb.FailedItemStateFailing,
+		item.Error,
+		time.Now(),
+	).Scan(
+		&recorded.State,
+		&recorded.Attempts,
+		&recorded.FirstFailure,
+		&recorded.LastFailure,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return recorded, nil
+}
+
+// SetFailedItemState sets the state of a failed item.
+func (s *Service) SetFailedItemState(ctx context.Context,
+	module string,
+	kind string,
+	item uint64,
+	state string,
+) error {
+	ctx, span := startSpan(ctx, "SetFailedItemState")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	tag, err := tx.Exec(ctx, `
+UPDATE t_failed_items
+SET f_state = $4
+WHERE f_module = $1
+  AND f_kind = $2
+  AND f_item = $3
+`,
+		module,
+		kind,
+		int64(item),
+		state,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("no failed %s %d for %s", kind, item, module)
+	}
+
+	return nil
+}
+
+// RemoveFailedItem removes a failed item, for example once it has been processed.
+func (s *Service) RemoveFailedItem(ctx context.Context,
+	module string,
+	kind string,
+	item uint64,
+) error {
+	ctx, span := startSpan(ctx, "RemoveFailedItem")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+DELETE FROM t_failed_items
+WHERE f_module = $1
+  AND f_kind = $2
+  AND f_item = $3
+`,
+		module,
+		kind,
+		int64(item),
+	)
+
+	return err
+}
+
+// FailedItems provides failed items according to the filter.
+func (s *Service) FailedItems(ctx context.Context,
+	filter *chaindb.FailedItemFilter,
+) (
+	[]*chaindb.FailedItem,
+	error,
+) {
+	ctx, span := startSpan(ctx, "FailedItems")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_module
+      ,f_kind
+      ,f_item
+      ,f_state
+      ,f_attempts
+      ,f_error
+      ,f_first_failure
+      ,f_last_failure
+FROM t_failed_items`)
+
+	wherestr := "WHERE"
+
+	if len(filter.Modules) > 0 {
+		queryVals = append(queryVals, filter.Modules)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_module = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.Kind != "" {
+		queryVals = append(queryVals, filter.Kind)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_kind = $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.States) > 0 {
+		queryVals = append(queryVals, filter.States)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_state = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.From != nil {
+		queryVals = append(queryVals, int64(*filter.From))
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_item >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, int64(*filter.To))
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_item <= $%d`, wherestr, len(queryVals)))
+	}
+
+	queryBuilder.WriteString(`
+ORDER BY f_module,f_kind,f_item`)
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]*chaindb.FailedItem, 0)
+	for rows.Next() {
+		item := &chaindb.FailedItem{}
+		var itemValue int64
+		err := rows.Scan(
+			&item.Module,
+			&item.Kind,
+			&itemValue,
+			&item.State,
+			&item.Attempts,
+			&item.Error,
+			&item.FirstFailure,
+			&item.LastFailure,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		item.Item = uint64(itemValue)
+		items = append(items, item)
+	}
+
+	return items, nil
+}
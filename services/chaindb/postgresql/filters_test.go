@@ -0,0 +1,452 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+// filterTestSlot is the first slot used by the filter tests, well above any slot in the test database.
+const filterTestSlot = phase0.Slot(1 << 30)
+
+// filterTestValidator is the first validator index used by the filter tests.
+const filterTestValidator = phase0.ValidatorIndex(1 << 40)
+
+// filterTestCase is a filter and the inclusion slots, relative to filterTestSlot, that it should return.
+type filterTestCase[F any] struct {
+	name   string
+	filter *F
+	slots  []phase0.Slot
+}
+
+// setFilterTestBlocks sets a canonical, a non-canonical and an undetermined block in consecutive
+// slots from filterTestSlot, and returns their roots.
+func setFilterTestBlocks(ctx context.Context, t *testing.T, s *postgresql.Service) []phase0.Root {
+	t.Helper()
+
+	canonical := true
+	nonCanonical := false
+	roots := make([]phase0.Root, 0, 3)
+	for i, state := range []*bool{&canonical, &nonCanonical, nil} {
+		root := phase0.Root{0xf1, byte(i)}
+		require.NoError(t, s.SetBlock(ctx, &chaindb.Block{
+			Slot:          filterTestSlot + phase0.Slot(i),
+			Root:          root,
+			Graffiti:      make([]byte, 32),
+			BodyRoot:      phase0.Root{0xf2, byte(i)},
+			StateRoot:     phase0.Root{0xf3, byte(i)},
+			Canonical:     state,
+			ETH1BlockHash: make([]byte, 32),
+		}))
+		roots = append(roots, root)
+	}
+
+	return roots
+}
+
+// relativeSlots returns the slots of the items relative to filterTestSlot.
+func relativeSlots[T any](items []T, slot func(T) phase0.Slot) []phase0.Slot {
+	slots := make([]phase0.Slot, len(items))
+	for i := range items {
+		slots[i] = slot(items[i]) - filterTestSlot
+	}
+
+	return slots
+}
+
+func newFilterTestService(ctx context.Context, t *testing.T) (*postgresql.Service, context.Context, context.CancelFunc) {
+	t.Helper()
+
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+
+	return s, ctx, cancel
+}
+
+func TestAttesterSlashingsFilter(t *testing.T) {
+	s, ctx, cancel := newFilterTestService(context.Background(), t)
+	defer cancel()
+
+	roots := setFilterTestBlocks(ctx, t, s)
+	for i := range roots {
+		validator := filterTestValidator + phase0.ValidatorIndex(i)
+		require.NoError(t, s.SetAttesterSlashing(ctx, &chaindb.AttesterSlashing{
+			InclusionSlot:       filterTestSlot + phase0.Slot(i),
+			InclusionBlockRoot:  roots[i],
+			Attestation1Indices: []phase0.ValidatorIndex{validator, filterTestValidator + 10},
+			Attestation1Slot:    filterTestSlot,
+			Attestation2Indices: []phase0.ValidatorIndex{validator},
+			Attestation2Slot:    filterTestSlot,
+		}))
+	}
+
+	from := filterTestSlot
+	to := filterTestSlot + 2
+	middle := filterTestSlot + 1
+	canonical := true
+	nonCanonical := false
+	tests := []filterTestCase[chaindb.AttesterSlashingFilter]{
+		{
+			name:   "All",
+			filter: &chaindb.AttesterSlashingFilter{From: &from, To: &to},
+			slots:  []phase0.Slot{0, 1, 2},
+		},
+		{
+			name:   "Range",
+			filter: &chaindb.AttesterSlashingFilter{From: &middle, To: &middle},
+			slots:  []phase0.Slot{1},
+		},
+		{
+			name:   "Validator",
+			filter: &chaindb.AttesterSlashingFilter{From: &from, To: &to, ValidatorIndices: []phase0.ValidatorIndex{filterTestValidator + 2}},
+			slots:  []phase0.Slot{2},
+		},
+		{
+			name:   "ValidatorNotSlashed",
+			filter: &chaindb.AttesterSlashingFilter{From: &from, To: &to, ValidatorIndices: []phase0.ValidatorIndex{filterTestValidator + 10}},
+			slots:  []phase0.Slot{},
+		},
+		{
+			name:   "Canonical",
+			filter: &chaindb.AttesterSlashingFilter{From: &from, To: &to, Canonical: &canonical},
+			slots:  []phase0.Slot{0},
+		},
+		{
+			name:   "NonCanonical",
+			filter: &chaindb.AttesterSlashingFilter{From: &from, To: &to, Canonical: &nonCanonical},
+			slots:  []phase0.Slot{1},
+		},
+		{
+			name:   "ExcludeNonCanonical",
+			filter: &chaindb.AttesterSlashingFilter{From: &from, To: &to, ExcludeNonCanonical: true},
+			slots:  []phase0.Slot{0, 2},
+		},
+		{
+			name:   "EarliestLimit",
+			filter: &chaindb.AttesterSlashingFilter{From: &from, To: &to, Order: chaindb.OrderEarliest, Limit: 2},
+			slots:  []phase0.Slot{0, 1},
+		},
+		{
+			name:   "LatestLimit",
+			filter: &chaindb.AttesterSlashingFilter{From: &from, To: &to, Order: chaindb.OrderLatest, Limit: 2},
+			slots:  []phase0.Slot{1, 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			slashings, err := s.AttesterSlashings(ctx, test.filter)
+			require.NoError(t, err)
+			require.Equal(t, test.slots, relativeSlots(slashings, func(slashing *chaindb.AttesterSlashing) phase0.Slot {
+				return slashing.InclusionSlot
+			}))
+		})
+	}
+}
+
+func TestProposerSlashingsFilter(t *testing.T) {
+	s, ctx, cancel := newFilterTestService(context.Background(), t)
+	defer cancel()
+
+	roots := setFilterTestBlocks(ctx, t, s)
+	for i := range roots {
+		require.NoError(t, s.SetProposerSlashing(ctx, &chaindb.ProposerSlashing{
+			InclusionSlot:        filterTestSlot + phase0.Slot(i),
+			InclusionBlockRoot:   roots[i],
+			Block1Root:           phase0.Root{0xf5, byte(i)},
+			Header1Slot:          filterTestSlot,
+			Header1ProposerIndex: filterTestValidator + phase0.ValidatorIndex(i),
+			Block2Root:           phase0.Root{0xf6, byte(i)},
+			Header2Slot:          filterTestSlot,
+			Header2ProposerIndex: filterTestValidator + phase0.ValidatorIndex(i),
+		}))
+	}
+
+	from := filterTestSlot
+	to := filterTestSlot + 2
+	middle := filterTestSlot + 1
+	canonical := true
+	nonCanonical := false
+	tests := []filterTestCase[chaindb.ProposerSlashingFilter]{
+		{
+			name:   "All",
+			filter: &chaindb.ProposerSlashingFilter{From: &from, To: &to},
+			slots:  []phase0.Slot{0, 1, 2},
+		},
+		{
+			name:   "Range",
+			filter: &chaindb.ProposerSlashingFilter{From: &middle, To: &middle},
+			slots:  []phase0.Slot{1},
+		},
+		{
+			name:   "Validator",
+			filter: &chaindb.ProposerSlashingFilter{From: &from, To: &to, ValidatorIndices: []phase0.ValidatorIndex{filterTestValidator, filterTestValidator + 2}},
+			slots:  []phase0.Slot{0, 2},
+		},
+		{
+			name:   "Canonical",
+			filter: &chaindb.ProposerSlashingFilter{From: &from, To: &to, Canonical: &canonical},
+			slots:  []phase0.Slot{0},
+		},
+		{
+			name:   "NonCanonical",
+			filter: &chaindb.ProposerSlashingFilter{From: &from, To: &to, Canonical: &nonCanonical},
+			slots:  []phase0.Slot{1},
+		},
+		{
+			name:   "ExcludeNonCanonical",
+			filter: &chaindb.ProposerSlashingFilter{From: &from, To: &to, ExcludeNonCanonical: true},
+			slots:  []phase0.Slot{0, 2},
+		},
+		{
+			name:   "EarliestLimit",
+			filter: &chaindb.ProposerSlashingFilter{From: &from, To: &to, Order: chaindb.OrderEarliest, Limit: 1},
+			slots:  []phase0.Slot{0},
+		},
+		{
+			name:   "LatestLimit",
+			filter: &chaindb.ProposerSlashingFilter{From: &from, To: &to, Order: chaindb.OrderLatest, Limit: 1},
+			slots:  []phase0.Slot{2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			slashings, err := s.ProposerSlashings(ctx, test.filter)
+			require.NoError(t, err)
+			require.Equal(t, test.slots, relativeSlots(slashings, func(slashing *chaindb.ProposerSlashing) phase0.Slot {
+				return slashing.InclusionSlot
+			}))
+		})
+	}
+
+	// The per-validator provider does not return slashings from non-canonical blocks.
+	slashings, err := s.ProposerSlashingsForValidator(ctx, filterTestValidator+1)
+	require.NoError(t, err)
+	require.Empty(t, slashings)
+}
+
+func TestDepositsFilter(t *testing.T) {
+	s, ctx, cancel := newFilterTestService(context.Background(), t)
+	defer cancel()
+
+	roots := setFilterTestBlocks(ctx, t, s)
+	for i := range roots {
+		require.NoError(t, s.SetDeposit(ctx, &chaindb.Deposit{
+			InclusionSlot:         filterTestSlot + phase0.Slot(i),
+			InclusionBlockRoot:    roots[i],
+			ValidatorPubKey:       phase0.BLSPubKey{0xf4, byte(i)},
+			WithdrawalCredentials: []byte{0x00, byte(i)},
+			Amount:                32000000000,
+		}))
+	}
+
+	from := filterTestSlot
+	to := filterTestSlot + 2
+	middle := filterTestSlot + 1
+	canonical := true
+	nonCanonical := false
+	tests := []filterTestCase[chaindb.DepositFilter]{
+		{
+			name:   "All",
+			filter: &chaindb.DepositFilter{From: &from, To: &to},
+			slots:  []phase0.Slot{0, 1, 2},
+		},
+		{
+			name:   "Range",
+			filter: &chaindb.DepositFilter{From: &middle, To: &middle},
+			slots:  []phase0.Slot{1},
+		},
+		{
+			name:   "PublicKey",
+			filter: &chaindb.DepositFilter{From: &from, To: &to, PublicKeys: []phase0.BLSPubKey{{0xf4, 0x01}}},
+			slots:  []phase0.Slot{1},
+		},
+		{
+			name:   "Canonical",
+			filter: &chaindb.DepositFilter{From: &from, To: &to, Canonical: &canonical},
+			slots:  []phase0.Slot{0},
+		},
+		{
+			name:   "NonCanonical",
+			filter: &chaindb.DepositFilter{From: &from, To: &to, Canonical: &nonCanonical},
+			slots:  []phase0.Slot{1},
+		},
+		{
+			name:   "ExcludeNonCanonical",
+			filter: &chaindb.DepositFilter{From: &from, To: &to, ExcludeNonCanonical: true},
+			slots:  []phase0.Slot{0, 2},
+		},
+		{
+			name:   "EarliestLimit",
+			filter: &chaindb.DepositFilter{From: &from, To: &to, Order: chaindb.OrderEarliest, Limit: 1},
+			slots:  []phase0.Slot{0},
+		},
+		{
+			name:   "LatestLimit",
+			filter: &chaindb.DepositFilter{From: &from, To: &to, Order: chaindb.OrderLatest, Limit: 2},
+			slots:  []phase0.Slot{1, 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deposits, err := s.Deposits(ctx, test.filter)
+			require.NoError(t, err)
+			require.Equal(t, test.slots, relativeSlots(deposits, func(deposit *chaindb.Deposit) phase0.Slot {
+				return deposit.InclusionSlot
+			}))
+		})
+	}
+}
+
+func TestVoluntaryExitsFilter(t *testing.T) {
+	s, ctx, cancel := newFilterTestService(context.Background(), t)
+	defer cancel()
+
+	roots := setFilterTestBlocks(ctx, t, s)
+	for i := range roots {
+		require.NoError(t, s.SetVoluntaryExit(ctx, &chaindb.VoluntaryExit{
+			InclusionSlot:      filterTestSlot + phase0.Slot(i),
+			InclusionBlockRoot: roots[i],
+			ValidatorIndex:     filterTestValidator + phase0.ValidatorIndex(i),
+			Epoch:              1,
+		}))
+	}
+
+	from := filterTestSlot
+	to := filterTestSlot + 2
+	middle := filterTestSlot + 1
+	canonical := true
+	nonCanonical := false
+	tests := []filterTestCase[chaindb.VoluntaryExitFilter]{
+		{
+			name:   "All",
+			filter: &chaindb.VoluntaryExitFilter{From: &from, To: &to},
+			slots:  []phase0.Slot{0, 1, 2},
+		},
+		{
+			name:   "Range",
+			filter: &chaindb.VoluntaryExitFilter{From: &middle, To: &middle},
+			slots:  []phase0.Slot{1},
+		},
+		{
+			name:   "Validator",
+			filter: &chaindb.VoluntaryExitFilter{From: &from, To: &to, ValidatorIndices: []phase0.ValidatorIndex{filterTestValidator + 1}},
+			slots:  []phase0.Slot{1},
+		},
+		{
+			name:   "Canonical",
+			filter: &chaindb.VoluntaryExitFilter{From: &from, To: &to, Canonical: &canonical},
+			slots:  []phase0.Slot{0},
+		},
+		{
+			name:   "NonCanonical",
+			filter: &chaindb.VoluntaryExitFilter{From: &from, To: &to, Canonical: &nonCanonical},
+			slots:  []phase0.Slot{1},
+		},
+		{
+			name:   "ExcludeNonCanonical",
+			filter: &chaindb.VoluntaryExitFilter{From: &from, To: &to, ExcludeNonCanonical: true},
+			slots:  []phase0.Slot{0, 2},
+		},
+		{
+			name:   "EarliestLimit",
+			filter: &chaindb.VoluntaryExitFilter{From: &from, To: &to, Order: chaindb.OrderEarliest, Limit: 2},
+			slots:  []phase0.Slot{0, 1},
+		},
+		{
+			name:   "LatestLimit",
+			filter: &chaindb.VoluntaryExitFilter{From: &from, To: &to, Order: chaindb.OrderLatest, Limit: 1},
+			slots:  []phase0.Slot{2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exits, err := s.VoluntaryExits(ctx, test.filter)
+			require.NoError(t, err)
+			require.Equal(t, test.slots, relativeSlots(exits, func(exit *chaindb.VoluntaryExit) phase0.Slot {
+				return exit.InclusionSlot
+			}))
+		})
+	}
+}
+
+func TestProposerDutiesFilter(t *testing.T) {
+	s, ctx, cancel := newFilterTestService(context.Background(), t)
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.SetProposerDuty(ctx, &chaindb.ProposerDuty{
+			Slot:           filterTestSlot + phase0.Slot(i),
+			ValidatorIndex: filterTestValidator + phase0.ValidatorIndex(i%2),
+		}))
+	}
+
+	from := filterTestSlot
+	to := filterTestSlot + 2
+	middle := filterTestSlot + 1
+	tests := []filterTestCase[chaindb.ProposerDutyFilter]{
+		{
+			name:   "All",
+			filter: &chaindb.ProposerDutyFilter{From: &from, To: &to},
+			slots:  []phase0.Slot{0, 1, 2},
+		},
+		{
+			name:   "Range",
+			filter: &chaindb.ProposerDutyFilter{From: &middle, To: &to},
+			slots:  []phase0.Slot{1, 2},
+		},
+		{
+			name:   "Validator",
+			filter: &chaindb.ProposerDutyFilter{From: &from, To: &to, ValidatorIndices: []phase0.ValidatorIndex{filterTestValidator}},
+			slots:  []phase0.Slot{0, 2},
+		},
+		{
+			name:   "EarliestLimit",
+			filter: &chaindb.ProposerDutyFilter{From: &from, To: &to, Order: chaindb.OrderEarliest, Limit: 1},
+			slots:  []phase0.Slot{0},
+		},
+		{
+			name:   "LatestLimit",
+			filter: &chaindb.ProposerDutyFilter{From: &from, To: &to, Order: chaindb.OrderLatest, Limit: 2},
+			slots:  []phase0.Slot{1, 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			duties, err := s.ProposerDuties(ctx, test.filter)
+			require.NoError(t, err)
+			require.Equal(t, test.slots, relativeSlots(duties, func(duty *chaindb.ProposerDuty) phase0.Slot {
+				return duty.Slot
+			}))
+		})
+	}
+}
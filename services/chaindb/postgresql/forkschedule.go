@@ -19,13 +19,13 @@ import (
 	"github.com/attestantio/go-eth2-client/api"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
-	"go.opentelemetry.io/otel"
+	"github.com/wealdtech/chaind/services/chaindb"
 )
 
 // SetForkSchedule sets the fork schedule.
 // This carries out a complete rewrite of the table.
 func (s *Service) SetForkSchedule(ctx context.Context, schedule []*phase0.Fork) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetForkSchedule")
+	ctx, span := startSpan(ctx, "SetForkSchedule")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -62,7 +62,7 @@ VALUES($1,$2,$3)
 
 // ForkSchedule provides details of past and future changes in the chain's fork version.
 func (s *Service) ForkSchedule(ctx context.Context, _ *api.ForkScheduleOpts) (*api.Response[[]*phase0.Fork], error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ForkSchedule")
+	ctx, span := startSpan(ctx, "ForkSchedule")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -110,3 +110,91 @@ ORDER BY f_epoch
 		Metadata: make(map[string]any),
 	}, nil
 }
+
+// SetForks sets the scheduled forks of the chain.
+// This carries out a complete rewrite of the table.
+func (s *Service) SetForks(ctx context.Context, forks []*chaindb.Fork) error {
+	ctx, span := startSpan(ctx, "SetForks")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+TRUNCATE TABLE t_forks
+`); err != nil {
+		return err
+	}
+
+	for _, fork := range forks {
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_forks(f_name
+                   ,f_epoch
+                   ,f_version
+                   ,f_digest
+                   )
+VALUES($1,$2,$3,$4)
+`,
+			fork.Name,
+			fork.Epoch,
+			fork.Version[:],
+			fork.Digest[:],
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Forks provides the scheduled forks of the chain, in the order in which they take place.
+func (s *Service) Forks(ctx context.Context) ([]*chaindb.Fork, error) {
+	ctx, span := startSpan(ctx, "Forks")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_name
+      ,f_epoch
+      ,f_version
+      ,f_digest
+FROM t_forks
+ORDER BY f_epoch,f_version
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	forks := make([]*chaindb.Fork, 0)
+	for rows.Next() {
+		fork := &chaindb.Fork{}
+		var version []byte
+		var digest []byte
+		err := rows.Scan(
+			&fork.Name,
+			&fork.Epoch,
+			&version,
+			&digest,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(fork.Version[:], version)
+		copy(fork.Digest[:], digest)
+		forks = append(forks, fork)
+	}
+
+	return forks, nil
+}
@@ -20,12 +20,14 @@ import (
 	"github.com/attestantio/go-eth2-client/api"
 	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/pkg/errors"
-	"go.opentelemetry.io/otel"
 )
 
+// genesisCacheKey is the read cache key for genesis values.
+const genesisCacheKey = "genesis"
+
 // SetGenesis sets the genesis information.
 func (s *Service) SetGenesis(ctx context.Context, genesis *apiv1.Genesis) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetGenesis")
+	ctx, span := startSpan(ctx, "SetGenesis")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -47,8 +49,12 @@ func (s *Service) SetGenesis(ctx context.Context, genesis *apiv1.Genesis) error
 		genesis.GenesisTime,
 		genesis.GenesisForkVersion[:],
 	)
+	if err != nil {
+		return err
+	}
+	s.invalidateReadCache(ctx, genesisCacheKey)
 
-	return err
+	return nil
 }
 
 // Genesis fetches genesis values.
@@ -58,9 +64,22 @@ func (s *Service) Genesis(ctx context.Context,
 	*api.Response[*apiv1.Genesis],
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "Genesis")
+	ctx, span := startSpan(ctx, "Genesis")
 	defer span.End()
 
+	genesis, err := cachedRead(ctx, s, genesisCacheKey, s.genesis)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.Response[*apiv1.Genesis]{
+		Data:     genesis,
+		Metadata: make(map[string]any),
+	}, nil
+}
+
+// genesis fetches genesis values from the database.
+func (s *Service) genesis(ctx context.Context) (*apiv1.Genesis, error) {
 	tx := s.tx(ctx)
 	if tx == nil {
 		ctx, err := s.BeginROTx(ctx)
@@ -90,15 +109,12 @@ func (s *Service) Genesis(ctx context.Context,
 	copy(genesis.GenesisValidatorsRoot[:], genesisValidatorsRoot)
 	copy(genesis.GenesisForkVersion[:], genesisForkVersion)
 
-	return &api.Response[*apiv1.Genesis]{
-		Data:     genesis,
-		Metadata: make(map[string]any),
-	}, nil
+	return genesis, nil
 }
 
 // GenesisTime provides the genesis time of the chain.
 func (s *Service) GenesisTime(ctx context.Context) (time.Time, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "GenesisTime")
+	ctx, span := startSpan(ctx, "GenesisTime")
 	defer span.End()
 
 	genesisResponse, err := s.Genesis(ctx, &api.GenesisOpts{})
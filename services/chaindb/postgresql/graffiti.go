@@ -0,0 +1,204 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// graffitiText is the expression that provides graffiti as text, as used by the trigram index.
+// Leading and trailing zero bytes are removed, and zero and non-ASCII bytes are escaped as octal.
+const graffitiText = `encode(btrim(f_graffiti,'\x00'::bytea),'escape')`
+
+// GraffitiFrequencies provides the number of canonical blocks with each graffiti according to the filter.
+func (s *Service) GraffitiFrequencies(ctx context.Context, filter *chaindb.GraffitiFrequencyFilter) ([]*chaindb.GraffitiFrequency, error) {
+	ctx, span := startSpan(ctx, "GraffitiFrequencies")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	slotsPerEpoch, err := s.slotsPerEpoch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(fmt.Sprintf(`
+SELECT %s AS graffiti
+      ,COUNT(*)
+      ,MIN(f_slot)
+      ,MAX(f_slot)
+FROM t_blocks
+WHERE f_canonical = true`, graffitiText))
+
+	wherestr := "  AND"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, uint64(*filter.From)*slotsPerEpoch)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot >= $%d`, wherestr, len(queryVals)))
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, (uint64(*filter.To)+1)*slotsPerEpoch-1)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot <= $%d`, wherestr, len(queryVals)))
+	}
+
+	queryVals, _ = addGraffitiConditions(&queryBuilder, queryVals, wherestr, filter.GraffitiContains, filter.GraffitiRegex)
+
+	queryBuilder.WriteString(`
+GROUP BY graffiti
+ORDER BY 2 DESC, graffiti`)
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	frequencies := make([]*chaindb.GraffitiFrequency, 0)
+	for rows.Next() {
+		frequency := &chaindb.GraffitiFrequency{}
+		var graffiti string
+		var firstSlot uint64
+		var lastSlot uint64
+		if err := rows.Scan(
+			&graffiti,
+			&frequency.Blocks,
+			&firstSlot,
+			&lastSlot,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		frequency.Graffiti = unescapeGraffiti(graffiti)
+		frequency.FirstSlot = phase0.Slot(firstSlot)
+		frequency.LastSlot = phase0.Slot(lastSlot)
+		frequencies = append(frequencies, frequency)
+	}
+
+	return frequencies, nil
+}
+
+// addGraffitiConditions adds conditions on the graffiti of blocks to a query,
+// returning the updated query values and where string.
+func addGraffitiConditions(queryBuilder *strings.Builder,
+	queryVals []any,
+	wherestr string,
+	contains *string,
+	regex *string,
+) (
+	[]any,
+	string,
+) {
+	if contains != nil {
+		queryVals = append(queryVals, fmt.Sprintf("%%%s%%", escapeLike(escapeGraffiti(*contains))))
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s %s ILIKE $%d`, wherestr, graffitiText, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if regex != nil {
+		queryVals = append(queryVals, *regex)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s %s ~ $%d`, wherestr, graffitiText, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	return queryVals, wherestr
+}
+
+// escapeGraffiti escapes text in the same way as PostgreSQL's 'escape' encoding,
+// so that it can be matched against graffitiText.
+func escapeGraffiti(input string) string {
+	res := strings.Builder{}
+	for _, b := range []byte(input) {
+		switch {
+		case b == '\\':
+			res.WriteString(`\\`)
+		case b == 0 || b >= 0x80:
+			res.WriteString(fmt.Sprintf(`\%03o`, b))
+		default:
+			res.WriteByte(b)
+		}
+	}
+
+	return res.String()
+}
+
+// unescapeGraffiti reverses PostgreSQL's 'escape' encoding.
+func unescapeGraffiti(input string) string {
+	res := make([]byte, 0, len(input))
+	for i := 0; i < len(input); i++ {
+		if input[i] != '\\' {
+			res = append(res, input[i])
+			continue
+		}
+		if i+1 < len(input) && input[i+1] == '\\' {
+			res = append(res, '\\')
+			i++
+			continue
+		}
+		if i+3 < len(input) {
+			var b byte
+			if _, err := fmt.Sscanf(input[i+1:i+4], "%03o", &b); err == nil {
+				res = append(res, b)
+				i += 3
+				continue
+			}
+		}
+		res = append(res, input[i])
+	}
+
+	return string(res)
+}
+
+// escapeLike escapes the special characters of a LIKE pattern.
+func escapeLike(input string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(input)
+}
@@ -0,0 +1,69 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeGraffiti(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		escaped  string
+		likeExpr string
+	}{
+		{
+			name:     "Empty",
+			input:    "",
+			escaped:  "",
+			likeExpr: "",
+		},
+		{
+			name:     "Plain",
+			input:    "Lighthouse/v4.5.0",
+			escaped:  "Lighthouse/v4.5.0",
+			likeExpr: "Lighthouse/v4.5.0",
+		},
+		{
+			name:     "Backslash",
+			input:    `a\b`,
+			escaped:  `a\\b`,
+			likeExpr: `a\\\\b`,
+		},
+		{
+			name:     "Wildcards",
+			input:    "100%_pool",
+			escaped:  "100%_pool",
+			likeExpr: `100\%\_pool`,
+		},
+		{
+			name:     "UTF8",
+			input:    "é",
+			escaped:  `\303\251`,
+			likeExpr: `\\303\\251`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			escaped := escapeGraffiti(test.input)
+			require.Equal(t, test.escaped, escaped)
+			require.Equal(t, test.likeExpr, escapeLike(escaped))
+			require.Equal(t, test.input, unescapeGraffiti(escaped))
+		})
+	}
+}
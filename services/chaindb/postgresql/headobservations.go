@@ -0,0 +1,180 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetHeadObservation sets a head observation.
+func (s *Service) SetHeadObservation(ctx context.Context, observation *chaindb.HeadObservation) error {
+	ctx, span := startSpan(ctx, "SetHeadObservation")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+INSERT INTO t_head_observations(f_slot
+                               ,f_observed_at
+                               ,f_head_slot
+                               ,f_head_root
+                               ,f_parent_root
+                               ,f_reorg
+                               ,f_reorg_depth)
+VALUES($1,$2,$3,$4,$5,$6,$7)
+ON CONFLICT (f_slot) DO
+UPDATE
+SET f_observed_at = excluded.f_observed_at
+   ,f_head_slot = excluded.f_head_slot
+   ,f_head_root = excluded.f_head_root
+   ,f_parent_root = excluded.f_parent_root
+   ,f_reorg = excluded.f_reorg
+   ,f_reorg_depth = excluded.f_reorg_depth
+`,
+		observation.Slot,
+		observation.ObservedAt,
+		observation.HeadSlot,
+		observation.HeadRoot[:],
+		observation.ParentRoot[:],
+		observation.Reorg,
+		observation.ReorgDepth,
+	); err != nil {
+		return errors.Wrap(err, "failed to set head observation")
+	}
+
+	return nil
+}
+
+// HeadObservations provides head observations according to the filter.
+func (s *Service) HeadObservations(ctx context.Context, filter *chaindb.HeadObservationFilter) ([]*chaindb.HeadObservation, error) {
+	ctx, span := startSpan(ctx, "HeadObservations")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_slot
+      ,f_observed_at
+      ,f_head_slot
+      ,f_head_root
+      ,f_parent_root
+      ,f_reorg
+      ,f_reorg_depth
+FROM t_head_observations`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.Reorg != nil {
+		queryVals = append(queryVals, *filter.Reorg)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_reorg = $%d`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	observations := make([]*chaindb.HeadObservation, 0)
+	var headRoot []byte
+	var parentRoot []byte
+	for rows.Next() {
+		observation := &chaindb.HeadObservation{}
+		err := rows.Scan(
+			&observation.Slot,
+			&observation.ObservedAt,
+			&observation.HeadSlot,
+			&headRoot,
+			&parentRoot,
+			&observation.Reorg,
+			&observation.ReorgDepth,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(observation.HeadRoot[:], headRoot)
+		copy(observation.ParentRoot[:], parentRoot)
+		observations = append(observations, observation)
+	}
+
+	// Always return order of slot.
+	sort.Slice(observations, func(i int, j int) bool {
+		return observations[i].Slot < observations[j].Slot
+	})
+	return observations, nil
+}
@@ -0,0 +1,146 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// secondaryIndex is an index that speeds up queries but is not used when
+// indexing data, so can be dropped while bulk loading.
+type secondaryIndex struct {
+	name       string
+	definition string
+}
+
+// secondaryIndexes are the secondary indexes, which must match those in the schema.
+var secondaryIndexes = []*secondaryIndex{
+	{name: "i_validators_3", definition: "t_validators(f_withdrawal_credentials)"},
+	{name: "i_blocks_4", definition: "t_blocks USING GIN(" + graffitiText + " gin_trgm_ops)"},
+	{name: "i_attestations_3", definition: "t_attestations(f_beacon_block_root)"},
+	{name: "i_deposits_2", definition: "t_deposits(f_validator_pubkey,f_inclusion_slot)"},
+	{name: "i_eth1_deposits_2", definition: "t_eth1_deposits(f_validator_pubkey)"},
+	{name: "i_eth1_deposits_3", definition: "t_eth1_deposits(f_withdrawal_credentials)"},
+	{name: "i_eth1_deposits_4", definition: "t_eth1_deposits(f_eth1_sender)"},
+	{name: "i_eth1_deposits_5", definition: "t_eth1_deposits(f_eth1_recipient)"},
+	{name: "i_block_bls_to_execution_changes_3", definition: "t_block_bls_to_execution_changes(f_to_execution_address)"},
+	{name: "i_block_withdrawals_3", definition: "t_block_withdrawals(f_validator_index)"},
+	{name: "i_block_withdrawals_4", definition: "t_block_withdrawals(f_address)"},
+}
+
+// DropSecondaryIndexes drops secondary indexes.
+func (s *Service) DropSecondaryIndexes(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "DropSecondaryIndexes")
+	defer span.End()
+
+	if s.externalSchema {
+		return errors.Wrap(ErrExternalSchema, "cannot drop secondary indexes")
+	}
+
+	for _, index := range secondaryIndexes {
+		log.Info().Str("index", index.name).Msg("Dropping secondary index")
+		if _, err := s.pool.Exec(ctx, fmt.Sprintf("DROP INDEX IF EXISTS %s", index.name)); err != nil {
+			return errors.Wrapf(err, "failed to drop %s", index.name)
+		}
+	}
+
+	return nil
+}
+
+// CreateSecondaryIndexes creates secondary indexes that are not present.
+// If the service was configured to create indexes concurrently then tables
+// are not locked against writes while their indexes are created.
+func (s *Service) CreateSecondaryIndexes(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "CreateSecondaryIndexes")
+	defer span.End()
+
+	if s.externalSchema {
+		return errors.Wrap(ErrExternalSchema, "cannot create secondary indexes")
+	}
+
+	concurrently := ""
+	if s.concurrentIndexes {
+		concurrently = " CONCURRENTLY"
+	}
+
+	// Building indexes can take a long time, so use a connection that is not
+	// subject to the statement timeout.
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to acquire connection")
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, "SET statement_timeout = 0"); err != nil {
+		return errors.Wrap(err, "failed to disable statement timeout")
+	}
+	defer func() {
+		if _, err := conn.Exec(ctx, "RESET statement_timeout"); err != nil {
+			log.Warn().Err(err).Msg("Failed to reset statement timeout")
+		}
+	}()
+
+	for _, index := range secondaryIndexes {
+		// A failed concurrent build leaves an invalid index behind, which
+		// must be removed before the index can be built again.
+		valid, exists, err := s.indexValid(ctx, index.name)
+		if err != nil {
+			return err
+		}
+		if exists && valid {
+			continue
+		}
+		if exists {
+			log.Warn().Str("index", index.name).Msg("Secondary index is invalid; rebuilding")
+			if _, err := conn.Exec(ctx, fmt.Sprintf("DROP INDEX%s IF EXISTS %s", concurrently, index.name)); err != nil {
+				return errors.Wrapf(err, "failed to drop invalid %s", index.name)
+			}
+		}
+
+		log.Info().Str("index", index.name).Bool("concurrently", s.concurrentIndexes).Msg("Creating secondary index")
+		if _, err := conn.Exec(ctx, fmt.Sprintf("CREATE INDEX%s IF NOT EXISTS %s ON %s", concurrently, index.name, index.definition)); err != nil {
+			return errors.Wrapf(err, "failed to create %s", index.name)
+		}
+	}
+
+	return nil
+}
+
+// indexValid returns true if the index is valid, and true if the index exists.
+func (s *Service) indexValid(ctx context.Context, name string) (bool, bool, error) {
+	rows, err := s.pool.Query(ctx, `
+SELECT pg_index.indisvalid
+FROM pg_index
+JOIN pg_class ON pg_class.oid = pg_index.indexrelid
+WHERE pg_class.relname = $1
+  AND pg_class.relnamespace = (SELECT oid FROM pg_namespace WHERE nspname = current_schema())`,
+		name,
+	)
+	if err != nil {
+		return false, false, errors.Wrapf(err, "failed to check validity of %s", name)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return false, false, rows.Err()
+	}
+	valid := false
+	if err := rows.Scan(&valid); err != nil {
+		return false, false, errors.Wrap(err, "failed to scan row")
+	}
+
+	return valid, true, nil
+}
@@ -0,0 +1,35 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecondaryIndexesInSchema(t *testing.T) {
+	statements := make([]string, 0)
+	ctx := context.WithValue(context.Background(), &Tx{}, &recordingTx{statements: &statements})
+	require.NoError(t, createInitialTables(ctx, &Service{}))
+	require.Len(t, statements, 1)
+
+	for _, index := range secondaryIndexes {
+		re := regexp.MustCompile(fmt.Sprintf(`CREATE INDEX (IF NOT EXISTS )?%s ON %s;`, index.name, regexp.QuoteMeta(index.definition)))
+		require.True(t, re.MatchString(statements[0]), "%s does not match schema", index.name)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// TableMaintenanceStats provides statistics for chaind's tables.
+func (s *Service) TableMaintenanceStats(ctx context.Context) ([]*chaindb.TableMaintenanceStats, error) {
+	ctx, span := startSpan(ctx, "TableMaintenanceStats")
+	defer span.End()
+
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.CommitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT relname
+      ,n_live_tup
+      ,n_dead_tup
+      ,n_mod_since_analyze
+      ,pg_total_relation_size(relid)
+      ,GREATEST(last_vacuum, last_autovacuum)
+      ,GREATEST(last_analyze, last_autoanalyze)
+FROM pg_stat_user_tables
+WHERE schemaname = current_schema()
+  AND relname LIKE 't\_%'
+ORDER BY relname`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make([]*chaindb.TableMaintenanceStats, 0)
+	for rows.Next() {
+		tableStats := &chaindb.TableMaintenanceStats{}
+		var lastVacuum *time.Time
+		var lastAnalyze *time.Time
+		err := rows.Scan(
+			&tableStats.Table,
+			&tableStats.LiveTuples,
+			&tableStats.DeadTuples,
+			&tableStats.ModificationsSinceAnalyze,
+			&tableStats.TotalBytes,
+			&lastVacuum,
+			&lastAnalyze,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		tableStats.LastVacuum = lastVacuum
+		tableStats.LastAnalyze = lastAnalyze
+		stats = append(stats, tableStats)
+	}
+
+	return stats, rows.Err()
+}
+
+// VacuumTable vacuums the table, reclaiming space held by dead tuples,
+// and analyzes it if requested.
+func (s *Service) VacuumTable(ctx context.Context, table string, analyze bool) error {
+	ctx, span := startSpan(ctx, "VacuumTable")
+	defer span.End()
+
+	command := "VACUUM"
+	if analyze {
+		command = "VACUUM (ANALYZE)"
+	}
+
+	return s.maintainTable(ctx, command, table)
+}
+
+// AnalyzeTable updates the planner statistics for the table.
+func (s *Service) AnalyzeTable(ctx context.Context, table string) error {
+	ctx, span := startSpan(ctx, "AnalyzeTable")
+	defer span.End()
+
+	return s.maintainTable(ctx, "ANALYZE", table)
+}
+
+// maintainTable runs a maintenance command against one of chaind's tables.
+// Maintenance commands cannot run inside a transaction, and can take a long
+// time, so they use a dedicated connection that is not subject to the
+// statement timeout.
+func (s *Service) maintainTable(ctx context.Context, command string, table string) error {
+	if !strings.HasPrefix(table, "t_") {
+		return fmt.Errorf("%s is not a chaind table", table)
+	}
+
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to acquire connection")
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, "SET statement_timeout = 0"); err != nil {
+		return errors.Wrap(err, "failed to disable statement timeout")
+	}
+	defer func() {
+		if _, err := conn.Exec(ctx, "RESET statement_timeout"); err != nil {
+			log.Warn().Err(err).Msg("Failed to reset statement timeout")
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, fmt.Sprintf("%s %s", command, pgx.Identifier{table}.Sanitize())); err != nil {
+		return errors.Wrapf(err, "failed to run %s on %s", command, table)
+	}
+
+	return nil
+}
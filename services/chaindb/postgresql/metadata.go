@@ -18,12 +18,11 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/pkg/errors"
-	"go.opentelemetry.io/otel"
 )
 
 // SetMetadata sets a metadata key to a JSON value.
 func (s *Service) SetMetadata(ctx context.Context, key string, value []byte) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetMetadata")
+	ctx, span := startSpan(ctx, "SetMetadata")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -47,7 +46,7 @@ func (s *Service) SetMetadata(ctx context.Context, key string, value []byte) err
 
 // Metadata obtains the JSON value from a metadata key.
 func (s *Service) Metadata(ctx context.Context, key string) ([]byte, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "Metadata")
+	ctx, span := startSpan(ctx, "Metadata")
 	defer span.End()
 
 	var err error
@@ -0,0 +1,132 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// registerPoolMetrics registers OpenTelemetry metrics for usage of the connection pool.
+// These are only exported if an OpenTelemetry metrics exporter is configured.
+// Each pool is distinguished by its name.
+func registerPoolMetrics(pool *pgxpool.Pool, name string) error {
+	meter := otel.Meter("wealdtech.chaind.services.chaindb.postgresql")
+
+	acquired, err := meter.Int64ObservableGauge("chaind.chaindb.pool.connections.acquired",
+		metric.WithDescription("The number of connections currently in use."),
+	)
+	if err != nil {
+		return err
+	}
+	idle, err := meter.Int64ObservableGauge("chaind.chaindb.pool.connections.idle",
+		metric.WithDescription("The number of idle connections in the pool."),
+	)
+	if err != nil {
+		return err
+	}
+	maxConns, err := meter.Int64ObservableGauge("chaind.chaindb.pool.connections.max",
+		metric.WithDescription("The maximum number of connections in the pool."),
+	)
+	if err != nil {
+		return err
+	}
+	acquires, err := meter.Int64ObservableCounter("chaind.chaindb.pool.acquires",
+		metric.WithDescription("The number of connections acquired from the pool."),
+	)
+	if err != nil {
+		return err
+	}
+	emptyAcquires, err := meter.Int64ObservableCounter("chaind.chaindb.pool.acquires.empty",
+		metric.WithDescription("The number of connections acquired that had to wait for a connection to become available."),
+	)
+	if err != nil {
+		return err
+	}
+	acquireDuration, err := meter.Float64ObservableCounter("chaind.chaindb.pool.acquire.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("The total time spent waiting to acquire connections."),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		stat := pool.Stat()
+		attrs := metric.WithAttributes(attribute.String("pool", name))
+		observer.ObserveInt64(acquired, int64(stat.AcquiredConns()), attrs)
+		observer.ObserveInt64(idle, int64(stat.IdleConns()), attrs)
+		observer.ObserveInt64(maxConns, int64(stat.MaxConns()), attrs)
+		observer.ObserveInt64(acquires, stat.AcquireCount(), attrs)
+		observer.ObserveInt64(emptyAcquires, stat.EmptyAcquireCount(), attrs)
+		observer.ObserveFloat64(acquireDuration, stat.AcquireDuration().Seconds(), attrs)
+
+		return nil
+	}, acquired, idle, maxConns, acquires, emptyAcquires, acquireDuration)
+
+	return err
+}
+
+// registerReadCacheMetrics registers OpenTelemetry metrics for usage of the read cache.
+func registerReadCacheMetrics() error {
+	meter := otel.Meter("wealdtech.chaind.services.chaindb.postgresql")
+
+	var err error
+	readCacheLookups, err = meter.Int64Counter("chaind.chaindb.read_cache.lookups",
+		metric.WithDescription("The number of lookups in the read cache, by result."),
+	)
+
+	return err
+}
+
+// registerQueryMetrics registers OpenTelemetry metrics for queries.
+func registerQueryMetrics() error {
+	meter := otel.Meter("wealdtech.chaind.services.chaindb.postgresql")
+
+	var err error
+	queriesTimedOut, err = meter.Int64Counter("chaind.chaindb.queries.timed_out",
+		metric.WithDescription("The number of queries cancelled because they exceeded their timeout, by operation."),
+	)
+
+	return err
+}
+
+// registerWriteQueueMetrics registers OpenTelemetry metrics for usage of write queues.
+func registerWriteQueueMetrics() error {
+	meter := otel.Meter("wealdtech.chaind.services.chaindb.postgresql")
+
+	var err error
+	writeQueueFlushes, err = meter.Int64Counter("chaind.chaindb.write_queue.flushes",
+		metric.WithDescription("The number of batches of queued writes sent to the database."),
+	)
+	if err != nil {
+		return err
+	}
+	writeQueueWrites, err = meter.Int64Counter("chaind.chaindb.write_queue.writes",
+		metric.WithDescription("The number of queued writes sent to the database."),
+	)
+	if err != nil {
+		return err
+	}
+	writeQueueFlushDuration, err = meter.Float64Histogram("chaind.chaindb.write_queue.flush.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("The time taken to send a batch of queued writes to the database."),
+	)
+
+	return err
+}
@@ -0,0 +1,447 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+// MigrateOpts are the options for migrating the database schema.
+type MigrateOpts struct {
+	// TargetVersion is the version of the schema to which to migrate.
+	// If 0 then the latest version is used.
+	TargetVersion uint64
+	// DryRun returns the statements that would be run without applying them.
+	DryRun bool
+}
+
+// MigrateResult is the result of migrating the database schema.
+type MigrateResult struct {
+	// FromVersion is the version of the schema before migration.
+	FromVersion uint64
+	// ToVersion is the version of the schema after migration.
+	ToVersion uint64
+	// RequiresRefetch is true if the migration requires blocks to be refetched.
+	RequiresRefetch bool
+	// Statements are the statements that would be run, if this was a dry run.
+	Statements []string
+}
+
+// SchemaChange is a change to the version of the database schema.
+type SchemaChange struct {
+	Timestamp   time.Time
+	FromVersion uint64
+	ToVersion   uint64
+}
+
+// recordingTx is a transaction that records, rather than executes, statements.
+// Queries are passed through to the underlying transaction.
+type recordingTx struct {
+	pgx.Tx
+	statements *[]string
+}
+
+// Exec records the statement.
+func (t *recordingTx) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	*t.statements = append(*t.statements, strings.TrimSpace(sql))
+
+	return pgconn.CommandTag{}, nil
+}
+
+// LatestSchemaVersion provides the latest version of the schema known to this release.
+func (*Service) LatestSchemaVersion() uint64 {
+	return currentVersion
+}
+
+// SchemaVersion provides the version of the schema in the database.
+func (s *Service) SchemaVersion(ctx context.Context) (uint64, error) {
+	// Without its schema the search path falls back to other schemas, which
+	// could hold tables for a different network.
+	schemaExists, err := s.schemaExists(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if !schemaExists {
+		return 0, nil
+	}
+
+	tableExists, err := s.tableExists(ctx, "t_metadata")
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to check presence of tables")
+	}
+	if !tableExists {
+		return 0, nil
+	}
+
+	return s.version(ctx)
+}
+
+// Migrate migrates the database schema to the target version.
+// Migrating to an earlier version is only possible if all of the upgrades
+// between the versions can be reversed.
+//
+// Each version is migrated in its own transaction while holding an advisory
+// lock, so an interrupted migration resumes from the last version that was
+// completed, and instances that migrate concurrently do not race: each
+// version is applied by exactly one instance, and the others find the work
+// done once they obtain the lock.
+func (s *Service) Migrate(ctx context.Context, opts *MigrateOpts) (*MigrateResult, error) {
+	if opts == nil {
+		opts = &MigrateOpts{}
+	}
+	targetVersion := opts.TargetVersion
+	if targetVersion == 0 {
+		targetVersion = currentVersion
+	}
+	if targetVersion > currentVersion {
+		return nil, errors.Errorf("target version %d is later than the latest version %d", targetVersion, currentVersion)
+	}
+
+	if !opts.DryRun {
+		if err := s.ensureSchema(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	initialised, err := s.tableExists(ctx, "t_metadata")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check presence of tables")
+	}
+
+	res := &MigrateResult{
+		ToVersion: targetVersion,
+	}
+	if initialised {
+		res.FromVersion, err = s.version(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain version")
+		}
+		if res.FromVersion == targetVersion {
+			// Nothing to do.
+			return res, nil
+		}
+		if res.FromVersion > currentVersion {
+			return nil, errors.Errorf("database schema version %d is later than the latest version %d", res.FromVersion, currentVersion)
+		}
+	} else if targetVersion != currentVersion {
+		return nil, errors.New("a new database can only be created at the latest version")
+	}
+
+	if opts.DryRun {
+		return s.migrateDryRun(ctx, res, initialised)
+	}
+	if s.externalSchema {
+		return nil, errors.Wrap(ErrExternalSchema, "cannot migrate schema")
+	}
+
+	if res.FromVersion > targetVersion {
+		// Ensure that all upgrades can be reversed before reversing any of them.
+		if err := checkReversible(res.FromVersion, targetVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		done, requiresRefetch, err := s.migrateVersion(ctx, targetVersion)
+		if err != nil {
+			return nil, err
+		}
+		res.RequiresRefetch = res.RequiresRefetch || requiresRefetch
+		if done {
+			break
+		}
+	}
+
+	return res, nil
+}
+
+// migrateDryRun returns the statements that would migrate the schema, without applying them.
+func (s *Service) migrateDryRun(ctx context.Context, res *MigrateResult, initialised bool) (*MigrateResult, error) {
+	statements, err := s.recordStatements(ctx, func(ctx context.Context) error {
+		var err error
+		switch {
+		case !initialised:
+			err = createInitialTables(ctx, s)
+		case res.FromVersion < res.ToVersion:
+			res.RequiresRefetch, _, err = s.migrateUp(ctx, res.FromVersion, res.ToVersion)
+		default:
+			_, err = s.migrateDown(ctx, res.FromVersion, res.ToVersion)
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.Statements = statements
+
+	return res, nil
+}
+
+// migrateVersion migrates the schema one version towards the target version
+// in a single transaction, returning true if the schema has reached the target
+// version.  It also returns true if the migration requires blocks to be refetched.
+func (s *Service) migrateVersion(ctx context.Context, targetVersion uint64) (bool, bool, error) {
+	txCtx, cancel, err := s.BeginTx(ctx)
+	if err != nil {
+		return false, false, errors.Wrap(err, "failed to begin migration transaction")
+	}
+
+	if err := s.lockMigrations(txCtx); err != nil {
+		cancel()
+		return false, false, err
+	}
+
+	// The version is read under the lock, as another instance could have
+	// migrated the schema while this one was waiting.
+	initialised, err := s.tableExists(txCtx, "t_metadata")
+	if err != nil {
+		cancel()
+		return false, false, errors.Wrap(err, "failed to check presence of tables")
+	}
+	version := uint64(0)
+	if initialised {
+		version, err = s.version(txCtx)
+		if err != nil {
+			cancel()
+			return false, false, errors.Wrap(err, "failed to obtain version")
+		}
+		if version == targetVersion {
+			cancel()
+			return true, false, nil
+		}
+		if version > currentVersion {
+			cancel()
+			return false, false, errors.Errorf("database schema version %d is later than the latest version %d", version, currentVersion)
+		}
+	}
+
+	var nextVersion uint64
+	var steps []*MigrationStep
+	requiresRefetch := false
+	switch {
+	case !initialised:
+		if targetVersion != currentVersion {
+			cancel()
+			return false, false, errors.New("a new database can only be created at the latest version")
+		}
+		log.Info().Uint64("target_version", currentVersion).Msg("Creating database")
+		nextVersion = currentVersion
+		var step *MigrationStep
+		step, err = s.runMigrationFunc(txCtx, nextVersion, 1, MigrationDirectionUp, createInitialTables)
+		steps = append(steps, step)
+	case version < targetVersion:
+		nextVersion = version + 1
+		requiresRefetch, steps, err = s.migrateUp(txCtx, version, nextVersion)
+	default:
+		nextVersion = version - 1
+		steps, err = s.migrateDown(txCtx, version, nextVersion)
+	}
+	if err != nil {
+		cancel()
+		if len(steps) > 0 {
+			s.recordFailedMigrationStep(ctx, steps[len(steps)-1])
+		}
+		return false, false, err
+	}
+
+	if err := s.setVersion(txCtx, nextVersion); err != nil {
+		cancel()
+		return false, false, errors.Wrap(err, "failed to set schema version")
+	}
+
+	if err := s.recordSchemaChange(txCtx, version, nextVersion); err != nil {
+		cancel()
+		return false, false, errors.Wrap(err, "failed to record schema change")
+	}
+
+	if err := s.recordMigrationSteps(txCtx, steps); err != nil {
+		cancel()
+		return false, false, errors.Wrap(err, "failed to record migration steps")
+	}
+
+	if err := s.CommitTx(txCtx); err != nil {
+		cancel()
+		return false, false, errors.Wrap(err, "failed to commit migration transaction")
+	}
+
+	return nextVersion == targetVersion, requiresRefetch, nil
+}
+
+// lockMigrations obtains the advisory lock for schema migrations, waiting if
+// required.  The lock is released when the transaction ends.
+func (s *Service) lockMigrations(ctx context.Context) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	// Migrations can take a long time, including waiting for the lock, so
+	// are not subject to the statement timeout.
+	if _, err := tx.Exec(ctx, `SET LOCAL statement_timeout = 0`); err != nil {
+		return errors.Wrap(err, "failed to disable statement timeout")
+	}
+
+	log.Trace().Msg("Obtaining schema migration lock")
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1,0))`, s.lockName(migrationLockName)); err != nil {
+		return errors.Wrap(err, "failed to obtain schema migration lock")
+	}
+
+	return nil
+}
+
+// migrateUp runs the upgrades between the two versions, returning the steps run.
+// If an upgrade fails the last step returned is that which failed.
+func (s *Service) migrateUp(ctx context.Context, fromVersion uint64, toVersion uint64) (bool, []*MigrationStep, error) {
+	requiresRefetch := false
+	steps := make([]*MigrationStep, 0)
+	for i := fromVersion + 1; i <= toVersion; i++ {
+		log.Info().Uint64("target_version", i).Msg("Upgrading database")
+		if upgrade, exists := upgrades[i]; exists {
+			for j, upgradeFunc := range upgrade.funcs {
+				log.Info().Int("current", j+1).Int("total", len(upgrade.funcs)).Msg("Running upgrade function")
+				step, err := s.runMigrationFunc(ctx, i, j+1, MigrationDirectionUp, upgradeFunc)
+				steps = append(steps, step)
+				if err != nil {
+					return false, steps, errors.Wrap(err, "failed to upgrade")
+				}
+			}
+			requiresRefetch = requiresRefetch || upgrade.requiresRefetch
+		}
+	}
+
+	return requiresRefetch, steps, nil
+}
+
+// checkReversible returns an error if any of the upgrades between the two versions cannot be reversed.
+func checkReversible(fromVersion uint64, toVersion uint64) error {
+	for i := fromVersion; i > toVersion; i-- {
+		if upgrade, exists := upgrades[i]; exists && len(upgrade.downFuncs) == 0 {
+			return errors.Errorf("upgrade to version %d cannot be reversed", i)
+		}
+	}
+
+	return nil
+}
+
+// migrateDown reverses the upgrades between the two versions, returning the steps run.
+// If a downgrade fails the last step returned is that which failed.
+func (s *Service) migrateDown(ctx context.Context, fromVersion uint64, toVersion uint64) ([]*MigrationStep, error) {
+	// Ensure that all upgrades can be reversed before reversing any of them.
+	if err := checkReversible(fromVersion, toVersion); err != nil {
+		return nil, err
+	}
+
+	steps := make([]*MigrationStep, 0)
+	for i := fromVersion; i > toVersion; i-- {
+		log.Info().Uint64("target_version", i-1).Msg("Downgrading database")
+		if upgrade, exists := upgrades[i]; exists {
+			// Reverse the functions in the opposite order to which they were applied.
+			for j := len(upgrade.downFuncs) - 1; j >= 0; j-- {
+				log.Info().Int("current", len(upgrade.downFuncs)-j).Int("total", len(upgrade.downFuncs)).Msg("Running downgrade function")
+				step, err := s.runMigrationFunc(ctx, i, len(upgrade.downFuncs)-j, MigrationDirectionDown, upgrade.downFuncs[j])
+				steps = append(steps, step)
+				if err != nil {
+					return steps, errors.Wrap(err, "failed to downgrade")
+				}
+			}
+		}
+	}
+
+	return steps, nil
+}
+
+// recordSchemaChange records a change to the version of the schema.
+// Changes are not recorded if the history table is not present.
+func (s *Service) recordSchemaChange(ctx context.Context, fromVersion uint64, toVersion uint64) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	exists, err := s.tableExists(ctx, "t_schema_history")
+	if err != nil {
+		return errors.Wrap(err, "failed to check presence of history table")
+	}
+	if !exists {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, `
+INSERT INTO t_schema_history(f_timestamp
+                            ,f_from_version
+                            ,f_to_version
+                            )
+VALUES($1,$2,$3)
+`,
+		time.Now(),
+		fromVersion,
+		toVersion,
+	)
+
+	return err
+}
+
+// SchemaHistory provides the changes made to the version of the schema, earliest first.
+func (s *Service) SchemaHistory(ctx context.Context) ([]*SchemaChange, error) {
+	exists, err := s.tableExists(ctx, "t_schema_history")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check presence of history table")
+	}
+	if !exists {
+		return []*SchemaChange{}, nil
+	}
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_timestamp
+      ,f_from_version
+      ,f_to_version
+FROM t_schema_history
+ORDER BY f_timestamp`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]*SchemaChange, 0)
+	for rows.Next() {
+		change := &SchemaChange{}
+		if err := rows.Scan(
+			&change.Timestamp,
+			&change.FromVersion,
+			&change.ToVersion,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,70 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateDownIrreversible(t *testing.T) {
+	ctx := context.Background()
+	s := &Service{}
+
+	// Upgrade 17 has no down functions, so a downgrade past it must fail
+	// before any downgrade functions are run.
+	_, err := s.migrateDown(ctx, currentVersion, 16)
+	require.EqualError(t, err, "upgrade to version 17 cannot be reversed")
+}
+
+func TestRecordingTx(t *testing.T) {
+	ctx := context.Background()
+	statements := make([]string, 0)
+	tx := &recordingTx{statements: &statements}
+
+	_, err := tx.Exec(ctx, `
+DROP TABLE t_test
+`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"DROP TABLE t_test"}, statements)
+}
+
+func TestMigrationFuncName(t *testing.T) {
+	require.Equal(t, "createSchemaMigrations", migrationFuncName(createSchemaMigrations))
+}
+
+func TestRunMigrationFunc(t *testing.T) {
+	ctx := context.Background()
+	s := &Service{}
+
+	step, err := s.runMigrationFunc(ctx, 38, 1, MigrationDirectionUp, func(_ context.Context, _ *Service) error {
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(38), step.Version)
+	require.Equal(t, 1, step.Step)
+	require.Equal(t, MigrationDirectionUp, step.Direction)
+	require.Equal(t, MigrationStatusCompleted, step.Status)
+	require.False(t, step.Finished.Before(step.Started))
+
+	step, err = s.runMigrationFunc(ctx, 38, 2, MigrationDirectionDown, func(_ context.Context, _ *Service) error {
+		return errors.New("step failed")
+	})
+	require.EqualError(t, err, "step failed")
+	require.Equal(t, MigrationStatusFailed, step.Status)
+	require.Equal(t, "step failed", step.Error)
+}
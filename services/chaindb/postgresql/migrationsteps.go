@@ -0,0 +1,235 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// migrationLockName is the name of the advisory lock held while migrating the schema.
+const migrationLockName = "chaind.schema.migrate"
+
+// Directions of migration steps.
+const (
+	// MigrationDirectionUp is a step that upgrades the schema.
+	MigrationDirectionUp = "up"
+	// MigrationDirectionDown is a step that reverses an upgrade to the schema.
+	MigrationDirectionDown = "down"
+)
+
+// Statuses of migration steps.
+const (
+	// MigrationStatusCompleted is a step that completed successfully.
+	MigrationStatusCompleted = "completed"
+	// MigrationStatusFailed is a step that failed, and whose migration was rolled back.
+	MigrationStatusFailed = "failed"
+)
+
+// MigrationStep is a single function run when migrating the schema.
+type MigrationStep struct {
+	// Version is the version of the upgrade to which the function belongs.
+	Version uint64
+	// Step is the position of the function within its upgrade, starting at 1.
+	Step int
+	// Function is the name of the function.
+	Function string
+	// Direction is one of the MigrationDirection constants.
+	Direction string
+	// Status is one of the MigrationStatus constants.
+	Status   string
+	Started  time.Time
+	Finished time.Time
+	// Error is the error returned by the function, if it failed.
+	Error string
+}
+
+// runMigrationFunc runs a single migration function, returning the step that describes it.
+func (s *Service) runMigrationFunc(ctx context.Context,
+	version uint64,
+	stepNum int,
+	direction string,
+	migrationFunc func(context.Context, *Service) error,
+) (
+	*MigrationStep,
+	error,
+) {
+	step := &MigrationStep{
+		Version:   version,
+		Step:      stepNum,
+		Function:  migrationFuncName(migrationFunc),
+		Direction: direction,
+		Status:    MigrationStatusCompleted,
+		Started:   time.Now(),
+	}
+	err := migrationFunc(ctx, s)
+	step.Finished = time.Now()
+	if err != nil {
+		step.Status = MigrationStatusFailed
+		step.Error = err.Error()
+	}
+	log.Trace().Str("function", step.Function).Dur("duration", step.Finished.Sub(step.Started)).Str("status", step.Status).Msg("Ran migration function")
+
+	return step, err
+}
+
+// migrationFuncName returns the name of a migration function.
+func migrationFuncName(migrationFunc func(context.Context, *Service) error) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(migrationFunc).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+	name := fn.Name()
+
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// recordMigrationSteps records the steps of a migration.
+// Steps are not recorded if the migrations table is not present.
+func (s *Service) recordMigrationSteps(ctx context.Context, steps []*MigrationStep) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	exists, err := s.tableExists(ctx, "t_schema_migrations")
+	if err != nil {
+		return errors.Wrap(err, "failed to check presence of migrations table")
+	}
+	if !exists {
+		return nil
+	}
+
+	for _, step := range steps {
+		migrationError := sql.NullString{}
+		if step.Error != "" {
+			migrationError.Valid = true
+			migrationError.String = step.Error
+		}
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_schema_migrations(f_version
+                               ,f_step
+                               ,f_function
+                               ,f_direction
+                               ,f_status
+                               ,f_started
+                               ,f_finished
+                               ,f_error
+                               )
+VALUES($1,$2,$3,$4,$5,$6,$7,$8)
+`,
+			step.Version,
+			step.Step,
+			step.Function,
+			step.Direction,
+			step.Status,
+			step.Started,
+			step.Finished,
+			migrationError,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordFailedMigrationStep records a failed migration step.  The transaction
+// in which the step ran will have been rolled back, so it is recorded in a
+// transaction of its own.
+func (s *Service) recordFailedMigrationStep(ctx context.Context, step *MigrationStep) {
+	ctx, cancel, err := s.BeginTx(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to begin transaction to record failed migration step")
+		return
+	}
+
+	if err := s.recordMigrationSteps(ctx, []*MigrationStep{step}); err != nil {
+		cancel()
+		log.Warn().Err(err).Msg("Failed to record failed migration step")
+		return
+	}
+
+	if err := s.CommitTx(ctx); err != nil {
+		cancel()
+		log.Warn().Err(err).Msg("Failed to commit failed migration step")
+	}
+}
+
+// MigrationSteps provides the steps run when migrating the schema, earliest first.
+func (s *Service) MigrationSteps(ctx context.Context) ([]*MigrationStep, error) {
+	exists, err := s.tableExists(ctx, "t_schema_migrations")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check presence of migrations table")
+	}
+	if !exists {
+		return []*MigrationStep{}, nil
+	}
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_version
+      ,f_step
+      ,f_function
+      ,f_direction
+      ,f_status
+      ,f_started
+      ,f_finished
+      ,f_error
+FROM t_schema_migrations
+ORDER BY f_started`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	steps := make([]*MigrationStep, 0)
+	for rows.Next() {
+		step := &MigrationStep{}
+		var migrationError sql.NullString
+		if err := rows.Scan(
+			&step.Version,
+			&step.Step,
+			&step.Function,
+			&step.Direction,
+			&step.Status,
+			&step.Started,
+			&step.Finished,
+			&migrationError,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if migrationError.Valid {
+			step.Error = migrationError.String
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
@@ -0,0 +1,197 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetNetworkAggregate sets a network aggregate.
+func (s *Service) SetNetworkAggregate(ctx context.Context, aggregate *chaindb.NetworkAggregate) error {
+	ctx, span := startSpan(ctx, "SetNetworkAggregate")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_network_aggregates(f_epoch
+                                ,f_total_balance
+                                ,f_active_validators
+                                ,f_active_balance
+                                ,f_average_balance
+                                ,f_entering_validators
+                                ,f_exiting_validators
+                                ,f_activated_validators
+                                ,f_exited_validators
+                                ,f_activation_churn_limit
+                                ,f_exit_churn_limit
+                                ,f_activation_churn_utilization
+                                ,f_exit_churn_utilization)
+VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
+ON CONFLICT (f_epoch) DO
+UPDATE
+SET f_total_balance = excluded.f_total_balance
+   ,f_active_validators = excluded.f_active_validators
+   ,f_active_balance = excluded.f_active_balance
+   ,f_average_balance = excluded.f_average_balance
+   ,f_entering_validators = excluded.f_entering_validators
+   ,f_exiting_validators = excluded.f_exiting_validators
+   ,f_activated_validators = excluded.f_activated_validators
+   ,f_exited_validators = excluded.f_exited_validators
+   ,f_activation_churn_limit = excluded.f_activation_churn_limit
+   ,f_exit_churn_limit = excluded.f_exit_churn_limit
+   ,f_activation_churn_utilization = excluded.f_activation_churn_utilization
+   ,f_exit_churn_utilization = excluded.f_exit_churn_utilization
+`,
+		aggregate.Epoch,
+		aggregate.TotalBalance,
+		aggregate.ActiveValidators,
+		aggregate.ActiveBalance,
+		aggregate.AverageBalance,
+		aggregate.EnteringValidators,
+		aggregate.ExitingValidators,
+		aggregate.ActivatedValidators,
+		aggregate.ExitedValidators,
+		aggregate.ActivationChurnLimit,
+		aggregate.ExitChurnLimit,
+		aggregate.ActivationChurnUtilization,
+		aggregate.ExitChurnUtilization,
+	)
+
+	return err
+}
+
+// NetworkAggregates provides network aggregates according to the filter.
+func (s *Service) NetworkAggregates(ctx context.Context, filter *chaindb.NetworkAggregateFilter) ([]*chaindb.NetworkAggregate, error) {
+	ctx, span := startSpan(ctx, "NetworkAggregates")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_epoch
+      ,f_total_balance
+      ,f_active_validators
+      ,f_active_balance
+      ,f_average_balance
+      ,f_entering_validators
+      ,f_exiting_validators
+      ,f_activated_validators
+      ,f_exited_validators
+      ,f_activation_churn_limit
+      ,f_exit_churn_limit
+      ,f_activation_churn_utilization
+      ,f_exit_churn_utilization
+FROM t_network_aggregates`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch <= $%d`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aggregates := make([]*chaindb.NetworkAggregate, 0)
+	for rows.Next() {
+		aggregate := &chaindb.NetworkAggregate{}
+		err := rows.Scan(
+			&aggregate.Epoch,
+			&aggregate.TotalBalance,
+			&aggregate.ActiveValidators,
+			&aggregate.ActiveBalance,
+			&aggregate.AverageBalance,
+			&aggregate.EnteringValidators,
+			&aggregate.ExitingValidators,
+			&aggregate.ActivatedValidators,
+			&aggregate.ExitedValidators,
+			&aggregate.ActivationChurnLimit,
+			&aggregate.ExitChurnLimit,
+			&aggregate.ActivationChurnUtilization,
+			&aggregate.ExitChurnUtilization,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		aggregates = append(aggregates, aggregate)
+	}
+
+	// Always return order of epoch.
+	sort.Slice(aggregates, func(i int, j int) bool {
+		return aggregates[i].Epoch < aggregates[j].Epoch
+	})
+	return aggregates, nil
+}
@@ -0,0 +1,242 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// outboxTrigger is the name of the trigger that captures changes to a table in the outbox.
+const outboxTrigger = "trg_outbox_capture"
+
+// outboxExcludedTables are tables whose changes are internal to chaind, so cannot be captured.
+var outboxExcludedTables = map[string]bool{
+	"t_failed_items":   true,
+	"t_metadata":       true,
+	"t_outbox":         true,
+	"t_progress":       true,
+	"t_progress_gaps":  true,
+	"t_schema_history": true,
+}
+
+// OutboxCapturableTables provides the tables whose changes can be captured in the outbox.
+func (s *Service) OutboxCapturableTables(ctx context.Context) ([]string, error) {
+	ctx, span := startSpan(ctx, "OutboxCapturableTables")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT table_name
+FROM information_schema.tables
+WHERE table_schema = current_schema()
+  AND table_type = 'BASE TABLE'
+  AND table_name LIKE 't\_%'
+ORDER BY table_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make([]string, 0)
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if !outboxExcludedTables[table] {
+			tables = append(tables, table)
+		}
+	}
+
+	return tables, rows.Err()
+}
+
+// OutboxEvents provides up to limit events from the outbox, in the order in which they were written.
+func (s *Service) OutboxEvents(ctx context.Context, limit uint32) ([]*chaindb.OutboxEvent, error) {
+	ctx, span := startSpan(ctx, "OutboxEvents")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_id
+      ,f_table
+      ,f_operation
+      ,f_data::TEXT
+      ,f_timestamp
+FROM t_outbox
+ORDER BY f_id
+LIMIT `,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*chaindb.OutboxEvent, 0)
+	for rows.Next() {
+		event := &chaindb.OutboxEvent{}
+		var data string
+		if err := rows.Scan(
+			&event.ID,
+			&event.Table,
+			&event.Operation,
+			&data,
+			&event.Timestamp,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		event.Data = []byte(data)
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// SetOutboxCapture captures changes to the given tables in the outbox,
+// and stops capturing changes to all other tables.
+func (s *Service) SetOutboxCapture(ctx context.Context, tables []string) error {
+	ctx, span := startSpan(ctx, "SetOutboxCapture")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	capturableTables, err := s.OutboxCapturableTables(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain capturable tables")
+	}
+	capturable := make(map[string]bool, len(capturableTables))
+	for _, table := range capturableTables {
+		capturable[table] = true
+	}
+	required := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		if !capturable[table] {
+			return fmt.Errorf("changes to table %s cannot be captured", table)
+		}
+		required[table] = true
+	}
+
+	captured, err := s.outboxCapturedTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	for table := range captured {
+		if required[table] {
+			continue
+		}
+		if s.externalSchema {
+			return errors.Wrapf(ErrExternalSchema, "cannot stop capture of changes to %s", table)
+		}
+		log.Debug().Str("table", table).Msg("Stopping capture of changes to table")
+		if _, err := tx.Exec(ctx, fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", outboxTrigger, pgx.Identifier{table}.Sanitize())); err != nil {
+			return errors.Wrapf(err, "failed to stop capture of changes to %s", table)
+		}
+	}
+
+	for _, table := range tables {
+		if captured[table] {
+			continue
+		}
+		if s.externalSchema {
+			return errors.Wrapf(ErrExternalSchema, "cannot start capture of changes to %s", table)
+		}
+		log.Debug().Str("table", table).Msg("Starting capture of changes to table")
+		if _, err := tx.Exec(ctx, fmt.Sprintf("CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s FOR EACH ROW EXECUTE FUNCTION f_outbox_capture()", outboxTrigger, pgx.Identifier{table}.Sanitize())); err != nil {
+			return errors.Wrapf(err, "failed to start capture of changes to %s", table)
+		}
+		captured[table] = true
+	}
+
+	return nil
+}
+
+// DeleteOutboxEvents deletes the events with the given IDs from the outbox.
+func (s *Service) DeleteOutboxEvents(ctx context.Context, ids []uint64) error {
+	ctx, span := startSpan(ctx, "DeleteOutboxEvents")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+DELETE FROM t_outbox
+WHERE f_id = ANY()
+`,
+		ids,
+	)
+
+	return err
+}
+
+// outboxCapturedTables provides the tables whose changes are currently captured in the outbox.
+func (s *Service) outboxCapturedTables(ctx context.Context) (map[string]bool, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return nil, ErrNoTransaction
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT pg_class.relname
+FROM pg_trigger
+JOIN pg_class ON pg_class.oid = pg_trigger.tgrelid
+WHERE pg_trigger.tgname = 
+  AND pg_class.relnamespace = (SELECT oid FROM pg_namespace WHERE nspname = current_schema())`,
+		outboxTrigger,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain captured tables")
+	}
+	defer rows.Close()
+
+	tables := make(map[string]bool)
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		tables[table] = true
+	}
+
+	return tables, rows.Err()
+}
@@ -14,11 +14,19 @@
 package postgresql
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/cache"
+	"github.com/wealdtech/chaind/services/coldstore"
 )
 
+// PasswordProvider provides the password with which to connect to the database.
+type PasswordProvider func(ctx context.Context) (string, error)
+
 type parameters struct {
 	logLevel       zerolog.Level
 	connectionURL  string
@@ -26,10 +34,76 @@ type parameters struct {
 	port           int32
 	user           string
 	password       string
+	database       string
+	sslMode        string
+	channelBinding string
 	clientCert     []byte
 	clientKey      []byte
 	caCert         []byte
 	maxConnections uint
+	// passwordProvider provides the password for each new connection.
+	passwordProvider PasswordProvider
+	// maxConnectionLifetime is the time after which connections are closed.
+	maxConnectionLifetime time.Duration
+	// maxConnectionIdleTime is the time after which idle connections are closed.
+	maxConnectionIdleTime time.Duration
+	// statementCacheMode is the mode in which statements are prepared and cached.
+	statementCacheMode string
+	// statementTimeout is the time after which statements are cancelled.
+	statementTimeout time.Duration
+	// queryTimeout is the time after which queries are cancelled.
+	queryTimeout time.Duration
+	// queryTimeouts are the times after which queries are cancelled, by operation.
+	queryTimeouts map[string]time.Duration
+	// poolPartitions are additional pools for use by individual modules.
+	poolPartitions map[string]*PoolPartition
+	// compactAttestations stores attestations without their aggregation indices.
+	compactAttestations bool
+	// coldStore holds data that has been offloaded from the database.
+	coldStore coldstore.Service
+	// canonicalOnly restricts providers to data from canonical blocks.
+	canonicalOnly bool
+	// concurrentIndexes creates secondary indexes without locking their tables.
+	concurrentIndexes bool
+	// externalSchema validates, rather than changes, the schema.
+	externalSchema bool
+	// schema is the schema in which tables are held.
+	schema string
+	// readOnlyRoles are granted read access to the tables.
+	readOnlyRoles []string
+	// rowLevelSecurity restricts access to rows to the owner and read-only roles.
+	rowLevelSecurity bool
+	// validatorIndexCache caches the indices of validators by public key.
+	validatorIndexCache cache.Service
+	// readCache caches the results of frequent reads.
+	readCache    cache.Service
+	readCacheTTL time.Duration
+	// writeBatchSize is the number of small upserts held back and sent together.
+	writeBatchSize int
+	// writeFlushInterval is the longest time that upserts are held back.
+	writeFlushInterval time.Duration
+	// timescaleMode is the mode of TimescaleDB support.
+	timescaleMode string
+	// timescaleCompressAfter is the age in epochs after which data in hypertables is compressed.
+	timescaleCompressAfter uint64
+	// timescaleAggregateEpochs is the width in epochs of the buckets of continuous aggregates.
+	timescaleAggregateEpochs uint64
+	// specTypes are the types with which chain specification values are parsed, by key.
+	specTypes map[string]string
+	// audit records the writes made by each transaction in the audit log.
+	audit bool
+	// auditRetention is the time for which audit log entries are kept.
+	auditRetention time.Duration
+	// sqlHooks are statements run when entities are stored.
+	sqlHooks []*SQLHook
+}
+
+// PoolPartition is the configuration of a pool partition.  Unset values are
+// taken from the configuration of the default pool.
+type PoolPartition struct {
+	MaxConnections   uint
+	StatementTimeout *time.Duration
+	QueryTimeout     *time.Duration
 }
 
 // Parameter is the interface for service parameters.
@@ -79,6 +153,15 @@ func WithPassword(password string) Parameter {
 	})
 }
 
+// WithPasswordProvider sets a provider for the password for this module.
+// The provider is called for each new connection, allowing the password to be
+// rotated without a restart, and overrides any password set elsewhere.
+func WithPasswordProvider(provider PasswordProvider) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.passwordProvider = provider
+	})
+}
+
 // WithPort sets the port for this module.
 func WithPort(port int32) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -86,6 +169,30 @@ func WithPort(port int32) Parameter {
 	})
 }
 
+// WithDatabase sets the database for this module.
+// If not supplied the database with the same name as the user is used.
+func WithDatabase(database string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.database = database
+	})
+}
+
+// WithSSLMode sets the SSL mode for this module, as per the libpq sslmode option.
+// If not supplied this is verify-full if certificates are supplied, otherwise prefer.
+func WithSSLMode(sslMode string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.sslMode = sslMode
+	})
+}
+
+// WithChannelBinding sets the channel binding mode for this module, as per the
+// libpq channel_binding option.
+func WithChannelBinding(channelBinding string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.channelBinding = channelBinding
+	})
+}
+
 // WithClientCert sets the bytes of the client TLS certificate.
 func WithClientCert(cert []byte) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -114,11 +221,231 @@ func WithMaxConnections(maxConnections uint) Parameter {
 	})
 }
 
+// WithMaxConnectionLifetime sets the time after which connections are closed.
+func WithMaxConnectionLifetime(maxConnectionLifetime time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxConnectionLifetime = maxConnectionLifetime
+	})
+}
+
+// WithMaxConnectionIdleTime sets the time after which idle connections are closed.
+func WithMaxConnectionIdleTime(maxConnectionIdleTime time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxConnectionIdleTime = maxConnectionIdleTime
+	})
+}
+
+// WithStatementCacheMode sets the mode in which statements are prepared and
+// cached: one of cache_statement, cache_describe, describe_exec, exec or
+// simple_protocol.  Modes other than cache_statement suit connection poolers
+// that do not support prepared statements.
+func WithStatementCacheMode(statementCacheMode string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.statementCacheMode = statementCacheMode
+	})
+}
+
+// WithStatementTimeout sets the time after which statements are cancelled.
+// Schema migrations and index creation are not subject to the timeout.
+func WithStatementTimeout(statementTimeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.statementTimeout = statementTimeout
+	})
+}
+
+// WithQueryTimeout sets the time after which queries are cancelled by the
+// client.  Cancelling a query closes its connection, so its transaction is
+// rolled back.
+func WithQueryTimeout(queryTimeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.queryTimeout = queryTimeout
+	})
+}
+
+// WithQueryTimeouts sets the times after which queries are cancelled by the
+// client, keyed by the name of the operation that issues them, for example
+// ValidatorBalancesByIndexAndEpochRange.  Names are not case-sensitive.
+// Operations without a time use the query timeout.
+func WithQueryTimeouts(queryTimeouts map[string]time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.queryTimeouts = queryTimeouts
+	})
+}
+
+// WithPoolPartitions sets additional pools, keyed by name, for use by individual modules.
+func WithPoolPartitions(poolPartitions map[string]*PoolPartition) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.poolPartitions = poolPartitions
+	})
+}
+
+// WithCompactAttestations sets attestations to be stored without their aggregation indices, which
+// are instead expanded from the aggregation bits and stored beacon committees when read.
+func WithCompactAttestations(compactAttestations bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.compactAttestations = compactAttestations
+	})
+}
+
+// WithColdStore sets the cold store from which offloaded data is read.
+func WithColdStore(coldStore coldstore.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.coldStore = coldStore
+	})
+}
+
+// WithCanonicalOnly restricts attestation, deposit and withdrawal providers to data
+// from blocks that have been confirmed as canonical.
+func WithCanonicalOnly(canonicalOnly bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.canonicalOnly = canonicalOnly
+	})
+}
+
+// WithConcurrentIndexes creates secondary indexes concurrently, without locking
+// their tables against writes.
+func WithConcurrentIndexes(concurrentIndexes bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.concurrentIndexes = concurrentIndexes
+	})
+}
+
+// WithExternalSchema states that the schema is managed externally, in which
+// case the service never issues DDL and instead validates the schema on upgrade.
+func WithExternalSchema(externalSchema bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.externalSchema = externalSchema
+	})
+}
+
+// WithSchema sets the schema in which tables are held.  If not set then the
+// first schema in the server's search path is used.
+func WithSchema(schema string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.schema = schema
+	})
+}
+
+// WithReadOnlyRoles sets the roles that are granted read access to the tables
+// in the schema.  Roles that do not exist are created.
+func WithReadOnlyRoles(roles []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.readOnlyRoles = roles
+	})
+}
+
+// WithRowLevelSecurity enables row-level security on the tables in the schema,
+// with policies that allow the read-only roles to read all rows.
+func WithRowLevelSecurity(rowLevelSecurity bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.rowLevelSecurity = rowLevelSecurity
+	})
+}
+
+// WithValidatorIndexCache sets the cache used to resolve validator public keys to indices.
+func WithValidatorIndexCache(validatorIndexCache cache.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorIndexCache = validatorIndexCache
+	})
+}
+
+// WithReadCache sets the cache used for the results of frequent reads, such as the chain
+// specification, genesis, latest summaries and validators.
+func WithReadCache(readCache cache.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.readCache = readCache
+	})
+}
+
+// WithReadCacheTTL sets the time for which results are held in the read cache.
+func WithReadCacheTTL(ttl time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.readCacheTTL = ttl
+	})
+}
+
+// WithWriteBatchSize sets the number of small upserts, such as validator
+// balances and epoch summaries, that are queued within a transaction and sent
+// to the database together.  A size of 0 or 1 sends each upsert immediately.
+func WithWriteBatchSize(size int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.writeBatchSize = size
+	})
+}
+
+// WithWriteFlushInterval sets the longest time that queued upserts are held
+// before being sent to the database.  An interval of 0 only sends queued
+// upserts when the batch is full or the transaction is otherwise used.
+func WithWriteFlushInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.writeFlushInterval = interval
+	})
+}
+
+// WithTimescale sets the mode of TimescaleDB support, one of the TimescaleMode constants.
+func WithTimescale(mode string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timescaleMode = mode
+	})
+}
+
+// WithTimescaleCompressAfter sets the age in epochs after which data in
+// hypertables is compressed.  An age of 0 does not compress data.
+func WithTimescaleCompressAfter(epochs uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timescaleCompressAfter = epochs
+	})
+}
+
+// WithTimescaleAggregateEpochs sets the width in epochs of the buckets of
+// continuous aggregates.  It only affects aggregates when they are created.
+func WithTimescaleAggregateEpochs(epochs uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timescaleAggregateEpochs = epochs
+	})
+}
+
+// WithSpecTypes sets the types with which chain specification values are
+// parsed, by key, one of the SpecType constants.  These take precedence over
+// the types recorded when the values were stored, and are intended for values
+// that would otherwise be misclassified.
+func WithSpecTypes(types map[string]string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.specTypes = types
+	})
+}
+
+// WithAudit records the writes made by each transaction in the audit log.
+func WithAudit(audit bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.audit = audit
+	})
+}
+
+// WithAuditRetention sets the time for which audit log entries are kept.
+// A retention of 0 keeps entries indefinitely.
+func WithAuditRetention(retention time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.auditRetention = retention
+	})
+}
+
+// WithSQLHooks sets statements to run in the same transaction when entities are stored.
+func WithSQLHooks(hooks []*SQLHook) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.sqlHooks = hooks
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel:       zerolog.GlobalLevel(),
-		maxConnections: 16,
+		logLevel:                 zerolog.GlobalLevel(),
+		maxConnections:           16,
+		readCacheTTL:             time.Minute,
+		timescaleMode:            TimescaleModeDisable,
+		timescaleCompressAfter:   2250,
+		timescaleAggregateEpochs: 225,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -126,6 +453,73 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 		}
 	}
 
+	if parameters.readCache != nil && parameters.readCacheTTL <= 0 {
+		return nil, errors.New("read cache time to live must be greater than 0")
+	}
+
+	if !timescaleModes[parameters.timescaleMode] {
+		return nil, fmt.Errorf("unknown TimescaleDB mode %s", parameters.timescaleMode)
+	}
+	if parameters.timescaleAggregateEpochs == 0 {
+		return nil, errors.New("TimescaleDB aggregate epochs must be greater than 0")
+	}
+
+	for key, typ := range parameters.specTypes {
+		if !specTypes[typ] {
+			return nil, fmt.Errorf("unknown chain specification type %s for %s", typ, key)
+		}
+	}
+
+	if parameters.auditRetention < 0 {
+		return nil, errors.New("audit retention cannot be negative")
+	}
+
+	for i, hook := range parameters.sqlHooks {
+		if hook == nil {
+			return nil, fmt.Errorf("SQL hook %d is missing", i)
+		}
+		if err := checkSQLHook(hook); err != nil {
+			return nil, fmt.Errorf("invalid SQL hook %d: %w", i, err)
+		}
+	}
+
+	if parameters.writeBatchSize < 0 {
+		return nil, errors.New("write batch size cannot be negative")
+	}
+	if parameters.writeFlushInterval < 0 {
+		return nil, errors.New("write flush interval cannot be negative")
+	}
+
+	if parameters.statementCacheMode != "" {
+		if _, exists := queryExecModes[parameters.statementCacheMode]; !exists {
+			return nil, fmt.Errorf("unknown statement cache mode %s", parameters.statementCacheMode)
+		}
+	}
+	for operation, timeout := range parameters.queryTimeouts {
+		if timeout < 0 {
+			return nil, fmt.Errorf("query timeout for %s cannot be negative", operation)
+		}
+	}
+	for name, partition := range parameters.poolPartitions {
+		if partition == nil {
+			return nil, fmt.Errorf("no configuration for pool partition %s", name)
+		}
+	}
+
+	if parameters.rowLevelSecurity && len(parameters.readOnlyRoles) == 0 {
+		return nil, errors.New("row-level security requires at least one read-only role")
+	}
+
+	if _, exists := sslModes[parameters.sslMode]; !exists {
+		return nil, fmt.Errorf("unknown SSL mode %s", parameters.sslMode)
+	}
+	if err := checkChannelBinding(parameters.channelBinding); err != nil {
+		return nil, err
+	}
+	if (parameters.clientCert == nil) != (parameters.clientKey == nil) {
+		return nil, errors.New("client certificate and key must be supplied together")
+	}
+
 	if parameters.connectionURL != "" {
 		// Allow deprecated connection URL.
 		return &parameters, nil
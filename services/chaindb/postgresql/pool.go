@@ -0,0 +1,120 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	zerologadapter "github.com/jackc/pgx-zerolog"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/tracelog"
+	"github.com/pkg/errors"
+)
+
+// queryExecModes are the statement cache modes, keyed by name.
+var queryExecModes = map[string]pgx.QueryExecMode{
+	"cache_statement": pgx.QueryExecModeCacheStatement,
+	"cache_describe":  pgx.QueryExecModeCacheDescribe,
+	"describe_exec":   pgx.QueryExecModeDescribeExec,
+	"exec":            pgx.QueryExecModeExec,
+	"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+}
+
+// configurePool applies the pool and connection parameters to the pool configuration.
+func configurePool(config *pgxpool.Config, parameters *parameters) error {
+	if parameters.maxConnectionLifetime > 0 {
+		config.MaxConnLifetime = parameters.maxConnectionLifetime
+	}
+	if parameters.maxConnectionIdleTime > 0 {
+		config.MaxConnIdleTime = parameters.maxConnectionIdleTime
+	}
+	if parameters.statementCacheMode != "" {
+		mode, exists := queryExecModes[parameters.statementCacheMode]
+		if !exists {
+			return fmt.Errorf("unknown statement cache mode %s", parameters.statementCacheMode)
+		}
+		config.ConnConfig.DefaultQueryExecMode = mode
+	}
+	setStatementTimeout(config, parameters.statementTimeout)
+	setSearchPath(config, parameters.schema)
+	config.ConnConfig.Tracer = newQueryTracer(&tracelog.TraceLog{Logger: zerologadapter.NewLogger(log)}, parameters.queryTimeout, parameters.queryTimeouts)
+
+	return nil
+}
+
+// setStatementTimeout sets the time after which statements on connections are cancelled.
+func setStatementTimeout(config *pgxpool.Config, timeout time.Duration) {
+	if timeout <= 0 {
+		delete(config.ConnConfig.RuntimeParams, "statement_timeout")
+		return
+	}
+	config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(timeout.Milliseconds(), 10)
+}
+
+// partitionConfig provides the configuration for a pool partition, based on
+// the configuration of the default pool.
+func partitionConfig(config *pgxpool.Config, partition *PoolPartition) *pgxpool.Config {
+	partitionConfig := config.Copy()
+	if partition.MaxConnections > 0 {
+		partitionConfig.MaxConns = int32(partition.MaxConnections)
+	}
+	if partition.StatementTimeout != nil {
+		setStatementTimeout(partitionConfig, *partition.StatementTimeout)
+	}
+	if tracer, isTracer := config.ConnConfig.Tracer.(*queryTracer); isTracer && partition.QueryTimeout != nil {
+		partitionConfig.ConnConfig.Tracer = newQueryTracer(tracer.TraceLog, *partition.QueryTimeout, tracer.timeouts)
+	}
+
+	return partitionConfig
+}
+
+// newPool creates a connection pool, closing it when the context is done.
+func newPool(ctx context.Context, config *pgxpool.Config, name string) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to database")
+	}
+
+	if err := registerPoolMetrics(pool, name); err != nil {
+		pool.Close()
+		return nil, errors.Wrap(err, "failed to register pool metrics")
+	}
+
+	go func() {
+		<-ctx.Done()
+		log.Trace().Str("pool", name).Msg("Context done; closing pool")
+		pool.Close()
+	}()
+
+	return pool, nil
+}
+
+// Partition provides a service that uses the named pool partition for its
+// connections, so that its queries cannot starve those of other modules.  If
+// the partition is not configured then the service itself is returned.
+func (s *Service) Partition(name string) *Service {
+	pool, exists := s.partitions[name]
+	if !exists {
+		return s
+	}
+
+	partition := *s
+	partition.pool = pool
+
+	return &partition
+}
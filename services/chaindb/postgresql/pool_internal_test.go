@@ -0,0 +1,81 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigurePool(t *testing.T) {
+	config, err := pgxpool.ParseConfig("host=localhost user=chain")
+	require.NoError(t, err)
+
+	require.NoError(t, configurePool(config, &parameters{
+		maxConnectionLifetime: 30 * time.Minute,
+		maxConnectionIdleTime: 5 * time.Minute,
+		statementCacheMode:    "describe_exec",
+		statementTimeout:      90 * time.Second,
+	}))
+	require.Equal(t, 30*time.Minute, config.MaxConnLifetime)
+	require.Equal(t, 5*time.Minute, config.MaxConnIdleTime)
+	require.Equal(t, pgx.QueryExecModeDescribeExec, config.ConnConfig.DefaultQueryExecMode)
+	require.Equal(t, "90000", config.ConnConfig.RuntimeParams["statement_timeout"])
+
+	require.EqualError(t, configurePool(config, &parameters{statementCacheMode: "unknown"}), "unknown statement cache mode unknown")
+}
+
+func TestPartitionConfig(t *testing.T) {
+	config, err := pgxpool.ParseConfig("host=localhost user=chain pool_max_conns=16")
+	require.NoError(t, err)
+	setStatementTimeout(config, time.Minute)
+
+	// Unset values are inherited.
+	inherited := partitionConfig(config, &PoolPartition{})
+	require.Equal(t, int32(16), inherited.MaxConns)
+	require.Equal(t, "60000", inherited.ConnConfig.RuntimeParams["statement_timeout"])
+
+	noTimeout := time.Duration(0)
+	partition := partitionConfig(config, &PoolPartition{
+		MaxConnections:   4,
+		StatementTimeout: &noTimeout,
+	})
+	require.Equal(t, int32(4), partition.MaxConns)
+	require.NotContains(t, partition.ConnConfig.RuntimeParams, "statement_timeout")
+
+	// The default configuration is unchanged.
+	require.Equal(t, int32(16), config.MaxConns)
+	require.Equal(t, "60000", config.ConnConfig.RuntimeParams["statement_timeout"])
+}
+
+func TestPartition(t *testing.T) {
+	pool := &pgxpool.Pool{}
+	partitionPool := &pgxpool.Pool{}
+	s := &Service{
+		pool:          pool,
+		partitions:    map[string]*pgxpool.Pool{"summarizer": partitionPool},
+		canonicalOnly: true,
+	}
+
+	require.Same(t, s, s.Partition("blocks"))
+
+	partition := s.Partition("summarizer")
+	require.Same(t, partitionPool, partition.pool)
+	require.True(t, partition.canonicalOnly)
+	require.Same(t, pool, s.pool)
+}
@@ -0,0 +1,165 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetProgress sets a progress value for a service.
+func (s *Service) SetProgress(ctx context.Context, service string, key string, value int64) error {
+	ctx, span := startSpan(ctx, "SetProgress")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_progress(f_service
+                      ,f_key
+                      ,f_value
+                      )
+VALUES($1,$2,$3)
+ON CONFLICT (f_service,f_key) DO
+UPDATE
+SET f_value = excluded.f_value
+`,
+		service,
+		key,
+		value,
+	)
+
+	return err
+}
+
+// SetProgressGaps sets the gaps in progress for a service, replacing any existing gaps for the key.
+func (s *Service) SetProgressGaps(ctx context.Context, service string, key string, gaps []int64) error {
+	ctx, span := startSpan(ctx, "SetProgressGaps")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+DELETE FROM t_progress_gaps
+WHERE f_service = $1
+  AND f_key = $2
+`,
+		service,
+		key,
+	); err != nil {
+		return errors.Wrap(err, "failed to remove existing gaps")
+	}
+
+	if len(gaps) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `
+INSERT INTO t_progress_gaps(f_service
+                           ,f_key
+                           ,f_value
+                           )
+SELECT $1,$2,UNNEST($3::BIGINT[])
+ON CONFLICT (f_service,f_key,f_value) DO NOTHING
+`,
+		service,
+		key,
+		gaps,
+	); err != nil {
+		return errors.Wrap(err, "failed to set gaps")
+	}
+
+	return nil
+}
+
+// Progress obtains the progress for a service.
+// Returns nil if no progress has been recorded for the service.
+func (s *Service) Progress(ctx context.Context, service string) (*chaindb.Progress, error) {
+	ctx, span := startSpan(ctx, "Progress")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	progress := &chaindb.Progress{
+		Service: service,
+		Values:  make(map[string]int64),
+		Gaps:    make(map[string][]int64),
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_key
+      ,f_value
+FROM t_progress
+WHERE f_service = $1`,
+		service,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var value int64
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		progress.Values[key] = value
+	}
+	rows.Close()
+
+	gapRows, err := tx.Query(ctx, `
+SELECT f_key
+      ,f_value
+FROM t_progress_gaps
+WHERE f_service = $1
+ORDER BY f_key, f_value`,
+		service,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer gapRows.Close()
+
+	for gapRows.Next() {
+		var key string
+		var value int64
+		if err := gapRows.Scan(&key, &value); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		progress.Gaps[key] = append(progress.Gaps[key], value)
+	}
+
+	if len(progress.Values) == 0 && len(progress.Gaps) == 0 {
+		return nil, nil
+	}
+
+	return progress, nil
+}
@@ -15,16 +15,18 @@ package postgresql
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // SetProposerDuty sets a proposer duty.
 func (s *Service) SetProposerDuty(ctx context.Context, proposerDuty *chaindb.ProposerDuty) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetProposerDuty")
+	ctx, span := startSpan(ctx, "SetProposerDuty")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -32,7 +34,7 @@ func (s *Service) SetProposerDuty(ctx context.Context, proposerDuty *chaindb.Pro
 		return ErrNoTransaction
 	}
 
-	_, err := tx.Exec(ctx, `
+	return s.queueExec(ctx, tx, `
       INSERT INTO t_proposer_duties(f_slot
                                    ,f_validator_index)
       VALUES($1,$2)
@@ -43,19 +45,11 @@ func (s *Service) SetProposerDuty(ctx context.Context, proposerDuty *chaindb.Pro
 		proposerDuty.Slot,
 		proposerDuty.ValidatorIndex,
 	)
-
-	return err
 }
 
-// ProposerDutiesForSlotRange fetches all proposer duties for a slot range.
-func (s *Service) ProposerDutiesForSlotRange(ctx context.Context,
-	startSlot phase0.Slot,
-	endSlot phase0.Slot,
-) (
-	[]*chaindb.ProposerDuty,
-	error,
-) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ProposerDutiesForSlotRange")
+// ProposerDuties provides proposer duties according to the filter.
+func (s *Service) ProposerDuties(ctx context.Context, filter *chaindb.ProposerDutyFilter) ([]*chaindb.ProposerDuty, error) {
+	ctx, span := startSpan(ctx, "ProposerDuties")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -68,60 +62,65 @@ func (s *Service) ProposerDutiesForSlotRange(ctx context.Context,
 		tx = s.tx(ctx)
 	}
 
-	rows, err := tx.Query(ctx, `
-      SELECT f_slot
-            ,f_validator_index
-      FROM t_proposer_duties
-      WHERE f_slot >= $1
-        AND f_slot < $2
-      ORDER BY f_slot`,
-		startSlot,
-		endSlot,
-	)
-	if err != nil {
-		return nil, err
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_slot
+      ,f_validator_index
+FROM t_proposer_duties`)
+
+	conditions := make([]string, 0)
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("f_slot >= $%d", len(queryVals)))
 	}
-	defer rows.Close()
 
-	proposerDuties := make([]*chaindb.ProposerDuty, 0)
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("f_slot <= $%d", len(queryVals)))
+	}
 
-	for rows.Next() {
-		proposerDuty := &chaindb.ProposerDuty{}
-		err := rows.Scan(
-			&proposerDuty.Slot,
-			&proposerDuty.ValidatorIndex,
-		)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan row")
-		}
-		proposerDuties = append(proposerDuties, proposerDuty)
+	if len(filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, filter.ValidatorIndices)
+		conditions = append(conditions, fmt.Sprintf("f_validator_index = ANY($%d)", len(queryVals)))
 	}
 
-	return proposerDuties, nil
-}
+	if len(conditions) > 0 {
+		queryBuilder.WriteString("\nWHERE ")
+		queryBuilder.WriteString(strings.Join(conditions, "\n  AND "))
+	}
 
-// ProposerDutiesForValidator provides all proposer duties for the given validator index.
-func (s *Service) ProposerDutiesForValidator(ctx context.Context, proposer phase0.ValidatorIndex) ([]*chaindb.ProposerDuty, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ProposerDutiesForValidator")
-	defer span.End()
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
 
-	tx := s.tx(ctx)
-	if tx == nil {
-		ctx, err := s.BeginROTx(ctx)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to begin transaction")
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
 		}
-		defer s.CommitROTx(ctx)
-		tx = s.tx(ctx)
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
 	}
 
-	rows, err := tx.Query(ctx, `
-SELECT f_slot
-FROM t_proposer_duties
-WHERE f_validator_index = $1
-ORDER BY f_slot
-`,
-		proposer,
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
 	)
 	if err != nil {
 		return nil, err
@@ -129,13 +128,11 @@ ORDER BY f_slot
 	defer rows.Close()
 
 	proposerDuties := make([]*chaindb.ProposerDuty, 0)
-
 	for rows.Next() {
-		proposerDuty := &chaindb.ProposerDuty{
-			ValidatorIndex: proposer,
-		}
+		proposerDuty := &chaindb.ProposerDuty{}
 		err := rows.Scan(
 			&proposerDuty.Slot,
+			&proposerDuty.ValidatorIndex,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -143,5 +140,35 @@ ORDER BY f_slot
 		proposerDuties = append(proposerDuties, proposerDuty)
 	}
 
+	// Always return order of slot.
+	sort.Slice(proposerDuties, func(i int, j int) bool {
+		return proposerDuties[i].Slot < proposerDuties[j].Slot
+	})
 	return proposerDuties, nil
 }
+
+// ProposerDutiesForSlotRange fetches all proposer duties for a slot range.
+func (s *Service) ProposerDutiesForSlotRange(ctx context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	[]*chaindb.ProposerDuty,
+	error,
+) {
+	if endSlot <= startSlot {
+		return []*chaindb.ProposerDuty{}, nil
+	}
+	to := endSlot - 1
+
+	return s.ProposerDuties(ctx, &chaindb.ProposerDutyFilter{
+		From: &startSlot,
+		To:   &to,
+	})
+}
+
+// ProposerDutiesForValidator provides all proposer duties for the given validator index.
+func (s *Service) ProposerDutiesForValidator(ctx context.Context, proposer phase0.ValidatorIndex) ([]*chaindb.ProposerDuty, error) {
+	return s.ProposerDuties(ctx, &chaindb.ProposerDutyFilter{
+		ValidatorIndices: []phase0.ValidatorIndex{proposer},
+	})
+}
@@ -0,0 +1,342 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetProposerPeriodSummaries sets multiple proposer period summaries.
+// Any existing summaries for the windows covered are replaced.
+func (s *Service) SetProposerPeriodSummaries(ctx context.Context, summaries []*chaindb.ProposerPeriodSummary) error {
+	ctx, span := startSpan(ctx, "SetProposerPeriodSummaries")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	type windowKey struct {
+		window         string
+		startTimestamp int64
+	}
+	seen := make(map[windowKey]bool)
+	for _, summary := range summaries {
+		key := windowKey{window: summary.Window, startTimestamp: summary.StartTimestamp.Unix()}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if _, err := tx.Exec(ctx, `
+DELETE FROM t_proposer_period_summaries
+WHERE f_window = $1
+  AND f_start_timestamp = $2
+`,
+			summary.Window,
+			summary.StartTimestamp,
+		); err != nil {
+			return errors.Wrap(err, "failed to remove existing proposer period summaries")
+		}
+	}
+
+	if _, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"t_proposer_period_summaries"},
+		[]string{
+			"f_window",
+			"f_start_timestamp",
+			"f_validator_index",
+			"f_tag",
+			"f_proposals",
+			"f_proposals_included",
+			"f_consensus_rewards",
+			"f_execution_fees",
+			"f_mev_payments",
+			"f_mev_blocks",
+		},
+		pgx.CopyFromSlice(len(summaries), func(i int) ([]any, error) {
+			var consensusRewards *uint64
+			if summaries[i].ConsensusRewards != nil {
+				rewards := uint64(*summaries[i].ConsensusRewards)
+				consensusRewards = &rewards
+			}
+			executionFees := decimal.Zero
+			if summaries[i].ExecutionFees != nil {
+				executionFees = decimal.NewFromBigInt(summaries[i].ExecutionFees, 0)
+			}
+			mevPayments := decimal.Zero
+			if summaries[i].MEVPayments != nil {
+				mevPayments = decimal.NewFromBigInt(summaries[i].MEVPayments, 0)
+			}
+			return []any{
+				summaries[i].Window,
+				summaries[i].StartTimestamp,
+				summaries[i].ValidatorIndex,
+				summaries[i].Tag,
+				summaries[i].Proposals,
+				summaries[i].ProposalsIncluded,
+				consensusRewards,
+				executionFees,
+				mevPayments,
+				summaries[i].MEVBlocks,
+			}, nil
+		})); err != nil {
+		return errors.Wrap(err, "failed to copy proposer period summaries")
+	}
+
+	return nil
+}
+
+// ProposerPeriodSummaries provides proposer period summaries according to the filter.
+func (s *Service) ProposerPeriodSummaries(ctx context.Context, filter *chaindb.ProposerPeriodSummaryFilter) ([]*chaindb.ProposerPeriodSummary, error) {
+	ctx, span := startSpan(ctx, "ProposerPeriodSummaries")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_window
+      ,f_start_timestamp
+      ,f_validator_index
+      ,f_tag
+      ,f_proposals
+      ,f_proposals_included
+      ,f_consensus_rewards
+      ,f_execution_fees
+      ,f_mev_payments
+      ,f_mev_blocks
+FROM t_proposer_period_summaries`)
+
+	queryVals = proposerPeriodSummaryConditions(&queryBuilder, queryVals, filter)
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_start_timestamp, f_validator_index, f_tag`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_start_timestamp DESC,f_validator_index DESC,f_tag DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]*chaindb.ProposerPeriodSummary, 0)
+	for rows.Next() {
+		summary := &chaindb.ProposerPeriodSummary{}
+		var validatorIndex *uint64
+		var consensusRewards *uint64
+		var executionFees decimal.Decimal
+		var mevPayments decimal.Decimal
+		err := rows.Scan(
+			&summary.Window,
+			&summary.StartTimestamp,
+			&validatorIndex,
+			&summary.Tag,
+			&summary.Proposals,
+			&summary.ProposalsIncluded,
+			&consensusRewards,
+			&executionFees,
+			&mevPayments,
+			&summary.MEVBlocks,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if validatorIndex != nil {
+			index := phase0.ValidatorIndex(*validatorIndex)
+			summary.ValidatorIndex = &index
+		}
+		if consensusRewards != nil {
+			rewards := phase0.Gwei(*consensusRewards)
+			summary.ConsensusRewards = &rewards
+		}
+		summary.StartTimestamp = summary.StartTimestamp.In(time.UTC)
+		summary.ExecutionFees = denominated(ctx, executionFees.BigInt())
+		summary.MEVPayments = denominated(ctx, mevPayments.BigInt())
+		summaries = append(summaries, summary)
+	}
+
+	// Always return order of start timestamp, then validator index, then tag.
+	sort.Slice(summaries, func(i int, j int) bool {
+		if !summaries[i].StartTimestamp.Equal(summaries[j].StartTimestamp) {
+			return summaries[i].StartTimestamp.Before(summaries[j].StartTimestamp)
+		}
+		if summaries[i].ValidatorIndex != nil && summaries[j].ValidatorIndex != nil {
+			return *summaries[i].ValidatorIndex < *summaries[j].ValidatorIndex
+		}
+		if summaries[i].ValidatorIndex != nil || summaries[j].ValidatorIndex != nil {
+			// Validator summaries come before tag summaries.
+			return summaries[i].ValidatorIndex != nil
+		}
+		return *summaries[i].Tag < *summaries[j].Tag
+	})
+	return summaries, nil
+}
+
+// ProposerPeriodTotals provides the totals of the proposer period summaries
+// that match the filter.  The limit and order of the filter are ignored.
+// Values are summed by the database, so cannot overflow.
+func (s *Service) ProposerPeriodTotals(ctx context.Context, filter *chaindb.ProposerPeriodSummaryFilter) (*chaindb.ProposerPeriodTotals, error) {
+	ctx, span := startSpan(ctx, "ProposerPeriodTotals")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT COUNT(*)
+      ,COALESCE(SUM(f_proposals),0)
+      ,COALESCE(SUM(f_proposals_included),0)
+      ,CASE WHEN COUNT(*) = COUNT(f_consensus_rewards) THEN COALESCE(SUM(f_consensus_rewards),0) END
+      ,COALESCE(SUM(f_execution_fees),0)
+      ,COALESCE(SUM(f_mev_payments),0)
+      ,COALESCE(SUM(f_mev_blocks),0)
+FROM t_proposer_period_summaries`)
+
+	queryVals = proposerPeriodSummaryConditions(&queryBuilder, queryVals, filter)
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	totals := &chaindb.ProposerPeriodTotals{}
+	var consensusRewards decimal.NullDecimal
+	var executionFees decimal.Decimal
+	var mevPayments decimal.Decimal
+	if err := tx.QueryRow(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	).Scan(
+		&totals.Summaries,
+		&totals.Proposals,
+		&totals.ProposalsIncluded,
+		&consensusRewards,
+		&executionFees,
+		&mevPayments,
+		&totals.MEVBlocks,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain proposer period totals")
+	}
+	totals.ConsensusRewards = bigIntFromNumeric(consensusRewards)
+	totals.ExecutionFees = denominated(ctx, executionFees.BigInt())
+	totals.MEVPayments = denominated(ctx, mevPayments.BigInt())
+
+	return totals, nil
+}
+
+// proposerPeriodSummaryConditions adds the conditions of the filter to the query.
+func proposerPeriodSummaryConditions(queryBuilder *strings.Builder,
+	queryVals []any,
+	filter *chaindb.ProposerPeriodSummaryFilter,
+) []any {
+	wherestr := "WHERE"
+
+	if filter.Window != "" {
+		queryVals = append(queryVals, filter.Window)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_window = $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_start_timestamp >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_start_timestamp <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	switch {
+	case filter.ValidatorIndices != nil && filter.Tags != nil:
+		queryVals = append(queryVals, *filter.ValidatorIndices, *filter.Tags)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s (f_validator_index = ANY($%d) OR f_tag = ANY($%d))`, wherestr, len(queryVals)-1, len(queryVals)))
+	case filter.ValidatorIndices != nil:
+		queryVals = append(queryVals, *filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_validator_index = ANY($%d)`, wherestr, len(queryVals)))
+	case filter.Tags != nil:
+		queryVals = append(queryVals, *filter.Tags)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_tag = ANY($%d)`, wherestr, len(queryVals)))
+	}
+
+	return queryVals
+}
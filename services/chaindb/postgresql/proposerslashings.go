@@ -15,16 +15,18 @@ package postgresql
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // SetProposerSlashing sets a proposer slashing.
 func (s *Service) SetProposerSlashing(ctx context.Context, proposerSlashing *chaindb.ProposerSlashing) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetProposerSlashing")
+	ctx, span := startSpan(ctx, "SetProposerSlashing")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -91,10 +93,9 @@ func (s *Service) SetProposerSlashing(ctx context.Context, proposerSlashing *cha
 	return err
 }
 
-// ProposerSlashingsForSlotRange fetches all proposer slashings made for the given slot range.
-// It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
-func (s *Service) ProposerSlashingsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.ProposerSlashing, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ProposerSlashingsForSlotRange")
+// ProposerSlashings provides proposer slashings according to the filter.
+func (s *Service) ProposerSlashings(ctx context.Context, filter *chaindb.ProposerSlashingFilter) ([]*chaindb.ProposerSlashing, error) {
+	ctx, span := startSpan(ctx, "ProposerSlashings")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -107,131 +108,89 @@ func (s *Service) ProposerSlashingsForSlotRange(ctx context.Context, minSlot pha
 		tx = s.tx(ctx)
 	}
 
-	rows, err := tx.Query(ctx, `
-      SELECT f_inclusion_slot
-            ,f_inclusion_block_root
-            ,f_inclusion_index
-            ,f_block_1_root
-            ,f_header_1_slot
-            ,f_header_1_proposer_index
-            ,f_header_1_parent_root
-            ,f_header_1_state_root
-            ,f_header_1_body_root
-            ,f_header_1_signature
-            ,f_block_2_root
-            ,f_header_2_slot
-            ,f_header_2_proposer_index
-            ,f_header_2_parent_root
-            ,f_header_2_state_root
-            ,f_header_2_body_root
-            ,f_header_2_signature
-      FROM t_proposer_slashings
-      WHERE f_inclusion_slot >= $1
-        AND f_inclusion_slot < $2
-		AND f_inclusion_slot IN (SELECT f_slot FROM t_blocks WHERE f_slot >= $1 AND f_slot < $2 AND (f_canonical IS NULL OR f_canonical = true))
-      ORDER BY f_inclusion_slot
-	          ,f_inclusion_index`,
-		minSlot,
-		maxSlot,
-	)
-	if err != nil {
-		return nil, err
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_inclusion_slot
+      ,f_inclusion_block_root
+      ,f_inclusion_index
+      ,f_block_1_root
+      ,f_header_1_slot
+      ,f_header_1_proposer_index
+      ,f_header_1_parent_root
+      ,f_header_1_state_root
+      ,f_header_1_body_root
+      ,f_header_1_signature
+      ,f_block_2_root
+      ,f_header_2_slot
+      ,f_header_2_proposer_index
+      ,f_header_2_parent_root
+      ,f_header_2_state_root
+      ,f_header_2_body_root
+      ,f_header_2_signature
+FROM t_proposer_slashings`)
+
+	conditions := make([]string, 0)
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("f_inclusion_slot >= $%d", len(queryVals)))
 	}
-	defer rows.Close()
 
-	proposerSlashings := make([]*chaindb.ProposerSlashing, 0)
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("f_inclusion_slot <= $%d", len(queryVals)))
+	}
 
-	var inclusionBlockRoot []byte
-	var block1Root []byte
-	var header1ParentRoot []byte
-	var header1StateRoot []byte
-	var header1BodyRoot []byte
-	var header1Signature []byte
-	var block2Root []byte
-	var header2ParentRoot []byte
-	var header2StateRoot []byte
-	var header2BodyRoot []byte
-	var header2Signature []byte
-	for rows.Next() {
-		proposerSlashing := &chaindb.ProposerSlashing{}
-		err := rows.Scan(
-			&proposerSlashing.InclusionSlot,
-			&inclusionBlockRoot,
-			&proposerSlashing.InclusionIndex,
-			&block1Root,
-			&proposerSlashing.Header1Slot,
-			&proposerSlashing.Header1ProposerIndex,
-			&header1ParentRoot,
-			&header1StateRoot,
-			&header1BodyRoot,
-			&header1Signature,
-			&block2Root,
-			&proposerSlashing.Header2Slot,
-			&proposerSlashing.Header2ProposerIndex,
-			&header2ParentRoot,
-			&header2StateRoot,
-			&header2BodyRoot,
-			&header2Signature,
-		)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan row")
-		}
-		copy(proposerSlashing.InclusionBlockRoot[:], inclusionBlockRoot)
-		copy(proposerSlashing.Block1Root[:], block1Root)
-		copy(proposerSlashing.Header1ParentRoot[:], header1ParentRoot)
-		copy(proposerSlashing.Header1StateRoot[:], header1StateRoot)
-		copy(proposerSlashing.Header1BodyRoot[:], header1BodyRoot)
-		copy(proposerSlashing.Header1Signature[:], header1Signature)
-		copy(proposerSlashing.Block2Root[:], block2Root)
-		copy(proposerSlashing.Header2ParentRoot[:], header2ParentRoot)
-		copy(proposerSlashing.Header2StateRoot[:], header2StateRoot)
-		copy(proposerSlashing.Header2BodyRoot[:], header2BodyRoot)
-		copy(proposerSlashing.Header2Signature[:], header2Signature)
-		proposerSlashings = append(proposerSlashings, proposerSlashing)
+	if len(filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, filter.ValidatorIndices)
+		conditions = append(conditions, fmt.Sprintf("f_header_1_proposer_index = ANY($%d)", len(queryVals)))
 	}
 
-	return proposerSlashings, nil
-}
+	if filter.Canonical != nil {
+		queryVals = append(queryVals, *filter.Canonical)
+		conditions = append(conditions, inclusionCanonicalCondition("t_proposer_slashings", len(queryVals)))
+	}
 
-// ProposerSlashingsForValidator fetches all proposer slashings made for the given validator.
-// It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
-func (s *Service) ProposerSlashingsForValidator(ctx context.Context, index phase0.ValidatorIndex) ([]*chaindb.ProposerSlashing, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ProposerSlashingsForValidator")
-	defer span.End()
+	if filter.ExcludeNonCanonical {
+		conditions = append(conditions, inclusionNotNonCanonicalCondition("t_proposer_slashings"))
+	}
 
-	tx := s.tx(ctx)
-	if tx == nil {
-		ctx, err := s.BeginROTx(ctx)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to begin transaction")
+	if len(conditions) > 0 {
+		queryBuilder.WriteString("\nWHERE ")
+		queryBuilder.WriteString(strings.Join(conditions, "\n  AND "))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_inclusion_slot, f_inclusion_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_inclusion_slot DESC,f_inclusion_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
 		}
-		defer s.CommitROTx(ctx)
-		tx = s.tx(ctx)
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
 	}
 
-	rows, err := tx.Query(ctx, `
-      SELECT f_inclusion_slot
-            ,f_inclusion_block_root
-            ,f_inclusion_index
-            ,f_block_1_root
-            ,f_header_1_slot
-            ,f_header_1_proposer_index
-            ,f_header_1_parent_root
-            ,f_header_1_state_root
-            ,f_header_1_body_root
-            ,f_header_1_signature
-            ,f_block_2_root
-            ,f_header_2_slot
-            ,f_header_2_proposer_index
-            ,f_header_2_parent_root
-            ,f_header_2_state_root
-            ,f_header_2_body_root
-            ,f_header_2_signature
-      FROM t_proposer_slashings
-      WHERE f_header_1_slot IN (SELECT f_slot FROM t_proposer_duties WHERE f_validator_index = $1)
-      ORDER BY f_inclusion_slot
-	          ,f_inclusion_index`,
-		index,
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
 	)
 	if err != nil {
 		return nil, err
@@ -289,5 +248,36 @@ func (s *Service) ProposerSlashingsForValidator(ctx context.Context, index phase
 		proposerSlashings = append(proposerSlashings, proposerSlashing)
 	}
 
+	// Always return order of inclusion slot then inclusion index.
+	sort.Slice(proposerSlashings, func(i int, j int) bool {
+		if proposerSlashings[i].InclusionSlot != proposerSlashings[j].InclusionSlot {
+			return proposerSlashings[i].InclusionSlot < proposerSlashings[j].InclusionSlot
+		}
+		return proposerSlashings[i].InclusionIndex < proposerSlashings[j].InclusionIndex
+	})
 	return proposerSlashings, nil
 }
+
+// ProposerSlashingsForSlotRange fetches all proposer slashings made for the given slot range.
+// It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
+func (s *Service) ProposerSlashingsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.ProposerSlashing, error) {
+	if maxSlot <= minSlot {
+		return []*chaindb.ProposerSlashing{}, nil
+	}
+	to := maxSlot - 1
+
+	return s.ProposerSlashings(ctx, &chaindb.ProposerSlashingFilter{
+		From:                &minSlot,
+		To:                  &to,
+		ExcludeNonCanonical: true,
+	})
+}
+
+// ProposerSlashingsForValidator fetches all proposer slashings made for the given validator.
+// It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
+func (s *Service) ProposerSlashingsForValidator(ctx context.Context, index phase0.ValidatorIndex) ([]*chaindb.ProposerSlashing, error) {
+	return s.ProposerSlashings(ctx, &chaindb.ProposerSlashingFilter{
+		ValidatorIndices:    []phase0.ValidatorIndex{index},
+		ExcludeNonCanonical: true,
+	})
+}
@@ -0,0 +1,169 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/tracelog"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// operationKey is a context tag for the name of the chain database operation.
+type operationKey struct{}
+
+// queryDeadline is a context tag for the deadline applied to a query.
+type queryDeadline struct{}
+
+// queryDeadlineInfo holds the deadline applied to a query.
+type queryDeadlineInfo struct {
+	parent    context.Context
+	operation string
+	cancel    context.CancelFunc
+}
+
+// queriesTimedOut counts queries cancelled by their timeout.
+var queriesTimedOut metric.Int64Counter
+
+// startSpan starts a tracing span for the chain database operation, and
+// records the operation so that its queries are subject to its timeout and
+// its writes are audited.
+func startSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	ctx = context.WithValue(ctx, &operationKey{}, operation)
+	recordAuditOperation(ctx, operation)
+
+	return otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, operation)
+}
+
+// operation returns the name of the chain database operation; "" if none.
+func operation(ctx context.Context) string {
+	if operation, ok := ctx.Value(&operationKey{}).(string); ok {
+		return operation
+	}
+
+	return ""
+}
+
+// queryTracer logs queries, and cancels those that exceed their timeout.
+// Cancelling a query closes its connection, which aborts the query and rolls
+// back its transaction on the server.
+type queryTracer struct {
+	*tracelog.TraceLog
+	// timeout is the timeout for operations without their own timeout.
+	timeout time.Duration
+	// timeouts are the timeouts for operations, keyed by lower-case operation name.
+	timeouts map[string]time.Duration
+}
+
+// newQueryTracer creates a new query tracer.
+func newQueryTracer(traceLog *tracelog.TraceLog, timeout time.Duration, timeouts map[string]time.Duration) *queryTracer {
+	lowerTimeouts := make(map[string]time.Duration, len(timeouts))
+	for operation, operationTimeout := range timeouts {
+		lowerTimeouts[strings.ToLower(operation)] = operationTimeout
+	}
+
+	return &queryTracer{
+		TraceLog: traceLog,
+		timeout:  timeout,
+		timeouts: lowerTimeouts,
+	}
+}
+
+// operationTimeout returns the timeout for queries of the operation; 0 if none.
+func (t *queryTracer) operationTimeout(operation string) time.Duration {
+	if timeout, exists := t.timeouts[strings.ToLower(operation)]; exists {
+		return timeout
+	}
+
+	return t.timeout
+}
+
+// withDeadline applies the operation's timeout to the context.
+func (t *queryTracer) withDeadline(ctx context.Context) context.Context {
+	operation := operation(ctx)
+	timeout := t.operationTimeout(operation)
+	if timeout <= 0 {
+		return ctx
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	return context.WithValue(ctx, &queryDeadline{}, &queryDeadlineInfo{
+		parent:    parent,
+		operation: operation,
+		cancel:    cancel,
+	})
+}
+
+// releaseDeadline releases the operation's timeout, noting if it expired.
+func (*queryTracer) releaseDeadline(ctx context.Context, err error) {
+	info, ok := ctx.Value(&queryDeadline{}).(*queryDeadlineInfo)
+	if !ok {
+		return
+	}
+	defer info.cancel()
+
+	// Only the timeout of the query itself counts; the parent may have its own deadline.
+	if err == nil || info.parent.Err() != nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return
+	}
+	log.Warn().Str("operation", info.operation).Msg("Query timed out")
+	if queriesTimedOut != nil {
+		queriesTimedOut.Add(context.Background(), 1, metric.WithAttributes(attribute.String("operation", info.operation)))
+	}
+}
+
+// TraceQueryStart is called at the start of Query, QueryRow and Exec calls.
+func (t *queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return t.TraceLog.TraceQueryStart(t.withDeadline(ctx), conn, data)
+}
+
+// TraceQueryEnd is called at the end of Query, QueryRow and Exec calls.
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	t.TraceLog.TraceQueryEnd(ctx, conn, data)
+	t.releaseDeadline(ctx, data.Err)
+	if data.Err == nil {
+		recordAuditRows(ctx, writtenRows(data.CommandTag))
+	}
+}
+
+// TraceBatchQuery is called for each query in a batch.
+func (t *queryTracer) TraceBatchQuery(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchQueryData) {
+	t.TraceLog.TraceBatchQuery(ctx, conn, data)
+	if data.Err == nil {
+		recordAuditRows(ctx, writtenRows(data.CommandTag))
+	}
+}
+
+// TraceCopyFromStart is called at the start of CopyFrom calls.
+func (t *queryTracer) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	return t.TraceLog.TraceCopyFromStart(t.withDeadline(ctx), conn, data)
+}
+
+// TraceCopyFromEnd is called at the end of CopyFrom calls.
+func (t *queryTracer) TraceCopyFromEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	t.TraceLog.TraceCopyFromEnd(ctx, conn, data)
+	t.releaseDeadline(ctx, data.Err)
+	if data.Err == nil {
+		recordAuditRows(ctx, data.CommandTag.RowsAffected())
+	}
+}
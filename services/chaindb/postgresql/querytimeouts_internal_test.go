@@ -0,0 +1,101 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/tracelog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartSpanOperation(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, "", operation(ctx))
+
+	ctx, span := startSpan(ctx, "BlocksBySlot")
+	defer span.End()
+	require.Equal(t, "BlocksBySlot", operation(ctx))
+
+	ctx, innerSpan := startSpan(ctx, "Validators")
+	defer innerSpan.End()
+	require.Equal(t, "Validators", operation(ctx))
+}
+
+func TestOperationTimeout(t *testing.T) {
+	tracer := newQueryTracer(&tracelog.TraceLog{}, time.Minute, map[string]time.Duration{
+		"ValidatorBalancesByIndexAndEpochRange": 10 * time.Minute,
+		"blocksbyslot":                          0,
+	})
+
+	require.Equal(t, time.Minute, tracer.operationTimeout(""))
+	require.Equal(t, time.Minute, tracer.operationTimeout("Validators"))
+	require.Equal(t, 10*time.Minute, tracer.operationTimeout("ValidatorBalancesByIndexAndEpochRange"))
+	require.Equal(t, time.Duration(0), tracer.operationTimeout("BlocksBySlot"))
+}
+
+func TestQueryDeadline(t *testing.T) {
+	tracer := newQueryTracer(&tracelog.TraceLog{}, time.Millisecond, map[string]time.Duration{
+		"BlocksBySlot": 0,
+	})
+
+	// No timeout for the operation.
+	ctx, span := startSpan(context.Background(), "BlocksBySlot")
+	defer span.End()
+	queryCtx := tracer.withDeadline(ctx)
+	_, hasDeadline := queryCtx.Deadline()
+	require.False(t, hasDeadline)
+	tracer.releaseDeadline(queryCtx, nil)
+
+	// Timeout for the operation.
+	ctx, span = startSpan(context.Background(), "Validators")
+	defer span.End()
+	queryCtx = tracer.withDeadline(ctx)
+	_, hasDeadline = queryCtx.Deadline()
+	require.True(t, hasDeadline)
+	<-queryCtx.Done()
+	require.ErrorIs(t, queryCtx.Err(), context.DeadlineExceeded)
+	tracer.releaseDeadline(queryCtx, errors.New("timeout: context deadline exceeded"))
+	require.NoError(t, ctx.Err())
+
+	// Releasing a deadline cancels the query context.
+	queryCtx = tracer.withDeadline(ctx)
+	tracer.releaseDeadline(queryCtx, nil)
+	require.ErrorIs(t, queryCtx.Err(), context.Canceled)
+}
+
+func TestPartitionQueryTimeout(t *testing.T) {
+	config, err := pgxpool.ParseConfig("host=localhost user=chain")
+	require.NoError(t, err)
+	require.NoError(t, configurePool(config, &parameters{
+		queryTimeout: time.Minute,
+		queryTimeouts: map[string]time.Duration{
+			"Validators": time.Hour,
+		},
+	}))
+
+	queryTimeout := 30 * time.Minute
+	partition := partitionConfig(config, &PoolPartition{QueryTimeout: &queryTimeout})
+	tracer, isTracer := partition.ConnConfig.Tracer.(*queryTracer)
+	require.True(t, isTracer)
+	require.Equal(t, 30*time.Minute, tracer.operationTimeout("Blocks"))
+	require.Equal(t, time.Hour, tracer.operationTimeout("Validators"))
+
+	// The default pool is unchanged.
+	require.Equal(t, time.Minute, config.ConnConfig.Tracer.(*queryTracer).operationTimeout("Blocks"))
+}
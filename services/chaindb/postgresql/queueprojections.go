@@ -0,0 +1,247 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetQueueProjections sets multiple queue projections.
+// Any existing projections for the epochs covered are replaced.
+func (s *Service) SetQueueProjections(ctx context.Context, projections []*chaindb.QueueProjection) error {
+	ctx, span := startSpan(ctx, "SetQueueProjections")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	epochs := make([]phase0.Epoch, 0)
+	seen := make(map[phase0.Epoch]bool)
+	for _, projection := range projections {
+		if !seen[projection.Epoch] {
+			seen[projection.Epoch] = true
+			epochs = append(epochs, projection.Epoch)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+DELETE FROM t_queue_projections
+WHERE f_epoch = ANY($1)
+`,
+		epochs,
+	); err != nil {
+		return errors.Wrap(err, "failed to remove existing queue projections")
+	}
+
+	if _, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"t_queue_projections"},
+		[]string{
+			"f_epoch",
+			"f_validator_index",
+			"f_activation_epoch",
+			"f_exit_epoch",
+		},
+		pgx.CopyFromSlice(len(projections), func(i int) ([]any, error) {
+			return []any{
+				projections[i].Epoch,
+				projections[i].ValidatorIndex,
+				projections[i].ActivationEpoch,
+				projections[i].ExitEpoch,
+			}, nil
+		})); err != nil {
+		return errors.Wrap(err, "failed to copy queue projections")
+	}
+
+	return nil
+}
+
+// QueueProjections provides queue projections according to the filter.
+func (s *Service) QueueProjections(ctx context.Context, filter *chaindb.QueueProjectionFilter) ([]*chaindb.QueueProjection, error) {
+	ctx, span := startSpan(ctx, "QueueProjections")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_epoch
+      ,f_validator_index
+      ,f_activation_epoch
+      ,f_exit_epoch
+FROM t_queue_projections`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_validator_index = ANY($%d)`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch, f_validator_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch DESC,f_validator_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projections := make([]*chaindb.QueueProjection, 0)
+	for rows.Next() {
+		projection := &chaindb.QueueProjection{}
+		err := rows.Scan(
+			&projection.Epoch,
+			&projection.ValidatorIndex,
+			&projection.ActivationEpoch,
+			&projection.ExitEpoch,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		projections = append(projections, projection)
+	}
+
+	// Always return order of epoch then validator index.
+	sort.Slice(projections, func(i int, j int) bool {
+		if projections[i].Epoch != projections[j].Epoch {
+			return projections[i].Epoch < projections[j].Epoch
+		}
+		return projections[i].ValidatorIndex < projections[j].ValidatorIndex
+	})
+	return projections, nil
+}
+
+// QueueProjectionAccuracy provides the accuracy of activation projections made
+// between the given epochs, for validators that activated by the given epoch.
+func (s *Service) QueueProjectionAccuracy(ctx context.Context,
+	from phase0.Epoch,
+	to phase0.Epoch,
+	activatedBy phase0.Epoch,
+) (
+	[]*chaindb.QueueProjectionAccuracy,
+	error,
+) {
+	ctx, span := startSpan(ctx, "QueueProjectionAccuracy")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT t_queue_projections.f_epoch
+      ,COUNT(*)
+      ,AVG(t_validators.f_activation_epoch - t_queue_projections.f_activation_epoch)::DOUBLE PRECISION
+      ,AVG(ABS(t_validators.f_activation_epoch - t_queue_projections.f_activation_epoch))::DOUBLE PRECISION
+FROM t_queue_projections
+JOIN t_validators ON t_validators.f_index = t_queue_projections.f_validator_index
+WHERE t_queue_projections.f_epoch >= $1
+  AND t_queue_projections.f_epoch <= $2
+  AND t_queue_projections.f_activation_epoch IS NOT NULL
+  AND t_validators.f_activation_epoch <= $3
+GROUP BY t_queue_projections.f_epoch
+ORDER BY t_queue_projections.f_epoch`,
+		from,
+		to,
+		activatedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accuracies := make([]*chaindb.QueueProjectionAccuracy, 0)
+	for rows.Next() {
+		accuracy := &chaindb.QueueProjectionAccuracy{}
+		err := rows.Scan(
+			&accuracy.Epoch,
+			&accuracy.Validators,
+			&accuracy.MeanError,
+			&accuracy.MeanAbsoluteError,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		accuracies = append(accuracies, accuracy)
+	}
+
+	return accuracies, rows.Err()
+}
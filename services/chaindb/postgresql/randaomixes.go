@@ -0,0 +1,145 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetRANDAOMix sets the RANDAO mix of an epoch.
+func (s *Service) SetRANDAOMix(ctx context.Context, mix *chaindb.RANDAOMix) error {
+	ctx, span := startSpan(ctx, "SetRANDAOMix")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_randao_mixes(f_epoch
+                          ,f_randao_mix)
+VALUES($1,$2)
+ON CONFLICT (f_epoch) DO
+UPDATE
+SET f_randao_mix = excluded.f_randao_mix
+`,
+		mix.Epoch,
+		mix.Mix[:],
+	)
+
+	return err
+}
+
+// RANDAOMixes provides RANDAO mixes according to the filter.
+func (s *Service) RANDAOMixes(ctx context.Context, filter *chaindb.RANDAOMixFilter) ([]*chaindb.RANDAOMix, error) {
+	ctx, span := startSpan(ctx, "RANDAOMixes")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_epoch
+      ,f_randao_mix
+FROM t_randao_mixes`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch <= $%d`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mixes := make([]*chaindb.RANDAOMix, 0)
+	randaoMix := make([]byte, phase0.RootLength)
+	for rows.Next() {
+		mix := &chaindb.RANDAOMix{}
+		err := rows.Scan(
+			&mix.Epoch,
+			&randaoMix,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(mix.Mix[:], randaoMix)
+		mixes = append(mixes, mix)
+	}
+
+	// Always return order of epoch.
+	sort.Slice(mixes, func(i int, j int) bool {
+		return mixes[i].Epoch < mixes[j].Epoch
+	})
+	return mixes, nil
+}
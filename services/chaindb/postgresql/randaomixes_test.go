@@ -0,0 +1,90 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestRANDAOMixes(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	// Use epochs far beyond any test data to avoid clashes.
+	mix := func(epoch phase0.Epoch) *chaindb.RANDAOMix {
+		return &chaindb.RANDAOMix{
+			Epoch: epoch,
+			Mix:   phase0.Root{0x01, byte(epoch)},
+		}
+	}
+	epochs := []phase0.Epoch{0xfff0000, 0xfff0001, 0xfff0002, 0xfff0003}
+
+	// Try to set outside of a transaction; should fail.
+	require.EqualError(t, s.SetRANDAOMix(ctx, mix(epochs[0])), postgresql.ErrNoTransaction.Error())
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	for _, epoch := range epochs {
+		require.NoError(t, s.SetRANDAOMix(ctx, mix(epoch)))
+	}
+	// Setting an epoch again replaces its mix.
+	updated := &chaindb.RANDAOMix{Epoch: epochs[1], Mix: phase0.Root{0x02}}
+	require.NoError(t, s.SetRANDAOMix(ctx, updated))
+
+	from := epochs[0]
+	to := epochs[2]
+	tests := []struct {
+		name     string
+		filter   *chaindb.RANDAOMixFilter
+		expected []*chaindb.RANDAOMix
+	}{
+		{
+			name:     "Range",
+			filter:   &chaindb.RANDAOMixFilter{From: &from, To: &to},
+			expected: []*chaindb.RANDAOMix{mix(epochs[0]), updated, mix(epochs[2])},
+		},
+		{
+			name:     "Earliest",
+			filter:   &chaindb.RANDAOMixFilter{From: &from, Limit: 2},
+			expected: []*chaindb.RANDAOMix{mix(epochs[0]), updated},
+		},
+		{
+			name:     "Latest",
+			filter:   &chaindb.RANDAOMixFilter{From: &from, Order: chaindb.OrderLatest, Limit: 2},
+			expected: []*chaindb.RANDAOMix{mix(epochs[2]), mix(epochs[3])},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mixes, err := s.RANDAOMixes(ctx, test.filter)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, mixes)
+		})
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetRawBlock sets a raw block.
+func (s *Service) SetRawBlock(ctx context.Context, block *chaindb.RawBlock) error {
+	ctx, span := startSpan(ctx, "SetRawBlock")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	var key *string
+	if block.Key != "" {
+		key = &block.Key
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_raw_blocks(f_root
+                        ,f_slot
+                        ,f_version
+                        ,f_data
+                        ,f_key)
+VALUES($1,$2,$3,$4,$5)
+ON CONFLICT (f_root) DO
+UPDATE
+SET f_slot = excluded.f_slot
+   ,f_version = excluded.f_version
+   ,f_data = excluded.f_data
+   ,f_key = excluded.f_key
+`,
+		block.Root[:],
+		block.Slot,
+		block.Version.String(),
+		block.Data,
+		key,
+	)
+
+	return err
+}
+
+// RawBlockByRoot fetches the raw block with the given root.
+func (s *Service) RawBlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.RawBlock, error) {
+	ctx, span := startSpan(ctx, "RawBlockByRoot")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_root
+      ,f_slot
+      ,f_version
+      ,f_data
+      ,f_key
+FROM t_raw_blocks
+WHERE f_root = $1`,
+		root[:],
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, pgx.ErrNoRows
+	}
+	block, err := rawBlockFromRow(rows)
+	if err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := s.resolveRawBlock(ctx, block); err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+// RawBlocks provides raw blocks according to the filter.
+func (s *Service) RawBlocks(ctx context.Context, filter *chaindb.RawBlockFilter) ([]*chaindb.RawBlock, error) {
+	ctx, span := startSpan(ctx, "RawBlocks")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_root
+      ,f_slot
+      ,f_version
+      ,f_data
+      ,f_key
+FROM t_raw_blocks`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot <= $%d`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot, f_root`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC, f_root DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocks := make([]*chaindb.RawBlock, 0)
+	for rows.Next() {
+		block, err := rawBlockFromRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain raw blocks")
+	}
+	rows.Close()
+
+	for _, block := range blocks {
+		if err := s.resolveRawBlock(ctx, block); err != nil {
+			return nil, err
+		}
+	}
+
+	// Always return order of slot.
+	sort.Slice(blocks, func(i int, j int) bool {
+		return blocks[i].Slot < blocks[j].Slot
+	})
+	return blocks, nil
+}
+
+// rawBlockFromRow converts a SQL row in to a raw block.
+func rawBlockFromRow(rows pgx.Rows) (*chaindb.RawBlock, error) {
+	block := &chaindb.RawBlock{}
+	var root []byte
+	var version string
+	var key *string
+	err := rows.Scan(
+		&root,
+		&block.Slot,
+		&version,
+		&block.Data,
+		&key,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to scan row")
+	}
+	copy(block.Root[:], root)
+	if err := block.Version.UnmarshalJSON([]byte(fmt.Sprintf("%q", version))); err != nil {
+		return nil, errors.Wrapf(err, "invalid version %q", version)
+	}
+	if key != nil {
+		block.Key = *key
+	}
+
+	return block, nil
+}
+
+// resolveRawBlock fetches the data for a raw block from cold storage if it
+// is not held in the database.
+func (s *Service) resolveRawBlock(ctx context.Context, block *chaindb.RawBlock) error {
+	if block.Data != nil || block.Key == "" {
+		return nil
+	}
+	if s.coldStore == nil {
+		return fmt.Errorf("raw block %#x is in cold storage but no cold store is configured", block.Root)
+	}
+
+	data, err := s.coldStore.Get(ctx, block.Key)
+	if err != nil {
+		return errors.Wrapf(err, "failed to obtain raw block %#x from cold storage", block.Root)
+	}
+	block.Data = data
+
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// readCacheInvalidationBatchSize is the maximum number of keys removed from the read cache at a time.
+const readCacheInvalidationBatchSize = 1024
+
+// ReadCacheInvalidations is a context tag for the keys to remove from the read cache when the
+// transaction commits.
+type ReadCacheInvalidations struct{}
+
+// readCacheInvalidations holds the keys to remove from the read cache when a transaction commits.
+type readCacheInvalidations struct {
+	mutex sync.Mutex
+	keys  map[string]struct{}
+}
+
+// readCacheLookups counts lookups in the read cache.
+var readCacheLookups metric.Int64Counter
+
+// cachedRead obtains a value from the read cache if present, otherwise obtains it with the
+// read function and caches it.
+// Reads within a transaction bypass the cache, as they could see data that is not yet committed.
+func cachedRead[T any](ctx context.Context,
+	s *Service,
+	key string,
+	read func(ctx context.Context) (T, error),
+) (
+	T,
+	error,
+) {
+	if s.readCache == nil || s.tx(ctx) != nil {
+		return read(ctx)
+	}
+
+	cached, err := s.readCache.Get(ctx, []string{key})
+	if err != nil {
+		// Not fatal, as we can fall back to the database.
+		log.Debug().Err(err).Str("key", key).Msg("Failed to obtain value from read cache")
+	} else if data, exists := cached[key]; exists {
+		var value T
+		if err := json.Unmarshal(data, &value); err == nil {
+			monitorReadCacheLookup(ctx, true)
+			return value, nil
+		}
+		log.Debug().Err(err).Str("key", key).Msg("Invalid value in read cache")
+	}
+	monitorReadCacheLookup(ctx, false)
+
+	value, err := read(ctx)
+	if err != nil {
+		return value, err
+	}
+	s.setReadCache(ctx, map[string]any{key: value})
+
+	return value, nil
+}
+
+// setReadCache stores the given values in the read cache.
+func (s *Service) setReadCache(ctx context.Context, values map[string]any) {
+	if s.readCache == nil || len(values) == 0 {
+		return
+	}
+
+	entries := make(map[string][]byte, len(values))
+	for key, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			log.Debug().Err(err).Str("key", key).Msg("Failed to encode value for read cache")
+			continue
+		}
+		entries[key] = data
+	}
+	if err := s.readCache.Set(ctx, entries, s.readCacheTTL); err != nil {
+		log.Debug().Err(err).Msg("Failed to store values in read cache")
+	}
+}
+
+// invalidateReadCache removes the given keys from the read cache once the current transaction commits.
+func (s *Service) invalidateReadCache(ctx context.Context, keys ...string) {
+	if s.readCache == nil {
+		return
+	}
+
+	invalidations, ok := ctx.Value(&ReadCacheInvalidations{}).(*readCacheInvalidations)
+	if !ok {
+		// No transaction to wait for.
+		s.deleteFromReadCache(ctx, keys)
+		return
+	}
+
+	invalidations.mutex.Lock()
+	for _, key := range keys {
+		invalidations.keys[key] = struct{}{}
+	}
+	invalidations.mutex.Unlock()
+}
+
+// applyReadCacheInvalidations removes the keys invalidated by a committed transaction from the read cache.
+func (s *Service) applyReadCacheInvalidations(ctx context.Context) {
+	invalidations, ok := ctx.Value(&ReadCacheInvalidations{}).(*readCacheInvalidations)
+	if !ok {
+		return
+	}
+
+	invalidations.mutex.Lock()
+	keys := make([]string, 0, len(invalidations.keys))
+	for key := range invalidations.keys {
+		keys = append(keys, key)
+	}
+	invalidations.keys = make(map[string]struct{})
+	invalidations.mutex.Unlock()
+
+	// Use a context that outlives the transaction, as the transaction's context is cancelled
+	// once it completes.
+	s.deleteFromReadCache(context.WithoutCancel(ctx), keys)
+}
+
+// deleteFromReadCache removes the given keys from the read cache.
+func (s *Service) deleteFromReadCache(ctx context.Context, keys []string) {
+	for start := 0; start < len(keys); start += readCacheInvalidationBatchSize {
+		end := start + readCacheInvalidationBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		if err := s.readCache.Delete(ctx, keys[start:end]); err != nil {
+			// Entries will expire on their own, so not fatal.
+			log.Warn().Err(err).Msg("Failed to remove values from read cache; they may be stale until they expire")
+		}
+	}
+}
+
+// monitorReadCacheLookup records a lookup in the read cache.
+func monitorReadCacheLookup(ctx context.Context, hit bool) {
+	if readCacheLookups == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	readCacheLookups.Add(ctx, 1, metric.WithAttributes(attribute.String("result", result)))
+}
@@ -0,0 +1,62 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	memorycache "github.com/wealdtech/chaind/services/cache/memory"
+)
+
+func TestCachedRead(t *testing.T) {
+	ctx := context.Background()
+
+	cache, err := memorycache.New(ctx)
+	require.NoError(t, err)
+	s := &Service{
+		readCache:    cache,
+		readCacheTTL: time.Minute,
+	}
+
+	reads := 0
+	read := func(_ context.Context) (map[string]string, error) {
+		reads++
+		return map[string]string{"SLOTS_PER_EPOCH": "32"}, nil
+	}
+
+	// First read goes to the database, second is served from the cache.
+	for range 2 {
+		value, err := cachedRead(ctx, s, chainSpecCacheKey, read)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"SLOTS_PER_EPOCH": "32"}, value)
+	}
+	require.Equal(t, 1, reads)
+
+	// Invalidation within a transaction is only applied once it commits.
+	txCtx := context.WithValue(ctx, &ReadCacheInvalidations{}, &readCacheInvalidations{
+		keys: make(map[string]struct{}),
+	})
+	s.invalidateReadCache(txCtx, chainSpecCacheKey)
+	_, err = cachedRead(ctx, s, chainSpecCacheKey, read)
+	require.NoError(t, err)
+	require.Equal(t, 1, reads)
+
+	s.applyReadCacheInvalidations(txCtx)
+	_, err = cachedRead(ctx, s, chainSpecCacheKey, read)
+	require.NoError(t, err)
+	require.Equal(t, 2, reads)
+}
@@ -18,21 +18,47 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"net/url"
+	"regexp"
 	"strings"
+	"time"
 
 	pgxdecimal "github.com/jackc/pgx-shopspring-decimal"
-	zerologadapter "github.com/jackc/pgx-zerolog"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/jackc/pgx/v5/tracelog"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/cache"
+	"github.com/wealdtech/chaind/services/coldstore"
+	"github.com/wealdtech/chaind/util"
 )
 
 // Service is a chain database service.
 type Service struct {
-	pool *pgxpool.Pool
+	pool                     *pgxpool.Pool
+	partitions               map[string]*pgxpool.Pool
+	compactAttestations      bool
+	coldStore                coldstore.Service
+	canonicalOnly            bool
+	concurrentIndexes        bool
+	externalSchema           bool
+	schema                   string
+	readOnlyRoles            []string
+	rowLevelSecurity         bool
+	validatorIndexCache      cache.Service
+	readCache                cache.Service
+	readCacheTTL             time.Duration
+	writeBatchSize           int
+	writeFlushInterval       time.Duration
+	timescaleMode            string
+	timescaleCompressAfter   uint64
+	timescaleAggregateEpochs uint64
+	specTypes                map[string]string
+	audit                    bool
+	auditRetention           time.Duration
+	sqlHooks                 map[string][]string
+	// module is the module to which writes are attributed in the audit log.
+	module string
 }
 
 // module-wide log.
@@ -46,115 +72,271 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "chaindb").Str("impl", "postgresql").Logger().Level(parameters.logLevel)
+	log = util.ServiceLogger("chaindb", "postgresql", parameters.logLevel)
 
-	var pool *pgxpool.Pool
+	var config *pgxpool.Config
 	if parameters.connectionURL != "" {
-		pool, err = newFromURL(ctx, parameters, log)
+		config, err = configFromURL(parameters)
 	} else {
-		pool, err = newFromComponents(ctx, parameters, log)
+		config, err = configFromComponents(parameters)
 	}
 	if err != nil {
 		return nil, err
 	}
+	if err := configurePool(config, parameters); err != nil {
+		return nil, err
+	}
+	if parameters.passwordProvider != nil {
+		config.BeforeConnect = beforeConnectPassword(parameters.passwordProvider)
+	}
 
-	go func() {
-		<-ctx.Done()
-		log.Trace().Msg("Context done; closing pool")
-		pool.Close()
-	}()
+	pool, err := newPool(ctx, config, "default")
+	if err != nil {
+		return nil, err
+	}
+	partitions := make(map[string]*pgxpool.Pool, len(parameters.poolPartitions))
+	for name, partition := range parameters.poolPartitions {
+		partitionPool, err := newPool(ctx, partitionConfig(config, partition), name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create pool partition %s", name)
+		}
+		partitions[name] = partitionPool
+	}
+	if err := registerQueryMetrics(); err != nil {
+		return nil, errors.Wrap(err, "failed to register query metrics")
+	}
+	if parameters.readCache != nil {
+		if err := registerReadCacheMetrics(); err != nil {
+			return nil, errors.Wrap(err, "failed to register read cache metrics")
+		}
+	}
+	if parameters.writeBatchSize > 1 {
+		if err := registerWriteQueueMetrics(); err != nil {
+			return nil, errors.Wrap(err, "failed to register write queue metrics")
+		}
+	}
 
 	s := &Service{
-		pool: pool,
+		pool:                     pool,
+		partitions:               partitions,
+		compactAttestations:      parameters.compactAttestations,
+		coldStore:                parameters.coldStore,
+		canonicalOnly:            parameters.canonicalOnly,
+		concurrentIndexes:        parameters.concurrentIndexes,
+		externalSchema:           parameters.externalSchema,
+		schema:                   parameters.schema,
+		readOnlyRoles:            parameters.readOnlyRoles,
+		rowLevelSecurity:         parameters.rowLevelSecurity,
+		validatorIndexCache:      parameters.validatorIndexCache,
+		readCache:                parameters.readCache,
+		readCacheTTL:             parameters.readCacheTTL,
+		writeBatchSize:           parameters.writeBatchSize,
+		writeFlushInterval:       parameters.writeFlushInterval,
+		timescaleMode:            parameters.timescaleMode,
+		timescaleCompressAfter:   parameters.timescaleCompressAfter,
+		timescaleAggregateEpochs: parameters.timescaleAggregateEpochs,
+		specTypes:                parameters.specTypes,
+		audit:                    parameters.audit,
+		auditRetention:           parameters.auditRetention,
+		sqlHooks:                 sqlHooksByEntity(parameters.sqlHooks),
+	}
+
+	if s.audit && s.auditRetention > 0 {
+		go s.pruneAuditLogPeriodically(ctx)
 	}
 
 	return s, nil
 }
 
-func newFromURL(ctx context.Context,
-	parameters *parameters,
-	log zerolog.Logger,
-) (
-	*pgxpool.Pool,
+func configFromURL(parameters *parameters) (
+	*pgxpool.Config,
 	error,
 ) {
 	// Use deprecated connection URL method.
-	config, err := pgxpool.ParseConfig(parameters.connectionURL)
+	connectionURL, err := stripChannelBinding(parameters.connectionURL)
+	if err != nil {
+		return nil, err
+	}
+	config, err := pgxpool.ParseConfig(connectionURL)
 	if err != nil {
 		return nil, errors.Wrap(err, "invalid connection URL")
 	}
 
 	config.AfterConnect = registerCustomTypes
 	config.MaxConns = int32(parameters.maxConnections)
-	config.ConnConfig.Tracer = &tracelog.TraceLog{Logger: zerologadapter.NewLogger(log)}
 
-	pool, err := pgxpool.NewWithConfig(ctx, config)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to connect to database")
-	}
-
-	return pool, nil
+	return config, nil
 }
 
-func newFromComponents(ctx context.Context,
-	parameters *parameters,
-	log zerolog.Logger,
-) (
-	*pgxpool.Pool,
+func configFromComponents(parameters *parameters) (
+	*pgxpool.Config,
 	error,
 ) {
 	dsnItems := make([]string, 0, 16)
 	dsnItems = append(dsnItems,
-		fmt.Sprintf("host=%s", parameters.server),
-		fmt.Sprintf("user=%s", parameters.user),
+		dsnItem("host", parameters.server),
+		dsnItem("user", parameters.user),
 	)
 	if parameters.password != "" {
-		dsnItems = append(dsnItems, fmt.Sprintf("password=%s", parameters.password))
+		dsnItems = append(dsnItems, dsnItem("password", parameters.password))
 	}
 	dsnItems = append(dsnItems, fmt.Sprintf("port=%d", parameters.port))
+	if parameters.database != "" {
+		dsnItems = append(dsnItems, dsnItem("dbname", parameters.database))
+	}
+
+	sslMode := parameters.sslMode
+	if sslMode == "" && (parameters.caCert != nil || parameters.clientCert != nil) {
+		sslMode = "verify-full"
+	}
+	if sslMode != "" {
+		dsnItems = append(dsnItems, dsnItem("sslmode", sslMode))
+	}
+
+	dsnItems = append(dsnItems, fmt.Sprintf("pool_max_conns=%d", parameters.maxConnections))
 
-	var tlsConfig *tls.Config
-	if parameters.caCert != nil || parameters.clientCert != nil {
-		dsnItems = append(dsnItems, "sslmode=verify-full")
+	config, err := pgxpool.ParseConfig(strings.Join(dsnItems, " "))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate pgx config")
+	}
+
+	if err := applyTLSCertificates(config, parameters); err != nil {
+		return nil, err
+	}
+
+	config.AfterConnect = registerCustomTypes
+
+	return config, nil
+}
 
-		// Add TLS configuration.
-		tlsConfig = &tls.Config{
-			ServerName: parameters.server,
-			MinVersion: tls.VersionTLS13,
+// beforeConnectPassword sets the password of each new connection from its provider.
+func beforeConnectPassword(provider PasswordProvider) func(context.Context, *pgx.ConnConfig) error {
+	return func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+		password, err := provider(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain database password")
 		}
+		connConfig.Password = password
+
+		return nil
+	}
+}
+
+// sslModes are the valid SSL modes, as per libpq.  A blank mode selects the default.
+var sslModes = map[string]struct{}{
+	"":            {},
+	"disable":     {},
+	"allow":       {},
+	"prefer":      {},
+	"require":     {},
+	"verify-ca":   {},
+	"verify-full": {},
+}
+
+// dsnItem returns a quoted key/value item for a connection string.
+func dsnItem(key string, value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+
+	return fmt.Sprintf("%s='%s'", key, value)
+}
+
+// applyTLSCertificates adds the supplied certificates to the TLS configuration
+// of each connection attempt made by the pool.
+func applyTLSCertificates(config *pgxpool.Config, parameters *parameters) error {
+	if parameters.caCert == nil && parameters.clientCert == nil {
+		return nil
 	}
+
+	tlsConfigs := make([]*tls.Config, 0, 1+len(config.ConnConfig.Fallbacks))
+	if config.ConnConfig.TLSConfig != nil {
+		tlsConfigs = append(tlsConfigs, config.ConnConfig.TLSConfig)
+	}
+	for _, fallback := range config.ConnConfig.Fallbacks {
+		if fallback.TLSConfig != nil {
+			tlsConfigs = append(tlsConfigs, fallback.TLSConfig)
+		}
+	}
+	if len(tlsConfigs) == 0 {
+		return errors.New("TLS certificates supplied but connection does not use TLS")
+	}
+
+	var certificates []tls.Certificate
 	if parameters.clientCert != nil {
 		clientPair, err := tls.X509KeyPair(parameters.clientCert, parameters.clientKey)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to create client certificate")
+			return errors.Wrap(err, "failed to create client certificate")
 		}
-		tlsConfig.Certificates = []tls.Certificate{clientPair}
+		certificates = []tls.Certificate{clientPair}
 	}
+	var rootCAs *x509.CertPool
 	if parameters.caCert != nil {
-		rootCAs := x509.NewCertPool()
+		rootCAs = x509.NewCertPool()
 		if !rootCAs.AppendCertsFromPEM(parameters.caCert) {
-			return nil, errors.New("failed to append root CA certificates")
+			return errors.New("failed to append root CA certificates")
 		}
-		tlsConfig.RootCAs = rootCAs
 	}
 
-	dsnItems = append(dsnItems, fmt.Sprintf("pool_max_conns=%d", parameters.maxConnections))
-
-	config, err := pgxpool.ParseConfig(strings.Join(dsnItems, " "))
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to generate pgx config")
+	for _, tlsConfig := range tlsConfigs {
+		tlsConfig.MinVersion = tls.VersionTLS13
+		if certificates != nil {
+			tlsConfig.Certificates = certificates
+		}
+		if rootCAs != nil {
+			tlsConfig.RootCAs = rootCAs
+		}
 	}
 
-	config.AfterConnect = registerCustomTypes
-	config.ConnConfig.TLSConfig = tlsConfig
-	config.ConnConfig.Tracer = &tracelog.TraceLog{Logger: zerologadapter.NewLogger(log)}
+	return nil
+}
 
-	pool, err := pgxpool.NewWithConfig(ctx, config)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to connect to database")
+// channelBindingRegex matches the channel_binding item of a keyword/value connection string.
+var channelBindingRegex = regexp.MustCompile(`(?:^|\s)channel_binding\s*=\s*('(?:[^'\\]|\\.)*'|\S*)`)
+
+// stripChannelBinding removes the channel_binding option from a connection string,
+// as it is not understood by the database driver.  Channel binding is optional
+// unless required, so an error is returned if it is required.
+func stripChannelBinding(connectionString string) (string, error) {
+	var channelBinding string
+	if strings.HasPrefix(connectionString, "postgres://") || strings.HasPrefix(connectionString, "postgresql://") {
+		connectionURL, err := url.Parse(connectionString)
+		if err != nil {
+			return "", errors.Wrap(err, "invalid connection URL")
+		}
+		query := connectionURL.Query()
+		if !query.Has("channel_binding") {
+			return connectionString, nil
+		}
+		channelBinding = query.Get("channel_binding")
+		query.Del("channel_binding")
+		connectionURL.RawQuery = query.Encode()
+		connectionString = connectionURL.String()
+	} else {
+		match := channelBindingRegex.FindStringSubmatch(connectionString)
+		if match == nil {
+			return connectionString, nil
+		}
+		channelBinding = strings.Trim(match[1], "'")
+		connectionString = strings.TrimSpace(strings.Replace(connectionString, match[0], "", 1))
 	}
 
-	return pool, nil
+	if err := checkChannelBinding(channelBinding); err != nil {
+		return "", err
+	}
+
+	return connectionString, nil
+}
+
+// checkChannelBinding checks that a channel binding mode can be satisfied.
+func checkChannelBinding(channelBinding string) error {
+	switch channelBinding {
+	case "", "disable", "prefer":
+		return nil
+	case "require":
+		return errors.New("channel binding is not supported by the database driver, so cannot be required")
+	default:
+		return fmt.Errorf("unknown channel binding mode %s", channelBinding)
+	}
 }
 
 // skipcq: RVV-B0012
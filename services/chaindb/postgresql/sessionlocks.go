@@ -0,0 +1,72 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// sessionLock is an advisory lock held by a dedicated connection.
+type sessionLock struct {
+	conn *pgxpool.Conn
+}
+
+// TrySessionLock attempts to obtain the named lock without waiting.
+// Returns nil if the lock is held elsewhere.
+//
+// The lock is a Postgres session-level advisory lock, and is held by a
+// connection that is removed from the pool for as long as the lock is held.
+func (s *Service) TrySessionLock(ctx context.Context, name string) (chaindb.SessionLock, error) {
+	ctx, span := startSpan(ctx, "TrySessionLock")
+	defer span.End()
+
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to acquire connection")
+	}
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtextextended($1,0))`, s.lockName(name)).Scan(&acquired); err != nil {
+		conn.Release()
+		return nil, errors.Wrap(err, "failed to obtain advisory lock")
+	}
+	if !acquired {
+		conn.Release()
+		return nil, nil
+	}
+
+	return &sessionLock{
+		conn: conn,
+	}, nil
+}
+
+// Check returns an error if the session holding the lock has been lost.
+func (l *sessionLock) Check(ctx context.Context) error {
+	if _, err := l.conn.Exec(ctx, `SELECT 1`); err != nil {
+		return errors.Wrap(err, "session holding lock unavailable")
+	}
+
+	return nil
+}
+
+// Release releases the lock.
+func (l *sessionLock) Release(ctx context.Context) error {
+	// Closing the connection ends the session, which releases the lock
+	// regardless of the state of the connection.
+	return l.conn.Hijack().Close(ctx)
+}
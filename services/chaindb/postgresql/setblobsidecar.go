@@ -18,12 +18,11 @@ import (
 	"context"
 
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // SetBlobSidecar sets a blob sidecar.
 func (s *Service) SetBlobSidecar(ctx context.Context, blobSidecar *chaindb.BlobSidecar) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetBlobSidecar")
+	ctx, span := startSpan(ctx, "SetBlobSidecar")
 	defer span.End()
 
 	tx := s.tx(ctx)
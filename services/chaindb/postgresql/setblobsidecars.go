@@ -20,12 +20,11 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // SetBlobSidecars sets blob sidecars.
 func (s *Service) SetBlobSidecars(ctx context.Context, blobSidecars []*chaindb.BlobSidecar) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetBlobSidecars")
+	ctx, span := startSpan(ctx, "SetBlobSidecars")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -0,0 +1,190 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetShufflingMismatches sets shuffling mismatches.
+// An existing mismatch for the same slot and index is replaced.
+func (s *Service) SetShufflingMismatches(ctx context.Context, mismatches []*chaindb.ShufflingMismatch) error {
+	ctx, span := startSpan(ctx, "SetShufflingMismatches")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, mismatch := range mismatches {
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_shuffling_mismatches(f_slot
+                                  ,f_index
+                                  ,f_committee
+                                  ,f_expected_committee
+                                  ,f_detected
+                                  )
+VALUES($1,$2,$3,$4,$5)
+ON CONFLICT (f_slot,f_index) DO
+UPDATE
+SET f_committee = excluded.f_committee
+   ,f_expected_committee = excluded.f_expected_committee
+   ,f_detected = excluded.f_detected
+`,
+			mismatch.Slot,
+			mismatch.Index,
+			mismatch.Committee,
+			mismatch.ExpectedCommittee,
+			mismatch.Detected,
+		); err != nil {
+			return errors.Wrapf(err, "failed to set shuffling mismatch for slot %d index %d", mismatch.Slot, mismatch.Index)
+		}
+	}
+
+	return nil
+}
+
+// ShufflingMismatches provides shuffling mismatches according to the filter.
+func (s *Service) ShufflingMismatches(ctx context.Context,
+	filter *chaindb.ShufflingMismatchFilter,
+) (
+	[]*chaindb.ShufflingMismatch,
+	error,
+) {
+	ctx, span := startSpan(ctx, "ShufflingMismatches")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_slot
+      ,f_index
+      ,f_committee
+      ,f_expected_committee
+      ,f_detected
+FROM t_shuffling_mismatches`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot <= $%d`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot,f_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC,f_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mismatches := make([]*chaindb.ShufflingMismatch, 0)
+	for rows.Next() {
+		mismatch := &chaindb.ShufflingMismatch{}
+		var committee []uint64
+		var expectedCommittee []uint64
+		err := rows.Scan(
+			&mismatch.Slot,
+			&mismatch.Index,
+			&committee,
+			&expectedCommittee,
+			&mismatch.Detected,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		mismatch.Committee = validatorIndicesFromUint64s(committee)
+		mismatch.ExpectedCommittee = validatorIndicesFromUint64s(expectedCommittee)
+		mismatches = append(mismatches, mismatch)
+	}
+
+	// Always return order of slot then index.
+	sort.Slice(mismatches, func(i int, j int) bool {
+		if mismatches[i].Slot != mismatches[j].Slot {
+			return mismatches[i].Slot < mismatches[j].Slot
+		}
+		return mismatches[i].Index < mismatches[j].Index
+	})
+
+	return mismatches, nil
+}
+
+// validatorIndicesFromUint64s converts database values to validator indices,
+// retaining nil.
+func validatorIndicesFromUint64s(vals []uint64) []phase0.ValidatorIndex {
+	if vals == nil {
+		return nil
+	}
+	res := make([]phase0.ValidatorIndex, len(vals))
+	for i := range vals {
+		res[i] = phase0.ValidatorIndex(vals[i])
+	}
+
+	return res
+}
@@ -0,0 +1,521 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+// SnapshotFormatVersion is the version of the format of snapshots created.
+const SnapshotFormatVersion = 1
+
+// snapshotManifestFile is the name of the file that describes a snapshot.
+const snapshotManifestFile = "manifest.json"
+
+// snapshotExcludedTables are tables whose contents relate to a single
+// database rather than to the chain, so are not held in snapshots.
+var snapshotExcludedTables = map[string]bool{
+	"t_failed_items":      true,
+	"t_outbox":            true,
+	"t_schema_history":    true,
+	"t_schema_migrations": true,
+}
+
+// SnapshotManifest describes a snapshot of the database.
+type SnapshotManifest struct {
+	FormatVersion uint64    `json:"format_version"`
+	SchemaVersion uint64    `json:"schema_version"`
+	Created       time.Time `json:"created"`
+	// GenesisValidatorsRoot identifies the chain of the snapshot.
+	GenesisValidatorsRoot string `json:"genesis_validators_root,omitempty"`
+	// LatestCanonicalSlot and LatestCanonicalRoot are those of the latest
+	// canonical block in the snapshot.
+	LatestCanonicalSlot uint64 `json:"latest_canonical_slot,omitempty"`
+	LatestCanonicalRoot string `json:"latest_canonical_root,omitempty"`
+	// Tables are in the order in which they are restored.
+	Tables []*SnapshotTable `json:"tables"`
+}
+
+// SnapshotTable describes the dump of a table in a snapshot.
+type SnapshotTable struct {
+	Name    string   `json:"name"`
+	File    string   `json:"file"`
+	Columns []string `json:"columns"`
+	Rows    int64    `json:"rows"`
+	// SHA256 is the hash of the compressed file.
+	SHA256 string `json:"sha256"`
+}
+
+// CreateSnapshot writes a consistent snapshot of the tables in the database
+// to the given directory, as a compressed dump of each table along with a
+// manifest that describes them.  The manifest is written last, so a snapshot
+// without a manifest is incomplete.
+func (s *Service) CreateSnapshot(ctx context.Context, dir string) (*SnapshotManifest, error) {
+	ctx, span := startSpan(ctx, "CreateSnapshot")
+	defer span.End()
+
+	if _, err := os.Stat(filepath.Join(dir, snapshotManifestFile)); err == nil {
+		return nil, fmt.Errorf("directory %s already holds a snapshot", dir)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrap(err, "failed to create snapshot directory")
+	}
+
+	schemaVersion, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain schema version")
+	}
+	if schemaVersion == 0 {
+		return nil, errors.New("database has no schema")
+	}
+
+	// All tables are read in the same repeatable read transaction, so they
+	// are consistent with each other.
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(context.WithoutCancel(ctx)); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Warn().Err(err).Msg("Failed to end snapshot transaction")
+		}
+	}()
+	ctx = context.WithValue(ctx, &Tx{}, tx)
+	if _, err := tx.Exec(ctx, "SET LOCAL statement_timeout = 0"); err != nil {
+		return nil, errors.Wrap(err, "failed to disable statement timeout")
+	}
+
+	manifest := &SnapshotManifest{
+		FormatVersion: SnapshotFormatVersion,
+		SchemaVersion: schemaVersion,
+		Created:       time.Now().UTC().Truncate(time.Second),
+		Tables:        make([]*SnapshotTable, 0),
+	}
+	if err := s.snapshotChain(ctx, manifest); err != nil {
+		return nil, err
+	}
+
+	tables, err := s.snapshotTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range tables {
+		table, err := s.snapshotTable(ctx, dir, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to snapshot %s", name)
+		}
+		manifest.Tables = append(manifest.Tables, table)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal manifest")
+	}
+	if err := os.WriteFile(filepath.Join(dir, snapshotManifestFile), append(data, '\n'), 0o600); err != nil {
+		return nil, errors.Wrap(err, "failed to write manifest")
+	}
+
+	return manifest, nil
+}
+
+// snapshotChain records the chain of the snapshot in the manifest.
+func (s *Service) snapshotChain(ctx context.Context, manifest *SnapshotManifest) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	var genesisValidatorsRoot []byte
+	err := tx.QueryRow(ctx, `SELECT f_validators_root FROM t_genesis`).Scan(&genesisValidatorsRoot)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// Genesis is not yet known.
+	case err != nil:
+		return errors.Wrap(err, "failed to obtain genesis")
+	default:
+		manifest.GenesisValidatorsRoot = fmt.Sprintf("%#x", genesisValidatorsRoot)
+	}
+
+	var root []byte
+	err = tx.QueryRow(ctx, `
+SELECT f_slot
+      ,f_root
+FROM t_blocks
+WHERE f_canonical = true
+ORDER BY f_slot DESC
+LIMIT 1`).Scan(&manifest.LatestCanonicalSlot, &root)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// No canonical blocks.
+	case err != nil:
+		return errors.Wrap(err, "failed to obtain latest canonical block")
+	default:
+		manifest.LatestCanonicalRoot = fmt.Sprintf("%#x", root)
+	}
+
+	return nil
+}
+
+// snapshotTables provides the tables held in a snapshot, ordered such that
+// tables are restored after the tables that they reference.
+func (s *Service) snapshotTables(ctx context.Context) ([]string, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return nil, ErrNoTransaction
+	}
+
+	tables, err := s.schemaTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(tables))
+	for _, table := range tables {
+		if !snapshotExcludedTables[table.name] {
+			names = append(names, table.name)
+		}
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT src.relname
+      ,dst.relname
+FROM pg_constraint
+JOIN pg_class src ON src.oid = pg_constraint.conrelid
+JOIN pg_class dst ON dst.oid = pg_constraint.confrelid
+WHERE pg_constraint.contype = 'f'
+  AND src.relnamespace = (SELECT oid FROM pg_namespace WHERE nspname = current_schema())`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain table references")
+	}
+	defer rows.Close()
+	references := make(map[string][]string)
+	for rows.Next() {
+		var table string
+		var referenced string
+		if err := rows.Scan(&table, &referenced); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		references[table] = append(references[table], referenced)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orderSnapshotTables(names, references), nil
+}
+
+// orderSnapshotTables orders tables such that each table comes after those
+// that it references, and otherwise by name.
+func orderSnapshotTables(tables []string, references map[string][]string) []string {
+	remaining := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		remaining[table] = true
+	}
+
+	res := make([]string, 0, len(tables))
+	for len(remaining) > 0 {
+		ready := make([]string, 0)
+		for table := range remaining {
+			blocked := false
+			for _, referenced := range references[table] {
+				if referenced != table && remaining[referenced] {
+					blocked = true
+					break
+				}
+			}
+			if !blocked {
+				ready = append(ready, table)
+			}
+		}
+		if len(ready) == 0 {
+			// A cycle of references; take the remaining tables in order of name.
+			for table := range remaining {
+				ready = append(ready, table)
+			}
+		}
+		sort.Strings(ready)
+		for _, table := range ready {
+			delete(remaining, table)
+		}
+		res = append(res, ready...)
+	}
+
+	return res
+}
+
+// snapshotColumns provides the columns of a table.
+func (s *Service) snapshotColumns(ctx context.Context, table string) ([]string, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return nil, ErrNoTransaction
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT column_name
+FROM information_schema.columns
+WHERE table_schema = current_schema()
+  AND table_name = $1
+  AND is_generated = 'NEVER'
+ORDER BY ordinal_position`,
+		table,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain columns")
+	}
+	defer rows.Close()
+
+	columns := make([]string, 0)
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
+
+// snapshotTable writes the compressed dump of a table.
+func (s *Service) snapshotTable(ctx context.Context, dir string, name string) (*SnapshotTable, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return nil, ErrNoTransaction
+	}
+
+	columns, err := s.snapshotColumns(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	table := &SnapshotTable{
+		Name:    name,
+		File:    fmt.Sprintf("%s.copy.gz", name),
+		Columns: columns,
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, table.File), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create file")
+	}
+	defer file.Close()
+	hasher := sha256.New()
+	writer := gzip.NewWriter(io.MultiWriter(file, hasher))
+
+	log.Info().Str("table", name).Msg("Writing table to snapshot")
+	// Selecting, rather than copying the table directly, includes the rows
+	// of partitions.
+	tag, err := tx.Conn().PgConn().CopyTo(ctx, writer, fmt.Sprintf("COPY (SELECT %s FROM %s) TO STDOUT",
+		snapshotColumnList(columns),
+		pgx.Identifier{name}.Sanitize(),
+	))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to copy rows")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to compress rows")
+	}
+	if err := file.Sync(); err != nil {
+		return nil, errors.Wrap(err, "failed to write file")
+	}
+	table.Rows = tag.RowsAffected()
+	table.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+
+	return table, nil
+}
+
+// ReadSnapshotManifest reads the manifest of the snapshot in the given directory.
+func ReadSnapshotManifest(dir string) (*SnapshotManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotManifestFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read manifest")
+	}
+	manifest := &SnapshotManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, errors.Wrap(err, "failed to parse manifest")
+	}
+	if manifest.FormatVersion != SnapshotFormatVersion {
+		return nil, fmt.Errorf("snapshot format version %d is not supported; expected %d", manifest.FormatVersion, SnapshotFormatVersion)
+	}
+	for _, table := range manifest.Tables {
+		if table.File != filepath.Base(table.File) {
+			return nil, fmt.Errorf("file %s of table %s is not in the snapshot directory", table.File, table.Name)
+		}
+	}
+
+	return manifest, nil
+}
+
+// RestoreSnapshot restores the snapshot in the given directory to the
+// database, which must have the same schema version as the snapshot and hold
+// no blocks.  The snapshot is restored in a single transaction, and the
+// hash and number of rows of each table are checked against the manifest,
+// so the database is unchanged if the snapshot is damaged.
+func (s *Service) RestoreSnapshot(ctx context.Context, dir string) (*SnapshotManifest, error) {
+	ctx, span := startSpan(ctx, "RestoreSnapshot")
+	defer span.End()
+
+	manifest, err := ReadSnapshotManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaVersion, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain schema version")
+	}
+	if schemaVersion != manifest.SchemaVersion {
+		return nil, fmt.Errorf("snapshot has schema version %d but the database has version %d; restore with a release that uses version %d, then upgrade", manifest.SchemaVersion, schemaVersion, manifest.SchemaVersion)
+	}
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.restoreSnapshot(ctx, dir, manifest); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if err := s.CommitTx(ctx); err != nil {
+		cancel()
+		return nil, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return manifest, nil
+}
+
+func (s *Service) restoreSnapshot(ctx context.Context, dir string, manifest *SnapshotManifest) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, "SET LOCAL statement_timeout = 0"); err != nil {
+		return errors.Wrap(err, "failed to disable statement timeout")
+	}
+
+	var hasBlocks bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM t_blocks)`).Scan(&hasBlocks); err != nil {
+		return errors.Wrap(err, "failed to check for blocks")
+	}
+	if hasBlocks {
+		return errors.New("database already holds blocks; snapshots can only be restored to a new database")
+	}
+
+	// Remove the rows that chaind creates in a new database, such as its
+	// metadata, which are replaced by those in the snapshot.
+	identifiers := make([]string, 0, len(manifest.Tables))
+	for _, table := range manifest.Tables {
+		identifiers = append(identifiers, pgx.Identifier{table.Name}.Sanitize())
+	}
+	if len(identifiers) > 0 {
+		if _, err := tx.Exec(ctx, fmt.Sprintf("TRUNCATE %s", strings.Join(identifiers, ","))); err != nil {
+			return errors.Wrap(err, "failed to truncate tables")
+		}
+	}
+
+	for _, table := range manifest.Tables {
+		if err := s.restoreSnapshotTable(ctx, dir, table); err != nil {
+			return errors.Wrapf(err, "failed to restore %s", table.Name)
+		}
+	}
+
+	return nil
+}
+
+// restoreSnapshotTable restores a table from its compressed dump.
+func (s *Service) restoreSnapshotTable(ctx context.Context, dir string, table *SnapshotTable) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	file, err := os.Open(filepath.Join(dir, table.File))
+	if err != nil {
+		return errors.Wrap(err, "failed to open file")
+	}
+	defer file.Close()
+	hasher := sha256.New()
+	reader, err := gzip.NewReader(io.TeeReader(file, hasher))
+	if err != nil {
+		return errors.Wrap(err, "failed to decompress file")
+	}
+	defer reader.Close()
+
+	identifier := pgx.Identifier{table.Name}.Sanitize()
+	// Triggers, such as those that capture changes for publication, are not
+	// fired for restored rows.
+	if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s DISABLE TRIGGER USER", identifier)); err != nil {
+		return errors.Wrap(err, "failed to disable triggers")
+	}
+
+	log.Info().Str("table", table.Name).Int64("rows", table.Rows).Msg("Restoring table from snapshot")
+	tag, err := tx.Conn().PgConn().CopyFrom(ctx, reader, fmt.Sprintf("COPY %s(%s) FROM STDIN",
+		identifier,
+		snapshotColumnList(table.Columns),
+	))
+	if err != nil {
+		return errors.Wrap(err, "failed to copy rows")
+	}
+	if err := checkSnapshotHash(file, hasher, table.SHA256); err != nil {
+		return err
+	}
+	if tag.RowsAffected() != table.Rows {
+		return fmt.Errorf("restored %d rows but the manifest lists %d", tag.RowsAffected(), table.Rows)
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s ENABLE TRIGGER USER", identifier)); err != nil {
+		return errors.Wrap(err, "failed to enable triggers")
+	}
+
+	return nil
+}
+
+// checkSnapshotHash checks the hash of a file, reading any of the file that
+// was not required to decompress it.
+func checkSnapshotHash(file io.Reader, hasher hash.Hash, expected string) error {
+	if _, err := io.Copy(hasher, file); err != nil {
+		return errors.Wrap(err, "failed to read file")
+	}
+	if actual := hex.EncodeToString(hasher.Sum(nil)); actual != expected {
+		return fmt.Errorf("file has hash %s but the manifest lists %s", actual, expected)
+	}
+
+	return nil
+}
+
+// snapshotColumnList provides the sanitized list of columns.
+func snapshotColumnList(columns []string) string {
+	identifiers := make([]string, len(columns))
+	for i := range columns {
+		identifiers[i] = pgx.Identifier{columns[i]}.Sanitize()
+	}
+
+	return strings.Join(identifiers, ",")
+}
@@ -0,0 +1,162 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderSnapshotTables(t *testing.T) {
+	tests := []struct {
+		name       string
+		tables     []string
+		references map[string][]string
+		expected   []string
+	}{
+		{
+			name:     "Empty",
+			tables:   []string{},
+			expected: []string{},
+		},
+		{
+			name:     "NoReferences",
+			tables:   []string{"t_c", "t_a", "t_b"},
+			expected: []string{"t_a", "t_b", "t_c"},
+		},
+		{
+			name:   "References",
+			tables: []string{"t_attestations", "t_blocks", "t_block_withdrawals", "t_genesis"},
+			references: map[string][]string{
+				"t_attestations":      {"t_blocks"},
+				"t_block_withdrawals": {"t_blocks"},
+			},
+			expected: []string{"t_blocks", "t_genesis", "t_attestations", "t_block_withdrawals"},
+		},
+		{
+			name:   "Chain",
+			tables: []string{"t_a", "t_b", "t_c"},
+			references: map[string][]string{
+				"t_a": {"t_b"},
+				"t_b": {"t_c"},
+			},
+			expected: []string{"t_c", "t_b", "t_a"},
+		},
+		{
+			name:   "SelfReference",
+			tables: []string{"t_a", "t_b"},
+			references: map[string][]string{
+				"t_a": {"t_a", "t_b"},
+			},
+			expected: []string{"t_b", "t_a"},
+		},
+		{
+			name:   "ExcludedReference",
+			tables: []string{"t_a"},
+			references: map[string][]string{
+				"t_a": {"t_outbox"},
+			},
+			expected: []string{"t_a"},
+		},
+		{
+			name:   "Cycle",
+			tables: []string{"t_a", "t_b", "t_c"},
+			references: map[string][]string{
+				"t_a": {"t_b"},
+				"t_b": {"t_a"},
+			},
+			expected: []string{"t_c", "t_a", "t_b"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, orderSnapshotTables(test.tables, test.references))
+		})
+	}
+}
+
+func TestReadSnapshotManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		err      string
+	}{
+		{
+			name: "Missing",
+			err:  "failed to read manifest",
+		},
+		{
+			name:     "Invalid",
+			manifest: `{`,
+			err:      "failed to parse manifest",
+		},
+		{
+			name:     "FormatVersion",
+			manifest: `{"format_version":2,"schema_version":47,"tables":[]}`,
+			err:      "snapshot format version 2 is not supported; expected 1",
+		},
+		{
+			name:     "FileOutsideDirectory",
+			manifest: `{"format_version":1,"schema_version":47,"tables":[{"name":"t_blocks","file":"../t_blocks.copy.gz"}]}`,
+			err:      "file ../t_blocks.copy.gz of table t_blocks is not in the snapshot directory",
+		},
+		{
+			name:     "Good",
+			manifest: `{"format_version":1,"schema_version":47,"latest_canonical_slot":12,"tables":[{"name":"t_blocks","file":"t_blocks.copy.gz","columns":["f_slot"],"rows":3}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if test.manifest != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, snapshotManifestFile), []byte(test.manifest), 0o600))
+			}
+			manifest, err := ReadSnapshotManifest(dir)
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, uint64(47), manifest.SchemaVersion)
+			require.Equal(t, uint64(12), manifest.LatestCanonicalSlot)
+			require.Len(t, manifest.Tables, 1)
+			require.Equal(t, int64(3), manifest.Tables[0].Rows)
+		})
+	}
+}
+
+func TestCheckSnapshotHash(t *testing.T) {
+	data := []byte("snapshot data")
+	sum := sha256.Sum256(data)
+
+	// Hash the start of the data, as if read by the decompressor.
+	hasher := sha256.New()
+	hasher.Write(data[:4])
+	require.NoError(t, checkSnapshotHash(bytes.NewReader(data[4:]), hasher, hex.EncodeToString(sum[:])))
+
+	hasher = sha256.New()
+	require.ErrorContains(t, checkSnapshotHash(bytes.NewReader(data[1:]), hasher, hex.EncodeToString(sum[:])), "but the manifest lists")
+}
+
+func TestSnapshotColumnList(t *testing.T) {
+	require.Equal(t, `"f_slot","f_root"`, snapshotColumnList([]string{"f_slot", "f_root"}))
+}
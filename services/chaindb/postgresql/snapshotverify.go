@@ -0,0 +1,360 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// snapshotVerificationKey is the metadata key for the result of verifying a
+// restored snapshot.
+const snapshotVerificationKey = "snapshot.verification"
+
+// VerifySnapshotOpts are the options for verifying a restored snapshot.
+type VerifySnapshotOpts struct {
+	// GenesisProvider provides the genesis of the chain from a trusted beacon node.
+	GenesisProvider eth2client.GenesisProvider
+	// BeaconBlockRootProvider provides block roots from a trusted beacon node.
+	BeaconBlockRootProvider eth2client.BeaconBlockRootProvider
+	// SpotChecks is the number of canonical blocks, in addition to the
+	// first and latest, whose roots are checked against the beacon node.
+	SpotChecks int
+}
+
+// SnapshotVerification is the result of verifying a restored snapshot.
+type SnapshotVerification struct {
+	Verified              time.Time            `json:"verified"`
+	GenesisValidatorsRoot string               `json:"genesis_validators_root"`
+	Blocks                uint64               `json:"blocks"`
+	FirstSlot             phase0.Slot          `json:"first_slot"`
+	LatestSlot            phase0.Slot          `json:"latest_slot"`
+	SpotChecks            []*SnapshotSpotCheck `json:"spot_checks"`
+	Passed                bool                 `json:"passed"`
+	Failure               string               `json:"failure,omitempty"`
+}
+
+// SnapshotSpotCheck is the check of a single block root against a beacon node.
+type SnapshotSpotCheck struct {
+	Slot          phase0.Slot `json:"slot"`
+	Root          string      `json:"root"`
+	ReferenceRoot string      `json:"reference_root,omitempty"`
+	Matched       bool        `json:"matched"`
+}
+
+// VerifySnapshot verifies the canonical chain in the database, as restored
+// from a snapshot that may have come from an untrusted source.  The canonical
+// blocks are walked from first to latest to confirm that each is the parent
+// of the next, so that the chain is linked to its latest block, and the
+// roots of the first, latest and a random sample of other canonical blocks
+// are checked against a trusted beacon node.  The result is recorded in the
+// database's metadata as well as being returned.
+//
+// An error is returned if verification could not be carried out; a snapshot
+// that fails verification is reported in the result.
+func (s *Service) VerifySnapshot(ctx context.Context, opts *VerifySnapshotOpts) (*SnapshotVerification, error) {
+	ctx, span := startSpan(ctx, "VerifySnapshot")
+	defer span.End()
+
+	if opts == nil || opts.GenesisProvider == nil || opts.BeaconBlockRootProvider == nil {
+		return nil, errors.New("beacon node providers are required to verify a snapshot")
+	}
+	if opts.SpotChecks < 0 {
+		return nil, errors.New("spot checks cannot be negative")
+	}
+
+	res := &SnapshotVerification{
+		Verified:   time.Now().UTC().Truncate(time.Second),
+		SpotChecks: make([]*SnapshotSpotCheck, 0),
+	}
+
+	failure, err := s.verifySnapshot(ctx, opts, res)
+	if err != nil {
+		return nil, err
+	}
+	if failure != "" {
+		log.Warn().Str("failure", failure).Msg("Snapshot failed verification")
+		res.Failure = failure
+	} else {
+		res.Passed = true
+	}
+
+	if err := s.setSnapshotVerification(ctx, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// verifySnapshot carries out the verification, returning the reason that the
+// snapshot failed verification if it did.
+func (s *Service) verifySnapshot(ctx context.Context, opts *VerifySnapshotOpts, res *SnapshotVerification) (string, error) {
+	genesisResponse, err := s.Genesis(ctx, &api.GenesisOpts{})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to obtain genesis from database")
+	}
+	if genesisResponse.Data == nil {
+		return "database has no genesis", nil
+	}
+	res.GenesisValidatorsRoot = fmt.Sprintf("%#x", genesisResponse.Data.GenesisValidatorsRoot)
+	referenceGenesisResponse, err := opts.GenesisProvider.Genesis(ctx, &api.GenesisOpts{})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to obtain genesis from beacon node")
+	}
+	if !bytes.Equal(genesisResponse.Data.GenesisValidatorsRoot[:], referenceGenesisResponse.Data.GenesisValidatorsRoot[:]) {
+		return fmt.Sprintf("database has genesis validators root %#x but the beacon node has %#x",
+			genesisResponse.Data.GenesisValidatorsRoot,
+			referenceGenesisResponse.Data.GenesisValidatorsRoot,
+		), nil
+	}
+
+	walker := newBlockChainWalker(opts.SpotChecks, rand.New(rand.NewSource(time.Now().UnixNano())))
+	failure, err := s.walkCanonicalBlocks(ctx, walker)
+	if err != nil {
+		return "", err
+	}
+	res.Blocks = walker.blocks
+	if walker.blocks == 0 {
+		return "database has no canonical blocks", nil
+	}
+	res.FirstSlot = walker.first.slot
+	res.LatestSlot = walker.previous.slot
+	if failure != "" {
+		return failure, nil
+	}
+
+	for _, link := range walker.spotChecks() {
+		check := &SnapshotSpotCheck{
+			Slot: link.slot,
+			Root: fmt.Sprintf("%#x", link.root),
+		}
+		res.SpotChecks = append(res.SpotChecks, check)
+		referenceRoot, err := s.snapshotReferenceRoot(ctx, opts.BeaconBlockRootProvider, link.slot)
+		if err != nil {
+			return "", err
+		}
+		if referenceRoot == nil {
+			return fmt.Sprintf("beacon node has no block at slot %d", link.slot), nil
+		}
+		check.ReferenceRoot = fmt.Sprintf("%#x", *referenceRoot)
+		if !bytes.Equal(link.root[:], referenceRoot[:]) {
+			return fmt.Sprintf("database has block %#x at slot %d but the beacon node has %#x", link.root, link.slot, *referenceRoot), nil
+		}
+		check.Matched = true
+	}
+
+	return "", nil
+}
+
+// walkCanonicalBlocks passes the canonical blocks to the walker in order of
+// slot, returning the reason that the chain is broken if it is.
+func (s *Service) walkCanonicalBlocks(ctx context.Context, walker *blockChainWalker) (string, error) {
+	ctx, err := s.BeginROTx(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer s.CommitROTx(ctx)
+	tx := s.tx(ctx)
+
+	rows, err := tx.Query(ctx, `
+SELECT f_slot
+      ,f_root
+      ,f_parent_root
+FROM t_blocks
+WHERE f_canonical = true
+ORDER BY f_slot`)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to obtain canonical blocks")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		link := &blockChainLink{}
+		var root []byte
+		var parentRoot []byte
+		if err := rows.Scan(&link.slot, &root, &parentRoot); err != nil {
+			return "", errors.Wrap(err, "failed to scan row")
+		}
+		copy(link.root[:], root)
+		copy(link.parentRoot[:], parentRoot)
+		if failure := walker.add(link); failure != "" {
+			return failure, nil
+		}
+	}
+
+	return "", rows.Err()
+}
+
+// snapshotReferenceRoot obtains the root of the block at the given slot from
+// the beacon node, or nil if there is no block at the slot.
+func (*Service) snapshotReferenceRoot(ctx context.Context,
+	provider eth2client.BeaconBlockRootProvider,
+	slot phase0.Slot,
+) (
+	*phase0.Root,
+	error,
+) {
+	response, err := provider.BeaconBlockRoot(ctx, &api.BeaconBlockRootOpts{
+		Block: fmt.Sprintf("%d", slot),
+	})
+	if err != nil {
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+
+		return nil, errors.Wrapf(err, "failed to obtain block root for slot %d from beacon node", slot)
+	}
+
+	return response.Data, nil
+}
+
+// setSnapshotVerification records the result of verifying a snapshot.
+func (s *Service) setSnapshotVerification(ctx context.Context, verification *SnapshotVerification) error {
+	data, err := json.Marshal(verification)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal verification")
+	}
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := s.SetMetadata(ctx, snapshotVerificationKey, data); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to record verification")
+	}
+	if err := s.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// SnapshotVerification provides the recorded result of verifying a restored
+// snapshot, or nil if the database has not been verified.
+func (s *Service) SnapshotVerification(ctx context.Context) (*SnapshotVerification, error) {
+	data, err := s.Metadata(ctx, snapshotVerificationKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain verification")
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	res := &SnapshotVerification{}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal verification")
+	}
+
+	return res, nil
+}
+
+// blockChainLink is a canonical block in the chain.
+type blockChainLink struct {
+	slot       phase0.Slot
+	root       phase0.Root
+	parentRoot phase0.Root
+}
+
+// blockChainWalker checks that each canonical block is the parent of the
+// next, sampling blocks to be checked against a beacon node as it goes.
+type blockChainWalker struct {
+	blocks     uint64
+	first      *blockChainLink
+	previous   *blockChainLink
+	maxSamples int
+	samples    []*blockChainLink
+	rng        *rand.Rand
+}
+
+func newBlockChainWalker(maxSamples int, rng *rand.Rand) *blockChainWalker {
+	return &blockChainWalker{
+		maxSamples: maxSamples,
+		samples:    make([]*blockChainLink, 0, maxSamples),
+		rng:        rng,
+	}
+}
+
+// add adds the next canonical block, returning the reason that the chain is
+// broken if it does not follow the previous block.  The first block is not
+// checked, as the database may start after genesis.
+func (w *blockChainWalker) add(link *blockChainLink) string {
+	if w.previous == nil {
+		w.first = link
+	} else {
+		if link.slot == w.previous.slot {
+			return fmt.Sprintf("database has multiple canonical blocks at slot %d", link.slot)
+		}
+		if !bytes.Equal(link.parentRoot[:], w.previous.root[:]) {
+			return fmt.Sprintf("canonical block %#x at slot %d has parent %#x but the previous canonical block is %#x at slot %d",
+				link.root,
+				link.slot,
+				link.parentRoot,
+				w.previous.root,
+				w.previous.slot,
+			)
+		}
+	}
+	w.blocks++
+
+	// Reservoir sample, so that every block is equally likely to be checked.
+	if len(w.samples) < w.maxSamples {
+		w.samples = append(w.samples, link)
+	} else if w.maxSamples > 0 {
+		if i := w.rng.Int63n(int64(w.blocks)); i < int64(w.maxSamples) {
+			w.samples[i] = link
+		}
+	}
+	w.previous = link
+
+	return ""
+}
+
+// spotChecks provides the blocks to be checked against a beacon node: the
+// first and latest blocks and the sampled blocks, in order of slot.
+func (w *blockChainWalker) spotChecks() []*blockChainLink {
+	if w.first == nil {
+		return []*blockChainLink{}
+	}
+
+	links := make(map[phase0.Slot]*blockChainLink, len(w.samples)+2)
+	links[w.first.slot] = w.first
+	links[w.previous.slot] = w.previous
+	for _, link := range w.samples {
+		links[link.slot] = link
+	}
+
+	res := make([]*blockChainLink, 0, len(links))
+	for _, link := range links {
+		res = append(res, link)
+	}
+	sort.Slice(res, func(i int, j int) bool {
+		return res[i].slot < res[j].slot
+	})
+
+	return res
+}
@@ -0,0 +1,124 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+func testChainLink(slot phase0.Slot, root byte, parentRoot byte) *blockChainLink {
+	return &blockChainLink{
+		slot:       slot,
+		root:       phase0.Root{root},
+		parentRoot: phase0.Root{parentRoot},
+	}
+}
+
+func TestBlockChainWalker(t *testing.T) {
+	tests := []struct {
+		name    string
+		links   []*blockChainLink
+		failure string
+		blocks  uint64
+	}{
+		{
+			name:   "Empty",
+			links:  []*blockChainLink{},
+			blocks: 0,
+		},
+		{
+			name: "Linked",
+			links: []*blockChainLink{
+				testChainLink(0, 0x01, 0x00),
+				testChainLink(1, 0x02, 0x01),
+				testChainLink(3, 0x03, 0x02),
+			},
+			blocks: 3,
+		},
+		{
+			name: "StartsAfterGenesis",
+			links: []*blockChainLink{
+				testChainLink(100, 0x01, 0xff),
+				testChainLink(101, 0x02, 0x01),
+			},
+			blocks: 2,
+		},
+		{
+			name: "Broken",
+			links: []*blockChainLink{
+				testChainLink(0, 0x01, 0x00),
+				testChainLink(1, 0x02, 0x01),
+				testChainLink(2, 0x03, 0x04),
+			},
+			failure: "canonical block 0x0300000000000000000000000000000000000000000000000000000000000000 at slot 2 has parent 0x0400000000000000000000000000000000000000000000000000000000000000 but the previous canonical block is 0x0200000000000000000000000000000000000000000000000000000000000000 at slot 1",
+			blocks:  2,
+		},
+		{
+			name: "DuplicateSlot",
+			links: []*blockChainLink{
+				testChainLink(0, 0x01, 0x00),
+				testChainLink(0, 0x02, 0x01),
+			},
+			failure: "database has multiple canonical blocks at slot 0",
+			blocks:  1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			walker := newBlockChainWalker(1, rand.New(rand.NewSource(1)))
+			failure := ""
+			for _, link := range test.links {
+				if failure = walker.add(link); failure != "" {
+					break
+				}
+			}
+			require.Equal(t, test.failure, failure)
+			require.Equal(t, test.blocks, walker.blocks)
+		})
+	}
+}
+
+func TestBlockChainWalkerSpotChecks(t *testing.T) {
+	walker := newBlockChainWalker(4, rand.New(rand.NewSource(1)))
+	require.Empty(t, walker.spotChecks())
+
+	for slot := phase0.Slot(0); slot < 1000; slot++ {
+		require.Empty(t, walker.add(testChainLink(slot, byte(slot+1), byte(slot))))
+	}
+
+	checks := walker.spotChecks()
+	require.LessOrEqual(t, len(checks), 6)
+	require.GreaterOrEqual(t, len(checks), 2)
+	require.Equal(t, phase0.Slot(0), checks[0].slot)
+	require.Equal(t, phase0.Slot(999), checks[len(checks)-1].slot)
+	for i := 1; i < len(checks); i++ {
+		require.Less(t, checks[i-1].slot, checks[i].slot)
+	}
+}
+
+func TestBlockChainWalkerNoSamples(t *testing.T) {
+	walker := newBlockChainWalker(0, rand.New(rand.NewSource(1)))
+	require.Empty(t, walker.add(testChainLink(5, 0x01, 0x00)))
+	require.Empty(t, walker.add(testChainLink(6, 0x02, 0x01)))
+
+	checks := walker.spotChecks()
+	require.Len(t, checks, 2)
+	require.Equal(t, phase0.Slot(5), checks[0].slot)
+	require.Equal(t, phase0.Slot(6), checks[1].slot)
+}
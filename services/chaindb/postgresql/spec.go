@@ -32,7 +32,7 @@ func (s *Service) Spec(ctx context.Context,
 	}
 
 	return &api.Response[map[string]any]{
-		Data:     res,
+		Data:     res.Values,
 		Metadata: make(map[string]any),
 	}, nil
 }
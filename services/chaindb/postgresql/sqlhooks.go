@@ -0,0 +1,339 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SQLHook is an operator-supplied statement that is run in the same
+// transaction whenever an entity is stored.
+type SQLHook struct {
+	// Entity is the entity whose storage triggers the hook, for example "block".
+	Entity string
+	// Statement is a single INSERT, UPDATE or DELETE statement.  It can reference
+	// the stored entity's values as named parameters, for example @slot.
+	Statement string
+}
+
+// sqlHookParameters are the named parameters available to hooks, by entity.
+var sqlHookParameters = map[string][]string{
+	"attestation": {
+		"inclusion_slot", "inclusion_block_root", "inclusion_index", "slot", "committee_index",
+		"beacon_block_root", "source_epoch", "source_root", "target_epoch", "target_root",
+		"aggregation_indices", "canonical",
+	},
+	"block": {
+		"slot", "root", "proposer_index", "parent_root", "state_root", "body_root", "graffiti",
+		"canonical", "execution_block_number", "execution_block_hash",
+	},
+	"deposit": {
+		"inclusion_slot", "inclusion_block_root", "inclusion_index", "validator_pubkey",
+		"withdrawal_credentials", "amount",
+	},
+	"validator": {
+		"index", "public_key", "withdrawal_credentials", "effective_balance", "slashed",
+		"activation_eligibility_epoch", "activation_epoch", "exit_epoch", "withdrawable_epoch",
+	},
+	"validator_balance": {
+		"validator_index", "epoch", "balance", "effective_balance",
+	},
+}
+
+// sqlHookStatementKeywords are the keywords with which a hook statement can start.
+var sqlHookStatementKeywords = map[string]bool{
+	"insert": true,
+	"update": true,
+	"delete": true,
+}
+
+// sqlHookForbiddenKeywords are keywords that cannot appear anywhere in a hook statement.
+var sqlHookForbiddenKeywords = map[string]bool{
+	"alter":    true,
+	"analyze":  true,
+	"call":     true,
+	"cluster":  true,
+	"comment":  true,
+	"copy":     true,
+	"create":   true,
+	"drop":     true,
+	"execute":  true,
+	"grant":    true,
+	"listen":   true,
+	"lock":     true,
+	"merge":    true,
+	"notify":   true,
+	"reindex":  true,
+	"reset":    true,
+	"revoke":   true,
+	"truncate": true,
+	"vacuum":   true,
+}
+
+var (
+	sqlHookLiteralRegexp   = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlHookCommentRegexp   = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+	sqlHookWordRegexp      = regexp.MustCompile(`(?:"(?:[^"]|"")*"|[A-Za-z_][A-Za-z0-9_$]*)(?:\.(?:"(?:[^"]|"")*"|[A-Za-z_][A-Za-z0-9_$]*))*`)
+	sqlHookParameterRegexp = regexp.MustCompile(`@([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// checkSQLHook checks that a hook refers to a known entity, contains a single
+// permitted statement, and only uses parameters available for its entity.
+func checkSQLHook(hook *SQLHook) error {
+	params, exists := sqlHookParameters[hook.Entity]
+	if !exists {
+		return fmt.Errorf("unknown entity %q", hook.Entity)
+	}
+
+	// Literals and comments are removed before examining the statement, so that
+	// their contents are neither mistaken for nor able to hide keywords.
+	stmt := sqlHookLiteralRegexp.ReplaceAllString(hook.Statement, "''")
+	stmt = sqlHookCommentRegexp.ReplaceAllString(stmt, " ")
+	stmt = strings.TrimSpace(stmt)
+	stmt = strings.TrimSpace(strings.TrimSuffix(stmt, ";"))
+	if stmt == "" {
+		return errors.New("statement is empty")
+	}
+	if strings.Contains(stmt, ";") {
+		return errors.New("statement must be a single statement")
+	}
+
+	for _, match := range sqlHookParameterRegexp.FindAllStringSubmatch(stmt, -1) {
+		found := false
+		for _, param := range params {
+			if match[1] == param {
+				found = true
+
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown parameter @%s for entity %s", match[1], hook.Entity)
+		}
+	}
+
+	words := sqlHookWordRegexp.FindAllString(sqlHookParameterRegexp.ReplaceAllString(stmt, " "), -1)
+	if len(words) == 0 || !sqlHookStatementKeywords[strings.ToLower(words[0])] {
+		return errors.New("statement must be an INSERT, UPDATE or DELETE")
+	}
+	for i, word := range words {
+		lower := strings.ToLower(word)
+		if sqlHookForbiddenKeywords[lower] {
+			return fmt.Errorf("statement cannot contain %s", strings.ToUpper(lower))
+		}
+		// A second data-modifying statement could be introduced through a
+		// common table expression; the only permitted later occurrence is the
+		// UPDATE of an INSERT's ON CONFLICT DO UPDATE clause.
+		if i > 0 && sqlHookStatementKeywords[lower] &&
+			(lower != "update" || strings.ToLower(words[i-1]) != "do") {
+			return fmt.Errorf("statement cannot contain a further %s", strings.ToUpper(lower))
+		}
+	}
+
+	table := sqlHookTarget(words)
+	if table == "" {
+		return errors.New("failed to find target table of statement")
+	}
+	if strings.HasPrefix(table, "t_") {
+		return fmt.Errorf("statement cannot modify chaind table %s", table)
+	}
+
+	return nil
+}
+
+// sqlHookTarget provides the name of the table modified by a hook statement,
+// without any schema or quotes.
+func sqlHookTarget(words []string) string {
+	var i int
+	switch strings.ToLower(words[0]) {
+	case "insert":
+		i = 1
+		if i < len(words) && strings.EqualFold(words[i], "into") {
+			i++
+		}
+	case "update":
+		i = 1
+		if i < len(words) && strings.EqualFold(words[i], "only") {
+			i++
+		}
+	case "delete":
+		i = 1
+		if i < len(words) && strings.EqualFold(words[i], "from") {
+			i++
+		}
+		if i < len(words) && strings.EqualFold(words[i], "only") {
+			i++
+		}
+	}
+	if i >= len(words) {
+		return ""
+	}
+
+	table := words[i]
+	if strings.HasSuffix(table, `"`) {
+		// Quoted identifiers can contain periods, so only strip the schema.
+		if idx := strings.LastIndex(table, `."`); idx != -1 {
+			table = table[idx+1:]
+		}
+
+		return strings.ReplaceAll(strings.Trim(table, `"`), `""`, `"`)
+	}
+	if idx := strings.LastIndex(table, "."); idx != -1 {
+		table = table[idx+1:]
+	}
+
+	return strings.ToLower(table)
+}
+
+// sqlHooksByEntity groups hook statements by entity, preserving their order.
+func sqlHooksByEntity(hooks []*SQLHook) map[string][]string {
+	res := make(map[string][]string)
+	for _, hook := range hooks {
+		res[hook.Entity] = append(res[hook.Entity], hook.Statement)
+	}
+
+	return res
+}
+
+// runSQLHooks runs the hooks registered for an entity, once for each set of
+// values, in the supplied transaction.
+func (s *Service) runSQLHooks(ctx context.Context, tx pgx.Tx, entity string, values ...pgx.NamedArgs) error {
+	stmts := s.sqlHooks[entity]
+	if len(stmts) == 0 || len(values) == 0 {
+		return nil
+	}
+
+	if len(stmts) == 1 && len(values) == 1 {
+		// Queue the single statement alongside other writes where possible.
+		if err := s.queueExec(ctx, tx, stmts[0], values[0]); err != nil {
+			return errors.Wrapf(err, "failed to run SQL hook for %s", entity)
+		}
+
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, value := range values {
+		for _, stmt := range stmts {
+			batch.Queue(stmt, value)
+		}
+	}
+	results := tx.SendBatch(ctx, batch)
+	for i := 0; i < batch.Len(); i++ {
+		if _, err := results.Exec(); err != nil {
+			_ = results.Close()
+
+			return errors.Wrapf(err, "failed to run SQL hook for %s", entity)
+		}
+	}
+	if err := results.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close SQL hooks for %s", entity)
+	}
+
+	return nil
+}
+
+// nullableEpoch provides nil for the far future epoch, otherwise the epoch.
+func nullableEpoch(epoch phase0.Epoch) any {
+	if epoch == farFutureEpoch {
+		return nil
+	}
+
+	return epoch
+}
+
+// blockHookValues provides the hook parameters for a block.
+func blockHookValues(block *chaindb.Block) pgx.NamedArgs {
+	values := pgx.NamedArgs{
+		"slot":                   block.Slot,
+		"root":                   block.Root[:],
+		"proposer_index":         block.ProposerIndex,
+		"parent_root":            block.ParentRoot[:],
+		"state_root":             block.StateRoot[:],
+		"body_root":              block.BodyRoot[:],
+		"graffiti":               block.Graffiti,
+		"canonical":              block.Canonical,
+		"execution_block_number": nil,
+		"execution_block_hash":   nil,
+	}
+	if block.ExecutionPayload != nil {
+		values["execution_block_number"] = block.ExecutionPayload.BlockNumber
+		values["execution_block_hash"] = block.ExecutionPayload.BlockHash[:]
+	}
+
+	return values
+}
+
+// validatorHookValues provides the hook parameters for a validator.
+func validatorHookValues(validator *chaindb.Validator) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"index":                        validator.Index,
+		"public_key":                   validator.PublicKey[:],
+		"withdrawal_credentials":       validator.WithdrawalCredentials[:],
+		"effective_balance":            validator.EffectiveBalance,
+		"slashed":                      validator.Slashed,
+		"activation_eligibility_epoch": nullableEpoch(validator.ActivationEligibilityEpoch),
+		"activation_epoch":             nullableEpoch(validator.ActivationEpoch),
+		"exit_epoch":                   nullableEpoch(validator.ExitEpoch),
+		"withdrawable_epoch":           nullableEpoch(validator.WithdrawableEpoch),
+	}
+}
+
+// validatorBalanceHookValues provides the hook parameters for a validator balance.
+func validatorBalanceHookValues(balance *chaindb.ValidatorBalance) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"validator_index":   balance.Index,
+		"epoch":             balance.Epoch,
+		"balance":           balance.Balance,
+		"effective_balance": balance.EffectiveBalance,
+	}
+}
+
+// attestationHookValues provides the hook parameters for an attestation.
+func attestationHookValues(attestation *chaindb.Attestation) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"inclusion_slot":       attestation.InclusionSlot,
+		"inclusion_block_root": attestation.InclusionBlockRoot[:],
+		"inclusion_index":      attestation.InclusionIndex,
+		"slot":                 attestation.Slot,
+		"committee_index":      attestation.CommitteeIndex,
+		"beacon_block_root":    attestation.BeaconBlockRoot[:],
+		"source_epoch":         attestation.SourceEpoch,
+		"source_root":          attestation.SourceRoot[:],
+		"target_epoch":         attestation.TargetEpoch,
+		"target_root":          attestation.TargetRoot[:],
+		"aggregation_indices":  attestation.AggregationIndices,
+		"canonical":            attestation.Canonical,
+	}
+}
+
+// depositHookValues provides the hook parameters for a deposit.
+func depositHookValues(deposit *chaindb.Deposit) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"inclusion_slot":         deposit.InclusionSlot,
+		"inclusion_block_root":   deposit.InclusionBlockRoot[:],
+		"inclusion_index":        deposit.InclusionIndex,
+		"validator_pubkey":       deposit.ValidatorPubKey[:],
+		"withdrawal_credentials": deposit.WithdrawalCredentials,
+		"amount":                 deposit.Amount,
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSQLHook(t *testing.T) {
+	tests := []struct {
+		name string
+		hook *SQLHook
+		err  string
+	}{
+		{
+			name: "UnknownEntity",
+			hook: &SQLHook{Entity: "unknown", Statement: "INSERT INTO x(a) VALUES(1)"},
+			err:  `unknown entity "unknown"`,
+		},
+		{
+			name: "Empty",
+			hook: &SQLHook{Entity: "block", Statement: " ; "},
+			err:  "statement is empty",
+		},
+		{
+			name: "Insert",
+			hook: &SQLHook{Entity: "block", Statement: "INSERT INTO my_proposers(f_slot,f_proposer) VALUES(@slot,@proposer_index)"},
+		},
+		{
+			name: "InsertTrailingSemicolon",
+			hook: &SQLHook{Entity: "block", Statement: "INSERT INTO my_proposers(f_slot) VALUES(@slot);"},
+		},
+		{
+			name: "InsertOnConflictUpdate",
+			hook: &SQLHook{Entity: "validator_balance", Statement: `
+INSERT INTO reporting.latest_balances(f_index,f_balance) VALUES(@validator_index,@balance)
+ON CONFLICT (f_index) DO UPDATE SET f_balance = excluded.f_balance`},
+		},
+		{
+			name: "UpdateOnly",
+			hook: &SQLHook{Entity: "validator", Statement: "UPDATE ONLY my_validators SET slashed = @slashed WHERE idx = @index"},
+		},
+		{
+			name: "DeleteReadingChaindTable",
+			hook: &SQLHook{Entity: "deposit", Statement: "DELETE FROM pending WHERE pubkey IN (SELECT f_public_key FROM t_validators WHERE f_public_key = @validator_pubkey)"},
+		},
+		{
+			name: "KeywordInLiteral",
+			hook: &SQLHook{Entity: "block", Statement: "INSERT INTO notes(f_slot,f_note) VALUES(@slot,'drop; it')"},
+		},
+		{
+			name: "Select",
+			hook: &SQLHook{Entity: "block", Statement: "SELECT 1"},
+			err:  "statement must be an INSERT, UPDATE or DELETE",
+		},
+		{
+			name: "MultipleStatements",
+			hook: &SQLHook{Entity: "block", Statement: "INSERT INTO x(a) VALUES(@slot); DELETE FROM y"},
+			err:  "statement must be a single statement",
+		},
+		{
+			name: "KeywordPrefixAndComment",
+			hook: &SQLHook{Entity: "block", Statement: "DELETE FROM x WHERE a = (SELECT 1) -- \n AND drop_me"},
+		},
+		{
+			name: "Truncate",
+			hook: &SQLHook{Entity: "block", Statement: "INSERT INTO x SELECT truncate FROM y"},
+			err:  "statement cannot contain TRUNCATE",
+		},
+		{
+			name: "WritingCTE",
+			hook: &SQLHook{Entity: "block", Statement: "INSERT INTO x WITH d AS (DELETE FROM t_blocks RETURNING f_slot) SELECT f_slot FROM d"},
+			err:  "statement cannot contain a further DELETE",
+		},
+		{
+			name: "ChaindTable",
+			hook: &SQLHook{Entity: "block", Statement: "UPDATE t_blocks SET f_canonical = NULL WHERE f_slot = @slot"},
+			err:  "statement cannot modify chaind table t_blocks",
+		},
+		{
+			name: "ChaindTableQualified",
+			hook: &SQLHook{Entity: "block", Statement: `DELETE FROM public."t_validators" WHERE f_index = 1`},
+			err:  "statement cannot modify chaind table t_validators",
+		},
+		{
+			name: "UnknownParameter",
+			hook: &SQLHook{Entity: "validator_balance", Statement: "INSERT INTO x(a) VALUES(@slot)"},
+			err:  "unknown parameter @slot for entity validator_balance",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkSQLHook(test.hook)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSQLHooksByEntity(t *testing.T) {
+	hooks := sqlHooksByEntity([]*SQLHook{
+		{Entity: "block", Statement: "a"},
+		{Entity: "validator", Statement: "b"},
+		{Entity: "block", Statement: "c"},
+	})
+	require.Equal(t, map[string][]string{
+		"block":     {"a", "c"},
+		"validator": {"b"},
+	}, hooks)
+}
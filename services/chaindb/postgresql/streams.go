@@ -0,0 +1,236 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// streamPageSize is the number of rows fetched from the database at a time by streams.
+const streamPageSize = uint32(1000)
+
+// pageIterator is an iterator that fetches its items a page at a time.
+type pageIterator[T any] struct {
+	// fetch obtains the next page of items, and if it is the final page.
+	fetch   func(ctx context.Context) ([]T, bool, error)
+	page    []T
+	pos     int
+	done    bool
+	current T
+	err     error
+}
+
+// newPageIterator creates a new iterator that obtains its pages with the given function.
+func newPageIterator[T any](fetch func(ctx context.Context) ([]T, bool, error)) *pageIterator[T] {
+	return &pageIterator[T]{
+		fetch: fetch,
+	}
+}
+
+// Next advances the iterator to the next item.
+func (i *pageIterator[T]) Next(ctx context.Context) bool {
+	for i.pos >= len(i.page) {
+		if i.done || i.err != nil {
+			return false
+		}
+		i.page, i.done, i.err = i.fetch(ctx)
+		i.pos = 0
+		if i.err != nil {
+			i.page = nil
+
+			return false
+		}
+	}
+	i.current = i.page[i.pos]
+	i.pos++
+
+	return true
+}
+
+// Value returns the item at the current position of the iterator.
+func (i *pageIterator[T]) Value() T {
+	return i.current
+}
+
+// Err returns the error, if any, that caused Next to return false.
+func (i *pageIterator[T]) Err() error {
+	return i.err
+}
+
+// Close releases any resources held by the iterator.
+func (i *pageIterator[T]) Close() {
+	i.page = nil
+	i.done = true
+}
+
+// pageLimit returns the limit for the next page of a stream, given the number of items
+// remaining to be returned; 0 remaining means unlimited.
+func pageLimit(remaining uint32) uint32 {
+	if remaining > 0 && remaining < streamPageSize {
+		return remaining
+	}
+
+	return streamPageSize
+}
+
+// reverse reverses a page so that it is in descending order.
+func reverse[T any](page []T) {
+	for i, j := 0, len(page)-1; i < j; i, j = i+1, j-1 {
+		page[i], page[j] = page[j], page[i]
+	}
+}
+
+// AttestationsStream provides attestations according to the filter one at a time,
+// in the order given by the filter.
+func (s *Service) AttestationsStream(_ context.Context,
+	filter *chaindb.AttestationFilter,
+) (
+	chaindb.Iterator[*chaindb.Attestation],
+	error,
+) {
+	if filter.Order != chaindb.OrderEarliest && filter.Order != chaindb.OrderLatest {
+		return nil, errors.New("no order specified")
+	}
+
+	pageFilter := *filter
+	remaining := filter.Limit
+
+	return newPageIterator(func(ctx context.Context) ([]*chaindb.Attestation, bool, error) {
+		ctx, span := startSpan(ctx, "AttestationsStream")
+		defer span.End()
+
+		pageFilter.Limit = pageLimit(remaining)
+		page, err := s.Attestations(ctx, &pageFilter)
+		if err != nil {
+			return nil, false, err
+		}
+		done := uint32(len(page)) < pageFilter.Limit
+		if remaining > 0 {
+			remaining -= uint32(len(page))
+			done = done || remaining == 0
+		}
+
+		if len(page) > 0 {
+			// Pages are returned in ascending order, so the cursor for the next page
+			// is the last item for OrderEarliest and the first for OrderLatest.
+			boundary := page[len(page)-1]
+			if pageFilter.Order == chaindb.OrderLatest {
+				boundary = page[0]
+				reverse(page)
+			}
+			pageFilter.Cursor = &chaindb.AttestationCursor{
+				InclusionSlot:      boundary.InclusionSlot,
+				InclusionBlockRoot: boundary.InclusionBlockRoot,
+				InclusionIndex:     boundary.InclusionIndex,
+			}
+		}
+
+		return page, done, nil
+	}), nil
+}
+
+// BlocksStream provides blocks according to the filter one at a time,
+// in the order given by the filter.
+func (s *Service) BlocksStream(_ context.Context,
+	filter *chaindb.BlockFilter,
+) (
+	chaindb.Iterator[*chaindb.Block],
+	error,
+) {
+	if filter.Order != chaindb.OrderEarliest && filter.Order != chaindb.OrderLatest {
+		return nil, errors.New("no order specified")
+	}
+
+	pageFilter := *filter
+	remaining := filter.Limit
+
+	return newPageIterator(func(ctx context.Context) ([]*chaindb.Block, bool, error) {
+		ctx, span := startSpan(ctx, "BlocksStream")
+		defer span.End()
+
+		pageFilter.Limit = pageLimit(remaining)
+		page, err := s.Blocks(ctx, &pageFilter)
+		if err != nil {
+			return nil, false, err
+		}
+		done := uint32(len(page)) < pageFilter.Limit
+		if remaining > 0 {
+			remaining -= uint32(len(page))
+			done = done || remaining == 0
+		}
+
+		if len(page) > 0 {
+			boundary := page[len(page)-1]
+			if pageFilter.Order == chaindb.OrderLatest {
+				boundary = page[0]
+				reverse(page)
+			}
+			pageFilter.Cursor = &chaindb.BlockCursor{
+				Slot: boundary.Slot,
+				Root: boundary.Root,
+			}
+		}
+
+		return page, done, nil
+	}), nil
+}
+
+// ValidatorsStream provides validators according to the filter one at a time,
+// in the order given by the filter.
+func (s *Service) ValidatorsStream(_ context.Context,
+	filter *chaindb.ValidatorFilter,
+) (
+	chaindb.Iterator[*chaindb.Validator],
+	error,
+) {
+	if filter.Order != chaindb.OrderEarliest && filter.Order != chaindb.OrderLatest {
+		return nil, errors.New("no order specified")
+	}
+
+	pageFilter := *filter
+	remaining := filter.Limit
+
+	return newPageIterator(func(ctx context.Context) ([]*chaindb.Validator, bool, error) {
+		ctx, span := startSpan(ctx, "ValidatorsStream")
+		defer span.End()
+
+		pageFilter.Limit = pageLimit(remaining)
+		page, err := s.ValidatorsByFilter(ctx, &pageFilter)
+		if err != nil {
+			return nil, false, err
+		}
+		done := uint32(len(page)) < pageFilter.Limit
+		if remaining > 0 {
+			remaining -= uint32(len(page))
+			done = done || remaining == 0
+		}
+
+		if len(page) > 0 {
+			var boundary phase0.ValidatorIndex
+			if pageFilter.Order == chaindb.OrderLatest {
+				boundary = page[0].Index
+				reverse(page)
+			} else {
+				boundary = page[len(page)-1].Index
+			}
+			pageFilter.Cursor = &boundary
+		}
+
+		return page, done, nil
+	}), nil
+}
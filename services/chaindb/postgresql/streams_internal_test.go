@@ -0,0 +1,96 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageIterator(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		pages  [][]int
+		final  int
+		err    error
+		values []int
+	}{
+		{
+			name:   "Empty",
+			pages:  [][]int{{}},
+			final:  0,
+			values: []int{},
+		},
+		{
+			name:   "SinglePage",
+			pages:  [][]int{{1, 2, 3}},
+			final:  0,
+			values: []int{1, 2, 3},
+		},
+		{
+			name:   "MultiplePages",
+			pages:  [][]int{{1, 2}, {3, 4}, {5}},
+			final:  2,
+			values: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:   "EmptyIntermediatePage",
+			pages:  [][]int{{1}, {}, {2}},
+			final:  2,
+			values: []int{1, 2},
+		},
+		{
+			name:   "Error",
+			pages:  [][]int{{1, 2}, nil},
+			final:  2,
+			err:    errors.New("bad"),
+			values: []int{1, 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fetches := 0
+			iterator := newPageIterator(func(_ context.Context) ([]int, bool, error) {
+				page := test.pages[fetches]
+				fetches++
+				if page == nil {
+					return nil, false, test.err
+				}
+
+				return page, fetches-1 == test.final, nil
+			})
+			defer iterator.Close()
+
+			values := make([]int, 0)
+			for iterator.Next(ctx) {
+				values = append(values, iterator.Value())
+			}
+			require.Equal(t, test.values, values)
+			require.Equal(t, test.err, iterator.Err())
+			require.False(t, iterator.Next(ctx))
+		})
+	}
+}
+
+func TestPageLimit(t *testing.T) {
+	require.Equal(t, streamPageSize, pageLimit(0))
+	require.Equal(t, uint32(10), pageLimit(10))
+	require.Equal(t, streamPageSize, pageLimit(streamPageSize+1))
+}
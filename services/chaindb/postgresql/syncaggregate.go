@@ -22,12 +22,11 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // SetSyncAggregate sets the sync aggregate.
 func (s *Service) SetSyncAggregate(ctx context.Context, syncAggregate *chaindb.SyncAggregate) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetSyncAggregate")
+	ctx, span := startSpan(ctx, "SetSyncAggregate")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -35,22 +34,30 @@ func (s *Service) SetSyncAggregate(ctx context.Context, syncAggregate *chaindb.S
 		return ErrNoTransaction
 	}
 
+	var signature []byte
+	if syncAggregate.Signature != nil {
+		signature = syncAggregate.Signature[:]
+	}
+
 	_, err := tx.Exec(ctx, `
       INSERT INTO t_sync_aggregates(f_inclusion_slot
                                    ,f_inclusion_block_root
                                    ,f_bits
                                    ,f_indices
+                                   ,f_signature
                                   )
-      VALUES($1,$2,$3,$4)
+      VALUES($1,$2,$3,$4,$5)
       ON CONFLICT (f_inclusion_slot, f_inclusion_block_root) DO
       UPDATE
       SET f_bits = excluded.f_bits
          ,f_indices = excluded.f_indices
+         ,f_signature = excluded.f_signature
 	  `,
 		syncAggregate.InclusionSlot,
 		syncAggregate.InclusionBlockRoot[:],
 		syncAggregate.Bits,
 		syncAggregate.Indices,
+		signature,
 	)
 
 	return err
@@ -58,7 +65,7 @@ func (s *Service) SetSyncAggregate(ctx context.Context, syncAggregate *chaindb.S
 
 // SyncAggregates provides sync aggregates according to the filter.
 func (s *Service) SyncAggregates(ctx context.Context, filter *chaindb.SyncAggregateFilter) ([]*chaindb.SyncAggregate, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SyncAggregates")
+	ctx, span := startSpan(ctx, "SyncAggregates")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -80,6 +87,7 @@ SELECT f_inclusion_slot
       ,f_inclusion_block_root
       ,f_bits
       ,f_indices
+      ,f_signature
 FROM t_sync_aggregates`)
 
 	wherestr := "WHERE"
@@ -136,16 +144,22 @@ LIMIT $%d`, len(queryVals)))
 		summary := &chaindb.SyncAggregate{}
 		indices := make([]uint64, 0)
 		var inclusionBlockRoot []byte
+		var signature []byte
 		err := rows.Scan(
 			&summary.InclusionSlot,
 			&inclusionBlockRoot,
 			&summary.Bits,
 			&indices,
+			&signature,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
 		copy(summary.InclusionBlockRoot[:], inclusionBlockRoot)
+		if len(signature) > 0 {
+			summary.Signature = &phase0.BLSSignature{}
+			copy(summary.Signature[:], signature)
+		}
 		summary.Indices = make([]phase0.ValidatorIndex, len(indices))
 		for i := range indices {
 			summary.Indices[i] = phase0.ValidatorIndex(indices[i])
@@ -159,3 +173,23 @@ LIMIT $%d`, len(queryVals)))
 	})
 	return aggregates, nil
 }
+
+// PruneSyncAggregates prunes sync aggregates included before the given slot.
+func (s *Service) PruneSyncAggregates(ctx context.Context, to phase0.Slot) error {
+	ctx, span := startSpan(ctx, "PruneSyncAggregates")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+DELETE FROM t_sync_aggregates
+WHERE f_inclusion_slot < $1
+`,
+		to,
+	)
+
+	return err
+}
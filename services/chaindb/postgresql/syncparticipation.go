@@ -0,0 +1,237 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SyncParticipation provides the participation of the sync committee in
+// the sync aggregates that match the filter.
+func (s *Service) SyncParticipation(ctx context.Context,
+	filter *chaindb.SyncAggregateFilter,
+) (
+	[]*chaindb.SyncParticipation,
+	error,
+) {
+	ctx, span := startSpan(ctx, "SyncParticipation")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	slotsPerPeriod, err := s.slotsPerSyncCommitteePeriod(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryVals = append(queryVals, slotsPerPeriod)
+	queryBuilder.WriteString(`
+SELECT a.f_inclusion_slot
+      ,a.f_inclusion_block_root
+      ,COALESCE(cardinality(a.f_indices),0)
+      ,COALESCE(cardinality(c.f_committee),0)
+FROM t_sync_aggregates a
+LEFT JOIN t_sync_committees c ON c.f_period = a.f_inclusion_slot / $1`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s a.f_inclusion_slot >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s a.f_inclusion_slot <= $%d`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY a.f_inclusion_slot`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY a.f_inclusion_slot DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	participations := make([]*chaindb.SyncParticipation, 0)
+	for rows.Next() {
+		participation := &chaindb.SyncParticipation{}
+		var inclusionBlockRoot []byte
+		err := rows.Scan(
+			&participation.InclusionSlot,
+			&inclusionBlockRoot,
+			&participation.Participants,
+			&participation.CommitteeSize,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(participation.InclusionBlockRoot[:], inclusionBlockRoot)
+		participation.Period = uint64(participation.InclusionSlot) / slotsPerPeriod
+		if participation.CommitteeSize > 0 {
+			participation.ParticipationRate = float64(participation.Participants) / float64(participation.CommitteeSize)
+		}
+		participations = append(participations, participation)
+	}
+
+	// Always return order of inclusion slot.
+	sort.Slice(participations, func(i int, j int) bool {
+		return participations[i].InclusionSlot < participations[j].InclusionSlot
+	})
+
+	return participations, nil
+}
+
+// SyncCommitteeParticipants provides the participation of each member of
+// the sync committee in the sync aggregate of the given block.
+func (s *Service) SyncCommitteeParticipants(ctx context.Context,
+	blockRoot phase0.Root,
+) (
+	[]*chaindb.SyncCommitteeParticipant,
+	error,
+) {
+	ctx, span := startSpan(ctx, "SyncCommitteeParticipants")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	slotsPerPeriod, err := s.slotsPerSyncCommitteePeriod(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT a.f_inclusion_slot
+      ,a.f_bits
+      ,c.f_committee
+FROM t_sync_aggregates a
+LEFT JOIN t_sync_committees c ON c.f_period = a.f_inclusion_slot / $2
+WHERE a.f_inclusion_block_root = $1
+`,
+		blockRoot[:],
+		slotsPerPeriod,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	participants := make([]*chaindb.SyncCommitteeParticipant, 0)
+	for rows.Next() {
+		var slot phase0.Slot
+		var bits []byte
+		var committee []uint64
+		if err := rows.Scan(
+			&slot,
+			&bits,
+			&committee,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if committee == nil {
+			return nil, errors.Errorf("sync committee for period %d not known", uint64(slot)/slotsPerPeriod)
+		}
+		slotParticipants, err := expandSyncCommitteeBits(slot, blockRoot, bits, committee)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to expand sync aggregate at slot %d", slot)
+		}
+		participants = append(participants, slotParticipants...)
+	}
+
+	return participants, nil
+}
+
+// expandSyncCommitteeBits expands the bits of a sync aggregate to the
+// participation of each member of the sync committee.
+func expandSyncCommitteeBits(slot phase0.Slot,
+	blockRoot phase0.Root,
+	bits []byte,
+	committee []uint64,
+) (
+	[]*chaindb.SyncCommitteeParticipant,
+	error,
+) {
+	if len(bits) != (len(committee)+7)/8 {
+		return nil, errors.New("sync committee bits and committee size mismatch")
+	}
+
+	participants := make([]*chaindb.SyncCommitteeParticipant, len(committee))
+	for i := range committee {
+		participants[i] = &chaindb.SyncCommitteeParticipant{
+			InclusionSlot:      slot,
+			InclusionBlockRoot: blockRoot,
+			Position:           uint64(i),
+			ValidatorIndex:     phase0.ValidatorIndex(committee[i]),
+			Participated:       bits[i/8]&(1<<(i%8)) != 0,
+		}
+	}
+
+	return participants, nil
+}
@@ -0,0 +1,74 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandSyncCommitteeBits(t *testing.T) {
+	tests := []struct {
+		name      string
+		bits      []byte
+		committee []uint64
+		expected  []bool
+		err       string
+	}{
+		{
+			name:      "None",
+			bits:      []byte{0x00},
+			committee: []uint64{1, 2, 3, 4, 5, 6, 7, 8},
+			expected:  []bool{false, false, false, false, false, false, false, false},
+		},
+		{
+			name:      "Some",
+			bits:      []byte{0x05, 0x80},
+			committee: []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+			expected:  []bool{true, false, true, false, false, false, false, false, false, false, false, false, false, false, false, true},
+		},
+		{
+			name:      "All",
+			bits:      []byte{0xff},
+			committee: []uint64{1, 2, 3, 4, 5, 6, 7, 8},
+			expected:  []bool{true, true, true, true, true, true, true, true},
+		},
+		{
+			name:      "SizeMismatch",
+			bits:      []byte{0xff},
+			committee: []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9},
+			err:       "sync committee bits and committee size mismatch",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := expandSyncCommitteeBits(10, phase0.Root{0x01}, test.bits, test.committee)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, res, len(test.committee))
+				for i := range res {
+					require.Equal(t, uint64(i), res[i].Position)
+					require.Equal(t, test.committee[i], uint64(res[i].ValidatorIndex))
+					require.Equal(t, test.expected[i], res[i].Participated, "position %d", i)
+					require.Equal(t, phase0.Slot(10), res[i].InclusionSlot)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+// Modes of TimescaleDB support.
+const (
+	// TimescaleModeAuto uses TimescaleDB if its extension is installed in the database.
+	TimescaleModeAuto = "auto"
+	// TimescaleModeEnable installs the TimescaleDB extension if required, and uses it.
+	TimescaleModeEnable = "enable"
+	// TimescaleModeDisable does not use TimescaleDB.
+	TimescaleModeDisable = "disable"
+)
+
+var timescaleModes = map[string]bool{
+	TimescaleModeAuto:    true,
+	TimescaleModeEnable:  true,
+	TimescaleModeDisable: true,
+}
+
+// minTimescaleVersion is the earliest version of TimescaleDB that allows
+// compressed chunks to be updated, as required by upserts.
+var minTimescaleVersion = [2]uint64{2, 11}
+
+// hypertableChunkEpochs is the number of epochs held in each chunk of a hypertable.
+const hypertableChunkEpochs = 1575
+
+// defaultSlotsPerEpoch is the number of slots in an epoch if the chain
+// specification is not yet known.
+const defaultSlotsPerEpoch = 32
+
+// hypertable is a table that is partitioned by TimescaleDB.
+type hypertable struct {
+	name string
+	// column is the column by which the table is partitioned.
+	column string
+	// slots is true if the column is a slot, otherwise it is an epoch.
+	slots bool
+	// segmentBy are the columns by which compressed rows are grouped.
+	segmentBy string
+	// orderBy are the columns by which compressed rows are ordered.
+	orderBy string
+}
+
+// hypertables are the tables that are partitioned when TimescaleDB is used.
+// Each unique index on a hypertable must include the partitioning column.
+var hypertables = []*hypertable{
+	{name: "t_validator_balances", column: "f_epoch", segmentBy: "f_validator_index", orderBy: "f_epoch DESC"},
+	{name: "t_validator_epoch_summaries", column: "f_epoch", segmentBy: "f_validator_index", orderBy: "f_epoch DESC"},
+	{name: "t_beacon_committees", column: "f_slot", slots: true, orderBy: "f_slot DESC,f_index"},
+}
+
+// continuousAggregate is a view whose results are maintained by TimescaleDB.
+type continuousAggregate struct {
+	name string
+	// query is the query of the view, with the width of its buckets in epochs
+	// as its only formatting parameter.
+	query string
+}
+
+// continuousAggregates are the views created when TimescaleDB is used.
+var continuousAggregates = []*continuousAggregate{
+	{
+		name: "v_validator_balance_periods",
+		query: `
+SELECT f_validator_index
+      ,time_bucket(%d, f_epoch) AS f_start_epoch
+      ,first(f_balance, f_epoch) AS f_start_balance
+      ,last(f_balance, f_epoch) AS f_end_balance
+      ,MIN(f_balance) AS f_min_balance
+      ,MAX(f_balance) AS f_max_balance
+      ,last(f_effective_balance, f_epoch) AS f_end_effective_balance
+FROM t_validator_balances
+GROUP BY f_validator_index, time_bucket(%[1]d, f_epoch)`,
+	},
+	{
+		name: "v_validator_epoch_summary_periods",
+		query: `
+SELECT f_validator_index
+      ,time_bucket(%d, f_epoch) AS f_start_epoch
+      ,COUNT(*) AS f_epochs
+      ,SUM(f_proposer_duties) AS f_proposer_duties
+      ,SUM(f_proposals_included) AS f_proposals_included
+      ,SUM(CASE WHEN f_attestation_included THEN 1 ELSE 0 END) AS f_attestations_included
+      ,SUM(CASE WHEN f_attestation_target_correct THEN 1 ELSE 0 END) AS f_attestations_target_correct
+      ,SUM(CASE WHEN f_attestation_head_correct THEN 1 ELSE 0 END) AS f_attestations_head_correct
+      ,AVG(f_attestation_inclusion_delay) AS f_attestations_inclusion_delay
+FROM t_validator_epoch_summaries
+GROUP BY f_validator_index, time_bucket(%[1]d, f_epoch)`,
+	},
+}
+
+// ApplyTimescale partitions tables keyed by epoch or slot with TimescaleDB,
+// compressing their older data, and creates continuous aggregates of common
+// summaries.  Tables that are already partitioned are left as they are, so it
+// can be called each time chaind starts.  It should be called after the schema
+// is upgraded.
+func (s *Service) ApplyTimescale(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "ApplyTimescale")
+	defer span.End()
+
+	if s.timescaleMode == TimescaleModeDisable {
+		return nil
+	}
+	if s.externalSchema {
+		if s.timescaleMode == TimescaleModeEnable {
+			return errors.Wrap(ErrExternalSchema, "cannot apply TimescaleDB")
+		}
+		return nil
+	}
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.applyTimescale(ctx); err != nil {
+		cancel()
+		return err
+	}
+
+	if err := s.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+func (s *Service) applyTimescale(ctx context.Context) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	version, err := s.timescaleVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version == "" {
+		if s.timescaleMode != TimescaleModeEnable {
+			log.Trace().Msg("TimescaleDB not installed; not using it")
+			return nil
+		}
+		log.Info().Msg("Installing TimescaleDB extension")
+		if _, err := tx.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS timescaledb SCHEMA public"); err != nil {
+			return errors.Wrap(err, "failed to install TimescaleDB extension")
+		}
+		if version, err = s.timescaleVersion(ctx); err != nil {
+			return err
+		}
+	}
+	if !timescaleVersionSupported(version) {
+		if s.timescaleMode == TimescaleModeEnable {
+			return fmt.Errorf("TimescaleDB version %s is earlier than the minimum supported version %d.%d", version, minTimescaleVersion[0], minTimescaleVersion[1])
+		}
+		log.Warn().Str("version", version).Msg("TimescaleDB version is not supported; not using it")
+		return nil
+	}
+
+	// Converting tables that already hold data can take a long time.
+	if _, err := tx.Exec(ctx, "SET LOCAL statement_timeout = 0"); err != nil {
+		return errors.Wrap(err, "failed to disable statement timeout")
+	}
+
+	slotsPerEpoch, err := s.slotsPerEpoch(ctx)
+	if err != nil {
+		log.Debug().Err(err).Msg("Slots per epoch not known; using default")
+		slotsPerEpoch = defaultSlotsPerEpoch
+	}
+
+	existing, err := s.existingHypertables(ctx)
+	if err != nil {
+		return err
+	}
+	for _, table := range hypertables {
+		compressed, exists := existing[table.name]
+		if !exists {
+			log.Info().Str("table", table.name).Msg("Converting table to hypertable; this can take some time if the table holds data")
+		}
+		for _, statement := range hypertableStatements(table, exists, compressed, slotsPerEpoch, s.timescaleCompressAfter) {
+			if _, err := tx.Exec(ctx, statement); err != nil {
+				return errors.Wrapf(err, "failed to apply TimescaleDB to %s", table.name)
+			}
+		}
+	}
+
+	for _, aggregate := range continuousAggregates {
+		for _, statement := range continuousAggregateStatements(aggregate, s.timescaleAggregateEpochs) {
+			if _, err := tx.Exec(ctx, statement); err != nil {
+				return errors.Wrapf(err, "failed to create continuous aggregate %s", aggregate.name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// timescaleVersion provides the version of the TimescaleDB extension installed
+// in the database, or an empty string if it is not installed.
+func (s *Service) timescaleVersion(ctx context.Context) (string, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return "", ErrNoTransaction
+	}
+
+	var version string
+	err := tx.QueryRow(ctx, `SELECT extversion FROM pg_extension WHERE extname = 'timescaledb'`).Scan(&version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed to obtain TimescaleDB version")
+	}
+
+	return version, nil
+}
+
+// existingHypertables provides the hypertables in the schema, and if they are compressed.
+func (s *Service) existingHypertables(ctx context.Context) (map[string]bool, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return nil, ErrNoTransaction
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT hypertable_name
+      ,compression_enabled
+FROM timescaledb_information.hypertables
+WHERE hypertable_schema = current_schema()`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain hypertables")
+	}
+	defer rows.Close()
+
+	res := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		var compressed bool
+		if err := rows.Scan(&name, &compressed); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		res[name] = compressed
+	}
+
+	return res, rows.Err()
+}
+
+// timescaleVersionSupported returns true if the version of TimescaleDB is supported.
+func timescaleVersionSupported(version string) bool {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	major, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return false
+	}
+	minor, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return false
+	}
+
+	return major > minTimescaleVersion[0] ||
+		(major == minTimescaleVersion[0] && minor >= minTimescaleVersion[1])
+}
+
+// integerNowFunc provides the name of the function that TimescaleDB uses to
+// obtain the current value of the partitioning column of a hypertable.
+func integerNowFunc(table string) string {
+	return fmt.Sprintf("f_timescale_now_%s", strings.TrimPrefix(table, "t_"))
+}
+
+// hypertableStatements provides the statements that convert a table to a
+// compressed hypertable, skipping those steps that have already been taken.
+func hypertableStatements(table *hypertable,
+	exists bool,
+	compressed bool,
+	slotsPerEpoch uint64,
+	compressAfterEpochs uint64,
+) []string {
+	chunk := uint64(hypertableChunkEpochs)
+	compressAfter := compressAfterEpochs
+	if table.slots {
+		chunk *= slotsPerEpoch
+		compressAfter *= slotsPerEpoch
+	}
+	nowFunc := integerNowFunc(table.name)
+
+	statements := make([]string, 0)
+	if !exists {
+		statements = append(statements,
+			fmt.Sprintf(`SELECT create_hypertable('%s', '%s', chunk_time_interval => %d, migrate_data => true, if_not_exists => true)`,
+				table.name, table.column, chunk),
+		)
+	}
+	statements = append(statements,
+		fmt.Sprintf(`CREATE OR REPLACE FUNCTION %s() RETURNS BIGINT LANGUAGE SQL STABLE AS $$ SELECT COALESCE(MAX(%s),0) FROM %s $$`,
+			nowFunc, table.column, table.name),
+		fmt.Sprintf(`SELECT set_integer_now_func('%s', '%s', replace_if_exists => true)`, table.name, nowFunc),
+	)
+	if compressAfterEpochs == 0 {
+		return statements
+	}
+	if !compressed {
+		options := []string{"timescaledb.compress"}
+		if table.segmentBy != "" {
+			options = append(options, fmt.Sprintf("timescaledb.compress_segmentby = '%s'", table.segmentBy))
+		}
+		options = append(options, fmt.Sprintf("timescaledb.compress_orderby = '%s'", table.orderBy))
+		statements = append(statements,
+			fmt.Sprintf(`ALTER TABLE %s SET (%s)`, table.name, strings.Join(options, ", ")),
+		)
+	}
+	statements = append(statements,
+		fmt.Sprintf(`SELECT add_compression_policy('%s', compress_after => %d::BIGINT, if_not_exists => true)`, table.name, compressAfter),
+	)
+
+	return statements
+}
+
+// continuousAggregateStatements provides the statements that create a
+// continuous aggregate and the policy that refreshes it.
+func continuousAggregateStatements(aggregate *continuousAggregate, bucketEpochs uint64) []string {
+	return []string{
+		fmt.Sprintf(`CREATE MATERIALIZED VIEW IF NOT EXISTS %s WITH (timescaledb.continuous) AS %s WITH NO DATA`,
+			aggregate.name, fmt.Sprintf(aggregate.query, bucketEpochs)),
+		// Results for the latest bucket are calculated when read, so it is not materialized.
+		fmt.Sprintf(`SELECT add_continuous_aggregate_policy('%s', start_offset => NULL, end_offset => %d::BIGINT, schedule_interval => INTERVAL '1 hour', if_not_exists => true)`,
+			aggregate.name, bucketEpochs),
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimescaleVersionSupported(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{version: "", expected: false},
+		{version: "2", expected: false},
+		{version: "1.7.5", expected: false},
+		{version: "2.10.3", expected: false},
+		{version: "2.11.0", expected: true},
+		{version: "2.14.2", expected: true},
+		{version: "3.0.0-dev", expected: true},
+		{version: "x.y", expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.version, func(t *testing.T) {
+			require.Equal(t, test.expected, timescaleVersionSupported(test.version))
+		})
+	}
+}
+
+func TestHypertableStatements(t *testing.T) {
+	balances := &hypertable{name: "t_validator_balances", column: "f_epoch", segmentBy: "f_validator_index", orderBy: "f_epoch DESC"}
+	committees := &hypertable{name: "t_beacon_committees", column: "f_slot", slots: true, orderBy: "f_slot DESC,f_index"}
+
+	tests := []struct {
+		name          string
+		table         *hypertable
+		exists        bool
+		compressed    bool
+		compressAfter uint64
+		expected      []string
+	}{
+		{
+			name:          "New",
+			table:         balances,
+			compressAfter: 2250,
+			expected: []string{
+				"SELECT create_hypertable('t_validator_balances', 'f_epoch', chunk_time_interval => 1575, migrate_data => true, if_not_exists => true)",
+				"CREATE OR REPLACE FUNCTION f_timescale_now_validator_balances() RETURNS BIGINT LANGUAGE SQL STABLE AS $$ SELECT COALESCE(MAX(f_epoch),0) FROM t_validator_balances $$",
+				"SELECT set_integer_now_func('t_validator_balances', 'f_timescale_now_validator_balances', replace_if_exists => true)",
+				"ALTER TABLE t_validator_balances SET (timescaledb.compress, timescaledb.compress_segmentby = 'f_validator_index', timescaledb.compress_orderby = 'f_epoch DESC')",
+				"SELECT add_compression_policy('t_validator_balances', compress_after => 2250::BIGINT, if_not_exists => true)",
+			},
+		},
+		{
+			name:          "Compressed",
+			table:         balances,
+			exists:        true,
+			compressed:    true,
+			compressAfter: 2250,
+			expected: []string{
+				"CREATE OR REPLACE FUNCTION f_timescale_now_validator_balances() RETURNS BIGINT LANGUAGE SQL STABLE AS $$ SELECT COALESCE(MAX(f_epoch),0) FROM t_validator_balances $$",
+				"SELECT set_integer_now_func('t_validator_balances', 'f_timescale_now_validator_balances', replace_if_exists => true)",
+				"SELECT add_compression_policy('t_validator_balances', compress_after => 2250::BIGINT, if_not_exists => true)",
+			},
+		},
+		{
+			name:   "NoCompression",
+			table:  balances,
+			exists: true,
+			expected: []string{
+				"CREATE OR REPLACE FUNCTION f_timescale_now_validator_balances() RETURNS BIGINT LANGUAGE SQL STABLE AS $$ SELECT COALESCE(MAX(f_epoch),0) FROM t_validator_balances $$",
+				"SELECT set_integer_now_func('t_validator_balances', 'f_timescale_now_validator_balances', replace_if_exists => true)",
+			},
+		},
+		{
+			name:          "Slots",
+			table:         committees,
+			compressAfter: 10,
+			expected: []string{
+				"SELECT create_hypertable('t_beacon_committees', 'f_slot', chunk_time_interval => 25200, migrate_data => true, if_not_exists => true)",
+				"CREATE OR REPLACE FUNCTION f_timescale_now_beacon_committees() RETURNS BIGINT LANGUAGE SQL STABLE AS $$ SELECT COALESCE(MAX(f_slot),0) FROM t_beacon_committees $$",
+				"SELECT set_integer_now_func('t_beacon_committees', 'f_timescale_now_beacon_committees', replace_if_exists => true)",
+				"ALTER TABLE t_beacon_committees SET (timescaledb.compress, timescaledb.compress_orderby = 'f_slot DESC,f_index')",
+				"SELECT add_compression_policy('t_beacon_committees', compress_after => 160::BIGINT, if_not_exists => true)",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, hypertableStatements(test.table, test.exists, test.compressed, 16, test.compressAfter))
+		})
+	}
+}
+
+func TestContinuousAggregateStatements(t *testing.T) {
+	for _, aggregate := range continuousAggregates {
+		t.Run(aggregate.name, func(t *testing.T) {
+			statements := continuousAggregateStatements(aggregate, 225)
+			require.Len(t, statements, 2)
+			for _, statement := range statements {
+				require.NotContains(t, statement, "%!")
+			}
+			require.True(t, strings.HasPrefix(statements[0], "CREATE MATERIALIZED VIEW IF NOT EXISTS "+aggregate.name+" WITH (timescaledb.continuous)"))
+			require.Equal(t, 2, strings.Count(statements[0], "time_bucket(225, f_epoch)"))
+			require.Contains(t, statements[1], "end_offset => 225::BIGINT")
+		})
+	}
+}
+
+func TestHypertablesInSchema(t *testing.T) {
+	schema, err := latestSchema()
+	require.NoError(t, err)
+
+	columns := make(map[string]map[string]bool)
+	for _, table := range schema.tables {
+		columns[table.name] = make(map[string]bool)
+		for _, column := range table.columns {
+			columns[table.name][column.name] = true
+		}
+	}
+	for _, table := range hypertables {
+		require.Contains(t, columns, table.name)
+		require.True(t, columns[table.name][table.column], "%s.%s", table.name, table.column)
+	}
+}
@@ -0,0 +1,440 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetTransactionReceipts sets transaction receipts.
+func (s *Service) SetTransactionReceipts(ctx context.Context, receipts []*chaindb.TransactionReceipt) error {
+	ctx, span := startSpan(ctx, "SetTransactionReceipts")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, receipt := range receipts {
+		var to []byte
+		if receipt.To != nil {
+			to = receipt.To[:]
+		}
+		var contractAddress []byte
+		if receipt.ContractAddress != nil {
+			contractAddress = receipt.ContractAddress[:]
+		}
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_block_transaction_receipts(f_block_root
+                                        ,f_slot
+                                        ,f_block_number
+                                        ,f_index
+                                        ,f_hash
+                                        ,f_from
+                                        ,f_to
+                                        ,f_contract_address
+                                        ,f_status
+                                        ,f_gas_used
+                                        )
+VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+ON CONFLICT (f_block_root,f_index) DO
+UPDATE
+SET f_slot = excluded.f_slot
+   ,f_block_number = excluded.f_block_number
+   ,f_hash = excluded.f_hash
+   ,f_from = excluded.f_from
+   ,f_to = excluded.f_to
+   ,f_contract_address = excluded.f_contract_address
+   ,f_status = excluded.f_status
+   ,f_gas_used = excluded.f_gas_used
+`,
+			receipt.InclusionBlockRoot[:],
+			receipt.InclusionSlot,
+			receipt.BlockNumber,
+			receipt.Index,
+			receipt.Hash[:],
+			receipt.From[:],
+			to,
+			contractAddress,
+			receipt.Status,
+			receipt.GasUsed,
+		); err != nil {
+			return errors.Wrap(err, "failed to set transaction receipt")
+		}
+	}
+
+	return nil
+}
+
+// SetTransactionEvents sets transaction events.
+func (s *Service) SetTransactionEvents(ctx context.Context, events []*chaindb.TransactionEvent) error {
+	ctx, span := startSpan(ctx, "SetTransactionEvents")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, event := range events {
+		topics := make([][]byte, len(event.Topics))
+		for i := range event.Topics {
+			topics[i] = event.Topics[i][:]
+		}
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_block_transaction_events(f_block_root
+                                      ,f_slot
+                                      ,f_block_number
+                                      ,f_transaction_index
+                                      ,f_transaction_hash
+                                      ,f_index
+                                      ,f_address
+                                      ,f_topics
+                                      ,f_data
+                                      )
+VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9)
+ON CONFLICT (f_block_root,f_index) DO
+UPDATE
+SET f_slot = excluded.f_slot
+   ,f_block_number = excluded.f_block_number
+   ,f_transaction_index = excluded.f_transaction_index
+   ,f_transaction_hash = excluded.f_transaction_hash
+   ,f_address = excluded.f_address
+   ,f_topics = excluded.f_topics
+   ,f_data = excluded.f_data
+`,
+			event.InclusionBlockRoot[:],
+			event.InclusionSlot,
+			event.BlockNumber,
+			event.TransactionIndex,
+			event.TransactionHash[:],
+			event.Index,
+			event.Address[:],
+			topics,
+			event.Data,
+		); err != nil {
+			return errors.Wrap(err, "failed to set transaction event")
+		}
+	}
+
+	return nil
+}
+
+// TransactionReceipts provides transaction receipts according to the filter.
+func (s *Service) TransactionReceipts(ctx context.Context,
+	filter *chaindb.TransactionReceiptFilter,
+) (
+	[]*chaindb.TransactionReceipt,
+	error,
+) {
+	ctx, span := startSpan(ctx, "TransactionReceipts")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_block_root
+      ,f_slot
+      ,f_block_number
+      ,f_index
+      ,f_hash
+      ,f_from
+      ,f_to
+      ,f_contract_address
+      ,f_status
+      ,f_gas_used
+FROM t_block_transaction_receipts`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.Hashes) > 0 {
+		hashes := make([][]byte, len(filter.Hashes))
+		for i := range filter.Hashes {
+			hashes[i] = filter.Hashes[i][:]
+		}
+		queryVals = append(queryVals, hashes)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_hash = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.Status != nil {
+		queryVals = append(queryVals, *filter.Status)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_status = $%d`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot,f_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC,f_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	receipts := make([]*chaindb.TransactionReceipt, 0)
+	for rows.Next() {
+		receipt := &chaindb.TransactionReceipt{}
+		var blockRoot []byte
+		var hash []byte
+		var from []byte
+		var to []byte
+		var contractAddress []byte
+		err := rows.Scan(
+			&blockRoot,
+			&receipt.InclusionSlot,
+			&receipt.BlockNumber,
+			&receipt.Index,
+			&hash,
+			&from,
+			&to,
+			&contractAddress,
+			&receipt.Status,
+			&receipt.GasUsed,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(receipt.InclusionBlockRoot[:], blockRoot)
+		copy(receipt.Hash[:], hash)
+		copy(receipt.From[:], from)
+		if len(to) == 20 {
+			receipt.To = &[20]byte{}
+			copy(receipt.To[:], to)
+		}
+		if len(contractAddress) == 20 {
+			receipt.ContractAddress = &[20]byte{}
+			copy(receipt.ContractAddress[:], contractAddress)
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	// Always return order of slot then index.
+	sort.Slice(receipts, func(i int, j int) bool {
+		if receipts[i].InclusionSlot != receipts[j].InclusionSlot {
+			return receipts[i].InclusionSlot < receipts[j].InclusionSlot
+		}
+		return receipts[i].Index < receipts[j].Index
+	})
+
+	return receipts, nil
+}
+
+// TransactionEvents provides transaction events according to the filter.
+func (s *Service) TransactionEvents(ctx context.Context,
+	filter *chaindb.TransactionEventFilter,
+) (
+	[]*chaindb.TransactionEvent,
+	error,
+) {
+	ctx, span := startSpan(ctx, "TransactionEvents")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_block_root
+      ,f_slot
+      ,f_block_number
+      ,f_transaction_index
+      ,f_transaction_hash
+      ,f_index
+      ,f_address
+      ,f_topics
+      ,f_data
+FROM t_block_transaction_events`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.Addresses) > 0 {
+		addresses := make([][]byte, len(filter.Addresses))
+		for i := range filter.Addresses {
+			addresses[i] = filter.Addresses[i][:]
+		}
+		queryVals = append(queryVals, addresses)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_address = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.Topics) > 0 {
+		topics := make([][]byte, len(filter.Topics))
+		for i := range filter.Topics {
+			topics[i] = filter.Topics[i][:]
+		}
+		queryVals = append(queryVals, topics)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_topics[1] = ANY($%d)`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot,f_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC,f_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*chaindb.TransactionEvent, 0)
+	for rows.Next() {
+		event := &chaindb.TransactionEvent{}
+		var blockRoot []byte
+		var transactionHash []byte
+		var address []byte
+		var topics [][]byte
+		err := rows.Scan(
+			&blockRoot,
+			&event.InclusionSlot,
+			&event.BlockNumber,
+			&event.TransactionIndex,
+			&transactionHash,
+			&event.Index,
+			&address,
+			&topics,
+			&event.Data,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(event.InclusionBlockRoot[:], blockRoot)
+		copy(event.TransactionHash[:], transactionHash)
+		copy(event.Address[:], address)
+		event.Topics = make([][32]byte, len(topics))
+		for i := range topics {
+			copy(event.Topics[i][:], topics[i])
+		}
+		events = append(events, event)
+	}
+
+	// Always return order of slot then index.
+	sort.Slice(events, func(i int, j int) bool {
+		if events[i].InclusionSlot != events[j].InclusionSlot {
+			return events[i].InclusionSlot < events[j].InclusionSlot
+		}
+		return events[i].Index < events[j].Index
+	})
+
+	return events, nil
+}
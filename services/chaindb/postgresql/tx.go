@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/pkg/errors"
@@ -26,6 +27,9 @@ import (
 // is not inside a transaction.
 var ErrNoTransaction = errors.New("no transaction for action")
 
+// txCleanupTimeout is the time allowed to end a transaction whose context has been cancelled.
+const txCleanupTimeout = 10 * time.Second
+
 // Tx is a context tag for the database transaction.
 type Tx struct{}
 
@@ -47,12 +51,27 @@ func (s *Service) BeginTx(ctx context.Context) (context.Context, context.CancelF
 		return nil, nil, errors.Wrap(err, "failed to begin transaction")
 	}
 
+	if s.writeBatchSize > 1 {
+		tx = newQueuedTx(tx, s.writeBatchSize, s.writeFlushInterval)
+	}
 	ctx = context.WithValue(ctx, &Tx{}, tx)
 	ctx = context.WithValue(ctx, &TxID{}, id)
+	if s.readCache != nil {
+		ctx = context.WithValue(ctx, &ReadCacheInvalidations{}, &readCacheInvalidations{
+			keys: make(map[string]struct{}),
+		})
+	}
+	if s.audit {
+		ctx = context.WithValue(ctx, &AuditRecord{}, newAuditRecord())
+	}
 
 	log.Trace().Str("trace", fmt.Sprintf("%+v", errors.New("stack"))).Msg("Transaction started")
 	return ctx, func() {
-		if err := tx.Rollback(ctx); err != nil {
+		// The context may have been cancelled, which is often the reason for
+		// the rollback, so roll back with a context that is not.
+		rollbackCtx, rollbackCancel := context.WithTimeout(context.WithoutCancel(ctx), txCleanupTimeout)
+		defer rollbackCancel()
+		if err := tx.Rollback(rollbackCtx); err != nil {
 			log.Debug().Err(err).Str("trace", fmt.Sprintf("%+v", errors.Wrap(err, "stack"))).Msg("Failed to rollback transaction")
 			log.Warn().Err(err).Msg("Failed to rollback transaction")
 		}
@@ -93,6 +112,11 @@ func (*Service) tx(ctx context.Context) pgx.Tx {
 	return nil
 }
 
+// Tx returns the transaction held in the context, for plugins; nil if no transaction.
+func (s *Service) Tx(ctx context.Context) pgx.Tx {
+	return s.tx(ctx)
+}
+
 // txID returns the transaction ID; "<unknown>" string if no transaction.
 func (*Service) txID(ctx context.Context) string {
 	if ctx == nil {
@@ -120,10 +144,12 @@ func (s *Service) CommitTx(ctx context.Context) error {
 		return errors.New("no transaction")
 	}
 
+	s.writeAuditEntry(ctx, tx)
 	if err := tx.Commit(ctx); err != nil {
 		log.Debug().Err(err).Str("trace", fmt.Sprintf("%+v", errors.Wrap(err, "stack"))).Msg("Failed to commit")
 		return err
 	}
+	s.applyReadCacheInvalidations(ctx)
 
 	log.Trace().Str("trace", fmt.Sprintf("%+v", errors.New("stack"))).Msg("Transaction committed")
 	return nil
@@ -144,7 +170,11 @@ func (s *Service) CommitROTx(ctx context.Context) {
 		return
 	}
 
-	if err := tx.Commit(ctx); err != nil {
+	// Committing a read-only transaction only releases it, so do so even if
+	// the context has been cancelled.
+	commitCtx, commitCancel := context.WithTimeout(context.WithoutCancel(ctx), txCleanupTimeout)
+	defer commitCancel()
+	if err := tx.Commit(commitCtx); err != nil {
 		log.Debug().Err(err).Str("trace", fmt.Sprintf("%+v", errors.Wrap(err, "stack"))).Msg("Failed to commit")
 		return
 	}
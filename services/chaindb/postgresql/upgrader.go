@@ -21,17 +21,22 @@ import (
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
 )
 
 type schemaMetadata struct {
 	Version uint64 `json:"version"`
 }
 
-var currentVersion = uint64(14)
+var currentVersion = uint64(63)
 
 type upgrade struct {
 	requiresRefetch bool
 	funcs           []func(context.Context, *Service) error
+	// downFuncs reverse the upgrade.  They are only present where the
+	// upgrade can be reversed without losing indexed data that cannot be
+	// recalculated.
+	downFuncs []func(context.Context, *Service) error
 }
 
 var upgrades = map[uint64]*upgrade{
@@ -126,11 +131,406 @@ var upgrades = map[uint64]*upgrade{
 			addBlobGasUsed,
 		},
 	},
+	15: {
+		funcs: []func(context.Context, *Service) error{
+			createValidatorCredentialsChanges,
+			createValidatorConsolidations,
+		},
+	},
+	16: {
+		funcs: []func(context.Context, *Service) error{
+			createArchiveOffloads,
+		},
+	},
+	17: {
+		funcs: []func(context.Context, *Service) error{
+			createProgress,
+			migrateMetadataToProgress,
+		},
+	},
+	18: {
+		funcs: []func(context.Context, *Service) error{
+			addChildCanonical,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropChildCanonical,
+		},
+	},
+	19: {
+		funcs: []func(context.Context, *Service) error{
+			addEpochParticipation,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropEpochParticipation,
+		},
+	},
+	20: {
+		funcs: []func(context.Context, *Service) error{
+			createBlockClientFingerprints,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropBlockClientFingerprints,
+		},
+	},
+	21: {
+		funcs: []func(context.Context, *Service) error{
+			createArrivals,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropArrivals,
+		},
+	},
+	22: {
+		funcs: []func(context.Context, *Service) error{
+			createSchemaHistory,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropSchemaHistory,
+		},
+	},
+	23: {
+		funcs: []func(context.Context, *Service) error{
+			createValidatorDayRankings,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropValidatorDayRankings,
+		},
+	},
+	24: {
+		funcs: []func(context.Context, *Service) error{
+			createNetworkAggregates,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropNetworkAggregates,
+		},
+	},
+	25: {
+		funcs: []func(context.Context, *Service) error{
+			createEntryQueues,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropEntryQueues,
+		},
+	},
+	26: {
+		funcs: []func(context.Context, *Service) error{
+			createEquivocations,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropEquivocations,
+		},
+	},
+	27: {
+		funcs: []func(context.Context, *Service) error{
+			createOutbox,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropOutbox,
+		},
+	},
+	28: {
+		funcs: []func(context.Context, *Service) error{
+			createWatchlist,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropWatchlist,
+		},
+	},
+	29: {
+		funcs: []func(context.Context, *Service) error{
+			createVerificationDisagreements,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropVerificationDisagreements,
+		},
+	},
+	30: {
+		funcs: []func(context.Context, *Service) error{
+			createTransactionReceipts,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropTransactionReceipts,
+		},
+	},
+	31: {
+		funcs: []func(context.Context, *Service) error{
+			createAddressLabels,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropAddressLabels,
+		},
+	},
+	32: {
+		funcs: []func(context.Context, *Service) error{
+			createGraffitiIndex,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropGraffitiIndex,
+		},
+	},
+	33: {
+		funcs: []func(context.Context, *Service) error{
+			createCommitteeEpochSummaries,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropCommitteeEpochSummaries,
+		},
+	},
+	34: {
+		funcs: []func(context.Context, *Service) error{
+			createEpochCheckpoints,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropEpochCheckpoints,
+		},
+	},
+	35: {
+		funcs: []func(context.Context, *Service) error{
+			createRawBlocks,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropRawBlocks,
+		},
+	},
+	36: {
+		funcs: []func(context.Context, *Service) error{
+			createWatchlistAlerts,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropWatchlistAlerts,
+		},
+	},
+	37: {
+		funcs: []func(context.Context, *Service) error{
+			createValidatorShards,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropValidatorShards,
+		},
+	},
+	38: {
+		funcs: []func(context.Context, *Service) error{
+			createSchemaMigrations,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropSchemaMigrations,
+		},
+	},
+	39: {
+		funcs: []func(context.Context, *Service) error{
+			createValidatorSyncPeriodSummaries,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropValidatorSyncPeriodSummaries,
+		},
+	},
+	40: {
+		funcs: []func(context.Context, *Service) error{
+			addExecutionPayloadValue,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropExecutionPayloadValue,
+		},
+	},
+	41: {
+		funcs: []func(context.Context, *Service) error{
+			createProposerPeriodSummaries,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropProposerPeriodSummaries,
+		},
+	},
+	42: {
+		funcs: []func(context.Context, *Service) error{
+			createETH1DepositDiscrepancies,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropETH1DepositDiscrepancies,
+		},
+	},
+	43: {
+		funcs: []func(context.Context, *Service) error{
+			addEpochBlockStats,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropEpochBlockStats,
+		},
+	},
+	44: {
+		funcs: []func(context.Context, *Service) error{
+			createQueueProjections,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropQueueProjections,
+		},
+	},
+	45: {
+		funcs: []func(context.Context, *Service) error{
+			createHeadObservations,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropHeadObservations,
+		},
+	},
+	46: {
+		funcs: []func(context.Context, *Service) error{
+			addAttestationCorrectness,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropAttestationCorrectness,
+		},
+	},
+	47: {
+		funcs: []func(context.Context, *Service) error{
+			numericWithdrawalAmounts,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			bigintWithdrawalAmounts,
+		},
+	},
+	48: {
+		funcs: []func(context.Context, *Service) error{
+			createValidatorClusters,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropValidatorClusters,
+		},
+	},
+	49: {
+		funcs: []func(context.Context, *Service) error{
+			createFailedItems,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropFailedItems,
+		},
+	},
+	50: {
+		funcs: []func(context.Context, *Service) error{
+			createForks,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropForks,
+		},
+	},
+	51: {
+		funcs: []func(context.Context, *Service) error{
+			addSyncAggregateSignatures,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropSyncAggregateSignatures,
+		},
+	},
+	52: {
+		funcs: []func(context.Context, *Service) error{
+			addChainSpecTypes,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropChainSpecTypes,
+		},
+	},
+	53: {
+		funcs: []func(context.Context, *Service) error{
+			createWithdrawalForecasts,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropWithdrawalForecasts,
+		},
+	},
+	54: {
+		funcs: []func(context.Context, *Service) error{
+			createAuditLog,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropAuditLog,
+		},
+	},
+	55: {
+		funcs: []func(context.Context, *Service) error{
+			createBlobFees,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropBlobFees,
+		},
+	},
+	56: {
+		funcs: []func(context.Context, *Service) error{
+			createValidatorAnomalies,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropValidatorAnomalies,
+		},
+	},
+	57: {
+		funcs: []func(context.Context, *Service) error{
+			createBeaconCommitteeMembersIndex,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropBeaconCommitteeMembersIndex,
+		},
+	},
+	58: {
+		funcs: []func(context.Context, *Service) error{
+			createBalanceAnomalies,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropBalanceAnomalies,
+		},
+	},
+	59: {
+		funcs: []func(context.Context, *Service) error{
+			createBlockHeaders,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropBlockHeaders,
+		},
+	},
+	60: {
+		funcs: []func(context.Context, *Service) error{
+			addExecutionPayloadTransactionStats,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropExecutionPayloadTransactionStats,
+		},
+	},
+	61: {
+		funcs: []func(context.Context, *Service) error{
+			createShufflingMismatches,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropShufflingMismatches,
+		},
+	},
+	62: {
+		funcs: []func(context.Context, *Service) error{
+			createRANDAOMixes,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropRANDAOMixes,
+		},
+	},
+	63: {
+		funcs: []func(context.Context, *Service) error{
+			createClientReleases,
+		},
+		downFuncs: []func(context.Context, *Service) error{
+			dropClientReleases,
+		},
+	},
 }
 
 // Upgrade upgrades the database.
+// If the schema is externally managed it is validated rather than upgraded.
 // Returns true if the upgrade requires blocks to be refetched.
 func (s *Service) Upgrade(ctx context.Context) (bool, error) {
+	if err := s.ensureSchema(ctx); err != nil {
+		return false, err
+	}
+
+	if s.externalSchema {
+		// The schema is not ours to change, so check that it is as expected.
+		return false, s.checkExternalSchema(ctx)
+	}
+
 	// See if we have anything at all.
 	tableExists, err := s.tableExists(ctx, "t_metadata")
 	if err != nil {
@@ -155,39 +555,14 @@ func (s *Service) Upgrade(ctx context.Context) (bool, error) {
 		return false, nil
 	}
 
-	ctx, cancel, err := s.BeginTx(ctx)
+	res, err := s.Migrate(ctx, &MigrateOpts{})
 	if err != nil {
-		return false, errors.Wrap(err, "failed to begin upgrade transaction")
-	}
-
-	requiresRefetch := false
-	for i := version + 1; i <= currentVersion; i++ {
-		log.Info().Uint64("target_version", i).Msg("Upgrading database")
-		if upgrade, exists := upgrades[i]; exists {
-			for i, upgradeFunc := range upgrade.funcs {
-				log.Info().Int("current", i+1).Int("total", len(upgrade.funcs)).Msg("Running upgrade function")
-				if err := upgradeFunc(ctx, s); err != nil {
-					cancel()
-					return false, errors.Wrap(err, "failed to upgrade")
-				}
-			}
-			requiresRefetch = requiresRefetch || upgrade.requiresRefetch
-		}
-	}
-
-	if err := s.setVersion(ctx, currentVersion); err != nil {
-		cancel()
-		return false, errors.Wrap(err, "failed to set latest schema version")
-	}
-
-	if err := s.CommitTx(ctx); err != nil {
-		cancel()
-		return false, errors.Wrap(err, "failed to commit upgrade transaction")
+		return false, err
 	}
 
 	log.Info().Msg("Upgrade complete")
 
-	return requiresRefetch, nil
+	return res.RequiresRefetch, nil
 }
 
 // validatorsEpochNull allows epochs in the t_validators table to be NULL.
@@ -828,17 +1203,24 @@ CREATE UNIQUE INDEX i_sync_aggregates_1 ON t_sync_aggregates(f_inclusion_slot, f
 
 // Init initialises the database.
 func (s *Service) Init(ctx context.Context) error {
-	ctx, cancel, err := s.BeginTx(ctx)
-	if err != nil {
-		return errors.Wrap(err, "failed to begin initial tables transaction")
+	if _, err := s.Migrate(ctx, &MigrateOpts{}); err != nil {
+		return errors.Wrap(err, "failed to create initial tables")
 	}
+
+	return nil
+}
+
+// createInitialTables creates the tables for the latest version of the schema.
+func createInitialTables(ctx context.Context, s *Service) error {
 	tx := s.tx(ctx)
 	if tx == nil {
-		cancel()
 		return ErrNoTransaction
 	}
 
 	if _, err := tx.Exec(ctx, `
+-- pg_trgm provides trigram indexes, used to search graffiti.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
 -- t_metadata stores data about chaind processing functions.
 CREATE TABLE t_metadata (
   f_key    TEXT NOT NULL PRIMARY KEY
@@ -851,6 +1233,7 @@ CREATE UNIQUE INDEX i_metadata_1 ON t_metadata(f_key);
 CREATE TABLE t_chain_spec (
   f_key TEXT NOT NULL PRIMARY KEY
  ,f_value TEXT NOT NULL
+ ,f_type TEXT
 );
 
 -- t_genesis contains the genesis parameters of the chain.
@@ -903,6 +1286,7 @@ CREATE TABLE t_blocks (
 CREATE UNIQUE INDEX i_blocks_1 ON t_blocks(f_slot,f_root);
 CREATE UNIQUE INDEX i_blocks_2 ON t_blocks(f_root);
 CREATE INDEX i_blocks_3 ON t_blocks(f_parent_root);
+CREATE INDEX i_blocks_4 ON t_blocks USING GIN(encode(btrim(f_graffiti,'\x00'::bytea),'escape') gin_trgm_ops);
 
 -- t_block_execution_payloads is a subtable for t_blocks.
 CREATE TABLE t_block_execution_payloads (
@@ -922,6 +1306,12 @@ CREATE TABLE t_block_execution_payloads (
  ,f_timestamp        BIGINT NOT NULL
  ,f_blob_gas_used    BIGINT NOT NULL DEFAULT 0
  ,f_excess_blob_gas  BIGINT NOT NULL DEFAULT 0
+ ,f_canonical        BOOL
+ ,f_payload_value    NUMERIC
+ ,f_payload_value_source TEXT
+ ,f_transaction_count BIGINT
+ ,f_transactions_size BIGINT
+ ,f_blob_transaction_count BIGINT
 );
 
 -- t_beacon_committees contains all beacon committees.
@@ -932,6 +1322,7 @@ CREATE TABLE t_beacon_committees (
  ,f_committee BIGINT[] NOT NULL -- REFERENCES t_validators(f_index)
 );
 CREATE UNIQUE INDEX i_beacon_committees_1 ON t_beacon_committees(f_slot, f_index);
+CREATE INDEX i_beacon_committees_2 ON t_beacon_committees USING GIN(f_committee);
 
 -- t_proposer_duties contains all proposer duties.
 -- N.B. in the case of a chain re-org the duties can alter.
@@ -958,6 +1349,9 @@ CREATE TABLE t_attestations (
  ,f_canonical            BOOL
  ,f_target_correct       BOOL
  ,f_head_correct         BOOL
+ ,f_source_correct       BOOL
+ ,f_inclusion_target_correct BOOL
+ ,f_inclusion_head_correct   BOOL
 );
 CREATE UNIQUE INDEX i_attestations_1 ON t_attestations(f_inclusion_slot,f_inclusion_block_root,f_inclusion_index);
 CREATE INDEX i_attestations_2 ON t_attestations(f_slot);
@@ -969,6 +1363,7 @@ CREATE TABLE t_sync_aggregates (
  ,f_inclusion_block_root BYTEA NOT NULL REFERENCES t_blocks(f_root) ON DELETE CASCADE
  ,f_bits                 BYTEA NOT NULL
  ,f_indices              BIGINT[] -- REFERENCES t_validators(f_index)
+ ,f_signature            BYTEA
 );
 CREATE UNIQUE INDEX i_sync_aggregates_1 ON t_sync_aggregates(f_inclusion_slot, f_inclusion_block_root);
 
@@ -1038,6 +1433,7 @@ CREATE TABLE t_deposits (
  ,f_validator_pubkey       BYTEA NOT NULL
  ,f_withdrawal_credentials BYTEA NOT NULL
  ,f_amount                 BIGINT NOT NULL
+ ,f_canonical              BOOL
 );
 CREATE UNIQUE INDEX i_deposits_1 ON t_deposits(f_inclusion_slot,f_inclusion_block_root,f_inclusion_index);
 CREATE INDEX i_deposits_2 ON t_deposits(f_validator_pubkey,f_inclusion_slot);
@@ -1065,6 +1461,130 @@ CREATE INDEX i_eth1_deposits_3 ON t_eth1_deposits(f_withdrawal_credentials);
 CREATE INDEX i_eth1_deposits_4 ON t_eth1_deposits(f_eth1_sender);
 CREATE INDEX i_eth1_deposits_5 ON t_eth1_deposits(f_eth1_recipient);
 
+-- t_eth1_deposit_discrepancies contains differences between the deposit contract and indexed deposits.
+CREATE TABLE t_eth1_deposit_discrepancies (
+  f_eth1_block_number        BIGINT PRIMARY KEY
+ ,f_timestamp                TIMESTAMPTZ NOT NULL
+ ,f_contract_deposit_count   BIGINT NOT NULL
+ ,f_indexed_deposit_count    BIGINT NOT NULL
+ ,f_contract_deposit_root    BYTEA NOT NULL
+ ,f_indexed_deposit_root     BYTEA NOT NULL
+ ,f_missing_deposit_indices  BIGINT[] NOT NULL
+);
+
+-- t_queue_projections contains projected activation and exit epochs of validators, as projected at each epoch.
+CREATE TABLE t_queue_projections (
+  f_epoch            BIGINT NOT NULL
+ ,f_validator_index  BIGINT NOT NULL
+ ,f_activation_epoch BIGINT
+ ,f_exit_epoch       BIGINT
+);
+CREATE UNIQUE INDEX i_queue_projections_1 ON t_queue_projections(f_epoch,f_validator_index);
+CREATE INDEX i_queue_projections_2 ON t_queue_projections(f_validator_index);
+
+-- t_withdrawal_forecasts contains the forecast full withdrawals of exited validators.
+CREATE TABLE t_withdrawal_forecasts (
+  f_validator_index    BIGINT NOT NULL PRIMARY KEY
+ ,f_epoch              BIGINT NOT NULL
+ ,f_withdrawable_epoch BIGINT NOT NULL
+ ,f_slot               BIGINT
+);
+CREATE INDEX i_withdrawal_forecasts_1 ON t_withdrawal_forecasts(f_slot);
+
+-- t_audit_log contains the writes made by each transaction, if auditing is enabled.
+CREATE TABLE t_audit_log (
+  f_timestamp  TIMESTAMPTZ NOT NULL
+ ,f_started    TIMESTAMPTZ NOT NULL
+ ,f_tx_id      TEXT NOT NULL
+ ,f_module     TEXT NOT NULL
+ ,f_operations JSONB NOT NULL
+ ,f_rows       BIGINT NOT NULL
+);
+CREATE INDEX i_audit_log_1 ON t_audit_log(f_timestamp);
+CREATE INDEX i_audit_log_2 ON t_audit_log(f_module,f_timestamp);
+
+-- t_blob_fees contains the state of the blob fee market at each canonical block.
+CREATE TABLE t_blob_fees (
+  f_slot                      BIGINT PRIMARY KEY
+ ,f_execution_block_number    BIGINT NOT NULL
+ ,f_excess_blob_gas           BIGINT NOT NULL
+ ,f_blob_gas_used             BIGINT NOT NULL
+ ,f_target_blob_gas           BIGINT NOT NULL
+ ,f_blob_base_fee             NUMERIC NOT NULL
+ ,f_window_blocks             INTEGER NOT NULL
+ ,f_window_blob_gas_used      BIGINT NOT NULL
+ ,f_window_mean_blob_base_fee NUMERIC NOT NULL
+ ,f_window_min_blob_base_fee  NUMERIC NOT NULL
+ ,f_window_max_blob_base_fee  NUMERIC NOT NULL
+);
+
+-- t_validator_anomalies contains inconsistencies found in validator and deposit data.
+CREATE TABLE t_validator_anomalies (
+  f_type                     TEXT NOT NULL
+ ,f_public_key               BYTEA NOT NULL
+ ,f_validator_index          BIGINT
+ ,f_existing_public_key      BYTEA
+ ,f_existing_validator_index BIGINT
+ ,f_inclusion_slot           BIGINT
+ ,f_first_seen               TIMESTAMPTZ NOT NULL
+ ,f_last_seen                TIMESTAMPTZ NOT NULL
+ ,f_details                  TEXT NOT NULL
+);
+CREATE INDEX i_validator_anomalies_1 ON t_validator_anomalies(f_public_key);
+CREATE INDEX i_validator_anomalies_2 ON t_validator_anomalies(f_last_seen);
+
+-- t_balance_anomalies contains validator balance changes that are outside of the range expected from rewards and penalties.
+CREATE TABLE t_balance_anomalies (
+  f_validator_index    BIGINT NOT NULL
+ ,f_epoch              BIGINT NOT NULL
+ ,f_reason             TEXT NOT NULL
+ ,f_previous_balance   BIGINT NOT NULL
+ ,f_balance            BIGINT NOT NULL
+ ,f_withdrawn          BIGINT NOT NULL
+ ,f_deposited          BIGINT NOT NULL
+ ,f_delta              BIGINT NOT NULL
+ ,f_expected_min_delta BIGINT NOT NULL
+ ,f_expected_max_delta BIGINT
+ ,f_deviation          BIGINT NOT NULL
+);
+CREATE UNIQUE INDEX i_balance_anomalies_1 ON t_balance_anomalies(f_validator_index,f_epoch);
+CREATE INDEX i_balance_anomalies_2 ON t_balance_anomalies(f_epoch);
+
+-- t_block_headers contains the headers of blocks, fetched ahead of their bodies.
+CREATE TABLE t_block_headers (
+  f_slot           BIGINT NOT NULL
+ ,f_proposer_index BIGINT NOT NULL
+ ,f_root           BYTEA NOT NULL
+ ,f_parent_root    BYTEA NOT NULL
+ ,f_state_root     BYTEA NOT NULL
+ ,f_body_root      BYTEA NOT NULL
+);
+CREATE UNIQUE INDEX i_block_headers_1 ON t_block_headers(f_slot,f_root);
+CREATE UNIQUE INDEX i_block_headers_2 ON t_block_headers(f_root);
+CREATE INDEX i_block_headers_3 ON t_block_headers(f_parent_root);
+
+-- t_shuffling_mismatches contains beacon committees that differ from those calculated locally.
+CREATE TABLE t_shuffling_mismatches (
+  f_slot               BIGINT NOT NULL
+ ,f_index              BIGINT NOT NULL
+ ,f_committee          BIGINT[]
+ ,f_expected_committee BIGINT[]
+ ,f_detected           TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX i_shuffling_mismatches_1 ON t_shuffling_mismatches(f_slot,f_index);
+
+-- t_head_observations contains the head of the chain as observed from the beacon node in each slot.
+CREATE TABLE t_head_observations (
+  f_slot        BIGINT PRIMARY KEY
+ ,f_observed_at TIMESTAMPTZ NOT NULL
+ ,f_head_slot   BIGINT NOT NULL
+ ,f_head_root   BYTEA NOT NULL
+ ,f_parent_root BYTEA NOT NULL
+ ,f_reorg       BOOL NOT NULL
+ ,f_reorg_depth BIGINT NOT NULL
+);
+CREATE INDEX i_head_observations_1 ON t_head_observations(f_head_root);
+
 -- t_validator_balances contains per-epoch balances.
 CREATE TABLE t_validator_balances (
   f_validator_index   BIGINT NOT NULL REFERENCES t_validators(f_index) ON DELETE CASCADE
@@ -1121,6 +1641,68 @@ CREATE TABLE t_epoch_summaries (
  ,f_exiting_validators               BIGINT NOT NULL
  ,f_canonical_blocks                 BIGINT NOT NULL
  ,f_withdrawals                      BIGINT NOT NULL
+ ,f_source_timely_validators         BIGINT
+ ,f_source_timely_balance            BIGINT
+ ,f_participation_rate               DOUBLE PRECISION
+ ,f_source_timely_rate               DOUBLE PRECISION
+ ,f_target_correct_rate              DOUBLE PRECISION
+ ,f_head_correct_rate                DOUBLE PRECISION
+ ,f_missed_proposals                 BIGINT
+ ,f_orphaned_blocks                  BIGINT
+ ,f_voluntary_exits                  BIGINT
+ ,f_average_inclusion_delay          DOUBLE PRECISION
+ ,f_source_correct_validators           BIGINT
+ ,f_source_correct_balance              BIGINT
+ ,f_inclusion_target_correct_validators BIGINT
+ ,f_inclusion_target_correct_balance    BIGINT
+ ,f_inclusion_head_correct_validators   BIGINT
+ ,f_inclusion_head_correct_balance      BIGINT
+);
+
+-- t_entry_queues contains the state of the validator entry queue for each epoch.
+CREATE TABLE t_entry_queues (
+  f_epoch                  BIGINT UNIQUE NOT NULL
+ ,f_pending_validators     BIGINT NOT NULL
+ ,f_queue_length           BIGINT NOT NULL
+ ,f_queue_balance          BIGINT NOT NULL
+ ,f_activation_churn_limit BIGINT NOT NULL
+ ,f_projected_wait         BIGINT NOT NULL
+ ,f_activated_validators   BIGINT NOT NULL
+ ,f_average_wait           DOUBLE PRECISION NOT NULL
+);
+
+-- t_equivocations contains slashable offences found in indexed data, whether or not they were slashed.
+CREATE TABLE t_equivocations (
+  f_type            TEXT NOT NULL
+ ,f_validator_index BIGINT NOT NULL
+ ,f_slot_1          BIGINT NOT NULL
+ ,f_root_1          BYTEA NOT NULL
+ ,f_slot_2          BIGINT NOT NULL
+ ,f_root_2          BYTEA NOT NULL
+ ,f_source_epoch_1  BIGINT
+ ,f_target_epoch_1  BIGINT
+ ,f_source_epoch_2  BIGINT
+ ,f_target_epoch_2  BIGINT
+ ,f_slashed         BOOLEAN NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_equivocations_1 ON t_equivocations(f_validator_index, f_type, f_root_1, f_root_2);
+CREATE INDEX IF NOT EXISTS i_equivocations_2 ON t_equivocations(f_slot_2);
+
+-- t_network_aggregates contains network-wide statistics for each epoch.
+CREATE TABLE t_network_aggregates (
+  f_epoch                        BIGINT UNIQUE NOT NULL
+ ,f_total_balance                BIGINT NOT NULL
+ ,f_active_validators            BIGINT NOT NULL
+ ,f_active_balance               BIGINT NOT NULL
+ ,f_average_balance              BIGINT NOT NULL
+ ,f_entering_validators          BIGINT NOT NULL
+ ,f_exiting_validators           BIGINT NOT NULL
+ ,f_activated_validators         BIGINT NOT NULL
+ ,f_exited_validators            BIGINT NOT NULL
+ ,f_activation_churn_limit       BIGINT NOT NULL
+ ,f_exit_churn_limit             BIGINT NOT NULL
+ ,f_activation_churn_utilization DOUBLE PRECISION NOT NULL
+ ,f_exit_churn_utilization       DOUBLE PRECISION NOT NULL
 );
 
 CREATE TABLE t_fork_schedule (
@@ -1129,6 +1711,14 @@ CREATE TABLE t_fork_schedule (
  ,f_previous_version BYTEA NOT NULL
 );
 
+-- t_forks contains the scheduled forks of the chain.
+CREATE TABLE t_forks (
+  f_name    TEXT PRIMARY KEY
+ ,f_epoch   BIGINT NOT NULL
+ ,f_version BYTEA UNIQUE NOT NULL
+ ,f_digest  BYTEA NOT NULL
+);
+
 CREATE TABLE t_sync_committees (
   f_period    BIGINT NOT NULL
  ,f_committee BIGINT[] NOT NULL -- REFERENCES t_validators(f_index)
@@ -1160,6 +1750,17 @@ CREATE TABLE t_validator_day_summaries (
 CREATE UNIQUE INDEX IF NOT EXISTS i_validator_day_summaries_1 ON t_validator_day_summaries(f_validator_index, f_start_timestamp);
 CREATE INDEX IF NOT EXISTS i_validator_day_summaries_2 ON t_validator_day_summaries(f_start_timestamp);
 
+-- t_validator_day_rankings contains the ranking of each validator's effectiveness for a day.
+CREATE TABLE t_validator_day_rankings (
+  f_validator_index BIGINT NOT NULL
+ ,f_start_timestamp TIMESTAMPTZ NOT NULL
+ ,f_effectiveness   DOUBLE PRECISION NOT NULL
+ ,f_percentile      DOUBLE PRECISION NOT NULL
+ ,f_z_score         DOUBLE PRECISION NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_validator_day_rankings_1 ON t_validator_day_rankings(f_validator_index, f_start_timestamp);
+CREATE INDEX IF NOT EXISTS i_validator_day_rankings_2 ON t_validator_day_rankings(f_start_timestamp);
+
 CREATE TABLE t_block_bls_to_execution_changes (
   f_block_root            BYTEA   NOT NULL REFERENCES t_blocks(f_root) ON DELETE CASCADE
  ,f_block_number          BIGINT  NOT NULL
@@ -1181,7 +1782,8 @@ CREATE TABLE t_block_withdrawals (
  ,f_withdrawal_index INTEGER NOT NULL
  ,f_validator_index  BIGINT  NOT NULL
  ,f_address          BYTEA   NOT NULL
- ,f_amount           BIGINT  NOT NULL
+ ,f_amount           NUMERIC NOT NULL
+ ,f_canonical        BOOL
 );
 CREATE UNIQUE INDEX IF NOT EXISTS i_block_withdrawals_1 ON t_block_withdrawals(f_block_root,f_block_number,f_index);
 CREATE INDEX IF NOT EXISTS i_block_withdrawals_2 ON t_block_withdrawals(f_block_number);
@@ -1199,30 +1801,359 @@ CREATE TABLE t_blob_sidecars (
 );
 CREATE UNIQUE INDEX i_blob_sidecars_1 ON t_blob_sidecars(f_block_root,f_index);
 CREATE INDEX i_blob_sidecars_2 ON t_blob_sidecars(f_slot);
-`); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to create initial tables")
-	}
 
-	if err := s.setVersion(ctx, currentVersion); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set initial schema version")
-	}
-
-	if err := s.CommitTx(ctx); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to commit initial tables transaction")
-	}
+-- t_validator_credentials_changes contains the history of validators' withdrawal credentials.
+CREATE TABLE t_validator_credentials_changes (
+  f_validator_index           BIGINT NOT NULL
+ ,f_epoch                     BIGINT NOT NULL
+ ,f_withdrawal_credentials    BYTEA NOT NULL
+ ,f_effective_balance_ceiling BIGINT NOT NULL
+);
+CREATE UNIQUE INDEX i_validator_credentials_changes_1 ON t_validator_credentials_changes(f_validator_index,f_epoch);
+CREATE INDEX i_validator_credentials_changes_2 ON t_validator_credentials_changes(f_epoch);
+
+-- t_validator_consolidations contains consolidations of balances between validators.
+CREATE TABLE t_validator_consolidations (
+  f_source_index BIGINT NOT NULL
+ ,f_target_index BIGINT NOT NULL
+ ,f_epoch        BIGINT NOT NULL
+ ,f_amount       BIGINT NOT NULL
+);
+CREATE UNIQUE INDEX i_validator_consolidations_1 ON t_validator_consolidations(f_source_index,f_target_index,f_epoch);
+CREATE INDEX i_validator_consolidations_2 ON t_validator_consolidations(f_target_index);
+CREATE INDEX i_validator_consolidations_3 ON t_validator_consolidations(f_epoch);
+
+-- t_archive_offloads contains the locations of data offloaded to cold storage.
+CREATE TABLE t_archive_offloads (
+  f_table     TEXT NOT NULL
+ ,f_epoch     BIGINT NOT NULL
+ ,f_key       TEXT NOT NULL
+ ,f_location  TEXT NOT NULL
+ ,f_rows      BIGINT NOT NULL
+ ,f_timestamp TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX i_archive_offloads_1 ON t_archive_offloads(f_table,f_epoch);
 
-	return nil
-}
+-- t_progress contains the progress of chaind's services.
+CREATE TABLE t_progress (
+  f_service TEXT NOT NULL
+ ,f_key     TEXT NOT NULL
+ ,f_value   BIGINT NOT NULL
+);
+CREATE UNIQUE INDEX i_progress_1 ON t_progress(f_service,f_key);
 
-// addValidatorSummaryTimely adds timely fields to the t_validator_epoch_summaries table.
-func addValidatorSummaryTimely(ctx context.Context, s *Service) error {
-	tx := s.tx(ctx)
-	if tx == nil {
-		return ErrNoTransaction
-	}
+-- t_progress_gaps contains the items that chaind's services have yet to process.
+CREATE TABLE t_progress_gaps (
+  f_service TEXT NOT NULL
+ ,f_key     TEXT NOT NULL
+ ,f_value   BIGINT NOT NULL
+);
+CREATE UNIQUE INDEX i_progress_gaps_1 ON t_progress_gaps(f_service,f_key,f_value);
+
+-- t_block_client_fingerprints contains the likely clients that produced blocks.
+CREATE TABLE t_block_client_fingerprints (
+  f_block_root       BYTEA UNIQUE NOT NULL REFERENCES t_blocks(f_root) ON DELETE CASCADE
+ ,f_slot             BIGINT NOT NULL
+ ,f_consensus_client TEXT
+ ,f_execution_client TEXT
+);
+CREATE INDEX i_block_client_fingerprints_1 ON t_block_client_fingerprints(f_slot);
+
+-- t_client_releases contains the known releases of clients.
+CREATE TABLE t_client_releases (
+  f_layer     TEXT NOT NULL
+ ,f_client    TEXT NOT NULL
+ ,f_version   TEXT NOT NULL
+ ,f_released  TIMESTAMPTZ NOT NULL
+ ,f_behaviors TEXT[] NOT NULL
+);
+CREATE UNIQUE INDEX i_client_releases_1 ON t_client_releases(f_layer,f_client,f_version);
+
+-- t_block_client_releases contains the likely client releases that produced blocks.
+CREATE TABLE t_block_client_releases (
+  f_block_root                BYTEA UNIQUE NOT NULL REFERENCES t_blocks(f_root) ON DELETE CASCADE
+ ,f_slot                      BIGINT NOT NULL
+ ,f_consensus_client          TEXT
+ ,f_consensus_version         TEXT
+ ,f_consensus_version_matched BOOLEAN
+ ,f_execution_client          TEXT
+ ,f_execution_version         TEXT
+ ,f_execution_version_matched BOOLEAN
+);
+CREATE INDEX i_block_client_releases_1 ON t_block_client_releases(f_slot);
+
+-- t_block_arrivals contains the times at which blocks were first seen.
+CREATE TABLE t_block_arrivals (
+  f_block_root     BYTEA UNIQUE NOT NULL
+ ,f_slot           BIGINT NOT NULL
+ ,f_seen_timestamp TIMESTAMPTZ NOT NULL
+ ,f_delay_ms       BIGINT NOT NULL
+);
+CREATE INDEX i_block_arrivals_1 ON t_block_arrivals(f_slot);
+
+-- t_attestation_arrivals contains the times at which attestations were first seen.
+CREATE TABLE t_attestation_arrivals (
+  f_slot              BIGINT NOT NULL
+ ,f_committee_index   BIGINT NOT NULL
+ ,f_beacon_block_root BYTEA NOT NULL
+ ,f_data_root         BYTEA NOT NULL
+ ,f_aggregation_bits  BYTEA NOT NULL
+ ,f_seen_timestamp    TIMESTAMPTZ NOT NULL
+ ,f_delay_ms          BIGINT NOT NULL
+);
+CREATE UNIQUE INDEX i_attestation_arrivals_1 ON t_attestation_arrivals(f_data_root,f_aggregation_bits);
+CREATE INDEX i_attestation_arrivals_2 ON t_attestation_arrivals(f_slot);
+
+-- t_watchlist contains the validators on the watchlist.
+CREATE TABLE t_watchlist (
+  f_validator_index BIGINT PRIMARY KEY
+ ,f_label           TEXT NOT NULL
+ ,f_added           TIMESTAMPTZ NOT NULL
+ ,f_proof           BYTEA
+);
+
+-- t_watchlist_events contains events for validators on the watchlist.
+CREATE TABLE t_watchlist_events (
+  f_validator_index BIGINT NOT NULL REFERENCES t_watchlist(f_validator_index) ON DELETE CASCADE
+ ,f_epoch           BIGINT NOT NULL
+ ,f_type            TEXT NOT NULL
+ ,f_slot            BIGINT NOT NULL
+ ,f_amount          BIGINT
+);
+CREATE UNIQUE INDEX i_watchlist_events_1 ON t_watchlist_events(f_validator_index,f_type,f_slot);
+CREATE INDEX i_watchlist_events_2 ON t_watchlist_events(f_epoch);
+
+-- t_watchlist_alerts contains alerts raised for validators on the watchlist.
+CREATE TABLE t_watchlist_alerts (
+  f_validator_index BIGINT NOT NULL REFERENCES t_watchlist(f_validator_index) ON DELETE CASCADE
+ ,f_epoch           BIGINT NOT NULL
+ ,f_rule            TEXT NOT NULL
+ ,f_description     TEXT NOT NULL
+);
+CREATE UNIQUE INDEX i_watchlist_alerts_1 ON t_watchlist_alerts(f_validator_index,f_rule,f_epoch);
+CREATE INDEX i_watchlist_alerts_2 ON t_watchlist_alerts(f_epoch);
+
+-- t_validator_shards contains the progress of instances indexing ranges of validators.
+CREATE TABLE t_validator_shards (
+  f_from_index            BIGINT PRIMARY KEY
+ ,f_to_index              BIGINT
+ ,f_latest_epoch          BIGINT NOT NULL
+ ,f_latest_balances_epoch BIGINT NOT NULL
+ ,f_updated               TIMESTAMPTZ NOT NULL
+);
+
+-- t_verification_disagreements contains slots where the indexed chain disagrees with a reference beacon node.
+CREATE TABLE t_verification_disagreements (
+  f_slot           BIGINT PRIMARY KEY
+ ,f_type           TEXT NOT NULL
+ ,f_indexed_root   BYTEA
+ ,f_reference_root BYTEA
+ ,f_detected       TIMESTAMPTZ NOT NULL
+);
+
+-- t_block_transaction_receipts contains the receipts of transactions in execution payloads.
+CREATE TABLE t_block_transaction_receipts (
+  f_block_root       BYTEA NOT NULL REFERENCES t_blocks(f_root) ON DELETE CASCADE
+ ,f_slot             BIGINT NOT NULL
+ ,f_block_number     BIGINT NOT NULL
+ ,f_index            INTEGER NOT NULL
+ ,f_hash             BYTEA NOT NULL
+ ,f_from             BYTEA NOT NULL
+ ,f_to               BYTEA
+ ,f_contract_address BYTEA
+ ,f_status           SMALLINT NOT NULL
+ ,f_gas_used         BIGINT NOT NULL
+);
+CREATE UNIQUE INDEX i_block_transaction_receipts_1 ON t_block_transaction_receipts(f_block_root,f_index);
+CREATE INDEX i_block_transaction_receipts_2 ON t_block_transaction_receipts(f_hash);
+CREATE INDEX i_block_transaction_receipts_3 ON t_block_transaction_receipts(f_slot);
+
+-- t_block_transaction_events contains the logs emitted by transactions in execution payloads.
+CREATE TABLE t_block_transaction_events (
+  f_block_root        BYTEA NOT NULL REFERENCES t_blocks(f_root) ON DELETE CASCADE
+ ,f_slot              BIGINT NOT NULL
+ ,f_block_number      BIGINT NOT NULL
+ ,f_transaction_index INTEGER NOT NULL
+ ,f_transaction_hash  BYTEA NOT NULL
+ ,f_index             INTEGER NOT NULL
+ ,f_address           BYTEA NOT NULL
+ ,f_topics            BYTEA[] NOT NULL
+ ,f_data              BYTEA
+);
+CREATE UNIQUE INDEX i_block_transaction_events_1 ON t_block_transaction_events(f_block_root,f_index);
+CREATE INDEX i_block_transaction_events_2 ON t_block_transaction_events(f_address,f_slot);
+CREATE INDEX i_block_transaction_events_3 ON t_block_transaction_events((f_topics[1]),f_slot);
+CREATE INDEX i_block_transaction_events_4 ON t_block_transaction_events(f_slot);
+
+-- t_address_labels contains labels for execution addresses.
+CREATE TABLE t_address_labels (
+  f_address  BYTEA PRIMARY KEY
+ ,f_label    TEXT NOT NULL
+ ,f_category TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX i_address_labels_1 ON t_address_labels(f_label);
+CREATE INDEX i_address_labels_2 ON t_address_labels(f_category);
+
+-- t_committee_epoch_summaries contains attestation performance for each committee.
+CREATE TABLE t_committee_epoch_summaries (
+  f_slot                       BIGINT NOT NULL
+ ,f_committee_index            BIGINT NOT NULL
+ ,f_epoch                      BIGINT NOT NULL
+ ,f_validators                 INTEGER NOT NULL
+ ,f_attesting_validators       INTEGER NOT NULL
+ ,f_source_timely_validators   INTEGER NOT NULL
+ ,f_target_correct_validators  INTEGER NOT NULL
+ ,f_head_correct_validators    INTEGER NOT NULL
+ ,f_average_inclusion_delay    DOUBLE PRECISION
+);
+CREATE UNIQUE INDEX i_committee_epoch_summaries_1 ON t_committee_epoch_summaries(f_slot,f_committee_index);
+CREATE INDEX i_committee_epoch_summaries_2 ON t_committee_epoch_summaries(f_epoch);
+
+-- t_validator_sync_period_summaries contains sync committee performance for each validator and period.
+CREATE TABLE t_validator_sync_period_summaries (
+  f_validator_index    BIGINT NOT NULL
+ ,f_period             BIGINT NOT NULL
+ ,f_assigned_slots     INTEGER NOT NULL
+ ,f_participated_slots INTEGER NOT NULL
+ ,f_missed_slots       INTEGER NOT NULL
+ ,f_rewards            BIGINT NOT NULL
+);
+CREATE UNIQUE INDEX i_validator_sync_period_summaries_1 ON t_validator_sync_period_summaries(f_validator_index,f_period);
+CREATE INDEX i_validator_sync_period_summaries_2 ON t_validator_sync_period_summaries(f_period);
+
+-- t_proposer_period_summaries contains proposal profitability for each proposer or tag and window.
+CREATE TABLE t_proposer_period_summaries (
+  f_window             TEXT NOT NULL
+ ,f_start_timestamp    TIMESTAMPTZ NOT NULL
+ ,f_validator_index    BIGINT
+ ,f_tag                TEXT
+ ,f_proposals          INTEGER NOT NULL
+ ,f_proposals_included INTEGER NOT NULL
+ ,f_consensus_rewards  BIGINT
+ ,f_execution_fees     NUMERIC NOT NULL
+ ,f_mev_payments       NUMERIC NOT NULL
+ ,f_mev_blocks         INTEGER NOT NULL
+ ,CHECK ((f_validator_index IS NULL) <> (f_tag IS NULL))
+);
+CREATE UNIQUE INDEX i_proposer_period_summaries_1 ON t_proposer_period_summaries(f_window,f_start_timestamp,f_validator_index) WHERE f_validator_index IS NOT NULL;
+CREATE UNIQUE INDEX i_proposer_period_summaries_2 ON t_proposer_period_summaries(f_window,f_start_timestamp,f_tag) WHERE f_tag IS NOT NULL;
+CREATE INDEX i_proposer_period_summaries_3 ON t_proposer_period_summaries(f_validator_index);
+
+-- t_validator_cluster_links contains the items, such as withdrawal addresses, that link validators into clusters.
+CREATE TABLE t_validator_cluster_links (
+  f_validator_index BIGINT NOT NULL
+ ,f_type            TEXT NOT NULL
+ ,f_key             BYTEA NOT NULL
+ ,f_epoch           BIGINT NOT NULL
+);
+CREATE UNIQUE INDEX i_validator_cluster_links_1 ON t_validator_cluster_links(f_validator_index,f_type,f_key);
+CREATE INDEX i_validator_cluster_links_2 ON t_validator_cluster_links(f_type,f_key);
+
+-- t_validator_clusters contains the cluster of each linked validator.
+CREATE TABLE t_validator_clusters (
+  f_validator_index BIGINT PRIMARY KEY
+ ,f_cluster_id      BIGINT NOT NULL
+);
+CREATE INDEX i_validator_clusters_1 ON t_validator_clusters(f_cluster_id);
+
+-- t_failed_items contains the slots and epochs that modules failed to process.
+CREATE TABLE t_failed_items (
+  f_module        TEXT NOT NULL
+ ,f_kind          TEXT NOT NULL
+ ,f_item          BIGINT NOT NULL
+ ,f_state         TEXT NOT NULL
+ ,f_attempts      INTEGER NOT NULL
+ ,f_error         TEXT NOT NULL
+ ,f_first_failure TIMESTAMPTZ NOT NULL
+ ,f_last_failure  TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX i_failed_items_1 ON t_failed_items(f_module,f_kind,f_item);
+CREATE INDEX i_failed_items_2 ON t_failed_items(f_state);
+
+-- t_epoch_checkpoints contains the boundary roots and finality checkpoints of each epoch.
+CREATE TABLE t_epoch_checkpoints (
+  f_epoch                     BIGINT PRIMARY KEY
+ ,f_block_root                BYTEA NOT NULL
+ ,f_state_root                BYTEA NOT NULL
+ ,f_previous_justified_epoch  BIGINT NOT NULL
+ ,f_previous_justified_root   BYTEA NOT NULL
+ ,f_justified_epoch           BIGINT NOT NULL
+ ,f_justified_root            BYTEA NOT NULL
+ ,f_finalized_epoch           BIGINT NOT NULL
+ ,f_finalized_root            BYTEA NOT NULL
+);
+CREATE UNIQUE INDEX i_epoch_checkpoints_1 ON t_epoch_checkpoints(f_state_root);
+
+-- t_randao_mixes contains the RANDAO mix at the end of each epoch.
+CREATE TABLE t_randao_mixes (
+  f_epoch      BIGINT PRIMARY KEY
+ ,f_randao_mix BYTEA NOT NULL
+);
+
+-- t_raw_blocks contains the SSZ encoding of signed beacon blocks.
+-- f_data is NULL if the encoding is held in cold storage under f_key.
+CREATE TABLE t_raw_blocks (
+  f_root    BYTEA PRIMARY KEY
+ ,f_slot    BIGINT NOT NULL
+ ,f_version TEXT NOT NULL
+ ,f_data    BYTEA
+ ,f_key     TEXT
+);
+CREATE INDEX i_raw_blocks_1 ON t_raw_blocks(f_slot);
+
+-- t_schema_history contains the changes made to the version of the schema.
+CREATE TABLE t_schema_history (
+  f_timestamp    TIMESTAMPTZ NOT NULL
+ ,f_from_version BIGINT NOT NULL
+ ,f_to_version   BIGINT NOT NULL
+);
+
+-- t_schema_migrations contains the functions run when migrating the schema.
+CREATE TABLE t_schema_migrations (
+  f_version   BIGINT NOT NULL
+ ,f_step      INTEGER NOT NULL
+ ,f_function  TEXT NOT NULL
+ ,f_direction TEXT NOT NULL
+ ,f_status    TEXT NOT NULL
+ ,f_started   TIMESTAMPTZ NOT NULL
+ ,f_finished  TIMESTAMPTZ NOT NULL
+ ,f_error     TEXT
+);
+CREATE INDEX i_schema_migrations_1 ON t_schema_migrations(f_started);
+
+-- t_outbox contains changes to captured tables, waiting to be published.
+CREATE TABLE t_outbox (
+  f_id        BIGSERIAL PRIMARY KEY
+ ,f_table     TEXT NOT NULL
+ ,f_operation TEXT NOT NULL
+ ,f_data      JSONB NOT NULL
+ ,f_timestamp TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- f_outbox_capture writes changes to rows of captured tables to t_outbox.
+CREATE FUNCTION f_outbox_capture() RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    INSERT INTO t_outbox(f_table, f_operation, f_data) VALUES(TG_TABLE_NAME, lower(TG_OP), to_jsonb(OLD));
+  ELSE
+    INSERT INTO t_outbox(f_table, f_operation, f_data) VALUES(TG_TABLE_NAME, lower(TG_OP), to_jsonb(NEW));
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+`); err != nil {
+		return errors.Wrap(err, "failed to create initial tables")
+	}
+
+	return nil
+}
+
+// addValidatorSummaryTimely adds timely fields to the t_validator_epoch_summaries table.
+func addValidatorSummaryTimely(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
 
 	if _, err := tx.Exec(ctx, `
 ALTER TABLE t_validator_epoch_summaries
@@ -1831,3 +2762,2434 @@ ADD COLUMN f_blob_gas_used BIGINT NOT NULL DEFAULT 0
 
 	return nil
 }
+
+// createValidatorCredentialsChanges creates the t_validator_credentials_changes table.
+func createValidatorCredentialsChanges(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE t_validator_credentials_changes (
+  f_validator_index           BIGINT NOT NULL
+ ,f_epoch                     BIGINT NOT NULL
+ ,f_withdrawal_credentials    BYTEA NOT NULL
+ ,f_effective_balance_ceiling BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_validator_credentials_changes")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX i_validator_credentials_changes_1 ON t_validator_credentials_changes(f_validator_index,f_epoch)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_credentials_changes_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX i_validator_credentials_changes_2 ON t_validator_credentials_changes(f_epoch)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_credentials_changes_2")
+	}
+
+	// Seed the table with the current credentials of each validator.  History prior to
+	// this upgrade is not available, so the credentials are recorded from the validator's
+	// activation eligibility epoch.
+	// The effective balance ceilings are taken from the stored chain spec where present,
+	// falling back to the mainnet values otherwise.
+	if _, err := tx.Exec(ctx, `
+INSERT INTO t_validator_credentials_changes(f_validator_index
+                                           ,f_epoch
+                                           ,f_withdrawal_credentials
+                                           ,f_effective_balance_ceiling)
+SELECT f_index
+      ,COALESCE(f_activation_eligibility_epoch,0)
+      ,f_withdrawal_credentials
+      ,CASE WHEN get_byte(f_withdrawal_credentials,0) = $1
+            THEN COALESCE((SELECT f_value::BIGINT FROM t_chain_spec WHERE f_key = 'MAX_EFFECTIVE_BALANCE_ELECTRA' AND f_value ~ '^[0-9]+$'),$2)
+            ELSE COALESCE((SELECT f_value::BIGINT FROM t_chain_spec WHERE f_key = 'MAX_EFFECTIVE_BALANCE' AND f_value ~ '^[0-9]+$'),$3)
+       END
+FROM t_validators
+WHERE f_withdrawal_credentials IS NOT NULL
+`,
+		int(chaindb.WithdrawalCredentialsCompounding),
+		int64(chaindb.DefaultMaxCompoundingEffectiveBalance),
+		int64(chaindb.DefaultMaxEffectiveBalance),
+	); err != nil {
+		return errors.Wrap(err, "failed to populate t_validator_credentials_changes")
+	}
+
+	return nil
+}
+
+// createValidatorConsolidations creates the t_validator_consolidations table.
+func createValidatorConsolidations(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE t_validator_consolidations (
+  f_source_index BIGINT NOT NULL
+ ,f_target_index BIGINT NOT NULL
+ ,f_epoch        BIGINT NOT NULL
+ ,f_amount       BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_validator_consolidations")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX i_validator_consolidations_1 ON t_validator_consolidations(f_source_index,f_target_index,f_epoch)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_consolidations_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX i_validator_consolidations_2 ON t_validator_consolidations(f_target_index)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_consolidations_2")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX i_validator_consolidations_3 ON t_validator_consolidations(f_epoch)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_consolidations_3")
+	}
+
+	return nil
+}
+
+// createArchiveOffloads creates the t_archive_offloads table.
+func createArchiveOffloads(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE t_archive_offloads (
+  f_table     TEXT NOT NULL
+ ,f_epoch     BIGINT NOT NULL
+ ,f_key       TEXT NOT NULL
+ ,f_location  TEXT NOT NULL
+ ,f_rows      BIGINT NOT NULL
+ ,f_timestamp TIMESTAMPTZ NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_archive_offloads")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX i_archive_offloads_1 ON t_archive_offloads(f_table,f_epoch)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_archive_offloads_1")
+	}
+
+	return nil
+}
+
+// createProgress creates the t_progress and t_progress_gaps tables.
+func createProgress(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE t_progress (
+  f_service TEXT NOT NULL
+ ,f_key     TEXT NOT NULL
+ ,f_value   BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_progress")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX i_progress_1 ON t_progress(f_service,f_key)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_progress_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE t_progress_gaps (
+  f_service TEXT NOT NULL
+ ,f_key     TEXT NOT NULL
+ ,f_value   BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_progress_gaps")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX i_progress_gaps_1 ON t_progress_gaps(f_service,f_key,f_value)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_progress_gaps_1")
+	}
+
+	return nil
+}
+
+// migrateMetadataToProgress converts services' metadata to progress.
+func migrateMetadataToProgress(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	services := []string{
+		"archiver.standard",
+		"beaconcommittees.standard",
+		"blocks.standard",
+		"eth1deposit.getlogs",
+		"finalizer.standard",
+		"proposerduties.standard",
+		"summarizer.standard",
+		"synccommittees.standard",
+		"validators.standard",
+	}
+
+	// Numeric and boolean values become progress values.
+	if _, err := tx.Exec(ctx, `
+INSERT INTO t_progress(f_service,f_key,f_value)
+SELECT m.f_key
+      ,e.key
+      ,CASE jsonb_typeof(e.value)
+         WHEN 'boolean' THEN CASE WHEN e.value = 'true'::JSONB THEN 1 ELSE 0 END
+         ELSE (e.value #>> '{}')::NUMERIC::BIGINT
+       END
+FROM t_metadata m
+    ,jsonb_each(CASE WHEN jsonb_typeof(m.f_value) = 'object' THEN m.f_value ELSE '{}'::JSONB END) e
+WHERE m.f_key = ANY($1)
+  AND jsonb_typeof(e.value) IN ('number','boolean')
+ON CONFLICT (f_service,f_key) DO NOTHING
+`, services); err != nil {
+		return errors.Wrap(err, "failed to migrate metadata values")
+	}
+
+	// Arrays become progress gaps.
+	if _, err := tx.Exec(ctx, `
+INSERT INTO t_progress_gaps(f_service,f_key,f_value)
+SELECT m.f_key
+      ,e.key
+      ,(a.value #>> '{}')::NUMERIC::BIGINT
+FROM t_metadata m
+    ,jsonb_each(CASE WHEN jsonb_typeof(m.f_value) = 'object' THEN m.f_value ELSE '{}'::JSONB END) e
+    ,jsonb_array_elements(CASE WHEN jsonb_typeof(e.value) = 'array' THEN e.value ELSE '[]'::JSONB END) a
+WHERE m.f_key = ANY($1)
+  AND jsonb_typeof(a.value) = 'number'
+ON CONFLICT (f_service,f_key,f_value) DO NOTHING
+`, services); err != nil {
+		return errors.Wrap(err, "failed to migrate metadata gaps")
+	}
+
+	// Remove the converted metadata.
+	if _, err := tx.Exec(ctx, `
+DELETE FROM t_metadata
+WHERE f_key = ANY($1)
+`, services); err != nil {
+		return errors.Wrap(err, "failed to remove migrated metadata")
+	}
+
+	return nil
+}
+
+// addChildCanonical adds the canonical flag to the subtables of t_blocks.
+func addChildCanonical(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	tables := []struct {
+		name      string
+		rootField string
+	}{
+		{name: "t_deposits", rootField: "f_inclusion_block_root"},
+		{name: "t_block_withdrawals", rootField: "f_block_root"},
+		{name: "t_block_execution_payloads", rootField: "f_block_root"},
+	}
+
+	for _, table := range tables {
+		// This exists in the initial SQL, so don't attempt to add it if already present.
+		alreadyPresent, err := s.columnExists(ctx, table.name, "f_canonical")
+		if err != nil {
+			return errors.Wrapf(err, "failed to check if f_canonical exists in %s", table.name)
+		}
+		if alreadyPresent {
+			continue
+		}
+
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+ALTER TABLE %s
+ADD COLUMN f_canonical BOOL
+`, table.name)); err != nil {
+			return errors.Wrapf(err, "failed to add f_canonical to %s", table.name)
+		}
+
+		// Set value for the column from the parent block.
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+UPDATE %s
+SET f_canonical = t_blocks.f_canonical
+FROM t_blocks
+WHERE t_blocks.f_root = %s.%s
+  AND t_blocks.f_canonical IS NOT NULL
+`, table.name, table.name, table.rootField)); err != nil {
+			return errors.Wrapf(err, "failed to set f_canonical for %s", table.name)
+		}
+	}
+
+	return nil
+}
+
+// addEpochParticipation adds participation fields to t_epoch_summaries.
+func addEpochParticipation(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_epoch_summaries
+ADD COLUMN IF NOT EXISTS f_source_timely_validators BIGINT
+,ADD COLUMN IF NOT EXISTS f_source_timely_balance BIGINT
+,ADD COLUMN IF NOT EXISTS f_participation_rate DOUBLE PRECISION
+,ADD COLUMN IF NOT EXISTS f_source_timely_rate DOUBLE PRECISION
+,ADD COLUMN IF NOT EXISTS f_target_correct_rate DOUBLE PRECISION
+,ADD COLUMN IF NOT EXISTS f_head_correct_rate DOUBLE PRECISION
+`); err != nil {
+		return errors.Wrap(err, "failed to add participation fields to t_epoch_summaries")
+	}
+
+	// Rates that can be calculated from existing data are populated; source timeliness
+	// requires the attestations to be summarized again.
+	if _, err := tx.Exec(ctx, `
+UPDATE t_epoch_summaries
+SET f_participation_rate = f_attesting_balance::DOUBLE PRECISION / f_active_balance
+   ,f_target_correct_rate = f_target_correct_balance::DOUBLE PRECISION / f_active_balance
+   ,f_head_correct_rate = f_head_correct_balance::DOUBLE PRECISION / f_active_balance
+WHERE f_active_balance > 0
+  AND f_participation_rate IS NULL
+`); err != nil {
+		return errors.Wrap(err, "failed to populate participation fields in t_epoch_summaries")
+	}
+
+	return nil
+}
+
+// createBlockClientFingerprints creates the t_block_client_fingerprints table.
+func createBlockClientFingerprints(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE t_block_client_fingerprints (
+  f_block_root       BYTEA UNIQUE NOT NULL REFERENCES t_blocks(f_root) ON DELETE CASCADE
+ ,f_slot             BIGINT NOT NULL
+ ,f_consensus_client TEXT
+ ,f_execution_client TEXT
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_block_client_fingerprints")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX i_block_client_fingerprints_1 ON t_block_client_fingerprints(f_slot)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_block_client_fingerprints_1")
+	}
+
+	return nil
+}
+
+// createArrivals creates the t_block_arrivals and t_attestation_arrivals tables.
+func createArrivals(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE t_block_arrivals (
+  f_block_root     BYTEA UNIQUE NOT NULL
+ ,f_slot           BIGINT NOT NULL
+ ,f_seen_timestamp TIMESTAMPTZ NOT NULL
+ ,f_delay_ms       BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_block_arrivals")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX i_block_arrivals_1 ON t_block_arrivals(f_slot)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_block_arrivals_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE t_attestation_arrivals (
+  f_slot              BIGINT NOT NULL
+ ,f_committee_index   BIGINT NOT NULL
+ ,f_beacon_block_root BYTEA NOT NULL
+ ,f_data_root         BYTEA NOT NULL
+ ,f_aggregation_bits  BYTEA NOT NULL
+ ,f_seen_timestamp    TIMESTAMPTZ NOT NULL
+ ,f_delay_ms          BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_attestation_arrivals")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX i_attestation_arrivals_1 ON t_attestation_arrivals(f_data_root,f_aggregation_bits)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_attestation_arrivals_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX i_attestation_arrivals_2 ON t_attestation_arrivals(f_slot)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_attestation_arrivals_2")
+	}
+
+	return nil
+}
+
+// dropChildCanonical removes the f_canonical column from child tables of t_blocks.
+func dropChildCanonical(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, table := range []string{"t_deposits", "t_block_withdrawals", "t_block_execution_payloads"} {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+ALTER TABLE %s
+DROP COLUMN IF EXISTS f_canonical
+`, table)); err != nil {
+			return errors.Wrapf(err, "failed to drop f_canonical from %s", table)
+		}
+	}
+
+	return nil
+}
+
+// dropEpochParticipation removes the participation fields from t_epoch_summaries.
+func dropEpochParticipation(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_epoch_summaries
+DROP COLUMN IF EXISTS f_source_timely_validators
+,DROP COLUMN IF EXISTS f_source_timely_balance
+,DROP COLUMN IF EXISTS f_participation_rate
+,DROP COLUMN IF EXISTS f_source_timely_rate
+,DROP COLUMN IF EXISTS f_target_correct_rate
+,DROP COLUMN IF EXISTS f_head_correct_rate
+`); err != nil {
+		return errors.Wrap(err, "failed to drop participation fields from t_epoch_summaries")
+	}
+
+	return nil
+}
+
+// dropBlockClientFingerprints drops the t_block_client_fingerprints table.
+func dropBlockClientFingerprints(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_block_client_fingerprints`); err != nil {
+		return errors.Wrap(err, "failed to drop t_block_client_fingerprints")
+	}
+
+	return nil
+}
+
+// dropArrivals drops the t_block_arrivals and t_attestation_arrivals tables.
+func dropArrivals(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_block_arrivals`); err != nil {
+		return errors.Wrap(err, "failed to drop t_block_arrivals")
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_attestation_arrivals`); err != nil {
+		return errors.Wrap(err, "failed to drop t_attestation_arrivals")
+	}
+
+	return nil
+}
+
+// createSchemaHistory creates the t_schema_history table.
+func createSchemaHistory(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_schema_history (
+  f_timestamp    TIMESTAMPTZ NOT NULL
+ ,f_from_version BIGINT NOT NULL
+ ,f_to_version   BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_schema_history")
+	}
+
+	return nil
+}
+
+// dropSchemaHistory drops the t_schema_history table.
+func dropSchemaHistory(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_schema_history`); err != nil {
+		return errors.Wrap(err, "failed to drop t_schema_history")
+	}
+
+	return nil
+}
+
+// createValidatorDayRankings creates the t_validator_day_rankings table.
+func createValidatorDayRankings(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_validator_day_rankings (
+  f_validator_index BIGINT NOT NULL
+ ,f_start_timestamp TIMESTAMPTZ NOT NULL
+ ,f_effectiveness   DOUBLE PRECISION NOT NULL
+ ,f_percentile      DOUBLE PRECISION NOT NULL
+ ,f_z_score         DOUBLE PRECISION NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_validator_day_rankings")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_validator_day_rankings_1 ON t_validator_day_rankings(f_validator_index, f_start_timestamp)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_day_rankings_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_validator_day_rankings_2 ON t_validator_day_rankings(f_start_timestamp)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_day_rankings_2")
+	}
+
+	return nil
+}
+
+// dropValidatorDayRankings drops the t_validator_day_rankings table.
+func dropValidatorDayRankings(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_validator_day_rankings`); err != nil {
+		return errors.Wrap(err, "failed to drop t_validator_day_rankings")
+	}
+
+	return nil
+}
+
+// createNetworkAggregates creates the t_network_aggregates table.
+func createNetworkAggregates(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_network_aggregates (
+  f_epoch                        BIGINT UNIQUE NOT NULL
+ ,f_total_balance                BIGINT NOT NULL
+ ,f_active_validators            BIGINT NOT NULL
+ ,f_active_balance               BIGINT NOT NULL
+ ,f_average_balance              BIGINT NOT NULL
+ ,f_entering_validators          BIGINT NOT NULL
+ ,f_exiting_validators           BIGINT NOT NULL
+ ,f_activated_validators         BIGINT NOT NULL
+ ,f_exited_validators            BIGINT NOT NULL
+ ,f_activation_churn_limit       BIGINT NOT NULL
+ ,f_exit_churn_limit             BIGINT NOT NULL
+ ,f_activation_churn_utilization DOUBLE PRECISION NOT NULL
+ ,f_exit_churn_utilization       DOUBLE PRECISION NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_network_aggregates")
+	}
+
+	return nil
+}
+
+// dropNetworkAggregates drops the t_network_aggregates table.
+func dropNetworkAggregates(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_network_aggregates`); err != nil {
+		return errors.Wrap(err, "failed to drop t_network_aggregates")
+	}
+
+	return nil
+}
+
+// createEntryQueues creates the t_entry_queues table.
+func createEntryQueues(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_entry_queues (
+  f_epoch                  BIGINT UNIQUE NOT NULL
+ ,f_pending_validators     BIGINT NOT NULL
+ ,f_queue_length           BIGINT NOT NULL
+ ,f_queue_balance          BIGINT NOT NULL
+ ,f_activation_churn_limit BIGINT NOT NULL
+ ,f_projected_wait         BIGINT NOT NULL
+ ,f_activated_validators   BIGINT NOT NULL
+ ,f_average_wait           DOUBLE PRECISION NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_entry_queues")
+	}
+
+	return nil
+}
+
+// dropEntryQueues drops the t_entry_queues table.
+func dropEntryQueues(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_entry_queues`); err != nil {
+		return errors.Wrap(err, "failed to drop t_entry_queues")
+	}
+
+	return nil
+}
+
+// createEquivocations creates the t_equivocations table.
+func createEquivocations(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_equivocations (
+  f_type            TEXT NOT NULL
+ ,f_validator_index BIGINT NOT NULL
+ ,f_slot_1          BIGINT NOT NULL
+ ,f_root_1          BYTEA NOT NULL
+ ,f_slot_2          BIGINT NOT NULL
+ ,f_root_2          BYTEA NOT NULL
+ ,f_source_epoch_1  BIGINT
+ ,f_target_epoch_1  BIGINT
+ ,f_source_epoch_2  BIGINT
+ ,f_target_epoch_2  BIGINT
+ ,f_slashed         BOOLEAN NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_equivocations")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_equivocations_1 ON t_equivocations(f_validator_index, f_type, f_root_1, f_root_2)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_equivocations_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_equivocations_2 ON t_equivocations(f_slot_2)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_equivocations_2")
+	}
+
+	return nil
+}
+
+// dropEquivocations drops the t_equivocations table.
+func dropEquivocations(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_equivocations`); err != nil {
+		return errors.Wrap(err, "failed to drop t_equivocations")
+	}
+
+	return nil
+}
+
+// createOutbox creates the t_outbox table, and the function to capture changes to it.
+func createOutbox(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_outbox (
+  f_id        BIGSERIAL PRIMARY KEY
+ ,f_table     TEXT NOT NULL
+ ,f_operation TEXT NOT NULL
+ ,f_data      JSONB NOT NULL
+ ,f_timestamp TIMESTAMPTZ NOT NULL DEFAULT now()
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_outbox")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE OR REPLACE FUNCTION f_outbox_capture() RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    INSERT INTO t_outbox(f_table, f_operation, f_data) VALUES(TG_TABLE_NAME, lower(TG_OP), to_jsonb(OLD));
+  ELSE
+    INSERT INTO t_outbox(f_table, f_operation, f_data) VALUES(TG_TABLE_NAME, lower(TG_OP), to_jsonb(NEW));
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql
+`); err != nil {
+		return errors.Wrap(err, "failed to create f_outbox_capture")
+	}
+
+	return nil
+}
+
+// dropOutbox drops the t_outbox table, and the function and triggers to capture changes to it.
+func dropOutbox(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP FUNCTION IF EXISTS f_outbox_capture() CASCADE`); err != nil {
+		return errors.Wrap(err, "failed to drop f_outbox_capture")
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_outbox`); err != nil {
+		return errors.Wrap(err, "failed to drop t_outbox")
+	}
+
+	return nil
+}
+
+// createWatchlist creates the t_watchlist and t_watchlist_events tables.
+func createWatchlist(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_watchlist (
+  f_validator_index BIGINT PRIMARY KEY
+ ,f_label           TEXT NOT NULL
+ ,f_added           TIMESTAMPTZ NOT NULL
+ ,f_proof           BYTEA
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_watchlist")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_watchlist_events (
+  f_validator_index BIGINT NOT NULL REFERENCES t_watchlist(f_validator_index) ON DELETE CASCADE
+ ,f_epoch           BIGINT NOT NULL
+ ,f_type            TEXT NOT NULL
+ ,f_slot            BIGINT NOT NULL
+ ,f_amount          BIGINT
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_watchlist_events")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_watchlist_events_1 ON t_watchlist_events(f_validator_index, f_type, f_slot)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_watchlist_events_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_watchlist_events_2 ON t_watchlist_events(f_epoch)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_watchlist_events_2")
+	}
+
+	return nil
+}
+
+// dropWatchlist drops the t_watchlist and t_watchlist_events tables.
+func dropWatchlist(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_watchlist_events`); err != nil {
+		return errors.Wrap(err, "failed to drop t_watchlist_events")
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_watchlist`); err != nil {
+		return errors.Wrap(err, "failed to drop t_watchlist")
+	}
+
+	return nil
+}
+
+// createVerificationDisagreements creates the t_verification_disagreements table.
+func createVerificationDisagreements(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_verification_disagreements (
+  f_slot           BIGINT PRIMARY KEY
+ ,f_type           TEXT NOT NULL
+ ,f_indexed_root   BYTEA
+ ,f_reference_root BYTEA
+ ,f_detected       TIMESTAMPTZ NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_verification_disagreements")
+	}
+
+	return nil
+}
+
+// dropVerificationDisagreements drops the t_verification_disagreements table.
+func dropVerificationDisagreements(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_verification_disagreements`); err != nil {
+		return errors.Wrap(err, "failed to drop t_verification_disagreements")
+	}
+
+	return nil
+}
+
+// createTransactionReceipts creates the t_block_transaction_receipts and t_block_transaction_events tables.
+func createTransactionReceipts(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_block_transaction_receipts (
+  f_block_root       BYTEA NOT NULL REFERENCES t_blocks(f_root) ON DELETE CASCADE
+ ,f_slot             BIGINT NOT NULL
+ ,f_block_number     BIGINT NOT NULL
+ ,f_index            INTEGER NOT NULL
+ ,f_hash             BYTEA NOT NULL
+ ,f_from             BYTEA NOT NULL
+ ,f_to               BYTEA
+ ,f_contract_address BYTEA
+ ,f_status           SMALLINT NOT NULL
+ ,f_gas_used         BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_block_transaction_receipts")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_block_transaction_receipts_1 ON t_block_transaction_receipts(f_block_root,f_index)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_block_transaction_receipts_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_block_transaction_receipts_2 ON t_block_transaction_receipts(f_hash)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_block_transaction_receipts_2")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_block_transaction_receipts_3 ON t_block_transaction_receipts(f_slot)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_block_transaction_receipts_3")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_block_transaction_events (
+  f_block_root        BYTEA NOT NULL REFERENCES t_blocks(f_root) ON DELETE CASCADE
+ ,f_slot              BIGINT NOT NULL
+ ,f_block_number      BIGINT NOT NULL
+ ,f_transaction_index INTEGER NOT NULL
+ ,f_transaction_hash  BYTEA NOT NULL
+ ,f_index             INTEGER NOT NULL
+ ,f_address           BYTEA NOT NULL
+ ,f_topics            BYTEA[] NOT NULL
+ ,f_data              BYTEA
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_block_transaction_events")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_block_transaction_events_1 ON t_block_transaction_events(f_block_root,f_index)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_block_transaction_events_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_block_transaction_events_2 ON t_block_transaction_events(f_address,f_slot)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_block_transaction_events_2")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_block_transaction_events_3 ON t_block_transaction_events((f_topics[1]),f_slot)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_block_transaction_events_3")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_block_transaction_events_4 ON t_block_transaction_events(f_slot)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_block_transaction_events_4")
+	}
+
+	return nil
+}
+
+// dropTransactionReceipts drops the t_block_transaction_receipts and t_block_transaction_events tables.
+func dropTransactionReceipts(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_block_transaction_events`); err != nil {
+		return errors.Wrap(err, "failed to drop t_block_transaction_events")
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_block_transaction_receipts`); err != nil {
+		return errors.Wrap(err, "failed to drop t_block_transaction_receipts")
+	}
+
+	return nil
+}
+
+// createAddressLabels creates the t_address_labels table.
+func createAddressLabels(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_address_labels (
+  f_address  BYTEA PRIMARY KEY
+ ,f_label    TEXT NOT NULL
+ ,f_category TEXT NOT NULL DEFAULT ''
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_address_labels")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_address_labels_1 ON t_address_labels(f_label)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_address_labels_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_address_labels_2 ON t_address_labels(f_category)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_address_labels_2")
+	}
+
+	return nil
+}
+
+// dropAddressLabels drops the t_address_labels table.
+func dropAddressLabels(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_address_labels`); err != nil {
+		return errors.Wrap(err, "failed to drop t_address_labels")
+	}
+
+	return nil
+}
+
+// createGraffitiIndex creates a trigram index on block graffiti.
+func createGraffitiIndex(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `CREATE EXTENSION IF NOT EXISTS pg_trgm`); err != nil {
+		return errors.Wrap(err, "failed to create pg_trgm extension")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_blocks_4 ON t_blocks USING GIN(encode(btrim(f_graffiti,'\x00'::bytea),'escape') gin_trgm_ops)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_blocks_4")
+	}
+
+	return nil
+}
+
+// dropGraffitiIndex drops the trigram index on block graffiti.
+// The pg_trgm extension is left in place, as it may be used elsewhere.
+func dropGraffitiIndex(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP INDEX IF EXISTS i_blocks_4`); err != nil {
+		return errors.Wrap(err, "failed to drop i_blocks_4")
+	}
+
+	return nil
+}
+
+// createCommitteeEpochSummaries creates the t_committee_epoch_summaries table.
+func createCommitteeEpochSummaries(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_committee_epoch_summaries (
+  f_slot                       BIGINT NOT NULL
+ ,f_committee_index            BIGINT NOT NULL
+ ,f_epoch                      BIGINT NOT NULL
+ ,f_validators                 INTEGER NOT NULL
+ ,f_attesting_validators       INTEGER NOT NULL
+ ,f_source_timely_validators   INTEGER NOT NULL
+ ,f_target_correct_validators  INTEGER NOT NULL
+ ,f_head_correct_validators    INTEGER NOT NULL
+ ,f_average_inclusion_delay    DOUBLE PRECISION
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_committee_epoch_summaries")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_committee_epoch_summaries_1 ON t_committee_epoch_summaries(f_slot,f_committee_index)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_committee_epoch_summaries_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_committee_epoch_summaries_2 ON t_committee_epoch_summaries(f_epoch)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_committee_epoch_summaries_2")
+	}
+
+	return nil
+}
+
+// dropCommitteeEpochSummaries drops the t_committee_epoch_summaries table.
+func dropCommitteeEpochSummaries(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_committee_epoch_summaries`); err != nil {
+		return errors.Wrap(err, "failed to drop t_committee_epoch_summaries")
+	}
+
+	return nil
+}
+
+// createEpochCheckpoints creates the t_epoch_checkpoints table.
+func createEpochCheckpoints(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_epoch_checkpoints (
+  f_epoch                     BIGINT PRIMARY KEY
+ ,f_block_root                BYTEA NOT NULL
+ ,f_state_root                BYTEA NOT NULL
+ ,f_previous_justified_epoch  BIGINT NOT NULL
+ ,f_previous_justified_root   BYTEA NOT NULL
+ ,f_justified_epoch           BIGINT NOT NULL
+ ,f_justified_root            BYTEA NOT NULL
+ ,f_finalized_epoch           BIGINT NOT NULL
+ ,f_finalized_root            BYTEA NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_epoch_checkpoints")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_epoch_checkpoints_1 ON t_epoch_checkpoints(f_state_root)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_epoch_checkpoints_1")
+	}
+
+	return nil
+}
+
+// dropEpochCheckpoints drops the t_epoch_checkpoints table.
+func dropEpochCheckpoints(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_epoch_checkpoints`); err != nil {
+		return errors.Wrap(err, "failed to drop t_epoch_checkpoints")
+	}
+
+	return nil
+}
+
+// createRawBlocks creates the t_raw_blocks table.
+func createRawBlocks(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_raw_blocks (
+  f_root    BYTEA PRIMARY KEY
+ ,f_slot    BIGINT NOT NULL
+ ,f_version TEXT NOT NULL
+ ,f_data    BYTEA
+ ,f_key     TEXT
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_raw_blocks")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_raw_blocks_1 ON t_raw_blocks(f_slot)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_raw_blocks_1")
+	}
+
+	return nil
+}
+
+// dropRawBlocks drops the t_raw_blocks table.
+func dropRawBlocks(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_raw_blocks`); err != nil {
+		return errors.Wrap(err, "failed to drop t_raw_blocks")
+	}
+
+	return nil
+}
+
+// createWatchlistAlerts creates the t_watchlist_alerts table.
+func createWatchlistAlerts(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_watchlist_alerts (
+  f_validator_index BIGINT NOT NULL REFERENCES t_watchlist(f_validator_index) ON DELETE CASCADE
+ ,f_epoch           BIGINT NOT NULL
+ ,f_rule            TEXT NOT NULL
+ ,f_description     TEXT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_watchlist_alerts")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_watchlist_alerts_1 ON t_watchlist_alerts(f_validator_index,f_rule,f_epoch)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_watchlist_alerts_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_watchlist_alerts_2 ON t_watchlist_alerts(f_epoch)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_watchlist_alerts_2")
+	}
+
+	return nil
+}
+
+// dropWatchlistAlerts drops the t_watchlist_alerts table.
+func dropWatchlistAlerts(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_watchlist_alerts`); err != nil {
+		return errors.Wrap(err, "failed to drop t_watchlist_alerts")
+	}
+
+	return nil
+}
+
+// createValidatorShards creates the t_validator_shards table.
+func createValidatorShards(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_validator_shards (
+  f_from_index            BIGINT PRIMARY KEY
+ ,f_to_index              BIGINT
+ ,f_latest_epoch          BIGINT NOT NULL
+ ,f_latest_balances_epoch BIGINT NOT NULL
+ ,f_updated               TIMESTAMPTZ NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_validator_shards")
+	}
+
+	return nil
+}
+
+// dropValidatorShards drops the t_validator_shards table.
+func dropValidatorShards(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_validator_shards`); err != nil {
+		return errors.Wrap(err, "failed to drop t_validator_shards")
+	}
+
+	return nil
+}
+
+// createSchemaMigrations creates the t_schema_migrations table.
+func createSchemaMigrations(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_schema_migrations (
+  f_version   BIGINT NOT NULL
+ ,f_step      INTEGER NOT NULL
+ ,f_function  TEXT NOT NULL
+ ,f_direction TEXT NOT NULL
+ ,f_status    TEXT NOT NULL
+ ,f_started   TIMESTAMPTZ NOT NULL
+ ,f_finished  TIMESTAMPTZ NOT NULL
+ ,f_error     TEXT
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_schema_migrations")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_schema_migrations_1 ON t_schema_migrations(f_started)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_schema_migrations_1")
+	}
+
+	return nil
+}
+
+// dropSchemaMigrations drops the t_schema_migrations table.
+func dropSchemaMigrations(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_schema_migrations`); err != nil {
+		return errors.Wrap(err, "failed to drop t_schema_migrations")
+	}
+
+	return nil
+}
+
+// createValidatorSyncPeriodSummaries creates the t_validator_sync_period_summaries table.
+func createValidatorSyncPeriodSummaries(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_validator_sync_period_summaries (
+  f_validator_index    BIGINT NOT NULL
+ ,f_period             BIGINT NOT NULL
+ ,f_assigned_slots     INTEGER NOT NULL
+ ,f_participated_slots INTEGER NOT NULL
+ ,f_missed_slots       INTEGER NOT NULL
+ ,f_rewards            BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_validator_sync_period_summaries")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_validator_sync_period_summaries_1 ON t_validator_sync_period_summaries(f_validator_index,f_period)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_sync_period_summaries_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_validator_sync_period_summaries_2 ON t_validator_sync_period_summaries(f_period)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_sync_period_summaries_2")
+	}
+
+	return nil
+}
+
+// dropValidatorSyncPeriodSummaries drops the t_validator_sync_period_summaries table.
+func dropValidatorSyncPeriodSummaries(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_validator_sync_period_summaries`); err != nil {
+		return errors.Wrap(err, "failed to drop t_validator_sync_period_summaries")
+	}
+
+	return nil
+}
+
+// addExecutionPayloadValue adds payload value fields to t_block_execution_payloads.
+func addExecutionPayloadValue(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_block_execution_payloads
+ADD COLUMN IF NOT EXISTS f_payload_value NUMERIC
+,ADD COLUMN IF NOT EXISTS f_payload_value_source TEXT
+`); err != nil {
+		return errors.Wrap(err, "failed to add payload value fields to t_block_execution_payloads")
+	}
+
+	return nil
+}
+
+// dropExecutionPayloadValue drops payload value fields from t_block_execution_payloads.
+func dropExecutionPayloadValue(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_block_execution_payloads
+DROP COLUMN IF EXISTS f_payload_value
+,DROP COLUMN IF EXISTS f_payload_value_source
+`); err != nil {
+		return errors.Wrap(err, "failed to drop payload value fields from t_block_execution_payloads")
+	}
+
+	return nil
+}
+
+// createProposerPeriodSummaries creates the t_proposer_period_summaries table.
+func createProposerPeriodSummaries(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_proposer_period_summaries (
+  f_window             TEXT NOT NULL
+ ,f_start_timestamp    TIMESTAMPTZ NOT NULL
+ ,f_validator_index    BIGINT
+ ,f_tag                TEXT
+ ,f_proposals          INTEGER NOT NULL
+ ,f_proposals_included INTEGER NOT NULL
+ ,f_consensus_rewards  BIGINT
+ ,f_execution_fees     NUMERIC NOT NULL
+ ,f_mev_payments       NUMERIC NOT NULL
+ ,f_mev_blocks         INTEGER NOT NULL
+ ,CHECK ((f_validator_index IS NULL) <> (f_tag IS NULL))
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_proposer_period_summaries")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_proposer_period_summaries_1 ON t_proposer_period_summaries(f_window,f_start_timestamp,f_validator_index) WHERE f_validator_index IS NOT NULL
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_proposer_period_summaries_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_proposer_period_summaries_2 ON t_proposer_period_summaries(f_window,f_start_timestamp,f_tag) WHERE f_tag IS NOT NULL
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_proposer_period_summaries_2")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_proposer_period_summaries_3 ON t_proposer_period_summaries(f_validator_index)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_proposer_period_summaries_3")
+	}
+
+	return nil
+}
+
+// dropProposerPeriodSummaries drops the t_proposer_period_summaries table.
+func dropProposerPeriodSummaries(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_proposer_period_summaries`); err != nil {
+		return errors.Wrap(err, "failed to drop t_proposer_period_summaries")
+	}
+
+	return nil
+}
+
+// createETH1DepositDiscrepancies creates the t_eth1_deposit_discrepancies table.
+func createETH1DepositDiscrepancies(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_eth1_deposit_discrepancies (
+  f_eth1_block_number        BIGINT PRIMARY KEY
+ ,f_timestamp                TIMESTAMPTZ NOT NULL
+ ,f_contract_deposit_count   BIGINT NOT NULL
+ ,f_indexed_deposit_count    BIGINT NOT NULL
+ ,f_contract_deposit_root    BYTEA NOT NULL
+ ,f_indexed_deposit_root     BYTEA NOT NULL
+ ,f_missing_deposit_indices  BIGINT[] NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_eth1_deposit_discrepancies")
+	}
+
+	return nil
+}
+
+// dropETH1DepositDiscrepancies drops the t_eth1_deposit_discrepancies table.
+func dropETH1DepositDiscrepancies(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_eth1_deposit_discrepancies`); err != nil {
+		return errors.Wrap(err, "failed to drop t_eth1_deposit_discrepancies")
+	}
+
+	return nil
+}
+
+// addEpochBlockStats adds block, exit and inclusion delay fields to t_epoch_summaries.
+func addEpochBlockStats(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_epoch_summaries
+ADD COLUMN IF NOT EXISTS f_missed_proposals BIGINT
+,ADD COLUMN IF NOT EXISTS f_orphaned_blocks BIGINT
+,ADD COLUMN IF NOT EXISTS f_voluntary_exits BIGINT
+,ADD COLUMN IF NOT EXISTS f_average_inclusion_delay DOUBLE PRECISION
+`); err != nil {
+		return errors.Wrap(err, "failed to add block statistics fields to t_epoch_summaries")
+	}
+
+	return nil
+}
+
+// dropEpochBlockStats drops block, exit and inclusion delay fields from t_epoch_summaries.
+func dropEpochBlockStats(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_epoch_summaries
+DROP COLUMN IF EXISTS f_missed_proposals
+,DROP COLUMN IF EXISTS f_orphaned_blocks
+,DROP COLUMN IF EXISTS f_voluntary_exits
+,DROP COLUMN IF EXISTS f_average_inclusion_delay
+`); err != nil {
+		return errors.Wrap(err, "failed to drop block statistics fields from t_epoch_summaries")
+	}
+
+	return nil
+}
+
+// createQueueProjections creates the t_queue_projections table.
+func createQueueProjections(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_queue_projections (
+  f_epoch            BIGINT NOT NULL
+ ,f_validator_index  BIGINT NOT NULL
+ ,f_activation_epoch BIGINT
+ ,f_exit_epoch       BIGINT
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_queue_projections")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_queue_projections_1 ON t_queue_projections(f_epoch,f_validator_index)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_queue_projections_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_queue_projections_2 ON t_queue_projections(f_validator_index)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_queue_projections_2")
+	}
+
+	return nil
+}
+
+// dropQueueProjections drops the t_queue_projections table.
+func dropQueueProjections(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_queue_projections`); err != nil {
+		return errors.Wrap(err, "failed to drop t_queue_projections")
+	}
+
+	return nil
+}
+
+// createHeadObservations creates the t_head_observations table.
+func createHeadObservations(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_head_observations (
+  f_slot        BIGINT PRIMARY KEY
+ ,f_observed_at TIMESTAMPTZ NOT NULL
+ ,f_head_slot   BIGINT NOT NULL
+ ,f_head_root   BYTEA NOT NULL
+ ,f_parent_root BYTEA NOT NULL
+ ,f_reorg       BOOL NOT NULL
+ ,f_reorg_depth BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_head_observations")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_head_observations_1 ON t_head_observations(f_head_root)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_head_observations_1")
+	}
+
+	return nil
+}
+
+// dropHeadObservations drops the t_head_observations table.
+func dropHeadObservations(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_head_observations`); err != nil {
+		return errors.Wrap(err, "failed to drop t_head_observations")
+	}
+
+	return nil
+}
+
+// addAttestationCorrectness adds source and at-inclusion correctness fields to t_attestations,
+// and the corresponding counts to t_epoch_summaries.
+func addAttestationCorrectness(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_attestations
+ADD COLUMN IF NOT EXISTS f_source_correct BOOL
+,ADD COLUMN IF NOT EXISTS f_inclusion_target_correct BOOL
+,ADD COLUMN IF NOT EXISTS f_inclusion_head_correct BOOL
+`); err != nil {
+		return errors.Wrap(err, "failed to add correctness fields to t_attestations")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_epoch_summaries
+ADD COLUMN IF NOT EXISTS f_source_correct_validators BIGINT
+,ADD COLUMN IF NOT EXISTS f_source_correct_balance BIGINT
+,ADD COLUMN IF NOT EXISTS f_inclusion_target_correct_validators BIGINT
+,ADD COLUMN IF NOT EXISTS f_inclusion_target_correct_balance BIGINT
+,ADD COLUMN IF NOT EXISTS f_inclusion_head_correct_validators BIGINT
+,ADD COLUMN IF NOT EXISTS f_inclusion_head_correct_balance BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add correctness fields to t_epoch_summaries")
+	}
+
+	return nil
+}
+
+// dropAttestationCorrectness drops source and at-inclusion correctness fields from t_attestations
+// and t_epoch_summaries.
+func dropAttestationCorrectness(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_attestations
+DROP COLUMN IF EXISTS f_source_correct
+,DROP COLUMN IF EXISTS f_inclusion_target_correct
+,DROP COLUMN IF EXISTS f_inclusion_head_correct
+`); err != nil {
+		return errors.Wrap(err, "failed to drop correctness fields from t_attestations")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_epoch_summaries
+DROP COLUMN IF EXISTS f_source_correct_validators
+,DROP COLUMN IF EXISTS f_source_correct_balance
+,DROP COLUMN IF EXISTS f_inclusion_target_correct_validators
+,DROP COLUMN IF EXISTS f_inclusion_target_correct_balance
+,DROP COLUMN IF EXISTS f_inclusion_head_correct_validators
+,DROP COLUMN IF EXISTS f_inclusion_head_correct_balance
+`); err != nil {
+		return errors.Wrap(err, "failed to drop correctness fields from t_epoch_summaries")
+	}
+
+	return nil
+}
+
+// numericWithdrawalAmounts stores the amounts of withdrawals as NUMERIC, allowing
+// the full range of gwei to be held.
+func numericWithdrawalAmounts(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_block_withdrawals
+ALTER COLUMN f_amount TYPE NUMERIC
+`); err != nil {
+		return errors.Wrap(err, "failed to change type of f_amount in t_block_withdrawals")
+	}
+
+	return nil
+}
+
+// bigintWithdrawalAmounts stores the amounts of withdrawals as BIGINT.
+func bigintWithdrawalAmounts(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_block_withdrawals
+ALTER COLUMN f_amount TYPE BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to change type of f_amount in t_block_withdrawals")
+	}
+
+	return nil
+}
+
+// createValidatorClusters creates the t_validator_cluster_links and t_validator_clusters tables.
+func createValidatorClusters(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_validator_cluster_links (
+  f_validator_index BIGINT NOT NULL
+ ,f_type            TEXT NOT NULL
+ ,f_key             BYTEA NOT NULL
+ ,f_epoch           BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_validator_cluster_links")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_validator_cluster_links_1 ON t_validator_cluster_links(f_validator_index,f_type,f_key)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_cluster_links_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_validator_cluster_links_2 ON t_validator_cluster_links(f_type,f_key)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_cluster_links_2")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_validator_clusters (
+  f_validator_index BIGINT PRIMARY KEY
+ ,f_cluster_id      BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_validator_clusters")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_validator_clusters_1 ON t_validator_clusters(f_cluster_id)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_clusters_1")
+	}
+
+	return nil
+}
+
+// dropValidatorClusters drops the t_validator_cluster_links and t_validator_clusters tables.
+func dropValidatorClusters(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_validator_clusters`); err != nil {
+		return errors.Wrap(err, "failed to drop t_validator_clusters")
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_validator_cluster_links`); err != nil {
+		return errors.Wrap(err, "failed to drop t_validator_cluster_links")
+	}
+
+	return nil
+}
+
+// createFailedItems creates the t_failed_items table.
+func createFailedItems(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_failed_items (
+  f_module        TEXT NOT NULL
+ ,f_kind          TEXT NOT NULL
+ ,f_item          BIGINT NOT NULL
+ ,f_state         TEXT NOT NULL
+ ,f_attempts      INTEGER NOT NULL
+ ,f_error         TEXT NOT NULL
+ ,f_first_failure TIMESTAMPTZ NOT NULL
+ ,f_last_failure  TIMESTAMPTZ NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_failed_items")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_failed_items_1 ON t_failed_items(f_module,f_kind,f_item)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_failed_items_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_failed_items_2 ON t_failed_items(f_state)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_failed_items_2")
+	}
+
+	return nil
+}
+
+// dropFailedItems drops the t_failed_items table.
+func dropFailedItems(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_failed_items`); err != nil {
+		return errors.Wrap(err, "failed to drop t_failed_items")
+	}
+
+	return nil
+}
+
+// createForks creates the t_forks table.
+func createForks(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_forks (
+  f_name    TEXT PRIMARY KEY
+ ,f_epoch   BIGINT NOT NULL
+ ,f_version BYTEA UNIQUE NOT NULL
+ ,f_digest  BYTEA NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_forks")
+	}
+
+	return nil
+}
+
+// dropForks drops the t_forks table.
+func dropForks(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_forks`); err != nil {
+		return errors.Wrap(err, "failed to drop t_forks")
+	}
+
+	return nil
+}
+
+// addSyncAggregateSignatures adds the f_signature column to t_sync_aggregates.
+func addSyncAggregateSignatures(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_sync_aggregates
+ADD COLUMN IF NOT EXISTS f_signature BYTEA
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_signature to t_sync_aggregates")
+	}
+
+	return nil
+}
+
+// dropSyncAggregateSignatures removes the f_signature column from t_sync_aggregates.
+func dropSyncAggregateSignatures(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_sync_aggregates
+DROP COLUMN IF EXISTS f_signature
+`); err != nil {
+		return errors.Wrap(err, "failed to drop f_signature from t_sync_aggregates")
+	}
+
+	return nil
+}
+
+// addChainSpecTypes adds the f_type column to t_chain_spec.
+func addChainSpecTypes(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_chain_spec
+ADD COLUMN IF NOT EXISTS f_type TEXT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_type to t_chain_spec")
+	}
+
+	return nil
+}
+
+// dropChainSpecTypes removes the f_type column from t_chain_spec.
+func dropChainSpecTypes(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_chain_spec
+DROP COLUMN IF EXISTS f_type
+`); err != nil {
+		return errors.Wrap(err, "failed to drop f_type from t_chain_spec")
+	}
+
+	return nil
+}
+
+// createWithdrawalForecasts creates the t_withdrawal_forecasts table.
+func createWithdrawalForecasts(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_withdrawal_forecasts (
+  f_validator_index    BIGINT NOT NULL PRIMARY KEY
+ ,f_epoch              BIGINT NOT NULL
+ ,f_withdrawable_epoch BIGINT NOT NULL
+ ,f_slot               BIGINT
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_withdrawal_forecasts")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_withdrawal_forecasts_1 ON t_withdrawal_forecasts(f_slot)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_withdrawal_forecasts_1")
+	}
+
+	return nil
+}
+
+// dropWithdrawalForecasts drops the t_withdrawal_forecasts table.
+func dropWithdrawalForecasts(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_withdrawal_forecasts`); err != nil {
+		return errors.Wrap(err, "failed to drop t_withdrawal_forecasts")
+	}
+
+	return nil
+}
+
+// createAuditLog creates the t_audit_log table.
+func createAuditLog(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_audit_log (
+  f_timestamp  TIMESTAMPTZ NOT NULL
+ ,f_started    TIMESTAMPTZ NOT NULL
+ ,f_tx_id      TEXT NOT NULL
+ ,f_module     TEXT NOT NULL
+ ,f_operations JSONB NOT NULL
+ ,f_rows       BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_audit_log")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_audit_log_1 ON t_audit_log(f_timestamp)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_audit_log_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_audit_log_2 ON t_audit_log(f_module,f_timestamp)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_audit_log_2")
+	}
+
+	return nil
+}
+
+// dropAuditLog drops the t_audit_log table.
+func dropAuditLog(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_audit_log`); err != nil {
+		return errors.Wrap(err, "failed to drop t_audit_log")
+	}
+
+	return nil
+}
+
+// createBlobFees creates the t_blob_fees table.
+func createBlobFees(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_blob_fees (
+  f_slot                      BIGINT PRIMARY KEY
+ ,f_execution_block_number    BIGINT NOT NULL
+ ,f_excess_blob_gas           BIGINT NOT NULL
+ ,f_blob_gas_used             BIGINT NOT NULL
+ ,f_target_blob_gas           BIGINT NOT NULL
+ ,f_blob_base_fee             NUMERIC NOT NULL
+ ,f_window_blocks             INTEGER NOT NULL
+ ,f_window_blob_gas_used      BIGINT NOT NULL
+ ,f_window_mean_blob_base_fee NUMERIC NOT NULL
+ ,f_window_min_blob_base_fee  NUMERIC NOT NULL
+ ,f_window_max_blob_base_fee  NUMERIC NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_blob_fees")
+	}
+
+	return nil
+}
+
+// dropBlobFees drops the t_blob_fees table.
+func dropBlobFees(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_blob_fees`); err != nil {
+		return errors.Wrap(err, "failed to drop t_blob_fees")
+	}
+
+	return nil
+}
+
+// createValidatorAnomalies creates the t_validator_anomalies table.
+func createValidatorAnomalies(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_validator_anomalies (
+  f_type                     TEXT NOT NULL
+ ,f_public_key               BYTEA NOT NULL
+ ,f_validator_index          BIGINT
+ ,f_existing_public_key      BYTEA
+ ,f_existing_validator_index BIGINT
+ ,f_inclusion_slot           BIGINT
+ ,f_first_seen               TIMESTAMPTZ NOT NULL
+ ,f_last_seen                TIMESTAMPTZ NOT NULL
+ ,f_details                  TEXT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_validator_anomalies")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_validator_anomalies_1 ON t_validator_anomalies(f_public_key)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_anomalies_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_validator_anomalies_2 ON t_validator_anomalies(f_last_seen)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_anomalies_2")
+	}
+
+	return nil
+}
+
+// dropValidatorAnomalies drops the t_validator_anomalies table.
+func dropValidatorAnomalies(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_validator_anomalies`); err != nil {
+		return errors.Wrap(err, "failed to drop t_validator_anomalies")
+	}
+
+	return nil
+}
+
+// createBeaconCommitteeMembersIndex creates an index on the members of beacon committees.
+func createBeaconCommitteeMembersIndex(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_beacon_committees_2 ON t_beacon_committees USING GIN(f_committee)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_beacon_committees_2")
+	}
+
+	return nil
+}
+
+// dropBeaconCommitteeMembersIndex drops the index on the members of beacon committees.
+func dropBeaconCommitteeMembersIndex(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP INDEX IF EXISTS i_beacon_committees_2`); err != nil {
+		return errors.Wrap(err, "failed to drop i_beacon_committees_2")
+	}
+
+	return nil
+}
+
+// createBalanceAnomalies creates the t_balance_anomalies table.
+func createBalanceAnomalies(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_balance_anomalies (
+  f_validator_index    BIGINT NOT NULL
+ ,f_epoch              BIGINT NOT NULL
+ ,f_reason             TEXT NOT NULL
+ ,f_previous_balance   BIGINT NOT NULL
+ ,f_balance            BIGINT NOT NULL
+ ,f_withdrawn          BIGINT NOT NULL
+ ,f_deposited          BIGINT NOT NULL
+ ,f_delta              BIGINT NOT NULL
+ ,f_expected_min_delta BIGINT NOT NULL
+ ,f_expected_max_delta BIGINT
+ ,f_deviation          BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_balance_anomalies")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_balance_anomalies_1 ON t_balance_anomalies(f_validator_index,f_epoch)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_balance_anomalies_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_balance_anomalies_2 ON t_balance_anomalies(f_epoch)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_balance_anomalies_2")
+	}
+
+	return nil
+}
+
+// dropBalanceAnomalies drops the t_balance_anomalies table.
+func dropBalanceAnomalies(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_balance_anomalies`); err != nil {
+		return errors.Wrap(err, "failed to drop t_balance_anomalies")
+	}
+
+	return nil
+}
+
+// createBlockHeaders creates the t_block_headers table.
+func createBlockHeaders(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_block_headers (
+  f_slot           BIGINT NOT NULL
+ ,f_proposer_index BIGINT NOT NULL
+ ,f_root           BYTEA NOT NULL
+ ,f_parent_root    BYTEA NOT NULL
+ ,f_state_root     BYTEA NOT NULL
+ ,f_body_root      BYTEA NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_block_headers")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_block_headers_1 ON t_block_headers(f_slot,f_root)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_block_headers_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_block_headers_2 ON t_block_headers(f_root)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_block_headers_2")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_block_headers_3 ON t_block_headers(f_parent_root)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_block_headers_3")
+	}
+
+	return nil
+}
+
+// dropBlockHeaders drops the t_block_headers table.
+func dropBlockHeaders(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_block_headers`); err != nil {
+		return errors.Wrap(err, "failed to drop t_block_headers")
+	}
+
+	return nil
+}
+
+// addExecutionPayloadTransactionStats adds transaction statistics fields to t_block_execution_payloads.
+func addExecutionPayloadTransactionStats(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_block_execution_payloads
+ADD COLUMN IF NOT EXISTS f_transaction_count BIGINT
+,ADD COLUMN IF NOT EXISTS f_transactions_size BIGINT
+,ADD COLUMN IF NOT EXISTS f_blob_transaction_count BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add transaction statistics fields to t_block_execution_payloads")
+	}
+
+	return nil
+}
+
+// dropExecutionPayloadTransactionStats drops transaction statistics fields from t_block_execution_payloads.
+func dropExecutionPayloadTransactionStats(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_block_execution_payloads
+DROP COLUMN IF EXISTS f_transaction_count
+,DROP COLUMN IF EXISTS f_transactions_size
+,DROP COLUMN IF EXISTS f_blob_transaction_count
+`); err != nil {
+		return errors.Wrap(err, "failed to drop transaction statistics fields from t_block_execution_payloads")
+	}
+
+	return nil
+}
+
+// createShufflingMismatches creates the t_shuffling_mismatches table.
+func createShufflingMismatches(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_shuffling_mismatches (
+  f_slot               BIGINT NOT NULL
+ ,f_index              BIGINT NOT NULL
+ ,f_committee          BIGINT[]
+ ,f_expected_committee BIGINT[]
+ ,f_detected           TIMESTAMPTZ NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_shuffling_mismatches")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_shuffling_mismatches_1 ON t_shuffling_mismatches(f_slot,f_index)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_shuffling_mismatches_1")
+	}
+
+	return nil
+}
+
+// dropShufflingMismatches drops the t_shuffling_mismatches table.
+func dropShufflingMismatches(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_shuffling_mismatches`); err != nil {
+		return errors.Wrap(err, "failed to drop t_shuffling_mismatches")
+	}
+
+	return nil
+}
+
+// createRANDAOMixes creates the t_randao_mixes table.
+func createRANDAOMixes(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_randao_mixes (
+  f_epoch      BIGINT PRIMARY KEY
+ ,f_randao_mix BYTEA NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_randao_mixes")
+	}
+
+	return nil
+}
+
+// dropRANDAOMixes drops the t_randao_mixes table.
+func dropRANDAOMixes(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_randao_mixes`); err != nil {
+		return errors.Wrap(err, "failed to drop t_randao_mixes")
+	}
+
+	return nil
+}
+
+// createClientReleases creates the t_client_releases and t_block_client_releases tables.
+func createClientReleases(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_client_releases (
+  f_layer     TEXT NOT NULL
+ ,f_client    TEXT NOT NULL
+ ,f_version   TEXT NOT NULL
+ ,f_released  TIMESTAMPTZ NOT NULL
+ ,f_behaviors TEXT[] NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_client_releases")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_client_releases_1 ON t_client_releases(f_layer,f_client,f_version)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_client_releases_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_block_client_releases (
+  f_block_root                BYTEA UNIQUE NOT NULL REFERENCES t_blocks(f_root) ON DELETE CASCADE
+ ,f_slot                      BIGINT NOT NULL
+ ,f_consensus_client          TEXT
+ ,f_consensus_version         TEXT
+ ,f_consensus_version_matched BOOLEAN
+ ,f_execution_client          TEXT
+ ,f_execution_version         TEXT
+ ,f_execution_version_matched BOOLEAN
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_block_client_releases")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_block_client_releases_1 ON t_block_client_releases(f_slot)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_block_client_releases_1")
+	}
+
+	return nil
+}
+
+// dropClientReleases drops the t_client_releases and t_block_client_releases tables.
+func dropClientReleases(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_block_client_releases`); err != nil {
+		return errors.Wrap(err, "failed to drop t_block_client_releases")
+	}
+
+	if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS t_client_releases`); err != nil {
+		return errors.Wrap(err, "failed to drop t_client_releases")
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateMetadataToProgress(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(ctx,
+		WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+	tx := s.tx(ctx)
+
+	// Metadata as written by each service before the move to t_progress.
+	metadata := map[string]string{
+		"archiver.standard":         `{"latest_validator_balance_epoch":12}`,
+		"beaconcommittees.standard": `{"latest_epoch":100}`,
+		"blocks.standard":           `{"latest_slot":3200,"latest_header_slot":3300}`,
+		"eth1deposit.getlogs":       `{"latest_block":9000000}`,
+		"finalizer.standard":        `{"latest_epoch":98,"latest_canonical_slot":3136,"missed_epochs":[5,7]}`,
+		"proposerduties.standard":   `{"latest_epoch":101,"missed_epochs":[]}`,
+		"summarizer.standard":       `{"latest_epoch":97,"latest_block_epoch":96,"latest_validator_day":19000,"period_epochs":true}`,
+		"synccommittees.standard":   `{"latest_period":3,"backfilled":false}`,
+		"validators.standard":       `{"latest_epoch":99,"latest_balances_epoch":95,"missed_epochs":[1,2,3],"name":"ignored"}`,
+		"unrelated":                 `{"latest_epoch":1}`,
+	}
+	for key, value := range metadata {
+		_, err := tx.Exec(ctx, `
+INSERT INTO t_metadata(f_key,f_value)
+VALUES($1,$2)
+ON CONFLICT (f_key) DO UPDATE
+SET f_value = excluded.f_value`,
+			key,
+			value,
+		)
+		require.NoError(t, err)
+	}
+	// Start from no progress for the services, as if before the migration.
+	_, err = tx.Exec(ctx, `DELETE FROM t_progress WHERE f_service = ANY($1)`, mapKeys(metadata))
+	require.NoError(t, err)
+	_, err = tx.Exec(ctx, `DELETE FROM t_progress_gaps WHERE f_service = ANY($1)`, mapKeys(metadata))
+	require.NoError(t, err)
+
+	require.NoError(t, migrateMetadataToProgress(ctx, s))
+
+	// Numbers and booleans become values; strings are dropped.
+	require.Equal(t, map[string]map[string]int64{
+		"archiver.standard":         {"latest_validator_balance_epoch": 12},
+		"beaconcommittees.standard": {"latest_epoch": 100},
+		"blocks.standard":           {"latest_slot": 3200, "latest_header_slot": 3300},
+		"eth1deposit.getlogs":       {"latest_block": 9000000},
+		"finalizer.standard":        {"latest_epoch": 98, "latest_canonical_slot": 3136},
+		"proposerduties.standard":   {"latest_epoch": 101},
+		"summarizer.standard":       {"latest_epoch": 97, "latest_block_epoch": 96, "latest_validator_day": 19000, "period_epochs": 1},
+		"synccommittees.standard":   {"latest_period": 3, "backfilled": 0},
+		"validators.standard":       {"latest_epoch": 99, "latest_balances_epoch": 95},
+	}, migrationRows(ctx, t, s, "t_progress", mapKeys(metadata)))
+
+	// Arrays become gaps.
+	gaps := migrationRows(ctx, t, s, "t_progress_gaps", mapKeys(metadata))
+	require.Equal(t, map[string]map[string]int64{
+		"finalizer.standard":  {"missed_epochs/5": 5, "missed_epochs/7": 7},
+		"validators.standard": {"missed_epochs/1": 1, "missed_epochs/2": 2, "missed_epochs/3": 3},
+	}, gaps)
+
+	// Migrated metadata is removed, and other metadata is left alone.
+	var remaining []string
+	rows, err := tx.Query(ctx, `SELECT f_key FROM t_metadata WHERE f_key = ANY($1) ORDER BY f_key`, mapKeys(metadata))
+	require.NoError(t, err)
+	for rows.Next() {
+		var key string
+		require.NoError(t, rows.Scan(&key))
+		remaining = append(remaining, key)
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []string{"unrelated"}, remaining)
+
+	// Running again is harmless, and does not replace progress that has since been made.
+	_, err = tx.Exec(ctx, `UPDATE t_progress SET f_value = 3300 WHERE f_service = 'blocks.standard' AND f_key = 'latest_slot'`)
+	require.NoError(t, err)
+	_, err = tx.Exec(ctx, `INSERT INTO t_metadata(f_key,f_value) VALUES('blocks.standard','{"latest_slot":3200}')`)
+	require.NoError(t, err)
+	require.NoError(t, migrateMetadataToProgress(ctx, s))
+	require.Equal(t, int64(3300), migrationRows(ctx, t, s, "t_progress", []string{"blocks.standard"})["blocks.standard"]["latest_slot"])
+}
+
+// mapKeys returns the keys of the map.
+func mapKeys(input map[string]string) []string {
+	res := make([]string, 0, len(input))
+	for key := range input {
+		res = append(res, key)
+	}
+
+	return res
+}
+
+// migrationRows returns the rows of a progress table for the given services, by service then key.
+// Gaps are keyed by their key and value, as there can be more than one for a key.
+func migrationRows(ctx context.Context, t *testing.T, s *Service, table string, services []string) map[string]map[string]int64 {
+	t.Helper()
+
+	rows, err := s.tx(ctx).Query(ctx, `SELECT f_service,f_key,f_value FROM `+table+` WHERE f_service = ANY($1)`, services)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	res := make(map[string]map[string]int64)
+	for rows.Next() {
+		var service string
+		var key string
+		var value int64
+		require.NoError(t, rows.Scan(&service, &key, &value))
+		if _, exists := res[service]; !exists {
+			res[service] = make(map[string]int64)
+		}
+		if table == "t_progress_gaps" {
+			key = key + "/" + strconv.FormatInt(value, 10)
+		}
+		res[service][key] = value
+	}
+	require.NoError(t, rows.Err())
+
+	return res
+}
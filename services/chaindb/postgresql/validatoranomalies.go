@@ -0,0 +1,301 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// recordValidatorMappingAnomalies records the anomalies for a validator that
+// conflicts with the index to public key mapping already held.
+func (s *Service) recordValidatorMappingAnomalies(ctx context.Context, tx pgx.Tx, validator *chaindb.Validator) error {
+	rows, err := tx.Query(ctx, `
+SELECT f_index
+      ,f_public_key
+FROM t_validators
+WHERE f_index = $1
+   OR f_public_key = $2
+`,
+		validator.Index,
+		validator.PublicKey[:],
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain conflicting validators")
+	}
+
+	anomalies := make([]*chaindb.ValidatorAnomaly, 0)
+	for rows.Next() {
+		var existingIndex phase0.ValidatorIndex
+		var existingPublicKeyBytes []byte
+		if err := rows.Scan(&existingIndex, &existingPublicKeyBytes); err != nil {
+			rows.Close()
+			return errors.Wrap(err, "failed to scan row")
+		}
+		var existingPublicKey phase0.BLSPubKey
+		copy(existingPublicKey[:], existingPublicKeyBytes)
+
+		index := validator.Index
+		anomaly := &chaindb.ValidatorAnomaly{
+			PublicKey:      validator.PublicKey,
+			ValidatorIndex: &index,
+		}
+		switch {
+		case existingIndex == validator.Index && existingPublicKey != validator.PublicKey:
+			anomaly.Type = chaindb.ValidatorAnomalyIndexPublicKeyChanged
+			anomaly.ExistingPublicKey = &existingPublicKey
+			anomaly.Details = fmt.Sprintf("validator %d has public key %#x but is held with public key %#x", validator.Index, validator.PublicKey, existingPublicKey)
+		case existingIndex != validator.Index && existingPublicKey == validator.PublicKey:
+			anomaly.Type = chaindb.ValidatorAnomalyPublicKeyIndexChanged
+			anomaly.ExistingValidatorIndex = &existingIndex
+			anomaly.Details = fmt.Sprintf("public key %#x has validator index %d but is held with validator index %d", validator.PublicKey, validator.Index, existingIndex)
+		default:
+			continue
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "failed to obtain conflicting validators")
+	}
+
+	for _, anomaly := range anomalies {
+		log.Warn().Str("type", anomaly.Type).Str("details", anomaly.Details).Msg("Validator anomaly; not storing validator")
+		if err := s.recordValidatorAnomaly(ctx, tx, anomaly); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordDepositCredentialsMismatch records an anomaly for a deposit with
+// withdrawal credentials that differ from those of the first deposit for its
+// public key.
+func (s *Service) recordDepositCredentialsMismatch(ctx context.Context,
+	tx pgx.Tx,
+	deposit *chaindb.Deposit,
+	credentials []byte,
+	inclusionSlot phase0.Slot,
+) error {
+	slot := deposit.InclusionSlot
+	anomaly := &chaindb.ValidatorAnomaly{
+		Type:          chaindb.ValidatorAnomalyDepositCredentialsMismatch,
+		PublicKey:     deposit.ValidatorPubKey,
+		InclusionSlot: &slot,
+		Details: fmt.Sprintf("deposit at slot %d has withdrawal credentials %#x but the first deposit, at slot %d, has withdrawal credentials %#x",
+			deposit.InclusionSlot, deposit.WithdrawalCredentials, inclusionSlot, credentials),
+	}
+	log.Warn().Str("type", anomaly.Type).Str("details", anomaly.Details).Msg("Validator anomaly")
+
+	return s.recordValidatorAnomaly(ctx, tx, anomaly)
+}
+
+// recordValidatorAnomaly records a validator anomaly.  An anomaly that has
+// already been recorded has the time at which it was last seen updated.
+func (*Service) recordValidatorAnomaly(ctx context.Context, tx pgx.Tx, anomaly *chaindb.ValidatorAnomaly) error {
+	now := time.Now()
+
+	var existingPublicKey []byte
+	if anomaly.ExistingPublicKey != nil {
+		existingPublicKey = anomaly.ExistingPublicKey[:]
+	}
+
+	tag, err := tx.Exec(ctx, `
+UPDATE t_validator_anomalies
+SET f_last_seen = $7
+   ,f_details = $8
+WHERE f_type = $1
+  AND f_public_key = $2
+  AND f_validator_index IS NOT DISTINCT FROM $3
+  AND f_existing_public_key IS NOT DISTINCT FROM $4
+  AND f_existing_validator_index IS NOT DISTINCT FROM $5
+  AND f_inclusion_slot IS NOT DISTINCT FROM $6
+`,
+		anomaly.Type,
+		anomaly.PublicKey[:],
+		anomaly.ValidatorIndex,
+		existingPublicKey,
+		anomaly.ExistingValidatorIndex,
+		anomaly.InclusionSlot,
+		now,
+		anomaly.Details,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to update validator anomaly")
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `
+INSERT INTO t_validator_anomalies(f_type
+                                 ,f_public_key
+                                 ,f_validator_index
+                                 ,f_existing_public_key
+                                 ,f_existing_validator_index
+                                 ,f_inclusion_slot
+                                 ,f_first_seen
+                                 ,f_last_seen
+                                 ,f_details)
+VALUES($1,$2,$3,$4,$5,$6,$7,$7,$8)
+`,
+		anomaly.Type,
+		anomaly.PublicKey[:],
+		anomaly.ValidatorIndex,
+		existingPublicKey,
+		anomaly.ExistingValidatorIndex,
+		anomaly.InclusionSlot,
+		now,
+		anomaly.Details,
+	); err != nil {
+		return errors.Wrap(err, "failed to insert validator anomaly")
+	}
+
+	return nil
+}
+
+// ValidatorAnomalies provides validator anomalies according to the filter.
+func (s *Service) ValidatorAnomalies(ctx context.Context, filter *chaindb.ValidatorAnomalyFilter) ([]*chaindb.ValidatorAnomaly, error) {
+	ctx, span := startSpan(ctx, "ValidatorAnomalies")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_type
+      ,f_public_key
+      ,f_validator_index
+      ,f_existing_public_key
+      ,f_existing_validator_index
+      ,f_inclusion_slot
+      ,f_first_seen
+      ,f_last_seen
+      ,f_details
+FROM t_validator_anomalies`)
+
+	wherestr := "WHERE"
+
+	if len(filter.Types) > 0 {
+		queryVals = append(queryVals, filter.Types)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_type = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.PublicKeys) > 0 {
+		publicKeys := make([][]byte, len(filter.PublicKeys))
+		for i := range filter.PublicKeys {
+			publicKeys[i] = filter.PublicKeys[i][:]
+		}
+		queryVals = append(queryVals, publicKeys)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_public_key = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s (f_validator_index = ANY($%d) OR f_existing_validator_index = ANY($%d))`, wherestr, len(queryVals), len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_last_seen`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_last_seen DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	anomalies := make([]*chaindb.ValidatorAnomaly, 0)
+	for rows.Next() {
+		anomaly := &chaindb.ValidatorAnomaly{}
+		var publicKey []byte
+		var existingPublicKey []byte
+		err := rows.Scan(
+			&anomaly.Type,
+			&publicKey,
+			&anomaly.ValidatorIndex,
+			&existingPublicKey,
+			&anomaly.ExistingValidatorIndex,
+			&anomaly.InclusionSlot,
+			&anomaly.FirstSeen,
+			&anomaly.LastSeen,
+			&anomaly.Details,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(anomaly.PublicKey[:], publicKey)
+		if existingPublicKey != nil {
+			anomaly.ExistingPublicKey = &phase0.BLSPubKey{}
+			copy(anomaly.ExistingPublicKey[:], existingPublicKey)
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+
+	// Always return order of last seen.
+	sort.Slice(anomalies, func(i int, j int) bool {
+		return anomalies[i].LastSeen.Before(anomalies[j].LastSeen)
+	})
+
+	return anomalies, rows.Err()
+}
@@ -0,0 +1,181 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+// anomalyPubKey creates a public key for anomaly tests.
+func anomalyPubKey(seed byte) phase0.BLSPubKey {
+	var pubKey phase0.BLSPubKey
+	for i := range pubKey {
+		pubKey[i] = seed
+	}
+	pubKey[0] = 0xa0
+
+	return pubKey
+}
+
+// anomalyValidator creates a validator for anomaly tests.
+func anomalyValidator(index phase0.ValidatorIndex, pubKey phase0.BLSPubKey, effectiveBalance phase0.Gwei) *chaindb.Validator {
+	return &chaindb.Validator{
+		PublicKey:                  pubKey,
+		Index:                      index,
+		EffectiveBalance:           effectiveBalance,
+		ActivationEligibilityEpoch: 1,
+		ActivationEpoch:            2,
+		ExitEpoch:                  0xffffffffffffffff,
+		WithdrawableEpoch:          0xffffffffffffffff,
+		WithdrawalCredentials:      [32]byte{0x01, 0x02, 0x03, 0x04},
+	}
+}
+
+func TestSetValidatorMappingAnomalies(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	index1 := phase0.ValidatorIndex(9000001)
+	index2 := phase0.ValidatorIndex(9000002)
+	pubKey1 := anomalyPubKey(0x01)
+	pubKey2 := anomalyPubKey(0x02)
+
+	// Set the validator, then update it; the matching mapping allows the update.
+	require.NoError(t, s.SetValidator(ctx, anomalyValidator(index1, pubKey1, 31000000000)))
+	require.NoError(t, s.SetValidator(ctx, anomalyValidator(index1, pubKey1, 32000000000)))
+	validators, err := s.ValidatorsByIndex(ctx, []phase0.ValidatorIndex{index1})
+	require.NoError(t, err)
+	require.Equal(t, phase0.Gwei(32000000000), validators[index1].EffectiveBalance)
+
+	anomalies, err := s.ValidatorAnomalies(ctx, &chaindb.ValidatorAnomalyFilter{
+		PublicKeys: []phase0.BLSPubKey{pubKey1, pubKey2},
+	})
+	require.NoError(t, err)
+	require.Empty(t, anomalies)
+
+	// Changing the public key for the index is refused by the conflict
+	// condition, and recorded.
+	require.NoError(t, s.SetValidator(ctx, anomalyValidator(index1, pubKey2, 1)))
+	validators, err = s.ValidatorsByIndex(ctx, []phase0.ValidatorIndex{index1})
+	require.NoError(t, err)
+	require.Equal(t, pubKey1, validators[index1].PublicKey)
+	require.Equal(t, phase0.Gwei(32000000000), validators[index1].EffectiveBalance)
+
+	anomalies, err = s.ValidatorAnomalies(ctx, &chaindb.ValidatorAnomalyFilter{
+		Types:      []string{chaindb.ValidatorAnomalyIndexPublicKeyChanged},
+		PublicKeys: []phase0.BLSPubKey{pubKey2},
+	})
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	require.Equal(t, index1, *anomalies[0].ValidatorIndex)
+	require.Equal(t, pubKey1, *anomalies[0].ExistingPublicKey)
+	require.Nil(t, anomalies[0].ExistingValidatorIndex)
+
+	// Reusing the public key for a new index is refused by the existence
+	// guard, and recorded.
+	require.NoError(t, s.SetValidator(ctx, anomalyValidator(index2, pubKey1, 1)))
+	validators, err = s.ValidatorsByIndex(ctx, []phase0.ValidatorIndex{index2})
+	require.NoError(t, err)
+	require.Empty(t, validators)
+
+	anomalies, err = s.ValidatorAnomalies(ctx, &chaindb.ValidatorAnomalyFilter{
+		Types:      []string{chaindb.ValidatorAnomalyPublicKeyIndexChanged},
+		PublicKeys: []phase0.BLSPubKey{pubKey1},
+	})
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	require.Equal(t, index2, *anomalies[0].ValidatorIndex)
+	require.Equal(t, index1, *anomalies[0].ExistingValidatorIndex)
+	require.Nil(t, anomalies[0].ExistingPublicKey)
+
+	// Seeing the same anomaly again updates it rather than adding another.
+	require.NoError(t, s.SetValidator(ctx, anomalyValidator(index2, pubKey1, 1)))
+	anomalies, err = s.ValidatorAnomalies(ctx, &chaindb.ValidatorAnomalyFilter{
+		PublicKeys: []phase0.BLSPubKey{pubKey1, pubKey2},
+	})
+	require.NoError(t, err)
+	require.Len(t, anomalies, 2)
+}
+
+func TestSetDepositCredentialsAnomalies(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	// Fetch a block so we can set the deposits' block root.
+	blocks, err := s.BlocksBySlot(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	pubKey := anomalyPubKey(0x03)
+	deposit := func(index uint64, credentials []byte) *chaindb.Deposit {
+		return &chaindb.Deposit{
+			InclusionSlot:         0,
+			InclusionBlockRoot:    blocks[0].Root,
+			InclusionIndex:        9000000 + index,
+			ValidatorPubKey:       pubKey,
+			WithdrawalCredentials: credentials,
+			Amount:                32000000000,
+		}
+	}
+	filter := &chaindb.ValidatorAnomalyFilter{
+		Types:      []string{chaindb.ValidatorAnomalyDepositCredentialsMismatch},
+		PublicKeys: []phase0.BLSPubKey{pubKey},
+	}
+
+	// The first deposit, and later deposits with the same credentials, are not anomalies.
+	require.NoError(t, s.SetDeposit(ctx, deposit(1, []byte{0x01, 0x02})))
+	require.NoError(t, s.SetDeposit(ctx, deposit(2, []byte{0x01, 0x02})))
+	// Setting the first deposit again does not compare it with itself or later deposits.
+	require.NoError(t, s.SetDeposit(ctx, deposit(1, []byte{0x01, 0x02})))
+	anomalies, err := s.ValidatorAnomalies(ctx, filter)
+	require.NoError(t, err)
+	require.Empty(t, anomalies)
+
+	// A duplicate deposit with different credentials is stored and recorded.
+	require.NoError(t, s.SetDeposit(ctx, deposit(3, []byte{0x03, 0x04})))
+	deposits, err := s.DepositsByPublicKey(ctx, []phase0.BLSPubKey{pubKey})
+	require.NoError(t, err)
+	require.Len(t, deposits[pubKey], 3)
+
+	anomalies, err = s.ValidatorAnomalies(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	require.Equal(t, phase0.Slot(0), *anomalies[0].InclusionSlot)
+	require.Contains(t, anomalies[0].Details, "0x0102")
+	require.Contains(t, anomalies[0].Details, "0x0304")
+}
@@ -0,0 +1,211 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// daysPerYear is the number of days used to annualise returns.
+const daysPerYear = 365
+
+// ValidatorAPRs calculates the APR of each validator matching the filter.
+func (s *Service) ValidatorAPRs(ctx context.Context, filter *chaindb.ValidatorAPRFilter) ([]*chaindb.ValidatorAPR, error) {
+	ctx, span := startSpan(ctx, "ValidatorAPRs")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	query, queryVals, err := validatorAPRDaysQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	queryBuilder := strings.Builder{}
+	queryBuilder.WriteString(query)
+	queryBuilder.WriteString(`
+SELECT f_validator_index
+      ,SUM(f_fraction)
+      ,SUM(f_fraction * f_reward_change)
+      ,SUM(f_fraction * f_capital)
+      ,SUM(f_fraction * f_effective_balance)
+FROM days
+GROUP BY f_validator_index
+ORDER BY f_validator_index`)
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aprs := make([]*chaindb.ValidatorAPR, 0)
+	for rows.Next() {
+		apr := &chaindb.ValidatorAPR{
+			Validators: 1,
+		}
+		var rewards float64
+		var capitalDays float64
+		var effectiveBalanceDays float64
+		err := rows.Scan(
+			&apr.Index,
+			&apr.Days,
+			&rewards,
+			&capitalDays,
+			&effectiveBalanceDays,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		setValidatorAPR(apr, rewards, capitalDays, effectiveBalanceDays)
+		aprs = append(aprs, apr)
+	}
+
+	return aprs, nil
+}
+
+// AggregateValidatorAPR calculates the combined APR of all validators matching the filter.
+func (s *Service) AggregateValidatorAPR(ctx context.Context, filter *chaindb.ValidatorAPRFilter) (*chaindb.ValidatorAPR, error) {
+	ctx, span := startSpan(ctx, "AggregateValidatorAPR")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	query, queryVals, err := validatorAPRDaysQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	queryBuilder := strings.Builder{}
+	queryBuilder.WriteString(query)
+	queryBuilder.WriteString(`
+SELECT COUNT(DISTINCT f_validator_index)
+      ,COALESCE(SUM(f_fraction),0)
+      ,COALESCE(SUM(f_fraction * f_reward_change),0)
+      ,COALESCE(SUM(f_fraction * f_capital),0)
+      ,COALESCE(SUM(f_fraction * f_effective_balance),0)
+FROM days`)
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	apr := &chaindb.ValidatorAPR{}
+	var rewards float64
+	var capitalDays float64
+	var effectiveBalanceDays float64
+	err = tx.QueryRow(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	).Scan(
+		&apr.Validators,
+		&apr.Days,
+		&rewards,
+		&capitalDays,
+		&effectiveBalanceDays,
+	)
+	if err != nil {
+		return nil, err
+	}
+	setValidatorAPR(apr, rewards, capitalDays, effectiveBalanceDays)
+
+	return apr, nil
+}
+
+// validatorAPRDaysQuery builds the common table expression "days" that contains the
+// fraction of each validator day summary that falls within the window, along with
+// the time-weighted capital and effective balance for the day.
+//
+// Deposits, withdrawals and effective balance changes within a day are not timestamped,
+// so are assumed to take place half way through the day.
+func validatorAPRDaysQuery(filter *chaindb.ValidatorAPRFilter) (string, []any, error) {
+	if filter == nil {
+		return "", nil, errors.New("no filter supplied")
+	}
+	if !filter.To.After(filter.From) {
+		return "", nil, errors.New("window end must be after window start")
+	}
+
+	queryBuilder := strings.Builder{}
+	queryVals := []any{filter.From, filter.To}
+
+	queryBuilder.WriteString(`
+WITH days AS (
+  SELECT f_validator_index
+        ,EXTRACT(EPOCH FROM LEAST(f_start_timestamp + INTERVAL '24 hours', $2) - GREATEST(f_start_timestamp, $1))::DOUBLE PRECISION / 86400 AS f_fraction
+        ,f_reward_change
+        ,f_start_balance + f_capital_change / 2.0 AS f_capital
+        ,f_start_effective_balance + f_effective_balance_change / 2.0 AS f_effective_balance
+  FROM t_validator_day_summaries
+  WHERE f_start_timestamp > $1 - INTERVAL '24 hours'
+    AND f_start_timestamp < $2`)
+
+	if filter.ValidatorIndices != nil && len(*filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, *filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+    AND f_validator_index = ANY($%d)`, len(queryVals)))
+	}
+
+	queryBuilder.WriteString(`
+)`)
+
+	return queryBuilder.String(), queryVals, nil
+}
+
+// setValidatorAPR sets the averages and APR of a validator APR from its time-weighted totals.
+func setValidatorAPR(apr *chaindb.ValidatorAPR, rewards float64, capitalDays float64, effectiveBalanceDays float64) {
+	apr.Rewards = int64(math.Round(rewards))
+	if apr.Days > 0 {
+		apr.AverageCapital = capitalDays / apr.Days
+		apr.AverageEffectiveBalance = effectiveBalanceDays / apr.Days
+	}
+	if capitalDays > 0 {
+		apr.APR = rewards * daysPerYear / capitalDays
+	}
+}
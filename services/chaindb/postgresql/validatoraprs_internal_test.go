@@ -0,0 +1,147 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestSetValidatorAPR(t *testing.T) {
+	tests := []struct {
+		name                 string
+		days                 float64
+		rewards              float64
+		capitalDays          float64
+		effectiveBalanceDays float64
+		expected             *chaindb.ValidatorAPR
+	}{
+		{
+			name:     "Empty",
+			expected: &chaindb.ValidatorAPR{},
+		},
+		{
+			name:                 "FullYear",
+			days:                 365,
+			rewards:              1_280_000_000,
+			capitalDays:          365 * 32_000_000_000,
+			effectiveBalanceDays: 365 * 32_000_000_000,
+			expected: &chaindb.ValidatorAPR{
+				Days:                    365,
+				Rewards:                 1_280_000_000,
+				AverageCapital:          32_000_000_000,
+				AverageEffectiveBalance: 32_000_000_000,
+				APR:                     0.04,
+			},
+		},
+		{
+			name:                 "PartialDay",
+			days:                 0.5,
+			rewards:              1_753_424.5,
+			capitalDays:          16_000_000_000,
+			effectiveBalanceDays: 16_000_000_000,
+			expected: &chaindb.ValidatorAPR{
+				Days:                    0.5,
+				Rewards:                 1_753_425,
+				AverageCapital:          32_000_000_000,
+				AverageEffectiveBalance: 32_000_000_000,
+				APR:                     0.04,
+			},
+		},
+		{
+			name:                 "Penalties",
+			days:                 1,
+			rewards:              -3_506_849,
+			capitalDays:          32_000_000_000,
+			effectiveBalanceDays: 32_000_000_000,
+			expected: &chaindb.ValidatorAPR{
+				Days:                    1,
+				Rewards:                 -3_506_849,
+				AverageCapital:          32_000_000_000,
+				AverageEffectiveBalance: 32_000_000_000,
+				APR:                     -0.04,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			apr := &chaindb.ValidatorAPR{Days: test.days}
+			setValidatorAPR(apr, test.rewards, test.capitalDays, test.effectiveBalanceDays)
+			require.Equal(t, test.expected.Rewards, apr.Rewards)
+			require.InDelta(t, test.expected.AverageCapital, apr.AverageCapital, 1e-6)
+			require.InDelta(t, test.expected.AverageEffectiveBalance, apr.AverageEffectiveBalance, 1e-6)
+			require.InDelta(t, test.expected.APR, apr.APR, 1e-6)
+		})
+	}
+}
+
+func TestValidatorAPRDaysQuery(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	indices := []phase0.ValidatorIndex{1, 2}
+
+	tests := []struct {
+		name   string
+		filter *chaindb.ValidatorAPRFilter
+		params int
+		err    string
+	}{
+		{
+			name: "FilterMissing",
+			err:  "no filter supplied",
+		},
+		{
+			name: "WindowEmpty",
+			filter: &chaindb.ValidatorAPRFilter{
+				From: from,
+				To:   from,
+			},
+			err: "window end must be after window start",
+		},
+		{
+			name: "Good",
+			filter: &chaindb.ValidatorAPRFilter{
+				From: from,
+				To:   to,
+			},
+			params: 2,
+		},
+		{
+			name: "Indices",
+			filter: &chaindb.ValidatorAPRFilter{
+				From:             from,
+				To:               to,
+				ValidatorIndices: &indices,
+			},
+			params: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, params, err := validatorAPRDaysQuery(test.filter)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, params, test.params)
+			}
+		})
+	}
+}
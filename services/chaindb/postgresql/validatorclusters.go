@@ -0,0 +1,463 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetValidatorClusterLinks sets validator cluster links, merging the clusters
+// of validators that they link.
+// Links that already exist keep the earliest epoch at which they were seen.
+func (s *Service) SetValidatorClusterLinks(ctx context.Context, links []*chaindb.ValidatorClusterLink) error {
+	ctx, span := startSpan(ctx, "SetValidatorClusterLinks")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	links = dedupeValidatorClusterLinks(links)
+	if len(links) == 0 {
+		return nil
+	}
+
+	validatorIndices := make([]uint64, len(links))
+	types := make([]string, len(links))
+	keys := make([][]byte, len(links))
+	epochs := make([]uint64, len(links))
+	for i, link := range links {
+		validatorIndices[i] = uint64(link.ValidatorIndex)
+		types[i] = link.Type
+		keys[i] = link.Key
+		epochs[i] = uint64(link.Epoch)
+	}
+
+	if _, err := tx.Exec(ctx, `
+INSERT INTO t_validator_cluster_links(f_validator_index
+                                     ,f_type
+                                     ,f_key
+                                     ,f_epoch)
+SELECT * FROM UNNEST($1::BIGINT[],$2::TEXT[],$3::BYTEA[],$4::BIGINT[])
+ON CONFLICT (f_validator_index,f_type,f_key) DO
+UPDATE
+SET f_epoch = LEAST(t_validator_cluster_links.f_epoch,excluded.f_epoch)
+`,
+		validatorIndices,
+		types,
+		keys,
+		epochs,
+	); err != nil {
+		return errors.Wrap(err, "failed to set validator cluster links")
+	}
+
+	// Obtain all validators that hold the items, along with the clusters to
+	// which they currently belong.
+	sharedLinks, err := s.validatorClusterLinksForKeys(ctx, types, keys)
+	if err != nil {
+		return err
+	}
+	linkedIndices := make([]uint64, 0, len(sharedLinks))
+	for _, link := range sharedLinks {
+		linkedIndices = append(linkedIndices, uint64(link.ValidatorIndex))
+	}
+	existing, err := s.validatorClusterMembers(ctx, linkedIndices)
+	if err != nil {
+		return err
+	}
+
+	assignments := validatorClusterAssignments(sharedLinks, existing)
+	if len(assignments) == 0 {
+		return nil
+	}
+	assignedIndices := make([]uint64, 0, len(assignments))
+	clusterIDs := make([]uint64, 0, len(assignments))
+	for validatorIndex, clusterID := range assignments {
+		assignedIndices = append(assignedIndices, uint64(validatorIndex))
+		clusterIDs = append(clusterIDs, uint64(clusterID))
+	}
+
+	if _, err := tx.Exec(ctx, `
+INSERT INTO t_validator_clusters(f_validator_index
+                                ,f_cluster_id)
+SELECT * FROM UNNEST($1::BIGINT[],$2::BIGINT[])
+ON CONFLICT (f_validator_index) DO
+UPDATE
+SET f_cluster_id = excluded.f_cluster_id
+`,
+		assignedIndices,
+		clusterIDs,
+	); err != nil {
+		return errors.Wrap(err, "failed to set validator clusters")
+	}
+
+	return nil
+}
+
+// validatorClusterLinksForKeys provides all links that hold any of the given items.
+func (s *Service) validatorClusterLinksForKeys(ctx context.Context,
+	types []string,
+	keys [][]byte,
+) (
+	[]*chaindb.ValidatorClusterLink,
+	error,
+) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return nil, ErrNoTransaction
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_validator_index
+      ,f_type
+      ,f_key
+      ,f_epoch
+FROM t_validator_cluster_links
+WHERE (f_type,f_key) IN (SELECT * FROM UNNEST($1::TEXT[],$2::BYTEA[]))`,
+		types,
+		keys,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain linked validators")
+	}
+	defer rows.Close()
+
+	return scanValidatorClusterLinks(rows)
+}
+
+// validatorClusterMembers provides the cluster of each validator in the
+// clusters of the given validators.
+func (s *Service) validatorClusterMembers(ctx context.Context,
+	validatorIndices []uint64,
+) (
+	map[phase0.ValidatorIndex]phase0.ValidatorIndex,
+	error,
+) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return nil, ErrNoTransaction
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_validator_index
+      ,f_cluster_id
+FROM t_validator_clusters
+WHERE f_cluster_id IN (SELECT f_cluster_id FROM t_validator_clusters WHERE f_validator_index = ANY($1))`,
+		validatorIndices,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator clusters")
+	}
+	defer rows.Close()
+
+	res := make(map[phase0.ValidatorIndex]phase0.ValidatorIndex)
+	for rows.Next() {
+		var validatorIndex phase0.ValidatorIndex
+		var clusterID phase0.ValidatorIndex
+		if err := rows.Scan(&validatorIndex, &clusterID); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		res[validatorIndex] = clusterID
+	}
+
+	return res, rows.Err()
+}
+
+// ValidatorClusters provides validator clusters according to the filter.
+func (s *Service) ValidatorClusters(ctx context.Context, filter *chaindb.ValidatorClusterFilter) ([]*chaindb.ValidatorCluster, error) {
+	ctx, span := startSpan(ctx, "ValidatorClusters")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_cluster_id
+      ,ARRAY_AGG(f_validator_index ORDER BY f_validator_index)
+FROM t_validator_clusters`)
+
+	if len(filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+WHERE f_cluster_id IN (SELECT f_cluster_id FROM t_validator_clusters WHERE f_validator_index = ANY($%d))`, len(queryVals)))
+	}
+
+	queryBuilder.WriteString(`
+GROUP BY f_cluster_id`)
+
+	if filter.MinSize > 0 {
+		queryVals = append(queryVals, filter.MinSize)
+		queryBuilder.WriteString(fmt.Sprintf(`
+HAVING COUNT(*) >= $%d`, len(queryVals)))
+	}
+
+	queryBuilder.WriteString(`
+ORDER BY COUNT(*) DESC, f_cluster_id`)
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clusters := make([]*chaindb.ValidatorCluster, 0)
+	var members []uint64
+	for rows.Next() {
+		cluster := &chaindb.ValidatorCluster{}
+		if err := rows.Scan(&cluster.ID, &members); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		cluster.Validators = make([]phase0.ValidatorIndex, len(members))
+		for i := range members {
+			cluster.Validators[i] = phase0.ValidatorIndex(members[i])
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, rows.Err()
+}
+
+// ValidatorClusterLinks provides the items that link the given validators to their clusters.
+func (s *Service) ValidatorClusterLinks(ctx context.Context,
+	validatorIndices []phase0.ValidatorIndex,
+) (
+	[]*chaindb.ValidatorClusterLink,
+	error,
+) {
+	ctx, span := startSpan(ctx, "ValidatorClusterLinks")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_validator_index
+      ,f_type
+      ,f_key
+      ,f_epoch
+FROM t_validator_cluster_links
+WHERE f_validator_index = ANY($1)
+ORDER BY f_validator_index,f_type,f_key`,
+		validatorIndices,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanValidatorClusterLinks(rows)
+}
+
+// ValidatorClusterSizes provides the distribution of cluster sizes, in increasing order of size.
+func (s *Service) ValidatorClusterSizes(ctx context.Context) ([]*chaindb.ValidatorClusterSize, error) {
+	ctx, span := startSpan(ctx, "ValidatorClusterSizes")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_size
+      ,COUNT(*)
+FROM (
+  SELECT COUNT(*) AS f_size
+  FROM t_validator_clusters
+  GROUP BY f_cluster_id
+) c
+GROUP BY f_size
+ORDER BY f_size`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sizes := make([]*chaindb.ValidatorClusterSize, 0)
+	for rows.Next() {
+		size := &chaindb.ValidatorClusterSize{}
+		if err := rows.Scan(&size.Size, &size.Clusters); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		sizes = append(sizes, size)
+	}
+
+	return sizes, rows.Err()
+}
+
+// scanValidatorClusterLinks scans validator cluster links from rows.
+func scanValidatorClusterLinks(rows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+},
+) (
+	[]*chaindb.ValidatorClusterLink,
+	error,
+) {
+	links := make([]*chaindb.ValidatorClusterLink, 0)
+	for rows.Next() {
+		link := &chaindb.ValidatorClusterLink{}
+		if err := rows.Scan(
+			&link.ValidatorIndex,
+			&link.Type,
+			&link.Key,
+			&link.Epoch,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// dedupeValidatorClusterLinks removes duplicate links, keeping the earliest epoch.
+func dedupeValidatorClusterLinks(links []*chaindb.ValidatorClusterLink) []*chaindb.ValidatorClusterLink {
+	type linkID struct {
+		validatorIndex phase0.ValidatorIndex
+		linkType       string
+		key            string
+	}
+
+	seen := make(map[linkID]*chaindb.ValidatorClusterLink, len(links))
+	res := make([]*chaindb.ValidatorClusterLink, 0, len(links))
+	for _, link := range links {
+		id := linkID{validatorIndex: link.ValidatorIndex, linkType: link.Type, key: string(link.Key)}
+		if existing, exists := seen[id]; exists {
+			if link.Epoch < existing.Epoch {
+				existing.Epoch = link.Epoch
+			}
+			continue
+		}
+		deduped := *link
+		seen[id] = &deduped
+		res = append(res, &deduped)
+	}
+
+	return res
+}
+
+// validatorClusterAssignments merges the existing clusters of validators with
+// the validators linked by shared items, returning the cluster of each
+// validator whose cluster is new or has changed.  The ID of each cluster is
+// its lowest validator index.
+func validatorClusterAssignments(links []*chaindb.ValidatorClusterLink,
+	existing map[phase0.ValidatorIndex]phase0.ValidatorIndex,
+) map[phase0.ValidatorIndex]phase0.ValidatorIndex {
+	parents := make(map[phase0.ValidatorIndex]phase0.ValidatorIndex)
+	var find func(phase0.ValidatorIndex) phase0.ValidatorIndex
+	find = func(index phase0.ValidatorIndex) phase0.ValidatorIndex {
+		parent, exists := parents[index]
+		if !exists {
+			parents[index] = index
+			return index
+		}
+		if parent == index {
+			return index
+		}
+		root := find(parent)
+		parents[index] = root
+		return root
+	}
+	union := func(a phase0.ValidatorIndex, b phase0.ValidatorIndex) {
+		rootA := find(a)
+		rootB := find(b)
+		switch {
+		case rootA < rootB:
+			parents[rootB] = rootA
+		case rootB < rootA:
+			parents[rootA] = rootB
+		}
+	}
+
+	for validatorIndex, clusterID := range existing {
+		union(validatorIndex, clusterID)
+	}
+
+	holders := make(map[string]phase0.ValidatorIndex)
+	// Sort to provide a deterministic order in which to union.
+	sorted := make([]*chaindb.ValidatorClusterLink, len(links))
+	copy(sorted, links)
+	sort.Slice(sorted, func(i int, j int) bool {
+		return sorted[i].ValidatorIndex < sorted[j].ValidatorIndex
+	})
+	for _, link := range sorted {
+		item := fmt.Sprintf("%s:%x", link.Type, link.Key)
+		if holder, exists := holders[item]; exists {
+			union(holder, link.ValidatorIndex)
+		} else {
+			holders[item] = link.ValidatorIndex
+			find(link.ValidatorIndex)
+		}
+	}
+
+	res := make(map[phase0.ValidatorIndex]phase0.ValidatorIndex)
+	for validatorIndex := range parents {
+		root := find(validatorIndex)
+		if clusterID, exists := existing[validatorIndex]; !exists || clusterID != root {
+			res[validatorIndex] = root
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,113 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestValidatorClusterAssignments(t *testing.T) {
+	address1 := []byte{0x01}
+	address2 := []byte{0x02}
+
+	tests := []struct {
+		name     string
+		links    []*chaindb.ValidatorClusterLink
+		existing map[phase0.ValidatorIndex]phase0.ValidatorIndex
+		expected map[phase0.ValidatorIndex]phase0.ValidatorIndex
+	}{
+		{
+			name:     "Empty",
+			expected: map[phase0.ValidatorIndex]phase0.ValidatorIndex{},
+		},
+		{
+			name: "Single",
+			links: []*chaindb.ValidatorClusterLink{
+				{ValidatorIndex: 5, Type: chaindb.ValidatorClusterLinkWithdrawalAddress, Key: address1},
+			},
+			expected: map[phase0.ValidatorIndex]phase0.ValidatorIndex{5: 5},
+		},
+		{
+			name: "SharedAddress",
+			links: []*chaindb.ValidatorClusterLink{
+				{ValidatorIndex: 7, Type: chaindb.ValidatorClusterLinkWithdrawalAddress, Key: address1},
+				{ValidatorIndex: 3, Type: chaindb.ValidatorClusterLinkWithdrawalAddress, Key: address1},
+				{ValidatorIndex: 9, Type: chaindb.ValidatorClusterLinkWithdrawalAddress, Key: address2},
+			},
+			expected: map[phase0.ValidatorIndex]phase0.ValidatorIndex{3: 3, 7: 3, 9: 9},
+		},
+		{
+			name: "TypesDistinct",
+			links: []*chaindb.ValidatorClusterLink{
+				{ValidatorIndex: 1, Type: chaindb.ValidatorClusterLinkWithdrawalAddress, Key: address1},
+				{ValidatorIndex: 2, Type: chaindb.ValidatorClusterLinkFeeRecipient, Key: address1},
+			},
+			expected: map[phase0.ValidatorIndex]phase0.ValidatorIndex{1: 1, 2: 2},
+		},
+		{
+			name: "Unchanged",
+			links: []*chaindb.ValidatorClusterLink{
+				{ValidatorIndex: 1, Type: chaindb.ValidatorClusterLinkWithdrawalAddress, Key: address1},
+				{ValidatorIndex: 2, Type: chaindb.ValidatorClusterLinkWithdrawalAddress, Key: address1},
+			},
+			existing: map[phase0.ValidatorIndex]phase0.ValidatorIndex{1: 1, 2: 1},
+			expected: map[phase0.ValidatorIndex]phase0.ValidatorIndex{},
+		},
+		{
+			name: "JoinsExisting",
+			links: []*chaindb.ValidatorClusterLink{
+				{ValidatorIndex: 4, Type: chaindb.ValidatorClusterLinkFeeRecipient, Key: address2},
+				{ValidatorIndex: 8, Type: chaindb.ValidatorClusterLinkFeeRecipient, Key: address2},
+			},
+			existing: map[phase0.ValidatorIndex]phase0.ValidatorIndex{4: 2, 2: 2, 6: 2},
+			expected: map[phase0.ValidatorIndex]phase0.ValidatorIndex{8: 2},
+		},
+		{
+			name: "MergesClusters",
+			links: []*chaindb.ValidatorClusterLink{
+				{ValidatorIndex: 10, Type: chaindb.ValidatorClusterLinkFeeRecipient, Key: address1},
+				{ValidatorIndex: 20, Type: chaindb.ValidatorClusterLinkFeeRecipient, Key: address1},
+			},
+			existing: map[phase0.ValidatorIndex]phase0.ValidatorIndex{10: 10, 11: 10, 20: 5, 5: 5},
+			expected: map[phase0.ValidatorIndex]phase0.ValidatorIndex{10: 5, 11: 5},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, validatorClusterAssignments(test.links, test.existing))
+		})
+	}
+}
+
+func TestDedupeValidatorClusterLinks(t *testing.T) {
+	links := []*chaindb.ValidatorClusterLink{
+		{ValidatorIndex: 1, Type: chaindb.ValidatorClusterLinkFeeRecipient, Key: []byte{0x01}, Epoch: 10},
+		{ValidatorIndex: 1, Type: chaindb.ValidatorClusterLinkFeeRecipient, Key: []byte{0x01}, Epoch: 5},
+		{ValidatorIndex: 1, Type: chaindb.ValidatorClusterLinkWithdrawalAddress, Key: []byte{0x01}, Epoch: 7},
+		{ValidatorIndex: 2, Type: chaindb.ValidatorClusterLinkFeeRecipient, Key: []byte{0x01}, Epoch: 8},
+	}
+
+	deduped := dedupeValidatorClusterLinks(links)
+	require.Len(t, deduped, 3)
+	require.Equal(t, phase0.Epoch(5), deduped[0].Epoch)
+	require.Equal(t, phase0.Epoch(7), deduped[1].Epoch)
+	require.Equal(t, phase0.Epoch(8), deduped[2].Epoch)
+	// Input is not altered.
+	require.Equal(t, phase0.Epoch(10), links[0].Epoch)
+}
@@ -0,0 +1,167 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetValidatorConsolidation sets a validator consolidation.
+func (s *Service) SetValidatorConsolidation(ctx context.Context, consolidation *chaindb.ValidatorConsolidation) error {
+	ctx, span := startSpan(ctx, "SetValidatorConsolidation")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_validator_consolidations(f_source_index
+                                      ,f_target_index
+                                      ,f_epoch
+                                      ,f_amount
+                                      )
+VALUES($1,$2,$3,$4)
+ON CONFLICT (f_source_index,f_target_index,f_epoch) DO
+UPDATE
+SET f_amount = excluded.f_amount
+`,
+		consolidation.SourceIndex,
+		consolidation.TargetIndex,
+		consolidation.Epoch,
+		consolidation.Amount,
+	)
+
+	return err
+}
+
+// ValidatorConsolidations provides consolidations according to the filter.
+func (s *Service) ValidatorConsolidations(ctx context.Context,
+	filter *chaindb.ValidatorConsolidationFilter,
+) (
+	[]*chaindb.ValidatorConsolidation,
+	error,
+) {
+	ctx, span := startSpan(ctx, "ValidatorConsolidations")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_source_index
+      ,f_target_index
+      ,f_epoch
+      ,f_amount
+FROM t_validator_consolidations`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s (f_source_index = ANY($%d) OR f_target_index = ANY($%d))`, wherestr, len(queryVals), len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch, f_source_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch DESC, f_source_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	consolidations := make([]*chaindb.ValidatorConsolidation, 0)
+	for rows.Next() {
+		consolidation := &chaindb.ValidatorConsolidation{}
+		err := rows.Scan(
+			&consolidation.SourceIndex,
+			&consolidation.TargetIndex,
+			&consolidation.Epoch,
+			&consolidation.Amount,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		consolidations = append(consolidations, consolidation)
+	}
+
+	// Always return order of epoch then source index.
+	sort.Slice(consolidations, func(i int, j int) bool {
+		if consolidations[i].Epoch != consolidations[j].Epoch {
+			return consolidations[i].Epoch < consolidations[j].Epoch
+		}
+		return consolidations[i].SourceIndex < consolidations[j].SourceIndex
+	})
+
+	return consolidations, nil
+}
@@ -0,0 +1,83 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestValidatorConsolidations(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(ctx,
+		WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	consolidations := []*chaindb.ValidatorConsolidation{
+		{SourceIndex: 1, TargetIndex: 2, Epoch: 10, Amount: 31000000000},
+		{SourceIndex: 3, TargetIndex: 2, Epoch: 11, Amount: 31000000000},
+		{SourceIndex: 4, TargetIndex: 5, Epoch: 12, Amount: 32000000000},
+	}
+	for _, consolidation := range consolidations {
+		require.NoError(t, s.SetValidatorConsolidation(ctx, consolidation))
+	}
+
+	// Setting a consolidation again updates its amount.
+	consolidations[0].Amount = 32000000000
+	require.NoError(t, s.SetValidatorConsolidation(ctx, consolidations[0]))
+
+	// Source or target can match the validator indices.
+	res, err := s.ValidatorConsolidations(ctx, &chaindb.ValidatorConsolidationFilter{
+		ValidatorIndices: []phase0.ValidatorIndex{2, 4},
+	})
+	require.NoError(t, err)
+	require.Equal(t, consolidations, res)
+
+	from := phase0.Epoch(11)
+	res, err = s.ValidatorConsolidations(ctx, &chaindb.ValidatorConsolidationFilter{
+		From:             &from,
+		ValidatorIndices: []phase0.ValidatorIndex{1, 2},
+	})
+	require.NoError(t, err)
+	require.Equal(t, consolidations[1:2], res)
+
+	res, err = s.ValidatorConsolidations(ctx, &chaindb.ValidatorConsolidationFilter{
+		Order:            chaindb.OrderLatest,
+		Limit:            1,
+		ValidatorIndices: []phase0.ValidatorIndex{1, 2, 3, 4, 5},
+	})
+	require.NoError(t, err)
+	require.Equal(t, consolidations[2:], res)
+}
+
+func TestSetValidatorConsolidationNoTransaction(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(ctx,
+		WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	err = s.SetValidatorConsolidation(ctx, &chaindb.ValidatorConsolidation{SourceIndex: 1, TargetIndex: 2, Epoch: 10})
+	require.ErrorIs(t, err, ErrNoTransaction)
+}
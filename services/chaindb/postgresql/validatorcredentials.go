@@ -0,0 +1,211 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetValidatorCredentialsChange sets a validator credentials change.
+func (s *Service) SetValidatorCredentialsChange(ctx context.Context, change *chaindb.ValidatorCredentialsChange) error {
+	ctx, span := startSpan(ctx, "SetValidatorCredentialsChange")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_validator_credentials_changes(f_validator_index
+                                           ,f_epoch
+                                           ,f_withdrawal_credentials
+                                           ,f_effective_balance_ceiling
+                                           )
+VALUES($1,$2,$3,$4)
+ON CONFLICT (f_validator_index,f_epoch) DO
+UPDATE
+SET f_withdrawal_credentials = excluded.f_withdrawal_credentials
+   ,f_effective_balance_ceiling = excluded.f_effective_balance_ceiling
+`,
+		change.Index,
+		change.Epoch,
+		change.WithdrawalCredentials[:],
+		change.EffectiveBalanceCeiling,
+	)
+
+	return err
+}
+
+// ValidatorCredentialsChanges provides credentials changes according to the filter.
+func (s *Service) ValidatorCredentialsChanges(ctx context.Context,
+	filter *chaindb.ValidatorCredentialsChangeFilter,
+) (
+	[]*chaindb.ValidatorCredentialsChange,
+	error,
+) {
+	ctx, span := startSpan(ctx, "ValidatorCredentialsChanges")
+	defer span.End()
+
+	return s.validatorCredentialsChanges(ctx, filter, false)
+}
+
+// ValidatorEffectiveBalanceCeilingChanges provides the credentials changes according to the filter that
+// altered the effective balance ceiling of their validator.  The first credentials for a validator are
+// always considered to be a change.
+func (s *Service) ValidatorEffectiveBalanceCeilingChanges(ctx context.Context,
+	filter *chaindb.ValidatorCredentialsChangeFilter,
+) (
+	[]*chaindb.ValidatorCredentialsChange,
+	error,
+) {
+	ctx, span := startSpan(ctx, "ValidatorEffectiveBalanceCeilingChanges")
+	defer span.End()
+
+	return s.validatorCredentialsChanges(ctx, filter, true)
+}
+
+func (s *Service) validatorCredentialsChanges(ctx context.Context,
+	filter *chaindb.ValidatorCredentialsChangeFilter,
+	ceilingChangesOnly bool,
+) (
+	[]*chaindb.ValidatorCredentialsChange,
+	error,
+) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	if ceilingChangesOnly {
+		// The previous ceiling must be calculated across all of a validator's changes, so
+		// filters are applied after the window function.
+		queryBuilder.WriteString(`
+SELECT f_validator_index
+      ,f_epoch
+      ,f_withdrawal_credentials
+      ,f_effective_balance_ceiling
+FROM (
+  SELECT f_validator_index
+        ,f_epoch
+        ,f_withdrawal_credentials
+        ,f_effective_balance_ceiling
+        ,LAG(f_effective_balance_ceiling) OVER (PARTITION BY f_validator_index ORDER BY f_epoch) AS f_previous_ceiling
+  FROM t_validator_credentials_changes
+) c
+WHERE (f_previous_ceiling IS NULL OR f_previous_ceiling <> f_effective_balance_ceiling)`)
+	} else {
+		queryBuilder.WriteString(`
+SELECT f_validator_index
+      ,f_epoch
+      ,f_withdrawal_credentials
+      ,f_effective_balance_ceiling
+FROM t_validator_credentials_changes
+WHERE TRUE`)
+	}
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+  AND f_epoch >= $%d`, len(queryVals)))
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+  AND f_epoch <= $%d`, len(queryVals)))
+	}
+
+	if len(filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+  AND f_validator_index = ANY($%d)`, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch, f_validator_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch DESC, f_validator_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]*chaindb.ValidatorCredentialsChange, 0)
+	var withdrawalCredentials []byte
+	for rows.Next() {
+		change := &chaindb.ValidatorCredentialsChange{}
+		err := rows.Scan(
+			&change.Index,
+			&change.Epoch,
+			&withdrawalCredentials,
+			&change.EffectiveBalanceCeiling,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(change.WithdrawalCredentials[:], withdrawalCredentials)
+		changes = append(changes, change)
+	}
+
+	// Always return order of epoch then validator index.
+	sort.Slice(changes, func(i int, j int) bool {
+		if changes[i].Epoch != changes[j].Epoch {
+			return changes[i].Epoch < changes[j].Epoch
+		}
+		return changes[i].Index < changes[j].Index
+	})
+
+	return changes, nil
+}
@@ -0,0 +1,102 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestValidatorCredentialsChanges(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	bls := &chaindb.ValidatorCredentialsChange{
+		Index:                   1,
+		Epoch:                   10,
+		WithdrawalCredentials:   [32]byte{chaindb.WithdrawalCredentialsBLS, 0x01},
+		EffectiveBalanceCeiling: chaindb.DefaultMaxEffectiveBalance,
+	}
+	execution := &chaindb.ValidatorCredentialsChange{
+		Index:                   1,
+		Epoch:                   20,
+		WithdrawalCredentials:   [32]byte{chaindb.WithdrawalCredentialsExecution, 0x02},
+		EffectiveBalanceCeiling: chaindb.DefaultMaxEffectiveBalance,
+	}
+	compounding := &chaindb.ValidatorCredentialsChange{
+		Index:                   1,
+		Epoch:                   30,
+		WithdrawalCredentials:   [32]byte{chaindb.WithdrawalCredentialsCompounding, 0x02},
+		EffectiveBalanceCeiling: chaindb.DefaultMaxCompoundingEffectiveBalance,
+	}
+	other := &chaindb.ValidatorCredentialsChange{
+		Index:                   2,
+		Epoch:                   15,
+		WithdrawalCredentials:   [32]byte{chaindb.WithdrawalCredentialsExecution, 0x03},
+		EffectiveBalanceCeiling: chaindb.DefaultMaxEffectiveBalance,
+	}
+
+	// Attempt to set the change without a transaction; should fail.
+	require.ErrorIs(t, s.SetValidatorCredentialsChange(ctx, bls), postgresql.ErrNoTransaction)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	for _, change := range []*chaindb.ValidatorCredentialsChange{bls, execution, compounding, other} {
+		require.NoError(t, s.SetValidatorCredentialsChange(ctx, change))
+	}
+	// Setting the same change again should update rather than duplicate.
+	require.NoError(t, s.SetValidatorCredentialsChange(ctx, bls))
+
+	res, err := s.ValidatorCredentialsChanges(ctx, &chaindb.ValidatorCredentialsChangeFilter{
+		ValidatorIndices: []phase0.ValidatorIndex{1, 2},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.ValidatorCredentialsChange{bls, other, execution, compounding}, res)
+
+	from := phase0.Epoch(15)
+	to := phase0.Epoch(25)
+	res, err = s.ValidatorCredentialsChanges(ctx, &chaindb.ValidatorCredentialsChangeFilter{
+		From:             &from,
+		To:               &to,
+		ValidatorIndices: []phase0.ValidatorIndex{1},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.ValidatorCredentialsChange{execution}, res)
+
+	res, err = s.ValidatorCredentialsChanges(ctx, &chaindb.ValidatorCredentialsChangeFilter{
+		Order:            chaindb.OrderLatest,
+		Limit:            1,
+		ValidatorIndices: []phase0.ValidatorIndex{1},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.ValidatorCredentialsChange{compounding}, res)
+
+	// The move from BLS to execution credentials does not change the ceiling, so is not returned.
+	res, err = s.ValidatorEffectiveBalanceCeilingChanges(ctx, &chaindb.ValidatorCredentialsChangeFilter{
+		ValidatorIndices: []phase0.ValidatorIndex{1},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.ValidatorCredentialsChange{bls, compounding}, res)
+}
@@ -0,0 +1,189 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetValidatorDayRankings sets multiple validator day rankings.
+// Rankings are calculated across all validators for a day, so any existing
+// rankings for the days covered are replaced.
+func (s *Service) SetValidatorDayRankings(ctx context.Context, rankings []*chaindb.ValidatorDayRanking) error {
+	ctx, span := startSpan(ctx, "SetValidatorDayRankings")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	startTimestamps := make([]time.Time, 0)
+	seen := make(map[time.Time]bool)
+	for _, ranking := range rankings {
+		if !seen[ranking.StartTimestamp] {
+			seen[ranking.StartTimestamp] = true
+			startTimestamps = append(startTimestamps, ranking.StartTimestamp)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+DELETE FROM t_validator_day_rankings
+WHERE f_start_timestamp = ANY($1)
+`,
+		startTimestamps,
+	); err != nil {
+		return errors.Wrap(err, "failed to remove existing validator day rankings")
+	}
+
+	if _, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"t_validator_day_rankings"},
+		[]string{
+			"f_validator_index",
+			"f_start_timestamp",
+			"f_effectiveness",
+			"f_percentile",
+			"f_z_score",
+		},
+		pgx.CopyFromSlice(len(rankings), func(i int) ([]any, error) {
+			return []any{
+				rankings[i].Index,
+				rankings[i].StartTimestamp,
+				rankings[i].Effectiveness,
+				rankings[i].Percentile,
+				rankings[i].ZScore,
+			}, nil
+		})); err != nil {
+		return errors.Wrap(err, "failed to copy validator day rankings")
+	}
+
+	return nil
+}
+
+// ValidatorDayRankings provides validator day rankings according to the filter.
+func (s *Service) ValidatorDayRankings(ctx context.Context, filter *chaindb.ValidatorDayRankingFilter) ([]*chaindb.ValidatorDayRanking, error) {
+	ctx, span := startSpan(ctx, "ValidatorDayRankings")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_validator_index
+      ,f_start_timestamp
+      ,f_effectiveness
+      ,f_percentile
+      ,f_z_score
+FROM t_validator_day_rankings`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_start_timestamp >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_start_timestamp <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.ValidatorIndices != nil && len(*filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, *filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_validator_index = ANY($%d)`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_start_timestamp, f_validator_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_start_timestamp DESC,f_validator_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rankings := make([]*chaindb.ValidatorDayRanking, 0)
+	for rows.Next() {
+		ranking := &chaindb.ValidatorDayRanking{}
+		err := rows.Scan(
+			&ranking.Index,
+			&ranking.StartTimestamp,
+			&ranking.Effectiveness,
+			&ranking.Percentile,
+			&ranking.ZScore,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		rankings = append(rankings, ranking)
+	}
+
+	// Always return order of start timestamp then validator index.
+	sort.Slice(rankings, func(i int, j int) bool {
+		if !rankings[i].StartTimestamp.Equal(rankings[j].StartTimestamp) {
+			return rankings[i].StartTimestamp.Before(rankings[j].StartTimestamp)
+		}
+		return rankings[i].Index < rankings[j].Index
+	})
+	return rankings, nil
+}
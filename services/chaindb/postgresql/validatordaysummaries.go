@@ -22,12 +22,11 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // SetValidatorDaySummaries sets multiple validator day summaries.
 func (s *Service) SetValidatorDaySummaries(ctx context.Context, summaries []*chaindb.ValidatorDaySummary) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetValidatorDaySummaries")
+	ctx, span := startSpan(ctx, "SetValidatorDaySummaries")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -116,7 +115,7 @@ func (s *Service) SetValidatorDaySummaries(ctx context.Context, summaries []*cha
 
 // SetValidatorDaySummary sets a validator day summary.
 func (s *Service) SetValidatorDaySummary(ctx context.Context, summary *chaindb.ValidatorDaySummary) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetValidatorDaySummary")
+	ctx, span := startSpan(ctx, "SetValidatorDaySummary")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -194,7 +193,7 @@ SET f_start_balance = excluded.f_start_balance
 
 // ValidatorDaySummaries provides validator day summaries according to the filter.
 func (s *Service) ValidatorDaySummaries(ctx context.Context, filter *chaindb.ValidatorDaySummaryFilter) ([]*chaindb.ValidatorDaySummary, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ValidatorDaySummaries")
+	ctx, span := startSpan(ctx, "ValidatorDaySummaries")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -24,12 +24,11 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // SetValidatorEpochSummaries sets multiple validator epoch summaries.
 func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*chaindb.ValidatorEpochSummary) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetValidatorEpochSummaries")
+	ctx, span := startSpan(ctx, "SetValidatorEpochSummaries")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -100,7 +99,7 @@ func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*c
 
 // SetValidatorEpochSummary sets a validator epoch summary.
 func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb.ValidatorEpochSummary) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetValidatorEpochSummary")
+	ctx, span := startSpan(ctx, "SetValidatorEpochSummary")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -140,7 +139,7 @@ func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb
 		attestationHeadTimely.Bool = *summary.AttestationHeadTimely
 	}
 
-	_, err := tx.Exec(ctx, `
+	return s.queueExec(ctx, tx, `
       INSERT INTO t_validator_epoch_summaries(f_validator_index
                               ,f_epoch
                               ,f_proposer_duties
@@ -177,13 +176,11 @@ func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb
 		attestationTargetTimely,
 		attestationHeadTimely,
 	)
-
-	return err
 }
 
 // ValidatorSummaries provides summaries according to the filter.
 func (s *Service) ValidatorSummaries(ctx context.Context, filter *chaindb.ValidatorSummaryFilter) ([]*chaindb.ValidatorEpochSummary, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ValidatorSummaries")
+	ctx, span := startSpan(ctx, "ValidatorSummaries")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -333,7 +330,7 @@ LIMIT $%d`, len(queryVals)))
 
 // ValidatorSummariesForEpoch obtains all summaries for a given epoch.
 func (s *Service) ValidatorSummariesForEpoch(ctx context.Context, epoch phase0.Epoch) ([]*chaindb.ValidatorEpochSummary, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ValidatorSummariesForEpoch")
+	ctx, span := startSpan(ctx, "ValidatorSummariesForEpoch")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -433,7 +430,7 @@ func (s *Service) ValidatorSummaryForEpoch(ctx context.Context,
 	*chaindb.ValidatorEpochSummary,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ValidatorSummaryForEpoch")
+	ctx, span := startSpan(ctx, "ValidatorSummaryForEpoch")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -519,7 +516,7 @@ WHERE f_validator_index = $1
 
 // PruneValidatorEpochSummaries prunes validator epoch summaries up to (but not including) the given point.
 func (s *Service) PruneValidatorEpochSummaries(ctx context.Context, to phase0.Epoch, retain []phase0.ValidatorIndex) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "PruneValidatorEpochSummaries")
+	ctx, span := startSpan(ctx, "PruneValidatorEpochSummaries")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -539,7 +536,7 @@ WHERE f_epoch <= $1
 
 	if len(retain) > 0 {
 		queryBuilder.WriteString(`
-AND  f_validator_index NOT IN($2)
+AND  NOT (f_validator_index = ANY($2))
 `)
 		queryVals = append(queryVals, retain)
 	}
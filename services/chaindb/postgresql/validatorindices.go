@@ -0,0 +1,146 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// ValidatorIndices fetches the indices of the validators with the given public keys.
+// Public keys of unknown validators are omitted from the result.
+func (s *Service) ValidatorIndices(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey]phase0.ValidatorIndex, error) {
+	ctx, span := startSpan(ctx, "ValidatorIndices")
+	defer span.End()
+
+	res := make(map[phase0.BLSPubKey]phase0.ValidatorIndex, len(pubKeys))
+	if len(pubKeys) == 0 {
+		return res, nil
+	}
+
+	// Results are only cached if they come from committed data, as a transaction
+	// that is in progress could yet be rolled back.
+	cacheable := s.tx(ctx) == nil
+
+	missing := pubKeys
+	if s.validatorIndexCache != nil {
+		var err error
+		missing, err = s.cachedValidatorIndices(ctx, pubKeys, res)
+		if err != nil {
+			// Not fatal, as we can fall back to the database.
+			log.Debug().Err(err).Msg("Failed to obtain validator indices from cache")
+			missing = pubKeys
+		}
+		if len(missing) == 0 {
+			return res, nil
+		}
+	}
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	sqlPubKeys := make([][]byte, len(missing))
+	for i := range missing {
+		sqlPubKeys[i] = missing[i][:]
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_public_key
+      ,f_index
+FROM t_validators
+WHERE f_public_key = ANY($1)
+`,
+		sqlPubKeys,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string][]byte)
+	for rows.Next() {
+		var pubKeyBytes []byte
+		var index uint64
+		if err := rows.Scan(&pubKeyBytes, &index); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		var pubKey phase0.BLSPubKey
+		copy(pubKey[:], pubKeyBytes)
+		res[pubKey] = phase0.ValidatorIndex(index)
+		found[validatorIndexCacheKey(pubKey)] = []byte(strconv.FormatUint(index, 10))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read rows")
+	}
+
+	if s.validatorIndexCache != nil && cacheable && len(found) > 0 {
+		// The index of a validator never changes, so cached entries do not expire.
+		if err := s.validatorIndexCache.Set(ctx, found, 0); err != nil {
+			log.Debug().Err(err).Msg("Failed to cache validator indices")
+		}
+	}
+
+	return res, nil
+}
+
+// cachedValidatorIndices adds the indices of validators present in the cache to the result,
+// returning the public keys that were not present.
+func (s *Service) cachedValidatorIndices(ctx context.Context,
+	pubKeys []phase0.BLSPubKey,
+	res map[phase0.BLSPubKey]phase0.ValidatorIndex,
+) (
+	[]phase0.BLSPubKey,
+	error,
+) {
+	keys := make([]string, len(pubKeys))
+	for i := range pubKeys {
+		keys[i] = validatorIndexCacheKey(pubKeys[i])
+	}
+	cached, err := s.validatorIndexCache.Get(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make([]phase0.BLSPubKey, 0)
+	for i, pubKey := range pubKeys {
+		value, exists := cached[keys[i]]
+		if !exists {
+			missing = append(missing, pubKey)
+			continue
+		}
+		index, err := strconv.ParseUint(string(value), 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cached index for %s", keys[i])
+		}
+		res[pubKey] = phase0.ValidatorIndex(index)
+	}
+
+	return missing, nil
+}
+
+// validatorIndexCacheKey is the cache key for the index of the validator with the given public key.
+func validatorIndexCacheKey(pubKey phase0.BLSPubKey) string {
+	return fmt.Sprintf("validator_index:%#x", pubKey)
+}
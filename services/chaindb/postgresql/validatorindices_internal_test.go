@@ -0,0 +1,50 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	memorycache "github.com/wealdtech/chaind/services/cache/memory"
+)
+
+func TestCachedValidatorIndices(t *testing.T) {
+	ctx := context.Background()
+
+	cache, err := memorycache.New(ctx)
+	require.NoError(t, err)
+	s := &Service{
+		validatorIndexCache: cache,
+	}
+
+	pubKey1 := phase0.BLSPubKey{0x01}
+	pubKey2 := phase0.BLSPubKey{0x02}
+	require.NoError(t, cache.Set(ctx, map[string][]byte{
+		validatorIndexCacheKey(pubKey1): []byte("12345"),
+	}, 0))
+
+	res := make(map[phase0.BLSPubKey]phase0.ValidatorIndex)
+	missing, err := s.cachedValidatorIndices(ctx, []phase0.BLSPubKey{pubKey1, pubKey2}, res)
+	require.NoError(t, err)
+	require.Equal(t, []phase0.BLSPubKey{pubKey2}, missing)
+	require.Equal(t, map[phase0.BLSPubKey]phase0.ValidatorIndex{pubKey1: 12345}, res)
+
+	// Fully cached lookups do not touch the database.
+	res, err = s.ValidatorIndices(ctx, []phase0.BLSPubKey{pubKey1})
+	require.NoError(t, err)
+	require.Equal(t, map[phase0.BLSPubKey]phase0.ValidatorIndex{pubKey1: 12345}, res)
+}
@@ -16,6 +16,7 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -24,14 +25,13 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 var farFutureEpoch = phase0.Epoch(0xffffffffffffffff)
 
 // SetValidator sets a validator.
 func (s *Service) SetValidator(ctx context.Context, validator *chaindb.Validator) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetValidator")
+	ctx, span := startSpan(ctx, "SetValidator")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -61,7 +61,9 @@ func (s *Service) SetValidator(ctx context.Context, validator *chaindb.Validator
 		withdrawableEpoch.Int64 = (int64)(validator.WithdrawableEpoch)
 	}
 
-	_, err := tx.Exec(ctx, `
+	// The index to public key mapping of a validator never changes, so a
+	// validator that conflicts with an existing mapping is not written.
+	tag, err := tx.Exec(ctx, `
       INSERT INTO t_validators(f_public_key
                               ,f_index
                               ,f_slashed
@@ -71,17 +73,18 @@ func (s *Service) SetValidator(ctx context.Context, validator *chaindb.Validator
                               ,f_withdrawable_epoch
                               ,f_effective_balance
                               ,f_withdrawal_credentials)
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9)
+      SELECT $1::BYTEA,$2::BIGINT,$3::BOOLEAN,$4::BIGINT,$5::BIGINT,$6::BIGINT,$7::BIGINT,$8::BIGINT,$9::BYTEA
+      WHERE NOT EXISTS (SELECT 1 FROM t_validators WHERE f_public_key = $1 AND f_index <> $2)
       ON CONFLICT (f_index) DO
       UPDATE
-      SET f_public_key = excluded.f_public_key
-         ,f_slashed = excluded.f_slashed
+      SET f_slashed = excluded.f_slashed
          ,f_activation_eligibility_epoch = excluded.f_activation_eligibility_epoch
          ,f_activation_epoch = excluded.f_activation_epoch
          ,f_exit_epoch = excluded.f_exit_epoch
          ,f_withdrawable_epoch = excluded.f_withdrawable_epoch
          ,f_effective_balance = excluded.f_effective_balance
          ,f_withdrawal_credentials = excluded.f_withdrawal_credentials
+      WHERE t_validators.f_public_key = excluded.f_public_key
 		 `,
 		validator.PublicKey[:],
 		validator.Index,
@@ -93,13 +96,20 @@ func (s *Service) SetValidator(ctx context.Context, validator *chaindb.Validator
 		validator.EffectiveBalance,
 		validator.WithdrawalCredentials[:],
 	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return s.recordValidatorMappingAnomalies(ctx, tx, validator)
+	}
+	s.invalidateReadCache(ctx, validatorCacheKey(validator.Index))
 
-	return err
+	return s.runSQLHooks(ctx, tx, "validator", validatorHookValues(validator))
 }
 
 // SetValidatorBalance sets a validator's balance.
 func (s *Service) SetValidatorBalance(ctx context.Context, balance *chaindb.ValidatorBalance) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetValidatorBalance")
+	ctx, span := startSpan(ctx, "SetValidatorBalance")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -107,7 +117,7 @@ func (s *Service) SetValidatorBalance(ctx context.Context, balance *chaindb.Vali
 		return ErrNoTransaction
 	}
 
-	_, err := tx.Exec(ctx, `
+	if err := s.queueExec(ctx, tx, `
       INSERT INTO t_validator_balances(f_validator_index
                                       ,f_epoch
                                       ,f_balance
@@ -122,14 +132,16 @@ func (s *Service) SetValidatorBalance(ctx context.Context, balance *chaindb.Vali
 		balance.Epoch,
 		balance.Balance,
 		balance.EffectiveBalance,
-	)
+	); err != nil {
+		return err
+	}
 
-	return err
+	return s.runSQLHooks(ctx, tx, "validator_balance", validatorBalanceHookValues(balance))
 }
 
 // SetValidatorBalances sets multiple validator balances.
 func (s *Service) SetValidatorBalances(ctx context.Context, balances []*chaindb.ValidatorBalance) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetValidatorBalances")
+	ctx, span := startSpan(ctx, "SetValidatorBalances")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -153,12 +165,26 @@ func (s *Service) SetValidatorBalances(ctx context.Context, balances []*chaindb.
 				balances[i].EffectiveBalance,
 			}, nil
 		}))
-	return err
+	if err != nil {
+		return err
+	}
+
+	if len(s.sqlHooks["validator_balance"]) > 0 {
+		values := make([]pgx.NamedArgs, len(balances))
+		for i := range balances {
+			values[i] = validatorBalanceHookValues(balances[i])
+		}
+		if err := s.runSQLHooks(ctx, tx, "validator_balance", values...); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // Validators fetches all validators.
 func (s *Service) Validators(ctx context.Context) ([]*chaindb.Validator, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "Validators")
+	ctx, span := startSpan(ctx, "Validators")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -201,13 +227,109 @@ func (s *Service) Validators(ctx context.Context) ([]*chaindb.Validator, error)
 	return validators, nil
 }
 
+// ValidatorsByFilter fetches validators according to the filter.
+func (s *Service) ValidatorsByFilter(ctx context.Context, filter *chaindb.ValidatorFilter) ([]*chaindb.Validator, error) {
+	ctx, span := startSpan(ctx, "ValidatorsByFilter")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_public_key
+      ,f_index
+      ,f_slashed
+      ,f_activation_eligibility_epoch
+      ,f_activation_epoch
+      ,f_exit_epoch
+      ,f_withdrawable_epoch
+      ,f_effective_balance
+      ,f_withdrawal_credentials
+FROM t_validators`)
+
+	if filter.Cursor != nil {
+		queryVals = append(queryVals, *filter.Cursor)
+		queryBuilder.WriteString(fmt.Sprintf(`
+WHERE f_index %s $%d`, cursorComparison(filter.Order), len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	validators := make([]*chaindb.Validator, 0)
+	for rows.Next() {
+		validator, err := validatorFromRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		validators = append(validators, validator)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Always return order of index.
+	sort.Slice(validators, func(i int, j int) bool {
+		return validators[i].Index < validators[j].Index
+	})
+
+	return validators, nil
+}
+
 // ValidatorsByPublicKey fetches all validators matching the given public keys.
 // This is a common starting point for external entities to query specific validators, as they should
 // always have the public key at a minimum, hence the return map keyed by public key.
 func (s *Service) ValidatorsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey]*chaindb.Validator, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ValidatorsByPublicKey")
+	ctx, span := startSpan(ctx, "ValidatorsByPublicKey")
 	defer span.End()
 
+	if s.readCache != nil && s.tx(ctx) == nil {
+		// Resolve through indices, so that the lookups are served from the caches.
+		return s.cachedValidatorsByPublicKey(ctx, pubKeys)
+	}
+
 	tx := s.tx(ctx)
 	if tx == nil {
 		ctx, err := s.BeginROTx(ctx)
@@ -258,13 +380,22 @@ func (s *Service) ValidatorsByPublicKey(ctx context.Context, pubKeys []phase0.BL
 
 // ValidatorsByIndex fetches all validators matching the given indices.
 func (s *Service) ValidatorsByIndex(ctx context.Context, indices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*chaindb.Validator, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ValidatorsByIndex")
+	ctx, span := startSpan(ctx, "ValidatorsByIndex")
 	defer span.End()
 
 	if len(indices) == 0 {
 		return map[phase0.ValidatorIndex]*chaindb.Validator{}, nil
 	}
 
+	if s.readCache != nil && s.tx(ctx) == nil {
+		return s.cachedValidatorsByIndex(ctx, indices)
+	}
+
+	return s.validatorsByIndex(ctx, indices)
+}
+
+// validatorsByIndex fetches all validators matching the given indices from the database.
+func (s *Service) validatorsByIndex(ctx context.Context, indices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*chaindb.Validator, error) {
 	tx := s.tx(ctx)
 	if tx == nil {
 		ctx, err := s.BeginROTx(ctx)
@@ -308,9 +439,85 @@ func (s *Service) ValidatorsByIndex(ctx context.Context, indices []phase0.Valida
 	return validators, nil
 }
 
+// cachedValidatorsByPublicKey fetches all validators matching the given public keys, using the read cache.
+func (s *Service) cachedValidatorsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey]*chaindb.Validator, error) {
+	pubKeyIndices, err := s.ValidatorIndices(ctx, pubKeys)
+	if err != nil {
+		return nil, err
+	}
+	indices := make([]phase0.ValidatorIndex, 0, len(pubKeyIndices))
+	for _, index := range pubKeyIndices {
+		indices = append(indices, index)
+	}
+
+	indexValidators, err := s.ValidatorsByIndex(ctx, indices)
+	if err != nil {
+		return nil, err
+	}
+
+	validators := make(map[phase0.BLSPubKey]*chaindb.Validator, len(indexValidators))
+	for _, validator := range indexValidators {
+		validators[validator.PublicKey] = validator
+	}
+
+	return validators, nil
+}
+
+// cachedValidatorsByIndex fetches all validators matching the given indices, using the read cache.
+func (s *Service) cachedValidatorsByIndex(ctx context.Context, indices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*chaindb.Validator, error) {
+	validators := make(map[phase0.ValidatorIndex]*chaindb.Validator, len(indices))
+
+	keys := make([]string, len(indices))
+	for i, index := range indices {
+		keys[i] = validatorCacheKey(index)
+	}
+	cached, err := s.readCache.Get(ctx, keys)
+	if err != nil {
+		// Not fatal, as we can fall back to the database.
+		log.Debug().Err(err).Msg("Failed to obtain validators from read cache")
+		cached = map[string][]byte{}
+	}
+
+	missing := make([]phase0.ValidatorIndex, 0)
+	for i, index := range indices {
+		data, exists := cached[keys[i]]
+		if exists {
+			validator := &chaindb.Validator{}
+			if err := json.Unmarshal(data, validator); err == nil {
+				monitorReadCacheLookup(ctx, true)
+				validators[index] = validator
+				continue
+			}
+		}
+		monitorReadCacheLookup(ctx, false)
+		missing = append(missing, index)
+	}
+	if len(missing) == 0 {
+		return validators, nil
+	}
+
+	fetched, err := s.validatorsByIndex(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]any, len(fetched))
+	for index, validator := range fetched {
+		validators[index] = validator
+		values[validatorCacheKey(index)] = validator
+	}
+	s.setReadCache(ctx, values)
+
+	return validators, nil
+}
+
+// validatorCacheKey is the read cache key for the validator with the given index.
+func validatorCacheKey(index phase0.ValidatorIndex) string {
+	return fmt.Sprintf("validator:%d", index)
+}
+
 // ValidatorsByWithdrawalCredential fetches all validators with the given withdrawal credential.
 func (s *Service) ValidatorsByWithdrawalCredential(ctx context.Context, withdrawalCredentials []byte) ([]*chaindb.Validator, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ValidatorsByWithdrawalCredential")
+	ctx, span := startSpan(ctx, "ValidatorsByWithdrawalCredential")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -367,7 +574,7 @@ func (s *Service) ValidatorBalancesByEpoch(
 	[]*chaindb.ValidatorBalance,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ValidatorBalancesByEpoch")
+	ctx, span := startSpan(ctx, "ValidatorBalancesByEpoch")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -408,6 +615,15 @@ func (s *Service) ValidatorBalancesByEpoch(
 		}
 	}
 
+	if len(validatorBalances) == 0 {
+		// Balances for the epoch may have been offloaded to cold storage.
+		archived, err := s.archivedValidatorBalances(ctx, epoch, epoch, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		validatorBalances = append(validatorBalances, archived...)
+	}
+
 	return validatorBalances, nil
 }
 
@@ -420,7 +636,7 @@ func (s *Service) ValidatorBalancesByIndexAndEpoch(
 	map[phase0.ValidatorIndex]*chaindb.ValidatorBalance,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ValidatorBalancesByIndexAndEpoch")
+	ctx, span := startSpan(ctx, "ValidatorBalancesByIndexAndEpoch")
 	defer span.End()
 
 	if len(validatorIndices) == 0 {
@@ -464,6 +680,19 @@ func (s *Service) ValidatorBalancesByIndexAndEpoch(
 		validatorBalances[validatorBalance.Index] = validatorBalance
 	}
 
+	if len(validatorBalances) < len(validatorIndices) {
+		// Balances for the epoch may have been offloaded to cold storage.
+		archived, err := s.archivedValidatorBalances(ctx, epoch, epoch, nil, validatorIndices)
+		if err != nil {
+			return nil, err
+		}
+		for _, validatorBalance := range archived {
+			if _, exists := validatorBalances[validatorBalance.Index]; !exists {
+				validatorBalances[validatorBalance.Index] = validatorBalance
+			}
+		}
+	}
+
 	return validatorBalances, nil
 }
 
@@ -479,7 +708,7 @@ func (s *Service) ValidatorBalancesByIndexAndEpochRange(
 	map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ValidatorBalancesByIndexAndEpochRange")
+	ctx, span := startSpan(ctx, "ValidatorBalancesByIndexAndEpochRange")
 	defer span.End()
 
 	if len(validatorIndices) == 0 {
@@ -541,6 +770,15 @@ func (s *Service) ValidatorBalancesByIndexAndEpochRange(
 		validatorBalances[validatorBalance.Index] = append(validatorBalances[validatorBalance.Index], validatorBalance)
 	}
 
+	if endEpoch > startEpoch {
+		// Some of the range may have been offloaded to cold storage.
+		archived, err := s.archivedValidatorBalances(ctx, startEpoch, endEpoch-1, nil, validatorIndices)
+		if err != nil {
+			return nil, err
+		}
+		mergeArchivedValidatorBalances(validatorBalances, archived)
+	}
+
 	// If a validator is not present until after the beginning of the range, for example we ask for epochs 5->10 and
 	// the validator is first present at epoch 7, we need to front-pad the data for that validator with 0s.
 	if err := padValidatorBalances(validatorBalances, int(uint64(endEpoch)-uint64(startEpoch)), startEpoch); err != nil {
@@ -559,7 +797,7 @@ func (s *Service) ValidatorBalancesByIndexAndEpochs(
 	map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ValidatorBalancesByIndexAndEpochs")
+	ctx, span := startSpan(ctx, "ValidatorBalancesByIndexAndEpochs")
 	defer span.End()
 
 	if len(validatorIndices) == 0 {
@@ -623,6 +861,25 @@ func (s *Service) ValidatorBalancesByIndexAndEpochs(
 		validatorBalances[validatorBalance.Index] = append(validatorBalances[validatorBalance.Index], validatorBalance)
 	}
 
+	if len(epochs) > 0 {
+		// Some of the epochs may have been offloaded to cold storage.
+		minEpoch := epochs[0]
+		maxEpoch := epochs[0]
+		for _, epoch := range epochs {
+			if epoch < minEpoch {
+				minEpoch = epoch
+			}
+			if epoch > maxEpoch {
+				maxEpoch = epoch
+			}
+		}
+		archived, err := s.archivedValidatorBalances(ctx, minEpoch, maxEpoch, epochs, validatorIndices)
+		if err != nil {
+			return nil, err
+		}
+		mergeArchivedValidatorBalances(validatorBalances, archived)
+	}
+
 	return validatorBalances, nil
 }
 
@@ -717,7 +974,7 @@ func validatorBalanceFromRow(rows pgx.Rows) (*chaindb.ValidatorBalance, error) {
 
 // PruneValidatorBalances prunes validator balances up to (but not including) the given epoch.
 func (s *Service) PruneValidatorBalances(ctx context.Context, to phase0.Epoch, retain []phase0.ValidatorIndex) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "PruneValidatorBalances")
+	ctx, span := startSpan(ctx, "PruneValidatorBalances")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -737,7 +994,7 @@ WHERE f_epoch <= $1
 
 	if len(retain) > 0 {
 		queryBuilder.WriteString(`
-AND  f_validator_index NOT IN($2)
+AND  NOT (f_validator_index = ANY($2))
 `)
 		queryVals = append(queryVals, retain)
 	}
@@ -0,0 +1,328 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// ValidatorSetDiff provides the changes to the validator set after fromEpoch, up to and including toEpoch.
+func (s *Service) ValidatorSetDiff(ctx context.Context,
+	fromEpoch phase0.Epoch,
+	toEpoch phase0.Epoch,
+) (
+	*chaindb.ValidatorSetDiff,
+	error,
+) {
+	ctx, span := startSpan(ctx, "ValidatorSetDiff")
+	defer span.End()
+
+	if toEpoch < fromEpoch {
+		return nil, fmt.Errorf("to epoch %d before from epoch %d", toEpoch, fromEpoch)
+	}
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		var err error
+		ctx, err = s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	diff := &chaindb.ValidatorSetDiff{
+		FromEpoch: fromEpoch,
+		ToEpoch:   toEpoch,
+	}
+	if fromEpoch == toEpoch {
+		return diff, nil
+	}
+
+	var err error
+	diff.Activations, err = s.validatorSetDiffIndices(ctx, tx, `
+SELECT f_index
+FROM t_validators
+WHERE f_activation_epoch > $1
+  AND f_activation_epoch <= $2
+ORDER BY f_index`, uint64(fromEpoch), uint64(toEpoch))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain activations")
+	}
+
+	diff.Exits, err = s.validatorSetDiffIndices(ctx, tx, `
+SELECT f_index
+FROM t_validators
+WHERE f_exit_epoch > $1
+  AND f_exit_epoch <= $2
+ORDER BY f_index`, uint64(fromEpoch), uint64(toEpoch))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain exits")
+	}
+
+	diff.Slashings, err = s.validatorSetDiffSlashings(ctx, tx, fromEpoch, toEpoch)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain slashings")
+	}
+
+	diff.BalanceChanges, err = s.validatorSetDiffBalanceChanges(ctx, tx, fromEpoch, toEpoch)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain balance changes")
+	}
+
+	diff.CredentialsChanges, err = s.validatorSetDiffCredentialsChanges(ctx, tx, fromEpoch, toEpoch)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain credentials changes")
+	}
+
+	return diff, nil
+}
+
+// validatorSetDiffIndices runs a query that returns validator indices.
+func (*Service) validatorSetDiffIndices(ctx context.Context,
+	tx pgx.Tx,
+	query string,
+	args ...any,
+) (
+	[]phase0.ValidatorIndex,
+	error,
+) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indices := make([]phase0.ValidatorIndex, 0)
+	for rows.Next() {
+		var index uint64
+		if err := rows.Scan(&index); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		indices = append(indices, phase0.ValidatorIndex(index))
+	}
+
+	return indices, nil
+}
+
+// validatorSetDiffSlashings provides the validators slashed by proposer and attester slashings
+// included in canonical blocks in the epochs after fromEpoch, up to and including toEpoch.
+func (s *Service) validatorSetDiffSlashings(ctx context.Context,
+	tx pgx.Tx,
+	fromEpoch phase0.Epoch,
+	toEpoch phase0.Epoch,
+) (
+	[]phase0.ValidatorIndex,
+	error,
+) {
+	slotsPerEpoch, err := s.slotsPerEpoch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	startSlot := (uint64(fromEpoch) + 1) * slotsPerEpoch
+	endSlot := (uint64(toEpoch)+1)*slotsPerEpoch - 1
+
+	return s.validatorSetDiffIndices(ctx, tx, `
+SELECT f_header_1_proposer_index
+FROM t_proposer_slashings
+JOIN t_blocks ON t_blocks.f_root = t_proposer_slashings.f_inclusion_block_root
+WHERE f_inclusion_slot >= $1
+  AND f_inclusion_slot <= $2
+  AND t_blocks.f_canonical = true
+UNION
+SELECT slashed_index
+FROM t_attester_slashings
+JOIN t_blocks ON t_blocks.f_root = t_attester_slashings.f_inclusion_block_root
+CROSS JOIN LATERAL UNNEST(f_attestation_1_indices) AS slashed_index
+WHERE f_inclusion_slot >= $1
+  AND f_inclusion_slot <= $2
+  AND t_blocks.f_canonical = true
+  AND slashed_index = ANY(f_attestation_2_indices)
+ORDER BY 1`, startSlot, endSlot)
+}
+
+// validatorSetDiffBalanceChanges provides the validators whose balances differ between the two epochs.
+func (s *Service) validatorSetDiffBalanceChanges(ctx context.Context,
+	tx pgx.Tx,
+	fromEpoch phase0.Epoch,
+	toEpoch phase0.Epoch,
+) (
+	[]*chaindb.ValidatorBalanceChange,
+	error,
+) {
+	var fromPresent bool
+	var toPresent bool
+	if err := tx.QueryRow(ctx, `
+SELECT EXISTS(SELECT 1 FROM t_validator_balances WHERE f_epoch = $1)
+      ,EXISTS(SELECT 1 FROM t_validator_balances WHERE f_epoch = $2)`,
+		uint64(fromEpoch),
+		uint64(toEpoch),
+	).Scan(&fromPresent, &toPresent); err != nil {
+		return nil, err
+	}
+
+	if !fromPresent || !toPresent {
+		// Balances for at least one of the epochs may have been offloaded to cold storage,
+		// so fetch them through the provider and compare them here.
+		fromBalances, err := s.ValidatorBalancesByEpoch(ctx, fromEpoch)
+		if err != nil {
+			return nil, err
+		}
+		toBalances, err := s.ValidatorBalancesByEpoch(ctx, toEpoch)
+		if err != nil {
+			return nil, err
+		}
+
+		return balanceChanges(fromBalances, toBalances), nil
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT COALESCE(f.f_validator_index,t.f_validator_index)
+      ,COALESCE(f.f_balance,0)
+      ,COALESCE(t.f_balance,0)
+      ,COALESCE(f.f_effective_balance,0)
+      ,COALESCE(t.f_effective_balance,0)
+FROM (SELECT * FROM t_validator_balances WHERE f_epoch = $1) f
+FULL OUTER JOIN (SELECT * FROM t_validator_balances WHERE f_epoch = $2) t
+  ON t.f_validator_index = f.f_validator_index
+WHERE f.f_balance IS DISTINCT FROM t.f_balance
+   OR f.f_effective_balance IS DISTINCT FROM t.f_effective_balance
+ORDER BY 1`,
+		uint64(fromEpoch),
+		uint64(toEpoch),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]*chaindb.ValidatorBalanceChange, 0)
+	for rows.Next() {
+		change := &chaindb.ValidatorBalanceChange{}
+		if err := rows.Scan(
+			&change.Index,
+			&change.FromBalance,
+			&change.ToBalance,
+			&change.FromEffectiveBalance,
+			&change.ToEffectiveBalance,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// validatorSetDiffCredentialsChanges provides the changes to withdrawal credentials after fromEpoch,
+// up to and including toEpoch.  The first credentials recorded for a validator are not a change.
+func (*Service) validatorSetDiffCredentialsChanges(ctx context.Context,
+	tx pgx.Tx,
+	fromEpoch phase0.Epoch,
+	toEpoch phase0.Epoch,
+) (
+	[]*chaindb.ValidatorCredentialsDiff,
+	error,
+) {
+	rows, err := tx.Query(ctx, `
+SELECT f_validator_index
+      ,f_epoch
+      ,f_previous_withdrawal_credentials
+      ,f_withdrawal_credentials
+FROM (
+  SELECT f_validator_index
+        ,f_epoch
+        ,f_withdrawal_credentials
+        ,LAG(f_withdrawal_credentials) OVER (PARTITION BY f_validator_index ORDER BY f_epoch) AS f_previous_withdrawal_credentials
+  FROM t_validator_credentials_changes
+  WHERE f_validator_index IN (SELECT f_validator_index FROM t_validator_credentials_changes WHERE f_epoch > $1 AND f_epoch <= $2)
+) c
+WHERE f_epoch > $1
+  AND f_epoch <= $2
+  AND f_previous_withdrawal_credentials IS NOT NULL
+ORDER BY f_validator_index,f_epoch`,
+		uint64(fromEpoch),
+		uint64(toEpoch),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]*chaindb.ValidatorCredentialsDiff, 0)
+	for rows.Next() {
+		change := &chaindb.ValidatorCredentialsDiff{}
+		var fromCredentials []byte
+		var toCredentials []byte
+		if err := rows.Scan(
+			&change.Index,
+			&change.Epoch,
+			&fromCredentials,
+			&toCredentials,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(change.FromWithdrawalCredentials[:], fromCredentials)
+		copy(change.ToWithdrawalCredentials[:], toCredentials)
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// balanceChanges compares two sets of validator balances, returning the validators whose
+// balance or effective balance differ in index order.
+func balanceChanges(fromBalances []*chaindb.ValidatorBalance,
+	toBalances []*chaindb.ValidatorBalance,
+) []*chaindb.ValidatorBalanceChange {
+	changes := make(map[phase0.ValidatorIndex]*chaindb.ValidatorBalanceChange, len(toBalances))
+	for _, balance := range fromBalances {
+		changes[balance.Index] = &chaindb.ValidatorBalanceChange{
+			Index:                balance.Index,
+			FromBalance:          balance.Balance,
+			FromEffectiveBalance: balance.EffectiveBalance,
+		}
+	}
+	for _, balance := range toBalances {
+		change, exists := changes[balance.Index]
+		if !exists {
+			change = &chaindb.ValidatorBalanceChange{
+				Index: balance.Index,
+			}
+			changes[balance.Index] = change
+		}
+		change.ToBalance = balance.Balance
+		change.ToEffectiveBalance = balance.EffectiveBalance
+	}
+
+	res := make([]*chaindb.ValidatorBalanceChange, 0)
+	for _, change := range changes {
+		if change.FromBalance != change.ToBalance || change.FromEffectiveBalance != change.ToEffectiveBalance {
+			res = append(res, change)
+		}
+	}
+	sort.Slice(res, func(i int, j int) bool {
+		return res[i].Index < res[j].Index
+	})
+
+	return res
+}
@@ -0,0 +1,88 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestBalanceChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     []*chaindb.ValidatorBalance
+		to       []*chaindb.ValidatorBalance
+		expected []*chaindb.ValidatorBalanceChange
+	}{
+		{
+			name:     "Empty",
+			expected: []*chaindb.ValidatorBalanceChange{},
+		},
+		{
+			name: "Unchanged",
+			from: []*chaindb.ValidatorBalance{
+				{Index: 1, Balance: 32000000000, EffectiveBalance: 32000000000},
+			},
+			to: []*chaindb.ValidatorBalance{
+				{Index: 1, Balance: 32000000000, EffectiveBalance: 32000000000},
+			},
+			expected: []*chaindb.ValidatorBalanceChange{},
+		},
+		{
+			name: "Changed",
+			from: []*chaindb.ValidatorBalance{
+				{Index: 2, Balance: 32000000000, EffectiveBalance: 32000000000},
+				{Index: 1, Balance: 32000000000, EffectiveBalance: 32000000000},
+			},
+			to: []*chaindb.ValidatorBalance{
+				{Index: 1, Balance: 32000001000, EffectiveBalance: 32000000000},
+				{Index: 2, Balance: 31000000000, EffectiveBalance: 31000000000},
+			},
+			expected: []*chaindb.ValidatorBalanceChange{
+				{Index: 1, FromBalance: 32000000000, ToBalance: 32000001000, FromEffectiveBalance: 32000000000, ToEffectiveBalance: 32000000000},
+				{Index: 2, FromBalance: 32000000000, ToBalance: 31000000000, FromEffectiveBalance: 32000000000, ToEffectiveBalance: 31000000000},
+			},
+		},
+		{
+			name: "NewValidator",
+			from: []*chaindb.ValidatorBalance{
+				{Index: 1, Balance: 32000000000, EffectiveBalance: 32000000000},
+			},
+			to: []*chaindb.ValidatorBalance{
+				{Index: 1, Balance: 32000000000, EffectiveBalance: 32000000000},
+				{Index: 2, Balance: 32000000000, EffectiveBalance: 32000000000},
+			},
+			expected: []*chaindb.ValidatorBalanceChange{
+				{Index: 2, ToBalance: 32000000000, ToEffectiveBalance: 32000000000},
+			},
+		},
+		{
+			name: "MissingValidator",
+			from: []*chaindb.ValidatorBalance{
+				{Index: 1, Balance: 1000, EffectiveBalance: 0},
+			},
+			expected: []*chaindb.ValidatorBalanceChange{
+				{Index: 1, FromBalance: 1000},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, balanceChanges(test.from, test.to))
+		})
+	}
+}
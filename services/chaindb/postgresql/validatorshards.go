@@ -0,0 +1,116 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetValidatorShard sets the progress of a validator shard.
+func (s *Service) SetValidatorShard(ctx context.Context, shard *chaindb.ValidatorShard) error {
+	ctx, span := startSpan(ctx, "SetValidatorShard")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	toIndex := sql.NullInt64{}
+	if shard.ToIndex != nil {
+		toIndex.Valid = true
+		toIndex.Int64 = int64(*shard.ToIndex)
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_validator_shards(f_from_index
+                              ,f_to_index
+                              ,f_latest_epoch
+                              ,f_latest_balances_epoch
+                              ,f_updated
+                              )
+VALUES($1,$2,$3,$4,$5)
+ON CONFLICT (f_from_index) DO
+UPDATE
+SET f_to_index = excluded.f_to_index
+   ,f_latest_epoch = excluded.f_latest_epoch
+   ,f_latest_balances_epoch = excluded.f_latest_balances_epoch
+   ,f_updated = excluded.f_updated
+`,
+		shard.FromIndex,
+		toIndex,
+		shard.LatestEpoch,
+		shard.LatestBalancesEpoch,
+		shard.Updated,
+	)
+
+	return err
+}
+
+// ValidatorShards provides the progress of all validator shards, ordered by first validator index.
+func (s *Service) ValidatorShards(ctx context.Context) ([]*chaindb.ValidatorShard, error) {
+	ctx, span := startSpan(ctx, "ValidatorShards")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_from_index
+      ,f_to_index
+      ,f_latest_epoch
+      ,f_latest_balances_epoch
+      ,f_updated
+FROM t_validator_shards
+ORDER BY f_from_index`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shards := make([]*chaindb.ValidatorShard, 0)
+	for rows.Next() {
+		shard := &chaindb.ValidatorShard{}
+		var toIndex sql.NullInt64
+		if err := rows.Scan(
+			&shard.FromIndex,
+			&toIndex,
+			&shard.LatestEpoch,
+			&shard.LatestBalancesEpoch,
+			&shard.Updated,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if toIndex.Valid {
+			index := phase0.ValidatorIndex(toIndex.Int64)
+			shard.ToIndex = &index
+		}
+		shards = append(shards, shard)
+	}
+
+	return shards, nil
+}
@@ -0,0 +1,191 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetValidatorSyncPeriodSummaries sets multiple validator sync period summaries.
+// Any existing summaries for the periods covered are replaced.
+func (s *Service) SetValidatorSyncPeriodSummaries(ctx context.Context, summaries []*chaindb.ValidatorSyncPeriodSummary) error {
+	ctx, span := startSpan(ctx, "SetValidatorSyncPeriodSummaries")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	periods := make([]uint64, 0)
+	seen := make(map[uint64]bool)
+	for _, summary := range summaries {
+		if !seen[summary.Period] {
+			seen[summary.Period] = true
+			periods = append(periods, summary.Period)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+DELETE FROM t_validator_sync_period_summaries
+WHERE f_period = ANY($1)
+`,
+		periods,
+	); err != nil {
+		return errors.Wrap(err, "failed to remove existing validator sync period summaries")
+	}
+
+	if _, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"t_validator_sync_period_summaries"},
+		[]string{
+			"f_validator_index",
+			"f_period",
+			"f_assigned_slots",
+			"f_participated_slots",
+			"f_missed_slots",
+			"f_rewards",
+		},
+		pgx.CopyFromSlice(len(summaries), func(i int) ([]any, error) {
+			return []any{
+				summaries[i].Index,
+				summaries[i].Period,
+				summaries[i].AssignedSlots,
+				summaries[i].ParticipatedSlots,
+				summaries[i].MissedSlots,
+				summaries[i].Rewards,
+			}, nil
+		})); err != nil {
+		return errors.Wrap(err, "failed to copy validator sync period summaries")
+	}
+
+	return nil
+}
+
+// ValidatorSyncPeriodSummaries provides validator sync period summaries according to the filter.
+func (s *Service) ValidatorSyncPeriodSummaries(ctx context.Context, filter *chaindb.ValidatorSyncPeriodSummaryFilter) ([]*chaindb.ValidatorSyncPeriodSummary, error) {
+	ctx, span := startSpan(ctx, "ValidatorSyncPeriodSummaries")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_validator_index
+      ,f_period
+      ,f_assigned_slots
+      ,f_participated_slots
+      ,f_missed_slots
+      ,f_rewards
+FROM t_validator_sync_period_summaries`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_period >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_period <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.ValidatorIndices != nil && len(*filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, *filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_validator_index = ANY($%d)`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_period, f_validator_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_period DESC,f_validator_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]*chaindb.ValidatorSyncPeriodSummary, 0)
+	for rows.Next() {
+		summary := &chaindb.ValidatorSyncPeriodSummary{}
+		err := rows.Scan(
+			&summary.Index,
+			&summary.Period,
+			&summary.AssignedSlots,
+			&summary.ParticipatedSlots,
+			&summary.MissedSlots,
+			&summary.Rewards,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		summaries = append(summaries, summary)
+	}
+
+	// Always return order of period then validator index.
+	sort.Slice(summaries, func(i int, j int) bool {
+		if summaries[i].Period != summaries[j].Period {
+			return summaries[i].Period < summaries[j].Period
+		}
+		return summaries[i].Index < summaries[j].Index
+	})
+	return summaries, nil
+}
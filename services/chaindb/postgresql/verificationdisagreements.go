@@ -0,0 +1,192 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetVerificationDisagreement sets a verification disagreement.
+// An existing disagreement for the same slot is replaced.
+func (s *Service) SetVerificationDisagreement(ctx context.Context, disagreement *chaindb.VerificationDisagreement) error {
+	ctx, span := startSpan(ctx, "SetVerificationDisagreement")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	var indexedRoot []byte
+	if disagreement.IndexedRoot != nil {
+		indexedRoot = disagreement.IndexedRoot[:]
+	}
+	var referenceRoot []byte
+	if disagreement.ReferenceRoot != nil {
+		referenceRoot = disagreement.ReferenceRoot[:]
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_verification_disagreements(f_slot
+                                        ,f_type
+                                        ,f_indexed_root
+                                        ,f_reference_root
+                                        ,f_detected
+                                        )
+VALUES($1,$2,$3,$4,$5)
+ON CONFLICT (f_slot) DO
+UPDATE
+SET f_type = excluded.f_type
+   ,f_indexed_root = excluded.f_indexed_root
+   ,f_reference_root = excluded.f_reference_root
+   ,f_detected = excluded.f_detected
+`,
+		disagreement.Slot,
+		disagreement.Type,
+		indexedRoot,
+		referenceRoot,
+		disagreement.Detected,
+	)
+
+	return err
+}
+
+// VerificationDisagreements provides verification disagreements according to the filter.
+func (s *Service) VerificationDisagreements(ctx context.Context,
+	filter *chaindb.VerificationDisagreementFilter,
+) (
+	[]*chaindb.VerificationDisagreement,
+	error,
+) {
+	ctx, span := startSpan(ctx, "VerificationDisagreements")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_slot
+      ,f_type
+      ,f_indexed_root
+      ,f_reference_root
+      ,f_detected
+FROM t_verification_disagreements`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.Types) > 0 {
+		queryVals = append(queryVals, filter.Types)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_type = ANY($%d)`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	disagreements := make([]*chaindb.VerificationDisagreement, 0)
+	for rows.Next() {
+		disagreement := &chaindb.VerificationDisagreement{}
+		var indexedRoot []byte
+		var referenceRoot []byte
+		err := rows.Scan(
+			&disagreement.Slot,
+			&disagreement.Type,
+			&indexedRoot,
+			&referenceRoot,
+			&disagreement.Detected,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if len(indexedRoot) == phase0.RootLength {
+			disagreement.IndexedRoot = &phase0.Root{}
+			copy(disagreement.IndexedRoot[:], indexedRoot)
+		}
+		if len(referenceRoot) == phase0.RootLength {
+			disagreement.ReferenceRoot = &phase0.Root{}
+			copy(disagreement.ReferenceRoot[:], referenceRoot)
+		}
+		disagreements = append(disagreements, disagreement)
+	}
+
+	// Always return order of slot.
+	sort.Slice(disagreements, func(i int, j int) bool {
+		return disagreements[i].Slot < disagreements[j].Slot
+	})
+
+	return disagreements, nil
+}
@@ -15,14 +15,18 @@ package postgresql
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // SetVoluntaryExit sets a voluntary exit.
 func (s *Service) SetVoluntaryExit(ctx context.Context, voluntaryExit *chaindb.VoluntaryExit) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetVoluntaryExit")
+	ctx, span := startSpan(ctx, "SetVoluntaryExit")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -52,3 +56,139 @@ func (s *Service) SetVoluntaryExit(ctx context.Context, voluntaryExit *chaindb.V
 
 	return err
 }
+
+// VoluntaryExits provides voluntary exits according to the filter.
+func (s *Service) VoluntaryExits(ctx context.Context, filter *chaindb.VoluntaryExitFilter) ([]*chaindb.VoluntaryExit, error) {
+	ctx, span := startSpan(ctx, "VoluntaryExits")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_inclusion_slot
+      ,f_inclusion_block_root
+      ,f_inclusion_index
+      ,f_validator_index
+      ,f_epoch
+FROM t_voluntary_exits`)
+
+	conditions := make([]string, 0)
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("f_inclusion_slot >= $%d", len(queryVals)))
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("f_inclusion_slot <= $%d", len(queryVals)))
+	}
+
+	if len(filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, filter.ValidatorIndices)
+		conditions = append(conditions, fmt.Sprintf("f_validator_index = ANY($%d)", len(queryVals)))
+	}
+
+	if filter.Canonical != nil {
+		queryVals = append(queryVals, *filter.Canonical)
+		conditions = append(conditions, inclusionCanonicalCondition("t_voluntary_exits", len(queryVals)))
+	}
+
+	if filter.ExcludeNonCanonical {
+		conditions = append(conditions, inclusionNotNonCanonicalCondition("t_voluntary_exits"))
+	}
+
+	if len(conditions) > 0 {
+		queryBuilder.WriteString("\nWHERE ")
+		queryBuilder.WriteString(strings.Join(conditions, "\n  AND "))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_inclusion_slot, f_inclusion_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_inclusion_slot DESC,f_inclusion_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	voluntaryExits := make([]*chaindb.VoluntaryExit, 0)
+	for rows.Next() {
+		voluntaryExit := &chaindb.VoluntaryExit{}
+		var inclusionBlockRoot []byte
+		err := rows.Scan(
+			&voluntaryExit.InclusionSlot,
+			&inclusionBlockRoot,
+			&voluntaryExit.InclusionIndex,
+			&voluntaryExit.ValidatorIndex,
+			&voluntaryExit.Epoch,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(voluntaryExit.InclusionBlockRoot[:], inclusionBlockRoot)
+
+		voluntaryExits = append(voluntaryExits, voluntaryExit)
+	}
+
+	// Always return order of inclusion slot then inclusion index.
+	sort.Slice(voluntaryExits, func(i int, j int) bool {
+		if voluntaryExits[i].InclusionSlot != voluntaryExits[j].InclusionSlot {
+			return voluntaryExits[i].InclusionSlot < voluntaryExits[j].InclusionSlot
+		}
+		return voluntaryExits[i].InclusionIndex < voluntaryExits[j].InclusionIndex
+	})
+	return voluntaryExits, nil
+}
+
+// VoluntaryExitsForSlotRange fetches all voluntary exits included in the given slot range.
+// It will return voluntary exits from blocks that are canonical or undefined, but not from non-canonical blocks.
+func (s *Service) VoluntaryExitsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.VoluntaryExit, error) {
+	if maxSlot <= minSlot {
+		return []*chaindb.VoluntaryExit{}, nil
+	}
+	to := maxSlot - 1
+
+	return s.VoluntaryExits(ctx, &chaindb.VoluntaryExitFilter{
+		From:                &minSlot,
+		To:                  &to,
+		ExcludeNonCanonical: true,
+	})
+}
@@ -0,0 +1,441 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetWatchedValidator adds a validator to the watchlist, or updates it if already present.
+func (s *Service) SetWatchedValidator(ctx context.Context, validator *chaindb.WatchedValidator) error {
+	ctx, span := startSpan(ctx, "SetWatchedValidator")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	var proof []byte
+	if validator.Proof != nil {
+		proof = validator.Proof[:]
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_watchlist(f_validator_index
+                       ,f_label
+                       ,f_added
+                       ,f_proof
+                       )
+VALUES($1,$2,$3,$4)
+ON CONFLICT (f_validator_index) DO
+UPDATE
+SET f_label = excluded.f_label
+   ,f_proof = excluded.f_proof
+`,
+		validator.Index,
+		validator.Label,
+		validator.Added,
+		proof,
+	)
+
+	return err
+}
+
+// RemoveWatchedValidator removes a validator from the watchlist, along with its events and alerts.
+func (s *Service) RemoveWatchedValidator(ctx context.Context, index phase0.ValidatorIndex) error {
+	ctx, span := startSpan(ctx, "RemoveWatchedValidator")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+DELETE FROM t_watchlist
+WHERE f_validator_index = $1
+`,
+		index,
+	)
+
+	return err
+}
+
+// WatchedValidators provides all validators on the watchlist, in index order.
+func (s *Service) WatchedValidators(ctx context.Context) ([]*chaindb.WatchedValidator, error) {
+	ctx, span := startSpan(ctx, "WatchedValidators")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_validator_index
+      ,f_label
+      ,f_added
+      ,f_proof
+FROM t_watchlist
+ORDER BY f_validator_index`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	validators := make([]*chaindb.WatchedValidator, 0)
+	for rows.Next() {
+		validator := &chaindb.WatchedValidator{}
+		var proof []byte
+		err := rows.Scan(
+			&validator.Index,
+			&validator.Label,
+			&validator.Added,
+			&proof,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if len(proof) == phase0.SignatureLength {
+			validator.Proof = &phase0.BLSSignature{}
+			copy(validator.Proof[:], proof)
+		}
+		validators = append(validators, validator)
+	}
+
+	return validators, nil
+}
+
+// SetWatchlistEvents sets watchlist events.
+// Events that already exist are left unchanged.
+func (s *Service) SetWatchlistEvents(ctx context.Context, events []*chaindb.WatchlistEvent) error {
+	ctx, span := startSpan(ctx, "SetWatchlistEvents")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, event := range events {
+		var amount sql.NullInt64
+		if event.Amount != nil {
+			amount.Valid = true
+			amount.Int64 = *event.Amount
+		}
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_watchlist_events(f_validator_index
+                              ,f_epoch
+                              ,f_type
+                              ,f_slot
+                              ,f_amount
+                              )
+VALUES($1,$2,$3,$4,$5)
+ON CONFLICT (f_validator_index,f_type,f_slot) DO NOTHING
+`,
+			event.ValidatorIndex,
+			event.Epoch,
+			event.Type,
+			event.Slot,
+			amount,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WatchlistEvents provides watchlist events according to the filter.
+func (s *Service) WatchlistEvents(ctx context.Context, filter *chaindb.WatchlistEventFilter) ([]*chaindb.WatchlistEvent, error) {
+	ctx, span := startSpan(ctx, "WatchlistEvents")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_validator_index
+      ,f_epoch
+      ,f_type
+      ,f_slot
+      ,f_amount
+FROM t_watchlist_events`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.Types) > 0 {
+		queryVals = append(queryVals, filter.Types)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_type = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_validator_index = ANY($%d)`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot, f_validator_index, f_type`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC, f_validator_index DESC, f_type DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*chaindb.WatchlistEvent, 0)
+	for rows.Next() {
+		event := &chaindb.WatchlistEvent{}
+		var amount sql.NullInt64
+		err := rows.Scan(
+			&event.ValidatorIndex,
+			&event.Epoch,
+			&event.Type,
+			&event.Slot,
+			&amount,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if amount.Valid {
+			event.Amount = &amount.Int64
+		}
+		events = append(events, event)
+	}
+
+	// Always return order of slot then validator index then type.
+	sort.Slice(events, func(i int, j int) bool {
+		if events[i].Slot != events[j].Slot {
+			return events[i].Slot < events[j].Slot
+		}
+		if events[i].ValidatorIndex != events[j].ValidatorIndex {
+			return events[i].ValidatorIndex < events[j].ValidatorIndex
+		}
+		return events[i].Type < events[j].Type
+	})
+
+	return events, nil
+}
+
+// SetWatchlistAlerts sets watchlist alerts.
+// Alerts that already exist are left unchanged.
+func (s *Service) SetWatchlistAlerts(ctx context.Context, alerts []*chaindb.WatchlistAlert) error {
+	ctx, span := startSpan(ctx, "SetWatchlistAlerts")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, alert := range alerts {
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_watchlist_alerts(f_validator_index
+                              ,f_epoch
+                              ,f_rule
+                              ,f_description
+                              )
+VALUES($1,$2,$3,$4)
+ON CONFLICT (f_validator_index,f_rule,f_epoch) DO NOTHING
+`,
+			alert.ValidatorIndex,
+			alert.Epoch,
+			alert.Rule,
+			alert.Description,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WatchlistAlerts provides watchlist alerts according to the filter.
+func (s *Service) WatchlistAlerts(ctx context.Context, filter *chaindb.WatchlistAlertFilter) ([]*chaindb.WatchlistAlert, error) {
+	ctx, span := startSpan(ctx, "WatchlistAlerts")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_validator_index
+      ,f_epoch
+      ,f_rule
+      ,f_description
+FROM t_watchlist_alerts`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_validator_index = ANY($%d)`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch, f_validator_index, f_rule`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch DESC, f_validator_index DESC, f_rule DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := make([]*chaindb.WatchlistAlert, 0)
+	for rows.Next() {
+		alert := &chaindb.WatchlistAlert{}
+		err := rows.Scan(
+			&alert.ValidatorIndex,
+			&alert.Epoch,
+			&alert.Rule,
+			&alert.Description,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		alerts = append(alerts, alert)
+	}
+
+	// Always return order of epoch then validator index then rule.
+	sort.Slice(alerts, func(i int, j int) bool {
+		if alerts[i].Epoch != alerts[j].Epoch {
+			return alerts[i].Epoch < alerts[j].Epoch
+		}
+		if alerts[i].ValidatorIndex != alerts[j].ValidatorIndex {
+			return alerts[i].ValidatorIndex < alerts[j].ValidatorIndex
+		}
+		return alerts[i].Rule < alerts[j].Rule
+	})
+
+	return alerts, nil
+}
@@ -0,0 +1,67 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// maxGwei is the largest value of gwei.
+var maxGwei = decimal.NewFromBigInt(new(big.Int).SetUint64(^uint64(0)), 0)
+
+// numericFromBigInt converts an integer to a value for a NUMERIC column,
+// which is null if the integer is nil.
+func numericFromBigInt(value *big.Int) decimal.NullDecimal {
+	if value == nil {
+		return decimal.NullDecimal{}
+	}
+
+	return decimal.NewNullDecimal(decimal.NewFromBigInt(value, 0))
+}
+
+// bigIntFromNumeric converts the value of a NUMERIC column to an integer,
+// which is nil if the value is null.
+func bigIntFromNumeric(value decimal.NullDecimal) *big.Int {
+	if !value.Valid {
+		return nil
+	}
+
+	return value.Decimal.BigInt()
+}
+
+// numericFromGwei converts gwei to a value for a NUMERIC column, allowing
+// the full range of gwei to be stored.
+func numericFromGwei(value phase0.Gwei) decimal.Decimal {
+	return decimal.NewFromBigInt(new(big.Int).SetUint64(uint64(value)), 0)
+}
+
+// gweiFromNumeric converts the value of a NUMERIC column to gwei.
+func gweiFromNumeric(value decimal.Decimal) (phase0.Gwei, error) {
+	if !value.IsInteger() || value.IsNegative() || value.GreaterThan(maxGwei) {
+		return 0, errors.Errorf("value %s is not a valid amount of gwei", value.String())
+	}
+
+	return phase0.Gwei(value.BigInt().Uint64()), nil
+}
+
+// denominated provides a value in wei in the denomination requested for the call.
+func denominated(ctx context.Context, wei *big.Int) *big.Int {
+	return chaindb.ValueDenomination(ctx).FromWei(wei)
+}
@@ -0,0 +1,116 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestBigIntNumericRoundTrip(t *testing.T) {
+	large, ok := new(big.Int).SetString("123456789012345678901234567890", 10)
+	require.True(t, ok)
+
+	tests := []struct {
+		name  string
+		value *big.Int
+	}{
+		{
+			name: "Nil",
+		},
+		{
+			name:  "Zero",
+			value: big.NewInt(0),
+		},
+		{
+			name:  "Large",
+			value: large,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			numeric := numericFromBigInt(test.value)
+			require.Equal(t, test.value != nil, numeric.Valid)
+			require.Equal(t, test.value, bigIntFromNumeric(numeric))
+		})
+	}
+}
+
+func TestGweiFromNumeric(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    decimal.Decimal
+		expected phase0.Gwei
+		err      string
+	}{
+		{
+			name:     "Zero",
+			value:    numericFromGwei(0),
+			expected: 0,
+		},
+		{
+			name:     "Max",
+			value:    numericFromGwei(phase0.Gwei(^uint64(0))),
+			expected: phase0.Gwei(^uint64(0)),
+		},
+		{
+			name:  "TooLarge",
+			value: numericFromGwei(phase0.Gwei(^uint64(0))).Add(decimal.NewFromInt(1)),
+			err:   "value 18446744073709551616 is not a valid amount of gwei",
+		},
+		{
+			name:  "Negative",
+			value: decimal.NewFromInt(-1),
+			err:   "value -1 is not a valid amount of gwei",
+		},
+		{
+			name:  "Fractional",
+			value: decimal.RequireFromString("1.5"),
+			err:   "value 1.5 is not a valid amount of gwei",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := gweiFromNumeric(test.value)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expected, res)
+			}
+		})
+	}
+}
+
+func TestDenominated(t *testing.T) {
+	ctx := context.Background()
+	wei, ok := new(big.Int).SetString("1234567890623456789", 10)
+	require.True(t, ok)
+
+	// Values are in wei unless requested otherwise for the call.
+	require.Equal(t, wei, denominated(ctx, wei))
+	require.Nil(t, denominated(ctx, nil))
+
+	gweiCtx := chaindb.WithValueDenomination(ctx, chaindb.DenominationGwei)
+	require.Equal(t, big.NewInt(1234567891), denominated(gweiCtx, wei))
+	require.Nil(t, denominated(gweiCtx, nil))
+}
@@ -0,0 +1,164 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetWithdrawalForecasts sets multiple withdrawal forecasts.
+// Any existing forecasts for the validators covered are replaced.
+func (s *Service) SetWithdrawalForecasts(ctx context.Context, forecasts []*chaindb.WithdrawalForecast) error {
+	ctx, span := startSpan(ctx, "SetWithdrawalForecasts")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	validatorIndices := make([]phase0.ValidatorIndex, len(forecasts))
+	for i, forecast := range forecasts {
+		validatorIndices[i] = forecast.ValidatorIndex
+	}
+
+	if _, err := tx.Exec(ctx, `
+DELETE FROM t_withdrawal_forecasts
+WHERE f_validator_index = ANY($1)
+`,
+		validatorIndices,
+	); err != nil {
+		return errors.Wrap(err, "failed to remove existing withdrawal forecasts")
+	}
+
+	if _, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"t_withdrawal_forecasts"},
+		[]string{
+			"f_validator_index",
+			"f_epoch",
+			"f_withdrawable_epoch",
+			"f_slot",
+		},
+		pgx.CopyFromSlice(len(forecasts), func(i int) ([]any, error) {
+			return []any{
+				forecasts[i].ValidatorIndex,
+				forecasts[i].Epoch,
+				forecasts[i].WithdrawableEpoch,
+				forecasts[i].Slot,
+			}, nil
+		})); err != nil {
+		return errors.Wrap(err, "failed to copy withdrawal forecasts")
+	}
+
+	return nil
+}
+
+// WithdrawalForecasts provides withdrawal forecasts according to the filter.
+func (s *Service) WithdrawalForecasts(ctx context.Context, filter *chaindb.WithdrawalForecastFilter) ([]*chaindb.WithdrawalForecast, error) {
+	ctx, span := startSpan(ctx, "WithdrawalForecasts")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]any, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_validator_index
+      ,f_epoch
+      ,f_withdrawable_epoch
+      ,f_slot
+FROM t_withdrawal_forecasts`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_validator_index = ANY($%d)`, wherestr, len(queryVals)))
+	}
+
+	queryBuilder.WriteString(`
+ORDER BY f_validator_index`)
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	forecasts := make([]*chaindb.WithdrawalForecast, 0)
+	for rows.Next() {
+		forecast := &chaindb.WithdrawalForecast{}
+		err := rows.Scan(
+			&forecast.ValidatorIndex,
+			&forecast.Epoch,
+			&forecast.WithdrawableEpoch,
+			&forecast.Slot,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		forecasts = append(forecasts, forecast)
+	}
+
+	return forecasts, rows.Err()
+}
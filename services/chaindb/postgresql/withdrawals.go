@@ -22,13 +22,13 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/bellatrix"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 	"github.com/wealdtech/chaind/services/chaindb"
-	"go.opentelemetry.io/otel"
 )
 
 // setWithdrawals sets the withdrawals of a block.
 func (s *Service) setWithdrawals(ctx context.Context, block *chaindb.Block) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "setWithdrawals")
+	ctx, span := startSpan(ctx, "setWithdrawals")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -78,7 +78,7 @@ SET f_withdrawal_index = excluded.f_withdrawal_index
 			withdrawal.Index,
 			withdrawal.ValidatorIndex,
 			withdrawal.Address[:],
-			withdrawal.Amount,
+			numericFromGwei(withdrawal.Amount),
 		); err != nil {
 			return err
 		}
@@ -89,7 +89,7 @@ SET f_withdrawal_index = excluded.f_withdrawal_index
 
 // Withdrawals provides withdrawals according to the filter.
 func (s *Service) Withdrawals(ctx context.Context, filter *chaindb.WithdrawalFilter) ([]*chaindb.Withdrawal, error) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "Withdrawals")
+	ctx, span := startSpan(ctx, "Withdrawals")
 	defer span.End()
 
 	tx := s.tx(ctx)
@@ -121,9 +121,12 @@ FROM t_block_withdrawals`)
 	if filter.Canonical != nil {
 		queryVals = append(queryVals, *filter.Canonical)
 		queryBuilder.WriteString(fmt.Sprintf(`
-LEFT JOIN t_blocks ON f_block_number = f_slot
 %s f_canonical = $%d`, wherestr, len(queryVals)))
 		wherestr = "  AND"
+	} else if s.canonicalOnly {
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_canonical = true`, wherestr))
+		wherestr = "  AND"
 	}
 
 	if filter.From != nil {
@@ -137,12 +140,20 @@ LEFT JOIN t_blocks ON f_block_number = f_slot
 		queryVals = append(queryVals, *filter.To)
 		queryBuilder.WriteString(fmt.Sprintf(`
 %s f_block_number <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
 	}
 
 	if len(filter.ValidatorIndices) > 0 {
 		queryVals = append(queryVals, filter.ValidatorIndices)
 		queryBuilder.WriteString(fmt.Sprintf(`
 %s f_validator_index = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if len(filter.AddressLabels) > 0 {
+		queryVals = append(queryVals, filter.AddressLabels)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s %s`, wherestr, addressLabelsCondition("f_address", len(queryVals))))
 	}
 
 	switch filter.Order {
@@ -184,6 +195,7 @@ LIMIT $%d`, len(queryVals)))
 	address := make([]byte, bellatrix.ExecutionAddressLength)
 	for rows.Next() {
 		withdrawal := &chaindb.Withdrawal{}
+		var amount decimal.Decimal
 		err := rows.Scan(
 			&inclusionBlockRoot,
 			&withdrawal.InclusionSlot,
@@ -191,11 +203,15 @@ LIMIT $%d`, len(queryVals)))
 			&withdrawal.Index,
 			&withdrawal.ValidatorIndex,
 			&address,
-			&withdrawal.Amount,
+			&amount,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
+		withdrawal.Amount, err = gweiFromNumeric(amount)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid withdrawal amount")
+		}
 		copy(withdrawal.InclusionBlockRoot[:], inclusionBlockRoot)
 		copy(withdrawal.Address[:], address)
 		withdrawals = append(withdrawals, withdrawal)
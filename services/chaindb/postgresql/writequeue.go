@@ -0,0 +1,249 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// writeQueueFlushes counts flushes of write queues.
+var writeQueueFlushes metric.Int64Counter
+
+// writeQueueWrites counts writes sent to the database by write queues.
+var writeQueueWrites metric.Int64Counter
+
+// writeQueueFlushDuration records the time taken to flush write queues.
+var writeQueueFlushDuration metric.Float64Histogram
+
+// queuedWrite is a single statement held in a write queue.
+type queuedWrite struct {
+	sql  string
+	args []any
+}
+
+// queuedTx is a transaction that holds small upserts back and sends them to
+// the database in batches.  Any other use of the transaction flushes the
+// queue first, so statements always reach the database in the order in which
+// they were issued and the queue never outlives the transaction.
+type queuedTx struct {
+	pgx.Tx
+	maxSize       int
+	flushInterval time.Duration
+	writes        []queuedWrite
+	firstQueued   time.Time
+}
+
+// newQueuedTx wraps a transaction with a write queue.
+func newQueuedTx(tx pgx.Tx, maxSize int, flushInterval time.Duration) *queuedTx {
+	return &queuedTx{
+		Tx:            tx,
+		maxSize:       maxSize,
+		flushInterval: flushInterval,
+		writes:        make([]queuedWrite, 0, maxSize),
+	}
+}
+
+// queue adds a write to the queue, flushing it if it is full or if it has
+// held writes for longer than the flush interval.
+func (t *queuedTx) queue(ctx context.Context, sql string, args ...any) error {
+	if len(t.writes) == 0 {
+		t.firstQueued = time.Now()
+	}
+	t.writes = append(t.writes, queuedWrite{sql: sql, args: args})
+
+	if len(t.writes) >= t.maxSize ||
+		(t.flushInterval > 0 && time.Since(t.firstQueued) >= t.flushInterval) {
+		return t.flush(ctx)
+	}
+
+	return nil
+}
+
+// flush sends any queued writes to the database as a single batch.
+func (t *queuedTx) flush(ctx context.Context) error {
+	if len(t.writes) == 0 {
+		return nil
+	}
+	writes := t.writes
+	t.writes = t.writes[:0]
+
+	batch := &pgx.Batch{}
+	for _, write := range writes {
+		batch.Queue(write.sql, write.args...)
+	}
+	started := time.Now()
+	results := t.Tx.SendBatch(ctx, batch)
+	for range writes {
+		if _, err := results.Exec(); err != nil {
+			_ = results.Close()
+			return errors.Wrap(err, "failed to execute queued write")
+		}
+	}
+	if err := results.Close(); err != nil {
+		return errors.Wrap(err, "failed to close queued write batch")
+	}
+	monitorWriteQueueFlush(ctx, len(writes), time.Since(started))
+
+	return nil
+}
+
+// Begin flushes the queue and starts a pseudo nested transaction.
+func (t *queuedTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	if err := t.flush(ctx); err != nil {
+		return nil, err
+	}
+
+	return t.Tx.Begin(ctx)
+}
+
+// Commit flushes the queue and commits the transaction.
+func (t *queuedTx) Commit(ctx context.Context) error {
+	if err := t.flush(ctx); err != nil {
+		return err
+	}
+
+	return t.Tx.Commit(ctx)
+}
+
+// Rollback discards the queue and rolls back the transaction.
+func (t *queuedTx) Rollback(ctx context.Context) error {
+	t.writes = t.writes[:0]
+
+	return t.Tx.Rollback(ctx)
+}
+
+// CopyFrom flushes the queue and copies rows to the database.
+func (t *queuedTx) CopyFrom(ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rowSrc pgx.CopyFromSource,
+) (
+	int64,
+	error,
+) {
+	if err := t.flush(ctx); err != nil {
+		return 0, err
+	}
+
+	return t.Tx.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+// SendBatch flushes the queue and sends the batch to the database.
+func (t *queuedTx) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	if err := t.flush(ctx); err != nil {
+		return &errBatchResults{err: err}
+	}
+
+	return t.Tx.SendBatch(ctx, b)
+}
+
+// Prepare flushes the queue and prepares a statement.
+func (t *queuedTx) Prepare(ctx context.Context, name string, sql string) (*pgconn.StatementDescription, error) {
+	if err := t.flush(ctx); err != nil {
+		return nil, err
+	}
+
+	return t.Tx.Prepare(ctx, name, sql)
+}
+
+// Exec flushes the queue and executes the statement.
+func (t *queuedTx) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	if err := t.flush(ctx); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+
+	return t.Tx.Exec(ctx, sql, arguments...)
+}
+
+// Query flushes the queue and runs the query.
+func (t *queuedTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if err := t.flush(ctx); err != nil {
+		return nil, err
+	}
+
+	return t.Tx.Query(ctx, sql, args...)
+}
+
+// QueryRow flushes the queue and runs the query.
+func (t *queuedTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if err := t.flush(ctx); err != nil {
+		return &errRow{err: err}
+	}
+
+	return t.Tx.QueryRow(ctx, sql, args...)
+}
+
+// monitorWriteQueueFlush records a flush of a write queue.
+func monitorWriteQueueFlush(ctx context.Context, writes int, duration time.Duration) {
+	if writeQueueFlushes == nil {
+		return
+	}
+
+	writeQueueFlushes.Add(ctx, 1)
+	writeQueueWrites.Add(ctx, int64(writes))
+	writeQueueFlushDuration.Record(ctx, duration.Seconds())
+}
+
+// queueExec queues a write if the transaction has a write queue, otherwise
+// executes it immediately.
+func (*Service) queueExec(ctx context.Context, tx pgx.Tx, sql string, args ...any) error {
+	if queued, isQueued := tx.(*queuedTx); isQueued {
+		return queued.queue(ctx, sql, args...)
+	}
+
+	_, err := tx.Exec(ctx, sql, args...)
+
+	return err
+}
+
+// errRow is a row that returns an error when scanned.
+type errRow struct {
+	err error
+}
+
+// Scan returns the error.
+func (r *errRow) Scan(_ ...any) error {
+	return r.err
+}
+
+// errBatchResults are batch results that return an error for every operation.
+type errBatchResults struct {
+	err error
+}
+
+// Exec returns the error.
+func (r *errBatchResults) Exec() (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, r.err
+}
+
+// Query returns the error.
+func (r *errBatchResults) Query() (pgx.Rows, error) {
+	return nil, r.err
+}
+
+// QueryRow returns a row that returns the error.
+func (r *errBatchResults) QueryRow() pgx.Row {
+	return &errRow{err: r.err}
+}
+
+// Close returns the error.
+func (r *errBatchResults) Close() error {
+	return r.err
+}
@@ -0,0 +1,172 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+// queueRecordingTx is a transaction that records the statements sent to it.
+type queueRecordingTx struct {
+	pgx.Tx
+	statements []string
+	err        error
+}
+
+func (t *queueRecordingTx) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	t.statements = append(t.statements, sql)
+
+	return pgconn.CommandTag{}, nil
+}
+
+func (t *queueRecordingTx) SendBatch(_ context.Context, b *pgx.Batch) pgx.BatchResults {
+	t.statements = append(t.statements, fmt.Sprintf("batch(%d)", b.Len()))
+
+	return &errBatchResults{err: t.err}
+}
+
+func (t *queueRecordingTx) Commit(_ context.Context) error {
+	t.statements = append(t.statements, "COMMIT")
+
+	return nil
+}
+
+func (t *queueRecordingTx) Rollback(_ context.Context) error {
+	t.statements = append(t.statements, "ROLLBACK")
+
+	return nil
+}
+
+func TestQueuedTx(t *testing.T) {
+	ctx := context.Background()
+	s := &Service{}
+
+	tests := []struct {
+		name       string
+		maxSize    int
+		interval   time.Duration
+		ops        func(ctx context.Context, tx pgx.Tx) error
+		statements []string
+	}{
+		{
+			name:    "Unqueued",
+			maxSize: 0,
+			ops: func(ctx context.Context, tx pgx.Tx) error {
+				return s.queueExec(ctx, tx, "a")
+			},
+			statements: []string{"a"},
+		},
+		{
+			name:    "HeldUntilCommit",
+			maxSize: 10,
+			ops: func(ctx context.Context, tx pgx.Tx) error {
+				if err := s.queueExec(ctx, tx, "a"); err != nil {
+					return err
+				}
+				if err := s.queueExec(ctx, tx, "b"); err != nil {
+					return err
+				}
+
+				return tx.Commit(ctx)
+			},
+			statements: []string{"batch(2)", "COMMIT"},
+		},
+		{
+			name:    "FlushedWhenFull",
+			maxSize: 2,
+			ops: func(ctx context.Context, tx pgx.Tx) error {
+				for _, sql := range []string{"a", "b", "c"} {
+					if err := s.queueExec(ctx, tx, sql); err != nil {
+						return err
+					}
+				}
+
+				return tx.Commit(ctx)
+			},
+			statements: []string{"batch(2)", "batch(1)", "COMMIT"},
+		},
+		{
+			name:     "FlushedAfterInterval",
+			maxSize:  10,
+			interval: time.Nanosecond,
+			ops: func(ctx context.Context, tx pgx.Tx) error {
+				if err := s.queueExec(ctx, tx, "a"); err != nil {
+					return err
+				}
+				time.Sleep(time.Millisecond)
+
+				return s.queueExec(ctx, tx, "b")
+			},
+			statements: []string{"batch(1)", "batch(1)"},
+		},
+		{
+			name:    "OrderKeptWithExec",
+			maxSize: 10,
+			ops: func(ctx context.Context, tx pgx.Tx) error {
+				if err := s.queueExec(ctx, tx, "a"); err != nil {
+					return err
+				}
+				if _, err := tx.Exec(ctx, "b"); err != nil {
+					return err
+				}
+
+				return s.queueExec(ctx, tx, "c")
+			},
+			statements: []string{"batch(1)", "b"},
+		},
+		{
+			name:    "DiscardedOnRollback",
+			maxSize: 10,
+			ops: func(ctx context.Context, tx pgx.Tx) error {
+				if err := s.queueExec(ctx, tx, "a"); err != nil {
+					return err
+				}
+
+				return tx.Rollback(ctx)
+			},
+			statements: []string{"ROLLBACK"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := &queueRecordingTx{}
+			var tx pgx.Tx = recorder
+			if test.maxSize > 1 {
+				tx = newQueuedTx(recorder, test.maxSize, test.interval)
+			}
+			require.NoError(t, test.ops(ctx, tx))
+			require.Equal(t, test.statements, recorder.statements)
+		})
+	}
+}
+
+func TestQueuedTxError(t *testing.T) {
+	ctx := context.Background()
+	s := &Service{}
+
+	recorder := &queueRecordingTx{err: errors.New("bad")}
+	tx := newQueuedTx(recorder, 10, 0)
+	require.NoError(t, s.queueExec(ctx, tx, "a"))
+	require.ErrorContains(t, tx.Commit(ctx), "failed to execute queued write")
+	require.NotContains(t, recorder.statements, "COMMIT")
+}
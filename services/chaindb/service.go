@@ -15,12 +15,20 @@ package chaindb
 
 import (
 	"context"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/api"
 	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 )
 
+// AttestationsStreamProvider defines functions to stream attestations.
+type AttestationsStreamProvider interface {
+	// AttestationsStream provides attestations according to the filter one at a time,
+	// in the order given by the filter.
+	AttestationsStream(ctx context.Context, filter *AttestationFilter) (Iterator[*Attestation], error)
+}
+
 // AttestationsProvider defines functions to access attestations.
 type AttestationsProvider interface {
 	// Attestations obtains attestations matching the supplied filter.
@@ -33,6 +41,7 @@ type AttestationsProvider interface {
 	AttestationsInBlock(ctx context.Context, blockRoot phase0.Root) ([]*Attestation, error)
 
 	// AttestationsForSlotRange fetches all attestations made for the given slot range.
+	// For large ranges use Attestations with a limit and cursor.
 	// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 	// attestations for slots 2 and 3.
 	AttestationsForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*Attestation, error)
@@ -42,10 +51,23 @@ type AttestationsProvider interface {
 	// attestations in slots 2 and 3.
 	AttestationsInSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*Attestation, error)
 
+	// AttestationsForValidator fetches all attestations that include the given validator for the given epoch range.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+	// attestations for epochs 2 and 3.
+	// Attestations are found through the validator's beacon committees, so beacon committees must be stored.
+	AttestationsForValidator(ctx context.Context, index phase0.ValidatorIndex, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]*Attestation, error)
+
 	// IndeterminateAttestationSlots fetches the slots in the given range with attestations that do not have a canonical status.
 	IndeterminateAttestationSlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error)
 }
 
+// AttestingIndicesProvider defines functions to expand attestation aggregation bits.
+type AttestingIndicesProvider interface {
+	// AttestingIndices expands the aggregation bits of the attestation to validator indices
+	// using the stored beacon committee.
+	AttestingIndices(ctx context.Context, attestation *Attestation) ([]phase0.ValidatorIndex, error)
+}
+
 // AttestationsSetter defines functions to create and update attestations.
 type AttestationsSetter interface {
 	// SetAttestation sets an attestation.
@@ -55,8 +77,17 @@ type AttestationsSetter interface {
 	SetAttestations(ctx context.Context, attestations []*Attestation) error
 }
 
+// AttestationsPruner defines functions to prune attestations.
+type AttestationsPruner interface {
+	// PruneAttestations prunes attestations included before the given slot.
+	PruneAttestations(ctx context.Context, to phase0.Slot) error
+}
+
 // AttesterSlashingsProvider defines functions to obtain attester slashings.
 type AttesterSlashingsProvider interface {
+	// AttesterSlashings provides attester slashings according to the filter.
+	AttesterSlashings(ctx context.Context, filter *AttesterSlashingFilter) ([]*AttesterSlashing, error)
+
 	// AttesterSlashingsForSlotRange fetches all attester slashings made for the given slot range.
 	// It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
 	AttesterSlashingsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*AttesterSlashing, error)
@@ -85,12 +116,32 @@ type BeaconCommitteesProvider interface {
 	AttesterDuties(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot, validatorIndices []phase0.ValidatorIndex) ([]*AttesterDuty, error)
 }
 
+// BeaconCommitteeMembersProvider defines functions to access beacon committees one member at a time.
+type BeaconCommitteeMembersProvider interface {
+	// BeaconCommitteeMembers fetches the members of the beacon committees matching the filter,
+	// with one entry per validator in each committee.  The limit of the filter applies to members.
+	BeaconCommitteeMembers(ctx context.Context, filter *BeaconCommitteeFilter) ([]*AttesterDuty, error)
+}
+
 // BeaconCommitteesSetter defines functions to create and update beacon committee information.
 type BeaconCommitteesSetter interface {
 	// SetBeaconCommittee sets a beacon committee.
 	SetBeaconCommittee(ctx context.Context, beaconCommittee *BeaconCommittee) error
 }
 
+// BeaconCommitteesPruner defines functions to prune beacon committees.
+type BeaconCommitteesPruner interface {
+	// PruneBeaconCommittees prunes beacon committees for slots before the given slot.
+	PruneBeaconCommittees(ctx context.Context, to phase0.Slot) error
+}
+
+// BlocksStreamProvider defines functions to stream blocks.
+type BlocksStreamProvider interface {
+	// BlocksStream provides blocks according to the filter one at a time,
+	// in the order given by the filter.
+	BlocksStream(ctx context.Context, filter *BlockFilter) (Iterator[*Block], error)
+}
+
 // BlocksProvider defines functions to access blocks.
 type BlocksProvider interface {
 	// Blocks provides blocks according to the filter.
@@ -100,6 +151,7 @@ type BlocksProvider interface {
 	BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*Block, error)
 
 	// BlocksForSlotRange fetches all blocks with the given slot range.
+	// For large ranges use Blocks with a limit and cursor.
 	// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 	// blocks duties for slots 2 and 3.
 	BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*Block, error)
@@ -129,12 +181,34 @@ type BlocksProvider interface {
 	LatestCanonicalBlock(ctx context.Context) (phase0.Slot, error)
 }
 
+// GraffitiProvider defines functions to analyse the graffiti of blocks.
+type GraffitiProvider interface {
+	// GraffitiFrequencies provides the number of canonical blocks with each graffiti according to the filter.
+	GraffitiFrequencies(ctx context.Context, filter *GraffitiFrequencyFilter) ([]*GraffitiFrequency, error)
+}
+
 // BlocksSetter defines functions to create and update blocks.
 type BlocksSetter interface {
 	// SetBlock sets a block.
 	SetBlock(ctx context.Context, block *Block) error
 }
 
+// CanonicalBlocksSetter defines functions to set the canonical state of ranges of blocks.
+type CanonicalBlocksSetter interface {
+	// ChainHeaders provides the headers of the chain of blocks held in the database that ends
+	// with the block with the given root, back to and including minSlot, in slot order.
+	// It returns an empty list if the block with the given root is not held in the database.
+	ChainHeaders(ctx context.Context, root phase0.Root, minSlot phase0.Slot) ([]*BlockHeader, error)
+
+	// SetBlocksCanonical marks the blocks with the given roots as canonical, along with their contents.
+	SetBlocksCanonical(ctx context.Context, roots []phase0.Root) error
+
+	// SetIndeterminateBlocksCanonical sets the canonical state of blocks from minSlot up to but
+	// not including maxSlot that do not have one, along with their contents.  Blocks with a
+	// canonical child are canonical, all others are non-canonical.
+	SetIndeterminateBlocksCanonical(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error
+}
+
 // BlobSidecarsProvider defines functions to obtain blob sidecars.
 type BlobSidecarsProvider interface {
 	// BlobSidecars provides blob sidecars according to the filter.
@@ -152,8 +226,8 @@ type BlobSidecarsSetter interface {
 
 // ChainSpecProvider defines functions to access chain specification.
 type ChainSpecProvider interface {
-	// ChainSpec fetches all chain specification values.
-	ChainSpec(ctx context.Context) (map[string]any, error)
+	// ChainSpec fetches the chain specification.
+	ChainSpec(ctx context.Context) (*Spec, error)
 
 	// ChainSpecValue fetches a chain specification value given its key.
 	ChainSpecValue(ctx context.Context, key string) (any, error)
@@ -169,12 +243,18 @@ type ChainSpecSetter interface {
 type ForkScheduleProvider interface {
 	// ForkSchedule provides details of past and future changes in the chain's fork version.
 	ForkSchedule(ctx context.Context, opts *api.ForkScheduleOpts) (*api.Response[[]*phase0.Fork], error)
+
+	// Forks provides the scheduled forks of the chain, in the order in which they take place.
+	Forks(ctx context.Context) ([]*Fork, error)
 }
 
 // ForkScheduleSetter defines functions to create and update fork schedule information.
 type ForkScheduleSetter interface {
 	// SetForkSchedule sets the fork schedule.
 	SetForkSchedule(ctx context.Context, schedule []*phase0.Fork) error
+
+	// SetForks sets the scheduled forks of the chain.
+	SetForks(ctx context.Context, forks []*Fork) error
 }
 
 // GenesisProvider defines functions to access genesis information.
@@ -193,6 +273,9 @@ type GenesisSetter interface {
 type ETH1DepositsProvider interface {
 	// ETH1DepositsByPublicKey fetches Ethereum 1 deposits for a given set of validator public keys.
 	ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) ([]*ETH1Deposit, error)
+
+	// ETH1Deposits provides Ethereum 1 deposits according to the filter.
+	ETH1Deposits(ctx context.Context, filter *ETH1DepositFilter) ([]*ETH1Deposit, error)
 }
 
 // ETH1DepositsSetter defines functions to create and update Ethereum 1 deposits.
@@ -201,8 +284,23 @@ type ETH1DepositsSetter interface {
 	SetETH1Deposit(ctx context.Context, deposit *ETH1Deposit) error
 }
 
+// ETH1DepositDiscrepanciesProvider defines functions to access Ethereum 1 deposit discrepancies.
+type ETH1DepositDiscrepanciesProvider interface {
+	// ETH1DepositDiscrepancies provides Ethereum 1 deposit discrepancies according to the filter.
+	ETH1DepositDiscrepancies(ctx context.Context, filter *ETH1DepositDiscrepancyFilter) ([]*ETH1DepositDiscrepancy, error)
+}
+
+// ETH1DepositDiscrepanciesSetter defines functions to create Ethereum 1 deposit discrepancies.
+type ETH1DepositDiscrepanciesSetter interface {
+	// SetETH1DepositDiscrepancy sets an Ethereum 1 deposit discrepancy.
+	SetETH1DepositDiscrepancy(ctx context.Context, discrepancy *ETH1DepositDiscrepancy) error
+}
+
 // ProposerDutiesProvider defines functions to access proposer duties.
 type ProposerDutiesProvider interface {
+	// ProposerDuties provides proposer duties according to the filter.
+	ProposerDuties(ctx context.Context, filter *ProposerDutyFilter) ([]*ProposerDuty, error)
+
 	// ProposerDutiesForSlotRange fetches all proposer duties for the given slot range.
 	// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 	// proposer duties for slots 2 and 3.
@@ -220,6 +318,9 @@ type ProposerDutiesSetter interface {
 
 // ProposerSlashingsProvider defines functions to access proposer slashings.
 type ProposerSlashingsProvider interface {
+	// ProposerSlashings provides proposer slashings according to the filter.
+	ProposerSlashings(ctx context.Context, filter *ProposerSlashingFilter) ([]*ProposerSlashing, error)
+
 	// ProposerSlashingsForSlotRange fetches all proposer slashings made for the given slot range.
 	// It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
 	ProposerSlashingsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*ProposerSlashing, error)
@@ -241,17 +342,51 @@ type SyncAggregateProvider interface {
 	SyncAggregates(ctx context.Context, filter *SyncAggregateFilter) ([]*SyncAggregate, error)
 }
 
+// SyncParticipationProvider defines functions to access sync committee participation.
+type SyncParticipationProvider interface {
+	// SyncParticipation provides the participation of the sync committee in
+	// the sync aggregates that match the filter.
+	SyncParticipation(ctx context.Context, filter *SyncAggregateFilter) ([]*SyncParticipation, error)
+
+	// SyncCommitteeParticipants provides the participation of each member of
+	// the sync committee in the sync aggregate of the given block.
+	SyncCommitteeParticipants(ctx context.Context, blockRoot phase0.Root) ([]*SyncCommitteeParticipant, error)
+}
+
 // SyncAggregateSetter defines functions to create and update fork schedule information.
 type SyncAggregateSetter interface {
 	// SetSyncAggregate sets the sync aggregate.
 	SetSyncAggregate(ctx context.Context, syncAggregate *SyncAggregate) error
 }
 
+// SyncAggregatePruner defines functions to prune sync aggregates.
+type SyncAggregatePruner interface {
+	// PruneSyncAggregates prunes sync aggregates included before the given slot.
+	PruneSyncAggregates(ctx context.Context, to phase0.Slot) error
+}
+
+// ValidatorIndicesProvider defines functions to resolve validator public keys to indices.
+type ValidatorIndicesProvider interface {
+	// ValidatorIndices fetches the indices of the validators with the given public keys.
+	// Public keys of unknown validators are omitted from the result.
+	ValidatorIndices(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey]phase0.ValidatorIndex, error)
+}
+
+// ValidatorsStreamProvider defines functions to stream validators.
+type ValidatorsStreamProvider interface {
+	// ValidatorsStream provides validators according to the filter one at a time,
+	// in the order given by the filter.
+	ValidatorsStream(ctx context.Context, filter *ValidatorFilter) (Iterator[*Validator], error)
+}
+
 // ValidatorsProvider defines functions to access validator information.
 type ValidatorsProvider interface {
 	// Validators fetches all validators.
 	Validators(ctx context.Context) ([]*Validator, error)
 
+	// ValidatorsByFilter fetches validators according to the filter.
+	ValidatorsByFilter(ctx context.Context, filter *ValidatorFilter) ([]*Validator, error)
+
 	// ValidatorsByPublicKey fetches all validators matching the given public keys.
 	// This is a common starting point for external entities to query specific validators, as they should
 	// always have the public key at a minimum, hence the return map keyed by public key.
@@ -346,6 +481,13 @@ type ValidatorBalancesPruner interface {
 	PruneValidatorBalances(ctx context.Context, to phase0.Epoch, retain []phase0.ValidatorIndex) error
 }
 
+// ValidatorBalancesArchiver defines functions to archive validator balances.
+type ValidatorBalancesArchiver interface {
+	// RemoveValidatorBalancesByEpoch removes the validator balances for the given epoch,
+	// returning the balances that were removed.
+	RemoveValidatorBalancesByEpoch(ctx context.Context, epoch phase0.Epoch) ([]*ValidatorBalance, error)
+}
+
 // ValidatorsSetter defines functions to create and update validator information.
 type ValidatorsSetter interface {
 	// SetValidator sets a validator.
@@ -358,8 +500,149 @@ type ValidatorsSetter interface {
 	SetValidatorBalances(ctx context.Context, validatorBalances []*ValidatorBalance) error
 }
 
+// ValidatorCredentialsProvider defines functions to access validator credentials history.
+type ValidatorCredentialsProvider interface {
+	// ValidatorCredentialsChanges provides credentials changes according to the filter.
+	ValidatorCredentialsChanges(ctx context.Context, filter *ValidatorCredentialsChangeFilter) ([]*ValidatorCredentialsChange, error)
+
+	// ValidatorEffectiveBalanceCeilingChanges provides the credentials changes according to the filter that
+	// altered the effective balance ceiling of their validator.  The first credentials for a validator are
+	// always considered to be a change.
+	ValidatorEffectiveBalanceCeilingChanges(ctx context.Context, filter *ValidatorCredentialsChangeFilter) ([]*ValidatorCredentialsChange, error)
+}
+
+// ValidatorCredentialsSetter defines functions to create and update validator credentials history.
+type ValidatorCredentialsSetter interface {
+	// SetValidatorCredentialsChange sets a validator credentials change.
+	SetValidatorCredentialsChange(ctx context.Context, change *ValidatorCredentialsChange) error
+}
+
+// ValidatorShardsProvider defines functions to access validator shard progress.
+type ValidatorShardsProvider interface {
+	// ValidatorShards provides the progress of all validator shards, ordered by first validator index.
+	ValidatorShards(ctx context.Context) ([]*ValidatorShard, error)
+}
+
+// ValidatorShardsSetter defines functions to create and update validator shard progress.
+type ValidatorShardsSetter interface {
+	// SetValidatorShard sets the progress of a validator shard.
+	SetValidatorShard(ctx context.Context, shard *ValidatorShard) error
+}
+
+// ValidatorSetDiffProvider defines functions to compare the validator set between epochs.
+type ValidatorSetDiffProvider interface {
+	// ValidatorSetDiff provides the changes to the validator set after fromEpoch, up to and including toEpoch.
+	ValidatorSetDiff(ctx context.Context, fromEpoch phase0.Epoch, toEpoch phase0.Epoch) (*ValidatorSetDiff, error)
+}
+
+// ValidatorConsolidationsProvider defines functions to access validator consolidations.
+type ValidatorConsolidationsProvider interface {
+	// ValidatorConsolidations provides consolidations according to the filter.
+	ValidatorConsolidations(ctx context.Context, filter *ValidatorConsolidationFilter) ([]*ValidatorConsolidation, error)
+}
+
+// ValidatorConsolidationsSetter defines functions to create and update validator consolidations.
+type ValidatorConsolidationsSetter interface {
+	// SetValidatorConsolidation sets a validator consolidation.
+	SetValidatorConsolidation(ctx context.Context, consolidation *ValidatorConsolidation) error
+}
+
+// ArchiveOffloadsProvider defines functions to access archive offloads.
+type ArchiveOffloadsProvider interface {
+	// ArchiveOffloads provides archive offloads according to the filter.
+	ArchiveOffloads(ctx context.Context, filter *ArchiveOffloadFilter) ([]*ArchiveOffload, error)
+}
+
+// ArchiveOffloadsSetter defines functions to create and update archive offloads.
+type ArchiveOffloadsSetter interface {
+	// SetArchiveOffload sets an archive offload.
+	SetArchiveOffload(ctx context.Context, offload *ArchiveOffload) error
+}
+
+// BlockClientFingerprintsProvider defines functions to access block client fingerprints.
+type BlockClientFingerprintsProvider interface {
+	// BlockClientFingerprints provides block client fingerprints according to the filter.
+	BlockClientFingerprints(ctx context.Context, filter *BlockClientFingerprintFilter) ([]*BlockClientFingerprint, error)
+}
+
+// BlockClientFingerprintsSetter defines functions to create and update block client fingerprints.
+type BlockClientFingerprintsSetter interface {
+	// SetBlockClientFingerprints sets block client fingerprints.
+	SetBlockClientFingerprints(ctx context.Context, fingerprints []*BlockClientFingerprint) error
+}
+
+// ClientReleasesProvider defines functions to access client releases.
+type ClientReleasesProvider interface {
+	// ClientReleases provides client releases according to the filter.
+	ClientReleases(ctx context.Context, filter *ClientReleaseFilter) ([]*ClientRelease, error)
+}
+
+// ClientReleasesSetter defines functions to create and update client releases.
+type ClientReleasesSetter interface {
+	// SetClientReleases sets client releases, replacing any existing releases.
+	SetClientReleases(ctx context.Context, releases []*ClientRelease) error
+}
+
+// BlockClientReleasesProvider defines functions to access block client releases.
+type BlockClientReleasesProvider interface {
+	// BlockClientReleases provides block client releases according to the filter.
+	BlockClientReleases(ctx context.Context, filter *BlockClientReleaseFilter) ([]*BlockClientRelease, error)
+}
+
+// BlockClientReleasesSetter defines functions to create and update block client releases.
+type BlockClientReleasesSetter interface {
+	// SetBlockClientReleases sets block client releases.
+	SetBlockClientReleases(ctx context.Context, releases []*BlockClientRelease) error
+}
+
+// ArrivalsProvider defines functions to access block and attestation arrivals.
+type ArrivalsProvider interface {
+	// BlockArrivals provides block arrivals according to the filter.
+	BlockArrivals(ctx context.Context, filter *ArrivalFilter) ([]*BlockArrival, error)
+
+	// AttestationArrivals provides attestation arrivals according to the filter.
+	AttestationArrivals(ctx context.Context, filter *ArrivalFilter) ([]*AttestationArrival, error)
+}
+
+// ArrivalsSetter defines functions to create block and attestation arrivals.
+type ArrivalsSetter interface {
+	// SetBlockArrivals sets block arrivals.
+	// The earliest arrival for each block is retained, as it holds the first-seen time.
+	SetBlockArrivals(ctx context.Context, arrivals []*BlockArrival) error
+
+	// SetAttestationArrivals sets attestation arrivals.
+	// Existing arrivals are retained, as they hold the first-seen time.
+	SetAttestationArrivals(ctx context.Context, arrivals []*AttestationArrival) error
+}
+
+// SecondaryIndexManager defines functions to manage secondary indexes.
+// Secondary indexes speed up queries, but are not required for data to be indexed.
+type SecondaryIndexManager interface {
+	// DropSecondaryIndexes drops secondary indexes.
+	DropSecondaryIndexes(ctx context.Context) error
+
+	// CreateSecondaryIndexes creates secondary indexes that are not present.
+	CreateSecondaryIndexes(ctx context.Context) error
+}
+
+// TableMaintainer defines functions to maintain tables.
+type TableMaintainer interface {
+	// TableMaintenanceStats provides statistics for chaind's tables.
+	TableMaintenanceStats(ctx context.Context) ([]*TableMaintenanceStats, error)
+
+	// VacuumTable vacuums the table, reclaiming space held by dead tuples,
+	// and analyzes it if requested.
+	VacuumTable(ctx context.Context, table string, analyze bool) error
+
+	// AnalyzeTable updates the planner statistics for the table.
+	AnalyzeTable(ctx context.Context, table string) error
+}
+
 // DepositsProvider defines functions to access deposits.
 type DepositsProvider interface {
+	// Deposits provides deposits according to the filter.
+	Deposits(ctx context.Context, filter *DepositFilter) ([]*Deposit, error)
+
 	// DepositsByPublicKey fetches deposits for a given set of validator public keys.
 	DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey][]*Deposit, error)
 
@@ -374,6 +657,16 @@ type DepositsSetter interface {
 	SetDeposit(ctx context.Context, deposit *Deposit) error
 }
 
+// VoluntaryExitsProvider defines functions to access voluntary exits.
+type VoluntaryExitsProvider interface {
+	// VoluntaryExits provides voluntary exits according to the filter.
+	VoluntaryExits(ctx context.Context, filter *VoluntaryExitFilter) ([]*VoluntaryExit, error)
+
+	// VoluntaryExitsForSlotRange fetches all voluntary exits included in the given slot range.
+	// It will return voluntary exits from blocks that are canonical or undefined, but not from non-canonical blocks.
+	VoluntaryExitsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*VoluntaryExit, error)
+}
+
 // VoluntaryExitsSetter defines functions to create and update voluntary exits.
 type VoluntaryExitsSetter interface {
 	// SetVoluntaryExit sets a voluntary exit.
@@ -386,6 +679,15 @@ type ValidatorDaySummariesProvider interface {
 	ValidatorDaySummaries(ctx context.Context, filter *ValidatorDaySummaryFilter) ([]*ValidatorDaySummary, error)
 }
 
+// ValidatorAPRsProvider defines functions to calculate validator APRs.
+type ValidatorAPRsProvider interface {
+	// ValidatorAPRs calculates the APR of each validator matching the filter.
+	ValidatorAPRs(ctx context.Context, filter *ValidatorAPRFilter) ([]*ValidatorAPR, error)
+
+	// AggregateValidatorAPR calculates the combined APR of all validators matching the filter.
+	AggregateValidatorAPR(ctx context.Context, filter *ValidatorAPRFilter) (*ValidatorAPR, error)
+}
+
 // ValidatorDaySummariesSetter defines functions to create and update validator day summaries.
 type ValidatorDaySummariesSetter interface {
 	// SetValidatorDaySummary sets a validator day summary.
@@ -395,6 +697,18 @@ type ValidatorDaySummariesSetter interface {
 	SetValidatorDaySummaries(ctx context.Context, summaries []*ValidatorDaySummary) error
 }
 
+// ValidatorDayRankingsProvider defines functions to fetch validator day rankings.
+type ValidatorDayRankingsProvider interface {
+	// ValidatorDayRankings provides rankings according to the filter.
+	ValidatorDayRankings(ctx context.Context, filter *ValidatorDayRankingFilter) ([]*ValidatorDayRanking, error)
+}
+
+// ValidatorDayRankingsSetter defines functions to create and update validator day rankings.
+type ValidatorDayRankingsSetter interface {
+	// SetValidatorDayRankings sets multiple validator day rankings.
+	SetValidatorDayRankings(ctx context.Context, rankings []*ValidatorDayRanking) error
+}
+
 // ValidatorEpochSummariesProvider defines functions to fetch validator epoch summaries.
 type ValidatorEpochSummariesProvider interface {
 	// ValidatorSummaries provides summaries according to the filter.
@@ -434,6 +748,178 @@ type BlockSummariesSetter interface {
 	SetBlockSummary(ctx context.Context, summary *BlockSummary) error
 }
 
+// NetworkAggregatesProvider defines functions to fetch network aggregates.
+type NetworkAggregatesProvider interface {
+	// NetworkAggregates provides network aggregates according to the filter.
+	NetworkAggregates(ctx context.Context, filter *NetworkAggregateFilter) ([]*NetworkAggregate, error)
+}
+
+// NetworkAggregatesSetter defines functions to create and update network aggregates.
+type NetworkAggregatesSetter interface {
+	// SetNetworkAggregate sets a network aggregate.
+	SetNetworkAggregate(ctx context.Context, aggregate *NetworkAggregate) error
+}
+
+// EntryQueuesProvider defines functions to fetch entry queues.
+type EntryQueuesProvider interface {
+	// EntryQueues provides entry queues according to the filter.
+	EntryQueues(ctx context.Context, filter *EntryQueueFilter) ([]*EntryQueue, error)
+}
+
+// EntryQueuesSetter defines functions to create and update entry queues.
+type EntryQueuesSetter interface {
+	// SetEntryQueue sets an entry queue.
+	SetEntryQueue(ctx context.Context, entryQueue *EntryQueue) error
+}
+
+// QueueProjectionsProvider defines functions to fetch queue projections.
+type QueueProjectionsProvider interface {
+	// QueueProjections provides queue projections according to the filter.
+	QueueProjections(ctx context.Context, filter *QueueProjectionFilter) ([]*QueueProjection, error)
+
+	// QueueProjectionAccuracy provides the accuracy of activation projections made
+	// between the given epochs, for validators that activated by the given epoch.
+	QueueProjectionAccuracy(ctx context.Context, from phase0.Epoch, to phase0.Epoch, activatedBy phase0.Epoch) ([]*QueueProjectionAccuracy, error)
+}
+
+// QueueProjectionsSetter defines functions to create and update queue projections.
+type QueueProjectionsSetter interface {
+	// SetQueueProjections sets multiple queue projections.
+	// Any existing projections for the epochs covered are replaced.
+	SetQueueProjections(ctx context.Context, projections []*QueueProjection) error
+}
+
+// AuditLogProvider defines functions to fetch audit log entries.
+type AuditLogProvider interface {
+	// AuditEntries provides audit log entries according to the filter.
+	AuditEntries(ctx context.Context, filter *AuditEntryFilter) ([]*AuditEntry, error)
+}
+
+// AuditLogPruner defines functions to prune the audit log.
+type AuditLogPruner interface {
+	// PruneAuditLog prunes audit log entries written before the given time.
+	PruneAuditLog(ctx context.Context, before time.Time) error
+}
+
+// WithdrawalForecastsProvider defines functions to fetch withdrawal forecasts.
+type WithdrawalForecastsProvider interface {
+	// WithdrawalForecasts provides withdrawal forecasts according to the filter.
+	WithdrawalForecasts(ctx context.Context, filter *WithdrawalForecastFilter) ([]*WithdrawalForecast, error)
+}
+
+// WithdrawalForecastsSetter defines functions to create and update withdrawal forecasts.
+type WithdrawalForecastsSetter interface {
+	// SetWithdrawalForecasts sets multiple withdrawal forecasts.
+	// Any existing forecasts for the validators covered are replaced.
+	SetWithdrawalForecasts(ctx context.Context, forecasts []*WithdrawalForecast) error
+}
+
+// ValidatorAnomaliesProvider defines functions to fetch validator anomalies.
+type ValidatorAnomaliesProvider interface {
+	// ValidatorAnomalies provides validator anomalies according to the filter.
+	ValidatorAnomalies(ctx context.Context, filter *ValidatorAnomalyFilter) ([]*ValidatorAnomaly, error)
+}
+
+// BlobFeesProvider defines functions to fetch blob fees.
+type BlobFeesProvider interface {
+	// BlobFees provides blob fees according to the filter.
+	BlobFees(ctx context.Context, filter *BlobFeeFilter) ([]*BlobFee, error)
+}
+
+// BlobFeesSetter defines functions to create and update blob fees.
+type BlobFeesSetter interface {
+	// SetBlobFees sets multiple blob fees.
+	SetBlobFees(ctx context.Context, fees []*BlobFee) error
+}
+
+// BlockHeadersProvider defines functions to fetch block headers.
+type BlockHeadersProvider interface {
+	// BlockHeaders provides block headers according to the filter.
+	BlockHeaders(ctx context.Context, filter *BlockHeaderFilter) ([]*BlockHeader, error)
+}
+
+// BlockHeadersSetter defines functions to create and update block headers.
+type BlockHeadersSetter interface {
+	// SetBlockHeaders sets multiple block headers.
+	SetBlockHeaders(ctx context.Context, headers []*BlockHeader) error
+}
+
+// BalanceAnomaliesProvider defines functions to fetch balance anomalies.
+type BalanceAnomaliesProvider interface {
+	// BalanceAnomalies provides balance anomalies according to the filter.
+	BalanceAnomalies(ctx context.Context, filter *BalanceAnomalyFilter) ([]*BalanceAnomaly, error)
+}
+
+// BalanceAnomaliesSetter defines functions to create and update balance anomalies.
+type BalanceAnomaliesSetter interface {
+	// SetBalanceAnomalies sets multiple balance anomalies.
+	SetBalanceAnomalies(ctx context.Context, anomalies []*BalanceAnomaly) error
+}
+
+// ValidatorClustersProvider defines functions to access validator clusters.
+type ValidatorClustersProvider interface {
+	// ValidatorClusters provides validator clusters according to the filter.
+	ValidatorClusters(ctx context.Context, filter *ValidatorClusterFilter) ([]*ValidatorCluster, error)
+
+	// ValidatorClusterLinks provides the items that link the given validators to their clusters.
+	ValidatorClusterLinks(ctx context.Context, validatorIndices []phase0.ValidatorIndex) ([]*ValidatorClusterLink, error)
+
+	// ValidatorClusterSizes provides the distribution of cluster sizes, in increasing order of size.
+	ValidatorClusterSizes(ctx context.Context) ([]*ValidatorClusterSize, error)
+}
+
+// ValidatorClustersSetter defines functions to create and update validator clusters.
+type ValidatorClustersSetter interface {
+	// SetValidatorClusterLinks sets validator cluster links, merging the
+	// clusters of validators that they link.
+	SetValidatorClusterLinks(ctx context.Context, links []*ValidatorClusterLink) error
+}
+
+// FailedItemsProvider defines functions to access failed items.
+type FailedItemsProvider interface {
+	// FailedItems provides failed items according to the filter.
+	FailedItems(ctx context.Context, filter *FailedItemFilter) ([]*FailedItem, error)
+}
+
+// FailedItemsSetter defines functions to create, update and remove failed items.
+type FailedItemsSetter interface {
+	// RecordFailedItem records a failure to process an item, incrementing
+	// its attempts.  The item is dead once it has failed maxAttempts times,
+	// or if it fails when being retried.
+	// Returns the item as recorded.
+	RecordFailedItem(ctx context.Context, item *FailedItem, maxAttempts uint32) (*FailedItem, error)
+
+	// SetFailedItemState sets the state of a failed item.
+	SetFailedItemState(ctx context.Context, module string, kind string, item uint64, state string) error
+
+	// RemoveFailedItem removes a failed item, for example once it has been processed.
+	RemoveFailedItem(ctx context.Context, module string, kind string, item uint64) error
+}
+
+// HeadObservationsProvider defines functions to fetch head observations.
+type HeadObservationsProvider interface {
+	// HeadObservations provides head observations according to the filter.
+	HeadObservations(ctx context.Context, filter *HeadObservationFilter) ([]*HeadObservation, error)
+}
+
+// HeadObservationsSetter defines functions to create and update head observations.
+type HeadObservationsSetter interface {
+	// SetHeadObservation sets a head observation.
+	SetHeadObservation(ctx context.Context, observation *HeadObservation) error
+}
+
+// EquivocationsProvider defines functions to fetch equivocations.
+type EquivocationsProvider interface {
+	// Equivocations provides equivocations according to the filter.
+	Equivocations(ctx context.Context, filter *EquivocationFilter) ([]*Equivocation, error)
+}
+
+// EquivocationsSetter defines functions to create and update equivocations.
+type EquivocationsSetter interface {
+	// SetEquivocations sets equivocations.
+	SetEquivocations(ctx context.Context, equivocations []*Equivocation) error
+}
+
 // EpochSummariesProvider defines functions to fetch epoch summaries.
 type EpochSummariesProvider interface {
 	// EpochSummaries provides summaries according to the filter.
@@ -446,6 +932,88 @@ type EpochSummariesSetter interface {
 	SetEpochSummary(ctx context.Context, summary *EpochSummary) error
 }
 
+// CommitteeEpochSummariesProvider defines functions to fetch committee epoch summaries.
+type CommitteeEpochSummariesProvider interface {
+	// CommitteeEpochSummaries provides summaries according to the filter.
+	CommitteeEpochSummaries(ctx context.Context, filter *CommitteeEpochSummaryFilter) ([]*CommitteeEpochSummary, error)
+}
+
+// CommitteeEpochSummariesSetter defines functions to create and update committee epoch summaries.
+type CommitteeEpochSummariesSetter interface {
+	// SetCommitteeEpochSummaries sets multiple committee epoch summaries.
+	SetCommitteeEpochSummaries(ctx context.Context, summaries []*CommitteeEpochSummary) error
+}
+
+// ValidatorSyncPeriodSummariesProvider defines functions to fetch validator sync period summaries.
+type ValidatorSyncPeriodSummariesProvider interface {
+	// ValidatorSyncPeriodSummaries provides summaries according to the filter.
+	ValidatorSyncPeriodSummaries(ctx context.Context, filter *ValidatorSyncPeriodSummaryFilter) ([]*ValidatorSyncPeriodSummary, error)
+}
+
+// ValidatorSyncPeriodSummariesSetter defines functions to create and update validator sync period summaries.
+type ValidatorSyncPeriodSummariesSetter interface {
+	// SetValidatorSyncPeriodSummaries sets multiple validator sync period summaries.
+	SetValidatorSyncPeriodSummaries(ctx context.Context, summaries []*ValidatorSyncPeriodSummary) error
+}
+
+// ProposerPeriodSummariesProvider defines functions to fetch proposer period summaries.
+type ProposerPeriodSummariesProvider interface {
+	// ProposerPeriodSummaries provides summaries according to the filter.
+	ProposerPeriodSummaries(ctx context.Context, filter *ProposerPeriodSummaryFilter) ([]*ProposerPeriodSummary, error)
+}
+
+// ProposerPeriodTotalsProvider defines functions to total proposer period summaries.
+type ProposerPeriodTotalsProvider interface {
+	// ProposerPeriodTotals provides the totals of the proposer period summaries
+	// that match the filter.  The limit and order of the filter are ignored.
+	ProposerPeriodTotals(ctx context.Context, filter *ProposerPeriodSummaryFilter) (*ProposerPeriodTotals, error)
+}
+
+// ProposerPeriodSummariesSetter defines functions to create and update proposer period summaries.
+type ProposerPeriodSummariesSetter interface {
+	// SetProposerPeriodSummaries sets multiple proposer period summaries.
+	SetProposerPeriodSummaries(ctx context.Context, summaries []*ProposerPeriodSummary) error
+}
+
+// CheckpointsProvider defines functions to fetch epoch checkpoints.
+type CheckpointsProvider interface {
+	// Checkpoints provides epoch checkpoints according to the filter.
+	Checkpoints(ctx context.Context, filter *CheckpointFilter) ([]*EpochCheckpoint, error)
+}
+
+// CheckpointsSetter defines functions to create and update epoch checkpoints.
+type CheckpointsSetter interface {
+	// SetCheckpoint sets an epoch checkpoint.
+	SetCheckpoint(ctx context.Context, checkpoint *EpochCheckpoint) error
+}
+
+// RANDAOMixesProvider defines functions to fetch RANDAO mixes.
+type RANDAOMixesProvider interface {
+	// RANDAOMixes provides RANDAO mixes according to the filter.
+	RANDAOMixes(ctx context.Context, filter *RANDAOMixFilter) ([]*RANDAOMix, error)
+}
+
+// RANDAOMixesSetter defines functions to create and update RANDAO mixes.
+type RANDAOMixesSetter interface {
+	// SetRANDAOMix sets the RANDAO mix of an epoch.
+	SetRANDAOMix(ctx context.Context, mix *RANDAOMix) error
+}
+
+// RawBlocksProvider defines functions to fetch raw blocks.
+type RawBlocksProvider interface {
+	// RawBlockByRoot fetches the raw block with the given root.
+	RawBlockByRoot(ctx context.Context, root phase0.Root) (*RawBlock, error)
+
+	// RawBlocks provides raw blocks according to the filter.
+	RawBlocks(ctx context.Context, filter *RawBlockFilter) ([]*RawBlock, error)
+}
+
+// RawBlocksSetter defines functions to create and update raw blocks.
+type RawBlocksSetter interface {
+	// SetRawBlock sets a raw block.
+	SetRawBlock(ctx context.Context, block *RawBlock) error
+}
+
 // SyncCommitteesProvider defines functions to obtain sync committee information.
 type SyncCommitteesProvider interface {
 	// SyncCommittee provides a sync committee for the given sync committee period.
@@ -470,6 +1038,138 @@ type BLSToExecutionChangesProvider interface {
 	BLSToExecutionChanges(ctx context.Context, filter *BLSToExecutionChangeFilter) ([]*BLSToExecutionChange, error)
 }
 
+// ExportRowsProvider defines functions to obtain rows of tables for export.
+type ExportRowsProvider interface {
+	// ExportRows obtains the columns of a table, and its rows for the first maxMarks distinct
+	// values of the mark column in the inclusive range [from, to].
+	// Numeric values are returned as strings.
+	ExportRows(ctx context.Context,
+		table string,
+		markColumn string,
+		from int64,
+		to int64,
+		maxMarks uint32,
+	) (
+		[]*ExportColumn,
+		[][]any,
+		error,
+	)
+}
+
+// OutboxProvider defines functions to obtain change events from the outbox.
+type OutboxProvider interface {
+	// OutboxCapturableTables provides the tables whose changes can be captured in the outbox.
+	OutboxCapturableTables(ctx context.Context) ([]string, error)
+
+	// OutboxEvents provides up to limit events from the outbox, in the order in which they were written.
+	OutboxEvents(ctx context.Context, limit uint32) ([]*OutboxEvent, error)
+}
+
+// OutboxSetter defines functions to manage the outbox.
+type OutboxSetter interface {
+	// SetOutboxCapture captures changes to the given tables in the outbox,
+	// and stops capturing changes to all other tables.
+	SetOutboxCapture(ctx context.Context, tables []string) error
+
+	// DeleteOutboxEvents deletes the events with the given IDs from the outbox.
+	DeleteOutboxEvents(ctx context.Context, ids []uint64) error
+}
+
+// WatchlistProvider defines functions to obtain watchlist information.
+type WatchlistProvider interface {
+	// WatchedValidators provides all validators on the watchlist, in index order.
+	WatchedValidators(ctx context.Context) ([]*WatchedValidator, error)
+
+	// WatchlistEvents provides watchlist events according to the filter.
+	WatchlistEvents(ctx context.Context, filter *WatchlistEventFilter) ([]*WatchlistEvent, error)
+
+	// WatchlistAlerts provides watchlist alerts according to the filter.
+	WatchlistAlerts(ctx context.Context, filter *WatchlistAlertFilter) ([]*WatchlistAlert, error)
+}
+
+// WatchlistSetter defines functions to manage the watchlist.
+type WatchlistSetter interface {
+	// SetWatchedValidator adds a validator to the watchlist, or updates it if already present.
+	SetWatchedValidator(ctx context.Context, validator *WatchedValidator) error
+
+	// RemoveWatchedValidator removes a validator from the watchlist, along with its events and alerts.
+	RemoveWatchedValidator(ctx context.Context, index phase0.ValidatorIndex) error
+
+	// SetWatchlistEvents sets watchlist events.
+	// Events that already exist are left unchanged.
+	SetWatchlistEvents(ctx context.Context, events []*WatchlistEvent) error
+
+	// SetWatchlistAlerts sets watchlist alerts.
+	// Alerts that already exist are left unchanged.
+	SetWatchlistAlerts(ctx context.Context, alerts []*WatchlistAlert) error
+}
+
+// VerificationDisagreementsProvider defines functions to obtain verification disagreements.
+type VerificationDisagreementsProvider interface {
+	// VerificationDisagreements provides verification disagreements according to the filter.
+	VerificationDisagreements(ctx context.Context, filter *VerificationDisagreementFilter) ([]*VerificationDisagreement, error)
+}
+
+// VerificationDisagreementsSetter defines functions to create and update verification disagreements.
+type VerificationDisagreementsSetter interface {
+	// SetVerificationDisagreement sets a verification disagreement.
+	// An existing disagreement for the same slot is replaced.
+	SetVerificationDisagreement(ctx context.Context, disagreement *VerificationDisagreement) error
+}
+
+// ShufflingMismatchesProvider defines functions to obtain shuffling mismatches.
+type ShufflingMismatchesProvider interface {
+	// ShufflingMismatches provides shuffling mismatches according to the filter.
+	ShufflingMismatches(ctx context.Context, filter *ShufflingMismatchFilter) ([]*ShufflingMismatch, error)
+}
+
+// ShufflingMismatchesSetter defines functions to create and update shuffling mismatches.
+type ShufflingMismatchesSetter interface {
+	// SetShufflingMismatches sets shuffling mismatches.
+	// An existing mismatch for the same slot and index is replaced.
+	SetShufflingMismatches(ctx context.Context, mismatches []*ShufflingMismatch) error
+}
+
+// TransactionReceiptsProvider defines functions to obtain transaction receipts and events.
+type TransactionReceiptsProvider interface {
+	// TransactionReceipts provides transaction receipts according to the filter.
+	TransactionReceipts(ctx context.Context, filter *TransactionReceiptFilter) ([]*TransactionReceipt, error)
+
+	// TransactionEvents provides transaction events according to the filter.
+	TransactionEvents(ctx context.Context, filter *TransactionEventFilter) ([]*TransactionEvent, error)
+}
+
+// TransactionReceiptsSetter defines functions to create and update transaction receipts and events.
+type TransactionReceiptsSetter interface {
+	// SetTransactionReceipts sets transaction receipts.
+	SetTransactionReceipts(ctx context.Context, receipts []*TransactionReceipt) error
+
+	// SetTransactionEvents sets transaction events.
+	SetTransactionEvents(ctx context.Context, events []*TransactionEvent) error
+}
+
+// ExecutionPayloadValuesSetter defines functions to set the values of execution payloads.
+type ExecutionPayloadValuesSetter interface {
+	// SetExecutionPayloadValues sets the values of execution payloads.
+	SetExecutionPayloadValues(ctx context.Context, values []*ExecutionPayloadValue) error
+}
+
+// AddressLabelsProvider defines functions to obtain address labels.
+type AddressLabelsProvider interface {
+	// AddressLabels provides address labels according to the filter.
+	AddressLabels(ctx context.Context, filter *AddressLabelFilter) ([]*AddressLabel, error)
+}
+
+// AddressLabelsSetter defines functions to create, update and remove address labels.
+type AddressLabelsSetter interface {
+	// SetAddressLabel sets the label for an address.
+	// An existing label for the address is replaced.
+	SetAddressLabel(ctx context.Context, label *AddressLabel) error
+
+	// RemoveAddressLabel removes the label for an address.
+	RemoveAddressLabel(ctx context.Context, address [20]byte) error
+}
+
 // Service defines a minimal chain database service.
 type Service interface {
 	// BeginTx begins a transaction.
@@ -485,9 +1185,41 @@ type Service interface {
 	// CommitROTx commits a read-only transaction.
 	CommitROTx(ctx context.Context)
 
-	// SetMetadata sets a metadata key to a JSON value.
-	SetMetadata(ctx context.Context, key string, value []byte) error
+	// SetProgress sets a progress value for a service.
+	SetProgress(ctx context.Context, service string, key string, value int64) error
+
+	// SetProgressGaps sets the gaps in progress for a service, replacing any existing gaps for the key.
+	SetProgressGaps(ctx context.Context, service string, key string, gaps []int64) error
+
+	// Progress obtains the progress for a service.
+	// Returns nil if no progress has been recorded for the service.
+	Progress(ctx context.Context, service string) (*Progress, error)
+}
+
+// SessionLocker defines functions to obtain locks that are held for the duration of a database session.
+type SessionLocker interface {
+	// TrySessionLock attempts to obtain the named lock without waiting.
+	// Returns nil if the lock is held elsewhere.
+	TrySessionLock(ctx context.Context, name string) (SessionLock, error)
+}
 
+// SessionLock is a lock held for the duration of a database session.
+type SessionLock interface {
+	// Check returns an error if the session holding the lock has been lost.
+	Check(ctx context.Context) error
+
+	// Release releases the lock.
+	Release(ctx context.Context) error
+}
+
+// MetadataProvider defines functions to access free-form metadata.
+type MetadataProvider interface {
 	// Metadata obtains the JSON value from a metadata key.
 	Metadata(ctx context.Context, key string) ([]byte, error)
 }
+
+// MetadataSetter defines functions to create and update free-form metadata.
+type MetadataSetter interface {
+	// SetMetadata sets a metadata key to a JSON value.
+	SetMetadata(ctx context.Context, key string, value []byte) error
+}
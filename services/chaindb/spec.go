@@ -0,0 +1,269 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindb
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// Spec holds the chain specification, with commonly used values typed.
+// Typed values that are not present in the chain specification, or that
+// cannot be converted to their type, are zero; the original value remains
+// available in Values.
+type Spec struct {
+	SlotsPerEpoch                    uint64
+	SecondsPerSlot                   time.Duration
+	EpochsPerSyncCommitteePeriod     uint64
+	SyncCommitteeSize                uint64
+	MaxEffectiveBalance              phase0.Gwei
+	MaxEffectiveBalanceElectra       phase0.Gwei
+	EffectiveBalanceIncrement        phase0.Gwei
+	EjectionBalance                  phase0.Gwei
+	MinPerEpochChurnLimit            uint64
+	MaxPerEpochActivationChurnLimit  uint64
+	ChurnLimitQuotient               uint64
+	MaxSeedLookahead                 uint64
+	EpochsPerSlashingsVector         uint64
+	MinAttestationInclusionDelay     uint64
+	BaseRewardFactor                 uint64
+	SyncRewardWeight                 uint64
+	WeightDenominator                uint64
+	MaxWithdrawalsPerPayload         uint64
+	MaxValidatorsPerWithdrawalsSweep uint64
+	DepositChainID                   uint64
+	GenesisForkVersion               phase0.Version
+	DepositContractAddress           []byte
+	DomainBeaconProposer             phase0.DomainType
+	DomainBeaconAttester             phase0.DomainType
+	DomainRandao                     phase0.DomainType
+	DomainDeposit                    phase0.DomainType
+	DomainVoluntaryExit              phase0.DomainType
+	DomainSelectionProof             phase0.DomainType
+	DomainAggregateAndProof          phase0.DomainType
+	DomainSyncCommittee              phase0.DomainType
+	// Values holds all values of the chain specification, keyed by name.
+	Values map[string]any
+}
+
+// NewSpec creates a typed chain specification from its values.
+// A value of an unexpected type leaves its typed field zero rather than
+// failing, as beacon nodes can add or change values that are not used here.
+func NewSpec(values map[string]any) *Spec {
+	spec := &Spec{
+		Values: values,
+	}
+
+	for key, target := range map[string]any{
+		"SLOTS_PER_EPOCH":                      &spec.SlotsPerEpoch,
+		"SECONDS_PER_SLOT":                     &spec.SecondsPerSlot,
+		"EPOCHS_PER_SYNC_COMMITTEE_PERIOD":     &spec.EpochsPerSyncCommitteePeriod,
+		"SYNC_COMMITTEE_SIZE":                  &spec.SyncCommitteeSize,
+		"MAX_EFFECTIVE_BALANCE":                &spec.MaxEffectiveBalance,
+		"MAX_EFFECTIVE_BALANCE_ELECTRA":        &spec.MaxEffectiveBalanceElectra,
+		"EFFECTIVE_BALANCE_INCREMENT":          &spec.EffectiveBalanceIncrement,
+		"EJECTION_BALANCE":                     &spec.EjectionBalance,
+		"MIN_PER_EPOCH_CHURN_LIMIT":            &spec.MinPerEpochChurnLimit,
+		"MAX_PER_EPOCH_ACTIVATION_CHURN_LIMIT": &spec.MaxPerEpochActivationChurnLimit,
+		"CHURN_LIMIT_QUOTIENT":                 &spec.ChurnLimitQuotient,
+		"MAX_SEED_LOOKAHEAD":                   &spec.MaxSeedLookahead,
+		"EPOCHS_PER_SLASHINGS_VECTOR":          &spec.EpochsPerSlashingsVector,
+		"MIN_ATTESTATION_INCLUSION_DELAY":      &spec.MinAttestationInclusionDelay,
+		"BASE_REWARD_FACTOR":                   &spec.BaseRewardFactor,
+		"SYNC_REWARD_WEIGHT":                   &spec.SyncRewardWeight,
+		"WEIGHT_DENOMINATOR":                   &spec.WeightDenominator,
+		"MAX_WITHDRAWALS_PER_PAYLOAD":          &spec.MaxWithdrawalsPerPayload,
+		"MAX_VALIDATORS_PER_WITHDRAWALS_SWEEP": &spec.MaxValidatorsPerWithdrawalsSweep,
+		"DEPOSIT_CHAIN_ID":                     &spec.DepositChainID,
+		"GENESIS_FORK_VERSION":                 &spec.GenesisForkVersion,
+		"DEPOSIT_CONTRACT_ADDRESS":             &spec.DepositContractAddress,
+		"DOMAIN_BEACON_PROPOSER":               &spec.DomainBeaconProposer,
+		"DOMAIN_BEACON_ATTESTER":               &spec.DomainBeaconAttester,
+		"DOMAIN_RANDAO":                        &spec.DomainRandao,
+		"DOMAIN_DEPOSIT":                       &spec.DomainDeposit,
+		"DOMAIN_VOLUNTARY_EXIT":                &spec.DomainVoluntaryExit,
+		"DOMAIN_SELECTION_PROOF":               &spec.DomainSelectionProof,
+		"DOMAIN_AGGREGATE_AND_PROOF":           &spec.DomainAggregateAndProof,
+		"DOMAIN_SYNC_COMMITTEE":                &spec.DomainSyncCommittee,
+	} {
+		value, exists := values[key]
+		if !exists {
+			continue
+		}
+		// The conversion only sets the target on success, so a failure leaves it zero.
+		_ = convertSpecValue(value, target)
+	}
+
+	return spec
+}
+
+// SpecValue provides the value of the given key in the chain specification
+// as the requested type.  Values are converted between the representations
+// used by beacon nodes and the database, so for example SECONDS_PER_SLOT can
+// be obtained as a time.Duration or a uint64 number of seconds regardless of
+// its source.
+func SpecValue[T any](values map[string]any, key string) (T, error) {
+	var res T
+	value, exists := values[key]
+	if !exists {
+		return res, errors.Errorf("%s not found in chain specification", key)
+	}
+	if typed, isType := value.(T); isType {
+		return typed, nil
+	}
+	if err := convertSpecValue(value, &res); err != nil {
+		return res, errors.Wrapf(err, "%s of unexpected type", key)
+	}
+
+	return res, nil
+}
+
+// convertSpecValue converts a chain specification value to the type of the target.
+func convertSpecValue(value any, target any) error {
+	switch t := target.(type) {
+	case *uint64:
+		v, err := specUint64(value)
+		if err != nil {
+			return err
+		}
+		*t = v
+	case *phase0.Slot:
+		v, err := specUint64(value)
+		if err != nil {
+			return err
+		}
+		*t = phase0.Slot(v)
+	case *phase0.Epoch:
+		v, err := specUint64(value)
+		if err != nil {
+			return err
+		}
+		*t = phase0.Epoch(v)
+	case *phase0.Gwei:
+		v, err := specUint64(value)
+		if err != nil {
+			return err
+		}
+		*t = phase0.Gwei(v)
+	case *time.Duration:
+		if v, isDuration := value.(time.Duration); isDuration {
+			*t = v
+			return nil
+		}
+		v, err := specUint64(value)
+		if err != nil {
+			return err
+		}
+		*t = time.Duration(v) * time.Second
+	case *time.Time:
+		if v, isTime := value.(time.Time); isTime {
+			*t = v
+			return nil
+		}
+		v, err := specUint64(value)
+		if err != nil {
+			return err
+		}
+		*t = time.Unix(int64(v), 0)
+	case *[]byte:
+		v, err := specBytes(value)
+		if err != nil {
+			return err
+		}
+		*t = v
+	case *phase0.Version:
+		return specFixedBytes(value, t[:])
+	case *phase0.DomainType:
+		return specFixedBytes(value, t[:])
+	case *phase0.ForkDigest:
+		return specFixedBytes(value, t[:])
+	case *phase0.Root:
+		return specFixedBytes(value, t[:])
+	case *string:
+		if v, isString := value.(string); isString {
+			*t = v
+			return nil
+		}
+		return errors.Errorf("cannot convert %T to string", value)
+	default:
+		return errors.Errorf("cannot convert %T to %T", value, target)
+	}
+
+	return nil
+}
+
+// specUint64 provides a chain specification value as a uint64.
+func specUint64(value any) (uint64, error) {
+	switch v := value.(type) {
+	case uint64:
+		return v, nil
+	case int:
+		if v < 0 {
+			return 0, errors.Errorf("negative value %d", v)
+		}
+		return uint64(v), nil
+	case phase0.Slot:
+		return uint64(v), nil
+	case phase0.Epoch:
+		return uint64(v), nil
+	case phase0.Gwei:
+		return uint64(v), nil
+	case time.Duration:
+		return uint64(v.Seconds()), nil
+	case time.Time:
+		return uint64(v.Unix()), nil
+	case *big.Int:
+		if !v.IsUint64() {
+			return 0, errors.Errorf("value %s out of range", v.String())
+		}
+		return v.Uint64(), nil
+	default:
+		return 0, errors.Errorf("cannot convert %T to integer", value)
+	}
+}
+
+// specBytes provides a chain specification value as a byte slice.
+func specBytes(value any) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case phase0.Version:
+		return v[:], nil
+	case phase0.DomainType:
+		return v[:], nil
+	case phase0.ForkDigest:
+		return v[:], nil
+	case phase0.Root:
+		return v[:], nil
+	default:
+		return nil, errors.Errorf("cannot convert %T to bytes", value)
+	}
+}
+
+// specFixedBytes copies a chain specification value in to a fixed-length array.
+func specFixedBytes(value any, target []byte) error {
+	v, err := specBytes(value)
+	if err != nil {
+		return err
+	}
+	if len(v) != len(target) {
+		return errors.Errorf("expected %d bytes, found %d", len(target), len(v))
+	}
+	copy(target, v)
+
+	return nil
+}
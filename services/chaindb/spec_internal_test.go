@@ -0,0 +1,163 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindb
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertSpecValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		target   any
+		expected any
+		err      string
+	}{
+		{
+			name:     "Uint64FromInt",
+			value:    5,
+			target:   new(uint64),
+			expected: uint64(5),
+		},
+		{
+			name:   "Uint64FromNegativeInt",
+			value:  -5,
+			target: new(uint64),
+			err:    "negative value -5",
+		},
+		{
+			name:     "Uint64FromBigInt",
+			value:    big.NewInt(12),
+			target:   new(uint64),
+			expected: uint64(12),
+		},
+		{
+			name:   "Uint64FromLargeBigInt",
+			value:  big.NewInt(0).Lsh(big.NewInt(1), 64),
+			target: new(uint64),
+			err:    "value 18446744073709551616 out of range",
+		},
+		{
+			name:     "Uint64FromDuration",
+			value:    12 * time.Second,
+			target:   new(uint64),
+			expected: uint64(12),
+		},
+		{
+			name:     "EpochFromUint64",
+			value:    uint64(256),
+			target:   new(phase0.Epoch),
+			expected: phase0.Epoch(256),
+		},
+		{
+			name:     "GweiFromUint64",
+			value:    uint64(32000000000),
+			target:   new(phase0.Gwei),
+			expected: phase0.Gwei(32000000000),
+		},
+		{
+			name:     "DurationFromUint64",
+			value:    uint64(12),
+			target:   new(time.Duration),
+			expected: 12 * time.Second,
+		},
+		{
+			name:     "TimeFromUint64",
+			value:    uint64(1606824023),
+			target:   new(time.Time),
+			expected: time.Unix(1606824023, 0),
+		},
+		{
+			name:     "BytesFromVersion",
+			value:    phase0.Version{0x01, 0x02, 0x03, 0x04},
+			target:   new([]byte),
+			expected: []byte{0x01, 0x02, 0x03, 0x04},
+		},
+		{
+			name:     "DomainTypeFromBytes",
+			value:    []byte{0x01, 0x00, 0x00, 0x00},
+			target:   new(phase0.DomainType),
+			expected: phase0.DomainType{0x01, 0x00, 0x00, 0x00},
+		},
+		{
+			name:   "DomainTypeShort",
+			value:  []byte{0x01},
+			target: new(phase0.DomainType),
+			err:    "expected 4 bytes, found 1",
+		},
+		{
+			name:   "BytesFromString",
+			value:  "0x01",
+			target: new([]byte),
+			err:    "cannot convert string to bytes",
+		},
+		{
+			name:   "StringFromUint64",
+			value:  uint64(1),
+			target: new(string),
+			err:    "cannot convert uint64 to string",
+		},
+		{
+			name:   "UnsupportedTarget",
+			value:  uint64(1),
+			target: new(int),
+			err:    "cannot convert uint64 to *int",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := convertSpecValue(test.value, test.target)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			switch target := test.target.(type) {
+			case *uint64:
+				require.Equal(t, test.expected, *target)
+			case *phase0.Epoch:
+				require.Equal(t, test.expected, *target)
+			case *phase0.Gwei:
+				require.Equal(t, test.expected, *target)
+			case *time.Duration:
+				require.Equal(t, test.expected, *target)
+			case *time.Time:
+				require.True(t, test.expected.(time.Time).Equal(*target))
+			case *[]byte:
+				require.Equal(t, test.expected, *target)
+			case *phase0.DomainType:
+				require.Equal(t, test.expected, *target)
+			default:
+				require.Fail(t, "unhandled target type")
+			}
+		})
+	}
+}
+
+func TestConvertSpecValueFailureLeavesTarget(t *testing.T) {
+	version := phase0.Version{0x01, 0x02, 0x03, 0x04}
+	require.Error(t, convertSpecValue([]byte{0xff}, &version))
+	require.Equal(t, phase0.Version{0x01, 0x02, 0x03, 0x04}, version)
+
+	value := uint64(7)
+	require.Error(t, convertSpecValue("8", &value))
+	require.Equal(t, uint64(7), value)
+}
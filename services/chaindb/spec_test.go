@@ -0,0 +1,150 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindb_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestNewSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   map[string]any
+		expected *chaindb.Spec
+	}{
+		{
+			name:     "Empty",
+			values:   map[string]any{},
+			expected: &chaindb.Spec{},
+		},
+		{
+			name: "BeaconNode",
+			values: map[string]any{
+				"SLOTS_PER_EPOCH":          uint64(32),
+				"SECONDS_PER_SLOT":         12 * time.Second,
+				"MAX_EFFECTIVE_BALANCE":    uint64(32000000000),
+				"GENESIS_FORK_VERSION":     phase0.Version{0x00, 0x00, 0x10, 0x20},
+				"DEPOSIT_CONTRACT_ADDRESS": []byte{0x01, 0x02},
+				"DOMAIN_RANDAO":            phase0.DomainType{0x02, 0x00, 0x00, 0x00},
+				"CONFIG_NAME":              "mainnet",
+			},
+			expected: &chaindb.Spec{
+				SlotsPerEpoch:          32,
+				SecondsPerSlot:         12 * time.Second,
+				MaxEffectiveBalance:    32000000000,
+				GenesisForkVersion:     phase0.Version{0x00, 0x00, 0x10, 0x20},
+				DepositContractAddress: []byte{0x01, 0x02},
+				DomainRandao:           phase0.DomainType{0x02, 0x00, 0x00, 0x00},
+			},
+		},
+		{
+			name: "Database",
+			values: map[string]any{
+				"SECONDS_PER_SLOT":     uint64(12),
+				"DEPOSIT_CHAIN_ID":     1,
+				"CHURN_LIMIT_QUOTIENT": big.NewInt(65536),
+			},
+			expected: &chaindb.Spec{
+				SecondsPerSlot:     12 * time.Second,
+				DepositChainID:     1,
+				ChurnLimitQuotient: 65536,
+			},
+		},
+		{
+			name: "Mismatches",
+			values: map[string]any{
+				"SLOTS_PER_EPOCH":           "32",
+				"MAX_EFFECTIVE_BALANCE":     big.NewInt(0).Lsh(big.NewInt(1), 64),
+				"GENESIS_FORK_VERSION":      []byte{0x00, 0x00},
+				"MIN_PER_EPOCH_CHURN_LIMIT": -4,
+				"SYNC_COMMITTEE_SIZE":       uint64(512),
+			},
+			expected: &chaindb.Spec{
+				SyncCommitteeSize: 512,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spec := chaindb.NewSpec(test.values)
+			// Values are always retained, regardless of whether they could be typed.
+			require.Equal(t, test.values, spec.Values)
+			spec.Values = nil
+			require.Equal(t, test.expected, spec)
+		})
+	}
+}
+
+func TestSpecValue(t *testing.T) {
+	values := map[string]any{
+		"SECONDS_PER_SLOT":     12 * time.Second,
+		"SLOTS_PER_EPOCH":      uint64(32),
+		"DEPOSIT_CHAIN_ID":     1,
+		"GENESIS_FORK_VERSION": phase0.Version{0x00, 0x00, 0x10, 0x20},
+		"GENESIS_TIME":         uint64(1606824023),
+		"CONFIG_NAME":          "mainnet",
+		"NEGATIVE":             -1,
+	}
+
+	seconds, err := chaindb.SpecValue[uint64](values, "SECONDS_PER_SLOT")
+	require.NoError(t, err)
+	require.Equal(t, uint64(12), seconds)
+
+	duration, err := chaindb.SpecValue[time.Duration](values, "SECONDS_PER_SLOT")
+	require.NoError(t, err)
+	require.Equal(t, 12*time.Second, duration)
+
+	slot, err := chaindb.SpecValue[phase0.Slot](values, "SLOTS_PER_EPOCH")
+	require.NoError(t, err)
+	require.Equal(t, phase0.Slot(32), slot)
+
+	chainID, err := chaindb.SpecValue[uint64](values, "DEPOSIT_CHAIN_ID")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), chainID)
+
+	version, err := chaindb.SpecValue[phase0.Version](values, "GENESIS_FORK_VERSION")
+	require.NoError(t, err)
+	require.Equal(t, phase0.Version{0x00, 0x00, 0x10, 0x20}, version)
+
+	versionBytes, err := chaindb.SpecValue[[]byte](values, "GENESIS_FORK_VERSION")
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x00, 0x00, 0x10, 0x20}, versionBytes)
+
+	genesisTime, err := chaindb.SpecValue[time.Time](values, "GENESIS_TIME")
+	require.NoError(t, err)
+	require.Equal(t, int64(1606824023), genesisTime.Unix())
+
+	name, err := chaindb.SpecValue[string](values, "CONFIG_NAME")
+	require.NoError(t, err)
+	require.Equal(t, "mainnet", name)
+
+	_, err = chaindb.SpecValue[uint64](values, "MISSING")
+	require.EqualError(t, err, "MISSING not found in chain specification")
+
+	_, err = chaindb.SpecValue[uint64](values, "CONFIG_NAME")
+	require.EqualError(t, err, "CONFIG_NAME of unexpected type: cannot convert string to integer")
+
+	_, err = chaindb.SpecValue[uint64](values, "NEGATIVE")
+	require.EqualError(t, err, "NEGATIVE of unexpected type: negative value -1")
+
+	_, err = chaindb.SpecValue[phase0.Root](values, "GENESIS_FORK_VERSION")
+	require.EqualError(t, err, "GENESIS_FORK_VERSION of unexpected type: expected 32 bytes, found 4")
+}
@@ -17,6 +17,7 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/capella"
 	"github.com/attestantio/go-eth2-client/spec/deneb"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
@@ -44,6 +45,16 @@ type Block struct {
 	BlobKZGCommitments []deneb.KZGCommitment
 }
 
+// BlockHeader holds information about the header of a block.
+type BlockHeader struct {
+	Slot          phase0.Slot
+	ProposerIndex phase0.ValidatorIndex
+	Root          phase0.Root
+	ParentRoot    phase0.Root
+	StateRoot     phase0.Root
+	BodyRoot      phase0.Root
+}
+
 // Validator holds information about a validator.
 type Validator struct {
 	PublicKey                  phase0.BLSPubKey
@@ -57,6 +68,153 @@ type Validator struct {
 	WithdrawalCredentials      [32]byte
 }
 
+// Withdrawal credential prefixes.
+const (
+	// WithdrawalCredentialsBLS is the prefix for BLS withdrawal credentials.
+	WithdrawalCredentialsBLS = byte(0x00)
+	// WithdrawalCredentialsExecution is the prefix for execution address withdrawal credentials.
+	WithdrawalCredentialsExecution = byte(0x01)
+	// WithdrawalCredentialsCompounding is the prefix for compounding withdrawal credentials.
+	WithdrawalCredentialsCompounding = byte(0x02)
+)
+
+// Effective balance ceilings.  These are the mainnet values of MAX_EFFECTIVE_BALANCE
+// and MAX_EFFECTIVE_BALANCE_ELECTRA; the chain spec should be preferred where available.
+const (
+	// DefaultMaxEffectiveBalance is the effective balance ceiling for 0x00 and 0x01 credentials.
+	DefaultMaxEffectiveBalance = phase0.Gwei(32000000000)
+	// DefaultMaxCompoundingEffectiveBalance is the effective balance ceiling for 0x02 credentials.
+	DefaultMaxCompoundingEffectiveBalance = phase0.Gwei(2048000000000)
+)
+
+// ValidatorCredentialsChange holds information about a validator's withdrawal credentials from a given epoch.
+type ValidatorCredentialsChange struct {
+	Index                 phase0.ValidatorIndex
+	Epoch                 phase0.Epoch
+	WithdrawalCredentials [32]byte
+	// EffectiveBalanceCeiling is the maximum effective balance allowed by the credentials.
+	EffectiveBalanceCeiling phase0.Gwei
+}
+
+// CredentialsType returns the type of the withdrawal credentials, as defined by their prefix.
+func (c *ValidatorCredentialsChange) CredentialsType() byte {
+	return c.WithdrawalCredentials[0]
+}
+
+// ValidatorConsolidation holds information about the consolidation of one validator's balance in to another.
+// Consolidations are not yet indexed from blocks, as the beacon client library does not expose Electra blocks.
+type ValidatorConsolidation struct {
+	SourceIndex phase0.ValidatorIndex
+	TargetIndex phase0.ValidatorIndex
+	Epoch       phase0.Epoch
+	Amount      phase0.Gwei
+}
+
+// ValidatorShard holds the progress of an instance that indexes a range of validators.
+type ValidatorShard struct {
+	// FromIndex is the first validator index handled by the shard.
+	FromIndex phase0.ValidatorIndex
+	// ToIndex is the last validator index handled by the shard, or nil if the shard is open-ended.
+	ToIndex             *phase0.ValidatorIndex
+	LatestEpoch         phase0.Epoch
+	LatestBalancesEpoch phase0.Epoch
+	Updated             time.Time
+}
+
+// Progress holds the progress of a service.
+type Progress struct {
+	Service string
+	// Values are the progress markers of the service, for example "latest_slot".
+	Values map[string]int64
+	// Gaps are the items that the service has yet to process, for example "missed_epochs".
+	Gaps map[string][]int64
+}
+
+// ArchiveOffload holds information about data offloaded from a table to cold storage.
+type ArchiveOffload struct {
+	Table     string
+	Epoch     phase0.Epoch
+	Key       string
+	Location  string
+	Rows      uint64
+	Timestamp time.Time
+}
+
+// GraffitiFrequency holds the number of blocks with a given graffiti.
+type GraffitiFrequency struct {
+	// Graffiti is the graffiti, with trailing zero bytes removed.
+	Graffiti  string
+	Blocks    uint64
+	FirstSlot phase0.Slot
+	LastSlot  phase0.Slot
+}
+
+// BlockClientFingerprint holds the likely clients that produced a block.
+type BlockClientFingerprint struct {
+	BlockRoot phase0.Root
+	Slot      phase0.Slot
+	// ConsensusClient is the likely consensus client, or blank if unknown.
+	ConsensusClient string
+	// ExecutionClient is the likely execution client, or blank if unknown.
+	ExecutionClient string
+}
+
+// ClientRelease holds information about a release of a client.
+type ClientRelease struct {
+	// Layer is the layer of the client, either "consensus" or "execution".
+	Layer   string
+	Client  string
+	Version string
+	// Released is the time at which the release was made available.
+	Released time.Time
+	// Behaviors are free-form notes about the behavior of the release.
+	Behaviors []string
+}
+
+// BlockClientRelease holds the likely client releases that produced a block.
+type BlockClientRelease struct {
+	BlockRoot phase0.Root
+	Slot      phase0.Slot
+	// ConsensusClient is the client of the likely consensus release, or blank if unknown.
+	ConsensusClient string
+	// ConsensusVersion is the version of the likely consensus release, or blank if unknown.
+	ConsensusVersion string
+	// ConsensusVersionMatched is true if the consensus release was matched by a pattern,
+	// and false if it was inferred as the latest release at the time of the block.
+	ConsensusVersionMatched bool
+	// ExecutionClient is the client of the likely execution release, or blank if unknown.
+	ExecutionClient string
+	// ExecutionVersion is the version of the likely execution release, or blank if unknown.
+	ExecutionVersion string
+	// ExecutionVersionMatched is true if the execution release was matched by a pattern,
+	// and false if it was inferred as the latest release at the time of the block.
+	ExecutionVersionMatched bool
+}
+
+// BlockArrival holds the time at which a block was first seen by the beacon node.
+type BlockArrival struct {
+	BlockRoot phase0.Root
+	Slot      phase0.Slot
+	// SeenTimestamp is the time at which the block was first seen.
+	SeenTimestamp time.Time
+	// Delay is the time between the start of the slot and the block being seen.
+	Delay time.Duration
+}
+
+// AttestationArrival holds the time at which an attestation was first seen by the beacon node.
+type AttestationArrival struct {
+	Slot            phase0.Slot
+	CommitteeIndex  phase0.CommitteeIndex
+	BeaconBlockRoot phase0.Root
+	// DataRoot is the hash tree root of the attestation data.
+	DataRoot        phase0.Root
+	AggregationBits []byte
+	// SeenTimestamp is the time at which the attestation was first seen.
+	SeenTimestamp time.Time
+	// Delay is the time between the start of the slot and the attestation being seen.
+	Delay time.Duration
+}
+
 // ValidatorBalance holds information about a validator's balance at a given epoch.
 type ValidatorBalance struct {
 	Index            phase0.ValidatorIndex
@@ -65,6 +223,46 @@ type ValidatorBalance struct {
 	EffectiveBalance phase0.Gwei
 }
 
+// ValidatorSetDiff holds the changes to the validator set between two epochs.
+// Changes are those that took effect after FromEpoch, up to and including ToEpoch.
+type ValidatorSetDiff struct {
+	FromEpoch phase0.Epoch
+	ToEpoch   phase0.Epoch
+	// Activations are the validators that were activated.
+	Activations []phase0.ValidatorIndex
+	// Exits are the validators that exited.
+	Exits []phase0.ValidatorIndex
+	// Slashings are the validators slashed by slashings included in canonical blocks.
+	Slashings []phase0.ValidatorIndex
+	// BalanceChanges are the validators whose balance or effective balance changed.
+	BalanceChanges []*ValidatorBalanceChange
+	// CredentialsChanges are the validators whose withdrawal credentials changed.
+	CredentialsChanges []*ValidatorCredentialsDiff
+}
+
+// ValidatorBalanceChange holds the change in a validator's balance between two epochs.
+// Balances are 0 for validators that did not exist at the epoch.
+type ValidatorBalanceChange struct {
+	Index                phase0.ValidatorIndex
+	FromBalance          phase0.Gwei
+	ToBalance            phase0.Gwei
+	FromEffectiveBalance phase0.Gwei
+	ToEffectiveBalance   phase0.Gwei
+}
+
+// Delta returns the change in balance.
+func (c *ValidatorBalanceChange) Delta() int64 {
+	return int64(c.ToBalance) - int64(c.FromBalance)
+}
+
+// ValidatorCredentialsDiff holds a change of a validator's withdrawal credentials.
+type ValidatorCredentialsDiff struct {
+	Index                     phase0.ValidatorIndex
+	Epoch                     phase0.Epoch
+	FromWithdrawalCredentials [32]byte
+	ToWithdrawalCredentials   [32]byte
+}
+
 // AggregateValidatorBalance holds aggreated information about validators' balances at a given epoch.
 type AggregateValidatorBalance struct {
 	Epoch            phase0.Epoch
@@ -111,6 +309,12 @@ type Attestation struct {
 	Canonical          *bool
 	TargetCorrect      *bool
 	HeadCorrect        *bool
+	SourceCorrect      *bool
+	// InclusionTargetCorrect and InclusionHeadCorrect are the correctness of the
+	// target and head votes against the chain of the block in which the attestation
+	// was included, rather than the finalized chain.
+	InclusionTargetCorrect *bool
+	InclusionHeadCorrect   *bool
 }
 
 // SyncAggregate holds information about a sync aggregate included in a block.
@@ -119,6 +323,32 @@ type SyncAggregate struct {
 	InclusionBlockRoot phase0.Root
 	Bits               []byte
 	Indices            []phase0.ValidatorIndex
+	// Signature is nil for sync aggregates stored before signatures were recorded.
+	Signature *phase0.BLSSignature
+}
+
+// SyncParticipation holds the participation of the sync committee in the
+// sync aggregate included in a block.
+type SyncParticipation struct {
+	InclusionSlot      phase0.Slot
+	InclusionBlockRoot phase0.Root
+	Period             uint64
+	Participants       uint64
+	// CommitteeSize is 0 if the sync committee for the period is unknown.
+	CommitteeSize uint64
+	// ParticipationRate is the fraction of the sync committee that participated.
+	ParticipationRate float64
+}
+
+// SyncCommitteeParticipant holds the participation of a member of the sync
+// committee in the sync aggregate included in a block.
+type SyncCommitteeParticipant struct {
+	InclusionSlot      phase0.Slot
+	InclusionBlockRoot phase0.Root
+	// Position is the position of the validator in the sync committee.
+	Position       uint64
+	ValidatorIndex phase0.ValidatorIndex
+	Participated   bool
 }
 
 // Deposit holds information about an Ethereum 2 deposit included by a block.
@@ -149,6 +379,20 @@ type ETH1Deposit struct {
 	Amount                phase0.Gwei
 }
 
+// ETH1DepositDiscrepancy holds a difference between the deposits held by the
+// deposit contract and those that have been indexed, as of an Ethereum 1 block.
+type ETH1DepositDiscrepancy struct {
+	ETH1BlockNumber      uint64
+	Timestamp            time.Time
+	ContractDepositCount uint64
+	IndexedDepositCount  uint64
+	ContractDepositRoot  phase0.Root
+	IndexedDepositRoot   phase0.Root
+	// MissingDepositIndices are the indices of deposits held by the contract
+	// that have not been indexed.
+	MissingDepositIndices []uint64
+}
+
 // VoluntaryExit holds information about a voluntary exit included in a block.
 type VoluntaryExit struct {
 	InclusionSlot      phase0.Slot
@@ -243,6 +487,40 @@ type ValidatorDaySummary struct {
 	SyncCommitteeMessagesIncluded int
 }
 
+// ValidatorDayRanking provides the ranking of a validator's effectiveness
+// against that of all validators for a day.
+type ValidatorDayRanking struct {
+	Index          phase0.ValidatorIndex
+	StartTimestamp time.Time
+	// Effectiveness is the fraction of the maximum attestation reward weight
+	// obtained by the validator over the day, between 0 and 1.
+	Effectiveness float64
+	// Percentile is the percentage of validators with a lower effectiveness
+	// for the day, with ties counted as half.
+	Percentile float64
+	// ZScore is the number of standard deviations that the validator's
+	// effectiveness is from the mean effectiveness for the day.
+	ZScore float64
+}
+
+// ValidatorAPR provides the annual percentage rate of return of one or more validators over a window.
+type ValidatorAPR struct {
+	// Index is the index of the validator.  It is 0 for aggregate APRs.
+	Index phase0.ValidatorIndex
+	// Validators is the number of validators that contributed to the APR.
+	Validators int
+	// Days is the number of validator-days over which the APR was calculated.
+	Days float64
+	// Rewards is the total rewards earned over the window.
+	Rewards int64
+	// AverageCapital is the time-weighted average capital over the window, excluding rewards.
+	AverageCapital float64
+	// AverageEffectiveBalance is the time-weighted average effective balance over the window.
+	AverageEffectiveBalance float64
+	// APR is the annual percentage rate, as a fraction, i.e. 0.04 is 4%.
+	APR float64
+}
+
 // BlockSummary provides a summary of an epoch.
 type BlockSummary struct {
 	Slot                          phase0.Slot
@@ -275,6 +553,562 @@ type EpochSummary struct {
 	ExitingValidators             int
 	CanonicalBlocks               int
 	Withdrawals                   phase0.Gwei
+	SourceTimelyValidators        int
+	SourceTimelyBalance           phase0.Gwei
+	// ParticipationRate is the fraction of active balance that attested.
+	ParticipationRate float64
+	// SourceTimelyRate is the fraction of active balance that attested with a timely source.
+	SourceTimelyRate float64
+	// TargetCorrectRate is the fraction of active balance that attested to the correct target.
+	TargetCorrectRate float64
+	// HeadCorrectRate is the fraction of active balance that attested to the correct head.
+	HeadCorrectRate float64
+	// MissedProposals is the number of slots in the epoch without a canonical block.
+	MissedProposals int
+	// OrphanedBlocks is the number of non-canonical blocks in the epoch.
+	OrphanedBlocks int
+	// VoluntaryExits is the number of voluntary exits included in canonical blocks in the epoch.
+	VoluntaryExits int
+	// AverageInclusionDelay is the average number of slots between the
+	// attestation slot and the first inclusion of each attesting validator's
+	// attestation.  It is nil if no validators attested.
+	AverageInclusionDelay *float64
+	// SourceCorrectValidators and SourceCorrectBalance are the number and effective balance
+	// of validators that attested to the correct source according to the finalized chain.
+	SourceCorrectValidators int
+	SourceCorrectBalance    phase0.Gwei
+	// InclusionTargetCorrectValidators and InclusionTargetCorrectBalance are the number and
+	// effective balance of validators that attested to the correct target according to the
+	// chain of the block in which their attestation was included.
+	InclusionTargetCorrectValidators int
+	InclusionTargetCorrectBalance    phase0.Gwei
+	// InclusionHeadCorrectValidators and InclusionHeadCorrectBalance are the number and
+	// effective balance of validators that attested to the correct head according to the
+	// chain of the block in which their attestation was included.
+	InclusionHeadCorrectValidators int
+	InclusionHeadCorrectBalance    phase0.Gwei
+}
+
+// CommitteeEpochSummary provides a summary of the attestation performance
+// of a single beacon committee.
+type CommitteeEpochSummary struct {
+	Epoch          phase0.Epoch
+	Slot           phase0.Slot
+	CommitteeIndex phase0.CommitteeIndex
+	// Validators is the number of validators in the committee.
+	Validators              int
+	AttestingValidators     int
+	SourceTimelyValidators  int
+	TargetCorrectValidators int
+	HeadCorrectValidators   int
+	// AverageInclusionDelay is the average number of slots between the
+	// attestation slot and the first inclusion of each attesting validator's
+	// attestation.  It is nil if no validators in the committee attested.
+	AverageInclusionDelay *float64
+}
+
+// ValidatorSyncPeriodSummary provides a summary of a validator's participation
+// in a sync committee over a sync committee period.
+type ValidatorSyncPeriodSummary struct {
+	Index  phase0.ValidatorIndex
+	Period uint64
+	// AssignedSlots is the number of slots in the period for which the validator
+	// was in the sync committee.  A validator that appears in the committee more
+	// than once is assigned each slot once per appearance.
+	AssignedSlots int
+	// ParticipatedSlots is the number of assigned slots for which the validator's
+	// sync committee message was included in a block.
+	ParticipatedSlots int
+	// MissedSlots is the number of assigned slots for which a block was included
+	// without the validator's sync committee message.  Slots without blocks are
+	// neither participated nor missed.
+	MissedSlots int
+	// Rewards is the net reward in Gwei for the period, being the rewards for
+	// participated slots less the penalties for missed slots.
+	Rewards int64
+}
+
+// Proposer summary windows.
+const (
+	// ProposerSummaryWindowDay is a calendar day, in UTC.
+	ProposerSummaryWindowDay = "day"
+	// ProposerSummaryWindowWeek is a calendar week starting on Monday, in UTC.
+	ProposerSummaryWindowWeek = "week"
+	// ProposerSummaryWindowMonth is a calendar month, in UTC.
+	ProposerSummaryWindowMonth = "month"
+)
+
+// ProposerPeriodSummary provides a summary of the profitability of block proposals
+// over a window of time, either for a single proposer or for all proposers with a tag.
+type ProposerPeriodSummary struct {
+	// Window is the length of the window, one of the ProposerSummaryWindow constants.
+	Window string
+	// StartTimestamp is the start of the window.
+	StartTimestamp time.Time
+	// ValidatorIndex is the index of the proposer.
+	// It is nil for summaries of a tag.
+	ValidatorIndex *phase0.ValidatorIndex
+	// Tag is the watchlist label of the proposers.
+	// It is nil for summaries of a single proposer.
+	Tag *string
+	// Proposals is the number of proposal duties in the window.
+	Proposals int
+	// ProposalsIncluded is the number of proposal duties that resulted in a canonical block.
+	ProposalsIncluded int
+	// ConsensusRewards is the total consensus layer reward in Gwei for the canonical blocks.
+	// It is nil if the rewards for one or more of the blocks could not be obtained.
+	ConsensusRewards *phase0.Gwei
+	// ExecutionFees is the total value in wei of locally built execution payloads,
+	// unless the provider is configured with another denomination.
+	ExecutionFees *big.Int
+	// MEVPayments is the total value in wei of execution payloads delivered by relays,
+	// unless the provider is configured with another denomination.
+	MEVPayments *big.Int
+	// MEVBlocks is the number of canonical blocks with payloads delivered by relays.
+	MEVBlocks int
+}
+
+// ProposerPeriodTotals holds the totals of a set of proposer period summaries.
+type ProposerPeriodTotals struct {
+	// Summaries is the number of summaries totalled.
+	Summaries int
+	// Proposals is the total number of proposal duties.
+	Proposals int64
+	// ProposalsIncluded is the total number of proposal duties that resulted in a canonical block.
+	ProposalsIncluded int64
+	// ConsensusRewards is the total consensus layer reward in Gwei.
+	// It is nil if the rewards of one or more of the summaries are not known.
+	ConsensusRewards *big.Int
+	// ExecutionFees is the total value in wei of locally built execution payloads,
+	// unless the provider is configured with another denomination.
+	ExecutionFees *big.Int
+	// MEVPayments is the total value in wei of execution payloads delivered by relays,
+	// unless the provider is configured with another denomination.
+	MEVPayments *big.Int
+	// MEVBlocks is the total number of canonical blocks with payloads delivered by relays.
+	MEVBlocks int64
+}
+
+// EpochCheckpoint holds the boundary roots of an epoch, along with the
+// finality checkpoints in the beacon state at the start of the epoch.
+type EpochCheckpoint struct {
+	Epoch phase0.Epoch
+	// BlockRoot is the root of the latest block at or before the first slot of the epoch.
+	BlockRoot phase0.Root
+	// StateRoot is the root of the beacon state at the first slot of the epoch.
+	StateRoot              phase0.Root
+	PreviousJustifiedEpoch phase0.Epoch
+	PreviousJustifiedRoot  phase0.Root
+	JustifiedEpoch         phase0.Epoch
+	JustifiedRoot          phase0.Root
+	FinalizedEpoch         phase0.Epoch
+	FinalizedRoot          phase0.Root
+}
+
+// RANDAOMix holds the RANDAO mix of an epoch.
+type RANDAOMix struct {
+	Epoch phase0.Epoch
+	// Mix is the RANDAO mix at the end of the epoch, after the RANDAO reveals
+	// of all of the epoch's blocks have been mixed in.
+	Mix phase0.Root
+}
+
+// RawBlock holds the SSZ encoding of a signed beacon block.
+type RawBlock struct {
+	Root    phase0.Root
+	Slot    phase0.Slot
+	Version spec.DataVersion
+	// Data is the SSZ encoding of the signed beacon block.
+	Data []byte
+	// Key is the key of the data in cold storage, if the data is not held
+	// in the database.
+	Key string
+}
+
+// NetworkAggregate provides network-wide aggregate statistics for an epoch.
+type NetworkAggregate struct {
+	Epoch phase0.Epoch
+	// TotalBalance is the total balance of all validators, including those
+	// that are not active.
+	TotalBalance phase0.Gwei
+	// ActiveValidators is the number of active validators.
+	ActiveValidators int
+	// ActiveBalance is the total balance of active validators.
+	ActiveBalance phase0.Gwei
+	// AverageBalance is the average balance of active validators.
+	AverageBalance phase0.Gwei
+	// EnteringValidators is the number of validators that have been deposited
+	// but are not yet active.
+	EnteringValidators int
+	// ExitingValidators is the number of active validators that have
+	// initiated an exit.
+	ExitingValidators int
+	// ActivatedValidators is the number of validators that became active in this epoch.
+	ActivatedValidators int
+	// ExitedValidators is the number of validators that exited in this epoch.
+	ExitedValidators int
+	// ActivationChurnLimit is the maximum number of validators that could become
+	// active in this epoch.
+	ActivationChurnLimit int
+	// ExitChurnLimit is the maximum number of validators that could exit in this epoch.
+	ExitChurnLimit int
+	// ActivationChurnUtilization is the fraction of the activation churn limit used.
+	ActivationChurnUtilization float64
+	// ExitChurnUtilization is the fraction of the exit churn limit used.
+	ExitChurnUtilization float64
+}
+
+// EntryQueue provides the state of the validator entry queue for an epoch.
+type EntryQueue struct {
+	Epoch phase0.Epoch
+	// PendingValidators is the number of validators that have been deposited
+	// but are not yet eligible for activation.
+	PendingValidators int
+	// QueueLength is the number of validators that are eligible for activation
+	// but are not yet active.
+	QueueLength int
+	// QueueBalance is the total effective balance of validators in the queue.
+	QueueBalance phase0.Gwei
+	// ActivationChurnLimit is the maximum number of validators that could
+	// become active in this epoch.
+	ActivationChurnLimit int
+	// ProjectedWait is the number of epochs a validator that became eligible
+	// for activation in this epoch is expected to wait before activation.
+	ProjectedWait int
+	// ActivatedValidators is the number of validators that became active in this epoch.
+	ActivatedValidators int
+	// AverageWait is the average number of epochs between eligibility and
+	// activation for validators that became active in this epoch.
+	AverageWait float64
+}
+
+// QueueProjection is the projected activation and exit of a validator, as
+// projected at an epoch.
+type QueueProjection struct {
+	// Epoch is the epoch at which the projection was made.
+	Epoch          phase0.Epoch
+	ValidatorIndex phase0.ValidatorIndex
+	// ActivationEpoch is the epoch at which the validator is projected to
+	// become active.  It is nil if the validator was already active.
+	ActivationEpoch *phase0.Epoch
+	// ExitEpoch is the epoch at which the validator is projected to exit.
+	// It is nil if the validator had not initiated an exit.
+	ExitEpoch *phase0.Epoch
+}
+
+// QueueProjectionAccuracy provides the accuracy of the activation
+// projections made at an epoch, for validators that have since activated.
+type QueueProjectionAccuracy struct {
+	// Epoch is the epoch at which the projections were made.
+	Epoch phase0.Epoch
+	// Validators is the number of projected validators that have since activated.
+	Validators int
+	// MeanError is the mean number of epochs by which actual activation
+	// followed the projection; negative values are activations earlier than projected.
+	MeanError float64
+	// MeanAbsoluteError is the mean absolute number of epochs between the
+	// projected and actual activations.
+	MeanAbsoluteError float64
+}
+
+// AuditEntry is the record of the writes made to the database by a transaction.
+type AuditEntry struct {
+	// Timestamp is the time at which the transaction was committed.
+	Timestamp time.Time
+	// Started is the time at which the transaction started.
+	Started time.Time
+	TxID    string
+	// Module is the module that made the writes.
+	Module string
+	// Operations are the number of calls to each operation that writes to the database.
+	Operations map[string]int64
+	// Rows is the number of rows inserted, updated or deleted.
+	Rows int64
+}
+
+// WithdrawalForecast is the forecast full withdrawal of an exited validator,
+// as forecast at an epoch.
+type WithdrawalForecast struct {
+	// Epoch is the epoch at which the forecast was made.
+	Epoch             phase0.Epoch
+	ValidatorIndex    phase0.ValidatorIndex
+	WithdrawableEpoch phase0.Epoch
+	// Slot is the slot at which the validator is forecast to be swept.
+	// It is nil if the validator cannot be swept, as it does not have
+	// execution withdrawal credentials.
+	Slot *phase0.Slot
+}
+
+// Validator anomaly types.
+const (
+	// ValidatorAnomalyIndexPublicKeyChanged is a validator whose index is
+	// already held by a validator with a different public key.
+	ValidatorAnomalyIndexPublicKeyChanged = "index_public_key_changed"
+	// ValidatorAnomalyPublicKeyIndexChanged is a validator whose public key
+	// is already held by a validator with a different index.
+	ValidatorAnomalyPublicKeyIndexChanged = "public_key_index_changed"
+	// ValidatorAnomalyDepositCredentialsMismatch is a deposit for a public key
+	// whose withdrawal credentials differ from those of the first deposit
+	// for the public key, which are the ones that take effect.
+	ValidatorAnomalyDepositCredentialsMismatch = "deposit_credentials_mismatch"
+)
+
+// ValidatorAnomaly is an inconsistency between validator data being stored
+// and that already held.
+type ValidatorAnomaly struct {
+	Type           string
+	PublicKey      phase0.BLSPubKey
+	ValidatorIndex *phase0.ValidatorIndex
+	// ExistingPublicKey and ExistingValidatorIndex are the conflicting values
+	// already held, if any.
+	ExistingPublicKey      *phase0.BLSPubKey
+	ExistingValidatorIndex *phase0.ValidatorIndex
+	// InclusionSlot is the slot of the block that included the deposit, for
+	// deposit anomalies.
+	InclusionSlot *phase0.Slot
+	FirstSeen     time.Time
+	LastSeen      time.Time
+	Details       string
+}
+
+// BlobFee holds the state of the blob fee market at a canonical block.
+type BlobFee struct {
+	Slot                 phase0.Slot
+	ExecutionBlockNumber uint64
+	ExcessBlobGas        uint64
+	BlobGasUsed          uint64
+	TargetBlobGas        uint64
+	// BlobBaseFee is the base fee per unit of blob gas, in wei.
+	BlobBaseFee *big.Int
+	// Window values are calculated over the blocks in the window that ends
+	// with, and includes, this block.
+	WindowBlocks          int
+	WindowBlobGasUsed     uint64
+	WindowMeanBlobBaseFee *big.Int
+	WindowMinBlobBaseFee  *big.Int
+	WindowMaxBlobBaseFee  *big.Int
+}
+
+// Balance anomaly reasons.
+const (
+	// BalanceAnomalyReasonSlashing is a balance change of a validator that
+	// was slashed in the epoch.
+	BalanceAnomalyReasonSlashing = "slashing"
+	// BalanceAnomalyReasonExcessPenalty is a balance change below that
+	// expected from the penalties that can be applied in an epoch.
+	BalanceAnomalyReasonExcessPenalty = "excess_penalty"
+	// BalanceAnomalyReasonExcessReward is a balance change above that
+	// expected from the rewards that can be obtained in an epoch.
+	BalanceAnomalyReasonExcessReward = "excess_reward"
+)
+
+// BalanceAnomaly is a change in the balance of a validator over an epoch
+// that is outside of the range expected from rewards and penalties.
+type BalanceAnomaly struct {
+	ValidatorIndex phase0.ValidatorIndex
+	// Epoch is the epoch over which the balance changed, from its start
+	// to the start of the following epoch.
+	Epoch           phase0.Epoch
+	Reason          string
+	PreviousBalance phase0.Gwei
+	Balance         phase0.Gwei
+	Withdrawn       phase0.Gwei
+	Deposited       phase0.Gwei
+	// Delta is the change in balance, excluding withdrawals and deposits.
+	Delta            int64
+	ExpectedMinDelta int64
+	// ExpectedMaxDelta is nil if there is no upper bound on the expected
+	// change, for example if the validator proposed a block.
+	ExpectedMaxDelta *int64
+	// Deviation is the amount by which the delta is outside of the expected
+	// range; negative if below it and positive if above it.
+	Deviation int64
+}
+
+// HeadObservation is the head of the chain as seen by the beacon node at a
+// point in time, regardless of whether it went on to become canonical.
+type HeadObservation struct {
+	// Slot is the slot in which the observation was made.
+	Slot       phase0.Slot
+	ObservedAt time.Time
+	HeadSlot   phase0.Slot
+	HeadRoot   phase0.Root
+	ParentRoot phase0.Root
+	// Reorg is true if the head is not a descendant of the previously observed head.
+	Reorg bool
+	// ReorgDepth is the number of slots between the previously observed head and
+	// the common ancestor of that head and this one.  It is 0 if there was no reorg.
+	ReorgDepth uint64
+}
+
+// Equivocation types.
+const (
+	// EquivocationTypeProposer is two distinct blocks proposed for the same slot by the same validator.
+	EquivocationTypeProposer = "proposer"
+	// EquivocationTypeDoubleVote is two distinct attestations with the same target epoch by the same validator.
+	EquivocationTypeDoubleVote = "double_vote"
+	// EquivocationTypeSurroundVote is an attestation that surrounds an earlier attestation by the same validator.
+	EquivocationTypeSurroundVote = "surround_vote"
+)
+
+// Equivocation holds information about a slashable offence by a validator found in indexed data.
+type Equivocation struct {
+	// Type is the type of the equivocation, one of the EquivocationType constants.
+	Type           string
+	ValidatorIndex phase0.ValidatorIndex
+	// Slot1 is the slot of the first block or attestation.
+	Slot1 phase0.Slot
+	// Root1 is the root of the first block, or the root of the data of the first attestation.
+	Root1 phase0.Root
+	// Slot2 is the slot of the second block or attestation.
+	Slot2 phase0.Slot
+	// Root2 is the root of the second block, or the root of the data of the second attestation.
+	Root2 phase0.Root
+	// SourceEpoch1 and TargetEpoch1 are the source and target epochs of the first attestation.
+	// Both are nil for proposer equivocations.
+	SourceEpoch1 *phase0.Epoch
+	TargetEpoch1 *phase0.Epoch
+	// SourceEpoch2 and TargetEpoch2 are the source and target epochs of the second attestation.
+	// Both are nil for proposer equivocations.
+	SourceEpoch2 *phase0.Epoch
+	TargetEpoch2 *phase0.Epoch
+	// Slashed is true if a slashing of the relevant type for the validator has been included on chain.
+	Slashed bool
+}
+
+// Export column types.
+const (
+	ExportColumnTypeInteger   = "integer"
+	ExportColumnTypeFloat     = "float"
+	ExportColumnTypeBoolean   = "boolean"
+	ExportColumnTypeBytes     = "bytes"
+	ExportColumnTypeTimestamp = "timestamp"
+	ExportColumnTypeNumeric   = "numeric"
+	ExportColumnTypeString    = "string"
+)
+
+// ExportColumn describes a column of a table for export.
+type ExportColumn struct {
+	Name string
+	// Type is the type of the column, one of the ExportColumnType constants.
+	Type string
+	// Repeated is true if the column holds an array of values of its type.
+	Repeated bool
+}
+
+// Outbox operations.
+const (
+	OutboxOperationInsert = "insert"
+	OutboxOperationUpdate = "update"
+	OutboxOperationDelete = "delete"
+)
+
+// OutboxEvent holds a change to a table captured in the outbox.
+type OutboxEvent struct {
+	ID    uint64
+	Table string
+	// Operation is the operation that changed the row, one of the OutboxOperation constants.
+	Operation string
+	// Data is the JSON representation of the row after the change, or before it for deletes.
+	Data      []byte
+	Timestamp time.Time
+}
+
+// WatchedValidator holds information about a validator on the watchlist.
+type WatchedValidator struct {
+	Index phase0.ValidatorIndex
+	Label string
+	Added time.Time
+	// Proof is a signature by the validator's key over its label, proving ownership.
+	// It is nil if no proof was supplied.
+	Proof *phase0.BLSSignature
+}
+
+// Watchlist event types.
+const (
+	// WatchlistEventActivated is a watched validator becoming active.
+	WatchlistEventActivated = "activated"
+	// WatchlistEventExited is a watched validator exiting.
+	WatchlistEventExited = "exited"
+	// WatchlistEventSlashed is a watched validator being slashed.
+	WatchlistEventSlashed = "slashed"
+	// WatchlistEventProposalIncluded is a block proposed by a watched validator becoming canonical.
+	WatchlistEventProposalIncluded = "proposal_included"
+	// WatchlistEventProposalMissed is a watched validator failing to propose a canonical block for its duty.
+	WatchlistEventProposalMissed = "proposal_missed"
+	// WatchlistEventAttestationMissed is a watched validator failing to have an attestation included.
+	WatchlistEventAttestationMissed = "attestation_missed"
+	// WatchlistEventBalanceDecreased is a watched validator's balance decreasing, excluding withdrawals.
+	WatchlistEventBalanceDecreased = "balance_decreased"
+)
+
+// WatchlistEvent holds an event for a watched validator.
+type WatchlistEvent struct {
+	ValidatorIndex phase0.ValidatorIndex
+	Epoch          phase0.Epoch
+	// Type is the type of the event, one of the WatchlistEvent constants.
+	Type string
+	// Slot is the slot of the event; for events that relate to the epoch as a whole
+	// it is the first slot of the epoch.
+	Slot phase0.Slot
+	// Amount is the change in balance, in Gwei, for balance events.
+	Amount *int64
+}
+
+// Watchlist alert rules.
+const (
+	// WatchlistAlertAttestationEffectiveness is a watched validator's attestation effectiveness
+	// falling below a threshold.
+	WatchlistAlertAttestationEffectiveness = "attestation_effectiveness"
+	// WatchlistAlertMissedAttestations is a watched validator missing a number of consecutive attestations.
+	WatchlistAlertMissedAttestations = "missed_attestations"
+	// WatchlistAlertMissedProposals is a watched validator missing a number of consecutive proposals.
+	WatchlistAlertMissedProposals = "missed_proposals"
+)
+
+// WatchlistAlert holds an alert raised for a watched validator.
+type WatchlistAlert struct {
+	ValidatorIndex phase0.ValidatorIndex
+	// Epoch is the epoch in which the alert was raised.
+	Epoch phase0.Epoch
+	// Rule is the rule that raised the alert, one of the WatchlistAlert constants.
+	Rule string
+	// Description is a human-readable description of the alert.
+	Description string
+}
+
+// Verification disagreement types.
+const (
+	// VerificationDisagreementMissing is a block present on the reference beacon node but not indexed.
+	VerificationDisagreementMissing = "missing"
+	// VerificationDisagreementUnexpected is a block indexed but not present on the reference beacon node.
+	VerificationDisagreementUnexpected = "unexpected"
+	// VerificationDisagreementMismatch is a block whose indexed root differs from that of the reference beacon node.
+	VerificationDisagreementMismatch = "mismatch"
+)
+
+// VerificationDisagreement holds a disagreement between the indexed chain and a reference beacon node.
+type VerificationDisagreement struct {
+	Slot phase0.Slot
+	// Type is the type of the disagreement, one of the VerificationDisagreement constants.
+	Type string
+	// IndexedRoot is the root of the canonical indexed block, or nil if there is none.
+	IndexedRoot *phase0.Root
+	// ReferenceRoot is the root of the block on the reference beacon node, or nil if there is none.
+	ReferenceRoot *phase0.Root
+	Detected      time.Time
+}
+
+// ShufflingMismatch holds a beacon committee that differs from the committee calculated
+// locally from the shuffling of active validators.
+type ShufflingMismatch struct {
+	Slot  phase0.Slot
+	Index phase0.CommitteeIndex
+	// Committee is the committee obtained from the beacon node, or nil if there is none.
+	Committee []phase0.ValidatorIndex
+	// ExpectedCommittee is the committee calculated locally, or nil if there is none.
+	ExpectedCommittee []phase0.ValidatorIndex
+	Detected          time.Time
 }
 
 // SyncCommittee holds information for sync committees.
@@ -284,6 +1118,8 @@ type SyncCommittee struct {
 }
 
 // ExecutionPayload holds information about a block's execution payload.
+// Values of ether are in wei, unless the provider is configured with another
+// denomination.
 type ExecutionPayload struct {
 	ParentHash    [32]byte
 	FeeRecipient  [20]byte
@@ -302,6 +1138,38 @@ type ExecutionPayload struct {
 	Withdrawals   []*Withdrawal
 	BlobGasUsed   uint64
 	ExcessBlobGas uint64
+	// PayloadValue is the value of the payload to its proposer.
+	// It is nil if the value is not known.
+	PayloadValue *big.Int
+	// PayloadValueSource is the source of the payload value, one of the
+	// PayloadValueSource constants.  It is empty if the value is not known.
+	PayloadValueSource string
+	// TransactionCount is the number of transactions in the payload.
+	// It is nil for payloads indexed before transaction statistics were recorded.
+	TransactionCount *uint64
+	// TransactionsSize is the total size of the payload's encoded transactions, in bytes.
+	// It is nil for payloads indexed before transaction statistics were recorded.
+	TransactionsSize *uint64
+	// BlobTransactionCount is the number of blob-carrying transactions in the payload.
+	// It is nil for payloads indexed before transaction statistics were recorded.
+	BlobTransactionCount *uint64
+}
+
+// Payload value sources.
+const (
+	// PayloadValueSourceRelay is a value declared in the bid trace of a relay that delivered the payload.
+	PayloadValueSourceRelay = "relay"
+	// PayloadValueSourceLocal is a value computed from the priority fees of the payload's transactions.
+	PayloadValueSourceLocal = "local"
+)
+
+// ExecutionPayloadValue holds the value of an execution payload to its proposer.
+type ExecutionPayloadValue struct {
+	BlockRoot phase0.Root
+	// Value is the value of the payload, in wei.
+	Value *big.Int
+	// Source is the source of the value, one of the PayloadValueSource constants.
+	Source string
 }
 
 // BLSToExecutionChange holds information about credentials change operations.
@@ -325,6 +1193,44 @@ type Withdrawal struct {
 	Amount             phase0.Gwei
 }
 
+// TransactionReceipt holds information about the receipt of a transaction in an execution payload.
+type TransactionReceipt struct {
+	InclusionBlockRoot phase0.Root
+	InclusionSlot      phase0.Slot
+	BlockNumber        uint64
+	Index              uint32
+	Hash               [32]byte
+	From               [20]byte
+	// To is nil for contract creation transactions.
+	To *[20]byte
+	// ContractAddress is set only for contract creation transactions.
+	ContractAddress *[20]byte
+	Status          uint8
+	GasUsed         uint64
+}
+
+// TransactionEvent holds information about a log emitted by a transaction in an execution payload.
+type TransactionEvent struct {
+	InclusionBlockRoot phase0.Root
+	InclusionSlot      phase0.Slot
+	BlockNumber        uint64
+	TransactionIndex   uint32
+	TransactionHash    [32]byte
+	Index              uint32
+	Address            [20]byte
+	Topics             [][32]byte
+	Data               []byte
+}
+
+// AddressLabel holds a label for an execution address.
+type AddressLabel struct {
+	Address [20]byte
+	// Label is the name of the owner of the address, for example "Example Exchange".
+	Label string
+	// Category is the type of the owner of the address, for example "exchange", "pool" or "bridge".
+	Category string
+}
+
 // BlobSidecar holds information about a blob sidecar for a block.
 type BlobSidecar struct {
 	InclusionBlockRoot          phase0.Root
@@ -335,3 +1241,103 @@ type BlobSidecar struct {
 	KZGProof                    deneb.KZGProof
 	KZGCommitmentInclusionProof deneb.KZGCommitmentInclusionProof
 }
+
+// TableMaintenanceStats holds statistics used to decide if a table requires maintenance.
+type TableMaintenanceStats struct {
+	Table      string
+	LiveTuples int64
+	DeadTuples int64
+	// ModificationsSinceAnalyze is the number of rows changed since the table was last analyzed.
+	ModificationsSinceAnalyze int64
+	// TotalBytes is the size of the table, including its indexes and TOAST data.
+	TotalBytes int64
+	// LastVacuum is the latest time at which the table was vacuumed, manually or automatically.
+	LastVacuum *time.Time
+	// LastAnalyze is the latest time at which the table was analyzed, manually or automatically.
+	LastAnalyze *time.Time
+}
+
+const (
+	// ValidatorClusterLinkWithdrawalAddress links validators whose execution
+	// withdrawal credentials share an address.
+	ValidatorClusterLinkWithdrawalAddress = "withdrawal_address"
+	// ValidatorClusterLinkBLSWithdrawalCredentials links validators that share
+	// BLS withdrawal credentials.
+	ValidatorClusterLinkBLSWithdrawalCredentials = "bls_withdrawal_credentials"
+	// ValidatorClusterLinkFeeRecipient links validators that proposed blocks
+	// paying the same fee recipient.
+	ValidatorClusterLinkFeeRecipient = "fee_recipient"
+)
+
+// ValidatorClusterLink is an item, such as a withdrawal address, held by a
+// validator.  Validators that hold the same item are in the same cluster.
+type ValidatorClusterLink struct {
+	ValidatorIndex phase0.ValidatorIndex
+	// Type is the type of the item, one of the ValidatorClusterLink constants.
+	Type string
+	Key  []byte
+	// Epoch is the first epoch at which the validator was seen with the item.
+	Epoch phase0.Epoch
+}
+
+// ValidatorCluster is a set of validators that are linked, directly or
+// through other validators, by the items that they hold, and so are likely
+// to be controlled by the same entity.
+type ValidatorCluster struct {
+	// ID is the lowest index of the validators in the cluster.
+	ID         phase0.ValidatorIndex
+	Validators []phase0.ValidatorIndex
+}
+
+// ValidatorClusterSize is the number of clusters with a given number of validators.
+type ValidatorClusterSize struct {
+	Size     int
+	Clusters int
+}
+
+const (
+	// FailedItemKindSlot is a failed item that is a slot.
+	FailedItemKindSlot = "slot"
+	// FailedItemKindEpoch is a failed item that is an epoch.
+	FailedItemKindEpoch = "epoch"
+)
+
+const (
+	// FailedItemStateFailing is an item that has failed, and that its
+	// module continues to retry.
+	FailedItemStateFailing = "failing"
+	// FailedItemStateDead is an item that has failed too many times, and
+	// that its module has moved past.
+	FailedItemStateDead = "dead"
+	// FailedItemStateRetry is a dead item that its module will retry.
+	FailedItemStateRetry = "retry"
+	// FailedItemStateAcknowledged is a dead item that has been acknowledged,
+	// and will not be retried.
+	FailedItemStateAcknowledged = "acknowledged"
+)
+
+// FailedItem is a slot or epoch that a module failed to process.
+type FailedItem struct {
+	Module string
+	// Kind is the kind of the item, one of the FailedItemKind constants.
+	Kind string
+	Item uint64
+	// State is the state of the item, one of the FailedItemState constants.
+	State string
+	// Attempts is the number of times the module has failed to process the item.
+	Attempts     uint32
+	Error        string
+	FirstFailure time.Time
+	LastFailure  time.Time
+}
+
+// Fork is a fork of the chain.
+type Fork struct {
+	// Name is the name of the fork, for example "capella".
+	Name    string
+	Epoch   phase0.Epoch
+	Version phase0.Version
+	// Digest is the digest of the fork version and the genesis validators root,
+	// used to identify the fork on the network.
+	Digest phase0.ForkDigest
+}
@@ -120,3 +120,8 @@ func (s *service) BellatrixInitialEpoch() phase0.Epoch {
 func (s *service) CapellaInitialEpoch() phase0.Epoch {
 	return 0
 }
+
+// DenebInitialEpoch provides the epoch at which the Deneb hard fork takes place.
+func (s *service) DenebInitialEpoch() phase0.Epoch {
+	return 0
+}
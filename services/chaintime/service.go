@@ -63,4 +63,6 @@ type Service interface {
 	BellatrixInitialEpoch() phase0.Epoch
 	// CapellaInitialEpoch provides the epoch at which the Capella hard fork takes place.
 	CapellaInitialEpoch() phase0.Epoch
+	// DenebInitialEpoch provides the epoch at which the Deneb hard fork takes place.
+	DenebInitialEpoch() phase0.Epoch
 }
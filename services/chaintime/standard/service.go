@@ -17,12 +17,12 @@ import (
 	"context"
 	"time"
 
-	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/attestantio/go-eth2-client/api"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 )
 
 // Service provides chain time services.
@@ -48,7 +48,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "chaintime").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log = util.ServiceLogger("chaintime", "standard", parameters.logLevel)
 
 	genesisResponse, err := parameters.genesisProvider.Genesis(ctx, &api.GenesisOpts{})
 	if err != nil {
@@ -63,58 +63,29 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 	spec := specResponse.Data
 
-	tmp, exists := spec["SECONDS_PER_SLOT"]
-	if !exists {
+	typedSpec := chaindb.NewSpec(spec)
+	slotDuration := typedSpec.SecondsPerSlot
+	if slotDuration == 0 {
 		return nil, errors.New("SECONDS_PER_SLOT not found in spec")
 	}
-	slotDuration, ok := tmp.(time.Duration)
-	if !ok {
-		return nil, errors.New("SECONDS_PER_SLOT of unexpected type")
-	}
-
-	tmp, exists = spec["SLOTS_PER_EPOCH"]
-	if !exists {
+	slotsPerEpoch := typedSpec.SlotsPerEpoch
+	if slotsPerEpoch == 0 {
 		return nil, errors.New("SLOTS_PER_EPOCH not found in spec")
 	}
-	slotsPerEpoch, ok := tmp.(uint64)
-	if !ok {
-		return nil, errors.New("SLOTS_PER_EPOCH of unexpected type")
-	}
+	// EPOCHS_PER_SYNC_COMMITTEE_PERIOD was introduced in Altair, so may not be present.
+	epochsPerSyncCommitteePeriod := typedSpec.EpochsPerSyncCommitteePeriod
 
-	var epochsPerSyncCommitteePeriod uint64
-	if tmp, exists := spec["EPOCHS_PER_SYNC_COMMITTEE_PERIOD"]; exists {
-		tmp2, ok := tmp.(uint64)
-		if !ok {
-			return nil, errors.New("EPOCHS_PER_SYNC_COMMITTEE_PERIOD of unexpected type")
-		}
-		epochsPerSyncCommitteePeriod = tmp2
-	}
-
-	altairForkEpoch, err := fetchAltairForkEpoch(ctx, parameters.specProvider)
+	forks, err := util.ForksFromSpec(spec, genesisResponse.Data.GenesisValidatorsRoot)
 	if err != nil {
-		// Set to far future epoch.
-		altairForkEpoch = 0xffffffffffffffff
+		return nil, errors.Wrap(err, "failed to obtain forks")
 	}
+	altairForkEpoch := util.ForkEpoch(forks, "altair")
 	log.Trace().Uint64("epoch", uint64(altairForkEpoch)).Msg("Obtained Altair fork epoch")
-
-	bellatrixForkEpoch, err := fetchBellatrixForkEpoch(ctx, parameters.specProvider)
-	if err != nil {
-		// Set to far future epoch.
-		bellatrixForkEpoch = 0xffffffffffffffff
-	}
+	bellatrixForkEpoch := util.ForkEpoch(forks, "bellatrix")
 	log.Trace().Uint64("epoch", uint64(bellatrixForkEpoch)).Msg("Obtained Bellatrix fork epoch")
-
-	capellaForkEpoch, err := fetchCapellaForkEpoch(ctx, parameters.specProvider)
-	if err != nil {
-		// Set to far future epoch.
-		capellaForkEpoch = 0xffffffffffffffff
-	}
+	capellaForkEpoch := util.ForkEpoch(forks, "capella")
 	log.Trace().Uint64("epoch", uint64(capellaForkEpoch)).Msg("Obtained Capella fork epoch")
-	denebForkEpoch, err := fetchDenebForkEpoch(ctx, parameters.specProvider)
-	if err != nil {
-		// Set to far future epoch.
-		denebForkEpoch = 0xffffffffffffffff
-	}
+	denebForkEpoch := util.ForkEpoch(forks, "deneb")
 	log.Trace().Uint64("epoch", uint64(denebForkEpoch)).Msg("Obtained Deneb fork epoch")
 
 	s := &Service{
@@ -237,121 +208,17 @@ func (s *Service) AltairInitialSyncCommitteePeriod() uint64 {
 	return uint64(s.altairForkEpoch) / s.epochsPerSyncCommitteePeriod
 }
 
-func fetchAltairForkEpoch(ctx context.Context,
-	specProvider eth2client.SpecProvider,
-) (
-	phase0.Epoch,
-	error,
-) {
-	// Fetch the fork version.
-	specResponse, err := specProvider.Spec(ctx, &api.SpecOpts{})
-	if err != nil {
-		return 0, errors.Wrap(err, "failed to obtain spec")
-	}
-	spec := specResponse.Data
-
-	tmp, exists := spec["ALTAIR_FORK_EPOCH"]
-	if !exists {
-		return 0, errors.New("altair fork version not known by chain")
-	}
-	epoch, isEpoch := tmp.(uint64)
-	if !isEpoch {
-		//nolint:revive
-		return 0, errors.New("ALTAIR_FORK_EPOCH is not a uint64!")
-	}
-
-	return phase0.Epoch(epoch), nil
-}
-
 // BellatrixInitialEpoch provides the epoch at which the Bellatrix hard fork takes place.
 func (s *Service) BellatrixInitialEpoch() phase0.Epoch {
 	return s.bellatrixForkEpoch
 }
 
-func fetchBellatrixForkEpoch(ctx context.Context,
-	specProvider eth2client.SpecProvider,
-) (
-	phase0.Epoch,
-	error,
-) {
-	// Fetch the fork version.
-	specResponse, err := specProvider.Spec(ctx, &api.SpecOpts{})
-	if err != nil {
-		return 0, errors.Wrap(err, "failed to obtain spec")
-	}
-	spec := specResponse.Data
-
-	tmp, exists := spec["BELLATRIX_FORK_EPOCH"]
-	if !exists {
-		return 0, errors.New("bellatrix fork version not known by chain")
-	}
-	epoch, isEpoch := tmp.(uint64)
-	if !isEpoch {
-		//nolint:revive
-		return 0, errors.New("BELLATRIX_FORK_EPOCH is not a uint64!")
-	}
-
-	return phase0.Epoch(epoch), nil
-}
-
 // CapellaInitialEpoch provides the epoch at which the Capella hard fork takes place.
 func (s *Service) CapellaInitialEpoch() phase0.Epoch {
 	return s.capellaForkEpoch
 }
 
-func fetchCapellaForkEpoch(ctx context.Context,
-	specProvider eth2client.SpecProvider,
-) (
-	phase0.Epoch,
-	error,
-) {
-	// Fetch the fork version.
-	specResponse, err := specProvider.Spec(ctx, &api.SpecOpts{})
-	if err != nil {
-		return 0, errors.Wrap(err, "failed to obtain spec")
-	}
-	spec := specResponse.Data
-
-	tmp, exists := spec["CAPELLA_FORK_EPOCH"]
-	if !exists {
-		return 0, errors.New("capella fork version not known by chain")
-	}
-	epoch, isEpoch := tmp.(uint64)
-	if !isEpoch {
-		//nolint:revive
-		return 0, errors.New("CAPELLA_FORK_EPOCH is not a uint64!")
-	}
-
-	return phase0.Epoch(epoch), nil
-}
-
 // DenebInitialEpoch provides the epoch at which the Deneb hard fork takes place.
 func (s *Service) DenebInitialEpoch() phase0.Epoch {
 	return s.denebForkEpoch
 }
-
-func fetchDenebForkEpoch(ctx context.Context,
-	specProvider eth2client.SpecProvider,
-) (
-	phase0.Epoch,
-	error,
-) {
-	// Fetch the fork version.
-	specResponse, err := specProvider.Spec(ctx, &api.SpecOpts{})
-	if err != nil {
-		return 0, errors.Wrap(err, "failed to obtain spec")
-	}
-	spec := specResponse.Data
-
-	tmp, exists := spec["DENEB_FORK_EPOCH"]
-	if !exists {
-		return 0, errors.New("deneb fork version not known by chain")
-	}
-	epoch, isEpoch := tmp.(uint64)
-	if !isEpoch {
-		//nolint:revive
-		return 0, errors.New("DENEB_FORK_EPOCH is not a uint64!")
-	}
-
-	return phase0.Epoch(epoch), nil
-}
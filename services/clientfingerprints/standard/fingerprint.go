@@ -0,0 +1,177 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// slotsPerBatch is the number of slots fingerprinted in a single transaction.
+const slotsPerBatch = uint64(256)
+
+// fingerprint fingerprints blocks that have been confirmed as canonical.
+func (s *Service) fingerprint(ctx context.Context) {
+	// Only allow 1 fingerprint to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another fingerprint running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	if err := s.fingerprintBlocks(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to fingerprint blocks")
+	}
+}
+
+// fingerprintBlocks fingerprints blocks from the last slot processed.
+func (s *Service) fingerprintBlocks(ctx context.Context) error {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	if md.RulesVersion != s.rules.Version {
+		log.Info().Int64("old_version", md.RulesVersion).Int64("new_version", s.rules.Version).Msg("Rules have changed; fingerprinting all blocks again")
+		md.LatestSlot = -1
+		md.RulesVersion = s.rules.Version
+	}
+	releasesVersion := int64(0)
+	if s.releases != nil {
+		releasesVersion = s.releases.Version
+	}
+	if md.ReleasesVersion != releasesVersion {
+		log.Info().Int64("old_version", md.ReleasesVersion).Int64("new_version", releasesVersion).Msg("Releases have changed; annotating all blocks again")
+		md.LatestSlot = -1
+		md.ReleasesVersion = releasesVersion
+	}
+
+	// Only fingerprint blocks up to the latest canonical block, as earlier forks will
+	// also be in the database by then.
+	targetSlot, err := s.blocksProvider.LatestCanonicalBlock(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain latest canonical block")
+	}
+
+	startSlot := phase0.Slot(md.LatestSlot + 1)
+	if startSlot > targetSlot {
+		log.Trace().Uint64("target_slot", uint64(targetSlot)).Msg("No blocks to fingerprint")
+		return nil
+	}
+	endSlot := targetSlot
+	if uint64(endSlot-startSlot) >= s.maxSlotsPerRun {
+		endSlot = startSlot + phase0.Slot(s.maxSlotsPerRun) - 1
+	}
+	log.Trace().Uint64("start_slot", uint64(startSlot)).Uint64("end_slot", uint64(endSlot)).Msg("Fingerprinting blocks")
+
+	for slot := startSlot; slot <= endSlot; slot += phase0.Slot(slotsPerBatch) {
+		batchEndSlot := slot + phase0.Slot(slotsPerBatch) - 1
+		if batchEndSlot > endSlot {
+			batchEndSlot = endSlot
+		}
+		if err := s.fingerprintSlots(ctx, md, slot, batchEndSlot); err != nil {
+			return errors.Wrapf(err, "failed to fingerprint slots %d to %d", slot, batchEndSlot)
+		}
+	}
+
+	return nil
+}
+
+// fingerprintSlots fingerprints the blocks in the given inclusive slot range.
+func (s *Service) fingerprintSlots(ctx context.Context, md *metadata, startSlot phase0.Slot, endSlot phase0.Slot) error {
+	blocks, err := s.blocksProvider.BlocksForSlotRange(ctx, startSlot, endSlot+1)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain blocks")
+	}
+
+	fingerprints := make([]*chaindb.BlockClientFingerprint, 0, len(blocks))
+	releases := make([]*chaindb.BlockClientRelease, 0, len(blocks))
+	for _, block := range blocks {
+		var extraData []byte
+		if block.ExecutionPayload != nil {
+			extraData = block.ExecutionPayload.ExtraData
+		}
+		consensusClient, executionClient := s.rules.Fingerprint(block.Graffiti, extraData)
+		fingerprints = append(fingerprints, &chaindb.BlockClientFingerprint{
+			BlockRoot:       block.Root,
+			Slot:            block.Slot,
+			ConsensusClient: consensusClient,
+			ExecutionClient: executionClient,
+		})
+		if s.releases != nil {
+			releases = append(releases, s.blockRelease(block, extraData, consensusClient, executionClient))
+		}
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.fingerprintsSetter.SetBlockClientFingerprints(ctx, fingerprints); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set block client fingerprints")
+	}
+
+	if s.releases != nil {
+		if err := s.releasesSetter.SetBlockClientReleases(ctx, releases); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set block client releases")
+		}
+	}
+
+	md.LatestSlot = int64(endSlot)
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	monitorSlotsProcessed(endSlot, len(fingerprints))
+
+	return nil
+}
+
+// blockRelease annotates a block with the likely releases of its clients.
+func (s *Service) blockRelease(block *chaindb.Block,
+	extraData []byte,
+	consensusClient string,
+	executionClient string,
+) *chaindb.BlockClientRelease {
+	timestamp := s.chainTime.StartOfSlot(block.Slot)
+
+	blockRelease := &chaindb.BlockClientRelease{
+		BlockRoot: block.Root,
+		Slot:      block.Slot,
+	}
+	if release, matched := s.releases.Release(LayerConsensus, consensusClient, block.Graffiti, extraData, timestamp); release != nil {
+		blockRelease.ConsensusClient = release.Client
+		blockRelease.ConsensusVersion = release.Version
+		blockRelease.ConsensusVersionMatched = matched
+	}
+	if release, matched := s.releases.Release(LayerExecution, executionClient, block.Graffiti, extraData, timestamp); release != nil {
+		blockRelease.ExecutionClient = release.Client
+		blockRelease.ExecutionVersion = release.Version
+		blockRelease.ExecutionVersionMatched = matched
+	}
+
+	return blockRelease
+}
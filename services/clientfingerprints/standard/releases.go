@@ -0,0 +1,175 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Release is a release of a client.
+type Release struct {
+	// Layer is the layer of the client, either "consensus" or "execution".
+	Layer string `json:"layer"`
+	// Client is the name of the client, as returned by the fingerprint rules.
+	Client string `json:"client"`
+	// Version is the version of the release.
+	Version string `json:"version"`
+	// Released is the time at which the release was made available.
+	Released time.Time `json:"released"`
+	// Source is the part of the block to match, either "graffiti" or "extra_data".
+	// Only required if pattern is present.
+	Source string `json:"source,omitempty"`
+	// Pattern is an optional regular expression to match against the source.
+	Pattern string `json:"pattern,omitempty"`
+	// Behaviors are free-form notes about the behavior of the release.
+	Behaviors []string `json:"behaviors,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// ReleaseSet is a set of client releases.
+type ReleaseSet struct {
+	// Version is the version of the releases.  Blocks are annotated again when the version changes.
+	Version int64 `json:"version"`
+	// Releases are the releases.
+	Releases []*Release `json:"releases"`
+}
+
+// ParseReleases parses a JSON release set.
+func ParseReleases(data []byte) (*ReleaseSet, error) {
+	releaseSet := &ReleaseSet{}
+	if err := json.Unmarshal(data, releaseSet); err != nil {
+		return nil, errors.Wrap(err, "invalid JSON")
+	}
+	if releaseSet.Version < 1 {
+		return nil, errors.New("version must be at least 1")
+	}
+
+	seen := make(map[string]bool)
+	for i, release := range releaseSet.Releases {
+		switch release.Layer {
+		case LayerConsensus, LayerExecution:
+		default:
+			return nil, fmt.Errorf("release %d has unknown layer %q", i, release.Layer)
+		}
+		if release.Client == "" {
+			return nil, fmt.Errorf("release %d has no client", i)
+		}
+		if release.Version == "" {
+			return nil, fmt.Errorf("release %d has no version", i)
+		}
+		if release.Released.IsZero() {
+			return nil, fmt.Errorf("release %d has no release time", i)
+		}
+		key := fmt.Sprintf("%s/%s/%s", release.Layer, release.Client, release.Version)
+		if seen[key] {
+			return nil, fmt.Errorf("release %d is a duplicate", i)
+		}
+		seen[key] = true
+		if release.Pattern == "" {
+			continue
+		}
+		switch release.Source {
+		case SourceGraffiti, SourceExtraData:
+		default:
+			return nil, fmt.Errorf("release %d has unknown source %q", i, release.Source)
+		}
+		var err error
+		release.pattern, err = regexp.Compile(release.Pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "release %d has invalid pattern", i)
+		}
+	}
+
+	// Sort releases latest first, so that the most recent release is found first.
+	sort.SliceStable(releaseSet.Releases, func(i int, j int) bool {
+		return releaseSet.Releases[i].Released.After(releaseSet.Releases[j].Released)
+	})
+
+	return releaseSet, nil
+}
+
+// Release returns the likely release of a client for the given layer, given the client
+// obtained from the fingerprint rules and the graffiti, execution payload extra data and
+// time of a block.
+//
+// Releases that were made after the block are ignored.  Of the remainder, the latest
+// release with a pattern that matches the block is used, in which case matched is true.
+// If no pattern matches then the latest release of the fingerprinted client is used, in
+// which case matched is false.  If there is no such release then nil is returned.
+func (r *ReleaseSet) Release(layer string,
+	client string,
+	graffiti []byte,
+	extraData []byte,
+	timestamp time.Time,
+) (
+	*Release,
+	bool,
+) {
+	// Graffiti is zero-padded.
+	graffiti = bytes.TrimRight(graffiti, "\x00")
+
+	var latest *Release
+	for _, release := range r.Releases {
+		if release.Layer != layer || release.Released.After(timestamp) {
+			continue
+		}
+		if release.pattern == nil {
+			if latest == nil && release.Client == client {
+				latest = release
+			}
+			continue
+		}
+
+		var source []byte
+		switch release.Source {
+		case SourceGraffiti:
+			source = graffiti
+		case SourceExtraData:
+			source = extraData
+		}
+		if len(source) > 0 && release.pattern.Match(source) {
+			return release, true
+		}
+		if latest == nil && release.Client == client {
+			latest = release
+		}
+	}
+
+	return latest, false
+}
+
+// chainDBReleases returns the releases in a form suitable for the chain database.
+func (r *ReleaseSet) chainDBReleases() []*chaindb.ClientRelease {
+	releases := make([]*chaindb.ClientRelease, len(r.Releases))
+	for i, release := range r.Releases {
+		releases[i] = &chaindb.ClientRelease{
+			Layer:     release.Layer,
+			Client:    release.Client,
+			Version:   release.Version,
+			Released:  release.Released,
+			Behaviors: release.Behaviors,
+		}
+	}
+
+	return releases
+}
@@ -0,0 +1,172 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/clientfingerprints/standard"
+)
+
+func TestRelease(t *testing.T) {
+	releases, err := standard.ParseReleases([]byte(`{
+  "version": 1,
+  "releases": [
+    {"layer": "consensus", "client": "lighthouse", "version": "v4.4.0", "released": "2023-08-01T00:00:00Z"},
+    {"layer": "consensus", "client": "lighthouse", "version": "v4.5.0", "released": "2023-09-28T00:00:00Z", "source": "graffiti", "pattern": "Lighthouse/v4\\.5\\.0", "behaviors": ["late blocks"]},
+    {"layer": "consensus", "client": "lighthouse", "version": "v4.6.0", "released": "2024-01-01T00:00:00Z"},
+    {"layer": "execution", "client": "geth", "version": "v1.13.4", "released": "2023-10-25T00:00:00Z", "source": "extra_data", "pattern": "geth.*go1\\.21"}
+  ]
+}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		layer     string
+		client    string
+		graffiti  []byte
+		extraData []byte
+		timestamp time.Time
+		version   string
+		matched   bool
+	}{
+		{
+			name:      "BeforeReleases",
+			layer:     standard.LayerConsensus,
+			client:    "lighthouse",
+			timestamp: time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "LatestAtTime",
+			layer:     standard.LayerConsensus,
+			client:    "lighthouse",
+			timestamp: time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC),
+			version:   "v4.5.0",
+		},
+		{
+			name:      "Matched",
+			layer:     standard.LayerConsensus,
+			client:    "lighthouse",
+			graffiti:  append([]byte("Lighthouse/v4.5.0"), make([]byte, 15)...),
+			timestamp: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			version:   "v4.5.0",
+			matched:   true,
+		},
+		{
+			name:      "MatchedBeforeRelease",
+			layer:     standard.LayerConsensus,
+			client:    "lighthouse",
+			graffiti:  []byte("Lighthouse/v4.5.0"),
+			timestamp: time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC),
+			version:   "v4.4.0",
+		},
+		{
+			name:      "UnknownClient",
+			layer:     standard.LayerConsensus,
+			timestamp: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "Execution",
+			layer:     standard.LayerExecution,
+			extraData: []byte("geth go1.21.3 linux"),
+			timestamp: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			version:   "v1.13.4",
+			matched:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			release, matched := releases.Release(test.layer, test.client, test.graffiti, test.extraData, test.timestamp)
+			if test.version == "" {
+				require.Nil(t, release)
+			} else {
+				require.NotNil(t, release)
+				require.Equal(t, test.version, release.Version)
+			}
+			require.Equal(t, test.matched, matched)
+		})
+	}
+}
+
+func TestParseReleases(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{
+			name:  "Invalid",
+			input: "{",
+			err:   "invalid JSON: unexpected end of JSON input",
+		},
+		{
+			name:  "VersionMissing",
+			input: `{"releases":[]}`,
+			err:   "version must be at least 1",
+		},
+		{
+			name:  "LayerInvalid",
+			input: `{"version":1,"releases":[{"layer":"other","client":"x","version":"1","released":"2024-01-01T00:00:00Z"}]}`,
+			err:   "release 0 has unknown layer \"other\"",
+		},
+		{
+			name:  "ClientMissing",
+			input: `{"version":1,"releases":[{"layer":"consensus","version":"1","released":"2024-01-01T00:00:00Z"}]}`,
+			err:   "release 0 has no client",
+		},
+		{
+			name:  "VersionMissing",
+			input: `{"version":1,"releases":[{"layer":"consensus","client":"x","released":"2024-01-01T00:00:00Z"}]}`,
+			err:   "release 0 has no version",
+		},
+		{
+			name:  "ReleasedMissing",
+			input: `{"version":1,"releases":[{"layer":"consensus","client":"x","version":"1"}]}`,
+			err:   "release 0 has no release time",
+		},
+		{
+			name:  "Duplicate",
+			input: `{"version":1,"releases":[{"layer":"consensus","client":"x","version":"1","released":"2024-01-01T00:00:00Z"},{"layer":"consensus","client":"x","version":"1","released":"2024-02-01T00:00:00Z"}]}`,
+			err:   "release 1 is a duplicate",
+		},
+		{
+			name:  "SourceInvalid",
+			input: `{"version":1,"releases":[{"layer":"consensus","client":"x","version":"1","released":"2024-01-01T00:00:00Z","source":"other","pattern":"x"}]}`,
+			err:   "release 0 has unknown source \"other\"",
+		},
+		{
+			name:  "PatternInvalid",
+			input: `{"version":1,"releases":[{"layer":"consensus","client":"x","version":"1","released":"2024-01-01T00:00:00Z","source":"graffiti","pattern":"("}]}`,
+			err:   "release 0 has invalid pattern: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name:  "Good",
+			input: `{"version":1,"releases":[{"layer":"consensus","client":"x","version":"1","released":"2024-01-01T00:00:00Z","source":"graffiti","pattern":"x"}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.ParseReleases([]byte(test.input))
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
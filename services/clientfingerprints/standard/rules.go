@@ -0,0 +1,222 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// LayerConsensus is the layer for consensus clients.
+	LayerConsensus = "consensus"
+	// LayerExecution is the layer for execution clients.
+	LayerExecution = "execution"
+
+	// SourceGraffiti matches against the graffiti of a block.
+	SourceGraffiti = "graffiti"
+	// SourceExtraData matches against the extra data of a block's execution payload.
+	SourceExtraData = "extra_data"
+)
+
+// Rule matches a pattern in a block to a client.
+type Rule struct {
+	// Layer is the layer of the client, either "consensus" or "execution".
+	Layer string `json:"layer"`
+	// Source is the part of the block to match, either "graffiti" or "extra_data".
+	Source string `json:"source"`
+	// Pattern is the regular expression to match against the source.
+	Pattern string `json:"pattern"`
+	// Client is the name of the client if the pattern matches.
+	Client string `json:"client"`
+
+	pattern *regexp.Regexp
+}
+
+// RuleSet is an ordered set of rules.  The first matching rule for each layer is used.
+type RuleSet struct {
+	// Version is the version of the rules.  Blocks are fingerprinted again when the version changes.
+	Version int64 `json:"version"`
+	// Rules are the rules.
+	Rules []*Rule `json:"rules"`
+}
+
+// ParseRules parses a JSON rule set.
+func ParseRules(data []byte) (*RuleSet, error) {
+	ruleSet := &RuleSet{}
+	if err := json.Unmarshal(data, ruleSet); err != nil {
+		return nil, errors.Wrap(err, "invalid JSON")
+	}
+	if ruleSet.Version < 1 {
+		return nil, errors.New("version must be at least 1")
+	}
+
+	for i, rule := range ruleSet.Rules {
+		switch rule.Layer {
+		case LayerConsensus, LayerExecution:
+		default:
+			return nil, fmt.Errorf("rule %d has unknown layer %q", i, rule.Layer)
+		}
+		switch rule.Source {
+		case SourceGraffiti, SourceExtraData:
+		default:
+			return nil, fmt.Errorf("rule %d has unknown source %q", i, rule.Source)
+		}
+		if rule.Client == "" {
+			return nil, fmt.Errorf("rule %d has no client", i)
+		}
+		var err error
+		rule.pattern, err = regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "rule %d has invalid pattern", i)
+		}
+	}
+
+	return ruleSet, nil
+}
+
+// clientCode is the two-letter code of a client used in graffiti.
+type clientCode struct {
+	code   string
+	client string
+}
+
+// executionCodes are the client codes of execution clients.
+var executionCodes = []*clientCode{
+	{code: "BU", client: "besu"},
+	{code: "EJ", client: "ethereumjs"},
+	{code: "EG", client: "erigon"},
+	{code: "GE", client: "geth"},
+	{code: "NM", client: "nethermind"},
+	{code: "RH", client: "reth"},
+}
+
+// consensusCodes are the client codes of consensus clients.
+var consensusCodes = []*clientCode{
+	{code: "GR", client: "grandine"},
+	{code: "LH", client: "lighthouse"},
+	{code: "LS", client: "lodestar"},
+	{code: "NB", client: "nimbus"},
+	{code: "PM", client: "prysm"},
+	{code: "TK", client: "teku"},
+}
+
+// anyCode returns a regular expression that matches any of the supplied codes.
+func anyCode(codes []*clientCode) string {
+	alternatives := make([]string, len(codes))
+	for i := range codes {
+		alternatives[i] = codes[i].code
+	}
+
+	return fmt.Sprintf("(?:%s)", strings.Join(alternatives, "|"))
+}
+
+// DefaultRules returns the default rule set.
+func DefaultRules() *RuleSet {
+	ruleSet := &RuleSet{
+		Version: 1,
+		Rules:   make([]*Rule, 0),
+	}
+
+	// Client version codes, as added to graffiti by consensus clients, are the most reliable indicator.
+	// These take the form <execution code><commit><consensus code><commit>, for example "GEabcdLHabcd".
+	codeRule := func(layer string, client string, executionCode string, consensusCode string) *Rule {
+		pattern := fmt.Sprintf(`(?:^|\s)%s(?:[0-9a-f]{2}|[0-9a-f]{4})?%s(?:[0-9a-f]{2}|[0-9a-f]{4})?$`, executionCode, consensusCode)
+		return &Rule{
+			Layer:   layer,
+			Source:  SourceGraffiti,
+			Pattern: pattern,
+			Client:  client,
+			pattern: regexp.MustCompile(pattern),
+		}
+	}
+	for _, executionCode := range executionCodes {
+		ruleSet.Rules = append(ruleSet.Rules, codeRule(LayerExecution, executionCode.client, executionCode.code, anyCode(consensusCodes)))
+	}
+	for _, consensusCode := range consensusCodes {
+		ruleSet.Rules = append(ruleSet.Rules, codeRule(LayerConsensus, consensusCode.client, anyCode(executionCodes), consensusCode.code))
+	}
+
+	nameRule := func(layer string, source string, pattern string, client string) *Rule {
+		return &Rule{
+			Layer:   layer,
+			Source:  source,
+			Pattern: pattern,
+			Client:  client,
+			pattern: regexp.MustCompile(pattern),
+		}
+	}
+	ruleSet.Rules = append(ruleSet.Rules,
+		nameRule(LayerConsensus, SourceGraffiti, `(?i)grandine`, "grandine"),
+		nameRule(LayerConsensus, SourceGraffiti, `(?i)lighthouse`, "lighthouse"),
+		nameRule(LayerConsensus, SourceGraffiti, `(?i)lodestar`, "lodestar"),
+		nameRule(LayerConsensus, SourceGraffiti, `(?i)nimbus`, "nimbus"),
+		nameRule(LayerConsensus, SourceGraffiti, `(?i)prysm`, "prysm"),
+		nameRule(LayerConsensus, SourceGraffiti, `(?i)teku`, "teku"),
+		nameRule(LayerExecution, SourceExtraData, `(?i)besu`, "besu"),
+		nameRule(LayerExecution, SourceExtraData, `(?i)ethereumjs`, "ethereumjs"),
+		nameRule(LayerExecution, SourceExtraData, `(?i)erigon`, "erigon"),
+		nameRule(LayerExecution, SourceExtraData, `(?i)geth`, "geth"),
+		nameRule(LayerExecution, SourceExtraData, `(?i)nethermind`, "nethermind"),
+		nameRule(LayerExecution, SourceExtraData, `(?i)(?:^|[^a-z])reth(?:[^a-z]|$)`, "reth"),
+	)
+
+	return ruleSet
+}
+
+// Fingerprint returns the likely consensus and execution clients given the graffiti
+// and execution payload extra data of a block.  Unknown clients are returned as blank.
+func (r *RuleSet) Fingerprint(graffiti []byte, extraData []byte) (string, string) {
+	// Graffiti is zero-padded.
+	graffiti = bytes.TrimRight(graffiti, "\x00")
+
+	consensusClient := ""
+	executionClient := ""
+	for _, rule := range r.Rules {
+		if consensusClient != "" && executionClient != "" {
+			break
+		}
+		if rule.Layer == LayerConsensus && consensusClient != "" {
+			continue
+		}
+		if rule.Layer == LayerExecution && executionClient != "" {
+			continue
+		}
+
+		var source []byte
+		switch rule.Source {
+		case SourceGraffiti:
+			source = graffiti
+		case SourceExtraData:
+			source = extraData
+		}
+		if len(source) == 0 || !rule.pattern.Match(source) {
+			continue
+		}
+
+		switch rule.Layer {
+		case LayerConsensus:
+			consensusClient = rule.Client
+		case LayerExecution:
+			executionClient = rule.Client
+		}
+	}
+
+	return consensusClient, executionClient
+}
@@ -0,0 +1,137 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/clientfingerprints/standard"
+)
+
+func TestFingerprint(t *testing.T) {
+	rules := standard.DefaultRules()
+
+	tests := []struct {
+		name      string
+		graffiti  []byte
+		extraData []byte
+		consensus string
+		execution string
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name:      "ClientCodes",
+			graffiti:  append([]byte("GE1a2bLH3c4d"), make([]byte, 20)...),
+			consensus: "lighthouse",
+			execution: "geth",
+		},
+		{
+			name:      "ShortClientCodes",
+			graffiti:  []byte("my validator NMabTKcd"),
+			consensus: "teku",
+			execution: "nethermind",
+		},
+		{
+			name:      "GraffitiName",
+			graffiti:  []byte("Lighthouse/v4.5.0"),
+			consensus: "lighthouse",
+		},
+		{
+			name:      "ExtraData",
+			graffiti:  []byte("prysm-validator"),
+			extraData: []byte{0xd8, 0x83, 0x01, 0x0d, 0x0e, 0x84, 'g', 'e', 't', 'h', 0x88, 'g', 'o', '1', '.', '2', '1', '.', '5', 0x85, 'l', 'i', 'n', 'u', 'x'},
+			consensus: "prysm",
+			execution: "geth",
+		},
+		{
+			name:      "CodesOverrideExtraData",
+			graffiti:  []byte("RHLS"),
+			extraData: []byte("Nethermind v1.25.0"),
+			consensus: "lodestar",
+			execution: "reth",
+		},
+		{
+			name:      "Unknown",
+			graffiti:  []byte("hello world"),
+			extraData: []byte("beaverbuild.org"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			consensus, execution := rules.Fingerprint(test.graffiti, test.extraData)
+			require.Equal(t, test.consensus, consensus)
+			require.Equal(t, test.execution, execution)
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{
+			name:  "Invalid",
+			input: "{",
+			err:   "invalid JSON: unexpected end of JSON input",
+		},
+		{
+			name:  "VersionMissing",
+			input: `{"rules":[]}`,
+			err:   "version must be at least 1",
+		},
+		{
+			name:  "LayerInvalid",
+			input: `{"version":2,"rules":[{"layer":"other","source":"graffiti","pattern":"x","client":"x"}]}`,
+			err:   "rule 0 has unknown layer \"other\"",
+		},
+		{
+			name:  "SourceInvalid",
+			input: `{"version":2,"rules":[{"layer":"consensus","source":"other","pattern":"x","client":"x"}]}`,
+			err:   "rule 0 has unknown source \"other\"",
+		},
+		{
+			name:  "ClientMissing",
+			input: `{"version":2,"rules":[{"layer":"consensus","source":"graffiti","pattern":"x"}]}`,
+			err:   "rule 0 has no client",
+		},
+		{
+			name:  "PatternInvalid",
+			input: `{"version":2,"rules":[{"layer":"consensus","source":"graffiti","pattern":"(","client":"x"}]}`,
+			err:   "rule 0 has invalid pattern: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name:  "Good",
+			input: `{"version":2,"rules":[{"layer":"consensus","source":"graffiti","pattern":"(?i)myclient","client":"myclient"}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules, err := standard.ParseRules([]byte(test.input))
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				consensus, _ := rules.Fingerprint([]byte("MyClient"), nil)
+				require.Equal(t, "myclient", consensus)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// Service is a client fingerprints service.
+type Service struct {
+	chainDB            chaindb.Service
+	chainTime          chaintime.Service
+	blocksProvider     chaindb.BlocksProvider
+	fingerprintsSetter chaindb.BlockClientFingerprintsSetter
+	releasesSetter     chaindb.BlockClientReleasesSetter
+	rules              *RuleSet
+	releases           *ReleaseSet
+	maxSlotsPerRun     uint64
+	activitySem        *semaphore.Weighted
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("clientfingerprints", "standard", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	blocksProvider, isBlocksProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isBlocksProvider {
+		return nil, errors.New("chain DB does not support block providing")
+	}
+
+	fingerprintsSetter, isFingerprintsSetter := parameters.chainDB.(chaindb.BlockClientFingerprintsSetter)
+	if !isFingerprintsSetter {
+		return nil, errors.New("chain DB does not support block client fingerprint setting")
+	}
+
+	var releasesSetter chaindb.BlockClientReleasesSetter
+	if parameters.releases != nil {
+		var isReleasesSetter bool
+		releasesSetter, isReleasesSetter = parameters.chainDB.(chaindb.BlockClientReleasesSetter)
+		if !isReleasesSetter {
+			return nil, errors.New("chain DB does not support block client release setting")
+		}
+		if err := storeReleases(ctx, parameters.chainDB, parameters.releases); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Service{
+		chainDB:            parameters.chainDB,
+		chainTime:          parameters.chainTime,
+		blocksProvider:     blocksProvider,
+		fingerprintsSetter: fingerprintsSetter,
+		releasesSetter:     releasesSetter,
+		rules:              parameters.rules,
+		releases:           parameters.releases,
+		maxSlotsPerRun:     parameters.maxSlotsPerRun,
+		activitySem:        semaphore.NewWeighted(1),
+	}
+
+	// Fingerprint once per epoch.
+	runtimeFunc := func(ctx context.Context, data any) (time.Time, error) {
+		return s.chainTime.StartOfEpoch(s.chainTime.CurrentEpoch() + 1), nil
+	}
+	jobFunc := func(ctx context.Context, data any) {
+		s := data.(*Service)
+		s.fingerprint(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "clientfingerprints", "fingerprint",
+		runtimeFunc,
+		nil,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic fingerprint")
+	}
+
+	return s, nil
+}
+
+// storeReleases stores the client releases in the chain database, replacing any existing releases.
+func storeReleases(ctx context.Context, chainDB chaindb.Service, releases *ReleaseSet) error {
+	setter, isSetter := chainDB.(chaindb.ClientReleasesSetter)
+	if !isSetter {
+		return errors.New("chain DB does not support client release setting")
+	}
+
+	ctx, cancel, err := chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := setter.SetClientReleases(ctx, releases.chainDBReleases()); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set client releases")
+	}
+	if err := chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
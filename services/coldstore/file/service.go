@@ -0,0 +1,90 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/coldstore"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a cold storage service backed by the local filesystem.
+type Service struct {
+	baseDir string
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("coldstore", "file", parameters.logLevel)
+
+	if err := os.MkdirAll(parameters.baseDir, 0o700); err != nil {
+		return nil, errors.Wrap(err, "failed to create base directory")
+	}
+
+	return &Service{
+		baseDir: parameters.baseDir,
+	}, nil
+}
+
+// Put stores data under the given key, overwriting any existing data.
+func (s *Service) Put(_ context.Context, key string, data []byte) error {
+	path := s.Location(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.Wrap(err, "failed to create directory")
+	}
+
+	// Write to a temporary file and rename, so that readers never see partial data.
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return errors.Wrap(err, "failed to write data")
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.Wrap(err, "failed to move data in to place")
+	}
+	log.Trace().Str("path", path).Int("bytes", len(data)).Msg("Stored data")
+
+	return nil
+}
+
+// Get fetches the data stored under the given key.
+func (s *Service) Get(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.Location(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, coldstore.ErrNotFound
+		}
+		return nil, errors.Wrap(err, "failed to read data")
+	}
+
+	return data, nil
+}
+
+// Location provides the full location of the given key.
+func (s *Service) Location(key string) string {
+	return filepath.Join(s.baseDir, filepath.FromSlash(key))
+}
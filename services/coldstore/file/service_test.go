@@ -0,0 +1,83 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/coldstore"
+	"github.com/wealdtech/chaind/services/coldstore/file"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []file.Parameter
+		err    string
+	}{
+		{
+			name: "BaseDirMissing",
+			params: []file.Parameter{
+				file.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no base directory specified",
+		},
+		{
+			name: "Good",
+			params: []file.Parameter{
+				file.WithLogLevel(zerolog.Disabled),
+				file.WithBaseDir(t.TempDir()),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := file.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPutGet(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := file.New(ctx,
+		file.WithLogLevel(zerolog.Disabled),
+		file.WithBaseDir(t.TempDir()),
+	)
+	require.NoError(t, err)
+
+	_, err = s.Get(ctx, "validator_balances/1.jsonl.gz")
+	require.ErrorIs(t, err, coldstore.ErrNotFound)
+
+	require.NoError(t, s.Put(ctx, "validator_balances/1.jsonl.gz", []byte("data")))
+	data, err := s.Get(ctx, "validator_balances/1.jsonl.gz")
+	require.NoError(t, err)
+	require.Equal(t, []byte("data"), data)
+
+	require.NoError(t, s.Put(ctx, "validator_balances/1.jsonl.gz", []byte("new data")))
+	data, err = s.Get(ctx, "validator_balances/1.jsonl.gz")
+	require.NoError(t, err)
+	require.Equal(t, []byte("new data"), data)
+}
@@ -0,0 +1,65 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coldstore
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// MarshalJSONL encodes the items as gzip-compressed JSON lines.
+func MarshalJSONL[T any](items []T) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	writer := gzip.NewWriter(buf)
+	encoder := json.NewEncoder(writer)
+	for _, item := range items {
+		if err := encoder.Encode(item); err != nil {
+			return nil, errors.Wrap(err, "failed to encode item")
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to compress items")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSONL decodes gzip-compressed JSON lines in to items.
+func UnmarshalJSONL[T any](data []byte) ([]T, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decompress items")
+	}
+	defer reader.Close()
+
+	items := make([]T, 0)
+	decoder := json.NewDecoder(bufio.NewReader(reader))
+	for {
+		var item T
+		if err := decoder.Decode(&item); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, errors.Wrap(err, "failed to decode item")
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
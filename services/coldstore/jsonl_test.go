@@ -0,0 +1,56 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coldstore_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/coldstore"
+)
+
+func TestJSONL(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []*chaindb.ValidatorBalance
+	}{
+		{
+			name:  "Empty",
+			items: []*chaindb.ValidatorBalance{},
+		},
+		{
+			name: "Multiple",
+			items: []*chaindb.ValidatorBalance{
+				{Index: 1, Epoch: 2, Balance: 32000000000, EffectiveBalance: 32000000000},
+				{Index: 2, Epoch: 2, Balance: 31999999999, EffectiveBalance: 31000000000},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := coldstore.MarshalJSONL(test.items)
+			require.NoError(t, err)
+			items, err := coldstore.UnmarshalJSONL[*chaindb.ValidatorBalance](data)
+			require.NoError(t, err)
+			require.Equal(t, test.items, items)
+		})
+	}
+}
+
+func TestUnmarshalJSONLBadData(t *testing.T) {
+	_, err := coldstore.UnmarshalJSONL[*chaindb.ValidatorBalance]([]byte("not gzip"))
+	require.Error(t, err)
+}
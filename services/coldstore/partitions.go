@@ -0,0 +1,70 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coldstore
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// ValidatorPartitionSize is the number of validators whose data is held in each partition of
+// offloaded per-validator data, so that the data for a few validators can be read without
+// reading that of every validator.
+const ValidatorPartitionSize = 16384
+
+// ValidatorPartition provides the partition that holds the data for the given validator.
+func ValidatorPartition(index phase0.ValidatorIndex) uint64 {
+	return uint64(index) / ValidatorPartitionSize
+}
+
+// ValidatorPartitions provides the partitions that hold the data for the given validators, in order.
+func ValidatorPartitions(indices []phase0.ValidatorIndex) []uint64 {
+	present := make(map[uint64]bool, len(indices))
+	partitions := make([]uint64, 0)
+	for _, index := range indices {
+		partition := ValidatorPartition(index)
+		if !present[partition] {
+			present[partition] = true
+			partitions = append(partitions, partition)
+		}
+	}
+	sort.Slice(partitions, func(i int, j int) bool {
+		return partitions[i] < partitions[j]
+	})
+
+	return partitions
+}
+
+// PartitionKey provides the key for a partition of the data stored under the given key.
+func PartitionKey(key string, partition uint64) string {
+	return fmt.Sprintf("%s/%d.jsonl.gz", key, partition)
+}
+
+// PartitionByValidator splits items in to partitions by their validator.  Partitions are returned
+// from the first up to that holding the highest validator, with empty partitions for any gaps, so
+// that a reader can stop at the first missing partition.
+func PartitionByValidator[T any](items []T, index func(T) phase0.ValidatorIndex) [][]T {
+	partitions := make([][]T, 0)
+	for _, item := range items {
+		partition := ValidatorPartition(index(item))
+		for uint64(len(partitions)) <= partition {
+			partitions = append(partitions, make([]T, 0))
+		}
+		partitions[partition] = append(partitions[partition], item)
+	}
+
+	return partitions
+}
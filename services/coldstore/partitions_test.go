@@ -0,0 +1,76 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coldstore_test
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/coldstore"
+)
+
+func TestValidatorPartitions(t *testing.T) {
+	tests := []struct {
+		name       string
+		indices    []phase0.ValidatorIndex
+		partitions []uint64
+	}{
+		{
+			name:       "Empty",
+			partitions: []uint64{},
+		},
+		{
+			name:       "Single",
+			indices:    []phase0.ValidatorIndex{coldstore.ValidatorPartitionSize - 1},
+			partitions: []uint64{0},
+		},
+		{
+			name:       "Multiple",
+			indices:    []phase0.ValidatorIndex{3 * coldstore.ValidatorPartitionSize, 1, coldstore.ValidatorPartitionSize, 2},
+			partitions: []uint64{0, 1, 3},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.partitions, coldstore.ValidatorPartitions(test.indices))
+		})
+	}
+}
+
+func TestPartitionKey(t *testing.T) {
+	require.Equal(t, "validator_balances/5/2.jsonl.gz", coldstore.PartitionKey("validator_balances/5", 2))
+}
+
+func TestPartitionByValidator(t *testing.T) {
+	balances := []*chaindb.ValidatorBalance{
+		{Index: 0},
+		{Index: 2*coldstore.ValidatorPartitionSize + 1},
+		{Index: 1},
+	}
+	partitions := coldstore.PartitionByValidator(balances, func(balance *chaindb.ValidatorBalance) phase0.ValidatorIndex {
+		return balance.Index
+	})
+	require.Equal(t, [][]*chaindb.ValidatorBalance{
+		{balances[0], balances[2]},
+		{},
+		{balances[1]},
+	}, partitions)
+
+	require.Empty(t, coldstore.PartitionByValidator([]*chaindb.ValidatorBalance{}, func(balance *chaindb.ValidatorBalance) phase0.ValidatorIndex {
+		return balance.Index
+	}))
+}
@@ -0,0 +1,119 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel  zerolog.Level
+	region    string
+	endpoint  string
+	bucket    string
+	prefix    string
+	id        string
+	secret    string
+	pathStyle bool
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(p *parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithRegion sets the region for this module.
+func WithRegion(region string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.region = region
+	})
+}
+
+// WithEndpoint sets the endpoint for this module.
+// This allows S3-compatible stores, for example Google Cloud Storage at
+// https://storage.googleapis.com, to be used.
+func WithEndpoint(endpoint string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.endpoint = endpoint
+	})
+}
+
+// WithBucket sets the bucket for this module.
+func WithBucket(bucket string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.bucket = bucket
+	})
+}
+
+// WithPrefix sets the prefix for keys stored by this module.
+func WithPrefix(prefix string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.prefix = prefix
+	})
+}
+
+// WithCredentials sets the static credentials for this module.
+// If not supplied, the default AWS credentials chain is used.
+func WithCredentials(id string, secret string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.id = id
+		p.secret = secret
+	})
+}
+
+// WithPathStyle sets path-style addressing of buckets for this module.
+func WithPathStyle(pathStyle bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.pathStyle = pathStyle
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.bucket == "" {
+		return nil, errors.New("no bucket specified")
+	}
+	if parameters.region == "" && parameters.endpoint == "" {
+		return nil, errors.New("no region or endpoint specified")
+	}
+	if (parameters.id == "") != (parameters.secret == "") {
+		return nil, errors.New("credentials require both ID and secret")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,129 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/coldstore"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a cold storage service backed by S3 or an S3-compatible store.
+type Service struct {
+	client *awss3.S3
+	bucket string
+	prefix string
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("coldstore", "s3", parameters.logLevel)
+
+	config := aws.NewConfig()
+	if parameters.region != "" {
+		config = config.WithRegion(parameters.region)
+	} else {
+		// Region is required by the SDK even if not used by the endpoint.
+		config = config.WithRegion("auto")
+	}
+	if parameters.endpoint != "" {
+		config = config.WithEndpoint(parameters.endpoint)
+	}
+	if parameters.id != "" {
+		config = config.WithCredentials(credentials.NewStaticCredentials(parameters.id, parameters.secret, ""))
+	}
+	config = config.WithS3ForcePathStyle(parameters.pathStyle)
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create session")
+	}
+
+	return &Service{
+		client: awss3.New(sess),
+		bucket: parameters.bucket,
+		prefix: strings.TrimSuffix(parameters.prefix, "/"),
+	}, nil
+}
+
+// Put stores data under the given key, overwriting any existing data.
+func (s *Service) Put(ctx context.Context, key string, data []byte) error {
+	if _, err := s.client.PutObjectWithContext(ctx, &awss3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(key)),
+		Body:   bytes.NewReader(data),
+	}); err != nil {
+		return errors.Wrap(err, "failed to put object")
+	}
+	log.Trace().Str("key", s.objectKey(key)).Int("bytes", len(data)).Msg("Stored data")
+
+	return nil
+}
+
+// Get fetches the data stored under the given key.
+func (s *Service) Get(ctx context.Context, key string) ([]byte, error) {
+	output, err := s.client.GetObjectWithContext(ctx, &awss3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(key)),
+	})
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == awss3.ErrCodeNoSuchKey {
+			return nil, coldstore.ErrNotFound
+		}
+		return nil, errors.Wrap(err, "failed to get object")
+	}
+	defer output.Body.Close()
+
+	data, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read object")
+	}
+
+	return data, nil
+}
+
+// Location provides the full location of the given key.
+func (s *Service) Location(key string) string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.objectKey(key))
+}
+
+func (s *Service) objectKey(key string) string {
+	if s.prefix == "" {
+		return key
+	}
+
+	return fmt.Sprintf("%s/%s", s.prefix, key)
+}
@@ -0,0 +1,93 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/coldstore/s3"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		params   []s3.Parameter
+		err      string
+		location string
+	}{
+		{
+			name: "BucketMissing",
+			params: []s3.Parameter{
+				s3.WithLogLevel(zerolog.Disabled),
+				s3.WithRegion("us-east-1"),
+			},
+			err: "problem with parameters: no bucket specified",
+		},
+		{
+			name: "RegionMissing",
+			params: []s3.Parameter{
+				s3.WithLogLevel(zerolog.Disabled),
+				s3.WithBucket("chaind"),
+			},
+			err: "problem with parameters: no region or endpoint specified",
+		},
+		{
+			name: "SecretMissing",
+			params: []s3.Parameter{
+				s3.WithLogLevel(zerolog.Disabled),
+				s3.WithBucket("chaind"),
+				s3.WithRegion("us-east-1"),
+				s3.WithCredentials("id", ""),
+			},
+			err: "problem with parameters: credentials require both ID and secret",
+		},
+		{
+			name: "Good",
+			params: []s3.Parameter{
+				s3.WithLogLevel(zerolog.Disabled),
+				s3.WithBucket("chaind"),
+				s3.WithRegion("us-east-1"),
+			},
+			location: "s3://chaind/validator_balances/1.jsonl.gz",
+		},
+		{
+			name: "GoodEndpointPrefix",
+			params: []s3.Parameter{
+				s3.WithLogLevel(zerolog.Disabled),
+				s3.WithBucket("chaind"),
+				s3.WithEndpoint("https://storage.googleapis.com"),
+				s3.WithPrefix("mainnet/"),
+				s3.WithCredentials("id", "secret"),
+			},
+			location: "s3://chaind/mainnet/validator_balances/1.jsonl.gz",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := s3.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.location, s.Location("validator_balances/1.jsonl.gz"))
+			}
+		})
+	}
+}
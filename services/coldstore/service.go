@@ -0,0 +1,35 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coldstore
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned when the requested key is not present in the store.
+var ErrNotFound = errors.New("not found")
+
+// Service is a cold storage service, holding data offloaded from the chain database.
+type Service interface {
+	// Put stores data under the given key, overwriting any existing data.
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Get fetches the data stored under the given key.
+	// Returns ErrNotFound if the key is not present.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Location provides the full location of the given key, for reference.
+	Location(key string) string
+}
@@ -0,0 +1,231 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// vote is a distinct piece of attestation data.
+type vote struct {
+	slot   phase0.Slot
+	root   phase0.Root
+	source phase0.Epoch
+	target phase0.Epoch
+}
+
+// epochVotes holds the votes made by validators for a target epoch.
+type epochVotes struct {
+	epoch phase0.Epoch
+	votes []*vote
+	// first is the index of the first vote seen for each validator, or -1 if the validator did not vote.
+	first []int32
+	// others are the indices of any additional distinct votes seen for each validator.
+	others map[phase0.ValidatorIndex][]int32
+}
+
+// newEpochVotes builds the votes for an epoch from the attestations with slots in that epoch.
+func newEpochVotes(epoch phase0.Epoch, attestations []*chaindb.Attestation) (*epochVotes, error) {
+	maxIndex := -1
+	for _, attestation := range attestations {
+		for _, index := range attestation.AggregationIndices {
+			if int(index) > maxIndex {
+				maxIndex = int(index)
+			}
+		}
+	}
+
+	e := &epochVotes{
+		epoch:  epoch,
+		votes:  make([]*vote, 0),
+		first:  make([]int32, maxIndex+1),
+		others: make(map[phase0.ValidatorIndex][]int32),
+	}
+	for i := range e.first {
+		e.first[i] = -1
+	}
+
+	voteIndices := make(map[phase0.Root]int32)
+	for _, attestation := range attestations {
+		data := &phase0.AttestationData{
+			Slot:            attestation.Slot,
+			Index:           attestation.CommitteeIndex,
+			BeaconBlockRoot: attestation.BeaconBlockRoot,
+			Source: &phase0.Checkpoint{
+				Epoch: attestation.SourceEpoch,
+				Root:  attestation.SourceRoot,
+			},
+			Target: &phase0.Checkpoint{
+				Epoch: attestation.TargetEpoch,
+				Root:  attestation.TargetRoot,
+			},
+		}
+		root, err := data.HashTreeRoot()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to calculate attestation data root")
+		}
+		voteIndex, exists := voteIndices[root]
+		if !exists {
+			voteIndex = int32(len(e.votes))
+			voteIndices[root] = voteIndex
+			e.votes = append(e.votes, &vote{
+				slot:   attestation.Slot,
+				root:   root,
+				source: attestation.SourceEpoch,
+				target: attestation.TargetEpoch,
+			})
+		}
+
+		for _, index := range attestation.AggregationIndices {
+			e.addVote(index, voteIndex)
+		}
+	}
+
+	return e, nil
+}
+
+// addVote adds a vote for a validator, ignoring votes that have already been seen.
+func (e *epochVotes) addVote(index phase0.ValidatorIndex, voteIndex int32) {
+	if e.first[index] == -1 {
+		e.first[index] = voteIndex
+		return
+	}
+	if e.first[index] == voteIndex {
+		return
+	}
+	for _, other := range e.others[index] {
+		if other == voteIndex {
+			return
+		}
+	}
+	e.others[index] = append(e.others[index], voteIndex)
+}
+
+// validatorVotes returns the distinct votes made by a validator.
+func (e *epochVotes) validatorVotes(index phase0.ValidatorIndex) []*vote {
+	if int(index) >= len(e.first) || e.first[index] == -1 {
+		return nil
+	}
+	votes := []*vote{e.votes[e.first[index]]}
+	for _, other := range e.others[index] {
+		votes = append(votes, e.votes[other])
+	}
+
+	return votes
+}
+
+// doubleVotes returns the equivocations found in the votes for an epoch,
+// being any pair of distinct votes for the same target epoch by the same validator.
+func doubleVotes(e *epochVotes) []*chaindb.Equivocation {
+	equivocations := make([]*chaindb.Equivocation, 0)
+	for index := range e.others {
+		votes := e.validatorVotes(index)
+		sort.Slice(votes, func(i int, j int) bool {
+			if votes[i].slot != votes[j].slot {
+				return votes[i].slot < votes[j].slot
+			}
+			return bytes.Compare(votes[i].root[:], votes[j].root[:]) < 0
+		})
+		for i := 0; i < len(votes); i++ {
+			for j := i + 1; j < len(votes); j++ {
+				equivocations = append(equivocations, attesterEquivocation(chaindb.EquivocationTypeDoubleVote, index, votes[i], votes[j]))
+			}
+		}
+	}
+
+	sortEquivocations(equivocations)
+
+	return equivocations
+}
+
+// surroundVotes returns the equivocations found between the votes for an epoch
+// and the votes for previous epochs, being any vote for the epoch that surrounds
+// a vote for a previous epoch by the same validator.
+//
+// A vote for a previous epoch has an earlier target than a vote for this epoch, so
+// it cannot surround it; a vote for this epoch surrounds it if its source is earlier.
+func surroundVotes(e *epochVotes, previous []*epochVotes) []*chaindb.Equivocation {
+	equivocations := make([]*chaindb.Equivocation, 0)
+	for i := range e.first {
+		index := phase0.ValidatorIndex(i)
+		surrounding := minSourceVote(e.validatorVotes(index))
+		if surrounding == nil {
+			continue
+		}
+		for _, p := range previous {
+			if p.epoch >= e.epoch {
+				continue
+			}
+			surrounded := maxSourceVote(p.validatorVotes(index))
+			if surrounded == nil || surrounding.source >= surrounded.source {
+				continue
+			}
+			equivocations = append(equivocations, attesterEquivocation(chaindb.EquivocationTypeSurroundVote, index, surrounded, surrounding))
+		}
+	}
+
+	sortEquivocations(equivocations)
+
+	return equivocations
+}
+
+// minSourceVote returns the vote with the earliest source.
+func minSourceVote(votes []*vote) *vote {
+	var res *vote
+	for _, v := range votes {
+		if res == nil || v.source < res.source {
+			res = v
+		}
+	}
+
+	return res
+}
+
+// maxSourceVote returns the vote with the latest source.
+func maxSourceVote(votes []*vote) *vote {
+	var res *vote
+	for _, v := range votes {
+		if res == nil || v.source > res.source {
+			res = v
+		}
+	}
+
+	return res
+}
+
+// attesterEquivocation creates an equivocation from two votes.
+func attesterEquivocation(equivocationType string, index phase0.ValidatorIndex, vote1 *vote, vote2 *vote) *chaindb.Equivocation {
+	sourceEpoch1 := vote1.source
+	targetEpoch1 := vote1.target
+	sourceEpoch2 := vote2.source
+	targetEpoch2 := vote2.target
+
+	return &chaindb.Equivocation{
+		Type:           equivocationType,
+		ValidatorIndex: index,
+		Slot1:          vote1.slot,
+		Root1:          vote1.root,
+		Slot2:          vote2.slot,
+		Root2:          vote2.root,
+		SourceEpoch1:   &sourceEpoch1,
+		TargetEpoch1:   &targetEpoch1,
+		SourceEpoch2:   &sourceEpoch2,
+		TargetEpoch2:   &targetEpoch2,
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// detect detects equivocations in epochs that have been confirmed as canonical.
+func (s *Service) detect(ctx context.Context) {
+	// Only allow 1 detection to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another detection running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	if err := s.updateSlashed(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to update slashed status of equivocations")
+	}
+
+	if err := s.detectEquivocations(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to detect equivocations")
+	}
+}
+
+// detectEquivocations detects equivocations from the last epoch processed.
+func (s *Service) detectEquivocations(ctx context.Context) error {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	latestCanonicalSlot, err := s.blocksProvider.LatestCanonicalBlock(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain latest canonical block")
+	}
+
+	// Attestations for an epoch can be included until the end of the following
+	// epoch, so only check epochs for which the following epoch is canonical.
+	latestCanonicalEpoch := s.chainTime.SlotToEpoch(latestCanonicalSlot)
+	if latestCanonicalEpoch < 2 {
+		log.Trace().Msg("Chain not yet canonical enough to detect equivocations")
+		return nil
+	}
+	targetEpoch := latestCanonicalEpoch - 2
+
+	startEpoch := phase0.Epoch(md.LatestEpoch + 1)
+	if startEpoch > targetEpoch {
+		log.Trace().Uint64("target_epoch", uint64(targetEpoch)).Msg("No epochs to check")
+		return nil
+	}
+	endEpoch := targetEpoch
+	if uint64(endEpoch-startEpoch) >= s.maxEpochsPerRun {
+		endEpoch = startEpoch + phase0.Epoch(s.maxEpochsPerRun) - 1
+	}
+	log.Trace().Uint64("start_epoch", uint64(startEpoch)).Uint64("end_epoch", uint64(endEpoch)).Msg("Detecting equivocations")
+
+	for epoch := startEpoch; epoch <= endEpoch; epoch++ {
+		if err := s.detectEpoch(ctx, md, epoch); err != nil {
+			return errors.Wrapf(err, "failed to detect equivocations for epoch %d", epoch)
+		}
+	}
+
+	return nil
+}
+
+// detectEpoch detects equivocations in the given epoch.
+func (s *Service) detectEpoch(ctx context.Context, md *metadata, epoch phase0.Epoch) error {
+	blocks, err := s.blocksProvider.BlocksForSlotRange(ctx, s.chainTime.FirstSlotOfEpoch(epoch), s.chainTime.FirstSlotOfEpoch(epoch+1))
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain blocks")
+	}
+	equivocations := proposerEquivocations(blocks)
+
+	votes, err := s.epochVotes(ctx, epoch)
+	if err != nil {
+		return err
+	}
+	equivocations = append(equivocations, doubleVotes(votes)...)
+
+	previousVotes, err := s.previousEpochVotes(ctx, epoch)
+	if err != nil {
+		return err
+	}
+	equivocations = append(equivocations, surroundVotes(votes, previousVotes)...)
+
+	for _, equivocation := range equivocations {
+		if err := s.setSlashed(ctx, equivocation); err != nil {
+			return err
+		}
+		log.Info().
+			Str("type", equivocation.Type).
+			Uint64("validator_index", uint64(equivocation.ValidatorIndex)).
+			Uint64("slot", uint64(equivocation.Slot2)).
+			Bool("slashed", equivocation.Slashed).
+			Msg("Equivocation found")
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.equivocationsSetter.SetEquivocations(ctx, equivocations); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set equivocations")
+	}
+
+	md.LatestEpoch = int64(epoch)
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	s.previousVotes[epoch] = votes
+	for _, equivocation := range equivocations {
+		monitorEquivocationFound(equivocation.Type)
+	}
+	monitorEpochProcessed(epoch)
+
+	return nil
+}
+
+// epochVotes obtains the votes for the given epoch.
+func (s *Service) epochVotes(ctx context.Context, epoch phase0.Epoch) (*epochVotes, error) {
+	attestations, err := s.attestationsProvider.AttestationsForSlotRange(ctx, s.chainTime.FirstSlotOfEpoch(epoch), s.chainTime.FirstSlotOfEpoch(epoch+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attestations")
+	}
+
+	votes, err := newEpochVotes(epoch, attestations)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain votes")
+	}
+
+	return votes, nil
+}
+
+// previousEpochVotes obtains the votes for the epochs prior to the given epoch
+// that are checked for surround votes, and discards votes that are no longer required.
+func (s *Service) previousEpochVotes(ctx context.Context, epoch phase0.Epoch) ([]*epochVotes, error) {
+	firstEpoch := phase0.Epoch(0)
+	if uint64(epoch) > s.surroundLookback {
+		firstEpoch = epoch - phase0.Epoch(s.surroundLookback)
+	}
+
+	for previousEpoch := range s.previousVotes {
+		if previousEpoch < firstEpoch || previousEpoch >= epoch {
+			delete(s.previousVotes, previousEpoch)
+		}
+	}
+
+	res := make([]*epochVotes, 0, epoch-firstEpoch)
+	for previousEpoch := firstEpoch; previousEpoch < epoch; previousEpoch++ {
+		votes, exists := s.previousVotes[previousEpoch]
+		if !exists {
+			var err error
+			votes, err = s.epochVotes(ctx, previousEpoch)
+			if err != nil {
+				return nil, err
+			}
+			s.previousVotes[previousEpoch] = votes
+		}
+		res = append(res, votes)
+	}
+
+	return res, nil
+}
+
+// setSlashed sets the slashed flag for an equivocation according to the slashings
+// included on chain for the validator.
+func (s *Service) setSlashed(ctx context.Context, equivocation *chaindb.Equivocation) error {
+	if equivocation.Type == chaindb.EquivocationTypeProposer {
+		slashings, err := s.proposerSlashingsProvider.ProposerSlashingsForValidator(ctx, equivocation.ValidatorIndex)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain proposer slashings")
+		}
+		equivocation.Slashed = len(slashings) > 0
+	} else {
+		slashings, err := s.attesterSlashingsProvider.AttesterSlashingsForValidator(ctx, equivocation.ValidatorIndex)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain attester slashings")
+		}
+		equivocation.Slashed = len(slashings) > 0
+	}
+
+	return nil
+}
+
+// updateSlashed updates equivocations that have not been slashed, as slashings
+// can be included on chain some time after the offence.
+func (s *Service) updateSlashed(ctx context.Context) error {
+	slashed := false
+	equivocations, err := s.equivocationsProvider.Equivocations(ctx, &chaindb.EquivocationFilter{
+		Order:   chaindb.OrderEarliest,
+		Slashed: &slashed,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain unslashed equivocations")
+	}
+
+	updated := make([]*chaindb.Equivocation, 0)
+	for _, equivocation := range equivocations {
+		if err := s.setSlashed(ctx, equivocation); err != nil {
+			return err
+		}
+		if equivocation.Slashed {
+			updated = append(updated, equivocation)
+		}
+	}
+	if len(updated) == 0 {
+		return nil
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.equivocationsSetter.SetEquivocations(ctx, updated); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set equivocations")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	log.Info().Int("equivocations", len(updated)).Msg("Equivocations have been slashed")
+
+	return nil
+}
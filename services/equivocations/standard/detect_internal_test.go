@@ -0,0 +1,208 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestProposerEquivocations(t *testing.T) {
+	tests := []struct {
+		name     string
+		blocks   []*chaindb.Block
+		expected int
+	}{
+		{
+			name:     "Empty",
+			expected: 0,
+		},
+		{
+			name: "Single",
+			blocks: []*chaindb.Block{
+				{Slot: 1, ProposerIndex: 5, Root: phase0.Root{0x01}},
+			},
+			expected: 0,
+		},
+		{
+			name: "Duplicate",
+			blocks: []*chaindb.Block{
+				{Slot: 1, ProposerIndex: 5, Root: phase0.Root{0x01}},
+				{Slot: 1, ProposerIndex: 5, Root: phase0.Root{0x01}},
+			},
+			expected: 0,
+		},
+		{
+			name: "DifferentProposers",
+			blocks: []*chaindb.Block{
+				{Slot: 1, ProposerIndex: 5, Root: phase0.Root{0x01}},
+				{Slot: 1, ProposerIndex: 6, Root: phase0.Root{0x02}},
+			},
+			expected: 0,
+		},
+		{
+			name: "Equivocation",
+			blocks: []*chaindb.Block{
+				{Slot: 1, ProposerIndex: 5, Root: phase0.Root{0x02}},
+				{Slot: 1, ProposerIndex: 5, Root: phase0.Root{0x01}},
+			},
+			expected: 1,
+		},
+		{
+			name: "Triple",
+			blocks: []*chaindb.Block{
+				{Slot: 1, ProposerIndex: 5, Root: phase0.Root{0x01}},
+				{Slot: 1, ProposerIndex: 5, Root: phase0.Root{0x02}},
+				{Slot: 1, ProposerIndex: 5, Root: phase0.Root{0x03}},
+			},
+			expected: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			equivocations := proposerEquivocations(test.blocks)
+			require.Len(t, equivocations, test.expected)
+			for _, equivocation := range equivocations {
+				require.Equal(t, chaindb.EquivocationTypeProposer, equivocation.Type)
+				require.Equal(t, phase0.ValidatorIndex(5), equivocation.ValidatorIndex)
+				require.Less(t, equivocation.Root1[0], equivocation.Root2[0])
+			}
+		})
+	}
+}
+
+func testAttestation(slot phase0.Slot, beaconBlockRoot byte, source phase0.Epoch, target phase0.Epoch, indices ...phase0.ValidatorIndex) *chaindb.Attestation {
+	return &chaindb.Attestation{
+		Slot:               slot,
+		BeaconBlockRoot:    phase0.Root{beaconBlockRoot},
+		SourceEpoch:        source,
+		TargetEpoch:        target,
+		AggregationIndices: indices,
+	}
+}
+
+func TestDoubleVotes(t *testing.T) {
+	tests := []struct {
+		name         string
+		attestations []*chaindb.Attestation
+		expected     []phase0.ValidatorIndex
+	}{
+		{
+			name:     "Empty",
+			expected: []phase0.ValidatorIndex{},
+		},
+		{
+			name: "SameData",
+			attestations: []*chaindb.Attestation{
+				testAttestation(32, 0x01, 0, 1, 1, 2),
+				testAttestation(32, 0x01, 0, 1, 2, 3),
+			},
+			expected: []phase0.ValidatorIndex{},
+		},
+		{
+			name: "DifferentValidators",
+			attestations: []*chaindb.Attestation{
+				testAttestation(32, 0x01, 0, 1, 1),
+				testAttestation(32, 0x02, 0, 1, 2),
+			},
+			expected: []phase0.ValidatorIndex{},
+		},
+		{
+			name: "DoubleVote",
+			attestations: []*chaindb.Attestation{
+				testAttestation(32, 0x01, 0, 1, 1, 2),
+				testAttestation(32, 0x02, 0, 1, 2, 3),
+			},
+			expected: []phase0.ValidatorIndex{2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			votes, err := newEpochVotes(1, test.attestations)
+			require.NoError(t, err)
+			equivocations := doubleVotes(votes)
+			indices := make([]phase0.ValidatorIndex, 0, len(equivocations))
+			for _, equivocation := range equivocations {
+				require.Equal(t, chaindb.EquivocationTypeDoubleVote, equivocation.Type)
+				require.NotEqual(t, equivocation.Root1, equivocation.Root2)
+				indices = append(indices, equivocation.ValidatorIndex)
+			}
+			require.Equal(t, test.expected, indices)
+		})
+	}
+}
+
+func TestSurroundVotes(t *testing.T) {
+	previous, err := newEpochVotes(3, []*chaindb.Attestation{
+		testAttestation(96, 0x01, 2, 3, 1, 2, 3),
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		attestations []*chaindb.Attestation
+		expected     []phase0.ValidatorIndex
+	}{
+		{
+			name: "Honest",
+			attestations: []*chaindb.Attestation{
+				testAttestation(160, 0x02, 3, 5, 1, 2, 3),
+			},
+			expected: []phase0.ValidatorIndex{},
+		},
+		{
+			name: "SameSource",
+			attestations: []*chaindb.Attestation{
+				testAttestation(160, 0x02, 2, 5, 1, 2, 3),
+			},
+			expected: []phase0.ValidatorIndex{},
+		},
+		{
+			name: "Surround",
+			attestations: []*chaindb.Attestation{
+				testAttestation(160, 0x02, 3, 5, 1, 3),
+				testAttestation(160, 0x03, 1, 5, 2),
+			},
+			expected: []phase0.ValidatorIndex{2},
+		},
+		{
+			name: "NotPrevious",
+			attestations: []*chaindb.Attestation{
+				testAttestation(160, 0x02, 1, 5, 4),
+			},
+			expected: []phase0.ValidatorIndex{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			votes, err := newEpochVotes(5, test.attestations)
+			require.NoError(t, err)
+			equivocations := surroundVotes(votes, []*epochVotes{previous})
+			indices := make([]phase0.ValidatorIndex, 0, len(equivocations))
+			for _, equivocation := range equivocations {
+				require.Equal(t, chaindb.EquivocationTypeSurroundVote, equivocation.Type)
+				require.Less(t, *equivocation.SourceEpoch2, *equivocation.SourceEpoch1)
+				require.Less(t, *equivocation.TargetEpoch1, *equivocation.TargetEpoch2)
+				indices = append(indices, equivocation.ValidatorIndex)
+			}
+			require.Equal(t, test.expected, indices)
+		})
+	}
+}
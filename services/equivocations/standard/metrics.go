@@ -0,0 +1,92 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_equivocations"
+
+var (
+	latestEpoch        prometheus.Gauge
+	epochsProcessed    prometheus.Counter
+	equivocationsFound *prometheus.CounterVec
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if latestEpoch != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	latestEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "latest_epoch",
+		Help:      "Latest epoch checked for equivocations",
+	})
+	if err := prometheus.Register(latestEpoch); err != nil {
+		return errors.Wrap(err, "failed to register latest_epoch")
+	}
+
+	epochsProcessed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "epochs_processed",
+		Help:      "Number of epochs checked for equivocations",
+	})
+	if err := prometheus.Register(epochsProcessed); err != nil {
+		return errors.Wrap(err, "failed to register epochs_processed")
+	}
+
+	equivocationsFound = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "found_total",
+		Help:      "Number of equivocations found",
+	}, []string{"type"})
+	if err := prometheus.Register(equivocationsFound); err != nil {
+		return errors.Wrap(err, "failed to register found_total")
+	}
+
+	return nil
+}
+
+func monitorEpochProcessed(epoch phase0.Epoch) {
+	if latestEpoch != nil {
+		latestEpoch.Set(float64(epoch))
+	}
+	if epochsProcessed != nil {
+		epochsProcessed.Inc()
+	}
+}
+
+func monitorEquivocationFound(equivocationType string) {
+	if equivocationsFound != nil {
+		equivocationsFound.WithLabelValues(equivocationType).Inc()
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// proposerEquivocations returns the equivocations found in the supplied blocks,
+// being any pair of distinct blocks for the same slot by the same proposer.
+func proposerEquivocations(blocks []*chaindb.Block) []*chaindb.Equivocation {
+	type proposal struct {
+		slot     phase0.Slot
+		proposer phase0.ValidatorIndex
+	}
+	proposals := make(map[proposal][]phase0.Root)
+	for _, block := range blocks {
+		key := proposal{slot: block.Slot, proposer: block.ProposerIndex}
+		duplicate := false
+		for _, root := range proposals[key] {
+			if root == block.Root {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			proposals[key] = append(proposals[key], block.Root)
+		}
+	}
+
+	equivocations := make([]*chaindb.Equivocation, 0)
+	for key, roots := range proposals {
+		if len(roots) < 2 {
+			continue
+		}
+		sort.Slice(roots, func(i int, j int) bool {
+			return bytes.Compare(roots[i][:], roots[j][:]) < 0
+		})
+		for i := 0; i < len(roots); i++ {
+			for j := i + 1; j < len(roots); j++ {
+				equivocations = append(equivocations, &chaindb.Equivocation{
+					Type:           chaindb.EquivocationTypeProposer,
+					ValidatorIndex: key.proposer,
+					Slot1:          key.slot,
+					Root1:          roots[i],
+					Slot2:          key.slot,
+					Root2:          roots[j],
+				})
+			}
+		}
+	}
+
+	sortEquivocations(equivocations)
+
+	return equivocations
+}
+
+// sortEquivocations sorts equivocations in to a deterministic order.
+func sortEquivocations(equivocations []*chaindb.Equivocation) {
+	sort.Slice(equivocations, func(i int, j int) bool {
+		if equivocations[i].Slot2 != equivocations[j].Slot2 {
+			return equivocations[i].Slot2 < equivocations[j].Slot2
+		}
+		if equivocations[i].ValidatorIndex != equivocations[j].ValidatorIndex {
+			return equivocations[i].ValidatorIndex < equivocations[j].ValidatorIndex
+		}
+		if equivocations[i].Root1 != equivocations[j].Root1 {
+			return bytes.Compare(equivocations[i].Root1[:], equivocations[j].Root1[:]) < 0
+		}
+		return bytes.Compare(equivocations[i].Root2[:], equivocations[j].Root2[:]) < 0
+	})
+}
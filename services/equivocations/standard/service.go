@@ -0,0 +1,126 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// Service is an equivocations service.
+type Service struct {
+	chainDB                   chaindb.Service
+	chainTime                 chaintime.Service
+	blocksProvider            chaindb.BlocksProvider
+	attestationsProvider      chaindb.AttestationsProvider
+	proposerSlashingsProvider chaindb.ProposerSlashingsProvider
+	attesterSlashingsProvider chaindb.AttesterSlashingsProvider
+	equivocationsProvider     chaindb.EquivocationsProvider
+	equivocationsSetter       chaindb.EquivocationsSetter
+	surroundLookback          uint64
+	maxEpochsPerRun           uint64
+	activitySem               *semaphore.Weighted
+	// previousVotes holds the votes of recent epochs, used to check for surround votes.
+	previousVotes map[phase0.Epoch]*epochVotes
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("equivocations", "standard", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	blocksProvider, isBlocksProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isBlocksProvider {
+		return nil, errors.New("chain DB does not support block providing")
+	}
+
+	attestationsProvider, isAttestationsProvider := parameters.chainDB.(chaindb.AttestationsProvider)
+	if !isAttestationsProvider {
+		return nil, errors.New("chain DB does not support attestation providing")
+	}
+
+	proposerSlashingsProvider, isProposerSlashingsProvider := parameters.chainDB.(chaindb.ProposerSlashingsProvider)
+	if !isProposerSlashingsProvider {
+		return nil, errors.New("chain DB does not support proposer slashing providing")
+	}
+
+	attesterSlashingsProvider, isAttesterSlashingsProvider := parameters.chainDB.(chaindb.AttesterSlashingsProvider)
+	if !isAttesterSlashingsProvider {
+		return nil, errors.New("chain DB does not support attester slashing providing")
+	}
+
+	equivocationsProvider, isEquivocationsProvider := parameters.chainDB.(chaindb.EquivocationsProvider)
+	if !isEquivocationsProvider {
+		return nil, errors.New("chain DB does not support equivocation providing")
+	}
+
+	equivocationsSetter, isEquivocationsSetter := parameters.chainDB.(chaindb.EquivocationsSetter)
+	if !isEquivocationsSetter {
+		return nil, errors.New("chain DB does not support equivocation setting")
+	}
+
+	s := &Service{
+		chainDB:                   parameters.chainDB,
+		chainTime:                 parameters.chainTime,
+		blocksProvider:            blocksProvider,
+		attestationsProvider:      attestationsProvider,
+		proposerSlashingsProvider: proposerSlashingsProvider,
+		attesterSlashingsProvider: attesterSlashingsProvider,
+		equivocationsProvider:     equivocationsProvider,
+		equivocationsSetter:       equivocationsSetter,
+		surroundLookback:          parameters.surroundLookback,
+		maxEpochsPerRun:           parameters.maxEpochsPerRun,
+		activitySem:               semaphore.NewWeighted(1),
+		previousVotes:             make(map[phase0.Epoch]*epochVotes),
+	}
+
+	// Detect once per epoch.
+	runtimeFunc := func(ctx context.Context, data any) (time.Time, error) {
+		return s.chainTime.StartOfEpoch(s.chainTime.CurrentEpoch() + 1), nil
+	}
+	jobFunc := func(ctx context.Context, data any) {
+		s := data.(*Service)
+		s.detect(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "equivocations", "detect",
+		runtimeFunc,
+		nil,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic detection")
+	}
+
+	return s, nil
+}
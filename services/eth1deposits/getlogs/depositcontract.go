@@ -0,0 +1,116 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// getDepositCountSelector is the function selector for get_deposit_count().
+	getDepositCountSelector = "0x621fd130"
+	// getDepositRootSelector is the function selector for get_deposit_root().
+	getDepositRootSelector = "0xc5f2892f"
+)
+
+type ethCallResponse struct {
+	Result string `json:"result"`
+}
+
+// depositContractState fetches the deposit count and root from the deposit contract
+// as of the given block.
+// It returns false if the deposit contract is not present at the block.
+func (s *Service) depositContractState(ctx context.Context, block uint64) (uint64, [32]byte, bool, error) {
+	countData, err := s.callDepositContract(ctx, getDepositCountSelector, block)
+	if err != nil {
+		return 0, [32]byte{}, false, errors.Wrap(err, "failed to obtain deposit count")
+	}
+	if len(countData) == 0 {
+		return 0, [32]byte{}, false, nil
+	}
+	count, err := parseDepositCount(countData)
+	if err != nil {
+		return 0, [32]byte{}, false, err
+	}
+
+	rootData, err := s.callDepositContract(ctx, getDepositRootSelector, block)
+	if err != nil {
+		return 0, [32]byte{}, false, errors.Wrap(err, "failed to obtain deposit root")
+	}
+	if len(rootData) != 32 {
+		return 0, [32]byte{}, false, fmt.Errorf("deposit root of unexpected length %d", len(rootData))
+	}
+	var root [32]byte
+	copy(root[:], rootData)
+
+	return count, root, true, nil
+}
+
+// callDepositContract calls a function of the deposit contract as of the given block.
+func (s *Service) callDepositContract(ctx context.Context, selector string, block uint64) ([]byte, error) {
+	reference, err := url.Parse("")
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid endpoint")
+	}
+	url := s.base.ResolveReference(reference).String()
+
+	reqBody := bytes.NewBufferString(fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_call","params":[{"to":"%#x","data":"%s"},"%#x"],"id":12}`, s.depositContractAddress, selector, block))
+	respBodyReader, err := s.post(ctx, url, reqBody)
+	if err != nil {
+		log.Trace().Str("url", url).Err(err).Msg("Request failed")
+		return nil, errors.Wrap(err, "request failed")
+	}
+	if respBodyReader == nil {
+		return nil, errors.New("empty response")
+	}
+
+	var response ethCallResponse
+	if err := json.NewDecoder(respBodyReader).Decode(&response); err != nil {
+		return nil, errors.Wrap(err, "invalid response")
+	}
+
+	data, err := hex.DecodeString(strings.TrimPrefix(response.Result, "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid result")
+	}
+
+	return data, nil
+}
+
+// parseDepositCount parses the ABI-encoded result of get_deposit_count(),
+// which is a dynamic byte array holding a little-endian count.
+func parseDepositCount(data []byte) (uint64, error) {
+	if len(data) < 64 {
+		return 0, fmt.Errorf("deposit count of unexpected length %d", len(data))
+	}
+	offset := binary.BigEndian.Uint64(data[24:32])
+	if offset+32 > uint64(len(data)) {
+		return 0, errors.New("deposit count offset out of range")
+	}
+	length := binary.BigEndian.Uint64(data[offset+24 : offset+32])
+	if length != 8 || offset+32+length > uint64(len(data)) {
+		return 0, fmt.Errorf("deposit count data of unexpected length %d", length)
+	}
+
+	return binary.LittleEndian.Uint64(data[offset+32 : offset+32+length]), nil
+}
@@ -0,0 +1,75 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// depositContractTreeDepth is the depth of the deposit contract's merkle tree.
+const depositContractTreeDepth = 32
+
+// zeroHashes are the roots of empty subtrees at each height of the tree.
+var zeroHashes = func() [depositContractTreeDepth][32]byte {
+	var hashes [depositContractTreeDepth][32]byte
+	for i := 1; i < depositContractTreeDepth; i++ {
+		hashes[i] = hashPair(hashes[i-1], hashes[i-1])
+	}
+	return hashes
+}()
+
+// depositTree is an incremental merkle tree of deposit data roots, built in
+// the same way as that of the deposit contract.
+type depositTree struct {
+	branch [depositContractTreeDepth][32]byte
+	count  uint64
+}
+
+// add adds a leaf to the tree.
+func (t *depositTree) add(leaf [32]byte) {
+	t.count++
+	size := t.count
+	node := leaf
+	for height := 0; height < depositContractTreeDepth; height++ {
+		if size&1 == 1 {
+			t.branch[height] = node
+			return
+		}
+		node = hashPair(t.branch[height], node)
+		size >>= 1
+	}
+}
+
+// root returns the root of the tree, mixed in with the number of leaves.
+func (t *depositTree) root() [32]byte {
+	var node [32]byte
+	size := t.count
+	for height := 0; height < depositContractTreeDepth; height++ {
+		if size&1 == 1 {
+			node = hashPair(t.branch[height], node)
+		} else {
+			node = hashPair(node, zeroHashes[height])
+		}
+		size >>= 1
+	}
+
+	var length [32]byte
+	binary.LittleEndian.PutUint64(length[:8], t.count)
+	return hashPair(node, length)
+}
+
+func hashPair(left [32]byte, right [32]byte) [32]byte {
+	return sha256.Sum256(append(left[:], right[:]...))
+}
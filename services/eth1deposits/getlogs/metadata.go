@@ -15,44 +15,47 @@ package getlogs
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/pkg/errors"
 )
 
 // metadata stored about this service.
 type metadata struct {
-	LatestBlock  uint64   `json:"latest_block"`
-	MissedBlocks []uint64 `json:"missed_blocks,omitempty"`
+	LatestBlock  uint64
+	MissedBlocks []uint64
 }
 
-// metadataKey is the key for the metadata.
-var metadataKey = "eth1deposit.getlogs"
+// progressService is the name of this service for progress.
+var progressService = "eth1deposit.getlogs"
 
 // getMetadata gets metadata for this service.
 func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 	md := &metadata{}
-	mdJSON, err := s.chainDB.Metadata(ctx, metadataKey)
+	progress, err := s.chainDB.Progress(ctx, progressService)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to fetch metadata")
+		return nil, errors.Wrap(err, "failed to fetch progress")
 	}
-	if mdJSON == nil {
+	if progress == nil {
 		return md, nil
 	}
-	if err := json.Unmarshal(mdJSON, md); err != nil {
-		return nil, errors.Wrap(err, "failed to unmarshal metadata")
+	md.LatestBlock = uint64(progress.Values["latest_block"])
+	for _, block := range progress.Gaps["missed_blocks"] {
+		md.MissedBlocks = append(md.MissedBlocks, uint64(block))
 	}
 	return md, nil
 }
 
 // setMetadata sets metadata for this service.
 func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
-	mdJSON, err := json.Marshal(md)
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal metadata")
+	if err := s.chainDB.SetProgress(ctx, progressService, "latest_block", int64(md.LatestBlock)); err != nil {
+		return errors.Wrap(err, "failed to update latest block")
+	}
+	missedBlocks := make([]int64, len(md.MissedBlocks))
+	for i, block := range md.MissedBlocks {
+		missedBlocks[i] = int64(block)
 	}
-	if err := s.chainDB.SetMetadata(ctx, metadataKey, mdJSON); err != nil {
-		return errors.Wrap(err, "failed to update metadata")
+	if err := s.chainDB.SetProgressGaps(ctx, progressService, "missed_blocks", missedBlocks); err != nil {
+		return errors.Wrap(err, "failed to update missed blocks")
 	}
 	return nil
 }
@@ -27,6 +27,8 @@ var (
 	highestBlock    uint64
 	latestBlock     prometheus.Gauge
 	blocksProcessed prometheus.Gauge
+	reconciledBlock prometheus.Gauge
+	discrepancies   prometheus.Counter
 )
 
 func registerMetrics(_ context.Context, monitor metrics.Service) error {
@@ -63,6 +65,24 @@ func registerPrometheusMetrics() error {
 		return errors.Wrap(err, "failed to register blocks_processed")
 	}
 
+	reconciledBlock = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconciled_block",
+		Help:      "Latest Ethereum 1 block at which deposits were reconciled",
+	})
+	if err := prometheus.Register(reconciledBlock); err != nil {
+		return errors.Wrap(err, "failed to register reconciled_block")
+	}
+
+	discrepancies = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "discrepancies_total",
+		Help:      "Number of reconciliations where indexed deposits did not match the deposit contract",
+	})
+	if err := prometheus.Register(discrepancies); err != nil {
+		return errors.Wrap(err, "failed to register discrepancies_total")
+	}
+
 	return nil
 }
 
@@ -75,3 +95,14 @@ func monitorBlockProcessed(block uint64) {
 		}
 	}
 }
+
+func monitorReconciliation(block uint64, discrepancy bool) {
+	if reconciledBlock == nil {
+		return
+	}
+	if discrepancy {
+		discrepancies.Inc()
+		return
+	}
+	reconciledBlock.Set(float64(block))
+}
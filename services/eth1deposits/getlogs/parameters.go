@@ -15,6 +15,7 @@ package getlogs
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -23,13 +24,14 @@ import (
 )
 
 type parameters struct {
-	logLevel           zerolog.Level
-	monitor            metrics.Service
-	connectionURL      string
-	chainDB            chaindb.Service
-	eth1DepositsSetter chaindb.ETH1DepositsSetter
-	eth1Confirmations  uint64
-	startBlock         string
+	logLevel               zerolog.Level
+	monitor                metrics.Service
+	connectionURL          string
+	chainDB                chaindb.Service
+	eth1DepositsSetter     chaindb.ETH1DepositsSetter
+	eth1Confirmations      uint64
+	startBlock             string
+	reconciliationInterval time.Duration
 }
 
 // Parameter is the interface for service parameters.
@@ -92,6 +94,14 @@ func WithStartBlock(block string) Parameter {
 	})
 }
 
+// WithReconciliationInterval sets the interval between reconciliations of indexed
+// deposits against the deposit contract.  0 disables reconciliation.
+func WithReconciliationInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.reconciliationInterval = interval
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
@@ -0,0 +1,209 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+const (
+	// reconciliationBatchSize is the number of indexed deposits fetched at a time.
+	reconciliationBatchSize = 10000
+	// maxMissingDepositIndices is the maximum number of missing deposit indices
+	// recorded for a single discrepancy.
+	maxMissingDepositIndices = 1024
+)
+
+// reconciliation is the state of the indexed deposits as of the last reconciled block.
+type reconciliation struct {
+	block uint64
+	tree  *depositTree
+	// next is the index of the next expected deposit.
+	next    uint64
+	missing []uint64
+}
+
+// reconcile compares the indexed deposits with the state of the deposit contract,
+// recording any discrepancy.
+func (s *Service) reconcile(ctx context.Context) {
+	// Only allow 1 handler to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another handler running")
+		return
+	}
+	defer s.activitySem.Release(1)
+	s.lastReconciliation = time.Now()
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain metadata for reconciliation")
+		return
+	}
+	if len(md.MissedBlocks) > 0 {
+		log.Debug().Int("missed_blocks", len(md.MissedBlocks)).Msg("Missed blocks outstanding; not reconciling deposits")
+		return
+	}
+	if md.LatestBlock == 0 {
+		return
+	}
+	log := log.With().Uint64("block", md.LatestBlock).Logger()
+
+	contractCount, contractRoot, deployed, err := s.depositContractState(ctx, md.LatestBlock)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain deposit contract state")
+		return
+	}
+	if !deployed {
+		log.Debug().Msg("Deposit contract not deployed; not reconciling deposits")
+		return
+	}
+
+	state := s.reconciliation
+	if state == nil || state.block > md.LatestBlock {
+		state = &reconciliation{
+			tree: &depositTree{},
+		}
+	}
+	if err := s.addIndexedDeposits(ctx, state, md.LatestBlock); err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain indexed deposits")
+		return
+	}
+
+	discrepancy := checkDeposits(state, contractCount, contractRoot)
+	monitorReconciliation(md.LatestBlock, discrepancy != nil)
+	if discrepancy == nil {
+		log.Trace().Uint64("deposits", contractCount).Msg("Deposits reconciled")
+		s.reconciliation = state
+		return
+	}
+
+	// Start afresh next time, as missing deposits may since have been indexed.
+	s.reconciliation = nil
+	discrepancy.ETH1BlockNumber = md.LatestBlock
+	discrepancy.Timestamp = s.lastReconciliation
+	log.Warn().
+		Uint64("contract_deposit_count", discrepancy.ContractDepositCount).
+		Uint64("indexed_deposit_count", discrepancy.IndexedDepositCount).
+		Str("contract_deposit_root", fmt.Sprintf("%#x", discrepancy.ContractDepositRoot)).
+		Str("indexed_deposit_root", fmt.Sprintf("%#x", discrepancy.IndexedDepositRoot)).
+		Int("missing_deposits", len(discrepancy.MissingDepositIndices)).
+		Msg("Indexed deposits do not match deposit contract")
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin transaction to set deposit discrepancy")
+		return
+	}
+	if err := s.discrepanciesSetter.SetETH1DepositDiscrepancy(ctx, discrepancy); err != nil {
+		log.Error().Err(err).Msg("Failed to set deposit discrepancy")
+		cancel()
+		return
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to commit transaction")
+		cancel()
+		return
+	}
+}
+
+// addIndexedDeposits adds the indexed deposits up to and including the given block to the state.
+func (s *Service) addIndexedDeposits(ctx context.Context, state *reconciliation, block uint64) error {
+	from := state.block + 1
+	for {
+		deposits, err := s.eth1DepositsProvider.ETH1Deposits(ctx, &chaindb.ETH1DepositFilter{
+			Limit: reconciliationBatchSize,
+			Order: chaindb.OrderEarliest,
+			From:  &from,
+			To:    &block,
+		})
+		if err != nil {
+			return err
+		}
+		if err := addDeposits(state, deposits); err != nil {
+			return err
+		}
+		if len(deposits) < reconciliationBatchSize {
+			break
+		}
+		// Refetch from the last block, as it may have further deposits.
+		last := deposits[len(deposits)-1].ETH1BlockNumber
+		if last == from {
+			return fmt.Errorf("more than %d deposits in block %d", reconciliationBatchSize, last)
+		}
+		from = last
+	}
+	state.block = block
+
+	return nil
+}
+
+// addDeposits adds deposits to the state, noting any gaps in the deposit indices.
+// Deposits must be supplied in index order; those already added are ignored.
+func addDeposits(state *reconciliation, deposits []*chaindb.ETH1Deposit) error {
+	for _, deposit := range deposits {
+		if deposit.DepositIndex < state.next {
+			continue
+		}
+		state.missing = appendMissing(state.missing, state.next, deposit.DepositIndex)
+		depositData := &phase0.DepositData{
+			PublicKey:             deposit.ValidatorPubKey,
+			WithdrawalCredentials: deposit.WithdrawalCredentials,
+			Amount:                deposit.Amount,
+			Signature:             deposit.Signature,
+		}
+		leaf, err := depositData.HashTreeRoot()
+		if err != nil {
+			return errors.Wrapf(err, "failed to calculate root of deposit %d", deposit.DepositIndex)
+		}
+		state.tree.add(leaf)
+		state.next = deposit.DepositIndex + 1
+	}
+
+	return nil
+}
+
+// checkDeposits checks the state against that of the deposit contract,
+// returning a discrepancy if they differ.
+func checkDeposits(state *reconciliation, contractCount uint64, contractRoot [32]byte) *chaindb.ETH1DepositDiscrepancy {
+	missing := appendMissing(state.missing, state.next, contractCount)
+	indexedRoot := state.tree.root()
+	if state.tree.count == contractCount && indexedRoot == contractRoot && len(missing) == 0 {
+		return nil
+	}
+
+	return &chaindb.ETH1DepositDiscrepancy{
+		ContractDepositCount:  contractCount,
+		IndexedDepositCount:   state.tree.count,
+		ContractDepositRoot:   contractRoot,
+		IndexedDepositRoot:    indexedRoot,
+		MissingDepositIndices: missing,
+	}
+}
+
+// appendMissing appends the indices in the range [from, to) to the missing indices,
+// up to the maximum number recorded.
+func appendMissing(missing []uint64, from uint64, to uint64) []uint64 {
+	for index := from; index < to && len(missing) < maxMissingDepositIndices; index++ {
+		missing = append(missing, index)
+	}
+
+	return missing
+}
@@ -0,0 +1,150 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// naiveDepositRoot calculates the deposit root by hashing the full tree.
+func naiveDepositRoot(leaves [][32]byte) [32]byte {
+	level := make([][32]byte, len(leaves))
+	copy(level, leaves)
+	for height := 0; height < depositContractTreeDepth; height++ {
+		if len(level)%2 == 1 {
+			level = append(level, zeroHashes[height])
+		}
+		next := make([][32]byte, len(level)/2)
+		for i := range next {
+			next[i] = hashPair(level[2*i], level[2*i+1])
+		}
+		level = next
+	}
+	node := zeroHashes[depositContractTreeDepth-1]
+	node = hashPair(node, node)
+	if len(level) > 0 {
+		node = level[0]
+	}
+	var length [32]byte
+	length[0] = byte(len(leaves))
+	return hashPair(node, length)
+}
+
+func TestDepositTree(t *testing.T) {
+	// Root of the empty deposit contract.
+	emptyRoot, err := hex.DecodeString("d70a234731285c6804c2a4f56711ddb8c82c99740f207854891028af34e27e5e")
+	require.NoError(t, err)
+	tree := &depositTree{}
+	root := tree.root()
+	require.Equal(t, emptyRoot, root[:])
+
+	leaves := make([][32]byte, 0)
+	for i := 1; i <= 9; i++ {
+		leaf := [32]byte{byte(i)}
+		leaves = append(leaves, leaf)
+		tree.add(leaf)
+		require.Equal(t, naiveDepositRoot(leaves), tree.root(), "root for %d leaves", i)
+	}
+}
+
+func TestParseDepositCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		count uint64
+		err   string
+	}{
+		{
+			name:  "Good",
+			input: "000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000080a1b0c0000000000000000000000000000000000000000000000000000000000",
+			count: 0x0c1b0a,
+		},
+		{
+			name:  "Short",
+			input: "0000000000000000000000000000000000000000000000000000000000000020",
+			err:   "deposit count of unexpected length 32",
+		},
+		{
+			name:  "BadLength",
+			input: "000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000040a1b0c0000000000000000000000000000000000000000000000000000000000",
+			err:   "deposit count data of unexpected length 4",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := hex.DecodeString(test.input)
+			require.NoError(t, err)
+			count, err := parseDepositCount(data)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.count, count)
+			}
+		})
+	}
+}
+
+func TestReconcileDeposits(t *testing.T) {
+	deposits := make([]*chaindb.ETH1Deposit, 0)
+	for i := uint64(0); i < 5; i++ {
+		deposits = append(deposits, &chaindb.ETH1Deposit{
+			DepositIndex:          i,
+			ValidatorPubKey:       phase0.BLSPubKey{byte(i)},
+			WithdrawalCredentials: make([]byte, 32),
+			Amount:                32000000000,
+		})
+	}
+
+	// Obtain the contract root from the full set of deposits.
+	expected := &reconciliation{tree: &depositTree{}}
+	require.NoError(t, addDeposits(expected, deposits))
+	contractRoot := expected.tree.root()
+
+	// All deposits present, supplied over overlapping batches.
+	state := &reconciliation{tree: &depositTree{}}
+	require.NoError(t, addDeposits(state, deposits[:3]))
+	require.NoError(t, addDeposits(state, deposits[2:]))
+	require.Nil(t, checkDeposits(state, 5, contractRoot))
+
+	// Deposit missing from the middle.
+	state = &reconciliation{tree: &depositTree{}}
+	require.NoError(t, addDeposits(state, append([]*chaindb.ETH1Deposit{deposits[0]}, deposits[2:]...)))
+	discrepancy := checkDeposits(state, 5, contractRoot)
+	require.NotNil(t, discrepancy)
+	require.Equal(t, uint64(5), discrepancy.ContractDepositCount)
+	require.Equal(t, uint64(4), discrepancy.IndexedDepositCount)
+	require.Equal(t, []uint64{1}, discrepancy.MissingDepositIndices)
+	require.NotEqual(t, discrepancy.ContractDepositRoot, discrepancy.IndexedDepositRoot)
+
+	// Deposits missing from the end.
+	state = &reconciliation{tree: &depositTree{}}
+	require.NoError(t, addDeposits(state, deposits[:3]))
+	discrepancy = checkDeposits(state, 5, contractRoot)
+	require.NotNil(t, discrepancy)
+	require.Equal(t, []uint64{3, 4}, discrepancy.MissingDepositIndices)
+
+	// Same count but different contents.
+	state = &reconciliation{tree: &depositTree{}}
+	require.NoError(t, addDeposits(state, deposits))
+	discrepancy = checkDeposits(state, 5, [32]byte{0x01})
+	require.NotNil(t, discrepancy)
+	require.Empty(t, discrepancy.MissingDepositIndices)
+}
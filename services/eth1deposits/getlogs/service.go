@@ -25,8 +25,8 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -44,6 +44,11 @@ type Service struct {
 	blockTimestamps        map[[32]byte]time.Time
 	blocksPerRequest       uint64
 	depositContractAddress []byte
+	eth1DepositsProvider   chaindb.ETH1DepositsProvider
+	discrepanciesSetter    chaindb.ETH1DepositDiscrepanciesSetter
+	reconciliationInterval time.Duration
+	lastReconciliation     time.Time
+	reconciliation         *reconciliation
 	activitySem            *semaphore.Weighted
 }
 
@@ -55,10 +60,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "eth1deposits").Str("impl", "getlogs").Logger()
-	if parameters.logLevel != log.GetLevel() {
-		log = log.Level(parameters.logLevel)
-	}
+	log = util.ServiceLogger("eth1deposits", "getlogs", parameters.logLevel)
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -92,11 +94,38 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.Wrap(err, "failed to obtain chain specification")
 	}
 
-	depositContractAddress, exists := spec["DEPOSIT_CONTRACT_ADDRESS"].([]byte)
-	if !exists {
+	depositContractAddress := spec.DepositContractAddress
+	if len(depositContractAddress) == 0 {
 		return nil, errors.New("failed to obtain deposit contract address")
 	}
 
+	startBlock, err := strconv.ParseInt(parameters.startBlock, 10, 64)
+	if err != nil {
+		startBlock = -1
+	}
+
+	reconciliationInterval := parameters.reconciliationInterval
+	var eth1DepositsProvider chaindb.ETH1DepositsProvider
+	var discrepanciesSetter chaindb.ETH1DepositDiscrepanciesSetter
+	if reconciliationInterval > 0 {
+		if startBlock > 0 {
+			// Deposits before the start block are deliberately not indexed, so cannot be reconciled.
+			log.Info().Msg("Start block supplied; not reconciling deposits")
+			reconciliationInterval = 0
+		} else {
+			var isProvider bool
+			eth1DepositsProvider, isProvider = parameters.chainDB.(chaindb.ETH1DepositsProvider)
+			if !isProvider {
+				return nil, errors.New("chain DB does not provide Ethereum 1 deposits")
+			}
+			var isSetter bool
+			discrepanciesSetter, isSetter = parameters.chainDB.(chaindb.ETH1DepositDiscrepanciesSetter)
+			if !isSetter {
+				return nil, errors.New("chain DB does not support Ethereum 1 deposit discrepancies")
+			}
+		}
+	}
+
 	s := &Service{
 		chainDB:                parameters.chainDB,
 		timeout:                30 * time.Second,
@@ -107,6 +136,9 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		blockTimestamps:        make(map[[32]byte]time.Time),
 		blocksPerRequest:       64,
 		depositContractAddress: depositContractAddress,
+		eth1DepositsProvider:   eth1DepositsProvider,
+		discrepanciesSetter:    discrepanciesSetter,
+		reconciliationInterval: reconciliationInterval,
 		activitySem:            semaphore.NewWeighted(1),
 	}
 
@@ -117,20 +149,14 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	if chainID == 0 {
 		log.Warn().Msg("Ethereum 1 client not synced; cannot confirm chain ID")
 	} else {
-		depositChainID, exists := spec["DEPOSIT_CHAIN_ID"].(uint64)
-		if !exists {
-			return nil, errors.Wrap(err, "failed to obtain deposit contract chain ID")
+		if spec.DepositChainID == 0 {
+			return nil, errors.New("failed to obtain deposit contract chain ID")
 		}
-		if chainID != depositChainID {
+		if chainID != spec.DepositChainID {
 			return nil, fmt.Errorf("incorrect Ethereum 1 client chain ID %d", chainID)
 		}
 	}
 
-	startBlock, err := strconv.ParseInt(parameters.startBlock, 10, 64)
-	if err != nil {
-		startBlock = -1
-	}
-
 	go s.updateAfterRestart(ctx, startBlock)
 
 	return s, nil
@@ -160,6 +186,7 @@ func (s *Service) updateAfterRestart(ctx context.Context, startBlock int64) {
 		s.parseNewBlocks(ctx, md)
 	}
 	log.Info().Msg("Caught up")
+	s.checkReconciliation(ctx)
 
 	// Run periodically.
 	go func(ctx context.Context, s *Service) {
@@ -167,6 +194,7 @@ func (s *Service) updateAfterRestart(ctx context.Context, startBlock int64) {
 			select {
 			case <-time.After(2 * time.Minute):
 				s.checkLatestBlock(ctx)
+				s.checkReconciliation(ctx)
 			case <-ctx.Done():
 				log.Debug().Msg("Context done")
 				return
@@ -195,6 +223,14 @@ func (s *Service) checkLatestBlock(ctx context.Context) {
 	s.parseNewBlocks(ctx, md)
 }
 
+// checkReconciliation reconciles deposits if the reconciliation interval has passed.
+func (s *Service) checkReconciliation(ctx context.Context) {
+	if s.reconciliationInterval == 0 || time.Since(s.lastReconciliation) < s.reconciliationInterval {
+		return
+	}
+	s.reconcile(ctx)
+}
+
 func (s *Service) parseNewBlocks(ctx context.Context, md *metadata) {
 	// Only allow 1 handler to be active.
 	acquired := s.activitySem.TryAcquire(1)
@@ -0,0 +1,135 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/warehouse"
+)
+
+// export exports new rows of the selected tables.
+func (s *Service) export(ctx context.Context) {
+	// Only allow 1 export to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another export running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	// Only export data up to the latest canonical block, as data after that can change.
+	latestCanonicalSlot, err := s.blocksProvider.LatestCanonicalBlock(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain latest canonical block")
+		return
+	}
+
+	highWaterMarks, err := s.highWaterMarks(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain high-water marks")
+		return
+	}
+
+	for _, table := range s.tables {
+		if err := s.exportTable(ctx, table, highWaterMarks[table], latestCanonicalSlot); err != nil {
+			log.Warn().Str("table", table).Err(err).Msg("Failed to export table")
+		}
+	}
+}
+
+// exportTable exports rows of a table added since its high-water mark.
+func (s *Service) exportTable(ctx context.Context,
+	name string,
+	highWaterMark int64,
+	latestCanonicalSlot phase0.Slot,
+) error {
+	table := exportTables[name]
+
+	maxMark := int64(latestCanonicalSlot)
+	if table.markUnit == markUnitEpoch {
+		// The epoch of the latest canonical slot may not be complete.
+		epoch := s.chainTime.SlotToEpoch(latestCanonicalSlot)
+		if epoch == 0 {
+			return nil
+		}
+		maxMark = int64(epoch) - 1
+	}
+
+	for highWaterMark < maxMark {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		columns, rows, err := s.exportRowsProvider.ExportRows(ctx, name, table.markColumn, highWaterMark+1, maxMark, s.marksPerBatch)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain rows")
+		}
+		if len(rows) == 0 {
+			// No new rows.
+			break
+		}
+
+		markIndex := -1
+		for i, column := range columns {
+			if column.Name == table.markColumn {
+				markIndex = i
+				break
+			}
+		}
+		if markIndex == -1 {
+			return fmt.Errorf("mark column %s not present", table.markColumn)
+		}
+		batchMark, err := markValue(rows[len(rows)-1][markIndex])
+		if err != nil {
+			return err
+		}
+
+		if err := s.warehouse.Export(ctx, &warehouse.Table{
+			Name:       name,
+			MarkColumn: table.markColumn,
+			Columns:    columns,
+		}, rows); err != nil {
+			return errors.Wrap(err, "failed to export rows")
+		}
+
+		// The high-water mark is the highest mark exported rather than the highest mark
+		// requested, as rows for later marks may not yet have been written.
+		if err := s.setHighWaterMark(ctx, name, batchMark); err != nil {
+			return err
+		}
+		log.Trace().Str("table", name).Int64("high_water_mark", batchMark).Int("rows", len(rows)).Msg("Exported rows")
+		monitorBatchExported(name, batchMark, len(rows))
+		highWaterMark = batchMark
+	}
+
+	return nil
+}
+
+// markValue converts the value of a mark column to an integer.
+func markValue(value any) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int32:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("unexpected mark type %T", value)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// progressService is the name of this service for progress.
+var progressService = "exporter.standard"
+
+// highWaterMarks gets the high-water marks of the exported tables, keyed by table name.
+// Tables that have not been exported have a high-water mark of -1.
+func (s *Service) highWaterMarks(ctx context.Context) (map[string]int64, error) {
+	marks := make(map[string]int64, len(s.tables))
+	for _, table := range s.tables {
+		marks[table] = -1
+	}
+
+	progress, err := s.chainDB.Progress(ctx, progressService)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch progress")
+	}
+	if progress == nil {
+		return marks, nil
+	}
+	for _, table := range s.tables {
+		if val, exists := progress.Values[table]; exists {
+			marks[table] = val
+		}
+	}
+
+	return marks, nil
+}
+
+// setHighWaterMark sets the high-water mark of an exported table.
+func (s *Service) setHighWaterMark(ctx context.Context, table string, mark int64) error {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.chainDB.SetProgress(ctx, progressService, table, mark); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to update high-water mark")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
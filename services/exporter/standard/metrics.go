@@ -0,0 +1,75 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_exporter"
+
+var (
+	highWaterMark *prometheus.GaugeVec
+	rowsExported  *prometheus.CounterVec
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if highWaterMark != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	highWaterMark = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "high_water_mark",
+		Help:      "Latest slot or epoch exported",
+	}, []string{"table"})
+	if err := prometheus.Register(highWaterMark); err != nil {
+		return errors.Wrap(err, "failed to register high_water_mark")
+	}
+
+	rowsExported = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rows_exported_total",
+		Help:      "Number of rows exported",
+	}, []string{"table"})
+	if err := prometheus.Register(rowsExported); err != nil {
+		return errors.Wrap(err, "failed to register rows_exported_total")
+	}
+
+	return nil
+}
+
+func monitorBatchExported(table string, mark int64, rows int) {
+	if highWaterMark != nil {
+		highWaterMark.WithLabelValues(table).Set(float64(mark))
+	}
+	if rowsExported != nil {
+		rowsExported.WithLabelValues(table).Add(float64(rows))
+	}
+}
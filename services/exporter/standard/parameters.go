@@ -0,0 +1,157 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/services/warehouse"
+)
+
+type parameters struct {
+	logLevel      zerolog.Level
+	monitor       metrics.Service
+	chainDB       chaindb.Service
+	chainTime     chaintime.Service
+	scheduler     scheduler.Service
+	warehouse     warehouse.Service
+	tables        []string
+	interval      time.Duration
+	marksPerBatch uint32
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(p *parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithScheduler sets the scheduler for this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
+// WithWarehouse sets the warehouse to which tables are exported.
+func WithWarehouse(warehouse warehouse.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.warehouse = warehouse
+	})
+}
+
+// WithTables sets the tables to export.
+// If not supplied, all exportable tables are exported.
+func WithTables(tables []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.tables = tables
+	})
+}
+
+// WithInterval sets the interval between exports.
+func WithInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.interval = interval
+	})
+}
+
+// WithMarksPerBatch sets the maximum number of slots or epochs of a table exported in a single batch.
+func WithMarksPerBatch(marksPerBatch uint32) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.marksPerBatch = marksPerBatch
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:      zerolog.GlobalLevel(),
+		interval:      5 * time.Minute,
+		marksPerBatch: 32,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified")
+	}
+	if parameters.warehouse == nil {
+		return nil, errors.New("no warehouse specified")
+	}
+	if len(parameters.tables) == 0 {
+		parameters.tables = ExportableTables()
+	}
+	for _, table := range parameters.tables {
+		if _, exists := exportTables[table]; !exists {
+			return nil, fmt.Errorf("table %s cannot be exported", table)
+		}
+	}
+	if parameters.interval <= 0 {
+		return nil, errors.New("interval must be greater than 0")
+	}
+	if parameters.marksPerBatch == 0 {
+		return nil, errors.New("marks per batch must be greater than 0")
+	}
+
+	return &parameters, nil
+}
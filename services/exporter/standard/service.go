@@ -0,0 +1,98 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/warehouse"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// Service is an exporter service.
+type Service struct {
+	chainDB            chaindb.Service
+	chainTime          chaintime.Service
+	blocksProvider     chaindb.BlocksProvider
+	exportRowsProvider chaindb.ExportRowsProvider
+	warehouse          warehouse.Service
+	tables             []string
+	interval           time.Duration
+	marksPerBatch      uint32
+	activitySem        *semaphore.Weighted
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("exporter", "standard", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	blocksProvider, isBlocksProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isBlocksProvider {
+		return nil, errors.New("chain DB does not support block providing")
+	}
+
+	exportRowsProvider, isExportRowsProvider := parameters.chainDB.(chaindb.ExportRowsProvider)
+	if !isExportRowsProvider {
+		return nil, errors.New("chain DB does not support export row providing")
+	}
+
+	s := &Service{
+		chainDB:            parameters.chainDB,
+		chainTime:          parameters.chainTime,
+		blocksProvider:     blocksProvider,
+		exportRowsProvider: exportRowsProvider,
+		warehouse:          parameters.warehouse,
+		tables:             parameters.tables,
+		interval:           parameters.interval,
+		marksPerBatch:      parameters.marksPerBatch,
+		activitySem:        semaphore.NewWeighted(1),
+	}
+
+	runtimeFunc := func(ctx context.Context, data any) (time.Time, error) {
+		return time.Now().Add(s.interval), nil
+	}
+	jobFunc := func(ctx context.Context, data any) {
+		s := data.(*Service)
+		s.export(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "exporter", "export",
+		runtimeFunc,
+		nil,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic export")
+	}
+
+	return s, nil
+}
@@ -0,0 +1,72 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"sort"
+)
+
+// Units of marks.
+const (
+	markUnitSlot  = "slot"
+	markUnitEpoch = "epoch"
+)
+
+// exportTable describes a table that can be exported.
+type exportTable struct {
+	// markColumn is the column that increases as data is added to the table.
+	markColumn string
+	// markUnit is the unit of the mark column.
+	markUnit string
+}
+
+// exportTables are the tables that can be exported.
+// Tables are only exportable if rows are added in the order of their mark column.
+var exportTables = map[string]*exportTable{
+	"t_attestations":                   {markColumn: "f_inclusion_slot", markUnit: markUnitSlot},
+	"t_attester_slashings":             {markColumn: "f_inclusion_slot", markUnit: markUnitSlot},
+	"t_block_bls_to_execution_changes": {markColumn: "f_block_number", markUnit: markUnitSlot},
+	"t_block_execution_payloads":       {markColumn: "f_block_number", markUnit: markUnitSlot},
+	"t_block_summaries":                {markColumn: "f_slot", markUnit: markUnitSlot},
+	"t_block_transaction_events":       {markColumn: "f_slot", markUnit: markUnitSlot},
+	"t_block_transaction_receipts":     {markColumn: "f_slot", markUnit: markUnitSlot},
+	"t_block_withdrawals":              {markColumn: "f_block_number", markUnit: markUnitSlot},
+	"t_blocks":                         {markColumn: "f_slot", markUnit: markUnitSlot},
+	"t_deposits":                       {markColumn: "f_inclusion_slot", markUnit: markUnitSlot},
+	"t_entry_queues":                   {markColumn: "f_epoch", markUnit: markUnitEpoch},
+	"t_epoch_summaries":                {markColumn: "f_epoch", markUnit: markUnitEpoch},
+	"t_equivocations":                  {markColumn: "f_slot_2", markUnit: markUnitSlot},
+	"t_network_aggregates":             {markColumn: "f_epoch", markUnit: markUnitEpoch},
+	"t_proposer_duties":                {markColumn: "f_slot", markUnit: markUnitSlot},
+	"t_proposer_slashings":             {markColumn: "f_inclusion_slot", markUnit: markUnitSlot},
+	"t_shuffling_mismatches":           {markColumn: "f_slot", markUnit: markUnitSlot},
+	"t_sync_aggregates":                {markColumn: "f_inclusion_slot", markUnit: markUnitSlot},
+	"t_validator_balances":             {markColumn: "f_epoch", markUnit: markUnitEpoch},
+	"t_validator_epoch_summaries":      {markColumn: "f_epoch", markUnit: markUnitEpoch},
+	"t_verification_disagreements":     {markColumn: "f_slot", markUnit: markUnitSlot},
+	"t_voluntary_exits":                {markColumn: "f_inclusion_slot", markUnit: markUnitSlot},
+	"t_watchlist_alerts":               {markColumn: "f_epoch", markUnit: markUnitEpoch},
+	"t_watchlist_events":               {markColumn: "f_epoch", markUnit: markUnitEpoch},
+}
+
+// ExportableTables returns the names of the tables that can be exported.
+func ExportableTables() []string {
+	res := make([]string, 0, len(exportTables))
+	for name := range exportTables {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+
+	return res
+}
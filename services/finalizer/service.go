@@ -0,0 +1,44 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finalizer
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// ProgressService is the name of the finalizer service for progress.
+// Services that depend on the canonical state set by the finalizer read its
+// progress under this name.
+const ProgressService = "finalizer.standard"
+
+// CanonicalSlot returns the latest slot for which the finalizer has set the canonical
+// state of blocks, or -1 if it has not set any.
+func CanonicalSlot(ctx context.Context, chainDB chaindb.Service) (int64, error) {
+	progress, err := chainDB.Progress(ctx, ProgressService)
+	if err != nil {
+		return -1, errors.Wrap(err, "failed to fetch finalizer progress")
+	}
+	if progress == nil {
+		return -1, nil
+	}
+	val, exists := progress.Values["latest_canonical_slot"]
+	if !exists {
+		return -1, nil
+	}
+
+	return val, nil
+}
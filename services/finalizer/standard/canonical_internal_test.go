@@ -0,0 +1,172 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+// progressDB is an in-memory chain database that records progress, and can be set
+// to fail to canonicalize blocks to simulate an interruption.
+type progressDB struct {
+	*mockchaindb.InMemoryService
+
+	progress       map[string]int64
+	canonicalCalls int
+	failAfter      int
+}
+
+func (d *progressDB) SetProgress(_ context.Context, _ string, key string, value int64) error {
+	d.progress[key] = value
+	return nil
+}
+
+func (d *progressDB) Progress(_ context.Context, service string) (*chaindb.Progress, error) {
+	return &chaindb.Progress{
+		Service: service,
+		Values:  d.progress,
+	}, nil
+}
+
+func (d *progressDB) SetBlocksCanonical(ctx context.Context, roots []phase0.Root) error {
+	d.canonicalCalls++
+	if d.failAfter > 0 && d.canonicalCalls > d.failAfter {
+		return errors.New("interrupted")
+	}
+
+	return d.InMemoryService.SetBlocksCanonical(ctx, roots)
+}
+
+func TestCanonicalRanges(t *testing.T) {
+	headers := func(slots ...phase0.Slot) []*chaindb.BlockHeader {
+		res := make([]*chaindb.BlockHeader, len(slots))
+		for i := range slots {
+			res[i] = &chaindb.BlockHeader{Slot: slots[i]}
+		}
+		return res
+	}
+
+	tests := []struct {
+		name     string
+		headers  []*chaindb.BlockHeader
+		expected [][]*chaindb.BlockHeader
+	}{
+		{
+			name:     "Empty",
+			headers:  headers(),
+			expected: [][]*chaindb.BlockHeader{},
+		},
+		{
+			name:     "Single",
+			headers:  headers(5, 6, 9),
+			expected: [][]*chaindb.BlockHeader{headers(5, 6, 9)},
+		},
+		{
+			name:     "Multiple",
+			headers:  headers(5, 9, 10, 19, 20, 45),
+			expected: [][]*chaindb.BlockHeader{headers(5, 9), headers(10, 19), headers(20), headers(45)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, canonicalRanges(test.headers, 10))
+		})
+	}
+}
+
+func TestUpdateCanonicalBlocksInRangesResume(t *testing.T) {
+	ctx := context.Background()
+
+	inMemory, err := mockchaindb.NewInMemory(ctx, nil)
+	require.NoError(t, err)
+	db := &progressDB{
+		InMemoryService: inMemory,
+		progress:        make(map[string]int64),
+	}
+
+	// Canonical chain with a block every 32 slots, and an orphaned fork of two blocks.
+	chain := make([]*chaindb.Block, 0)
+	parentRoot := phase0.Root{}
+	for slot := phase0.Slot(0); slot <= 288; slot += 32 {
+		block := &chaindb.Block{
+			Slot:       slot,
+			Root:       phase0.Root{0x01, byte(slot / 32)},
+			ParentRoot: parentRoot,
+		}
+		require.NoError(t, db.SetBlock(ctx, block))
+		chain = append(chain, block)
+		parentRoot = block.Root
+	}
+	fork := &chaindb.Block{Slot: 40, Root: phase0.Root{0x02, 40}, ParentRoot: chain[1].Root}
+	require.NoError(t, db.SetBlock(ctx, fork))
+	forkChild := &chaindb.Block{Slot: 70, Root: phase0.Root{0x02, 70}, ParentRoot: fork.Root}
+	require.NoError(t, db.SetBlock(ctx, forkChild))
+
+	s := &Service{
+		chainDB:         db,
+		blocksProvider:  db,
+		blocksSetter:    db,
+		canonicalSetter: db,
+		chainTime:       mockchaintime.New(),
+	}
+
+	canonicalState := func(root phase0.Root) *bool {
+		block, err := db.BlockByRoot(ctx, root)
+		require.NoError(t, err)
+		return block.Canonical
+	}
+
+	// Interrupt the first run after the first range of 8 epochs (96 slots).
+	db.failAfter = 1
+	_, err = s.updateCanonicalBlocksInRanges(ctx, chain[len(chain)-1].Root)
+	require.EqualError(t, err, "failed to set blocks canonical: interrupted")
+	require.Equal(t, int64(64), db.progress["latest_canonical_slot"])
+	for _, block := range chain[:3] {
+		require.True(t, *canonicalState(block.Root))
+	}
+	for _, block := range chain[3:] {
+		require.Nil(t, canonicalState(block.Root))
+	}
+	require.False(t, *canonicalState(fork.Root))
+	// The fork's child is after the completed range, so is not yet determined.
+	require.Nil(t, canonicalState(forkChild.Root))
+
+	// Resume; only the remaining ranges should be canonicalized.
+	db.failAfter = 0
+	db.canonicalCalls = 0
+	canonicalized, err := s.updateCanonicalBlocksInRanges(ctx, chain[len(chain)-1].Root)
+	require.NoError(t, err)
+	require.True(t, canonicalized)
+	require.Equal(t, 3, db.canonicalCalls)
+	require.Equal(t, int64(288), db.progress["latest_canonical_slot"])
+	for _, block := range chain {
+		require.True(t, *canonicalState(block.Root))
+	}
+	require.False(t, *canonicalState(fork.Root))
+	require.False(t, *canonicalState(forkChild.Root))
+
+	// A block that is not held cannot be canonicalized in ranges.
+	canonicalized, err = s.updateCanonicalBlocksInRanges(ctx, phase0.Root{0x03})
+	require.NoError(t, err)
+	require.False(t, canonicalized)
+}
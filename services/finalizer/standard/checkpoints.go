@@ -0,0 +1,123 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// updateCheckpoints stores the checkpoints for all epochs up to and including the given finalized epoch.
+func (s *Service) updateCheckpoints(ctx context.Context, finalizedEpoch phase0.Epoch) error {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	for epoch := phase0.Epoch(md.LatestCheckpointEpoch + 1); epoch <= finalizedEpoch; epoch++ {
+		if int64(s.chainTime.FirstSlotOfEpoch(epoch)) > md.LatestCanonicalSlot {
+			// Blocks for this epoch are not yet canonical.
+			log.Trace().Uint64("epoch", uint64(epoch)).Msg("Epoch boundary not yet canonical; not storing checkpoint")
+			break
+		}
+
+		checkpoint, err := s.epochCheckpoint(ctx, epoch)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to obtain checkpoint for epoch %d", epoch))
+		}
+
+		ctx, cancel, err := s.chainDB.BeginTx(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to begin transaction")
+		}
+		if err := s.checkpointsSetter.SetCheckpoint(ctx, checkpoint); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set checkpoint")
+		}
+		md.LatestCheckpointEpoch = int64(epoch)
+		if err := s.setMetadata(ctx, md); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to update metadata for checkpoint")
+		}
+		if err := s.chainDB.CommitTx(ctx); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to commit transaction")
+		}
+		log.Trace().Uint64("epoch", uint64(epoch)).Msg("Stored checkpoint")
+	}
+
+	return nil
+}
+
+// epochCheckpoint obtains the checkpoint for the given epoch.
+func (s *Service) epochCheckpoint(ctx context.Context, epoch phase0.Epoch) (*chaindb.EpochCheckpoint, error) {
+	slot := s.chainTime.FirstSlotOfEpoch(epoch)
+
+	blockRoot, err := s.canonicalBlockRootAtOrBefore(ctx, slot)
+	if err != nil {
+		return nil, err
+	}
+
+	stateRootResponse, err := s.eth2Client.(eth2client.BeaconStateRootProvider).BeaconStateRoot(ctx, &api.BeaconStateRootOpts{
+		State: fmt.Sprintf("%d", slot),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain state root")
+	}
+
+	finalityResponse, err := s.eth2Client.(eth2client.FinalityProvider).Finality(ctx, &api.FinalityOpts{
+		State: fmt.Sprintf("%d", slot),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain finality")
+	}
+	finality := finalityResponse.Data
+
+	return &chaindb.EpochCheckpoint{
+		Epoch:                  epoch,
+		BlockRoot:              blockRoot,
+		StateRoot:              *stateRootResponse.Data,
+		PreviousJustifiedEpoch: finality.PreviousJustified.Epoch,
+		PreviousJustifiedRoot:  finality.PreviousJustified.Root,
+		JustifiedEpoch:         finality.Justified.Epoch,
+		JustifiedRoot:          finality.Justified.Root,
+		FinalizedEpoch:         finality.Finalized.Epoch,
+		FinalizedRoot:          finality.Finalized.Root,
+	}, nil
+}
+
+// canonicalBlockRootAtOrBefore obtains the root of the latest canonical block at or before the given slot.
+func (s *Service) canonicalBlockRootAtOrBefore(ctx context.Context, slot phase0.Slot) (phase0.Root, error) {
+	for {
+		blocks, err := s.blocksProvider.BlocksBySlot(ctx, slot)
+		if err != nil {
+			return phase0.Root{}, errors.Wrap(err, "failed to obtain blocks")
+		}
+		for _, block := range blocks {
+			if block.Canonical != nil && *block.Canonical {
+				return block.Root, nil
+			}
+		}
+		if slot == 0 {
+			return phase0.Root{}, errors.New("failed to obtain canonical block")
+		}
+		slot--
+	}
+}
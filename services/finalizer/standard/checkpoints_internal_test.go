@@ -0,0 +1,225 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/chaintime"
+)
+
+// stateClient is a beacon node that serves state information derived from the
+// requested slot, and can be set to fail for a slot to simulate an interruption.
+type stateClient struct {
+	failSlot *phase0.Slot
+	states   []string
+}
+
+func (*stateClient) Name() string {
+	return "state"
+}
+
+func (*stateClient) Address() string {
+	return "state"
+}
+
+func (c *stateClient) slot(state string) (phase0.Slot, error) {
+	slot, err := strconv.ParseUint(state, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if c.failSlot != nil && *c.failSlot == phase0.Slot(slot) {
+		return 0, errors.New("unavailable")
+	}
+	c.states = append(c.states, state)
+
+	return phase0.Slot(slot), nil
+}
+
+func (c *stateClient) BeaconStateRoot(_ context.Context, opts *api.BeaconStateRootOpts) (*api.Response[*phase0.Root], error) {
+	slot, err := c.slot(opts.State)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.Response[*phase0.Root]{Data: &phase0.Root{0x02, byte(slot)}}, nil
+}
+
+func (c *stateClient) Finality(_ context.Context, opts *api.FinalityOpts) (*api.Response[*apiv1.Finality], error) {
+	slot, err := c.slot(opts.State)
+	if err != nil {
+		return nil, err
+	}
+	epoch := phase0.Epoch(slot / 32)
+
+	return &api.Response[*apiv1.Finality]{
+		Data: &apiv1.Finality{
+			PreviousJustified: &phase0.Checkpoint{Epoch: epoch, Root: phase0.Root{0x03, byte(epoch)}},
+			Justified:         &phase0.Checkpoint{Epoch: epoch, Root: phase0.Root{0x04, byte(epoch)}},
+			Finalized:         &phase0.Checkpoint{Epoch: epoch, Root: phase0.Root{0x05, byte(epoch)}},
+		},
+	}, nil
+}
+
+// stateDB is an in-memory chain database that records epoch data and
+// committed progress.
+type stateDB struct {
+	*mockchaindb.InMemoryService
+
+	checkpoints map[phase0.Epoch]*chaindb.EpochCheckpoint
+	mixes       map[phase0.Epoch]*chaindb.RANDAOMix
+	pending     map[string]int64
+	progress    map[string]int64
+}
+
+func newStateDB(t *testing.T) *stateDB {
+	t.Helper()
+	inMemory, err := mockchaindb.NewInMemory(context.Background(), nil)
+	require.NoError(t, err)
+
+	return &stateDB{
+		InMemoryService: inMemory,
+		checkpoints:     make(map[phase0.Epoch]*chaindb.EpochCheckpoint),
+		mixes:           make(map[phase0.Epoch]*chaindb.RANDAOMix),
+		pending:         make(map[string]int64),
+		progress:        make(map[string]int64),
+	}
+}
+
+func (d *stateDB) SetCheckpoint(_ context.Context, checkpoint *chaindb.EpochCheckpoint) error {
+	d.checkpoints[checkpoint.Epoch] = checkpoint
+	return nil
+}
+
+func (d *stateDB) SetProgress(_ context.Context, _ string, key string, value int64) error {
+	d.pending[key] = value
+	return nil
+}
+
+func (d *stateDB) CommitTx(_ context.Context) error {
+	for key, value := range d.pending {
+		d.progress[key] = value
+	}
+	d.pending = make(map[string]int64)
+	return nil
+}
+
+func (d *stateDB) Progress(_ context.Context, service string) (*chaindb.Progress, error) {
+	values := make(map[string]int64, len(d.progress))
+	for key, value := range d.progress {
+		values[key] = value
+	}
+	return &chaindb.Progress{Service: service, Values: values}, nil
+}
+
+// setCanonicalBlocks stores canonical blocks at the given slots.
+func (d *stateDB) setCanonicalBlocks(t *testing.T, slots ...phase0.Slot) {
+	t.Helper()
+	canonical := true
+	for _, slot := range slots {
+		require.NoError(t, d.SetBlock(context.Background(), &chaindb.Block{
+			Slot:      slot,
+			Root:      phase0.Root{0x01, byte(slot)},
+			Canonical: &canonical,
+		}))
+	}
+}
+
+// stateChainTime is a chain time with 32 slots per epoch.
+type stateChainTime struct {
+	chaintime.Service
+}
+
+func (*stateChainTime) FirstSlotOfEpoch(epoch phase0.Epoch) phase0.Slot {
+	return phase0.Slot(epoch * 32)
+}
+
+func (*stateChainTime) SlotToEpoch(slot phase0.Slot) phase0.Epoch {
+	return phase0.Epoch(slot / 32)
+}
+
+func TestUpdateCheckpoints(t *testing.T) {
+	ctx := context.Background()
+
+	db := newStateDB(t)
+	// Epoch 3 has no blocks, and the block at the start of epoch 4 was orphaned.
+	db.setCanonicalBlocks(t, 0, 32, 64, 65, 160, 192)
+	notCanonical := false
+	require.NoError(t, db.SetBlock(ctx, &chaindb.Block{Slot: 128, Root: phase0.Root{0x07}, Canonical: &notCanonical}))
+	db.progress["latest_canonical_slot"] = 192
+
+	failSlot := phase0.Slot(128)
+	client := &stateClient{failSlot: &failSlot}
+	s := &Service{
+		eth2Client:        client,
+		chainDB:           db,
+		blocksProvider:    db,
+		checkpointsSetter: db,
+		chainTime:         &stateChainTime{},
+	}
+
+	// Interrupt when obtaining the checkpoint for epoch 4.
+	require.EqualError(t, s.updateCheckpoints(ctx, 7), "failed to obtain checkpoint for epoch 4: failed to obtain state root: unavailable")
+	require.Equal(t, int64(3), db.progress["latest_checkpoint_epoch"])
+	require.Len(t, db.checkpoints, 4)
+	// Epochs without a block at their first slot use the latest canonical block before it.
+	require.Equal(t, phase0.Root{0x01, 32}, db.checkpoints[1].BlockRoot)
+	require.Equal(t, phase0.Root{0x01, 65}, db.checkpoints[3].BlockRoot)
+	require.Equal(t, &chaindb.EpochCheckpoint{
+		Epoch:                  3,
+		BlockRoot:              phase0.Root{0x01, 65},
+		StateRoot:              phase0.Root{0x02, 96},
+		PreviousJustifiedEpoch: 3,
+		PreviousJustifiedRoot:  phase0.Root{0x03, 3},
+		JustifiedEpoch:         3,
+		JustifiedRoot:          phase0.Root{0x04, 3},
+		FinalizedEpoch:         3,
+		FinalizedRoot:          phase0.Root{0x05, 3},
+	}, db.checkpoints[3])
+
+	// Catch up after the gap; only the remaining epochs are fetched, and those
+	// past the latest canonical slot are left for later.
+	client.failSlot = nil
+	client.states = nil
+	require.NoError(t, s.updateCheckpoints(ctx, 7))
+	require.Equal(t, []string{"128", "128", "160", "160", "192", "192"}, client.states)
+	require.Equal(t, int64(6), db.progress["latest_checkpoint_epoch"])
+	require.Len(t, db.checkpoints, 7)
+	// The orphaned block is not used for the checkpoint.
+	require.Equal(t, phase0.Root{0x01, 65}, db.checkpoints[4].BlockRoot)
+	require.Equal(t, phase0.Root{0x01, 192}, db.checkpoints[6].BlockRoot)
+
+	// Once blocks are canonical the remaining epochs are stored.
+	db.setCanonicalBlocks(t, 224)
+	db.progress["latest_canonical_slot"] = 255
+	client.states = nil
+	require.NoError(t, s.updateCheckpoints(ctx, 7))
+	require.Equal(t, []string{"224", "224"}, client.states)
+	require.Equal(t, int64(7), db.progress["latest_checkpoint_epoch"])
+	require.Equal(t, phase0.Root{0x01, 224}, db.checkpoints[7].BlockRoot)
+
+	// Nothing further to do.
+	client.states = nil
+	require.NoError(t, s.updateCheckpoints(ctx, 7))
+	require.Empty(t, client.states)
+}
@@ -29,6 +29,9 @@ import (
 	"github.com/wealdtech/chaind/services/chaindb"
 )
 
+// canonicalRangeEpochs is the number of epochs of blocks canonicalized in each transaction.
+const canonicalRangeEpochs = 8
+
 // OnFinalityCheckpointReceived receives finality checkpoint notifications.
 func (s *Service) OnFinalityCheckpointReceived(
 	ctx context.Context,
@@ -83,6 +86,20 @@ func (s *Service) OnFinalityCheckpointReceived(
 		monitorEpochProcessed(checkpoint.Epoch)
 	}
 
+	if s.checkpointsSetter != nil {
+		if err := s.updateCheckpoints(ctx, finality.Finalized.Epoch); err != nil {
+			// Checkpoints are not required for finality, so carry on.
+			log.Warn().Err(err).Msg("Failed to update epoch checkpoints; will retry next finality update")
+		}
+	}
+
+	if s.randaoMixesSetter != nil {
+		if err := s.updateRANDAOMixes(ctx, finality.Finalized.Epoch); err != nil {
+			// RANDAO mixes are not required for finality, so carry on.
+			log.Warn().Err(err).Msg("Failed to update epoch RANDAO mixes; will retry next finality update")
+		}
+	}
+
 	log.Trace().Msg("Finished handling finality checkpoint")
 
 	// Notify that finality has been updated.
@@ -154,15 +171,28 @@ func (s *Service) runFinalityTransaction(
 	ctx context.Context,
 	checkpoint *phase0.Checkpoint,
 ) error {
+	canonicalized := false
+	if s.canonicalSetter != nil {
+		// Blocks are canonicalized in ranges, each in its own transaction.
+		var err error
+		log.Trace().Uint64("epoch", uint64(checkpoint.Epoch)).Msg("Updating canonical blocks in ranges on finality")
+		canonicalized, err = s.updateCanonicalBlocksInRanges(ctx, checkpoint.Root)
+		if err != nil {
+			return errors.Wrap(err, "Failed to update canonical blocks in ranges on finality")
+		}
+	}
+
 	ctx, cancel, err := s.chainDB.BeginTx(ctx)
 	if err != nil {
 		return errors.Wrap(err, "Failed to start transaction on finality")
 	}
 
-	log.Trace().Uint64("epoch", uint64(checkpoint.Epoch)).Msg("Updating canonical blocks on finality")
-	if err := s.updateCanonicalBlocks(ctx, checkpoint.Root); err != nil {
-		cancel()
-		return errors.Wrap(err, "Failed to update canonical blocks on finality")
+	if !canonicalized {
+		log.Trace().Uint64("epoch", uint64(checkpoint.Epoch)).Msg("Updating canonical blocks on finality")
+		if err := s.updateCanonicalBlocks(ctx, checkpoint.Root); err != nil {
+			cancel()
+			return errors.Wrap(err, "Failed to update canonical blocks on finality")
+		}
 	}
 
 	if err := s.updateAttestations(ctx, checkpoint.Epoch); err != nil {
@@ -181,6 +211,128 @@ func (s *Service) runFinalityTransaction(
 	return nil
 }
 
+// updateCanonicalBlocksInRanges updates all canonical blocks given a canonical block root, using
+// set-based updates over ranges of canonicalRangeEpochs epochs.  Each range is committed in its
+// own transaction along with the finalizer's progress, so an interrupted run resumes from the
+// last completed range.
+// It returns false if the block with the given root is not held in the database, in which case
+// the blocks must be canonicalized individually.
+func (s *Service) updateCanonicalBlocksInRanges(ctx context.Context, root phase0.Root) (bool, error) {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to obtain metadata on finality")
+	}
+
+	// The block at the latest canonical slot was marked as canonical by a previous run, so start after it.
+	minSlot := phase0.Slot(0)
+	if md.LatestCanonicalSlot > 0 {
+		minSlot = phase0.Slot(md.LatestCanonicalSlot + 1)
+	}
+
+	headers, err := s.canonicalSetter.ChainHeaders(ctx, root, minSlot)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to obtain chain")
+	}
+	if len(headers) == 0 {
+		return false, nil
+	}
+	log.Trace().Uint64("min_slot", uint64(minSlot)).Int("blocks", len(headers)).Msg("Obtained chain to canonicalize")
+
+	// If the chain ended early, because we do not hold a block, the missing part of the
+	// chain is walked as part of the first range.
+	var walkRoot *phase0.Root
+	if headers[0].Slot != 0 && headers[0].Slot != minSlot {
+		walkRoot = &headers[0].ParentRoot
+	}
+
+	// Indeterminate blocks are updated from the start of the chain for the first range, to
+	// pick up any blocks that arrived after their slot was canonicalized.
+	indeterminateFrom := phase0.Slot(0)
+	for _, rangeHeaders := range canonicalRanges(headers, phase0.Slot(s.chainTime.SlotsPerEpoch()*canonicalRangeEpochs)) {
+		if err := s.canonicalizeRange(ctx, rangeHeaders, indeterminateFrom, walkRoot); err != nil {
+			return false, err
+		}
+		walkRoot = nil
+		indeterminateFrom = rangeHeaders[len(rangeHeaders)-1].Slot
+	}
+
+	return true, nil
+}
+
+// canonicalizeRange marks the chain of blocks with the given headers as canonical, and sets the
+// state of indeterminate blocks from the given slot up to the last of the headers, in a single
+// transaction that also records the finalizer's progress.
+func (s *Service) canonicalizeRange(ctx context.Context,
+	headers []*chaindb.BlockHeader,
+	indeterminateFrom phase0.Slot,
+	walkRoot *phase0.Root,
+) error {
+	latest := headers[len(headers)-1]
+	log.Trace().Uint64("from_slot", uint64(headers[0].Slot)).Uint64("to_slot", uint64(latest.Slot)).Msg("Canonicalizing range")
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction for range")
+	}
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to obtain metadata for range")
+	}
+
+	if walkRoot != nil {
+		if err := s.canonicalizeBlocks(ctx, *walkRoot, phase0.Slot(md.LatestCanonicalSlot)); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to update canonical blocks missing from chain")
+		}
+	}
+
+	roots := make([]phase0.Root, len(headers))
+	for i := range headers {
+		roots[i] = headers[i].Root
+	}
+	if err := s.canonicalSetter.SetBlocksCanonical(ctx, roots); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set blocks canonical")
+	}
+
+	if err := s.canonicalSetter.SetIndeterminateBlocksCanonical(ctx, indeterminateFrom, latest.Slot); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set indeterminate blocks")
+	}
+
+	md.LatestCanonicalSlot = int64(latest.Slot)
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to update metadata for range")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction for range")
+	}
+
+	return nil
+}
+
+// canonicalRanges splits the headers, which are in slot order, in to ranges that do not
+// cross a multiple of the given number of slots.
+func canonicalRanges(headers []*chaindb.BlockHeader, rangeSlots phase0.Slot) [][]*chaindb.BlockHeader {
+	ranges := make([][]*chaindb.BlockHeader, 0)
+	for start := 0; start < len(headers); {
+		rangeEnd := (headers[start].Slot/rangeSlots + 1) * rangeSlots
+		end := start + 1
+		for end < len(headers) && headers[end].Slot < rangeEnd {
+			end++
+		}
+		ranges = append(ranges, headers[start:end])
+		start = end
+	}
+
+	return ranges
+}
+
 // updateCanonicalBlocks updates all canonical blocks given a canonical block root.
 func (s *Service) updateCanonicalBlocks(ctx context.Context, root phase0.Root) error {
 	md, err := s.getMetadata(ctx)
@@ -356,6 +508,9 @@ func (s *Service) updateAttestationsInEpoch(ctx context.Context, epoch phase0.Ep
 	// Keep track of roots for heads to reduce lookups.
 	headRoots := make(map[phase0.Slot]phase0.Root)
 
+	// Keep track of blocks to reduce lookups when walking the chains of inclusion blocks.
+	blocks := make(map[phase0.Root]*chaindb.Block)
+
 	updatedSlots := make(map[int]struct{})
 	for _, attestation := range attestations {
 		if err := s.updateCanonical(ctx, attestation, blockCanonicals); err != nil {
@@ -367,6 +522,12 @@ func (s *Service) updateAttestationsInEpoch(ctx context.Context, epoch phase0.Ep
 		if err := s.updateAttestationHeadCorrect(ctx, attestation, headRoots); err != nil {
 			return errors.Wrap(err, "failed to update attestation head vote state")
 		}
+		if err := s.updateAttestationSourceCorrect(ctx, attestation, epochRoots); err != nil {
+			return errors.Wrap(err, "failed to update attestation source vote state")
+		}
+		if err := s.updateAttestationInclusionCorrect(ctx, attestation, blocks); err != nil {
+			return errors.Wrap(err, "failed to update attestation inclusion vote state")
+		}
 		if err := s.chainDB.(chaindb.AttestationsSetter).SetAttestation(ctx, attestation); err != nil {
 			return errors.Wrap(err, "failed to update attestation")
 		}
@@ -410,42 +571,68 @@ func (s *Service) updateCanonical(ctx context.Context, attestation *chaindb.Atte
 // An attestation has a correct target vote if it matches the root of the latest canonical block
 // since the start of the target epoch.
 func (s *Service) updateAttestationTargetCorrect(ctx context.Context, attestation *chaindb.Attestation, epochRoots map[phase0.Epoch]phase0.Root) error {
-	targetCorrect := false
-	if epochRoot, exists := epochRoots[attestation.TargetEpoch]; exists {
-		targetCorrect = bytes.Equal(attestation.TargetRoot[:], epochRoot[:])
-	} else {
-		// Start with first slot of the target epoch.
-		startSlot := s.chainTime.FirstSlotOfEpoch(attestation.TargetEpoch)
-
-		// Work backwards until we find a canonical block.
-		canonicalBlockFound := false
-		for slot := startSlot; !canonicalBlockFound; slot-- {
-			log.Trace().Uint64("slot", uint64(slot)).Msg("Fetching blocks at slot")
-			blocks, err := s.chainDB.(chaindb.BlocksProvider).BlocksBySlot(ctx, slot)
-			if err != nil {
-				return errors.Wrap(err, "failed to obtain block")
-			}
-			for _, block := range blocks {
-				if block.Canonical != nil && *block.Canonical {
-					log.Trace().Uint64("target_epoch", uint64(attestation.TargetEpoch)).Uint64("slot", uint64(block.Slot)).Msg("Found canonical block")
-					canonicalBlockFound = true
-					epochRoots[attestation.TargetEpoch] = block.Root
-					targetCorrect = bytes.Equal(attestation.TargetRoot[:], block.Root[:])
-					break
-				}
-			}
-			if slot == 0 {
-				break
+	epochRoot, err := s.canonicalEpochRoot(ctx, attestation.TargetEpoch, epochRoots)
+	if err != nil {
+		return err
+	}
+	targetCorrect := bytes.Equal(attestation.TargetRoot[:], epochRoot[:])
+	attestation.TargetCorrect = &targetCorrect
+
+	return nil
+}
+
+// updateAttestationSourceCorrect updates the attestation to confirm if its source vote is correct.
+// An attestation has a correct source vote if it matches the root of the latest canonical block
+// since the start of the source epoch.
+func (s *Service) updateAttestationSourceCorrect(ctx context.Context, attestation *chaindb.Attestation, epochRoots map[phase0.Epoch]phase0.Root) error {
+	if attestation.SourceEpoch == 0 && attestation.SourceRoot == (phase0.Root{}) {
+		// The genesis checkpoint has a zero root.
+		sourceCorrect := true
+		attestation.SourceCorrect = &sourceCorrect
+
+		return nil
+	}
+
+	epochRoot, err := s.canonicalEpochRoot(ctx, attestation.SourceEpoch, epochRoots)
+	if err != nil {
+		return err
+	}
+	sourceCorrect := bytes.Equal(attestation.SourceRoot[:], epochRoot[:])
+	attestation.SourceCorrect = &sourceCorrect
+
+	return nil
+}
+
+// canonicalEpochRoot provides the root of the latest canonical block since the start of the epoch.
+func (s *Service) canonicalEpochRoot(ctx context.Context, epoch phase0.Epoch, epochRoots map[phase0.Epoch]phase0.Root) (phase0.Root, error) {
+	if epochRoot, exists := epochRoots[epoch]; exists {
+		return epochRoot, nil
+	}
+
+	// Start with first slot of the epoch.
+	startSlot := s.chainTime.FirstSlotOfEpoch(epoch)
+
+	// Work backwards until we find a canonical block.
+	for slot := startSlot; ; slot-- {
+		log.Trace().Uint64("slot", uint64(slot)).Msg("Fetching blocks at slot")
+		blocks, err := s.chainDB.(chaindb.BlocksProvider).BlocksBySlot(ctx, slot)
+		if err != nil {
+			return phase0.Root{}, errors.Wrap(err, "failed to obtain block")
+		}
+		for _, block := range blocks {
+			if block.Canonical != nil && *block.Canonical {
+				log.Trace().Uint64("epoch", uint64(epoch)).Uint64("slot", uint64(block.Slot)).Msg("Found canonical block")
+				epochRoots[epoch] = block.Root
+
+				return block.Root, nil
 			}
 		}
-		if !canonicalBlockFound {
-			return errors.New("failed to obtain canonical block")
+		if slot == 0 {
+			break
 		}
 	}
 
-	attestation.TargetCorrect = &targetCorrect
-
-	return nil
+	return phase0.Root{}, errors.New("failed to obtain canonical block")
 }
 
 // updateAttestationHeadCorrect updates the attestation to confirm if its head vote is correct.
@@ -0,0 +1,93 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// updateAttestationInclusionCorrect updates the attestation to confirm if its target and head votes
+// were correct according to the chain of the block in which it was included.
+// If the chain of the block is not fully stored then the values are left unset.
+func (s *Service) updateAttestationInclusionCorrect(ctx context.Context,
+	attestation *chaindb.Attestation,
+	blocks map[phase0.Root]*chaindb.Block,
+) error {
+	fetch := func(ctx context.Context, root phase0.Root) (*chaindb.Block, error) {
+		if block, exists := blocks[root]; exists {
+			return block, nil
+		}
+		block, err := s.chainDB.(chaindb.BlocksProvider).BlockByRoot(ctx, root)
+		if err != nil {
+			return nil, err
+		}
+		blocks[root] = block
+
+		return block, nil
+	}
+
+	targetRoot, err := ancestorAtOrBefore(ctx, attestation.InclusionBlockRoot, s.chainTime.FirstSlotOfEpoch(attestation.TargetEpoch), fetch)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug().Stringer("block_root", attestation.InclusionBlockRoot).Msg("Chain of inclusion block not stored; cannot check inclusion correctness")
+			return nil
+		}
+		return err
+	}
+	headRoot, err := ancestorAtOrBefore(ctx, attestation.InclusionBlockRoot, attestation.Slot, fetch)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug().Stringer("block_root", attestation.InclusionBlockRoot).Msg("Chain of inclusion block not stored; cannot check inclusion correctness")
+			return nil
+		}
+		return err
+	}
+
+	targetCorrect := attestation.TargetRoot == targetRoot
+	headCorrect := attestation.BeaconBlockRoot == headRoot
+	attestation.InclusionTargetCorrect = &targetCorrect
+	attestation.InclusionHeadCorrect = &headCorrect
+
+	return nil
+}
+
+// ancestorAtOrBefore provides the root of the latest block at or before the given slot
+// in the chain of the given block.
+func ancestorAtOrBefore(ctx context.Context,
+	root phase0.Root,
+	slot phase0.Slot,
+	fetch func(ctx context.Context, root phase0.Root) (*chaindb.Block, error),
+) (
+	phase0.Root,
+	error,
+) {
+	for {
+		block, err := fetch(ctx, root)
+		if err != nil {
+			return phase0.Root{}, err
+		}
+		if block == nil {
+			return phase0.Root{}, pgx.ErrNoRows
+		}
+		if block.Slot <= slot || block.Slot == 0 {
+			return block.Root, nil
+		}
+		root = block.ParentRoot
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestAncestorAtOrBefore(t *testing.T) {
+	ctx := context.Background()
+
+	// Chain of blocks at slots 0, 1, 3 and 4, with a fork from slot 1 containing a block at slot 2,
+	// and a block at slot 6 whose parent is not stored.
+	blocks := map[phase0.Root]*chaindb.Block{
+		{0x00}: {Slot: 0, Root: phase0.Root{0x00}},
+		{0x01}: {Slot: 1, Root: phase0.Root{0x01}, ParentRoot: phase0.Root{0x00}},
+		{0x03}: {Slot: 3, Root: phase0.Root{0x03}, ParentRoot: phase0.Root{0x01}},
+		{0x04}: {Slot: 4, Root: phase0.Root{0x04}, ParentRoot: phase0.Root{0x03}},
+		{0x02}: {Slot: 2, Root: phase0.Root{0x02}, ParentRoot: phase0.Root{0x01}},
+		{0x06}: {Slot: 6, Root: phase0.Root{0x06}, ParentRoot: phase0.Root{0x05}},
+	}
+	fetch := func(_ context.Context, root phase0.Root) (*chaindb.Block, error) {
+		block, exists := blocks[root]
+		if !exists {
+			return nil, pgx.ErrNoRows
+		}
+
+		return block, nil
+	}
+
+	tests := []struct {
+		name     string
+		root     phase0.Root
+		slot     phase0.Slot
+		ancestor phase0.Root
+		err      error
+	}{
+		{
+			name:     "Self",
+			root:     phase0.Root{0x04},
+			slot:     4,
+			ancestor: phase0.Root{0x04},
+		},
+		{
+			name:     "Parent",
+			root:     phase0.Root{0x04},
+			slot:     3,
+			ancestor: phase0.Root{0x03},
+		},
+		{
+			name:     "EmptySlot",
+			root:     phase0.Root{0x04},
+			slot:     2,
+			ancestor: phase0.Root{0x01},
+		},
+		{
+			name:     "Fork",
+			root:     phase0.Root{0x02},
+			slot:     2,
+			ancestor: phase0.Root{0x02},
+		},
+		{
+			name:     "Genesis",
+			root:     phase0.Root{0x04},
+			slot:     0,
+			ancestor: phase0.Root{0x00},
+		},
+		{
+			name: "Missing",
+			root: phase0.Root{0x06},
+			slot: 4,
+			err:  pgx.ErrNoRows,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ancestor, err := ancestorAtOrBefore(ctx, test.root, test.slot, fetch)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.ancestor, ancestor)
+			}
+		})
+	}
+}
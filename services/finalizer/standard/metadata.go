@@ -15,48 +15,74 @@ package standard
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/finalizer"
 )
 
 // metadata stored about this service.
 type metadata struct {
-	LastFinalizedEpoch  int64   `json:"latest_epoch"`
-	LatestCanonicalSlot int64   `json:"latest_canonical_slot"`
-	MissedEpochs        []int64 `json:"missed_epochs,omitempty"`
+	LastFinalizedEpoch    int64
+	LatestCanonicalSlot   int64
+	LatestCheckpointEpoch int64
+	LatestRANDAOEpoch     int64
+	MissedEpochs          []int64
 }
 
-// metadataKey is the key for the metadata.
-var metadataKey = "finalizer.standard"
+// progressService is the name of this service for progress.
+var progressService = finalizer.ProgressService
 
 // getMetadata gets metadata for this service.
 func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 	md := &metadata{
-		LastFinalizedEpoch:  -1,
-		LatestCanonicalSlot: -1,
+		LastFinalizedEpoch:    -1,
+		LatestCanonicalSlot:   -1,
+		LatestCheckpointEpoch: -1,
+		LatestRANDAOEpoch:     -1,
 	}
-	mdJSON, err := s.chainDB.Metadata(ctx, metadataKey)
+	progress, err := s.chainDB.Progress(ctx, progressService)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to fetch metadata")
+		return nil, errors.Wrap(err, "failed to fetch progress")
 	}
-	if mdJSON == nil {
+	if progress == nil {
 		return md, nil
 	}
-	if err := json.Unmarshal(mdJSON, md); err != nil {
-		return nil, errors.Wrap(err, "failed to unmarshal metadata")
+	if val, exists := progress.Values["latest_epoch"]; exists {
+		md.LastFinalizedEpoch = val
 	}
+	if val, exists := progress.Values["latest_canonical_slot"]; exists {
+		md.LatestCanonicalSlot = val
+	}
+	if val, exists := progress.Values["latest_checkpoint_epoch"]; exists {
+		md.LatestCheckpointEpoch = val
+	}
+	if val, exists := progress.Values["latest_randao_epoch"]; exists {
+		md.LatestRANDAOEpoch = val
+	}
+	md.MissedEpochs = progress.Gaps["missed_epochs"]
 	return md, nil
 }
 
 // setMetadata sets metadata for this service.
 func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
-	mdJSON, err := json.Marshal(md)
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal metadata")
+	if err := s.chainDB.SetProgress(ctx, progressService, "latest_epoch", md.LastFinalizedEpoch); err != nil {
+		return errors.Wrap(err, "failed to update latest epoch")
+	}
+	if err := s.chainDB.SetProgress(ctx, progressService, "latest_canonical_slot", md.LatestCanonicalSlot); err != nil {
+		return errors.Wrap(err, "failed to update latest canonical slot")
+	}
+	if md.LatestCheckpointEpoch != -1 {
+		if err := s.chainDB.SetProgress(ctx, progressService, "latest_checkpoint_epoch", md.LatestCheckpointEpoch); err != nil {
+			return errors.Wrap(err, "failed to update latest checkpoint epoch")
+		}
+	}
+	if md.LatestRANDAOEpoch != -1 {
+		if err := s.chainDB.SetProgress(ctx, progressService, "latest_randao_epoch", md.LatestRANDAOEpoch); err != nil {
+			return errors.Wrap(err, "failed to update latest RANDAO epoch")
+		}
 	}
-	if err := s.chainDB.SetMetadata(ctx, metadataKey, mdJSON); err != nil {
-		return errors.Wrap(err, "failed to update metadata")
+	if err := s.chainDB.SetProgressGaps(ctx, progressService, "missed_epochs", md.MissedEpochs); err != nil {
+		return errors.Wrap(err, "failed to update missed epochs")
 	}
 	return nil
 }
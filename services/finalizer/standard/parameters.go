@@ -35,6 +35,8 @@ type parameters struct {
 	blocks           blocks.Service
 	finalityHandlers []handlers.FinalityHandler
 	activitySem      *semaphore.Weighted
+	checkpoints      bool
+	randaoMixes      bool
 }
 
 // Parameter is the interface for service parameters.
@@ -104,6 +106,20 @@ func WithActivitySem(sem *semaphore.Weighted) Parameter {
 	})
 }
 
+// WithCheckpoints states if the module should store epoch checkpoints.
+func WithCheckpoints(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.checkpoints = enabled
+	})
+}
+
+// WithRANDAOMixes states if the module should store epoch RANDAO mixes.
+func WithRANDAOMixes(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.randaoMixes = enabled
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
@@ -0,0 +1,74 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// updateRANDAOMixes stores the RANDAO mixes for all epochs up to and including the given finalized epoch.
+func (s *Service) updateRANDAOMixes(ctx context.Context, finalizedEpoch phase0.Epoch) error {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	for epoch := phase0.Epoch(md.LatestRANDAOEpoch + 1); epoch <= finalizedEpoch; epoch++ {
+		// The mix is taken from the state at the last slot of the epoch.
+		slot := s.chainTime.FirstSlotOfEpoch(epoch+1) - 1
+		if int64(slot) > md.LatestCanonicalSlot {
+			// Blocks for this epoch are not yet canonical.
+			log.Trace().Uint64("epoch", uint64(epoch)).Msg("Epoch end not yet canonical; not storing RANDAO mix")
+			break
+		}
+
+		randaoResponse, err := s.eth2Client.(eth2client.BeaconStateRandaoProvider).BeaconStateRandao(ctx, &api.BeaconStateRandaoOpts{
+			State: fmt.Sprintf("%d", slot),
+		})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to obtain RANDAO mix for epoch %d", epoch))
+		}
+
+		ctx, cancel, err := s.chainDB.BeginTx(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to begin transaction")
+		}
+		if err := s.randaoMixesSetter.SetRANDAOMix(ctx, &chaindb.RANDAOMix{
+			Epoch: epoch,
+			Mix:   *randaoResponse.Data,
+		}); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set RANDAO mix")
+		}
+		md.LatestRANDAOEpoch = int64(epoch)
+		if err := s.setMetadata(ctx, md); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to update metadata for RANDAO mix")
+		}
+		if err := s.chainDB.CommitTx(ctx); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to commit transaction")
+		}
+		log.Trace().Uint64("epoch", uint64(epoch)).Msg("Stored RANDAO mix")
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func (c *stateClient) BeaconStateRandao(_ context.Context, opts *api.BeaconStateRandaoOpts) (*api.Response[*phase0.Root], error) {
+	slot, err := c.slot(opts.State)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.Response[*phase0.Root]{Data: &phase0.Root{0x08, byte(slot)}}, nil
+}
+
+func (d *stateDB) SetRANDAOMix(_ context.Context, mix *chaindb.RANDAOMix) error {
+	d.mixes[mix.Epoch] = mix
+	return nil
+}
+
+func TestUpdateRANDAOMixes(t *testing.T) {
+	ctx := context.Background()
+
+	db := newStateDB(t)
+	db.progress["latest_canonical_slot"] = 150
+
+	failSlot := phase0.Slot(95)
+	client := &stateClient{failSlot: &failSlot}
+	s := &Service{
+		eth2Client:        client,
+		chainDB:           db,
+		randaoMixesSetter: db,
+		chainTime:         &stateChainTime{},
+	}
+
+	// Interrupt when obtaining the mix for epoch 2.
+	require.EqualError(t, s.updateRANDAOMixes(ctx, 5), "failed to obtain RANDAO mix for epoch 2: unavailable")
+	require.Equal(t, int64(1), db.progress["latest_randao_epoch"])
+	require.Len(t, db.mixes, 2)
+
+	// Resume; only the remaining epochs are fetched, and those that do not
+	// end by the latest canonical slot are left for later.
+	client.failSlot = nil
+	client.states = nil
+	require.NoError(t, s.updateRANDAOMixes(ctx, 5))
+	require.Equal(t, []string{"95", "127"}, client.states)
+	require.Equal(t, int64(3), db.progress["latest_randao_epoch"])
+
+	// Once blocks are canonical the remaining finalized epochs are stored.
+	db.progress["latest_canonical_slot"] = 200
+	client.states = nil
+	require.NoError(t, s.updateRANDAOMixes(ctx, 5))
+	require.Equal(t, []string{"159", "191"}, client.states)
+	require.Equal(t, int64(5), db.progress["latest_randao_epoch"])
+
+	// The mix for each epoch is that of the state at the end of the epoch.
+	require.Len(t, db.mixes, 6)
+	for epoch := phase0.Epoch(0); epoch <= 5; epoch++ {
+		require.Equal(t, &chaindb.RANDAOMix{
+			Epoch: epoch,
+			Mix:   phase0.Root{0x08, byte(epoch*32 + 31)},
+		}, db.mixes[epoch])
+	}
+
+	// Nothing further to do until a later epoch is finalized.
+	client.states = nil
+	require.NoError(t, s.updateRANDAOMixes(ctx, 5))
+	require.Empty(t, client.states)
+}
@@ -23,24 +23,27 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/handlers"
 	"github.com/wealdtech/chaind/services/blocks"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
 
 // Service is a finalizer service.
 type Service struct {
-	eth2Client       eth2client.Service
-	chainDB          chaindb.Service
-	blocksProvider   chaindb.BlocksProvider
-	blocksSetter     chaindb.BlocksSetter
-	chainTime        chaintime.Service
-	blocks           blocks.Service
-	finalityHandlers []handlers.FinalityHandler
-	activitySem      *semaphore.Weighted
+	eth2Client        eth2client.Service
+	chainDB           chaindb.Service
+	blocksProvider    chaindb.BlocksProvider
+	blocksSetter      chaindb.BlocksSetter
+	canonicalSetter   chaindb.CanonicalBlocksSetter
+	checkpointsSetter chaindb.CheckpointsSetter
+	randaoMixesSetter chaindb.RANDAOMixesSetter
+	chainTime         chaintime.Service
+	blocks            blocks.Service
+	finalityHandlers  []handlers.FinalityHandler
+	activitySem       *semaphore.Weighted
 }
 
 // module-wide log.
@@ -54,7 +57,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "finalizer").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log = util.ServiceLogger("finalizer", "standard", parameters.logLevel)
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -70,15 +73,42 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not support block setting")
 	}
 
+	// Canonical blocks setter is optional; if not present blocks are canonicalized individually.
+	canonicalSetter, _ := parameters.chainDB.(chaindb.CanonicalBlocksSetter)
+
+	var checkpointsSetter chaindb.CheckpointsSetter
+	if parameters.checkpoints {
+		var isCheckpointsSetter bool
+		checkpointsSetter, isCheckpointsSetter = parameters.chainDB.(chaindb.CheckpointsSetter)
+		if !isCheckpointsSetter {
+			return nil, errors.New("chain DB does not support checkpoint setting")
+		}
+	}
+
+	var randaoMixesSetter chaindb.RANDAOMixesSetter
+	if parameters.randaoMixes {
+		var isRANDAOMixesSetter bool
+		randaoMixesSetter, isRANDAOMixesSetter = parameters.chainDB.(chaindb.RANDAOMixesSetter)
+		if !isRANDAOMixesSetter {
+			return nil, errors.New("chain DB does not support RANDAO mix setting")
+		}
+		if _, isProvider := parameters.eth2Client.(eth2client.BeaconStateRandaoProvider); !isProvider {
+			return nil, errors.New("client does not support RANDAO mix providing")
+		}
+	}
+
 	s := &Service{
-		eth2Client:       parameters.eth2Client,
-		chainDB:          parameters.chainDB,
-		blocksProvider:   blocksProvider,
-		blocksSetter:     blocksSetter,
-		chainTime:        parameters.chainTime,
-		blocks:           parameters.blocks,
-		finalityHandlers: parameters.finalityHandlers,
-		activitySem:      parameters.activitySem,
+		eth2Client:        parameters.eth2Client,
+		chainDB:           parameters.chainDB,
+		blocksProvider:    blocksProvider,
+		blocksSetter:      blocksSetter,
+		canonicalSetter:   canonicalSetter,
+		checkpointsSetter: checkpointsSetter,
+		randaoMixesSetter: randaoMixesSetter,
+		chainTime:         parameters.chainTime,
+		blocks:            parameters.blocks,
+		finalityHandlers:  parameters.finalityHandlers,
+		activitySem:       parameters.activitySem,
 	}
 
 	// Set up the handler for new finality checkpoint updates.
@@ -0,0 +1,133 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+)
+
+// OnEvent handles an event from the beacon node.
+func (s *Service) OnEvent(event *apiv1.Event) {
+	// Take the time before doing anything else, to keep the recorded time as accurate as possible.
+	seen := time.Now()
+
+	switch data := event.Data.(type) {
+	case *apiv1.BlockEvent:
+		s.onBlock(data, seen)
+	case *phase0.Attestation:
+		s.onAttestation(data, seen)
+	default:
+		log.Trace().Str("topic", event.Topic).Msg("Ignoring unhandled event")
+	}
+}
+
+func (s *Service) onBlock(event *apiv1.BlockEvent, seen time.Time) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
+	if _, exists := s.pendingBlockArrivals[event.Block]; exists {
+		return
+	}
+	delay := seen.Sub(s.chainTime.StartOfSlot(event.Slot))
+	s.pendingBlockArrivals[event.Block] = &chaindb.BlockArrival{
+		BlockRoot:     event.Block,
+		Slot:          event.Slot,
+		SeenTimestamp: seen,
+		Delay:         delay,
+	}
+	monitorBlockSeen(delay)
+}
+
+func (s *Service) onAttestation(attestation *phase0.Attestation, seen time.Time) {
+	if attestation.Data == nil {
+		return
+	}
+	dataRoot, err := attestation.Data.HashTreeRoot()
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to obtain attestation data root")
+		return
+	}
+	key := fmt.Sprintf("%x:%x", dataRoot, []byte(attestation.AggregationBits))
+
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
+	if _, exists := s.pendingAttestationArrivals[key]; exists {
+		return
+	}
+	delay := seen.Sub(s.chainTime.StartOfSlot(attestation.Data.Slot))
+	s.pendingAttestationArrivals[key] = &chaindb.AttestationArrival{
+		Slot:            attestation.Data.Slot,
+		CommitteeIndex:  attestation.Data.Index,
+		BeaconBlockRoot: attestation.Data.BeaconBlockRoot,
+		DataRoot:        dataRoot,
+		AggregationBits: []byte(attestation.AggregationBits),
+		SeenTimestamp:   seen,
+		Delay:           delay,
+	}
+	monitorAttestationSeen(delay)
+}
+
+// flush writes pending arrivals to the database.
+func (s *Service) flush(ctx context.Context) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.gossip.standard").Start(ctx, "flush")
+	defer span.End()
+
+	s.pendingMu.Lock()
+	blockArrivals := make([]*chaindb.BlockArrival, 0, len(s.pendingBlockArrivals))
+	for _, arrival := range s.pendingBlockArrivals {
+		blockArrivals = append(blockArrivals, arrival)
+	}
+	attestationArrivals := make([]*chaindb.AttestationArrival, 0, len(s.pendingAttestationArrivals))
+	for _, arrival := range s.pendingAttestationArrivals {
+		attestationArrivals = append(attestationArrivals, arrival)
+	}
+	s.pendingBlockArrivals = make(map[[32]byte]*chaindb.BlockArrival)
+	s.pendingAttestationArrivals = make(map[string]*chaindb.AttestationArrival)
+	s.pendingMu.Unlock()
+
+	if len(blockArrivals) == 0 && len(attestationArrivals) == 0 {
+		return
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin transaction")
+		return
+	}
+	if err := s.arrivalsSetter.SetBlockArrivals(ctx, blockArrivals); err != nil {
+		cancel()
+		log.Error().Err(err).Msg("Failed to set block arrivals")
+		return
+	}
+	if err := s.arrivalsSetter.SetAttestationArrivals(ctx, attestationArrivals); err != nil {
+		cancel()
+		log.Error().Err(err).Msg("Failed to set attestation arrivals")
+		return
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		log.Error().Err(err).Msg("Failed to commit transaction")
+		return
+	}
+
+	log.Trace().Int("blocks", len(blockArrivals)).Int("attestations", len(attestationArrivals)).Msg("Flushed arrivals")
+}
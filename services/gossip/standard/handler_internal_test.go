@@ -0,0 +1,77 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+func TestOnEvent(t *testing.T) {
+	s := &Service{
+		chainTime:                  mockchaintime.New(),
+		pendingBlockArrivals:       make(map[[32]byte]*chaindb.BlockArrival),
+		pendingAttestationArrivals: make(map[string]*chaindb.AttestationArrival),
+	}
+
+	blockEvent := &apiv1.Event{
+		Topic: "block",
+		Data: &apiv1.BlockEvent{
+			Slot:  1,
+			Block: phase0.Root{0x01},
+		},
+	}
+	s.OnEvent(blockEvent)
+	first := s.pendingBlockArrivals[phase0.Root{0x01}].SeenTimestamp
+	time.Sleep(time.Millisecond)
+	// A second sighting of the same block should not alter the first-seen time.
+	s.OnEvent(blockEvent)
+	require.Len(t, s.pendingBlockArrivals, 1)
+	require.Equal(t, first, s.pendingBlockArrivals[phase0.Root{0x01}].SeenTimestamp)
+
+	attestation := &phase0.Attestation{
+		AggregationBits: bitfield.NewBitlist(4),
+		Data: &phase0.AttestationData{
+			Slot:            1,
+			Index:           2,
+			BeaconBlockRoot: phase0.Root{0x01},
+			Source:          &phase0.Checkpoint{},
+			Target:          &phase0.Checkpoint{},
+		},
+	}
+	attestation.AggregationBits.SetBitAt(0, true)
+	s.OnEvent(&apiv1.Event{Topic: "attestation", Data: attestation})
+	s.OnEvent(&apiv1.Event{Topic: "attestation", Data: attestation})
+	require.Len(t, s.pendingAttestationArrivals, 1)
+
+	// A different aggregation is a separate arrival.
+	aggregate := &phase0.Attestation{
+		AggregationBits: bitfield.NewBitlist(4),
+		Data:            attestation.Data,
+	}
+	aggregate.AggregationBits.SetBitAt(0, true)
+	aggregate.AggregationBits.SetBitAt(1, true)
+	s.OnEvent(&apiv1.Event{Topic: "attestation", Data: aggregate})
+	require.Len(t, s.pendingAttestationArrivals, 2)
+	for _, arrival := range s.pendingAttestationArrivals {
+		require.Equal(t, phase0.CommitteeIndex(2), arrival.CommitteeIndex)
+	}
+}
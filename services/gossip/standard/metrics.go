@@ -0,0 +1,81 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_gossip"
+
+var (
+	blockDelay       prometheus.Histogram
+	attestationDelay prometheus.Histogram
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if blockDelay != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	blockDelay = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "block_delay_seconds",
+		Help:      "Delay between the start of the slot and a block being first seen",
+		Buckets:   []float64{0.5, 1, 1.5, 2, 2.5, 3, 3.5, 4, 6, 8, 12},
+	})
+	if err := prometheus.Register(blockDelay); err != nil {
+		return errors.Wrap(err, "failed to register block_delay_seconds")
+	}
+
+	attestationDelay = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "attestation_delay_seconds",
+		Help:      "Delay between the start of the slot and an attestation being first seen",
+		Buckets:   []float64{2, 3, 4, 5, 6, 8, 10, 12, 18, 24},
+	})
+	if err := prometheus.Register(attestationDelay); err != nil {
+		return errors.Wrap(err, "failed to register attestation_delay_seconds")
+	}
+
+	return nil
+}
+
+func monitorBlockSeen(delay time.Duration) {
+	if blockDelay != nil {
+		blockDelay.Observe(delay.Seconds())
+	}
+}
+
+func monitorAttestationSeen(delay time.Duration) {
+	if attestationDelay != nil {
+		attestationDelay.Observe(delay.Seconds())
+	}
+}
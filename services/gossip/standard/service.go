@@ -0,0 +1,105 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a gossip capture service.
+type Service struct {
+	chainDB        chaindb.Service
+	chainTime      chaintime.Service
+	arrivalsSetter chaindb.ArrivalsSetter
+	flushInterval  time.Duration
+
+	pendingMu                  sync.Mutex
+	pendingBlockArrivals       map[[32]byte]*chaindb.BlockArrival
+	pendingAttestationArrivals map[string]*chaindb.AttestationArrival
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("gossip", "standard", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	eventsProvider, isEventsProvider := parameters.eth2Client.(eth2client.EventsProvider)
+	if !isEventsProvider {
+		return nil, errors.New("Ethereum 2 client does not support events")
+	}
+
+	arrivalsSetter, isArrivalsSetter := parameters.chainDB.(chaindb.ArrivalsSetter)
+	if !isArrivalsSetter {
+		return nil, errors.New("chain DB does not support arrival setting")
+	}
+
+	s := &Service{
+		chainDB:                    parameters.chainDB,
+		chainTime:                  parameters.chainTime,
+		arrivalsSetter:             arrivalsSetter,
+		flushInterval:              parameters.flushInterval,
+		pendingBlockArrivals:       make(map[[32]byte]*chaindb.BlockArrival),
+		pendingAttestationArrivals: make(map[string]*chaindb.AttestationArrival),
+	}
+
+	topics := []string{"block"}
+	if parameters.attestations {
+		topics = append(topics, "attestation")
+	}
+	if err := eventsProvider.Events(ctx, topics, s.OnEvent); err != nil {
+		return nil, errors.Wrap(err, "failed to add gossip event handler")
+	}
+
+	go s.flushLoop(ctx)
+
+	return s, nil
+}
+
+// flushLoop periodically writes captured arrivals to the database.
+func (s *Service) flushLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// Use a fresh context to write any remaining arrivals.
+			s.flush(context.Background())
+			log.Debug().Msg("Context done; gossip capture stopped")
+			return
+		case <-ticker.C:
+			s.flush(ctx)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_heads"
+
+var (
+	observations *prometheus.CounterVec
+	reorgs       prometheus.Counter
+	reorgDepth   prometheus.Histogram
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if observations != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	observations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "observations_total",
+		Help:      "Number of observations of the chain head",
+	}, []string{"result"})
+	if err := prometheus.Register(observations); err != nil {
+		return errors.Wrap(err, "failed to register observations_total")
+	}
+
+	reorgs = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reorgs_total",
+		Help:      "Number of observed heads that were not descendants of the previously observed head",
+	})
+	if err := prometheus.Register(reorgs); err != nil {
+		return errors.Wrap(err, "failed to register reorgs_total")
+	}
+
+	reorgDepth = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "reorg_depth_slots",
+		Help:      "Number of slots between the previously observed head and the common ancestor in a reorg",
+		Buckets:   []float64{1, 2, 3, 4, 8, 16, 32},
+	})
+	if err := prometheus.Register(reorgDepth); err != nil {
+		return errors.Wrap(err, "failed to register reorg_depth_slots")
+	}
+
+	return nil
+}
+
+func monitorObservation(result string) {
+	if observations != nil {
+		observations.WithLabelValues(result).Inc()
+	}
+}
+
+func monitorReorg(depth uint64) {
+	if reorgs != nil {
+		reorgs.Inc()
+		reorgDepth.Observe(float64(depth))
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+)
+
+// maxAncestorSearch is the maximum number of blocks walked back when
+// searching for the common ancestor of two heads.
+const maxAncestorSearch = 64
+
+// blockInfo is the information about a block required to walk its ancestry.
+type blockInfo struct {
+	slot       phase0.Slot
+	root       phase0.Root
+	parentRoot phase0.Root
+}
+
+// blockInfoFunc fetches information about the block with the given root.
+type blockInfoFunc func(ctx context.Context, root phase0.Root) (*blockInfo, error)
+
+// observe observes the current head of the chain.
+func (s *Service) observe(ctx context.Context) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.heads.standard").Start(ctx, "observe")
+	defer span.End()
+
+	// Only allow 1 handler to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another handler running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	observedAt := time.Now()
+	head, err := s.headerInfo(ctx, "head")
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain chain head")
+		monitorObservation("failed")
+		return
+	}
+
+	observation := &chaindb.HeadObservation{
+		Slot:       s.chainTime.TimestampToSlot(observedAt),
+		ObservedAt: observedAt,
+		HeadSlot:   head.slot,
+		HeadRoot:   head.root,
+		ParentRoot: head.parentRoot,
+	}
+
+	if s.previous != nil && s.previous.HeadRoot != head.root {
+		previous := &blockInfo{
+			slot:       s.previous.HeadSlot,
+			root:       s.previous.HeadRoot,
+			parentRoot: s.previous.ParentRoot,
+		}
+		ancestor, err := commonAncestor(ctx, previous, head, s.blockInfo)
+		if err != nil {
+			// Record the observation regardless; the reorg status is unknown.
+			log.Warn().Err(err).Uint64("slot", uint64(observation.Slot)).Msg("Failed to find common ancestor with previous head")
+		} else if ancestor.root != previous.root {
+			observation.Reorg = true
+			observation.ReorgDepth = uint64(previous.slot - ancestor.slot)
+			log.Info().
+				Uint64("slot", uint64(observation.Slot)).
+				Str("previous_head", fmt.Sprintf("%#x", previous.root)).
+				Str("head", fmt.Sprintf("%#x", head.root)).
+				Uint64("depth", observation.ReorgDepth).
+				Msg("Observed reorg")
+			monitorReorg(observation.ReorgDepth)
+		}
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin transaction")
+		monitorObservation("failed")
+		return
+	}
+	if err := s.headObservationsSetter.SetHeadObservation(ctx, observation); err != nil {
+		cancel()
+		log.Error().Err(err).Msg("Failed to set head observation")
+		monitorObservation("failed")
+		return
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		log.Error().Err(err).Msg("Failed to commit transaction")
+		monitorObservation("failed")
+		return
+	}
+
+	s.previous = observation
+	monitorObservation("succeeded")
+}
+
+// blockInfo fetches information about the block with the given root from the beacon node.
+func (s *Service) blockInfo(ctx context.Context, root phase0.Root) (*blockInfo, error) {
+	return s.headerInfo(ctx, fmt.Sprintf("%#x", root))
+}
+
+// headerInfo fetches information about the given block from the beacon node.
+func (s *Service) headerInfo(ctx context.Context, block string) (*blockInfo, error) {
+	headerResponse, err := s.headersProvider.BeaconBlockHeader(ctx, &api.BeaconBlockHeaderOpts{
+		Block: block,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to obtain header for block %s", block))
+	}
+
+	return &blockInfo{
+		slot:       headerResponse.Data.Header.Message.Slot,
+		root:       headerResponse.Data.Root,
+		parentRoot: headerResponse.Data.Header.Message.ParentRoot,
+	}, nil
+}
+
+// commonAncestor finds the latest common ancestor of two blocks.
+// If the first block is the common ancestor then the second block is its descendant.
+func commonAncestor(ctx context.Context,
+	first *blockInfo,
+	second *blockInfo,
+	fetch blockInfoFunc,
+) (
+	*blockInfo,
+	error,
+) {
+	var err error
+	for steps := 0; first.root != second.root; steps++ {
+		if steps == maxAncestorSearch {
+			return nil, fmt.Errorf("no common ancestor within %d blocks", maxAncestorSearch)
+		}
+		// Walk back the later of the two blocks.
+		if second.slot >= first.slot {
+			second, err = fetch(ctx, second.parentRoot)
+		} else {
+			first, err = fetch(ctx, first.parentRoot)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return first, nil
+}
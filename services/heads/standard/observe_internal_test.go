@@ -0,0 +1,116 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommonAncestor(t *testing.T) {
+	ctx := context.Background()
+
+	// Chain of blocks 1-4, with a fork from block 2 containing blocks 5 (slot 3) and 6 (slot 5).
+	blocks := map[phase0.Root]*blockInfo{
+		{0x01}: {slot: 1, root: phase0.Root{0x01}},
+		{0x02}: {slot: 2, root: phase0.Root{0x02}, parentRoot: phase0.Root{0x01}},
+		{0x03}: {slot: 3, root: phase0.Root{0x03}, parentRoot: phase0.Root{0x02}},
+		{0x04}: {slot: 4, root: phase0.Root{0x04}, parentRoot: phase0.Root{0x03}},
+		{0x05}: {slot: 3, root: phase0.Root{0x05}, parentRoot: phase0.Root{0x02}},
+		{0x06}: {slot: 5, root: phase0.Root{0x06}, parentRoot: phase0.Root{0x05}},
+	}
+	fetch := func(_ context.Context, root phase0.Root) (*blockInfo, error) {
+		block, exists := blocks[root]
+		if !exists {
+			return nil, errors.New("unknown block")
+		}
+
+		return block, nil
+	}
+
+	tests := []struct {
+		name     string
+		first    phase0.Root
+		second   phase0.Root
+		ancestor phase0.Root
+		err      string
+	}{
+		{
+			name:     "Same",
+			first:    phase0.Root{0x04},
+			second:   phase0.Root{0x04},
+			ancestor: phase0.Root{0x04},
+		},
+		{
+			name:     "Child",
+			first:    phase0.Root{0x03},
+			second:   phase0.Root{0x04},
+			ancestor: phase0.Root{0x03},
+		},
+		{
+			name:     "Descendant",
+			first:    phase0.Root{0x01},
+			second:   phase0.Root{0x04},
+			ancestor: phase0.Root{0x01},
+		},
+		{
+			name:     "SameSlotFork",
+			first:    phase0.Root{0x03},
+			second:   phase0.Root{0x05},
+			ancestor: phase0.Root{0x02},
+		},
+		{
+			name:     "LaterFork",
+			first:    phase0.Root{0x04},
+			second:   phase0.Root{0x06},
+			ancestor: phase0.Root{0x02},
+		},
+		{
+			name:     "EarlierFork",
+			first:    phase0.Root{0x06},
+			second:   phase0.Root{0x04},
+			ancestor: phase0.Root{0x02},
+		},
+		{
+			name:   "Unknown",
+			first:  phase0.Root{0x04},
+			second: phase0.Root{0x07},
+			err:    "unknown block",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first, exists := blocks[test.first]
+			if !exists {
+				first = &blockInfo{slot: 6, root: test.first, parentRoot: phase0.Root{0xff}}
+			}
+			second, exists := blocks[test.second]
+			if !exists {
+				second = &blockInfo{slot: 6, root: test.second, parentRoot: phase0.Root{0xff}}
+			}
+			ancestor, err := commonAncestor(ctx, first, second, fetch)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.ancestor, ancestor.root)
+			}
+		})
+	}
+}
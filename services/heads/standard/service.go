@@ -0,0 +1,124 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// Service is a heads service.
+// It observes the head of the chain as seen by the beacon node in each slot,
+// recording the sequence of heads including those later reorganised away.
+type Service struct {
+	chainDB                  chaindb.Service
+	chainTime                chaintime.Service
+	headersProvider          eth2client.BeaconBlockHeadersProvider
+	headObservationsProvider chaindb.HeadObservationsProvider
+	headObservationsSetter   chaindb.HeadObservationsSetter
+	offset                   time.Duration
+	previous                 *chaindb.HeadObservation
+	activitySem              *semaphore.Weighted
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("heads", "standard", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	headersProvider, isProvider := parameters.eth2Client.(eth2client.BeaconBlockHeadersProvider)
+	if !isProvider {
+		return nil, errors.New("Ethereum 2 client does not provide beacon block headers")
+	}
+	headObservationsProvider, isProvider := parameters.chainDB.(chaindb.HeadObservationsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide head observations")
+	}
+	headObservationsSetter, isSetter := parameters.chainDB.(chaindb.HeadObservationsSetter)
+	if !isSetter {
+		return nil, errors.New("chain DB does not support head observations")
+	}
+
+	s := &Service{
+		chainDB:                  parameters.chainDB,
+		chainTime:                parameters.chainTime,
+		headersProvider:          headersProvider,
+		headObservationsProvider: headObservationsProvider,
+		headObservationsSetter:   headObservationsSetter,
+		offset:                   parameters.offset,
+		activitySem:              semaphore.NewWeighted(1),
+	}
+
+	// Continue from the latest stored observation, if it is recent enough to be useful.
+	latest, err := headObservationsProvider.HeadObservations(ctx, &chaindb.HeadObservationFilter{
+		Limit: 1,
+		Order: chaindb.OrderLatest,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain latest head observation")
+	}
+	if len(latest) > 0 && latest[0].Slot+maxAncestorSearch >= s.chainTime.CurrentSlot() {
+		s.previous = latest[0]
+	}
+
+	// Observe once per slot, at the configured offset.
+	runtimeFunc := func(_ context.Context, _ any) (time.Time, error) {
+		return s.nextObservationTime(time.Now()), nil
+	}
+	jobFunc := func(ctx context.Context, data any) {
+		s := data.(*Service)
+		s.observe(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "heads", "observe",
+		runtimeFunc,
+		nil,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic observation")
+	}
+
+	return s, nil
+}
+
+// nextObservationTime provides the time of the next observation after the given time.
+func (s *Service) nextObservationTime(now time.Time) time.Time {
+	slot := s.chainTime.TimestampToSlot(now)
+	next := s.chainTime.StartOfSlot(slot).Add(s.offset)
+	if !next.After(now) {
+		next = s.chainTime.StartOfSlot(slot + 1).Add(s.offset)
+	}
+
+	return next
+}
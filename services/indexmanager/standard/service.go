@@ -0,0 +1,162 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// Service is an index manager service.
+// It drops secondary indexes while blocks are being backfilled, and creates
+// them once blocks have caught up with the chain head.
+type Service struct {
+	chainDB      chaindb.Service
+	chainTime    chaintime.Service
+	indexManager chaindb.SecondaryIndexManager
+	maxSlotLag   uint64
+	activitySem  *semaphore.Weighted
+	deferred     atomic.Bool
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("indexmanager", "standard", parameters.logLevel)
+
+	indexManager, isIndexManager := parameters.chainDB.(chaindb.SecondaryIndexManager)
+	if !isIndexManager {
+		return nil, errors.New("chain DB does not support secondary index management")
+	}
+
+	s := &Service{
+		chainDB:      parameters.chainDB,
+		chainTime:    parameters.chainTime,
+		indexManager: indexManager,
+		maxSlotLag:   parameters.maxSlotLag,
+		activitySem:  semaphore.NewWeighted(1),
+	}
+
+	caughtUp, err := s.caughtUp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if caughtUp {
+		// Ensure that indexes are present, for example if chaind stopped
+		// after backfill but before they were created.
+		go s.createIndexes(ctx)
+	} else {
+		log.Info().Msg("Blocks are behind the chain head; deferring secondary indexes until caught up")
+		if err := s.indexManager.DropSecondaryIndexes(ctx); err != nil {
+			return nil, errors.Wrap(err, "failed to drop secondary indexes")
+		}
+		s.deferred.Store(true)
+	}
+
+	// Check once per epoch.
+	runtimeFunc := func(ctx context.Context, data any) (time.Time, error) {
+		return s.chainTime.StartOfEpoch(s.chainTime.CurrentEpoch() + 1), nil
+	}
+	jobFunc := func(ctx context.Context, data any) {
+		s := data.(*Service)
+		s.check(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "indexmanager", "check",
+		runtimeFunc,
+		nil,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic check")
+	}
+
+	return s, nil
+}
+
+// check creates deferred indexes once blocks have caught up.
+func (s *Service) check(ctx context.Context) {
+	if !s.deferred.Load() {
+		return
+	}
+
+	caughtUp, err := s.caughtUp(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check if blocks have caught up")
+		return
+	}
+	if !caughtUp {
+		log.Trace().Msg("Blocks not yet caught up; continuing to defer secondary indexes")
+		return
+	}
+
+	s.createIndexes(ctx)
+}
+
+// createIndexes creates the secondary indexes.
+func (s *Service) createIndexes(ctx context.Context) {
+	// Only allow 1 handler to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another handler running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	log.Info().Msg("Creating secondary indexes")
+	started := time.Now()
+	if err := s.indexManager.CreateSecondaryIndexes(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to create secondary indexes")
+		return
+	}
+	s.deferred.Store(false)
+	log.Info().Dur("elapsed", time.Since(started)).Msg("Secondary indexes created")
+}
+
+// caughtUp returns true if blocks are within the maximum lag of the chain head.
+func (s *Service) caughtUp(ctx context.Context) (bool, error) {
+	progress, err := s.chainDB.Progress(ctx, "blocks.standard")
+	if err != nil {
+		return false, errors.Wrap(err, "failed to obtain blocks progress")
+	}
+	latestSlot := int64(-1)
+	if progress != nil {
+		if val, exists := progress.Values["latest_slot"]; exists {
+			latestSlot = val
+		}
+	}
+
+	currentSlot := s.chainTime.CurrentSlot()
+	if latestSlot < 0 {
+		return uint64(currentSlot) <= s.maxSlotLag, nil
+	}
+
+	return currentSlot <= phase0.Slot(latestSlot)+phase0.Slot(s.maxSlotLag), nil
+}
@@ -0,0 +1,28 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leader
+
+import "context"
+
+// Service is the interface for a leader election service.
+type Service interface {
+	// AwaitLeadership blocks until this instance is the leader, or the context is done.
+	AwaitLeadership(ctx context.Context) error
+
+	// IsLeader returns true if this instance is currently the leader.
+	IsLeader() bool
+
+	// Lost returns a channel that is closed if this instance loses leadership.
+	Lost() <-chan struct{}
+}
@@ -0,0 +1,150 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a leader election service that uses locks held by database
+// sessions, so that only one instance with the same name is leader at a time.
+type Service struct {
+	locker   chaindb.SessionLocker
+	name     string
+	interval time.Duration
+	mu       sync.RWMutex
+	lock     chaindb.SessionLock
+	lost     chan struct{}
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("leader", "standard", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	locker, isLocker := parameters.chainDB.(chaindb.SessionLocker)
+	if !isLocker {
+		return nil, errors.New("chain DB does not support session locks")
+	}
+
+	s := &Service{
+		locker:   locker,
+		name:     parameters.name,
+		interval: parameters.interval,
+		lost:     make(chan struct{}),
+	}
+	monitorLeader(false)
+
+	return s, nil
+}
+
+// AwaitLeadership blocks until this instance is the leader, or the context is done.
+func (s *Service) AwaitLeadership(ctx context.Context) error {
+	if s.IsLeader() {
+		return nil
+	}
+
+	for {
+		lock, err := s.locker.TrySessionLock(ctx, s.name)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to attempt to obtain leadership; will retry")
+		}
+		if lock != nil {
+			s.mu.Lock()
+			s.lock = lock
+			s.mu.Unlock()
+			monitorLeader(true)
+			log.Info().Str("name", s.name).Msg("Obtained leadership")
+			go s.maintain(ctx, lock)
+
+			return nil
+		}
+
+		log.Trace().Msg("Leadership held elsewhere; waiting")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.interval):
+		}
+	}
+}
+
+// IsLeader returns true if this instance is currently the leader.
+func (s *Service) IsLeader() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lock != nil
+}
+
+// Lost returns a channel that is closed if this instance loses leadership.
+func (s *Service) Lost() <-chan struct{} {
+	return s.lost
+}
+
+// maintain checks that leadership is still held, relinquishing it when the context is done.
+func (s *Service) maintain(ctx context.Context, lock chaindb.SessionLock) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Trace().Msg("Context done; relinquishing leadership")
+			s.relinquish(context.WithoutCancel(ctx), lock)
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, s.interval)
+			err := lock.Check(checkCtx)
+			cancel()
+			if err != nil {
+				log.Error().Err(err).Msg("Lost leadership")
+				s.relinquish(context.WithoutCancel(ctx), lock)
+				close(s.lost)
+				return
+			}
+		}
+	}
+}
+
+// relinquish releases the lock that provides leadership.
+func (s *Service) relinquish(ctx context.Context, lock chaindb.SessionLock) {
+	s.mu.Lock()
+	s.lock = nil
+	s.mu.Unlock()
+	monitorLeader(false)
+
+	if err := lock.Release(ctx); err != nil {
+		log.Debug().Err(err).Msg("Failed to release leadership lock")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/leader/standard"
+)
+
+// lockingChainDB is a chain database with controllable session locks.
+type lockingChainDB struct {
+	chaindb.Service
+	held      bool
+	checkErr  error
+	attempted int
+}
+
+func (c *lockingChainDB) TrySessionLock(_ context.Context, _ string) (chaindb.SessionLock, error) {
+	c.attempted++
+	if c.held {
+		return nil, nil
+	}
+
+	return &sessionLock{chainDB: c}, nil
+}
+
+type sessionLock struct {
+	chainDB *lockingChainDB
+}
+
+func (l *sessionLock) Check(_ context.Context) error {
+	return l.chainDB.checkErr
+}
+
+func (*sessionLock) Release(_ context.Context) error {
+	return nil
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	chainDB := mockchaindb.New()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "NameMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithName(""),
+			},
+			err: "problem with parameters: no name specified",
+		},
+		{
+			name: "IntervalZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithInterval(0),
+			},
+			err: "problem with parameters: interval must be greater than 0",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAwaitLeadership(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithChainDB(mockchaindb.New()),
+	)
+	require.NoError(t, err)
+	require.False(t, s.IsLeader())
+
+	require.NoError(t, s.AwaitLeadership(ctx))
+	require.True(t, s.IsLeader())
+}
+
+func TestAwaitLeadershipHeld(t *testing.T) {
+	chainDB := &lockingChainDB{
+		Service: mockchaindb.New(),
+		held:    true,
+	}
+
+	s, err := standard.New(context.Background(),
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithChainDB(chainDB),
+		standard.WithInterval(10*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, s.AwaitLeadership(ctx), context.DeadlineExceeded)
+	require.False(t, s.IsLeader())
+	require.Greater(t, chainDB.attempted, 1)
+}
+
+func TestLeadershipLost(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainDB := &lockingChainDB{
+		Service:  mockchaindb.New(),
+		checkErr: errors.New("connection lost"),
+	}
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithChainDB(chainDB),
+		standard.WithInterval(10*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, s.AwaitLeadership(ctx))
+
+	select {
+	case <-s.Lost():
+	case <-time.After(time.Second):
+		require.Fail(t, "leadership not lost")
+	}
+	require.False(t, s.IsLeader())
+}
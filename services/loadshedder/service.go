@@ -0,0 +1,70 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadshedder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Window is a period within each slot, relative to the start of the slot.
+type Window struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// String returns a string representation of the window.
+func (w Window) String() string {
+	return fmt.Sprintf("%s-%s", w.Start, w.End)
+}
+
+// ParseWindow parses a window of the form "start-end", for example "0s-4s".
+func ParseWindow(input string) (Window, error) {
+	start, end, found := strings.Cut(strings.TrimSpace(input), "-")
+	if !found {
+		return Window{}, errors.New("window must be of the form start-end")
+	}
+	startDuration, err := time.ParseDuration(strings.TrimSpace(start))
+	if err != nil {
+		return Window{}, errors.Wrap(err, "invalid window start")
+	}
+	endDuration, err := time.ParseDuration(strings.TrimSpace(end))
+	if err != nil {
+		return Window{}, errors.Wrap(err, "invalid window end")
+	}
+	if startDuration < 0 {
+		return Window{}, errors.New("window start cannot be negative")
+	}
+	if endDuration <= startDuration {
+		return Window{}, errors.New("window end must be after window start")
+	}
+
+	return Window{
+		Start: startDuration,
+		End:   endDuration,
+	}, nil
+}
+
+// Service is the interface for a service that keeps background work out of
+// the busy periods of each slot, when the beacon node is serving co-located
+// validators.
+type Service interface {
+	// AwaitQuiet blocks until the current time is outside the busy periods
+	// of the slot, or the context is done.
+	AwaitQuiet(ctx context.Context) error
+}
@@ -0,0 +1,76 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_loadshedder"
+
+var (
+	delaysMetric     prometheus.Counter
+	delayTimesMetric prometheus.Counter
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if delaysMetric != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	delaysMetric = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "delays_total",
+		Help:      "The number of times background work was delayed by a busy window",
+	})
+	if err := prometheus.Register(delaysMetric); err != nil {
+		return errors.Wrap(err, "failed to register delays_total")
+	}
+
+	delayTimesMetric = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "delay_seconds_total",
+		Help:      "The total time for which background work was delayed by busy windows",
+	})
+	if err := prometheus.Register(delayTimesMetric); err != nil {
+		return errors.Wrap(err, "failed to register delay_seconds_total")
+	}
+
+	return nil
+}
+
+func monitorDelay(duration time.Duration) {
+	if delaysMetric == nil {
+		return
+	}
+
+	delaysMetric.Inc()
+	delayTimesMetric.Add(duration.Seconds())
+}
@@ -0,0 +1,106 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/loadshedder"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+type parameters struct {
+	logLevel    zerolog.Level
+	monitor     metrics.Service
+	chainTime   chaintime.Service
+	busyWindows []loadshedder.Window
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(p *parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithChainTime sets the chaintime service.
+func WithChainTime(service chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = service
+	})
+}
+
+// WithBusyWindows sets the periods within each slot during which background
+// work should not start.
+func WithBusyWindows(windows []loadshedder.Window) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.busyWindows = windows
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if len(parameters.busyWindows) == 0 {
+		return nil, errors.New("no busy windows specified")
+	}
+	slotDuration := parameters.chainTime.SlotDuration()
+	busy := time.Duration(0)
+	for _, window := range parameters.busyWindows {
+		if window.Start < 0 || window.End <= window.Start {
+			return nil, errors.New("busy window invalid")
+		}
+		if window.End > slotDuration {
+			return nil, errors.New("busy window ends after the end of the slot")
+		}
+		busy += window.End - window.Start
+	}
+	if busy >= slotDuration {
+		return nil, errors.New("busy windows cover the entire slot")
+	}
+
+	return &parameters, nil
+}
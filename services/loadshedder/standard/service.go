@@ -0,0 +1,100 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/loadshedder"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a load shedding service that delays background work until
+// the current time is outside the busy windows of the slot.
+type Service struct {
+	chainTime   chaintime.Service
+	busyWindows []loadshedder.Window
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("loadshedder", "standard", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	s := &Service{
+		chainTime:   parameters.chainTime,
+		busyWindows: parameters.busyWindows,
+	}
+
+	return s, nil
+}
+
+// AwaitQuiet blocks until the current time is outside the busy periods
+// of the slot, or the context is done.
+func (s *Service) AwaitQuiet(ctx context.Context) error {
+	delayed := time.Duration(0)
+	for {
+		remaining := s.busyFor(time.Now())
+		if remaining == 0 {
+			break
+		}
+		log.Trace().Dur("remaining", remaining).Msg("In busy window; delaying")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(remaining):
+		}
+		delayed += remaining
+	}
+
+	if delayed > 0 {
+		monitorDelay(delayed)
+	}
+
+	return nil
+}
+
+// busyFor returns the time remaining in the busy window that contains the
+// given time, or 0 if the time is not within a busy window.
+func (s *Service) busyFor(now time.Time) time.Duration {
+	genesisTime := s.chainTime.GenesisTime()
+	if now.Before(genesisTime) {
+		return 0
+	}
+	offset := now.Sub(genesisTime) % s.chainTime.SlotDuration()
+
+	for _, window := range s.busyWindows {
+		if offset >= window.Start && offset < window.End {
+			return window.End - offset
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,83 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	"github.com/wealdtech/chaind/services/loadshedder"
+)
+
+func TestBusyFor(t *testing.T) {
+	chainTime := mockchaintime.New()
+	s := &Service{
+		chainTime: chainTime,
+		busyWindows: []loadshedder.Window{
+			{Start: 0, End: 4 * time.Second},
+			{Start: 8 * time.Second, End: 9 * time.Second},
+		},
+	}
+	genesisTime := chainTime.GenesisTime()
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected time.Duration
+	}{
+		{
+			name:     "PreGenesis",
+			now:      genesisTime.Add(-time.Second),
+			expected: 0,
+		},
+		{
+			name:     "StartOfSlot",
+			now:      genesisTime,
+			expected: 4 * time.Second,
+		},
+		{
+			name:     "InFirstWindow",
+			now:      genesisTime.Add(12*time.Second + 2500*time.Millisecond),
+			expected: 1500 * time.Millisecond,
+		},
+		{
+			name:     "EndOfFirstWindow",
+			now:      genesisTime.Add(4 * time.Second),
+			expected: 0,
+		},
+		{
+			name:     "BetweenWindows",
+			now:      genesisTime.Add(24*time.Second + 6*time.Second),
+			expected: 0,
+		},
+		{
+			name:     "InSecondWindow",
+			now:      genesisTime.Add(36*time.Second + 8*time.Second),
+			expected: time.Second,
+		},
+		{
+			name:     "EndOfSlot",
+			now:      genesisTime.Add(11 * time.Second),
+			expected: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, s.busyFor(test.now))
+		})
+	}
+}
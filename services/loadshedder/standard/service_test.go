@@ -0,0 +1,156 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	"github.com/wealdtech/chaind/services/loadshedder"
+	"github.com/wealdtech/chaind/services/loadshedder/standard"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	chainTime := mockchaintime.New()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ChainTimeMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithBusyWindows([]loadshedder.Window{{Start: 0, End: 4 * time.Second}}),
+			},
+			err: "problem with parameters: no chain time specified",
+		},
+		{
+			name: "BusyWindowsMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+			},
+			err: "problem with parameters: no busy windows specified",
+		},
+		{
+			name: "BusyWindowInvalid",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+				standard.WithBusyWindows([]loadshedder.Window{{Start: 4 * time.Second, End: 2 * time.Second}}),
+			},
+			err: "problem with parameters: busy window invalid",
+		},
+		{
+			name: "BusyWindowAfterSlot",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+				standard.WithBusyWindows([]loadshedder.Window{{Start: 10 * time.Second, End: 14 * time.Second}}),
+			},
+			err: "problem with parameters: busy window ends after the end of the slot",
+		},
+		{
+			name: "BusyWindowsEntireSlot",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+				standard.WithBusyWindows([]loadshedder.Window{
+					{Start: 0, End: 6 * time.Second},
+					{Start: 6 * time.Second, End: 12 * time.Second},
+				}),
+			},
+			err: "problem with parameters: busy windows cover the entire slot",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+				standard.WithBusyWindows([]loadshedder.Window{{Start: 0, End: 4 * time.Second}}),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected loadshedder.Window
+		err      string
+	}{
+		{
+			name:  "Empty",
+			input: "",
+			err:   "window must be of the form start-end",
+		},
+		{
+			name:  "StartInvalid",
+			input: "x-4s",
+			err:   `invalid window start: time: invalid duration "x"`,
+		},
+		{
+			name:  "EndInvalid",
+			input: "0s-x",
+			err:   `invalid window end: time: invalid duration "x"`,
+		},
+		{
+			name:  "EndBeforeStart",
+			input: "4s-2s",
+			err:   "window end must be after window start",
+		},
+		{
+			name:     "Good",
+			input:    "0s-4s",
+			expected: loadshedder.Window{Start: 0, End: 4 * time.Second},
+		},
+		{
+			name:     "Spaces",
+			input:    " 500ms - 2s ",
+			expected: loadshedder.Window{Start: 500 * time.Millisecond, End: 2 * time.Second},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := loadshedder.ParseWindow(test.input)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expected, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_maintenance"
+
+var (
+	deadTuples      *prometheus.GaugeVec
+	recommendations *prometheus.CounterVec
+	operations      *prometheus.CounterVec
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if deadTuples != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	deadTuples = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "dead_tuples",
+		Help:      "Number of dead tuples in the table",
+	}, []string{"table"})
+	if err := prometheus.Register(deadTuples); err != nil {
+		return errors.Wrap(err, "failed to register dead_tuples")
+	}
+
+	recommendations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "recommendations_total",
+		Help:      "Number of maintenance operations recommended",
+	}, []string{"table", "operation"})
+	if err := prometheus.Register(recommendations); err != nil {
+		return errors.Wrap(err, "failed to register recommendations_total")
+	}
+
+	operations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "operations_total",
+		Help:      "Number of maintenance operations carried out",
+	}, []string{"table", "operation", "result"})
+	if err := prometheus.Register(operations); err != nil {
+		return errors.Wrap(err, "failed to register operations_total")
+	}
+
+	return nil
+}
+
+func monitorTableStats(stats *chaindb.TableMaintenanceStats) {
+	if deadTuples != nil {
+		deadTuples.WithLabelValues(stats.Table).Set(float64(stats.DeadTuples))
+	}
+}
+
+func monitorRecommendation(table string, operation string) {
+	if recommendations != nil {
+		recommendations.WithLabelValues(table, operation).Inc()
+	}
+}
+
+func monitorOperation(table string, operation string, result string) {
+	if operations != nil {
+		operations.WithLabelValues(table, operation, result).Inc()
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+type parameters struct {
+	logLevel       zerolog.Level
+	monitor        metrics.Service
+	chainDB        chaindb.Service
+	chainTime      chaintime.Service
+	scheduler      scheduler.Service
+	maxSlotLag     uint64
+	interval       time.Duration
+	automatic      bool
+	minDeadTuples  int64
+	deadTupleRatio float64
+	analyzeRatio   float64
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(p *parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithScheduler sets the scheduler for this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
+// WithMaxSlotLag sets the number of slots that blocks can lag the chain head
+// and be considered caught up.
+func WithMaxSlotLag(maxSlotLag uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxSlotLag = maxSlotLag
+	})
+}
+
+// WithInterval sets the interval between maintenance checks.
+func WithInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.interval = interval
+	})
+}
+
+// WithAutomatic sets whether recommended maintenance is carried out,
+// rather than only reported.
+func WithAutomatic(automatic bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.automatic = automatic
+	})
+}
+
+// WithMinDeadTuples sets the minimum number of dead tuples a table must
+// hold before it is considered for vacuuming.
+func WithMinDeadTuples(minDeadTuples int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.minDeadTuples = minDeadTuples
+	})
+}
+
+// WithDeadTupleRatio sets the ratio of dead to live tuples above which
+// a table is vacuumed.
+func WithDeadTupleRatio(deadTupleRatio float64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.deadTupleRatio = deadTupleRatio
+	})
+}
+
+// WithAnalyzeRatio sets the ratio of modified to live tuples above which
+// a table is analyzed.
+func WithAnalyzeRatio(analyzeRatio float64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.analyzeRatio = analyzeRatio
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:       zerolog.GlobalLevel(),
+		maxSlotLag:     64,
+		interval:       time.Hour,
+		minDeadTuples:  10000,
+		deadTupleRatio: 0.2,
+		analyzeRatio:   0.1,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified")
+	}
+	if parameters.interval <= 0 {
+		return nil, errors.New("interval must be greater than 0")
+	}
+	if parameters.minDeadTuples < 0 {
+		return nil, errors.New("minimum dead tuples cannot be negative")
+	}
+	if parameters.deadTupleRatio <= 0 {
+		return nil, errors.New("dead tuple ratio must be greater than 0")
+	}
+	if parameters.analyzeRatio <= 0 {
+		return nil, errors.New("analyze ratio must be greater than 0")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,75 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"sort"
+
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+const (
+	operationVacuum  = "vacuum"
+	operationAnalyze = "analyze"
+)
+
+// recommendation is a recommended maintenance operation on a table.
+type recommendation struct {
+	table                     string
+	operation                 string
+	analyze                   bool
+	deadTuples                int64
+	liveTuples                int64
+	modificationsSinceAnalyze int64
+}
+
+// recommendations returns the maintenance operations recommended for the
+// tables, those with the most dead tuples first.
+func (s *Service) recommendations(stats []*chaindb.TableMaintenanceStats) []*recommendation {
+	recommendations := make([]*recommendation, 0)
+	for _, tableStats := range stats {
+		liveTuples := tableStats.LiveTuples
+		if liveTuples < 1 {
+			liveTuples = 1
+		}
+		needsVacuum := tableStats.DeadTuples >= s.minDeadTuples &&
+			float64(tableStats.DeadTuples)/float64(liveTuples) >= s.deadTupleRatio
+		needsAnalyze := tableStats.ModificationsSinceAnalyze > 0 &&
+			float64(tableStats.ModificationsSinceAnalyze)/float64(liveTuples) >= s.analyzeRatio
+
+		var operation string
+		switch {
+		case needsVacuum:
+			operation = operationVacuum
+		case needsAnalyze:
+			operation = operationAnalyze
+		default:
+			continue
+		}
+		recommendations = append(recommendations, &recommendation{
+			table:                     tableStats.Table,
+			operation:                 operation,
+			analyze:                   needsVacuum && needsAnalyze,
+			deadTuples:                tableStats.DeadTuples,
+			liveTuples:                tableStats.LiveTuples,
+			modificationsSinceAnalyze: tableStats.ModificationsSinceAnalyze,
+		})
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].deadTuples > recommendations[j].deadTuples
+	})
+
+	return recommendations
+}
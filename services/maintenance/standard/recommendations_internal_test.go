@@ -0,0 +1,109 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestRecommendations(t *testing.T) {
+	s := &Service{
+		minDeadTuples:  1000,
+		deadTupleRatio: 0.2,
+		analyzeRatio:   0.1,
+	}
+
+	tests := []struct {
+		name     string
+		stats    []*chaindb.TableMaintenanceStats
+		expected []*recommendation
+	}{
+		{
+			name:     "Empty",
+			expected: []*recommendation{},
+		},
+		{
+			name: "Healthy",
+			stats: []*chaindb.TableMaintenanceStats{
+				{Table: "t_blocks", LiveTuples: 100000, DeadTuples: 500, ModificationsSinceAnalyze: 500},
+			},
+			expected: []*recommendation{},
+		},
+		{
+			name: "DeadTuplesBelowMinimum",
+			stats: []*chaindb.TableMaintenanceStats{
+				{Table: "t_blocks", LiveTuples: 100, DeadTuples: 999},
+			},
+			expected: []*recommendation{},
+		},
+		{
+			name: "Vacuum",
+			stats: []*chaindb.TableMaintenanceStats{
+				{Table: "t_blocks", LiveTuples: 100000, DeadTuples: 30000, ModificationsSinceAnalyze: 500},
+			},
+			expected: []*recommendation{
+				{table: "t_blocks", operation: operationVacuum, deadTuples: 30000, liveTuples: 100000, modificationsSinceAnalyze: 500},
+			},
+		},
+		{
+			name: "VacuumAndAnalyze",
+			stats: []*chaindb.TableMaintenanceStats{
+				{Table: "t_blocks", LiveTuples: 100000, DeadTuples: 30000, ModificationsSinceAnalyze: 40000},
+			},
+			expected: []*recommendation{
+				{table: "t_blocks", operation: operationVacuum, analyze: true, deadTuples: 30000, liveTuples: 100000, modificationsSinceAnalyze: 40000},
+			},
+		},
+		{
+			name: "Analyze",
+			stats: []*chaindb.TableMaintenanceStats{
+				{Table: "t_blocks", LiveTuples: 100000, DeadTuples: 500, ModificationsSinceAnalyze: 40000},
+			},
+			expected: []*recommendation{
+				{table: "t_blocks", operation: operationAnalyze, deadTuples: 500, liveTuples: 100000, modificationsSinceAnalyze: 40000},
+			},
+		},
+		{
+			name: "EmptyTable",
+			stats: []*chaindb.TableMaintenanceStats{
+				{Table: "t_blocks", DeadTuples: 2000},
+			},
+			expected: []*recommendation{
+				{table: "t_blocks", operation: operationVacuum, deadTuples: 2000},
+			},
+		},
+		{
+			name: "Ordered",
+			stats: []*chaindb.TableMaintenanceStats{
+				{Table: "t_attestations", LiveTuples: 100000, DeadTuples: 30000},
+				{Table: "t_blocks", LiveTuples: 100000, DeadTuples: 500, ModificationsSinceAnalyze: 40000},
+				{Table: "t_validators", LiveTuples: 100000, DeadTuples: 50000},
+			},
+			expected: []*recommendation{
+				{table: "t_validators", operation: operationVacuum, deadTuples: 50000, liveTuples: 100000},
+				{table: "t_attestations", operation: operationVacuum, deadTuples: 30000, liveTuples: 100000},
+				{table: "t_blocks", operation: operationAnalyze, deadTuples: 500, liveTuples: 100000, modificationsSinceAnalyze: 40000},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, s.recommendations(test.stats))
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// Service is a maintenance service.
+// It monitors dead tuples and stale statistics on chaind's tables, and
+// recommends or carries out VACUUM and ANALYZE operations while blocks are
+// not being backfilled.
+type Service struct {
+	chainDB         chaindb.Service
+	chainTime       chaintime.Service
+	tableMaintainer chaindb.TableMaintainer
+	maxSlotLag      uint64
+	interval        time.Duration
+	automatic       bool
+	minDeadTuples   int64
+	deadTupleRatio  float64
+	analyzeRatio    float64
+	activitySem     *semaphore.Weighted
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("maintenance", "standard", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	tableMaintainer, isTableMaintainer := parameters.chainDB.(chaindb.TableMaintainer)
+	if !isTableMaintainer {
+		return nil, errors.New("chain DB does not support table maintenance")
+	}
+
+	s := &Service{
+		chainDB:         parameters.chainDB,
+		chainTime:       parameters.chainTime,
+		tableMaintainer: tableMaintainer,
+		maxSlotLag:      parameters.maxSlotLag,
+		interval:        parameters.interval,
+		automatic:       parameters.automatic,
+		minDeadTuples:   parameters.minDeadTuples,
+		deadTupleRatio:  parameters.deadTupleRatio,
+		analyzeRatio:    parameters.analyzeRatio,
+		activitySem:     semaphore.NewWeighted(1),
+	}
+
+	runtimeFunc := func(ctx context.Context, data any) (time.Time, error) {
+		return time.Now().Add(s.interval), nil
+	}
+	jobFunc := func(ctx context.Context, data any) {
+		s := data.(*Service)
+		s.maintain(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "maintenance", "maintain",
+		runtimeFunc,
+		nil,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic maintenance")
+	}
+
+	return s, nil
+}
+
+// maintain checks the tables and recommends or carries out maintenance.
+func (s *Service) maintain(ctx context.Context) {
+	// Only allow 1 handler to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another handler running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	caughtUp, err := s.caughtUp(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check if blocks have caught up")
+		return
+	}
+	if !caughtUp {
+		log.Debug().Msg("Blocks not yet caught up; deferring maintenance")
+		return
+	}
+
+	stats, err := s.tableMaintainer.TableMaintenanceStats(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain table statistics")
+		return
+	}
+	for _, tableStats := range stats {
+		monitorTableStats(tableStats)
+	}
+
+	for _, recommendation := range s.recommendations(stats) {
+		log.Info().
+			Str("table", recommendation.table).
+			Str("operation", recommendation.operation).
+			Int64("dead_tuples", recommendation.deadTuples).
+			Int64("live_tuples", recommendation.liveTuples).
+			Int64("modifications_since_analyze", recommendation.modificationsSinceAnalyze).
+			Bool("automatic", s.automatic).
+			Msg("Table maintenance recommended")
+		monitorRecommendation(recommendation.table, recommendation.operation)
+		if !s.automatic {
+			continue
+		}
+
+		// A backfill may have started since the last table was maintained,
+		// in which case leave the remaining tables for a later run.
+		caughtUp, err := s.caughtUp(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to check if blocks have caught up")
+			return
+		}
+		if !caughtUp {
+			log.Debug().Msg("Blocks no longer caught up; deferring remaining maintenance")
+			return
+		}
+
+		if err := s.runOperation(ctx, recommendation); err != nil {
+			log.Error().Str("table", recommendation.table).Str("operation", recommendation.operation).Err(err).Msg("Failed to maintain table")
+			monitorOperation(recommendation.table, recommendation.operation, "failed")
+			continue
+		}
+		monitorOperation(recommendation.table, recommendation.operation, "succeeded")
+	}
+}
+
+// runOperation carries out the recommended operation.
+func (s *Service) runOperation(ctx context.Context, recommendation *recommendation) error {
+	log.Info().Str("table", recommendation.table).Str("operation", recommendation.operation).Msg("Maintaining table")
+	started := time.Now()
+	var err error
+	switch recommendation.operation {
+	case operationVacuum:
+		err = s.tableMaintainer.VacuumTable(ctx, recommendation.table, recommendation.analyze)
+	case operationAnalyze:
+		err = s.tableMaintainer.AnalyzeTable(ctx, recommendation.table)
+	default:
+		err = errors.Errorf("unknown operation %s", recommendation.operation)
+	}
+	if err != nil {
+		return err
+	}
+	log.Info().Str("table", recommendation.table).Str("operation", recommendation.operation).Dur("elapsed", time.Since(started)).Msg("Table maintained")
+
+	return nil
+}
+
+// caughtUp returns true if blocks are within the maximum lag of the chain head.
+func (s *Service) caughtUp(ctx context.Context) (bool, error) {
+	progress, err := s.chainDB.Progress(ctx, "blocks.standard")
+	if err != nil {
+		return false, errors.Wrap(err, "failed to obtain blocks progress")
+	}
+	latestSlot := int64(-1)
+	if progress != nil {
+		if val, exists := progress.Values["latest_slot"]; exists {
+			latestSlot = val
+		}
+	}
+
+	currentSlot := s.chainTime.CurrentSlot()
+	if latestSlot < 0 {
+		return uint64(currentSlot) <= s.maxSlotLag, nil
+	}
+
+	return currentSlot <= phase0.Slot(latestSlot)+phase0.Slot(s.maxSlotLag), nil
+}
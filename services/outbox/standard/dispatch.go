@@ -0,0 +1,88 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// dispatch publishes events in the outbox, removing them once they have
+// been accepted by all publishers.
+func (s *Service) dispatch(ctx context.Context) {
+	// Only allow 1 dispatch to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another dispatch running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	for {
+		dispatched, err := s.dispatchBatch(ctx)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to dispatch events")
+			return
+		}
+		if dispatched < int(s.batchSize) {
+			// Outbox is empty.
+			return
+		}
+	}
+}
+
+// dispatchBatch publishes a batch of events in the outbox, returning the number of events published.
+func (s *Service) dispatchBatch(ctx context.Context) (int, error) {
+	events, err := s.outboxProvider.OutboxEvents(ctx, s.batchSize)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain events")
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	// Events are only removed from the outbox once all publishers have accepted
+	// them, so if any publisher fails then all publishers will receive the
+	// events again on the next dispatch.
+	for _, publisher := range s.publishers {
+		if err := publisher.Publish(ctx, events); err != nil {
+			monitorPublishFailed(publisher.Name())
+			return 0, errors.Wrapf(err, "failed to publish events to %s", publisher.Name())
+		}
+	}
+
+	ids := make([]uint64, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := s.outboxSetter.DeleteOutboxEvents(ctx, ids); err != nil {
+		cancel()
+		return 0, errors.Wrap(err, "failed to delete published events")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return 0, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	latestID := ids[len(ids)-1]
+	log.Trace().Int("events", len(events)).Uint64("latest_id", latestID).Msg("Dispatched events")
+	monitorEventsPublished(latestID, len(events))
+
+	return len(events), nil
+}
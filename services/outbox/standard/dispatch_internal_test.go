@@ -0,0 +1,157 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/publisher"
+	"golang.org/x/sync/semaphore"
+)
+
+// outboxDB is a chain database with an in-memory outbox.
+type outboxDB struct {
+	chaindb.Service
+	events []*chaindb.OutboxEvent
+}
+
+func (d *outboxDB) OutboxCapturableTables(_ context.Context) ([]string, error) {
+	return []string{}, nil
+}
+
+func (d *outboxDB) OutboxEvents(_ context.Context, limit uint32) ([]*chaindb.OutboxEvent, error) {
+	if len(d.events) < int(limit) {
+		return d.events, nil
+	}
+	return d.events[:limit], nil
+}
+
+func (d *outboxDB) SetOutboxCapture(_ context.Context, _ []string) error {
+	return nil
+}
+
+func (d *outboxDB) DeleteOutboxEvents(_ context.Context, ids []uint64) error {
+	deleted := make(map[uint64]bool, len(ids))
+	for _, id := range ids {
+		deleted[id] = true
+	}
+	remaining := make([]*chaindb.OutboxEvent, 0, len(d.events))
+	for _, event := range d.events {
+		if !deleted[event.ID] {
+			remaining = append(remaining, event)
+		}
+	}
+	d.events = remaining
+
+	return nil
+}
+
+// recordingPublisher records the IDs of the events it publishes.
+type recordingPublisher struct {
+	fail bool
+	ids  []uint64
+}
+
+func (p *recordingPublisher) Name() string {
+	return "recording"
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, events []*chaindb.OutboxEvent) error {
+	if p.fail {
+		return errors.New("failed")
+	}
+	for _, event := range events {
+		p.ids = append(p.ids, event.ID)
+	}
+
+	return nil
+}
+
+func TestDispatch(t *testing.T) {
+	ctx := context.Background()
+	log = zerolog.Nop()
+
+	newEvents := func(n int) []*chaindb.OutboxEvent {
+		events := make([]*chaindb.OutboxEvent, n)
+		for i := range events {
+			events[i] = &chaindb.OutboxEvent{ID: uint64(i + 1), Table: "t_blocks", Operation: chaindb.OutboxOperationInsert}
+		}
+		return events
+	}
+
+	tests := []struct {
+		name      string
+		events    int
+		batchSize uint32
+		fail      bool
+		published []uint64
+		remaining int
+	}{
+		{
+			name:      "Empty",
+			batchSize: 10,
+		},
+		{
+			name:      "SingleBatch",
+			events:    3,
+			batchSize: 10,
+			published: []uint64{1, 2, 3},
+		},
+		{
+			name:      "MultipleBatches",
+			events:    5,
+			batchSize: 2,
+			published: []uint64{1, 2, 3, 4, 5},
+		},
+		{
+			name:      "PublisherFails",
+			events:    3,
+			batchSize: 10,
+			fail:      true,
+			remaining: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := &outboxDB{
+				Service: mockchaindb.New(),
+				events:  newEvents(test.events),
+			}
+			good := &recordingPublisher{}
+			other := &recordingPublisher{fail: test.fail}
+			s := &Service{
+				chainDB:        db,
+				outboxProvider: db,
+				outboxSetter:   db,
+				publishers:     []publisher.Service{good, other},
+				batchSize:      test.batchSize,
+				activitySem:    semaphore.NewWeighted(1),
+			}
+			s.dispatch(ctx)
+			require.Equal(t, test.published, other.ids)
+			require.Len(t, db.events, test.remaining)
+			if test.fail {
+				// Events accepted by a publisher before the failure are published again on the next dispatch.
+				require.Equal(t, []uint64{1, 2, 3}, good.ids)
+			}
+		})
+	}
+}
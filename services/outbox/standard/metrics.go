@@ -0,0 +1,91 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_outbox"
+
+var (
+	latestEvent     prometheus.Gauge
+	eventsPublished prometheus.Counter
+	publishFailures *prometheus.CounterVec
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if latestEvent != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	latestEvent = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "latest_event",
+		Help:      "ID of the latest event published",
+	})
+	if err := prometheus.Register(latestEvent); err != nil {
+		return errors.Wrap(err, "failed to register latest_event")
+	}
+
+	eventsPublished = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "events_published_total",
+		Help:      "Number of events published",
+	})
+	if err := prometheus.Register(eventsPublished); err != nil {
+		return errors.Wrap(err, "failed to register events_published_total")
+	}
+
+	publishFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "publish_failures_total",
+		Help:      "Number of failed attempts to publish events",
+	}, []string{"publisher"})
+	if err := prometheus.Register(publishFailures); err != nil {
+		return errors.Wrap(err, "failed to register publish_failures_total")
+	}
+
+	return nil
+}
+
+func monitorEventsPublished(id uint64, events int) {
+	if latestEvent != nil {
+		latestEvent.Set(float64(id))
+	}
+	if eventsPublished != nil {
+		eventsPublished.Add(float64(events))
+	}
+}
+
+func monitorPublishFailed(publisher string) {
+	if publishFailures != nil {
+		publishFailures.WithLabelValues(publisher).Inc()
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/publisher"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// Service is an outbox dispatcher service.
+type Service struct {
+	chainDB        chaindb.Service
+	outboxProvider chaindb.OutboxProvider
+	outboxSetter   chaindb.OutboxSetter
+	publishers     []publisher.Service
+	interval       time.Duration
+	batchSize      uint32
+	activitySem    *semaphore.Weighted
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("outbox", "standard", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	outboxProvider, isOutboxProvider := parameters.chainDB.(chaindb.OutboxProvider)
+	if !isOutboxProvider {
+		return nil, errors.New("chain DB does not support outbox providing")
+	}
+
+	outboxSetter, isOutboxSetter := parameters.chainDB.(chaindb.OutboxSetter)
+	if !isOutboxSetter {
+		return nil, errors.New("chain DB does not support outbox setting")
+	}
+
+	s := &Service{
+		chainDB:        parameters.chainDB,
+		outboxProvider: outboxProvider,
+		outboxSetter:   outboxSetter,
+		publishers:     parameters.publishers,
+		interval:       parameters.interval,
+		batchSize:      parameters.batchSize,
+		activitySem:    semaphore.NewWeighted(1),
+	}
+
+	if err := s.setCapture(ctx, parameters.tables); err != nil {
+		return nil, err
+	}
+
+	runtimeFunc := func(ctx context.Context, data any) (time.Time, error) {
+		return time.Now().Add(s.interval), nil
+	}
+	jobFunc := func(ctx context.Context, data any) {
+		s := data.(*Service)
+		s.dispatch(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "outbox", "dispatch",
+		runtimeFunc,
+		nil,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic dispatch")
+	}
+
+	return s, nil
+}
+
+// setCapture sets the tables whose changes are captured in the outbox.
+func (s *Service) setCapture(ctx context.Context, tables []string) error {
+	if len(tables) == 0 {
+		var err error
+		tables, err = s.outboxProvider.OutboxCapturableTables(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain capturable tables")
+		}
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := s.outboxSetter.SetOutboxCapture(ctx, tables); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set captured tables")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	log.Info().Strs("tables", tables).Msg("Capturing changes to tables")
+
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proofs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// Service is a proofs service.
+type Service interface {
+	// WithdrawalProof provides a proof of the withdrawal with the given position in the
+	// execution payload of the block with the given root, against the block root.
+	WithdrawalProof(ctx context.Context, blockRoot phase0.Root, position uint64) (*Proof, error)
+
+	// ValidatorBalanceProof provides a proof of the balance of the given validator in the
+	// beacon state at the first slot of the given epoch, against the state root.
+	ValidatorBalanceProof(ctx context.Context, epoch phase0.Epoch, index phase0.ValidatorIndex) (*Proof, error)
+}
+
+// Proof is an SSZ Merkle proof of a single leaf.
+type Proof struct {
+	// Root is the root against which the proof verifies.
+	Root phase0.Root
+	// GeneralizedIndex is the generalized index of the leaf within the tree.
+	GeneralizedIndex uint64
+	// Leaf is the leaf being proven.  Note that for packed values, such as
+	// balances, the leaf contains more than one value.
+	Leaf phase0.Root
+	// Branch are the sibling hashes from the leaf up to the root.
+	Branch []phase0.Root
+}
+
+// proofJSON is the JSON representation of a proof.
+type proofJSON struct {
+	Root             string   `json:"root"`
+	GeneralizedIndex string   `json:"generalized_index"`
+	Leaf             string   `json:"leaf"`
+	Branch           []string `json:"branch"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p *Proof) MarshalJSON() ([]byte, error) {
+	branch := make([]string, len(p.Branch))
+	for i := range p.Branch {
+		branch[i] = fmt.Sprintf("%#x", p.Branch[i])
+	}
+
+	return json.Marshal(&proofJSON{
+		Root:             fmt.Sprintf("%#x", p.Root),
+		GeneralizedIndex: fmt.Sprintf("%d", p.GeneralizedIndex),
+		Leaf:             fmt.Sprintf("%#x", p.Leaf),
+		Branch:           branch,
+	})
+}
@@ -0,0 +1,149 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/proofs"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// balancesPosition is the position of the balances in a beacon state.
+	balancesPosition = 12
+	// balancesChunkLimit is the maximum number of chunks in the balances list,
+	// with four balances packed in to each chunk.
+	balancesChunkLimit = (1 << 40) / 4
+)
+
+// ValidatorBalanceProof provides a proof of the balance of the given validator in the
+// beacon state at the first slot of the given epoch, against the state root.
+func (s *Service) ValidatorBalanceProof(ctx context.Context,
+	epoch phase0.Epoch,
+	index phase0.ValidatorIndex,
+) (
+	*proofs.Proof,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.proofs.standard").Start(ctx, "ValidatorBalanceProof")
+	defer span.End()
+
+	slot := s.chainTime.FirstSlotOfEpoch(epoch)
+	stateResponse, err := s.eth2Client.(eth2client.BeaconStateProvider).BeaconState(ctx, &api.BeaconStateOpts{
+		State: fmt.Sprintf("%d", slot),
+	})
+	if err != nil {
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, errors.Wrap(ErrNotFound, "state not available from beacon node")
+		}
+		return nil, errors.Wrap(err, "failed to obtain state")
+	}
+
+	proof, err := validatorBalanceProof(stateResponse.Data, index)
+	if err != nil {
+		return nil, err
+	}
+
+	s.crossCheckBalance(ctx, epoch, index, proof)
+
+	return proof, nil
+}
+
+// validatorBalanceProof provides a proof of the balance of the given validator in the state.
+func validatorBalanceProof(state *spec.VersionedBeaconState, index phase0.ValidatorIndex) (*proofs.Proof, error) {
+	var obj treeProvider
+	var stateFields uint64
+	var balances []phase0.Gwei
+	switch state.Version {
+	case spec.DataVersionPhase0:
+		obj = state.Phase0
+		stateFields = 21
+		balances = state.Phase0.Balances
+	case spec.DataVersionAltair:
+		obj = state.Altair
+		stateFields = 24
+		balances = state.Altair.Balances
+	case spec.DataVersionBellatrix:
+		obj = state.Bellatrix
+		stateFields = 25
+		balances = state.Bellatrix.Balances
+	case spec.DataVersionCapella:
+		obj = state.Capella
+		stateFields = 28
+		balances = state.Capella.Balances
+	case spec.DataVersionDeneb:
+		obj = state.Deneb
+		stateFields = 28
+		balances = state.Deneb.Balances
+	default:
+		return nil, fmt.Errorf("unsupported state version %s", state.Version)
+	}
+	if uint64(index) >= uint64(len(balances)) {
+		return nil, errors.Wrap(ErrNotFound, fmt.Sprintf("state has %d validators", len(balances)))
+	}
+
+	gindex := concatIndices(
+		fieldIndex(stateFields, balancesPosition),
+		listChunkIndex(balancesChunkLimit, uint64(index)/4),
+	)
+
+	return prove(obj, gindex)
+}
+
+// crossCheckBalance checks the proof against data stored by chaind, if present.
+// Mismatches are logged but do not invalidate the proof, which is generated from the beacon state.
+func (s *Service) crossCheckBalance(ctx context.Context,
+	epoch phase0.Epoch,
+	index phase0.ValidatorIndex,
+	proof *proofs.Proof,
+) {
+	log := log.With().Uint64("epoch", uint64(epoch)).Uint64("validator_index", uint64(index)).Logger()
+
+	if s.checkpointsProvider != nil {
+		checkpoints, err := s.checkpointsProvider.Checkpoints(ctx, &chaindb.CheckpointFilter{
+			From: &epoch,
+			To:   &epoch,
+		})
+		switch {
+		case err != nil:
+			log.Debug().Err(err).Msg("Failed to obtain checkpoint for cross-check")
+		case len(checkpoints) == 1 && checkpoints[0].StateRoot != proof.Root:
+			log.Warn().Stringer("stored_state_root", checkpoints[0].StateRoot).Stringer("state_root", proof.Root).Msg("State root does not match stored checkpoint")
+		}
+	}
+
+	if s.validatorsProvider != nil {
+		balances, err := s.validatorsProvider.ValidatorBalancesByIndexAndEpoch(ctx, []phase0.ValidatorIndex{index}, epoch)
+		if err != nil {
+			log.Debug().Err(err).Msg("Failed to obtain balance for cross-check")
+			return
+		}
+		offset := (uint64(index) % 4) * 8
+		balance := phase0.Gwei(binary.LittleEndian.Uint64(proof.Leaf[offset : offset+8]))
+		if stored, exists := balances[index]; exists && stored.Balance != balance {
+			log.Warn().Uint64("stored_balance", uint64(stored.Balance)).Uint64("balance", uint64(balance)).Msg("Balance does not match stored balance")
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"math/bits"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	ssz "github.com/ferranbt/fastssz"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/proofs"
+)
+
+// fieldIndex returns the generalized index of the field at the given position
+// in a container with the given number of fields.
+func fieldIndex(fields uint64, position uint64) uint64 {
+	return nextPowerOfTwo(fields) + position
+}
+
+// listChunkIndex returns the generalized index of the chunk at the given position
+// in a list with the given maximum number of chunks.  The list root mixes in the
+// length of the list, so the chunks are in the left subtree.
+func listChunkIndex(limit uint64, position uint64) uint64 {
+	return 2*nextPowerOfTwo(limit) + position
+}
+
+// concatIndices concatenates generalized indices, each relative to the node at
+// the previous index, to provide a single generalized index from the root.
+func concatIndices(indices ...uint64) uint64 {
+	res := uint64(1)
+	for _, index := range indices {
+		depth := bits.Len64(index) - 1
+		res = res<<depth | (index ^ (1 << depth))
+	}
+
+	return res
+}
+
+// nextPowerOfTwo returns the smallest power of two that is at least n.
+func nextPowerOfTwo(n uint64) uint64 {
+	if n <= 1 {
+		return 1
+	}
+
+	return 1 << bits.Len64(n-1)
+}
+
+// treeProvider is implemented by SSZ containers that can provide their Merkle tree.
+type treeProvider interface {
+	GetTree() (*ssz.Node, error)
+	HashTreeRoot() ([32]byte, error)
+}
+
+// prove generates a proof of the leaf at the given generalized index of the object,
+// and verifies it against the object's root.
+func prove(obj treeProvider, gindex uint64) (*proofs.Proof, error) {
+	root, err := obj.HashTreeRoot()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain root")
+	}
+
+	tree, err := obj.GetTree()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain tree")
+	}
+
+	sszProof, err := tree.Prove(int(gindex))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate proof")
+	}
+	// The proof only contains the value of the leaf if it has already been hashed,
+	// which is not the case for leaves that are themselves containers, so hash it here.
+	leaf, err := tree.Get(int(gindex))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain leaf")
+	}
+	sszProof.Leaf = leaf.Hash()
+
+	verified, err := ssz.VerifyProof(root[:], sszProof)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify proof")
+	}
+	if !verified {
+		return nil, errors.New("generated proof does not verify")
+	}
+
+	proof := &proofs.Proof{
+		Root:             root,
+		GeneralizedIndex: gindex,
+		Branch:           make([]phase0.Root, len(sszProof.Hashes)),
+	}
+	copy(proof.Leaf[:], sszProof.Leaf)
+	for i := range sszProof.Hashes {
+		copy(proof.Branch[i][:], sszProof.Hashes[i])
+	}
+
+	return proof, nil
+}
@@ -0,0 +1,85 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/proofs"
+)
+
+// handleWithdrawalProof handles requests for withdrawal proofs.
+func (s *Service) handleWithdrawalProof(w http.ResponseWriter, r *http.Request) {
+	blockRootBytes, err := hex.DecodeString(strings.TrimPrefix(r.PathValue("block_root"), "0x"))
+	if err != nil || len(blockRootBytes) != phase0.RootLength {
+		http.Error(w, "invalid block root", http.StatusBadRequest)
+		return
+	}
+	blockRoot := phase0.Root(blockRootBytes)
+	position, err := strconv.ParseUint(r.PathValue("position"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid position", http.StatusBadRequest)
+		return
+	}
+
+	proof, err := s.WithdrawalProof(r.Context(), blockRoot, position)
+	s.writeProof(w, proof, err)
+}
+
+// handleValidatorBalanceProof handles requests for validator balance proofs.
+func (s *Service) handleValidatorBalanceProof(w http.ResponseWriter, r *http.Request) {
+	epoch, err := strconv.ParseUint(r.PathValue("epoch"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid epoch", http.StatusBadRequest)
+		return
+	}
+	index, err := strconv.ParseUint(r.PathValue("validator_index"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid validator index", http.StatusBadRequest)
+		return
+	}
+
+	proof, err := s.ValidatorBalanceProof(r.Context(), phase0.Epoch(epoch), phase0.ValidatorIndex(index))
+	s.writeProof(w, proof, err)
+}
+
+// writeProof writes the proof, or the error obtained when generating it.
+func (*Service) writeProof(w http.ResponseWriter, proof *proofs.Proof, err error) {
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		log.Warn().Err(err).Msg("Failed to generate proof")
+		http.Error(w, "failed to generate proof", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(proof)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to marshal proof")
+		http.Error(w, "failed to marshal proof", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
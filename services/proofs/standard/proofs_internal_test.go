@@ -0,0 +1,167 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/holiman/uint256"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcatIndices(t *testing.T) {
+	tests := []struct {
+		name     string
+		indices  []uint64
+		expected uint64
+	}{
+		{
+			name:     "Empty",
+			expected: 1,
+		},
+		{
+			name:     "Single",
+			indices:  []uint64{12},
+			expected: 12,
+		},
+		{
+			name:     "Root",
+			indices:  []uint64{1, 5, 1},
+			expected: 5,
+		},
+		{
+			name:     "DenebWithdrawal",
+			indices:  []uint64{12, 25, 46, 35},
+			expected: 206275,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, concatIndices(test.indices...))
+		})
+	}
+}
+
+func TestIndices(t *testing.T) {
+	require.Equal(t, uint64(12), fieldIndex(5, 4))
+	require.Equal(t, uint64(25), fieldIndex(12, 9))
+	require.Equal(t, uint64(25), fieldIndex(11, 9))
+	require.Equal(t, uint64(46), fieldIndex(17, 14))
+	require.Equal(t, uint64(30), fieldIndex(15, 14))
+	require.Equal(t, uint64(35), listChunkIndex(16, 3))
+	require.Equal(t, uint64(1), nextPowerOfTwo(0))
+	require.Equal(t, uint64(1), nextPowerOfTwo(1))
+	require.Equal(t, uint64(32), nextPowerOfTwo(17))
+	require.Equal(t, uint64(32), nextPowerOfTwo(32))
+}
+
+func denebBlock(withdrawals int) *deneb.BeaconBlock {
+	payloadWithdrawals := make([]*capella.Withdrawal, withdrawals)
+	for i := range payloadWithdrawals {
+		payloadWithdrawals[i] = &capella.Withdrawal{
+			Index:          capella.WithdrawalIndex(100 + i),
+			ValidatorIndex: phase0.ValidatorIndex(1000 + i),
+			Amount:         phase0.Gwei(12345 + i),
+		}
+	}
+
+	return &deneb.BeaconBlock{
+		Slot:          1,
+		ProposerIndex: 2,
+		Body: &deneb.BeaconBlockBody{
+			ETH1Data: &phase0.ETH1Data{
+				BlockHash: make([]byte, 32),
+			},
+			SyncAggregate: &altair.SyncAggregate{
+				SyncCommitteeBits: bitfield.NewBitvector512(),
+			},
+			ExecutionPayload: &deneb.ExecutionPayload{
+				BaseFeePerGas: uint256.NewInt(7),
+				Withdrawals:   payloadWithdrawals,
+			},
+		},
+	}
+}
+
+func TestWithdrawalProof(t *testing.T) {
+	block := denebBlock(4)
+	blockRoot, err := block.HashTreeRoot()
+	require.NoError(t, err)
+
+	versionedBlock := &spec.VersionedSignedBeaconBlock{
+		Version: spec.DataVersionDeneb,
+		Deneb: &deneb.SignedBeaconBlock{
+			Message: block,
+		},
+	}
+
+	for position := uint64(0); position < 4; position++ {
+		proof, err := withdrawalProof(versionedBlock, position)
+		require.NoError(t, err)
+		require.Equal(t, phase0.Root(blockRoot), proof.Root)
+		withdrawalRoot, err := block.Body.ExecutionPayload.Withdrawals[position].HashTreeRoot()
+		require.NoError(t, err)
+		require.Equal(t, phase0.Root(withdrawalRoot), proof.Leaf)
+	}
+
+	_, err = withdrawalProof(versionedBlock, 4)
+	require.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestValidatorBalanceProof(t *testing.T) {
+	balances := []phase0.Gwei{32000000000, 31000000000, 33000000000, 30000000000, 29000000000}
+	state := &phase0.BeaconState{
+		Fork:              &phase0.Fork{},
+		LatestBlockHeader: &phase0.BeaconBlockHeader{},
+		BlockRoots:        make([]phase0.Root, 8192),
+		StateRoots:        make([]phase0.Root, 8192),
+		ETH1Data: &phase0.ETH1Data{
+			BlockHash: make([]byte, 32),
+		},
+		Balances:                    balances,
+		RANDAOMixes:                 make([]phase0.Root, 65536),
+		Slashings:                   make([]phase0.Gwei, 8192),
+		JustificationBits:           bitfield.NewBitvector4(),
+		PreviousJustifiedCheckpoint: &phase0.Checkpoint{},
+		CurrentJustifiedCheckpoint:  &phase0.Checkpoint{},
+		FinalizedCheckpoint:         &phase0.Checkpoint{},
+	}
+	stateRoot, err := state.HashTreeRoot()
+	require.NoError(t, err)
+
+	versionedState := &spec.VersionedBeaconState{
+		Version: spec.DataVersionPhase0,
+		Phase0:  state,
+	}
+
+	for i, balance := range balances {
+		proof, err := validatorBalanceProof(versionedState, phase0.ValidatorIndex(i))
+		require.NoError(t, err)
+		require.Equal(t, phase0.Root(stateRoot), proof.Root)
+		offset := (i % 4) * 8
+		require.Equal(t, uint64(balance), binary.LittleEndian.Uint64(proof.Leaf[offset:offset+8]))
+	}
+
+	_, err = validatorBalanceProof(versionedState, 5)
+	require.True(t, errors.Is(err, ErrNotFound))
+}
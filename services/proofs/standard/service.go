@@ -0,0 +1,102 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a proofs service.
+type Service struct {
+	eth2Client          eth2client.Service
+	chainTime           chaintime.Service
+	checkpointsProvider chaindb.CheckpointsProvider
+	validatorsProvider  chaindb.ValidatorsProvider
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("proofs", "standard", parameters.logLevel)
+
+	if _, isProvider := parameters.eth2Client.(eth2client.SignedBeaconBlockProvider); !isProvider {
+		return nil, errors.New("Ethereum 2 client does not provide signed beacon blocks")
+	}
+	if _, isProvider := parameters.eth2Client.(eth2client.BeaconStateProvider); !isProvider {
+		return nil, errors.New("Ethereum 2 client does not provide beacon states")
+	}
+
+	// The following are optional, and used to cross-check proofs against stored data.
+	checkpointsProvider, _ := parameters.chainDB.(chaindb.CheckpointsProvider)
+	validatorsProvider, _ := parameters.chainDB.(chaindb.ValidatorsProvider)
+
+	s := &Service{
+		eth2Client:          parameters.eth2Client,
+		chainTime:           parameters.chainTime,
+		checkpointsProvider: checkpointsProvider,
+		validatorsProvider:  validatorsProvider,
+	}
+
+	if parameters.listenAddress != "" {
+		s.serve(ctx, parameters.listenAddress)
+	}
+
+	return s, nil
+}
+
+// serve serves proofs over HTTP.
+func (s *Service) serve(ctx context.Context, listenAddress string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /proofs/withdrawals/{block_root}/{position}", s.handleWithdrawalProof)
+	mux.HandleFunc("GET /proofs/balances/{epoch}/{validator_index}", s.handleValidatorBalanceProof)
+
+	server := &http.Server{
+		Addr:              listenAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Info().Str("listen_address", listenAddress).Msg("Starting proofs server")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warn().Str("listen_address", listenAddress).Err(err).Msg("Failed to run proofs server")
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Warn().Err(err).Msg("Failed to shut down proofs server")
+		}
+	}()
+}
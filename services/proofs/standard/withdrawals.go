@@ -0,0 +1,112 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/proofs"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// beaconBlockFields is the number of fields in a beacon block.
+	beaconBlockFields = 5
+	// beaconBlockBodyPosition is the position of the body in a beacon block.
+	beaconBlockBodyPosition = 4
+	// executionPayloadPosition is the position of the execution payload in a beacon block body.
+	executionPayloadPosition = 9
+	// withdrawalsPosition is the position of the withdrawals in an execution payload.
+	withdrawalsPosition = 14
+	// maxWithdrawalsPerPayload is the maximum number of withdrawals in an execution payload.
+	maxWithdrawalsPerPayload = 16
+)
+
+// ErrNotFound is returned when the item to be proven does not exist.
+var ErrNotFound = errors.New("not found")
+
+// WithdrawalProof provides a proof of the withdrawal with the given position in the
+// execution payload of the block with the given root, against the block root.
+func (s *Service) WithdrawalProof(ctx context.Context,
+	blockRoot phase0.Root,
+	position uint64,
+) (
+	*proofs.Proof,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.proofs.standard").Start(ctx, "WithdrawalProof")
+	defer span.End()
+
+	blockResponse, err := s.eth2Client.(eth2client.SignedBeaconBlockProvider).SignedBeaconBlock(ctx, &api.SignedBeaconBlockOpts{
+		Block: fmt.Sprintf("%#x", blockRoot),
+	})
+	if err != nil {
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, errors.Wrap(ErrNotFound, "block not available from beacon node")
+		}
+		return nil, errors.Wrap(err, "failed to obtain block")
+	}
+
+	proof, err := withdrawalProof(blockResponse.Data, position)
+	if err != nil {
+		return nil, err
+	}
+	if proof.Root != blockRoot {
+		return nil, fmt.Errorf("obtained block has root %#x", proof.Root)
+	}
+
+	return proof, nil
+}
+
+// withdrawalProof provides a proof of the withdrawal with the given position in the block.
+func withdrawalProof(block *spec.VersionedSignedBeaconBlock, position uint64) (*proofs.Proof, error) {
+	var obj treeProvider
+	var bodyFields uint64
+	var payloadFields uint64
+	var withdrawals int
+	switch block.Version {
+	case spec.DataVersionCapella:
+		obj = block.Capella.Message
+		bodyFields = 11
+		payloadFields = 15
+		withdrawals = len(block.Capella.Message.Body.ExecutionPayload.Withdrawals)
+	case spec.DataVersionDeneb:
+		obj = block.Deneb.Message
+		bodyFields = 12
+		payloadFields = 17
+		withdrawals = len(block.Deneb.Message.Body.ExecutionPayload.Withdrawals)
+	default:
+		return nil, errors.Wrap(ErrNotFound, fmt.Sprintf("block version %s does not have withdrawals", block.Version))
+	}
+	if position >= uint64(withdrawals) {
+		return nil, errors.Wrap(ErrNotFound, fmt.Sprintf("block has %d withdrawals", withdrawals))
+	}
+
+	gindex := concatIndices(
+		fieldIndex(beaconBlockFields, beaconBlockBodyPosition),
+		fieldIndex(bodyFields, executionPayloadPosition),
+		fieldIndex(payloadFields, withdrawalsPosition),
+		listChunkIndex(maxWithdrawalsPerPayload, position),
+	)
+
+	return prove(obj, gindex)
+}
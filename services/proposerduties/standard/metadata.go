@@ -15,7 +15,6 @@ package standard
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
@@ -23,39 +22,45 @@ import (
 
 // metadata stored about this service.
 type metadata struct {
-	LatestEpoch  int64          `json:"latest_epoch"`
-	MissedEpochs []phase0.Epoch `json:"missed_epochs,omitempty"`
+	LatestEpoch  int64
+	MissedEpochs []phase0.Epoch
 }
 
-// metadataKey is the key for the metadata.
-var metadataKey = "proposerduties.standard"
+// progressService is the name of this service for progress.
+var progressService = "proposerduties.standard"
 
 // getMetadata gets metadata for this service.
 func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 	md := &metadata{
 		LatestEpoch: -1,
 	}
-	mdJSON, err := s.chainDB.Metadata(ctx, metadataKey)
+	progress, err := s.chainDB.Progress(ctx, progressService)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to fetch metadata")
+		return nil, errors.Wrap(err, "failed to fetch progress")
 	}
-	if mdJSON == nil {
+	if progress == nil {
 		return md, nil
 	}
-	if err := json.Unmarshal(mdJSON, md); err != nil {
-		return nil, errors.Wrap(err, "failed to unmarshal metadata")
+	if val, exists := progress.Values["latest_epoch"]; exists {
+		md.LatestEpoch = val
+	}
+	for _, epoch := range progress.Gaps["missed_epochs"] {
+		md.MissedEpochs = append(md.MissedEpochs, phase0.Epoch(epoch))
 	}
 	return md, nil
 }
 
 // setMetadata sets metadata for this service.
 func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
-	mdJSON, err := json.Marshal(md)
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal metadata")
+	if err := s.chainDB.SetProgress(ctx, progressService, "latest_epoch", md.LatestEpoch); err != nil {
+		return errors.Wrap(err, "failed to update latest epoch")
+	}
+	missedEpochs := make([]int64, len(md.MissedEpochs))
+	for i, epoch := range md.MissedEpochs {
+		missedEpochs[i] = int64(epoch)
 	}
-	if err := s.chainDB.SetMetadata(ctx, metadataKey, mdJSON); err != nil {
-		return errors.Wrap(err, "failed to update metadata")
+	if err := s.chainDB.SetProgressGaps(ctx, progressService, "missed_epochs", missedEpochs); err != nil {
+		return errors.Wrap(err, "failed to update missed epochs")
 	}
 	return nil
 }
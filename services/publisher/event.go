@@ -0,0 +1,66 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package publisher
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Serializations of events.
+const (
+	// SerializationEnvelope serializes an event as a JSON object holding its metadata and row.
+	SerializationEnvelope = "envelope"
+	// SerializationData serializes an event as the JSON representation of its row alone,
+	// for publishers that can carry the metadata separately.
+	SerializationData = "data"
+)
+
+// eventJSON is the JSON representation of a change event.
+type eventJSON struct {
+	ID        uint64          `json:"id"`
+	Table     string          `json:"table"`
+	Operation string          `json:"operation"`
+	Timestamp string          `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// MarshalEvent provides the JSON representation of a change event.
+func MarshalEvent(event *chaindb.OutboxEvent) ([]byte, error) {
+	return json.Marshal(&eventJSON{
+		ID:        event.ID,
+		Table:     event.Table,
+		Operation: event.Operation,
+		Timestamp: event.Timestamp.UTC().Format(time.RFC3339Nano),
+		Data:      event.Data,
+	})
+}
+
+// SerializeEvent serializes a change event.
+func SerializeEvent(event *chaindb.OutboxEvent, serialization string) ([]byte, error) {
+	switch serialization {
+	case SerializationEnvelope:
+		return MarshalEvent(event)
+	case SerializationData:
+		if !json.Valid(event.Data) {
+			return nil, fmt.Errorf("event %d has invalid data", event.ID)
+		}
+		return event.Data, nil
+	default:
+		return nil, fmt.Errorf("unknown serialization %q", serialization)
+	}
+}
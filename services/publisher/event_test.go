@@ -0,0 +1,125 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package publisher_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/publisher"
+)
+
+func TestMarshalEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    *chaindb.OutboxEvent
+		err      string
+		expected string
+	}{
+		{
+			name: "Good",
+			event: &chaindb.OutboxEvent{
+				ID:        12,
+				Table:     "t_blocks",
+				Operation: chaindb.OutboxOperationInsert,
+				Data:      []byte(`{"f_slot": 1, "f_root": "\\x01"}`),
+				Timestamp: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+			expected: `{"id":12,"table":"t_blocks","operation":"insert","timestamp":"2023-01-02T03:04:05Z","data":{"f_slot":1,"f_root":"\\x01"}}`,
+		},
+		{
+			name: "DataInvalid",
+			event: &chaindb.OutboxEvent{
+				ID:        12,
+				Table:     "t_blocks",
+				Operation: chaindb.OutboxOperationInsert,
+				Data:      []byte(`{"f_slot": `),
+				Timestamp: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+			err: "unexpected end of JSON input",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := publisher.MarshalEvent(test.event)
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expected, string(res))
+			}
+		})
+	}
+}
+
+func TestSerializeEvent(t *testing.T) {
+	event := &chaindb.OutboxEvent{
+		ID:        12,
+		Table:     "t_blocks",
+		Operation: chaindb.OutboxOperationDelete,
+		Data:      []byte(`{"f_slot":1}`),
+		Timestamp: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name          string
+		event         *chaindb.OutboxEvent
+		serialization string
+		err           string
+		expected      string
+	}{
+		{
+			name:          "Envelope",
+			event:         event,
+			serialization: publisher.SerializationEnvelope,
+			expected:      `{"id":12,"table":"t_blocks","operation":"delete","timestamp":"2023-01-02T03:04:05Z","data":{"f_slot":1}}`,
+		},
+		{
+			name:          "Data",
+			event:         event,
+			serialization: publisher.SerializationData,
+			expected:      `{"f_slot":1}`,
+		},
+		{
+			name: "DataInvalid",
+			event: &chaindb.OutboxEvent{
+				ID:   13,
+				Data: []byte(`{"f_slot":`),
+			},
+			serialization: publisher.SerializationData,
+			err:           "event 13 has invalid data",
+		},
+		{
+			name:          "Unknown",
+			event:         event,
+			serialization: "xml",
+			err:           `unknown serialization "xml"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := publisher.SerializeEvent(test.event, test.serialization)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expected, string(res))
+			}
+		})
+	}
+}
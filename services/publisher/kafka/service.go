@@ -0,0 +1,161 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/publisher"
+	"github.com/wealdtech/chaind/util"
+)
+
+// contentType is the content type for JSON records sent to the Kafka REST proxy.
+const contentType = "application/vnd.kafka.json.v2+json"
+
+// Service is a publisher that produces events to a Kafka topic through a Kafka REST proxy.
+type Service struct {
+	client *http.Client
+	url    string
+	topic  string
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+type record struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type produceRequest struct {
+	Records []*record `json:"records"`
+}
+
+type produceOffset struct {
+	Partition *int32  `json:"partition"`
+	Offset    *int64  `json:"offset"`
+	ErrorCode *int    `json:"error_code"`
+	Error     *string `json:"error"`
+}
+
+type produceResponse struct {
+	Offsets []*produceOffset `json:"offsets"`
+}
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("publisher", "kafka", parameters.logLevel)
+
+	return &Service{
+		client: &http.Client{
+			Timeout: parameters.timeout,
+		},
+		url:   strings.TrimSuffix(parameters.url, "/"),
+		topic: parameters.topic,
+	}, nil
+}
+
+// Name provides the name of the publisher, for reference.
+func (*Service) Name() string {
+	return "kafka"
+}
+
+// Publish publishes events in order, returning once all of them have been
+// accepted by the destination.
+// Events are keyed by their table, so events for the same table are written
+// to the same partition and retain their order.
+func (s *Service) Publish(ctx context.Context, events []*chaindb.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	request := &produceRequest{
+		Records: make([]*record, 0, len(events)),
+	}
+	for _, event := range events {
+		value, err := publisher.MarshalEvent(event)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal event")
+		}
+		request.Records = append(request.Records, &record{
+			Key:   event.Table,
+			Value: value,
+		})
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal records")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/topics/%s", s.url, url.PathEscape(s.topic)), bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to produce records")
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("REST proxy returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return checkProduceResponse(respBody, len(events))
+}
+
+// checkProduceResponse checks that all records in a produce request were accepted.
+func checkProduceResponse(body []byte, records int) error {
+	var response produceResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return errors.Wrap(err, "invalid response")
+	}
+	if len(response.Offsets) != records {
+		return fmt.Errorf("response contains %d offsets but %d records were produced", len(response.Offsets), records)
+	}
+	for i, offset := range response.Offsets {
+		if offset.ErrorCode != nil || offset.Error != nil {
+			msg := "unknown error"
+			if offset.Error != nil {
+				msg = *offset.Error
+			}
+			return fmt.Errorf("failed to produce record %d: %s", i, msg)
+		}
+	}
+	log.Trace().Int("records", records).Msg("Produced records")
+
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/publisher/kafka"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []kafka.Parameter
+		err    string
+	}{
+		{
+			name: "URLMissing",
+			params: []kafka.Parameter{
+				kafka.WithLogLevel(zerolog.Disabled),
+				kafka.WithTopic("chaind"),
+			},
+			err: "problem with parameters: no URL specified",
+		},
+		{
+			name: "TopicMissing",
+			params: []kafka.Parameter{
+				kafka.WithLogLevel(zerolog.Disabled),
+				kafka.WithURL("http://localhost:8082"),
+			},
+			err: "problem with parameters: no topic specified",
+		},
+		{
+			name: "Good",
+			params: []kafka.Parameter{
+				kafka.WithLogLevel(zerolog.Disabled),
+				kafka.WithURL("http://localhost:8082"),
+				kafka.WithTopic("chaind"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := kafka.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+
+	response := `{"offsets":[{"partition":0,"offset":10},{"partition":0,"offset":11}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/topics/chaind", r.URL.Path)
+		require.Equal(t, "application/vnd.kafka.json.v2+json", r.Header.Get("Content-Type"))
+		request := struct {
+			Records []struct {
+				Key   string         `json:"key"`
+				Value map[string]any `json:"value"`
+			} `json:"records"`
+		}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		require.Len(t, request.Records, 2)
+		require.Equal(t, "t_blocks", request.Records[0].Key)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	s, err := kafka.New(ctx,
+		kafka.WithLogLevel(zerolog.Disabled),
+		kafka.WithURL(server.URL+"/"),
+		kafka.WithTopic("chaind"),
+	)
+	require.NoError(t, err)
+
+	events := []*chaindb.OutboxEvent{
+		{ID: 1, Table: "t_blocks", Operation: chaindb.OutboxOperationInsert, Data: []byte(`{"f_slot":1}`), Timestamp: time.Unix(1700000000, 0)},
+		{ID: 2, Table: "t_blocks", Operation: chaindb.OutboxOperationUpdate, Data: []byte(`{"f_slot":1}`), Timestamp: time.Unix(1700000001, 0)},
+	}
+	require.NoError(t, s.Publish(ctx, events))
+
+	response = `{"offsets":[{"partition":0,"offset":10},{"error_code":50003,"error":"timed out"}]}`
+	require.EqualError(t, s.Publish(ctx, events), "failed to produce record 1: timed out")
+
+	response = `{"offsets":[{"partition":0,"offset":10}]}`
+	require.EqualError(t, s.Publish(ctx, events), "response contains 1 offsets but 2 records were produced")
+}
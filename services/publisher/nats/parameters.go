@@ -0,0 +1,140 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/publisher"
+)
+
+// Subject layouts.
+const (
+	// SubjectLayoutSingle publishes all events to the subject.
+	SubjectLayoutSingle = "single"
+	// SubjectLayoutEntity publishes events to a subject per table and operation
+	// beneath the subject, for example "chaind.outbox.blocks.insert".
+	SubjectLayoutEntity = "entity"
+)
+
+type parameters struct {
+	logLevel      zerolog.Level
+	url           string
+	subject       string
+	subjectLayout string
+	serialization string
+	jetStream     bool
+	timeout       time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(p *parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithURL sets the URL of the NATS server.
+func WithURL(url string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.url = url
+	})
+}
+
+// WithSubject sets the subject to which events are published.
+func WithSubject(subject string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.subject = subject
+	})
+}
+
+// WithSubjectLayout sets the layout of the subjects to which events are published.
+func WithSubjectLayout(subjectLayout string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.subjectLayout = subjectLayout
+	})
+}
+
+// WithSerialization sets the serialization of events.
+func WithSerialization(serialization string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.serialization = serialization
+	})
+}
+
+// WithJetStream publishes events to JetStream, confirming that they have been stored.
+func WithJetStream(jetStream bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.jetStream = jetStream
+	})
+}
+
+// WithTimeout sets the timeout for publishing events.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:      zerolog.GlobalLevel(),
+		subject:       "chaind.outbox",
+		subjectLayout: SubjectLayoutSingle,
+		serialization: publisher.SerializationEnvelope,
+		timeout:       30 * time.Second,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.url == "" {
+		return nil, errors.New("no URL specified")
+	}
+	if parameters.subject == "" {
+		return nil, errors.New("no subject specified")
+	}
+	switch parameters.subjectLayout {
+	case SubjectLayoutSingle, SubjectLayoutEntity:
+	default:
+		return nil, fmt.Errorf("unknown subject layout %q", parameters.subjectLayout)
+	}
+	switch parameters.serialization {
+	case publisher.SerializationEnvelope, publisher.SerializationData:
+	default:
+		return nil, fmt.Errorf("unknown serialization %q", parameters.serialization)
+	}
+	if parameters.timeout <= 0 {
+		return nil, errors.New("timeout must be greater than 0")
+	}
+
+	return &parameters, nil
+}
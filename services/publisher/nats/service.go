@@ -0,0 +1,195 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/publisher"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Headers added to each message.
+const (
+	headerEventID   = "Chaind-Event-Id"
+	headerTable     = "Chaind-Table"
+	headerOperation = "Chaind-Operation"
+	headerTimestamp = "Chaind-Timestamp"
+)
+
+// Service is a publisher that publishes events to NATS, optionally through JetStream.
+type Service struct {
+	conn          *nats.Conn
+	js            nats.JetStreamContext
+	subject       string
+	subjectLayout string
+	serialization string
+	timeout       time.Duration
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("publisher", "nats", parameters.logLevel)
+
+	conn, err := nats.Connect(parameters.url,
+		nats.Name("chaind"),
+		nats.Timeout(parameters.timeout),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			log.Warn().Err(err).Msg("Disconnected from NATS server")
+		}),
+		nats.ReconnectHandler(func(_ *nats.Conn) {
+			log.Info().Msg("Reconnected to NATS server")
+		}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to NATS server")
+	}
+
+	s := &Service{
+		conn:          conn,
+		subject:       parameters.subject,
+		subjectLayout: parameters.subjectLayout,
+		serialization: parameters.serialization,
+		timeout:       parameters.timeout,
+	}
+
+	if parameters.jetStream {
+		s.js, err = conn.JetStream()
+		if err != nil {
+			conn.Close()
+			return nil, errors.Wrap(err, "failed to create JetStream context")
+		}
+	}
+
+	return s, nil
+}
+
+// Name provides the name of the publisher, for reference.
+func (*Service) Name() string {
+	return "nats"
+}
+
+// Publish publishes events in order, returning once all of them have been
+// accepted by the destination.
+// If publishing through JetStream then events are accepted once they have been
+// stored in a stream, and their IDs are used for deduplication within the
+// stream's duplicate window.  Otherwise events are accepted once the NATS server
+// has received them, and delivery to subscribers is not confirmed.
+func (s *Service) Publish(ctx context.Context, events []*chaindb.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if s.js != nil {
+		return s.publishJetStream(ctx, events)
+	}
+
+	for _, event := range events {
+		msg, err := s.msg(event)
+		if err != nil {
+			return err
+		}
+		if err := s.conn.PublishMsg(msg); err != nil {
+			return errors.Wrap(err, "failed to publish event")
+		}
+	}
+
+	// Flush to confirm that the server has received the events.
+	if err := s.conn.FlushWithContext(ctx); err != nil {
+		return errors.Wrap(err, "failed to flush events")
+	}
+	log.Trace().Int("events", len(events)).Msg("Published events")
+
+	return nil
+}
+
+// publishJetStream publishes events through JetStream, waiting for all of them to be acknowledged.
+func (s *Service) publishJetStream(ctx context.Context, events []*chaindb.OutboxEvent) error {
+	futures := make([]nats.PubAckFuture, 0, len(events))
+	for _, event := range events {
+		msg, err := s.msg(event)
+		if err != nil {
+			return err
+		}
+		future, err := s.js.PublishMsgAsync(msg, nats.MsgId(strconv.FormatUint(event.ID, 10)))
+		if err != nil {
+			return errors.Wrap(err, "failed to publish event")
+		}
+		futures = append(futures, future)
+	}
+
+	duplicates := 0
+	for i, future := range futures {
+		select {
+		case ack := <-future.Ok():
+			if ack.Duplicate {
+				duplicates++
+			}
+		case err := <-future.Err():
+			return errors.Wrapf(err, "failed to store event %d", events[i].ID)
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "timed out waiting for events to be stored")
+		}
+	}
+	log.Trace().Int("events", len(events)).Int("duplicates", duplicates).Msg("Published events")
+
+	return nil
+}
+
+// msg creates the message for an event.
+func (s *Service) msg(event *chaindb.OutboxEvent) (*nats.Msg, error) {
+	data, err := publisher.SerializeEvent(event, s.serialization)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to serialize event")
+	}
+
+	msg := nats.NewMsg(subject(s.subject, s.subjectLayout, event))
+	msg.Data = data
+	msg.Header.Set(headerEventID, strconv.FormatUint(event.ID, 10))
+	msg.Header.Set(headerTable, event.Table)
+	msg.Header.Set(headerOperation, event.Operation)
+	msg.Header.Set(headerTimestamp, event.Timestamp.UTC().Format(time.RFC3339Nano))
+
+	return msg, nil
+}
+
+// subject provides the subject to which an event is published.
+func subject(base string, layout string, event *chaindb.OutboxEvent) string {
+	if layout != SubjectLayoutEntity {
+		return base
+	}
+
+	return fmt.Sprintf("%s.%s.%s", base, strings.TrimPrefix(event.Table, "t_"), event.Operation)
+}
@@ -0,0 +1,74 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/publisher"
+)
+
+func TestMsg(t *testing.T) {
+	event := &chaindb.OutboxEvent{
+		ID:        12,
+		Table:     "t_validator_balances",
+		Operation: chaindb.OutboxOperationInsert,
+		Data:      []byte(`{"f_validator_index":1}`),
+		Timestamp: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name          string
+		subjectLayout string
+		serialization string
+		subject       string
+		data          string
+	}{
+		{
+			name:          "SingleEnvelope",
+			subjectLayout: SubjectLayoutSingle,
+			serialization: publisher.SerializationEnvelope,
+			subject:       "chaind.outbox",
+			data:          `{"id":12,"table":"t_validator_balances","operation":"insert","timestamp":"2023-01-02T03:04:05Z","data":{"f_validator_index":1}}`,
+		},
+		{
+			name:          "EntityData",
+			subjectLayout: SubjectLayoutEntity,
+			serialization: publisher.SerializationData,
+			subject:       "chaind.outbox.validator_balances.insert",
+			data:          `{"f_validator_index":1}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Service{
+				subject:       "chaind.outbox",
+				subjectLayout: test.subjectLayout,
+				serialization: test.serialization,
+			}
+			msg, err := s.msg(event)
+			require.NoError(t, err)
+			require.Equal(t, test.subject, msg.Subject)
+			require.Equal(t, test.data, string(msg.Data))
+			require.Equal(t, "12", msg.Header.Get(headerEventID))
+			require.Equal(t, "t_validator_balances", msg.Header.Get(headerTable))
+			require.Equal(t, "insert", msg.Header.Get(headerOperation))
+			require.Equal(t, "2023-01-02T03:04:05Z", msg.Header.Get(headerTimestamp))
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/publisher/nats"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []nats.Parameter
+		err    string
+	}{
+		{
+			name: "URLMissing",
+			params: []nats.Parameter{
+				nats.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no URL specified",
+		},
+		{
+			name: "SubjectMissing",
+			params: []nats.Parameter{
+				nats.WithLogLevel(zerolog.Disabled),
+				nats.WithURL("nats://localhost:4222"),
+				nats.WithSubject(""),
+			},
+			err: "problem with parameters: no subject specified",
+		},
+		{
+			name: "SubjectLayoutUnknown",
+			params: []nats.Parameter{
+				nats.WithLogLevel(zerolog.Disabled),
+				nats.WithURL("nats://localhost:4222"),
+				nats.WithSubjectLayout("table"),
+			},
+			err: "problem with parameters: unknown subject layout \"table\"",
+		},
+		{
+			name: "SerializationUnknown",
+			params: []nats.Parameter{
+				nats.WithLogLevel(zerolog.Disabled),
+				nats.WithURL("nats://localhost:4222"),
+				nats.WithSerialization("xml"),
+			},
+			err: "problem with parameters: unknown serialization \"xml\"",
+		},
+		{
+			name: "TimeoutZero",
+			params: []nats.Parameter{
+				nats.WithLogLevel(zerolog.Disabled),
+				nats.WithURL("nats://localhost:4222"),
+				nats.WithTimeout(0),
+			},
+			err: "problem with parameters: timeout must be greater than 0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := nats.New(ctx, test.params...)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package publisher
+
+import (
+	"context"
+
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Service is a publisher of change events to downstream consumers.
+type Service interface {
+	// Publish publishes events in order, returning once all of them have been
+	// accepted by the destination.
+	Publish(ctx context.Context, events []*chaindb.OutboxEvent) error
+
+	// Name provides the name of the publisher, for reference.
+	Name() string
+}
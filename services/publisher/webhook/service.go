@@ -0,0 +1,105 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/publisher"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a publisher that posts events to a webhook.
+type Service struct {
+	client *http.Client
+	url    string
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("publisher", "webhook", parameters.logLevel)
+
+	return &Service{
+		client: &http.Client{
+			Timeout: parameters.timeout,
+		},
+		url: parameters.url,
+	}, nil
+}
+
+// Name provides the name of the publisher, for reference.
+func (*Service) Name() string {
+	return "webhook"
+}
+
+// Publish publishes events in order, returning once all of them have been
+// accepted by the destination.
+// Events are posted as a single JSON array, and are accepted if the webhook
+// responds with a 2xx status code.
+func (s *Service) Publish(ctx context.Context, events []*chaindb.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	items := make([]json.RawMessage, 0, len(events))
+	for _, event := range events {
+		item, err := publisher.MarshalEvent(event)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal event")
+		}
+		items = append(items, item)
+	}
+	body, err := json.Marshal(items)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal events")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to post events")
+	}
+	defer resp.Body.Close()
+	// Drain the body to allow the connection to be reused.
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	log.Trace().Int("events", len(events)).Msg("Posted events")
+
+	return nil
+}
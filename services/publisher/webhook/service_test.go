@@ -0,0 +1,105 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/publisher/webhook"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []webhook.Parameter
+		err    string
+	}{
+		{
+			name: "URLMissing",
+			params: []webhook.Parameter{
+				webhook.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no URL specified",
+		},
+		{
+			name: "TimeoutZero",
+			params: []webhook.Parameter{
+				webhook.WithLogLevel(zerolog.Disabled),
+				webhook.WithURL("http://localhost:8080/events"),
+				webhook.WithTimeout(0),
+			},
+			err: "problem with parameters: timeout must be greater than 0",
+		},
+		{
+			name: "Good",
+			params: []webhook.Parameter{
+				webhook.WithLogLevel(zerolog.Disabled),
+				webhook.WithURL("http://localhost:8080/events"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := webhook.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+
+	var received []map[string]any
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	s, err := webhook.New(ctx,
+		webhook.WithLogLevel(zerolog.Disabled),
+		webhook.WithURL(server.URL),
+	)
+	require.NoError(t, err)
+
+	events := []*chaindb.OutboxEvent{
+		{ID: 1, Table: "t_blocks", Operation: chaindb.OutboxOperationInsert, Data: []byte(`{"f_slot":1}`), Timestamp: time.Unix(1700000000, 0)},
+		{ID: 2, Table: "t_blocks", Operation: chaindb.OutboxOperationUpdate, Data: []byte(`{"f_slot":1}`), Timestamp: time.Unix(1700000001, 0)},
+	}
+
+	require.NoError(t, s.Publish(ctx, events))
+	require.Len(t, received, 2)
+	require.Equal(t, float64(1), received[0]["id"])
+	require.Equal(t, "update", received[1]["operation"])
+
+	status = http.StatusInternalServerError
+	require.EqualError(t, s.Publish(ctx, events), "webhook returned status 500")
+}
@@ -0,0 +1,181 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queues
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Service is a queues service.
+type Service interface {
+	// Queues provides the state of the activation and exit queues.
+	Queues(ctx context.Context) (*Queues, error)
+
+	// ValidatorProjection provides the projected activation and exit of the given validator.
+	ValidatorProjection(ctx context.Context, index phase0.ValidatorIndex) (*ValidatorProjection, error)
+
+	// WithdrawalForecast provides the forecast full withdrawal of the given validator.
+	WithdrawalForecast(ctx context.Context, index phase0.ValidatorIndex) (*WithdrawalForecast, error)
+
+	// ProjectionAccuracy provides the accuracy of the activation projections
+	// stored between the given epochs.
+	ProjectionAccuracy(ctx context.Context, from phase0.Epoch, to phase0.Epoch) ([]*chaindb.QueueProjectionAccuracy, error)
+}
+
+// Queues is the state of the activation and exit queues at an epoch.
+type Queues struct {
+	// Epoch is the epoch at which the projections were made.
+	Epoch phase0.Epoch
+	// ActivationQueueLength is the number of validators awaiting activation.
+	ActivationQueueLength int
+	// ActivationChurnLimit is the maximum number of validators activated each epoch.
+	ActivationChurnLimit int
+	// ActivationEpoch is the projected activation epoch of a validator that
+	// becomes eligible for activation at the next epoch.
+	ActivationEpoch phase0.Epoch
+	// ActivationTime is the start of the projected activation epoch.
+	ActivationTime time.Time
+	// ExitQueueLength is the number of validators that have initiated an exit
+	// but not yet exited.
+	ExitQueueLength int
+	// ExitChurnLimit is the maximum number of validators exited each epoch.
+	ExitChurnLimit int
+	// ExitEpoch is the projected exit epoch of a validator that initiates an exit now.
+	ExitEpoch phase0.Epoch
+	// ExitTime is the start of the projected exit epoch.
+	ExitTime time.Time
+}
+
+// queuesJSON is the JSON representation of the queues.
+type queuesJSON struct {
+	Epoch                 string `json:"epoch"`
+	ActivationQueueLength int    `json:"activation_queue_length"`
+	ActivationChurnLimit  int    `json:"activation_churn_limit"`
+	ActivationEpoch       string `json:"activation_epoch"`
+	ActivationTime        string `json:"activation_time"`
+	ExitQueueLength       int    `json:"exit_queue_length"`
+	ExitChurnLimit        int    `json:"exit_churn_limit"`
+	ExitEpoch             string `json:"exit_epoch"`
+	ExitTime              string `json:"exit_time"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (q *Queues) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&queuesJSON{
+		Epoch:                 fmt.Sprintf("%d", q.Epoch),
+		ActivationQueueLength: q.ActivationQueueLength,
+		ActivationChurnLimit:  q.ActivationChurnLimit,
+		ActivationEpoch:       fmt.Sprintf("%d", q.ActivationEpoch),
+		ActivationTime:        q.ActivationTime.UTC().Format(time.RFC3339),
+		ExitQueueLength:       q.ExitQueueLength,
+		ExitChurnLimit:        q.ExitChurnLimit,
+		ExitEpoch:             fmt.Sprintf("%d", q.ExitEpoch),
+		ExitTime:              q.ExitTime.UTC().Format(time.RFC3339),
+	})
+}
+
+// ValidatorProjection is the projected activation and exit of a validator.
+type ValidatorProjection struct {
+	// Epoch is the epoch at which the projection was made.
+	Epoch phase0.Epoch
+	Index phase0.ValidatorIndex
+	// ActivationEpoch is the projected activation epoch of the validator.
+	// It is nil if the validator is not awaiting activation.
+	ActivationEpoch *phase0.Epoch
+	// ActivationTime is the start of the projected activation epoch.
+	ActivationTime *time.Time
+	// ExitEpoch is the projected exit epoch of the validator.
+	// It is nil if the validator has not initiated an exit.
+	ExitEpoch *phase0.Epoch
+	// ExitTime is the start of the projected exit epoch.
+	ExitTime *time.Time
+}
+
+// validatorProjectionJSON is the JSON representation of a validator projection.
+type validatorProjectionJSON struct {
+	Epoch           string `json:"epoch"`
+	Index           string `json:"index"`
+	ActivationEpoch string `json:"activation_epoch,omitempty"`
+	ActivationTime  string `json:"activation_time,omitempty"`
+	ExitEpoch       string `json:"exit_epoch,omitempty"`
+	ExitTime        string `json:"exit_time,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p *ValidatorProjection) MarshalJSON() ([]byte, error) {
+	data := &validatorProjectionJSON{
+		Epoch: fmt.Sprintf("%d", p.Epoch),
+		Index: fmt.Sprintf("%d", p.Index),
+	}
+	if p.ActivationEpoch != nil {
+		data.ActivationEpoch = fmt.Sprintf("%d", *p.ActivationEpoch)
+	}
+	if p.ActivationTime != nil {
+		data.ActivationTime = p.ActivationTime.UTC().Format(time.RFC3339)
+	}
+	if p.ExitEpoch != nil {
+		data.ExitEpoch = fmt.Sprintf("%d", *p.ExitEpoch)
+	}
+	if p.ExitTime != nil {
+		data.ExitTime = p.ExitTime.UTC().Format(time.RFC3339)
+	}
+
+	return json.Marshal(data)
+}
+
+// WithdrawalForecast is the forecast full withdrawal of an exited validator.
+type WithdrawalForecast struct {
+	// Epoch is the epoch at which the forecast was made.
+	Epoch             phase0.Epoch
+	Index             phase0.ValidatorIndex
+	WithdrawableEpoch phase0.Epoch
+	// Slot is the slot at which the validator is forecast to be swept.
+	// It is nil if the validator cannot be swept, as it does not have
+	// execution withdrawal credentials.
+	Slot *phase0.Slot
+	// Time is the start of the forecast slot.
+	Time *time.Time
+}
+
+// withdrawalForecastJSON is the JSON representation of a withdrawal forecast.
+type withdrawalForecastJSON struct {
+	Epoch             string `json:"epoch"`
+	Index             string `json:"index"`
+	WithdrawableEpoch string `json:"withdrawable_epoch"`
+	Slot              string `json:"slot,omitempty"`
+	Time              string `json:"time,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f *WithdrawalForecast) MarshalJSON() ([]byte, error) {
+	data := &withdrawalForecastJSON{
+		Epoch:             fmt.Sprintf("%d", f.Epoch),
+		Index:             fmt.Sprintf("%d", f.Index),
+		WithdrawableEpoch: fmt.Sprintf("%d", f.WithdrawableEpoch),
+	}
+	if f.Slot != nil {
+		data.Slot = fmt.Sprintf("%d", *f.Slot)
+	}
+	if f.Time != nil {
+		data.Time = f.Time.UTC().Format(time.RFC3339)
+	}
+
+	return json.Marshal(data)
+}
@@ -0,0 +1,124 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// handleQueues handles requests for the state of the queues.
+func (s *Service) handleQueues(w http.ResponseWriter, r *http.Request) {
+	queues, err := s.Queues(r.Context())
+	writeResponse(w, queues, err)
+}
+
+// handleValidatorProjection handles requests for the projection of a validator.
+func (s *Service) handleValidatorProjection(w http.ResponseWriter, r *http.Request) {
+	index, err := strconv.ParseUint(r.PathValue("validator_index"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid validator index", http.StatusBadRequest)
+		return
+	}
+
+	projection, err := s.ValidatorProjection(r.Context(), phase0.ValidatorIndex(index))
+	writeResponse(w, projection, err)
+}
+
+// handleWithdrawalForecast handles requests for the withdrawal forecast of a validator.
+func (s *Service) handleWithdrawalForecast(w http.ResponseWriter, r *http.Request) {
+	index, err := strconv.ParseUint(r.PathValue("validator_index"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid validator index", http.StatusBadRequest)
+		return
+	}
+
+	forecast, err := s.WithdrawalForecast(r.Context(), phase0.ValidatorIndex(index))
+	writeResponse(w, forecast, err)
+}
+
+// accuracyJSON is the JSON representation of the accuracy of projections.
+type accuracyJSON struct {
+	Epoch             string  `json:"epoch"`
+	Validators        int     `json:"validators"`
+	MeanError         float64 `json:"mean_error"`
+	MeanAbsoluteError float64 `json:"mean_absolute_error"`
+}
+
+// handleProjectionAccuracy handles requests for the accuracy of stored projections.
+// The optional from and to query parameters bound the epochs of the projections.
+func (s *Service) handleProjectionAccuracy(w http.ResponseWriter, r *http.Request) {
+	from := uint64(0)
+	to := uint64(s.chainTime.CurrentEpoch())
+	var err error
+	if val := r.URL.Query().Get("from"); val != "" {
+		from, err = strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid from epoch", http.StatusBadRequest)
+			return
+		}
+	}
+	if val := r.URL.Query().Get("to"); val != "" {
+		to, err = strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid to epoch", http.StatusBadRequest)
+			return
+		}
+	}
+
+	accuracies, err := s.ProjectionAccuracy(r.Context(), phase0.Epoch(from), phase0.Epoch(to))
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+	data := make([]*accuracyJSON, len(accuracies))
+	for i, accuracy := range accuracies {
+		data[i] = &accuracyJSON{
+			Epoch:             fmt.Sprintf("%d", accuracy.Epoch),
+			Validators:        accuracy.Validators,
+			MeanError:         accuracy.MeanError,
+			MeanAbsoluteError: accuracy.MeanAbsoluteError,
+		}
+	}
+	writeResponse(w, data, nil)
+}
+
+// writeResponse writes the response, or the error obtained when generating it.
+func writeResponse(w http.ResponseWriter, response any, err error) {
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		log.Warn().Err(err).Msg("Failed to generate response")
+		http.Error(w, "failed to generate response", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to marshal response")
+		http.Error(w, "failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
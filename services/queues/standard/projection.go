@@ -0,0 +1,182 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// finalityLag is the number of epochs after a validator becomes eligible for
+// activation that the eligibility epoch is expected to be finalized, at which
+// point the validator can be dequeued.
+const finalityLag = 1
+
+// projection holds the projected state of the queues at an epoch.
+type projection struct {
+	epoch                 phase0.Epoch
+	validators            int
+	activationQueueLength int
+	activationChurnLimit  int
+	activationEpoch       phase0.Epoch
+	exitQueueLength       int
+	exitChurnLimit        int
+	exitEpoch             phase0.Epoch
+	validatorProjections  map[phase0.ValidatorIndex]*chaindb.QueueProjection
+}
+
+// project projects the activation and exit epochs of the validators as of the given epoch.
+// Validators are dequeued in order of eligibility epoch then index, up to the
+// churn limit each epoch, once their eligibility epoch is finalized.
+func (s *Service) project(epoch phase0.Epoch, validators []*chaindb.Validator) *projection {
+	res := &projection{
+		epoch:                epoch,
+		validators:           len(validators),
+		validatorProjections: make(map[phase0.ValidatorIndex]*chaindb.QueueProjection),
+	}
+
+	activeValidators := 0
+	queue := make([]*chaindb.Validator, 0)
+	// latestExitEpoch is the latest exit epoch of any validator, and exitsAtLatest
+	// the number of validators exiting in that epoch.
+	latestExitEpoch := phase0.Epoch(0)
+	exitsAtLatest := 0
+	for _, validator := range validators {
+		if validator.ActivationEpoch <= epoch && validator.ExitEpoch > epoch {
+			activeValidators++
+		}
+		if validator.ExitEpoch != farFutureEpoch {
+			switch {
+			case validator.ExitEpoch > latestExitEpoch:
+				latestExitEpoch = validator.ExitEpoch
+				exitsAtLatest = 1
+			case validator.ExitEpoch == latestExitEpoch:
+				exitsAtLatest++
+			}
+			if validator.ExitEpoch > epoch {
+				res.exitQueueLength++
+				exitEpoch := validator.ExitEpoch
+				res.validatorProjection(epoch, validator.Index).ExitEpoch = &exitEpoch
+			}
+			continue
+		}
+		switch {
+		case validator.ActivationEpoch == farFutureEpoch:
+			queue = append(queue, validator)
+		case validator.ActivationEpoch > epoch:
+			// Already dequeued, so the activation epoch is known.
+			res.activationQueueLength++
+			activationEpoch := validator.ActivationEpoch
+			res.validatorProjection(epoch, validator.Index).ActivationEpoch = &activationEpoch
+		}
+	}
+
+	res.exitChurnLimit = s.churnLimit(activeValidators)
+	res.activationChurnLimit = s.activationChurnLimit(epoch, activeValidators)
+
+	// Validators without an eligibility epoch have yet to be processed, and
+	// will become eligible at the next epoch.
+	eligibilityEpoch := func(validator *chaindb.Validator) phase0.Epoch {
+		if validator.ActivationEligibilityEpoch == farFutureEpoch {
+			return epoch + 1
+		}
+		return validator.ActivationEligibilityEpoch
+	}
+	sort.Slice(queue, func(i int, j int) bool {
+		if eligibilityEpoch(queue[i]) != eligibilityEpoch(queue[j]) {
+			return eligibilityEpoch(queue[i]) < eligibilityEpoch(queue[j])
+		}
+		return queue[i].Index < queue[j].Index
+	})
+
+	dequeueEpoch := epoch
+	dequeued := 0
+	dequeue := func(eligibility phase0.Epoch) phase0.Epoch {
+		if eligibility+finalityLag > dequeueEpoch {
+			dequeueEpoch = eligibility + finalityLag
+			dequeued = 0
+		}
+		activationEpoch := s.activationExitEpoch(dequeueEpoch)
+		dequeued++
+		if dequeued >= res.activationChurnLimit {
+			dequeueEpoch++
+			dequeued = 0
+		}
+		return activationEpoch
+	}
+	for _, validator := range queue {
+		activationEpoch := dequeue(eligibilityEpoch(validator))
+		res.activationQueueLength++
+		res.validatorProjection(epoch, validator.Index).ActivationEpoch = &activationEpoch
+	}
+	// A validator that becomes eligible at the next epoch joins the end of the queue.
+	res.activationEpoch = dequeue(epoch + 1)
+
+	// A validator that initiates an exit now exits after all existing exits,
+	// subject to the exit churn limit.
+	res.exitEpoch = s.activationExitEpoch(epoch)
+	if latestExitEpoch > res.exitEpoch {
+		res.exitEpoch = latestExitEpoch
+	}
+	if res.exitEpoch == latestExitEpoch && exitsAtLatest >= res.exitChurnLimit {
+		res.exitEpoch++
+	}
+
+	return res
+}
+
+// validatorProjection returns the projection for the given validator, creating it if required.
+func (p *projection) validatorProjection(epoch phase0.Epoch, index phase0.ValidatorIndex) *chaindb.QueueProjection {
+	validatorProjection, exists := p.validatorProjections[index]
+	if !exists {
+		validatorProjection = &chaindb.QueueProjection{
+			Epoch:          epoch,
+			ValidatorIndex: index,
+		}
+		p.validatorProjections[index] = validatorProjection
+	}
+
+	return validatorProjection
+}
+
+// activationExitEpoch returns the epoch at which activations and exits
+// initiated in the given epoch take effect.
+func (s *Service) activationExitEpoch(epoch phase0.Epoch) phase0.Epoch {
+	return epoch + 1 + phase0.Epoch(s.maxSeedLookahead)
+}
+
+// churnLimit calculates the validator churn limit for the given number of active validators.
+func (s *Service) churnLimit(activeValidators int) int {
+	churnLimit := uint64(activeValidators) / s.churnLimitQuotient
+	if churnLimit < s.minPerEpochChurnLimit {
+		churnLimit = s.minPerEpochChurnLimit
+	}
+
+	return int(churnLimit)
+}
+
+// activationChurnLimit calculates the validator activation churn limit for the given
+// number of active validators at the given epoch.
+func (s *Service) activationChurnLimit(epoch phase0.Epoch, activeValidators int) int {
+	churnLimit := s.churnLimit(activeValidators)
+	if epoch >= s.chainTime.DenebInitialEpoch() &&
+		s.maxPerEpochActivationChurnLimit > 0 &&
+		uint64(churnLimit) > s.maxPerEpochActivationChurnLimit {
+		churnLimit = int(s.maxPerEpochActivationChurnLimit)
+	}
+
+	return churnLimit
+}
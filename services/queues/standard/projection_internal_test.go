@@ -0,0 +1,162 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+func TestProject(t *testing.T) {
+	s := &Service{
+		chainTime:                       mockchaintime.New(),
+		minPerEpochChurnLimit:           4,
+		churnLimitQuotient:              65536,
+		maxPerEpochActivationChurnLimit: 8,
+		maxSeedLookahead:                4,
+	}
+
+	// active returns the given number of active validators, starting at the given index.
+	active := func(start int, count int) []*chaindb.Validator {
+		validators := make([]*chaindb.Validator, count)
+		for i := range validators {
+			validators[i] = &chaindb.Validator{
+				Index:                      phase0.ValidatorIndex(start + i),
+				ActivationEligibilityEpoch: 0,
+				ActivationEpoch:            0,
+				ExitEpoch:                  farFutureEpoch,
+			}
+		}
+		return validators
+	}
+
+	// queued returns the given number of validators eligible at the given epoch, starting at the given index.
+	queued := func(start int, count int, eligibility phase0.Epoch) []*chaindb.Validator {
+		validators := make([]*chaindb.Validator, count)
+		for i := range validators {
+			validators[i] = &chaindb.Validator{
+				Index:                      phase0.ValidatorIndex(start + i),
+				ActivationEligibilityEpoch: eligibility,
+				ActivationEpoch:            farFutureEpoch,
+				ExitEpoch:                  farFutureEpoch,
+			}
+		}
+		return validators
+	}
+
+	epoch := func(epoch phase0.Epoch) *phase0.Epoch {
+		return &epoch
+	}
+
+	tests := []struct {
+		name                  string
+		validators            []*chaindb.Validator
+		activationQueueLength int
+		activationEpoch       phase0.Epoch
+		exitQueueLength       int
+		exitEpoch             phase0.Epoch
+		projections           map[phase0.ValidatorIndex]*chaindb.QueueProjection
+	}{
+		{
+			name:            "Empty",
+			validators:      active(0, 100),
+			activationEpoch: 17,
+			exitEpoch:       15,
+			projections:     map[phase0.ValidatorIndex]*chaindb.QueueProjection{},
+		},
+		{
+			name:                  "ActivationQueue",
+			validators:            append(active(0, 100), queued(100, 10, 5)...),
+			activationQueueLength: 10,
+			activationEpoch:       17,
+			exitEpoch:             15,
+			projections: map[phase0.ValidatorIndex]*chaindb.QueueProjection{
+				100: {Epoch: 10, ValidatorIndex: 100, ActivationEpoch: epoch(15)},
+				101: {Epoch: 10, ValidatorIndex: 101, ActivationEpoch: epoch(15)},
+				102: {Epoch: 10, ValidatorIndex: 102, ActivationEpoch: epoch(15)},
+				103: {Epoch: 10, ValidatorIndex: 103, ActivationEpoch: epoch(15)},
+				104: {Epoch: 10, ValidatorIndex: 104, ActivationEpoch: epoch(16)},
+				105: {Epoch: 10, ValidatorIndex: 105, ActivationEpoch: epoch(16)},
+				106: {Epoch: 10, ValidatorIndex: 106, ActivationEpoch: epoch(16)},
+				107: {Epoch: 10, ValidatorIndex: 107, ActivationEpoch: epoch(16)},
+				108: {Epoch: 10, ValidatorIndex: 108, ActivationEpoch: epoch(17)},
+				109: {Epoch: 10, ValidatorIndex: 109, ActivationEpoch: epoch(17)},
+			},
+		},
+		{
+			name: "PendingAndScheduled",
+			validators: append(active(0, 100),
+				&chaindb.Validator{Index: 100, ActivationEligibilityEpoch: 8, ActivationEpoch: 13, ExitEpoch: farFutureEpoch},
+				&chaindb.Validator{Index: 101, ActivationEligibilityEpoch: farFutureEpoch, ActivationEpoch: farFutureEpoch, ExitEpoch: farFutureEpoch},
+			),
+			activationQueueLength: 2,
+			activationEpoch:       17,
+			exitEpoch:             15,
+			projections: map[phase0.ValidatorIndex]*chaindb.QueueProjection{
+				100: {Epoch: 10, ValidatorIndex: 100, ActivationEpoch: epoch(13)},
+				101: {Epoch: 10, ValidatorIndex: 101, ActivationEpoch: epoch(17)},
+			},
+		},
+		{
+			name: "ExitQueue",
+			validators: append(active(0, 100),
+				&chaindb.Validator{Index: 100, ActivationEpoch: 0, ExitEpoch: 5},
+				&chaindb.Validator{Index: 101, ActivationEpoch: 0, ExitEpoch: 20},
+				&chaindb.Validator{Index: 102, ActivationEpoch: 0, ExitEpoch: 20},
+				&chaindb.Validator{Index: 103, ActivationEpoch: 0, ExitEpoch: 20},
+				&chaindb.Validator{Index: 104, ActivationEpoch: 0, ExitEpoch: 20},
+			),
+			activationEpoch: 17,
+			exitQueueLength: 4,
+			exitEpoch:       21,
+			projections: map[phase0.ValidatorIndex]*chaindb.QueueProjection{
+				101: {Epoch: 10, ValidatorIndex: 101, ExitEpoch: epoch(20)},
+				102: {Epoch: 10, ValidatorIndex: 102, ExitEpoch: epoch(20)},
+				103: {Epoch: 10, ValidatorIndex: 103, ExitEpoch: epoch(20)},
+				104: {Epoch: 10, ValidatorIndex: 104, ExitEpoch: epoch(20)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := s.project(10, test.validators)
+			require.Equal(t, len(test.validators), res.validators)
+			require.Equal(t, 4, res.activationChurnLimit)
+			require.Equal(t, 4, res.exitChurnLimit)
+			require.Equal(t, test.activationQueueLength, res.activationQueueLength)
+			require.Equal(t, test.activationEpoch, res.activationEpoch)
+			require.Equal(t, test.exitQueueLength, res.exitQueueLength)
+			require.Equal(t, test.exitEpoch, res.exitEpoch)
+			require.Equal(t, test.projections, res.validatorProjections)
+		})
+	}
+}
+
+func TestActivationChurnLimit(t *testing.T) {
+	s := &Service{
+		chainTime:                       mockchaintime.New(),
+		minPerEpochChurnLimit:           4,
+		churnLimitQuotient:              65536,
+		maxPerEpochActivationChurnLimit: 8,
+	}
+
+	require.Equal(t, 4, s.activationChurnLimit(1, 100))
+	require.Equal(t, 8, s.activationChurnLimit(1, 1000000))
+	require.Equal(t, 15, s.churnLimit(1000000))
+}
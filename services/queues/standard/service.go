@@ -0,0 +1,348 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/queues"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel"
+	"golang.org/x/sync/semaphore"
+)
+
+// ErrNotFound is returned when projections are not available.
+var ErrNotFound = errors.New("not found")
+
+var farFutureEpoch = phase0.Epoch(0xffffffffffffffff)
+
+// Service is a queues service.
+// It projects the activation and exit epochs of validators from the current
+// validator set and churn limits, and stores snapshots of the projections so
+// that their accuracy can be measured.  It also forecasts when exited
+// validators will be swept for their full withdrawals.
+type Service struct {
+	chainDB                          chaindb.Service
+	chainTime                        chaintime.Service
+	validatorsProvider               chaindb.ValidatorsProvider
+	queueProjectionsProvider         chaindb.QueueProjectionsProvider
+	queueProjectionsSetter           chaindb.QueueProjectionsSetter
+	snapshotInterval                 uint64
+	minPerEpochChurnLimit            uint64
+	churnLimitQuotient               uint64
+	maxPerEpochActivationChurnLimit  uint64
+	maxSeedLookahead                 uint64
+	withdrawalsProvider              chaindb.WithdrawalsProvider
+	withdrawalForecastsProvider      chaindb.WithdrawalForecastsProvider
+	withdrawalForecastsSetter        chaindb.WithdrawalForecastsSetter
+	maxEffectiveBalance              phase0.Gwei
+	maxWithdrawalsPerPayload         uint64
+	maxValidatorsPerWithdrawalsSweep uint64
+	projection                       atomic.Pointer[projection]
+	activitySem                      *semaphore.Weighted
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("queues", "standard", parameters.logLevel)
+
+	validatorsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validators")
+	}
+	queueProjectionsProvider, isProvider := parameters.chainDB.(chaindb.QueueProjectionsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide queue projections")
+	}
+	queueProjectionsSetter, isSetter := parameters.chainDB.(chaindb.QueueProjectionsSetter)
+	if !isSetter {
+		return nil, errors.New("chain DB does not support queue projections")
+	}
+	withdrawalsProvider, isProvider := parameters.chainDB.(chaindb.WithdrawalsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide withdrawals")
+	}
+	withdrawalForecastsProvider, isProvider := parameters.chainDB.(chaindb.WithdrawalForecastsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide withdrawal forecasts")
+	}
+	withdrawalForecastsSetter, isSetter := parameters.chainDB.(chaindb.WithdrawalForecastsSetter)
+	if !isSetter {
+		return nil, errors.New("chain DB does not support withdrawal forecasts")
+	}
+
+	spec, err := parameters.chainDB.(chaindb.ChainSpecProvider).ChainSpec(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain chain specification")
+	}
+	if spec.MinPerEpochChurnLimit == 0 {
+		return nil, errors.New("failed to obtain MIN_PER_EPOCH_CHURN_LIMIT")
+	}
+	if spec.ChurnLimitQuotient == 0 {
+		return nil, errors.New("failed to obtain CHURN_LIMIT_QUOTIENT")
+	}
+	if spec.MaxSeedLookahead == 0 {
+		return nil, errors.New("failed to obtain MAX_SEED_LOOKAHEAD")
+	}
+	// MAX_PER_EPOCH_ACTIVATION_CHURN_LIMIT was introduced in Deneb, so may not be present.
+	// Withdrawal values were introduced in Capella, so may not be present, in
+	// which case withdrawals are not forecast.
+	maxWithdrawalsPerPayload := spec.MaxWithdrawalsPerPayload
+	if maxWithdrawalsPerPayload == 0 || spec.MaxValidatorsPerWithdrawalsSweep == 0 {
+		log.Debug().Msg("Withdrawals not in chain specification; not forecasting withdrawals")
+		maxWithdrawalsPerPayload = 0
+	}
+
+	s := &Service{
+		chainDB:                          parameters.chainDB,
+		chainTime:                        parameters.chainTime,
+		validatorsProvider:               validatorsProvider,
+		queueProjectionsProvider:         queueProjectionsProvider,
+		queueProjectionsSetter:           queueProjectionsSetter,
+		snapshotInterval:                 parameters.snapshotInterval,
+		minPerEpochChurnLimit:            spec.MinPerEpochChurnLimit,
+		churnLimitQuotient:               spec.ChurnLimitQuotient,
+		maxPerEpochActivationChurnLimit:  spec.MaxPerEpochActivationChurnLimit,
+		maxSeedLookahead:                 spec.MaxSeedLookahead,
+		withdrawalsProvider:              withdrawalsProvider,
+		withdrawalForecastsProvider:      withdrawalForecastsProvider,
+		withdrawalForecastsSetter:        withdrawalForecastsSetter,
+		maxEffectiveBalance:              spec.MaxEffectiveBalance,
+		maxWithdrawalsPerPayload:         maxWithdrawalsPerPayload,
+		maxValidatorsPerWithdrawalsSweep: spec.MaxValidatorsPerWithdrawalsSweep,
+		activitySem:                      semaphore.NewWeighted(1),
+	}
+
+	// Update once per epoch.
+	runtimeFunc := func(ctx context.Context, data any) (time.Time, error) {
+		return s.chainTime.StartOfEpoch(s.chainTime.CurrentEpoch() + 1), nil
+	}
+	jobFunc := func(ctx context.Context, data any) {
+		s := data.(*Service)
+		s.update(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "queues", "update",
+		runtimeFunc,
+		nil,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic update")
+	}
+
+	// Project immediately, rather than waiting for the next epoch.
+	go s.update(ctx)
+
+	if parameters.listenAddress != "" {
+		s.serve(ctx, parameters.listenAddress)
+	}
+
+	return s, nil
+}
+
+// update projects the queues for the current epoch, storing a snapshot of the
+// projections if one is due.
+func (s *Service) update(ctx context.Context) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.queues.standard").Start(ctx, "update")
+	defer span.End()
+
+	// Only allow 1 handler to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another handler running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	epoch := s.chainTime.CurrentEpoch()
+	validators, err := s.validatorsProvider.Validators(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain validators")
+		return
+	}
+
+	projection := s.project(epoch, validators)
+	s.projection.Store(projection)
+	log.Trace().Uint64("epoch", uint64(epoch)).Int("activation_queue_length", projection.activationQueueLength).Int("exit_queue_length", projection.exitQueueLength).Msg("Projected queues")
+
+	if err := s.updateWithdrawalForecasts(ctx, epoch, validators); err != nil {
+		log.Error().Uint64("epoch", uint64(epoch)).Err(err).Msg("Failed to forecast withdrawals")
+	}
+
+	if s.snapshotInterval == 0 || uint64(epoch)%s.snapshotInterval != 0 {
+		return
+	}
+	if err := s.storeSnapshot(ctx, projection); err != nil {
+		log.Error().Uint64("epoch", uint64(epoch)).Err(err).Msg("Failed to store queue projections")
+		return
+	}
+	log.Debug().Uint64("epoch", uint64(epoch)).Int("projections", len(projection.validatorProjections)).Msg("Stored queue projections")
+}
+
+// storeSnapshot stores the validator projections.
+func (s *Service) storeSnapshot(ctx context.Context, projection *projection) error {
+	validatorProjections := make([]*chaindb.QueueProjection, 0, len(projection.validatorProjections))
+	for _, validatorProjection := range projection.validatorProjections {
+		validatorProjections = append(validatorProjections, validatorProjection)
+	}
+	sort.Slice(validatorProjections, func(i int, j int) bool {
+		return validatorProjections[i].ValidatorIndex < validatorProjections[j].ValidatorIndex
+	})
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := s.queueProjectionsSetter.SetQueueProjections(ctx, validatorProjections); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set queue projections")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// Queues provides the state of the activation and exit queues.
+func (s *Service) Queues(_ context.Context) (*queues.Queues, error) {
+	projection := s.projection.Load()
+	if projection == nil {
+		return nil, errors.Wrap(ErrNotFound, "queues not yet projected")
+	}
+
+	return &queues.Queues{
+		Epoch:                 projection.epoch,
+		ActivationQueueLength: projection.activationQueueLength,
+		ActivationChurnLimit:  projection.activationChurnLimit,
+		ActivationEpoch:       projection.activationEpoch,
+		ActivationTime:        s.chainTime.StartOfEpoch(projection.activationEpoch),
+		ExitQueueLength:       projection.exitQueueLength,
+		ExitChurnLimit:        projection.exitChurnLimit,
+		ExitEpoch:             projection.exitEpoch,
+		ExitTime:              s.chainTime.StartOfEpoch(projection.exitEpoch),
+	}, nil
+}
+
+// ValidatorProjection provides the projected activation and exit of the given validator.
+func (s *Service) ValidatorProjection(_ context.Context, index phase0.ValidatorIndex) (*queues.ValidatorProjection, error) {
+	projection := s.projection.Load()
+	if projection == nil {
+		return nil, errors.Wrap(ErrNotFound, "queues not yet projected")
+	}
+	if int(index) >= projection.validators {
+		return nil, errors.Wrap(ErrNotFound, "unknown validator")
+	}
+
+	res := &queues.ValidatorProjection{
+		Epoch: projection.epoch,
+		Index: index,
+	}
+	if validatorProjection, exists := projection.validatorProjections[index]; exists {
+		if validatorProjection.ActivationEpoch != nil {
+			res.ActivationEpoch = validatorProjection.ActivationEpoch
+			activationTime := s.chainTime.StartOfEpoch(*validatorProjection.ActivationEpoch)
+			res.ActivationTime = &activationTime
+		}
+		if validatorProjection.ExitEpoch != nil {
+			res.ExitEpoch = validatorProjection.ExitEpoch
+			exitTime := s.chainTime.StartOfEpoch(*validatorProjection.ExitEpoch)
+			res.ExitTime = &exitTime
+		}
+	}
+
+	return res, nil
+}
+
+// WithdrawalForecast provides the forecast full withdrawal of the given validator.
+func (s *Service) WithdrawalForecast(ctx context.Context, index phase0.ValidatorIndex) (*queues.WithdrawalForecast, error) {
+	forecasts, err := s.withdrawalForecastsProvider.WithdrawalForecasts(ctx, &chaindb.WithdrawalForecastFilter{
+		ValidatorIndices: []phase0.ValidatorIndex{index},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain withdrawal forecast")
+	}
+	if len(forecasts) == 0 {
+		return nil, errors.Wrap(ErrNotFound, "no withdrawal forecast for validator")
+	}
+
+	res := &queues.WithdrawalForecast{
+		Epoch:             forecasts[0].Epoch,
+		Index:             forecasts[0].ValidatorIndex,
+		WithdrawableEpoch: forecasts[0].WithdrawableEpoch,
+		Slot:              forecasts[0].Slot,
+	}
+	if forecasts[0].Slot != nil {
+		slotTime := s.chainTime.StartOfSlot(*forecasts[0].Slot)
+		res.Time = &slotTime
+	}
+
+	return res, nil
+}
+
+// ProjectionAccuracy provides the accuracy of the activation projections
+// stored between the given epochs.
+func (s *Service) ProjectionAccuracy(ctx context.Context, from phase0.Epoch, to phase0.Epoch) ([]*chaindb.QueueProjectionAccuracy, error) {
+	return s.queueProjectionsProvider.QueueProjectionAccuracy(ctx, from, to, s.chainTime.CurrentEpoch())
+}
+
+// serve serves projections over HTTP.
+func (s *Service) serve(ctx context.Context, listenAddress string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /queues", s.handleQueues)
+	mux.HandleFunc("GET /queues/validators/{validator_index}", s.handleValidatorProjection)
+	mux.HandleFunc("GET /queues/accuracy", s.handleProjectionAccuracy)
+	mux.HandleFunc("GET /queues/withdrawals/{validator_index}", s.handleWithdrawalForecast)
+
+	server := &http.Server{
+		Addr:              listenAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Info().Str("listen_address", listenAddress).Msg("Starting queues server")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warn().Str("listen_address", listenAddress).Err(err).Msg("Failed to run queues server")
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Warn().Err(err).Msg("Failed to shut down queues server")
+		}
+	}()
+}
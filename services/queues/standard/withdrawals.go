@@ -0,0 +1,200 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// updateWithdrawalForecasts forecasts the full withdrawals of exited
+// validators from the latest indexed withdrawal, and stores the forecasts.
+func (s *Service) updateWithdrawalForecasts(ctx context.Context, epoch phase0.Epoch, validators []*chaindb.Validator) error {
+	if s.maxWithdrawalsPerPayload == 0 || epoch < s.chainTime.CapellaInitialEpoch() {
+		// Withdrawals are not available.
+		return nil
+	}
+
+	withdrawals, err := s.withdrawalsProvider.Withdrawals(ctx, &chaindb.WithdrawalFilter{
+		Limit: 1,
+		Order: chaindb.OrderLatest,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain latest withdrawal")
+	}
+	if len(withdrawals) == 0 {
+		log.Trace().Msg("No withdrawals from which to forecast the sweep")
+		return nil
+	}
+
+	// The sweep continues after the validator of the latest withdrawal.
+	latest := withdrawals[0]
+	forecasts := s.forecastWithdrawals(epoch, validators, latest.ValidatorIndex+1, latest.InclusionSlot+1)
+	if len(forecasts) == 0 {
+		return nil
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := s.withdrawalForecastsSetter.SetWithdrawalForecasts(ctx, forecasts); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set withdrawal forecasts")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	log.Trace().Uint64("epoch", uint64(epoch)).Int("forecasts", len(forecasts)).Msg("Stored withdrawal forecasts")
+
+	return nil
+}
+
+// forecastWithdrawals forecasts the slots at which exited validators that
+// still hold a balance will be swept for their full withdrawals.
+// The withdrawal sweep is simulated slot by slot, starting at the given
+// validator index at the given slot, assuming that every slot has a block.
+// Validators with execution withdrawal credentials and the maximum effective
+// balance that have been active are assumed to have an excess balance, and so
+// to be partially withdrawn whenever the sweep reaches them.
+func (s *Service) forecastWithdrawals(epoch phase0.Epoch,
+	validators []*chaindb.Validator,
+	nextIndex phase0.ValidatorIndex,
+	slot phase0.Slot,
+) []*chaindb.WithdrawalForecast {
+	res := make([]*chaindb.WithdrawalForecast, 0)
+	validatorCount := uint64(len(validators))
+	if validatorCount == 0 {
+		return res
+	}
+
+	// eligible holds the sorted indices of the validators that are withdrawn
+	// when the sweep reaches them.
+	eligible := make([]uint64, 0)
+	// pending holds the exited validators that can be swept, in order of
+	// withdrawable epoch.
+	pending := make([]*chaindb.Validator, 0)
+	forecasts := make(map[phase0.ValidatorIndex]*chaindb.WithdrawalForecast)
+	for _, validator := range validators {
+		executionCredentials := validator.WithdrawalCredentials[0] == chaindb.WithdrawalCredentialsExecution ||
+			validator.WithdrawalCredentials[0] == chaindb.WithdrawalCredentialsCompounding
+		if validator.ExitEpoch != farFutureEpoch &&
+			validator.WithdrawableEpoch != farFutureEpoch &&
+			validator.EffectiveBalance > 0 {
+			forecast := &chaindb.WithdrawalForecast{
+				Epoch:             epoch,
+				ValidatorIndex:    validator.Index,
+				WithdrawableEpoch: validator.WithdrawableEpoch,
+			}
+			res = append(res, forecast)
+			if executionCredentials {
+				forecasts[validator.Index] = forecast
+				pending = append(pending, validator)
+			}
+		}
+		if validator.WithdrawalCredentials[0] == chaindb.WithdrawalCredentialsExecution &&
+			validator.EffectiveBalance == s.maxEffectiveBalance &&
+			validator.ActivationEpoch <= epoch {
+			eligible = append(eligible, uint64(validator.Index))
+		}
+	}
+	if len(pending) == 0 {
+		return res
+	}
+	sort.Slice(eligible, func(i int, j int) bool {
+		return eligible[i] < eligible[j]
+	})
+	sort.SliceStable(pending, func(i int, j int) bool {
+		return pending[i].WithdrawableEpoch < pending[j].WithdrawableEpoch
+	})
+
+	// Once the last pending validator is withdrawable, a full sweep of the
+	// validators takes at most one slot for each maximum payload of withdrawals.
+	slotsPerEpoch := s.chainTime.SlotsPerEpoch()
+	lastSlot := slot
+	if lastWithdrawableSlot := phase0.Slot(uint64(pending[len(pending)-1].WithdrawableEpoch) * slotsPerEpoch); lastWithdrawableSlot > lastSlot {
+		lastSlot = lastWithdrawableSlot
+	}
+	lastSlot += phase0.Slot(validatorCount/s.maxWithdrawalsPerPayload + 1)
+
+	sweepSize := s.maxValidatorsPerWithdrawalsSweep
+	if sweepSize > validatorCount {
+		sweepSize = validatorCount
+	}
+	position := uint64(nextIndex) % validatorCount
+	released := 0
+	remaining := len(forecasts)
+	for ; remaining > 0 && slot <= lastSlot; slot++ {
+		slotEpoch := phase0.Epoch(uint64(slot) / slotsPerEpoch)
+		for released < len(pending) && pending[released].WithdrawableEpoch <= slotEpoch {
+			eligible = insertIndex(eligible, uint64(pending[released].Index))
+			released++
+		}
+
+		withdrawals := uint64(0)
+		scanned := uint64(0)
+		current := position
+		for withdrawals < s.maxWithdrawalsPerPayload && len(eligible) > 0 {
+			i := sort.Search(len(eligible), func(i int) bool { return eligible[i] >= current })
+			if i == len(eligible) {
+				// Wrap around to the start of the validators.
+				i = 0
+			}
+			index := eligible[i]
+			distance := (index + validatorCount - current) % validatorCount
+			if scanned+distance >= sweepSize {
+				break
+			}
+			scanned += distance + 1
+			withdrawals++
+			current = (index + 1) % validatorCount
+
+			forecast, exists := forecasts[phase0.ValidatorIndex(index)]
+			if exists && forecast.WithdrawableEpoch <= slotEpoch {
+				forecastSlot := slot
+				forecast.Slot = &forecastSlot
+				delete(forecasts, phase0.ValidatorIndex(index))
+				remaining--
+				// A fully withdrawn validator has no further balance to withdraw.
+				eligible = append(eligible[:i], eligible[i+1:]...)
+			}
+		}
+
+		if withdrawals == s.maxWithdrawalsPerPayload {
+			position = current
+		} else {
+			position = (position + sweepSize) % validatorCount
+		}
+	}
+
+	return res
+}
+
+// insertIndex inserts an index in to a sorted list of indices, if not already present.
+func insertIndex(indices []uint64, index uint64) []uint64 {
+	i := sort.Search(len(indices), func(i int) bool { return indices[i] >= index })
+	if i < len(indices) && indices[i] == index {
+		return indices
+	}
+	indices = append(indices, 0)
+	copy(indices[i+1:], indices[i:])
+	indices[i] = index
+
+	return indices
+}
@@ -0,0 +1,139 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+func TestForecastWithdrawals(t *testing.T) {
+	s := &Service{
+		chainTime:                        mockchaintime.New(),
+		maxEffectiveBalance:              32000000000,
+		maxWithdrawalsPerPayload:         4,
+		maxValidatorsPerWithdrawalsSweep: 8,
+	}
+
+	// validators returns the given number of active validators with the given
+	// withdrawal credentials prefix and effective balance.
+	validators := func(count int, prefix byte, effectiveBalance phase0.Gwei) []*chaindb.Validator {
+		res := make([]*chaindb.Validator, count)
+		for i := range res {
+			res[i] = &chaindb.Validator{
+				Index:             phase0.ValidatorIndex(i),
+				EffectiveBalance:  effectiveBalance,
+				ActivationEpoch:   0,
+				ExitEpoch:         farFutureEpoch,
+				WithdrawableEpoch: farFutureEpoch,
+			}
+			res[i].WithdrawalCredentials[0] = prefix
+		}
+		return res
+	}
+	// exit marks the validator as exited, withdrawable at the given epoch.
+	exit := func(validators []*chaindb.Validator, index int, withdrawableEpoch phase0.Epoch) []*chaindb.Validator {
+		validators[index].ExitEpoch = 1
+		validators[index].WithdrawableEpoch = withdrawableEpoch
+		return validators
+	}
+	slot := func(slot phase0.Slot) *phase0.Slot {
+		return &slot
+	}
+
+	tests := []struct {
+		name       string
+		validators []*chaindb.Validator
+		nextIndex  phase0.ValidatorIndex
+		slot       phase0.Slot
+		expected   []*chaindb.WithdrawalForecast
+	}{
+		{
+			name:       "None",
+			validators: validators(32, chaindb.WithdrawalCredentialsExecution, 32000000000),
+			expected:   []*chaindb.WithdrawalForecast{},
+		},
+		{
+			name:       "Withdrawable",
+			validators: exit(validators(32, chaindb.WithdrawalCredentialsExecution, 32000000000), 5, 0),
+			expected: []*chaindb.WithdrawalForecast{
+				{Epoch: 10, ValidatorIndex: 5, WithdrawableEpoch: 0, Slot: slot(1)},
+			},
+		},
+		{
+			name:       "StartPosition",
+			validators: exit(validators(32, chaindb.WithdrawalCredentialsExecution, 32000000000), 5, 0),
+			nextIndex:  6,
+			slot:       100,
+			expected: []*chaindb.WithdrawalForecast{
+				{Epoch: 10, ValidatorIndex: 5, WithdrawableEpoch: 0, Slot: slot(107)},
+			},
+		},
+		{
+			name:       "NotYetWithdrawable",
+			validators: exit(validators(32, chaindb.WithdrawalCredentialsExecution, 32000000000), 5, 1),
+			expected: []*chaindb.WithdrawalForecast{
+				// Partially withdrawn at slots 1 and 9, fully withdrawn at slot 17 in epoch 1.
+				{Epoch: 10, ValidatorIndex: 5, WithdrawableEpoch: 1, Slot: slot(17)},
+			},
+		},
+		{
+			name:       "BLSCredentials",
+			validators: exit(validators(32, chaindb.WithdrawalCredentialsBLS, 32000000000), 7, 0),
+			expected: []*chaindb.WithdrawalForecast{
+				{Epoch: 10, ValidatorIndex: 7, WithdrawableEpoch: 0},
+			},
+		},
+		{
+			name: "Sparse",
+			validators: func() []*chaindb.Validator {
+				res := exit(validators(32, chaindb.WithdrawalCredentialsBLS, 32000000000), 20, 0)
+				res[20].WithdrawalCredentials[0] = chaindb.WithdrawalCredentialsExecution
+				return res
+			}(),
+			expected: []*chaindb.WithdrawalForecast{
+				// No withdrawals, so each slot sweeps 8 validators.
+				{Epoch: 10, ValidatorIndex: 20, WithdrawableEpoch: 0, Slot: slot(2)},
+			},
+		},
+		{
+			name: "AlreadyWithdrawn",
+			validators: func() []*chaindb.Validator {
+				res := exit(validators(32, chaindb.WithdrawalCredentialsExecution, 32000000000), 5, 0)
+				res[5].EffectiveBalance = 0
+				return res
+			}(),
+			expected: []*chaindb.WithdrawalForecast{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := s.forecastWithdrawals(10, test.validators, test.nextIndex, test.slot)
+			require.Equal(t, test.expected, res)
+		})
+	}
+}
+
+func TestInsertIndex(t *testing.T) {
+	require.Equal(t, []uint64{1}, insertIndex([]uint64{}, 1))
+	require.Equal(t, []uint64{1, 2, 3}, insertIndex([]uint64{1, 3}, 2))
+	require.Equal(t, []uint64{1, 3}, insertIndex([]uint64{1, 3}, 3))
+	require.Equal(t, []uint64{0, 1, 3}, insertIndex([]uint64{1, 3}, 0))
+	require.Equal(t, []uint64{1, 3, 4}, insertIndex([]uint64{1, 3}, 4))
+}
@@ -0,0 +1,64 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+type blockReceiptsResponse struct {
+	Result []*receipt     `json:"result"`
+	Error  *responseError `json:"error"`
+}
+
+type responseError struct {
+	Code    int64  `json:"code"`
+	Message string `json:"message"`
+}
+
+// blockReceipts fetches the transaction receipts for an execution block given its hash.
+// It returns nil if the execution client does not know of the block.
+func (s *Service) blockReceipts(ctx context.Context, blockHash [32]byte) ([]*receipt, error) {
+	reference, err := url.Parse("")
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid endpoint")
+	}
+	url := s.base.ResolveReference(reference).String()
+
+	reqBody := bytes.NewBufferString(fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getBlockReceipts","params":["%#x"],"id":1901}`, blockHash))
+	respBodyReader, err := s.post(ctx, url, reqBody)
+	if err != nil {
+		log.Trace().Str("url", url).Err(err).Msg("Request failed")
+		return nil, errors.Wrap(err, "request failed")
+	}
+	if respBodyReader == nil {
+		return nil, errors.New("empty response")
+	}
+
+	var response blockReceiptsResponse
+	if err := json.NewDecoder(respBodyReader).Decode(&response); err != nil {
+		return nil, errors.Wrap(err, "invalid response")
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("request failed with code %d: %s", response.Error.Code, response.Error.Message)
+	}
+
+	return response.Result, nil
+}
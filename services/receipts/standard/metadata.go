@@ -0,0 +1,55 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// metadata stored about this service.
+type metadata struct {
+	LatestSlot int64
+}
+
+// progressService is the name of this service for progress.
+var progressService = "receipts.standard"
+
+// getMetadata gets metadata for this service.
+func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
+	md := &metadata{
+		LatestSlot: -1,
+	}
+	progress, err := s.chainDB.Progress(ctx, progressService)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch progress")
+	}
+	if progress == nil {
+		return md, nil
+	}
+	if val, exists := progress.Values["latest_slot"]; exists {
+		md.LatestSlot = val
+	}
+
+	return md, nil
+}
+
+// setMetadata sets metadata for this service.
+func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
+	if err := s.chainDB.SetProgress(ctx, progressService, "latest_slot", md.LatestSlot); err != nil {
+		return errors.Wrap(err, "failed to update latest slot")
+	}
+	return nil
+}
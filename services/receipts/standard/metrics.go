@@ -0,0 +1,101 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_receipts"
+
+var (
+	latestSlot      prometheus.Gauge
+	blocksProcessed prometheus.Counter
+	receiptsStored  prometheus.Counter
+	eventsStored    prometheus.Counter
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if latestSlot != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	latestSlot = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "latest_slot",
+		Help:      "Latest slot for which receipts have been fetched",
+	})
+	if err := prometheus.Register(latestSlot); err != nil {
+		return errors.Wrap(err, "failed to register latest_slot")
+	}
+
+	blocksProcessed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "blocks_processed",
+		Help:      "Number of execution blocks for which receipts have been fetched",
+	})
+	if err := prometheus.Register(blocksProcessed); err != nil {
+		return errors.Wrap(err, "failed to register blocks_processed")
+	}
+
+	receiptsStored = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "receipts_stored",
+		Help:      "Number of transaction receipts stored",
+	})
+	if err := prometheus.Register(receiptsStored); err != nil {
+		return errors.Wrap(err, "failed to register receipts_stored")
+	}
+
+	eventsStored = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "events_stored",
+		Help:      "Number of transaction events stored",
+	})
+	if err := prometheus.Register(eventsStored); err != nil {
+		return errors.Wrap(err, "failed to register events_stored")
+	}
+
+	return nil
+}
+
+// monitorLatestSlot sets the latest slot.
+func monitorLatestSlot(slot int64) {
+	if latestSlot != nil {
+		latestSlot.Set(float64(slot))
+	}
+}
+
+func monitorBlockProcessed(receipts int, events int) {
+	if blocksProcessed != nil {
+		blocksProcessed.Inc()
+		receiptsStored.Add(float64(receipts))
+		eventsStored.Add(float64(events))
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+type parameters struct {
+	logLevel       zerolog.Level
+	monitor        metrics.Service
+	connectionURL  string
+	timeout        time.Duration
+	chainDB        chaindb.Service
+	chainTime      chaintime.Service
+	scheduler      scheduler.Service
+	startSlot      int64
+	eventAddresses []string
+	eventTopics    []string
+	relays         []string
+	addresses      [][20]byte
+	topics         [][32]byte
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(p *parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithConnectionURL sets the connection URL for the execution client.
+func WithConnectionURL(connectionURL string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.connectionURL = connectionURL
+	})
+}
+
+// WithTimeout sets the timeout for requests to the execution client.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithScheduler sets the scheduler for this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
+// WithStartSlot sets the slot from which to (re-)fetch receipts.
+func WithStartSlot(startSlot int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.startSlot = startSlot
+	})
+}
+
+// WithEventAddresses sets the contract addresses for which to store events.
+// If empty then events from all addresses are stored.
+func WithEventAddresses(addresses []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eventAddresses = addresses
+	})
+}
+
+// WithEventTopics sets the first topics for which to store events.
+// If empty then events with any topic are stored.
+func WithEventTopics(topics []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eventTopics = topics
+	})
+}
+
+// WithRelays sets the addresses of relays from which to obtain the declared values
+// of execution payloads that they delivered.
+func WithRelays(relays []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.relays = relays
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:  zerolog.GlobalLevel(),
+		timeout:   30 * time.Second,
+		startSlot: -1,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.connectionURL == "" {
+		return nil, errors.New("no connection URL specified")
+	}
+	if parameters.timeout == 0 {
+		return nil, errors.New("no timeout specified")
+	}
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified")
+	}
+
+	parameters.addresses = make([][20]byte, 0, len(parameters.eventAddresses))
+	for _, address := range parameters.eventAddresses {
+		data, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+		if err != nil || len(data) != 20 {
+			return nil, fmt.Errorf("invalid event address %q", address)
+		}
+		parameters.addresses = append(parameters.addresses, [20]byte(data))
+	}
+
+	parameters.topics = make([][32]byte, 0, len(parameters.eventTopics))
+	for _, topic := range parameters.eventTopics {
+		data, err := hex.DecodeString(strings.TrimPrefix(topic, "0x"))
+		if err != nil || len(data) != 32 {
+			return nil, fmt.Errorf("invalid event topic %q", topic)
+		}
+		parameters.topics = append(parameters.topics, [32]byte(data))
+	}
+
+	return &parameters, nil
+}
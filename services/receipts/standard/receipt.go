@@ -0,0 +1,225 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// receipt is a transaction receipt as returned by the execution client.
+type receipt struct {
+	BlockHash        [32]byte
+	BlockNumber      uint64
+	TransactionHash  [32]byte
+	TransactionIndex uint32
+	From             [20]byte
+	To               *[20]byte
+	ContractAddress  *[20]byte
+	Status           uint8
+	GasUsed          uint64
+	// EffectiveGasPrice is nil if not supplied by the execution client.
+	EffectiveGasPrice *big.Int
+	Logs              []*receiptLog
+}
+
+// receiptLog is a log in a transaction receipt as returned by the execution client.
+type receiptLog struct {
+	Address  [20]byte
+	Topics   [][32]byte
+	Data     []byte
+	LogIndex uint32
+}
+
+//nolint:tagliatelle
+type receiptJSON struct {
+	BlockHash         string             `json:"blockHash"`
+	BlockNumber       string             `json:"blockNumber"`
+	TransactionHash   string             `json:"transactionHash"`
+	TransactionIndex  string             `json:"transactionIndex"`
+	From              string             `json:"from"`
+	To                string             `json:"to"`
+	ContractAddress   string             `json:"contractAddress"`
+	Status            string             `json:"status"`
+	GasUsed           string             `json:"gasUsed"`
+	EffectiveGasPrice string             `json:"effectiveGasPrice"`
+	Logs              []*json.RawMessage `json:"logs"`
+}
+
+//nolint:tagliatelle
+type receiptLogJSON struct {
+	Address  string   `json:"address"`
+	Topics   []string `json:"topics"`
+	Data     string   `json:"data"`
+	LogIndex string   `json:"logIndex"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *receipt) UnmarshalJSON(input []byte) error {
+	var data receiptJSON
+	if err := json.Unmarshal(input, &data); err != nil {
+		return errors.Wrap(err, "invalid JSON")
+	}
+
+	var err error
+	if r.BlockHash, err = decodeHash(data.BlockHash); err != nil {
+		return errors.Wrap(err, "block hash")
+	}
+	if r.BlockNumber, err = decodeQuantity(data.BlockNumber); err != nil {
+		return errors.Wrap(err, "block number")
+	}
+	if r.TransactionHash, err = decodeHash(data.TransactionHash); err != nil {
+		return errors.Wrap(err, "transaction hash")
+	}
+	transactionIndex, err := decodeQuantity(data.TransactionIndex)
+	if err != nil {
+		return errors.Wrap(err, "transaction index")
+	}
+	r.TransactionIndex = uint32(transactionIndex)
+	if r.From, err = decodeAddress(data.From); err != nil {
+		return errors.Wrap(err, "from")
+	}
+	if data.To != "" {
+		to, err := decodeAddress(data.To)
+		if err != nil {
+			return errors.Wrap(err, "to")
+		}
+		r.To = &to
+	}
+	if data.ContractAddress != "" {
+		contractAddress, err := decodeAddress(data.ContractAddress)
+		if err != nil {
+			return errors.Wrap(err, "contract address")
+		}
+		r.ContractAddress = &contractAddress
+	}
+	status, err := decodeQuantity(data.Status)
+	if err != nil {
+		return errors.Wrap(err, "status")
+	}
+	r.Status = uint8(status)
+	if r.GasUsed, err = decodeQuantity(data.GasUsed); err != nil {
+		return errors.Wrap(err, "gas used")
+	}
+	if data.EffectiveGasPrice != "" {
+		if r.EffectiveGasPrice, err = decodeBigQuantity(data.EffectiveGasPrice); err != nil {
+			return errors.Wrap(err, "effective gas price")
+		}
+	}
+
+	r.Logs = make([]*receiptLog, len(data.Logs))
+	for i := range data.Logs {
+		if data.Logs[i] == nil {
+			return errors.New("log missing")
+		}
+		r.Logs[i] = &receiptLog{}
+		if err := json.Unmarshal(*data.Logs[i], r.Logs[i]); err != nil {
+			return errors.Wrapf(err, "log %d", i)
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *receiptLog) UnmarshalJSON(input []byte) error {
+	var data receiptLogJSON
+	if err := json.Unmarshal(input, &data); err != nil {
+		return errors.Wrap(err, "invalid JSON")
+	}
+
+	var err error
+	if l.Address, err = decodeAddress(data.Address); err != nil {
+		return errors.Wrap(err, "address")
+	}
+	l.Topics = make([][32]byte, len(data.Topics))
+	for i := range data.Topics {
+		if l.Topics[i], err = decodeHash(data.Topics[i]); err != nil {
+			return errors.Wrap(err, "topic")
+		}
+	}
+	if l.Data, err = hex.DecodeString(strings.TrimPrefix(data.Data, "0x")); err != nil {
+		return errors.Wrap(err, "invalid value for data")
+	}
+	logIndex, err := decodeQuantity(data.LogIndex)
+	if err != nil {
+		return errors.Wrap(err, "log index")
+	}
+	l.LogIndex = uint32(logIndex)
+
+	return nil
+}
+
+func decodeQuantity(input string) (uint64, error) {
+	if input == "" {
+		return 0, errors.New("missing")
+	}
+	val, err := strconv.ParseUint(strings.TrimPrefix(input, "0x"), 16, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid value")
+	}
+
+	return val, nil
+}
+
+func decodeBigQuantity(input string) (*big.Int, error) {
+	if input == "" {
+		return nil, errors.New("missing")
+	}
+	val, ok := new(big.Int).SetString(strings.TrimPrefix(input, "0x"), 16)
+	if !ok {
+		return nil, errors.New("invalid value")
+	}
+
+	return val, nil
+}
+
+func decodeHash(input string) ([32]byte, error) {
+	var res [32]byte
+	if input == "" {
+		return res, errors.New("missing")
+	}
+	data, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil {
+		return res, errors.Wrap(err, "invalid value")
+	}
+	if len(data) != len(res) {
+		return res, errors.New("incorrect length")
+	}
+	copy(res[:], data)
+
+	return res, nil
+}
+
+func decodeAddress(input string) ([20]byte, error) {
+	var res [20]byte
+	if input == "" {
+		return res, errors.New("missing")
+	}
+	data, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil {
+		return res, errors.Wrap(err, "invalid value")
+	}
+	if len(data) != len(res) {
+		return res, errors.New("incorrect length")
+	}
+	copy(res[:], data)
+
+	return res, nil
+}
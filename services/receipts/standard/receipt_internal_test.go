@@ -0,0 +1,112 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiptUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		err      string
+		to       bool
+		contract bool
+		status   uint8
+		logs     int
+		// effectiveGasPrice is nil if not present.
+		effectiveGasPrice *big.Int
+	}{
+		{
+			name:  "Empty",
+			input: []byte(`{}`),
+			err:   "block hash: missing",
+		},
+		{
+			name:  "BlockHashInvalid",
+			input: []byte(`{"blockHash":"0x01"}`),
+			err:   "block hash: incorrect length",
+		},
+		{
+			name:  "StatusMissing",
+			input: []byte(`{"blockHash":"0x2d3f1a8f5e33d35b5e71a4d5cde1a5b6b7b0b1e1a0b4f7b2f6c2d1e0a9b8c7d6","blockNumber":"0x12d687","transactionHash":"0x8a3e5b2c1d0f9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d","transactionIndex":"0x2","from":"0x1f9090aae28b8a3dceadf281b0f12828e676c326","to":"0x00000000219ab540356cbb839cbe05303d7705fa","gasUsed":"0x5208","logs":[]}`),
+			err:   "status: missing",
+		},
+		{
+			name:   "Transfer",
+			input:  []byte(`{"blockHash":"0x2d3f1a8f5e33d35b5e71a4d5cde1a5b6b7b0b1e1a0b4f7b2f6c2d1e0a9b8c7d6","blockNumber":"0x12d687","transactionHash":"0x8a3e5b2c1d0f9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d","transactionIndex":"0x2","from":"0x1f9090aae28b8a3dceadf281b0f12828e676c326","to":"0x00000000219ab540356cbb839cbe05303d7705fa","contractAddress":null,"status":"0x0","gasUsed":"0x5208","logs":[]}`),
+			to:     true,
+			status: 0,
+		},
+		{
+			name:     "ContractCreation",
+			input:    []byte(`{"blockHash":"0x2d3f1a8f5e33d35b5e71a4d5cde1a5b6b7b0b1e1a0b4f7b2f6c2d1e0a9b8c7d6","blockNumber":"0x12d687","transactionHash":"0x8a3e5b2c1d0f9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d","transactionIndex":"0x2","from":"0x1f9090aae28b8a3dceadf281b0f12828e676c326","to":null,"contractAddress":"0x00000000219ab540356cbb839cbe05303d7705fa","status":"0x1","gasUsed":"0x5208","logs":[]}`),
+			contract: true,
+			status:   1,
+		},
+		{
+			name:  "LogTopicInvalid",
+			input: []byte(`{"blockHash":"0x2d3f1a8f5e33d35b5e71a4d5cde1a5b6b7b0b1e1a0b4f7b2f6c2d1e0a9b8c7d6","blockNumber":"0x12d687","transactionHash":"0x8a3e5b2c1d0f9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d","transactionIndex":"0x2","from":"0x1f9090aae28b8a3dceadf281b0f12828e676c326","to":"0x00000000219ab540356cbb839cbe05303d7705fa","status":"0x1","gasUsed":"0x5208","logs":[{"address":"0x00000000219ab540356cbb839cbe05303d7705fa","topics":["0x649b"],"data":"0x","logIndex":"0x7"}]}`),
+			err:   "log 0: topic: incorrect length",
+		},
+		{
+			name:   "Logs",
+			input:  []byte(`{"blockHash":"0x2d3f1a8f5e33d35b5e71a4d5cde1a5b6b7b0b1e1a0b4f7b2f6c2d1e0a9b8c7d6","blockNumber":"0x12d687","transactionHash":"0x8a3e5b2c1d0f9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d","transactionIndex":"0x2","from":"0x1f9090aae28b8a3dceadf281b0f12828e676c326","to":"0x00000000219ab540356cbb839cbe05303d7705fa","status":"0x1","gasUsed":"0x5208","logs":[{"address":"0x00000000219ab540356cbb839cbe05303d7705fa","topics":["0x649bbc62d0e31342afea4e5cd82d4049e7e1ee912fc0889aa790803be39038c5"],"data":"0x0102","logIndex":"0x7"}]}`),
+			to:     true,
+			status: 1,
+			logs:   1,
+		},
+		{
+			name:  "EffectiveGasPriceInvalid",
+			input: []byte(`{"blockHash":"0x2d3f1a8f5e33d35b5e71a4d5cde1a5b6b7b0b1e1a0b4f7b2f6c2d1e0a9b8c7d6","blockNumber":"0x12d687","transactionHash":"0x8a3e5b2c1d0f9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d","transactionIndex":"0x2","from":"0x1f9090aae28b8a3dceadf281b0f12828e676c326","to":"0x00000000219ab540356cbb839cbe05303d7705fa","status":"0x1","gasUsed":"0x5208","effectiveGasPrice":"0xinvalid","logs":[]}`),
+			err:   "effective gas price: invalid value",
+		},
+		{
+			name:              "EffectiveGasPrice",
+			input:             []byte(`{"blockHash":"0x2d3f1a8f5e33d35b5e71a4d5cde1a5b6b7b0b1e1a0b4f7b2f6c2d1e0a9b8c7d6","blockNumber":"0x12d687","transactionHash":"0x8a3e5b2c1d0f9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d","transactionIndex":"0x2","from":"0x1f9090aae28b8a3dceadf281b0f12828e676c326","to":"0x00000000219ab540356cbb839cbe05303d7705fa","status":"0x1","gasUsed":"0x5208","effectiveGasPrice":"0x3b9aca00","logs":[]}`),
+			to:                true,
+			status:            1,
+			effectiveGasPrice: big.NewInt(1000000000),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var res receipt
+			err := json.Unmarshal(test.input, &res)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, uint64(0x12d687), res.BlockNumber)
+			require.Equal(t, uint32(2), res.TransactionIndex)
+			require.Equal(t, uint64(21000), res.GasUsed)
+			require.Equal(t, test.status, res.Status)
+			require.Equal(t, test.to, res.To != nil)
+			require.Equal(t, test.contract, res.ContractAddress != nil)
+			require.Len(t, res.Logs, test.logs)
+			if test.effectiveGasPrice != nil {
+				require.Equal(t, test.effectiveGasPrice, res.EffectiveGasPrice)
+			} else {
+				require.Nil(t, res.EffectiveGasPrice)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// relayPageSize is the number of bid traces requested from a relay at a time.
+const relayPageSize = 100
+
+// bidTrace is the trace of a bid for a payload delivered by a relay.
+type bidTrace struct {
+	Slot      phase0.Slot
+	BlockHash [32]byte
+	Value     *big.Int
+}
+
+//nolint:tagliatelle
+type bidTraceJSON struct {
+	Slot      string `json:"slot"`
+	BlockHash string `json:"block_hash"`
+	Value     string `json:"value"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *bidTrace) UnmarshalJSON(input []byte) error {
+	var data bidTraceJSON
+	if err := json.Unmarshal(input, &data); err != nil {
+		return errors.Wrap(err, "invalid JSON")
+	}
+
+	if data.Slot == "" {
+		return errors.New("slot: missing")
+	}
+	slot, err := strconv.ParseUint(data.Slot, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "slot: invalid value")
+	}
+	b.Slot = phase0.Slot(slot)
+	if b.BlockHash, err = decodeHash(data.BlockHash); err != nil {
+		return errors.Wrap(err, "block hash")
+	}
+	if data.Value == "" {
+		return errors.New("value: missing")
+	}
+	var ok bool
+	b.Value, ok = new(big.Int).SetString(data.Value, 10)
+	if !ok {
+		return errors.New("value: invalid value")
+	}
+
+	return nil
+}
+
+// relayPayloadValues fetches the declared values of the payloads delivered by the
+// relays for the given range of slots, inclusive, keyed by execution block hash.
+// If more than one relay delivered a payload the value from the first relay is used.
+func (s *Service) relayPayloadValues(ctx context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	map[[32]byte]*big.Int,
+	error,
+) {
+	res := make(map[[32]byte]*big.Int)
+	for _, relay := range s.relays {
+		cursor := endSlot
+		for {
+			bidTraces, err := s.deliveredPayloads(ctx, relay, cursor)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to obtain delivered payloads from %s", relay.Host)
+			}
+			earliest := cursor
+			for _, bidTrace := range bidTraces {
+				if bidTrace.Slot < earliest {
+					earliest = bidTrace.Slot
+				}
+				if bidTrace.Slot < startSlot || bidTrace.Slot > endSlot {
+					continue
+				}
+				if _, exists := res[bidTrace.BlockHash]; !exists {
+					res[bidTrace.BlockHash] = bidTrace.Value
+				}
+			}
+			if len(bidTraces) < relayPageSize || earliest <= startSlot {
+				break
+			}
+			cursor = earliest - 1
+		}
+	}
+
+	return res, nil
+}
+
+// deliveredPayloads fetches the bid traces of the payloads delivered by a relay,
+// latest first, starting at the given slot.
+func (s *Service) deliveredPayloads(ctx context.Context,
+	relay *url.URL,
+	cursor phase0.Slot,
+) (
+	[]*bidTrace,
+	error,
+) {
+	reference, err := url.Parse(fmt.Sprintf("relay/v1/data/bidtraces/proposer_payload_delivered?cursor=%d&limit=%d", cursor, relayPageSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid endpoint")
+	}
+	url := relay.ResolveReference(reference).String()
+
+	respBodyReader, err := s.get(ctx, url)
+	if err != nil {
+		log.Trace().Str("url", url).Err(err).Msg("Request failed")
+		return nil, errors.Wrap(err, "request failed")
+	}
+	if respBodyReader == nil {
+		return nil, errors.New("empty response")
+	}
+
+	var bidTraces []*bidTrace
+	if err := json.NewDecoder(respBodyReader).Decode(&bidTraces); err != nil {
+		return nil, errors.Wrap(err, "invalid response")
+	}
+
+	return bidTraces, nil
+}
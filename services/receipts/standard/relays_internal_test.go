@@ -0,0 +1,131 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBidTraceUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		err   string
+	}{
+		{
+			name:  "Empty",
+			input: []byte(`{}`),
+			err:   "slot: missing",
+		},
+		{
+			name:  "SlotInvalid",
+			input: []byte(`{"slot":"-1","block_hash":"0x2d3f1a8f5e33d35b5e71a4d5cde1a5b6b7b0b1e1a0b4f7b2f6c2d1e0a9b8c7d6","value":"1"}`),
+			err:   `slot: invalid value: strconv.ParseUint: parsing "-1": invalid syntax`,
+		},
+		{
+			name:  "BlockHashInvalid",
+			input: []byte(`{"slot":"100","block_hash":"0x01","value":"1"}`),
+			err:   "block hash: incorrect length",
+		},
+		{
+			name:  "ValueMissing",
+			input: []byte(`{"slot":"100","block_hash":"0x2d3f1a8f5e33d35b5e71a4d5cde1a5b6b7b0b1e1a0b4f7b2f6c2d1e0a9b8c7d6"}`),
+			err:   "value: missing",
+		},
+		{
+			name:  "ValueInvalid",
+			input: []byte(`{"slot":"100","block_hash":"0x2d3f1a8f5e33d35b5e71a4d5cde1a5b6b7b0b1e1a0b4f7b2f6c2d1e0a9b8c7d6","value":"0x01"}`),
+			err:   "value: invalid value",
+		},
+		{
+			name:  "Good",
+			input: []byte(`{"slot":"100","parent_hash":"0x8a3e5b2c1d0f9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d","block_hash":"0x2d3f1a8f5e33d35b5e71a4d5cde1a5b6b7b0b1e1a0b4f7b2f6c2d1e0a9b8c7d6","gas_used":"21000","value":"123456789012345678901"}`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var res bidTrace
+			err := json.Unmarshal(test.input, &res)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, phase0.Slot(100), res.Slot)
+			require.Equal(t, byte(0x2d), res.BlockHash[0])
+			expected, _ := new(big.Int).SetString("123456789012345678901", 10)
+			require.Equal(t, expected, res.Value)
+		})
+	}
+}
+
+func TestRelayPayloadValues(t *testing.T) {
+	ctx := context.Background()
+
+	// The relay has delivered a payload for every even slot, and returns
+	// pages of payloads at or before the cursor.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cursor, err := strconv.ParseUint(r.URL.Query().Get("cursor"), 10, 64)
+		require.NoError(t, err)
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		require.NoError(t, err)
+
+		bidTraces := make([]map[string]string, 0, limit)
+		for slot := cursor; len(bidTraces) < limit; slot-- {
+			if slot%2 == 0 {
+				bidTraces = append(bidTraces, map[string]string{
+					"slot":       fmt.Sprintf("%d", slot),
+					"block_hash": fmt.Sprintf("%#064x", slot),
+					"value":      fmt.Sprintf("%d", slot*1000),
+				})
+			}
+			if slot == 0 {
+				break
+			}
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(bidTraces))
+	}))
+	defer server.Close()
+
+	relay, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+	s := &Service{
+		timeout: 5 * time.Second,
+		client:  server.Client(),
+		relays:  []*url.URL{relay},
+	}
+
+	values, err := s.relayPayloadValues(ctx, 1000, 1400)
+	require.NoError(t, err)
+	require.Len(t, values, 201)
+	for slot := uint64(1000); slot <= 1400; slot += 2 {
+		var blockHash [32]byte
+		blockHash[30] = byte(slot >> 8)
+		blockHash[31] = byte(slot)
+		require.Equal(t, new(big.Int).SetUint64(slot*1000), values[blockHash])
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// Service is a transaction receipts service.
+type Service struct {
+	chainDB                   chaindb.Service
+	chainTime                 chaintime.Service
+	blocksProvider            chaindb.BlocksProvider
+	transactionReceiptsSetter chaindb.TransactionReceiptsSetter
+	payloadValuesSetter       chaindb.ExecutionPayloadValuesSetter
+	timeout                   time.Duration
+	base                      *url.URL
+	client                    *http.Client
+	addresses                 map[[20]byte]bool
+	topics                    map[[32]byte]bool
+	relays                    []*url.URL
+	activitySem               *semaphore.Weighted
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("receipts", "standard", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	blocksProvider, isBlocksProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isBlocksProvider {
+		return nil, errors.New("chain DB does not support block providing")
+	}
+
+	transactionReceiptsSetter, isTransactionReceiptsSetter := parameters.chainDB.(chaindb.TransactionReceiptsSetter)
+	if !isTransactionReceiptsSetter {
+		return nil, errors.New("chain DB does not support transaction receipt setting")
+	}
+
+	payloadValuesSetter, isPayloadValuesSetter := parameters.chainDB.(chaindb.ExecutionPayloadValuesSetter)
+	if !isPayloadValuesSetter {
+		return nil, errors.New("chain DB does not support execution payload value setting")
+	}
+
+	connectionURL := parameters.connectionURL
+	if !strings.HasPrefix(connectionURL, "http") {
+		connectionURL = fmt.Sprintf("http://%s", parameters.connectionURL)
+	}
+	base, err := url.Parse(connectionURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid URL")
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:        64,
+			MaxIdleConnsPerHost: 64,
+			IdleConnTimeout:     384 * time.Second,
+		},
+	}
+
+	addresses := make(map[[20]byte]bool, len(parameters.addresses))
+	for _, address := range parameters.addresses {
+		addresses[address] = true
+	}
+	topics := make(map[[32]byte]bool, len(parameters.topics))
+	for _, topic := range parameters.topics {
+		topics[topic] = true
+	}
+	if len(addresses) == 0 && len(topics) == 0 {
+		log.Info().Msg("No event filters supplied; all events will be stored")
+	}
+
+	relays := make([]*url.URL, 0, len(parameters.relays))
+	for _, relay := range parameters.relays {
+		if !strings.HasPrefix(relay, "http") {
+			relay = fmt.Sprintf("https://%s", relay)
+		}
+		relayURL, err := url.Parse(relay)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid relay URL %s", relay)
+		}
+		// Relay addresses commonly include the relay's public key, which is not a credential.
+		relayURL.User = nil
+		if !strings.HasSuffix(relayURL.Path, "/") {
+			relayURL.Path += "/"
+		}
+		relays = append(relays, relayURL)
+	}
+
+	s := &Service{
+		chainDB:                   parameters.chainDB,
+		chainTime:                 parameters.chainTime,
+		blocksProvider:            blocksProvider,
+		transactionReceiptsSetter: transactionReceiptsSetter,
+		payloadValuesSetter:       payloadValuesSetter,
+		timeout:                   parameters.timeout,
+		base:                      base,
+		client:                    client,
+		addresses:                 addresses,
+		topics:                    topics,
+		relays:                    relays,
+		activitySem:               semaphore.NewWeighted(1),
+	}
+
+	if parameters.startSlot >= 0 {
+		// Explicit requirement to (re-)fetch receipts from a given slot.
+		if err := s.resetLatestSlot(ctx, parameters.startSlot-1); err != nil {
+			return nil, err
+		}
+	}
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain metadata")
+	}
+	monitorLatestSlot(md.LatestSlot)
+
+	// Update once per epoch.
+	runtimeFunc := func(ctx context.Context, data any) (time.Time, error) {
+		return s.chainTime.StartOfEpoch(s.chainTime.CurrentEpoch() + 1), nil
+	}
+	jobFunc := func(ctx context.Context, data any) {
+		s := data.(*Service)
+		s.update(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "receipts", "update",
+		runtimeFunc,
+		nil,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic update")
+	}
+
+	return s, nil
+}
+
+// resetLatestSlot sets the latest slot for which receipts have been fetched.
+func (s *Service) resetLatestSlot(ctx context.Context, slot int64) error {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := s.setMetadata(ctx, &metadata{LatestSlot: slot}); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/finalizer"
+	"go.opentelemetry.io/otel"
+)
+
+// update fetches receipts for blocks that have been canonicalized since the last update.
+func (s *Service) update(ctx context.Context) {
+	// Only allow 1 update to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another update running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	if err := s.updateReceipts(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to update receipts")
+	}
+}
+
+// updateReceipts fetches receipts from the last slot processed.
+func (s *Service) updateReceipts(ctx context.Context) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.receipts.standard").Start(ctx, "updateReceipts")
+	defer span.End()
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	// Only canonical blocks have their receipts fetched, so wait for the finalizer.
+	targetSlot, err := finalizer.CanonicalSlot(ctx, s.chainDB)
+	if err != nil {
+		return err
+	}
+	if targetSlot < 0 || md.LatestSlot >= targetSlot {
+		log.Trace().Int64("target_slot", targetSlot).Msg("No slots to process")
+		return nil
+	}
+	log.Trace().Int64("start_slot", md.LatestSlot+1).Int64("target_slot", targetSlot).Msg("Fetching receipts")
+
+	// Process an epoch's worth of slots per transaction.
+	batchSlots := int64(s.chainTime.SlotsPerEpoch())
+	for startSlot := md.LatestSlot + 1; startSlot <= targetSlot; startSlot += batchSlots {
+		endSlot := startSlot + batchSlots - 1
+		if endSlot > targetSlot {
+			endSlot = targetSlot
+		}
+		if err := s.updateSlots(ctx, md, phase0.Slot(startSlot), phase0.Slot(endSlot)); err != nil {
+			return errors.Wrapf(err, "failed to fetch receipts for slots %d to %d", startSlot, endSlot)
+		}
+	}
+
+	return nil
+}
+
+// updateSlots fetches and stores receipts for the canonical blocks in the given range of slots,
+// inclusive, in a single transaction.
+func (s *Service) updateSlots(ctx context.Context,
+	md *metadata,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) error {
+	canonical := true
+	blocks, err := s.blocksProvider.Blocks(ctx, &chaindb.BlockFilter{
+		From:      &startSlot,
+		To:        &endSlot,
+		Canonical: &canonical,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain blocks")
+	}
+
+	relayValues := make(map[[32]byte]*big.Int)
+	if len(s.relays) > 0 {
+		relayValues, err = s.relayPayloadValues(ctx, startSlot, endSlot)
+		if err != nil {
+			return err
+		}
+	}
+
+	receipts := make([]*chaindb.TransactionReceipt, 0)
+	events := make([]*chaindb.TransactionEvent, 0)
+	values := make([]*chaindb.ExecutionPayloadValue, 0, len(blocks))
+	processed := make([][2]int, 0, len(blocks))
+	for _, block := range blocks {
+		if block.ExecutionPayload == nil || block.ExecutionPayload.BlockHash == [32]byte{} {
+			// Pre-merge block; no receipts.
+			continue
+		}
+		blockReceipts, blockEvents, localValue, err := s.receiptsForBlock(ctx, block)
+		if err != nil {
+			return err
+		}
+		receipts = append(receipts, blockReceipts...)
+		events = append(events, blockEvents...)
+		processed = append(processed, [2]int{len(blockReceipts), len(blockEvents)})
+
+		// A payload delivered by a relay was built externally, so its value is that declared by the relay.
+		if relayValue, exists := relayValues[block.ExecutionPayload.BlockHash]; exists {
+			values = append(values, &chaindb.ExecutionPayloadValue{
+				BlockRoot: block.Root,
+				Value:     relayValue,
+				Source:    chaindb.PayloadValueSourceRelay,
+			})
+		} else if localValue != nil {
+			values = append(values, &chaindb.ExecutionPayloadValue{
+				BlockRoot: block.Root,
+				Value:     localValue,
+				Source:    chaindb.PayloadValueSourceLocal,
+			})
+		}
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.transactionReceiptsSetter.SetTransactionReceipts(ctx, receipts); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set transaction receipts")
+	}
+	if err := s.transactionReceiptsSetter.SetTransactionEvents(ctx, events); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set transaction events")
+	}
+	if err := s.payloadValuesSetter.SetExecutionPayloadValues(ctx, values); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set execution payload values")
+	}
+
+	md.LatestSlot = int64(endSlot)
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	for _, counts := range processed {
+		monitorBlockProcessed(counts[0], counts[1])
+	}
+	monitorLatestSlot(md.LatestSlot)
+
+	return nil
+}
+
+// receiptsForBlock fetches the receipts for the execution payload of the given block,
+// returning the receipts, those events that pass the event filters, and the value of
+// the payload calculated from its priority fees.
+func (s *Service) receiptsForBlock(ctx context.Context,
+	block *chaindb.Block,
+) (
+	[]*chaindb.TransactionReceipt,
+	[]*chaindb.TransactionEvent,
+	*big.Int,
+	error,
+) {
+	payload := block.ExecutionPayload
+	blockReceipts, err := s.blockReceipts(ctx, payload.BlockHash)
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "failed to obtain receipts for execution block %d", payload.BlockNumber)
+	}
+	if blockReceipts == nil {
+		return nil, nil, nil, fmt.Errorf("execution client does not have execution block %d", payload.BlockNumber)
+	}
+
+	receipts := make([]*chaindb.TransactionReceipt, 0, len(blockReceipts))
+	events := make([]*chaindb.TransactionEvent, 0)
+	for _, blockReceipt := range blockReceipts {
+		if blockReceipt.BlockHash != payload.BlockHash {
+			return nil, nil, nil, fmt.Errorf("receipt for execution block %d has incorrect block hash %#x", payload.BlockNumber, blockReceipt.BlockHash)
+		}
+		receipts = append(receipts, &chaindb.TransactionReceipt{
+			InclusionBlockRoot: block.Root,
+			InclusionSlot:      block.Slot,
+			BlockNumber:        payload.BlockNumber,
+			Index:              blockReceipt.TransactionIndex,
+			Hash:               blockReceipt.TransactionHash,
+			From:               blockReceipt.From,
+			To:                 blockReceipt.To,
+			ContractAddress:    blockReceipt.ContractAddress,
+			Status:             blockReceipt.Status,
+			GasUsed:            blockReceipt.GasUsed,
+		})
+		for _, receiptLog := range blockReceipt.Logs {
+			if !s.eventWanted(receiptLog) {
+				continue
+			}
+			events = append(events, &chaindb.TransactionEvent{
+				InclusionBlockRoot: block.Root,
+				InclusionSlot:      block.Slot,
+				BlockNumber:        payload.BlockNumber,
+				TransactionIndex:   blockReceipt.TransactionIndex,
+				TransactionHash:    blockReceipt.TransactionHash,
+				Index:              receiptLog.LogIndex,
+				Address:            receiptLog.Address,
+				Topics:             receiptLog.Topics,
+				Data:               receiptLog.Data,
+			})
+		}
+	}
+
+	return receipts, events, priorityFees(payload.BaseFeePerGas, blockReceipts), nil
+}
+
+// priorityFees calculates the total priority fees paid by the transactions with the
+// given receipts, which is the value of a locally built payload to its proposer.
+// It returns nil if the value cannot be calculated.
+func priorityFees(baseFeePerGas *big.Int, receipts []*receipt) *big.Int {
+	if baseFeePerGas == nil {
+		return nil
+	}
+
+	res := new(big.Int)
+	for _, receipt := range receipts {
+		if receipt.EffectiveGasPrice == nil {
+			return nil
+		}
+		priorityFee := new(big.Int).Sub(receipt.EffectiveGasPrice, baseFeePerGas)
+		res.Add(res, priorityFee.Mul(priorityFee, new(big.Int).SetUint64(receipt.GasUsed)))
+	}
+
+	return res
+}
+
+// eventWanted returns true if the log passes the address and topic filters.
+// An empty filter allows all values.
+func (s *Service) eventWanted(receiptLog *receiptLog) bool {
+	if len(s.addresses) > 0 && !s.addresses[receiptLog.Address] {
+		return false
+	}
+	if len(s.topics) > 0 {
+		if len(receiptLog.Topics) == 0 || !s.topics[receiptLog.Topics[0]] {
+			return false
+		}
+	}
+
+	return true
+}
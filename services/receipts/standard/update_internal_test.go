@@ -0,0 +1,140 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventWanted(t *testing.T) {
+	address1 := [20]byte{0x01}
+	address2 := [20]byte{0x02}
+	topic1 := [32]byte{0x01}
+	topic2 := [32]byte{0x02}
+
+	tests := []struct {
+		name      string
+		addresses map[[20]byte]bool
+		topics    map[[32]byte]bool
+		log       *receiptLog
+		expected  bool
+	}{
+		{
+			name:     "NoFilters",
+			log:      &receiptLog{Address: address1, Topics: [][32]byte{topic1}},
+			expected: true,
+		},
+		{
+			name:      "AddressMatch",
+			addresses: map[[20]byte]bool{address1: true},
+			log:       &receiptLog{Address: address1, Topics: [][32]byte{topic1}},
+			expected:  true,
+		},
+		{
+			name:      "AddressMismatch",
+			addresses: map[[20]byte]bool{address1: true},
+			log:       &receiptLog{Address: address2, Topics: [][32]byte{topic1}},
+			expected:  false,
+		},
+		{
+			name:     "TopicMatch",
+			topics:   map[[32]byte]bool{topic1: true},
+			log:      &receiptLog{Address: address2, Topics: [][32]byte{topic1, topic2}},
+			expected: true,
+		},
+		{
+			name:     "TopicNotFirst",
+			topics:   map[[32]byte]bool{topic2: true},
+			log:      &receiptLog{Address: address2, Topics: [][32]byte{topic1, topic2}},
+			expected: false,
+		},
+		{
+			name:     "TopicAnonymous",
+			topics:   map[[32]byte]bool{topic1: true},
+			log:      &receiptLog{Address: address1},
+			expected: false,
+		},
+		{
+			name:      "BothMatch",
+			addresses: map[[20]byte]bool{address1: true},
+			topics:    map[[32]byte]bool{topic1: true},
+			log:       &receiptLog{Address: address1, Topics: [][32]byte{topic1}},
+			expected:  true,
+		},
+		{
+			name:      "AddressMatchTopicMismatch",
+			addresses: map[[20]byte]bool{address1: true},
+			topics:    map[[32]byte]bool{topic2: true},
+			log:       &receiptLog{Address: address1, Topics: [][32]byte{topic1}},
+			expected:  false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Service{
+				addresses: test.addresses,
+				topics:    test.topics,
+			}
+			require.Equal(t, test.expected, s.eventWanted(test.log))
+		})
+	}
+}
+
+func TestPriorityFees(t *testing.T) {
+	tests := []struct {
+		name          string
+		baseFeePerGas *big.Int
+		receipts      []*receipt
+		expected      *big.Int
+	}{
+		{
+			name:     "BaseFeeMissing",
+			receipts: []*receipt{},
+		},
+		{
+			name:          "Empty",
+			baseFeePerGas: big.NewInt(10),
+			receipts:      []*receipt{},
+			expected:      big.NewInt(0),
+		},
+		{
+			name:          "EffectiveGasPriceMissing",
+			baseFeePerGas: big.NewInt(10),
+			receipts: []*receipt{
+				{GasUsed: 21000, EffectiveGasPrice: big.NewInt(12)},
+				{GasUsed: 21000},
+			},
+		},
+		{
+			name:          "Good",
+			baseFeePerGas: big.NewInt(10),
+			receipts: []*receipt{
+				{GasUsed: 21000, EffectiveGasPrice: big.NewInt(12)},
+				{GasUsed: 50000, EffectiveGasPrice: big.NewInt(10)},
+				{GasUsed: 100000, EffectiveGasPrice: big.NewInt(15)},
+			},
+			expected: big.NewInt(21000*2 + 100000*5),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, priorityFees(test.baseFeePerGas, test.receipts))
+		})
+	}
+}
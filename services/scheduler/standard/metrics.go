@@ -15,9 +15,13 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/wealdtech/chaind/services/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
 var metricsNamespace = "scheduler"
@@ -28,7 +32,13 @@ var (
 	schedulerJobsStarted   *prometheus.CounterVec
 )
 
+// schedulerJobDuration is exported through OpenTelemetry, if configured.
+var schedulerJobDuration metric.Float64Histogram
+
 func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if err := registerOtelMetrics(ctx); err != nil {
+		return err
+	}
 	if schedulerJobsScheduled != nil {
 		// Already registered.
 		return nil
@@ -73,6 +83,21 @@ func registerPrometheusMetrics(_ context.Context) error {
 	return prometheus.Register(schedulerJobsStarted)
 }
 
+func registerOtelMetrics(_ context.Context) error {
+	if schedulerJobDuration != nil {
+		// Already registered.
+		return nil
+	}
+
+	var err error
+	schedulerJobDuration, err = otel.Meter("wealdtech.chaind.services.scheduler.standard").Float64Histogram("chaind.scheduler.job.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("The time taken to run scheduled jobs."),
+	)
+
+	return err
+}
+
 // jobScheduled is called when a job is scheduled.
 func jobScheduled(class string) {
 	if schedulerJobsScheduled != nil {
@@ -100,3 +125,13 @@ func jobStartedOnSignal(class string) {
 		schedulerJobsStarted.WithLabelValues(class, "signal").Inc()
 	}
 }
+
+// jobFinished is called when a scheduled job finishes.
+func jobFinished(ctx context.Context, class string, name string, started time.Time) {
+	if schedulerJobDuration != nil {
+		schedulerJobDuration.Record(ctx, time.Since(started).Seconds(), metric.WithAttributes(
+			attribute.String("class", class),
+			attribute.String("job", name),
+		))
+	}
+}
@@ -20,9 +20,9 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/sasha-s/go-deadlock"
 	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
 	"go.uber.org/atomic"
 )
 
@@ -56,10 +56,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "scheduler").Str("impl", "advanced").Logger()
-	if parameters.logLevel != log.GetLevel() {
-		log = log.Level(parameters.logLevel)
-	}
+	log = util.ServiceLogger("scheduler", "advanced", parameters.logLevel)
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -121,7 +118,9 @@ func (s *Service) ScheduleJob(ctx context.Context,
 			// If we receive this signal the job has already been deleted from the jobs list so no need to
 			// do so again here.
 			jobStartedOnSignal(class)
+			started := time.Now()
 			jobFunc(ctx, data)
+			jobFinished(ctx, class, name, started)
 			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
 			finaliseJob(job)
 			job.active.Store(false)
@@ -137,7 +136,9 @@ func (s *Service) ScheduleJob(ctx context.Context,
 			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Timer triggered; job running")
 			job.active.Store(true)
 			jobStartedOnTimer(class)
+			started := time.Now()
 			jobFunc(ctx, data)
+			jobFinished(ctx, class, name, started)
 			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
 			job.active.Store(false)
 			finaliseJob(job)
@@ -223,7 +224,9 @@ func (s *Service) SchedulePeriodicJob(ctx context.Context,
 			case <-job.runCh:
 				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Run triggered; job running")
 				jobStartedOnSignal(class)
+				started := time.Now()
 				jobFunc(ctx, jobData)
+				jobFinished(ctx, class, name, started)
 				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
 				job.active.Store(false)
 			case <-time.After(time.Until(runtime)):
@@ -234,7 +237,9 @@ func (s *Service) SchedulePeriodicJob(ctx context.Context,
 				job.active.Store(true)
 				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Timer triggered; job running")
 				jobStartedOnTimer(class)
+				started := time.Now()
 				jobFunc(ctx, jobData)
+				jobFinished(ctx, class, name, started)
 				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
 				job.active.Store(false)
 			}
@@ -21,8 +21,8 @@ import (
 	"github.com/attestantio/go-eth2-client/api"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 )
 
 // Service is a spec service.
@@ -45,10 +45,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "spec").Str("impl", "standard").Logger()
-	if parameters.logLevel != log.GetLevel() {
-		log = log.Level(parameters.logLevel)
-	}
+	log = util.ServiceLogger("spec", "standard", parameters.logLevel)
 
 	chainSpecSetter, isChainSpecSetter := parameters.chainDB.(chaindb.ChainSpecSetter)
 	if !isChainSpecSetter {
@@ -118,6 +115,10 @@ func (s *Service) updateSpec(ctx context.Context) {
 		log.Fatal().Err(err).Msg("Failed to update fork schedule")
 	}
 
+	if err := s.updateForks(ctx); err != nil {
+		log.Fatal().Err(err).Msg("Failed to update forks")
+	}
+
 	if err := s.chainDB.CommitTx(ctx); err != nil {
 		cancel()
 		log.Fatal().Err(err).Msg("Failed to commit transaction")
@@ -172,3 +173,28 @@ func (s *Service) updateForkSchedule(ctx context.Context) error {
 
 	return nil
 }
+
+func (s *Service) updateForks(ctx context.Context) error {
+	specResponse, err := s.eth2Client.(eth2client.SpecProvider).Spec(ctx, &api.SpecOpts{})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain chain spec")
+	}
+	genesisResponse, err := s.eth2Client.(eth2client.GenesisProvider).Genesis(ctx, &api.GenesisOpts{})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain genesis")
+	}
+
+	// The forks are named from the spec, which holds forks that the beacon
+	// node knows about but that have not yet been scheduled.
+	forks, err := util.ForksFromSpec(specResponse.Data, genesisResponse.Data.GenesisValidatorsRoot)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain forks")
+	}
+
+	// Update the database.
+	if err := s.forkScheduleSetter.SetForks(ctx, forks); err != nil {
+		return errors.Wrap(err, "failed to set forks")
+	}
+
+	return nil
+}
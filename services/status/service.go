@@ -0,0 +1,59 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// Service is a status service.
+type Service interface {
+	// Status provides the current status of chaind.
+	Status(ctx context.Context) (*Status, error)
+}
+
+// Status is the status of chaind.
+type Status struct {
+	Timestamp time.Time `json:"timestamp"`
+	// HeadSlot is the slot of the chain head.
+	HeadSlot phase0.Slot `json:"head_slot"`
+	// FinalizedEpoch is the latest finalized epoch, if known.
+	FinalizedEpoch *phase0.Epoch `json:"finalized_epoch,omitempty"`
+	// Services is the status of the individual services.
+	Services []*ServiceStatus `json:"services"`
+}
+
+// ServiceStatus is the status of an individual service.
+type ServiceStatus struct {
+	Name     string      `json:"name"`
+	Progress []*Progress `json:"progress"`
+	// PendingGaps is the number of missed items awaiting processing.
+	PendingGaps int `json:"pending_gaps"`
+}
+
+// Progress is the progress of a service against its target.
+type Progress struct {
+	Name string `json:"name"`
+	// Unit is the unit of progress, for example "slot" or "epoch".
+	Unit string `json:"unit"`
+	// Latest is the latest item processed, or nil if nothing has been processed.
+	Latest *int64 `json:"latest,omitempty"`
+	// Target is the item the service is working towards, or nil if not known.
+	Target *int64 `json:"target,omitempty"`
+	// Gap is the number of items between latest and target, or nil if not known.
+	Gap *int64 `json:"gap,omitempty"`
+}
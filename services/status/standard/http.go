@@ -0,0 +1,53 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleHealth handles requests for the health of chaind.
+func (s *Service) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if err := s.Healthy(r.Context()); err != nil {
+		log.Debug().Err(err).Msg("Unhealthy")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("OK\n"))
+}
+
+// handleStatus handles requests for the status of chaind.
+func (s *Service) handleStatus(w http.ResponseWriter, r *http.Request) {
+	st, err := s.Status(r.Context())
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain status")
+		http.Error(w, "failed to obtain status", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(st)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to marshal status")
+		http.Error(w, "failed to marshal status", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
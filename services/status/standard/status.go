@@ -0,0 +1,335 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/finalizer"
+	"github.com/wealdtech/chaind/services/status"
+)
+
+// chainState is the state of the chain against which progress is measured.
+type chainState struct {
+	headSlot       phase0.Slot
+	headEpoch      phase0.Epoch
+	finalizedEpoch *phase0.Epoch
+	currentPeriod  uint64
+}
+
+// targetFunc provides the target for a progress item given the state of the chain.
+type targetFunc func(cs *chainState) *int64
+
+// tracker defines how to obtain the progress of a service.
+type tracker struct {
+	name            string
+	progressService string
+	items           []*trackerItem
+	// gapKeys are the progress keys that hold lists of items awaiting processing.
+	gapKeys []string
+}
+
+// trackerItem defines a single progress value of a service.
+type trackerItem struct {
+	key    string
+	unit   string
+	target targetFunc
+}
+
+func headSlotTarget(cs *chainState) *int64 {
+	return int64Ptr(int64(cs.headSlot))
+}
+
+func headEpochTarget(cs *chainState) *int64 {
+	return int64Ptr(int64(cs.headEpoch))
+}
+
+func finalizedEpochTarget(cs *chainState) *int64 {
+	if cs.finalizedEpoch == nil {
+		return nil
+	}
+	return int64Ptr(int64(*cs.finalizedEpoch))
+}
+
+func currentPeriodTarget(cs *chainState) *int64 {
+	return int64Ptr(int64(cs.currentPeriod))
+}
+
+// trackers are the services for which progress is reported.
+var trackers = []*tracker{
+	{
+		name:            "blocks",
+		progressService: "blocks.standard",
+		items: []*trackerItem{
+			{key: "latest_slot", unit: "slot", target: headSlotTarget},
+		},
+	},
+	{
+		name:            "finalizer",
+		progressService: finalizer.ProgressService,
+		items: []*trackerItem{
+			{key: "latest_epoch", unit: "epoch", target: finalizedEpochTarget},
+			{key: "latest_canonical_slot", unit: "slot"},
+			{key: "latest_checkpoint_epoch", unit: "epoch", target: finalizedEpochTarget},
+			{key: "latest_randao_epoch", unit: "epoch", target: finalizedEpochTarget},
+		},
+		gapKeys: []string{"missed_epochs"},
+	},
+	{
+		name:            "summarizer",
+		progressService: "summarizer.standard",
+		items: []*trackerItem{
+			{key: "latest_epoch", unit: "epoch", target: finalizedEpochTarget},
+			{key: "latest_block_epoch", unit: "epoch", target: finalizedEpochTarget},
+			{key: "latest_validator_epoch", unit: "epoch", target: finalizedEpochTarget},
+			{key: "last_validator_day", unit: "day"},
+		},
+	},
+	{
+		name:            "validators",
+		progressService: "validators.standard",
+		items: []*trackerItem{
+			{key: "latest_epoch", unit: "epoch", target: headEpochTarget},
+			{key: "latest_balances_epoch", unit: "epoch", target: headEpochTarget},
+		},
+		gapKeys: []string{"missed_epochs"},
+	},
+	{
+		name:            "beacon-committees",
+		progressService: "beaconcommittees.standard",
+		items: []*trackerItem{
+			{key: "latest_epoch", unit: "epoch", target: headEpochTarget},
+		},
+	},
+	{
+		name:            "proposer-duties",
+		progressService: "proposerduties.standard",
+		items: []*trackerItem{
+			{key: "latest_epoch", unit: "epoch", target: headEpochTarget},
+		},
+		gapKeys: []string{"missed_epochs"},
+	},
+	{
+		name:            "sync-committees",
+		progressService: "synccommittees.standard",
+		items: []*trackerItem{
+			{key: "latest_period", unit: "period", target: currentPeriodTarget},
+		},
+	},
+	{
+		name:            "eth1deposits",
+		progressService: "eth1deposit.getlogs",
+		items: []*trackerItem{
+			{key: "latest_block", unit: "block"},
+		},
+		gapKeys: []string{"missed_blocks"},
+	},
+	{
+		name:            "client-fingerprints",
+		progressService: "clientfingerprints.standard",
+		items: []*trackerItem{
+			{key: "latest_slot", unit: "slot", target: headSlotTarget},
+		},
+	},
+	{
+		name:            "equivocations",
+		progressService: "equivocations.standard",
+		items: []*trackerItem{
+			{key: "latest_epoch", unit: "epoch", target: headEpochTarget},
+		},
+	},
+	{
+		name:            "watchlist",
+		progressService: "watchlist.standard",
+		items: []*trackerItem{
+			{key: "latest_epoch", unit: "epoch", target: finalizedEpochTarget},
+		},
+	},
+	{
+		name:            "verifier",
+		progressService: "verifier.standard",
+		items: []*trackerItem{
+			{key: "latest_slot", unit: "slot"},
+		},
+	},
+	{
+		name:            "receipts",
+		progressService: "receipts.standard",
+		items: []*trackerItem{
+			{key: "latest_slot", unit: "slot"},
+		},
+	},
+	{
+		name:            "archiver",
+		progressService: "archiver.standard",
+		items: []*trackerItem{
+			{key: "latest_validator_balance_epoch", unit: "epoch"},
+		},
+	},
+}
+
+// Status provides the current status of chaind.
+func (s *Service) Status(ctx context.Context) (*status.Status, error) {
+	cs, err := s.chainState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &status.Status{
+		Timestamp:      time.Now(),
+		HeadSlot:       cs.headSlot,
+		FinalizedEpoch: cs.finalizedEpoch,
+		Services:       make([]*status.ServiceStatus, 0, len(trackers)),
+	}
+
+	for _, tracker := range trackers {
+		progress, err := s.chainDB.Progress(ctx, tracker.progressService)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to obtain progress for %s", tracker.name)
+		}
+		if progress == nil {
+			// Service has never run.
+			continue
+		}
+		serviceStatus := serviceStatusFromProgress(tracker, progress, cs)
+		applyEndBounds(serviceStatus, s.endBounds)
+		res.Services = append(res.Services, serviceStatus)
+	}
+
+	return res, nil
+}
+
+// Healthy returns an error if chaind is not considered healthy.
+func (s *Service) Healthy(ctx context.Context) error {
+	st, err := s.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, serviceStatus := range st.Services {
+		if serviceStatus.Name != "blocks" {
+			continue
+		}
+		for _, progress := range serviceStatus.Progress {
+			if progress.Gap != nil && *progress.Gap > int64(s.maxSlotLag) {
+				return fmt.Errorf("blocks are %d slots behind the chain head", *progress.Gap)
+			}
+		}
+	}
+
+	return nil
+}
+
+// chainState obtains the state of the chain, using the beacon node if available.
+func (s *Service) chainState(ctx context.Context) (*chainState, error) {
+	cs := &chainState{
+		headSlot: s.chainTime.CurrentSlot(),
+	}
+
+	if s.eth2Client != nil {
+		if provider, isProvider := s.eth2Client.(eth2client.BeaconBlockHeadersProvider); isProvider {
+			headerResponse, err := provider.BeaconBlockHeader(ctx, &api.BeaconBlockHeaderOpts{
+				Block: "head",
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to obtain chain head")
+			}
+			cs.headSlot = headerResponse.Data.Header.Message.Slot
+		}
+		if provider, isProvider := s.eth2Client.(eth2client.FinalityProvider); isProvider {
+			finalityResponse, err := provider.Finality(ctx, &api.FinalityOpts{
+				State: "head",
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to obtain finality")
+			}
+			cs.finalizedEpoch = &finalityResponse.Data.Finalized.Epoch
+		}
+	}
+
+	cs.headEpoch = s.chainTime.SlotToEpoch(cs.headSlot)
+	cs.currentPeriod = s.chainTime.SlotToSyncCommitteePeriod(cs.headSlot)
+
+	return cs, nil
+}
+
+// serviceStatusFromProgress creates the status of a service from its progress.
+func serviceStatusFromProgress(tracker *tracker, progress *chaindb.Progress, cs *chainState) *status.ServiceStatus {
+	serviceStatus := &status.ServiceStatus{
+		Name:     tracker.name,
+		Progress: make([]*status.Progress, 0, len(tracker.items)),
+	}
+
+	for _, item := range tracker.items {
+		itemProgress := &status.Progress{
+			Name: item.key,
+			Unit: item.unit,
+		}
+		// Negative values are used to denote that nothing has been processed.
+		if latest, exists := progress.Values[item.key]; exists && latest >= 0 {
+			itemProgress.Latest = &latest
+		}
+		if item.target != nil {
+			itemProgress.Target = item.target(cs)
+		}
+		if itemProgress.Target != nil {
+			gap := *itemProgress.Target + 1
+			if itemProgress.Latest != nil {
+				gap = *itemProgress.Target - *itemProgress.Latest
+			}
+			if gap < 0 {
+				gap = 0
+			}
+			itemProgress.Gap = &gap
+		}
+		serviceStatus.Progress = append(serviceStatus.Progress, itemProgress)
+	}
+
+	for _, key := range tracker.gapKeys {
+		serviceStatus.PendingGaps += len(progress.Gaps[key])
+	}
+
+	return serviceStatus
+}
+
+// applyEndBounds limits the targets of the progress items of a service to
+// their end bounds, if any.
+func applyEndBounds(serviceStatus *status.ServiceStatus, endBounds map[string]int64) {
+	for _, itemProgress := range serviceStatus.Progress {
+		end, exists := endBounds[fmt.Sprintf("%s/%s", serviceStatus.Name, itemProgress.Name)]
+		if !exists || itemProgress.Target == nil || *itemProgress.Target <= end {
+			continue
+		}
+		itemProgress.Target = int64Ptr(end)
+		gap := end + 1
+		if itemProgress.Latest != nil {
+			gap = end - *itemProgress.Latest
+		}
+		if gap < 0 {
+			gap = 0
+		}
+		itemProgress.Gap = &gap
+	}
+}
+
+func int64Ptr(val int64) *int64 {
+	return &val
+}
@@ -0,0 +1,213 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/status"
+)
+
+func TestServiceStatusFromProgress(t *testing.T) {
+	finalizedEpoch := phase0.Epoch(98)
+	cs := &chainState{
+		headSlot:       3200,
+		headEpoch:      100,
+		finalizedEpoch: &finalizedEpoch,
+		currentPeriod:  0,
+	}
+
+	tests := []struct {
+		name     string
+		tracker  *tracker
+		progress *chaindb.Progress
+		expected *status.ServiceStatus
+	}{
+		{
+			name: "Empty",
+			tracker: &tracker{
+				name:  "blocks",
+				items: []*trackerItem{{key: "latest_slot", unit: "slot", target: headSlotTarget}},
+			},
+			progress: &chaindb.Progress{},
+			expected: &status.ServiceStatus{
+				Name: "blocks",
+				Progress: []*status.Progress{
+					{Name: "latest_slot", Unit: "slot", Target: int64Ptr(3200), Gap: int64Ptr(3201)},
+				},
+			},
+		},
+		{
+			name: "Behind",
+			tracker: &tracker{
+				name:  "blocks",
+				items: []*trackerItem{{key: "latest_slot", unit: "slot", target: headSlotTarget}},
+			},
+			progress: &chaindb.Progress{
+				Values: map[string]int64{"latest_slot": 3190},
+			},
+			expected: &status.ServiceStatus{
+				Name: "blocks",
+				Progress: []*status.Progress{
+					{Name: "latest_slot", Unit: "slot", Latest: int64Ptr(3190), Target: int64Ptr(3200), Gap: int64Ptr(10)},
+				},
+			},
+		},
+		{
+			name: "NotStarted",
+			tracker: &tracker{
+				name:  "finalizer",
+				items: []*trackerItem{{key: "latest_epoch", unit: "epoch", target: finalizedEpochTarget}},
+			},
+			progress: &chaindb.Progress{
+				Values: map[string]int64{"latest_epoch": -1},
+			},
+			expected: &status.ServiceStatus{
+				Name: "finalizer",
+				Progress: []*status.Progress{
+					{Name: "latest_epoch", Unit: "epoch", Target: int64Ptr(98), Gap: int64Ptr(99)},
+				},
+			},
+		},
+		{
+			name: "Missed",
+			tracker: &tracker{
+				name: "validators",
+				items: []*trackerItem{
+					{key: "latest_epoch", unit: "epoch", target: headEpochTarget},
+					{key: "latest_balances_epoch", unit: "epoch"},
+				},
+				gapKeys: []string{"missed_epochs"},
+			},
+			progress: &chaindb.Progress{
+				Values: map[string]int64{"latest_epoch": 100, "latest_balances_epoch": 99},
+				Gaps:   map[string][]int64{"missed_epochs": {5, 6}},
+			},
+			expected: &status.ServiceStatus{
+				Name: "validators",
+				Progress: []*status.Progress{
+					{Name: "latest_epoch", Unit: "epoch", Latest: int64Ptr(100), Target: int64Ptr(100), Gap: int64Ptr(0)},
+					{Name: "latest_balances_epoch", Unit: "epoch", Latest: int64Ptr(99)},
+				},
+				PendingGaps: 2,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := serviceStatusFromProgress(test.tracker, test.progress, cs)
+			require.Equal(t, test.expected, res)
+		})
+	}
+}
+
+func TestApplyEndBounds(t *testing.T) {
+	tests := []struct {
+		name          string
+		serviceStatus *status.ServiceStatus
+		endBounds     map[string]int64
+		expected      *status.ServiceStatus
+	}{
+		{
+			name: "NoBounds",
+			serviceStatus: &status.ServiceStatus{
+				Name: "blocks",
+				Progress: []*status.Progress{
+					{Name: "latest_slot", Unit: "slot", Latest: int64Ptr(1000), Target: int64Ptr(3200), Gap: int64Ptr(2200)},
+				},
+			},
+			expected: &status.ServiceStatus{
+				Name: "blocks",
+				Progress: []*status.Progress{
+					{Name: "latest_slot", Unit: "slot", Latest: int64Ptr(1000), Target: int64Ptr(3200), Gap: int64Ptr(2200)},
+				},
+			},
+		},
+		{
+			name: "Bounded",
+			serviceStatus: &status.ServiceStatus{
+				Name: "blocks",
+				Progress: []*status.Progress{
+					{Name: "latest_slot", Unit: "slot", Latest: int64Ptr(1000), Target: int64Ptr(3200), Gap: int64Ptr(2200)},
+				},
+			},
+			endBounds: map[string]int64{"blocks/latest_slot": 1999},
+			expected: &status.ServiceStatus{
+				Name: "blocks",
+				Progress: []*status.Progress{
+					{Name: "latest_slot", Unit: "slot", Latest: int64Ptr(1000), Target: int64Ptr(1999), Gap: int64Ptr(999)},
+				},
+			},
+		},
+		{
+			name: "BoundReached",
+			serviceStatus: &status.ServiceStatus{
+				Name: "blocks",
+				Progress: []*status.Progress{
+					{Name: "latest_slot", Unit: "slot", Latest: int64Ptr(1999), Target: int64Ptr(3200), Gap: int64Ptr(1201)},
+				},
+			},
+			endBounds: map[string]int64{"blocks/latest_slot": 1999},
+			expected: &status.ServiceStatus{
+				Name: "blocks",
+				Progress: []*status.Progress{
+					{Name: "latest_slot", Unit: "slot", Latest: int64Ptr(1999), Target: int64Ptr(1999), Gap: int64Ptr(0)},
+				},
+			},
+		},
+		{
+			name: "BoundAfterTarget",
+			serviceStatus: &status.ServiceStatus{
+				Name: "summarizer",
+				Progress: []*status.Progress{
+					{Name: "latest_epoch", Unit: "epoch", Latest: int64Ptr(90), Target: int64Ptr(98), Gap: int64Ptr(8)},
+				},
+			},
+			endBounds: map[string]int64{"summarizer/latest_epoch": 200},
+			expected: &status.ServiceStatus{
+				Name: "summarizer",
+				Progress: []*status.Progress{
+					{Name: "latest_epoch", Unit: "epoch", Latest: int64Ptr(90), Target: int64Ptr(98), Gap: int64Ptr(8)},
+				},
+			},
+		},
+		{
+			name: "NotStarted",
+			serviceStatus: &status.ServiceStatus{
+				Name: "validators",
+				Progress: []*status.Progress{
+					{Name: "latest_balances_epoch", Unit: "epoch", Target: int64Ptr(100), Gap: int64Ptr(101)},
+				},
+			},
+			endBounds: map[string]int64{"validators/latest_balances_epoch": 9},
+			expected: &status.ServiceStatus{
+				Name: "validators",
+				Progress: []*status.Progress{
+					{Name: "latest_balances_epoch", Unit: "epoch", Target: int64Ptr(9), Gap: int64Ptr(10)},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			applyEndBounds(test.serviceStatus, test.endBounds)
+			require.Equal(t, test.expected, test.serviceStatus)
+		})
+	}
+}
@@ -0,0 +1,392 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// backfill is a single pass of the summarizer over epoch summaries, validator
+// epoch summaries and validator day summaries.  The work is planned as a DAG
+// of tasks, allowing calculations with independent inputs to run concurrently
+// whilst summaries are stored, and metadata updated, in order.
+type backfill struct {
+	dag *dag
+	// md is the metadata, shared by all tasks.
+	md *metadata
+	// mdMu serializes the tasks that store summaries, as they update md.
+	mdMu sync.Mutex
+	// epochStores are the IDs of the tasks that store epoch summaries.
+	epochStores map[phase0.Epoch]string
+	// lastEpoch is the last epoch for which an epoch summary is planned.
+	lastEpoch *phase0.Epoch
+	// validatorEpochStores are the IDs of the tasks that store validator epoch summaries.
+	validatorEpochStores map[phase0.Epoch]string
+	// lastValidatorEpoch is the last epoch for which validator epoch summaries are planned.
+	lastValidatorEpoch *phase0.Epoch
+}
+
+// summarizeEpochsAndValidators summarizes epochs, validator epochs and, if
+// periodic rollups are enabled, validator days.
+func (s *Service) summarizeEpochsAndValidators(ctx context.Context, targetEpoch phase0.Epoch) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "summarizeEpochsAndValidators",
+		trace.WithAttributes(
+			attribute.Int64("target epoch", int64(targetEpoch)),
+		))
+	defer span.End()
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata for epoch summarizer")
+	}
+
+	bf := &backfill{
+		dag:                  newDAG(),
+		md:                   md,
+		epochStores:          make(map[phase0.Epoch]string),
+		validatorEpochStores: make(map[phase0.Epoch]string),
+	}
+
+	if err := s.planEpochs(ctx, bf, targetEpoch); err != nil {
+		return err
+	}
+	if err := s.planValidators(ctx, bf, targetEpoch); err != nil {
+		return err
+	}
+	if md.PeriodicValidatorRollups {
+		if err := s.planValidatorDays(bf); err != nil {
+			return err
+		}
+	}
+
+	log.Trace().Int("tasks", bf.dag.size()).Uint64("concurrency", s.concurrency).Msg("Running summarizer tasks")
+	err = bf.dag.run(ctx, s.concurrency)
+	if errors.Is(err, errNotReady) {
+		log.Debug().Msg("Not enough data to summarize further")
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if s.epochSummaries {
+		s.clearFailedEpochs(ctx, md)
+	}
+
+	return nil
+}
+
+// planEpochs plans the tasks to summarize epochs up to the target epoch.
+func (s *Service) planEpochs(ctx context.Context, bf *backfill, targetEpoch phase0.Epoch) error {
+	if !s.epochSummaries {
+		return nil
+	}
+
+	md := bf.md
+	firstEpoch := md.LastEpoch
+	if firstEpoch != 0 {
+		firstEpoch++
+	}
+	firstEpoch = s.boundFirstEpoch(firstEpoch)
+
+	if targetEpoch < firstEpoch {
+		log.Trace().Uint64("target_epoch", uint64(targetEpoch)).Uint64("first_epoch", uint64(firstEpoch)).Msg("Target epoch before first epoch; nothing to do")
+		return nil
+	}
+
+	// Limit the number of epochs summarised per pass, if we are also pruning.
+	maxEpochsPerRun := phase0.Epoch(s.maxDaysPerRun) * s.epochsPerDay()
+	if s.validatorEpochRetention != nil && maxEpochsPerRun > 0 && targetEpoch-firstEpoch > maxEpochsPerRun {
+		log.Trace().Uint64("first_epoch", uint64(firstEpoch)).Uint64("old_target_epoch", uint64(targetEpoch)).Uint64("max_epochs_per_run", uint64(maxEpochsPerRun)).Uint64("new_target_epoch", uint64(firstEpoch+maxEpochsPerRun)).Msg("Reducing target epoch")
+		targetEpoch = firstEpoch + maxEpochsPerRun
+	}
+	log.Trace().Uint64("first_epoch", uint64(firstEpoch)).Uint64("target_epoch", uint64(targetEpoch)).Msg("Epochs catchup bounds")
+
+	s.retryFailedEpochs(ctx, md)
+	deadEpochs, err := s.deadEpochs(ctx, firstEpoch, targetEpoch)
+	if err != nil {
+		return err
+	}
+
+	window := phase0.Epoch(s.concurrency)
+	for epoch := firstEpoch; epoch <= targetEpoch; epoch++ {
+		storeID := fmt.Sprintf("epoch %d store", epoch)
+		if deadEpochs[epoch] {
+			if err := bf.dag.add(storeID, func(ctx context.Context) error {
+				log.Trace().Uint64("epoch", uint64(epoch)).Msg("Skipping dead epoch")
+				bf.mdMu.Lock()
+				defer bf.mdMu.Unlock()
+				if err := s.skipEpoch(ctx, md, epoch); err != nil {
+					return errors.Wrapf(err, "failed to skip epoch %d", epoch)
+				}
+
+				return nil
+			}, bf.epochStores[epoch-1]); err != nil {
+				return err
+			}
+			bf.epochStores[epoch] = storeID
+			continue
+		}
+
+		// The calculation of an epoch waits for the epoch a window earlier to
+		// be stored, bounding the number of calculated epochs held in memory.
+		var data *epochSummaryData
+		var updated bool
+		var summaryErr error
+		computeID := fmt.Sprintf("epoch %d compute", epoch)
+		if err := bf.dag.add(computeID, func(ctx context.Context) error {
+			if s.loadShedder != nil {
+				if err := s.loadShedder.AwaitQuiet(ctx); err != nil {
+					return errors.Wrap(err, "failed to await quiet period")
+				}
+			}
+			data, updated, summaryErr = s.epochSummary(ctx, epoch)
+
+			return nil
+		}, bf.epochStores[epoch-window]); err != nil {
+			return err
+		}
+
+		if err := bf.dag.add(storeID, func(ctx context.Context) error {
+			defer func() {
+				data = nil
+			}()
+			bf.mdMu.Lock()
+			defer bf.mdMu.Unlock()
+			if summaryErr != nil {
+				if s.recordFailedEpoch(ctx, epoch, summaryErr) {
+					if err := s.skipEpoch(ctx, md, epoch); err != nil {
+						return errors.Wrapf(err, "failed to skip epoch %d", epoch)
+					}
+					return nil
+				}
+				return errors.Wrapf(summaryErr, "failed to update summary for epoch %d", epoch)
+			}
+			if !updated {
+				log.Debug().Uint64("epoch", uint64(epoch)).Msg("Not enough data to update summary")
+				return errNotReady
+			}
+
+			return s.storeEpochSummary(ctx, md, data)
+		}, computeID, bf.epochStores[epoch-1]); err != nil {
+			return err
+		}
+		bf.epochStores[epoch] = storeID
+	}
+	bf.lastEpoch = &targetEpoch
+
+	return nil
+}
+
+// planValidators plans the tasks to summarize validator epochs up to the
+// target epoch.  Each epoch is summarized only once its epoch summary is stored.
+func (s *Service) planValidators(ctx context.Context, bf *backfill, targetEpoch phase0.Epoch) error {
+	// If validator summaries are not enabled we still summarize watched validators.
+	var watched map[phase0.ValidatorIndex]bool
+	if !s.validatorSummaries {
+		var err error
+		watched, err = s.watchedValidators(ctx)
+		if err != nil {
+			return err
+		}
+		if len(watched) == 0 {
+			return nil
+		}
+	}
+
+	md := bf.md
+	firstEpoch := md.LastValidatorEpoch
+	if firstEpoch != 0 {
+		firstEpoch++
+	} else if watched != nil {
+		// Start from the earliest activation of the watched validators rather than genesis.
+		var err error
+		firstEpoch, err = s.earliestActivationEpoch(ctx, watched)
+		if err != nil {
+			return err
+		}
+	}
+	firstEpoch = s.boundFirstEpoch(firstEpoch)
+
+	// The last epoch summarized, including those planned, tells us how far we
+	// can summarize, as it checks for the component data.  As such, if the
+	// finalized epoch is beyond our summarized epoch we truncate to the
+	// summarized value.
+	// However, if we don't have validator balances the summarizer won't run at all
+	// for epochs, so if the last epoch is 0 we continue.
+	lastEpoch := md.LastEpoch
+	if bf.lastEpoch != nil && *bf.lastEpoch > lastEpoch {
+		lastEpoch = *bf.lastEpoch
+	}
+	if targetEpoch > lastEpoch && lastEpoch > 0 {
+		targetEpoch = lastEpoch
+	}
+
+	if targetEpoch < firstEpoch {
+		log.Trace().Uint64("target_epoch", uint64(targetEpoch)).Uint64("first_epoch", uint64(firstEpoch)).Msg("Target epoch before first epoch; nothing to do")
+		return nil
+	}
+
+	// Limit the number of epochs summarised per pass, if we are also pruning.
+	maxEpochsPerRun := phase0.Epoch(s.maxDaysPerRun) * s.epochsPerDay()
+	if s.validatorEpochRetention != nil && maxEpochsPerRun > 0 && targetEpoch-firstEpoch > maxEpochsPerRun {
+		log.Trace().Uint64("first_epoch", uint64(firstEpoch)).Uint64("old_target_epoch", uint64(targetEpoch)).Uint64("max_epochs_per_run", uint64(maxEpochsPerRun)).Uint64("new_target_epoch", uint64(firstEpoch+maxEpochsPerRun)).Msg("Reducing target validator epoch")
+		targetEpoch = firstEpoch + maxEpochsPerRun
+	}
+	log.Trace().Uint64("first_epoch", uint64(firstEpoch)).Uint64("target_epoch", uint64(targetEpoch)).Msg("Validators catchup bounds")
+
+	window := phase0.Epoch(s.concurrency)
+	for epoch := firstEpoch; epoch <= targetEpoch; epoch++ {
+		var summaries []*chaindb.ValidatorEpochSummary
+		computeID := fmt.Sprintf("validator epoch %d compute", epoch)
+		if err := bf.dag.add(computeID, func(ctx context.Context) error {
+			log.Trace().Uint64("epoch", uint64(epoch)).Msg("Summarizing epoch")
+			if s.loadShedder != nil {
+				if err := s.loadShedder.AwaitQuiet(ctx); err != nil {
+					return errors.Wrap(err, "failed to await quiet period")
+				}
+			}
+			var err error
+			summaries, err = s.validatorEpochSummaries(ctx, epoch, watched)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("failed to update validator summaries in epoch %d", epoch))
+			}
+
+			return nil
+		}, bf.epochStores[epoch], bf.validatorEpochStores[epoch-window]); err != nil {
+			return err
+		}
+
+		storeID := fmt.Sprintf("validator epoch %d store", epoch)
+		if err := bf.dag.add(storeID, func(ctx context.Context) error {
+			defer func() {
+				summaries = nil
+			}()
+			bf.mdMu.Lock()
+			defer bf.mdMu.Unlock()
+			if err := s.storeValidatorEpochSummaries(ctx, md, epoch, summaries); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("failed to update validator summaries in epoch %d", epoch))
+			}
+
+			return nil
+		}, computeID, bf.validatorEpochStores[epoch-1]); err != nil {
+			return err
+		}
+		bf.validatorEpochStores[epoch] = storeID
+	}
+	bf.lastValidatorEpoch = &targetEpoch
+
+	return nil
+}
+
+// planValidatorDays plans the tasks to summarize validator days.  Each day is
+// summarized only once the epoch and validator epoch summaries for its last
+// epoch are stored.
+func (s *Service) planValidatorDays(bf *backfill) error {
+	md := bf.md
+	lastValidatorEpoch := md.LastValidatorEpoch
+	if bf.lastValidatorEpoch != nil && *bf.lastValidatorEpoch > lastValidatorEpoch {
+		lastValidatorEpoch = *bf.lastValidatorEpoch
+	}
+	days := s.validatorDays(md.LastValidatorDay, lastValidatorEpoch)
+
+	window := int(s.concurrency)
+	dayStores := make([]string, len(days))
+	for i, startTime := range days {
+		// The end epoch is the last epoch that has finished by the end of the day.
+		endEpoch := s.chainTime.TimestampToEpoch(startTime.AddDate(0, 0, 1)) - 1
+		deps := []string{bf.epochStores[endEpoch], bf.validatorEpochStores[endEpoch]}
+		if i >= window {
+			deps = append(deps, dayStores[i-window])
+		}
+
+		var summaries []*chaindb.ValidatorDaySummary
+		computeID := fmt.Sprintf("validator day %s compute", startTime.Format("2006-01-02"))
+		if err := bf.dag.add(computeID, func(ctx context.Context) error {
+			var err error
+			summaries, err = s.validatorDaySummaries(ctx, startTime)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("failed to update validator summaries for day %s", startTime.Format("2006-01-02")))
+			}
+			if summaries == nil {
+				return errNotReady
+			}
+
+			return nil
+		}, deps...); err != nil {
+			return err
+		}
+
+		storeID := fmt.Sprintf("validator day %s store", startTime.Format("2006-01-02"))
+		prevStoreID := ""
+		if i > 0 {
+			prevStoreID = dayStores[i-1]
+		}
+		if err := bf.dag.add(storeID, func(ctx context.Context) error {
+			defer func() {
+				summaries = nil
+			}()
+			bf.mdMu.Lock()
+			defer bf.mdMu.Unlock()
+			if err := s.storeValidatorDaySummaries(ctx, md, startTime, summaries); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("failed to update validator summaries for day %s", startTime.Format("2006-01-02")))
+			}
+
+			return nil
+		}, computeID, prevStoreID); err != nil {
+			return err
+		}
+		dayStores[i] = storeID
+	}
+
+	return nil
+}
+
+// validatorDays provides the start times of the days that can be summarized
+// given the last day summarized and the last validator epoch summarized.
+func (s *Service) validatorDays(lastValidatorDay int64, lastValidatorEpoch phase0.Epoch) []time.Time {
+	epochSummariesTime := s.chainTime.StartOfEpoch(lastValidatorEpoch).In(time.UTC)
+	daySummariesTime := time.Unix(lastValidatorDay, 0).In(time.UTC)
+	log.Trace().Time("epoch_summaries_time", epochSummariesTime).Time("day_summaries_time", daySummariesTime).Msg("Times")
+	if !epochSummariesTime.After(daySummariesTime.AddDate(0, 0, 1)) {
+		return nil
+	}
+
+	var startTime time.Time
+	if lastValidatorDay == -1 {
+		// Start at the beginning of the day in which genesis occurred.
+		genesis := s.chainTime.GenesisTime().In(time.UTC)
+		startTime = time.Date(genesis.Year(), genesis.Month(), genesis.Day(), 0, 0, 0, 0, time.UTC)
+	} else {
+		startTime = daySummariesTime.AddDate(0, 0, 1)
+	}
+	endTimestamp := epochSummariesTime.AddDate(0, 0, -1)
+
+	days := make([]time.Time, 0)
+	for timestamp := startTime; timestamp.Before(endTimestamp); timestamp = timestamp.AddDate(0, 0, 1) {
+		days = append(days, timestamp)
+	}
+
+	return days
+}
@@ -0,0 +1,398 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// epochBalanceItems are the items in the canonical blocks of an epoch that
+// change the balances of validators.
+type epochBalanceItems struct {
+	withdrawn map[phase0.ValidatorIndex]phase0.Gwei
+	deposited map[phase0.ValidatorIndex]phase0.Gwei
+	proposers map[phase0.ValidatorIndex]bool
+	slashed   map[phase0.ValidatorIndex]bool
+	// syncCommittee is the number of positions that each validator holds
+	// in the sync committee.
+	syncCommittee map[phase0.ValidatorIndex]uint64
+}
+
+// summarizeBalanceAnomalies detects anomalous changes in validator balances
+// for all epochs that have been finalized.
+func (s *Service) summarizeBalanceAnomalies(ctx context.Context, targetEpoch phase0.Epoch) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "summarizeBalanceAnomalies",
+		trace.WithAttributes(
+			attribute.Int64("target epoch", int64(targetEpoch)),
+		))
+	defer span.End()
+
+	if !s.balanceAnomalies {
+		return nil
+	}
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata for balance anomaly summarizer")
+	}
+
+	// Expected rewards and penalties are calculated from Altair onwards.
+	firstEpoch := s.boundFirstEpoch(phase0.Epoch(md.LastBalanceAnomalyEpoch + 1))
+	if altairEpoch := s.chainTime.AltairInitialEpoch(); firstEpoch < altairEpoch {
+		firstEpoch = altairEpoch
+	}
+	// The change over an epoch requires the balances at the start of the
+	// following epoch, and the summary of the epoch itself.
+	if md.LastEpoch < targetEpoch {
+		targetEpoch = md.LastEpoch
+	}
+	if targetEpoch == 0 {
+		return nil
+	}
+	targetEpoch--
+	if targetEpoch < firstEpoch {
+		log.Trace().Uint64("target_epoch", uint64(targetEpoch)).Uint64("first_epoch", uint64(firstEpoch)).Msg("Target epoch before first epoch; nothing to do")
+		return nil
+	}
+	epochsPerDay := s.epochsPerDay()
+	maxEpochsPerRun := phase0.Epoch(s.maxDaysPerRun) * epochsPerDay
+	if maxEpochsPerRun > 0 && targetEpoch-firstEpoch >= maxEpochsPerRun {
+		targetEpoch = firstEpoch + maxEpochsPerRun - 1
+	}
+	log.Trace().Uint64("first_epoch", uint64(firstEpoch)).Uint64("target_epoch", uint64(targetEpoch)).Msg("Balance anomalies catchup bounds")
+
+	// Balance anomalies are updated a day at a time.
+	for startEpoch := firstEpoch; startEpoch <= targetEpoch; startEpoch += epochsPerDay {
+		endEpoch := startEpoch + epochsPerDay - 1
+		if endEpoch > targetEpoch {
+			endEpoch = targetEpoch
+		}
+		if err := s.summarizeBalanceAnomaliesInEpochs(ctx, md, startEpoch, endEpoch); err != nil {
+			return errors.Wrapf(err, "failed to update balance anomalies for epochs %d to %d", startEpoch, endEpoch)
+		}
+	}
+
+	return nil
+}
+
+// summarizeBalanceAnomaliesInEpochs detects anomalous changes in validator
+// balances over the given epochs.
+func (s *Service) summarizeBalanceAnomaliesInEpochs(ctx context.Context,
+	md *metadata,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "summarizeBalanceAnomaliesInEpochs",
+		trace.WithAttributes(
+			attribute.Int64("start epoch", int64(startEpoch)),
+			attribute.Int64("end epoch", int64(endEpoch)),
+		))
+	defer span.End()
+
+	items, err := s.epochBalanceItems(ctx, startEpoch, endEpoch)
+	if err != nil {
+		return err
+	}
+
+	epochSummaries, err := s.chainDB.(chaindb.EpochSummariesProvider).EpochSummaries(ctx, &chaindb.EpochSummaryFilter{
+		From: &startEpoch,
+		To:   &endEpoch,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain epoch summaries")
+	}
+	activeBalances := make(map[phase0.Epoch]phase0.Gwei, len(epochSummaries))
+	for _, epochSummary := range epochSummaries {
+		activeBalances[epochSummary.Epoch] = epochSummary.ActiveBalance
+	}
+
+	anomalies := make([]*chaindb.BalanceAnomaly, 0)
+	previousBalances, err := s.validatorsProvider.ValidatorBalancesByEpoch(ctx, startEpoch)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain validator balances")
+	}
+	for epoch := startEpoch; epoch <= endEpoch; epoch++ {
+		balances, err := s.validatorsProvider.ValidatorBalancesByEpoch(ctx, epoch+1)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain validator balances")
+		}
+		activeBalance, exists := activeBalances[epoch]
+		if !exists {
+			return errors.Errorf("no epoch summary for epoch %d", epoch)
+		}
+		if len(previousBalances) == 0 || len(balances) == 0 {
+			log.Trace().Uint64("epoch", uint64(epoch)).Msg("No validator balances for epoch; skipping")
+		} else {
+			anomalies = append(anomalies, s.epochBalanceAnomalies(epoch, activeBalance, previousBalances, balances, items[epoch])...)
+		}
+		previousBalances = balances
+	}
+	log.Trace().Uint64("start_epoch", uint64(startEpoch)).Uint64("end_epoch", uint64(endEpoch)).Int("anomalies", len(anomalies)).Msg("Detected balance anomalies")
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to set balance anomalies")
+	}
+
+	if len(anomalies) > 0 {
+		if err := s.chainDB.(chaindb.BalanceAnomaliesSetter).SetBalanceAnomalies(ctx, anomalies); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set balance anomalies")
+		}
+	}
+
+	md.LastBalanceAnomalyEpoch = int64(endEpoch)
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	for _, anomaly := range anomalies {
+		if s.balanceAnomalyAlerts {
+			log.Warn().
+				Str("reason", anomaly.Reason).
+				Uint64("validator_index", uint64(anomaly.ValidatorIndex)).
+				Uint64("epoch", uint64(anomaly.Epoch)).
+				Int64("delta", anomaly.Delta).
+				Int64("deviation", anomaly.Deviation).
+				Msg("Balance anomaly")
+		}
+		monitorBalanceAnomaly(anomaly.Reason)
+	}
+
+	return nil
+}
+
+// epochBalanceItems obtains the items in the canonical blocks of the given
+// epochs that change the balances of validators, keyed by epoch.
+func (s *Service) epochBalanceItems(ctx context.Context,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	map[phase0.Epoch]*epochBalanceItems,
+	error,
+) {
+	items := make(map[phase0.Epoch]*epochBalanceItems, endEpoch+1-startEpoch)
+	syncCommittees := make(map[uint64]map[phase0.ValidatorIndex]uint64)
+	for epoch := startEpoch; epoch <= endEpoch; epoch++ {
+		period := s.chainTime.EpochToSyncCommitteePeriod(epoch)
+		if _, exists := syncCommittees[period]; !exists {
+			syncCommittee, err := s.chainDB.(chaindb.SyncCommitteesProvider).SyncCommittee(ctx, period)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to obtain sync committee for period %d", period)
+			}
+			syncCommittees[period] = make(map[phase0.ValidatorIndex]uint64, len(syncCommittee.Committee))
+			for _, index := range syncCommittee.Committee {
+				syncCommittees[period][index]++
+			}
+		}
+		items[epoch] = &epochBalanceItems{
+			withdrawn:     make(map[phase0.ValidatorIndex]phase0.Gwei),
+			deposited:     make(map[phase0.ValidatorIndex]phase0.Gwei),
+			proposers:     make(map[phase0.ValidatorIndex]bool),
+			slashed:       make(map[phase0.ValidatorIndex]bool),
+			syncCommittee: syncCommittees[period],
+		}
+	}
+
+	startSlot := s.chainTime.FirstSlotOfEpoch(startEpoch)
+	endSlot := s.chainTime.LastSlotOfEpoch(endEpoch)
+	canonical := true
+
+	blocks, err := s.blocksProvider.Blocks(ctx, &chaindb.BlockFilter{
+		From:      &startSlot,
+		To:        &endSlot,
+		Canonical: &canonical,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain canonical blocks")
+	}
+	for _, block := range blocks {
+		items[s.chainTime.SlotToEpoch(block.Slot)].proposers[block.ProposerIndex] = true
+	}
+
+	withdrawals, err := s.withdrawalsProvider.Withdrawals(ctx, &chaindb.WithdrawalFilter{
+		From:      &startSlot,
+		To:        &endSlot,
+		Canonical: &canonical,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain withdrawals")
+	}
+	for _, withdrawal := range withdrawals {
+		items[s.chainTime.SlotToEpoch(withdrawal.InclusionSlot)].withdrawn[withdrawal.ValidatorIndex] += withdrawal.Amount
+	}
+
+	deposits, err := s.depositsProvider.Deposits(ctx, &chaindb.DepositFilter{
+		From:      &startSlot,
+		To:        &endSlot,
+		Canonical: &canonical,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain deposits")
+	}
+	if len(deposits) > 0 {
+		pubKeys := make([]phase0.BLSPubKey, 0, len(deposits))
+		for _, deposit := range deposits {
+			pubKeys = append(pubKeys, deposit.ValidatorPubKey)
+		}
+		validators, err := s.validatorsProvider.ValidatorsByPublicKey(ctx, pubKeys)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators for deposits")
+		}
+		for _, deposit := range deposits {
+			validator, exists := validators[deposit.ValidatorPubKey]
+			if !exists {
+				// Deposit for a validator that has yet to be added to the registry.
+				continue
+			}
+			items[s.chainTime.SlotToEpoch(deposit.InclusionSlot)].deposited[validator.Index] += deposit.Amount
+		}
+	}
+
+	attesterSlashings, err := s.attesterSlashingsProvider.AttesterSlashings(ctx, &chaindb.AttesterSlashingFilter{
+		From:      &startSlot,
+		To:        &endSlot,
+		Canonical: &canonical,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attester slashings")
+	}
+	for _, attesterSlashing := range attesterSlashings {
+		epoch := s.chainTime.SlotToEpoch(attesterSlashing.InclusionSlot)
+		for _, index := range intersection(attesterSlashing.Attestation1Indices, attesterSlashing.Attestation2Indices) {
+			items[epoch].slashed[index] = true
+		}
+	}
+
+	proposerSlashings, err := s.proposerSlashingsProvider.ProposerSlashings(ctx, &chaindb.ProposerSlashingFilter{
+		From:      &startSlot,
+		To:        &endSlot,
+		Canonical: &canonical,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain proposer slashings")
+	}
+	for _, proposerSlashing := range proposerSlashings {
+		items[s.chainTime.SlotToEpoch(proposerSlashing.InclusionSlot)].slashed[proposerSlashing.Header1ProposerIndex] = true
+	}
+
+	return items, nil
+}
+
+// epochBalanceAnomalies returns the changes in validator balances over the
+// given epoch that are outside of the range expected from the rewards and
+// penalties of the epoch by more than the threshold.
+// Inactivity penalties are not expected, so balance changes during an
+// inactivity leak will show up as anomalies.
+func (s *Service) epochBalanceAnomalies(epoch phase0.Epoch,
+	totalActiveBalance phase0.Gwei,
+	previousBalances []*chaindb.ValidatorBalance,
+	balances []*chaindb.ValidatorBalance,
+	items *epochBalanceItems,
+) []*chaindb.BalanceAnomaly {
+	if totalActiveBalance == 0 || s.effectiveBalanceIncrement == 0 || s.weightDenominator == 0 {
+		return nil
+	}
+
+	previous := make(map[phase0.ValidatorIndex]*chaindb.ValidatorBalance, len(previousBalances))
+	for _, balance := range previousBalances {
+		previous[balance.Index] = balance
+	}
+
+	baseRewardPerIncrement := s.effectiveBalanceIncrement * s.baseRewardFactor / integerSquareRoot(uint64(totalActiveBalance))
+	syncRewards := int64(s.slotsPerEpoch * uint64(s.syncParticipantReward(totalActiveBalance)))
+
+	anomalies := make([]*chaindb.BalanceAnomaly, 0)
+	for _, balance := range balances {
+		// A validator without a previous balance was added to the registry
+		// in the epoch, with a previous balance of 0.
+		previousBalance := phase0.Gwei(0)
+		effectiveBalance := phase0.Gwei(0)
+		if previousValidatorBalance, exists := previous[balance.Index]; exists {
+			previousBalance = previousValidatorBalance.Balance
+			effectiveBalance = previousValidatorBalance.EffectiveBalance
+		}
+
+		baseReward := uint64(effectiveBalance) / s.effectiveBalanceIncrement * baseRewardPerIncrement
+		expectedMinDelta := -int64(baseReward * uint64(timelySourceWeight+timelyTargetWeight) / s.weightDenominator)
+		expectedMaxDelta := int64(baseReward * uint64(timelySourceWeight+timelyTargetWeight+timelyHeadWeight) / s.weightDenominator)
+		if seats := items.syncCommittee[balance.Index]; seats > 0 {
+			expectedMinDelta -= int64(seats) * syncRewards
+			expectedMaxDelta += int64(seats) * syncRewards
+		}
+
+		withdrawn := items.withdrawn[balance.Index]
+		deposited := items.deposited[balance.Index]
+		delta := int64(balance.Balance) - int64(previousBalance) + int64(withdrawn) - int64(deposited)
+
+		anomaly := &chaindb.BalanceAnomaly{
+			ValidatorIndex:   balance.Index,
+			Epoch:            epoch,
+			PreviousBalance:  previousBalance,
+			Balance:          balance.Balance,
+			Withdrawn:        withdrawn,
+			Deposited:        deposited,
+			Delta:            delta,
+			ExpectedMinDelta: expectedMinDelta,
+		}
+		// Proposer rewards depend on the contents of the block, so there
+		// is no upper bound on the change for proposers.
+		if !items.proposers[balance.Index] {
+			anomaly.ExpectedMaxDelta = &expectedMaxDelta
+		}
+
+		switch {
+		case delta < expectedMinDelta:
+			anomaly.Deviation = delta - expectedMinDelta
+		case anomaly.ExpectedMaxDelta != nil && delta > expectedMaxDelta:
+			anomaly.Deviation = delta - expectedMaxDelta
+		default:
+			continue
+		}
+
+		switch {
+		case anomaly.Deviation > 0:
+			if uint64(anomaly.Deviation) <= s.balanceAnomalyThreshold {
+				continue
+			}
+			anomaly.Reason = chaindb.BalanceAnomalyReasonExcessReward
+		default:
+			if uint64(-anomaly.Deviation) <= s.balanceAnomalyThreshold {
+				continue
+			}
+			if items.slashed[balance.Index] {
+				anomaly.Reason = chaindb.BalanceAnomalyReasonSlashing
+			} else {
+				anomaly.Reason = chaindb.BalanceAnomalyReasonExcessPenalty
+			}
+		}
+
+		anomalies = append(anomalies, anomaly)
+	}
+
+	return anomalies
+}
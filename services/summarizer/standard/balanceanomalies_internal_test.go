@@ -0,0 +1,137 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestEpochBalanceAnomalies(t *testing.T) {
+	s := &Service{
+		slotsPerEpoch:             32,
+		effectiveBalanceIncrement: 1000000000,
+		baseRewardFactor:          64,
+		syncCommitteeSize:         512,
+		syncRewardWeight:          2,
+		weightDenominator:         64,
+		balanceAnomalyThreshold:   1000,
+	}
+
+	// 500,000 validators with 32 Ether each, giving a base reward of 16160,
+	// and a sync committee reward of 15411 per slot.
+	totalActiveBalance := phase0.Gwei(16000000000000000)
+	previousBalances := make([]*chaindb.ValidatorBalance, 0)
+	for i := 1; i <= 9; i++ {
+		previousBalances = append(previousBalances, &chaindb.ValidatorBalance{
+			Index:            phase0.ValidatorIndex(i),
+			Epoch:            100,
+			Balance:          32000000000,
+			EffectiveBalance: 32000000000,
+		})
+	}
+	balances := []*chaindb.ValidatorBalance{
+		// Within the expected range.
+		{Index: 1, Epoch: 101, Balance: 32000013000, EffectiveBalance: 32000000000},
+		// Penalty larger than expected.
+		{Index: 2, Epoch: 101, Balance: 31999980000, EffectiveBalance: 32000000000},
+		// Slashed.
+		{Index: 3, Epoch: 101, Balance: 31000000000, EffectiveBalance: 32000000000},
+		// Reward larger than expected.
+		{Index: 4, Epoch: 101, Balance: 32000020000, EffectiveBalance: 32000000000},
+		// Proposer, so no upper bound.
+		{Index: 5, Epoch: 101, Balance: 32001000000, EffectiveBalance: 32000000000},
+		// Withdrawal.
+		{Index: 6, Epoch: 101, Balance: 31000000000, EffectiveBalance: 32000000000},
+		// Sync committee member.
+		{Index: 7, Epoch: 101, Balance: 31999600000, EffectiveBalance: 32000000000},
+		// Penalty larger than expected, but within the threshold.
+		{Index: 8, Epoch: 101, Balance: 31999989500, EffectiveBalance: 32000000000},
+		// Top up that is missing its deposit.
+		{Index: 9, Epoch: 101, Balance: 33000000000, EffectiveBalance: 32000000000},
+		// New validator.
+		{Index: 10, Epoch: 101, Balance: 32000000000, EffectiveBalance: 32000000000},
+	}
+	items := &epochBalanceItems{
+		withdrawn: map[phase0.ValidatorIndex]phase0.Gwei{
+			6: 1000005000,
+		},
+		deposited: map[phase0.ValidatorIndex]phase0.Gwei{
+			10: 32000000000,
+		},
+		proposers: map[phase0.ValidatorIndex]bool{
+			5: true,
+		},
+		slashed: map[phase0.ValidatorIndex]bool{
+			3: true,
+		},
+		syncCommittee: map[phase0.ValidatorIndex]uint64{
+			7: 1,
+		},
+	}
+
+	expectedMaxDelta := int64(13635)
+	require.Equal(t, []*chaindb.BalanceAnomaly{
+		{
+			ValidatorIndex:   2,
+			Epoch:            100,
+			Reason:           chaindb.BalanceAnomalyReasonExcessPenalty,
+			PreviousBalance:  32000000000,
+			Balance:          31999980000,
+			Delta:            -20000,
+			ExpectedMinDelta: -10100,
+			ExpectedMaxDelta: &expectedMaxDelta,
+			Deviation:        -9900,
+		},
+		{
+			ValidatorIndex:   3,
+			Epoch:            100,
+			Reason:           chaindb.BalanceAnomalyReasonSlashing,
+			PreviousBalance:  32000000000,
+			Balance:          31000000000,
+			Delta:            -1000000000,
+			ExpectedMinDelta: -10100,
+			ExpectedMaxDelta: &expectedMaxDelta,
+			Deviation:        -999989900,
+		},
+		{
+			ValidatorIndex:   4,
+			Epoch:            100,
+			Reason:           chaindb.BalanceAnomalyReasonExcessReward,
+			PreviousBalance:  32000000000,
+			Balance:          32000020000,
+			Delta:            20000,
+			ExpectedMinDelta: -10100,
+			ExpectedMaxDelta: &expectedMaxDelta,
+			Deviation:        6365,
+		},
+		{
+			ValidatorIndex:   9,
+			Epoch:            100,
+			Reason:           chaindb.BalanceAnomalyReasonExcessReward,
+			PreviousBalance:  32000000000,
+			Balance:          33000000000,
+			Delta:            1000000000,
+			ExpectedMinDelta: -10100,
+			ExpectedMaxDelta: &expectedMaxDelta,
+			Deviation:        999986365,
+		},
+	}, s.epochBalanceAnomalies(100, totalActiveBalance, previousBalances, balances, items))
+
+	// No active balance.
+	require.Empty(t, s.epochBalanceAnomalies(100, 0, previousBalances, balances, items))
+}
@@ -0,0 +1,231 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Blob gas parameters, as defined in EIP-4844.  These are execution layer
+// parameters so are not available from the beacon node's specification.
+const (
+	targetBlobGasPerBlock     = uint64(393216)
+	minBlobBaseFee            = int64(1)
+	blobBaseFeeUpdateFraction = int64(3338477)
+)
+
+// summarizeBlobFees calculates the blob fees of canonical blocks for all
+// epochs that have been finalized.
+func (s *Service) summarizeBlobFees(ctx context.Context, targetEpoch phase0.Epoch) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "summarizeBlobFees",
+		trace.WithAttributes(
+			attribute.Int64("target epoch", int64(targetEpoch)),
+		))
+	defer span.End()
+
+	if !s.blobFees {
+		return nil
+	}
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata for blob fee summarizer")
+	}
+
+	// Blocks only contain blobs from Deneb onwards.
+	firstEpoch := s.boundFirstEpoch(phase0.Epoch(md.LastBlobFeeEpoch + 1))
+	if denebEpoch := s.chainTime.DenebInitialEpoch(); firstEpoch < denebEpoch {
+		firstEpoch = denebEpoch
+	}
+	if targetEpoch < firstEpoch {
+		log.Trace().Uint64("target_epoch", uint64(targetEpoch)).Uint64("first_epoch", uint64(firstEpoch)).Msg("Target epoch before first epoch; nothing to do")
+		return nil
+	}
+	epochsPerDay := s.epochsPerDay()
+	maxEpochsPerRun := phase0.Epoch(s.maxDaysPerRun) * epochsPerDay
+	if maxEpochsPerRun > 0 && targetEpoch-firstEpoch >= maxEpochsPerRun {
+		targetEpoch = firstEpoch + maxEpochsPerRun - 1
+	}
+	log.Trace().Uint64("first_epoch", uint64(firstEpoch)).Uint64("target_epoch", uint64(targetEpoch)).Msg("Blob fees catchup bounds")
+
+	// Blob fees are updated a day at a time.
+	for startEpoch := firstEpoch; startEpoch <= targetEpoch; startEpoch += epochsPerDay {
+		endEpoch := startEpoch + epochsPerDay - 1
+		if endEpoch > targetEpoch {
+			endEpoch = targetEpoch
+		}
+		if err := s.summarizeBlobFeesInEpochs(ctx, md, startEpoch, endEpoch); err != nil {
+			return errors.Wrapf(err, "failed to update blob fees for epochs %d to %d", startEpoch, endEpoch)
+		}
+	}
+
+	return nil
+}
+
+// summarizeBlobFeesInEpochs calculates the blob fees of canonical blocks in the given epochs.
+func (s *Service) summarizeBlobFeesInEpochs(ctx context.Context,
+	md *metadata,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "summarizeBlobFeesInEpochs",
+		trace.WithAttributes(
+			attribute.Int64("start epoch", int64(startEpoch)),
+			attribute.Int64("end epoch", int64(endEpoch)),
+		))
+	defer span.End()
+
+	startSlot := s.chainTime.FirstSlotOfEpoch(startEpoch)
+	endSlot := s.chainTime.LastSlotOfEpoch(endEpoch)
+
+	// The window for the first blocks in the range includes blocks before it.
+	var previous []*chaindb.BlobFee
+	if s.blobFeeWindow > 1 && startSlot > 0 {
+		previousSlot := startSlot - 1
+		var err error
+		previous, err = s.chainDB.(chaindb.BlobFeesProvider).BlobFees(ctx, &chaindb.BlobFeeFilter{
+			Limit: uint32(s.blobFeeWindow - 1),
+			Order: chaindb.OrderLatest,
+			To:    &previousSlot,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain previous blob fees")
+		}
+	}
+
+	canonical := true
+	blocks, err := s.blocksProvider.Blocks(ctx, &chaindb.BlockFilter{
+		From:      &startSlot,
+		To:        &endSlot,
+		Canonical: &canonical,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain canonical blocks")
+	}
+
+	fees := blobFees(previous, blocks, int(s.blobFeeWindow))
+	log.Trace().Uint64("start_epoch", uint64(startEpoch)).Uint64("end_epoch", uint64(endEpoch)).Int("fees", len(fees)).Msg("Calculated blob fees")
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to set blob fees")
+	}
+
+	if len(fees) > 0 {
+		if err := s.chainDB.(chaindb.BlobFeesSetter).SetBlobFees(ctx, fees); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set blob fees")
+		}
+	}
+
+	md.LastBlobFeeEpoch = int64(endEpoch)
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// blobFees calculates the blob fees of the given blocks, which must be in
+// slot order.  The window values of the first blocks also take in to account
+// the previous blob fees, which must also be in slot order.
+func blobFees(previous []*chaindb.BlobFee, blocks []*chaindb.Block, window int) []*chaindb.BlobFee {
+	// recent holds the blob fees in the current window.
+	recent := make([]*chaindb.BlobFee, 0, window)
+	if len(previous) >= window {
+		previous = previous[len(previous)-window+1:]
+	}
+	recent = append(recent, previous...)
+
+	fees := make([]*chaindb.BlobFee, 0, len(blocks))
+	for _, block := range blocks {
+		if block.ExecutionPayload == nil {
+			continue
+		}
+
+		fee := &chaindb.BlobFee{
+			Slot:                 block.Slot,
+			ExecutionBlockNumber: block.ExecutionPayload.BlockNumber,
+			ExcessBlobGas:        block.ExecutionPayload.ExcessBlobGas,
+			BlobGasUsed:          block.ExecutionPayload.BlobGasUsed,
+			TargetBlobGas:        targetBlobGasPerBlock,
+			BlobBaseFee:          blobBaseFee(block.ExecutionPayload.ExcessBlobGas),
+		}
+
+		recent = append(recent, fee)
+		if len(recent) > window {
+			recent = recent[1:]
+		}
+		setBlobFeeWindow(fee, recent)
+
+		fees = append(fees, fee)
+	}
+
+	return fees
+}
+
+// setBlobFeeWindow sets the window values of a blob fee from the blob fees in its window.
+func setBlobFeeWindow(fee *chaindb.BlobFee, window []*chaindb.BlobFee) {
+	total := new(big.Int)
+	fee.WindowBlocks = len(window)
+	fee.WindowBlobGasUsed = 0
+	fee.WindowMinBlobBaseFee = nil
+	fee.WindowMaxBlobBaseFee = nil
+	for _, windowFee := range window {
+		fee.WindowBlobGasUsed += windowFee.BlobGasUsed
+		total.Add(total, windowFee.BlobBaseFee)
+		if fee.WindowMinBlobBaseFee == nil || windowFee.BlobBaseFee.Cmp(fee.WindowMinBlobBaseFee) < 0 {
+			fee.WindowMinBlobBaseFee = windowFee.BlobBaseFee
+		}
+		if fee.WindowMaxBlobBaseFee == nil || windowFee.BlobBaseFee.Cmp(fee.WindowMaxBlobBaseFee) > 0 {
+			fee.WindowMaxBlobBaseFee = windowFee.BlobBaseFee
+		}
+	}
+	fee.WindowMeanBlobBaseFee = total.Div(total, big.NewInt(int64(len(window))))
+}
+
+// blobBaseFee calculates the base fee per unit of blob gas, in wei, from the
+// excess blob gas, as defined in EIP-4844.
+func blobBaseFee(excessBlobGas uint64) *big.Int {
+	return fakeExponential(big.NewInt(minBlobBaseFee), new(big.Int).SetUint64(excessBlobGas), big.NewInt(blobBaseFeeUpdateFraction))
+}
+
+// fakeExponential approximates factor * e ** (numerator / denominator) using
+// a Taylor expansion, as defined in EIP-4844.
+func fakeExponential(factor *big.Int, numerator *big.Int, denominator *big.Int) *big.Int {
+	output := new(big.Int)
+	accum := new(big.Int).Mul(factor, denominator)
+	divisor := new(big.Int)
+	for i := int64(1); accum.Sign() > 0; i++ {
+		output.Add(output, accum)
+		accum.Mul(accum, numerator)
+		accum.Div(accum, divisor.Mul(denominator, big.NewInt(i)))
+	}
+
+	return output.Div(output, denominator)
+}
@@ -0,0 +1,83 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestBlobBaseFee(t *testing.T) {
+	tests := []struct {
+		excessBlobGas uint64
+		expected      string
+	}{
+		{excessBlobGas: 0, expected: "1"},
+		{excessBlobGas: 393216, expected: "1"},
+		{excessBlobGas: 3338477, expected: "2"},
+		{excessBlobGas: 33384770, expected: "22026"},
+		{excessBlobGas: 50000000, expected: "3194333"},
+		{excessBlobGas: 100000000, expected: "10203769476395"},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, blobBaseFee(test.excessBlobGas).String())
+	}
+}
+
+func TestBlobFees(t *testing.T) {
+	previous := []*chaindb.BlobFee{
+		{Slot: 1, BlobGasUsed: 1, BlobBaseFee: big.NewInt(5)},
+		{Slot: 2, BlobGasUsed: 2, BlobBaseFee: big.NewInt(1)},
+		{Slot: 3, BlobGasUsed: 3, BlobBaseFee: big.NewInt(3)},
+	}
+	blocks := []*chaindb.Block{
+		{Slot: 4, ExecutionPayload: &chaindb.ExecutionPayload{BlockNumber: 100, ExcessBlobGas: 33384770, BlobGasUsed: 131072}},
+		{Slot: 5},
+		{Slot: 6, ExecutionPayload: &chaindb.ExecutionPayload{BlockNumber: 101}},
+	}
+
+	fees := blobFees(previous, blocks, 3)
+	require.Len(t, fees, 2)
+
+	require.Equal(t, phase0.Slot(4), fees[0].Slot)
+	require.Equal(t, uint64(100), fees[0].ExecutionBlockNumber)
+	require.Equal(t, targetBlobGasPerBlock, fees[0].TargetBlobGas)
+	require.Equal(t, "22026", fees[0].BlobBaseFee.String())
+	require.Equal(t, 3, fees[0].WindowBlocks)
+	require.Equal(t, uint64(131077), fees[0].WindowBlobGasUsed)
+	require.Equal(t, "7343", fees[0].WindowMeanBlobBaseFee.String())
+	require.Equal(t, "1", fees[0].WindowMinBlobBaseFee.String())
+	require.Equal(t, "22026", fees[0].WindowMaxBlobBaseFee.String())
+
+	require.Equal(t, phase0.Slot(6), fees[1].Slot)
+	require.Equal(t, "1", fees[1].BlobBaseFee.String())
+	require.Equal(t, 3, fees[1].WindowBlocks)
+	require.Equal(t, uint64(131075), fees[1].WindowBlobGasUsed)
+	require.Equal(t, "7343", fees[1].WindowMeanBlobBaseFee.String())
+	require.Equal(t, "1", fees[1].WindowMinBlobBaseFee.String())
+	require.Equal(t, "22026", fees[1].WindowMaxBlobBaseFee.String())
+
+	// Without previous fees the window starts with the first block.
+	fees = blobFees(nil, blocks, 3)
+	require.Len(t, fees, 2)
+	require.Equal(t, 1, fees[0].WindowBlocks)
+	require.Equal(t, "22026", fees[0].WindowMeanBlobBaseFee.String())
+	require.Equal(t, 2, fees[1].WindowBlocks)
+	require.Equal(t, "11013", fees[1].WindowMeanBlobBaseFee.String())
+}
@@ -0,0 +1,81 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+type blockRewardsJSON struct {
+	Data *struct {
+		Total string `json:"total"`
+	} `json:"data"`
+}
+
+// consensusBlockRewards obtains the consensus rewards for the proposer of
+// the given block from the beacon node.
+// It returns nil if the beacon node is unable to provide the rewards, for
+// example if it no longer holds the state for the block.
+func (s *Service) consensusBlockRewards(ctx context.Context, root phase0.Root) (*phase0.Gwei, error) {
+	url := fmt.Sprintf("%s/eth/v1/beacon/rewards/blocks/%#x", s.beaconAddress, root)
+	log.Trace().Str("url", url).Msg("GET request")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create block rewards request")
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to call block rewards endpoint")
+	}
+	// skipcq:GO-S2307
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read block rewards response")
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		log.Debug().Str("block_root", fmt.Sprintf("%#x", root)).Msg("Block rewards not available")
+		return nil, nil
+	case resp.StatusCode/100 != 2:
+		return nil, fmt.Errorf("block rewards request failed with status %d: %s", resp.StatusCode, string(data))
+	}
+
+	var rewardsJSON blockRewardsJSON
+	if err := json.Unmarshal(data, &rewardsJSON); err != nil {
+		return nil, errors.Wrap(err, "failed to parse block rewards response")
+	}
+	if rewardsJSON.Data == nil {
+		return nil, errors.New("block rewards data missing")
+	}
+	total, err := strconv.ParseUint(rewardsJSON.Data.Total, 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid block rewards total")
+	}
+	rewards := phase0.Gwei(total)
+
+	return &rewards, nil
+}
@@ -0,0 +1,190 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// summarizeClusters links validators into clusters by the withdrawal
+// credentials, and optionally the fee recipients, that they share, for all
+// epochs that have been finalized.
+func (s *Service) summarizeClusters(ctx context.Context, targetEpoch phase0.Epoch) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "summarizeClusters",
+		trace.WithAttributes(
+			attribute.Int64("target epoch", int64(targetEpoch)),
+		))
+	defer span.End()
+
+	if !s.validatorClusters {
+		return nil
+	}
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata for cluster summarizer")
+	}
+
+	firstEpoch := s.boundFirstEpoch(phase0.Epoch(md.LastClusterEpoch + 1))
+	if targetEpoch < firstEpoch {
+		log.Trace().Uint64("target_epoch", uint64(targetEpoch)).Uint64("first_epoch", uint64(firstEpoch)).Msg("Target epoch before first epoch; nothing to do")
+		return nil
+	}
+	epochsPerDay := s.epochsPerDay()
+	maxEpochsPerRun := phase0.Epoch(s.maxDaysPerRun) * epochsPerDay
+	if maxEpochsPerRun > 0 && targetEpoch-firstEpoch >= maxEpochsPerRun {
+		targetEpoch = firstEpoch + maxEpochsPerRun - 1
+	}
+	log.Trace().Uint64("first_epoch", uint64(firstEpoch)).Uint64("target_epoch", uint64(targetEpoch)).Msg("Clusters catchup bounds")
+
+	// Clusters are updated a day at a time.
+	for startEpoch := firstEpoch; startEpoch <= targetEpoch; startEpoch += epochsPerDay {
+		endEpoch := startEpoch + epochsPerDay - 1
+		if endEpoch > targetEpoch {
+			endEpoch = targetEpoch
+		}
+		// The first run picks up all credentials recorded before the first epoch.
+		allCredentials := md.LastClusterEpoch == -1 && startEpoch == firstEpoch
+		if err := s.summarizeClustersInEpochs(ctx, md, startEpoch, endEpoch, allCredentials); err != nil {
+			return errors.Wrapf(err, "failed to update clusters for epochs %d to %d", startEpoch, endEpoch)
+		}
+	}
+
+	return nil
+}
+
+// summarizeClustersInEpochs updates the clusters with the links seen in the given epochs.
+func (s *Service) summarizeClustersInEpochs(ctx context.Context,
+	md *metadata,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+	allCredentials bool,
+) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "summarizeClustersInEpochs",
+		trace.WithAttributes(
+			attribute.Int64("start epoch", int64(startEpoch)),
+			attribute.Int64("end epoch", int64(endEpoch)),
+		))
+	defer span.End()
+
+	filter := &chaindb.ValidatorCredentialsChangeFilter{
+		Order: chaindb.OrderEarliest,
+		To:    &endEpoch,
+	}
+	if !allCredentials {
+		filter.From = &startEpoch
+	}
+	changes, err := s.chainDB.(chaindb.ValidatorCredentialsProvider).ValidatorCredentialsChanges(ctx, filter)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain credentials changes")
+	}
+	links := credentialsClusterLinks(changes)
+
+	if s.clusterFeeRecipients {
+		canonical := true
+		startSlot := s.chainTime.FirstSlotOfEpoch(startEpoch)
+		endSlot := s.chainTime.LastSlotOfEpoch(endEpoch)
+		blocks, err := s.blocksProvider.Blocks(ctx, &chaindb.BlockFilter{
+			From:      &startSlot,
+			To:        &endSlot,
+			Canonical: &canonical,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain canonical blocks")
+		}
+		links = append(links, feeRecipientClusterLinks(s.chainTime.SlotToEpoch, blocks)...)
+	}
+	log.Trace().Uint64("start_epoch", uint64(startEpoch)).Uint64("end_epoch", uint64(endEpoch)).Int("links", len(links)).Msg("Obtained cluster links")
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to set validator clusters")
+	}
+
+	if err := s.chainDB.(chaindb.ValidatorClustersSetter).SetValidatorClusterLinks(ctx, links); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set validator cluster links")
+	}
+
+	md.LastClusterEpoch = int64(endEpoch)
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// credentialsClusterLinks provides the cluster links for withdrawal credentials.
+// Execution and compounding credentials link validators by their withdrawal
+// address, and BLS credentials by the credentials themselves.
+func credentialsClusterLinks(changes []*chaindb.ValidatorCredentialsChange) []*chaindb.ValidatorClusterLink {
+	links := make([]*chaindb.ValidatorClusterLink, 0, len(changes))
+	for _, change := range changes {
+		link := &chaindb.ValidatorClusterLink{
+			ValidatorIndex: change.Index,
+			Epoch:          change.Epoch,
+		}
+		switch change.CredentialsType() {
+		case chaindb.WithdrawalCredentialsBLS:
+			link.Type = chaindb.ValidatorClusterLinkBLSWithdrawalCredentials
+			link.Key = change.WithdrawalCredentials[:]
+		case chaindb.WithdrawalCredentialsExecution, chaindb.WithdrawalCredentialsCompounding:
+			link.Type = chaindb.ValidatorClusterLinkWithdrawalAddress
+			link.Key = change.WithdrawalCredentials[12:]
+		default:
+			continue
+		}
+		links = append(links, link)
+	}
+
+	return links
+}
+
+// feeRecipientClusterLinks provides the cluster links for the fee recipients of blocks.
+func feeRecipientClusterLinks(slotToEpoch func(phase0.Slot) phase0.Epoch,
+	blocks []*chaindb.Block,
+) []*chaindb.ValidatorClusterLink {
+	links := make([]*chaindb.ValidatorClusterLink, 0, len(blocks))
+	for _, block := range blocks {
+		if block.ExecutionPayload == nil {
+			continue
+		}
+		// Payloads before the merge have no fee recipient.
+		if block.ExecutionPayload.FeeRecipient == [20]byte{} {
+			continue
+		}
+		feeRecipient := block.ExecutionPayload.FeeRecipient
+		links = append(links, &chaindb.ValidatorClusterLink{
+			ValidatorIndex: block.ProposerIndex,
+			Type:           chaindb.ValidatorClusterLinkFeeRecipient,
+			Key:            feeRecipient[:],
+			Epoch:          slotToEpoch(block.Slot),
+		})
+	}
+
+	return links
+}
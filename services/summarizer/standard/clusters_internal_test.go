@@ -0,0 +1,67 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestCredentialsClusterLinks(t *testing.T) {
+	bls := [32]byte{0x00, 0xaa}
+	execution := [32]byte{0x01}
+	execution[12] = 0xbb
+	compounding := [32]byte{0x02}
+	compounding[12] = 0xbb
+	unknown := [32]byte{0x7f}
+
+	links := credentialsClusterLinks([]*chaindb.ValidatorCredentialsChange{
+		{Index: 1, Epoch: 10, WithdrawalCredentials: bls},
+		{Index: 2, Epoch: 11, WithdrawalCredentials: execution},
+		{Index: 3, Epoch: 12, WithdrawalCredentials: compounding},
+		{Index: 4, Epoch: 13, WithdrawalCredentials: unknown},
+	})
+	require.Len(t, links, 3)
+
+	require.Equal(t, phase0.ValidatorIndex(1), links[0].ValidatorIndex)
+	require.Equal(t, chaindb.ValidatorClusterLinkBLSWithdrawalCredentials, links[0].Type)
+	require.Equal(t, bls[:], links[0].Key)
+	require.Equal(t, phase0.Epoch(10), links[0].Epoch)
+
+	// Execution and compounding credentials with the same address share a key.
+	require.Equal(t, chaindb.ValidatorClusterLinkWithdrawalAddress, links[1].Type)
+	require.Len(t, links[1].Key, 20)
+	require.Equal(t, links[1].Key, links[2].Key)
+	require.Equal(t, links[1].Type, links[2].Type)
+}
+
+func TestFeeRecipientClusterLinks(t *testing.T) {
+	slotToEpoch := func(slot phase0.Slot) phase0.Epoch {
+		return phase0.Epoch(slot / 32)
+	}
+
+	links := feeRecipientClusterLinks(slotToEpoch, []*chaindb.Block{
+		{Slot: 64, ProposerIndex: 1},
+		{Slot: 65, ProposerIndex: 2, ExecutionPayload: &chaindb.ExecutionPayload{}},
+		{Slot: 96, ProposerIndex: 3, ExecutionPayload: &chaindb.ExecutionPayload{FeeRecipient: [20]byte{0xcc}}},
+	})
+	require.Len(t, links, 1)
+	require.Equal(t, phase0.ValidatorIndex(3), links[0].ValidatorIndex)
+	require.Equal(t, chaindb.ValidatorClusterLinkFeeRecipient, links[0].Type)
+	require.Equal(t, []byte{0xcc, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, links[0].Key)
+	require.Equal(t, phase0.Epoch(3), links[0].Epoch)
+}
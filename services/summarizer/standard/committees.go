@@ -0,0 +1,138 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// committeeVote holds the best vote seen for a single validator in a committee.
+type committeeVote struct {
+	inclusionDelay phase0.Slot
+	sourceTimely   bool
+	targetCorrect  bool
+	headCorrect    bool
+}
+
+// committeeSummariesForEpoch calculates the committee summaries for the given epoch.
+func (s *Service) committeeSummariesForEpoch(ctx context.Context,
+	epoch phase0.Epoch,
+	attestations []*chaindb.Attestation,
+) (
+	[]*chaindb.CommitteeEpochSummary,
+	error,
+) {
+	minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
+	maxSlot := s.chainTime.LastSlotOfEpoch(epoch)
+	log.Trace().Uint64("epoch", uint64(epoch)).Uint64("min_slot", uint64(minSlot)).Uint64("max_slot", uint64(maxSlot)).Msg("Updating committee summaries")
+
+	committees, err := s.beaconCommitteesProvider.BeaconCommittees(ctx, &chaindb.BeaconCommitteeFilter{
+		From: &minSlot,
+		To:   &maxSlot,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain beacon committees")
+	}
+	if len(committees) == 0 {
+		// This can happen if chaind does not have beacon committees enabled.
+		log.Debug().Uint64("epoch", uint64(epoch)).Msg("No beacon committees for epoch; not summarizing committees")
+		return nil, nil
+	}
+
+	return s.committeeEpochSummaries(epoch, committees, attestations), nil
+}
+
+// committeeEpochSummaries calculates the attestation performance of each committee.
+// The attestations should be canonical and deduplicated.  A validator's vote is
+// counted as correct if any of its included attestations was correct, and its
+// inclusion delay is that of the first inclusion of any of its attestations.
+func (s *Service) committeeEpochSummaries(epoch phase0.Epoch,
+	committees []*chaindb.BeaconCommittee,
+	attestations []*chaindb.Attestation,
+) []*chaindb.CommitteeEpochSummary {
+	type committeeKey struct {
+		slot  phase0.Slot
+		index phase0.CommitteeIndex
+	}
+
+	votes := make(map[committeeKey]map[phase0.ValidatorIndex]*committeeVote, len(committees))
+	for _, committee := range committees {
+		votes[committeeKey{slot: committee.Slot, index: committee.Index}] = make(map[phase0.ValidatorIndex]*committeeVote)
+	}
+
+	for _, attestation := range attestations {
+		committeeVotes, exists := votes[committeeKey{slot: attestation.Slot, index: attestation.CommitteeIndex}]
+		if !exists {
+			log.Debug().Uint64("slot", uint64(attestation.Slot)).Uint64("committee_index", uint64(attestation.CommitteeIndex)).Msg("No beacon committee for attestation; ignoring")
+			continue
+		}
+		inclusionDelay := attestation.InclusionSlot - attestation.Slot
+		sourceTimely := uint64(inclusionDelay) <= s.maxTimelyAttestationSourceDelay
+		targetCorrect := attestation.TargetCorrect != nil && *attestation.TargetCorrect
+		headCorrect := attestation.HeadCorrect != nil && *attestation.HeadCorrect
+		for _, index := range attestation.AggregationIndices {
+			vote, exists := committeeVotes[index]
+			if !exists {
+				committeeVotes[index] = &committeeVote{
+					inclusionDelay: inclusionDelay,
+					sourceTimely:   sourceTimely,
+					targetCorrect:  targetCorrect,
+					headCorrect:    headCorrect,
+				}
+				continue
+			}
+			if inclusionDelay < vote.inclusionDelay {
+				vote.inclusionDelay = inclusionDelay
+			}
+			vote.sourceTimely = vote.sourceTimely || sourceTimely
+			vote.targetCorrect = vote.targetCorrect || targetCorrect
+			vote.headCorrect = vote.headCorrect || headCorrect
+		}
+	}
+
+	summaries := make([]*chaindb.CommitteeEpochSummary, 0, len(committees))
+	for _, committee := range committees {
+		summary := &chaindb.CommitteeEpochSummary{
+			Epoch:          epoch,
+			Slot:           committee.Slot,
+			CommitteeIndex: committee.Index,
+			Validators:     len(committee.Committee),
+		}
+		totalInclusionDelay := uint64(0)
+		for _, vote := range votes[committeeKey{slot: committee.Slot, index: committee.Index}] {
+			summary.AttestingValidators++
+			totalInclusionDelay += uint64(vote.inclusionDelay)
+			if vote.sourceTimely {
+				summary.SourceTimelyValidators++
+			}
+			if vote.targetCorrect {
+				summary.TargetCorrectValidators++
+			}
+			if vote.headCorrect {
+				summary.HeadCorrectValidators++
+			}
+		}
+		if summary.AttestingValidators > 0 {
+			averageInclusionDelay := float64(totalInclusionDelay) / float64(summary.AttestingValidators)
+			summary.AverageInclusionDelay = &averageInclusionDelay
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries
+}
@@ -0,0 +1,107 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestCommitteeEpochSummaries(t *testing.T) {
+	s := &Service{
+		maxTimelyAttestationSourceDelay: 5,
+	}
+
+	correct := true
+	incorrect := false
+	committees := []*chaindb.BeaconCommittee{
+		{Slot: 320, Index: 0, Committee: []phase0.ValidatorIndex{1, 2, 3, 4}},
+		{Slot: 320, Index: 1, Committee: []phase0.ValidatorIndex{5, 6, 7, 8}},
+		{Slot: 351, Index: 0, Committee: []phase0.ValidatorIndex{9, 10}},
+	}
+	attestations := []*chaindb.Attestation{
+		// Late, incorrect attestation for validators 1 and 2.
+		{
+			Slot:               320,
+			CommitteeIndex:     0,
+			InclusionSlot:      327,
+			AggregationIndices: []phase0.ValidatorIndex{1, 2},
+			TargetCorrect:      &incorrect,
+			HeadCorrect:        &incorrect,
+		},
+		// Prompt, correct attestation for validators 2 and 3.
+		{
+			Slot:               320,
+			CommitteeIndex:     0,
+			InclusionSlot:      321,
+			AggregationIndices: []phase0.ValidatorIndex{2, 3},
+			TargetCorrect:      &correct,
+			HeadCorrect:        &correct,
+		},
+		// Attestation for validator 5 with correct target but incorrect head.
+		{
+			Slot:               320,
+			CommitteeIndex:     1,
+			InclusionSlot:      322,
+			AggregationIndices: []phase0.ValidatorIndex{5},
+			TargetCorrect:      &correct,
+			HeadCorrect:        &incorrect,
+		},
+		// Attestation for an unknown committee.
+		{
+			Slot:               321,
+			CommitteeIndex:     0,
+			InclusionSlot:      322,
+			AggregationIndices: []phase0.ValidatorIndex{11},
+			TargetCorrect:      &correct,
+			HeadCorrect:        &correct,
+		},
+	}
+
+	delay1 := 3.0
+	delay2 := 2.0
+	require.Equal(t, []*chaindb.CommitteeEpochSummary{
+		{
+			Epoch:                   10,
+			Slot:                    320,
+			CommitteeIndex:          0,
+			Validators:              4,
+			AttestingValidators:     3,
+			SourceTimelyValidators:  2,
+			TargetCorrectValidators: 2,
+			HeadCorrectValidators:   2,
+			AverageInclusionDelay:   &delay1,
+		},
+		{
+			Epoch:                   10,
+			Slot:                    320,
+			CommitteeIndex:          1,
+			Validators:              4,
+			AttestingValidators:     1,
+			SourceTimelyValidators:  1,
+			TargetCorrectValidators: 1,
+			HeadCorrectValidators:   0,
+			AverageInclusionDelay:   &delay2,
+		},
+		{
+			Epoch:          10,
+			Slot:           351,
+			CommitteeIndex: 0,
+			Validators:     2,
+		},
+	}, s.committeeEpochSummaries(10, committees, attestations))
+}
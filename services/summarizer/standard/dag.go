@@ -0,0 +1,163 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// errNotReady is returned by a task when the data that it requires is not yet
+// available.  It stops the tasks that depend on it, but is not a failure.
+var errNotReady = errors.New("not ready")
+
+// dagTask is a single task in a DAG.
+type dagTask struct {
+	id    string
+	order int
+	run   func(ctx context.Context) error
+	deps  []*dagTask
+}
+
+// dag is a directed acyclic graph of tasks.  A task runs only once all of the
+// tasks on which it depends have succeeded, so tasks with independent inputs
+// can run concurrently.
+type dag struct {
+	tasks []*dagTask
+	byID  map[string]*dagTask
+}
+
+// newDAG creates a new, empty, DAG.
+func newDAG() *dag {
+	return &dag{
+		tasks: make([]*dagTask, 0),
+		byID:  make(map[string]*dagTask),
+	}
+}
+
+// add adds a task to the DAG.  Dependencies must have been added before the
+// tasks that depend on them, which ensures that the graph is acyclic.  Empty
+// dependencies are ignored, to allow dependencies that may not exist to be
+// obtained from maps of task IDs.
+func (d *dag) add(id string, run func(ctx context.Context) error, deps ...string) error {
+	if _, exists := d.byID[id]; exists {
+		return fmt.Errorf("duplicate task %s", id)
+	}
+
+	task := &dagTask{
+		id:    id,
+		order: len(d.tasks),
+		run:   run,
+		deps:  make([]*dagTask, 0, len(deps)),
+	}
+	for _, dep := range deps {
+		if dep == "" {
+			continue
+		}
+		depTask, exists := d.byID[dep]
+		if !exists {
+			return fmt.Errorf("task %s depends on unknown task %s", id, dep)
+		}
+		task.deps = append(task.deps, depTask)
+	}
+
+	d.tasks = append(d.tasks, task)
+	d.byID[id] = task
+
+	return nil
+}
+
+// size provides the number of tasks in the DAG.
+func (d *dag) size() int {
+	return len(d.tasks)
+}
+
+// run runs the tasks in the DAG, with up to the given number of tasks running
+// at a time.  Where more tasks are ready to run than can be started, those
+// added to the DAG first are started first.
+// If a task fails then the tasks that depend on it, directly or indirectly,
+// are not run, but other tasks are.  The error returned is that of the first
+// task to fail, preferring failures over errNotReady.
+func (d *dag) run(ctx context.Context, concurrency uint64) error {
+	if concurrency == 0 {
+		return errors.New("no concurrency specified")
+	}
+
+	remaining := make(map[*dagTask]int, len(d.tasks))
+	dependents := make(map[*dagTask][]*dagTask, len(d.tasks))
+	ready := make([]*dagTask, 0)
+	for _, task := range d.tasks {
+		remaining[task] = len(task.deps)
+		for _, dep := range task.deps {
+			dependents[dep] = append(dependents[dep], task)
+		}
+		if len(task.deps) == 0 {
+			ready = append(ready, task)
+		}
+	}
+
+	type result struct {
+		task *dagTask
+		err  error
+	}
+	results := make(chan *result)
+
+	var res error
+	running := uint64(0)
+	for {
+		for running < concurrency && len(ready) > 0 && ctx.Err() == nil {
+			task := ready[0]
+			ready = ready[1:]
+			running++
+			go func(ctx context.Context, task *dagTask) {
+				results <- &result{
+					task: task,
+					err:  task.run(ctx),
+				}
+			}(ctx, task)
+		}
+		if running == 0 {
+			break
+		}
+
+		taskResult := <-results
+		running--
+		if taskResult.err != nil {
+			if !errors.Is(taskResult.err, errNotReady) {
+				log.Trace().Str("task", taskResult.task.id).Err(taskResult.err).Msg("Task failed")
+			}
+			if res == nil || (errors.Is(res, errNotReady) && !errors.Is(taskResult.err, errNotReady)) {
+				res = taskResult.err
+			}
+			continue
+		}
+		for _, dependent := range dependents[taskResult.task] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+		sort.Slice(ready, func(i int, j int) bool {
+			return ready[i].order < ready[j].order
+		})
+	}
+
+	if res == nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return res
+}
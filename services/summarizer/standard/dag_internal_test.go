@@ -0,0 +1,144 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDAGAdd(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+
+	d := newDAG()
+	require.NoError(t, d.add("a", noop))
+	require.NoError(t, d.add("b", noop, "a", ""))
+	require.EqualError(t, d.add("a", noop), "duplicate task a")
+	require.EqualError(t, d.add("c", noop, "d"), "task c depends on unknown task d")
+	require.Equal(t, 2, d.size())
+}
+
+func TestDAGRunOrder(t *testing.T) {
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	order := make([]string, 0)
+	task := func(id string) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, id)
+			return nil
+		}
+	}
+
+	d := newDAG()
+	require.NoError(t, d.add("a1", task("a1")))
+	require.NoError(t, d.add("a2", task("a2"), "a1"))
+	require.NoError(t, d.add("b1", task("b1"), "a1"))
+	require.NoError(t, d.add("b2", task("b2"), "a2", "b1"))
+	require.NoError(t, d.add("c", task("c")))
+
+	// With a concurrency of 1 tasks run in the order in which they were added,
+	// subject to their dependencies.
+	require.NoError(t, d.run(ctx, 1))
+	require.Equal(t, []string{"a1", "a2", "b1", "b2", "c"}, order)
+}
+
+func TestDAGRunFailure(t *testing.T) {
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	ran := make(map[string]bool)
+	task := func(id string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			ran[id] = true
+			return err
+		}
+	}
+
+	d := newDAG()
+	require.NoError(t, d.add("a", task("a", errNotReady)))
+	require.NoError(t, d.add("b", task("b", nil), "a"))
+	require.NoError(t, d.add("c", task("c", errors.New("failed"))))
+	require.NoError(t, d.add("d", task("d", nil), "c"))
+	require.NoError(t, d.add("e", task("e", nil), "b", "d"))
+	require.NoError(t, d.add("f", task("f", nil)))
+
+	// Failures are preferred over tasks that are not ready.
+	require.EqualError(t, d.run(ctx, 2), "failed")
+	require.Equal(t, map[string]bool{"a": true, "c": true, "f": true}, ran)
+}
+
+func TestDAGRunNotReady(t *testing.T) {
+	ctx := context.Background()
+
+	d := newDAG()
+	require.NoError(t, d.add("a", func(context.Context) error { return nil }))
+	require.NoError(t, d.add("b", func(context.Context) error { return errNotReady }, "a"))
+	require.ErrorIs(t, d.run(ctx, 1), errNotReady)
+}
+
+func TestDAGRunConcurrency(t *testing.T) {
+	ctx := context.Background()
+
+	var running atomic.Int32
+	var maxRunning atomic.Int32
+	task := func(context.Context) error {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			highest := maxRunning.Load()
+			if current <= highest || maxRunning.CompareAndSwap(highest, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+
+	d := newDAG()
+	for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+		require.NoError(t, d.add(id, task))
+	}
+	require.NoError(t, d.run(ctx, 3))
+	require.Equal(t, int32(3), maxRunning.Load())
+
+	require.EqualError(t, d.run(ctx, 0), "no concurrency specified")
+}
+
+func TestDAGRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	d := newDAG()
+	require.NoError(t, d.add("a", func(context.Context) error {
+		cancel()
+		return nil
+	}))
+	ran := false
+	require.NoError(t, d.add("b", func(context.Context) error {
+		ran = true
+		return nil
+	}, "a"))
+	require.ErrorIs(t, d.run(ctx, 1), context.Canceled)
+	require.False(t, ran)
+}
@@ -0,0 +1,71 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// entryQueue calculates the state of the entry queue for the given epoch.
+// Validators without a balance at the epoch did not exist at the time, so are ignored.
+func (s *Service) entryQueue(epoch phase0.Epoch,
+	validators []*chaindb.Validator,
+	balances []*chaindb.ValidatorBalance,
+) *chaindb.EntryQueue {
+	entryQueue := &chaindb.EntryQueue{
+		Epoch: epoch,
+	}
+
+	activeValidators := 0
+	// unscheduled is the number of validators in the queue that have yet to be
+	// assigned an activation epoch.
+	unscheduled := 0
+	totalWait := 0
+	for i, validator := range validators {
+		if i >= len(balances) {
+			break
+		}
+		switch {
+		case validator.ActivationEligibilityEpoch > epoch:
+			entryQueue.PendingValidators++
+		case validator.ActivationEpoch > epoch:
+			entryQueue.QueueLength++
+			entryQueue.QueueBalance += balances[i].EffectiveBalance
+			if validator.ActivationEpoch == s.farFutureEpoch {
+				unscheduled++
+			}
+		case validator.ExitEpoch > epoch:
+			activeValidators++
+			if validator.ActivationEpoch == epoch {
+				entryQueue.ActivatedValidators++
+				totalWait += int(validator.ActivationEpoch - validator.ActivationEligibilityEpoch)
+			}
+		}
+	}
+
+	if entryQueue.ActivatedValidators > 0 {
+		entryQueue.AverageWait = float64(totalWait) / float64(entryQueue.ActivatedValidators)
+	}
+
+	entryQueue.ActivationChurnLimit = s.activationChurnLimit(epoch, activeValidators)
+	// A validator joining the queue now waits for the unscheduled validators
+	// ahead of it to be dequeued, after which its activation is delayed by the
+	// seed lookahead.
+	if entryQueue.ActivationChurnLimit > 0 {
+		entryQueue.ProjectedWait = (unscheduled+entryQueue.ActivationChurnLimit)/entryQueue.ActivationChurnLimit + 1 + int(s.maxSeedLookahead)
+	}
+
+	return entryQueue
+}
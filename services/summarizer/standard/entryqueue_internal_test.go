@@ -0,0 +1,73 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+func TestEntryQueue(t *testing.T) {
+	farFutureEpoch := phase0.Epoch(0xffffffffffffffff)
+	s := &Service{
+		chainTime:             mockchaintime.New(),
+		farFutureEpoch:        farFutureEpoch,
+		minPerEpochChurnLimit: 2,
+		churnLimitQuotient:    65536,
+		maxSeedLookahead:      4,
+	}
+
+	validators := []*chaindb.Validator{
+		// Active.
+		{Index: 0, ActivationEligibilityEpoch: 0, ActivationEpoch: 0, ExitEpoch: farFutureEpoch},
+		// Activated this epoch.
+		{Index: 1, ActivationEligibilityEpoch: 2, ActivationEpoch: 10, ExitEpoch: farFutureEpoch},
+		{Index: 2, ActivationEligibilityEpoch: 4, ActivationEpoch: 10, ExitEpoch: farFutureEpoch},
+		// Scheduled for activation.
+		{Index: 3, ActivationEligibilityEpoch: 5, ActivationEpoch: 12, ExitEpoch: farFutureEpoch},
+		// Awaiting activation.
+		{Index: 4, ActivationEligibilityEpoch: 8, ActivationEpoch: farFutureEpoch, ExitEpoch: farFutureEpoch},
+		{Index: 5, ActivationEligibilityEpoch: 9, ActivationEpoch: farFutureEpoch, ExitEpoch: farFutureEpoch},
+		{Index: 6, ActivationEligibilityEpoch: 10, ActivationEpoch: farFutureEpoch, ExitEpoch: farFutureEpoch},
+		// Not yet eligible.
+		{Index: 7, ActivationEligibilityEpoch: farFutureEpoch, ActivationEpoch: farFutureEpoch, ExitEpoch: farFutureEpoch},
+		// Exited.
+		{Index: 8, ActivationEligibilityEpoch: 0, ActivationEpoch: 0, ExitEpoch: 5},
+		// Not present at the epoch.
+		{Index: 9, ActivationEligibilityEpoch: farFutureEpoch, ActivationEpoch: farFutureEpoch, ExitEpoch: farFutureEpoch},
+	}
+	balances := make([]*chaindb.ValidatorBalance, 9)
+	for i := range balances {
+		balances[i] = &chaindb.ValidatorBalance{
+			Index:            phase0.ValidatorIndex(i),
+			Balance:          32000000000,
+			EffectiveBalance: 32000000000,
+		}
+	}
+
+	require.Equal(t, &chaindb.EntryQueue{
+		Epoch:                10,
+		PendingValidators:    1,
+		QueueLength:          4,
+		QueueBalance:         128000000000,
+		ActivationChurnLimit: 2,
+		ProjectedWait:        7,
+		ActivatedValidators:  2,
+		AverageWait:          7,
+	}, s.entryQueue(10, validators, balances))
+}
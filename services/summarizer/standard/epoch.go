@@ -26,6 +26,14 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// epochSummaryData is the data stored for a summarized epoch.
+type epochSummaryData struct {
+	summary            *chaindb.EpochSummary
+	committeeSummaries []*chaindb.CommitteeEpochSummary
+	networkAggregate   *chaindb.NetworkAggregate
+	entryQueue         *chaindb.EntryQueue
+}
+
 // summarizeEpoch updates the summary for a given epoch.
 // Returns true if the epoch has been updated, otherwise false.
 func (s *Service) summarizeEpoch(ctx context.Context,
@@ -35,7 +43,28 @@ func (s *Service) summarizeEpoch(ctx context.Context,
 	bool,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "summarizeEpoch",
+	data, updated, err := s.epochSummary(ctx, epoch)
+	if err != nil || !updated {
+		return updated, err
+	}
+	if err := s.storeEpochSummary(ctx, md, data); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// epochSummary calculates the summary data for a given epoch.
+// Returns true if the epoch can be summarized, otherwise false.  If the
+// epoch can be summarized but there is nothing to store then the data is nil.
+func (s *Service) epochSummary(ctx context.Context,
+	epoch phase0.Epoch,
+) (
+	*epochSummaryData,
+	bool,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "epochSummary",
 		trace.WithAttributes(
 			attribute.Int64("epoch", int64(epoch)),
 		))
@@ -45,37 +74,39 @@ func (s *Service) summarizeEpoch(ctx context.Context,
 	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
 	if !s.epochSummaries {
 		log.Trace().Msg("Epoch summaries not enabled")
-		return false, nil
+		return nil, false, nil
 	}
 	log.Trace().Msg("Summarizing epoch")
 
 	if epoch == 0 {
 		log.Trace().Msg("Not summarizing for epoch 0")
-		return true, nil
+		return nil, true, nil
 	}
 
 	summary := &chaindb.EpochSummary{
 		Epoch: epoch,
 	}
 
-	activeValidators, err := s.validatorSummaryStatsForEpoch(ctx, epoch, summary)
+	validators, err := s.validatorsProvider.Validators(ctx)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate validator summary statistics for epoch")
+		return nil, false, errors.Wrap(err, "failed to obtain validators")
 	}
+
+	activeValidators := s.validatorSummaryStatsForEpoch(validators, epoch, summary)
 	if summary.ActiveValidators == 0 {
-		return false, errors.New("no active validators to summarize for epoch")
+		return nil, false, errors.New("no active validators to summarize for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set validator summary stats")
 
 	// Active balance and active effective balance.
 	balances, err := s.validatorsProvider.ValidatorBalancesByEpoch(ctx, epoch)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to obtain validator balances")
+		return nil, false, errors.Wrap(err, "failed to obtain validator balances")
 	}
 	if len(balances) == 0 {
 		// This can happen if chaind does not have validator balances enabled, or has not yet obtained
 		// the balances.  We return false but no error.
-		return false, nil
+		return nil, false, nil
 	}
 	for i, balance := range balances {
 		if activeValidators[i] {
@@ -87,70 +118,120 @@ func (s *Service) summarizeEpoch(ctx context.Context,
 
 	err = s.blockStatsForEpoch(ctx, epoch, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate block summary statistics for epoch")
+		return nil, false, errors.Wrap(err, "failed to calculate block summary statistics for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set block summary stats")
 
 	err = s.slashingsStatsForEpoch(ctx, epoch, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate slashings summary statistics for epoch")
+		return nil, false, errors.Wrap(err, "failed to calculate slashings summary statistics for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set slashing stats")
 
-	err = s.attestationStatsForEpoch(ctx, epoch, balances, summary)
+	epochAttestations, err := s.attestationStatsForEpoch(ctx, epoch, balances, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate attestation summary statistics for epoch")
+		return nil, false, errors.Wrap(err, "failed to calculate attestation summary statistics for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set attestation stats")
 
+	var committeeSummaries []*chaindb.CommitteeEpochSummary
+	if s.committeeSummaries {
+		committeeSummaries, err = s.committeeSummariesForEpoch(ctx, epoch, epochAttestations)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "failed to calculate committee summaries for epoch")
+		}
+		log.Trace().Dur("elapsed", time.Since(started)).Msg("Set committee summaries")
+	}
+
+	setParticipationRates(summary)
+
 	err = s.depositStatsForEpoch(ctx, epoch, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate deposit summary statistics for epoch")
+		return nil, false, errors.Wrap(err, "failed to calculate deposit summary statistics for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set deposit stats")
 
+	err = s.voluntaryExitStatsForEpoch(ctx, epoch, summary)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to calculate voluntary exit summary statistics for epoch")
+	}
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set voluntary exit stats")
+
 	err = s.withdrawalStatsForEpoch(ctx, epoch, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate withdrawal summary statistics for epoch")
+		return nil, false, errors.Wrap(err, "failed to calculate withdrawal summary statistics for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set withdrawal stats")
 
+	return &epochSummaryData{
+		summary:            summary,
+		committeeSummaries: committeeSummaries,
+		networkAggregate:   s.networkAggregate(epoch, validators, balances),
+		entryQueue:         s.entryQueue(epoch, validators, balances),
+	}, true, nil
+}
+
+// storeEpochSummary stores the summary data for an epoch, and updates the
+// metadata to reflect it.
+func (s *Service) storeEpochSummary(ctx context.Context,
+	md *metadata,
+	data *epochSummaryData,
+) error {
+	if data == nil {
+		return nil
+	}
+	epoch := data.summary.Epoch
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "storeEpochSummary",
+		trace.WithAttributes(
+			attribute.Int64("epoch", int64(epoch)),
+		))
+	defer span.End()
+
 	ctx, cancel, err := s.chainDB.BeginTx(ctx)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to begin transaction to set epoch summary")
+		return errors.Wrap(err, "failed to begin transaction to set epoch summary")
+	}
+	if err := s.chainDB.(chaindb.EpochSummariesSetter).SetEpochSummary(ctx, data.summary); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set epoch summary")
+	}
+	if len(data.committeeSummaries) > 0 {
+		if err := s.chainDB.(chaindb.CommitteeEpochSummariesSetter).SetCommitteeEpochSummaries(ctx, data.committeeSummaries); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set committee epoch summaries")
+		}
 	}
-	if err := s.chainDB.(chaindb.EpochSummariesSetter).SetEpochSummary(ctx, summary); err != nil {
+	if err := s.chainDB.(chaindb.NetworkAggregatesSetter).SetNetworkAggregate(ctx, data.networkAggregate); err != nil {
 		cancel()
-		return false, errors.Wrap(err, "failed to set epoch summary")
+		return errors.Wrap(err, "failed to set network aggregate")
+	}
+	if err := s.chainDB.(chaindb.EntryQueuesSetter).SetEntryQueue(ctx, data.entryQueue); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set entry queue")
+	}
+	// Retried epochs can be earlier than the last epoch, which must not go backwards.
+	if epoch > md.LastEpoch {
+		log.Trace().Uint64("md.lastEpoch", uint64(epoch)).Msg("Updated last epoch")
+		md.LastEpoch = epoch
 	}
-	log.Trace().Uint64("md.lastEpoch", uint64(epoch)).Msg("Updated last epoch")
-	md.LastEpoch = epoch
 	if err := s.setMetadata(ctx, md); err != nil {
 		cancel()
-		return false, errors.Wrap(err, "failed to set summarizer metadata for epoch summary")
+		return errors.Wrap(err, "failed to set summarizer metadata for epoch summary")
 	}
 	if err := s.chainDB.CommitTx(ctx); err != nil {
 		cancel()
-		return false, errors.Wrap(err, "failed to set commit transaction to set epoch summary")
+		return errors.Wrap(err, "failed to set commit transaction to set epoch summary")
 	}
-	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set summary")
+	log.Trace().Uint64("epoch", uint64(epoch)).Msg("Set summary")
 
-	return true, nil
+	return nil
 }
 
-func (s *Service) validatorSummaryStatsForEpoch(ctx context.Context,
+func (s *Service) validatorSummaryStatsForEpoch(validators []*chaindb.Validator,
 	epoch phase0.Epoch,
 	summary *chaindb.EpochSummary,
-) (
-	[]bool,
-	error,
-) {
+) []bool {
 	// Number of validators that are active, became active, and exited in this epoch.
-	validators, err := s.validatorsProvider.Validators(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to obtain validators")
-	}
-
 	activeValidators := make([]bool, len(validators))
 	for i, validator := range validators {
 		switch {
@@ -170,7 +251,7 @@ func (s *Service) validatorSummaryStatsForEpoch(ctx context.Context,
 			summary.ActivationQueueLength++
 		}
 	}
-	return activeValidators, nil
+	return activeValidators
 }
 
 func (s *Service) blockStatsForEpoch(ctx context.Context,
@@ -186,13 +267,24 @@ func (s *Service) blockStatsForEpoch(ctx context.Context,
 		return errors.Wrap(err, "failed to obtain blocks")
 	}
 
+	setBlockStats(summary, blocks, int(maxSlot-minSlot)+1)
+
+	return nil
+}
+
+// setBlockStats sets the block counts of the summary from the blocks in an epoch of the given number of slots.
+func setBlockStats(summary *chaindb.EpochSummary, blocks []*chaindb.Block, slots int) {
 	for _, block := range blocks {
-		if block.Canonical == nil || !*block.Canonical {
-			continue
+		switch {
+		case block.Canonical == nil:
+			// Canonical status not yet known.
+		case *block.Canonical:
+			summary.CanonicalBlocks++
+		default:
+			summary.OrphanedBlocks++
 		}
-		summary.CanonicalBlocks++
 	}
-	return nil
+	summary.MissedProposals = slots - summary.CanonicalBlocks
 }
 
 func (s *Service) depositStatsForEpoch(ctx context.Context,
@@ -213,6 +305,24 @@ func (s *Service) depositStatsForEpoch(ctx context.Context,
 	return nil
 }
 
+func (s *Service) voluntaryExitStatsForEpoch(ctx context.Context,
+	epoch phase0.Epoch,
+	summary *chaindb.EpochSummary,
+) error {
+	minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
+	maxSlot := s.chainTime.LastSlotOfEpoch(epoch)
+	log.Trace().Uint64("epoch", uint64(epoch)).Uint64("min_slot", uint64(minSlot)).Uint64("max_slot", uint64(maxSlot)).Msg("Updating voluntary exit statistics")
+
+	voluntaryExits, err := s.voluntaryExitsProvider.VoluntaryExitsForSlotRange(ctx, minSlot, maxSlot+1)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain voluntary exits")
+	}
+
+	summary.VoluntaryExits = len(voluntaryExits)
+
+	return nil
+}
+
 func (s *Service) withdrawalStatsForEpoch(ctx context.Context,
 	epoch phase0.Epoch,
 	summary *chaindb.EpochSummary,
@@ -237,18 +347,23 @@ func (s *Service) withdrawalStatsForEpoch(ctx context.Context,
 	return nil
 }
 
+// attestationStatsForEpoch sets the attestation statistics of the summary,
+// returning the canonical, deduplicated attestations for the epoch.
 func (s *Service) attestationStatsForEpoch(ctx context.Context,
 	epoch phase0.Epoch,
 	balances []*chaindb.ValidatorBalance,
 	summary *chaindb.EpochSummary,
-) error {
+) (
+	[]*chaindb.Attestation,
+	error,
+) {
 	minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
 	maxSlot := s.chainTime.LastSlotOfEpoch(epoch)
 	log.Trace().Uint64("epoch", uint64(epoch)).Uint64("min_slot", uint64(minSlot)).Uint64("max_slot", uint64(maxSlot)).Msg("Updating attestation statistics")
 
 	attestationsForEpoch, err := s.attestationsProvider.AttestationsForSlotRange(ctx, minSlot, maxSlot+1)
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain attestations")
+		return nil, errors.Wrap(err, "failed to obtain attestations")
 	}
 
 	epochAttestations := make([]*chaindb.Attestation, 0)
@@ -283,7 +398,7 @@ func (s *Service) attestationStatsForEpoch(ctx context.Context,
 		}
 		specAttestationRoot, err := specAttestation.HashTreeRoot()
 		if err != nil {
-			return errors.Wrap(err, "failed to obtain attestation hash tree root")
+			return nil, errors.Wrap(err, "failed to obtain attestation hash tree root")
 		}
 		if _, exists := seenAttestations[specAttestationRoot]; exists {
 			// This is a duplicate.
@@ -302,7 +417,7 @@ func (s *Service) attestationStatsForEpoch(ctx context.Context,
 	// Fetch all attestations in the epoch for a simple count.
 	attestationsInEpoch, err := s.attestationsProvider.AttestationsInSlotRange(ctx, minSlot, maxSlot+1)
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain attestations in epoch")
+		return nil, errors.Wrap(err, "failed to obtain attestations in epoch")
 	}
 	summary.AttestationsInEpoch = len(attestationsInEpoch)
 
@@ -310,15 +425,32 @@ func (s *Service) attestationStatsForEpoch(ctx context.Context,
 	attestingValidatorBalances := make(map[phase0.ValidatorIndex]phase0.Gwei)
 	targetCorrectBalances := make(map[phase0.ValidatorIndex]phase0.Gwei)
 	headCorrectBalances := make(map[phase0.ValidatorIndex]phase0.Gwei)
+	sourceTimelyBalances := make(map[phase0.ValidatorIndex]phase0.Gwei)
+	sourceCorrectBalances := make(map[phase0.ValidatorIndex]phase0.Gwei)
+	inclusionTargetCorrectBalances := make(map[phase0.ValidatorIndex]phase0.Gwei)
+	inclusionHeadCorrectBalances := make(map[phase0.ValidatorIndex]phase0.Gwei)
 	for _, attestation := range epochAttestations {
+		sourceTimely := uint64(attestation.InclusionSlot-attestation.Slot) <= s.maxTimelyAttestationSourceDelay
 		for _, index := range attestation.AggregationIndices {
 			attestingValidatorBalances[index] = balances[index].EffectiveBalance
+			if sourceTimely {
+				sourceTimelyBalances[index] = balances[index].EffectiveBalance
+			}
 			if attestation.TargetCorrect != nil && *attestation.TargetCorrect {
 				targetCorrectBalances[index] = balances[index].EffectiveBalance
 			}
 			if attestation.HeadCorrect != nil && *attestation.HeadCorrect {
 				headCorrectBalances[index] = balances[index].EffectiveBalance
 			}
+			if attestation.SourceCorrect != nil && *attestation.SourceCorrect {
+				sourceCorrectBalances[index] = balances[index].EffectiveBalance
+			}
+			if attestation.InclusionTargetCorrect != nil && *attestation.InclusionTargetCorrect {
+				inclusionTargetCorrectBalances[index] = balances[index].EffectiveBalance
+			}
+			if attestation.InclusionHeadCorrect != nil && *attestation.InclusionHeadCorrect {
+				inclusionHeadCorrectBalances[index] = balances[index].EffectiveBalance
+			}
 		}
 	}
 	for _, attestingValidatorBalance := range attestingValidatorBalances {
@@ -333,8 +465,64 @@ func (s *Service) attestationStatsForEpoch(ctx context.Context,
 		summary.HeadCorrectValidators++
 		summary.HeadCorrectBalance += headCorrectBalance
 	}
+	for _, sourceTimelyBalance := range sourceTimelyBalances {
+		summary.SourceTimelyValidators++
+		summary.SourceTimelyBalance += sourceTimelyBalance
+	}
+	for _, sourceCorrectBalance := range sourceCorrectBalances {
+		summary.SourceCorrectValidators++
+		summary.SourceCorrectBalance += sourceCorrectBalance
+	}
+	for _, inclusionTargetCorrectBalance := range inclusionTargetCorrectBalances {
+		summary.InclusionTargetCorrectValidators++
+		summary.InclusionTargetCorrectBalance += inclusionTargetCorrectBalance
+	}
+	for _, inclusionHeadCorrectBalance := range inclusionHeadCorrectBalances {
+		summary.InclusionHeadCorrectValidators++
+		summary.InclusionHeadCorrectBalance += inclusionHeadCorrectBalance
+	}
+	summary.AverageInclusionDelay = averageInclusionDelay(epochAttestations)
 
-	return nil
+	return epochAttestations, nil
+}
+
+// averageInclusionDelay returns the average number of slots between the
+// attestation slot and the first inclusion of each attesting validator's
+// attestation, or nil if there are no attesting validators.
+func averageInclusionDelay(attestations []*chaindb.Attestation) *float64 {
+	inclusionDelays := make(map[phase0.ValidatorIndex]phase0.Slot)
+	for _, attestation := range attestations {
+		inclusionDelay := attestation.InclusionSlot - attestation.Slot
+		for _, index := range attestation.AggregationIndices {
+			if existing, exists := inclusionDelays[index]; !exists || inclusionDelay < existing {
+				inclusionDelays[index] = inclusionDelay
+			}
+		}
+	}
+	if len(inclusionDelays) == 0 {
+		return nil
+	}
+
+	totalInclusionDelay := uint64(0)
+	for _, inclusionDelay := range inclusionDelays {
+		totalInclusionDelay += uint64(inclusionDelay)
+	}
+	average := float64(totalInclusionDelay) / float64(len(inclusionDelays))
+
+	return &average
+}
+
+// setParticipationRates sets the participation rates of the summary as fractions of the active balance.
+func setParticipationRates(summary *chaindb.EpochSummary) {
+	if summary.ActiveBalance == 0 {
+		return
+	}
+
+	activeBalance := float64(summary.ActiveBalance)
+	summary.ParticipationRate = float64(summary.AttestingBalance) / activeBalance
+	summary.SourceTimelyRate = float64(summary.SourceTimelyBalance) / activeBalance
+	summary.TargetCorrectRate = float64(summary.TargetCorrectBalance) / activeBalance
+	summary.HeadCorrectRate = float64(summary.HeadCorrectBalance) / activeBalance
 }
 
 func (s *Service) slashingsStatsForEpoch(ctx context.Context,
@@ -0,0 +1,144 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestSetParticipationRates(t *testing.T) {
+	tests := []struct {
+		name     string
+		summary  *chaindb.EpochSummary
+		expected *chaindb.EpochSummary
+	}{
+		{
+			name:     "NoActiveBalance",
+			summary:  &chaindb.EpochSummary{},
+			expected: &chaindb.EpochSummary{},
+		},
+		{
+			name: "Good",
+			summary: &chaindb.EpochSummary{
+				ActiveBalance:        1000,
+				AttestingBalance:     900,
+				SourceTimelyBalance:  800,
+				TargetCorrectBalance: 750,
+				HeadCorrectBalance:   500,
+			},
+			expected: &chaindb.EpochSummary{
+				ActiveBalance:        1000,
+				AttestingBalance:     900,
+				SourceTimelyBalance:  800,
+				TargetCorrectBalance: 750,
+				HeadCorrectBalance:   500,
+				ParticipationRate:    0.9,
+				SourceTimelyRate:     0.8,
+				TargetCorrectRate:    0.75,
+				HeadCorrectRate:      0.5,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setParticipationRates(test.summary)
+			require.Equal(t, test.expected, test.summary)
+		})
+	}
+}
+
+func TestSetBlockStats(t *testing.T) {
+	canonical := true
+	nonCanonical := false
+
+	tests := []struct {
+		name     string
+		blocks   []*chaindb.Block
+		slots    int
+		expected *chaindb.EpochSummary
+	}{
+		{
+			name:  "NoBlocks",
+			slots: 32,
+			expected: &chaindb.EpochSummary{
+				MissedProposals: 32,
+			},
+		},
+		{
+			name: "Mixed",
+			blocks: []*chaindb.Block{
+				{Slot: 1, Canonical: &canonical},
+				{Slot: 2, Canonical: &canonical},
+				{Slot: 2, Canonical: &nonCanonical},
+				{Slot: 3, Canonical: &nonCanonical},
+				{Slot: 4},
+			},
+			slots: 4,
+			expected: &chaindb.EpochSummary{
+				CanonicalBlocks: 2,
+				OrphanedBlocks:  2,
+				MissedProposals: 2,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			summary := &chaindb.EpochSummary{}
+			setBlockStats(summary, test.blocks, test.slots)
+			require.Equal(t, test.expected, summary)
+		})
+	}
+}
+
+func TestAverageInclusionDelay(t *testing.T) {
+	delay1 := 1.0
+	delay2 := 2.5
+
+	tests := []struct {
+		name         string
+		attestations []*chaindb.Attestation
+		expected     *float64
+	}{
+		{
+			name: "None",
+		},
+		{
+			name: "Single",
+			attestations: []*chaindb.Attestation{
+				{Slot: 10, InclusionSlot: 11, AggregationIndices: []phase0.ValidatorIndex{1, 2}},
+			},
+			expected: &delay1,
+		},
+		{
+			name: "FirstInclusion",
+			attestations: []*chaindb.Attestation{
+				{Slot: 10, InclusionSlot: 14, AggregationIndices: []phase0.ValidatorIndex{1, 2}},
+				{Slot: 10, InclusionSlot: 11, AggregationIndices: []phase0.ValidatorIndex{1}},
+			},
+			expected: &delay2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, averageInclusionDelay(test.attestations))
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// failedItemsModule is the name of this module in failed items.
+const failedItemsModule = "summarizer"
+
+// deadEpochs provides the epochs in the given range whose summaries have
+// failed too many times, and so are skipped.
+func (s *Service) deadEpochs(ctx context.Context, firstEpoch phase0.Epoch, lastEpoch phase0.Epoch) (map[phase0.Epoch]bool, error) {
+	if s.maxAttempts == 0 {
+		return nil, nil
+	}
+
+	from := uint64(firstEpoch)
+	to := uint64(lastEpoch)
+	items, err := s.chainDB.(chaindb.FailedItemsProvider).FailedItems(ctx, &chaindb.FailedItemFilter{
+		Modules: []string{failedItemsModule},
+		Kind:    chaindb.FailedItemKindEpoch,
+		States:  []string{chaindb.FailedItemStateDead, chaindb.FailedItemStateAcknowledged},
+		From:    &from,
+		To:      &to,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain dead epochs")
+	}
+
+	epochs := make(map[phase0.Epoch]bool, len(items))
+	for _, item := range items {
+		epochs[phase0.Epoch(item.Item)] = true
+	}
+
+	return epochs, nil
+}
+
+// recordFailedEpoch records a failure to summarize the given epoch.
+// Returns true if the epoch has now failed too many times, and so will be skipped.
+func (s *Service) recordFailedEpoch(ctx context.Context, epoch phase0.Epoch, failure error) bool {
+	if s.maxAttempts == 0 {
+		return false
+	}
+	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+
+	item, err := util.RecordFailedItem(ctx, s.chainDB, failedItemsModule, chaindb.FailedItemKindEpoch, uint64(epoch), failure, s.maxAttempts)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to record failed epoch")
+		return false
+	}
+	if item.State != chaindb.FailedItemStateDead {
+		log.Debug().Uint32("attempts", item.Attempts).Msg("Recorded failed epoch")
+		return false
+	}
+
+	log.Warn().Uint32("attempts", item.Attempts).Str("error", item.Error).Msg("Epoch failed too many times; skipping")
+	return true
+}
+
+// skipEpoch moves the epoch summarizer past an epoch without summarizing it.
+func (s *Service) skipEpoch(ctx context.Context, md *metadata, epoch phase0.Epoch) error {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	md.LastEpoch = epoch
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// retryFailedEpochs summarizes epochs that have been marked for retry.
+func (s *Service) retryFailedEpochs(ctx context.Context, md *metadata) {
+	if s.maxAttempts == 0 {
+		return
+	}
+
+	items, err := s.chainDB.(chaindb.FailedItemsProvider).FailedItems(ctx, &chaindb.FailedItemFilter{
+		Modules: []string{failedItemsModule},
+		Kind:    chaindb.FailedItemKindEpoch,
+		States:  []string{chaindb.FailedItemStateRetry},
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain epochs to retry")
+		return
+	}
+
+	retried := make([]uint64, 0, len(items))
+	for _, item := range items {
+		epoch := phase0.Epoch(item.Item)
+		updated, err := s.summarizeEpoch(ctx, md, epoch)
+		if err != nil {
+			log.Warn().Uint64("epoch", uint64(epoch)).Err(err).Msg("Failed to retry epoch")
+			s.recordFailedEpoch(ctx, epoch, err)
+			continue
+		}
+		if !updated {
+			log.Debug().Uint64("epoch", uint64(epoch)).Msg("Not enough data to retry epoch")
+			continue
+		}
+		log.Info().Uint64("epoch", uint64(epoch)).Msg("Retried epoch")
+		retried = append(retried, item.Item)
+	}
+
+	if err := util.RemoveFailedItems(ctx, s.chainDB, failedItemsModule, chaindb.FailedItemKindEpoch, retried); err != nil {
+		log.Error().Err(err).Msg("Failed to remove retried epochs")
+	}
+}
+
+// clearFailedEpochs removes failing epochs that have since been summarized.
+func (s *Service) clearFailedEpochs(ctx context.Context, md *metadata) {
+	if s.maxAttempts == 0 {
+		return
+	}
+
+	to := uint64(md.LastEpoch)
+	items, err := s.chainDB.(chaindb.FailedItemsProvider).FailedItems(ctx, &chaindb.FailedItemFilter{
+		Modules: []string{failedItemsModule},
+		Kind:    chaindb.FailedItemKindEpoch,
+		States:  []string{chaindb.FailedItemStateFailing},
+		To:      &to,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain failing epochs")
+		return
+	}
+
+	summarized := make([]uint64, len(items))
+	for i := range items {
+		summarized[i] = items[i].Item
+	}
+	if err := util.RemoveFailedItems(ctx, s.chainDB, failedItemsModule, chaindb.FailedItemKindEpoch, summarized); err != nil {
+		log.Error().Err(err).Msg("Failed to remove summarized epochs")
+	}
+}
@@ -15,7 +15,6 @@ package standard
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
@@ -23,30 +22,59 @@ import (
 
 // metadata stored about this service.
 type metadata struct {
-	LastValidatorEpoch       phase0.Epoch `json:"latest_validator_epoch"`
-	LastBlockEpoch           phase0.Epoch `json:"latest_block_epoch"`
-	LastEpoch                phase0.Epoch `json:"latest_epoch"`
-	LastValidatorDay         int64        `json:"last_validator_day"`
-	PeriodicValidatorRollups bool         `json:"periodic_validator_rollups"`
+	LastValidatorEpoch       phase0.Epoch
+	LastBlockEpoch           phase0.Epoch
+	LastEpoch                phase0.Epoch
+	LastValidatorDay         int64
+	PeriodicValidatorRollups bool
+	LastSyncPeriod           int64
+	LastProposerDay          int64
+	LastClusterEpoch         int64
+	LastBlobFeeEpoch         int64
+	LastBalanceAnomalyEpoch  int64
 }
 
-// metadataKey is the key for the metadata.
-var metadataKey = "summarizer.standard"
+// progressService is the name of this service for progress.
+var progressService = "summarizer.standard"
 
 // getMetadata gets metadata for this service.
 func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 	md := &metadata{
-		LastValidatorDay: -1,
+		LastValidatorDay:        -1,
+		LastSyncPeriod:          -1,
+		LastProposerDay:         -1,
+		LastClusterEpoch:        -1,
+		LastBlobFeeEpoch:        -1,
+		LastBalanceAnomalyEpoch: -1,
 	}
-	mdJSON, err := s.chainDB.Metadata(ctx, metadataKey)
+	progress, err := s.chainDB.Progress(ctx, progressService)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to fetch metadata")
+		return nil, errors.Wrap(err, "failed to fetch progress")
 	}
-	if mdJSON == nil {
+	if progress == nil {
 		return md, nil
 	}
-	if err := json.Unmarshal(mdJSON, md); err != nil {
-		return nil, errors.Wrap(err, "failed to unmarshal metadata")
+	md.LastValidatorEpoch = phase0.Epoch(progress.Values["latest_validator_epoch"])
+	md.LastBlockEpoch = phase0.Epoch(progress.Values["latest_block_epoch"])
+	md.LastEpoch = phase0.Epoch(progress.Values["latest_epoch"])
+	if val, exists := progress.Values["last_validator_day"]; exists {
+		md.LastValidatorDay = val
+	}
+	md.PeriodicValidatorRollups = progress.Values["periodic_validator_rollups"] == 1
+	if val, exists := progress.Values["last_sync_period"]; exists {
+		md.LastSyncPeriod = val
+	}
+	if val, exists := progress.Values["last_proposer_day"]; exists {
+		md.LastProposerDay = val
+	}
+	if val, exists := progress.Values["last_cluster_epoch"]; exists {
+		md.LastClusterEpoch = val
+	}
+	if val, exists := progress.Values["last_blob_fee_epoch"]; exists {
+		md.LastBlobFeeEpoch = val
+	}
+	if val, exists := progress.Values["last_balance_anomaly_epoch"]; exists {
+		md.LastBalanceAnomalyEpoch = val
 	}
 
 	return md, nil
@@ -54,12 +82,25 @@ func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 
 // setMetadata sets metadata for this service.
 func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
-	mdJSON, err := json.Marshal(md)
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal metadata")
+	values := map[string]int64{
+		"latest_validator_epoch":     int64(md.LastValidatorEpoch),
+		"latest_block_epoch":         int64(md.LastBlockEpoch),
+		"latest_epoch":               int64(md.LastEpoch),
+		"last_validator_day":         md.LastValidatorDay,
+		"periodic_validator_rollups": 0,
+		"last_sync_period":           md.LastSyncPeriod,
+		"last_proposer_day":          md.LastProposerDay,
+		"last_cluster_epoch":         md.LastClusterEpoch,
+		"last_blob_fee_epoch":        md.LastBlobFeeEpoch,
+		"last_balance_anomaly_epoch": md.LastBalanceAnomalyEpoch,
+	}
+	if md.PeriodicValidatorRollups {
+		values["periodic_validator_rollups"] = 1
 	}
-	if err := s.chainDB.SetMetadata(ctx, metadataKey, mdJSON); err != nil {
-		return errors.Wrap(err, "failed to update metadata")
+	for key, value := range values {
+		if err := s.chainDB.SetProgress(ctx, progressService, key, value); err != nil {
+			return errors.Wrapf(err, "failed to update %s", key)
+		}
 	}
 	return nil
 }
@@ -36,9 +36,12 @@ var (
 	daysProcessed prometheus.Counter
 )
 
+var balanceAnomaliesFound *prometheus.CounterVec
+
 var (
-	lastEpochPrune   prometheus.Gauge
-	lastBalancePrune prometheus.Gauge
+	lastEpochPrune     prometheus.Gauge
+	lastBalancePrune   prometheus.Gauge
+	lastTransientPrune prometheus.Gauge
 )
 
 func registerMetrics(_ context.Context, monitor metrics.Service) error {
@@ -112,6 +115,24 @@ func registerPrometheusMetrics() error {
 		return errors.Wrap(err, "failed to register epoch_prune_ts")
 	}
 
+	lastTransientPrune = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "transient_prune_ts",
+		Help:      "Timestamp of last transient data prune",
+	})
+	if err := prometheus.Register(lastTransientPrune); err != nil {
+		return errors.Wrap(err, "failed to register transient_prune_ts")
+	}
+
+	balanceAnomaliesFound = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "balance_anomalies_total",
+		Help:      "Number of validator balance anomalies found",
+	}, []string{"reason"})
+	if err := prometheus.Register(balanceAnomaliesFound); err != nil {
+		return errors.Wrap(err, "failed to register balance_anomalies_total")
+	}
+
 	return nil
 }
 
@@ -164,3 +185,15 @@ func monitorEpochPruned() {
 		lastEpochPrune.SetToCurrentTime()
 	}
 }
+
+func monitorTransientPruned() {
+	if lastTransientPrune != nil {
+		lastTransientPrune.SetToCurrentTime()
+	}
+}
+
+func monitorBalanceAnomaly(reason string) {
+	if balanceAnomaliesFound != nil {
+		balanceAnomaliesFound.WithLabelValues(reason).Inc()
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// networkAggregate calculates the network aggregate for the given epoch.
+// Validators without a balance at the epoch did not exist at the time, so are ignored.
+func (s *Service) networkAggregate(epoch phase0.Epoch,
+	validators []*chaindb.Validator,
+	balances []*chaindb.ValidatorBalance,
+) *chaindb.NetworkAggregate {
+	aggregate := &chaindb.NetworkAggregate{
+		Epoch: epoch,
+	}
+
+	for i, validator := range validators {
+		if i >= len(balances) {
+			break
+		}
+		aggregate.TotalBalance += balances[i].Balance
+		switch {
+		case validator.ActivationEpoch > epoch:
+			aggregate.EnteringValidators++
+		case validator.ExitEpoch <= epoch:
+			if validator.ExitEpoch == epoch {
+				aggregate.ExitedValidators++
+			}
+		default:
+			aggregate.ActiveValidators++
+			aggregate.ActiveBalance += balances[i].Balance
+			if validator.ActivationEpoch == epoch {
+				aggregate.ActivatedValidators++
+			}
+			if validator.ExitEpoch != s.farFutureEpoch {
+				aggregate.ExitingValidators++
+			}
+		}
+	}
+
+	if aggregate.ActiveValidators > 0 {
+		aggregate.AverageBalance = aggregate.ActiveBalance / phase0.Gwei(aggregate.ActiveValidators)
+	}
+
+	aggregate.ExitChurnLimit = s.churnLimit(aggregate.ActiveValidators)
+	aggregate.ActivationChurnLimit = s.activationChurnLimit(epoch, aggregate.ActiveValidators)
+	if aggregate.ActivationChurnLimit > 0 {
+		aggregate.ActivationChurnUtilization = float64(aggregate.ActivatedValidators) / float64(aggregate.ActivationChurnLimit)
+	}
+	if aggregate.ExitChurnLimit > 0 {
+		aggregate.ExitChurnUtilization = float64(aggregate.ExitedValidators) / float64(aggregate.ExitChurnLimit)
+	}
+
+	return aggregate
+}
+
+// churnLimit calculates the validator churn limit for the given number of active validators.
+func (s *Service) churnLimit(activeValidators int) int {
+	if s.churnLimitQuotient == 0 {
+		return int(s.minPerEpochChurnLimit)
+	}
+	churnLimit := uint64(activeValidators) / s.churnLimitQuotient
+	if churnLimit < s.minPerEpochChurnLimit {
+		churnLimit = s.minPerEpochChurnLimit
+	}
+
+	return int(churnLimit)
+}
+
+// activationChurnLimit calculates the validator activation churn limit for the given
+// number of active validators at the given epoch.
+func (s *Service) activationChurnLimit(epoch phase0.Epoch, activeValidators int) int {
+	churnLimit := s.churnLimit(activeValidators)
+	if epoch >= s.chainTime.DenebInitialEpoch() &&
+		s.maxPerEpochActivationChurnLimit > 0 &&
+		uint64(churnLimit) > s.maxPerEpochActivationChurnLimit {
+		churnLimit = int(s.maxPerEpochActivationChurnLimit)
+	}
+
+	return churnLimit
+}
@@ -0,0 +1,86 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+func TestNetworkAggregate(t *testing.T) {
+	farFutureEpoch := phase0.Epoch(0xffffffffffffffff)
+	s := &Service{
+		chainTime:                       mockchaintime.New(),
+		farFutureEpoch:                  farFutureEpoch,
+		minPerEpochChurnLimit:           4,
+		churnLimitQuotient:              2,
+		maxPerEpochActivationChurnLimit: 8,
+	}
+
+	validators := []*chaindb.Validator{
+		// Active.
+		{Index: 0, ActivationEpoch: 0, ExitEpoch: farFutureEpoch},
+		// Activated this epoch.
+		{Index: 1, ActivationEpoch: 10, ExitEpoch: farFutureEpoch},
+		// Exiting.
+		{Index: 2, ActivationEpoch: 0, ExitEpoch: 12},
+		// Exited this epoch.
+		{Index: 3, ActivationEpoch: 0, ExitEpoch: 10},
+		// Exited previously.
+		{Index: 4, ActivationEpoch: 0, ExitEpoch: 5},
+		// Entering.
+		{Index: 5, ActivationEpoch: farFutureEpoch, ExitEpoch: farFutureEpoch},
+		// Not present at the epoch.
+		{Index: 6, ActivationEpoch: farFutureEpoch, ExitEpoch: farFutureEpoch},
+	}
+	balances := []*chaindb.ValidatorBalance{
+		{Index: 0, Balance: 32000000000},
+		{Index: 1, Balance: 32000000000},
+		{Index: 2, Balance: 31000000000},
+		{Index: 3, Balance: 30000000000},
+		{Index: 4, Balance: 0},
+		{Index: 5, Balance: 32000000000},
+	}
+
+	require.Equal(t, &chaindb.NetworkAggregate{
+		Epoch:                      10,
+		TotalBalance:               157000000000,
+		ActiveValidators:           3,
+		ActiveBalance:              95000000000,
+		AverageBalance:             31666666666,
+		EnteringValidators:         1,
+		ExitingValidators:          1,
+		ActivatedValidators:        1,
+		ExitedValidators:           1,
+		ActivationChurnLimit:       4,
+		ExitChurnLimit:             4,
+		ActivationChurnUtilization: 0.25,
+		ExitChurnUtilization:       0.25,
+	}, s.networkAggregate(10, validators, balances))
+}
+
+func TestChurnLimit(t *testing.T) {
+	s := &Service{
+		minPerEpochChurnLimit: 4,
+		churnLimitQuotient:    65536,
+	}
+
+	require.Equal(t, 4, s.churnLimit(0))
+	require.Equal(t, 4, s.churnLimit(300000))
+	require.Equal(t, 15, s.churnLimit(1000000))
+}
@@ -15,11 +15,13 @@ package standard
 
 import (
 	"errors"
+	"fmt"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/rs/zerolog"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/loadshedder"
 	"github.com/wealdtech/chaind/services/metrics"
 )
 
@@ -29,12 +31,32 @@ type parameters struct {
 	eth2Client                eth2client.Service
 	chainDB                   chaindb.Service
 	chainTime                 chaintime.Service
+	loadShedder               loadshedder.Service
 	epochSummaries            bool
 	blockSummaries            bool
 	validatorSummaries        bool
+	validatorRankings         bool
+	committeeSummaries        bool
+	syncPeriodSummaries       bool
+	proposerSummaries         bool
+	proposerSummaryWindows    []string
+	validatorClusters         bool
+	clusterFeeRecipients      bool
+	blobFees                  bool
+	blobFeeWindow             uint64
+	balanceAnomalies          bool
+	balanceAnomalyThreshold   uint64
+	balanceAnomalyAlerts      bool
+	maxAttempts               uint32
 	validatorEpochRetention   string
 	maxDaysPerRun             uint64
+	concurrency               uint64
+	startEpoch                int64
+	endEpoch                  int64
 	validatorBalanceRetention string
+	transientAttestations     bool
+	transientBeaconCommittees bool
+	transientSyncAggregates   bool
 }
 
 // Parameter is the interface for service parameters.
@@ -83,6 +105,14 @@ func WithChainTime(chainTime chaintime.Service) Parameter {
 	})
 }
 
+// WithLoadShedder sets the load shedder, which delays summarization until
+// the busy periods of the slot have passed.
+func WithLoadShedder(loadShedder loadshedder.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.loadShedder = loadShedder
+	})
+}
+
 // WithEpochSummaries states if the module should generate epoch summaries.
 func WithEpochSummaries(enabled bool) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -104,6 +134,111 @@ func WithValidatorSummaries(enabled bool) Parameter {
 	})
 }
 
+// WithValidatorRankings states if the module should generate validator rankings
+// alongside validator day summaries.
+func WithValidatorRankings(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorRankings = enabled
+	})
+}
+
+// WithCommitteeSummaries states if the module should generate committee summaries
+// alongside epoch summaries.
+func WithCommitteeSummaries(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.committeeSummaries = enabled
+	})
+}
+
+// WithSyncPeriodSummaries states if the module should generate validator
+// summaries for each sync committee period.
+func WithSyncPeriodSummaries(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.syncPeriodSummaries = enabled
+	})
+}
+
+// WithProposerSummaries states if the module should generate proposer
+// profitability summaries.
+func WithProposerSummaries(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.proposerSummaries = enabled
+	})
+}
+
+// WithProposerSummaryWindows sets the windows, in addition to days, for which
+// proposer profitability summaries are generated.
+func WithProposerSummaryWindows(windows []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.proposerSummaryWindows = windows
+	})
+}
+
+// WithValidatorClusters states if the module should link validators into
+// clusters by the withdrawal credentials that they share.
+func WithValidatorClusters(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorClusters = enabled
+	})
+}
+
+// WithClusterFeeRecipients states if validator clusters should also link
+// validators by the fee recipients of the blocks that they propose.
+func WithClusterFeeRecipients(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.clusterFeeRecipients = enabled
+	})
+}
+
+// WithBlobFees states if the module should calculate the blob fees of
+// canonical blocks.
+func WithBlobFees(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.blobFees = enabled
+	})
+}
+
+// WithBlobFeeWindow sets the number of blocks over which rolling blob fee
+// values are calculated.
+func WithBlobFeeWindow(blocks uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.blobFeeWindow = blocks
+	})
+}
+
+// WithBalanceAnomalies states if the module should detect validator balance
+// changes that are outside of the range expected from rewards and penalties.
+func WithBalanceAnomalies(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.balanceAnomalies = enabled
+	})
+}
+
+// WithBalanceAnomalyThreshold sets the amount, in Gwei, by which a balance
+// change must be outside of its expected range to be recorded as an anomaly.
+func WithBalanceAnomalyThreshold(threshold uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.balanceAnomalyThreshold = threshold
+	})
+}
+
+// WithBalanceAnomalyAlerts states if the module should raise alerts for
+// balance anomalies.
+func WithBalanceAnomalyAlerts(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.balanceAnomalyAlerts = enabled
+	})
+}
+
+// WithMaxAttempts sets the number of times that summarizing an epoch can
+// fail before the epoch is recorded as dead and skipped.
+// If 0 then failing epochs are retried indefinitely.
+func WithMaxAttempts(maxAttempts uint32) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxAttempts = maxAttempts
+	})
+}
+
 // WithMaxDaysPerRun provides the maximum number of days to process in a single run of the summarizer.
 func WithMaxDaysPerRun(maxDaysPerRun uint64) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -111,6 +246,30 @@ func WithMaxDaysPerRun(maxDaysPerRun uint64) Parameter {
 	})
 }
 
+// WithConcurrency sets the number of epochs, and of days, that can be
+// summarized concurrently.
+func WithConcurrency(concurrency uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.concurrency = concurrency
+	})
+}
+
+// WithStartEpoch sets the start epoch for this module.  Earlier epochs are
+// not summarized.
+func WithStartEpoch(startEpoch int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.startEpoch = startEpoch
+	})
+}
+
+// WithEndEpoch sets the end epoch for this module.  Later epochs are not
+// summarized.
+func WithEndEpoch(endEpoch int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.endEpoch = endEpoch
+	})
+}
+
 // WithValidatorEpochRetention provides the amount of validator epoch data to retain.
 func WithValidatorEpochRetention(retention string) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -125,10 +284,34 @@ func WithValidatorBalanceRetention(retention string) Parameter {
 	})
 }
 
+// WithTransientAttestations states if attestations should be pruned once summarized.
+func WithTransientAttestations(transient bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.transientAttestations = transient
+	})
+}
+
+// WithTransientBeaconCommittees states if beacon committees should be pruned once summarized.
+func WithTransientBeaconCommittees(transient bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.transientBeaconCommittees = transient
+	})
+}
+
+// WithTransientSyncAggregates states if sync aggregates should be pruned once summarized.
+func WithTransientSyncAggregates(transient bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.transientSyncAggregates = transient
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel: zerolog.GlobalLevel(),
+		logLevel:    zerolog.GlobalLevel(),
+		startEpoch:  -1,
+		endEpoch:    -1,
+		concurrency: 1,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -148,6 +331,28 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.maxDaysPerRun == 0 {
 		return nil, errors.New("no max days per run specified")
 	}
+	if parameters.concurrency == 0 {
+		return nil, errors.New("no concurrency specified")
+	}
+	if parameters.endEpoch >= 0 && parameters.startEpoch > parameters.endEpoch {
+		return nil, errors.New("end epoch before start epoch")
+	}
+	if parameters.syncPeriodSummaries && !parameters.epochSummaries {
+		return nil, errors.New("sync period summaries require epoch summaries")
+	}
+	if parameters.balanceAnomalies && !parameters.epochSummaries {
+		return nil, errors.New("balance anomalies require epoch summaries")
+	}
+	for _, window := range parameters.proposerSummaryWindows {
+		switch window {
+		case chaindb.ProposerSummaryWindowDay, chaindb.ProposerSummaryWindowWeek, chaindb.ProposerSummaryWindowMonth:
+		default:
+			return nil, fmt.Errorf("unknown proposer summary window %q", window)
+		}
+	}
+	if parameters.blobFees && parameters.blobFeeWindow == 0 {
+		return nil, errors.New("no blob fee window specified")
+	}
 
 	return &parameters, nil
 }
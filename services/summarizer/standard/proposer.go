@@ -0,0 +1,391 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// summarizeProposers summarizes proposer profitability for all days that have
+// been finalized.
+func (s *Service) summarizeProposers(ctx context.Context, targetEpoch phase0.Epoch) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "summarizeProposers",
+		trace.WithAttributes(
+			attribute.Int64("target epoch", int64(targetEpoch)),
+		))
+	defer span.End()
+
+	if !s.proposerSummaries {
+		return nil
+	}
+	// Summaries hold values in wei, so values are read in wei whatever the caller requested.
+	ctx = chaindb.WithValueDenomination(ctx, chaindb.DenominationWei)
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata for proposer summarizer")
+	}
+
+	var startTime time.Time
+	if md.LastProposerDay == -1 {
+		// Start at the beginning of the day in which genesis, or the start epoch, occurred.
+		start := s.chainTime.StartOfEpoch(s.boundFirstEpoch(0)).In(time.UTC)
+		startTime = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	} else {
+		startTime = time.Unix(md.LastProposerDay, 0).In(time.UTC).AddDate(0, 0, 1)
+	}
+
+	for days := uint64(0); days < s.maxDaysPerRun; days++ {
+		// The day can only be summarized once all of its epochs are finalized.
+		endEpoch := s.chainTime.TimestampToEpoch(startTime.AddDate(0, 0, 1)) - 1
+		if endEpoch > targetEpoch {
+			log.Trace().Time("start_time", startTime).Uint64("end_epoch", uint64(endEpoch)).Msg("Day not yet finalized; not summarizing proposers")
+			return nil
+		}
+		if err := s.summarizeProposersInDay(ctx, startTime); err != nil {
+			return errors.Wrapf(err, "failed to update proposer summaries for day %s", startTime.Format("2006-01-02"))
+		}
+		startTime = startTime.AddDate(0, 0, 1)
+	}
+
+	return nil
+}
+
+// summarizeProposersInDay updates the proposer summaries for a given day,
+// along with the summaries of any windows that end with the day.
+func (s *Service) summarizeProposersInDay(ctx context.Context, startTime time.Time) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "summarizeProposersInDay",
+		trace.WithAttributes(
+			attribute.Int64("start time", startTime.Unix()),
+		))
+	defer span.End()
+
+	log := log.With().Str("date", startTime.Format("2006-01-02")).Logger()
+	startSlot := s.chainTime.TimestampToSlot(startTime)
+	endSlot := s.chainTime.TimestampToSlot(startTime.AddDate(0, 0, 1))
+	if endSlot == startSlot {
+		// Day is before genesis.
+		return s.setProposerSummaries(ctx, startTime, nil)
+	}
+
+	duties, err := s.proposerDutiesProvider.ProposerDutiesForSlotRange(ctx, startSlot, endSlot)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain proposer duties")
+	}
+
+	canonical := true
+	lastSlot := endSlot - 1
+	blocks, err := s.blocksProvider.Blocks(ctx, &chaindb.BlockFilter{
+		From:      &startSlot,
+		To:        &lastSlot,
+		Canonical: &canonical,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain canonical blocks")
+	}
+
+	consensusRewards := make(map[phase0.Root]*phase0.Gwei, len(blocks))
+	for _, block := range blocks {
+		if block.Slot == 0 {
+			// The genesis block has no rewards.
+			consensusRewards[block.Root] = new(phase0.Gwei)
+			continue
+		}
+		consensusRewards[block.Root], err = s.consensusBlockRewards(ctx, block.Root)
+		if err != nil {
+			return err
+		}
+	}
+	span.AddEvent("Obtained consensus rewards")
+
+	labels, err := s.watchedValidatorLabels(ctx)
+	if err != nil {
+		return err
+	}
+
+	summaries := proposerDaySummaries(startTime, duties, blocks, consensusRewards, labels)
+	log.Trace().Int("summaries", len(summaries)).Msg("Generated day summaries")
+
+	return s.setProposerSummaries(ctx, startTime, summaries)
+}
+
+// setProposerSummaries stores the proposer summaries for a day, rolls up any
+// windows that end with the day, and records progress.
+func (s *Service) setProposerSummaries(ctx context.Context,
+	startTime time.Time,
+	summaries []*chaindb.ProposerPeriodSummary,
+) error {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to set proposer summaries")
+	}
+
+	if len(summaries) > 0 {
+		if err := s.chainDB.(chaindb.ProposerPeriodSummariesSetter).SetProposerPeriodSummaries(ctx, summaries); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set proposer day summaries")
+		}
+	}
+
+	for _, window := range s.proposerSummaryWindows {
+		windowStart, ends := proposerWindowEndingOn(window, startTime)
+		if !ends {
+			continue
+		}
+		daySummaries, err := s.chainDB.(chaindb.ProposerPeriodSummariesProvider).ProposerPeriodSummaries(ctx, &chaindb.ProposerPeriodSummaryFilter{
+			Window: chaindb.ProposerSummaryWindowDay,
+			From:   &windowStart,
+			To:     &startTime,
+		})
+		if err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to obtain proposer day summaries")
+		}
+		windowSummaries := aggregateProposerSummaries(window, windowStart, daySummaries)
+		if len(windowSummaries) == 0 {
+			continue
+		}
+		if err := s.chainDB.(chaindb.ProposerPeriodSummariesSetter).SetProposerPeriodSummaries(ctx, windowSummaries); err != nil {
+			cancel()
+			return errors.Wrapf(err, "failed to set proposer %s summaries", window)
+		}
+	}
+
+	// Fetch updated metadata as it may have changed since we last obtained it.
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to obtain metadata for proposer summarizer")
+	}
+	md.LastProposerDay = startTime.Unix()
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set summarizer metadata for proposer summary")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set commit transaction to set proposer summaries")
+	}
+
+	return nil
+}
+
+// watchedValidatorLabels returns the labels of validators on the watchlist.
+func (s *Service) watchedValidatorLabels(ctx context.Context) (map[phase0.ValidatorIndex]string, error) {
+	labels := make(map[phase0.ValidatorIndex]string)
+	if s.watchlistProvider == nil {
+		return labels, nil
+	}
+
+	validators, err := s.watchlistProvider.WatchedValidators(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain watched validators")
+	}
+	for _, validator := range validators {
+		if validator.Label != "" {
+			labels[validator.Index] = validator.Label
+		}
+	}
+
+	return labels, nil
+}
+
+// proposerWindowEndingOn returns the start of the window of the given type
+// if the window ends on the day starting at the given time.
+func proposerWindowEndingOn(window string, day time.Time) (time.Time, bool) {
+	nextDay := day.AddDate(0, 0, 1)
+	switch window {
+	case chaindb.ProposerSummaryWindowWeek:
+		if nextDay.Weekday() != time.Monday {
+			return time.Time{}, false
+		}
+		return day.AddDate(0, 0, -6), true
+	case chaindb.ProposerSummaryWindowMonth:
+		if nextDay.Day() != 1 {
+			return time.Time{}, false
+		}
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// proposerDaySummaries generates the proposer summaries for a day, for each
+// proposer and for each tag.
+func proposerDaySummaries(startTime time.Time,
+	duties []*chaindb.ProposerDuty,
+	blocks []*chaindb.Block,
+	consensusRewards map[phase0.Root]*phase0.Gwei,
+	labels map[phase0.ValidatorIndex]string,
+) []*chaindb.ProposerPeriodSummary {
+	proposers := make(map[phase0.ValidatorIndex]*chaindb.ProposerPeriodSummary)
+	summary := func(index phase0.ValidatorIndex) *chaindb.ProposerPeriodSummary {
+		if _, exists := proposers[index]; !exists {
+			proposers[index] = newProposerSummary(chaindb.ProposerSummaryWindowDay, startTime)
+			proposers[index].ValidatorIndex = &index
+		}
+		return proposers[index]
+	}
+
+	dutySlots := make(map[phase0.Slot]bool, len(duties))
+	for _, duty := range duties {
+		dutySlots[duty.Slot] = true
+		summary(duty.ValidatorIndex).Proposals++
+	}
+
+	for _, block := range blocks {
+		proposer := summary(block.ProposerIndex)
+		if !dutySlots[block.Slot] {
+			// No duty recorded for this block, so count it here.
+			proposer.Proposals++
+		}
+		proposer.ProposalsIncluded++
+		addConsensusRewards(proposer, consensusRewards[block.Root])
+		if block.ExecutionPayload == nil || block.ExecutionPayload.PayloadValue == nil {
+			continue
+		}
+		if block.ExecutionPayload.PayloadValueSource == chaindb.PayloadValueSourceRelay {
+			proposer.MEVPayments.Add(proposer.MEVPayments, block.ExecutionPayload.PayloadValue)
+			proposer.MEVBlocks++
+		} else {
+			proposer.ExecutionFees.Add(proposer.ExecutionFees, block.ExecutionPayload.PayloadValue)
+		}
+	}
+
+	summaries := make([]*chaindb.ProposerPeriodSummary, 0, len(proposers))
+	tags := make(map[string]*chaindb.ProposerPeriodSummary)
+	for index, proposer := range proposers {
+		summaries = append(summaries, proposer)
+		label, exists := labels[index]
+		if !exists {
+			continue
+		}
+		if _, exists := tags[label]; !exists {
+			tags[label] = newProposerSummary(chaindb.ProposerSummaryWindowDay, startTime)
+			tag := label
+			tags[label].Tag = &tag
+		}
+		addProposerSummary(tags[label], proposer)
+	}
+	for _, tag := range tags {
+		summaries = append(summaries, tag)
+	}
+	sortProposerSummaries(summaries)
+
+	return summaries
+}
+
+// aggregateProposerSummaries rolls up day summaries in to summaries for a window.
+func aggregateProposerSummaries(window string,
+	startTime time.Time,
+	daySummaries []*chaindb.ProposerPeriodSummary,
+) []*chaindb.ProposerPeriodSummary {
+	proposers := make(map[phase0.ValidatorIndex]*chaindb.ProposerPeriodSummary)
+	tags := make(map[string]*chaindb.ProposerPeriodSummary)
+	for _, daySummary := range daySummaries {
+		var summary *chaindb.ProposerPeriodSummary
+		switch {
+		case daySummary.ValidatorIndex != nil:
+			index := *daySummary.ValidatorIndex
+			if _, exists := proposers[index]; !exists {
+				proposers[index] = newProposerSummary(window, startTime)
+				proposers[index].ValidatorIndex = &index
+			}
+			summary = proposers[index]
+		case daySummary.Tag != nil:
+			tag := *daySummary.Tag
+			if _, exists := tags[tag]; !exists {
+				tags[tag] = newProposerSummary(window, startTime)
+				tags[tag].Tag = &tag
+			}
+			summary = tags[tag]
+		default:
+			continue
+		}
+		addProposerSummary(summary, daySummary)
+	}
+
+	summaries := make([]*chaindb.ProposerPeriodSummary, 0, len(proposers)+len(tags))
+	for _, proposer := range proposers {
+		summaries = append(summaries, proposer)
+	}
+	for _, tag := range tags {
+		summaries = append(summaries, tag)
+	}
+	sortProposerSummaries(summaries)
+
+	return summaries
+}
+
+func newProposerSummary(window string, startTime time.Time) *chaindb.ProposerPeriodSummary {
+	consensusRewards := phase0.Gwei(0)
+	return &chaindb.ProposerPeriodSummary{
+		Window:           window,
+		StartTimestamp:   startTime,
+		ConsensusRewards: &consensusRewards,
+		ExecutionFees:    new(big.Int),
+		MEVPayments:      new(big.Int),
+	}
+}
+
+// addProposerSummary adds the values of one summary to another.
+func addProposerSummary(dst *chaindb.ProposerPeriodSummary, src *chaindb.ProposerPeriodSummary) {
+	dst.Proposals += src.Proposals
+	dst.ProposalsIncluded += src.ProposalsIncluded
+	addConsensusRewards(dst, src.ConsensusRewards)
+	if src.ExecutionFees != nil {
+		dst.ExecutionFees.Add(dst.ExecutionFees, src.ExecutionFees)
+	}
+	if src.MEVPayments != nil {
+		dst.MEVPayments.Add(dst.MEVPayments, src.MEVPayments)
+	}
+	dst.MEVBlocks += src.MEVBlocks
+}
+
+// addConsensusRewards adds consensus rewards to a summary.
+// Unknown rewards make the summary's rewards unknown.
+func addConsensusRewards(dst *chaindb.ProposerPeriodSummary, rewards *phase0.Gwei) {
+	if dst.ConsensusRewards == nil {
+		return
+	}
+	if rewards == nil {
+		dst.ConsensusRewards = nil
+		return
+	}
+	total := *dst.ConsensusRewards + *rewards
+	dst.ConsensusRewards = &total
+}
+
+// sortProposerSummaries sorts summaries by validator index, followed by tag.
+func sortProposerSummaries(summaries []*chaindb.ProposerPeriodSummary) {
+	sort.Slice(summaries, func(i int, j int) bool {
+		if summaries[i].ValidatorIndex != nil && summaries[j].ValidatorIndex != nil {
+			return *summaries[i].ValidatorIndex < *summaries[j].ValidatorIndex
+		}
+		if summaries[i].ValidatorIndex != nil || summaries[j].ValidatorIndex != nil {
+			return summaries[i].ValidatorIndex != nil
+		}
+		return *summaries[i].Tag < *summaries[j].Tag
+	})
+}
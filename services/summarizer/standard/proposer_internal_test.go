@@ -0,0 +1,242 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func gwei(val uint64) *phase0.Gwei {
+	res := phase0.Gwei(val)
+	return &res
+}
+
+func validatorIndex(val uint64) *phase0.ValidatorIndex {
+	res := phase0.ValidatorIndex(val)
+	return &res
+}
+
+func tag(val string) *string {
+	return &val
+}
+
+func TestProposerDaySummaries(t *testing.T) {
+	startTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	duties := []*chaindb.ProposerDuty{
+		{Slot: 1, ValidatorIndex: 1},
+		{Slot: 2, ValidatorIndex: 2},
+		{Slot: 3, ValidatorIndex: 1},
+		{Slot: 4, ValidatorIndex: 3},
+	}
+	blocks := []*chaindb.Block{
+		{
+			Slot:          1,
+			ProposerIndex: 1,
+			Root:          phase0.Root{0x01},
+			ExecutionPayload: &chaindb.ExecutionPayload{
+				PayloadValue:       big.NewInt(1000),
+				PayloadValueSource: chaindb.PayloadValueSourceLocal,
+			},
+		},
+		{
+			Slot:          2,
+			ProposerIndex: 2,
+			Root:          phase0.Root{0x02},
+			ExecutionPayload: &chaindb.ExecutionPayload{
+				PayloadValue:       big.NewInt(5000),
+				PayloadValueSource: chaindb.PayloadValueSourceRelay,
+			},
+		},
+		{
+			// Payload value not yet known.
+			Slot:             4,
+			ProposerIndex:    3,
+			Root:             phase0.Root{0x04},
+			ExecutionPayload: &chaindb.ExecutionPayload{BlockHash: [32]byte{0x04}},
+		},
+	}
+	consensusRewards := map[phase0.Root]*phase0.Gwei{
+		{0x01}: gwei(10),
+		{0x02}: gwei(20),
+		// Rewards for block 0x04 are not available.
+		{0x04}: nil,
+	}
+	labels := map[phase0.ValidatorIndex]string{
+		1: "a",
+		2: "a",
+		3: "b",
+	}
+
+	summaries := proposerDaySummaries(startTime, duties, blocks, consensusRewards, labels)
+	require.Equal(t, []*chaindb.ProposerPeriodSummary{
+		{
+			Window:            chaindb.ProposerSummaryWindowDay,
+			StartTimestamp:    startTime,
+			ValidatorIndex:    validatorIndex(1),
+			Proposals:         2,
+			ProposalsIncluded: 1,
+			ConsensusRewards:  gwei(10),
+			ExecutionFees:     big.NewInt(1000),
+			MEVPayments:       big.NewInt(0),
+		},
+		{
+			Window:            chaindb.ProposerSummaryWindowDay,
+			StartTimestamp:    startTime,
+			ValidatorIndex:    validatorIndex(2),
+			Proposals:         1,
+			ProposalsIncluded: 1,
+			ConsensusRewards:  gwei(20),
+			ExecutionFees:     big.NewInt(0),
+			MEVPayments:       big.NewInt(5000),
+			MEVBlocks:         1,
+		},
+		{
+			Window:            chaindb.ProposerSummaryWindowDay,
+			StartTimestamp:    startTime,
+			ValidatorIndex:    validatorIndex(3),
+			Proposals:         1,
+			ProposalsIncluded: 1,
+			ExecutionFees:     big.NewInt(0),
+			MEVPayments:       big.NewInt(0),
+		},
+		{
+			Window:            chaindb.ProposerSummaryWindowDay,
+			StartTimestamp:    startTime,
+			Tag:               tag("a"),
+			Proposals:         3,
+			ProposalsIncluded: 2,
+			ConsensusRewards:  gwei(30),
+			ExecutionFees:     big.NewInt(1000),
+			MEVPayments:       big.NewInt(5000),
+			MEVBlocks:         1,
+		},
+		{
+			Window:            chaindb.ProposerSummaryWindowDay,
+			StartTimestamp:    startTime,
+			Tag:               tag("b"),
+			Proposals:         1,
+			ProposalsIncluded: 1,
+			ExecutionFees:     big.NewInt(0),
+			MEVPayments:       big.NewInt(0),
+		},
+	}, summaries)
+}
+
+func TestAggregateProposerSummaries(t *testing.T) {
+	day1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	daySummaries := []*chaindb.ProposerPeriodSummary{
+		{Window: chaindb.ProposerSummaryWindowDay, StartTimestamp: day1, ValidatorIndex: validatorIndex(1), Proposals: 1, ProposalsIncluded: 1, ConsensusRewards: gwei(10), ExecutionFees: big.NewInt(100), MEVPayments: big.NewInt(0)},
+		{Window: chaindb.ProposerSummaryWindowDay, StartTimestamp: day1, Tag: tag("a"), Proposals: 1, ProposalsIncluded: 1, ConsensusRewards: gwei(10), ExecutionFees: big.NewInt(100), MEVPayments: big.NewInt(0)},
+		{Window: chaindb.ProposerSummaryWindowDay, StartTimestamp: day2, ValidatorIndex: validatorIndex(1), Proposals: 2, ProposalsIncluded: 1, ConsensusRewards: gwei(15), ExecutionFees: big.NewInt(0), MEVPayments: big.NewInt(700), MEVBlocks: 1},
+		{Window: chaindb.ProposerSummaryWindowDay, StartTimestamp: day2, ValidatorIndex: validatorIndex(2), Proposals: 1, ProposalsIncluded: 1, ExecutionFees: big.NewInt(50), MEVPayments: big.NewInt(0)},
+		{Window: chaindb.ProposerSummaryWindowDay, StartTimestamp: day2, Tag: tag("a"), Proposals: 2, ProposalsIncluded: 1, ConsensusRewards: gwei(15), ExecutionFees: big.NewInt(0), MEVPayments: big.NewInt(700), MEVBlocks: 1},
+	}
+
+	summaries := aggregateProposerSummaries(chaindb.ProposerSummaryWindowWeek, day1, daySummaries)
+	require.Equal(t, []*chaindb.ProposerPeriodSummary{
+		{Window: chaindb.ProposerSummaryWindowWeek, StartTimestamp: day1, ValidatorIndex: validatorIndex(1), Proposals: 3, ProposalsIncluded: 2, ConsensusRewards: gwei(25), ExecutionFees: big.NewInt(100), MEVPayments: big.NewInt(700), MEVBlocks: 1},
+		{Window: chaindb.ProposerSummaryWindowWeek, StartTimestamp: day1, ValidatorIndex: validatorIndex(2), Proposals: 1, ProposalsIncluded: 1, ExecutionFees: big.NewInt(50), MEVPayments: big.NewInt(0)},
+		{Window: chaindb.ProposerSummaryWindowWeek, StartTimestamp: day1, Tag: tag("a"), Proposals: 3, ProposalsIncluded: 2, ConsensusRewards: gwei(25), ExecutionFees: big.NewInt(100), MEVPayments: big.NewInt(700), MEVBlocks: 1},
+	}, summaries)
+}
+
+func TestProposerWindowEndingOn(t *testing.T) {
+	tests := []struct {
+		name   string
+		window string
+		day    time.Time
+		start  time.Time
+		ends   bool
+	}{
+		{
+			name:   "WeekEnds",
+			window: chaindb.ProposerSummaryWindowWeek,
+			day:    time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC),
+			start:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			ends:   true,
+		},
+		{
+			name:   "WeekContinues",
+			window: chaindb.ProposerSummaryWindowWeek,
+			day:    time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "MonthEnds",
+			window: chaindb.ProposerSummaryWindowMonth,
+			day:    time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			start:  time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			ends:   true,
+		},
+		{
+			name:   "MonthContinues",
+			window: chaindb.ProposerSummaryWindowMonth,
+			day:    time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "Day",
+			window: chaindb.ProposerSummaryWindowDay,
+			day:    time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, ends := proposerWindowEndingOn(test.window, test.day)
+			require.Equal(t, test.ends, ends)
+			require.Equal(t, test.start, start)
+		})
+	}
+}
+
+func TestConsensusBlockRewards(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/eth/v1/beacon/rewards/blocks/0x0100000000000000000000000000000000000000000000000000000000000000":
+			_, _ = w.Write([]byte(`{"execution_optimistic":false,"finalized":true,"data":{"proposer_index":"1","total":"12345","attestations":"12000","sync_aggregate":"345","proposer_slashings":"0","attester_slashings":"0"}}`))
+		case "/eth/v1/beacon/rewards/blocks/0x0200000000000000000000000000000000000000000000000000000000000000":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	s := &Service{
+		beaconAddress: server.URL,
+		httpClient:    server.Client(),
+	}
+
+	rewards, err := s.consensusBlockRewards(ctx, phase0.Root{0x01})
+	require.NoError(t, err)
+	require.Equal(t, gwei(12345), rewards)
+
+	rewards, err = s.consensusBlockRewards(ctx, phase0.Root{0x02})
+	require.NoError(t, err)
+	require.Nil(t, rewards)
+
+	_, err = s.consensusBlockRewards(ctx, phase0.Root{0x03})
+	require.Error(t, err)
+}
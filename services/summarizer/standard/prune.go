@@ -65,12 +65,18 @@ func (s *Service) pruneBalances(ctx context.Context, summaryEpoch phase0.Epoch)
 	pruneEpoch := s.chainTime.TimestampToEpoch(pruneTime)
 	log.Trace().Stringer("retention", s.validatorBalanceRetention).Time("summary_time", summaryTime).Time("summarized_time", summarizedTime).Time("prune_time", pruneTime).Uint64("prune_epoch", uint64(pruneEpoch)).Msg("Prune parameters for balances")
 
+	// Watched validators are retained regardless of the retention period.
+	retain, err := s.watchedValidatorIndices(ctx)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel, err := s.chainDB.BeginTx(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to begin transaction to prune validator balances")
 	}
 
-	if err := s.chainDB.(chaindb.ValidatorBalancesPruner).PruneValidatorBalances(ctx, pruneEpoch, nil); err != nil {
+	if err := s.chainDB.(chaindb.ValidatorBalancesPruner).PruneValidatorBalances(ctx, pruneEpoch, retain); err != nil {
 		cancel()
 		return errors.Wrap(err, "failed to prune validator balances")
 	}
@@ -109,12 +115,18 @@ func (s *Service) pruneEpochs(ctx context.Context, summaryEpoch phase0.Epoch) er
 	pruneEpoch := s.chainTime.TimestampToEpoch(pruneTime)
 	log.Trace().Stringer("retention", s.validatorEpochRetention).Time("summary_time", summaryTime).Time("summarized_time", summarizedTime).Time("prune_time", pruneTime).Uint64("prune_epoch", uint64(pruneEpoch)).Msg("Prune parameters for epochs")
 
+	// Watched validators are retained regardless of the retention period.
+	retain, err := s.watchedValidatorIndices(ctx)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel, err := s.chainDB.BeginTx(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to begin transaction to prune validator epoch summaries")
 	}
 
-	if err := s.chainDB.(chaindb.ValidatorEpochSummariesPruner).PruneValidatorEpochSummaries(ctx, pruneEpoch, nil); err != nil {
+	if err := s.chainDB.(chaindb.ValidatorEpochSummariesPruner).PruneValidatorEpochSummaries(ctx, pruneEpoch, retain); err != nil {
 		cancel()
 		return errors.Wrap(err, "failed to prune validator epoch summaries")
 	}
@@ -127,3 +139,124 @@ func (s *Service) pruneEpochs(ctx context.Context, summaryEpoch phase0.Epoch) er
 
 	return nil
 }
+
+// pruneTransient prunes data that is only stored until it has been summarized.
+func (s *Service) pruneTransient(ctx context.Context, targetEpoch phase0.Epoch) error {
+	if !s.transientAttestations && !s.transientBeaconCommittees && !s.transientSyncAggregates {
+		return nil
+	}
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+	watched, err := s.watchedValidatorIndices(ctx)
+	if err != nil {
+		return err
+	}
+	summarizedEpoch := s.summarizedEpoch(md, targetEpoch, len(watched) > 0)
+
+	// Attestations included in the last summarized epoch can still be needed when summarizing
+	// the following epoch, so they are retained.
+	attestationsPruneSlot := s.chainTime.FirstSlotOfEpoch(summarizedEpoch)
+
+	// Attestations can be included up to an epoch after their slot, and when compact need
+	// the beacon committee to expand their indices, so committees are kept for an extra epoch.
+	beaconCommitteesPruneSlot := phase0.Slot(0)
+	if summarizedEpoch > 0 {
+		beaconCommitteesPruneSlot = s.chainTime.FirstSlotOfEpoch(summarizedEpoch - 1)
+	}
+
+	// Sync aggregates are rolled up in to day summaries, so are retained until the day is summarized.
+	syncAggregatesPruneSlot := attestationsPruneSlot
+	if md.PeriodicValidatorRollups {
+		if md.LastValidatorDay == -1 {
+			syncAggregatesPruneSlot = 0
+		} else {
+			daySlot := s.chainTime.TimestampToSlot(time.Unix(md.LastValidatorDay, 0).AddDate(0, 0, 1))
+			if daySlot < syncAggregatesPruneSlot {
+				syncAggregatesPruneSlot = daySlot
+			}
+		}
+	}
+
+	// Sync aggregates are also rolled up in to sync period summaries, so are retained until the period is summarized.
+	if s.syncPeriodSummaries {
+		if md.LastSyncPeriod == -1 {
+			syncAggregatesPruneSlot = 0
+		} else {
+			periodSlot := s.chainTime.FirstSlotOfEpoch(s.chainTime.FirstEpochOfSyncPeriod(uint64(md.LastSyncPeriod + 1)))
+			if periodSlot < syncAggregatesPruneSlot {
+				syncAggregatesPruneSlot = periodSlot
+			}
+		}
+	}
+
+	log.Trace().Uint64("summarized_epoch", uint64(summarizedEpoch)).Uint64("attestations_slot", uint64(attestationsPruneSlot)).Uint64("beacon_committees_slot", uint64(beaconCommitteesPruneSlot)).Uint64("sync_aggregates_slot", uint64(syncAggregatesPruneSlot)).Msg("Prune parameters for transient data")
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to prune transient data")
+	}
+
+	if s.transientAttestations && attestationsPruneSlot > 0 {
+		pruner, isPruner := s.chainDB.(chaindb.AttestationsPruner)
+		if !isPruner {
+			cancel()
+			return errors.New("chain DB does not support attestation pruning")
+		}
+		if err := pruner.PruneAttestations(ctx, attestationsPruneSlot); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to prune attestations")
+		}
+	}
+
+	if s.transientBeaconCommittees && beaconCommitteesPruneSlot > 0 {
+		pruner, isPruner := s.chainDB.(chaindb.BeaconCommitteesPruner)
+		if !isPruner {
+			cancel()
+			return errors.New("chain DB does not support beacon committee pruning")
+		}
+		if err := pruner.PruneBeaconCommittees(ctx, beaconCommitteesPruneSlot); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to prune beacon committees")
+		}
+	}
+
+	if s.transientSyncAggregates && syncAggregatesPruneSlot > 0 {
+		pruner, isPruner := s.chainDB.(chaindb.SyncAggregatePruner)
+		if !isPruner {
+			cancel()
+			return errors.New("chain DB does not support sync aggregate pruning")
+		}
+		if err := pruner.PruneSyncAggregates(ctx, syncAggregatesPruneSlot); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to prune sync aggregates")
+		}
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction to prune transient data")
+	}
+	monitorTransientPruned()
+	log.Trace().Msg("Pruned transient data")
+
+	return nil
+}
+
+// summarizedEpoch is the latest epoch for which all enabled summaries have been generated.
+func (s *Service) summarizedEpoch(md *metadata, targetEpoch phase0.Epoch, watching bool) phase0.Epoch {
+	epoch := targetEpoch
+	if s.epochSummaries && md.LastEpoch < epoch {
+		epoch = md.LastEpoch
+	}
+	if s.blockSummaries && md.LastBlockEpoch < epoch {
+		epoch = md.LastBlockEpoch
+	}
+	if (s.validatorSummaries || watching) && md.LastValidatorEpoch < epoch {
+		epoch = md.LastValidatorEpoch
+	}
+
+	return epoch
+}
@@ -0,0 +1,81 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizedEpoch(t *testing.T) {
+	md := &metadata{
+		LastEpoch:          100,
+		LastBlockEpoch:     90,
+		LastValidatorEpoch: 80,
+	}
+
+	tests := []struct {
+		name     string
+		service  *Service
+		target   phase0.Epoch
+		watching bool
+		expected phase0.Epoch
+	}{
+		{
+			name:     "NoSummaries",
+			service:  &Service{},
+			target:   110,
+			expected: 110,
+		},
+		{
+			name:     "EpochSummaries",
+			service:  &Service{epochSummaries: true},
+			target:   110,
+			expected: 100,
+		},
+		{
+			name:     "BlockSummaries",
+			service:  &Service{epochSummaries: true, blockSummaries: true},
+			target:   110,
+			expected: 90,
+		},
+		{
+			name:     "ValidatorSummaries",
+			service:  &Service{epochSummaries: true, blockSummaries: true, validatorSummaries: true},
+			target:   110,
+			expected: 80,
+		},
+		{
+			name:     "Watching",
+			service:  &Service{epochSummaries: true},
+			target:   110,
+			watching: true,
+			expected: 80,
+		},
+		{
+			name:     "TargetEarlier",
+			service:  &Service{epochSummaries: true, validatorSummaries: true},
+			target:   50,
+			expected: 50,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, test.service.summarizedEpoch(md, test.target, test.watching))
+		})
+	}
+}
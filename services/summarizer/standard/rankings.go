@@ -0,0 +1,109 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"math"
+	"sort"
+
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Attestation reward weights, as per the Altair specification.
+const (
+	timelySourceWeight = 14
+	timelyTargetWeight = 26
+	timelyHeadWeight   = 14
+)
+
+// dayEffectiveness calculates the attestation effectiveness of a validator for a day,
+// as the fraction of the maximum attestation reward weight that it obtained.
+// Prior to Altair there are no timely flags, so correctness of the vote is used instead.
+func dayEffectiveness(summary *chaindb.ValidatorDaySummary, altair bool) float64 {
+	if summary.Attestations == 0 {
+		return 0
+	}
+
+	var source, target, head int
+	if altair {
+		source = summary.AttestationsSourceTimely
+		target = summary.AttestationsTargetTimely
+		head = summary.AttestationsHeadTimely
+	} else {
+		source = summary.AttestationsIncluded
+		target = summary.AttestationsTargetCorrect
+		head = summary.AttestationsHeadCorrect
+	}
+
+	obtained := float64(source*timelySourceWeight + target*timelyTargetWeight + head*timelyHeadWeight)
+	maximum := float64(summary.Attestations * (timelySourceWeight + timelyTargetWeight + timelyHeadWeight))
+
+	return obtained / maximum
+}
+
+// rankValidatorDays ranks the effectiveness of each validator against that of all validators for the day.
+// Validators without attestation duties for the day are not ranked.
+func rankValidatorDays(summaries []*chaindb.ValidatorDaySummary, altair bool) []*chaindb.ValidatorDayRanking {
+	rankings := make([]*chaindb.ValidatorDayRanking, 0, len(summaries))
+	for _, summary := range summaries {
+		if summary.Attestations == 0 {
+			continue
+		}
+		rankings = append(rankings, &chaindb.ValidatorDayRanking{
+			Index:          summary.Index,
+			StartTimestamp: summary.StartTimestamp,
+			Effectiveness:  dayEffectiveness(summary, altair),
+		})
+	}
+	if len(rankings) == 0 {
+		return rankings
+	}
+
+	sort.Slice(rankings, func(i int, j int) bool {
+		if rankings[i].Effectiveness != rankings[j].Effectiveness {
+			return rankings[i].Effectiveness < rankings[j].Effectiveness
+		}
+		return rankings[i].Index < rankings[j].Index
+	})
+
+	total := 0.0
+	for _, ranking := range rankings {
+		total += ranking.Effectiveness
+	}
+	mean := total / float64(len(rankings))
+	variance := 0.0
+	for _, ranking := range rankings {
+		variance += (ranking.Effectiveness - mean) * (ranking.Effectiveness - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(rankings)))
+
+	// Effectiveness takes a limited number of values so ties are common; tied
+	// validators share the same percentile, with ties counted as half below.
+	for start := 0; start < len(rankings); {
+		end := start
+		for end < len(rankings) && rankings[end].Effectiveness == rankings[start].Effectiveness {
+			end++
+		}
+		percentile := 100 * (float64(start) + float64(end-start)/2) / float64(len(rankings))
+		for i := start; i < end; i++ {
+			rankings[i].Percentile = percentile
+			if stddev > 0 {
+				rankings[i].ZScore = (rankings[i].Effectiveness - mean) / stddev
+			}
+		}
+		start = end
+	}
+
+	return rankings
+}
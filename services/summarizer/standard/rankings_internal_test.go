@@ -0,0 +1,130 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestDayEffectiveness(t *testing.T) {
+	tests := []struct {
+		name     string
+		summary  *chaindb.ValidatorDaySummary
+		altair   bool
+		expected float64
+	}{
+		{
+			name:     "NoAttestations",
+			summary:  &chaindb.ValidatorDaySummary{},
+			altair:   true,
+			expected: 0,
+		},
+		{
+			name: "Perfect",
+			summary: &chaindb.ValidatorDaySummary{
+				Attestations:             2,
+				AttestationsSourceTimely: 2,
+				AttestationsTargetTimely: 2,
+				AttestationsHeadTimely:   2,
+			},
+			altair:   true,
+			expected: 1,
+		},
+		{
+			name: "HeadMissed",
+			summary: &chaindb.ValidatorDaySummary{
+				Attestations:             1,
+				AttestationsSourceTimely: 1,
+				AttestationsTargetTimely: 1,
+			},
+			altair:   true,
+			expected: 40.0 / 54.0,
+		},
+		{
+			name: "Phase0",
+			summary: &chaindb.ValidatorDaySummary{
+				Attestations:              2,
+				AttestationsIncluded:      2,
+				AttestationsTargetCorrect: 1,
+				AttestationsHeadCorrect:   1,
+			},
+			altair:   false,
+			expected: 68.0 / 108.0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.InDelta(t, test.expected, dayEffectiveness(test.summary, test.altair), 1e-9)
+		})
+	}
+}
+
+func TestRankValidatorDays(t *testing.T) {
+	startTimestamp := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	summary := func(index phase0.ValidatorIndex, attestations int, timely int) *chaindb.ValidatorDaySummary {
+		return &chaindb.ValidatorDaySummary{
+			Index:                    index,
+			StartTimestamp:           startTimestamp,
+			Attestations:             attestations,
+			AttestationsSourceTimely: timely,
+			AttestationsTargetTimely: timely,
+			AttestationsHeadTimely:   timely,
+		}
+	}
+
+	t.Run("Empty", func(t *testing.T) {
+		require.Empty(t, rankValidatorDays(nil, true))
+	})
+
+	t.Run("Equal", func(t *testing.T) {
+		rankings := rankValidatorDays([]*chaindb.ValidatorDaySummary{
+			summary(1, 2, 2),
+			summary(2, 2, 2),
+		}, true)
+		require.Len(t, rankings, 2)
+		for _, ranking := range rankings {
+			require.Equal(t, 50.0, ranking.Percentile)
+			require.Equal(t, 0.0, ranking.ZScore)
+		}
+	})
+
+	t.Run("Mixed", func(t *testing.T) {
+		rankings := rankValidatorDays([]*chaindb.ValidatorDaySummary{
+			summary(3, 4, 4),
+			summary(1, 4, 0),
+			summary(2, 4, 2),
+			summary(4, 4, 4),
+			summary(5, 0, 0),
+		}, true)
+		require.Len(t, rankings, 4)
+		require.Equal(t, phase0.ValidatorIndex(1), rankings[0].Index)
+		require.Equal(t, 12.5, rankings[0].Percentile)
+		require.Equal(t, phase0.ValidatorIndex(2), rankings[1].Index)
+		require.Equal(t, 37.5, rankings[1].Percentile)
+		require.Equal(t, 75.0, rankings[2].Percentile)
+		require.Equal(t, 75.0, rankings[3].Percentile)
+		// Mean 0.625, standard deviation sqrt(0.171875).
+		require.InDelta(t, -1.5075567, rankings[0].ZScore, 1e-6)
+		require.InDelta(t, 0.9045340, rankings[3].ZScore, 1e-6)
+		for _, ranking := range rankings {
+			require.Equal(t, startTimestamp, ranking.StartTimestamp)
+		}
+	})
+}
@@ -15,16 +15,20 @@ package standard
 
 import (
 	"context"
+	"fmt"
 	"math"
+	"net/http"
+	"strings"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/attestantio/go-eth2-client/api"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/loadshedder"
 	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
@@ -38,20 +42,55 @@ type Service struct {
 	attestationsProvider            chaindb.AttestationsProvider
 	blocksProvider                  chaindb.BlocksProvider
 	depositsProvider                chaindb.DepositsProvider
+	voluntaryExitsProvider          chaindb.VoluntaryExitsProvider
 	withdrawalsProvider             chaindb.WithdrawalsProvider
 	validatorsProvider              chaindb.ValidatorsProvider
 	attesterSlashingsProvider       chaindb.AttesterSlashingsProvider
 	proposerSlashingsProvider       chaindb.ProposerSlashingsProvider
+	watchlistProvider               chaindb.WatchlistProvider
+	beaconCommitteesProvider        chaindb.BeaconCommitteesProvider
 	chainTime                       chaintime.Service
+	loadShedder                     loadshedder.Service
 	maxTimelyAttestationSourceDelay uint64
 	maxTimelyAttestationTargetDelay uint64
 	maxTimelyAttestationHeadDelay   uint64
 	epochSummaries                  bool
 	blockSummaries                  bool
 	validatorSummaries              bool
+	validatorRankings               bool
+	committeeSummaries              bool
+	syncPeriodSummaries             bool
+	proposerSummaries               bool
+	proposerSummaryWindows          []string
+	validatorClusters               bool
+	clusterFeeRecipients            bool
+	blobFees                        bool
+	blobFeeWindow                   uint64
+	balanceAnomalies                bool
+	balanceAnomalyThreshold         uint64
+	balanceAnomalyAlerts            bool
+	maxAttempts                     uint32
+	beaconAddress                   string
+	httpClient                      *http.Client
 	maxDaysPerRun                   uint64
+	concurrency                     uint64
+	startEpoch                      *phase0.Epoch
+	endEpoch                        *phase0.Epoch
 	validatorEpochRetention         *util.CalendarDuration
 	validatorBalanceRetention       *util.CalendarDuration
+	transientAttestations           bool
+	transientBeaconCommittees       bool
+	transientSyncAggregates         bool
+	minPerEpochChurnLimit           uint64
+	churnLimitQuotient              uint64
+	maxPerEpochActivationChurnLimit uint64
+	maxSeedLookahead                uint64
+	slotsPerEpoch                   uint64
+	effectiveBalanceIncrement       uint64
+	baseRewardFactor                uint64
+	syncCommitteeSize               uint64
+	syncRewardWeight                uint64
+	weightDenominator               uint64
 	activitySem                     *semaphore.Weighted
 }
 
@@ -66,7 +105,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "summarizer").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log = util.ServiceLogger("summarizer", "standard", parameters.logLevel)
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -92,6 +131,11 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not provide deposits")
 	}
 
+	voluntaryExitsProvider, isProvider := parameters.chainDB.(chaindb.VoluntaryExitsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide voluntary exits")
+	}
+
 	withdrawalsProvider, isProvider := parameters.chainDB.(chaindb.WithdrawalsProvider)
 	if !isProvider {
 		return nil, errors.New("chain DB does not provide withdrawals")
@@ -112,28 +156,160 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not provide proposer slashings")
 	}
 
+	// The watchlist is optional, so no error if it is not provided.
+	watchlistProvider, _ := parameters.chainDB.(chaindb.WatchlistProvider)
+
+	var beaconCommitteesProvider chaindb.BeaconCommitteesProvider
+	if parameters.committeeSummaries {
+		beaconCommitteesProvider, isProvider = parameters.chainDB.(chaindb.BeaconCommitteesProvider)
+		if !isProvider {
+			return nil, errors.New("chain DB does not provide beacon committees")
+		}
+		if _, isSetter := parameters.chainDB.(chaindb.CommitteeEpochSummariesSetter); !isSetter {
+			return nil, errors.New("chain DB does not support committee epoch summaries")
+		}
+	}
+
 	specResponse, err := parameters.eth2Client.(eth2client.SpecProvider).Spec(ctx, &api.SpecOpts{})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain spec")
 	}
-	spec := specResponse.Data
+	spec := chaindb.NewSpec(specResponse.Data)
 
-	tmp, exists := spec["MIN_ATTESTATION_INCLUSION_DELAY"]
-	if !exists {
-		return nil, errors.New("MIN_ATTESTATION_INCLUSION_DELAY not found in spec")
+	minAttestationInclusionDelay := spec.MinAttestationInclusionDelay
+	if minAttestationInclusionDelay == 0 {
+		return nil, errors.New("failed to obtain MIN_ATTESTATION_INCLUSION_DELAY")
 	}
-	minAttestationInclusionDelay, ok := tmp.(uint64)
-	if !ok {
-		return nil, errors.New("MIN_ATTESTATION_INCLUSION_DELAY of unexpected type")
+
+	slotsPerEpoch := spec.SlotsPerEpoch
+	if slotsPerEpoch == 0 {
+		return nil, errors.New("failed to obtain SLOTS_PER_EPOCH")
+	}
+
+	minPerEpochChurnLimit := spec.MinPerEpochChurnLimit
+	if minPerEpochChurnLimit == 0 {
+		return nil, errors.New("failed to obtain MIN_PER_EPOCH_CHURN_LIMIT")
+	}
+
+	churnLimitQuotient := spec.ChurnLimitQuotient
+	if churnLimitQuotient == 0 {
+		return nil, errors.New("failed to obtain CHURN_LIMIT_QUOTIENT")
+	}
+
+	maxSeedLookahead := spec.MaxSeedLookahead
+	if maxSeedLookahead == 0 {
+		return nil, errors.New("failed to obtain MAX_SEED_LOOKAHEAD")
 	}
 
-	tmp, exists = spec["SLOTS_PER_EPOCH"]
-	if !exists {
-		return nil, errors.New("SLOTS_PER_EPOCH not found in spec")
+	// MAX_PER_EPOCH_ACTIVATION_CHURN_LIMIT was introduced in Deneb, so may not be present.
+	maxPerEpochActivationChurnLimit := spec.MaxPerEpochActivationChurnLimit
+
+	// Reward parameters are only required for sync period summaries and balance anomalies.
+	var effectiveBalanceIncrement uint64
+	var baseRewardFactor uint64
+	var syncCommitteeSize uint64
+	// SYNC_REWARD_WEIGHT and WEIGHT_DENOMINATOR are constants, so may not be present.
+	syncRewardWeight := uint64(2)
+	weightDenominator := uint64(64)
+	if parameters.syncPeriodSummaries {
+		if _, isProvider := parameters.chainDB.(chaindb.EpochSummariesProvider); !isProvider {
+			return nil, errors.New("chain DB does not provide epoch summaries")
+		}
+		if _, isProvider := parameters.chainDB.(chaindb.SyncCommitteesProvider); !isProvider {
+			return nil, errors.New("chain DB does not provide sync committees")
+		}
+		if _, isProvider := parameters.chainDB.(chaindb.SyncAggregateProvider); !isProvider {
+			return nil, errors.New("chain DB does not provide sync aggregates")
+		}
+		if _, isSetter := parameters.chainDB.(chaindb.ValidatorSyncPeriodSummariesSetter); !isSetter {
+			return nil, errors.New("chain DB does not support validator sync period summaries")
+		}
 	}
-	slotsPerEpoch, ok := tmp.(uint64)
-	if !ok {
-		return nil, errors.New("SLOTS_PER_EPOCH of unexpected type")
+	if parameters.syncPeriodSummaries || parameters.balanceAnomalies {
+		effectiveBalanceIncrement = uint64(spec.EffectiveBalanceIncrement)
+		if effectiveBalanceIncrement == 0 {
+			return nil, errors.New("failed to obtain EFFECTIVE_BALANCE_INCREMENT")
+		}
+
+		baseRewardFactor = spec.BaseRewardFactor
+		if baseRewardFactor == 0 {
+			return nil, errors.New("failed to obtain BASE_REWARD_FACTOR")
+		}
+
+		syncCommitteeSize = spec.SyncCommitteeSize
+		if syncCommitteeSize == 0 {
+			return nil, errors.New("failed to obtain SYNC_COMMITTEE_SIZE")
+		}
+
+		if spec.SyncRewardWeight != 0 {
+			syncRewardWeight = spec.SyncRewardWeight
+		}
+
+		if spec.WeightDenominator != 0 {
+			weightDenominator = spec.WeightDenominator
+		}
+	}
+
+	if parameters.validatorClusters {
+		if _, isProvider := parameters.chainDB.(chaindb.ValidatorCredentialsProvider); !isProvider {
+			return nil, errors.New("chain DB does not provide validator credentials")
+		}
+		if _, isSetter := parameters.chainDB.(chaindb.ValidatorClustersSetter); !isSetter {
+			return nil, errors.New("chain DB does not support validator clusters")
+		}
+	}
+
+	if parameters.blobFees {
+		if _, isProvider := parameters.chainDB.(chaindb.BlobFeesProvider); !isProvider {
+			return nil, errors.New("chain DB does not provide blob fees")
+		}
+		if _, isSetter := parameters.chainDB.(chaindb.BlobFeesSetter); !isSetter {
+			return nil, errors.New("chain DB does not support blob fees")
+		}
+	}
+
+	if parameters.balanceAnomalies {
+		if _, isProvider := parameters.chainDB.(chaindb.EpochSummariesProvider); !isProvider {
+			return nil, errors.New("chain DB does not provide epoch summaries")
+		}
+		if _, isProvider := parameters.chainDB.(chaindb.SyncCommitteesProvider); !isProvider {
+			return nil, errors.New("chain DB does not provide sync committees")
+		}
+		if _, isSetter := parameters.chainDB.(chaindb.BalanceAnomaliesSetter); !isSetter {
+			return nil, errors.New("chain DB does not support balance anomalies")
+		}
+	}
+
+	if parameters.maxAttempts > 0 {
+		if _, isProvider := parameters.chainDB.(chaindb.FailedItemsProvider); !isProvider {
+			return nil, errors.New("chain DB does not provide failed items")
+		}
+		if _, isSetter := parameters.chainDB.(chaindb.FailedItemsSetter); !isSetter {
+			return nil, errors.New("chain DB does not support failed items")
+		}
+	}
+
+	var proposerSummaryWindows []string
+	var beaconAddress string
+	if parameters.proposerSummaries {
+		if _, isProvider := parameters.chainDB.(chaindb.ProposerPeriodSummariesProvider); !isProvider {
+			return nil, errors.New("chain DB does not provide proposer period summaries")
+		}
+		if _, isSetter := parameters.chainDB.(chaindb.ProposerPeriodSummariesSetter); !isSetter {
+			return nil, errors.New("chain DB does not support proposer period summaries")
+		}
+		// Day summaries are always generated, as other windows are rolled up from them.
+		for _, window := range parameters.proposerSummaryWindows {
+			if window != chaindb.ProposerSummaryWindowDay {
+				proposerSummaryWindows = append(proposerSummaryWindows, window)
+			}
+		}
+		// Consensus rewards are not available through the client library, so are
+		// fetched directly from the beacon node.
+		beaconAddress = strings.TrimSuffix(parameters.eth2Client.Address(), "/")
+		if !strings.HasPrefix(beaconAddress, "http") {
+			beaconAddress = fmt.Sprintf("http://%s", beaconAddress)
+		}
 	}
 
 	var validatorEpochRetention *util.CalendarDuration
@@ -152,6 +328,17 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		}
 	}
 
+	var startEpoch *phase0.Epoch
+	if parameters.startEpoch >= 0 {
+		epoch := phase0.Epoch(parameters.startEpoch)
+		startEpoch = &epoch
+	}
+	var endEpoch *phase0.Epoch
+	if parameters.endEpoch >= 0 {
+		epoch := phase0.Epoch(parameters.endEpoch)
+		endEpoch = &epoch
+	}
+
 	s := &Service{
 		eth2Client:                      parameters.eth2Client,
 		chainDB:                         parameters.chainDB,
@@ -160,20 +347,55 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		attestationsProvider:            attestationsProvider,
 		blocksProvider:                  blocksProvider,
 		depositsProvider:                depositsProvider,
+		voluntaryExitsProvider:          voluntaryExitsProvider,
 		withdrawalsProvider:             withdrawalsProvider,
 		validatorsProvider:              validatorsProvider,
 		attesterSlashingsProvider:       attesterSlashingsProvider,
 		proposerSlashingsProvider:       proposerSlashingsProvider,
+		watchlistProvider:               watchlistProvider,
+		beaconCommitteesProvider:        beaconCommitteesProvider,
 		chainTime:                       parameters.chainTime,
+		loadShedder:                     parameters.loadShedder,
 		maxTimelyAttestationSourceDelay: uint64(math.Sqrt(float64(slotsPerEpoch))),
 		maxTimelyAttestationTargetDelay: slotsPerEpoch,
 		maxTimelyAttestationHeadDelay:   minAttestationInclusionDelay,
 		epochSummaries:                  parameters.epochSummaries,
 		blockSummaries:                  parameters.blockSummaries,
 		validatorSummaries:              parameters.validatorSummaries,
+		validatorRankings:               parameters.validatorRankings,
+		committeeSummaries:              parameters.committeeSummaries,
+		syncPeriodSummaries:             parameters.syncPeriodSummaries,
+		proposerSummaries:               parameters.proposerSummaries,
+		proposerSummaryWindows:          proposerSummaryWindows,
+		validatorClusters:               parameters.validatorClusters,
+		clusterFeeRecipients:            parameters.clusterFeeRecipients,
+		blobFees:                        parameters.blobFees,
+		blobFeeWindow:                   parameters.blobFeeWindow,
+		balanceAnomalies:                parameters.balanceAnomalies,
+		balanceAnomalyThreshold:         parameters.balanceAnomalyThreshold,
+		balanceAnomalyAlerts:            parameters.balanceAnomalyAlerts,
+		maxAttempts:                     parameters.maxAttempts,
+		beaconAddress:                   beaconAddress,
+		httpClient:                      &http.Client{Timeout: 30 * time.Second},
 		maxDaysPerRun:                   parameters.maxDaysPerRun,
+		concurrency:                     parameters.concurrency,
+		startEpoch:                      startEpoch,
+		endEpoch:                        endEpoch,
 		validatorEpochRetention:         validatorEpochRetention,
 		validatorBalanceRetention:       validatorBalanceRetention,
+		transientAttestations:           parameters.transientAttestations,
+		transientBeaconCommittees:       parameters.transientBeaconCommittees,
+		transientSyncAggregates:         parameters.transientSyncAggregates,
+		minPerEpochChurnLimit:           minPerEpochChurnLimit,
+		churnLimitQuotient:              churnLimitQuotient,
+		maxPerEpochActivationChurnLimit: maxPerEpochActivationChurnLimit,
+		maxSeedLookahead:                maxSeedLookahead,
+		slotsPerEpoch:                   slotsPerEpoch,
+		effectiveBalanceIncrement:       effectiveBalanceIncrement,
+		baseRewardFactor:                baseRewardFactor,
+		syncCommitteeSize:               syncCommitteeSize,
+		syncRewardWeight:                syncRewardWeight,
+		weightDenominator:               weightDenominator,
 		activitySem:                     semaphore.NewWeighted(1),
 	}
 
@@ -201,6 +423,12 @@ func (s *Service) catchup(ctx context.Context) {
 	}
 	defer s.activitySem.Release(1)
 
+	if s.loadShedder != nil {
+		if err := s.loadShedder.AwaitQuiet(ctx); err != nil {
+			return
+		}
+	}
+
 	response, err := s.eth2Client.(eth2client.FinalityProvider).Finality(ctx, &api.FinalityOpts{
 		State: "head",
 	})
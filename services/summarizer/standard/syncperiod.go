@@ -0,0 +1,227 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (s *Service) summarizeSyncPeriods(ctx context.Context, targetEpoch phase0.Epoch) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "summarizeSyncPeriods",
+		trace.WithAttributes(
+			attribute.Int64("target epoch", int64(targetEpoch)),
+		))
+	defer span.End()
+
+	if !s.syncPeriodSummaries {
+		return nil
+	}
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata for sync period summarizer")
+	}
+
+	// Rewards are calculated from epoch summaries, so cannot summarize beyond them.
+	if md.LastEpoch < targetEpoch {
+		targetEpoch = md.LastEpoch
+	}
+
+	firstPeriod := s.chainTime.AltairInitialSyncCommitteePeriod()
+	if md.LastSyncPeriod >= 0 && uint64(md.LastSyncPeriod)+1 > firstPeriod {
+		firstPeriod = uint64(md.LastSyncPeriod) + 1
+	}
+	if s.startEpoch != nil && s.chainTime.EpochToSyncCommitteePeriod(*s.startEpoch) > firstPeriod {
+		firstPeriod = s.chainTime.EpochToSyncCommitteePeriod(*s.startEpoch)
+	}
+
+	// A period is complete once the first epoch of the following period has been summarized,
+	// as that contains the block with the sync aggregate for the last slot of the period.
+	for period := firstPeriod; s.chainTime.FirstEpochOfSyncPeriod(period+1) <= targetEpoch; period++ {
+		if err := s.summarizeSyncPeriod(ctx, md, period); err != nil {
+			return errors.Wrapf(err, "failed to update summary for sync period %d", period)
+		}
+	}
+
+	return nil
+}
+
+// summarizeSyncPeriod summarizes the sync committee performance of validators
+// for the given period.
+func (s *Service) summarizeSyncPeriod(ctx context.Context,
+	md *metadata,
+	period uint64,
+) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "summarizeSyncPeriod",
+		trace.WithAttributes(
+			attribute.Int64("period", int64(period)),
+		))
+	defer span.End()
+
+	log := log.With().Uint64("period", period).Logger()
+	log.Trace().Msg("Summarizing sync period")
+
+	syncCommittee, err := s.chainDB.(chaindb.SyncCommitteesProvider).SyncCommittee(ctx, period)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain sync committee")
+	}
+
+	// The period may start before sync committees existed.
+	startEpoch := s.chainTime.FirstEpochOfSyncPeriod(period)
+	if startEpoch < s.chainTime.AltairInitialEpoch() {
+		startEpoch = s.chainTime.AltairInitialEpoch()
+	}
+	endEpoch := s.chainTime.FirstEpochOfSyncPeriod(period+1) - 1
+
+	// Sync aggregates are included in the block following the slot to which they refer.
+	fromSlot := s.chainTime.FirstSlotOfEpoch(startEpoch) + 1
+	toSlot := s.chainTime.LastSlotOfEpoch(endEpoch) + 1
+	syncAggregates, err := s.chainDB.(chaindb.SyncAggregateProvider).SyncAggregates(ctx, &chaindb.SyncAggregateFilter{
+		From: &fromSlot,
+		To:   &toSlot,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain sync aggregates")
+	}
+
+	fromEpoch := s.chainTime.SlotToEpoch(fromSlot)
+	toEpoch := s.chainTime.SlotToEpoch(toSlot)
+	epochSummaries, err := s.chainDB.(chaindb.EpochSummariesProvider).EpochSummaries(ctx, &chaindb.EpochSummaryFilter{
+		From: &fromEpoch,
+		To:   &toEpoch,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain epoch summaries")
+	}
+	participantRewards := make(map[phase0.Epoch]phase0.Gwei, len(epochSummaries))
+	for _, epochSummary := range epochSummaries {
+		participantRewards[epochSummary.Epoch] = s.syncParticipantReward(epochSummary.ActiveBalance)
+	}
+
+	slots := int(uint64(endEpoch+1-startEpoch) * s.slotsPerEpoch)
+	summaries, err := s.validatorSyncPeriodSummaries(period, syncCommittee.Committee, slots, syncAggregates, participantRewards)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to set validator sync period summaries")
+	}
+	if err := s.chainDB.(chaindb.ValidatorSyncPeriodSummariesSetter).SetValidatorSyncPeriodSummaries(ctx, summaries); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set validator sync period summaries")
+	}
+	md.LastSyncPeriod = int64(period)
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set summarizer metadata for sync period summary")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction to set validator sync period summaries")
+	}
+	log.Trace().Int("validators", len(summaries)).Msg("Summarized sync period")
+
+	return nil
+}
+
+// validatorSyncPeriodSummaries calculates the sync committee performance of each
+// member of the sync committee for the period.  Each aggregate must be canonical,
+// and the participant rewards are keyed by the epoch of the aggregate's inclusion slot.
+func (s *Service) validatorSyncPeriodSummaries(period uint64,
+	syncCommittee []phase0.ValidatorIndex,
+	slots int,
+	syncAggregates []*chaindb.SyncAggregate,
+	participantRewards map[phase0.Epoch]phase0.Gwei,
+) (
+	[]*chaindb.ValidatorSyncPeriodSummary,
+	error,
+) {
+	summaries := make(map[phase0.ValidatorIndex]*chaindb.ValidatorSyncPeriodSummary, len(syncCommittee))
+	res := make([]*chaindb.ValidatorSyncPeriodSummary, 0, len(syncCommittee))
+	for _, index := range syncCommittee {
+		summary, exists := summaries[index]
+		if !exists {
+			summary = &chaindb.ValidatorSyncPeriodSummary{
+				Index:  index,
+				Period: period,
+			}
+			summaries[index] = summary
+			res = append(res, summary)
+		}
+		summary.AssignedSlots += slots
+	}
+
+	for _, aggregate := range syncAggregates {
+		epoch := s.chainTime.SlotToEpoch(aggregate.InclusionSlot)
+		participantReward, exists := participantRewards[epoch]
+		if !exists {
+			return nil, errors.Errorf("no epoch summary for epoch %d", epoch)
+		}
+
+		aggregateBits := bitfield.Bitlist(aggregate.Bits)
+		if aggregateBits.Len() > uint64(len(syncCommittee)) {
+			return nil, errors.Errorf("sync aggregate at slot %d larger than sync committee", aggregate.InclusionSlot)
+		}
+		for i := uint64(0); i < aggregateBits.Len(); i++ {
+			summary := summaries[syncCommittee[i]]
+			if aggregateBits.BitAt(i) {
+				summary.ParticipatedSlots++
+				summary.Rewards += int64(participantReward)
+			} else {
+				summary.MissedSlots++
+				summary.Rewards -= int64(participantReward)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// syncParticipantReward calculates the reward for a single member of the sync
+// committee for a single slot, given the total active balance of the chain.
+// Members that do not participate are penalized by the same amount.
+func (s *Service) syncParticipantReward(totalActiveBalance phase0.Gwei) phase0.Gwei {
+	if totalActiveBalance == 0 || s.effectiveBalanceIncrement == 0 || s.syncCommitteeSize == 0 {
+		return 0
+	}
+
+	totalActiveIncrements := uint64(totalActiveBalance) / s.effectiveBalanceIncrement
+	baseRewardPerIncrement := s.effectiveBalanceIncrement * s.baseRewardFactor / integerSquareRoot(uint64(totalActiveBalance))
+	totalBaseRewards := baseRewardPerIncrement * totalActiveIncrements
+	maxParticipantRewards := totalBaseRewards * s.syncRewardWeight / s.weightDenominator / s.slotsPerEpoch
+
+	return phase0.Gwei(maxParticipantRewards / s.syncCommitteeSize)
+}
+
+// integerSquareRoot returns the largest integer x such that x*x <= n.
+func integerSquareRoot(n uint64) uint64 {
+	x := n
+	y := (x + 1) / 2
+	for y < x {
+		x = y
+		y = (x + n/x) / 2
+	}
+
+	return x
+}
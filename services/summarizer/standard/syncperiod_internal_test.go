@@ -0,0 +1,109 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/mock"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+)
+
+func TestValidatorSyncPeriodSummaries(t *testing.T) {
+	ctx := context.Background()
+
+	consensusClient, err := mock.New(ctx,
+		mock.WithGenesisTime(time.Now()),
+	)
+	require.NoError(t, err)
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithGenesisProvider(consensusClient),
+		standardchaintime.WithSpecProvider(consensusClient),
+		standardchaintime.WithForkScheduleProvider(consensusClient),
+	)
+	require.NoError(t, err)
+
+	s := &Service{
+		chainTime: chainTime,
+	}
+
+	// Validator 1 appears in the committee twice.
+	syncCommittee := []phase0.ValidatorIndex{1, 2, 3, 1}
+	syncAggregates := []*chaindb.SyncAggregate{
+		// All members participate.
+		{InclusionSlot: 3200, Bits: []byte{0x1f}},
+		// Validator 2 and the second appearance of validator 1 miss.
+		{InclusionSlot: 3201, Bits: []byte{0x15}},
+		// Aggregate in the following epoch, with a different reward.
+		{InclusionSlot: 3232, Bits: []byte{0x13}},
+	}
+	participantRewards := map[phase0.Epoch]phase0.Gwei{
+		100: 10,
+		101: 20,
+	}
+
+	summaries, err := s.validatorSyncPeriodSummaries(5, syncCommittee, 64, syncAggregates, participantRewards)
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.ValidatorSyncPeriodSummary{
+		{Index: 1, Period: 5, AssignedSlots: 128, ParticipatedSlots: 4, MissedSlots: 2, Rewards: 10 + 10 + 10 - 10 + 20 - 20},
+		{Index: 2, Period: 5, AssignedSlots: 64, ParticipatedSlots: 2, MissedSlots: 1, Rewards: 10 - 10 + 20},
+		{Index: 3, Period: 5, AssignedSlots: 64, ParticipatedSlots: 2, MissedSlots: 1, Rewards: 10 + 10 - 20},
+	}, summaries)
+
+	// Missing epoch summary.
+	_, err = s.validatorSyncPeriodSummaries(5, syncCommittee, 64, syncAggregates, map[phase0.Epoch]phase0.Gwei{100: 10})
+	require.EqualError(t, err, "no epoch summary for epoch 101")
+
+	// Aggregate larger than the committee.
+	_, err = s.validatorSyncPeriodSummaries(5, syncCommittee[:2], 64, syncAggregates, participantRewards)
+	require.EqualError(t, err, "sync aggregate at slot 3200 larger than sync committee")
+}
+
+func TestSyncParticipantReward(t *testing.T) {
+	s := &Service{
+		slotsPerEpoch:             32,
+		effectiveBalanceIncrement: 1000000000,
+		baseRewardFactor:          64,
+		syncCommitteeSize:         512,
+		syncRewardWeight:          2,
+		weightDenominator:         64,
+	}
+
+	require.Equal(t, phase0.Gwei(0), s.syncParticipantReward(0))
+	// 500,000 validators with 32 Ether each.
+	require.Equal(t, phase0.Gwei(15411), s.syncParticipantReward(16000000000000000))
+}
+
+func TestIntegerSquareRoot(t *testing.T) {
+	tests := []struct {
+		n        uint64
+		expected uint64
+	}{
+		{n: 0, expected: 0},
+		{n: 1, expected: 1},
+		{n: 3, expected: 1},
+		{n: 4, expected: 2},
+		{n: 99, expected: 9},
+		{n: 16000000000000000, expected: 126491106},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, integerSquareRoot(test.n))
+	}
+}
@@ -30,7 +30,32 @@ import (
 func (s *Service) summarizeValidatorsInDay(ctx context.Context,
 	startTime time.Time,
 ) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "summarizeValidatorsInDay",
+	summaries, err := s.validatorDaySummaries(ctx, startTime)
+	if err != nil {
+		return err
+	}
+	if summaries == nil {
+		return nil
+	}
+
+	// Fetch updated metadata as it may have changed since we last obtained it.
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata for validator day summarizer")
+	}
+
+	return s.storeValidatorDaySummaries(ctx, md, startTime, summaries)
+}
+
+// validatorDaySummaries calculates the validator summaries in a given day.
+// Returns nil if the data required to summarize the day is not yet available.
+func (s *Service) validatorDaySummaries(ctx context.Context,
+	startTime time.Time,
+) (
+	[]*chaindb.ValidatorDaySummary,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "validatorDaySummaries",
 		trace.WithAttributes(
 			attribute.Int64("start time", startTime.Unix()),
 		))
@@ -49,15 +74,15 @@ func (s *Service) summarizeValidatorsInDay(ctx context.Context,
 	// Ensure that we have enough epoch-level summarised data to turn this in to a day summary.
 	md, err := s.getMetadata(ctx)
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain metadata for validator day summarizer check")
+		return nil, errors.Wrap(err, "failed to obtain metadata for validator day summarizer check")
 	}
 	if md.LastEpoch < endEpoch {
 		log.Debug().Uint64("last_epoch", uint64(md.LastEpoch)).Msg("Epoch summaries not yet ready to be rolled up to a daily entry")
-		return nil
+		return nil, nil
 	}
 	if md.LastValidatorEpoch < endEpoch {
 		log.Debug().Uint64("last_epoch", uint64(md.LastEpoch)).Msg("Validator epoch summaries not yet ready to be rolled up to a daily entry")
-		return nil
+		return nil, nil
 	}
 
 	log.Trace().Msg("Summarising validator day")
@@ -65,20 +90,20 @@ func (s *Service) summarizeValidatorsInDay(ctx context.Context,
 	// Generate and populate the day summaries map.
 	daySummaries := make(map[phase0.ValidatorIndex]*chaindb.ValidatorDaySummary)
 	if err := s.addValidatorEpochSummaries(ctx, daySummaries, startTime, endTime); err != nil {
-		return err
+		return nil, err
 	}
 	span.AddEvent("Set epoch information")
 	if err := s.addValidatorSyncCommitteeSummaries(ctx, daySummaries, startTime, endTime); err != nil {
-		return err
+		return nil, err
 	}
 	span.AddEvent("Set sync committee information")
 	found, err := s.addValidatorBalanceSummaries(ctx, daySummaries, startTime, endTime)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if !found {
 		log.Debug().Time("startTime", startTime).Msg("Validator balances not yet ready to be rolled up to a daily entry")
-		return nil
+		return nil, nil
 	}
 	span.AddEvent("Set balance information")
 
@@ -88,6 +113,27 @@ func (s *Service) summarizeValidatorsInDay(ctx context.Context,
 		summaries = append(summaries, daySummary)
 	}
 
+	return summaries, nil
+}
+
+// storeValidatorDaySummaries stores the validator summaries for a day, and
+// updates the metadata to reflect them.
+func (s *Service) storeValidatorDaySummaries(ctx context.Context,
+	md *metadata,
+	startTime time.Time,
+	summaries []*chaindb.ValidatorDaySummary,
+) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "storeValidatorDaySummaries",
+		trace.WithAttributes(
+			attribute.Int64("start time", startTime.Unix()),
+		))
+	defer span.End()
+
+	// Ensure the timestamp points to the start of a day.
+	startTime = time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, 0, 0, 0, time.UTC)
+	log := log.With().Str("date", startTime.Format("2006-01-02")).Logger()
+	startEpoch := s.chainTime.TimestampToEpoch(startTime)
+
 	ctx, cancel, err := s.chainDB.BeginTx(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to begin transaction to set validator day summaries")
@@ -100,12 +146,15 @@ func (s *Service) summarizeValidatorsInDay(ctx context.Context,
 
 	log.Trace().Msg("Set summaries")
 
-	// Fetch updated metadata as it may have changed since we last obtained it.
-	md, err = s.getMetadata(ctx)
-	if err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to obtain metadata for validator day summarizer")
+	if s.validatorRankings {
+		rankings := rankValidatorDays(summaries, startEpoch >= s.chainTime.AltairInitialEpoch())
+		if err := s.chainDB.(chaindb.ValidatorDayRankingsSetter).SetValidatorDayRankings(ctx, rankings); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set validator day rankings")
+		}
+		log.Trace().Int("rankings", len(rankings)).Msg("Set rankings")
 	}
+
 	md.LastValidatorDay = startTime.Unix()
 	if err := s.setMetadata(ctx, md); err != nil {
 		cancel()
@@ -248,17 +297,22 @@ func (s *Service) addValidatorBalanceSummaries(ctx context.Context,
 	if err != nil {
 		return false, errors.Wrap(err, "failed to obtain deposits")
 	}
+	depositPubKeys := make([]phase0.BLSPubKey, 0, len(dbDeposits))
+	for _, deposit := range dbDeposits {
+		depositPubKeys = append(depositPubKeys, deposit.ValidatorPubKey)
+	}
+	depositIndices, err := s.chainDB.(chaindb.ValidatorIndicesProvider).ValidatorIndices(ctx, depositPubKeys)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to obtain validator indices for deposits")
+	}
 	totalDeposits := make(map[phase0.ValidatorIndex]phase0.Gwei)
 	for _, deposit := range dbDeposits {
-		validators, err := s.chainDB.(chaindb.ValidatorsProvider).ValidatorsByPublicKey(ctx, []phase0.BLSPubKey{deposit.ValidatorPubKey})
-		if err != nil {
-			return false, err
-		}
-		if len(validators) == 0 {
+		index, exists := depositIndices[deposit.ValidatorPubKey]
+		if !exists {
 			// This can happen with an invalid deposit, so ignore it.
 			continue
 		}
-		totalDeposits[validators[deposit.ValidatorPubKey].Index] += deposit.Amount
+		totalDeposits[index] += deposit.Amount
 	}
 
 	// Obtain withdrawals, and turn them in to a map for easy lookup.
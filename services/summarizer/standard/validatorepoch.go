@@ -26,11 +26,30 @@ import (
 )
 
 // summarizeValidatorsInEpoch updates the validator summaries in a given epoch.
+// If watched is supplied then only summaries for the watched validators are stored.
 func (s *Service) summarizeValidatorsInEpoch(ctx context.Context,
 	md *metadata,
 	epoch phase0.Epoch,
+	watched map[phase0.ValidatorIndex]bool,
 ) error {
-	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "summarizeValidatorsInEpoch",
+	summaries, err := s.validatorEpochSummaries(ctx, epoch, watched)
+	if err != nil {
+		return err
+	}
+
+	return s.storeValidatorEpochSummaries(ctx, md, epoch, summaries)
+}
+
+// validatorEpochSummaries calculates the validator summaries in a given epoch.
+// If watched is supplied then only summaries for the watched validators are returned.
+func (s *Service) validatorEpochSummaries(ctx context.Context,
+	epoch phase0.Epoch,
+	watched map[phase0.ValidatorIndex]bool,
+) (
+	[]*chaindb.ValidatorEpochSummary,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "validatorEpochSummaries",
 		trace.WithAttributes(
 			attribute.Int64("epoch", int64(epoch)),
 		))
@@ -39,33 +58,36 @@ func (s *Service) summarizeValidatorsInEpoch(ctx context.Context,
 	started := time.Now()
 
 	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
-	if !s.validatorSummaries {
+	if !s.validatorSummaries && len(watched) == 0 {
 		log.Trace().Msg("Validator epoch summaries not enabled")
-		return nil
+		return nil, nil
 	}
 	log.Trace().Msg("Summarizing validator epoch")
 
 	proposerDuties, validatorProposerDuties, err := s.validatorProposerDutiesForEpoch(ctx, epoch)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Fetched proposer duties")
 
 	validatorProposals, err := s.validatorProposalsForEpoch(ctx, epoch, proposerDuties, validatorProposerDuties)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Fetched proposals")
 
 	attestationsIncluded, attestationsTargetCorrect, attestationsHeadCorrect, attestationsInclusionDelay, attestationsSourceTimely, attestationsTargetTimely, attestationsHeadTimely, err := s.attestationsForEpoch(ctx, epoch)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Fetched attestations")
 
 	// Store the data.
 	summaries := make([]*chaindb.ValidatorEpochSummary, 0, len(attestationsIncluded))
 	for index := range attestationsIncluded {
+		if watched != nil && !watched[index] {
+			continue
+		}
 		summary := &chaindb.ValidatorEpochSummary{
 			Index:               index,
 			Epoch:               epoch,
@@ -95,6 +117,24 @@ func (s *Service) summarizeValidatorsInEpoch(ctx context.Context,
 		summaries = append(summaries, summary)
 	}
 
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Calculated summaries")
+
+	return summaries, nil
+}
+
+// storeValidatorEpochSummaries stores the validator summaries for an epoch,
+// and updates the metadata to reflect them.
+func (s *Service) storeValidatorEpochSummaries(ctx context.Context,
+	md *metadata,
+	epoch phase0.Epoch,
+	summaries []*chaindb.ValidatorEpochSummary,
+) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.summarizer.standard").Start(ctx, "storeValidatorEpochSummaries",
+		trace.WithAttributes(
+			attribute.Int64("epoch", int64(epoch)),
+		))
+	defer span.End()
+
 	ctx, cancel, err := s.chainDB.BeginTx(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to begin transaction to set validator epoch summary")
@@ -105,7 +145,7 @@ func (s *Service) summarizeValidatorsInEpoch(ctx context.Context,
 		return errors.Wrap(err, "failed to set validator epoch summary")
 	}
 
-	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set summary")
+	log.Trace().Uint64("epoch", uint64(epoch)).Msg("Set summary")
 	md.LastValidatorEpoch = epoch
 	if err := s.setMetadata(ctx, md); err != nil {
 		cancel()
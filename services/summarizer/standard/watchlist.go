@@ -0,0 +1,77 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// watchedValidatorIndices returns the indices of the validators on the watchlist.
+func (s *Service) watchedValidatorIndices(ctx context.Context) ([]phase0.ValidatorIndex, error) {
+	if s.watchlistProvider == nil {
+		return nil, nil
+	}
+
+	validators, err := s.watchlistProvider.WatchedValidators(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain watched validators")
+	}
+
+	indices := make([]phase0.ValidatorIndex, 0, len(validators))
+	for _, validator := range validators {
+		indices = append(indices, validator.Index)
+	}
+
+	return indices, nil
+}
+
+// watchedValidators returns the validators on the watchlist as a set.
+func (s *Service) watchedValidators(ctx context.Context) (map[phase0.ValidatorIndex]bool, error) {
+	indices, err := s.watchedValidatorIndices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	watched := make(map[phase0.ValidatorIndex]bool, len(indices))
+	for _, index := range indices {
+		watched[index] = true
+	}
+
+	return watched, nil
+}
+
+// earliestActivationEpoch returns the earliest activation epoch of the given validators.
+func (s *Service) earliestActivationEpoch(ctx context.Context, watched map[phase0.ValidatorIndex]bool) (phase0.Epoch, error) {
+	indices := make([]phase0.ValidatorIndex, 0, len(watched))
+	for index := range watched {
+		indices = append(indices, index)
+	}
+
+	validators, err := s.validatorsProvider.ValidatorsByIndex(ctx, indices)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain watched validators")
+	}
+
+	earliest := s.farFutureEpoch
+	for _, validator := range validators {
+		if validator.ActivationEpoch < earliest {
+			earliest = validator.ActivationEpoch
+		}
+	}
+
+	return earliest, nil
+}
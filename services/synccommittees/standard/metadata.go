@@ -15,45 +15,40 @@ package standard
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/pkg/errors"
 )
 
 // metadata stored about this service.
 type metadata struct {
-	LatestPeriod int64 `json:"latest_period"`
+	LatestPeriod int64
 }
 
-// metadataKey is the key for the metadata.
-var metadataKey = "synccommittees.standard"
+// progressService is the name of this service for progress.
+var progressService = "synccommittees.standard"
 
 // getMetadata gets metadata for this service.
 func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 	md := &metadata{
 		LatestPeriod: -1,
 	}
-	mdJSON, err := s.chainDB.Metadata(ctx, metadataKey)
+	progress, err := s.chainDB.Progress(ctx, progressService)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to fetch metadata")
+		return nil, errors.Wrap(err, "failed to fetch progress")
 	}
-	if mdJSON == nil {
+	if progress == nil {
 		return md, nil
 	}
-	if err := json.Unmarshal(mdJSON, md); err != nil {
-		return nil, errors.Wrap(err, "failed to unmarshal metadata")
+	if val, exists := progress.Values["latest_period"]; exists {
+		md.LatestPeriod = val
 	}
 	return md, nil
 }
 
 // setMetadata sets metadata for this service.
 func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
-	mdJSON, err := json.Marshal(md)
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal metadata")
-	}
-	if err := s.chainDB.SetMetadata(ctx, metadataKey, mdJSON); err != nil {
-		return errors.Wrap(err, "failed to update metadata")
+	if err := s.chainDB.SetProgress(ctx, progressService, "latest_period", md.LatestPeriod); err != nil {
+		return errors.Wrap(err, "failed to update latest period")
 	}
 	return nil
 }
@@ -0,0 +1,67 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backfill
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// metadata stored about this service.
+type metadata struct {
+	StartEpoch  phase0.Epoch
+	EndEpoch    phase0.Epoch
+	LatestEpoch int64
+}
+
+// progressService is the name of this service for progress.
+var progressService = "validators.backfill"
+
+// getMetadata gets metadata for this service.
+// Returns nil if there is no metadata, or no epoch has yet been backfilled.
+func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
+	progress, err := s.chainDB.Progress(ctx, progressService)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain progress")
+	}
+	if progress == nil {
+		return nil, nil
+	}
+	latestEpoch, exists := progress.Values["latest_epoch"]
+	if !exists {
+		return nil, nil
+	}
+	md := &metadata{
+		StartEpoch:  phase0.Epoch(progress.Values["start_epoch"]),
+		EndEpoch:    phase0.Epoch(progress.Values["end_epoch"]),
+		LatestEpoch: latestEpoch,
+	}
+	return md, nil
+}
+
+// setMetadata sets metadata for this service.
+func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
+	if err := s.chainDB.SetProgress(ctx, progressService, "start_epoch", int64(md.StartEpoch)); err != nil {
+		return errors.Wrap(err, "failed to update start epoch")
+	}
+	if err := s.chainDB.SetProgress(ctx, progressService, "end_epoch", int64(md.EndEpoch)); err != nil {
+		return errors.Wrap(err, "failed to update end epoch")
+	}
+	if err := s.chainDB.SetProgress(ctx, progressService, "latest_epoch", md.LatestEpoch); err != nil {
+		return errors.Wrap(err, "failed to update latest epoch")
+	}
+	return nil
+}
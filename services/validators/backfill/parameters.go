@@ -0,0 +1,143 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backfill
+
+import (
+	"errors"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/loadshedder"
+)
+
+type parameters struct {
+	logLevel    zerolog.Level
+	eth2Client  eth2client.Service
+	chainDB     chaindb.Service
+	chainTime   chaintime.Service
+	loadShedder loadshedder.Service
+	startEpoch  int64
+	endEpoch    int64
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(p *parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithETH2Client sets the Ethereum 2 client for this module.
+// This should be an archive node, able to provide state for historical epochs.
+func WithETH2Client(eth2Client eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eth2Client = eth2Client
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithLoadShedder sets the load shedder, which delays fetches from the beacon
+// node until the busy periods of the slot have passed.
+func WithLoadShedder(loadShedder loadshedder.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.loadShedder = loadShedder
+	})
+}
+
+// WithStartEpoch sets the first epoch to backfill.
+func WithStartEpoch(startEpoch int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.startEpoch = startEpoch
+	})
+}
+
+// WithEndEpoch sets the last epoch to backfill.
+// If not set, this defaults to the last completed epoch.
+func WithEndEpoch(endEpoch int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.endEpoch = endEpoch
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:   zerolog.GlobalLevel(),
+		startEpoch: -1,
+		endEpoch:   -1,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.eth2Client == nil {
+		return nil, errors.New("no Ethereum 2 client specified")
+	}
+	if _, isProvider := parameters.eth2Client.(eth2client.ValidatorsProvider); !isProvider {
+		return nil, errors.New("Ethereum 2 client does not provide validators")
+	}
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if _, isSetter := parameters.chainDB.(chaindb.ValidatorsSetter); !isSetter {
+		return nil, errors.New("chain database does not support validator setting")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if parameters.startEpoch < 0 {
+		return nil, errors.New("no start epoch specified")
+	}
+	if parameters.endEpoch < 0 {
+		currentEpoch := parameters.chainTime.CurrentEpoch()
+		if currentEpoch == 0 {
+			return nil, errors.New("no completed epochs to backfill")
+		}
+		parameters.endEpoch = int64(currentEpoch - phase0.Epoch(1))
+	}
+	if parameters.endEpoch < parameters.startEpoch {
+		return nil, errors.New("end epoch before start epoch")
+	}
+
+	return &parameters, nil
+}
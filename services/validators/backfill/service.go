@@ -0,0 +1,209 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/loadshedder"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service backfills historical validator balances.
+type Service struct {
+	eth2Client       eth2client.Service
+	chainDB          chaindb.Service
+	validatorsSetter chaindb.ValidatorsSetter
+	chainTime        chaintime.Service
+	loadShedder      loadshedder.Service
+	startEpoch       phase0.Epoch
+	endEpoch         phase0.Epoch
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("validators", "backfill", parameters.logLevel)
+
+	s := &Service{
+		eth2Client:       parameters.eth2Client,
+		chainDB:          parameters.chainDB,
+		validatorsSetter: parameters.chainDB.(chaindb.ValidatorsSetter),
+		chainTime:        parameters.chainTime,
+		loadShedder:      parameters.loadShedder,
+		startEpoch:       phase0.Epoch(parameters.startEpoch),
+		endEpoch:         phase0.Epoch(parameters.endEpoch),
+	}
+
+	return s, nil
+}
+
+// Backfill backfills validator balances for the configured range of epochs.
+// Progress is checkpointed after each epoch, so if a backfill of the same range
+// is interrupted it will resume from where it left off.
+func (s *Service) Backfill(ctx context.Context) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.validators.backfill").Start(ctx, "Backfill")
+	defer span.End()
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return err
+	}
+	firstEpoch, md, complete := epochsToBackfill(md, s.startEpoch, s.endEpoch)
+	if complete {
+		log.Info().Uint64("start_epoch", uint64(s.startEpoch)).Uint64("end_epoch", uint64(s.endEpoch)).Msg("Backfill already complete")
+		return nil
+	}
+	if firstEpoch != s.startEpoch {
+		log.Info().Uint64("epoch", uint64(firstEpoch)).Msg("Resuming backfill")
+	}
+
+	log.Info().Uint64("start_epoch", uint64(firstEpoch)).Uint64("end_epoch", uint64(s.endEpoch)).Msg("Backfilling validator balances")
+	started := time.Now()
+	for epoch := firstEpoch; epoch <= s.endEpoch; epoch++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.backfillEpoch(ctx, md, epoch); err != nil {
+			return errors.Wrapf(err, "failed to backfill epoch %d", epoch)
+		}
+		done := epoch - firstEpoch + 1
+		remaining := s.endEpoch - epoch
+		log.Info().
+			Uint64("epoch", uint64(epoch)).
+			Uint64("remaining", uint64(remaining)).
+			Dur("eta", time.Since(started)/time.Duration(done)*time.Duration(remaining)).
+			Msg("Backfilled epoch")
+	}
+	log.Info().Dur("elapsed", time.Since(started)).Msg("Backfill complete")
+
+	return nil
+}
+
+// epochsToBackfill returns the first epoch to backfill for the range from the start
+// epoch to the end epoch, along with the metadata against which to record progress.
+// A backfill resumes after the latest epoch of an earlier backfill only if that was of
+// the same range; otherwise it starts afresh.
+// Returns true if the range has already been backfilled.
+func epochsToBackfill(md *metadata, startEpoch phase0.Epoch, endEpoch phase0.Epoch) (phase0.Epoch, *metadata, bool) {
+	if md == nil || md.StartEpoch != startEpoch || md.EndEpoch != endEpoch || md.LatestEpoch < int64(startEpoch) {
+		return startEpoch, &metadata{
+			StartEpoch:  startEpoch,
+			EndEpoch:    endEpoch,
+			LatestEpoch: -1,
+		}, false
+	}
+	if md.LatestEpoch >= int64(endEpoch) {
+		return endEpoch, md, true
+	}
+
+	return phase0.Epoch(md.LatestEpoch + 1), md, false
+}
+
+// validatorBalances returns the balances of the validators at the given epoch.
+func validatorBalances(epoch phase0.Epoch, validators map[phase0.ValidatorIndex]*apiv1.Validator) []*chaindb.ValidatorBalance {
+	balances := make([]*chaindb.ValidatorBalance, 0, len(validators))
+	for index, validator := range validators {
+		balances = append(balances, &chaindb.ValidatorBalance{
+			Index:            index,
+			Epoch:            epoch,
+			Balance:          validator.Balance,
+			EffectiveBalance: validator.Validator.EffectiveBalance,
+		})
+	}
+	sort.Slice(balances, func(i int, j int) bool {
+		return balances[i].Index < balances[j].Index
+	})
+
+	return balances
+}
+
+func (s *Service) backfillEpoch(ctx context.Context, md *metadata, epoch phase0.Epoch) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.validators.backfill").Start(ctx, "backfillEpoch",
+		trace.WithAttributes(
+			attribute.Int64("epoch", int64(epoch)),
+		))
+	defer span.End()
+
+	if s.loadShedder != nil {
+		if err := s.loadShedder.AwaitQuiet(ctx); err != nil {
+			return errors.Wrap(err, "failed to await quiet period")
+		}
+	}
+
+	stateID := fmt.Sprintf("%d", s.chainTime.FirstSlotOfEpoch(epoch))
+	validatorsResponse, err := s.eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, &api.ValidatorsOpts{
+		State: stateID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain validators")
+	}
+	span.AddEvent("Obtained validators")
+
+	dbValidatorBalances := validatorBalances(epoch, validatorsResponse.Data)
+
+	dbCtx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := s.validatorsSetter.SetValidatorBalances(dbCtx, dbValidatorBalances); err != nil {
+		log.Trace().Err(err).Msg("Bulk insert failed; falling back to individual insert")
+		// This error will have caused the transaction to fail, so cancel it and start a new one.
+		cancel()
+		dbCtx, cancel, err = s.chainDB.BeginTx(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to begin transaction (2)")
+		}
+		for _, dbValidatorBalance := range dbValidatorBalances {
+			if err := s.validatorsSetter.SetValidatorBalance(dbCtx, dbValidatorBalance); err != nil {
+				cancel()
+				return errors.Wrap(err, "failed to set validator balance")
+			}
+		}
+	}
+
+	md.LatestEpoch = int64(epoch)
+	if err := s.setMetadata(dbCtx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+	if err := s.chainDB.CommitTx(dbCtx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
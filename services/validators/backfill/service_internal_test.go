@@ -0,0 +1,298 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backfill
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/chaintime"
+)
+
+func TestEpochsToBackfill(t *testing.T) {
+	tests := []struct {
+		name       string
+		md         *metadata
+		startEpoch phase0.Epoch
+		endEpoch   phase0.Epoch
+		first      phase0.Epoch
+		expectedMD *metadata
+		complete   bool
+	}{
+		{
+			name:       "Fresh",
+			startEpoch: 10,
+			endEpoch:   20,
+			first:      10,
+			expectedMD: &metadata{StartEpoch: 10, EndEpoch: 20, LatestEpoch: -1},
+		},
+		{
+			name:       "FreshGenesis",
+			startEpoch: 0,
+			endEpoch:   0,
+			first:      0,
+			expectedMD: &metadata{StartEpoch: 0, EndEpoch: 0, LatestEpoch: -1},
+		},
+		{
+			name:       "Resume",
+			md:         &metadata{StartEpoch: 10, EndEpoch: 20, LatestEpoch: 14},
+			startEpoch: 10,
+			endEpoch:   20,
+			first:      15,
+			expectedMD: &metadata{StartEpoch: 10, EndEpoch: 20, LatestEpoch: 14},
+		},
+		{
+			name:       "ResumeLastEpoch",
+			md:         &metadata{StartEpoch: 10, EndEpoch: 20, LatestEpoch: 19},
+			startEpoch: 10,
+			endEpoch:   20,
+			first:      20,
+			expectedMD: &metadata{StartEpoch: 10, EndEpoch: 20, LatestEpoch: 19},
+		},
+		{
+			name:       "Complete",
+			md:         &metadata{StartEpoch: 10, EndEpoch: 20, LatestEpoch: 20},
+			startEpoch: 10,
+			endEpoch:   20,
+			first:      20,
+			expectedMD: &metadata{StartEpoch: 10, EndEpoch: 20, LatestEpoch: 20},
+			complete:   true,
+		},
+		{
+			name:       "CompleteSingleEpoch",
+			md:         &metadata{StartEpoch: 0, EndEpoch: 0, LatestEpoch: 0},
+			startEpoch: 0,
+			endEpoch:   0,
+			first:      0,
+			expectedMD: &metadata{StartEpoch: 0, EndEpoch: 0, LatestEpoch: 0},
+			complete:   true,
+		},
+		{
+			name:       "DifferentStart",
+			md:         &metadata{StartEpoch: 5, EndEpoch: 20, LatestEpoch: 14},
+			startEpoch: 10,
+			endEpoch:   20,
+			first:      10,
+			expectedMD: &metadata{StartEpoch: 10, EndEpoch: 20, LatestEpoch: -1},
+		},
+		{
+			name:       "DifferentEnd",
+			md:         &metadata{StartEpoch: 10, EndEpoch: 30, LatestEpoch: 14},
+			startEpoch: 10,
+			endEpoch:   20,
+			first:      10,
+			expectedMD: &metadata{StartEpoch: 10, EndEpoch: 20, LatestEpoch: -1},
+		},
+		{
+			name:       "LatestBeforeStart",
+			md:         &metadata{StartEpoch: 10, EndEpoch: 20, LatestEpoch: 9},
+			startEpoch: 10,
+			endEpoch:   20,
+			first:      10,
+			expectedMD: &metadata{StartEpoch: 10, EndEpoch: 20, LatestEpoch: -1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first, md, complete := epochsToBackfill(test.md, test.startEpoch, test.endEpoch)
+			require.Equal(t, test.first, first)
+			require.Equal(t, test.expectedMD, md)
+			require.Equal(t, test.complete, complete)
+		})
+	}
+}
+
+// backfillClient is a beacon node that serves two validators whose balances
+// depend on the requested slot, and can be set to fail for a slot.
+type backfillClient struct {
+	failSlot *phase0.Slot
+	states   []string
+}
+
+func (*backfillClient) Name() string {
+	return "backfill"
+}
+
+func (*backfillClient) Address() string {
+	return "backfill"
+}
+
+func (c *backfillClient) Validators(_ context.Context,
+	opts *api.ValidatorsOpts,
+) (
+	*api.Response[map[phase0.ValidatorIndex]*apiv1.Validator],
+	error,
+) {
+	slot, err := strconv.ParseUint(opts.State, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if c.failSlot != nil && *c.failSlot == phase0.Slot(slot) {
+		return nil, errors.New("unavailable")
+	}
+	c.states = append(c.states, opts.State)
+
+	validators := make(map[phase0.ValidatorIndex]*apiv1.Validator)
+	for index := phase0.ValidatorIndex(0); index < 2; index++ {
+		validators[index] = &apiv1.Validator{
+			Index:   index,
+			Balance: phase0.Gwei(slot) + phase0.Gwei(index),
+			Validator: &phase0.Validator{
+				EffectiveBalance: 32000000000,
+			},
+		}
+	}
+
+	return &api.Response[map[phase0.ValidatorIndex]*apiv1.Validator]{Data: validators}, nil
+}
+
+// backfillDB is an in-memory chain database that records committed progress.
+type backfillDB struct {
+	*mockchaindb.InMemoryService
+
+	pending  map[string]int64
+	progress map[string]int64
+}
+
+func (d *backfillDB) SetProgress(_ context.Context, _ string, key string, value int64) error {
+	d.pending[key] = value
+	return nil
+}
+
+func (d *backfillDB) CommitTx(_ context.Context) error {
+	for key, value := range d.pending {
+		d.progress[key] = value
+	}
+	d.pending = make(map[string]int64)
+	return nil
+}
+
+func (d *backfillDB) Progress(_ context.Context, service string) (*chaindb.Progress, error) {
+	values := make(map[string]int64, len(d.progress))
+	for key, value := range d.progress {
+		values[key] = value
+	}
+	return &chaindb.Progress{Service: service, Values: values}, nil
+}
+
+// backfillChainTime is a chain time with 32 slots per epoch.
+type backfillChainTime struct {
+	chaintime.Service
+}
+
+func (*backfillChainTime) FirstSlotOfEpoch(epoch phase0.Epoch) phase0.Slot {
+	return phase0.Slot(epoch * 32)
+}
+
+func TestBackfill(t *testing.T) {
+	ctx := context.Background()
+	inMemory, err := mockchaindb.NewInMemory(ctx, nil)
+	require.NoError(t, err)
+	db := &backfillDB{
+		InMemoryService: inMemory,
+		pending:         make(map[string]int64),
+		progress:        make(map[string]int64),
+	}
+	failSlot := phase0.Slot(64)
+	client := &backfillClient{failSlot: &failSlot}
+	s := &Service{
+		eth2Client:       client,
+		chainDB:          db,
+		validatorsSetter: db,
+		chainTime:        &backfillChainTime{},
+		startEpoch:       0,
+		endEpoch:         3,
+	}
+
+	// Interrupt at epoch 2.
+	require.EqualError(t, s.Backfill(ctx), "failed to backfill epoch 2: failed to obtain validators: unavailable")
+	require.Equal(t, []string{"0", "32"}, client.states)
+	require.Equal(t, map[string]int64{"start_epoch": 0, "end_epoch": 3, "latest_epoch": 1}, db.progress)
+
+	// Resume from the interrupted epoch.
+	client.failSlot = nil
+	client.states = nil
+	require.NoError(t, s.Backfill(ctx))
+	require.Equal(t, []string{"64", "96"}, client.states)
+	require.Equal(t, int64(3), db.progress["latest_epoch"])
+
+	// Balances are those of the state at the start of each epoch.
+	for epoch := phase0.Epoch(0); epoch <= 3; epoch++ {
+		balances, err := db.ValidatorBalancesByEpoch(ctx, epoch)
+		require.NoError(t, err)
+		require.Len(t, balances, 2)
+		for _, balance := range balances {
+			require.Equal(t, phase0.Gwei(epoch*32)+phase0.Gwei(balance.Index), balance.Balance)
+			require.Equal(t, phase0.Gwei(32000000000), balance.EffectiveBalance)
+		}
+	}
+
+	// Running again does nothing.
+	client.states = nil
+	require.NoError(t, s.Backfill(ctx))
+	require.Empty(t, client.states)
+
+	// Extending the range starts again from its start.
+	s.endEpoch = 4
+	require.NoError(t, s.Backfill(ctx))
+	require.Equal(t, []string{"0", "32", "64", "96", "128"}, client.states)
+	require.Equal(t, map[string]int64{"start_epoch": 0, "end_epoch": 4, "latest_epoch": 4}, db.progress)
+}
+
+func TestBackfillGenesisEpoch(t *testing.T) {
+	ctx := context.Background()
+	inMemory, err := mockchaindb.NewInMemory(ctx, nil)
+	require.NoError(t, err)
+	db := &backfillDB{
+		InMemoryService: inMemory,
+		pending:         make(map[string]int64),
+		progress:        make(map[string]int64),
+	}
+	client := &backfillClient{}
+	s := &Service{
+		eth2Client:       client,
+		chainDB:          db,
+		validatorsSetter: db,
+		chainTime:        &backfillChainTime{},
+		startEpoch:       0,
+		endEpoch:         0,
+	}
+
+	// With no earlier progress the single epoch is backfilled, rather than
+	// taken to be complete.
+	require.NoError(t, s.Backfill(ctx))
+	require.Equal(t, []string{"0"}, client.states)
+	require.Equal(t, int64(0), db.progress["latest_epoch"])
+}
+
+func TestValidatorBalances(t *testing.T) {
+	validators := map[phase0.ValidatorIndex]*apiv1.Validator{
+		5: {Index: 5, Balance: 31, Validator: &phase0.Validator{EffectiveBalance: 30}},
+		2: {Index: 2, Balance: 33, Validator: &phase0.Validator{EffectiveBalance: 32}},
+	}
+	require.Equal(t, []*chaindb.ValidatorBalance{
+		{Index: 2, Epoch: 7, Balance: 33, EffectiveBalance: 32},
+		{Index: 5, Epoch: 7, Balance: 31, EffectiveBalance: 30},
+	}, validatorBalances(7, validators))
+	require.Empty(t, validatorBalances(7, nil))
+}
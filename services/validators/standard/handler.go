@@ -19,7 +19,6 @@ import (
 	"fmt"
 
 	eth2client "github.com/attestantio/go-eth2-client"
-	"github.com/attestantio/go-eth2-client/api"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
@@ -86,10 +85,19 @@ func (s *Service) onEpochTransitionValidators(ctx context.Context,
 	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "onEpochTransitionValidators")
 	defer span.End()
 
+	if s.endEpoch >= 0 && int64(transitionedEpoch) > s.endEpoch {
+		log.Trace().Uint64("epoch", uint64(transitionedEpoch)).Msg("Epoch after end epoch; not updating validators")
+		return nil
+	}
+
+	if s.loadShedder != nil {
+		if err := s.loadShedder.AwaitQuiet(ctx); err != nil {
+			return errors.Wrap(err, "failed to await quiet period")
+		}
+	}
+
 	// We always fetch the latest validator information regardless of epoch.
-	validatorsResponse, err := s.eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, &api.ValidatorsOpts{
-		State: "head",
-	})
+	validatorsResponse, err := s.eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, s.validatorsOpts("head"))
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain validators")
 	}
@@ -111,6 +119,9 @@ func (s *Service) onEpochTransitionValidators(ctx context.Context,
 		return errors.Wrap(err, "failed to begin transaction for validators")
 	}
 	for index, validator := range validators {
+		if !s.handles(index) {
+			continue
+		}
 		if !needsUpdate(validator.Validator, index, dbValidators) {
 			continue
 		}
@@ -132,6 +143,10 @@ func (s *Service) onEpochTransitionValidators(ctx context.Context,
 			cancel()
 			return errors.Wrap(err, "failed to set validator")
 		}
+		if err := s.updateCredentialsHistory(ctx, dbValidator, dbValidators[index], transitionedEpoch); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set validator credentials change")
+		}
 	}
 	md.LatestEpoch = transitionedEpoch
 	if err := s.setMetadata(ctx, md); err != nil {
@@ -164,6 +179,12 @@ func (s *Service) onEpochTransitionValidatorBalances(ctx context.Context,
 	if firstEpoch > 0 {
 		firstEpoch++
 	}
+	if s.startEpoch >= 0 && firstEpoch < phase0.Epoch(s.startEpoch) {
+		firstEpoch = phase0.Epoch(s.startEpoch)
+	}
+	if s.endEpoch >= 0 && transitionedEpoch > phase0.Epoch(s.endEpoch) {
+		transitionedEpoch = phase0.Epoch(s.endEpoch)
+	}
 	for epoch := firstEpoch; epoch <= transitionedEpoch; epoch++ {
 		if err := s.onEpochTransitionValidatorBalancesForEpoch(ctx, md, epoch); err != nil {
 			return err
@@ -183,9 +204,12 @@ func (s *Service) onEpochTransitionValidatorBalancesForEpoch(ctx context.Context
 	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
 	stateID := fmt.Sprintf("%d", s.chainTime.FirstSlotOfEpoch(epoch))
 	log.Trace().Uint64("slot", uint64(s.chainTime.FirstSlotOfEpoch(epoch))).Msg("Fetching validators")
-	validatorsResponse, err := s.eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, &api.ValidatorsOpts{
-		State: stateID,
-	})
+	if s.loadShedder != nil {
+		if err := s.loadShedder.AwaitQuiet(ctx); err != nil {
+			return errors.Wrap(err, "failed to await quiet period")
+		}
+	}
+	validatorsResponse, err := s.eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, s.validatorsOpts(stateID))
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain validators for validator balances")
 	}
@@ -202,6 +226,9 @@ func (s *Service) onEpochTransitionValidatorBalancesForEpoch(ctx context.Context
 	if s.balances {
 		dbValidatorBalances := make([]*chaindb.ValidatorBalance, 0, len(validators))
 		for index, validator := range validators {
+			if !s.handles(index) {
+				continue
+			}
 			dbValidatorBalances = append(dbValidatorBalances, &chaindb.ValidatorBalance{
 				Index:            index,
 				Epoch:            epoch,
@@ -242,6 +269,44 @@ func (s *Service) onEpochTransitionValidatorBalancesForEpoch(ctx context.Context
 	return nil
 }
 
+// updateCredentialsHistory records a change in withdrawal credentials for the validator, if required.
+func (s *Service) updateCredentialsHistory(ctx context.Context,
+	validator *chaindb.Validator,
+	dbValidator *chaindb.Validator,
+	transitionedEpoch phase0.Epoch,
+) error {
+	if s.credentialsSetter == nil {
+		return nil
+	}
+
+	epoch := transitionedEpoch
+	if dbValidator == nil {
+		// New validator; credentials are valid from when the validator was first seen by the chain.
+		if validator.ActivationEligibilityEpoch < epoch {
+			epoch = validator.ActivationEligibilityEpoch
+		}
+	} else if bytes.Equal(dbValidator.WithdrawalCredentials[:], validator.WithdrawalCredentials[:]) {
+		// No change.
+		return nil
+	}
+
+	return s.credentialsSetter.SetValidatorCredentialsChange(ctx, &chaindb.ValidatorCredentialsChange{
+		Index:                   validator.Index,
+		Epoch:                   epoch,
+		WithdrawalCredentials:   validator.WithdrawalCredentials,
+		EffectiveBalanceCeiling: s.effectiveBalanceCeiling(validator.WithdrawalCredentials),
+	})
+}
+
+// effectiveBalanceCeiling returns the maximum effective balance for the given withdrawal credentials.
+func (s *Service) effectiveBalanceCeiling(withdrawalCredentials [32]byte) phase0.Gwei {
+	if withdrawalCredentials[0] == chaindb.WithdrawalCredentialsCompounding {
+		return s.maxCompoundingEffectiveBalance
+	}
+
+	return s.maxEffectiveBalance
+}
+
 // needsUpdate returns true if the validator needs an update according to our database information.
 func needsUpdate(validator *phase0.Validator,
 	index phase0.ValidatorIndex,
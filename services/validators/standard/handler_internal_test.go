@@ -0,0 +1,111 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+type credentialsRecorder struct {
+	changes []*chaindb.ValidatorCredentialsChange
+}
+
+func (r *credentialsRecorder) SetValidatorCredentialsChange(_ context.Context, change *chaindb.ValidatorCredentialsChange) error {
+	r.changes = append(r.changes, change)
+	return nil
+}
+
+func TestUpdateCredentialsHistory(t *testing.T) {
+	blsCredentials := [32]byte{chaindb.WithdrawalCredentialsBLS, 0x01}
+	executionCredentials := [32]byte{chaindb.WithdrawalCredentialsExecution, 0x01}
+	compoundingCredentials := [32]byte{chaindb.WithdrawalCredentialsCompounding, 0x01}
+
+	tests := []struct {
+		name        string
+		validator   *chaindb.Validator
+		dbValidator *chaindb.Validator
+		expected    []*chaindb.ValidatorCredentialsChange
+	}{
+		{
+			name: "NewValidator",
+			validator: &chaindb.Validator{
+				Index:                      1,
+				ActivationEligibilityEpoch: 5,
+				WithdrawalCredentials:      blsCredentials,
+			},
+			expected: []*chaindb.ValidatorCredentialsChange{
+				{Index: 1, Epoch: 5, WithdrawalCredentials: blsCredentials, EffectiveBalanceCeiling: 32000000000},
+			},
+		},
+		{
+			name: "NewValidatorNotEligible",
+			validator: &chaindb.Validator{
+				Index:                      1,
+				ActivationEligibilityEpoch: 0xffffffffffffffff,
+				WithdrawalCredentials:      executionCredentials,
+			},
+			expected: []*chaindb.ValidatorCredentialsChange{
+				{Index: 1, Epoch: 10, WithdrawalCredentials: executionCredentials, EffectiveBalanceCeiling: 32000000000},
+			},
+		},
+		{
+			name: "Unchanged",
+			validator: &chaindb.Validator{
+				Index:                 1,
+				WithdrawalCredentials: executionCredentials,
+			},
+			dbValidator: &chaindb.Validator{
+				Index:                 1,
+				WithdrawalCredentials: executionCredentials,
+			},
+		},
+		{
+			name: "Compounding",
+			validator: &chaindb.Validator{
+				Index:                 1,
+				WithdrawalCredentials: compoundingCredentials,
+			},
+			dbValidator: &chaindb.Validator{
+				Index:                 1,
+				WithdrawalCredentials: executionCredentials,
+			},
+			expected: []*chaindb.ValidatorCredentialsChange{
+				{Index: 1, Epoch: 10, WithdrawalCredentials: compoundingCredentials, EffectiveBalanceCeiling: 2048000000000},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := &credentialsRecorder{}
+			s := &Service{
+				credentialsSetter:              recorder,
+				maxEffectiveBalance:            32000000000,
+				maxCompoundingEffectiveBalance: 2048000000000,
+			}
+			require.NoError(t, s.updateCredentialsHistory(context.Background(), test.validator, test.dbValidator, phase0.Epoch(10)))
+			require.Equal(t, test.expected, recorder.changes)
+		})
+	}
+}
+
+func TestUpdateCredentialsHistoryNoSetter(t *testing.T) {
+	s := &Service{}
+	require.NoError(t, s.updateCredentialsHistory(context.Background(), &chaindb.Validator{}, nil, 10))
+}
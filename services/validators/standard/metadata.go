@@ -15,7 +15,7 @@ package standard
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
@@ -23,38 +23,57 @@ import (
 
 // metadata stored about this service.
 type metadata struct {
-	LatestEpoch         phase0.Epoch   `json:"latest_epoch"`
-	LatestBalancesEpoch phase0.Epoch   `json:"latest_balances_epoch"`
-	MissedEpochs        []phase0.Epoch `json:"missed_epochs,omitempty"`
+	LatestEpoch         phase0.Epoch
+	LatestBalancesEpoch phase0.Epoch
+	MissedEpochs        []phase0.Epoch
 }
 
-// metadataKey is the key for the metadata.
-var metadataKey = "validators.standard"
+// progressService is the name of this service for progress.
+// Instances that handle a shard of validators record their own progress
+// under a per-shard name, and the overall progress under this name once
+// all validators are covered.
+var progressService = "validators.standard"
+
+// shardProgressService returns the name of this service for progress of the given shard.
+func shardProgressService(shard *shard) string {
+	return fmt.Sprintf("%s.shard.%d", progressService, shard.fromIndex)
+}
 
 // getMetadata gets metadata for this service.
 func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 	md := &metadata{}
-	mdJSON, err := s.chainDB.Metadata(ctx, metadataKey)
+	progress, err := s.chainDB.Progress(ctx, s.progressService)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to obtain metadata")
+		return nil, errors.Wrap(err, "failed to obtain progress")
 	}
-	if mdJSON == nil {
+	if progress == nil {
 		return md, nil
 	}
-	if err := json.Unmarshal(mdJSON, md); err != nil {
-		return nil, errors.Wrap(err, "failed to unmarshal metadata")
+	md.LatestEpoch = phase0.Epoch(progress.Values["latest_epoch"])
+	md.LatestBalancesEpoch = phase0.Epoch(progress.Values["latest_balances_epoch"])
+	for _, epoch := range progress.Gaps["missed_epochs"] {
+		md.MissedEpochs = append(md.MissedEpochs, phase0.Epoch(epoch))
 	}
 	return md, nil
 }
 
 // setMetadata sets metadata for this service.
 func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
-	mdJSON, err := json.Marshal(md)
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal metadata")
+	if err := s.chainDB.SetProgress(ctx, s.progressService, "latest_epoch", int64(md.LatestEpoch)); err != nil {
+		return errors.Wrap(err, "failed to update latest epoch")
+	}
+	if err := s.chainDB.SetProgress(ctx, s.progressService, "latest_balances_epoch", int64(md.LatestBalancesEpoch)); err != nil {
+		return errors.Wrap(err, "failed to update latest balances epoch")
+	}
+	missedEpochs := make([]int64, len(md.MissedEpochs))
+	for i, epoch := range md.MissedEpochs {
+		missedEpochs[i] = int64(epoch)
+	}
+	if err := s.chainDB.SetProgressGaps(ctx, s.progressService, "missed_epochs", missedEpochs); err != nil {
+		return errors.Wrap(err, "failed to update missed epochs")
 	}
-	if err := s.chainDB.SetMetadata(ctx, metadataKey, mdJSON); err != nil {
-		return errors.Wrap(err, "failed to update metadata")
+	if err := s.updateShards(ctx, md); err != nil {
+		return errors.Wrap(err, "failed to update shards")
 	}
 	return nil
 }
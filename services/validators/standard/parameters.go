@@ -20,17 +20,22 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/loadshedder"
 	"github.com/wealdtech/chaind/services/metrics"
 )
 
 type parameters struct {
-	logLevel   zerolog.Level
-	monitor    metrics.Service
-	eth2Client eth2client.Service
-	chainDB    chaindb.Service
-	chainTime  chaintime.Service
-	balances   bool
-	startEpoch int64
+	logLevel    zerolog.Level
+	monitor     metrics.Service
+	eth2Client  eth2client.Service
+	chainDB     chaindb.Service
+	chainTime   chaintime.Service
+	loadShedder loadshedder.Service
+	balances    bool
+	startEpoch  int64
+	endEpoch    int64
+	shardFrom   int64
+	shardTo     int64
 }
 
 // Parameter is the interface for service parameters.
@@ -79,13 +84,43 @@ func WithChainTime(chainTime chaintime.Service) Parameter {
 	})
 }
 
-// WithStartEpoch sets the start epoch for this module.
+// WithLoadShedder sets the load shedder, which delays fetches from the beacon
+// node until the busy periods of the slot have passed.
+func WithLoadShedder(loadShedder loadshedder.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.loadShedder = loadShedder
+	})
+}
+
+// WithStartEpoch sets the start epoch for this module.  Balances for
+// earlier epochs are not fetched.
 func WithStartEpoch(startEpoch int64) Parameter {
 	return parameterFunc(func(p *parameters) {
 		p.startEpoch = startEpoch
 	})
 }
 
+// WithEndEpoch sets the end epoch for this module.  Validators and their
+// balances are not updated for later epochs, and once the chain has passed
+// the end epoch the module idles.
+func WithEndEpoch(endEpoch int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.endEpoch = endEpoch
+	})
+}
+
+// WithShard sets the range of validator indices handled by this module,
+// allowing multiple instances to share the work of indexing validators.
+// Either value can be -1, in which case the range starts at the first
+// validator or is open-ended respectively.  If both are -1 all validators
+// are handled.
+func WithShard(fromIndex int64, toIndex int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.shardFrom = fromIndex
+		p.shardTo = toIndex
+	})
+}
+
 // WithBalances states if the module should fetch validator balances.
 func WithBalances(balances bool) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -98,6 +133,9 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
 		logLevel:   zerolog.GlobalLevel(),
 		startEpoch: -1,
+		endEpoch:   -1,
+		shardFrom:  -1,
+		shardTo:    -1,
 		balances:   false,
 	}
 	for _, p := range params {
@@ -115,6 +153,12 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.chainTime == nil {
 		return nil, errors.New("no chain time specified")
 	}
+	if parameters.endEpoch >= 0 && parameters.startEpoch > parameters.endEpoch {
+		return nil, errors.New("end epoch before start epoch")
+	}
+	if parameters.shardTo >= 0 && parameters.shardFrom > parameters.shardTo {
+		return nil, errors.New("shard end index before shard start index")
+	}
 
 	return &parameters, nil
 }
@@ -17,12 +17,15 @@ import (
 	"context"
 
 	eth2client "github.com/attestantio/go-eth2-client"
-	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/loadshedder"
+	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -32,9 +35,20 @@ type Service struct {
 	chainDB            chaindb.Service
 	validatorsProvider chaindb.ValidatorsProvider
 	validatorsSetter   chaindb.ValidatorsSetter
+	credentialsSetter  chaindb.ValidatorCredentialsSetter
+	shardsProvider     chaindb.ValidatorShardsProvider
+	shardsSetter       chaindb.ValidatorShardsSetter
 	chainTime          chaintime.Service
+	loadShedder        loadshedder.Service
 	balances           bool
+	startEpoch         int64
+	endEpoch           int64
+	shard              *shard
+	progressService    string
 	activitySem        *semaphore.Weighted
+	// Effective balance ceilings for the different credential types.
+	maxEffectiveBalance            phase0.Gwei
+	maxCompoundingEffectiveBalance phase0.Gwei
 }
 
 // module-wide log.
@@ -48,7 +62,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "validators").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log = util.ServiceLogger("validators", "standard", parameters.logLevel)
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -64,23 +78,82 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not support validator setting")
 	}
 
+	// Credentials history is optional.
+	credentialsSetter, isCredentialsSetter := parameters.chainDB.(chaindb.ValidatorCredentialsSetter)
+	if !isCredentialsSetter {
+		log.Debug().Msg("Chain DB does not support validator credentials setting; history will not be recorded")
+	}
+
+	var validatorShard *shard
+	var shardsProvider chaindb.ValidatorShardsProvider
+	var shardsSetter chaindb.ValidatorShardsSetter
+	progressServiceName := progressService
+	if parameters.shardFrom >= 0 || parameters.shardTo >= 0 {
+		validatorShard = &shard{}
+		if parameters.shardFrom > 0 {
+			validatorShard.fromIndex = phase0.ValidatorIndex(parameters.shardFrom)
+		}
+		if parameters.shardTo >= 0 {
+			toIndex := phase0.ValidatorIndex(parameters.shardTo)
+			validatorShard.toIndex = &toIndex
+		}
+		var isProvider, isSetter bool
+		shardsProvider, isProvider = parameters.chainDB.(chaindb.ValidatorShardsProvider)
+		if !isProvider {
+			return nil, errors.New("chain DB is not a validator shards provider")
+		}
+		shardsSetter, isSetter = parameters.chainDB.(chaindb.ValidatorShardsSetter)
+		if !isSetter {
+			return nil, errors.New("chain DB does not support validator shard setting")
+		}
+		progressServiceName = shardProgressService(validatorShard)
+		log.Info().Str("shard", validatorShard.String()).Msg("Handling shard of validators")
+	}
+
+	specResponse, err := parameters.eth2Client.(eth2client.SpecProvider).Spec(ctx, &api.SpecOpts{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain spec")
+	}
+	spec := chaindb.NewSpec(specResponse.Data)
+
+	maxEffectiveBalance := uint64(spec.MaxEffectiveBalance)
+	if maxEffectiveBalance == 0 {
+		return nil, errors.New("failed to obtain MAX_EFFECTIVE_BALANCE")
+	}
+
+	// Compounding credentials are not defined prior to Electra, so use the expected value if not present.
+	maxCompoundingEffectiveBalance := uint64(spec.MaxEffectiveBalanceElectra)
+	if maxCompoundingEffectiveBalance == 0 {
+		maxCompoundingEffectiveBalance = uint64(chaindb.DefaultMaxCompoundingEffectiveBalance)
+	}
+
 	s := &Service{
-		eth2Client:         parameters.eth2Client,
-		chainDB:            parameters.chainDB,
-		validatorsProvider: validatorsProvider,
-		validatorsSetter:   validatorsSetter,
-		chainTime:          parameters.chainTime,
-		balances:           parameters.balances,
-		activitySem:        semaphore.NewWeighted(1),
+		eth2Client:                     parameters.eth2Client,
+		chainDB:                        parameters.chainDB,
+		validatorsProvider:             validatorsProvider,
+		validatorsSetter:               validatorsSetter,
+		credentialsSetter:              credentialsSetter,
+		shardsProvider:                 shardsProvider,
+		shardsSetter:                   shardsSetter,
+		chainTime:                      parameters.chainTime,
+		loadShedder:                    parameters.loadShedder,
+		balances:                       parameters.balances,
+		startEpoch:                     parameters.startEpoch,
+		endEpoch:                       parameters.endEpoch,
+		shard:                          validatorShard,
+		progressService:                progressServiceName,
+		activitySem:                    semaphore.NewWeighted(1),
+		maxEffectiveBalance:            phase0.Gwei(maxEffectiveBalance),
+		maxCompoundingEffectiveBalance: phase0.Gwei(maxCompoundingEffectiveBalance),
 	}
 
 	// Update to current epoch (in the background).
-	go s.updateAfterRestart(ctx, parameters.startEpoch)
+	go s.updateAfterRestart(ctx)
 
 	return s, nil
 }
 
-func (s *Service) updateAfterRestart(ctx context.Context, startEpoch int64) {
+func (s *Service) updateAfterRestart(ctx context.Context) {
 	// Only allow 1 handler to be active.
 	acquired := s.activitySem.TryAcquire(1)
 	if !acquired {
@@ -93,25 +166,6 @@ func (s *Service) updateAfterRestart(ctx context.Context, startEpoch int64) {
 		s.activitySem.Release(1)
 		log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
 	}
-	if startEpoch >= 0 {
-		// Explicit requirement to start at a given epoch; update metadata accordingly.
-		ctx, cancel, err := s.chainDB.BeginTx(ctx)
-		if err != nil {
-			s.activitySem.Release(1)
-			log.Fatal().Err(err).Msg("Failed to begin transaction to set start epoch")
-		}
-		if err := s.setMetadata(ctx, md); err != nil {
-			cancel()
-			s.activitySem.Release(1)
-			log.Fatal().Err(err).Msg("Failed to set metadata with start epoch")
-		}
-		if err := s.chainDB.CommitTx(ctx); err != nil {
-			cancel()
-			s.activitySem.Release(1)
-			log.Fatal().Err(err).Msg("Failed to commit transaction to set start epoch")
-		}
-	}
-
 	log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Catching up from epoch")
 	currentEpoch := s.chainTime.CurrentEpoch()
 	if err := s.onEpochTransitionValidators(ctx, md, currentEpoch); err != nil {
@@ -124,9 +178,14 @@ func (s *Service) updateAfterRestart(ctx context.Context, startEpoch int64) {
 
 	log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Caught up")
 
+	if s.endEpoch >= 0 && int64(currentEpoch) > s.endEpoch {
+		log.Info().Int64("end_epoch", s.endEpoch).Msg("Passed end epoch; idling")
+		return
+	}
+
 	// Set up the handler for new chain head updates.
-	if err := s.eth2Client.(eth2client.EventsProvider).Events(ctx, []string{"head"}, func(event *api.Event) {
-		eventData := event.Data.(*api.HeadEvent)
+	if err := s.eth2Client.(eth2client.EventsProvider).Events(ctx, []string{"head"}, func(event *apiv1.Event) {
+		eventData := event.Data.(*apiv1.HeadEvent)
 		s.OnBeaconChainHeadUpdated(ctx, eventData.Slot, eventData.Block, eventData.State, eventData.EpochTransition)
 	}); err != nil {
 		log.Fatal().Err(err).Msg("Failed to add beacon chain head updated handler")
@@ -0,0 +1,161 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// shard is the range of validators handled by this instance.
+type shard struct {
+	fromIndex phase0.ValidatorIndex
+	// toIndex is nil if the shard is open-ended.
+	toIndex *phase0.ValidatorIndex
+}
+
+// contains returns true if the validator index is handled by the shard.
+func (s *shard) contains(index phase0.ValidatorIndex) bool {
+	if index < s.fromIndex {
+		return false
+	}
+
+	return s.toIndex == nil || index <= *s.toIndex
+}
+
+// String returns a readable representation of the shard.
+func (s *shard) String() string {
+	if s.toIndex == nil {
+		return fmt.Sprintf("%d-", s.fromIndex)
+	}
+
+	return fmt.Sprintf("%d-%d", s.fromIndex, *s.toIndex)
+}
+
+// validatorsOpts returns the options to obtain the validators handled by this instance at the given state.
+func (s *Service) validatorsOpts(state string) *api.ValidatorsOpts {
+	opts := &api.ValidatorsOpts{
+		State: state,
+	}
+	if s.shard != nil && s.shard.toIndex != nil {
+		opts.Indices = make([]phase0.ValidatorIndex, 0, *s.shard.toIndex-s.shard.fromIndex+1)
+		for index := s.shard.fromIndex; index <= *s.shard.toIndex; index++ {
+			opts.Indices = append(opts.Indices, index)
+		}
+	}
+
+	return opts
+}
+
+// handles returns true if the validator index is handled by this instance.
+func (s *Service) handles(index phase0.ValidatorIndex) bool {
+	return s.shard == nil || s.shard.contains(index)
+}
+
+// updateShards records the progress of this instance's shard and, if the
+// recorded shards between them cover all validators, the overall progress
+// of the validators service.
+func (s *Service) updateShards(ctx context.Context, md *metadata) error {
+	if s.shard == nil {
+		return nil
+	}
+
+	if err := s.shardsSetter.SetValidatorShard(ctx, &chaindb.ValidatorShard{
+		FromIndex:           s.shard.fromIndex,
+		ToIndex:             s.shard.toIndex,
+		LatestEpoch:         md.LatestEpoch,
+		LatestBalancesEpoch: md.LatestBalancesEpoch,
+		Updated:             time.Now(),
+	}); err != nil {
+		return errors.Wrap(err, "failed to set shard progress")
+	}
+
+	shards, err := s.shardsProvider.ValidatorShards(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain shards")
+	}
+	latestEpoch, latestBalancesEpoch, complete := shardsProgress(shards)
+	if !complete {
+		log.Trace().Msg("Shards do not cover all validators; not updating overall progress")
+		return nil
+	}
+
+	// Shards update concurrently, so never move the overall progress backwards.
+	progress, err := s.chainDB.Progress(ctx, progressService)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain overall progress")
+	}
+	if progress != nil {
+		if current := phase0.Epoch(progress.Values["latest_epoch"]); current > latestEpoch {
+			latestEpoch = current
+		}
+		if current := phase0.Epoch(progress.Values["latest_balances_epoch"]); current > latestBalancesEpoch {
+			latestBalancesEpoch = current
+		}
+	}
+	if err := s.chainDB.SetProgress(ctx, progressService, "latest_epoch", int64(latestEpoch)); err != nil {
+		return errors.Wrap(err, "failed to update overall latest epoch")
+	}
+	if err := s.chainDB.SetProgress(ctx, progressService, "latest_balances_epoch", int64(latestBalancesEpoch)); err != nil {
+		return errors.Wrap(err, "failed to update overall latest balances epoch")
+	}
+
+	return nil
+}
+
+// shardsProgress returns the epochs to which all validators have been
+// processed by the shards.  The returned flag is false if the shards do not
+// cover every validator exactly once, in which case the epochs are meaningless.
+func shardsProgress(shards []*chaindb.ValidatorShard) (phase0.Epoch, phase0.Epoch, bool) {
+	if len(shards) == 0 {
+		return 0, 0, false
+	}
+
+	sorted := make([]*chaindb.ValidatorShard, len(shards))
+	copy(sorted, shards)
+	sort.Slice(sorted, func(i int, j int) bool {
+		return sorted[i].FromIndex < sorted[j].FromIndex
+	})
+
+	latestEpoch := sorted[0].LatestEpoch
+	latestBalancesEpoch := sorted[0].LatestBalancesEpoch
+	nextIndex := phase0.ValidatorIndex(0)
+	for i, shard := range sorted {
+		if shard.FromIndex != nextIndex {
+			// Gap or overlap.
+			return 0, 0, false
+		}
+		if shard.LatestEpoch < latestEpoch {
+			latestEpoch = shard.LatestEpoch
+		}
+		if shard.LatestBalancesEpoch < latestBalancesEpoch {
+			latestBalancesEpoch = shard.LatestBalancesEpoch
+		}
+		if shard.ToIndex == nil {
+			// Open-ended shard, must be the last.
+			return latestEpoch, latestBalancesEpoch, i == len(sorted)-1
+		}
+		nextIndex = *shard.ToIndex + 1
+	}
+
+	// No open-ended shard, so later validators are not covered.
+	return 0, 0, false
+}
@@ -0,0 +1,119 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestShardContains(t *testing.T) {
+	toIndex := phase0.ValidatorIndex(199)
+	bounded := &shard{fromIndex: 100, toIndex: &toIndex}
+	require.False(t, bounded.contains(99))
+	require.True(t, bounded.contains(100))
+	require.True(t, bounded.contains(199))
+	require.False(t, bounded.contains(200))
+
+	openEnded := &shard{fromIndex: 100}
+	require.False(t, openEnded.contains(99))
+	require.True(t, openEnded.contains(100))
+	require.True(t, openEnded.contains(1000000))
+}
+
+func TestShardsProgress(t *testing.T) {
+	index := func(i phase0.ValidatorIndex) *phase0.ValidatorIndex {
+		return &i
+	}
+
+	tests := []struct {
+		name                string
+		shards              []*chaindb.ValidatorShard
+		latestEpoch         phase0.Epoch
+		latestBalancesEpoch phase0.Epoch
+		complete            bool
+	}{
+		{
+			name:   "Empty",
+			shards: []*chaindb.ValidatorShard{},
+		},
+		{
+			name: "Single",
+			shards: []*chaindb.ValidatorShard{
+				{FromIndex: 0, LatestEpoch: 10, LatestBalancesEpoch: 9},
+			},
+			latestEpoch:         10,
+			latestBalancesEpoch: 9,
+			complete:            true,
+		},
+		{
+			name: "Multiple",
+			shards: []*chaindb.ValidatorShard{
+				{FromIndex: 100, LatestEpoch: 8, LatestBalancesEpoch: 9},
+				{FromIndex: 0, ToIndex: index(99), LatestEpoch: 10, LatestBalancesEpoch: 7},
+			},
+			latestEpoch:         8,
+			latestBalancesEpoch: 7,
+			complete:            true,
+		},
+		{
+			name: "MissingStart",
+			shards: []*chaindb.ValidatorShard{
+				{FromIndex: 100, LatestEpoch: 10, LatestBalancesEpoch: 10},
+			},
+		},
+		{
+			name: "Gap",
+			shards: []*chaindb.ValidatorShard{
+				{FromIndex: 0, ToIndex: index(99), LatestEpoch: 10, LatestBalancesEpoch: 10},
+				{FromIndex: 101, LatestEpoch: 10, LatestBalancesEpoch: 10},
+			},
+		},
+		{
+			name: "Overlap",
+			shards: []*chaindb.ValidatorShard{
+				{FromIndex: 0, ToIndex: index(99), LatestEpoch: 10, LatestBalancesEpoch: 10},
+				{FromIndex: 50, LatestEpoch: 10, LatestBalancesEpoch: 10},
+			},
+		},
+		{
+			name: "NotOpenEnded",
+			shards: []*chaindb.ValidatorShard{
+				{FromIndex: 0, ToIndex: index(99), LatestEpoch: 10, LatestBalancesEpoch: 10},
+				{FromIndex: 100, ToIndex: index(199), LatestEpoch: 10, LatestBalancesEpoch: 10},
+			},
+		},
+		{
+			name: "OpenEndedNotLast",
+			shards: []*chaindb.ValidatorShard{
+				{FromIndex: 0, LatestEpoch: 10, LatestBalancesEpoch: 10},
+				{FromIndex: 0, ToIndex: index(99), LatestEpoch: 10, LatestBalancesEpoch: 10},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			latestEpoch, latestBalancesEpoch, complete := shardsProgress(test.shards)
+			require.Equal(t, test.complete, complete)
+			if test.complete {
+				require.Equal(t, test.latestEpoch, latestEpoch)
+				require.Equal(t, test.latestBalancesEpoch, latestBalancesEpoch)
+			}
+		})
+	}
+}
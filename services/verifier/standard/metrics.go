@@ -0,0 +1,97 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_verifier"
+
+var (
+	latestSlot         prometheus.Gauge
+	slotsVerified      prometheus.Counter
+	disagreementsFound *prometheus.CounterVec
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if latestSlot != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	latestSlot = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "latest_slot",
+		Help:      "Latest slot verified against the reference beacon node",
+	})
+	if err := prometheus.Register(latestSlot); err != nil {
+		return errors.Wrap(err, "failed to register latest_slot")
+	}
+
+	slotsVerified = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "slots_verified",
+		Help:      "Number of slots verified against the reference beacon node",
+	})
+	if err := prometheus.Register(slotsVerified); err != nil {
+		return errors.Wrap(err, "failed to register slots_verified")
+	}
+
+	disagreementsFound = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "disagreements_total",
+		Help:      "Number of disagreements with the reference beacon node",
+	}, []string{"type"})
+	if err := prometheus.Register(disagreementsFound); err != nil {
+		return errors.Wrap(err, "failed to register disagreements_total")
+	}
+
+	return nil
+}
+
+// monitorLatestSlot sets the latest slot without registering an
+// increase in slots verified.
+func monitorLatestSlot(slot int64) {
+	if latestSlot != nil {
+		latestSlot.Set(float64(slot))
+	}
+}
+
+func monitorSlotVerified(slot int64) {
+	monitorLatestSlot(slot)
+	if slotsVerified != nil {
+		slotsVerified.Inc()
+	}
+}
+
+func monitorDisagreementFound(disagreementType string) {
+	if disagreementsFound != nil {
+		disagreementsFound.WithLabelValues(disagreementType).Inc()
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// Service is a verifier service.
+type Service struct {
+	eth2Client                      eth2client.Service
+	beaconBlockRootProvider         eth2client.BeaconBlockRootProvider
+	chainDB                         chaindb.Service
+	chainTime                       chaintime.Service
+	blocksProvider                  chaindb.BlocksProvider
+	verificationDisagreementsSetter chaindb.VerificationDisagreementsSetter
+	activitySem                     *semaphore.Weighted
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("verifier", "standard", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	beaconBlockRootProvider, isBeaconBlockRootProvider := parameters.eth2Client.(eth2client.BeaconBlockRootProvider)
+	if !isBeaconBlockRootProvider {
+		return nil, errors.New("Ethereum 2 client does not provide beacon block roots")
+	}
+
+	blocksProvider, isBlocksProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isBlocksProvider {
+		return nil, errors.New("chain DB does not support block providing")
+	}
+
+	verificationDisagreementsSetter, isVerificationDisagreementsSetter := parameters.chainDB.(chaindb.VerificationDisagreementsSetter)
+	if !isVerificationDisagreementsSetter {
+		return nil, errors.New("chain DB does not support verification disagreement setting")
+	}
+
+	s := &Service{
+		eth2Client:                      parameters.eth2Client,
+		beaconBlockRootProvider:         beaconBlockRootProvider,
+		chainDB:                         parameters.chainDB,
+		chainTime:                       parameters.chainTime,
+		blocksProvider:                  blocksProvider,
+		verificationDisagreementsSetter: verificationDisagreementsSetter,
+		activitySem:                     semaphore.NewWeighted(1),
+	}
+
+	if parameters.startSlot >= 0 {
+		// Explicit requirement to (re-)verify from a given slot.
+		if err := s.resetLatestSlot(ctx, parameters.startSlot-1); err != nil {
+			return nil, err
+		}
+	}
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain metadata")
+	}
+	monitorLatestSlot(md.LatestSlot)
+
+	// Update once per epoch.
+	runtimeFunc := func(ctx context.Context, data any) (time.Time, error) {
+		return s.chainTime.StartOfEpoch(s.chainTime.CurrentEpoch() + 1), nil
+	}
+	jobFunc := func(ctx context.Context, data any) {
+		s := data.(*Service)
+		s.update(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "verifier", "update",
+		runtimeFunc,
+		nil,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic update")
+	}
+
+	return s, nil
+}
+
+// resetLatestSlot sets the latest verified slot.
+func (s *Service) resetLatestSlot(ctx context.Context, slot int64) error {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := s.setMetadata(ctx, &metadata{LatestSlot: slot}); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
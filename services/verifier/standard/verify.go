@@ -0,0 +1,234 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/finalizer"
+	"go.opentelemetry.io/otel"
+)
+
+// update verifies indexed slots that have been canonicalized since the last update.
+func (s *Service) update(ctx context.Context) {
+	// Only allow 1 update to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another update running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	if err := s.verify(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to verify indexed chain")
+	}
+}
+
+// verify verifies slots from the last slot verified.
+func (s *Service) verify(ctx context.Context) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.verifier.standard").Start(ctx, "verify")
+	defer span.End()
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	targetSlot, err := s.targetSlot(ctx)
+	if err != nil {
+		return err
+	}
+	if targetSlot < 0 || md.LatestSlot >= targetSlot {
+		log.Trace().Int64("target_slot", targetSlot).Msg("No slots to verify")
+		return nil
+	}
+	log.Trace().Int64("start_slot", md.LatestSlot+1).Int64("target_slot", targetSlot).Msg("Verifying slots")
+
+	// Verify an epoch's worth of slots per transaction.
+	batchSlots := int64(s.chainTime.SlotsPerEpoch())
+	for startSlot := md.LatestSlot + 1; startSlot <= targetSlot; startSlot += batchSlots {
+		endSlot := startSlot + batchSlots - 1
+		if endSlot > targetSlot {
+			endSlot = targetSlot
+		}
+		if err := s.verifySlots(ctx, md, phase0.Slot(startSlot), phase0.Slot(endSlot)); err != nil {
+			return errors.Wrapf(err, "failed to verify slots %d to %d", startSlot, endSlot)
+		}
+	}
+
+	return nil
+}
+
+// targetSlot is the latest slot that can be verified, being the earlier of the latest slot
+// canonicalized by the finalizer and the last finalized slot on the reference beacon node.
+// It returns -1 if no slots can be verified.
+func (s *Service) targetSlot(ctx context.Context) (int64, error) {
+	canonicalSlot, err := finalizer.CanonicalSlot(ctx, s.chainDB)
+	if err != nil {
+		return -1, err
+	}
+
+	finalityProvider, isFinalityProvider := s.eth2Client.(eth2client.FinalityProvider)
+	if !isFinalityProvider {
+		return canonicalSlot, nil
+	}
+	response, err := finalityProvider.Finality(ctx, &api.FinalityOpts{
+		State: "head",
+	})
+	if err != nil {
+		return -1, errors.Wrap(err, "failed to obtain finality from reference beacon node")
+	}
+	referenceSlot := int64(s.chainTime.FirstSlotOfEpoch(response.Data.Finalized.Epoch))
+	if referenceSlot < canonicalSlot {
+		return referenceSlot, nil
+	}
+
+	return canonicalSlot, nil
+}
+
+// verifySlots verifies the given range of slots, inclusive, in a single transaction.
+func (s *Service) verifySlots(ctx context.Context,
+	md *metadata,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) error {
+	disagreements := make([]*chaindb.VerificationDisagreement, 0)
+	for slot := startSlot; slot <= endSlot; slot++ {
+		disagreement, err := s.verifySlot(ctx, slot)
+		if err != nil {
+			return err
+		}
+		if disagreement != nil {
+			disagreements = append(disagreements, disagreement)
+		}
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	for _, disagreement := range disagreements {
+		if err := s.verificationDisagreementsSetter.SetVerificationDisagreement(ctx, disagreement); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set verification disagreement")
+		}
+	}
+
+	md.LatestSlot = int64(endSlot)
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	for _, disagreement := range disagreements {
+		log.Warn().Uint64("slot", uint64(disagreement.Slot)).Str("type", disagreement.Type).Msg("Indexed chain disagrees with reference beacon node")
+		monitorDisagreementFound(disagreement.Type)
+	}
+	for slot := startSlot; slot <= endSlot; slot++ {
+		monitorSlotVerified(int64(slot))
+	}
+
+	return nil
+}
+
+// verifySlot verifies the indexed block at the given slot against the reference beacon node,
+// returning a disagreement if they differ.
+func (s *Service) verifySlot(ctx context.Context, slot phase0.Slot) (*chaindb.VerificationDisagreement, error) {
+	referenceRoot, err := s.referenceRoot(ctx, slot)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := s.blocksProvider.BlocksBySlot(ctx, slot)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain indexed blocks")
+	}
+
+	disagreement := compare(slot, canonicalRoot(blocks), referenceRoot)
+	if disagreement != nil {
+		disagreement.Detected = time.Now()
+	}
+
+	return disagreement, nil
+}
+
+// referenceRoot obtains the root of the block at the given slot from the reference beacon node,
+// or nil if there is no block at the slot.
+func (s *Service) referenceRoot(ctx context.Context, slot phase0.Slot) (*phase0.Root, error) {
+	response, err := s.beaconBlockRootProvider.BeaconBlockRoot(ctx, &api.BeaconBlockRootOpts{
+		Block: fmt.Sprintf("%d", slot),
+	})
+	if err != nil {
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			// Missed slot.
+			return nil, nil
+		}
+
+		return nil, errors.Wrapf(err, "failed to obtain block root for slot %d from reference beacon node", slot)
+	}
+
+	return response.Data, nil
+}
+
+// canonicalRoot provides the root of the canonical block from the given blocks,
+// or nil if none of them are canonical.
+func canonicalRoot(blocks []*chaindb.Block) *phase0.Root {
+	for _, block := range blocks {
+		if block.Canonical != nil && *block.Canonical {
+			root := block.Root
+			return &root
+		}
+	}
+
+	return nil
+}
+
+// compare compares the indexed and reference roots for a slot, returning a disagreement if they differ.
+func compare(slot phase0.Slot, indexedRoot *phase0.Root, referenceRoot *phase0.Root) *chaindb.VerificationDisagreement {
+	var disagreementType string
+	switch {
+	case indexedRoot == nil && referenceRoot == nil:
+		return nil
+	case indexedRoot == nil:
+		disagreementType = chaindb.VerificationDisagreementMissing
+	case referenceRoot == nil:
+		disagreementType = chaindb.VerificationDisagreementUnexpected
+	case *indexedRoot != *referenceRoot:
+		disagreementType = chaindb.VerificationDisagreementMismatch
+	default:
+		return nil
+	}
+
+	return &chaindb.VerificationDisagreement{
+		Slot:          slot,
+		Type:          disagreementType,
+		IndexedRoot:   indexedRoot,
+		ReferenceRoot: referenceRoot,
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestCanonicalRoot(t *testing.T) {
+	canonical := true
+	nonCanonical := false
+	root1 := phase0.Root{0x01}
+	root2 := phase0.Root{0x02}
+
+	tests := []struct {
+		name     string
+		blocks   []*chaindb.Block
+		expected *phase0.Root
+	}{
+		{
+			name:   "Empty",
+			blocks: []*chaindb.Block{},
+		},
+		{
+			name: "Undetermined",
+			blocks: []*chaindb.Block{
+				{Root: root1},
+			},
+		},
+		{
+			name: "NonCanonical",
+			blocks: []*chaindb.Block{
+				{Root: root1, Canonical: &nonCanonical},
+			},
+		},
+		{
+			name: "Canonical",
+			blocks: []*chaindb.Block{
+				{Root: root1, Canonical: &nonCanonical},
+				{Root: root2, Canonical: &canonical},
+			},
+			expected: &root2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, canonicalRoot(test.blocks))
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	root1 := phase0.Root{0x01}
+	root2 := phase0.Root{0x02}
+
+	tests := []struct {
+		name          string
+		indexedRoot   *phase0.Root
+		referenceRoot *phase0.Root
+		expected      *chaindb.VerificationDisagreement
+	}{
+		{
+			name: "BothEmpty",
+		},
+		{
+			name:          "Match",
+			indexedRoot:   &root1,
+			referenceRoot: &root1,
+		},
+		{
+			name:          "Missing",
+			referenceRoot: &root1,
+			expected: &chaindb.VerificationDisagreement{
+				Slot:          10,
+				Type:          chaindb.VerificationDisagreementMissing,
+				ReferenceRoot: &root1,
+			},
+		},
+		{
+			name:        "Unexpected",
+			indexedRoot: &root1,
+			expected: &chaindb.VerificationDisagreement{
+				Slot:        10,
+				Type:        chaindb.VerificationDisagreementUnexpected,
+				IndexedRoot: &root1,
+			},
+		},
+		{
+			name:          "Mismatch",
+			indexedRoot:   &root1,
+			referenceRoot: &root2,
+			expected: &chaindb.VerificationDisagreement{
+				Slot:          10,
+				Type:          chaindb.VerificationDisagreementMismatch,
+				IndexedRoot:   &root1,
+				ReferenceRoot: &root2,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, compare(10, test.indexedRoot, test.referenceRoot))
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel        zerolog.Level
+	project         string
+	dataset         string
+	credentialsFile string
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(p *parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithProject sets the Google Cloud project for this module.
+func WithProject(project string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.project = project
+	})
+}
+
+// WithDataset sets the BigQuery dataset to which tables are exported.
+func WithDataset(dataset string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.dataset = dataset
+	})
+}
+
+// WithCredentialsFile sets the path to a service account credentials file for this module.
+// If not supplied, the application default credentials are used.
+func WithCredentialsFile(credentialsFile string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.credentialsFile = credentialsFile
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.project == "" {
+		return nil, errors.New("no project specified")
+	}
+	if parameters.dataset == "" {
+		return nil, errors.New("no dataset specified")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,233 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/warehouse"
+	"github.com/wealdtech/chaind/util"
+	bigquery "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+// rowsPerInsert is the maximum number of rows sent in a single streaming insert.
+const rowsPerInsert = 500
+
+// columnTypes maps export column types to BigQuery column types.
+var columnTypes = map[string]string{
+	chaindb.ExportColumnTypeInteger:   "INTEGER",
+	chaindb.ExportColumnTypeFloat:     "FLOAT",
+	chaindb.ExportColumnTypeBoolean:   "BOOLEAN",
+	chaindb.ExportColumnTypeBytes:     "BYTES",
+	chaindb.ExportColumnTypeTimestamp: "TIMESTAMP",
+	chaindb.ExportColumnTypeNumeric:   "BIGNUMERIC",
+	chaindb.ExportColumnTypeString:    "STRING",
+}
+
+// Service is a data warehouse service backed by Google BigQuery.
+type Service struct {
+	client   *bigquery.Service
+	project  string
+	dataset  string
+	tablesMu sync.Mutex
+	tables   map[string]bool
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("warehouse", "bigquery", parameters.logLevel)
+
+	opts := make([]option.ClientOption, 0)
+	if parameters.credentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(parameters.credentialsFile))
+	}
+	client, err := bigquery.NewService(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create client")
+	}
+
+	return &Service{
+		client:  client,
+		project: parameters.project,
+		dataset: parameters.dataset,
+		tables:  make(map[string]bool),
+	}, nil
+}
+
+// Export writes rows of a table to the warehouse, creating the table if it does not exist.
+//
+// Rows are streamed with an insert ID derived from their contents, so BigQuery will
+// discard rows that are supplied again on a best-effort basis.
+func (s *Service) Export(ctx context.Context, table *warehouse.Table, rows [][]any) error {
+	if err := s.ensureTable(ctx, table); err != nil {
+		return err
+	}
+
+	for start := 0; start < len(rows); start += rowsPerInsert {
+		end := start + rowsPerInsert
+		if end > len(rows) {
+			end = len(rows)
+		}
+		request := &bigquery.TableDataInsertAllRequest{
+			Rows: make([]*bigquery.TableDataInsertAllRequestRows, 0, end-start),
+		}
+		for _, row := range rows[start:end] {
+			insertRow, err := insertAllRow(table, row)
+			if err != nil {
+				return err
+			}
+			request.Rows = append(request.Rows, insertRow)
+		}
+
+		response, err := s.client.Tabledata.InsertAll(s.project, s.dataset, table.Name, request).Context(ctx).Do()
+		if err != nil {
+			return errors.Wrap(err, "failed to insert rows")
+		}
+		if len(response.InsertErrors) > 0 {
+			insertError := response.InsertErrors[0]
+			if len(insertError.Errors) > 0 {
+				return fmt.Errorf("failed to insert row %d: %s", insertError.Index, insertError.Errors[0].Message)
+			}
+			return fmt.Errorf("failed to insert row %d", insertError.Index)
+		}
+		log.Trace().Str("table", table.Name).Int("rows", end-start).Msg("Inserted rows")
+	}
+
+	return nil
+}
+
+// Location provides the full location of the given table.
+func (s *Service) Location(table string) string {
+	return fmt.Sprintf("bigquery://%s/%s/%s", s.project, s.dataset, table)
+}
+
+// ensureTable creates the table in the dataset if it does not already exist.
+func (s *Service) ensureTable(ctx context.Context, table *warehouse.Table) error {
+	s.tablesMu.Lock()
+	defer s.tablesMu.Unlock()
+
+	if s.tables[table.Name] {
+		return nil
+	}
+
+	_, err := s.client.Tables.Get(s.project, s.dataset, table.Name).Context(ctx).Do()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if !errors.As(err, &apiErr) || apiErr.Code != http.StatusNotFound {
+			return errors.Wrap(err, "failed to obtain table")
+		}
+		log.Debug().Str("table", table.Name).Msg("Creating table")
+		if _, err := s.client.Tables.Insert(s.project, s.dataset, tableDefinition(s.project, s.dataset, table)).Context(ctx).Do(); err != nil {
+			return errors.Wrap(err, "failed to create table")
+		}
+	}
+	s.tables[table.Name] = true
+
+	return nil
+}
+
+// tableDefinition creates the BigQuery definition of a table.
+func tableDefinition(project string, dataset string, table *warehouse.Table) *bigquery.Table {
+	fields := make([]*bigquery.TableFieldSchema, 0, len(table.Columns))
+	for _, column := range table.Columns {
+		mode := "NULLABLE"
+		if column.Repeated {
+			mode = "REPEATED"
+		}
+		fields = append(fields, &bigquery.TableFieldSchema{
+			Name: column.Name,
+			Type: columnTypes[column.Type],
+			Mode: mode,
+		})
+	}
+
+	return &bigquery.Table{
+		TableReference: &bigquery.TableReference{
+			ProjectId: project,
+			DatasetId: dataset,
+			TableId:   table.Name,
+		},
+		Schema: &bigquery.TableSchema{
+			Fields: fields,
+		},
+		Clustering: &bigquery.Clustering{
+			Fields: []string{table.MarkColumn},
+		},
+	}
+}
+
+// insertAllRow converts a row to a streaming insert row.
+func insertAllRow(table *warehouse.Table, row []any) (*bigquery.TableDataInsertAllRequestRows, error) {
+	if len(row) != len(table.Columns) {
+		return nil, fmt.Errorf("row has %d values but table has %d columns", len(row), len(table.Columns))
+	}
+
+	values := make(map[string]bigquery.JsonValue, len(row))
+	for i, column := range table.Columns {
+		values[column.Name] = jsonValue(row[i])
+	}
+
+	// The insert ID is derived from the contents of the row, allowing BigQuery to
+	// discard duplicates if a row is supplied again.
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal row")
+	}
+	hash := sha256.Sum256(append([]byte(table.Name), data...))
+
+	return &bigquery.TableDataInsertAllRequestRows{
+		InsertId: hex.EncodeToString(hash[:]),
+		Json:     values,
+	}, nil
+}
+
+// jsonValue converts a value to its BigQuery JSON representation.
+func jsonValue(value any) bigquery.JsonValue {
+	switch v := value.(type) {
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case []any:
+		res := make([]any, len(v))
+		for i := range v {
+			res[i] = jsonValue(v[i])
+		}
+		return res
+	default:
+		return v
+	}
+}
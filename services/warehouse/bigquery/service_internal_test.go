@@ -0,0 +1,102 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/warehouse"
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+func TestTableDefinition(t *testing.T) {
+	table := &warehouse.Table{
+		Name:       "t_attestations",
+		MarkColumn: "f_inclusion_slot",
+		Columns: []*chaindb.ExportColumn{
+			{Name: "f_inclusion_slot", Type: chaindb.ExportColumnTypeInteger},
+			{Name: "f_aggregation_indices", Type: chaindb.ExportColumnTypeInteger, Repeated: true},
+		},
+	}
+
+	definition := tableDefinition("project", "chaind", table)
+	require.Equal(t, "t_attestations", definition.TableReference.TableId)
+	require.Equal(t, []string{"f_inclusion_slot"}, definition.Clustering.Fields)
+	require.Equal(t, []*bigquery.TableFieldSchema{
+		{Name: "f_inclusion_slot", Type: "INTEGER", Mode: "NULLABLE"},
+		{Name: "f_aggregation_indices", Type: "INTEGER", Mode: "REPEATED"},
+	}, definition.Schema.Fields)
+}
+
+func TestInsertAllRow(t *testing.T) {
+	table := &warehouse.Table{
+		Name:       "t_blocks",
+		MarkColumn: "f_slot",
+		Columns: []*chaindb.ExportColumn{
+			{Name: "f_slot", Type: chaindb.ExportColumnTypeInteger},
+			{Name: "f_root", Type: chaindb.ExportColumnTypeBytes},
+			{Name: "f_timestamp", Type: chaindb.ExportColumnTypeTimestamp},
+			{Name: "f_indices", Type: chaindb.ExportColumnTypeBytes, Repeated: true},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		row      []any
+		err      string
+		expected map[string]bigquery.JsonValue
+	}{
+		{
+			name: "ColumnsMismatch",
+			row:  []any{int64(1)},
+			err:  "row has 1 values but table has 4 columns",
+		},
+		{
+			name: "Good",
+			row: []any{
+				int64(1),
+				[]byte{0x01, 0x02},
+				time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+				[]any{[]byte{0x03}, nil},
+			},
+			expected: map[string]bigquery.JsonValue{
+				"f_slot":      int64(1),
+				"f_root":      "AQI=",
+				"f_timestamp": "2023-01-02T03:04:05Z",
+				"f_indices":   []any{"Aw==", nil},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := insertAllRow(table, test.row)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expected, res.Json)
+				require.Len(t, res.InsertId, 64)
+
+				// Same row should provide the same insert ID.
+				res2, err := insertAllRow(table, test.row)
+				require.NoError(t, err)
+				require.Equal(t, res.InsertId, res2.InsertId)
+			}
+		})
+	}
+}
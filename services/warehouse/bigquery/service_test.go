@@ -0,0 +1,57 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/warehouse/bigquery"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []bigquery.Parameter
+		err    string
+	}{
+		{
+			name: "ProjectMissing",
+			params: []bigquery.Parameter{
+				bigquery.WithLogLevel(zerolog.Disabled),
+				bigquery.WithDataset("chaind"),
+			},
+			err: "problem with parameters: no project specified",
+		},
+		{
+			name: "DatasetMissing",
+			params: []bigquery.Parameter{
+				bigquery.WithLogLevel(zerolog.Disabled),
+				bigquery.WithProject("project"),
+			},
+			err: "problem with parameters: no dataset specified",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := bigquery.New(ctx, test.params...)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/warehouse"
+	"github.com/wealdtech/chaind/util"
+)
+
+// columnTypes maps export column types to PostgreSQL column types.
+var columnTypes = map[string]string{
+	chaindb.ExportColumnTypeInteger:   "BIGINT",
+	chaindb.ExportColumnTypeFloat:     "DOUBLE PRECISION",
+	chaindb.ExportColumnTypeBoolean:   "BOOLEAN",
+	chaindb.ExportColumnTypeBytes:     "BYTEA",
+	chaindb.ExportColumnTypeTimestamp: "TIMESTAMPTZ",
+	chaindb.ExportColumnTypeNumeric:   "NUMERIC",
+	chaindb.ExportColumnTypeString:    "TEXT",
+}
+
+// Service is a data warehouse service backed by a PostgreSQL-compatible database.
+type Service struct {
+	pool *pgxpool.Pool
+	host string
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("warehouse", "postgresql", parameters.logLevel)
+
+	config, err := pgxpool.ParseConfig(parameters.url)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid URL")
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create pool")
+	}
+
+	return &Service{
+		pool: pool,
+		host: config.ConnConfig.Host,
+	}, nil
+}
+
+// Export writes rows of a table to the warehouse, creating the table if it does not exist.
+//
+// Existing rows with marks in the range of those supplied are replaced in the same
+// transaction, so rows that are supplied again are not duplicated.
+func (s *Service) Export(ctx context.Context, table *warehouse.Table, rows [][]any) error {
+	markIndex := -1
+	for i, column := range table.Columns {
+		if column.Name == table.MarkColumn {
+			markIndex = i
+			break
+		}
+	}
+	if markIndex == -1 {
+		return fmt.Errorf("mark column %s not present in table %s", table.MarkColumn, table.Name)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Warn().Err(err).Msg("Failed to roll back transaction")
+		}
+	}()
+
+	if _, err := tx.Exec(ctx, createTableStatement(table)); err != nil {
+		return errors.Wrap(err, "failed to create table")
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s(%s)",
+		pgx.Identifier{fmt.Sprintf("i_%s_mark", table.Name)}.Sanitize(),
+		pgx.Identifier{table.Name}.Sanitize(),
+		pgx.Identifier{table.MarkColumn}.Sanitize(),
+	)); err != nil {
+		return errors.Wrap(err, "failed to create mark index")
+	}
+
+	if len(rows) > 0 {
+		tableName := pgx.Identifier{table.Name}.Sanitize()
+		markColumnName := pgx.Identifier{table.MarkColumn}.Sanitize()
+		if _, err := tx.Exec(ctx,
+			fmt.Sprintf("DELETE FROM %s WHERE %s >= $1 AND %s <= $2", tableName, markColumnName, markColumnName),
+			rows[0][markIndex],
+			rows[len(rows)-1][markIndex],
+		); err != nil {
+			return errors.Wrap(err, "failed to remove existing rows")
+		}
+
+		columnNames := make([]string, len(table.Columns))
+		placeholders := make([]string, len(table.Columns))
+		for i, column := range table.Columns {
+			columnNames[i] = pgx.Identifier{column.Name}.Sanitize()
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+		}
+		insert := fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)", tableName, strings.Join(columnNames, ","), strings.Join(placeholders, ","))
+		batch := &pgx.Batch{}
+		for _, row := range rows {
+			batch.Queue(insert, row...)
+		}
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return errors.Wrap(err, "failed to insert rows")
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	log.Trace().Str("table", table.Name).Int("rows", len(rows)).Msg("Inserted rows")
+
+	return nil
+}
+
+// Location provides the full location of the given table.
+func (s *Service) Location(table string) string {
+	return fmt.Sprintf("postgresql://%s/%s", s.host, table)
+}
+
+// createTableStatement creates the statement to create a table if it does not exist.
+func createTableStatement(table *warehouse.Table) string {
+	columns := make([]string, 0, len(table.Columns))
+	for _, column := range table.Columns {
+		columnType := columnTypes[column.Type]
+		if column.Repeated {
+			columnType += "[]"
+		}
+		columns = append(columns, fmt.Sprintf("%s %s", pgx.Identifier{column.Name}.Sanitize(), columnType))
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", pgx.Identifier{table.Name}.Sanitize(), strings.Join(columns, ","))
+}
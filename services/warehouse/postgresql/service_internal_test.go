@@ -0,0 +1,63 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/warehouse"
+)
+
+func TestCreateTableStatement(t *testing.T) {
+	tests := []struct {
+		name     string
+		table    *warehouse.Table
+		expected string
+	}{
+		{
+			name: "Single",
+			table: &warehouse.Table{
+				Name:       "t_blocks",
+				MarkColumn: "f_slot",
+				Columns: []*chaindb.ExportColumn{
+					{Name: "f_slot", Type: chaindb.ExportColumnTypeInteger},
+				},
+			},
+			expected: `CREATE TABLE IF NOT EXISTS "t_blocks" ("f_slot" BIGINT)`,
+		},
+		{
+			name: "Multiple",
+			table: &warehouse.Table{
+				Name:       "t_attestations",
+				MarkColumn: "f_inclusion_slot",
+				Columns: []*chaindb.ExportColumn{
+					{Name: "f_inclusion_slot", Type: chaindb.ExportColumnTypeInteger},
+					{Name: "f_aggregation_bits", Type: chaindb.ExportColumnTypeBytes},
+					{Name: "f_aggregation_indices", Type: chaindb.ExportColumnTypeInteger, Repeated: true},
+					{Name: "f_canonical", Type: chaindb.ExportColumnTypeBoolean},
+					{Name: "f_amount", Type: chaindb.ExportColumnTypeNumeric},
+				},
+			},
+			expected: `CREATE TABLE IF NOT EXISTS "t_attestations" ("f_inclusion_slot" BIGINT,"f_aggregation_bits" BYTEA,"f_aggregation_indices" BIGINT[],"f_canonical" BOOLEAN,"f_amount" NUMERIC)`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, createTableStatement(test.table))
+		})
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warehouse
+
+import (
+	"context"
+
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Table describes a table exported to a warehouse.
+type Table struct {
+	Name string
+	// MarkColumn is the column that tracks the progress of exports, for example the slot or epoch.
+	MarkColumn string
+	Columns    []*chaindb.ExportColumn
+}
+
+// Service is a data warehouse service, holding copies of tables exported from the chain database.
+type Service interface {
+	// Export writes rows of a table to the warehouse, creating the table if it does not exist.
+	// Exports are at-least-once, so rows for marks that have already been exported can be
+	// supplied again; the warehouse should replace them where it is able to do so.
+	Export(ctx context.Context, table *Table, rows [][]any) error
+
+	// Location provides the full location of the given table, for reference.
+	Location(table string) string
+}
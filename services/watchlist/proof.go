@@ -0,0 +1,94 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchlist
+
+import (
+	"crypto/sha256"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	blst "github.com/supranational/blst/bindings/go"
+)
+
+// OwnershipDomainType is the domain type for proofs of ownership of validators on the watchlist.
+// It is an application domain type, as defined in the specification.
+var OwnershipDomainType = phase0.DomainType{0x63, 0x68, 0x64, 0x01}
+
+// dst is the domain separation tag for Ethereum consensus signatures.
+var dst = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+
+// OwnershipDomain returns the domain for proofs of ownership on the chain with the given genesis fork version.
+func OwnershipDomain(genesisForkVersion phase0.Version) (phase0.Domain, error) {
+	forkData := &phase0.ForkData{
+		CurrentVersion:        genesisForkVersion,
+		GenesisValidatorsRoot: phase0.Root{},
+	}
+	forkDataRoot, err := forkData.HashTreeRoot()
+	if err != nil {
+		return phase0.Domain{}, errors.Wrap(err, "failed to calculate fork data root")
+	}
+
+	var domain phase0.Domain
+	copy(domain[:], OwnershipDomainType[:])
+	copy(domain[4:], forkDataRoot[:28])
+
+	return domain, nil
+}
+
+// OwnershipSigningRoot returns the root that a validator signs to prove ownership of itself
+// for a watchlist entry with the given label.
+func OwnershipSigningRoot(label string, genesisForkVersion phase0.Version) (phase0.Root, error) {
+	domain, err := OwnershipDomain(genesisForkVersion)
+	if err != nil {
+		return phase0.Root{}, err
+	}
+
+	signingData := &phase0.SigningData{
+		ObjectRoot: sha256.Sum256([]byte(label)),
+		Domain:     domain,
+	}
+	root, err := signingData.HashTreeRoot()
+	if err != nil {
+		return phase0.Root{}, errors.Wrap(err, "failed to calculate signing root")
+	}
+
+	return root, nil
+}
+
+// VerifyOwnershipProof verifies that the proof is a signature by the given public key over
+// the ownership signing root for the label.
+func VerifyOwnershipProof(pubKey phase0.BLSPubKey,
+	label string,
+	genesisForkVersion phase0.Version,
+	proof phase0.BLSSignature,
+) error {
+	root, err := OwnershipSigningRoot(label, genesisForkVersion)
+	if err != nil {
+		return err
+	}
+
+	pk := new(blst.P1Affine).Uncompress(pubKey[:])
+	if pk == nil {
+		return errors.New("invalid public key")
+	}
+	sig := new(blst.P2Affine).Uncompress(proof[:])
+	if sig == nil {
+		return errors.New("invalid proof")
+	}
+	if !sig.Verify(true, pk, true, root[:], dst) {
+		return errors.New("proof does not verify")
+	}
+
+	return nil
+}
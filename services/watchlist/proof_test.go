@@ -0,0 +1,98 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchlist_test
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	blst "github.com/supranational/blst/bindings/go"
+	"github.com/wealdtech/chaind/services/watchlist"
+)
+
+func TestVerifyOwnershipProof(t *testing.T) {
+	genesisForkVersion := phase0.Version{0x00, 0x00, 0x00, 0x00}
+
+	ikm := make([]byte, 32)
+	for i := range ikm {
+		ikm[i] = byte(i)
+	}
+	sk := blst.KeyGen(ikm)
+	var pubKey phase0.BLSPubKey
+	copy(pubKey[:], new(blst.P1Affine).From(sk).Compress())
+
+	root, err := watchlist.OwnershipSigningRoot("my validator", genesisForkVersion)
+	require.NoError(t, err)
+	var proof phase0.BLSSignature
+	copy(proof[:], new(blst.P2Affine).Sign(sk, root[:], []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")).Compress())
+
+	tests := []struct {
+		name               string
+		pubKey             phase0.BLSPubKey
+		label              string
+		genesisForkVersion phase0.Version
+		proof              phase0.BLSSignature
+		err                string
+	}{
+		{
+			name:               "Good",
+			pubKey:             pubKey,
+			label:              "my validator",
+			genesisForkVersion: genesisForkVersion,
+			proof:              proof,
+		},
+		{
+			name:               "LabelMismatch",
+			pubKey:             pubKey,
+			label:              "another validator",
+			genesisForkVersion: genesisForkVersion,
+			proof:              proof,
+			err:                "proof does not verify",
+		},
+		{
+			name:               "ForkVersionMismatch",
+			pubKey:             pubKey,
+			label:              "my validator",
+			genesisForkVersion: phase0.Version{0x01, 0x00, 0x00, 0x00},
+			proof:              proof,
+			err:                "proof does not verify",
+		},
+		{
+			name:               "PubKeyInvalid",
+			label:              "my validator",
+			genesisForkVersion: genesisForkVersion,
+			proof:              proof,
+			err:                "invalid public key",
+		},
+		{
+			name:               "ProofInvalid",
+			pubKey:             pubKey,
+			label:              "my validator",
+			genesisForkVersion: genesisForkVersion,
+			err:                "invalid proof",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := watchlist.VerifyOwnershipProof(test.pubKey, test.label, test.genesisForkVersion, test.proof)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// alertRules are the rules that raise alerts for watched validators.
+// A zero value for a rule disables it.
+type alertRules struct {
+	attestationEffectivenessThreshold float64
+	attestationEffectivenessEpochs    uint64
+	missedAttestations                uint64
+	missedProposals                   uint64
+}
+
+// alerts returns the alerts raised for the watched validators in the given epoch.
+// The events for the epoch must already have been written.
+func (s *Service) alerts(ctx context.Context,
+	epoch phase0.Epoch,
+	indices []phase0.ValidatorIndex,
+	events []*chaindb.WatchlistEvent,
+) (
+	[]*chaindb.WatchlistAlert,
+	error,
+) {
+	alerts := make([]*chaindb.WatchlistAlert, 0)
+	if len(indices) == 0 {
+		return alerts, nil
+	}
+
+	if s.alertRules.attestationEffectivenessThreshold > 0 {
+		epochs := s.alertRules.attestationEffectivenessEpochs
+		from := phase0.Epoch(0)
+		if uint64(epoch) > epochs {
+			from = epoch - phase0.Epoch(epochs)
+		}
+		summaries, err := s.validatorEpochSummariesProvider.ValidatorSummaries(ctx, &chaindb.ValidatorSummaryFilter{
+			From:             &from,
+			To:               &epoch,
+			ValidatorIndices: &indices,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validator summaries")
+		}
+		alerts = append(alerts, attestationEffectivenessAlerts(epoch, epochs, s.alertRules.attestationEffectivenessThreshold, summaries)...)
+	}
+
+	if s.alertRules.missedAttestations > 0 {
+		missed := validatorsWithEvent(events, chaindb.WatchlistEventAttestationMissed)
+		if len(missed) > 0 {
+			count := s.alertRules.missedAttestations
+			from := phase0.Epoch(0)
+			if uint64(epoch) > count {
+				from = epoch - phase0.Epoch(count)
+			}
+			history, err := s.watchlistProvider.WatchlistEvents(ctx, &chaindb.WatchlistEventFilter{
+				Order:            chaindb.OrderEarliest,
+				From:             &from,
+				To:               &epoch,
+				Types:            []string{chaindb.WatchlistEventAttestationMissed},
+				ValidatorIndices: missed,
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to obtain missed attestations")
+			}
+			alerts = append(alerts, missedAttestationAlerts(epoch, count, history)...)
+		}
+	}
+
+	if s.alertRules.missedProposals > 0 {
+		for _, index := range validatorsWithEvent(events, chaindb.WatchlistEventProposalMissed) {
+			// Fetch enough proposals to see whether the run of missed proposals started
+			// before this epoch.
+			limit := s.alertRules.missedProposals
+			for _, event := range events {
+				if event.ValidatorIndex == index && isProposalEvent(event) {
+					limit++
+				}
+			}
+			history, err := s.watchlistProvider.WatchlistEvents(ctx, &chaindb.WatchlistEventFilter{
+				Order:            chaindb.OrderLatest,
+				Limit:            uint32(limit),
+				To:               &epoch,
+				Types:            []string{chaindb.WatchlistEventProposalIncluded, chaindb.WatchlistEventProposalMissed},
+				ValidatorIndices: []phase0.ValidatorIndex{index},
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to obtain proposals")
+			}
+			if alert := missedProposalAlert(epoch, index, s.alertRules.missedProposals, history); alert != nil {
+				alerts = append(alerts, alert)
+			}
+		}
+	}
+
+	return alerts, nil
+}
+
+// attestationEffectivenessAlerts returns alerts for validators whose attestation effectiveness
+// over the given number of epochs ending at the given epoch has fallen below the threshold.
+// An alert is only raised when the effectiveness first falls below the threshold, rather than
+// for every epoch in which it remains below it.
+func attestationEffectivenessAlerts(epoch phase0.Epoch,
+	epochs uint64,
+	threshold float64,
+	summaries []*chaindb.ValidatorEpochSummary,
+) []*chaindb.WatchlistAlert {
+	alerts := make([]*chaindb.WatchlistAlert, 0)
+	if uint64(epoch)+1 < epochs {
+		// Not enough epochs for a full window.
+		return alerts
+	}
+
+	validatorSummaries := make(map[phase0.ValidatorIndex]map[phase0.Epoch]*chaindb.ValidatorEpochSummary)
+	for _, summary := range summaries {
+		if _, exists := validatorSummaries[summary.Index]; !exists {
+			validatorSummaries[summary.Index] = make(map[phase0.Epoch]*chaindb.ValidatorEpochSummary)
+		}
+		validatorSummaries[summary.Index][summary.Epoch] = summary
+	}
+
+	for index, epochSummaries := range validatorSummaries {
+		current, complete := attestationEffectiveness(epochSummaries, epoch, epochs)
+		if !complete || current >= threshold {
+			continue
+		}
+		if epoch > 0 {
+			previous, complete := attestationEffectiveness(epochSummaries, epoch-1, epochs)
+			if complete && previous < threshold {
+				// Already below the threshold.
+				continue
+			}
+		}
+		alerts = append(alerts, &chaindb.WatchlistAlert{
+			ValidatorIndex: index,
+			Epoch:          epoch,
+			Rule:           chaindb.WatchlistAlertAttestationEffectiveness,
+			Description:    fmt.Sprintf("attestation effectiveness of %.2f%% over %d epochs is below %.2f%%", current, epochs, threshold),
+		})
+	}
+	sort.Slice(alerts, func(i int, j int) bool {
+		return alerts[i].ValidatorIndex < alerts[j].ValidatorIndex
+	})
+
+	return alerts
+}
+
+// attestationEffectiveness returns the attestation effectiveness, as a percentage, over the given
+// number of epochs ending at the given epoch, and whether there is a summary for every epoch.
+func attestationEffectiveness(summaries map[phase0.Epoch]*chaindb.ValidatorEpochSummary,
+	epoch phase0.Epoch,
+	epochs uint64,
+) (
+	float64,
+	bool,
+) {
+	if uint64(epoch)+1 < epochs {
+		return 0, false
+	}
+
+	total := 0.0
+	for i := uint64(0); i < epochs; i++ {
+		summary, exists := summaries[epoch-phase0.Epoch(i)]
+		if !exists {
+			return 0, false
+		}
+		total += attestationScore(summary)
+	}
+
+	return 100 * total / float64(epochs), true
+}
+
+// attestationScore returns the score of a validator's attestation for an epoch.
+// An attestation that is missing or has an incorrect target scores 0, otherwise the
+// score is the reciprocal of its inclusion delay.
+func attestationScore(summary *chaindb.ValidatorEpochSummary) float64 {
+	if !summary.AttestationIncluded {
+		return 0
+	}
+	if summary.AttestationTargetCorrect != nil && !*summary.AttestationTargetCorrect {
+		return 0
+	}
+	if summary.AttestationInclusionDelay == nil || *summary.AttestationInclusionDelay <= 1 {
+		return 1
+	}
+
+	return 1 / float64(*summary.AttestationInclusionDelay)
+}
+
+// missedAttestationAlerts returns alerts for validators that have missed the given number of
+// consecutive attestations ending at the given epoch, given the missed attestation events for
+// the epochs up to and including it.
+func missedAttestationAlerts(epoch phase0.Epoch,
+	count uint64,
+	events []*chaindb.WatchlistEvent,
+) []*chaindb.WatchlistAlert {
+	alerts := make([]*chaindb.WatchlistAlert, 0)
+	if uint64(epoch)+1 < count {
+		return alerts
+	}
+
+	missed := make(map[phase0.ValidatorIndex]map[phase0.Epoch]bool)
+	validators := make([]phase0.ValidatorIndex, 0)
+	for _, event := range events {
+		if event.Type != chaindb.WatchlistEventAttestationMissed {
+			continue
+		}
+		if _, exists := missed[event.ValidatorIndex]; !exists {
+			missed[event.ValidatorIndex] = make(map[phase0.Epoch]bool)
+			validators = append(validators, event.ValidatorIndex)
+		}
+		missed[event.ValidatorIndex][event.Epoch] = true
+	}
+
+	for _, index := range validators {
+		run := uint64(0)
+		for run < count && missed[index][epoch-phase0.Epoch(run)] {
+			run++
+		}
+		if run < count {
+			continue
+		}
+		// Only alert when the run reaches the count, rather than for every epoch after.
+		if uint64(epoch) >= count && missed[index][epoch-phase0.Epoch(count)] {
+			continue
+		}
+		alerts = append(alerts, &chaindb.WatchlistAlert{
+			ValidatorIndex: index,
+			Epoch:          epoch,
+			Rule:           chaindb.WatchlistAlertMissedAttestations,
+			Description:    fmt.Sprintf("missed %d consecutive attestations", count),
+		})
+	}
+
+	return alerts
+}
+
+// missedProposalAlert returns an alert if the validator's run of consecutive missed proposals
+// reached the given count in the given epoch, given its most recent proposal events.
+func missedProposalAlert(epoch phase0.Epoch,
+	index phase0.ValidatorIndex,
+	count uint64,
+	events []*chaindb.WatchlistEvent,
+) *chaindb.WatchlistAlert {
+	run := uint64(0)
+	for _, event := range events {
+		if !isProposalEvent(event) || event.ValidatorIndex != index {
+			continue
+		}
+		if event.Type == chaindb.WatchlistEventProposalIncluded {
+			run = 0
+			continue
+		}
+		run++
+		if run == count && event.Epoch == epoch {
+			return &chaindb.WatchlistAlert{
+				ValidatorIndex: index,
+				Epoch:          epoch,
+				Rule:           chaindb.WatchlistAlertMissedProposals,
+				Description:    fmt.Sprintf("missed %d consecutive proposals, most recently at slot %d", count, event.Slot),
+			}
+		}
+	}
+
+	return nil
+}
+
+// validatorsWithEvent returns the validators that have an event of the given type.
+func validatorsWithEvent(events []*chaindb.WatchlistEvent, eventType string) []phase0.ValidatorIndex {
+	seen := make(map[phase0.ValidatorIndex]bool)
+	res := make([]phase0.ValidatorIndex, 0)
+	for _, event := range events {
+		if event.Type == eventType && !seen[event.ValidatorIndex] {
+			seen[event.ValidatorIndex] = true
+			res = append(res, event.ValidatorIndex)
+		}
+	}
+
+	return res
+}
+
+// isProposalEvent returns true if the event relates to a proposal.
+func isProposalEvent(event *chaindb.WatchlistEvent) bool {
+	return event.Type == chaindb.WatchlistEventProposalIncluded ||
+		event.Type == chaindb.WatchlistEventProposalMissed
+}
@@ -0,0 +1,187 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestAttestationEffectivenessAlerts(t *testing.T) {
+	delay := 2
+	incorrect := false
+
+	// summaries creates summaries for epochs 0 to 3 for validator 1 with the given final epoch.
+	summaries := func(final *chaindb.ValidatorEpochSummary) []*chaindb.ValidatorEpochSummary {
+		res := []*chaindb.ValidatorEpochSummary{
+			{Index: 1, Epoch: 0, AttestationIncluded: true},
+			{Index: 1, Epoch: 1, AttestationIncluded: true},
+			{Index: 1, Epoch: 2, AttestationIncluded: true},
+		}
+		if final != nil {
+			res = append(res, final)
+		}
+
+		return res
+	}
+
+	tests := []struct {
+		name      string
+		epoch     phase0.Epoch
+		epochs    uint64
+		summaries []*chaindb.ValidatorEpochSummary
+		expected  []*chaindb.WatchlistAlert
+	}{
+		{
+			name:      "Effective",
+			epoch:     3,
+			epochs:    2,
+			summaries: summaries(&chaindb.ValidatorEpochSummary{Index: 1, Epoch: 3, AttestationIncluded: true}),
+			expected:  []*chaindb.WatchlistAlert{},
+		},
+		{
+			name:      "Missed",
+			epoch:     3,
+			epochs:    2,
+			summaries: summaries(&chaindb.ValidatorEpochSummary{Index: 1, Epoch: 3}),
+			expected: []*chaindb.WatchlistAlert{
+				{ValidatorIndex: 1, Epoch: 3, Rule: chaindb.WatchlistAlertAttestationEffectiveness, Description: "attestation effectiveness of 50.00% over 2 epochs is below 95.00%"},
+			},
+		},
+		{
+			name:      "Delayed",
+			epoch:     3,
+			epochs:    4,
+			summaries: summaries(&chaindb.ValidatorEpochSummary{Index: 1, Epoch: 3, AttestationIncluded: true, AttestationInclusionDelay: &delay}),
+			expected: []*chaindb.WatchlistAlert{
+				{ValidatorIndex: 1, Epoch: 3, Rule: chaindb.WatchlistAlertAttestationEffectiveness, Description: "attestation effectiveness of 87.50% over 4 epochs is below 95.00%"},
+			},
+		},
+		{
+			name:      "IncorrectTarget",
+			epoch:     3,
+			epochs:    2,
+			summaries: summaries(&chaindb.ValidatorEpochSummary{Index: 1, Epoch: 3, AttestationIncluded: true, AttestationTargetCorrect: &incorrect}),
+			expected: []*chaindb.WatchlistAlert{
+				{ValidatorIndex: 1, Epoch: 3, Rule: chaindb.WatchlistAlertAttestationEffectiveness, Description: "attestation effectiveness of 50.00% over 2 epochs is below 95.00%"},
+			},
+		},
+		{
+			name:   "AlreadyBelow",
+			epoch:  3,
+			epochs: 2,
+			summaries: []*chaindb.ValidatorEpochSummary{
+				{Index: 1, Epoch: 1, AttestationIncluded: true},
+				{Index: 1, Epoch: 2},
+				{Index: 1, Epoch: 3, AttestationIncluded: true},
+			},
+			expected: []*chaindb.WatchlistAlert{},
+		},
+		{
+			name:      "IncompleteWindow",
+			epoch:     3,
+			epochs:    2,
+			summaries: []*chaindb.ValidatorEpochSummary{{Index: 1, Epoch: 3}},
+			expected:  []*chaindb.WatchlistAlert{},
+		},
+		{
+			name:      "TooEarly",
+			epoch:     3,
+			epochs:    5,
+			summaries: summaries(&chaindb.ValidatorEpochSummary{Index: 1, Epoch: 3}),
+			expected:  []*chaindb.WatchlistAlert{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			alerts := attestationEffectivenessAlerts(test.epoch, test.epochs, 95, test.summaries)
+			require.Equal(t, test.expected, alerts)
+		})
+	}
+}
+
+func TestMissedAttestationAlerts(t *testing.T) {
+	missed := func(index phase0.ValidatorIndex, epoch phase0.Epoch) *chaindb.WatchlistEvent {
+		return &chaindb.WatchlistEvent{ValidatorIndex: index, Epoch: epoch, Type: chaindb.WatchlistEventAttestationMissed}
+	}
+
+	events := []*chaindb.WatchlistEvent{
+		// Validator 1 missed epochs 4 and 5.
+		missed(1, 4),
+		missed(1, 5),
+		// Validator 2 missed epochs 3, 4 and 5, so has already been alerted.
+		missed(2, 3),
+		missed(2, 4),
+		missed(2, 5),
+		// Validator 3 missed epochs 3 and 5.
+		missed(3, 3),
+		missed(3, 5),
+	}
+
+	alerts := missedAttestationAlerts(5, 2, events)
+	require.Equal(t, []*chaindb.WatchlistAlert{
+		{ValidatorIndex: 1, Epoch: 5, Rule: chaindb.WatchlistAlertMissedAttestations, Description: "missed 2 consecutive attestations"},
+	}, alerts)
+}
+
+func TestMissedProposalAlert(t *testing.T) {
+	proposal := func(epoch phase0.Epoch, slot phase0.Slot, included bool) *chaindb.WatchlistEvent {
+		eventType := chaindb.WatchlistEventProposalMissed
+		if included {
+			eventType = chaindb.WatchlistEventProposalIncluded
+		}
+
+		return &chaindb.WatchlistEvent{ValidatorIndex: 1, Epoch: epoch, Slot: slot, Type: eventType}
+	}
+
+	tests := []struct {
+		name     string
+		events   []*chaindb.WatchlistEvent
+		expected *chaindb.WatchlistAlert
+	}{
+		{
+			name:   "Reached",
+			events: []*chaindb.WatchlistEvent{proposal(1, 40, true), proposal(3, 100, false), proposal(5, 170, false)},
+			expected: &chaindb.WatchlistAlert{
+				ValidatorIndex: 1,
+				Epoch:          5,
+				Rule:           chaindb.WatchlistAlertMissedProposals,
+				Description:    "missed 2 consecutive proposals, most recently at slot 170",
+			},
+		},
+		{
+			name:   "NotReached",
+			events: []*chaindb.WatchlistEvent{proposal(3, 100, true), proposal(5, 170, false)},
+		},
+		{
+			name:   "AlreadyReached",
+			events: []*chaindb.WatchlistEvent{proposal(2, 70, false), proposal(3, 100, false), proposal(5, 170, false)},
+		},
+		{
+			name:   "ReachedEarlier",
+			events: []*chaindb.WatchlistEvent{proposal(2, 70, false), proposal(3, 100, false), proposal(5, 170, true)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			alert := missedProposalAlert(5, 1, 2, test.events)
+			require.Equal(t, test.expected, alert)
+		})
+	}
+}
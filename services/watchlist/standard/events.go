@@ -0,0 +1,146 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// validatorEvents returns the lifecycle events for the given validators in the given epoch.
+func (s *Service) validatorEvents(epoch phase0.Epoch,
+	validators map[phase0.ValidatorIndex]*chaindb.Validator,
+) []*chaindb.WatchlistEvent {
+	slot := s.chainTime.FirstSlotOfEpoch(epoch)
+	events := make([]*chaindb.WatchlistEvent, 0)
+	for _, validator := range validators {
+		if validator.ActivationEpoch == epoch {
+			events = append(events, epochEvent(validator.Index, epoch, slot, chaindb.WatchlistEventActivated))
+		}
+		if validator.ExitEpoch == epoch {
+			events = append(events, epochEvent(validator.Index, epoch, slot, chaindb.WatchlistEventExited))
+		}
+		// A slashed validator becomes withdrawable a fixed number of epochs after it is slashed.
+		if validator.Slashed &&
+			validator.WithdrawableEpoch >= s.epochsPerSlashingsVector &&
+			validator.WithdrawableEpoch-s.epochsPerSlashingsVector == epoch {
+			events = append(events, epochEvent(validator.Index, epoch, slot, chaindb.WatchlistEventSlashed))
+		}
+	}
+
+	return events
+}
+
+// proposalEvents returns the proposal events for watched validators given the proposer duties
+// and canonical block presence for the slots of an epoch starting at firstSlot.
+func proposalEvents(epoch phase0.Epoch,
+	firstSlot phase0.Slot,
+	duties []*chaindb.ProposerDuty,
+	presence []bool,
+	watched map[phase0.ValidatorIndex]bool,
+) []*chaindb.WatchlistEvent {
+	events := make([]*chaindb.WatchlistEvent, 0)
+	for _, duty := range duties {
+		if !watched[duty.ValidatorIndex] || duty.Slot == 0 {
+			continue
+		}
+		offset := int(duty.Slot - firstSlot)
+		if offset < 0 || offset >= len(presence) {
+			continue
+		}
+		eventType := chaindb.WatchlistEventProposalMissed
+		if presence[offset] {
+			eventType = chaindb.WatchlistEventProposalIncluded
+		}
+		events = append(events, epochEvent(duty.ValidatorIndex, epoch, duty.Slot, eventType))
+	}
+
+	return events
+}
+
+// attestationEvents returns the attestation events for the given validator summaries.
+func attestationEvents(slot phase0.Slot,
+	summaries []*chaindb.ValidatorEpochSummary,
+) []*chaindb.WatchlistEvent {
+	events := make([]*chaindb.WatchlistEvent, 0)
+	for _, summary := range summaries {
+		if !summary.AttestationIncluded {
+			events = append(events, epochEvent(summary.Index, summary.Epoch, slot, chaindb.WatchlistEventAttestationMissed))
+		}
+	}
+
+	return events
+}
+
+// balanceEvents returns the balance events for the given epoch, given the balances for it and the
+// previous epoch, and the withdrawals made between them.
+func balanceEvents(epoch phase0.Epoch,
+	slot phase0.Slot,
+	balances map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance,
+	withdrawals []*chaindb.Withdrawal,
+) []*chaindb.WatchlistEvent {
+	withdrawn := make(map[phase0.ValidatorIndex]int64)
+	for _, withdrawal := range withdrawals {
+		withdrawn[withdrawal.ValidatorIndex] += int64(withdrawal.Amount)
+	}
+
+	events := make([]*chaindb.WatchlistEvent, 0)
+	for index, validatorBalances := range balances {
+		var previous *chaindb.ValidatorBalance
+		var current *chaindb.ValidatorBalance
+		for _, balance := range validatorBalances {
+			switch balance.Epoch {
+			case epoch - 1:
+				previous = balance
+			case epoch:
+				current = balance
+			}
+		}
+		if previous == nil || current == nil {
+			continue
+		}
+		change := int64(current.Balance) - int64(previous.Balance) + withdrawn[index]
+		if change < 0 {
+			event := epochEvent(index, epoch, slot, chaindb.WatchlistEventBalanceDecreased)
+			event.Amount = &change
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+// epochEvent creates a watchlist event.
+func epochEvent(index phase0.ValidatorIndex,
+	epoch phase0.Epoch,
+	slot phase0.Slot,
+	eventType string,
+) *chaindb.WatchlistEvent {
+	return &chaindb.WatchlistEvent{
+		ValidatorIndex: index,
+		Epoch:          epoch,
+		Type:           eventType,
+		Slot:           slot,
+	}
+}
+
+// indexSet returns the given indices as a set.
+func indexSet(indices []phase0.ValidatorIndex) map[phase0.ValidatorIndex]bool {
+	res := make(map[phase0.ValidatorIndex]bool, len(indices))
+	for _, index := range indices {
+		res[index] = true
+	}
+
+	return res
+}
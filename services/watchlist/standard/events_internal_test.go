@@ -0,0 +1,122 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+func TestProposalEvents(t *testing.T) {
+	duties := []*chaindb.ProposerDuty{
+		{Slot: 32, ValidatorIndex: 1},
+		{Slot: 33, ValidatorIndex: 2},
+		{Slot: 34, ValidatorIndex: 3},
+	}
+	presence := []bool{true, false, false}
+	watched := map[phase0.ValidatorIndex]bool{1: true, 2: true}
+
+	events := proposalEvents(1, 32, duties, presence, watched)
+	require.Equal(t, []*chaindb.WatchlistEvent{
+		{ValidatorIndex: 1, Epoch: 1, Type: chaindb.WatchlistEventProposalIncluded, Slot: 32},
+		{ValidatorIndex: 2, Epoch: 1, Type: chaindb.WatchlistEventProposalMissed, Slot: 33},
+	}, events)
+}
+
+func TestAttestationEvents(t *testing.T) {
+	summaries := []*chaindb.ValidatorEpochSummary{
+		{Index: 1, Epoch: 2, AttestationIncluded: true},
+		{Index: 2, Epoch: 2, AttestationIncluded: false},
+	}
+
+	events := attestationEvents(64, summaries)
+	require.Equal(t, []*chaindb.WatchlistEvent{
+		{ValidatorIndex: 2, Epoch: 2, Type: chaindb.WatchlistEventAttestationMissed, Slot: 64},
+	}, events)
+}
+
+func TestBalanceEvents(t *testing.T) {
+	decrease := int64(-1000)
+
+	tests := []struct {
+		name        string
+		balances    map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance
+		withdrawals []*chaindb.Withdrawal
+		expected    []*chaindb.WatchlistEvent
+	}{
+		{
+			name: "Increase",
+			balances: map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance{
+				1: {{Index: 1, Epoch: 9, Balance: 32000000000}, {Index: 1, Epoch: 10, Balance: 32000001000}},
+			},
+			expected: []*chaindb.WatchlistEvent{},
+		},
+		{
+			name: "Decrease",
+			balances: map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance{
+				1: {{Index: 1, Epoch: 9, Balance: 32000000000}, {Index: 1, Epoch: 10, Balance: 31999999000}},
+			},
+			expected: []*chaindb.WatchlistEvent{
+				{ValidatorIndex: 1, Epoch: 10, Type: chaindb.WatchlistEventBalanceDecreased, Slot: 320, Amount: &decrease},
+			},
+		},
+		{
+			name: "Withdrawal",
+			balances: map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance{
+				1: {{Index: 1, Epoch: 9, Balance: 32100000000}, {Index: 1, Epoch: 10, Balance: 32000001000}},
+			},
+			withdrawals: []*chaindb.Withdrawal{
+				{ValidatorIndex: 1, Amount: 100000000},
+			},
+			expected: []*chaindb.WatchlistEvent{},
+		},
+		{
+			name: "PreviousMissing",
+			balances: map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance{
+				1: {{Index: 1, Epoch: 10, Balance: 31000000000}},
+			},
+			expected: []*chaindb.WatchlistEvent{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, balanceEvents(10, 320, test.balances, test.withdrawals))
+		})
+	}
+}
+
+func TestValidatorEvents(t *testing.T) {
+	s := &Service{
+		chainTime:                mockchaintime.New(),
+		epochsPerSlashingsVector: 8192,
+	}
+	validators := map[phase0.ValidatorIndex]*chaindb.Validator{
+		1: {Index: 1, ActivationEpoch: 5, ExitEpoch: 0xffffffffffffffff, WithdrawableEpoch: 0xffffffffffffffff},
+		2: {Index: 2, ActivationEpoch: 0, ExitEpoch: 5, WithdrawableEpoch: 261},
+		3: {Index: 3, ActivationEpoch: 0, ExitEpoch: 6, WithdrawableEpoch: 8197, Slashed: true},
+		4: {Index: 4, ActivationEpoch: 0, ExitEpoch: 100, WithdrawableEpoch: 8300, Slashed: true},
+	}
+
+	events := s.validatorEvents(5, validators)
+	require.ElementsMatch(t, []*chaindb.WatchlistEvent{
+		{ValidatorIndex: 1, Epoch: 5, Type: chaindb.WatchlistEventActivated, Slot: 0},
+		{ValidatorIndex: 2, Epoch: 5, Type: chaindb.WatchlistEventExited, Slot: 0},
+		{ValidatorIndex: 3, Epoch: 5, Type: chaindb.WatchlistEventSlashed, Slot: 0},
+	}, events)
+}
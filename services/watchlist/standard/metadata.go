@@ -0,0 +1,76 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// metadata stored about this service.
+type metadata struct {
+	LatestEpoch int64
+}
+
+// progressService is the name of this service for progress.
+var progressService = "watchlist.standard"
+
+// summarizerProgressService is the name of the summarizer service for progress.
+var summarizerProgressService = "summarizer.standard"
+
+// getMetadata gets metadata for this service.
+func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
+	md := &metadata{
+		LatestEpoch: -1,
+	}
+	progress, err := s.chainDB.Progress(ctx, progressService)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch progress")
+	}
+	if progress == nil {
+		return md, nil
+	}
+	if val, exists := progress.Values["latest_epoch"]; exists {
+		md.LatestEpoch = val
+	}
+
+	return md, nil
+}
+
+// setMetadata sets metadata for this service.
+func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
+	if err := s.chainDB.SetProgress(ctx, progressService, "latest_epoch", md.LatestEpoch); err != nil {
+		return errors.Wrap(err, "failed to update latest epoch")
+	}
+	return nil
+}
+
+// summarizedEpoch returns the latest epoch for which the summarizer has summarized validators,
+// or -1 if it has not summarized any.
+func (s *Service) summarizedEpoch(ctx context.Context) (int64, error) {
+	progress, err := s.chainDB.Progress(ctx, summarizerProgressService)
+	if err != nil {
+		return -1, errors.Wrap(err, "failed to fetch summarizer progress")
+	}
+	if progress == nil {
+		return -1, nil
+	}
+	val, exists := progress.Values["latest_validator_epoch"]
+	if !exists || val == 0 {
+		return -1, nil
+	}
+
+	return val, nil
+}
@@ -0,0 +1,108 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_watchlist"
+
+var (
+	latestEpoch     prometheus.Gauge
+	epochsProcessed prometheus.Counter
+	eventsFound     *prometheus.CounterVec
+	alertsRaised    *prometheus.CounterVec
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if latestEpoch != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	latestEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "latest_epoch",
+		Help:      "Latest epoch processed for watched validators",
+	})
+	if err := prometheus.Register(latestEpoch); err != nil {
+		return errors.Wrap(err, "failed to register latest_epoch")
+	}
+
+	epochsProcessed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "epochs_processed",
+		Help:      "Number of epochs processed for watched validators",
+	})
+	if err := prometheus.Register(epochsProcessed); err != nil {
+		return errors.Wrap(err, "failed to register epochs_processed")
+	}
+
+	eventsFound = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "events_total",
+		Help:      "Number of events for watched validators",
+	}, []string{"type"})
+	if err := prometheus.Register(eventsFound); err != nil {
+		return errors.Wrap(err, "failed to register events_total")
+	}
+
+	alertsRaised = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "alerts_total",
+		Help:      "Number of alerts raised for watched validators",
+	}, []string{"rule"})
+	if err := prometheus.Register(alertsRaised); err != nil {
+		return errors.Wrap(err, "failed to register alerts_total")
+	}
+
+	return nil
+}
+
+func monitorEpochProcessed(epoch phase0.Epoch) {
+	if latestEpoch != nil {
+		latestEpoch.Set(float64(epoch))
+	}
+	if epochsProcessed != nil {
+		epochsProcessed.Inc()
+	}
+}
+
+func monitorEventFound(eventType string) {
+	if eventsFound != nil {
+		eventsFound.WithLabelValues(eventType).Inc()
+	}
+}
+
+func monitorAlertRaised(rule string) {
+	if alertsRaised != nil {
+		alertsRaised.WithLabelValues(rule).Inc()
+	}
+}
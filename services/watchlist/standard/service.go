@@ -0,0 +1,146 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// Service is a watchlist service.
+type Service struct {
+	chainDB                         chaindb.Service
+	chainTime                       chaintime.Service
+	blocksProvider                  chaindb.BlocksProvider
+	proposerDutiesProvider          chaindb.ProposerDutiesProvider
+	validatorsProvider              chaindb.ValidatorsProvider
+	validatorEpochSummariesProvider chaindb.ValidatorEpochSummariesProvider
+	withdrawalsProvider             chaindb.WithdrawalsProvider
+	watchlistProvider               chaindb.WatchlistProvider
+	watchlistSetter                 chaindb.WatchlistSetter
+	epochsPerSlashingsVector        phase0.Epoch
+	maxEpochsPerRun                 uint64
+	alertRules                      *alertRules
+	activitySem                     *semaphore.Weighted
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("watchlist", "standard", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	blocksProvider, isBlocksProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isBlocksProvider {
+		return nil, errors.New("chain DB does not support block providing")
+	}
+
+	proposerDutiesProvider, isProposerDutiesProvider := parameters.chainDB.(chaindb.ProposerDutiesProvider)
+	if !isProposerDutiesProvider {
+		return nil, errors.New("chain DB does not support proposer duty providing")
+	}
+
+	validatorsProvider, isValidatorsProvider := parameters.chainDB.(chaindb.ValidatorsProvider)
+	if !isValidatorsProvider {
+		return nil, errors.New("chain DB does not support validator providing")
+	}
+
+	validatorEpochSummariesProvider, isValidatorEpochSummariesProvider := parameters.chainDB.(chaindb.ValidatorEpochSummariesProvider)
+	if !isValidatorEpochSummariesProvider {
+		return nil, errors.New("chain DB does not support validator epoch summary providing")
+	}
+
+	withdrawalsProvider, isWithdrawalsProvider := parameters.chainDB.(chaindb.WithdrawalsProvider)
+	if !isWithdrawalsProvider {
+		return nil, errors.New("chain DB does not support withdrawal providing")
+	}
+
+	watchlistProvider, isWatchlistProvider := parameters.chainDB.(chaindb.WatchlistProvider)
+	if !isWatchlistProvider {
+		return nil, errors.New("chain DB does not support watchlist providing")
+	}
+
+	watchlistSetter, isWatchlistSetter := parameters.chainDB.(chaindb.WatchlistSetter)
+	if !isWatchlistSetter {
+		return nil, errors.New("chain DB does not support watchlist setting")
+	}
+
+	chainSpecProvider, isChainSpecProvider := parameters.chainDB.(chaindb.ChainSpecProvider)
+	if !isChainSpecProvider {
+		return nil, errors.New("chain DB does not support chain spec providing")
+	}
+
+	spec, err := chainSpecProvider.ChainSpec(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain chain spec")
+	}
+	epochsPerSlashingsVector := phase0.Epoch(spec.EpochsPerSlashingsVector)
+	if epochsPerSlashingsVector == 0 {
+		return nil, errors.New("failed to obtain EPOCHS_PER_SLASHINGS_VECTOR")
+	}
+
+	s := &Service{
+		chainDB:                         parameters.chainDB,
+		chainTime:                       parameters.chainTime,
+		blocksProvider:                  blocksProvider,
+		proposerDutiesProvider:          proposerDutiesProvider,
+		validatorsProvider:              validatorsProvider,
+		validatorEpochSummariesProvider: validatorEpochSummariesProvider,
+		withdrawalsProvider:             withdrawalsProvider,
+		watchlistProvider:               watchlistProvider,
+		watchlistSetter:                 watchlistSetter,
+		epochsPerSlashingsVector:        epochsPerSlashingsVector,
+		maxEpochsPerRun:                 parameters.maxEpochsPerRun,
+		alertRules:                      parameters.alertRules,
+		activitySem:                     semaphore.NewWeighted(1),
+	}
+
+	// Update once per epoch.
+	runtimeFunc := func(ctx context.Context, data any) (time.Time, error) {
+		return s.chainTime.StartOfEpoch(s.chainTime.CurrentEpoch() + 1), nil
+	}
+	jobFunc := func(ctx context.Context, data any) {
+		s := data.(*Service)
+		s.update(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "watchlist", "update",
+		runtimeFunc,
+		nil,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic update")
+	}
+
+	return s, nil
+}
@@ -0,0 +1,253 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// update updates events for watched validators in epochs that have been summarized.
+func (s *Service) update(ctx context.Context) {
+	// Only allow 1 update to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another update running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	if err := s.updateEvents(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to update watchlist events")
+	}
+}
+
+// updateEvents updates events from the last epoch processed.
+func (s *Service) updateEvents(ctx context.Context) error {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	// Events use validator epoch summaries, so we can only go as far as the summarizer.
+	summarizedEpoch, err := s.summarizedEpoch(ctx)
+	if err != nil {
+		return err
+	}
+	if summarizedEpoch < 0 {
+		log.Trace().Msg("No validator summaries available")
+		return nil
+	}
+	targetEpoch := phase0.Epoch(summarizedEpoch)
+
+	watched, err := s.watchlistProvider.WatchedValidators(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain watched validators")
+	}
+	if md.LatestEpoch == -1 || len(watched) == 0 {
+		// Nothing to backfill, so start from the target.
+		md.LatestEpoch = int64(targetEpoch) - 1
+	}
+
+	startEpoch := phase0.Epoch(md.LatestEpoch + 1)
+	if startEpoch > targetEpoch {
+		log.Trace().Uint64("target_epoch", uint64(targetEpoch)).Msg("No epochs to process")
+		return nil
+	}
+	endEpoch := targetEpoch
+	if uint64(endEpoch-startEpoch) >= s.maxEpochsPerRun {
+		endEpoch = startEpoch + phase0.Epoch(s.maxEpochsPerRun) - 1
+	}
+	log.Trace().Uint64("start_epoch", uint64(startEpoch)).Uint64("end_epoch", uint64(endEpoch)).Int("watched", len(watched)).Msg("Updating watchlist events")
+
+	indices := make([]phase0.ValidatorIndex, 0, len(watched))
+	for _, validator := range watched {
+		indices = append(indices, validator.Index)
+	}
+
+	for epoch := startEpoch; epoch <= endEpoch; epoch++ {
+		if err := s.updateEpoch(ctx, md, epoch, indices); err != nil {
+			return errors.Wrapf(err, "failed to update watchlist events for epoch %d", epoch)
+		}
+	}
+
+	return nil
+}
+
+// updateEpoch updates events for the watched validators in the given epoch.
+func (s *Service) updateEpoch(ctx context.Context,
+	md *metadata,
+	epoch phase0.Epoch,
+	indices []phase0.ValidatorIndex,
+) error {
+	events := make([]*chaindb.WatchlistEvent, 0)
+
+	if len(indices) > 0 {
+		validators, err := s.validatorsProvider.ValidatorsByIndex(ctx, indices)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain validators")
+		}
+		events = append(events, s.validatorEvents(epoch, validators)...)
+
+		proposalEvents, err := s.proposalEvents(ctx, epoch, indices)
+		if err != nil {
+			return err
+		}
+		events = append(events, proposalEvents...)
+
+		attestationEvents, err := s.attestationEvents(ctx, epoch, indices)
+		if err != nil {
+			return err
+		}
+		events = append(events, attestationEvents...)
+
+		balanceEvents, err := s.balanceEvents(ctx, epoch, indices)
+		if err != nil {
+			return err
+		}
+		events = append(events, balanceEvents...)
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.watchlistSetter.SetWatchlistEvents(ctx, events); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set watchlist events")
+	}
+
+	alerts, err := s.alerts(ctx, epoch, indices, events)
+	if err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to obtain watchlist alerts")
+	}
+	if err := s.watchlistSetter.SetWatchlistAlerts(ctx, alerts); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set watchlist alerts")
+	}
+
+	md.LatestEpoch = int64(epoch)
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	for _, event := range events {
+		log.Debug().
+			Str("type", event.Type).
+			Uint64("validator_index", uint64(event.ValidatorIndex)).
+			Uint64("slot", uint64(event.Slot)).
+			Msg("Watchlist event")
+		monitorEventFound(event.Type)
+	}
+	for _, alert := range alerts {
+		log.Warn().
+			Str("rule", alert.Rule).
+			Uint64("validator_index", uint64(alert.ValidatorIndex)).
+			Uint64("epoch", uint64(alert.Epoch)).
+			Str("description", alert.Description).
+			Msg("Watchlist alert")
+		monitorAlertRaised(alert.Rule)
+	}
+	monitorEpochProcessed(epoch)
+
+	return nil
+}
+
+// proposalEvents returns the proposal events for the watched validators in the given epoch.
+func (s *Service) proposalEvents(ctx context.Context,
+	epoch phase0.Epoch,
+	indices []phase0.ValidatorIndex,
+) (
+	[]*chaindb.WatchlistEvent,
+	error,
+) {
+	minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
+	maxSlot := s.chainTime.FirstSlotOfEpoch(epoch + 1)
+	duties, err := s.proposerDutiesProvider.ProposerDutiesForSlotRange(ctx, minSlot, maxSlot)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain proposer duties")
+	}
+	presence, err := s.blocksProvider.CanonicalBlockPresenceForSlotRange(ctx, minSlot, maxSlot)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain block presence")
+	}
+
+	return proposalEvents(epoch, minSlot, duties, presence, indexSet(indices)), nil
+}
+
+// attestationEvents returns the attestation events for the watched validators in the given epoch.
+func (s *Service) attestationEvents(ctx context.Context,
+	epoch phase0.Epoch,
+	indices []phase0.ValidatorIndex,
+) (
+	[]*chaindb.WatchlistEvent,
+	error,
+) {
+	summaries, err := s.validatorEpochSummariesProvider.ValidatorSummaries(ctx, &chaindb.ValidatorSummaryFilter{
+		From:             &epoch,
+		To:               &epoch,
+		ValidatorIndices: &indices,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator summaries")
+	}
+
+	return attestationEvents(s.chainTime.FirstSlotOfEpoch(epoch), summaries), nil
+}
+
+// balanceEvents returns the balance events for the watched validators in the given epoch.
+func (s *Service) balanceEvents(ctx context.Context,
+	epoch phase0.Epoch,
+	indices []phase0.ValidatorIndex,
+) (
+	[]*chaindb.WatchlistEvent,
+	error,
+) {
+	if epoch == 0 {
+		// No previous balance to compare against.
+		return nil, nil
+	}
+
+	balances, err := s.validatorsProvider.ValidatorBalancesByIndexAndEpochRange(ctx, indices, epoch-1, epoch+1)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator balances")
+	}
+
+	// Withdrawals made in the previous epoch reduce the balance at the start of this one.
+	from := s.chainTime.FirstSlotOfEpoch(epoch - 1)
+	to := s.chainTime.FirstSlotOfEpoch(epoch) - 1
+	canonical := true
+	withdrawals, err := s.withdrawalsProvider.Withdrawals(ctx, &chaindb.WithdrawalFilter{
+		From:             &from,
+		To:               &to,
+		ValidatorIndices: indices,
+		Canonical:        &canonical,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain withdrawals")
+	}
+
+	return balanceEvents(epoch, s.chainTime.FirstSlotOfEpoch(epoch), balances, withdrawals), nil
+}
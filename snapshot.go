@@ -0,0 +1,152 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+// runSnapshot runs a snapshot command.
+func runSnapshot(ctx context.Context, command string) error {
+	dir := viper.GetString("snapshot.dir")
+	if dir == "" && command != "verify" {
+		return errors.New("snapshot.dir is required")
+	}
+
+	chainDB, err := startDatabase(ctx, nil)
+	if err != nil {
+		return err
+	}
+	db, isPostgreSQL := chainDB.(*postgresqlchaindb.Service)
+	if !isPostgreSQL {
+		return errors.New("chain database does not support snapshots")
+	}
+
+	switch command {
+	case "create":
+		return createSnapshot(ctx, db, dir)
+	case "restore":
+		if err := restoreSnapshot(ctx, db, dir); err != nil {
+			return err
+		}
+		if !viper.GetBool("snapshot.verify") {
+			return nil
+		}
+		return verifySnapshot(ctx, db)
+	case "verify":
+		return verifySnapshot(ctx, db)
+	default:
+		return fmt.Errorf("unknown snapshot command %q; supported commands are create, restore and verify", command)
+	}
+}
+
+// createSnapshot creates a snapshot of the database.
+func createSnapshot(ctx context.Context, db *postgresqlchaindb.Service, dir string) error {
+	if err := checkSchemaVersion(ctx, db); err != nil {
+		return err
+	}
+
+	manifest, err := db.CreateSnapshot(ctx, dir)
+	if err != nil {
+		return err
+	}
+	printSnapshotManifest(manifest)
+
+	return nil
+}
+
+// restoreSnapshot restores a snapshot to the database, creating the schema
+// of a new database first.
+func restoreSnapshot(ctx context.Context, db *postgresqlchaindb.Service, dir string) error {
+	manifest, err := postgresqlchaindb.ReadSnapshotManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	version, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain schema version")
+	}
+	if version == 0 && manifest.SchemaVersion == db.LatestSchemaVersion() {
+		if _, err := db.Upgrade(ctx); err != nil {
+			return errors.Wrap(err, "failed to create schema")
+		}
+	}
+
+	if _, err := db.RestoreSnapshot(ctx, dir); err != nil {
+		return err
+	}
+	printSnapshotManifest(manifest)
+
+	return nil
+}
+
+// printSnapshotManifest prints a summary of a snapshot.
+func printSnapshotManifest(manifest *postgresqlchaindb.SnapshotManifest) {
+	fmt.Printf("Schema version: %d\n", manifest.SchemaVersion)
+	if manifest.GenesisValidatorsRoot != "" {
+		fmt.Printf("Genesis validators root: %s\n", manifest.GenesisValidatorsRoot)
+	}
+	if manifest.LatestCanonicalRoot != "" {
+		fmt.Printf("Latest canonical block: %s (slot %d)\n", manifest.LatestCanonicalRoot, manifest.LatestCanonicalSlot)
+	}
+	rows := int64(0)
+	for _, table := range manifest.Tables {
+		rows += table.Rows
+	}
+	fmt.Printf("Tables: %d (%d rows)\n", len(manifest.Tables), rows)
+}
+
+// verifySnapshot verifies the chain in the database against a beacon node.
+func verifySnapshot(ctx context.Context, db *postgresqlchaindb.Service) error {
+	address := viper.GetString("eth2client.address")
+	eth2Client, err := fetchClient(ctx, address)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", address))
+	}
+	beaconBlockRootProvider, isProvider := eth2Client.(eth2client.BeaconBlockRootProvider)
+	if !isProvider {
+		return errors.New("client is not a BeaconBlockRootProvider")
+	}
+
+	verification, err := db.VerifySnapshot(ctx, &postgresqlchaindb.VerifySnapshotOpts{
+		GenesisProvider:         eth2Client.(eth2client.GenesisProvider),
+		BeaconBlockRootProvider: beaconBlockRootProvider,
+		SpotChecks:              viper.GetInt("snapshot.spot-checks"),
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Canonical blocks: %d (slots %d to %d)\n", verification.Blocks, verification.FirstSlot, verification.LatestSlot)
+	matched := 0
+	for _, check := range verification.SpotChecks {
+		if check.Matched {
+			matched++
+		}
+	}
+	fmt.Printf("Block roots matching beacon node: %d\n", matched)
+	if !verification.Passed {
+		return fmt.Errorf("snapshot failed verification: %s", verification.Failure)
+	}
+	fmt.Println("Snapshot verified")
+
+	return nil
+}
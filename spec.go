@@ -0,0 +1,145 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	"github.com/wealdtech/chaind/util"
+)
+
+// runSpec runs a chain specification command.
+func runSpec(ctx context.Context, command string) error {
+	switch command {
+	case "diff":
+		return diffSpecs(ctx)
+	default:
+		return fmt.Errorf("unknown spec command %q; supported commands are diff", command)
+	}
+}
+
+// diffSpecs prints the differences between the chain specifications of two
+// networks, each held in a database or by a beacon node.
+func diffSpecs(ctx context.Context) error {
+	networkA := viper.GetString("network-a")
+	if networkA == "" {
+		networkA = viper.GetString("chaindb.url")
+	}
+	networkB := viper.GetString("network-b")
+	if networkB == "" {
+		networkB = viper.GetString("eth2client.address")
+	}
+
+	var providerA eth2client.SpecProvider
+	var err error
+	if networkA == "" && viper.GetString("chaindb.server") != "" {
+		// Database configured by its individual components.
+		providerA, err = configuredDatabaseSpecProvider(ctx)
+	} else {
+		providerA, err = specProvider(ctx, networkA)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to access network A")
+	}
+	providerB, err := specProvider(ctx, networkB)
+	if err != nil {
+		return errors.Wrap(err, "failed to access network B")
+	}
+
+	differences, err := util.DiffSpecs(ctx, providerA, providerB)
+	if err != nil {
+		return err
+	}
+	if len(differences) == 0 {
+		fmt.Println("Chain specifications match")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "KEY\tNETWORK A\tNETWORK B")
+	for _, difference := range differences {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", difference.Key, specDiffValue(difference.A), specDiffValue(difference.B))
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("chain specifications differ in %d key(s)", len(differences))
+}
+
+// specProvider provides the chain specification of a network, from a
+// database if the source is a PostgreSQL connection URL or else from the
+// beacon node at the given address.
+func specProvider(ctx context.Context, source string) (eth2client.SpecProvider, error) {
+	if source == "" {
+		return nil, errors.New("no database or beacon node specified")
+	}
+
+	if strings.HasPrefix(source, "postgres://") || strings.HasPrefix(source, "postgresql://") {
+		chainDB, err := postgresqlchaindb.New(ctx,
+			postgresqlchaindb.WithLogLevel(util.LogLevel("chaindb")),
+			postgresqlchaindb.WithConnectionURL(source),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to start chain database service")
+		}
+
+		return chainDB, nil
+	}
+
+	eth2Client, err := fetchClient(ctx, source)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", source))
+	}
+	provider, isProvider := eth2Client.(eth2client.SpecProvider)
+	if !isProvider {
+		return nil, errors.New("client does not provide the chain specification")
+	}
+
+	return provider, nil
+}
+
+// configuredDatabaseSpecProvider provides the chain specification from the
+// database configured by its individual components.
+func configuredDatabaseSpecProvider(ctx context.Context) (eth2client.SpecProvider, error) {
+	params, err := databaseConnectionParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	params = append(params, postgresqlchaindb.WithLogLevel(util.LogLevel("chaindb")))
+
+	chainDB, err := postgresqlchaindb.New(ctx, params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start chain database service")
+	}
+
+	return chainDB, nil
+}
+
+// specDiffValue formats a value in a chain specification difference.
+func specDiffValue(value *string) string {
+	if value == nil {
+		return "(absent)"
+	}
+
+	return *value
+}
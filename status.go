@@ -0,0 +1,178 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/services/status"
+	standardstatus "github.com/wealdtech/chaind/services/status/standard"
+	"github.com/wealdtech/chaind/util"
+)
+
+// startStatus starts the status service, serving status over HTTP if configured.
+func startStatus(
+	ctx context.Context,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+) (
+	*standardstatus.Service,
+	error,
+) {
+	// The status service is also required to track progress in run-once mode.
+	if viper.GetString("status.listen-address") == "" && !viper.GetBool("run-once") {
+		return nil, nil
+	}
+
+	statusSvc, err := standardstatus.New(ctx,
+		standardstatus.WithLogLevel(util.LogLevel("status")),
+		standardstatus.WithETH2Client(eth2Client),
+		standardstatus.WithChainDB(chainDB),
+		standardstatus.WithChainTime(chainTime),
+		standardstatus.WithEndBounds(endBounds()),
+		standardstatus.WithListenAddress(viper.GetString("status.listen-address")),
+		standardstatus.WithMaxSlotLag(viper.GetUint64("status.max-slot-lag")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create status service")
+	}
+
+	return statusSvc, nil
+}
+
+// runStatus prints the status of chaind.
+func runStatus(ctx context.Context) error {
+	chainDB, err := startDatabase(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// If chaind is waiting for the chain to start there is no progress to report.
+	genesisState, err := obtainGenesisBootstrap(ctx, chainDB)
+	if err != nil {
+		return err
+	}
+	if genesisState != nil && genesisState.State != genesisStateStarted {
+		printGenesisBootstrap(genesisState)
+		return nil
+	}
+
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(util.LogLevel("chaintime")),
+		standardchaintime.WithGenesisProvider(chainDB.(eth2client.GenesisProvider)),
+		standardchaintime.WithSpecProvider(chainDB.(eth2client.SpecProvider)),
+		standardchaintime.WithForkScheduleProvider(chainDB.(eth2client.ForkScheduleProvider)),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to start chain time service")
+	}
+
+	// The beacon node is optional; without it the chain head is calculated from the current time.
+	var eth2Client eth2client.Service
+	if viper.GetString("eth2client.address") != "" {
+		eth2Client, err = fetchClient(ctx, viper.GetString("eth2client.address"))
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("eth2client.address")))
+		}
+	}
+
+	statusSvc, err := standardstatus.New(ctx,
+		standardstatus.WithLogLevel(util.LogLevel("status")),
+		standardstatus.WithETH2Client(eth2Client),
+		standardstatus.WithChainDB(chainDB),
+		standardstatus.WithChainTime(chainTime),
+		standardstatus.WithEndBounds(endBounds()),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create status service")
+	}
+
+	st, err := statusSvc.Status(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain status")
+	}
+
+	printStatus(st)
+
+	return nil
+}
+
+// printStatus prints the status in human-readable form.
+func printStatus(st *status.Status) {
+	fmt.Printf("Head slot: %d\n", st.HeadSlot)
+	if st.FinalizedEpoch != nil {
+		fmt.Printf("Finalized epoch: %d\n", *st.FinalizedEpoch)
+	}
+	fmt.Println()
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "SERVICE\tPROGRESS\tLATEST\tTARGET\tGAP\tPENDING")
+	for _, serviceStatus := range st.Services {
+		for i, progress := range serviceStatus.Progress {
+			name := ""
+			pending := ""
+			if i == 0 {
+				name = serviceStatus.Name
+				pending = fmt.Sprintf("%d", serviceStatus.PendingGaps)
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				name,
+				strings.TrimPrefix(progress.Name, "latest_"),
+				formatProgressValue(progress.Latest),
+				formatProgressValue(progress.Target),
+				formatProgressValue(progress.Gap),
+				pending,
+			)
+		}
+	}
+	writer.Flush()
+}
+
+func formatProgressValue(val *int64) string {
+	if val == nil {
+		return "-"
+	}
+
+	return fmt.Sprintf("%d", *val)
+}
+
+// endBounds provides the end bounds of the modules, keyed by module and progress item.
+func endBounds() map[string]int64 {
+	bounds := make(map[string]int64)
+	if endSlot := viper.GetInt64("blocks.end-slot"); endSlot >= 0 {
+		bounds["blocks/latest_slot"] = endSlot
+	}
+	if endEpoch := viper.GetInt64("validators.end-epoch"); endEpoch >= 0 {
+		bounds["validators/latest_epoch"] = endEpoch
+		bounds["validators/latest_balances_epoch"] = endEpoch
+	}
+	if endEpoch := viper.GetInt64("summarizer.end-epoch"); endEpoch >= 0 {
+		bounds["summarizer/latest_epoch"] = endEpoch
+		bounds["summarizer/latest_block_epoch"] = endEpoch
+		bounds["summarizer/latest_validator_epoch"] = endEpoch
+	}
+
+	return bounds
+}
@@ -22,6 +22,7 @@ import (
 	// #nosec G108
 	_ "net/http/pprof"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -29,9 +30,12 @@ import (
 	majordomo "github.com/wealdtech/go-majordomo"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
@@ -42,59 +46,81 @@ import (
 func initTracing(ctx context.Context, majordomo majordomo.Service) error {
 	if viper.GetString("tracing.address") == "" {
 		log.Debug().Msg("No tracing endpoint supplied; tracing not enabled")
-		return nil
+	} else if err := initTraceExporter(ctx, majordomo); err != nil {
+		return err
 	}
+
+	if viper.GetString("metrics.otlp.address") == "" {
+		log.Debug().Msg("No OTLP metrics endpoint supplied; OTLP metrics not enabled")
+	} else if err := initMetricsExporter(ctx, majordomo); err != nil {
+		return err
+	}
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return nil
+}
+
+// initTraceExporter initialises the exporter for traces.
+func initTraceExporter(ctx context.Context, majordomo majordomo.Service) error {
 	log.Info().Str("endpoint", viper.GetString("tracing.address")).Msg("Starting tracing")
 
-	driverOpts := []otlptracegrpc.Option{
-		otlptracegrpc.WithEndpoint(viper.GetString("tracing.address")),
+	samplingRatio := viper.GetFloat64("tracing.sampling-ratio")
+	if samplingRatio < 0 || samplingRatio > 1 {
+		return errors.New("tracing sampling ratio must be between 0 and 1")
 	}
-	if viper.GetString("tracing.client-cert") != "" {
-		log.Trace().Msg("Using TLS tracing connection")
-		creds, err := credentialsFromCerts(ctx, majordomo, "tracing")
-		if err != nil {
-			return errors.Wrap(err, "invalid TLS credentials")
+
+	tlsCfg, err := tlsConfigFromConfig(ctx, majordomo, "tracing")
+	if err != nil {
+		return err
+	}
+
+	var driver otlptrace.Client
+	switch strings.ToLower(viper.GetString("tracing.protocol")) {
+	case "", "grpc":
+		driverOpts := []otlptracegrpc.Option{
+			otlptracegrpc.WithEndpoint(viper.GetString("tracing.address")),
+			otlptracegrpc.WithHeaders(viper.GetStringMapString("tracing.headers")),
 		}
-		driverOpts = append(driverOpts,
-			otlptracegrpc.WithTLSCredentials(creds),
-		)
-	} else {
-		log.Trace().Msg("Using insecure tracing connection")
-		driverOpts = append(driverOpts,
-			otlptracegrpc.WithInsecure(),
-		)
+		if tlsCfg != nil {
+			driverOpts = append(driverOpts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg)))
+		} else {
+			driverOpts = append(driverOpts, otlptracegrpc.WithInsecure())
+		}
+		driver = otlptracegrpc.NewClient(driverOpts...)
+	case "http":
+		driverOpts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(viper.GetString("tracing.address")),
+			otlptracehttp.WithHeaders(viper.GetStringMapString("tracing.headers")),
+		}
+		if tlsCfg != nil {
+			driverOpts = append(driverOpts, otlptracehttp.WithTLSClientConfig(tlsCfg))
+		} else {
+			driverOpts = append(driverOpts, otlptracehttp.WithInsecure())
+		}
+		driver = otlptracehttp.NewClient(driverOpts...)
+	default:
+		return errors.New("tracing protocol must be one of grpc or http")
 	}
 
-	driver := otlptracegrpc.NewClient(driverOpts...)
 	exp, err := otlptrace.New(ctx, driver)
 	if err != nil {
 		return errors.Wrap(err, "failed to set up OTLP exporter")
 	}
 
-	hostname, err := os.Hostname()
-	if err != nil {
-		log.Debug().Err(err).Msg("Failed to obtain hostname")
-		hostname = "unknown"
-	}
 	tp := trace.NewTracerProvider(
 		trace.WithBatcher(exp),
-		trace.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String("chaind"),
-			semconv.ServiceInstanceIDKey.String(hostname),
-			attribute.String("release", ReleaseVersion),
-		)),
+		trace.WithSampler(trace.ParentBased(trace.TraceIDRatioBased(samplingRatio))),
+		trace.WithResource(otelResource()),
 	)
 
 	// Register our TracerProvider as the global so any imported
 	// instrumentation in the future will default to using it.
 	otel.SetTracerProvider(tp)
 
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
-		propagation.TraceContext{},
-		propagation.Baggage{},
-	))
-
 	// Shut down cleanly on exit.
 	go func(ctx context.Context) {
 		<-ctx.Done()
@@ -111,8 +137,90 @@ func initTracing(ctx context.Context, majordomo majordomo.Service) error {
 	return nil
 }
 
-func credentialsFromCerts(ctx context.Context, majordomo majordomo.Service, base string) (credentials.TransportCredentials, error) {
-	_, span := otel.Tracer("wealdtech.chaind").Start(ctx, "credentialsFromCerts")
+// initMetricsExporter initialises the exporter for OpenTelemetry metrics.
+func initMetricsExporter(ctx context.Context, majordomo majordomo.Service) error {
+	log.Info().Str("endpoint", viper.GetString("metrics.otlp.address")).Msg("Starting OTLP metrics")
+
+	interval := viper.GetDuration("metrics.otlp.interval")
+	if interval <= 0 {
+		return errors.New("OTLP metrics interval must be greater than 0")
+	}
+
+	tlsCfg, err := tlsConfigFromConfig(ctx, majordomo, "metrics.otlp")
+	if err != nil {
+		return err
+	}
+
+	exporterOpts := []otlpmetrichttp.Option{
+		otlpmetrichttp.WithEndpoint(viper.GetString("metrics.otlp.address")),
+		otlpmetrichttp.WithHeaders(viper.GetStringMapString("metrics.otlp.headers")),
+	}
+	if tlsCfg != nil {
+		exporterOpts = append(exporterOpts, otlpmetrichttp.WithTLSClientConfig(tlsCfg))
+	} else {
+		exporterOpts = append(exporterOpts, otlpmetrichttp.WithInsecure())
+	}
+	exp, err := otlpmetrichttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return errors.Wrap(err, "failed to set up OTLP metrics exporter")
+	}
+
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exp, sdkmetric.WithInterval(interval))),
+		sdkmetric.WithResource(otelResource()),
+	)
+	otel.SetMeterProvider(mp)
+
+	// Shut down cleanly on exit.
+	go func(ctx context.Context) {
+		<-ctx.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		//nolint:contextcheck
+		if err := mp.Shutdown(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to shut down OTLP metrics")
+		} else {
+			log.Trace().Msg("Shut down OTLP metrics")
+		}
+	}(ctx)
+
+	return nil
+}
+
+// otelResource returns the resource that describes this process to OpenTelemetry.
+func otelResource() *resource.Resource {
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to obtain hostname")
+		hostname = "unknown"
+	}
+
+	return resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceNameKey.String("chaind"),
+		semconv.ServiceInstanceIDKey.String(hostname),
+		attribute.String("release", ReleaseVersion),
+	)
+}
+
+// tlsConfigFromConfig returns the TLS configuration for the given base, or nil if the connection is insecure.
+func tlsConfigFromConfig(ctx context.Context, majordomo majordomo.Service, base string) (*tls.Config, error) {
+	if viper.GetString(fmt.Sprintf("%s.client-cert", base)) == "" {
+		log.Trace().Str("base", base).Msg("Using insecure connection")
+		return nil, nil
+	}
+
+	log.Trace().Str("base", base).Msg("Using TLS connection")
+	tlsCfg, err := tlsConfigFromCerts(ctx, majordomo, base)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid TLS credentials")
+	}
+
+	return tlsCfg, nil
+}
+
+func tlsConfigFromCerts(ctx context.Context, majordomo majordomo.Service, base string) (*tls.Config, error) {
+	_, span := otel.Tracer("wealdtech.chaind").Start(ctx, "tlsConfigFromCerts")
 	defer span.End()
 
 	clientCert, err := majordomo.Fetch(ctx, viper.GetString(fmt.Sprintf("%s.client-cert", base)))
@@ -149,5 +257,5 @@ func credentialsFromCerts(ctx context.Context, majordomo majordomo.Service, base
 		tlsCfg.RootCAs = cp
 	}
 
-	return credentials.NewTLS(tlsCfg), nil
+	return tlsCfg, nil
 }
@@ -0,0 +1,124 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// addressLabelJSON is the JSON representation of an address label.
+type addressLabelJSON struct {
+	Address  string `json:"address"`
+	Label    string `json:"label"`
+	Category string `json:"category"`
+}
+
+// ParseAddressLabels parses address labels in the given format, either "csv" or "json".
+// CSV data has the columns address, label and optionally category, with an optional
+// header row.  JSON data is an array of objects with address, label and category fields.
+func ParseAddressLabels(reader io.Reader, format string) ([]*chaindb.AddressLabel, error) {
+	switch strings.ToLower(format) {
+	case "csv":
+		return parseAddressLabelsCSV(reader)
+	case "json":
+		return parseAddressLabelsJSON(reader)
+	default:
+		return nil, fmt.Errorf("unsupported address label format %q; supported formats are csv and json", format)
+	}
+}
+
+func parseAddressLabelsCSV(reader io.Reader) ([]*chaindb.AddressLabel, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	csvReader.Comment = '#'
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid CSV")
+	}
+
+	labels := make([]*chaindb.AddressLabel, 0, len(records))
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "address") {
+			// Header row.
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("line %d: expected 2 or 3 fields but found %d", i+1, len(record))
+		}
+		data := &addressLabelJSON{
+			Address: record[0],
+			Label:   record[1],
+		}
+		if len(record) == 3 {
+			data.Category = record[2]
+		}
+		label, err := data.addressLabel()
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", i+1)
+		}
+		labels = append(labels, label)
+	}
+
+	return labels, nil
+}
+
+func parseAddressLabelsJSON(reader io.Reader) ([]*chaindb.AddressLabel, error) {
+	var data []*addressLabelJSON
+	if err := json.NewDecoder(reader).Decode(&data); err != nil {
+		return nil, errors.Wrap(err, "invalid JSON")
+	}
+
+	labels := make([]*chaindb.AddressLabel, 0, len(data))
+	for i := range data {
+		if data[i] == nil {
+			return nil, fmt.Errorf("entry %d: missing", i)
+		}
+		label, err := data[i].addressLabel()
+		if err != nil {
+			return nil, errors.Wrapf(err, "entry %d", i)
+		}
+		labels = append(labels, label)
+	}
+
+	return labels, nil
+}
+
+// addressLabel converts the JSON representation of an address label to its database form.
+func (a *addressLabelJSON) addressLabel() (*chaindb.AddressLabel, error) {
+	address, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(a.Address), "0x"))
+	if err != nil || len(address) != 20 {
+		return nil, fmt.Errorf("invalid address %q", a.Address)
+	}
+	label := strings.TrimSpace(a.Label)
+	if label == "" {
+		return nil, fmt.Errorf("no label for address %q", a.Address)
+	}
+
+	res := &chaindb.AddressLabel{
+		Label:    label,
+		Category: strings.ToLower(strings.TrimSpace(a.Category)),
+	}
+	copy(res.Address[:], address)
+
+	return res, nil
+}
@@ -0,0 +1,114 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestParseAddressLabels(t *testing.T) {
+	depositContract := [20]byte{0x00, 0x00, 0x00, 0x00, 0x21, 0x9a, 0xb5, 0x40, 0x35, 0x6c, 0xbb, 0x83, 0x9c, 0xbe, 0x05, 0x30, 0x3d, 0x77, 0x05, 0xfa}
+
+	tests := []struct {
+		name     string
+		input    string
+		format   string
+		expected []*chaindb.AddressLabel
+		err      string
+	}{
+		{
+			name:   "FormatUnknown",
+			input:  "",
+			format: "xml",
+			err:    "unsupported address label format \"xml\"; supported formats are csv and json",
+		},
+		{
+			name:     "CSVEmpty",
+			input:    "",
+			format:   "csv",
+			expected: []*chaindb.AddressLabel{},
+		},
+		{
+			name:   "CSV",
+			input:  "0x00000000219ab540356cbb839cbe05303d7705fa,Deposit contract,Contract\n",
+			format: "csv",
+			expected: []*chaindb.AddressLabel{
+				{Address: depositContract, Label: "Deposit contract", Category: "contract"},
+			},
+		},
+		{
+			name:   "CSVHeaderNoCategory",
+			input:  "address,label\n# Comment.\n00000000219ab540356cbb839cbe05303d7705fa, Deposit contract\n",
+			format: "CSV",
+			expected: []*chaindb.AddressLabel{
+				{Address: depositContract, Label: "Deposit contract"},
+			},
+		},
+		{
+			name:   "CSVFieldsMissing",
+			input:  "address,label,category\n0x00000000219ab540356cbb839cbe05303d7705fa\n",
+			format: "csv",
+			err:    "line 2: expected 2 or 3 fields but found 1",
+		},
+		{
+			name:   "CSVAddressInvalid",
+			input:  "0x00000000219ab540356cbb839cbe05303d7705,Deposit contract\n",
+			format: "csv",
+			err:    "line 1: invalid address \"0x00000000219ab540356cbb839cbe05303d7705\"",
+		},
+		{
+			name:   "CSVLabelMissing",
+			input:  "0x00000000219ab540356cbb839cbe05303d7705fa, ,contract\n",
+			format: "csv",
+			err:    "line 1: no label for address \"0x00000000219ab540356cbb839cbe05303d7705fa\"",
+		},
+		{
+			name:   "JSON",
+			input:  `[{"address":"0x00000000219ab540356cbb839cbe05303d7705fa","label":"Deposit contract","category":"contract"}]`,
+			format: "json",
+			expected: []*chaindb.AddressLabel{
+				{Address: depositContract, Label: "Deposit contract", Category: "contract"},
+			},
+		},
+		{
+			name:   "JSONInvalid",
+			input:  `{"address":"0x00000000219ab540356cbb839cbe05303d7705fa"}`,
+			format: "json",
+			err:    "invalid JSON: json: cannot unmarshal object into Go value of type []*util.addressLabelJSON",
+		},
+		{
+			name:   "JSONAddressInvalid",
+			input:  `[{"address":"0xinvalid","label":"Deposit contract"}]`,
+			format: "json",
+			err:    "entry 0: invalid address \"0xinvalid\"",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			labels, err := util.ParseAddressLabels(strings.NewReader(test.input), test.format)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expected, labels)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BeaconNodeAuth is the authentication for a beacon node endpoint.
+type BeaconNodeAuth struct {
+	// Address is the address of the endpoint.
+	Address string
+	// AuthToken is sent as a bearer token.
+	AuthToken string `mapstructure:"auth-token"`
+	// Username and Password are sent with basic authentication.
+	Username string
+	Password string
+	// Headers are additional headers sent with each request.
+	Headers map[string]string
+}
+
+// SecretFetcher fetches the value of a configuration item that can refer to a secret.
+type SecretFetcher func(ctx context.Context, source string) (string, error)
+
+// BeaconNodeHeaders provides the headers to send with each request to a beacon node,
+// from the first of the supplied authentications that matches its address and from
+// any credentials in the address itself.  It also provides the address stripped of
+// credentials, so that they are not logged.
+// Token, password and header values are passed through the fetcher, allowing them
+// to refer to secrets.
+func BeaconNodeHeaders(ctx context.Context,
+	address string,
+	auths []*BeaconNodeAuth,
+	fetcher SecretFetcher,
+) (
+	string,
+	map[string]string,
+	error,
+) {
+	address, userInfo, err := stripAddressCredentials(address)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var auth *BeaconNodeAuth
+	for _, candidate := range auths {
+		candidateAddress, _, err := stripAddressCredentials(candidate.Address)
+		if err != nil {
+			return "", nil, err
+		}
+		if normalizeBeaconNodeAddress(candidateAddress) == normalizeBeaconNodeAddress(address) {
+			auth = candidate
+			break
+		}
+	}
+
+	headers := make(map[string]string)
+	authorizations := 0
+	if auth != nil {
+		for name, value := range auth.Headers {
+			if strings.EqualFold(name, "Authorization") {
+				authorizations++
+			}
+			headerValue, err := fetcher(ctx, value)
+			if err != nil {
+				return "", nil, errors.Wrapf(err, "failed to obtain value of header %s", name)
+			}
+			headers[name] = headerValue
+		}
+	}
+
+	if userInfo != nil {
+		password, _ := userInfo.Password()
+		headers["Authorization"] = basicAuthorization(userInfo.Username(), password)
+		authorizations++
+	}
+	if auth != nil && auth.Username != "" {
+		password, err := fetcher(ctx, auth.Password)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "failed to obtain password")
+		}
+		headers["Authorization"] = basicAuthorization(auth.Username, password)
+		authorizations++
+	}
+	if auth != nil && auth.AuthToken != "" {
+		token, err := fetcher(ctx, auth.AuthToken)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "failed to obtain auth token")
+		}
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", token)
+		authorizations++
+	}
+	if authorizations > 1 {
+		return "", nil, fmt.Errorf("multiple authorization methods supplied for beacon node %s", address)
+	}
+
+	return address, headers, nil
+}
+
+// stripAddressCredentials removes credentials from an address, returning them separately.
+func stripAddressCredentials(address string) (string, *url.Userinfo, error) {
+	if !strings.Contains(address, "@") {
+		return address, nil, nil
+	}
+
+	parsedURL, err := url.Parse(address)
+	if err == nil && parsedURL.Host == "" {
+		// No scheme, so try again with one.
+		parsedURL, err = url.Parse(fmt.Sprintf("http://%s", address))
+		if err == nil {
+			parsedURL.Scheme = ""
+		}
+	}
+	if err != nil {
+		return "", nil, errors.New("invalid beacon node address")
+	}
+	userInfo := parsedURL.User
+	if userInfo == nil {
+		return address, nil, nil
+	}
+	parsedURL.User = nil
+
+	return strings.TrimPrefix(parsedURL.String(), "//"), userInfo, nil
+}
+
+// normalizeBeaconNodeAddress normalizes an address for comparison, as the scheme
+// and trailing slash are optional.
+func normalizeBeaconNodeAddress(address string) string {
+	if !strings.HasPrefix(address, "http") {
+		address = fmt.Sprintf("http://%s", address)
+	}
+
+	return strings.TrimSuffix(address, "/")
+}
+
+// basicAuthorization provides the value of a basic authorization header.
+func basicAuthorization(username string, password string) string {
+	return fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", username, password))))
+}
@@ -0,0 +1,111 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// SpecDifference is a difference between the values of a key in two chain
+// specifications.
+type SpecDifference struct {
+	Key string
+	// A is the value in the first specification, or nil if the key is not present.
+	A *string
+	// B is the value in the second specification, or nil if the key is not present.
+	B *string
+}
+
+// FormatSpecValue formats a chain specification value as it is held in the
+// database, so that values from different sources can be compared.
+func FormatSpecValue(value any) string {
+	switch v := value.(type) {
+	case phase0.Slot, phase0.Epoch, phase0.CommitteeIndex, phase0.ValidatorIndex, phase0.Gwei:
+		return fmt.Sprintf("%d", v)
+	case phase0.Root, phase0.Version, phase0.DomainType, phase0.ForkDigest, phase0.Domain, phase0.BLSPubKey, phase0.BLSSignature, []byte:
+		return fmt.Sprintf("%#x", v)
+	case time.Duration:
+		return strconv.Itoa(int(v.Seconds()))
+	case time.Time:
+		return strconv.FormatInt(v.Unix(), 10)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// DiffSpecs compares the chain specifications from two providers, returning
+// the keys whose values differ, in key order.
+func DiffSpecs(ctx context.Context,
+	a eth2client.SpecProvider,
+	b eth2client.SpecProvider,
+) (
+	[]*SpecDifference,
+	error,
+) {
+	specA, err := a.Spec(ctx, &api.SpecOpts{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain first chain specification")
+	}
+	specB, err := b.Spec(ctx, &api.SpecOpts{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain second chain specification")
+	}
+
+	return DiffSpecValues(specA.Data, specB.Data), nil
+}
+
+// DiffSpecValues compares two chain specifications, returning the keys whose
+// values differ, in key order.
+func DiffSpecValues(a map[string]any, b map[string]any) []*SpecDifference {
+	keys := make(map[string]struct{}, len(a))
+	for key := range a {
+		keys[key] = struct{}{}
+	}
+	for key := range b {
+		keys[key] = struct{}{}
+	}
+
+	differences := make([]*SpecDifference, 0)
+	for key := range keys {
+		difference := &SpecDifference{
+			Key: key,
+		}
+		if value, exists := a[key]; exists {
+			formatted := FormatSpecValue(value)
+			difference.A = &formatted
+		}
+		if value, exists := b[key]; exists {
+			formatted := FormatSpecValue(value)
+			difference.B = &formatted
+		}
+		if difference.A != nil && difference.B != nil && *difference.A == *difference.B {
+			continue
+		}
+		differences = append(differences, difference)
+	}
+	sort.Slice(differences, func(i int, j int) bool {
+		return differences[i].Key < differences[j].Key
+	})
+
+	return differences
+}
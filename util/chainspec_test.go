@@ -0,0 +1,127 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestFormatSpecValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{
+			name:     "Uint64",
+			value:    uint64(32),
+			expected: "32",
+		},
+		{
+			name:     "Epoch",
+			value:    phase0.Epoch(74240),
+			expected: "74240",
+		},
+		{
+			name:     "Version",
+			value:    phase0.Version{0x01, 0x00, 0x00, 0x00},
+			expected: "0x01000000",
+		},
+		{
+			name:     "Bytes",
+			value:    []byte{0xab, 0xcd},
+			expected: "0xabcd",
+		},
+		{
+			name:     "Duration",
+			value:    12 * time.Second,
+			expected: "12",
+		},
+		{
+			name:     "Time",
+			value:    time.Unix(1606824023, 0),
+			expected: "1606824023",
+		},
+		{
+			name:     "String",
+			value:    "mainnet",
+			expected: "mainnet",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, util.FormatSpecValue(test.value))
+		})
+	}
+}
+
+func TestDiffSpecValues(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		a        map[string]any
+		b        map[string]any
+		expected []*util.SpecDifference
+	}{
+		{
+			name:     "Empty",
+			a:        map[string]any{},
+			b:        map[string]any{},
+			expected: []*util.SpecDifference{},
+		},
+		{
+			name: "Same",
+			a: map[string]any{
+				"SECONDS_PER_SLOT":    12 * time.Second,
+				"ALTAIR_FORK_VERSION": phase0.Version{0x01, 0x00, 0x00, 0x00},
+			},
+			b: map[string]any{
+				"SECONDS_PER_SLOT":    uint64(12),
+				"ALTAIR_FORK_VERSION": []byte{0x01, 0x00, 0x00, 0x00},
+			},
+			expected: []*util.SpecDifference{},
+		},
+		{
+			name: "Different",
+			a: map[string]any{
+				"CONFIG_NAME":       "mainnet",
+				"SLOTS_PER_EPOCH":   uint64(32),
+				"ALTAIR_FORK_EPOCH": uint64(74240),
+			},
+			b: map[string]any{
+				"CONFIG_NAME":      "holesky",
+				"SLOTS_PER_EPOCH":  uint64(32),
+				"DENEB_FORK_EPOCH": uint64(29696),
+			},
+			expected: []*util.SpecDifference{
+				{Key: "ALTAIR_FORK_EPOCH", A: str("74240")},
+				{Key: "CONFIG_NAME", A: str("mainnet"), B: str("holesky")},
+				{Key: "DENEB_FORK_EPOCH", B: str("29696")},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, util.DiffSpecValues(test.a, test.b))
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"context"
+	"net/url"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/go-majordomo"
+)
+
+// Service returns values from environment variables.
+// This service handles URLs with the scheme "env".
+// A URL is of the form "env://NAME", and returns the value of the environment
+// variable NAME.  If NAME is not set but NAME_FILE is, the contents of the file
+// named by NAME_FILE are returned instead, allowing secrets to be mounted as
+// files by container orchestrators.
+type Service struct{}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new environment confidant.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "confidant").Str("impl", "env").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	return &Service{}, nil
+}
+
+// SupportedURLSchemes provides the list of schemes supported by this confidant.
+func (*Service) SupportedURLSchemes(_ context.Context) ([]string, error) {
+	return []string{"env"}, nil
+}
+
+// Fetch fetches a value given its URL.
+func (*Service) Fetch(_ context.Context, url *url.URL) ([]byte, error) {
+	name := url.Host
+	if name == "" {
+		return nil, majordomo.ErrURLInvalid
+	}
+
+	if value, exists := os.LookupEnv(name); exists {
+		return []byte(value), nil
+	}
+
+	filename, exists := os.LookupEnv(name + "_FILE")
+	if !exists {
+		return nil, majordomo.ErrNotFound
+	}
+	log.Trace().Str("name", name).Str("file", filename).Msg("Obtaining value from file")
+	value, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read file for %s", name)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,91 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env_test
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util/confidants/env"
+	"github.com/wealdtech/go-majordomo"
+)
+
+func TestFetch(t *testing.T) {
+	ctx := context.Background()
+
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("from file"), 0o600))
+	t.Setenv("CHAIND_TEST_SECRET", "from variable")
+	t.Setenv("CHAIND_TEST_SECRET_FILE", "/nonexistent")
+	t.Setenv("CHAIND_TEST_INDIRECT_FILE", secretFile)
+	t.Setenv("CHAIND_TEST_BAD_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	s, err := env.New(ctx)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		url      string
+		expected []byte
+		err      error
+		errStr   string
+	}{
+		{
+			name: "NoName",
+			url:  "env://",
+			err:  majordomo.ErrURLInvalid,
+		},
+		{
+			name:     "Variable",
+			url:      "env://CHAIND_TEST_SECRET",
+			expected: []byte("from variable"),
+		},
+		{
+			name:     "File",
+			url:      "env://CHAIND_TEST_INDIRECT",
+			expected: []byte("from file"),
+		},
+		{
+			name:   "FileMissing",
+			url:    "env://CHAIND_TEST_BAD",
+			errStr: "failed to read file for CHAIND_TEST_BAD",
+		},
+		{
+			name: "Unknown",
+			url:  "env://CHAIND_TEST_UNKNOWN",
+			err:  majordomo.ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u, err := url.Parse(test.url)
+			require.NoError(t, err)
+			value, err := s.Fetch(ctx, u)
+			switch {
+			case test.err != nil:
+				require.ErrorIs(t, err, test.err)
+			case test.errStr != "":
+				require.ErrorContains(t, err, test.errStr)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, test.expected, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel  zerolog.Level
+	address   string
+	token     string
+	tokenFile string
+	namespace string
+	kvVersion int
+	timeout   time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(p *parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithAddress sets the address of the Vault server, for example "https://vault.example.com:8200".
+func WithAddress(address string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.address = address
+	})
+}
+
+// WithToken sets the token with which to access Vault.
+func WithToken(token string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.token = token
+	})
+}
+
+// WithTokenFile sets a file from which to read the token with which to access Vault.
+// The file is read for each request, so the token can be renewed by an external agent.
+func WithTokenFile(tokenFile string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.tokenFile = tokenFile
+	})
+}
+
+// WithNamespace sets the Vault namespace in which secrets are held.
+func WithNamespace(namespace string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.namespace = namespace
+	})
+}
+
+// WithKVVersion sets the version of the key/value secrets engine, either 1 or 2.
+func WithKVVersion(kvVersion int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.kvVersion = kvVersion
+	})
+}
+
+// WithTimeout sets the timeout for requests to Vault.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:  zerolog.GlobalLevel(),
+		kvVersion: 2,
+		timeout:   30 * time.Second,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.address == "" {
+		return nil, errors.New("no address specified")
+	}
+	if parameters.token == "" && parameters.tokenFile == "" {
+		return nil, errors.New("no token or token file specified")
+	}
+	if parameters.kvVersion != 1 && parameters.kvVersion != 2 {
+		return nil, fmt.Errorf("unsupported key/value version %d", parameters.kvVersion)
+	}
+	if parameters.timeout <= 0 {
+		return nil, errors.New("timeout must be greater than 0")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,194 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/go-majordomo"
+)
+
+// Service returns values from the key/value secrets engine of HashiCorp Vault.
+// This service handles URLs with the scheme "vault".
+// A full URL is of the form "vault://mount/path#key", where mount is the mount point
+// of the secrets engine, path is the path of the secret within it, and key is the
+// key of the value within the secret.  The key can be omitted if the secret holds
+// a single value.
+type Service struct {
+	client    *http.Client
+	address   string
+	token     string
+	tokenFile string
+	namespace string
+	kvVersion int
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new Vault confidant.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "confidant").Str("impl", "vault").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	return &Service{
+		client: &http.Client{
+			Timeout: parameters.timeout,
+		},
+		address:   strings.TrimSuffix(parameters.address, "/"),
+		token:     parameters.token,
+		tokenFile: parameters.tokenFile,
+		namespace: parameters.namespace,
+		kvVersion: parameters.kvVersion,
+	}, nil
+}
+
+// SupportedURLSchemes provides the list of schemes supported by this confidant.
+func (*Service) SupportedURLSchemes(_ context.Context) ([]string, error) {
+	return []string{"vault"}, nil
+}
+
+// Fetch fetches a value given its URL.
+func (s *Service) Fetch(ctx context.Context, url *url.URL) ([]byte, error) {
+	mount := url.Host
+	path := strings.Trim(url.Path, "/")
+	if mount == "" || path == "" {
+		return nil, majordomo.ErrURLInvalid
+	}
+
+	data, err := s.secret(ctx, mount, path)
+	if err != nil {
+		return nil, err
+	}
+
+	key := url.Fragment
+	if key == "" {
+		if len(data) != 1 {
+			log.Debug().Str("mount", mount).Str("path", path).Int("keys", len(data)).Msg("No key specified for secret with multiple values")
+			return nil, majordomo.ErrURLInvalid
+		}
+		for k := range data {
+			key = k
+		}
+	}
+	value, exists := data[key]
+	if !exists {
+		return nil, majordomo.ErrNotFound
+	}
+	strValue, isString := value.(string)
+	if !isString {
+		return nil, fmt.Errorf("value of %s is not a string", key)
+	}
+
+	return []byte(strValue), nil
+}
+
+// secret obtains the values of a secret.
+func (s *Service) secret(ctx context.Context, mount string, path string) (map[string]any, error) {
+	token, err := s.currentToken()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s/%s", s.address, mount, path)
+	if s.kvVersion == 2 {
+		endpoint = fmt.Sprintf("%s/v1/%s/data/%s", s.address, mount, path)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if s.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.namespace)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to call Vault")
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response from Vault")
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, majordomo.ErrNotFound
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		log.Debug().Int("status_code", resp.StatusCode).Str("data", string(body)).Msg("Request failed")
+		return nil, fmt.Errorf("request to Vault returned status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, errors.Wrap(err, "failed to parse response from Vault")
+	}
+	if s.kvVersion == 2 {
+		// Version 2 of the engine wraps the values alongside their metadata.
+		var versioned struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(response.Data, &versioned); err != nil {
+			return nil, errors.Wrap(err, "failed to parse secret from Vault")
+		}
+		response.Data = versioned.Data
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal(response.Data, &data); err != nil {
+		return nil, errors.Wrap(err, "failed to parse secret values from Vault")
+	}
+	if data == nil {
+		// Deleted secrets are returned without values.
+		return nil, majordomo.ErrNotFound
+	}
+
+	return data, nil
+}
+
+// currentToken provides the token with which to access Vault.
+func (s *Service) currentToken() (string, error) {
+	if s.tokenFile == "" {
+		return s.token, nil
+	}
+
+	token, err := os.ReadFile(s.tokenFile)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read Vault token file")
+	}
+
+	return strings.TrimSpace(string(token)), nil
+}
@@ -0,0 +1,261 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util/confidants/vault"
+	"github.com/wealdtech/go-majordomo"
+)
+
+// testServer provides a server that responds as Vault, with the given responses by path.
+func testServer(t *testing.T, token string, responses map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		response, exists := responses[r.URL.Path]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []vault.Parameter
+		err    string
+	}{
+		{
+			name: "AddressMissing",
+			params: []vault.Parameter{
+				vault.WithToken("token"),
+			},
+			err: "problem with parameters: no address specified",
+		},
+		{
+			name: "TokenMissing",
+			params: []vault.Parameter{
+				vault.WithAddress("http://localhost:8200"),
+			},
+			err: "problem with parameters: no token or token file specified",
+		},
+		{
+			name: "KVVersionInvalid",
+			params: []vault.Parameter{
+				vault.WithAddress("http://localhost:8200"),
+				vault.WithToken("token"),
+				vault.WithKVVersion(3),
+			},
+			err: "problem with parameters: unsupported key/value version 3",
+		},
+		{
+			name: "TimeoutZero",
+			params: []vault.Parameter{
+				vault.WithAddress("http://localhost:8200"),
+				vault.WithToken("token"),
+				vault.WithTimeout(0),
+			},
+			err: "problem with parameters: timeout must be greater than 0",
+		},
+		{
+			name: "Good",
+			params: []vault.Parameter{
+				vault.WithAddress("http://localhost:8200"),
+				vault.WithToken("token"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := vault.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	ctx := context.Background()
+
+	server := testServer(t, "token", map[string]string{
+		"/v1/secret/data/chaind/db":     `{"data":{"data":{"password":"secret"},"metadata":{"version":3}}}`,
+		"/v1/secret/data/chaind/multi":  `{"data":{"data":{"user":"chain","password":"secret"},"metadata":{"version":1}}}`,
+		"/v1/secret/data/chaind/number": `{"data":{"data":{"port":5432},"metadata":{"version":1}}}`,
+		"/v1/secret/data/chaind/gone":   `{"data":{"data":null,"metadata":{"version":2,"deletion_time":"2024-01-01T00:00:00Z"}}}`,
+		"/v1/kv/chaind/db":              `{"data":{"password":"v1 secret"}}`,
+	})
+
+	s, err := vault.New(ctx,
+		vault.WithAddress(server.URL),
+		vault.WithToken("token"),
+	)
+	require.NoError(t, err)
+	v1, err := vault.New(ctx,
+		vault.WithAddress(server.URL+"/"),
+		vault.WithToken("token"),
+		vault.WithKVVersion(1),
+	)
+	require.NoError(t, err)
+	badToken, err := vault.New(ctx,
+		vault.WithAddress(server.URL),
+		vault.WithToken("bad"),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		service  *vault.Service
+		url      string
+		expected []byte
+		err      error
+		errStr   string
+	}{
+		{
+			name:    "PathMissing",
+			service: s,
+			url:     "vault://secret",
+			err:     majordomo.ErrURLInvalid,
+		},
+		{
+			name:     "Key",
+			service:  s,
+			url:      "vault://secret/chaind/db#password",
+			expected: []byte("secret"),
+		},
+		{
+			name:     "SingleValue",
+			service:  s,
+			url:      "vault://secret/chaind/db",
+			expected: []byte("secret"),
+		},
+		{
+			name:     "MultipleValuesKey",
+			service:  s,
+			url:      "vault://secret/chaind/multi#user",
+			expected: []byte("chain"),
+		},
+		{
+			name:    "MultipleValuesNoKey",
+			service: s,
+			url:     "vault://secret/chaind/multi",
+			err:     majordomo.ErrURLInvalid,
+		},
+		{
+			name:    "KeyUnknown",
+			service: s,
+			url:     "vault://secret/chaind/db#user",
+			err:     majordomo.ErrNotFound,
+		},
+		{
+			name:    "NotString",
+			service: s,
+			url:     "vault://secret/chaind/number#port",
+			errStr:  "value of port is not a string",
+		},
+		{
+			name:    "Deleted",
+			service: s,
+			url:     "vault://secret/chaind/gone#password",
+			err:     majordomo.ErrNotFound,
+		},
+		{
+			name:    "SecretUnknown",
+			service: s,
+			url:     "vault://secret/chaind/unknown#password",
+			err:     majordomo.ErrNotFound,
+		},
+		{
+			name:     "KVVersion1",
+			service:  v1,
+			url:      "vault://kv/chaind/db#password",
+			expected: []byte("v1 secret"),
+		},
+		{
+			name:    "BadToken",
+			service: badToken,
+			url:     "vault://secret/chaind/db#password",
+			errStr:  "request to Vault returned status 403",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u, err := url.Parse(test.url)
+			require.NoError(t, err)
+			value, err := test.service.Fetch(ctx, u)
+			switch {
+			case test.err != nil:
+				require.ErrorIs(t, err, test.err)
+			case test.errStr != "":
+				require.EqualError(t, err, test.errStr)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, test.expected, value)
+			}
+		})
+	}
+}
+
+func TestFetchTokenFile(t *testing.T) {
+	ctx := context.Background()
+
+	server := testServer(t, "renewed", map[string]string{
+		"/v1/secret/data/chaind/db": `{"data":{"data":{"password":"secret"}}}`,
+	})
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("initial\n"), 0o600))
+
+	s, err := vault.New(ctx,
+		vault.WithAddress(server.URL),
+		vault.WithTokenFile(tokenFile),
+	)
+	require.NoError(t, err)
+	u, err := url.Parse("vault://secret/chaind/db#password")
+	require.NoError(t, err)
+
+	_, err = s.Fetch(ctx, u)
+	require.EqualError(t, err, "request to Vault returned status 403")
+
+	// The token file is re-read for each request.
+	require.NoError(t, os.WriteFile(tokenFile, []byte("renewed\n"), 0o600))
+	value, err := s.Fetch(ctx, u)
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret"), value)
+}
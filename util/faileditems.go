@@ -0,0 +1,97 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// RecordFailedItem records a failure by a module to process an item.
+// The failure is recorded in its own transaction, as any transaction in
+// which the item was being processed will have been rolled back.
+// Returns the item as recorded, including its state.
+func RecordFailedItem(ctx context.Context,
+	chainDB chaindb.Service,
+	module string,
+	kind string,
+	item uint64,
+	failure error,
+	maxAttempts uint32,
+) (
+	*chaindb.FailedItem,
+	error,
+) {
+	setter, isSetter := chainDB.(chaindb.FailedItemsSetter)
+	if !isSetter {
+		return nil, errors.New("chain DB does not support failed item setting")
+	}
+
+	ctx, cancel, err := chainDB.BeginTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin transaction")
+	}
+	recorded, err := setter.RecordFailedItem(ctx, &chaindb.FailedItem{
+		Module: module,
+		Kind:   kind,
+		Item:   item,
+		Error:  failure.Error(),
+	}, maxAttempts)
+	if err != nil {
+		cancel()
+		return nil, errors.Wrap(err, "failed to record failed item")
+	}
+	if err := chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return nil, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return recorded, nil
+}
+
+// RemoveFailedItems removes failed items for a module, for example once
+// they have been processed successfully.
+func RemoveFailedItems(ctx context.Context,
+	chainDB chaindb.Service,
+	module string,
+	kind string,
+	items []uint64,
+) error {
+	if len(items) == 0 {
+		return nil
+	}
+	setter, isSetter := chainDB.(chaindb.FailedItemsSetter)
+	if !isSetter {
+		return errors.New("chain DB does not support failed item setting")
+	}
+
+	ctx, cancel, err := chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	for _, item := range items {
+		if err := setter.RemoveFailedItem(ctx, module, kind, item); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to remove failed item")
+		}
+	}
+	if err := chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
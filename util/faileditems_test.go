@@ -0,0 +1,45 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestRecordFailedItem(t *testing.T) {
+	ctx := context.Background()
+	chainDB := mockchaindb.New()
+
+	item, err := util.RecordFailedItem(ctx, chainDB, "blocks", chaindb.FailedItemKindSlot, 12345, errors.New("bad block"), 5)
+	require.NoError(t, err)
+	require.Equal(t, "blocks", item.Module)
+	require.Equal(t, chaindb.FailedItemKindSlot, item.Kind)
+	require.Equal(t, uint64(12345), item.Item)
+	require.Equal(t, "bad block", item.Error)
+}
+
+func TestRemoveFailedItems(t *testing.T) {
+	ctx := context.Background()
+	chainDB := mockchaindb.New()
+
+	require.NoError(t, util.RemoveFailedItems(ctx, chainDB, "blocks", chaindb.FailedItemKindSlot, nil))
+	require.NoError(t, util.RemoveFailedItems(ctx, chainDB, "blocks", chaindb.FailedItemKindSlot, []uint64{1, 2, 3}))
+}
@@ -0,0 +1,140 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// farFutureEpoch is the epoch of forks that are not scheduled.
+const farFutureEpoch = phase0.Epoch(0xffffffffffffffff)
+
+// ForksFromSpec provides the scheduled forks of the chain, in the order in
+// which they take place, from the fork versions and epochs in its spec.
+// The genesis fork is named "phase0"; other forks are named after their
+// spec keys, for example "capella" for CAPELLA_FORK_VERSION.
+func ForksFromSpec(spec map[string]any, genesisValidatorsRoot phase0.Root) ([]*chaindb.Fork, error) {
+	forks := make([]*chaindb.Fork, 0)
+	for key, value := range spec {
+		prefix, isVersion := strings.CutSuffix(key, "_FORK_VERSION")
+		if !isVersion {
+			continue
+		}
+		version, ok := value.(phase0.Version)
+		if !ok {
+			return nil, fmt.Errorf("%s of unexpected type", key)
+		}
+
+		fork := &chaindb.Fork{
+			Name:    strings.ToLower(prefix),
+			Version: version,
+		}
+		if prefix == "GENESIS" {
+			fork.Name = "phase0"
+		} else {
+			tmp, exists := spec[prefix+"_FORK_EPOCH"]
+			if !exists {
+				// Not scheduled.
+				continue
+			}
+			epoch, ok := tmp.(uint64)
+			if !ok {
+				return nil, fmt.Errorf("%s_FORK_EPOCH of unexpected type", prefix)
+			}
+			if phase0.Epoch(epoch) == farFutureEpoch {
+				// Not scheduled.
+				continue
+			}
+			fork.Epoch = phase0.Epoch(epoch)
+		}
+
+		var err error
+		fork.Digest, err = ForkDigest(version, genesisValidatorsRoot)
+		if err != nil {
+			return nil, err
+		}
+		forks = append(forks, fork)
+	}
+
+	// Forks can share an epoch, in which case the versions give their order.
+	sort.Slice(forks, func(i, j int) bool {
+		if forks[i].Epoch != forks[j].Epoch {
+			return forks[i].Epoch < forks[j].Epoch
+		}
+		return bytes.Compare(forks[i].Version[:], forks[j].Version[:]) < 0
+	})
+
+	return forks, nil
+}
+
+// ForkDigest calculates the digest of a fork version for a chain.
+func ForkDigest(version phase0.Version, genesisValidatorsRoot phase0.Root) (phase0.ForkDigest, error) {
+	forkData := &phase0.ForkData{
+		CurrentVersion:        version,
+		GenesisValidatorsRoot: genesisValidatorsRoot,
+	}
+	root, err := forkData.HashTreeRoot()
+	if err != nil {
+		return phase0.ForkDigest{}, errors.Wrap(err, "failed to calculate fork data root")
+	}
+
+	var digest phase0.ForkDigest
+	copy(digest[:], root[:])
+
+	return digest, nil
+}
+
+// ForkByName provides the named fork.
+// Returns nil if the fork is not scheduled.
+func ForkByName(forks []*chaindb.Fork, name string) *chaindb.Fork {
+	for _, fork := range forks {
+		if fork.Name == name {
+			return fork
+		}
+	}
+
+	return nil
+}
+
+// ForkAtEpoch provides the fork in effect at the given epoch.
+// Returns nil if there are no forks at or before the epoch.
+func ForkAtEpoch(forks []*chaindb.Fork, epoch phase0.Epoch) *chaindb.Fork {
+	var res *chaindb.Fork
+	for _, fork := range forks {
+		if fork.Epoch > epoch {
+			break
+		}
+		res = fork
+	}
+
+	return res
+}
+
+// ForkEpoch provides the epoch of the named fork.
+// Returns the far future epoch if the fork is not scheduled.
+func ForkEpoch(forks []*chaindb.Fork, name string) phase0.Epoch {
+	fork := ForkByName(forks, name)
+	if fork == nil {
+		return farFutureEpoch
+	}
+
+	return fork.Epoch
+}
@@ -0,0 +1,146 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestForksFromSpec(t *testing.T) {
+	// Mainnet genesis validators root.
+	genesisValidatorsRoot := phase0.Root{
+		0x4b, 0x36, 0x3d, 0xb9, 0x4e, 0x28, 0x61, 0x20, 0xd7, 0x6e, 0xb9, 0x05, 0x34, 0x0f, 0xdd, 0x4e,
+		0x54, 0xbf, 0xe9, 0xf0, 0x6b, 0xf3, 0x3f, 0xf6, 0xcf, 0x5a, 0xd2, 0x7f, 0x51, 0x1b, 0xfe, 0x95,
+	}
+
+	tests := []struct {
+		name    string
+		spec    map[string]any
+		names   []string
+		epochs  []phase0.Epoch
+		digests []phase0.ForkDigest
+		err     string
+	}{
+		{
+			name:  "Empty",
+			spec:  map[string]any{},
+			names: []string{},
+		},
+		{
+			name: "Mainnet",
+			spec: map[string]any{
+				"GENESIS_FORK_VERSION":   phase0.Version{0x00, 0x00, 0x00, 0x00},
+				"ALTAIR_FORK_VERSION":    phase0.Version{0x01, 0x00, 0x00, 0x00},
+				"ALTAIR_FORK_EPOCH":      uint64(74240),
+				"BELLATRIX_FORK_VERSION": phase0.Version{0x02, 0x00, 0x00, 0x00},
+				"BELLATRIX_FORK_EPOCH":   uint64(144896),
+				"CAPELLA_FORK_VERSION":   phase0.Version{0x03, 0x00, 0x00, 0x00},
+				"CAPELLA_FORK_EPOCH":     uint64(194048),
+				"DENEB_FORK_VERSION":     phase0.Version{0x04, 0x00, 0x00, 0x00},
+				"DENEB_FORK_EPOCH":       uint64(269568),
+				"SHARDING_FORK_VERSION":  phase0.Version{0x05, 0x00, 0x00, 0x00},
+				"SHARDING_FORK_EPOCH":    uint64(0xffffffffffffffff),
+				"SLOTS_PER_EPOCH":        uint64(32),
+			},
+			names:  []string{"phase0", "altair", "bellatrix", "capella", "deneb"},
+			epochs: []phase0.Epoch{0, 74240, 144896, 194048, 269568},
+			digests: []phase0.ForkDigest{
+				{0xb5, 0x30, 0x3f, 0x2a},
+				{0xaf, 0xca, 0xab, 0xa0},
+				{0x4a, 0x26, 0xc5, 0x8b},
+				{0xbb, 0xa4, 0xda, 0x96},
+				{0x6a, 0x95, 0xa1, 0xa9},
+			},
+		},
+		{
+			name: "SameEpoch",
+			spec: map[string]any{
+				"GENESIS_FORK_VERSION":   phase0.Version{0x10, 0x00, 0x00, 0x00},
+				"BELLATRIX_FORK_VERSION": phase0.Version{0x12, 0x00, 0x00, 0x00},
+				"BELLATRIX_FORK_EPOCH":   uint64(0),
+				"ALTAIR_FORK_VERSION":    phase0.Version{0x11, 0x00, 0x00, 0x00},
+				"ALTAIR_FORK_EPOCH":      uint64(0),
+			},
+			names:  []string{"phase0", "altair", "bellatrix"},
+			epochs: []phase0.Epoch{0, 0, 0},
+		},
+		{
+			name: "Unscheduled",
+			spec: map[string]any{
+				"GENESIS_FORK_VERSION": phase0.Version{0x00, 0x00, 0x00, 0x00},
+				"ALTAIR_FORK_VERSION":  phase0.Version{0x01, 0x00, 0x00, 0x00},
+			},
+			names:  []string{"phase0"},
+			epochs: []phase0.Epoch{0},
+		},
+		{
+			name: "VersionInvalid",
+			spec: map[string]any{
+				"GENESIS_FORK_VERSION": "0x00000000",
+			},
+			err: "GENESIS_FORK_VERSION of unexpected type",
+		},
+		{
+			name: "EpochInvalid",
+			spec: map[string]any{
+				"ALTAIR_FORK_VERSION": phase0.Version{0x01, 0x00, 0x00, 0x00},
+				"ALTAIR_FORK_EPOCH":   "74240",
+			},
+			err: "ALTAIR_FORK_EPOCH of unexpected type",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			forks, err := util.ForksFromSpec(test.spec, genesisValidatorsRoot)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			names := make([]string, len(forks))
+			for i := range forks {
+				names[i] = forks[i].Name
+			}
+			require.Equal(t, test.names, names)
+			for i := range test.epochs {
+				require.Equal(t, test.epochs[i], forks[i].Epoch)
+			}
+			for i := range test.digests {
+				require.Equal(t, test.digests[i], forks[i].Digest)
+			}
+		})
+	}
+}
+
+func TestForkAtEpoch(t *testing.T) {
+	forks, err := util.ForksFromSpec(map[string]any{
+		"GENESIS_FORK_VERSION": phase0.Version{0x00, 0x00, 0x00, 0x00},
+		"ALTAIR_FORK_VERSION":  phase0.Version{0x01, 0x00, 0x00, 0x00},
+		"ALTAIR_FORK_EPOCH":    uint64(10),
+	}, phase0.Root{})
+	require.NoError(t, err)
+
+	require.Equal(t, "phase0", util.ForkAtEpoch(forks, 0).Name)
+	require.Equal(t, "phase0", util.ForkAtEpoch(forks, 9).Name)
+	require.Equal(t, "altair", util.ForkAtEpoch(forks, 10).Name)
+	require.Equal(t, "altair", util.ForkAtEpoch(forks, 1000).Name)
+	require.Nil(t, util.ForkAtEpoch(nil, 0))
+	require.Equal(t, phase0.Epoch(10), util.ForkEpoch(forks, "altair"))
+	require.Equal(t, phase0.Epoch(0xffffffffffffffff), util.ForkEpoch(forks, "bellatrix"))
+}
@@ -16,6 +16,9 @@ package util
 import (
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
@@ -62,3 +65,171 @@ func stringToLevel(input string) zerolog.Level {
 		return zerologger.Logger.GetLevel()
 	}
 }
+
+// serviceLevels are the dynamic log levels of services, keyed by service name.
+var (
+	serviceLevels   = make(map[string]*serviceLevel)
+	serviceLevelsMu sync.Mutex
+)
+
+// serviceLevel is a zerolog sampler that passes events at or above a level that can be changed at runtime.
+type serviceLevel struct {
+	level atomic.Int32
+}
+
+// Sample implements zerolog.Sampler.
+func (s *serviceLevel) Sample(lvl zerolog.Level) bool {
+	return lvl >= zerolog.Level(s.level.Load())
+}
+
+// ServiceLogger returns a logger for the given service and implementation.  The level of the
+// logger can be changed while running with SetServiceLogLevel.
+func ServiceLogger(service string, impl string, level zerolog.Level) zerolog.Logger {
+	serviceLevelsMu.Lock()
+	sl, exists := serviceLevels[service]
+	if !exists {
+		sl = &serviceLevel{}
+		serviceLevels[service] = sl
+	}
+	serviceLevelsMu.Unlock()
+	sl.level.Store(int32(level))
+
+	// The logger itself passes all levels, with the sampler filtering them.  This allows
+	// the level to change without the logger being recreated.
+	return zerologger.With().Str("service", service).Str("impl", impl).Logger().Level(zerolog.TraceLevel).Sample(sl)
+}
+
+// ServiceLogLevels returns the current log level of each service.
+func ServiceLogLevels() map[string]string {
+	serviceLevelsMu.Lock()
+	defer serviceLevelsMu.Unlock()
+
+	levels := make(map[string]string, len(serviceLevels))
+	for service, sl := range serviceLevels {
+		levels[service] = levelToString(zerolog.Level(sl.level.Load()))
+	}
+
+	return levels
+}
+
+// SetServiceLogLevel sets the log level of a running service.  If service is empty then the
+// level of all services is set.
+func SetServiceLogLevel(service string, level string) error {
+	lvl, err := ParseLogLevel(level)
+	if err != nil {
+		return err
+	}
+
+	serviceLevelsMu.Lock()
+	defer serviceLevelsMu.Unlock()
+
+	if service == "" {
+		for _, sl := range serviceLevels {
+			sl.level.Store(int32(lvl))
+		}
+		return nil
+	}
+
+	sl, exists := serviceLevels[service]
+	if !exists {
+		return fmt.Errorf("unknown service %q", service)
+	}
+	sl.level.Store(int32(lvl))
+
+	return nil
+}
+
+// ParseLogLevel parses a log level.
+func ParseLogLevel(input string) (zerolog.Level, error) {
+	switch strings.ToLower(input) {
+	case "none", "trace", "debug", "warn", "warning", "info", "information", "err", "error", "fatal":
+		return stringToLevel(input), nil
+	default:
+		return zerolog.Disabled, fmt.Errorf("unknown log level %q", input)
+	}
+}
+
+// levelToString converts a log level to the string used to configure it.
+func levelToString(level zerolog.Level) string {
+	if level == zerolog.Disabled {
+		return "none"
+	}
+
+	return level.String()
+}
+
+// repeatSampler is a zerolog hook that limits the number of identical messages logged in a period.
+type repeatSampler struct {
+	burst    uint32
+	period   time.Duration
+	minLevel zerolog.Level
+	mu       sync.Mutex
+	entries  map[string]*repeatEntry
+}
+
+type repeatEntry struct {
+	start      time.Time
+	count      uint32
+	suppressed uint32
+}
+
+// maxRepeatEntries is the number of distinct messages tracked before expired entries are removed.
+const maxRepeatEntries = 1024
+
+// NewRepeatSampler returns a zerolog hook that logs at most burst messages with the same level and text
+// in each period, for messages at or above minLevel.  The first message logged after messages have
+// been suppressed carries the number of messages suppressed.
+func NewRepeatSampler(burst uint32, period time.Duration, minLevel zerolog.Level) zerolog.Hook {
+	return &repeatSampler{
+		burst:    burst,
+		period:   period,
+		minLevel: minLevel,
+		entries:  make(map[string]*repeatEntry),
+	}
+}
+
+// Run implements zerolog.Hook.
+func (r *repeatSampler) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	if level < r.minLevel || level == zerolog.NoLevel {
+		return
+	}
+
+	now := time.Now()
+	key := fmt.Sprintf("%d:%s", level, msg)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.entries[key]
+	if !exists {
+		if len(r.entries) >= maxRepeatEntries {
+			r.prune(now)
+		}
+		entry = &repeatEntry{start: now}
+		r.entries[key] = entry
+	}
+	if now.Sub(entry.start) >= r.period {
+		entry.start = now
+		entry.count = 0
+	}
+
+	entry.count++
+	if entry.count > r.burst {
+		entry.suppressed++
+		e.Discard()
+		return
+	}
+	if entry.suppressed > 0 {
+		e.Uint32("suppressed", entry.suppressed)
+		entry.suppressed = 0
+	}
+}
+
+// prune removes entries whose period has expired and that have no suppressed messages outstanding.
+func (r *repeatSampler) prune(now time.Time) {
+	for key, entry := range r.entries {
+		if now.Sub(entry.start) >= r.period && entry.suppressed == 0 {
+			delete(r.entries, key)
+		}
+	}
+}
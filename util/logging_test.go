@@ -0,0 +1,67 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestServiceLogLevel(t *testing.T) {
+	log := util.ServiceLogger("testservice", "test", zerolog.InfoLevel)
+	buf := &bytes.Buffer{}
+	log = log.Output(buf)
+
+	log.Debug().Msg("hidden")
+	require.Empty(t, buf.String())
+
+	require.NoError(t, util.SetServiceLogLevel("testservice", "debug"))
+	log.Debug().Msg("shown")
+	require.Contains(t, buf.String(), "shown")
+	require.Equal(t, "debug", util.ServiceLogLevels()["testservice"])
+
+	buf.Reset()
+	require.NoError(t, util.SetServiceLogLevel("", "none"))
+	log.Error().Msg("hidden")
+	require.Empty(t, buf.String())
+	require.Equal(t, "none", util.ServiceLogLevels()["testservice"])
+
+	require.EqualError(t, util.SetServiceLogLevel("unknown", "debug"), `unknown service "unknown"`)
+	require.EqualError(t, util.SetServiceLogLevel("testservice", "verbose"), `unknown log level "verbose"`)
+}
+
+func TestRepeatSampler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := zerolog.New(buf).Hook(util.NewRepeatSampler(2, 50*time.Millisecond, zerolog.WarnLevel))
+
+	for i := 0; i < 5; i++ {
+		log.Warn().Msg("repeated")
+		log.Info().Msg("unsampled")
+	}
+	log.Warn().Msg("other")
+	require.Equal(t, 2, strings.Count(buf.String(), `"repeated"`))
+	require.Equal(t, 5, strings.Count(buf.String(), `"unsampled"`))
+	require.Equal(t, 1, strings.Count(buf.String(), `"other"`))
+
+	buf.Reset()
+	time.Sleep(60 * time.Millisecond)
+	log.Warn().Msg("repeated")
+	require.Contains(t, buf.String(), `"suppressed":3`)
+}
@@ -15,10 +15,13 @@ package util
 
 import (
 	"context"
+	"os"
 
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
+	envconfidant "github.com/wealdtech/chaind/util/confidants/env"
+	vaultconfidant "github.com/wealdtech/chaind/util/confidants/vault"
 	majordomo "github.com/wealdtech/go-majordomo"
 	asmconfidant "github.com/wealdtech/go-majordomo/confidants/asm"
 	directconfidant "github.com/wealdtech/go-majordomo/confidants/direct"
@@ -56,10 +59,20 @@ func InitMajordomo(ctx context.Context) (majordomo.Service, error) {
 		return nil, errors.Wrap(err, "failed to register file confidant")
 	}
 
+	envConfidant, err := envconfidant.New(ctx,
+		envconfidant.WithLogLevel(LogLevel("majordomo.confidants.env")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create environment confidant")
+	}
+	if err := majordomo.RegisterConfidant(ctx, envConfidant); err != nil {
+		return nil, errors.Wrap(err, "failed to register environment confidant")
+	}
+
 	if viper.GetString("majordomo.asm.region") != "" {
 		var asmCredentials *credentials.Credentials
 		if viper.GetString("majordomo.asm.id") != "" {
-			asmCredentials = credentials.NewStaticCredentials(viper.GetString("majordomo.asm.id"), viper.GetString("ma  jordomo.asm.secret"), "")
+			asmCredentials = credentials.NewStaticCredentials(viper.GetString("majordomo.asm.id"), viper.GetString("majordomo.asm.secret"), "")
 		}
 		asmConfidant, err := asmconfidant.New(ctx,
 			asmconfidant.WithLogLevel(LogLevel("majordomo.confidants.asm")),
@@ -92,5 +105,52 @@ func InitMajordomo(ctx context.Context) (majordomo.Service, error) {
 		}
 	}
 
+	if err := registerVaultConfidant(ctx, majordomo); err != nil {
+		return nil, err
+	}
+
 	return majordomo, nil
 }
+
+// registerVaultConfidant registers the HashiCorp Vault confidant, if configured.
+// The address and token fall back to the standard Vault environment variables.
+func registerVaultConfidant(ctx context.Context, majordomo *standardmajordomo.Service) error {
+	address := viper.GetString("majordomo.vault.address")
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return nil
+	}
+
+	token := viper.GetString("majordomo.vault.token")
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	var tokenFile string
+	if viper.GetString("majordomo.vault.token-file") != "" {
+		tokenFile = ResolvePath(viper.GetString("majordomo.vault.token-file"))
+	}
+	params := []vaultconfidant.Parameter{
+		vaultconfidant.WithLogLevel(LogLevel("majordomo.confidants.vault")),
+		vaultconfidant.WithAddress(address),
+		vaultconfidant.WithToken(token),
+		vaultconfidant.WithTokenFile(tokenFile),
+		vaultconfidant.WithNamespace(viper.GetString("majordomo.vault.namespace")),
+	}
+	if viper.IsSet("majordomo.vault.kv-version") {
+		params = append(params, vaultconfidant.WithKVVersion(viper.GetInt("majordomo.vault.kv-version")))
+	}
+	if viper.IsSet("majordomo.vault.timeout") {
+		params = append(params, vaultconfidant.WithTimeout(viper.GetDuration("majordomo.vault.timeout")))
+	}
+	vaultConfidant, err := vaultconfidant.New(ctx, params...)
+	if err != nil {
+		return errors.Wrap(err, "failed to create Vault confidant")
+	}
+	if err := majordomo.RegisterConfidant(ctx, vaultConfidant); err != nil {
+		return errors.Wrap(err, "failed to register Vault confidant")
+	}
+
+	return nil
+}
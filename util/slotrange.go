@@ -0,0 +1,50 @@
+// Copyright © 2024 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// ParseSlotRange parses a range of slots of the form "A-B", inclusive.
+// A single slot "A" is treated as the range "A-A".
+func ParseSlotRange(input string) (phase0.Slot, phase0.Slot, error) {
+	if input == "" {
+		return 0, 0, errors.New("no slot range supplied")
+	}
+
+	startStr, endStr, isRange := strings.Cut(input, "-")
+	if !isRange {
+		endStr = startStr
+	}
+
+	start, err := strconv.ParseUint(strings.TrimSpace(startStr), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid start slot in %q", input)
+	}
+	end, err := strconv.ParseUint(strings.TrimSpace(endStr), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid end slot in %q", input)
+	}
+	if end < start {
+		return 0, 0, fmt.Errorf("end slot before start slot in %q", input)
+	}
+
+	return phase0.Slot(start), phase0.Slot(end), nil
+}
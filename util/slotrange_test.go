@@ -0,0 +1,83 @@
+// Copyright © 2024 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestParseSlotRange(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		start phase0.Slot
+		end   phase0.Slot
+		err   string
+	}{
+		{
+			name: "Empty",
+			err:  "no slot range supplied",
+		},
+		{
+			name:  "Single",
+			input: "100",
+			start: 100,
+			end:   100,
+		},
+		{
+			name:  "Range",
+			input: "100-200",
+			start: 100,
+			end:   200,
+		},
+		{
+			name:  "Spaces",
+			input: "100 - 200",
+			start: 100,
+			end:   200,
+		},
+		{
+			name:  "StartMissing",
+			input: "-200",
+			err:   "invalid start slot in \"-200\"",
+		},
+		{
+			name:  "EndMissing",
+			input: "100-",
+			err:   "invalid end slot in \"100-\"",
+		},
+		{
+			name:  "Reversed",
+			input: "200-100",
+			err:   "end slot before start slot in \"200-100\"",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, end, err := util.ParseSlotRange(test.input)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.start, start)
+				require.Equal(t, test.end, end)
+			}
+		})
+	}
+}
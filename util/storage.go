@@ -0,0 +1,114 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StorageMode defines how long data in a table is kept.
+type StorageMode string
+
+const (
+	// StorageModeFull keeps data indefinitely.
+	StorageModeFull StorageMode = "full"
+	// StorageModeTransient keeps data only until it has been summarized.
+	StorageModeTransient StorageMode = "transient"
+	// StorageModeNone does not store data at all.
+	StorageModeNone StorageMode = "none"
+)
+
+// Tables whose storage can be configured.
+const (
+	StorageTableAttestations     = "attestations"
+	StorageTableBeaconCommittees = "beacon_committees"
+	StorageTableSyncAggregates   = "sync_aggregates"
+	StorageTableBlobSidecars     = "blob_sidecars"
+)
+
+// storageTableModes are the storage modes supported by each configurable table.
+// Tables that feed summaries can be transient; tables that do not can be dropped entirely.
+var storageTableModes = map[string][]StorageMode{
+	StorageTableAttestations:     {StorageModeFull, StorageModeTransient},
+	StorageTableBeaconCommittees: {StorageModeFull, StorageModeTransient},
+	StorageTableSyncAggregates:   {StorageModeFull, StorageModeTransient},
+	StorageTableBlobSidecars:     {StorageModeFull, StorageModeNone},
+}
+
+// storageProfiles are the preset storage modes for each profile.
+var storageProfiles = map[string]map[string]StorageMode{
+	"full": {
+		StorageTableAttestations:     StorageModeFull,
+		StorageTableBeaconCommittees: StorageModeFull,
+		StorageTableSyncAggregates:   StorageModeFull,
+		StorageTableBlobSidecars:     StorageModeFull,
+	},
+	"light": {
+		StorageTableAttestations:     StorageModeTransient,
+		StorageTableBeaconCommittees: StorageModeTransient,
+		StorageTableSyncAggregates:   StorageModeTransient,
+		StorageTableBlobSidecars:     StorageModeNone,
+	},
+}
+
+// StorageModes provides the storage mode for each configurable table, starting
+// from the named profile and applying any per-table overrides.
+func StorageModes(profile string, overrides map[string]string) (map[string]StorageMode, error) {
+	if profile == "" {
+		profile = "full"
+	}
+	preset, exists := storageProfiles[profile]
+	if !exists {
+		return nil, fmt.Errorf("unknown storage profile %q; supported profiles are %s", profile, strings.Join(StorageProfiles(), ", "))
+	}
+
+	modes := make(map[string]StorageMode, len(preset))
+	for table, mode := range preset {
+		modes[table] = mode
+	}
+
+	for table, override := range overrides {
+		supported, exists := storageTableModes[table]
+		if !exists {
+			return nil, fmt.Errorf("storage of table %q is not configurable", table)
+		}
+		mode := StorageMode(strings.ToLower(override))
+		valid := false
+		for _, supportedMode := range supported {
+			if mode == supportedMode {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("storage mode %q not supported for table %q", override, table)
+		}
+		modes[table] = mode
+	}
+
+	return modes, nil
+}
+
+// StorageProfiles provides the names of the available storage profiles.
+func StorageProfiles() []string {
+	profiles := make([]string, 0, len(storageProfiles))
+	for profile := range storageProfiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+
+	return profiles
+}
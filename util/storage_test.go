@@ -0,0 +1,96 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestStorageModes(t *testing.T) {
+	tests := []struct {
+		name      string
+		profile   string
+		overrides map[string]string
+		expected  map[string]util.StorageMode
+		err       string
+	}{
+		{
+			name: "Default",
+			expected: map[string]util.StorageMode{
+				util.StorageTableAttestations:     util.StorageModeFull,
+				util.StorageTableBeaconCommittees: util.StorageModeFull,
+				util.StorageTableSyncAggregates:   util.StorageModeFull,
+				util.StorageTableBlobSidecars:     util.StorageModeFull,
+			},
+		},
+		{
+			name:    "Light",
+			profile: "light",
+			expected: map[string]util.StorageMode{
+				util.StorageTableAttestations:     util.StorageModeTransient,
+				util.StorageTableBeaconCommittees: util.StorageModeTransient,
+				util.StorageTableSyncAggregates:   util.StorageModeTransient,
+				util.StorageTableBlobSidecars:     util.StorageModeNone,
+			},
+		},
+		{
+			name:    "LightOverride",
+			profile: "light",
+			overrides: map[string]string{
+				"attestations":  "Full",
+				"blob_sidecars": "full",
+			},
+			expected: map[string]util.StorageMode{
+				util.StorageTableAttestations:     util.StorageModeFull,
+				util.StorageTableBeaconCommittees: util.StorageModeTransient,
+				util.StorageTableSyncAggregates:   util.StorageModeTransient,
+				util.StorageTableBlobSidecars:     util.StorageModeFull,
+			},
+		},
+		{
+			name:    "UnknownProfile",
+			profile: "tiny",
+			err:     "unknown storage profile \"tiny\"; supported profiles are full, light",
+		},
+		{
+			name: "UnknownTable",
+			overrides: map[string]string{
+				"blocks": "none",
+			},
+			err: "storage of table \"blocks\" is not configurable",
+		},
+		{
+			name: "UnsupportedMode",
+			overrides: map[string]string{
+				"attestations": "none",
+			},
+			err: "storage mode \"none\" not supported for table \"attestations\"",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			modes, err := util.StorageModes(test.profile, test.overrides)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expected, modes)
+			}
+		})
+	}
+}
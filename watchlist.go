@@ -0,0 +1,370 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/chaindb"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	"github.com/wealdtech/chaind/services/watchlist"
+	"github.com/wealdtech/chaind/util"
+)
+
+// runWatchlist runs a watchlist command.
+func runWatchlist(ctx context.Context, command string) error {
+	chainDB, err := startDatabase(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if db, isPostgreSQL := chainDB.(*postgresqlchaindb.Service); isPostgreSQL {
+		if err := checkSchemaVersion(ctx, db); err != nil {
+			return err
+		}
+	}
+
+	switch command {
+	case "", "list":
+		return listWatchlist(ctx, chainDB)
+	case "add":
+		return addToWatchlist(ctx, chainDB)
+	case "remove":
+		return removeFromWatchlist(ctx, chainDB)
+	case "events":
+		return listWatchlistEvents(ctx, chainDB)
+	case "alerts":
+		return listWatchlistAlerts(ctx, chainDB)
+	case "signing-root":
+		return printWatchlistSigningRoot(ctx, chainDB)
+	default:
+		return fmt.Errorf("unknown watchlist command %q; supported commands are list, add, remove, events, alerts and signing-root", command)
+	}
+}
+
+// listWatchlist prints the validators on the watchlist.
+func listWatchlist(ctx context.Context, chainDB chaindb.Service) error {
+	validators, err := chainDB.(chaindb.WatchlistProvider).WatchedValidators(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain watched validators")
+	}
+	if len(validators) == 0 {
+		fmt.Println("No validators on the watchlist")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "INDEX\tLABEL\tADDED\tPROVEN")
+	for _, validator := range validators {
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%t\n", validator.Index, validator.Label, validator.Added.Format(time.RFC3339), validator.Proof != nil)
+	}
+	writer.Flush()
+
+	return nil
+}
+
+// addToWatchlist adds the configured validators to the watchlist.
+func addToWatchlist(ctx context.Context, chainDB chaindb.Service) error {
+	indices, err := watchlistValidatorIndices(ctx, chainDB)
+	if err != nil {
+		return err
+	}
+
+	label := viper.GetString("watchlist.label")
+	proofs, err := watchlistProofs(ctx, chainDB, indices, label)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel, err := chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	added := time.Now()
+	for i, index := range indices {
+		if err := chainDB.(chaindb.WatchlistSetter).SetWatchedValidator(ctx, &chaindb.WatchedValidator{
+			Index: index,
+			Label: label,
+			Added: added,
+			Proof: proofs[i],
+		}); err != nil {
+			cancel()
+			return errors.Wrapf(err, "failed to add validator %d to the watchlist", index)
+		}
+	}
+	if err := chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	fmt.Printf("Added %d validator(s) to the watchlist\n", len(indices))
+
+	return nil
+}
+
+// removeFromWatchlist removes the configured validators from the watchlist.
+func removeFromWatchlist(ctx context.Context, chainDB chaindb.Service) error {
+	indices, err := watchlistValidatorIndices(ctx, chainDB)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel, err := chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	for _, index := range indices {
+		if err := chainDB.(chaindb.WatchlistSetter).RemoveWatchedValidator(ctx, index); err != nil {
+			cancel()
+			return errors.Wrapf(err, "failed to remove validator %d from the watchlist", index)
+		}
+	}
+	if err := chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	fmt.Printf("Removed %d validator(s) from the watchlist\n", len(indices))
+
+	return nil
+}
+
+// listWatchlistEvents prints the latest events for watched validators.
+func listWatchlistEvents(ctx context.Context, chainDB chaindb.Service) error {
+	filter := &chaindb.WatchlistEventFilter{
+		Order: chaindb.OrderLatest,
+		Limit: viper.GetUint32("watchlist.limit"),
+	}
+	if len(viper.GetStringSlice("watchlist.validators")) > 0 {
+		indices, err := watchlistValidatorIndices(ctx, chainDB)
+		if err != nil {
+			return err
+		}
+		filter.ValidatorIndices = indices
+	}
+
+	events, err := chainDB.(chaindb.WatchlistProvider).WatchlistEvents(ctx, filter)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain watchlist events")
+	}
+	if len(events) == 0 {
+		fmt.Println("No watchlist events")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "SLOT\tEPOCH\tINDEX\tEVENT\tAMOUNT")
+	for _, event := range events {
+		amount := ""
+		if event.Amount != nil {
+			amount = strconv.FormatInt(*event.Amount, 10)
+		}
+		fmt.Fprintf(writer, "%d\t%d\t%d\t%s\t%s\n", event.Slot, event.Epoch, event.ValidatorIndex, event.Type, amount)
+	}
+	writer.Flush()
+
+	return nil
+}
+
+// listWatchlistAlerts prints the latest alerts for watched validators.
+func listWatchlistAlerts(ctx context.Context, chainDB chaindb.Service) error {
+	filter := &chaindb.WatchlistAlertFilter{
+		Order: chaindb.OrderLatest,
+		Limit: viper.GetUint32("watchlist.limit"),
+	}
+	if len(viper.GetStringSlice("watchlist.validators")) > 0 {
+		indices, err := watchlistValidatorIndices(ctx, chainDB)
+		if err != nil {
+			return err
+		}
+		filter.ValidatorIndices = indices
+	}
+
+	alerts, err := chainDB.(chaindb.WatchlistProvider).WatchlistAlerts(ctx, filter)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain watchlist alerts")
+	}
+	if len(alerts) == 0 {
+		fmt.Println("No watchlist alerts")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "EPOCH\tINDEX\tRULE\tDESCRIPTION")
+	for _, alert := range alerts {
+		fmt.Fprintf(writer, "%d\t%d\t%s\t%s\n", alert.Epoch, alert.ValidatorIndex, alert.Rule, alert.Description)
+	}
+	writer.Flush()
+
+	return nil
+}
+
+// printWatchlistSigningRoot prints the data to be signed to prove ownership of validators
+// added to the watchlist with the configured label.
+func printWatchlistSigningRoot(ctx context.Context, chainDB chaindb.Service) error {
+	genesisForkVersion, err := genesisForkVersion(ctx, chainDB)
+	if err != nil {
+		return err
+	}
+
+	label := viper.GetString("watchlist.label")
+	domain, err := watchlist.OwnershipDomain(genesisForkVersion)
+	if err != nil {
+		return err
+	}
+	root, err := watchlist.OwnershipSigningRoot(label, genesisForkVersion)
+	if err != nil {
+		return err
+	}
+	objectRoot := sha256.Sum256([]byte(label))
+
+	fmt.Printf("Object root: %#x\n", objectRoot)
+	fmt.Printf("Domain: %#x\n", domain)
+	fmt.Printf("Signing root: %#x\n", root)
+
+	return nil
+}
+
+// watchlistValidatorIndices resolves the configured validators to indices.
+func watchlistValidatorIndices(ctx context.Context, chainDB chaindb.Service) ([]phase0.ValidatorIndex, error) {
+	return resolveValidatorIndices(ctx, chainDB, viper.GetStringSlice("watchlist.validators"))
+}
+
+// resolveValidatorIndices resolves validators supplied as indices or public keys to indices,
+// in the order given.
+func resolveValidatorIndices(ctx context.Context, chainDB chaindb.Service, validators []string) ([]phase0.ValidatorIndex, error) {
+	if len(validators) == 0 {
+		return nil, errors.New("no validators specified")
+	}
+
+	// Resolve public keys in bulk, then assemble the indices in the order given.
+	pubKeys := make(map[string]phase0.BLSPubKey)
+	for _, validator := range validators {
+		if !strings.HasPrefix(validator, "0x") {
+			continue
+		}
+		data, err := hex.DecodeString(strings.TrimPrefix(validator, "0x"))
+		if err != nil || len(data) != phase0.PublicKeyLength {
+			return nil, fmt.Errorf("invalid validator public key %q", validator)
+		}
+		var pubKey phase0.BLSPubKey
+		copy(pubKey[:], data)
+		pubKeys[validator] = pubKey
+	}
+	var resolved map[phase0.BLSPubKey]phase0.ValidatorIndex
+	if len(pubKeys) > 0 {
+		lookup := make([]phase0.BLSPubKey, 0, len(pubKeys))
+		for _, pubKey := range pubKeys {
+			lookup = append(lookup, pubKey)
+		}
+		var err error
+		resolved, err = chainDB.(chaindb.ValidatorIndicesProvider).ValidatorIndices(ctx, lookup)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validator indices")
+		}
+	}
+
+	indices := make([]phase0.ValidatorIndex, 0, len(validators))
+	for _, validator := range validators {
+		pubKey, isPubKey := pubKeys[validator]
+		if !isPubKey {
+			index, err := strconv.ParseUint(validator, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid validator %q", validator)
+			}
+			indices = append(indices, phase0.ValidatorIndex(index))
+			continue
+		}
+
+		index, exists := resolved[pubKey]
+		if !exists {
+			return nil, fmt.Errorf("unknown validator %q", validator)
+		}
+		indices = append(indices, index)
+	}
+
+	return indices, nil
+}
+
+// watchlistProofs parses and verifies the configured proofs of ownership for the validators.
+// If no proofs are configured then all returned proofs are nil.
+func watchlistProofs(ctx context.Context,
+	chainDB chaindb.Service,
+	indices []phase0.ValidatorIndex,
+	label string,
+) (
+	[]*phase0.BLSSignature,
+	error,
+) {
+	proofs := make([]*phase0.BLSSignature, len(indices))
+	configured := viper.GetStringSlice("watchlist.proofs")
+	if len(configured) == 0 {
+		return proofs, nil
+	}
+	if len(configured) != len(indices) {
+		return nil, fmt.Errorf("%d proofs supplied for %d validators", len(configured), len(indices))
+	}
+
+	genesisForkVersion, err := genesisForkVersion(ctx, chainDB)
+	if err != nil {
+		return nil, err
+	}
+	validators, err := chainDB.(chaindb.ValidatorsProvider).ValidatorsByIndex(ctx, indices)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validators")
+	}
+
+	for i, index := range indices {
+		validator, exists := validators[index]
+		if !exists {
+			return nil, fmt.Errorf("unknown validator %d", index)
+		}
+		data, err := hex.DecodeString(strings.TrimPrefix(configured[i], "0x"))
+		if err != nil || len(data) != phase0.SignatureLength {
+			return nil, fmt.Errorf("invalid proof for validator %d", index)
+		}
+		proof := phase0.BLSSignature{}
+		copy(proof[:], data)
+		if err := watchlist.VerifyOwnershipProof(validator.PublicKey, label, genesisForkVersion, proof); err != nil {
+			return nil, errors.Wrapf(err, "invalid proof for validator %d", index)
+		}
+		proofs[i] = &proof
+	}
+
+	return proofs, nil
+}
+
+// genesisForkVersion obtains the genesis fork version of the chain.
+func genesisForkVersion(ctx context.Context, chainDB chaindb.Service) (phase0.Version, error) {
+	forks, err := chainDB.(chaindb.ForkScheduleProvider).Forks(ctx)
+	if err != nil {
+		return phase0.Version{}, errors.Wrap(err, "failed to obtain forks")
+	}
+	fork := util.ForkByName(forks, "phase0")
+	if fork == nil {
+		return phase0.Version{}, errors.New("genesis fork not known; chaind must run to record the forks of the chain")
+	}
+
+	return fork.Version, nil
+}